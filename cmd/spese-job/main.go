@@ -0,0 +1,241 @@
+// Command spese-job lists and runs the background jobs of a running spese
+// server through /api/v1/jobs:
+//
+//	spese-job list
+//	spese-job run <name>
+//	spese-job runs <name>
+//
+// run triggers the job and follows its state until the run ends, exiting
+// with status 1 when it fails. The server is SPESE_URL (or -url); with
+// login enabled, AUTH_PASSWORD is sent as HTTP Basic auth.
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// job and run mirror the JSON of /api/v1/jobs
+type job struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Every       string     `json:"every"`
+	Queued      bool       `json:"queued"`
+	Running     bool       `json:"running"`
+	NextRun     *time.Time `json:"next_run"`
+	LastRun     *run       `json:"last_run"`
+}
+
+type run struct {
+	ID         int64     `json:"id"`
+	Source     string    `json:"source"`
+	Attempt    int       `json:"attempt"`
+	Status     string    `json:"status"`
+	StartedAt  time.Time `json:"started_at"`
+	DurationMs int64     `json:"duration_ms"`
+	Result     string    `json:"result"`
+	Error      string    `json:"error"`
+}
+
+// client calls the jobs API of a server
+type client struct {
+	base     string
+	password string
+	http     *http.Client
+}
+
+func main() {
+	base := flag.String("url", envOr("SPESE_URL", "http://localhost:8081"), "base URL of the spese server")
+	timeout := flag.Duration("timeout", 30*time.Minute, "how long run waits for the job to finish")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: spese-job [flags] list | run <name> | runs <name>")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	c := &client{
+		base:     strings.TrimRight(*base, "/"),
+		password: os.Getenv("AUTH_PASSWORD"),
+		http:     &http.Client{},
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var err error
+	switch args := flag.Args(); {
+	case len(args) == 1 && args[0] == "list":
+		err = c.list(ctx)
+	case len(args) == 2 && args[0] == "run":
+		ctx, cancel := context.WithTimeout(ctx, *timeout)
+		defer cancel()
+		err = c.run(ctx, args[1])
+	case len(args) == 2 && args[0] == "runs":
+		err = c.runs(ctx, args[1])
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "spese-job:", err)
+		os.Exit(1)
+	}
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// do sends a request to the jobs API, turning error statuses into errors
+func (c *client) do(ctx context.Context, method, path string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.base+"/api/v1/jobs"+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.password != "" {
+		req.SetBasicAuth("spese", c.password)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if json.Unmarshal(body, &apiErr) != nil || apiErr.Error == "" {
+			apiErr.Error = strings.TrimSpace(string(body))
+		}
+		return nil, fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, apiErr.Error)
+	}
+	return resp, nil
+}
+
+// get decodes the JSON of a GET request into v
+func (c *client) get(ctx context.Context, path string, v any) error {
+	resp, err := c.do(ctx, http.MethodGet, path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func (c *client) list(ctx context.Context) error {
+	var jobs []job
+	if err := c.get(ctx, "", &jobs); err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSTATE\tNEXT RUN\tLAST RUN\tDESCRIPTION")
+	for _, j := range jobs {
+		next := "on demand"
+		if j.NextRun != nil {
+			next = j.NextRun.Local().Format("2006-01-02 15:04")
+		}
+		last := "-"
+		if j.LastRun != nil {
+			last = j.LastRun.Status + " " + j.LastRun.StartedAt.Local().Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", j.Name, state(j), next, last, j.Description)
+	}
+	return w.Flush()
+}
+
+func (c *client) runs(ctx context.Context, name string) error {
+	var runs []run
+	if err := c.get(ctx, "/"+url.PathEscape(name)+"/runs", &runs); err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "STARTED\tSOURCE\tATTEMPT\tSTATUS\tDURATION\tRESULT")
+	for _, r := range runs {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\n", r.StartedAt.Local().Format("2006-01-02 15:04:05"),
+			r.Source, r.Attempt, r.Status, time.Duration(r.DurationMs)*time.Millisecond, outcome(r))
+	}
+	return w.Flush()
+}
+
+// run triggers a job and prints its state until the run ends
+func (c *client) run(ctx context.Context, name string) error {
+	path := "/" + url.PathEscape(name)
+	resp, err := c.do(ctx, http.MethodPost, path+"/run")
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	fmt.Printf("%s: queued\n", name)
+
+	resp, err = c.do(ctx, http.MethodGet, path+"/events")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var last job
+	shown := "queued"
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		if err := json.Unmarshal([]byte(data), &last); err != nil {
+			return fmt.Errorf("decode event: %w", err)
+		}
+		if st := state(last); st != shown && st != "idle" {
+			fmt.Printf("%s: %s\n", name, st)
+			shown = st
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if last.Queued || last.Running || last.LastRun == nil {
+		return errors.New("event stream ended before the run finished")
+	}
+
+	r := last.LastRun
+	fmt.Printf("%s: %s in %s", name, r.Status, time.Duration(r.DurationMs)*time.Millisecond)
+	if out := outcome(*r); out != "" {
+		fmt.Printf(": %s", out)
+	}
+	fmt.Println()
+	if r.Status != "succeeded" {
+		return fmt.Errorf("job %s %s", name, r.Status)
+	}
+	return nil
+}
+
+func state(j job) string {
+	switch {
+	case j.Running:
+		return "running"
+	case j.Queued:
+		return "queued"
+	}
+	return "idle"
+}
+
+func outcome(r run) string {
+	if r.Error != "" {
+		return r.Error
+	}
+	return r.Result
+}
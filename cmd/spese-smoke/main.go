@@ -0,0 +1,299 @@
+// Command spese-smoke checks a freshly deployed spese server end to end:
+//
+//	spese-smoke [-sheets] [-timeout 2m]
+//
+// It checks /healthz and /readyz, creates a test expense of €0.01 tagged
+// "smoke" through /api/v1/expenses, waits for it to be listed and, with
+// -sheets, for it to be written to Google Sheets, then deletes it for good.
+// Each step is printed; the exit status is 1 when one fails, so a release
+// pipeline can stop there. The server is SPESE_URL (or -url); with login
+// enabled, AUTH_PASSWORD is sent as HTTP Basic auth.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+)
+
+// smokeTag marks the expenses created by the smoke test
+const smokeTag = "smoke"
+
+// expense mirrors the JSON of /api/v1/expenses
+type expense struct {
+	ID          string   `json:"id"`
+	Date        string   `json:"date"`
+	Description string   `json:"description"`
+	AmountCents int64    `json:"amount_cents"`
+	Tags        []string `json:"tags"`
+	SyncStatus  string   `json:"sync_status"`
+}
+
+// client calls the API of a server
+type client struct {
+	base     string
+	password string
+	http     *http.Client
+}
+
+func main() {
+	base := flag.String("url", envOr("SPESE_URL", "http://localhost:8081"), "base URL of the spese server")
+	timeout := flag.Duration("timeout", 2*time.Minute, "how long the whole check may take")
+	poll := flag.Duration("poll", 2*time.Second, "interval between checks while waiting")
+	sheets := flag.Bool("sheets", false, "wait for the expense to be written to Google Sheets (SQLite backend with sync)")
+	primary := flag.String("primary", "Altre spese", "primary category of the test expense")
+	secondary := flag.String("secondary", "Unknown", "secondary category of the test expense")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: spese-smoke [flags]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() > 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	c := &client{
+		base:     strings.TrimRight(*base, "/"),
+		password: os.Getenv("AUTH_PASSWORD"),
+		http:     &http.Client{Timeout: 30 * time.Second},
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+
+	s := &smoke{client: c, poll: *poll, sheets: *sheets, primary: *primary, secondary: *secondary}
+	if err := s.run(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, "spese-smoke:", err)
+		os.Exit(1)
+	}
+	fmt.Println("ok")
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// smoke is one run of the check
+type smoke struct {
+	*client
+	poll               time.Duration
+	sheets             bool
+	primary, secondary string
+}
+
+// run performs the steps in order. Once the expense exists it is deleted
+// even when a later step fails, so failed runs leave nothing behind.
+func (s *smoke) run(ctx context.Context) (err error) {
+	for _, path := range []string{"/healthz", "/readyz"} {
+		if err := s.check(ctx, path); err != nil {
+			return err
+		}
+		fmt.Printf("%s: ok\n", path)
+	}
+
+	created, err := s.create(ctx)
+	if err != nil {
+		return fmt.Errorf("create expense: %w", err)
+	}
+	fmt.Printf("created expense %s %q\n", created.ID, created.Description)
+	defer func() {
+		// The run's context may be the one that expired
+		cleanup, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if delErr := s.delete(cleanup, created.ID); delErr != nil {
+			err = errors.Join(err, fmt.Errorf("delete expense %s: %w", created.ID, delErr))
+			return
+		}
+		fmt.Printf("deleted expense %s\n", created.ID)
+	}()
+
+	start := time.Now()
+	if err := s.waitListed(ctx, created); err != nil {
+		return err
+	}
+	fmt.Printf("expense listed after %s\n", time.Since(start).Round(time.Millisecond))
+
+	if s.sheets {
+		start = time.Now()
+		if err := s.waitSynced(ctx, created.ID); err != nil {
+			return err
+		}
+		fmt.Printf("expense in Google Sheets after %s\n", time.Since(start).Round(time.Millisecond))
+	}
+	return nil
+}
+
+// check fails unless GET path answers 200
+func (s *smoke) check(ctx context.Context, path string) error {
+	resp, err := s.do(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// create saves the test expense, described with the run time so it can
+// be told apart from the others
+func (s *smoke) create(ctx context.Context) (expense, error) {
+	body, err := json.Marshal(map[string]any{
+		"description": "spese-smoke " + time.Now().UTC().Format(time.RFC3339),
+		"amount":      "0.01",
+		"primary":     s.primary,
+		"secondary":   s.secondary,
+		"tags":        []string{smokeTag},
+	})
+	if err != nil {
+		return expense{}, err
+	}
+	resp, err := s.do(ctx, http.MethodPost, "/api/v1/expenses", body)
+	if err != nil {
+		return expense{}, err
+	}
+	defer resp.Body.Close()
+	var e expense
+	if err := json.NewDecoder(resp.Body).Decode(&e); err != nil {
+		return expense{}, fmt.Errorf("decode expense: %w", err)
+	}
+	if e.ID == "" {
+		return expense{}, errors.New("no id in the response")
+	}
+	return e, nil
+}
+
+// waitListed polls the expenses of the expense's month until it is there
+func (s *smoke) waitListed(ctx context.Context, e expense) error {
+	date, err := time.Parse("2006-01-02", e.Date)
+	if err != nil {
+		return fmt.Errorf("expense date %q: %w", e.Date, err)
+	}
+	q := url.Values{
+		"year":  {fmt.Sprint(date.Year())},
+		"month": {fmt.Sprint(int(date.Month()))},
+		"q":     {e.Description},
+	}
+	return s.waitFor(ctx, "listed", func() (bool, error) {
+		var page struct {
+			Items []expense `json:"items"`
+		}
+		if err := s.get(ctx, "/api/v1/expenses?"+q.Encode(), &page); err != nil {
+			return false, err
+		}
+		for _, item := range page.Items {
+			if item.ID == e.ID {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+}
+
+// waitSynced polls the expense until the sync marks it written to the sheet
+func (s *smoke) waitSynced(ctx context.Context, id string) error {
+	last := ""
+	err := s.waitFor(ctx, "written to Google Sheets", func() (bool, error) {
+		var e expense
+		if err := s.get(ctx, "/api/v1/expenses/"+url.PathEscape(id), &e); err != nil {
+			return false, err
+		}
+		if e.SyncStatus == "" {
+			return false, errors.New("the server reports no sync status (SQLite backend needed)")
+		}
+		last = e.SyncStatus
+		return e.SyncStatus == "synced", nil
+	})
+	if err != nil && last != "" {
+		return fmt.Errorf("%w (sync status %s)", err, last)
+	}
+	return err
+}
+
+// waitFor calls done every poll interval until it reports true, fails or
+// the context expires
+func (s *smoke) waitFor(ctx context.Context, what string, done func() (bool, error)) error {
+	ticker := time.NewTicker(s.poll)
+	defer ticker.Stop()
+	for {
+		ok, err := done()
+		if err != nil {
+			return fmt.Errorf("wait until %s: %w", what, err)
+		}
+		if ok {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("expense not %s: %w", what, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// delete removes the test expense for good, bypassing the trash
+func (s *smoke) delete(ctx context.Context, id string) error {
+	resp, err := s.do(ctx, http.MethodDelete, "/api/v1/expenses/"+url.PathEscape(id)+"?purge=true", nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// do sends a request to the server, turning error statuses into errors
+func (c *client) do(ctx context.Context, method, path string, body []byte) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.base+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.password != "" {
+		req.SetBasicAuth("spese", c.password)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if json.Unmarshal(data, &apiErr) != nil || apiErr.Error == "" {
+			apiErr.Error = strings.TrimSpace(string(data))
+		}
+		return nil, fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, apiErr.Error)
+	}
+	return resp, nil
+}
+
+// get decodes the JSON of a GET request into v
+func (c *client) get(ctx context.Context, path string, v any) error {
+	resp, err := c.do(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -2,23 +2,38 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
+	"golang.org/x/crypto/bcrypt"
 	"golang.org/x/sync/errgroup"
 
 	"github.com/joho/godotenv"
 	"spese/internal/adapters"
+	"spese/internal/classifier"
 	"spese/internal/config"
+	"spese/internal/core"
+	"spese/internal/demo"
+	"spese/internal/grpcserver"
+	"spese/internal/hooks"
 	apphttp "spese/internal/http"
+	"spese/internal/httpclient"
+	"spese/internal/llm"
+	"spese/internal/receipts"
+	"spese/internal/replication"
+	"spese/internal/rules"
 	"spese/internal/services"
 	ports "spese/internal/sheets"
 	gsheet "spese/internal/sheets/google"
 	"spese/internal/storage"
+	"spese/internal/tracing"
 )
 
 func main() {
@@ -40,6 +55,20 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Spans of requests, queries and the sync go to the configured exporter
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.TracesExporter)
+	if err != nil {
+		logger.Error("Failed to set up tracing", "error", err)
+		os.Exit(1)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			logger.Warn("Failed to flush traces", "error", err)
+		}
+	}()
+
 	var (
 		expWriter       ports.ExpenseWriter
 		taxReader       ports.TaxonomyReader
@@ -47,11 +76,34 @@ func main() {
 		expLister       ports.ExpenseLister
 		expDeleter      ports.ExpenseDeleter
 		expListerWithID ports.ExpenseListerWithID
+		batchWriter     ports.ExpenseBatchWriter
 		sqliteRepo      *storage.SQLiteRepository
 		expenseService  *services.ExpenseService
 		sheetsClient    *gsheet.Client
+		syncSheets      *gsheet.Client // Sheet the sync writes to: sheetsClient or its sandbox
+		sandbox         *gsheet.Sandbox
+		incomeStore     grpcserver.IncomeStore
+		demoStore       demo.Store // Where the demo dataset is seeded, nil for sheets
+		categorizer     *rules.Categorizer
 	)
 
+	hookRunner := hooks.NewRunner(cfg.Hooks, cfg.HookTimeout)
+
+	// Outbound integrations share one pooled transport going through the
+	// configured proxy and CA; their requests show up in /metrics
+	outbound, err := httpclient.NewFactory(httpclient.Options{CAFile: cfg.OutboundCAFile})
+	if err != nil {
+		logger.Error("Failed to configure outbound HTTP client", "error", err)
+		os.Exit(1)
+	}
+	// Sheets API requests refused with 429 or 503 are retried before the
+	// sync queue puts the item back
+	sheetsRetry := httpclient.RetryPolicy{
+		MaxRetries: cfg.GoogleRetryMax,
+		BaseDelay:  cfg.GoogleRetryBaseDelay,
+		MaxDelay:   cfg.GoogleRetryMaxDelay,
+	}
+
 	switch cfg.DataBackend {
 	case "sqlite":
 		// Initialize SQLite repository
@@ -64,35 +116,315 @@ func main() {
 
 		// Create expense service (no longer needs AMQP - uses sync queue)
 		expenseService = services.NewExpenseService(sqliteRepo)
+		expenseService.SetHooks(hookRunner)
+		categorizer = rules.NewCategorizer(sqliteRepo)
+		if dictionary, err := rules.NewDictionary(sqliteRepo); err != nil {
+			logger.Warn("Keyword dictionaries unavailable", "error", err)
+		} else {
+			categorizer.SetDictionary(dictionary)
+		}
+		if cfg.ClassifierEnabled {
+			categorizer.SetClassifier(classifier.New(sqliteRepo, 15*time.Minute), float64(cfg.ClassifierMinConfidence)/100)
+			logger.Info("Local categorization model enabled", "min_confidence", cfg.ClassifierMinConfidence)
+		}
+		expenseService.SetCategorizer(categorizer)
 		adapter := adapters.NewSQLiteAdapter(sqliteRepo, expenseService)
 
 		expWriter, taxReader, dashReader, expLister, expDeleter, expListerWithID = adapter, adapter, adapter, adapter, adapter, adapter
+		incomeStore = adapter
+		batchWriter = adapter
+		demoStore = sqliteRepo
+
+		// Initialize Google Sheets client for sync processor (optional).
+		// The demo never syncs: its data is fake and wiped nightly.
+		if cfg.DemoMode {
+			logger.Info("Demo mode enabled, Google Sheets sync disabled")
+		} else {
+			sheetsClient, err = gsheet.NewFromEnvWithClient(context.Background(), outbound.RetryingClient("sheets", gsheet.RequestTimeout, sheetsRetry))
+			if err != nil {
+				logger.Warn("Google Sheets client not available, sync processor will be disabled", "error", err)
+			} else {
+				sheetsClient.SetWriteLimiter(gsheet.NewWriteLimiter(cfg.GoogleWriteLimit, cfg.GoogleWriteWindow))
+			}
+		}
 
-		// Initialize Google Sheets client for sync processor (optional)
-		sheetsClient, err = gsheet.NewFromEnv(context.Background())
-		if err != nil {
-			logger.Warn("Google Sheets client not available, sync processor will be disabled", "error", err)
+		syncSheets = sheetsClient
+		if sheetsClient != nil && cfg.GoogleSandboxSpreadsheetID != "" {
+			sandbox = gsheet.NewSandbox(sheetsClient, cfg.GoogleSandboxSpreadsheetID)
+			syncSheets = sandbox.Client()
+			logger.Warn("Google Sheets sync writes to the sandbox spreadsheet, not the production one",
+				"sandbox_spreadsheet_id", cfg.GoogleSandboxSpreadsheetID)
 		}
 
 		logger.Info("Initialized SQLite backend", "db_path", cfg.SQLiteDBPath, "sheets_sync_enabled", sheetsClient != nil)
 
 	case "sheets":
 		var err error
-		sheetsClient, err = gsheet.NewFromEnv(context.Background())
+		sheetsClient, err = gsheet.NewFromEnvWithClient(context.Background(), outbound.RetryingClient("sheets", gsheet.RequestTimeout, sheetsRetry))
 		if err != nil {
 			logger.Error("Failed to initialize Google Sheets client", "error", err)
 			os.Exit(1)
 		}
+		sheetsClient.SetWriteLimiter(gsheet.NewWriteLimiter(cfg.GoogleWriteLimit, cfg.GoogleWriteWindow))
 		expWriter, taxReader, dashReader, expLister, expDeleter = sheetsClient, sheetsClient, sheetsClient, sheetsClient, sheetsClient
 		expListerWithID = nil // Google Sheets backend doesn't support listing with IDs yet
+		expWriter = hooks.ExpenseWriter{Next: sheetsClient, Hooks: hookRunner}
+		syncSheets = sheetsClient
 		logger.Info("Initialized Google Sheets backend")
 
+	case "memory":
+		// Everything lives in the process: no database file, no credentials
+		// and no sync. The data is lost on restart.
+		adapter := adapters.NewMemoryAdapter(nil)
+		expWriter, taxReader, dashReader, expLister, expDeleter, expListerWithID = adapter, adapter, adapter, adapter, adapter, adapter
+		expWriter = hooks.ExpenseWriter{Next: adapter, Hooks: hookRunner}
+		incomeStore = adapter
+		batchWriter = adapter
+		demoStore = adapter
+		logger.Warn("Initialized in-memory backend: data is lost on restart")
+
 	default:
 		logger.Error("Unsupported data backend", "backend", cfg.DataBackend)
 		os.Exit(1)
 	}
 
+	// Whether Google Sheets accepts the credentials, shown as a banner in
+	// the UI while it does not
+	integrationHealth := services.NewIntegrationHealth()
+
+	// Check that the expenses sheet still has its columns where rows are
+	// written, so a reorganized sheet is reported rather than filled with
+	// categories in the wrong columns
+	if syncSheets != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		problems, err := syncSheets.CheckExpenseHeader(ctx)
+		cancel()
+		switch {
+		case ports.Classify(err) == ports.ErrorAuth:
+			logger.Error("Google Sheets rejected the credentials", "error", err)
+			integrationHealth.MarkDegraded(err)
+		case err != nil:
+			logger.Warn("Could not check the expenses sheet columns", "sheet", syncSheets.ExpensesSheet(), "error", err)
+		case len(problems) > 0:
+			for _, p := range problems {
+				logger.Warn("Expenses sheet column mismatch", "sheet", syncSheets.ExpensesSheet(),
+					"column", p.Column.Name, "expected", p.Column.Letter, "found", p.Found, "header", p.Header, "problem", p.String())
+			}
+		default:
+			logger.Info("Expenses sheet columns match the expected layout", "sheet", syncSheets.ExpensesSheet())
+		}
+	}
+
 	srv := apphttp.NewServer(":"+cfg.Port, expWriter, taxReader, dashReader, expLister, expDeleter, expListerWithID)
+	srv.SetOutboundStats(outbound.Stats)
+	if syncSheets != nil {
+		srv.AddCaches(syncSheets)
+	}
+	outbound.ObserveRequests(srv.ObserveOutbound)
+	if sqliteRepo != nil {
+		sqliteRepo.ObserveQueries(srv.ObserveQuery)
+	}
+	srv.SetBackendName(cfg.DataBackend)
+	srv.SetIntegrationHealth(integrationHealth)
+	if batchWriter != nil {
+		srv.SetBatchWriter(batchWriter)
+	}
+	if categorizer != nil {
+		srv.SetCategorizer(categorizer)
+	}
+	llmClient, err := llm.New(llm.Config{
+		Provider:   cfg.LLMProvider,
+		BaseURL:    cfg.LLMBaseURL,
+		APIKey:     cfg.LLMAPIKey,
+		Model:      cfg.LLMModel,
+		Timeout:    cfg.LLMTimeout,
+		HTTPClient: outbound.Client("llm", cfg.LLMTimeout),
+	})
+	if err != nil {
+		logger.Error("Failed to configure language model", "error", err)
+		os.Exit(1)
+	}
+	if llmClient != nil {
+		srv.SetExtractor(llmClient)
+		logger.Info("Language model enabled for receipts", "provider", cfg.LLMProvider, "model", llmClient.Model())
+	}
+
+	// Receipt photos and PDFs in a local directory or an S3-compatible bucket
+	var receiptStore receipts.Store
+	if cfg.DataBackend == "sqlite" && sqliteRepo != nil {
+		receiptStore, err = receipts.New(receipts.Config{
+			Storage:    cfg.ReceiptsStorage,
+			Dir:        cfg.ReceiptsDir,
+			Endpoint:   cfg.ReceiptsS3Endpoint,
+			Bucket:     cfg.ReceiptsS3Bucket,
+			Region:     cfg.ReceiptsS3Region,
+			AccessKey:  cfg.ReceiptsS3AccessKey,
+			SecretKey:  cfg.ReceiptsS3SecretKey,
+			HTTPClient: outbound.Client("receipts", receipts.DefaultTimeout),
+		})
+		if err != nil {
+			logger.Error("Failed to configure receipt storage", "error", err)
+			os.Exit(1)
+		}
+		if receiptStore != nil {
+			srv.SetReceiptStore(receiptStore, int64(cfg.ReceiptsMaxMB)<<20)
+			logger.Info("Receipt attachments enabled", "storage", cfg.ReceiptsStorage)
+		}
+	}
+
+	// Replication (Litestream/LiteFS): writes on replicas go to the primary,
+	// and background writers only run on the primary
+	var replicationMonitor *replication.Monitor
+	if cfg.ReplicationMode != "" && sqliteRepo != nil {
+		replicationMonitor = replication.NewMonitor(replication.Mode(cfg.ReplicationMode), cfg.LiteFSDir, cfg.SQLiteDBPath)
+		if replicationMonitor.Mode() == replication.ModeLitestream {
+			if err := sqliteRepo.EnableWAL(context.Background()); err != nil {
+				logger.Error("Failed to enable WAL for Litestream", "error", err)
+				os.Exit(1)
+			}
+		}
+		srv.SetReplication(replicationMonitor, cfg.ReplicationPrimaryURL, cfg.ReplicationMaxLag)
+		logger.Info("Replication enabled", "mode", cfg.ReplicationMode, "primary", replicationMonitor.IsPrimary())
+	}
+
+	// Read replicas serve reads, except for sessions that just wrote
+	if sqliteRepo != nil {
+		for _, path := range cfg.SQLiteReadReplicas {
+			if err := sqliteRepo.AddReadReplica(path); err != nil {
+				logger.Error("Failed to open read replica", "error", err, "path", path)
+				os.Exit(1)
+			}
+		}
+		if len(cfg.SQLiteReadReplicas) > 0 || cfg.ReplicationMode == string(replication.ModeLiteFS) {
+			srv.SetReadYourWritesWindow(cfg.ReadYourWritesWindow)
+			logger.Info("Read replicas enabled", "replicas", len(cfg.SQLiteReadReplicas), "read_your_writes_window", cfg.ReadYourWritesWindow)
+		}
+	}
+	if sqliteRepo != nil && cfg.ExcludeReimbursed {
+		sqliteRepo.SetExcludeReimbursed(true)
+		logger.Info("Reimbursed amounts excluded from month totals")
+	}
+	if sqliteRepo != nil && cfg.ExcludePending {
+		sqliteRepo.SetExcludePending(true)
+		logger.Info("Pending expenses excluded from month totals")
+	}
+	if sqliteRepo != nil && cfg.LineItemCategories {
+		sqliteRepo.SetLineItemCategories(true)
+		logger.Info("Line item categories used in month totals")
+	}
+	var (
+		historyImporter  *services.HistoryImporter
+		reconcileService *services.ReconcileService
+	)
+	if sqliteRepo != nil && sheetsClient != nil {
+		// Reconciliation compares the database with the sheet the sync fills
+		reconcileService = services.NewReconcileService(sqliteRepo, syncSheets)
+		srv.SetReconcileService(reconcileService)
+		historyImporter = services.NewHistoryImporter(sqliteRepo, sheetsClient)
+		srv.SetHistoryImporter(historyImporter)
+	}
+	if sandbox != nil {
+		srv.SetSheetSandbox(sandbox)
+	}
+	if sqliteRepo != nil {
+		srv.SetMonthReviewer(services.NewMonthReviewer(sqliteRepo, sheetsClient != nil))
+		srv.SetRuleReprocessor(services.NewRuleReprocessor(sqliteRepo))
+	}
+	var integrityChecker *services.IntegrityChecker
+	if sqliteRepo != nil {
+		// The sync flags are compared with the sheet the sync fills
+		var sheetRows ports.AmountReconciler
+		if syncSheets != nil {
+			sheetRows = syncSheets
+		}
+		integrityChecker = services.NewIntegrityChecker(sqliteRepo, sheetRows)
+		srv.SetIntegrityChecker(integrityChecker)
+	}
+	if sqliteRepo != nil && expenseService != nil {
+		srv.SetCSVImporter(services.NewCSVImporter(sqliteRepo, expenseService))
+
+		// API keys of the members and their quotas
+		quotas := services.NewQuotas(sqliteRepo, core.QuotaLimits{
+			DailyExpenses:   int64(cfg.QuotaDailyExpenses),
+			AttachmentBytes: int64(cfg.QuotaAttachmentMB) << 20,
+		}, cfg.QuotaSoftPercent)
+		expenseService.SetQuotas(quotas)
+		srv.SetQuotas(quotas)
+	}
+	if cfg.AuthEnabled() {
+		hash := []byte(cfg.AuthPasswordHash)
+		if cfg.AuthPassword != "" {
+			var err error
+			if hash, err = bcrypt.GenerateFromPassword([]byte(cfg.AuthPassword), bcrypt.DefaultCost); err != nil {
+				logger.Error("Failed to hash AUTH_PASSWORD", "error", err)
+				os.Exit(1)
+			}
+		}
+		srv.SetAuth(apphttp.AuthConfig{
+			PasswordHash:  hash,
+			SessionSecret: []byte(cfg.AuthSessionSecret),
+			SessionTTL:    cfg.AuthSessionTTL,
+			SecureCookie:  cfg.AuthCookieSecure,
+			PublicPaths:   cfg.AuthPublicPaths,
+		})
+		logger.Info("Web UI login enabled", "session_ttl", cfg.AuthSessionTTL, "public_paths", cfg.AuthPublicPaths)
+	} else {
+		logger.Warn("Web UI login disabled: set AUTH_PASSWORD or AUTH_PASSWORD_HASH to require one")
+	}
+	if cfg.WSToken != "" {
+		srv.SetWebSocketToken(cfg.WSToken)
+	}
+	if cfg.DemoMode {
+		srv.SetDemoMode(true)
+	}
+	srv.SetCalculatorRates(core.Money{Cents: cfg.MileageRateCents}, core.Money{Cents: cfg.PerDiemRateCents})
+	srv.SetVehicleCategory(cfg.VehicleCategory)
+	srv.SetTrashRetention(cfg.TrashRetentionDays)
+	srv.SetSQLConsole(cfg.SQLConsoleEnabled)
+	srv.SetEntryDefaults(cfg.DayStartHour, cfg.YearSelection)
+	if cfg.WorkflowEnabled {
+		srv.SetWorkflow(cfg.WorkflowApproverToken)
+		logger.Info("Expense approval workflow enabled")
+	}
+	if cfg.ExportHashKey != "" {
+		srv.SetExportHashKey(cfg.ExportHashKey)
+	}
+	var parquetExporter *services.ParquetExporter
+	if sqliteRepo != nil {
+		parquetExporter = services.NewParquetExporter(sqliteRepo, cfg.ParquetExportDir)
+		srv.SetParquetExporter(parquetExporter)
+	}
+	// Alerts go through the router, which drops those muted or snoozed on
+	// the preferences page
+	var alertRouter *services.AlertRouter
+	if sqliteRepo != nil {
+		alertRouter = services.NewAlertRouter(sqliteRepo, srv.Events(), core.DefaultAlertUser)
+	}
+	if expenseService != nil && alertRouter != nil {
+		expenseService.SetViewWatcher(services.NewViewWatcher(sqliteRepo, alertRouter))
+	}
+	var peerSync *services.PeerSyncService
+	if sqliteRepo != nil && cfg.PeerToken != "" {
+		peerSync = services.NewPeerSyncService(sqliteRepo, cfg.PeerURL, cfg.PeerToken)
+		peerSync.SetHTTPClient(outbound.Client("peer", 30*time.Second))
+		srv.SetPeerSync(peerSync)
+	}
+
+	// Background jobs share one runner; with the SQLite backend their runs
+	// are recorded in the jobs table
+	var jobStore services.JobStore
+	if sqliteRepo != nil {
+		jobStore = sqliteRepo
+	}
+	jobRunner := services.NewJobRunner(jobStore, cfg.JobWorkers, cfg.JobMaxAttempts)
+	jobRunner.SetPrimaryCheck(replicationMonitor.IsPrimary)
+	srv.SetJobRunner(jobRunner)
+	registerJob := func(job services.Job) {
+		if err := jobRunner.Register(job); err != nil {
+			logger.Error("Failed to register job", "error", err)
+			os.Exit(1)
+		}
+	}
 
 	// Configure server timeouts and limits
 	srv.ReadTimeout = 10 * time.Second
@@ -142,17 +474,63 @@ func main() {
 		return srv.Shutdown(shutdownCtx)
 	})
 
+	// Start optional gRPC server, sharing the HTTP server's event bus so
+	// streams see changes made from either side
+	if cfg.GRPCAddr != "" {
+		grpcSrv := grpcserver.New(grpcserver.Deps{
+			ExpenseWriter:   expWriter,
+			ExpenseLister:   expListerWithID,
+			ExpenseDeleter:  expDeleter,
+			DashboardReader: dashReader,
+			Incomes:         incomeStore,
+			Events:          srv.Events(),
+		}, cfg.GRPCToken)
+
+		lis, err := net.Listen("tcp", cfg.GRPCAddr)
+		if err != nil {
+			logger.Error("Failed to listen for gRPC", "error", err, "addr", cfg.GRPCAddr)
+			os.Exit(1)
+		}
+
+		g.Go(func() error {
+			logger.Info("Starting gRPC server", "addr", cfg.GRPCAddr)
+			return grpcSrv.Serve(lis)
+		})
+
+		// Graceful shutdown of gRPC server; open streams are cut after the timeout
+		g.Go(func() error {
+			<-gCtx.Done()
+			logger.Info("Shutting down gRPC server")
+
+			stopped := make(chan struct{})
+			go func() {
+				grpcSrv.GracefulStop()
+				close(stopped)
+			}()
+			select {
+			case <-stopped:
+			case <-time.After(10 * time.Second):
+				grpcSrv.Stop()
+			}
+			return nil
+		})
+	}
+
 	// Start SyncProcessor (SQLite backend with Google Sheets client)
 	var syncProcessor *services.SyncProcessor
 	if cfg.DataBackend == "sqlite" && sheetsClient != nil && sqliteRepo != nil {
 		syncConfig := services.SyncProcessorConfig{
 			PollInterval:    cfg.SyncInterval,
 			BatchSize:       cfg.SyncBatchSize,
-			MaxRetries:      3,
+			MaxRetries:      cfg.SyncMaxAttempts,
 			CleanupInterval: 1 * time.Hour,
 			CleanupAge:      24 * time.Hour,
+			PullInterval:    cfg.SyncPullInterval,
 		}
-		syncProcessor = services.NewSyncProcessor(sqliteRepo, sheetsClient, sheetsClient, syncConfig)
+		syncProcessor = services.NewSyncProcessor(sqliteRepo, syncSheets, syncSheets, syncConfig)
+		syncProcessor.SetPrimaryCheck(replicationMonitor.IsPrimary)
+		syncProcessor.SetAlertRouter(alertRouter)
+		syncProcessor.SetIntegrationHealth(integrationHealth)
 
 		g.Go(func() error {
 			logger.Info("Starting sync processor",
@@ -172,39 +550,290 @@ func main() {
 		})
 	}
 
-	// Start RecurringProcessor (SQLite backend only)
-	if cfg.DataBackend == "sqlite" && sqliteRepo != nil && expenseService != nil {
-		recurringProcessor := services.NewRecurringProcessor(sqliteRepo, expenseService)
+	// Sheet sync and amount reconciliation on demand, e.g. after fixing
+	// the credentials or editing the sheet by hand
+	if syncProcessor != nil {
+		registerJob(services.Job{
+			Name:        "sheet-sync",
+			Description: "Writes the sync queue to Google Sheets now",
+			PrimaryOnly: true,
+			Run: func(ctx context.Context) (string, error) {
+				count, err := syncProcessor.SyncNow(ctx)
+				return countResult(count, "expenses"), err
+			},
+		})
+		registerJob(services.Job{
+			Name:        "sync-reprocess",
+			Description: "Queues the dead-lettered sync items again",
+			PrimaryOnly: true,
+			Run: func(ctx context.Context) (string, error) {
+				count, err := syncProcessor.RetryFailed(ctx)
+				return countResult(int(count), "items"), err
+			},
+		})
+		registerJob(services.Job{
+			Name:        "sheet-pull",
+			Description: "Stores the rows edited or added by hand in Google Sheets",
+			PrimaryOnly: true,
+			Run: func(ctx context.Context) (string, error) {
+				count, err := syncProcessor.PullEdits(ctx)
+				return countResult(count, "rows"), err
+			},
+		})
+	}
+	if reconcileService != nil {
+		registerJob(services.Job{
+			Name:        "reconcile",
+			Description: "Compares the amounts of this month with Google Sheets",
+			Run: func(ctx context.Context) (string, error) {
+				now := time.Now()
+				divergences, err := reconcileService.FindAmountDivergences(ctx, now.Year(), int(now.Month()))
+				return countResult(len(divergences), "divergences"), err
+			},
+		})
+	}
 
+	// Integrity check at startup, logged, and on demand
+	if integrityChecker != nil {
+		registerJob(services.Job{
+			Name:        "integrity-check",
+			Description: "Checks the data invariants and suggests repairs at /integrita",
+			Run: func(ctx context.Context) (string, error) {
+				report, err := integrityChecker.Check(ctx, time.Now())
+				if err != nil {
+					return "", err
+				}
+				return report.Summary(), nil
+			},
+		})
 		g.Go(func() error {
-			ticker := time.NewTicker(cfg.RecurringProcessorInterval)
-			defer ticker.Stop()
-
-			logger.Info("Starting recurring processor", "interval", cfg.RecurringProcessorInterval)
+			ctx, cancel := context.WithTimeout(gCtx, time.Minute)
+			defer cancel()
+			report, err := integrityChecker.Check(ctx, time.Now())
+			switch {
+			case err != nil:
+				logger.Warn("Integrity check failed", "error", err)
+			case len(report.Issues) > 0:
+				for kind, count := range report.Counts() {
+					logger.Warn("Integrity check found issues, see /integrita", "check", kind, "count", count)
+				}
+			default:
+				logger.Info("Integrity check found no issues", "sheet_checked", report.SheetChecked)
+			}
+			if report.SheetError != "" {
+				logger.Warn("Integrity check could not read the expenses sheet", "error", report.SheetError)
+			}
+			return nil
+		})
+	}
 
-			// Process immediately on startup
-			if count, err := recurringProcessor.ProcessDueExpenses(gCtx, time.Now()); err != nil {
-				logger.Error("Failed to process recurring expenses on startup", "error", err)
-			} else if count > 0 {
-				logger.Info("Processed recurring expenses on startup", "count", count)
+	// Import of the expenses sheets of past years; completed years are
+	// recorded, so later startups skip them and resume interrupted ones
+	if cfg.SheetsHistoryImport && historyImporter != nil {
+		g.Go(func() error {
+			if !replicationMonitor.IsPrimary() {
+				logger.Info("Replica node, the Google Sheets history is imported by the primary")
+				return nil
+			}
+			report, err := historyImporter.Import(gCtx)
+			if err != nil {
+				logger.Error("Failed to import Google Sheets history", "error", err)
+			}
+			if len(report.Imported) > 0 || len(report.Skipped) > 0 {
+				logger.Info("Google Sheets history import finished", "imported_years", len(report.Imported), "skipped_years", report.Skipped)
 			}
+			return nil
+		})
+	}
 
-			for {
-				select {
-				case <-gCtx.Done():
-					logger.Info("Stopping recurring processor")
-					return nil
-				case <-ticker.C:
-					if count, err := recurringProcessor.ProcessDueExpenses(gCtx, time.Now()); err != nil {
-						logger.Error("Failed to process recurring expenses", "error", err)
-					} else if count > 0 {
-						logger.Info("Processed recurring expenses", "count", count)
+	// Recurring expenses and incomes (SQLite backend only; the demo dataset
+	// is fixed)
+	if cfg.DataBackend == "sqlite" && sqliteRepo != nil && expenseService != nil && !cfg.DemoMode {
+		recurringProcessor := services.NewRecurringProcessor(sqliteRepo, expenseService)
+		registerJob(services.Job{
+			Name:        "process-recurring",
+			Description: "Creates the due recurring expenses and incomes",
+			Every:       cfg.RecurringProcessorInterval,
+			PrimaryOnly: true,
+			Run: func(ctx context.Context) (string, error) {
+				expenses, err := recurringProcessor.ProcessDueExpenses(ctx, time.Now())
+				if err != nil {
+					return "", fmt.Errorf("process recurring expenses: %w", err)
+				}
+				incomes, err := recurringProcessor.ProcessDueIncomes(ctx, time.Now())
+				if err != nil {
+					return "", fmt.Errorf("process recurring incomes: %w", err)
+				}
+				var done []string
+				for _, part := range []string{countResult(expenses, "expenses"), countResult(incomes, "incomes")} {
+					if part != "" {
+						done = append(done, part)
 					}
 				}
-			}
+				return strings.Join(done, ", "), nil
+			},
+		})
+	}
+
+	// Remind return deadlines of purchases, on the recurring processor schedule
+	if cfg.DataBackend == "sqlite" && sqliteRepo != nil && !cfg.DemoMode {
+		warrantyNotifier := services.NewWarrantyNotifier(sqliteRepo, alertRouter, cfg.ReturnReminderDays)
+		registerJob(services.Job{
+			Name:        "return-reminders",
+			Description: "Reminds the return deadlines of purchases",
+			Every:       cfg.RecurringProcessorInterval,
+			PrimaryOnly: true,
+			Run: func(ctx context.Context) (string, error) {
+				count, err := warrantyNotifier.NotifyReturnDeadlines(ctx, time.Now())
+				return countResult(count, "reminders"), err
+			},
+		})
+	}
+
+	// Delete the files of replaced receipts and deleted expenses, on the
+	// recurring processor schedule
+	if receiptStore != nil {
+		receiptCleaner := services.NewReceiptCleaner(sqliteRepo, receiptStore)
+		registerJob(services.Job{
+			Name:        "receipt-cleanup",
+			Description: "Deletes the files of replaced receipts and deleted expenses",
+			Every:       cfg.RecurringProcessorInterval,
+			PrimaryOnly: true,
+			Run: func(ctx context.Context) (string, error) {
+				count, err := receiptCleaner.Clean(ctx)
+				return countResult(count, "files"), err
+			},
+		})
+	}
+
+	// Detect spending insights for the dashboard feed, on the recurring
+	// processor schedule
+	if cfg.DataBackend == "sqlite" && sqliteRepo != nil {
+		insightDetector := services.NewInsightDetector(sqliteRepo, alertRouter)
+		registerJob(services.Job{
+			Name:        "insights",
+			Description: "Detects spending insights for the dashboard",
+			Every:       cfg.RecurringProcessorInterval,
+			PrimaryOnly: true,
+			Run: func(ctx context.Context) (string, error) {
+				count, err := insightDetector.Detect(ctx, time.Now())
+				return countResult(count, "insights"), err
+			},
+		})
+	}
+
+	// Seed the demo dataset and reset it every night
+	if cfg.DemoMode && demoStore != nil {
+		if err := demo.Reset(ctx, demoStore, time.Now()); err != nil {
+			logger.Error("Failed to seed demo dataset", "error", err)
+			os.Exit(1)
+		}
+		logger.Info("Demo dataset seeded")
+
+		registerJob(services.Job{
+			Name:        "demo-reset",
+			Description: "Resets the demo dataset",
+			Next:        demo.NextReset,
+			SkipStartup: true,
+			Run: func(ctx context.Context) (string, error) {
+				return "", demo.Reset(ctx, demoStore, time.Now())
+			},
+		})
+	}
+
+	// Audit log retention (SQLite backend, AUDIT_RETENTION_DAYS above 0)
+	if sqliteRepo != nil && cfg.AuditRetentionDays > 0 {
+		registerJob(services.Job{
+			Name:        "audit-retention",
+			Description: fmt.Sprintf("Removes audit log entries older than %d days", cfg.AuditRetentionDays),
+			Every:       24 * time.Hour,
+			PrimaryOnly: true,
+			Run: func(ctx context.Context) (string, error) {
+				n, err := sqliteRepo.PruneAuditLog(ctx, time.Now().AddDate(0, 0, -cfg.AuditRetentionDays))
+				return countResult(int(n), "entries"), err
+			},
+		})
+	}
+
+	// Trash purge (SQLite backend, TRASH_RETENTION_DAYS above 0)
+	if sqliteRepo != nil && cfg.TrashRetentionDays > 0 {
+		registerJob(services.Job{
+			Name:        "trash-purge",
+			Description: fmt.Sprintf("Deletes for good the expenses and incomes in the trash for more than %d days", cfg.TrashRetentionDays),
+			Every:       24 * time.Hour,
+			PrimaryOnly: true,
+			Run: func(ctx context.Context) (string, error) {
+				n, err := sqliteRepo.PurgeTrash(ctx, time.Now().AddDate(0, 0, -cfg.TrashRetentionDays))
+				return countResult(n, "items"), err
+			},
+		})
+	}
+
+	// Monthly aggregates check (SQLite backend)
+	if sqliteRepo != nil {
+		registerJob(services.Job{
+			Name:        "aggregates-rebuild",
+			Description: "Recomputes the monthly totals the overviews read when they disagree with the entries",
+			Every:       24 * time.Hour,
+			PrimaryOnly: true,
+			Run: func(ctx context.Context) (string, error) {
+				n, err := sqliteRepo.RebuildMonthlyAggregates(ctx)
+				return countResult(int(n), "stale aggregates"), err
+			},
+		})
+	}
+
+	// Database maintenance (SQLite backend, DB_MAINTENANCE_INTERVAL above 0)
+	if sqliteRepo != nil && cfg.DBMaintenanceInterval > 0 {
+		registerJob(services.Job{
+			Name:        "db-maintenance",
+			Description: "Checkpoints the WAL, vacuums free pages and refreshes the query planner statistics",
+			Every:       cfg.DBMaintenanceInterval,
+			SkipStartup: true,
+			PrimaryOnly: true,
+			Run: func(ctx context.Context) (string, error) {
+				res, err := sqliteRepo.Maintain(ctx)
+				if err != nil {
+					return "", err
+				}
+				summary := fmt.Sprintf("checkpoint %s, %d KiB freed", res.CheckpointDuration.Round(time.Millisecond), res.FreedBytes/1024)
+				if res.CheckpointBusy {
+					summary += ", WAL in use"
+				}
+				return summary, nil
+			},
+		})
+	}
+
+	// Scheduled Parquet export (SQLite backend, PARQUET_EXPORT_DIR set)
+	if parquetExporter != nil && cfg.ParquetExportDir != "" {
+		logger.Info("Scheduled Parquet export enabled", "dir", cfg.ParquetExportDir, "interval", cfg.ParquetExportInterval)
+		registerJob(services.Job{
+			Name:        "parquet-export",
+			Description: "Exports the data to " + cfg.ParquetExportDir,
+			Every:       cfg.ParquetExportInterval,
+			Run: func(ctx context.Context) (string, error) {
+				return "", parquetExporter.ExportToDir(ctx)
+			},
 		})
 	}
 
+	// Peer sync (PEER_URL set)
+	if peerSync != nil && cfg.PeerURL != "" {
+		logger.Info("Peer sync enabled", "peer", cfg.PeerURL, "interval", cfg.PeerSyncInterval)
+		registerJob(services.Job{
+			Name:        "peer-sync",
+			Description: "Syncs with " + cfg.PeerURL,
+			Every:       cfg.PeerSyncInterval,
+			PrimaryOnly: true,
+			Run:         func(ctx context.Context) (string, error) { return "", peerSync.Sync(ctx) },
+		})
+	}
+
+	g.Go(func() error {
+		return jobRunner.Start(gCtx)
+	})
+
 	// Wait for all goroutines to complete
 	if err := g.Wait(); err != nil {
 		logger.Error("Error during shutdown", "error", err)
@@ -219,3 +848,12 @@ func main() {
 
 	logger.Info("Server stopped gracefully")
 }
+
+// countResult summarizes what a job did as "3 expenses", or empty when it
+// did nothing.
+func countResult(n int, noun string) string {
+	if n == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d %s", n, noun)
+}
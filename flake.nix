@@ -24,7 +24,7 @@
             ldflags = [ "-s" "-w" ];
 
             # Only build the main binary
-            subPackages = [ "cmd/spese" ];
+            subPackages = [ "cmd/spese" "cmd/spese-job" "cmd/spese-smoke" ];
 
             meta = with pkgs.lib; {
               description = "Personal expense tracker with Google Sheets sync";
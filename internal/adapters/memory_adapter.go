@@ -0,0 +1,364 @@
+package adapters
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"spese/internal/core"
+	"spese/internal/sheets"
+	"spese/internal/storage"
+)
+
+// MemoryAdapter keeps expenses, incomes and recurrent expenses in memory and
+// implements the same sheets.* and income interfaces as SQLiteAdapter. It
+// needs no files or credentials, so it backs demos and handler tests; its
+// data is lost when the process exits.
+type MemoryAdapter struct {
+	mu         sync.RWMutex
+	nextID     int64
+	taxonomy   map[string][]string // Primary category -> its secondaries
+	expenses   map[int64]core.Expense
+	incomes    map[int64]core.Income
+	recurrents []core.RecurrentExpenses
+}
+
+// NewMemoryAdapter returns an empty store offering the given categories.
+// Categories of stored expenses are added to the taxonomy as they arrive.
+func NewMemoryAdapter(groups []core.CategoryGroup) *MemoryAdapter {
+	a := &MemoryAdapter{
+		taxonomy: make(map[string][]string),
+		expenses: make(map[int64]core.Expense),
+		incomes:  make(map[int64]core.Income),
+	}
+	for _, g := range groups {
+		for _, s := range g.Secondaries {
+			a.addCategory(g.Primary, s)
+		}
+		if _, ok := a.taxonomy[g.Primary]; !ok {
+			a.taxonomy[g.Primary] = nil
+		}
+	}
+	return a
+}
+
+// addCategory records a primary/secondary pair; callers hold the lock
+func (a *MemoryAdapter) addCategory(primary, secondary string) {
+	if !slices.Contains(a.taxonomy[primary], secondary) {
+		a.taxonomy[primary] = append(a.taxonomy[primary], secondary)
+	}
+}
+
+// Append implements sheets.ExpenseWriter
+func (a *MemoryAdapter) Append(ctx context.Context, e core.Expense) (string, error) {
+	ids, err := a.AppendBatch(ctx, []core.Expense{e})
+	if err != nil {
+		return "", err
+	}
+	return ids[0], nil
+}
+
+// AppendBatch implements sheets.ExpenseBatchWriter; nothing is stored when
+// one of the expenses is invalid.
+func (a *MemoryAdapter) AppendBatch(_ context.Context, expenses []core.Expense) ([]string, error) {
+	for i, e := range expenses {
+		if err := e.Validate(); err != nil {
+			return nil, fmt.Errorf("expense %d: %w", i+1, err)
+		}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	ids := make([]string, len(expenses))
+	for i, e := range expenses {
+		a.nextID++
+		a.expenses[a.nextID] = e
+		a.addCategory(e.Primary, e.Secondary)
+		ids[i] = strconv.FormatInt(a.nextID, 10)
+	}
+	return ids, nil
+}
+
+// List implements sheets.TaxonomyReader
+func (a *MemoryAdapter) List(_ context.Context) ([]string, []string, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	primaries := make([]string, 0, len(a.taxonomy))
+	var secondaries []string
+	for p, subs := range a.taxonomy {
+		primaries = append(primaries, p)
+		for _, s := range subs {
+			if !slices.Contains(secondaries, s) {
+				secondaries = append(secondaries, s)
+			}
+		}
+	}
+	sort.Strings(primaries)
+	sort.Strings(secondaries)
+	return primaries, secondaries, nil
+}
+
+// GetSecondariesByPrimary returns secondary categories for a given primary category
+func (a *MemoryAdapter) GetSecondariesByPrimary(_ context.Context, primaryCategory string) ([]string, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	subs := slices.Clone(a.taxonomy[primaryCategory])
+	sort.Strings(subs)
+	return subs, nil
+}
+
+// ReadMonthOverview implements sheets.DashboardReader
+func (a *MemoryAdapter) ReadMonthOverview(_ context.Context, year int, month int) (core.MonthOverview, error) {
+	overview := core.MonthOverview{Year: year, Month: month}
+	totals := make(map[string]int64)
+
+	a.mu.RLock()
+	for _, e := range a.expenses {
+		if inMonth(e.Date, year, month) {
+			overview.Total.Cents += e.Amount.Cents
+			totals[e.Primary] += e.Amount.Cents
+		}
+	}
+	a.mu.RUnlock()
+
+	overview.ByCategory = sortedAmounts(totals)
+	return overview, nil
+}
+
+// ListExpenses implements sheets.ExpenseLister
+func (a *MemoryAdapter) ListExpenses(ctx context.Context, year int, month int) ([]core.Expense, error) {
+	withID, err := a.ListExpensesWithID(ctx, year, month)
+	if err != nil {
+		return nil, err
+	}
+	expenses := make([]core.Expense, len(withID))
+	for i, e := range withID {
+		expenses[i] = e.Expense
+	}
+	return expenses, nil
+}
+
+// ListExpensesWithID implements sheets.ExpenseListerWithID, newest first
+func (a *MemoryAdapter) ListExpensesWithID(_ context.Context, year int, month int) ([]sheets.ExpenseWithID, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	var ids []int64
+	for id, e := range a.expenses {
+		if inMonth(e.Date, year, month) {
+			ids = append(ids, id)
+		}
+	}
+	sortNewestFirst(ids, func(id int64) core.Date { return a.expenses[id].Date })
+
+	result := make([]sheets.ExpenseWithID, len(ids))
+	for i, id := range ids {
+		result[i] = sheets.ExpenseWithID{ID: strconv.FormatInt(id, 10), Expense: a.expenses[id]}
+	}
+	return result, nil
+}
+
+// DeleteExpense implements sheets.ExpenseDeleter
+func (a *MemoryAdapter) DeleteExpense(_ context.Context, id string) error {
+	expenseID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid expense ID: %w", err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, ok := a.expenses[expenseID]; !ok {
+		return fmt.Errorf("expense not found: %d", expenseID)
+	}
+	delete(a.expenses, expenseID)
+	return nil
+}
+
+// SaveRecurrentExpense implements sheets.RecurrentExpenseWriter
+func (a *MemoryAdapter) SaveRecurrentExpense(_ context.Context, re core.RecurrentExpenses) error {
+	if err := re.Validate(); err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.nextID++
+	re.ID = a.nextID
+	a.recurrents = append(a.recurrents, re)
+	a.addCategory(re.Primary, re.Secondary)
+	return nil
+}
+
+// ListActiveRecurrentExpenses implements sheets.RecurrentExpenseLister:
+// the recurrent expenses without an end date or ending today or later.
+func (a *MemoryAdapter) ListActiveRecurrentExpenses(_ context.Context) ([]core.RecurrentExpenses, error) {
+	y, m, d := time.Now().Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	var active []core.RecurrentExpenses
+	for _, re := range a.recurrents {
+		if re.EndDate.IsZero() || !re.EndDate.Before(today) {
+			active = append(active, re)
+		}
+	}
+	return active, nil
+}
+
+// Income methods
+
+// AppendIncome creates a new income entry
+func (a *MemoryAdapter) AppendIncome(_ context.Context, i core.Income) (string, error) {
+	if err := i.Validate(); err != nil {
+		return "", err
+	}
+	i.Tags = core.NormalizeTags(i.Tags)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.nextID++
+	a.incomes[a.nextID] = i
+	return strconv.FormatInt(a.nextID, 10), nil
+}
+
+// ListIncomesWithID returns the incomes of a month with their IDs, newest first
+func (a *MemoryAdapter) ListIncomesWithID(_ context.Context, year int, month int) ([]storage.IncomeWithID, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	var ids []int64
+	for id, i := range a.incomes {
+		if inMonth(i.Date, year, month) {
+			ids = append(ids, id)
+		}
+	}
+	sortNewestFirst(ids, func(id int64) core.Date { return a.incomes[id].Date })
+
+	result := make([]storage.IncomeWithID, len(ids))
+	for i, id := range ids {
+		result[i] = storage.IncomeWithID{ID: strconv.FormatInt(id, 10), Income: a.incomes[id]}
+	}
+	return result, nil
+}
+
+// DeleteIncome removes an income
+func (a *MemoryAdapter) DeleteIncome(_ context.Context, id string) error {
+	incomeID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid income ID: %w", err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, ok := a.incomes[incomeID]; !ok {
+		return fmt.Errorf("income not found: %d", incomeID)
+	}
+	delete(a.incomes, incomeID)
+	return nil
+}
+
+// ReadIncomeMonthOverview returns the monthly income overview
+func (a *MemoryAdapter) ReadIncomeMonthOverview(_ context.Context, year int, month int) (core.IncomeMonthOverview, error) {
+	overview := core.IncomeMonthOverview{Year: year, Month: month}
+	byCategory := make(map[string]int64)
+	byTag := make(map[string]int64)
+	bySub := make(map[[2]string]int64)
+
+	a.mu.RLock()
+	for _, i := range a.incomes {
+		if !inMonth(i.Date, year, month) {
+			continue
+		}
+		overview.Total.Cents += i.Amount.Cents
+		byCategory[i.Category] += i.Amount.Cents
+		if i.Subcategory != "" {
+			bySub[[2]string{i.Category, i.Subcategory}] += i.Amount.Cents
+		}
+		for _, tag := range i.Tags {
+			byTag[tag] += i.Amount.Cents
+		}
+	}
+	a.mu.RUnlock()
+
+	overview.ByCategory = sortedAmounts(byCategory)
+	overview.ByTag = sortedAmounts(byTag)
+	for key, cents := range bySub {
+		overview.BySubcategory = append(overview.BySubcategory, core.SubcategoryAmount{
+			Category:    key[0],
+			Subcategory: key[1],
+			Amount:      core.Money{Cents: cents},
+		})
+	}
+	sort.Slice(overview.BySubcategory, func(i, j int) bool {
+		x, y := overview.BySubcategory[i], overview.BySubcategory[j]
+		if x.Amount.Cents != y.Amount.Cents {
+			return x.Amount.Cents > y.Amount.Cents
+		}
+		if x.Category != y.Category {
+			return x.Category < y.Category
+		}
+		return x.Subcategory < y.Subcategory
+	})
+	return overview, nil
+}
+
+// ReplaceDataset implements demo.Store, swapping all stored data at once
+func (a *MemoryAdapter) ReplaceDataset(_ context.Context, expenses []core.Expense, incomes []core.Income, recurrents []core.RecurrentExpenses) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.expenses = make(map[int64]core.Expense, len(expenses))
+	a.incomes = make(map[int64]core.Income, len(incomes))
+	a.recurrents = nil
+	for _, e := range expenses {
+		a.nextID++
+		a.expenses[a.nextID] = e
+		a.addCategory(e.Primary, e.Secondary)
+	}
+	for _, i := range incomes {
+		a.nextID++
+		i.Tags = core.NormalizeTags(i.Tags)
+		a.incomes[a.nextID] = i
+	}
+	for _, re := range recurrents {
+		a.nextID++
+		re.ID = a.nextID
+		a.recurrents = append(a.recurrents, re)
+		a.addCategory(re.Primary, re.Secondary)
+	}
+	return nil
+}
+
+// inMonth reports whether d falls in the given year and month
+func inMonth(d core.Date, year, month int) bool {
+	return d.Year() == year && int(d.Month()) == month
+}
+
+// sortNewestFirst orders IDs by date, latest first, then by insertion,
+// like the SQLite month queries
+func sortNewestFirst(ids []int64, date func(int64) core.Date) {
+	sort.Slice(ids, func(i, j int) bool {
+		di, dj := date(ids[i]), date(ids[j])
+		if !di.Equal(dj.Time) {
+			return di.After(dj.Time)
+		}
+		return ids[i] > ids[j]
+	})
+}
+
+// sortedAmounts turns totals by name into amounts, largest first
+func sortedAmounts(totals map[string]int64) []core.CategoryAmount {
+	amounts := make([]core.CategoryAmount, 0, len(totals))
+	for name, cents := range totals {
+		amounts = append(amounts, core.CategoryAmount{Name: name, Amount: core.Money{Cents: cents}})
+	}
+	sort.Slice(amounts, func(i, j int) bool {
+		if amounts[i].Amount.Cents != amounts[j].Amount.Cents {
+			return amounts[i].Amount.Cents > amounts[j].Amount.Cents
+		}
+		return amounts[i].Name < amounts[j].Name
+	})
+	return amounts
+}
@@ -0,0 +1,122 @@
+package adapters
+
+import (
+	"context"
+	"testing"
+
+	"spese/internal/core"
+	"spese/internal/demo"
+	"spese/internal/grpcserver"
+	"spese/internal/sheets"
+)
+
+// The memory backend must be usable wherever the SQLite adapter is
+var (
+	_ sheets.ExpenseWriter          = (*MemoryAdapter)(nil)
+	_ sheets.ExpenseBatchWriter     = (*MemoryAdapter)(nil)
+	_ sheets.TaxonomyReader         = (*MemoryAdapter)(nil)
+	_ sheets.DashboardReader        = (*MemoryAdapter)(nil)
+	_ sheets.ExpenseLister          = (*MemoryAdapter)(nil)
+	_ sheets.ExpenseListerWithID    = (*MemoryAdapter)(nil)
+	_ sheets.ExpenseDeleter         = (*MemoryAdapter)(nil)
+	_ sheets.RecurrentExpenseWriter = (*MemoryAdapter)(nil)
+	_ sheets.RecurrentExpenseLister = (*MemoryAdapter)(nil)
+	_ grpcserver.IncomeStore        = (*MemoryAdapter)(nil)
+	_ demo.Store                    = (*MemoryAdapter)(nil)
+)
+
+func TestMemoryAdapter_Expenses(t *testing.T) {
+	ctx := context.Background()
+	adapter := NewMemoryAdapter([]core.CategoryGroup{{Primary: "Casa", Secondaries: []string{"Spesa", "Affitto"}}})
+
+	var ids []string
+	for _, e := range []core.Expense{
+		{Date: core.NewDate(2031, 3, 5), Description: "Spesa", Amount: core.Money{Cents: 2500}, Primary: "Casa", Secondary: "Spesa"},
+		{Date: core.NewDate(2031, 3, 9), Description: "Treno", Amount: core.Money{Cents: 4000}, Primary: "Trasporti", Secondary: "Treno"},
+		{Date: core.NewDate(2030, 3, 9), Description: "Altro anno", Amount: core.Money{Cents: 100}, Primary: "Casa", Secondary: "Spesa"},
+	} {
+		id, err := adapter.Append(ctx, e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	if _, err := adapter.Append(ctx, core.Expense{Date: core.NewDate(2031, 3, 5), Description: "Vuota"}); err == nil {
+		t.Error("invalid expense accepted")
+	}
+
+	primaries, secondaries, err := adapter.List(ctx)
+	if err != nil || len(primaries) != 2 || primaries[1] != "Trasporti" || len(secondaries) != 3 {
+		t.Errorf("taxonomy = %v %v, %v", primaries, secondaries, err)
+	}
+
+	overview, err := adapter.ReadMonthOverview(ctx, 2031, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if overview.Total.Cents != 6500 || len(overview.ByCategory) != 2 || overview.ByCategory[0].Name != "Trasporti" {
+		t.Errorf("overview 2031-03 = %+v", overview)
+	}
+
+	list, err := adapter.ListExpensesWithID(ctx, 2031, 3)
+	if err != nil || len(list) != 2 || list[0].Expense.Description != "Treno" || list[0].ID != ids[1] {
+		t.Errorf("expenses 2031-03 = %+v, %v", list, err)
+	}
+
+	if err := adapter.DeleteExpense(ctx, ids[1]); err != nil {
+		t.Fatal(err)
+	}
+	if err := adapter.DeleteExpense(ctx, ids[1]); err == nil {
+		t.Error("deleting a missing expense succeeded")
+	}
+	expenses, err := adapter.ListExpenses(ctx, 2031, 3)
+	if err != nil || len(expenses) != 1 || expenses[0].Description != "Spesa" {
+		t.Errorf("expenses after delete = %+v, %v", expenses, err)
+	}
+
+	if _, err := adapter.AppendBatch(ctx, []core.Expense{
+		{Date: core.NewDate(2031, 4, 1), Description: "Ok", Amount: core.Money{Cents: 100}, Primary: "Casa", Secondary: "Spesa"},
+		{Date: core.NewDate(2031, 4, 1), Description: "Senza importo", Primary: "Casa", Secondary: "Spesa"},
+	}); err == nil {
+		t.Error("batch with an invalid expense accepted")
+	}
+	if expenses, _ := adapter.ListExpenses(ctx, 2031, 4); len(expenses) != 0 {
+		t.Errorf("failed batch stored %d expenses", len(expenses))
+	}
+}
+
+func TestMemoryAdapter_Incomes(t *testing.T) {
+	ctx := context.Background()
+	adapter := NewMemoryAdapter(nil)
+
+	for _, i := range []core.Income{
+		{Date: core.NewDate(2031, 3, 27), Description: "Stipendio", Amount: core.Money{Cents: 120000}, Category: "Stipendio", Tags: []string{"Lavoro"}},
+		{Date: core.NewDate(2031, 3, 10), Description: "Bonus", Amount: core.Money{Cents: 30000}, Category: "Stipendio", Subcategory: "Bonus", Tags: []string{"lavoro", "extra"}},
+	} {
+		if _, err := adapter.AppendIncome(ctx, i); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	overview, err := adapter.ReadIncomeMonthOverview(ctx, 2031, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if overview.Total.Cents != 150000 || len(overview.ByCategory) != 1 || len(overview.BySubcategory) != 1 {
+		t.Errorf("income overview = %+v", overview)
+	}
+	if len(overview.ByTag) != 2 || overview.ByTag[0].Name != "lavoro" || overview.ByTag[0].Amount.Cents != 150000 {
+		t.Errorf("income by tag = %+v", overview.ByTag)
+	}
+
+	incomes, err := adapter.ListIncomesWithID(ctx, 2031, 3)
+	if err != nil || len(incomes) != 2 || incomes[0].Income.Description != "Stipendio" {
+		t.Fatalf("incomes = %+v, %v", incomes, err)
+	}
+	if err := adapter.DeleteIncome(ctx, incomes[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if incomes, _ := adapter.ListIncomesWithID(ctx, 2031, 3); len(incomes) != 1 {
+		t.Errorf("incomes after delete = %+v", incomes)
+	}
+}
@@ -31,6 +31,11 @@ func (a *SQLiteAdapter) Append(ctx context.Context, e core.Expense) (string, err
 	return a.service.CreateExpense(ctx, e)
 }
 
+// AppendBatch implements sheets.ExpenseBatchWriter
+func (a *SQLiteAdapter) AppendBatch(ctx context.Context, expenses []core.Expense) ([]string, error) {
+	return a.service.CreateExpenses(ctx, expenses)
+}
+
 // List implements sheets.TaxonomyReader
 func (a *SQLiteAdapter) List(ctx context.Context) ([]string, []string, error) {
 	return a.storage.List(ctx)
@@ -66,6 +71,16 @@ func (a *SQLiteAdapter) DeleteExpense(ctx context.Context, id string) error {
 	return a.service.DeleteExpense(ctx, expenseID)
 }
 
+// UpdateExpense replaces an expense, based on the version it was read at
+func (a *SQLiteAdapter) UpdateExpense(ctx context.Context, id string, e core.Expense, version int64) error {
+	expenseID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid expense ID: %w", err)
+	}
+
+	return a.service.UpdateExpense(ctx, expenseID, e, version)
+}
+
 // ListExpensesWithID implements sheets.ExpenseListerWithID
 func (a *SQLiteAdapter) ListExpensesWithID(ctx context.Context, year int, month int) ([]sheets.ExpenseWithID, error) {
 	storageExpenses, err := a.storage.ListExpensesWithID(ctx, year, month)
@@ -103,6 +118,11 @@ func (a *SQLiteAdapter) GetIncomeCategories(ctx context.Context) ([]string, erro
 	return a.storage.GetIncomeCategories(ctx)
 }
 
+// GetIncomeSubcategories returns the subcategories used for an income category
+func (a *SQLiteAdapter) GetIncomeSubcategories(ctx context.Context, category string) ([]string, error) {
+	return a.storage.GetIncomeSubcategories(ctx, category)
+}
+
 // ReadIncomeMonthOverview returns monthly income overview
 func (a *SQLiteAdapter) ReadIncomeMonthOverview(ctx context.Context, year int, month int) (core.IncomeMonthOverview, error) {
 	return a.storage.ReadIncomeMonthOverview(ctx, year, month)
@@ -118,13 +138,13 @@ func (a *SQLiteAdapter) ListIncomesWithID(ctx context.Context, year int, month i
 	return a.storage.ListIncomesWithID(ctx, year, month)
 }
 
-// DeleteIncome deletes an income entry
+// DeleteIncome moves an income entry to the trash
 func (a *SQLiteAdapter) DeleteIncome(ctx context.Context, id string) error {
 	incomeID, err := strconv.ParseInt(id, 10, 64)
 	if err != nil {
 		return fmt.Errorf("invalid income ID: %w", err)
 	}
-	return a.storage.HardDeleteIncome(ctx, incomeID)
+	return a.storage.TrashIncome(ctx, incomeID)
 }
 
 // Dashboard methods
@@ -299,11 +319,9 @@ func (a *SQLiteAdapter) GetExpenseTrend(ctx context.Context, period string) ([]T
 	return points, nil
 }
 
-// GetCategoryBreakdown returns expense totals by primary category for a given period
-func (a *SQLiteAdapter) GetCategoryBreakdown(ctx context.Context, period string) ([]CategoryTotal, error) {
-	now := time.Now()
-	var startDate time.Time
-
+// periodStart returns the first day of the dashboard period ("week",
+// "month", "quarter" or "year") containing now; unknown periods mean month.
+func periodStart(period string, now time.Time) time.Time {
 	switch period {
 	case "week":
 		// Current week (Monday to now)
@@ -311,19 +329,37 @@ func (a *SQLiteAdapter) GetCategoryBreakdown(ctx context.Context, period string)
 		if weekday == 0 {
 			weekday = 7 // Sunday = 7
 		}
-		startDate = time.Date(now.Year(), now.Month(), now.Day()-weekday+1, 0, 0, 0, 0, now.Location())
-	case "month":
-		// Current calendar month (1st of month to now)
-		startDate = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+		return time.Date(now.Year(), now.Month(), now.Day()-weekday+1, 0, 0, 0, 0, now.Location())
 	case "quarter":
 		// Current quarter (Q1: Jan-Mar, Q2: Apr-Jun, Q3: Jul-Sep, Q4: Oct-Dec)
 		quarterMonth := ((int(now.Month())-1)/3)*3 + 1
-		startDate = time.Date(now.Year(), time.Month(quarterMonth), 1, 0, 0, 0, 0, now.Location())
+		return time.Date(now.Year(), time.Month(quarterMonth), 1, 0, 0, 0, 0, now.Location())
 	case "year":
 		// Current calendar year (Jan 1 to now)
-		startDate = time.Date(now.Year(), 1, 1, 0, 0, 0, 0, now.Location())
+		return time.Date(now.Year(), 1, 1, 0, 0, 0, 0, now.Location())
 	default:
-		startDate = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+		// Current calendar month (1st of month to now)
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	}
+}
+
+// GetCategoryBreakdown returns expense totals by primary category for a given period
+func (a *SQLiteAdapter) GetCategoryBreakdown(ctx context.Context, period string) ([]CategoryTotal, error) {
+	now := time.Now()
+	startDate := periodStart(period, now)
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+
+	catMap := make(map[string]int64)
+	// Whole months before the current one come from the monthly aggregates
+	if startDate.Before(monthStart) {
+		totals, err := a.storage.ExpenseCategoryTotalsByMonths(ctx, startDate, monthStart.AddDate(0, -1, 0))
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range totals {
+			catMap[t.Name] += t.Amount.Cents
+		}
+		startDate = monthStart
 	}
 
 	expenses, err := a.storage.ListExpensesByDateRange(ctx, startDate, now)
@@ -332,7 +368,6 @@ func (a *SQLiteAdapter) GetCategoryBreakdown(ctx context.Context, period string)
 	}
 
 	// Group by category
-	catMap := make(map[string]int64)
 	for _, e := range expenses {
 		catMap[e.Primary] += e.Amount.Cents
 	}
@@ -358,6 +393,21 @@ func (a *SQLiteAdapter) GetCategoryBreakdown(ctx context.Context, period string)
 	return cats, nil
 }
 
+// GetPayerCategoryBreakdown returns the totals by primary category of the
+// expenses a household member paid in a given period
+func (a *SQLiteAdapter) GetPayerCategoryBreakdown(ctx context.Context, period string, userID int64) ([]CategoryTotal, error) {
+	now := time.Now()
+	totals, err := a.storage.PayerCategoryTotals(ctx, userID, periodStart(period, now), now)
+	if err != nil {
+		return nil, err
+	}
+	cats := make([]CategoryTotal, len(totals))
+	for i, t := range totals {
+		cats[i] = CategoryTotal{Name: t.Name, AmountCents: t.Amount.Cents}
+	}
+	return cats, nil
+}
+
 // ListIncomeCategories returns all income category names
 func (a *SQLiteAdapter) ListIncomeCategories(ctx context.Context) ([]string, error) {
 	return a.storage.GetIncomeCategories(ctx)
@@ -370,6 +420,7 @@ type RecurrentExpenseItem struct {
 	AmountCents int64
 	Category    string
 	Frequency   string
+	Interval    int // Units between occurrences
 }
 
 // RecurrentExpenseDetail represents a recurrent expense with full details for editing
@@ -380,8 +431,11 @@ type RecurrentExpenseDetail struct {
 	Category    string
 	Subcategory string
 	Frequency   string
+	Interval    int // Units between occurrences
+	DayOfMonth  int // Anchor day of monthly and yearly ones; 0 when not set
 	StartDate   string
 	EndDate     string
+	Version     int64
 }
 
 // GetActiveRecurrentExpenses returns all active recurrent expenses
@@ -399,6 +453,7 @@ func (a *SQLiteAdapter) GetActiveRecurrentExpenses(ctx context.Context) ([]Recur
 			AmountCents: e.Amount.Cents,
 			Category:    e.Primary,
 			Frequency:   string(e.Every),
+			Interval:    e.Step(),
 		})
 	}
 	return items, nil
@@ -418,7 +473,10 @@ func (a *SQLiteAdapter) GetRecurrentExpenseByID(ctx context.Context, id int64) (
 		Category:    expense.Primary,
 		Subcategory: expense.Secondary,
 		Frequency:   string(expense.Every),
+		Interval:    expense.Step(),
+		DayOfMonth:  expense.DayOfMonth,
 		StartDate:   formatDateForInput(expense.StartDate),
+		Version:     expense.Version,
 	}
 
 	if !expense.EndDate.IsZero() {
@@ -444,24 +502,36 @@ type YTDStats struct {
 func (a *SQLiteAdapter) GetYTDTotals(ctx context.Context) (*YTDStats, error) {
 	now := time.Now()
 	startOfYear := time.Date(now.Year(), 1, 1, 0, 0, 0, 0, now.Location())
+	startOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
 
-	// Get YTD expenses
-	expenses, err := a.storage.ListExpensesByDateRange(ctx, startOfYear, now)
+	// Get YTD expenses: the months before this one from the monthly
+	// aggregates, this one up to today from its entries
+	var totalExpenses int64
+	if now.Month() > time.January {
+		totals, err := a.storage.ExpenseCategoryTotalsByMonths(ctx, startOfYear, startOfMonth.AddDate(0, -1, 0))
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range totals {
+			totalExpenses += t.Amount.Cents
+		}
+	}
+	expenses, err := a.storage.ListExpensesByDateRange(ctx, startOfMonth, now)
 	if err != nil {
 		return nil, err
 	}
-	var totalExpenses int64
 	for _, e := range expenses {
 		totalExpenses += e.Amount.Cents
 	}
 
-	// Get YTD income - iterate through each month
+	// Get YTD income, this month whole
+	incomes, err := a.storage.IncomeCategoryTotalsByMonths(ctx, startOfYear, now)
+	if err != nil {
+		return nil, err
+	}
 	var totalIncome int64
-	for month := 1; month <= int(now.Month()); month++ {
-		overview, err := a.storage.ReadIncomeMonthOverview(ctx, now.Year(), month)
-		if err == nil {
-			totalIncome += overview.Total.Cents
-		}
+	for _, t := range incomes {
+		totalIncome += t.Amount.Cents
 	}
 
 	return &YTDStats{
@@ -550,7 +620,7 @@ func (a *SQLiteAdapter) GetDailyAverage(ctx context.Context) (*DailyAverage, err
 	daysElapsed := now.Day()
 	var averageCents int64
 	if daysElapsed > 0 {
-		averageCents = totalCents / int64(daysElapsed)
+		averageCents = core.DivRoundHalfEven(totalCents, int64(daysElapsed))
 	}
 
 	return &DailyAverage{
@@ -651,7 +721,8 @@ func (a *SQLiteAdapter) GetFixedVariableRatio(ctx context.Context) (*FixedVariab
 	}, nil
 }
 
-// GetRecurrentMonthlyTotal returns the total monthly cost of all active recurrent expenses
+// GetRecurrentMonthlyTotal returns the total monthly cost of all active recurrent expenses,
+// leaving out the paused ones
 func (a *SQLiteAdapter) GetRecurrentMonthlyTotal(ctx context.Context) int64 {
 	expenses, err := a.storage.GetRecurrentExpenses(ctx)
 	if err != nil {
@@ -660,16 +731,21 @@ func (a *SQLiteAdapter) GetRecurrentMonthlyTotal(ctx context.Context) int64 {
 
 	var totalMonthly int64
 	for _, e := range expenses {
+		if e.Paused {
+			continue
+		}
+		var monthly core.Money
 		switch e.Every {
 		case core.Monthly:
-			totalMonthly += e.Amount.Cents
+			monthly = e.Amount
 		case core.Yearly:
-			totalMonthly += e.Amount.Cents / 12
+			monthly = e.Amount.DivRound(12)
 		case core.Weekly:
-			totalMonthly += e.Amount.Cents * 4
+			monthly = core.Money{Cents: e.Amount.Cents * 4}
 		case core.Daily:
-			totalMonthly += e.Amount.Cents * 30
+			monthly = core.Money{Cents: e.Amount.Cents * 30}
 		}
+		totalMonthly += monthly.DivRound(int64(e.Step())).Cents
 	}
 	return totalMonthly
 }
@@ -695,8 +771,8 @@ func (a *SQLiteAdapter) GetMonthEndForecast(ctx context.Context) (*ForecastStats
 	// Simple forecast: (current total / days elapsed) * days in month
 	var forecastCents int64
 	if daysElapsed > 0 {
-		dailyAverage := currentTotal / int64(daysElapsed)
-		forecastCents = dailyAverage * int64(daysInMonth)
+		// Scale before dividing so the daily average is not truncated
+		forecastCents = core.DivRoundHalfEven(currentTotal*int64(daysInMonth), int64(daysElapsed))
 	}
 
 	return &ForecastStats{
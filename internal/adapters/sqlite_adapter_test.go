@@ -0,0 +1,185 @@
+package adapters
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"spese/internal/core"
+	"spese/internal/services"
+	"spese/internal/storage"
+)
+
+// Month queries must not mix in the same month of other years
+func TestSQLiteAdapter_MonthQueriesAreYearAware(t *testing.T) {
+	ctx := context.Background()
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	adapter := NewSQLiteAdapter(repo, services.NewExpenseService(repo))
+
+	for _, e := range []core.Expense{
+		{Date: core.NewDate(2030, 3, 5), Description: "Spesa 2030", Amount: core.Money{Cents: 1000}, Primary: "Casa", Secondary: "Spesa"},
+		{Date: core.NewDate(2031, 3, 5), Description: "Spesa 2031", Amount: core.Money{Cents: 2500}, Primary: "Casa", Secondary: "Spesa"},
+		{Date: core.NewDate(2032, 3, 5), Description: "Spesa 2032", Amount: core.Money{Cents: 4000}, Primary: "Trasporti", Secondary: "Treno"},
+	} {
+		if _, err := adapter.Append(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, i := range []core.Income{
+		{Date: core.NewDate(2030, 3, 27), Description: "Stipendio 2030", Amount: core.Money{Cents: 100000}, Category: "Stipendio"},
+		{Date: core.NewDate(2031, 3, 27), Description: "Stipendio 2031", Amount: core.Money{Cents: 120000}, Category: "Stipendio"},
+	} {
+		if _, err := adapter.AppendIncome(ctx, i); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	overview, err := adapter.ReadMonthOverview(ctx, 2031, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if overview.Total.Cents != 2500 || len(overview.ByCategory) != 1 || overview.ByCategory[0].Name != "Casa" {
+		t.Errorf("overview 2031-03 = %+v, want only the 2031 expense", overview)
+	}
+
+	expenses, err := adapter.ListExpenses(ctx, 2031, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expenses) != 1 || expenses[0].Description != "Spesa 2031" || expenses[0].Date.Year() != 2031 {
+		t.Errorf("expenses 2031-03 = %+v", expenses)
+	}
+	withID, err := adapter.ListExpensesWithID(ctx, 2032, 3)
+	if err != nil || len(withID) != 1 || withID[0].Expense.Description != "Spesa 2032" {
+		t.Errorf("expenses with ID 2032-03 = %+v, %v", withID, err)
+	}
+
+	if total, err := adapter.GetMonthlyExpenseTotal(ctx, 2030, 3); err != nil || total != 1000 {
+		t.Errorf("expense total 2030-03 = %d, %v, want 1000", total, err)
+	}
+	if total, err := adapter.GetMonthlyIncomeTotal(ctx, 2031, 3); err != nil || total != 120000 {
+		t.Errorf("income total 2031-03 = %d, %v, want 120000", total, err)
+	}
+
+	incomes, err := adapter.ListIncomes(ctx, 2030, 3)
+	if err != nil || len(incomes) != 1 || incomes[0].Description != "Stipendio 2030" {
+		t.Errorf("incomes 2030-03 = %+v, %v", incomes, err)
+	}
+	incomeOverview, err := adapter.ReadIncomeMonthOverview(ctx, 2032, 3)
+	if err != nil || incomeOverview.Total.Cents != 0 || len(incomeOverview.ByCategory) != 0 {
+		t.Errorf("income overview 2032-03 = %+v, %v, want empty", incomeOverview, err)
+	}
+}
+
+// The monthly aggregates the overviews read must follow every write
+func TestSQLiteAdapter_MonthlyAggregatesFollowWrites(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "spese.db")
+	repo, err := storage.NewSQLiteRepository(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	adapter := NewSQLiteAdapter(repo, services.NewExpenseService(repo))
+
+	var ids []int64
+	for _, e := range []core.Expense{
+		{Date: core.NewDate(2031, 6, 5), Description: "Spesa", Amount: core.Money{Cents: 1000}, Primary: "Casa", Secondary: "Spesa"},
+		{Date: core.NewDate(2031, 6, 10), Description: "Detersivo", Amount: core.Money{Cents: 500}, Primary: "Casa", Secondary: "Spesa"},
+		{Date: core.NewDate(2031, 6, 12), Description: "Treno", Amount: core.Money{Cents: 2000}, Primary: "Trasporti", Secondary: "Treno"},
+	} {
+		id, err := adapter.Append(ctx, e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		n, _ := strconv.ParseInt(id, 10, 64)
+		ids = append(ids, n)
+	}
+	check := func(step string, year, month int, want map[string]int64) {
+		t.Helper()
+		overview, err := adapter.ReadMonthOverview(ctx, year, month)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := map[string]int64{}
+		var total int64
+		for _, c := range overview.ByCategory {
+			got[c.Name] = c.Amount.Cents
+			total += c.Amount.Cents
+		}
+		if len(got) != len(want) || overview.Total.Cents != total {
+			t.Errorf("%s: %d-%02d = %+v, want %v", step, year, month, overview, want)
+			return
+		}
+		for name, cents := range want {
+			if got[name] != cents {
+				t.Errorf("%s: %d-%02d %s = %d, want %d", step, year, month, name, got[name], cents)
+			}
+		}
+	}
+	check("insert", 2031, 6, map[string]int64{"Casa": 1500, "Trasporti": 2000})
+
+	moved, err := repo.GetExpense(ctx, ids[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.UpdateExpense(ctx, ids[1], core.Expense{Date: core.NewDate(2031, 7, 1), Description: "Detersivo", Amount: core.Money{Cents: 700}, Primary: "Trasporti", Secondary: "Treno"}, moved.Version); err != nil {
+		t.Fatal(err)
+	}
+	check("update", 2031, 6, map[string]int64{"Casa": 1000, "Trasporti": 2000})
+	check("update", 2031, 7, map[string]int64{"Trasporti": 700})
+
+	if err := repo.TrashExpense(ctx, ids[0]); err != nil {
+		t.Fatal(err)
+	}
+	check("trash", 2031, 6, map[string]int64{"Trasporti": 2000})
+	if err := repo.RestoreExpense(ctx, ids[0]); err != nil {
+		t.Fatal(err)
+	}
+	check("restore", 2031, 6, map[string]int64{"Casa": 1000, "Trasporti": 2000})
+
+	if err := repo.TrashExpense(ctx, ids[2]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.PurgeTrash(ctx, time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	check("purge", 2031, 6, map[string]int64{"Casa": 1000})
+
+	if _, err := adapter.AppendIncome(ctx, core.Income{Date: core.NewDate(2031, 6, 27), Description: "Stipendio", Amount: core.Money{Cents: 120000}, Category: "Stipendio"}); err != nil {
+		t.Fatal(err)
+	}
+	if total, err := adapter.GetMonthlyIncomeTotal(ctx, 2031, 6); err != nil || total != 120000 {
+		t.Errorf("income total 2031-06 = %d, %v, want 120000", total, err)
+	}
+
+	if n, err := repo.RebuildMonthlyAggregates(ctx); err != nil || n != 0 {
+		t.Fatalf("rebuild after trigger writes = %d, %v, want 0", n, err)
+	}
+	// A write bypassing the triggers, as a restored file would
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec("UPDATE monthly_aggregates SET total_cents = 1 WHERE kind = 'expense' AND year = 2031 AND month = 7"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("DELETE FROM monthly_aggregates WHERE kind = 'income' AND year = 2031"); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := repo.RebuildMonthlyAggregates(ctx); err != nil || n != 2 {
+		t.Fatalf("rebuild after tampering = %d, %v, want 2", n, err)
+	}
+	check("rebuild", 2031, 7, map[string]int64{"Trasporti": 700})
+	if total, err := adapter.GetMonthlyIncomeTotal(ctx, 2031, 6); err != nil || total != 120000 {
+		t.Errorf("income total 2031-06 after rebuild = %d, %v, want 120000", total, err)
+	}
+}
@@ -0,0 +1,215 @@
+// Package classifier proposes expense categories with a multinomial naive
+// Bayes model trained on the user's own history. Everything runs in
+// process: descriptions never leave the machine.
+package classifier
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"spese/internal/core"
+)
+
+// MinExamples is the history size below which the model proposes nothing.
+const MinExamples = 5
+
+// smoothing is the pseudo-count of every word in every category. Below
+// one, a word seen a few times in a small category outweighs the size of
+// the large ones, which is what merchant names need.
+const smoothing = 0.1
+
+// Prediction is the most likely category for a description, with the
+// model's probability for it between 0 and 1.
+type Prediction struct {
+	Primary    string
+	Secondary  string
+	Confidence float64
+}
+
+// class is a category pair with its token counts.
+type class struct {
+	primary   string
+	secondary string
+	docs      int
+	tokens    int
+	counts    map[string]int
+}
+
+// Model is a trained naive Bayes model. It is immutable once built.
+type Model struct {
+	classes  []*class
+	vocab    map[string]bool
+	examples int
+}
+
+// Tokens splits a description into the words the model uses: lowercase,
+// at least two characters and not only digits, so card numbers and dates
+// do not count.
+func Tokens(description string) []string {
+	var tokens []string
+	for _, word := range strings.Fields(core.NormalizeKeyword(description)) {
+		if len([]rune(word)) < 2 || strings.IndexFunc(word, func(r rune) bool { return !unicode.IsDigit(r) }) < 0 {
+			continue
+		}
+		tokens = append(tokens, word)
+	}
+	return tokens
+}
+
+// Train builds a model from categorized expenses; only their description
+// and categories are used. Uncategorized expenses and descriptions without
+// usable words are ignored.
+func Train(examples []core.Expense) *Model {
+	m := &Model{vocab: make(map[string]bool)}
+	byKey := make(map[string]*class)
+	for _, e := range examples {
+		tokens := Tokens(e.Description)
+		if e.IsUncategorized() || len(tokens) == 0 {
+			continue
+		}
+		key := e.Primary + "\x00" + e.Secondary
+		c, ok := byKey[key]
+		if !ok {
+			c = &class{primary: e.Primary, secondary: e.Secondary, counts: make(map[string]int)}
+			byKey[key] = c
+			m.classes = append(m.classes, c)
+		}
+		c.docs++
+		for _, t := range tokens {
+			c.counts[t]++
+			c.tokens++
+			m.vocab[t] = true
+		}
+		m.examples++
+	}
+	// Stable order, so ties always resolve the same way
+	sort.Slice(m.classes, func(i, j int) bool {
+		if m.classes[i].primary != m.classes[j].primary {
+			return m.classes[i].primary < m.classes[j].primary
+		}
+		return m.classes[i].secondary < m.classes[j].secondary
+	})
+	return m
+}
+
+// Examples returns how many examples the model learned from.
+func (m *Model) Examples() int {
+	if m == nil {
+		return 0
+	}
+	return m.examples
+}
+
+// Predict returns the most likely category for description. It returns
+// false when the model is too small or knows none of the words.
+func (m *Model) Predict(description string) (Prediction, bool) {
+	if m.Examples() < MinExamples {
+		return Prediction{}, false
+	}
+
+	var known []string
+	for _, t := range Tokens(description) {
+		if m.vocab[t] {
+			known = append(known, t)
+		}
+	}
+	if len(known) == 0 {
+		return Prediction{}, false
+	}
+
+	// Log posteriors with additive smoothing
+	vocab := smoothing * float64(len(m.vocab))
+	scores := make([]float64, len(m.classes))
+	best := 0
+	for i, c := range m.classes {
+		score := math.Log(float64(c.docs) / float64(m.examples))
+		for _, t := range known {
+			score += math.Log((float64(c.counts[t]) + smoothing) / (float64(c.tokens) + vocab))
+		}
+		scores[i] = score
+		if score > scores[best] {
+			best = i
+		}
+	}
+
+	// Normalize to a probability, shifting by the best score so the
+	// exponentials cannot underflow to zero
+	var sum float64
+	for _, score := range scores {
+		sum += math.Exp(score - scores[best])
+	}
+	return Prediction{
+		Primary:    m.classes[best].primary,
+		Secondary:  m.classes[best].secondary,
+		Confidence: 1 / sum,
+	}, true
+}
+
+// Store provides the categorized history the model is trained on.
+type Store interface {
+	ListTrainingExpenses(ctx context.Context) ([]core.Expense, error)
+}
+
+// Classifier keeps a model trained on the store's history, retraining it
+// when it is older than maxAge or after Invalidate. A nil Classifier
+// predicts nothing.
+type Classifier struct {
+	store  Store
+	maxAge time.Duration
+
+	mu        sync.Mutex
+	model     *Model
+	trainedAt time.Time
+}
+
+// New creates a classifier over store's history. The model is trained on
+// first use.
+func New(store Store, maxAge time.Duration) *Classifier {
+	return &Classifier{store: store, maxAge: maxAge}
+}
+
+// Model returns the current model, training it if missing or stale.
+func (c *Classifier) Model(ctx context.Context) (*Model, error) {
+	if c == nil {
+		return nil, nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.model != nil && time.Since(c.trainedAt) < c.maxAge {
+		return c.model, nil
+	}
+	examples, err := c.store.ListTrainingExpenses(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.model = Train(examples)
+	c.trainedAt = time.Now()
+	return c.model, nil
+}
+
+// Predict proposes a category for description with the current model.
+func (c *Classifier) Predict(ctx context.Context, description string) (Prediction, bool, error) {
+	m, err := c.Model(ctx)
+	if err != nil {
+		return Prediction{}, false, err
+	}
+	p, ok := m.Predict(description)
+	return p, ok, nil
+}
+
+// Invalidate drops the model, so the next prediction learns from the
+// latest history, e.g. after the user taught a category.
+func (c *Classifier) Invalidate() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	c.model = nil
+	c.mu.Unlock()
+}
@@ -0,0 +1,107 @@
+package classifier
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"spese/internal/core"
+)
+
+var history = []core.Expense{
+	{Description: "Esselunga spesa settimanale", Primary: "Spesa", Secondary: "Supermercato"},
+	{Description: "Spesa Esselunga", Primary: "Spesa", Secondary: "Supermercato"},
+	{Description: "Carrefour Market", Primary: "Spesa", Secondary: "Supermercato"},
+	{Description: "Pizzeria da Gino", Primary: "Fuori", Secondary: "Ristoranti"},
+	{Description: "Cena pizzeria", Primary: "Fuori", Secondary: "Ristoranti"},
+	{Description: "Sushi cena", Primary: "Fuori", Secondary: "Ristoranti"},
+	{Description: "Benzina Eni", Primary: "Trasporti", Secondary: "Carburante"},
+	{Description: "Benzina Q8 autostrada", Primary: "Trasporti", Secondary: "Carburante"},
+	{Description: "", Primary: "Spesa", Secondary: "Supermercato"}, // no words: ignored
+	{Description: "Regalo", Primary: "", Secondary: ""},            // no category: ignored
+}
+
+func TestTokens(t *testing.T) {
+	got := Tokens("POS 1234 ESSELUNGA 05/03 Milano - A")
+	want := []string{"pos", "esselunga", "milano"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Tokens = %q, want %q", got, want)
+	}
+}
+
+func TestModelPredict(t *testing.T) {
+	m := Train(history)
+	if m.Examples() != 8 {
+		t.Fatalf("Examples = %d, want 8", m.Examples())
+	}
+
+	cases := []struct {
+		desc, secondary string
+	}{
+		{"ESSELUNGA Viale Piave", "Supermercato"},
+		{"Cena in pizzeria", "Ristoranti"},
+		{"Benzina", "Carburante"},
+	}
+	for _, c := range cases {
+		p, ok := m.Predict(c.desc)
+		if !ok || p.Secondary != c.secondary {
+			t.Errorf("Predict(%q) = %+v, %v, want %s", c.desc, p, ok, c.secondary)
+		}
+		if p.Confidence <= 0.5 || p.Confidence > 1 {
+			t.Errorf("Predict(%q) confidence = %v", c.desc, p.Confidence)
+		}
+	}
+
+	// Words never seen give no prediction
+	if p, ok := m.Predict("Farmacia comunale"); ok {
+		t.Errorf("unknown words: got %+v", p)
+	}
+
+	// Too little history gives no prediction
+	if _, ok := Train(history[:3]).Predict("Esselunga"); ok {
+		t.Error("small model should not predict")
+	}
+	var none *Model
+	if _, ok := none.Predict("Esselunga"); ok {
+		t.Error("nil model should not predict")
+	}
+}
+
+type fakeStore struct {
+	examples []core.Expense
+	calls    int
+}
+
+func (f *fakeStore) ListTrainingExpenses(context.Context) ([]core.Expense, error) {
+	f.calls++
+	return f.examples, nil
+}
+
+func TestClassifierRetrains(t *testing.T) {
+	store := &fakeStore{examples: history}
+	c := New(store, time.Hour)
+	ctx := context.Background()
+
+	if _, ok, err := c.Predict(ctx, "Esselunga"); err != nil || !ok {
+		t.Fatalf("Predict: %v, %v", ok, err)
+	}
+	if _, _, _ = c.Predict(ctx, "Pizzeria"); store.calls != 1 {
+		t.Errorf("model trained %d times, want 1", store.calls)
+	}
+
+	// Teaching a new merchant is picked up after Invalidate
+	store.examples = append(store.examples,
+		core.Expense{Description: "Farmacia comunale", Primary: "Salute", Secondary: "Medicine"},
+		core.Expense{Description: "Farmacia Garibaldi", Primary: "Salute", Secondary: "Medicine"})
+	c.Invalidate()
+	p, ok, _ := c.Predict(ctx, "farmacia")
+	if !ok || p.Secondary != "Medicine" || store.calls != 2 {
+		t.Errorf("after Invalidate: %+v, %v, calls = %d", p, ok, store.calls)
+	}
+
+	var none *Classifier
+	if _, ok, err := none.Predict(ctx, "Esselunga"); ok || err != nil {
+		t.Errorf("nil classifier: %v, %v", ok, err)
+	}
+}
@@ -2,12 +2,19 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 	"slices"
 	"strconv"
 	"strings"
 	"time"
+
+	"spese/internal/core"
+	"spese/internal/httpclient"
+	"spese/internal/tracing"
+
+	"golang.org/x/crypto/bcrypt"
 )
 
 type Config struct {
@@ -22,16 +29,185 @@ type Config struct {
 	GoogleSheetName          string
 	GoogleServiceAccountFile string
 	GoogleServiceAccountJSON string
+	// Spreadsheet the sync worker writes to instead of GoogleSpreadsheetID,
+	// to test sync changes without touching the real sheet
+	GoogleSandboxSpreadsheetID string
+	// Client-side budget of Sheets API writes, shared by every write of
+	// the process; a limit of 0 disables it
+	GoogleWriteLimit  int
+	GoogleWriteWindow time.Duration
+	// Retries of the Sheets API requests refused with 429 or 503, with
+	// exponential backoff from the base delay up to the max delay; 0
+	// retries disables them
+	GoogleRetryMax       int
+	GoogleRetryBaseDelay time.Duration
+	GoogleRetryMaxDelay  time.Duration
+
+	// Copy the expenses sheets of past years into SQLite at startup, once
+	// per year
+	SheetsHistoryImport bool
 
 	// Worker
-	SyncBatchSize int
-	SyncInterval  time.Duration
+	SyncBatchSize   int
+	SyncInterval    time.Duration
+	SyncMaxAttempts int // Attempts of a sync item before it is dead-lettered
+	// How often rows edited or added in the sheet are pulled back; 0 disables
+	SyncPullInterval time.Duration
 
 	// Recurring Processor
 	RecurringProcessorInterval time.Duration
 
+	// Background jobs: runs at once, and attempts of a failing run
+	JobWorkers     int
+	JobMaxAttempts int
+
 	// Backend selection
 	DataBackend string
+
+	// Login of the web UI: a bcrypt hash of the password, or the password
+	// itself; both empty leave the UI open. Sessions are signed cookies
+	// lasting AuthSessionTTL; replicas need the same AuthSessionSecret.
+	AuthPassword      string
+	AuthPasswordHash  string
+	AuthSessionSecret string
+	AuthSessionTTL    time.Duration
+	AuthCookieSecure  bool
+	// Paths reachable without logging in (health probes, metrics scraping)
+	AuthPublicPaths []string
+
+	// Companion app WebSocket (/ws); empty disables it
+	WSToken string
+
+	// Optional gRPC listener (e.g. ":9090"); empty disables it
+	GRPCAddr  string
+	GRPCToken string
+
+	// User hook commands, keyed by hook point; empty values are disabled
+	Hooks       map[string]string
+	HookTimeout time.Duration
+
+	// Key for anonymized export hashes; empty uses a random key per export
+	ExportHashKey string
+
+	// Scheduled Parquet export (SQLite backend); empty dir disables it
+	ParquetExportDir      string
+	ParquetExportInterval time.Duration
+
+	// Read-only public demo with seeded fake data, reset nightly
+	DemoMode bool
+
+	// Peer sync with another instance (SQLite backend). The token enables
+	// /peer/changes; the URL makes this instance sync with that peer.
+	PeerToken        string
+	PeerURL          string
+	PeerSyncInterval time.Duration
+
+	// Replication layer the database runs under ("", "litestream" or
+	// "litefs"). LiteFSDir is the LiteFS mount, defaulting to the database
+	// directory; replicas forward writes to ReplicationPrimaryURL, or to
+	// the host LiteFS advertises when empty.
+	ReplicationMode       string
+	LiteFSDir             string
+	ReplicationPrimaryURL string
+	ReplicationMaxLag     time.Duration
+
+	// Read-only copies of the SQLite database serving reads, and how long
+	// after a write a session keeps reading from the primary
+	SQLiteReadReplicas   []string
+	ReadYourWritesWindow time.Duration
+
+	// Subtract the amounts linked to reimbursement incomes from the month
+	// total and category totals (SQLite backend)
+	ExcludeReimbursed bool
+
+	// Leave pending card holds out of the month total and category totals
+	// (SQLite backend), set by INCLUDE_PENDING_IN_TOTALS=false
+	ExcludePending bool
+
+	// Count expense line items under their own primary categories in the
+	// category totals (SQLite backend)
+	LineItemCategories bool
+
+	// Business approval workflow (SQLite backend). Requests carrying the
+	// approver token as a bearer token can approve and reimburse expenses.
+	WorkflowEnabled       bool
+	WorkflowApproverToken string
+
+	// Rates of the mileage (per km) and per-diem (per day) calculators, in
+	// cents; zero disables a calculator
+	MileageRateCents int64
+	PerDiemRateCents int64
+
+	// Days before a purchase's return deadline the reminder goes out
+	ReturnReminderDays int
+
+	// Days the audit log keeps a change (SQLite backend); 0 keeps them
+	// forever
+	AuditRetentionDays int
+
+	// Days a deleted expense or income stays in the trash before it is
+	// deleted for good, from Google Sheets too (SQLite backend); 0 keeps
+	// them forever
+	TrashRetentionDays int
+
+	// Time between runs of the SQLite maintenance: WAL checkpoint, vacuum
+	// and ANALYZE; 0 disables it
+	DBMaintenanceInterval time.Duration
+
+	// Whether the read-only SQL console at /admin/sql is enabled (SQLite
+	// backend, login required)
+	SQLConsoleEnabled bool
+
+	// Quotas of the members calling the API with their keys (SQLite
+	// backend): expenses created per day and megabytes of receipts stored,
+	// 0 for no limit. Members can have their own. Past QuotaSoftPercent
+	// of a quota usage is logged and flagged; 0 disables the warning.
+	QuotaDailyExpenses int
+	QuotaAttachmentMB  int
+	QuotaSoftPercent   int
+
+	// Secondary category whose expenses make up the vehicle cost center
+	VehicleCategory string
+
+	// Hour before which the entry forms still default to the previous day
+	// (0 disables), and whether the expense form offers a selector for the
+	// current and previous year
+	DayStartHour  int
+	YearSelection bool
+
+	// Local categorization model trained on the expense history, and the
+	// confidence (percent) its proposals need to be applied automatically
+	ClassifierEnabled       bool
+	ClassifierMinConfidence int
+
+	// Language model reading receipts and statement lines ("", "openai"
+	// or "ollama"); empty disables it. The base URL and model default per
+	// provider.
+	LLMProvider string
+	LLMBaseURL  string
+	LLMAPIKey   string
+	LLMModel    string
+	LLMTimeout  time.Duration
+
+	// Storage of receipt photos and PDFs (SQLite backend): "local" keeps
+	// them under ReceiptsDir, "s3" in a bucket of an S3-compatible service.
+	// Uploads larger than ReceiptsMaxMB megabytes are refused.
+	ReceiptsStorage     string
+	ReceiptsDir         string
+	ReceiptsMaxMB       int
+	ReceiptsS3Endpoint  string
+	ReceiptsS3Bucket    string
+	ReceiptsS3Region    string
+	ReceiptsS3AccessKey string
+	ReceiptsS3SecretKey string
+
+	// PEM file of CA certificates trusted by outbound calls besides the
+	// system ones. The proxy comes from HTTPS_PROXY, HTTP_PROXY, NO_PROXY.
+	OutboundCAFile string
+
+	// Where spans go: "otlp" (OTLP over HTTP, configured by the standard
+	// OTEL_EXPORTER_OTLP_* variables), "console" or "none"
+	TracesExporter string
 }
 
 func Load() *Config {
@@ -44,12 +220,114 @@ func Load() *Config {
 		GoogleServiceAccountFile: getEnv("GOOGLE_SERVICE_ACCOUNT_FILE", ""),
 		GoogleServiceAccountJSON: getEnv("GOOGLE_SERVICE_ACCOUNT_JSON", ""),
 
-		SyncBatchSize: getEnvInt("SYNC_BATCH_SIZE", 10),
-		SyncInterval:  getEnvDuration("SYNC_INTERVAL", 30*time.Second),
+		GoogleSandboxSpreadsheetID: getEnv("GOOGLE_SANDBOX_SPREADSHEET_ID", ""),
+		GoogleWriteLimit:           getEnvInt("GOOGLE_WRITE_LIMIT", 50),
+		GoogleWriteWindow:          getEnvDuration("GOOGLE_WRITE_WINDOW", 100*time.Second),
+		GoogleRetryMax:             getEnvInt("GOOGLE_RETRY_MAX", 3),
+		GoogleRetryBaseDelay:       getEnvDuration("GOOGLE_RETRY_BASE_DELAY", time.Second),
+		GoogleRetryMaxDelay:        getEnvDuration("GOOGLE_RETRY_MAX_DELAY", 30*time.Second),
+
+		SheetsHistoryImport: getEnvBool("SHEETS_HISTORY_IMPORT", false),
+
+		SyncBatchSize:   getEnvInt("SYNC_BATCH_SIZE", 10),
+		SyncInterval:    getEnvDuration("SYNC_INTERVAL", 30*time.Second),
+		SyncMaxAttempts: getEnvInt("SYNC_MAX_ATTEMPTS", 3),
+
+		SyncPullInterval: getEnvDuration("SYNC_PULL_INTERVAL", 0),
 
 		RecurringProcessorInterval: getEnvDuration("RECURRING_PROCESSOR_INTERVAL", 1*time.Hour),
 
+		JobWorkers:     getEnvInt("JOB_WORKERS", 2),
+		JobMaxAttempts: getEnvInt("JOB_MAX_ATTEMPTS", 3),
+
 		DataBackend: getEnv("DATA_BACKEND", "sqlite"),
+
+		AuthPassword:      getEnv("AUTH_PASSWORD", ""),
+		AuthPasswordHash:  getEnv("AUTH_PASSWORD_HASH", ""),
+		AuthSessionSecret: getEnv("AUTH_SESSION_SECRET", ""),
+		AuthSessionTTL:    getEnvDuration("AUTH_SESSION_TTL", 30*24*time.Hour),
+		AuthCookieSecure:  getEnvBool("AUTH_COOKIE_SECURE", true),
+		AuthPublicPaths:   getEnvListDefault("AUTH_PUBLIC_PATHS", []string{"/healthz", "/readyz", "/metrics"}),
+
+		WSToken: getEnv("WS_TOKEN", ""),
+
+		GRPCAddr:  getEnv("GRPC_ADDR", ""),
+		GRPCToken: getEnv("GRPC_TOKEN", ""),
+
+		Hooks: map[string]string{
+			"before_expense_save": getEnv("HOOK_BEFORE_EXPENSE_SAVE", ""),
+			"after_expense_save":  getEnv("HOOK_AFTER_EXPENSE_SAVE", ""),
+			"after_import":        getEnv("HOOK_AFTER_IMPORT", ""),
+		},
+		HookTimeout: getEnvDuration("HOOK_TIMEOUT", 5*time.Second),
+
+		ExportHashKey: getEnv("EXPORT_HASH_KEY", ""),
+
+		ParquetExportDir:      getEnv("PARQUET_EXPORT_DIR", ""),
+		ParquetExportInterval: getEnvDuration("PARQUET_EXPORT_INTERVAL", 24*time.Hour),
+
+		DemoMode: getEnvBool("DEMO_MODE", false),
+
+		PeerToken:        getEnv("PEER_TOKEN", ""),
+		PeerURL:          getEnv("PEER_URL", ""),
+		PeerSyncInterval: getEnvDuration("PEER_SYNC_INTERVAL", 5*time.Minute),
+
+		ReplicationMode:       getEnv("REPLICATION_MODE", ""),
+		LiteFSDir:             getEnv("LITEFS_DIR", ""),
+		ReplicationPrimaryURL: getEnv("REPLICATION_PRIMARY_URL", ""),
+		ReplicationMaxLag:     getEnvDuration("REPLICATION_MAX_LAG", 30*time.Second),
+
+		SQLiteReadReplicas:   getEnvList("SQLITE_READ_REPLICAS"),
+		ReadYourWritesWindow: getEnvDuration("READ_YOUR_WRITES_WINDOW", 10*time.Second),
+
+		ExcludeReimbursed:  getEnvBool("EXCLUDE_REIMBURSED_FROM_TOTALS", false),
+		ExcludePending:     !getEnvBool("INCLUDE_PENDING_IN_TOTALS", true),
+		LineItemCategories: getEnvBool("LINE_ITEM_CATEGORIES", false),
+
+		WorkflowEnabled:       getEnvBool("WORKFLOW_ENABLED", false),
+		WorkflowApproverToken: getEnv("WORKFLOW_APPROVER_TOKEN", ""),
+
+		MileageRateCents: getEnvCents("MILEAGE_RATE", 42),
+		PerDiemRateCents: getEnvCents("PER_DIEM_RATE", 4648),
+
+		ReturnReminderDays: getEnvInt("RETURN_REMINDER_DAYS", 3),
+
+		AuditRetentionDays: getEnvInt("AUDIT_RETENTION_DAYS", 365),
+		TrashRetentionDays: getEnvInt("TRASH_RETENTION_DAYS", 30),
+
+		DBMaintenanceInterval: getEnvDuration("DB_MAINTENANCE_INTERVAL", 24*time.Hour),
+		SQLConsoleEnabled:     getEnvBool("SQL_CONSOLE_ENABLED", false),
+
+		QuotaDailyExpenses: getEnvInt("QUOTA_DAILY_EXPENSES", 0),
+		QuotaAttachmentMB:  getEnvInt("QUOTA_ATTACHMENT_MB", 0),
+		QuotaSoftPercent:   getEnvInt("QUOTA_SOFT_PERCENT", 80),
+
+		VehicleCategory: getEnv("VEHICLE_CATEGORY", "Spese automobile"),
+
+		DayStartHour:  getEnvInt("DAY_START_HOUR", 0),
+		YearSelection: getEnvBool("YEAR_SELECTION", false),
+
+		ClassifierEnabled:       getEnvBool("CLASSIFIER_ENABLED", false),
+		ClassifierMinConfidence: getEnvInt("CLASSIFIER_MIN_CONFIDENCE", 60),
+
+		LLMProvider: getEnv("LLM_PROVIDER", ""),
+		LLMBaseURL:  getEnv("LLM_BASE_URL", ""),
+		LLMAPIKey:   getEnv("LLM_API_KEY", ""),
+		LLMModel:    getEnv("LLM_MODEL", ""),
+		LLMTimeout:  getEnvDuration("LLM_TIMEOUT", 30*time.Second),
+
+		ReceiptsStorage:     getEnv("RECEIPTS_STORAGE", "local"),
+		ReceiptsDir:         getEnv("RECEIPTS_DIR", "./data/receipts"),
+		ReceiptsMaxMB:       getEnvInt("RECEIPTS_MAX_MB", 10),
+		ReceiptsS3Endpoint:  getEnv("RECEIPTS_S3_ENDPOINT", ""),
+		ReceiptsS3Bucket:    getEnv("RECEIPTS_S3_BUCKET", ""),
+		ReceiptsS3Region:    getEnv("RECEIPTS_S3_REGION", "us-east-1"),
+		ReceiptsS3AccessKey: getEnv("RECEIPTS_S3_ACCESS_KEY", ""),
+		ReceiptsS3SecretKey: getEnv("RECEIPTS_S3_SECRET_KEY", ""),
+
+		OutboundCAFile: getEnv("OUTBOUND_CA_FILE", ""),
+
+		TracesExporter: getEnv("OTEL_TRACES_EXPORTER", tracing.ExporterNone),
 	}
 
 	return cfg
@@ -67,7 +345,7 @@ func (c *Config) Validate() error {
 	}
 
 	// Validate data backend
-	validBackends := []string{"sheets", "sqlite"}
+	validBackends := []string{"memory", "sheets", "sqlite"}
 	isValidBackend := slices.Contains(validBackends, c.DataBackend)
 	if !isValidBackend {
 		errors = append(errors, fmt.Sprintf("invalid data backend '%s': must be one of %v", c.DataBackend, validBackends))
@@ -124,6 +402,33 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	if c.GoogleWriteLimit < 0 {
+		errors = append(errors, fmt.Sprintf("GOOGLE_WRITE_LIMIT must not be negative, got %d", c.GoogleWriteLimit))
+	}
+	if c.GoogleWriteLimit > 0 && c.GoogleWriteWindow <= 0 {
+		errors = append(errors, "GOOGLE_WRITE_WINDOW must be positive when GOOGLE_WRITE_LIMIT is set")
+	}
+	if c.TracesExporter != "" && !slices.Contains(tracing.Exporters, c.TracesExporter) {
+		errors = append(errors, fmt.Sprintf("invalid OTEL_TRACES_EXPORTER '%s': must be one of %s", c.TracesExporter, strings.Join(tracing.Exporters, ", ")))
+	}
+	if c.GoogleRetryMax < 0 {
+		errors = append(errors, fmt.Sprintf("GOOGLE_RETRY_MAX must not be negative, got %d", c.GoogleRetryMax))
+	}
+	if c.GoogleRetryMax > 0 && (c.GoogleRetryBaseDelay <= 0 || c.GoogleRetryMaxDelay < c.GoogleRetryBaseDelay) {
+		errors = append(errors, "GOOGLE_RETRY_BASE_DELAY must be positive and at most GOOGLE_RETRY_MAX_DELAY when GOOGLE_RETRY_MAX is set")
+	}
+
+	// The sandbox only redirects the sync worker, which the sheets backend
+	// does not have: its pages write to the spreadsheet directly
+	if c.GoogleSandboxSpreadsheetID != "" {
+		if c.DataBackend != "sqlite" {
+			errors = append(errors, "GOOGLE_SANDBOX_SPREADSHEET_ID requires the sqlite backend")
+		}
+		if c.GoogleSandboxSpreadsheetID == c.GoogleSpreadsheetID {
+			errors = append(errors, "GOOGLE_SANDBOX_SPREADSHEET_ID must differ from GOOGLE_SPREADSHEET_ID")
+		}
+	}
+
 	// Validate worker configuration
 	if c.SyncBatchSize < 1 {
 		errors = append(errors, fmt.Sprintf("invalid sync batch size %d: must be at least 1", c.SyncBatchSize))
@@ -131,12 +436,20 @@ func (c *Config) Validate() error {
 		errors = append(errors, fmt.Sprintf("invalid sync batch size %d: must be at most 1000", c.SyncBatchSize))
 	}
 
+	if c.SyncMaxAttempts < 0 || c.SyncMaxAttempts > 20 {
+		errors = append(errors, fmt.Sprintf("invalid SYNC_MAX_ATTEMPTS %d: must be between 1 and 20", c.SyncMaxAttempts))
+	}
+
 	if c.SyncInterval < time.Second {
 		errors = append(errors, fmt.Sprintf("invalid sync interval %v: must be at least 1 second", c.SyncInterval))
 	} else if c.SyncInterval > 24*time.Hour {
 		errors = append(errors, fmt.Sprintf("invalid sync interval %v: must be at most 24 hours", c.SyncInterval))
 	}
 
+	if c.SyncPullInterval != 0 && c.SyncPullInterval < time.Minute {
+		errors = append(errors, fmt.Sprintf("invalid SYNC_PULL_INTERVAL %v: must be 0 or at least 1 minute", c.SyncPullInterval))
+	}
+
 	// Validate recurring processor configuration
 	if c.RecurringProcessorInterval < time.Minute {
 		errors = append(errors, fmt.Sprintf("invalid recurring processor interval %v: must be at least 1 minute", c.RecurringProcessorInterval))
@@ -144,6 +457,209 @@ func (c *Config) Validate() error {
 		errors = append(errors, fmt.Sprintf("invalid recurring processor interval %v: must be at most 7 days", c.RecurringProcessorInterval))
 	}
 
+	// Zero keeps the job runner defaults
+	if c.JobWorkers < 0 || c.JobWorkers > 16 {
+		errors = append(errors, fmt.Sprintf("invalid JOB_WORKERS %d: must be between 1 and 16", c.JobWorkers))
+	}
+	if c.JobMaxAttempts < 0 || c.JobMaxAttempts > 10 {
+		errors = append(errors, fmt.Sprintf("invalid JOB_MAX_ATTEMPTS %d: must be between 1 and 10", c.JobMaxAttempts))
+	}
+
+	if c.ParquetExportDir != "" && c.ParquetExportInterval < time.Minute {
+		errors = append(errors, fmt.Sprintf("invalid parquet export interval %v: must be at least 1 minute", c.ParquetExportInterval))
+	}
+
+	// The gRPC API can write data, so it is never exposed without a token
+	if c.GRPCAddr != "" && c.GRPCToken == "" {
+		errors = append(errors, "GRPC_TOKEN is required when GRPC_ADDR is set")
+	}
+
+	// The demo wipes its database and must never reach real data or APIs
+	if c.DemoMode {
+		if c.DataBackend != "sqlite" && c.DataBackend != "memory" {
+			errors = append(errors, "DEMO_MODE requires the sqlite or memory backend")
+		}
+		if c.GRPCAddr != "" {
+			errors = append(errors, "GRPC_ADDR cannot be used with DEMO_MODE")
+		}
+		if c.PeerToken != "" {
+			errors = append(errors, "PEER_TOKEN cannot be used with DEMO_MODE")
+		}
+		if c.ReplicationMode != "" {
+			errors = append(errors, "REPLICATION_MODE cannot be used with DEMO_MODE")
+		}
+		if c.LLMProvider != "" {
+			errors = append(errors, "LLM_PROVIDER cannot be used with DEMO_MODE")
+		}
+	}
+
+	if c.PeerURL != "" {
+		if c.PeerToken == "" {
+			errors = append(errors, "PEER_TOKEN is required when PEER_URL is set")
+		}
+		if u, err := url.Parse(c.PeerURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			errors = append(errors, fmt.Sprintf("invalid PEER_URL %q: must be an http(s) URL", c.PeerURL))
+		}
+		if c.PeerSyncInterval < time.Minute {
+			errors = append(errors, fmt.Sprintf("invalid peer sync interval %v: must be at least 1 minute", c.PeerSyncInterval))
+		}
+	}
+	if c.PeerToken != "" && c.DataBackend != "sqlite" {
+		errors = append(errors, "peer sync requires the sqlite backend")
+	}
+
+	if c.ReplicationMode != "" {
+		if c.ReplicationMode != "litestream" && c.ReplicationMode != "litefs" {
+			errors = append(errors, fmt.Sprintf("invalid REPLICATION_MODE %q: must be litestream or litefs", c.ReplicationMode))
+		}
+		if c.DataBackend != "sqlite" {
+			errors = append(errors, "REPLICATION_MODE requires the sqlite backend")
+		}
+		if c.ReplicationPrimaryURL != "" {
+			if u, err := url.Parse(c.ReplicationPrimaryURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+				errors = append(errors, fmt.Sprintf("invalid REPLICATION_PRIMARY_URL %q: must be an http(s) URL", c.ReplicationPrimaryURL))
+			}
+		}
+	}
+
+	if len(c.SQLiteReadReplicas) > 0 && c.DataBackend != "sqlite" {
+		errors = append(errors, "SQLITE_READ_REPLICAS requires the sqlite backend")
+	}
+	for _, path := range c.SQLiteReadReplicas {
+		if filepath.Clean(path) == filepath.Clean(c.SQLiteDBPath) {
+			errors = append(errors, fmt.Sprintf("read replica %q is the primary database", path))
+		}
+	}
+	if c.ReadYourWritesWindow < 0 {
+		errors = append(errors, fmt.Sprintf("invalid READ_YOUR_WRITES_WINDOW %v: cannot be negative", c.ReadYourWritesWindow))
+	}
+	if c.ExcludeReimbursed && c.DataBackend != "sqlite" {
+		errors = append(errors, "EXCLUDE_REIMBURSED_FROM_TOTALS requires the sqlite backend")
+	}
+	if c.ExcludePending && c.DataBackend != "sqlite" {
+		errors = append(errors, "INCLUDE_PENDING_IN_TOTALS=false requires the sqlite backend")
+	}
+	if c.LineItemCategories && c.DataBackend != "sqlite" {
+		errors = append(errors, "LINE_ITEM_CATEGORIES requires the sqlite backend")
+	}
+	if c.ReturnReminderDays < 0 || c.ReturnReminderDays > 60 {
+		errors = append(errors, fmt.Sprintf("invalid RETURN_REMINDER_DAYS %d: must be between 0 and 60", c.ReturnReminderDays))
+	}
+	if c.AuditRetentionDays < 0 {
+		errors = append(errors, fmt.Sprintf("invalid AUDIT_RETENTION_DAYS %d: must not be negative", c.AuditRetentionDays))
+	}
+	if c.TrashRetentionDays < 0 {
+		errors = append(errors, fmt.Sprintf("invalid TRASH_RETENTION_DAYS %d: must not be negative", c.TrashRetentionDays))
+	}
+	if c.DBMaintenanceInterval != 0 && c.DBMaintenanceInterval < time.Minute {
+		errors = append(errors, fmt.Sprintf("invalid DB_MAINTENANCE_INTERVAL %v: must be 0 or at least 1 minute", c.DBMaintenanceInterval))
+	}
+	if c.DayStartHour < 0 || c.DayStartHour > 6 {
+		errors = append(errors, fmt.Sprintf("invalid DAY_START_HOUR %d: must be between 0 and 6", c.DayStartHour))
+	}
+	if c.ClassifierEnabled {
+		if c.DataBackend != "sqlite" {
+			errors = append(errors, "CLASSIFIER_ENABLED requires the sqlite backend")
+		}
+		if c.ClassifierMinConfidence < 1 || c.ClassifierMinConfidence > 100 {
+			errors = append(errors, fmt.Sprintf("invalid CLASSIFIER_MIN_CONFIDENCE %d: must be between 1 and 100", c.ClassifierMinConfidence))
+		}
+	}
+	if c.LLMProvider != "" {
+		if c.LLMProvider != "openai" && c.LLMProvider != "ollama" {
+			errors = append(errors, fmt.Sprintf("invalid LLM_PROVIDER %q: must be openai or ollama", c.LLMProvider))
+		}
+		if c.LLMBaseURL != "" {
+			if u, err := url.Parse(c.LLMBaseURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+				errors = append(errors, fmt.Sprintf("invalid LLM_BASE_URL %q: must be an http(s) URL", c.LLMBaseURL))
+			}
+		}
+		// The OpenAI API needs a key; compatible local servers may not
+		if c.LLMProvider == "openai" && c.LLMBaseURL == "" && c.LLMAPIKey == "" {
+			errors = append(errors, "LLM_PROVIDER=openai requires LLM_API_KEY")
+		}
+		if c.LLMTimeout < time.Second {
+			errors = append(errors, fmt.Sprintf("invalid LLM_TIMEOUT %v: must be at least 1 second", c.LLMTimeout))
+		}
+	}
+	switch c.ReceiptsStorage {
+	case "":
+	case "local":
+		if c.ReceiptsDir == "" {
+			errors = append(errors, "RECEIPTS_DIR cannot be empty with RECEIPTS_STORAGE=local")
+		}
+	case "s3":
+		if u, err := url.Parse(c.ReceiptsS3Endpoint); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			errors = append(errors, fmt.Sprintf("invalid RECEIPTS_S3_ENDPOINT %q: must be an http(s) URL", c.ReceiptsS3Endpoint))
+		}
+		if c.ReceiptsS3Bucket == "" || c.ReceiptsS3AccessKey == "" || c.ReceiptsS3SecretKey == "" {
+			errors = append(errors, "RECEIPTS_STORAGE=s3 requires RECEIPTS_S3_BUCKET, RECEIPTS_S3_ACCESS_KEY and RECEIPTS_S3_SECRET_KEY")
+		}
+	default:
+		errors = append(errors, fmt.Sprintf("invalid RECEIPTS_STORAGE %q: must be local or s3", c.ReceiptsStorage))
+	}
+	if c.ReceiptsStorage != "" && c.ReceiptsMaxMB < 1 {
+		errors = append(errors, fmt.Sprintf("invalid RECEIPTS_MAX_MB %d: must be at least 1", c.ReceiptsMaxMB))
+	}
+	if c.OutboundCAFile != "" {
+		if _, err := httpclient.LoadCAFile(c.OutboundCAFile); err != nil {
+			errors = append(errors, fmt.Sprintf("invalid OUTBOUND_CA_FILE: %v", err))
+		}
+	}
+	if c.AuthPassword != "" && c.AuthPasswordHash != "" {
+		errors = append(errors, "set only one of AUTH_PASSWORD and AUTH_PASSWORD_HASH")
+	}
+	if c.AuthPasswordHash != "" {
+		if _, err := bcrypt.Cost([]byte(c.AuthPasswordHash)); err != nil {
+			errors = append(errors, "invalid AUTH_PASSWORD_HASH: must be a bcrypt hash")
+		}
+	}
+	if c.AuthEnabled() {
+		if c.AuthSessionTTL < time.Minute {
+			errors = append(errors, fmt.Sprintf("invalid AUTH_SESSION_TTL %v: must be at least 1 minute", c.AuthSessionTTL))
+		}
+		for _, p := range c.AuthPublicPaths {
+			if !strings.HasPrefix(p, "/") {
+				errors = append(errors, fmt.Sprintf("invalid AUTH_PUBLIC_PATHS entry %q: must be a path", p))
+			}
+		}
+		// Requests forwarded between LiteFS nodes carry the session cookie, which
+		// only verifies with the same key everywhere
+		if c.ReplicationMode == "litefs" && c.AuthSessionSecret == "" {
+			errors = append(errors, "login with REPLICATION_MODE=litefs requires AUTH_SESSION_SECRET")
+		}
+	}
+	if c.WorkflowEnabled {
+		if c.DataBackend != "sqlite" {
+			errors = append(errors, "WORKFLOW_ENABLED requires the sqlite backend")
+		}
+		// Without an approver nothing could ever be approved
+		if c.WorkflowApproverToken == "" {
+			errors = append(errors, "WORKFLOW_ENABLED requires WORKFLOW_APPROVER_TOKEN")
+		}
+	}
+	if c.QuotaDailyExpenses < 0 {
+		errors = append(errors, fmt.Sprintf("invalid QUOTA_DAILY_EXPENSES %d: must not be negative", c.QuotaDailyExpenses))
+	}
+	if c.QuotaAttachmentMB < 0 {
+		errors = append(errors, fmt.Sprintf("invalid QUOTA_ATTACHMENT_MB %d: must not be negative", c.QuotaAttachmentMB))
+	}
+	if (c.QuotaDailyExpenses > 0 || c.QuotaAttachmentMB > 0) && c.DataBackend != "sqlite" {
+		errors = append(errors, "QUOTA_DAILY_EXPENSES and QUOTA_ATTACHMENT_MB require the sqlite backend")
+	}
+	if c.QuotaSoftPercent < 0 || c.QuotaSoftPercent > 100 {
+		errors = append(errors, fmt.Sprintf("invalid QUOTA_SOFT_PERCENT %d: must be between 0 and 100", c.QuotaSoftPercent))
+	}
+	if c.SQLConsoleEnabled {
+		if c.DataBackend != "sqlite" {
+			errors = append(errors, "SQL_CONSOLE_ENABLED requires the sqlite backend")
+		}
+		// The console reads every table, sessions and tokens included
+		if !c.AuthEnabled() {
+			errors = append(errors, "SQL_CONSOLE_ENABLED requires a login (AUTH_PASSWORD or AUTH_PASSWORD_HASH)")
+		}
+	}
+
 	// Return combined errors
 	if len(errors) > 0 {
 		return fmt.Errorf("configuration validation failed:\n- %s", strings.Join(errors, "\n- "))
@@ -152,6 +668,11 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+// AuthEnabled reports whether the web UI requires a login
+func (c *Config) AuthEnabled() bool {
+	return c.AuthPassword != "" || c.AuthPasswordHash != ""
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -176,3 +697,45 @@ func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
 	}
 	return defaultValue
 }
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return defaultValue
+}
+
+// getEnvCents reads a decimal amount in euros ("0.42" or "0,42") as cents.
+// "0" disables the setting; invalid values keep the default.
+func getEnvCents(key string, defaultValue int64) int64 {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return defaultValue
+	}
+	if cents, err := core.ParseSignedDecimalToCents(value); err == nil && cents >= 0 {
+		return cents
+	}
+	return defaultValue
+}
+
+// getEnvList splits a comma-separated variable, dropping empty entries.
+func getEnvList(key string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// getEnvListDefault is getEnvList keeping defaultValue while the variable
+// is unset; set to an empty string it yields an empty list.
+func getEnvListDefault(key string, defaultValue []string) []string {
+	if _, ok := os.LookupEnv(key); !ok {
+		return defaultValue
+	}
+	return getEnvList(key)
+}
@@ -75,7 +75,7 @@ func TestConfig_Validate(t *testing.T) {
 				RecurringProcessorInterval: 1 * time.Hour,
 			},
 			wantErr:     true,
-			errorString: "invalid data backend 'invalid': must be one of [sheets sqlite]",
+			errorString: "invalid data backend 'invalid': must be one of [memory sheets sqlite]",
 		},
 		{
 			name: "sqlite backend missing database path",
@@ -158,6 +158,34 @@ func TestConfig_Validate(t *testing.T) {
 			wantErr:     true,
 			errorString: "invalid sync batch size 2000: must be at most 1000",
 		},
+		{
+			name: "invalid sync max attempts",
+			config: Config{
+				Port:                       "8080",
+				DataBackend:                "sqlite",
+				SQLiteDBPath:               "./test.db",
+				SyncBatchSize:              10,
+				SyncInterval:               30 * time.Second,
+				SyncMaxAttempts:            50,
+				RecurringProcessorInterval: 1 * time.Hour,
+			},
+			wantErr:     true,
+			errorString: "invalid SYNC_MAX_ATTEMPTS 50: must be between 1 and 20",
+		},
+		{
+			name: "invalid sync pull interval",
+			config: Config{
+				Port:                       "8080",
+				DataBackend:                "sqlite",
+				SQLiteDBPath:               "./test.db",
+				SyncBatchSize:              10,
+				SyncInterval:               30 * time.Second,
+				SyncPullInterval:           10 * time.Second,
+				RecurringProcessorInterval: 1 * time.Hour,
+			},
+			wantErr:     true,
+			errorString: "invalid SYNC_PULL_INTERVAL 10s: must be 0 or at least 1 minute",
+		},
 		{
 			name: "invalid sync interval - too short",
 			config: Config{
@@ -184,6 +212,414 @@ func TestConfig_Validate(t *testing.T) {
 			wantErr:     true,
 			errorString: "invalid sync interval 25h0m0s: must be at most 24 hours",
 		},
+		{
+			name: "gRPC listener without token",
+			config: Config{
+				Port:                       "8081",
+				DataBackend:                "sqlite",
+				SQLiteDBPath:               "./test.db",
+				SyncBatchSize:              10,
+				SyncInterval:               30 * time.Second,
+				RecurringProcessorInterval: 1 * time.Hour,
+				GRPCAddr:                   ":9090",
+			},
+			wantErr:     true,
+			errorString: "GRPC_TOKEN is required when GRPC_ADDR is set",
+		},
+		{
+			name: "parquet export interval too short",
+			config: Config{
+				Port:                       "8081",
+				DataBackend:                "sqlite",
+				SQLiteDBPath:               "./test.db",
+				SyncBatchSize:              10,
+				SyncInterval:               30 * time.Second,
+				RecurringProcessorInterval: 1 * time.Hour,
+				ParquetExportDir:           "./exports",
+				ParquetExportInterval:      time.Second,
+			},
+			wantErr:     true,
+			errorString: "invalid parquet export interval 1s: must be at least 1 minute",
+		},
+		{
+			name: "demo mode with gRPC",
+			config: Config{
+				Port:                       "8081",
+				DataBackend:                "sqlite",
+				SQLiteDBPath:               "./test.db",
+				SyncBatchSize:              10,
+				SyncInterval:               30 * time.Second,
+				RecurringProcessorInterval: 1 * time.Hour,
+				GRPCAddr:                   ":9090",
+				GRPCToken:                  "secret",
+				DemoMode:                   true,
+			},
+			wantErr:     true,
+			errorString: "GRPC_ADDR cannot be used with DEMO_MODE",
+		},
+		{
+			name: "demo mode on the memory backend",
+			config: Config{
+				Port:                       "8081",
+				DataBackend:                "memory",
+				SyncBatchSize:              10,
+				SyncInterval:               30 * time.Second,
+				RecurringProcessorInterval: 1 * time.Hour,
+				DemoMode:                   true,
+			},
+			wantErr: false,
+		},
+		{
+			name: "negative Google write limit",
+			config: Config{
+				Port:                       "8081",
+				DataBackend:                "sqlite",
+				SQLiteDBPath:               "./test.db",
+				SyncBatchSize:              10,
+				SyncInterval:               30 * time.Second,
+				RecurringProcessorInterval: 1 * time.Hour,
+				GoogleWriteLimit:           -1,
+			},
+			wantErr:     true,
+			errorString: "GOOGLE_WRITE_LIMIT must not be negative, got -1",
+		},
+		{
+			name: "Google retries without a base delay",
+			config: Config{
+				Port:                       "8081",
+				DataBackend:                "sqlite",
+				SQLiteDBPath:               "./test.db",
+				SyncBatchSize:              10,
+				SyncInterval:               30 * time.Second,
+				RecurringProcessorInterval: 1 * time.Hour,
+				GoogleRetryMax:             3,
+				GoogleRetryMaxDelay:        30 * time.Second,
+			},
+			wantErr:     true,
+			errorString: "GOOGLE_RETRY_BASE_DELAY must be positive and at most GOOGLE_RETRY_MAX_DELAY when GOOGLE_RETRY_MAX is set",
+		},
+		{
+			name: "unknown trace exporter",
+			config: Config{
+				Port:                       "8081",
+				DataBackend:                "sqlite",
+				SQLiteDBPath:               "./test.db",
+				SyncBatchSize:              10,
+				SyncInterval:               30 * time.Second,
+				RecurringProcessorInterval: 1 * time.Hour,
+				TracesExporter:             "jaeger",
+			},
+			wantErr:     true,
+			errorString: "invalid OTEL_TRACES_EXPORTER 'jaeger': must be one of none, otlp, console",
+		},
+		{
+			name: "peer URL without token",
+			config: Config{
+				Port:                       "8081",
+				DataBackend:                "sqlite",
+				SQLiteDBPath:               "./test.db",
+				SyncBatchSize:              10,
+				SyncInterval:               30 * time.Second,
+				RecurringProcessorInterval: 1 * time.Hour,
+				PeerURL:                    "https://home.example.com",
+				PeerSyncInterval:           5 * time.Minute,
+			},
+			wantErr:     true,
+			errorString: "PEER_TOKEN is required when PEER_URL is set",
+		},
+		{
+			name: "unknown replication mode",
+			config: Config{
+				Port:                       "8081",
+				DataBackend:                "sqlite",
+				SQLiteDBPath:               "./test.db",
+				SyncBatchSize:              10,
+				SyncInterval:               30 * time.Second,
+				RecurringProcessorInterval: 1 * time.Hour,
+				ReplicationMode:            "rqlite",
+			},
+			wantErr:     true,
+			errorString: `invalid REPLICATION_MODE "rqlite"`,
+		},
+		{
+			name: "read replica is the primary database",
+			config: Config{
+				Port:                       "8081",
+				DataBackend:                "sqlite",
+				SQLiteDBPath:               "./test.db",
+				SyncBatchSize:              10,
+				SyncInterval:               30 * time.Second,
+				RecurringProcessorInterval: 1 * time.Hour,
+				SQLiteReadReplicas:         []string{"test.db"},
+			},
+			wantErr:     true,
+			errorString: `read replica "test.db" is the primary database`,
+		},
+		{
+			name: "valid peer sync",
+			config: Config{
+				Port:                       "8081",
+				DataBackend:                "sqlite",
+				SQLiteDBPath:               "./test.db",
+				SyncBatchSize:              10,
+				SyncInterval:               30 * time.Second,
+				RecurringProcessorInterval: 1 * time.Hour,
+				PeerURL:                    "https://home.example.com",
+				PeerToken:                  "secret",
+				PeerSyncInterval:           5 * time.Minute,
+			},
+			wantErr: false,
+		},
+		{
+			name: "workflow without approver token",
+			config: Config{
+				Port:                       "8081",
+				DataBackend:                "sqlite",
+				SQLiteDBPath:               "./test.db",
+				SyncBatchSize:              10,
+				SyncInterval:               30 * time.Second,
+				RecurringProcessorInterval: 1 * time.Hour,
+				WorkflowEnabled:            true,
+			},
+			wantErr:     true,
+			errorString: "WORKFLOW_ENABLED requires WORKFLOW_APPROVER_TOKEN",
+		},
+		{
+			name: "classifier confidence out of range",
+			config: Config{
+				Port:                       "8081",
+				DataBackend:                "sqlite",
+				SQLiteDBPath:               "./test.db",
+				SyncBatchSize:              10,
+				SyncInterval:               30 * time.Second,
+				RecurringProcessorInterval: 1 * time.Hour,
+				ClassifierEnabled:          true,
+				ClassifierMinConfidence:    150,
+			},
+			wantErr:     true,
+			errorString: "invalid CLASSIFIER_MIN_CONFIDENCE 150",
+		},
+		{
+			name: "openai provider without API key",
+			config: Config{
+				Port:                       "8081",
+				DataBackend:                "sqlite",
+				SQLiteDBPath:               "./test.db",
+				SyncBatchSize:              10,
+				SyncInterval:               30 * time.Second,
+				RecurringProcessorInterval: 1 * time.Hour,
+				LLMProvider:                "openai",
+				LLMTimeout:                 30 * time.Second,
+			},
+			wantErr:     true,
+			errorString: "LLM_PROVIDER=openai requires LLM_API_KEY",
+		},
+		{
+			name: "s3 receipts without credentials",
+			config: Config{
+				Port:                       "8081",
+				DataBackend:                "sqlite",
+				SQLiteDBPath:               "./test.db",
+				SyncBatchSize:              10,
+				SyncInterval:               30 * time.Second,
+				RecurringProcessorInterval: 1 * time.Hour,
+				ReceiptsStorage:            "s3",
+				ReceiptsMaxMB:              10,
+				ReceiptsS3Endpoint:         "https://s3.example.com",
+				ReceiptsS3Bucket:           "receipts",
+			},
+			wantErr:     true,
+			errorString: "RECEIPTS_STORAGE=s3 requires",
+		},
+		{
+			name: "day start hour past dawn",
+			config: Config{
+				Port:                       "8081",
+				DataBackend:                "sqlite",
+				SQLiteDBPath:               "./test.db",
+				SyncBatchSize:              10,
+				SyncInterval:               30 * time.Second,
+				RecurringProcessorInterval: 1 * time.Hour,
+				DayStartHour:               9,
+			},
+			wantErr:     true,
+			errorString: "invalid DAY_START_HOUR 9",
+		},
+		{
+			name: "negative audit retention",
+			config: Config{
+				Port:                       "8081",
+				DataBackend:                "sqlite",
+				SQLiteDBPath:               "./test.db",
+				SyncBatchSize:              10,
+				SyncInterval:               30 * time.Second,
+				RecurringProcessorInterval: 1 * time.Hour,
+				AuditRetentionDays:         -1,
+			},
+			wantErr:     true,
+			errorString: "invalid AUDIT_RETENTION_DAYS -1",
+		},
+		{
+			name: "negative trash retention",
+			config: Config{
+				Port:                       "8081",
+				DataBackend:                "sqlite",
+				SQLiteDBPath:               "./test.db",
+				SyncBatchSize:              10,
+				SyncInterval:               30 * time.Second,
+				RecurringProcessorInterval: 1 * time.Hour,
+				TrashRetentionDays:         -1,
+			},
+			wantErr:     true,
+			errorString: "invalid TRASH_RETENTION_DAYS -1",
+		},
+		{
+			name: "database maintenance too often",
+			config: Config{
+				Port:                       "8081",
+				DataBackend:                "sqlite",
+				SQLiteDBPath:               "./test.db",
+				SyncBatchSize:              10,
+				SyncInterval:               30 * time.Second,
+				RecurringProcessorInterval: 1 * time.Hour,
+				DBMaintenanceInterval:      time.Second,
+			},
+			wantErr:     true,
+			errorString: "invalid DB_MAINTENANCE_INTERVAL 1s",
+		},
+		{
+			name: "quota with sheets backend",
+			config: Config{
+				Port:                       "8081",
+				DataBackend:                "sheets",
+				GoogleSpreadsheetID:        "sheet",
+				SyncBatchSize:              10,
+				SyncInterval:               30 * time.Second,
+				RecurringProcessorInterval: 1 * time.Hour,
+				QuotaDailyExpenses:         50,
+			},
+			wantErr:     true,
+			errorString: "QUOTA_DAILY_EXPENSES and QUOTA_ATTACHMENT_MB require the sqlite backend",
+		},
+		{
+			name: "negative attachment quota",
+			config: Config{
+				Port:                       "8081",
+				DataBackend:                "sqlite",
+				SQLiteDBPath:               "./test.db",
+				SyncBatchSize:              10,
+				SyncInterval:               30 * time.Second,
+				RecurringProcessorInterval: 1 * time.Hour,
+				QuotaAttachmentMB:          -1,
+			},
+			wantErr:     true,
+			errorString: "invalid QUOTA_ATTACHMENT_MB -1",
+		},
+		{
+			name: "quota soft percent out of range",
+			config: Config{
+				Port:                       "8081",
+				DataBackend:                "sqlite",
+				SQLiteDBPath:               "./test.db",
+				SyncBatchSize:              10,
+				SyncInterval:               30 * time.Second,
+				RecurringProcessorInterval: 1 * time.Hour,
+				QuotaDailyExpenses:         50,
+				QuotaSoftPercent:           120,
+			},
+			wantErr:     true,
+			errorString: "invalid QUOTA_SOFT_PERCENT 120",
+		},
+		{
+			name: "sql console without login",
+			config: Config{
+				Port:                       "8081",
+				DataBackend:                "sqlite",
+				SQLiteDBPath:               "./test.db",
+				SyncBatchSize:              10,
+				SyncInterval:               30 * time.Second,
+				RecurringProcessorInterval: 1 * time.Hour,
+				SQLConsoleEnabled:          true,
+			},
+			wantErr:     true,
+			errorString: "SQL_CONSOLE_ENABLED requires a login",
+		},
+		{
+			name: "sql console with login",
+			config: Config{
+				Port:                       "8081",
+				DataBackend:                "sqlite",
+				SQLiteDBPath:               "./test.db",
+				SyncBatchSize:              10,
+				SyncInterval:               30 * time.Second,
+				RecurringProcessorInterval: 1 * time.Hour,
+				SQLConsoleEnabled:          true,
+				AuthPassword:               "secret",
+				AuthSessionTTL:             24 * time.Hour,
+			},
+			wantErr: false,
+		},
+		{
+			name: "auth password hash not bcrypt",
+			config: Config{
+				Port:                       "8081",
+				DataBackend:                "sqlite",
+				SQLiteDBPath:               "./test.db",
+				SyncBatchSize:              10,
+				SyncInterval:               30 * time.Second,
+				RecurringProcessorInterval: 1 * time.Hour,
+				AuthPasswordHash:           "secret",
+				AuthSessionTTL:             time.Hour,
+			},
+			wantErr:     true,
+			errorString: "invalid AUTH_PASSWORD_HASH",
+		},
+		{
+			name: "auth with replication without session secret",
+			config: Config{
+				Port:                       "8081",
+				DataBackend:                "sqlite",
+				SQLiteDBPath:               "./test.db",
+				SyncBatchSize:              10,
+				SyncInterval:               30 * time.Second,
+				RecurringProcessorInterval: 1 * time.Hour,
+				AuthPassword:               "secret",
+				AuthSessionTTL:             time.Hour,
+				ReplicationMode:            "litefs",
+			},
+			wantErr:     true,
+			errorString: "login with REPLICATION_MODE=litefs requires AUTH_SESSION_SECRET",
+		},
+		{
+			name: "auth with password",
+			config: Config{
+				Port:                       "8081",
+				DataBackend:                "sqlite",
+				SQLiteDBPath:               "./test.db",
+				SyncBatchSize:              10,
+				SyncInterval:               30 * time.Second,
+				RecurringProcessorInterval: 1 * time.Hour,
+				AuthPassword:               "secret",
+				AuthSessionTTL:             time.Hour,
+				AuthPublicPaths:            []string{"/healthz"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "sandbox spreadsheet same as production",
+			config: Config{
+				Port:                       "8081",
+				DataBackend:                "sqlite",
+				SQLiteDBPath:               "./test.db",
+				SyncBatchSize:              10,
+				SyncInterval:               30 * time.Second,
+				RecurringProcessorInterval: 1 * time.Hour,
+				GoogleSpreadsheetID:        "prod",
+				GoogleSandboxSpreadsheetID: "prod",
+			},
+			wantErr:     true,
+			errorString: "GOOGLE_SANDBOX_SPREADSHEET_ID must differ from GOOGLE_SPREADSHEET_ID",
+		},
 	}
 
 	for _, tt := range tests {
@@ -250,6 +686,21 @@ func TestConfig_ValidateWithFiles(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "outbound CA file without certificates",
+			config: Config{
+				Port:                       "8080",
+				DataBackend:                "sheets",
+				GoogleSpreadsheetID:        "123456789",
+				GoogleSheetName:            "Expenses",
+				GoogleServiceAccountFile:   serviceAccountFile,
+				SyncBatchSize:              10,
+				SyncInterval:               30 * time.Second,
+				RecurringProcessorInterval: 1 * time.Hour,
+				OutboundCAFile:             serviceAccountFile,
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
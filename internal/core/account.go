@@ -0,0 +1,135 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxAccountNameLength is the longest name of an account
+const MaxAccountNameLength = 40
+
+// MaxTransferDescriptionLength is the longest note of a transfer
+const MaxTransferDescriptionLength = 100
+
+// ErrInvalidAccount is returned for an account that cannot be stored.
+var ErrInvalidAccount = errors.New("invalid account")
+
+// ErrInvalidTransfer is returned for a transfer that cannot be stored.
+var ErrInvalidTransfer = errors.New("invalid transfer")
+
+// AccountKind tells where an account keeps its money.
+type AccountKind string
+
+const (
+	AccountChecking   AccountKind = "checking"
+	AccountCash       AccountKind = "cash"
+	AccountCreditCard AccountKind = "credit_card"
+)
+
+// AccountKinds lists the kinds of account, in the order forms offer them.
+var AccountKinds = []AccountKind{AccountChecking, AccountCash, AccountCreditCard}
+
+// Valid reports whether k is one of AccountKinds.
+func (k AccountKind) Valid() bool {
+	for _, kind := range AccountKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Label is the Italian name of the kind shown in the pages.
+func (k AccountKind) Label() string {
+	switch k {
+	case AccountChecking:
+		return "Conto corrente"
+	case AccountCash:
+		return "Contanti"
+	case AccountCreditCard:
+		return "Carta di credito"
+	}
+	return string(k)
+}
+
+// Account holds money of the household: a checking account, cash, a
+// credit card. Expenses and incomes may say which account they moved.
+// The opening balance is what the account held when it was added; a
+// credit card usually starts at zero and goes negative as it is used.
+type Account struct {
+	ID             int64
+	Name           string
+	Kind           AccountKind
+	OpeningBalance Money
+}
+
+// Validate checks the name and the kind of the account.
+func (a Account) Validate() error {
+	name := strings.TrimSpace(a.Name)
+	switch {
+	case name == "":
+		return fmt.Errorf("%w: name is required", ErrInvalidAccount)
+	case utf8.RuneCountInString(name) > MaxAccountNameLength:
+		return fmt.Errorf("%w: name too long", ErrInvalidAccount)
+	case !a.Kind.Valid():
+		return fmt.Errorf("%w: unknown kind %q", ErrInvalidAccount, a.Kind)
+	}
+	return nil
+}
+
+// AccountTransfer moves money from one account to another, such as a cash
+// withdrawal or the payment of a credit card bill. It is neither an
+// expense nor an income: the household keeps the same money.
+type AccountTransfer struct {
+	ID          int64
+	Date        Date
+	From        int64
+	To          int64
+	Amount      Money
+	Description string
+}
+
+// Validate checks that the transfer moves a positive amount between two
+// different accounts on a given day.
+func (t AccountTransfer) Validate() error {
+	switch {
+	case t.From <= 0 || t.To <= 0:
+		return fmt.Errorf("%w: both accounts are required", ErrInvalidTransfer)
+	case t.From == t.To:
+		return fmt.Errorf("%w: the accounts must differ", ErrInvalidTransfer)
+	case t.Amount.Cents <= 0:
+		return fmt.Errorf("%w: amount must be positive", ErrInvalidTransfer)
+	case t.Date.IsZero():
+		return fmt.Errorf("%w: date is required", ErrInvalidTransfer)
+	case utf8.RuneCountInString(t.Description) > MaxTransferDescriptionLength:
+		return fmt.Errorf("%w: description too long", ErrInvalidTransfer)
+	}
+	return nil
+}
+
+// AccountBalance is what moved through an account since it was opened.
+type AccountBalance struct {
+	Account
+	Incomes      Money
+	Expenses     Money
+	TransfersIn  Money
+	TransfersOut Money
+}
+
+// Balance is the money in the account now: the opening balance plus what
+// came in minus what went out.
+func (b AccountBalance) Balance() Money {
+	return Money{Cents: b.OpeningBalance.Cents + b.Incomes.Cents - b.Expenses.Cents + b.TransfersIn.Cents - b.TransfersOut.Cents}
+}
+
+// NetWorth is the sum of the balances of all accounts. Transfers cancel
+// out, so it only moves with expenses and incomes.
+func NetWorth(balances []AccountBalance) Money {
+	var total int64
+	for _, b := range balances {
+		total += b.Balance().Cents
+	}
+	return Money{Cents: total}
+}
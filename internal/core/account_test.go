@@ -0,0 +1,58 @@
+package core
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestAccount_Validate(t *testing.T) {
+	if err := (Account{Name: "Conto", Kind: AccountChecking}).Validate(); err != nil {
+		t.Fatalf("valid account refused: %v", err)
+	}
+	for _, a := range []Account{
+		{Name: "  ", Kind: AccountCash},
+		{Name: strings.Repeat("a", MaxAccountNameLength+1), Kind: AccountCash},
+		{Name: "Risparmi", Kind: "savings"},
+	} {
+		if err := a.Validate(); !errors.Is(err, ErrInvalidAccount) {
+			t.Errorf("Validate(%+v) = %v, want ErrInvalidAccount", a, err)
+		}
+	}
+}
+
+func TestAccountTransfer_Validate(t *testing.T) {
+	valid := AccountTransfer{Date: NewDate(2030, 3, 1), From: 1, To: 2, Amount: Money{Cents: 100}}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("valid transfer refused: %v", err)
+	}
+	for name, change := range map[string]func(*AccountTransfer){
+		"same account": func(t *AccountTransfer) { t.To = 1 },
+		"no account":   func(t *AccountTransfer) { t.From = 0 },
+		"zero amount":  func(t *AccountTransfer) { t.Amount.Cents = 0 },
+		"no date":      func(t *AccountTransfer) { t.Date = Date{} },
+		"long note":    func(t *AccountTransfer) { t.Description = strings.Repeat("a", MaxTransferDescriptionLength+1) },
+	} {
+		tr := valid
+		change(&tr)
+		if err := tr.Validate(); !errors.Is(err, ErrInvalidTransfer) {
+			t.Errorf("%s: err = %v, want ErrInvalidTransfer", name, err)
+		}
+	}
+}
+
+func TestNetWorth(t *testing.T) {
+	// A card bill paid from the checking account moves money between
+	// the two without changing the total
+	checking := AccountBalance{Account: Account{OpeningBalance: Money{Cents: 100000}}, Incomes: Money{Cents: 20000}, TransfersOut: Money{Cents: 5000}}
+	card := AccountBalance{Expenses: Money{Cents: 5000}, TransfersIn: Money{Cents: 5000}}
+	if got := checking.Balance().Cents; got != 115000 {
+		t.Errorf("checking balance = %d, want 115000", got)
+	}
+	if got := card.Balance().Cents; got != 0 {
+		t.Errorf("card balance = %d, want 0", got)
+	}
+	if got := NetWorth([]AccountBalance{checking, card}).Cents; got != 115000 {
+		t.Errorf("NetWorth = %d, want 115000", got)
+	}
+}
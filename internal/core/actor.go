@@ -0,0 +1,22 @@
+package core
+
+import "context"
+
+// SystemActor is the actor recorded for changes made by background processes.
+const SystemActor = "system"
+
+type actorKey struct{}
+
+// WithActor returns a context carrying the name of who is performing a change.
+// It is recorded in the expense history.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor stored in ctx, or SystemActor when none is set.
+func ActorFromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return SystemActor
+}
@@ -0,0 +1,18 @@
+package core
+
+import (
+	"context"
+	"testing"
+)
+
+func TestActorFromContext(t *testing.T) {
+	if got := ActorFromContext(context.Background()); got != SystemActor {
+		t.Fatalf("expected %q, got %q", SystemActor, got)
+	}
+	if got := ActorFromContext(WithActor(context.Background(), "")); got != SystemActor {
+		t.Fatalf("expected %q for empty actor, got %q", SystemActor, got)
+	}
+	if got := ActorFromContext(WithActor(context.Background(), "web")); got != "web" {
+		t.Fatalf("expected web, got %q", got)
+	}
+}
@@ -0,0 +1,109 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// AlertType is a kind of notification the app sends.
+type AlertType string
+
+// Alert types
+const (
+	// AlertBudget: a category reached what it cost in the whole previous
+	// month
+	AlertBudget AlertType = "budget"
+	// AlertSpending: a category goes up, or is used again after a while
+	AlertSpending AlertType = "spending"
+	// AlertReturnDue: the return window of a purchase is closing
+	AlertReturnDue AlertType = "return_due"
+	// AlertSync: an expense could not be synced to Google Sheets
+	AlertSync AlertType = "sync"
+	// AlertView: a new expense matches a saved view with notifications on
+	AlertView AlertType = "view"
+)
+
+// AlertTypes lists the alert types, in the order the preferences page
+// shows them.
+var AlertTypes = []AlertType{AlertBudget, AlertSpending, AlertReturnDue, AlertSync, AlertView}
+
+// DefaultAlertUser owns the alert preferences while the app has no user
+// accounts.
+const DefaultAlertUser = "default"
+
+// MaxAlertSnooze is the longest an alert type can be snoozed
+const MaxAlertSnooze = 365 * 24 * time.Hour
+
+// Valid reports whether t is a known alert type.
+func (t AlertType) Valid() bool {
+	for _, known := range AlertTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Scoped reports whether alerts of the type concern a category, so their
+// preferences may be limited to one.
+func (t AlertType) Scoped() bool {
+	return t != AlertSync && t != AlertView
+}
+
+// AlertForInsight returns the alert type of an insight kind.
+func AlertForInsight(kind InsightKind) AlertType {
+	if kind == InsightBudgetReached {
+		return AlertBudget
+	}
+	return AlertSpending
+}
+
+// AlertPreference silences the alerts of a type for a user, for good when
+// muted or until SnoozedUntil otherwise. Scope limits it to a primary
+// category; an empty scope covers them all.
+type AlertPreference struct {
+	User         string
+	Type         AlertType
+	Scope        string
+	Muted        bool
+	SnoozedUntil time.Time
+}
+
+// Validate checks that the preference silences a known alert type.
+func (p AlertPreference) Validate() error {
+	if strings.TrimSpace(p.User) == "" {
+		return errors.New("user is required")
+	}
+	if !p.Type.Valid() {
+		return fmt.Errorf("unknown alert type %q", p.Type)
+	}
+	if p.Scope != "" && !p.Type.Scoped() {
+		return fmt.Errorf("%s alerts have no category", p.Type)
+	}
+	if !p.Muted && p.SnoozedUntil.IsZero() {
+		return errors.New("preference must mute or snooze")
+	}
+	return nil
+}
+
+// Silences reports whether the preference drops an alert of type t about
+// scope at now.
+func (p AlertPreference) Silences(t AlertType, scope string, now time.Time) bool {
+	if p.Type != t || (p.Scope != "" && p.Scope != scope) {
+		return false
+	}
+	return p.Muted || now.Before(p.SnoozedUntil)
+}
+
+// AlertSilenced reports whether any of prefs drops an alert of type t about
+// scope at now.
+func AlertSilenced(prefs []AlertPreference, t AlertType, scope string, now time.Time) bool {
+	for _, p := range prefs {
+		if p.Silences(t, scope, now) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAlertPreference_Validate(t *testing.T) {
+	week := time.Date(2031, 3, 17, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name string
+		p    AlertPreference
+		ok   bool
+	}{
+		{"mute category", AlertPreference{User: DefaultAlertUser, Type: AlertBudget, Scope: "Spesa", Muted: true}, true},
+		{"snooze all", AlertPreference{User: DefaultAlertUser, Type: AlertSync, SnoozedUntil: week}, true},
+		{"no user", AlertPreference{Type: AlertBudget, Muted: true}, false},
+		{"unknown type", AlertPreference{User: DefaultAlertUser, Type: "weather", Muted: true}, false},
+		{"sync has no category", AlertPreference{User: DefaultAlertUser, Type: AlertSync, Scope: "Spesa", Muted: true}, false},
+		{"neither mute nor snooze", AlertPreference{User: DefaultAlertUser, Type: AlertBudget}, false},
+	}
+	for _, tc := range cases {
+		if err := tc.p.Validate(); (err == nil) != tc.ok {
+			t.Errorf("%s: err = %v", tc.name, err)
+		}
+	}
+}
+
+func TestAlertSilenced(t *testing.T) {
+	now := time.Date(2031, 3, 10, 0, 0, 0, 0, time.UTC)
+	prefs := []AlertPreference{
+		{Type: AlertBudget, Scope: "Spesa", Muted: true},
+		{Type: AlertSpending, SnoozedUntil: now.Add(time.Hour)},
+		{Type: AlertReturnDue, SnoozedUntil: now.Add(-time.Hour)},
+	}
+	cases := []struct {
+		t      AlertType
+		scope  string
+		silent bool
+	}{
+		{AlertBudget, "Spesa", true},
+		{AlertBudget, "Casa", false},
+		{AlertSpending, "Casa", true},
+		{AlertReturnDue, "Casa", false}, // snooze over
+		{AlertSync, "", false},
+	}
+	for _, tc := range cases {
+		if got := AlertSilenced(prefs, tc.t, tc.scope, now); got != tc.silent {
+			t.Errorf("AlertSilenced(%s, %q) = %v, want %v", tc.t, tc.scope, got, tc.silent)
+		}
+	}
+	if AlertForInsight(InsightBudgetReached) != AlertBudget || AlertForInsight(InsightCategoryBack) != AlertSpending {
+		t.Error("AlertForInsight maps kinds to the wrong types")
+	}
+}
@@ -0,0 +1,73 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// CalculationKind is a calculated expense type: the amount comes from a
+// quantity times a configured rate.
+type CalculationKind string
+
+const (
+	CalculationMileage CalculationKind = "mileage"  // Kilometres × rate per km
+	CalculationPerDiem CalculationKind = "per_diem" // Days × daily allowance
+)
+
+// MaxCalculationQuantity bounds the kilometres or days of one entry.
+const MaxCalculationQuantity = 100000
+
+var ErrInvalidCalculation = errors.New("invalid calculation")
+
+// ExpenseCalculation holds the inputs of a calculated expense, kept with the
+// expense for audit since rates change over time.
+type ExpenseCalculation struct {
+	Kind     CalculationKind
+	Quantity float64 // Kilometres or days
+	Rate     Money   // Per kilometre or per day
+}
+
+// ParseCalculationKind returns the calculation kind named s.
+func ParseCalculationKind(s string) (CalculationKind, error) {
+	switch k := CalculationKind(s); k {
+	case CalculationMileage, CalculationPerDiem:
+		return k, nil
+	}
+	return "", fmt.Errorf("%w: unknown kind %q", ErrInvalidCalculation, s)
+}
+
+// ParseQuantity parses a positive quantity with either a dot or a comma as
+// decimal separator, as typed in forms ("12,5").
+func ParseQuantity(s string) (float64, error) {
+	q, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(s), ",", "."), 64)
+	if err != nil || math.IsNaN(q) || math.IsInf(q, 0) {
+		return 0, fmt.Errorf("%w: quantity %q", ErrInvalidCalculation, s)
+	}
+	return q, nil
+}
+
+// Amount returns the quantity times the rate, rounded to the cent.
+func (c ExpenseCalculation) Amount() Money {
+	return Money{Cents: int64(math.Round(c.Quantity * float64(c.Rate.Cents)))}
+}
+
+// Validate checks the kind, the quantity range and that the rate and the
+// resulting amount are positive.
+func (c ExpenseCalculation) Validate() error {
+	if _, err := ParseCalculationKind(string(c.Kind)); err != nil {
+		return err
+	}
+	if c.Quantity <= 0 || c.Quantity > MaxCalculationQuantity {
+		return fmt.Errorf("%w: quantity must be between 0 and %d", ErrInvalidCalculation, MaxCalculationQuantity)
+	}
+	if c.Rate.Cents <= 0 {
+		return fmt.Errorf("%w: rate must be positive", ErrInvalidCalculation)
+	}
+	if c.Amount().Cents <= 0 {
+		return fmt.Errorf("%w: amount rounds to zero", ErrInvalidCalculation)
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package core
+
+import "testing"
+
+func TestExpenseCalculation(t *testing.T) {
+	q, err := ParseQuantity("123,5")
+	if err != nil || q != 123.5 {
+		t.Fatalf("ParseQuantity = %v, %v", q, err)
+	}
+	c := ExpenseCalculation{Kind: CalculationMileage, Quantity: q, Rate: Money{Cents: 42}}
+	if err := c.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if got := c.Amount().Cents; got != 5187 {
+		t.Errorf("Amount = %d, want 5187", got)
+	}
+
+	bads := map[string]ExpenseCalculation{
+		"unknown kind":  {Kind: "taxi", Quantity: 1, Rate: Money{Cents: 100}},
+		"zero quantity": {Kind: CalculationPerDiem, Quantity: 0, Rate: Money{Cents: 4648}},
+		"huge quantity": {Kind: CalculationMileage, Quantity: MaxCalculationQuantity + 1, Rate: Money{Cents: 42}},
+		"no rate":       {Kind: CalculationPerDiem, Quantity: 2},
+		"rounds to 0":   {Kind: CalculationMileage, Quantity: 0.01, Rate: Money{Cents: 42}},
+	}
+	for name, c := range bads {
+		if err := c.Validate(); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+	if _, err := ParseQuantity("dieci"); err == nil {
+		t.Error("ParseQuantity(dieci): expected error")
+	}
+}
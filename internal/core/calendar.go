@@ -0,0 +1,94 @@
+package core
+
+import (
+	"sort"
+	"time"
+)
+
+// CalendarEntry is an occurrence of a recurrent expense or income on the
+// obligation calendar.
+type CalendarEntry struct {
+	RecurrentID int64
+	Income      bool // A recurrent income rather than an expense
+	Description string
+	Category    string // Primary and secondary, or category and subcategory, joined by " / "
+	Amount      Money
+}
+
+// CalendarDay is a day of the obligation calendar: what the recurrents
+// will charge and pay on it, and the balance projected once they have.
+type CalendarDay struct {
+	Date    Date
+	Entries []CalendarEntry // Incomes first, then charges
+	Net     Money           // Incomes minus charges of the day
+	Balance Money           // The opening balance plus the net of this day and the days before
+}
+
+// ObligationCalendar lays out by day the occurrences of recurrent expenses
+// and incomes from the day of from to the day of until, both included,
+// projecting the balance from opening. Days without occurrences are left
+// out; paused, skipped and already run occurrences too, as in Upcoming.
+func ObligationCalendar(expenses []RecurrentExpenses, incomes []RecurrentIncome, from, until time.Time, opening Money) []CalendarDay {
+	byDay := make(map[string]*CalendarDay)
+	day := func(d Date) *CalendarDay {
+		key := d.Format("2006-01-02")
+		if cd, ok := byDay[key]; ok {
+			return cd
+		}
+		cd := &CalendarDay{Date: d}
+		byDay[key] = cd
+		return cd
+	}
+
+	for _, ri := range incomes {
+		for _, d := range ri.Upcoming(from, until) {
+			cd := day(d)
+			cd.Entries = append(cd.Entries, CalendarEntry{
+				RecurrentID: ri.ID,
+				Income:      true,
+				Description: ri.Description,
+				Category:    joinCategory(ri.Category, ri.Subcategory),
+				Amount:      ri.Amount,
+			})
+			cd.Net.Cents += ri.Amount.Cents
+		}
+	}
+	for _, c := range UpcomingCharges(expenses, from, until) {
+		cd := day(c.Date)
+		cd.Entries = append(cd.Entries, CalendarEntry{
+			RecurrentID: c.RecurrentID,
+			Description: c.Description,
+			Category:    joinCategory(c.Primary, c.Secondary),
+			Amount:      c.Amount,
+		})
+		cd.Net.Cents -= c.Amount.Cents
+	}
+
+	days := make([]CalendarDay, 0, len(byDay))
+	for _, cd := range byDay {
+		days = append(days, *cd)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Date.Before(days[j].Date.Time) })
+	balance := opening.Cents
+	for i := range days {
+		balance += days[i].Net.Cents
+		days[i].Balance = Money{Cents: balance}
+	}
+	return days
+}
+
+// Upcoming returns the occurrences incomes will be created for from the day
+// of from to the day of until, both included, leaving out those already
+// run.
+func (ri RecurrentIncome) Upcoming(from, until time.Time) []Date {
+	return ri.schedule().Upcoming(from, until)
+}
+
+// joinCategory joins two category levels as "first / second", or returns
+// the first alone when there is no second.
+func joinCategory(first, second string) string {
+	if second == "" {
+		return first
+	}
+	return first + " / " + second
+}
@@ -0,0 +1,49 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestObligationCalendar(t *testing.T) {
+	from := time.Date(2031, 3, 20, 9, 0, 0, 0, time.UTC)
+	until := from.AddDate(0, 0, 60)
+	rent := RecurrentExpenses{ID: 1, StartDate: NewDate(2031, 1, 27), Every: Monthly, Description: "Affitto", Amount: Money{Cents: 80000}, Primary: "Casa"}
+	phone := RecurrentExpenses{ID: 2, StartDate: NewDate(2031, 1, 5), Every: Monthly, Description: "Telefono", Amount: Money{Cents: 1500}, Primary: "Casa", Secondary: "Utenze"}
+	paused := RecurrentExpenses{ID: 3, StartDate: NewDate(2031, 1, 1), Every: Daily, Paused: true, Amount: Money{Cents: 100}}
+	salary := RecurrentIncome{ID: 7, StartDate: NewDate(2031, 1, 27), Every: Monthly, Description: "Stipendio", Amount: Money{Cents: 250000}, Category: "Lavoro"}
+	salary.LastRun = NewDate(2031, 2, 27)
+
+	days := ObligationCalendar([]RecurrentExpenses{rent, phone, paused}, []RecurrentIncome{salary}, from, until, Money{Cents: -10000})
+
+	var got []string
+	for _, d := range days {
+		var entries []string
+		for _, e := range d.Entries {
+			sign := "-"
+			if e.Income {
+				sign = "+"
+			}
+			entries = append(entries, sign+e.Description)
+		}
+		got = append(got, fmt.Sprintf("%s %s net=%d bal=%d", d.Date.Format("01-02"), strings.Join(entries, ","), d.Net.Cents, d.Balance.Cents))
+	}
+	want := []string{
+		"03-27 +Stipendio,-Affitto net=170000 bal=160000",
+		"04-05 -Telefono net=-1500 bal=158500",
+		"04-27 +Stipendio,-Affitto net=170000 bal=328500",
+		"05-05 -Telefono net=-1500 bal=327000",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("calendar:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if days[1].Entries[0].Category != "Casa / Utenze" || days[0].Entries[0].Category != "Lavoro" {
+		t.Errorf("categories = %q, %q", days[1].Entries[0].Category, days[0].Entries[0].Category)
+	}
+
+	if days := ObligationCalendar(nil, nil, from, until, Money{}); len(days) != 0 {
+		t.Errorf("expected an empty calendar, got %v", days)
+	}
+}
@@ -24,6 +24,16 @@ const (
 // It is a string type that can be one of the predefined constants.
 type RepetitionTypes string
 
+// ExpenseStatus constants define the settlement state of an expense.
+const (
+	StatusCleared ExpenseStatus = "cleared" // Settled (default)
+	StatusPending ExpenseStatus = "pending" // Card hold awaiting settlement
+)
+
+// ExpenseStatus tells card holds imported before settlement apart from
+// settled expenses. The empty value means cleared.
+type ExpenseStatus string
+
 // Date wraps time.Time to provide domain-specific date handling.
 // It provides methods for day, month, and year access while maintaining
 // compatibility with Go's standard time package.
@@ -41,11 +51,36 @@ type Money struct {
 // It contains all the necessary information for tracking an individual expense,
 // including date, description, amount, and categorization.
 type Expense struct {
-	Date        Date   // Date when the expense occurred
-	Description string // Human-readable description of the expense
-	Amount      Money  // Monetary amount in cents
-	Primary     string // Primary category (e.g., "Food", "Transport")
-	Secondary   string // Secondary category (e.g., "Supermarket", "Public")
+	Date        Date          // Date when the expense occurred
+	Description string        // Human-readable description of the expense
+	Amount      Money         // Monetary amount in cents
+	Primary     string        // Primary category (e.g., "Food", "Transport")
+	Secondary   string        // Secondary category (e.g., "Supermarket", "Public")
+	Status      ExpenseStatus // Empty or StatusCleared, StatusPending for card holds
+	PaidBy      int64         // User who paid it, in a household; 0 when not set
+	AccountID   int64         // Account it was paid from; 0 when not set
+	Tags        []string      // Optional free labels, normalized with NormalizeTags
+}
+
+// IsPending reports whether the expense is a card hold not yet settled.
+func (e Expense) IsPending() bool {
+	return e.Status == StatusPending
+}
+
+// OccurrencesPerYear returns how many times a year a recurrence repeats.
+func (t RepetitionTypes) OccurrencesPerYear() int {
+	switch t {
+	case Daily:
+		return 365
+	case Weekly:
+		return 52
+	case Monthly:
+		return 12
+	case Yearly:
+		return 1
+	default:
+		return 0
+	}
 }
 
 // RecurrentExpenses represents a recurring expense configuration.
@@ -56,28 +91,89 @@ type RecurrentExpenses struct {
 	StartDate   Date            // Date when the recurrence starts
 	EndDate     Date            // Optional date when the recurrence ends (zero if indefinite)
 	Every       RepetitionTypes // Frequency of recurrence
+	Interval    int             // Units between occurrences (every 2 weeks); 0 means 1
+	DayOfMonth  int             // Monthly/yearly anchor day, clamped to short months; 0 keeps the start day
 	Description string          // Human-readable description
 	Amount      Money           // Monetary amount in cents per occurrence
 	Primary     string          // Primary category
 	Secondary   string          // Secondary category
+	Version     int64           // Row version for optimistic concurrency (0 if unknown)
+	Paused      bool            // Paused recurrences create no expenses until resumed
+	SkipUntil   Date            // Occurrences up to this date (included) are skipped; zero if none
+	LastRun     Date            // Date of the last expense created; zero before the first
 }
 
 // Income represents a single income entry in the system.
 // It contains all the necessary information for tracking an individual income,
-// including date, description, amount, and category.
+// including date, description, amount, and categorization.
 type Income struct {
-	Date        Date   // Date when the income was received
-	Description string // Human-readable description of the income
-	Amount      Money  // Monetary amount in cents
-	Category    string // Income category (e.g., "Stipendio E", "Freelance")
+	Date        Date     // Date when the income was received
+	Description string   // Human-readable description of the income
+	Amount      Money    // Monetary amount in cents
+	Category    string   // Income category (e.g., "Stipendio E", "Freelance")
+	Subcategory string   // Optional second level (e.g., "Bonus", "Rimborso")
+	Tags        []string // Optional free labels, normalized with NormalizeTags
+	AccountID   int64    // Account it was received on; 0 when not set
+}
+
+// RecurrentIncome is the configuration of an income that repeats, such as
+// a monthly salary. Its schedule works like that of RecurrentExpenses.
+type RecurrentIncome struct {
+	ID          int64           // Database ID for operations
+	StartDate   Date            // Date when the recurrence starts
+	EndDate     Date            // Optional date when the recurrence ends (zero if indefinite)
+	Every       RepetitionTypes // Frequency of recurrence
+	Interval    int             // Units between occurrences; 0 means 1
+	DayOfMonth  int             // Monthly/yearly anchor day; 0 keeps the start day
+	Description string          // Human-readable description
+	Amount      Money           // Monetary amount in cents per occurrence
+	Category    string          // Income category
+	Subcategory string          // Optional second level
+	LastRun     Date            // Date of the last income created; zero before the first
+	Version     int64           // Row version for optimistic concurrency (0 if unknown)
 }
 
 // IncomeMonthOverview represents aggregated monthly income summary
 type IncomeMonthOverview struct {
-	Year       int
-	Month      int
-	Total      Money
-	ByCategory []CategoryAmount
+	Year          int
+	Month         int
+	Total         Money
+	ByCategory    []CategoryAmount
+	BySubcategory []SubcategoryAmount // incomes without a subcategory are left out
+	ByTag         []CategoryAmount    // an income counts once for each of its tags
+}
+
+// CategoryRule assigns categories to new expenses whose fields match a
+// condition expression (see package rules for the available fields).
+type CategoryRule struct {
+	ID         int64
+	Name       string // Human-readable label
+	Expression string // Condition, e.g. `description contains "Esselunga"`
+	Primary    string // Primary category to assign
+	Secondary  string // Secondary category to assign
+	Priority   int64  // Lower values are evaluated first
+	Active     bool
+}
+
+// Reimbursement is an income that pays back expenses (e.g. a company
+// refund), with the parts of the expenses it covers.
+type Reimbursement struct {
+	IncomeID int64
+	Income   Income
+	Links    []ReimbursementLink
+	Linked   Money // Sum of the linked amounts
+}
+
+// Net returns the part of the income not netted out by linked expenses.
+func (r Reimbursement) Net() Money {
+	return Money{Cents: r.Income.Amount.Cents - r.Linked.Cents}
+}
+
+// ReimbursementLink is the part of an expense covered by an income.
+type ReimbursementLink struct {
+	ExpenseID int64
+	Expense   Expense
+	Amount    Money // At most the expense amount
 }
 
 // Domain validation errors.
@@ -89,8 +185,19 @@ var (
 	ErrEmptyPrimary     = errors.New("empty primary category")   // Primary category is empty
 	ErrEmptySecondary   = errors.New("empty secondary category") // Secondary category is empty
 	ErrEmptyCategory    = errors.New("empty category")           // Category is empty (for income)
+	ErrEmptyName        = errors.New("empty name")               // Name is empty (for rules)
+	ErrEmptyExpression  = errors.New("empty expression")         // Rule condition is empty
+	ErrInvalidStatus    = errors.New("invalid status")           // Expense status is not pending or cleared
 )
 
+// ErrVersionConflict is returned when an update was based on a stale version
+// of a record that has been modified in the meantime.
+var ErrVersionConflict = errors.New("version conflict")
+
+// ErrNothingToReimburse is returned when linking an income whose amount, or
+// whose expenses, are already fully reimbursed.
+var ErrNothingToReimburse = errors.New("nothing left to reimburse")
+
 // Validate checks if the Date represents a valid date.
 // It ensures the date is not zero and has valid day/month ranges.
 func (d Date) Validate() error {
@@ -131,6 +238,16 @@ func NewDate(year, month, day int) Date {
 	return Date{Time: time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)}
 }
 
+// EntryDate returns the date a new entry defaults to at now. Before
+// dayStartHour (0 to disable) the night still counts as the previous day,
+// so an expense recorded at 1am lands on the evening it was made.
+func EntryDate(now time.Time, dayStartHour int) Date {
+	if now.Hour() < dayStartHour {
+		now = now.AddDate(0, 0, -1)
+	}
+	return NewDate(now.Year(), int(now.Month()), now.Day())
+}
+
 // IsEmpty returns true if the date is zero (for backward compatibility with optional dates).
 // This is equivalent to IsZero() but provides a more descriptive name for optional date fields.
 func (d Date) IsEmpty() bool {
@@ -168,36 +285,31 @@ func (e Expense) Validate() error {
 	if strings.TrimSpace(e.Secondary) == "" {
 		return ErrEmptySecondary
 	}
-	return nil
+	if e.Status != "" && e.Status != StatusCleared && e.Status != StatusPending {
+		return ErrInvalidStatus
+	}
+	return ValidateTags(e.Tags)
+}
+
+// Placeholder category of expenses still waiting for a real one, such as
+// imported rows nothing recognized.
+const (
+	UncategorizedPrimary   = "Altre spese"
+	UncategorizedSecondary = "Unknown"
+)
+
+// IsUncategorized reports whether e has no category yet: a missing one or
+// the Unknown placeholder.
+func (e Expense) IsUncategorized() bool {
+	return strings.TrimSpace(e.Primary) == "" || strings.TrimSpace(e.Secondary) == "" || e.Secondary == UncategorizedSecondary
 }
 
 // Validate performs comprehensive validation of a RecurrentExpenses configuration.
 // It checks start date validity, end date validity (if provided), ensures end date
 // is after start date, validates repetition type, and checks all other required fields.
 func (re RecurrentExpenses) Validate() error {
-	// Validate start date
-	if err := re.StartDate.Validate(); err != nil {
-		return errors.New("invalid start date: " + err.Error())
-	}
-
-	// Validate end date if provided
-	if !re.EndDate.IsZero() {
-		if err := re.EndDate.Validate(); err != nil {
-			return errors.New("invalid end date: " + err.Error())
-		}
-
-		// Ensure end date is after start date
-		if !re.EndDate.After(re.StartDate.Time) && !re.EndDate.Equal(re.StartDate.Time) {
-			return errors.New("end date must be after start date")
-		}
-	}
-
-	// Validate repetition type
-	switch re.Every {
-	case Daily, Weekly, Monthly, Yearly:
-		// Valid repetition types
-	default:
-		return errors.New("invalid repetition type")
+	if err := re.validateRecurrence(); err != nil {
+		return err
 	}
 
 	// Validate description
@@ -224,9 +336,34 @@ func (re RecurrentExpenses) Validate() error {
 	return nil
 }
 
+// Validate checks the schedule of a RecurrentIncome like that of a
+// RecurrentExpenses, and its other fields like those of an Income.
+func (ri RecurrentIncome) Validate() error {
+	if err := ri.schedule().validateRecurrence(); err != nil {
+		return err
+	}
+	if len(strings.TrimSpace(ri.Description)) == 0 {
+		return ErrEmptyDescription
+	}
+	if len(ri.Description) > 200 {
+		return errors.New("description too long (max 200 characters)")
+	}
+	if err := ri.Amount.Validate(); err != nil {
+		return err
+	}
+	if strings.TrimSpace(ri.Category) == "" {
+		return ErrEmptyCategory
+	}
+	if len(ri.Subcategory) > 50 {
+		return errors.New("subcategory too long (max 50 characters)")
+	}
+	return nil
+}
+
 // Validate performs comprehensive validation of an Income.
 // It checks that the date is valid, description is non-empty and not too long,
-// amount is positive, and category is non-empty.
+// amount is positive, category is non-empty, and subcategory and tags are
+// within their limits.
 func (i Income) Validate() error {
 	if err := i.Date.Validate(); err != nil {
 		return err
@@ -243,5 +380,29 @@ func (i Income) Validate() error {
 	if strings.TrimSpace(i.Category) == "" {
 		return ErrEmptyCategory
 	}
+	if len(i.Subcategory) > 50 {
+		return errors.New("subcategory too long (max 50 characters)")
+	}
+	return ValidateTags(i.Tags)
+}
+
+// Validate checks the fields of a CategoryRule. The expression itself is
+// compiled by the rules package.
+func (r CategoryRule) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return ErrEmptyName
+	}
+	if len(r.Name) > 100 {
+		return errors.New("name too long (max 100 characters)")
+	}
+	if strings.TrimSpace(r.Expression) == "" {
+		return ErrEmptyExpression
+	}
+	if strings.TrimSpace(r.Primary) == "" {
+		return ErrEmptyPrimary
+	}
+	if strings.TrimSpace(r.Secondary) == "" {
+		return ErrEmptySecondary
+	}
 	return nil
 }
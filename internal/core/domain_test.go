@@ -25,6 +25,26 @@ func TestDateValidate(t *testing.T) {
 	}
 }
 
+func TestEntryDate(t *testing.T) {
+	at := func(y int, m time.Month, d, h int) time.Time { return time.Date(y, m, d, h, 30, 0, 0, time.Local) }
+	cases := []struct {
+		now      time.Time
+		startsAt int
+		want     Date
+	}{
+		{at(2025, 3, 10, 2), 0, NewDate(2025, 3, 10)},
+		{at(2025, 3, 10, 2), 4, NewDate(2025, 3, 9)},
+		{at(2025, 3, 10, 4), 4, NewDate(2025, 3, 10)},
+		{at(2025, 3, 1, 0), 4, NewDate(2025, 2, 28)},
+		{at(2026, 1, 1, 3), 4, NewDate(2025, 12, 31)},
+	}
+	for _, tc := range cases {
+		if got := EntryDate(tc.now, tc.startsAt); !got.Equal(tc.want.Time) {
+			t.Errorf("EntryDate(%v, %d) = %v, want %v", tc.now, tc.startsAt, got.Format("2006-01-02"), tc.want.Format("2006-01-02"))
+		}
+	}
+}
+
 func TestMoneyValidate(t *testing.T) {
 	if err := (Money{Cents: 1}).Validate(); err != nil {
 		t.Fatalf("expected ok, got %v", err)
@@ -45,6 +65,10 @@ func TestExpenseValidate(t *testing.T) {
 	if err := good.Validate(); err != nil {
 		t.Fatalf("expected ok, got %v", err)
 	}
+	good.Status = StatusPending
+	if err := good.Validate(); err != nil {
+		t.Fatalf("expected pending ok, got %v", err)
+	}
 
 	bads := []Expense{
 		{Date: Date{Time: time.Time{}}, Description: "a", Amount: Money{Cents: 1}, Primary: "c", Secondary: "s"}, // zero date
@@ -52,6 +76,7 @@ func TestExpenseValidate(t *testing.T) {
 		{Date: NewDate(2025, 1, 1), Description: "a", Amount: Money{Cents: 0}, Primary: "c", Secondary: "s"},
 		{Date: NewDate(2025, 1, 1), Description: "a", Amount: Money{Cents: 1}, Primary: "", Secondary: "s"},
 		{Date: NewDate(2025, 1, 1), Description: "a", Amount: Money{Cents: 1}, Primary: "c", Secondary: ""},
+		{Date: NewDate(2025, 1, 1), Description: "a", Amount: Money{Cents: 1}, Primary: "c", Secondary: "s", Status: "settled"},
 	}
 	for i, e := range bads {
 		if err := e.Validate(); err == nil {
@@ -0,0 +1,120 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxUserNameLength is the longest name of a household member
+const MaxUserNameLength = 30
+
+// ErrInvalidUser is returned for a household member that cannot be stored.
+var ErrInvalidUser = errors.New("invalid user")
+
+// User is a member of a household sharing the instance. Expenses record
+// which member paid them.
+type User struct {
+	ID   int64
+	Name string
+}
+
+// Validate checks that the member has a name of reasonable length.
+func (u User) Validate() error {
+	name := strings.TrimSpace(u.Name)
+	switch {
+	case name == "":
+		return fmt.Errorf("%w: name is required", ErrInvalidUser)
+	case utf8.RuneCountInString(name) > MaxUserNameLength:
+		return fmt.Errorf("%w: name too long", ErrInvalidUser)
+	}
+	return nil
+}
+
+// Transfer is a payment from one member to another settling a month.
+type Transfer struct {
+	From   int64
+	To     int64
+	Amount Money
+}
+
+// Balance is what a member paid in a month against their share of the
+// household's expenses. Positive Net means the others owe them.
+type Balance struct {
+	User  int64
+	Paid  Money
+	Share Money
+	Net   Money
+}
+
+// Settle splits the expenses the members paid equally among them and
+// returns each member's balance, in the order of members, and the
+// transfers that even them out, largest first. Cents that do not split
+// evenly go to the first members. At most len(members)-1 transfers are
+// needed.
+func Settle(members []int64, paid map[int64]int64) ([]Balance, []Transfer) {
+	if len(members) == 0 {
+		return nil, nil
+	}
+
+	var total int64
+	for _, id := range members {
+		total += paid[id]
+	}
+	n := int64(len(members))
+	balances := make([]Balance, len(members))
+	for i, id := range members {
+		share := total / n
+		if int64(i) < total%n {
+			share++
+		}
+		balances[i] = Balance{
+			User:  id,
+			Paid:  Money{Cents: paid[id]},
+			Share: Money{Cents: share},
+			Net:   Money{Cents: paid[id] - share},
+		}
+	}
+
+	// Match the largest debtor with the largest creditor until even
+	type side struct {
+		user  int64
+		cents int64
+	}
+	var debtors, creditors []side
+	for _, b := range balances {
+		switch {
+		case b.Net.Cents < 0:
+			debtors = append(debtors, side{b.User, -b.Net.Cents})
+		case b.Net.Cents > 0:
+			creditors = append(creditors, side{b.User, b.Net.Cents})
+		}
+	}
+	largest := func(s []side) func(i, j int) bool {
+		return func(i, j int) bool {
+			if s[i].cents != s[j].cents {
+				return s[i].cents > s[j].cents
+			}
+			return s[i].user < s[j].user
+		}
+	}
+	sort.SliceStable(debtors, largest(debtors))
+	sort.SliceStable(creditors, largest(creditors))
+
+	var transfers []Transfer
+	for d, c := 0, 0; d < len(debtors) && c < len(creditors); {
+		amount := min(debtors[d].cents, creditors[c].cents)
+		transfers = append(transfers, Transfer{From: debtors[d].user, To: creditors[c].user, Amount: Money{Cents: amount}})
+		debtors[d].cents -= amount
+		creditors[c].cents -= amount
+		if debtors[d].cents == 0 {
+			d++
+		}
+		if creditors[c].cents == 0 {
+			c++
+		}
+	}
+	return balances, transfers
+}
@@ -0,0 +1,50 @@
+package core
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestUser_Validate(t *testing.T) {
+	if err := (User{Name: "Anna"}).Validate(); err != nil {
+		t.Fatalf("valid user refused: %v", err)
+	}
+	for _, u := range []User{{Name: "  "}, {Name: strings.Repeat("a", MaxUserNameLength+1)}} {
+		if err := u.Validate(); !errors.Is(err, ErrInvalidUser) {
+			t.Errorf("Validate(%q) = %v, want ErrInvalidUser", u.Name, err)
+		}
+	}
+}
+
+func TestSettle(t *testing.T) {
+	// Two members: the one who paid less owes half the difference
+	balances, transfers := Settle([]int64{1, 2}, map[int64]int64{1: 30000, 2: 10000})
+	if balances[0].Share.Cents != 20000 || balances[0].Net.Cents != 10000 || balances[1].Net.Cents != -10000 {
+		t.Errorf("balances = %+v", balances)
+	}
+	if len(transfers) != 1 || transfers[0] != (Transfer{From: 2, To: 1, Amount: Money{Cents: 10000}}) {
+		t.Errorf("transfers = %+v, want 2 pays 1 100.00", transfers)
+	}
+
+	// Odd cents go to the first members, and shares add up to the total
+	balances, transfers = Settle([]int64{1, 2, 3}, map[int64]int64{1: 100})
+	var shares int64
+	for _, b := range balances {
+		shares += b.Share.Cents
+	}
+	if shares != 100 || balances[0].Share.Cents != 34 || balances[2].Share.Cents != 33 {
+		t.Errorf("odd split: balances = %+v", balances)
+	}
+	if len(transfers) != 2 || transfers[0].Amount.Cents+transfers[1].Amount.Cents != 66 {
+		t.Errorf("odd split: transfers = %+v", transfers)
+	}
+
+	// Even months and no members need no transfers
+	if _, transfers := Settle([]int64{1, 2}, map[int64]int64{1: 500, 2: 500}); len(transfers) != 0 {
+		t.Errorf("even month: transfers = %+v", transfers)
+	}
+	if balances, transfers := Settle(nil, map[int64]int64{1: 500}); balances != nil || transfers != nil {
+		t.Errorf("no members: %v %v", balances, transfers)
+	}
+}
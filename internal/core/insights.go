@@ -0,0 +1,162 @@
+package core
+
+import (
+	"sort"
+	"time"
+)
+
+// InsightKind is the kind of a spending insight.
+type InsightKind string
+
+// Insight kinds
+const (
+	// InsightCategoryUp: a category costs more than in the same days of
+	// the previous month
+	InsightCategoryUp InsightKind = "category_up"
+	// InsightCategoryBack: first expense in a category after at least a
+	// month without any
+	InsightCategoryBack InsightKind = "category_back"
+	// InsightBudgetReached: a category already cost as much as in the
+	// whole previous month, which acts as its budget
+	InsightBudgetReached InsightKind = "budget_reached"
+)
+
+// Insight detection thresholds
+const (
+	// InsightMinCents is the smallest baseline worth comparing against,
+	// so a €2 coffee last month does not make a €3 one "+50%"
+	InsightMinCents = 2000
+	// InsightChangePercent is the increase that makes a category go up
+	InsightChangePercent = 40
+	// insightHistoryMonths is how far back a category may have been quiet
+	insightHistoryMonths = 12
+)
+
+// Insight is something noteworthy about the spending of a primary
+// category in a month. At most one insight of a kind exists per category
+// and month.
+type Insight struct {
+	ID       int64
+	Kind     InsightKind
+	Primary  string
+	Period   string // Month the insight is about, "2006-01"
+	Date     Date   // Day of the expense that triggered it
+	Amount   Money  // Spending in the category in the period; the first expense for InsightCategoryBack
+	Baseline Money  // What Amount is compared with
+	Days     int    // Days before the end of the month, for InsightBudgetReached
+	Since    string // Month of the previous expense, for InsightCategoryBack
+}
+
+// ChangePercent returns how much Amount exceeds Baseline, in percent.
+func (i Insight) ChangePercent() int {
+	if i.Baseline.Cents <= 0 {
+		return 0
+	}
+	return int((i.Amount.Cents - i.Baseline.Cents) * 100 / i.Baseline.Cents)
+}
+
+// InsightHistoryStart returns the first day of the history DetectInsights
+// needs for the month of now.
+func InsightHistoryStart(now time.Time) time.Time {
+	return time.Date(now.Year(), now.Month()-insightHistoryMonths, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// DetectInsights returns the insights about the month of now, from the
+// expenses since InsightHistoryStart(now). Expenses dated after now are
+// ignored. Insights are sorted by date, then kind and category.
+func DetectInsights(expenses []Expense, now time.Time) []Insight {
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	prevStart := monthStart.AddDate(0, -1, 0)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	daysInMonth := monthStart.AddDate(0, 1, -1).Day()
+	period := monthStart.Format("2006-01")
+	// Same days of the previous month, cut to its length
+	prevSameDay := prevStart.AddDate(0, 0, min(now.Day(), prevStart.AddDate(0, 1, -1).Day())-1)
+
+	type categoryStats struct {
+		current    []Expense // this month, by date
+		prevToDay  int64     // previous month up to the same day
+		prevTotal  int64     // whole previous month
+		lastBefore time.Time // last expense before this month
+	}
+	stats := make(map[string]*categoryStats)
+	for _, e := range expenses {
+		day := time.Date(e.Date.Time.Year(), e.Date.Time.Month(), e.Date.Time.Day(), 0, 0, 0, 0, time.UTC)
+		if day.After(today) || e.Primary == "" {
+			continue
+		}
+		st, ok := stats[e.Primary]
+		if !ok {
+			st = &categoryStats{}
+			stats[e.Primary] = st
+		}
+		switch {
+		case !day.Before(monthStart):
+			st.current = append(st.current, e)
+		case !day.Before(prevStart):
+			st.prevTotal += e.Amount.Cents
+			if !day.After(prevSameDay) {
+				st.prevToDay += e.Amount.Cents
+			}
+		}
+		if day.Before(monthStart) && day.After(st.lastBefore) {
+			st.lastBefore = day
+		}
+	}
+
+	var insights []Insight
+	for primary, st := range stats {
+		if len(st.current) == 0 {
+			continue
+		}
+		sort.SliceStable(st.current, func(i, j int) bool { return st.current[i].Date.Before(st.current[j].Date.Time) })
+		var total int64
+		for _, e := range st.current {
+			total += e.Amount.Cents
+		}
+		base := Insight{Primary: primary, Period: period, Amount: Money{Cents: total}}
+
+		if st.prevToDay >= InsightMinCents && total*100 >= st.prevToDay*(100+InsightChangePercent) {
+			in := base
+			in.Kind, in.Baseline, in.Date = InsightCategoryUp, Money{Cents: st.prevToDay}, st.current[len(st.current)-1].Date
+			insights = append(insights, in)
+		}
+
+		// Quiet for at least a whole month: the previous expense is older
+		// than the start of the previous month
+		if !st.lastBefore.IsZero() && st.lastBefore.Before(prevStart) {
+			first := st.current[0]
+			in := base
+			in.Kind, in.Date, in.Since = InsightCategoryBack, first.Date, st.lastBefore.Format("2006-01")
+			in.Amount = first.Amount
+			insights = append(insights, in)
+		}
+
+		if st.prevTotal >= InsightMinCents && total >= st.prevTotal {
+			var running int64
+			for _, e := range st.current {
+				running += e.Amount.Cents
+				if running >= st.prevTotal {
+					if days := daysInMonth - e.Date.Day(); days > 0 {
+						in := base
+						in.Kind, in.Baseline, in.Date, in.Days = InsightBudgetReached, Money{Cents: st.prevTotal}, e.Date, days
+						insights = append(insights, in)
+					}
+					break
+				}
+			}
+		}
+	}
+
+	sort.Slice(insights, func(i, j int) bool {
+		a, b := insights[i], insights[j]
+		if !a.Date.Equal(b.Date.Time) {
+			return a.Date.Before(b.Date.Time)
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Primary < b.Primary
+	})
+	return insights
+}
@@ -0,0 +1,60 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDetectInsights(t *testing.T) {
+	day := func(y int, m time.Month, d int) Date { return Date{Time: time.Date(y, m, d, 0, 0, 0, 0, time.UTC)} }
+	exp := func(date Date, primary string, cents int64) Expense {
+		return Expense{Date: date, Description: "x", Amount: Money{Cents: cents}, Primary: primary, Secondary: "y"}
+	}
+	now := time.Date(2026, 10, 20, 15, 0, 0, 0, time.UTC)
+	expenses := []Expense{
+		// Restaurants: €50 by 20 September, €80 by 20 October
+		exp(day(2026, 9, 5), "Fuori", 5000),
+		exp(day(2026, 9, 25), "Fuori", 1000),
+		exp(day(2026, 10, 3), "Fuori", 3000),
+		exp(day(2026, 10, 18), "Fuori", 5000),
+		// Groceries: €100 in September, reached on 14 October
+		exp(day(2026, 9, 10), "Spesa", 10000),
+		exp(day(2026, 10, 2), "Spesa", 6000),
+		exp(day(2026, 10, 14), "Spesa", 4000),
+		// Travel: nothing since March
+		exp(day(2026, 3, 12), "Viaggi", 20000),
+		exp(day(2026, 10, 9), "Viaggi", 15000),
+		// Last month too: not quiet
+		exp(day(2026, 9, 1), "Casa", 500),
+		exp(day(2026, 10, 1), "Casa", 500),
+		// Small baseline: no change insight
+		exp(day(2026, 9, 1), "Salute", 500),
+		exp(day(2026, 10, 1), "Salute", 5000),
+		// Future expense is ignored
+		exp(day(2026, 10, 28), "Fuori", 90000),
+	}
+
+	got := DetectInsights(expenses, now)
+	want := []Insight{
+		{Kind: InsightCategoryBack, Primary: "Viaggi", Date: day(2026, 10, 9), Amount: Money{15000}, Since: "2026-03"},
+		{Kind: InsightBudgetReached, Primary: "Spesa", Date: day(2026, 10, 14), Amount: Money{10000}, Baseline: Money{10000}, Days: 17},
+		{Kind: InsightBudgetReached, Primary: "Fuori", Date: day(2026, 10, 18), Amount: Money{8000}, Baseline: Money{6000}, Days: 13},
+		{Kind: InsightCategoryUp, Primary: "Fuori", Date: day(2026, 10, 18), Amount: Money{8000}, Baseline: Money{5000}},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d insights, want %d: %+v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		w.Period = "2026-10"
+		if got[i] != w {
+			t.Errorf("insight %d = %+v, want %+v", i, got[i], w)
+		}
+	}
+	if p := got[3].ChangePercent(); p != 60 {
+		t.Errorf("ChangePercent = %d, want 60", p)
+	}
+
+	if got := InsightHistoryStart(now); !got.Equal(time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("InsightHistoryStart = %v", got)
+	}
+}
@@ -0,0 +1,43 @@
+package core
+
+import "time"
+
+// JobStatus is the state of a run of a background job.
+type JobStatus string
+
+const (
+	JobRunning     JobStatus = "running"
+	JobSucceeded   JobStatus = "succeeded"
+	JobFailed      JobStatus = "failed"
+	JobInterrupted JobStatus = "interrupted" // the process stopped during the run
+)
+
+// JobSource tells what started a run of a background job.
+type JobSource string
+
+const (
+	JobScheduled JobSource = "schedule"
+	JobManual    JobSource = "manual"
+	JobRetry     JobSource = "retry" // a new attempt after a failed run
+)
+
+// JobRun is one attempt at running a background job.
+type JobRun struct {
+	ID         int64
+	Job        string
+	Source     JobSource
+	Attempt    int // 1 for the first attempt, counting retries
+	Status     JobStatus
+	StartedAt  time.Time
+	FinishedAt time.Time // zero while running
+	Result     string    // summary of what a successful run did, e.g. "3 expenses"
+	Error      string
+}
+
+// Duration returns how long the run took, or has been running at now.
+func (r JobRun) Duration(now time.Time) time.Duration {
+	if r.FinishedAt.IsZero() {
+		return now.Sub(r.StartedAt)
+	}
+	return r.FinishedAt.Sub(r.StartedAt)
+}
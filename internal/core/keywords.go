@@ -0,0 +1,67 @@
+package core
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// MaxKeywordLength bounds a dictionary keyword, after normalization.
+const MaxKeywordLength = 100
+
+var ErrEmptyKeyword = errors.New("empty keyword")
+
+// CategoryKeyword maps a merchant keyword, e.g. "esselunga", to the
+// category of the expenses whose description contains it. Builtin keywords
+// ship with the app; user overrides replace them or, when Disabled, turn
+// them off.
+type CategoryKeyword struct {
+	Keyword   string // Normalized, see NormalizeKeyword
+	Primary   string
+	Secondary string
+	Disabled  bool
+	Builtin   bool
+}
+
+// NormalizeKeyword lowercases s and reduces it to its words separated by
+// single spaces, so "McDonald's  Milano" becomes "mcdonald s milano".
+// Descriptions are normalized the same way before matching.
+func NormalizeKeyword(s string) string {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, " ")
+}
+
+// Validate checks the keyword and, unless the entry only disables a
+// keyword, its categories.
+func (k CategoryKeyword) Validate() error {
+	if k.Keyword == "" {
+		return ErrEmptyKeyword
+	}
+	if k.Keyword != NormalizeKeyword(k.Keyword) {
+		return errors.New("keyword is not normalized")
+	}
+	if len(k.Keyword) > MaxKeywordLength {
+		return errors.New("keyword too long (max 100 characters)")
+	}
+	if k.Disabled {
+		return nil
+	}
+	if strings.TrimSpace(k.Primary) == "" {
+		return ErrEmptyPrimary
+	}
+	if strings.TrimSpace(k.Secondary) == "" {
+		return ErrEmptySecondary
+	}
+	return nil
+}
+
+// MatchesDescription reports whether the keyword appears in description as
+// whole words: "coop" matches "COOP Lombardia" but not "cooperativa".
+func (k CategoryKeyword) MatchesDescription(description string) bool {
+	if k.Keyword == "" {
+		return false
+	}
+	return strings.Contains(" "+NormalizeKeyword(description)+" ", " "+k.Keyword+" ")
+}
@@ -0,0 +1,64 @@
+package core
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeKeyword(t *testing.T) {
+	cases := map[string]string{
+		"  McDonald's  Milano":  "mcdonald s milano",
+		"ESSELUNGA/Viale Piave": "esselunga viale piave",
+		"Caffè 2x":              "caffè 2x",
+		"--":                    "",
+	}
+	for in, want := range cases {
+		if got := NormalizeKeyword(in); got != want {
+			t.Errorf("NormalizeKeyword(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestCategoryKeywordMatchesDescription(t *testing.T) {
+	k := CategoryKeyword{Keyword: "coop", Primary: "Spesa", Secondary: "Altre spese (non Everli)"}
+	for desc, want := range map[string]bool{
+		"COOP Lombardia":      true,
+		"Spesa alla Coop.":    true,
+		"Cooperativa sociale": false,
+		"":                    false,
+	} {
+		if got := k.MatchesDescription(desc); got != want {
+			t.Errorf("MatchesDescription(%q) = %v, want %v", desc, got, want)
+		}
+	}
+
+	multi := CategoryKeyword{Keyword: "just eat"}
+	if !multi.MatchesDescription("Ordine JUST-EAT #123") || multi.MatchesDescription("just a meal to eat") {
+		t.Error("multi-word keyword must match consecutive words only")
+	}
+}
+
+func TestCategoryKeywordValidate(t *testing.T) {
+	good := CategoryKeyword{Keyword: "esselunga", Primary: "Spesa", Secondary: "Altre spese (non Everli)"}
+	if err := good.Validate(); err != nil {
+		t.Fatalf("expected ok, got %v", err)
+	}
+	if err := (CategoryKeyword{Keyword: "esselunga", Disabled: true}).Validate(); err != nil {
+		t.Errorf("disabling needs no categories, got %v", err)
+	}
+
+	bads := map[string]func(*CategoryKeyword){
+		"empty":          func(k *CategoryKeyword) { k.Keyword = "" },
+		"not normalized": func(k *CategoryKeyword) { k.Keyword = "Esselunga" },
+		"too long":       func(k *CategoryKeyword) { k.Keyword = strings.Repeat("x", MaxKeywordLength+1) },
+		"no primary":     func(k *CategoryKeyword) { k.Primary = " " },
+		"no secondary":   func(k *CategoryKeyword) { k.Secondary = "" },
+	}
+	for name, mutate := range bads {
+		k := good
+		mutate(&k)
+		if err := k.Validate(); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package core
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidLedgerCursor is returned when a ledger page cursor cannot be
+// parsed.
+var ErrInvalidLedgerCursor = errors.New("invalid ledger cursor")
+
+// LedgerCursor is the position of an expense in the ledger, which lists
+// expenses newest first and by descending ID within a day: the next page
+// starts right after it.
+type LedgerCursor struct {
+	Date Date
+	ID   int64
+}
+
+// String encodes the cursor as "2006-01-02.ID", for page links.
+func (c LedgerCursor) String() string {
+	return c.Date.Format("2006-01-02") + "." + strconv.FormatInt(c.ID, 10)
+}
+
+// ParseLedgerCursor parses a cursor encoded by String.
+func ParseLedgerCursor(s string) (LedgerCursor, error) {
+	day, id, ok := strings.Cut(strings.TrimSpace(s), ".")
+	if !ok {
+		return LedgerCursor{}, ErrInvalidLedgerCursor
+	}
+	t, err := time.Parse("2006-01-02", day)
+	if err != nil {
+		return LedgerCursor{}, ErrInvalidLedgerCursor
+	}
+	n, err := strconv.ParseInt(id, 10, 64)
+	if err != nil || n <= 0 {
+		return LedgerCursor{}, ErrInvalidLedgerCursor
+	}
+	return LedgerCursor{Date: Date{Time: t}, ID: n}, nil
+}
@@ -0,0 +1,26 @@
+package core
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLedgerCursor_RoundTrip(t *testing.T) {
+	c := LedgerCursor{Date: NewDate(2031, 2, 9), ID: 42}
+	if got := c.String(); got != "2031-02-09.42" {
+		t.Fatalf("String() = %q", got)
+	}
+	parsed, err := ParseLedgerCursor(c.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !parsed.Date.Equal(c.Date.Time) || parsed.ID != c.ID {
+		t.Fatalf("ParseLedgerCursor() = %+v, want %+v", parsed, c)
+	}
+
+	for _, bad := range []string{"", "2031-02-09", "2031-02-09.", "2031-02-30.4", "2031-02-09.0", "2031-02-09.x"} {
+		if _, err := ParseLedgerCursor(bad); !errors.Is(err, ErrInvalidLedgerCursor) {
+			t.Errorf("ParseLedgerCursor(%q) error = %v, want ErrInvalidLedgerCursor", bad, err)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package core
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxLineItems caps the line items of an expense
+const MaxLineItems = 200
+
+// LineItem is a line of the receipt behind an expense. Its categories are
+// optional: without them the line stays in the expense's categories.
+type LineItem struct {
+	Description string
+	Quantity    float64
+	Amount      Money // Total of the line
+	Primary     string
+	Secondary   string
+}
+
+// Validate checks the description, a positive quantity and amount, and
+// that a secondary category comes with a primary one.
+func (l LineItem) Validate() error {
+	if strings.TrimSpace(l.Description) == "" {
+		return ErrEmptyDescription
+	}
+	if utf8.RuneCountInString(l.Description) > 200 {
+		return errors.New("description too long (max 200 characters)")
+	}
+	if l.Quantity <= 0 || l.Quantity > MaxCalculationQuantity {
+		return errors.New("invalid quantity")
+	}
+	if err := l.Amount.Validate(); err != nil {
+		return err
+	}
+	if strings.TrimSpace(l.Primary) == "" && strings.TrimSpace(l.Secondary) != "" {
+		return ErrEmptyPrimary
+	}
+	return nil
+}
+
+// AllocateLineItems returns the part of the expense total each line
+// accounts for. The expense amount is authoritative: lines adding up to
+// less leave the rest to the expense's own categories, while lines adding
+// up to more are scaled down proportionally so that they sum to the total.
+func AllocateLineItems(total Money, lines []LineItem) []Money {
+	allocated := make([]Money, len(lines))
+	var sum int64
+	for i, l := range lines {
+		allocated[i] = l.Amount
+		sum += l.Amount.Cents
+	}
+	if sum <= total.Cents || sum == 0 {
+		return allocated
+	}
+
+	// Largest remainder: floor every share, then hand the leftover cents to
+	// the lines that lost the most to rounding
+	remainders := make([]int64, len(lines))
+	var given int64
+	for i, l := range lines {
+		scaled := l.Amount.Cents * total.Cents
+		allocated[i] = Money{Cents: scaled / sum}
+		remainders[i] = scaled % sum
+		given += allocated[i].Cents
+	}
+	for ; given < total.Cents; given++ {
+		best := 0
+		for i := range remainders {
+			if remainders[i] > remainders[best] {
+				best = i
+			}
+		}
+		allocated[best].Cents++
+		remainders[best] = -1
+	}
+	return allocated
+}
+
+var (
+	// "x2", "2x", "×2"
+	quantityToken = regexp.MustCompile(`^(?:[xX×](\d+(?:[.,]\d+)?)|(\d+(?:[.,]\d+)?)[xX×])$`)
+	// Line totals always carry the cents, unlike codes and counts
+	receiptAmount = regexp.MustCompile(`^\d+[.,]\d{2}$`)
+	// Receipt lines that are not items
+	receiptTotals = regexp.MustCompile(`^(sub)?totale|^resto|^contanti?\b|^carta\b|^pagamento|^iva\b|^sconto totale`)
+)
+
+// ParseReceiptLines reads line items from receipt text, as typed or read by
+// OCR: one item per line with the line total last, in euros with cents, e.g.
+// "Latte intero x2 3,38" or "Latte intero 2 x 1,69 3,38". Totals, payment
+// lines and lines without an amount are returned as skipped.
+func ParseReceiptLines(text string) (items []LineItem, skipped []string) {
+	for _, raw := range strings.Split(text, "\n") {
+		line := strings.TrimSpace(raw)
+		if line == "" {
+			continue
+		}
+		if receiptTotals.MatchString(strings.ToLower(line)) {
+			skipped = append(skipped, line)
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if last := fields[len(fields)-1]; last == "€" || strings.EqualFold(last, "EUR") {
+			fields = fields[:len(fields)-1]
+		}
+		if len(fields) < 2 {
+			skipped = append(skipped, line)
+			continue
+		}
+		last := strings.Trim(fields[len(fields)-1], "€")
+		if !receiptAmount.MatchString(last) {
+			skipped = append(skipped, line)
+			continue
+		}
+		amount, err := ParseDecimalToCents(last)
+		if err != nil {
+			skipped = append(skipped, line)
+			continue
+		}
+		fields = fields[:len(fields)-1]
+
+		item := LineItem{Quantity: 1, Amount: Money{Cents: amount}}
+		var desc []string
+		for i := 0; i < len(fields); i++ {
+			// "2 x 1,69": quantity times unit price
+			if i+2 < len(fields) && (fields[i+1] == "x" || fields[i+1] == "X" || fields[i+1] == "×") {
+				if q, err := ParseQuantity(fields[i]); err == nil && q > 0 {
+					item.Quantity = q
+					i += 2
+					continue
+				}
+			}
+			if m := quantityToken.FindStringSubmatch(fields[i]); m != nil {
+				if q, err := ParseQuantity(m[1] + m[2]); err == nil && q > 0 {
+					item.Quantity = q
+					continue
+				}
+			}
+			desc = append(desc, fields[i])
+		}
+		item.Description = strings.Join(desc, " ")
+		if item.Validate() != nil {
+			skipped = append(skipped, line)
+			continue
+		}
+		items = append(items, item)
+	}
+	return items, skipped
+}
@@ -0,0 +1,48 @@
+package core
+
+import "testing"
+
+func TestAllocateLineItems(t *testing.T) {
+	lines := []LineItem{{Amount: Money{Cents: 600}}, {Amount: Money{Cents: 300}}}
+
+	// Less than the total: the rest stays with the expense
+	if got := AllocateLineItems(Money{Cents: 1000}, lines); got[0].Cents != 600 || got[1].Cents != 300 {
+		t.Errorf("under total: %v", got)
+	}
+	// More than the total: scaled down to sum to it
+	got := AllocateLineItems(Money{Cents: 100}, lines)
+	if got[0].Cents != 67 || got[1].Cents != 33 {
+		t.Errorf("over total: %v", got)
+	}
+	if got := AllocateLineItems(Money{Cents: 100}, nil); len(got) != 0 {
+		t.Errorf("no lines: %v", got)
+	}
+}
+
+func TestParseReceiptLines(t *testing.T) {
+	text := `
+LATTE INTERO  2 x 1,69  3,38
+Pane x2 2.50 €
+Caffè 250g 3,99
+SUBTOTALE 9,87
+Scontrino n. 42
+TOTALE EURO 9,87
+`
+	items, skipped := ParseReceiptLines(text)
+	want := []LineItem{
+		{Description: "LATTE INTERO", Quantity: 2, Amount: Money{Cents: 338}},
+		{Description: "Pane", Quantity: 2, Amount: Money{Cents: 250}},
+		{Description: "Caffè 250g", Quantity: 1, Amount: Money{Cents: 399}},
+	}
+	if len(items) != len(want) {
+		t.Fatalf("items = %+v", items)
+	}
+	for i := range want {
+		if items[i] != want[i] {
+			t.Errorf("item %d = %+v, want %+v", i, items[i], want[i])
+		}
+	}
+	if len(skipped) != 3 {
+		t.Errorf("skipped = %q", skipped)
+	}
+}
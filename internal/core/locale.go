@@ -0,0 +1,91 @@
+package core
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Locale is a UI language category names can be displayed in.
+type Locale string
+
+const (
+	LocaleItalian Locale = "it"
+	LocaleEnglish Locale = "en"
+)
+
+// DefaultLocale is the language of the stored category names, which stay
+// the keys used by expenses, rules and Sheets sync whatever the UI shows.
+const DefaultLocale = LocaleItalian
+
+// Locales lists the supported locales, the default first.
+var Locales = []Locale{LocaleItalian, LocaleEnglish}
+
+// ParseLocale returns the supported locale named s, ignoring case and any
+// region ("en-GB" is English).
+func ParseLocale(s string) (Locale, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if i := strings.IndexAny(s, "-_"); i >= 0 {
+		s = s[:i]
+	}
+	for _, l := range Locales {
+		if string(l) == s {
+			return l, true
+		}
+	}
+	return "", false
+}
+
+// NegotiateLocale picks the supported locale the Accept-Language header
+// prefers, falling back to the default.
+func NegotiateLocale(acceptLanguage string) Locale {
+	type tag struct {
+		locale Locale
+		q      float64
+	}
+	var tags []tag
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		l, ok := ParseLocale(name)
+		if !ok {
+			continue
+		}
+		q := 1.0
+		if v, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		if q > 0 {
+			tags = append(tags, tag{l, q})
+		}
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+	if len(tags) == 0 {
+		return DefaultLocale
+	}
+	return tags[0].locale
+}
+
+// CategoryLabels are the display names of categories in a locale, by key.
+// Categories without a translation are shown by their key.
+type CategoryLabels struct {
+	Primary   map[string]string
+	Secondary map[string]string
+}
+
+// PrimaryLabel returns the display name of a primary category.
+func (l CategoryLabels) PrimaryLabel(key string) string {
+	if name, ok := l.Primary[key]; ok {
+		return name
+	}
+	return key
+}
+
+// SecondaryLabel returns the display name of a secondary category.
+func (l CategoryLabels) SecondaryLabel(key string) string {
+	if name, ok := l.Secondary[key]; ok {
+		return name
+	}
+	return key
+}
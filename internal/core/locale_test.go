@@ -0,0 +1,34 @@
+package core
+
+import "testing"
+
+func TestNegotiateLocale(t *testing.T) {
+	tests := []struct {
+		header string
+		want   Locale
+	}{
+		{"", LocaleItalian},
+		{"en-GB,en;q=0.9", LocaleEnglish},
+		{"fr-FR, it;q=0.8, en;q=0.5", LocaleItalian},
+		{"de, en;q=0.3", LocaleEnglish},
+		{"it;q=0, en", LocaleEnglish},
+	}
+	for _, tt := range tests {
+		if got := NegotiateLocale(tt.header); got != tt.want {
+			t.Errorf("NegotiateLocale(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestCategoryLabels(t *testing.T) {
+	labels := CategoryLabels{Primary: map[string]string{"Casa": "Home"}}
+	if got := labels.PrimaryLabel("Casa"); got != "Home" {
+		t.Errorf("PrimaryLabel = %q", got)
+	}
+	if got := labels.PrimaryLabel("Bimbi"); got != "Bimbi" {
+		t.Errorf("untranslated PrimaryLabel = %q, want the key", got)
+	}
+	if got := labels.SecondaryLabel("Mutuo"); got != "Mutuo" {
+		t.Errorf("SecondaryLabel with no map = %q", got)
+	}
+}
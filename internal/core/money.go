@@ -2,10 +2,16 @@
 //
 // This file contains functions for parsing monetary amounts from strings
 // and converting between cents and euro representations.
+//
+// Every conversion that has to drop precision (more than two decimals,
+// float inputs, divisions) uses the same rounding policy: round half to
+// even ("banker's rounding"). Ties go to the nearest even cent, so repeated
+// conversions do not drift upwards and negative values round symmetrically.
 package core
 
 import (
 	"errors"
+	"math"
 	"strconv"
 	"strings"
 	"unicode"
@@ -13,25 +19,60 @@ import (
 
 // ParseDecimalToCents converts a decimal string to cents with proper rounding.
 //
-// It accepts both dot (12.34) and comma (12,34) decimal separators and performs
-// half-up rounding on the third decimal place. The result is always positive cents.
+// It accepts both dot (12.34) and comma (12,34) decimal separators, thousands
+// separators as described in normalizeSeparators, and rounds any digits
+// beyond the second decimal half to even. The result is always positive cents.
 // Returns an error for invalid formats, negative values, or zero amounts.
 //
 // Examples:
 //
 //	ParseDecimalToCents("12.34") -> 1234, nil
 //	ParseDecimalToCents("12,34") -> 1234, nil
-//	ParseDecimalToCents("12.345") -> 1234, nil (rounds down)
+//	ParseDecimalToCents("1.234,56") -> 123456, nil
+//	ParseDecimalToCents("12.345") -> 1234, nil (tie, rounds to even)
+//	ParseDecimalToCents("12.355") -> 1236, nil (tie, rounds to even)
 //	ParseDecimalToCents("12.346") -> 1235, nil (rounds up)
 func ParseDecimalToCents(s string) (int64, error) {
 	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "+") || strings.HasPrefix(s, "-") {
+		// Only positive values allowed
+		return 0, ErrInvalidAmount
+	}
+	cents, err := ParseSignedDecimalToCents(s)
+	if err != nil {
+		return 0, err
+	}
+	if cents <= 0 {
+		return 0, ErrInvalidAmount
+	}
+	return cents, nil
+}
+
+// ParseSignedDecimalToCents converts a decimal string that may carry a sign
+// to cents, rounding half to even beyond the second decimal.
+//
+// Unlike ParseDecimalToCents it accepts zero and negative amounts, which makes
+// it suitable for values read back from external sources such as spreadsheets.
+//
+// Examples:
+//
+//	ParseSignedDecimalToCents("-12.345") -> -1234, nil
+//	ParseSignedDecimalToCents("0,125") -> 12, nil
+func ParseSignedDecimalToCents(s string) (int64, error) {
+	s = amountNoise.Replace(s)
 	if s == "" {
 		return 0, ErrInvalidAmount
 	}
-	// Normalize decimal comma to dot
-	s = strings.ReplaceAll(s, ",", ".")
-	if strings.HasPrefix(s, "+") || strings.HasPrefix(s, "-") {
-		// Only positive values allowed
+	negative := false
+	switch s[0] {
+	case '-':
+		negative = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+	s, ok := normalizeSeparators(s)
+	if !ok {
 		return 0, ErrInvalidAmount
 	}
 	// Split into integer and fractional part
@@ -44,6 +85,9 @@ func ParseDecimalToCents(s string) (int64, error) {
 	if len(parts) == 2 {
 		fracPart = parts[1]
 	}
+	if intPart == "" && fracPart == "" {
+		return 0, ErrInvalidAmount
+	}
 	if intPart == "" {
 		intPart = "0"
 	}
@@ -62,31 +106,183 @@ func ParseDecimalToCents(s string) (int64, error) {
 	if err != nil {
 		return 0, ErrInvalidAmount
 	}
-	// Prevent overflow when multiplying by 100
+	// Prevent overflow when multiplying by 100 (and rounding up by one cent)
 	const maxSafeInt64 = (1<<63 - 1) / 100
-	if iv > maxSafeInt64 {
+	if iv >= maxSafeInt64 {
 		return 0, ErrInvalidAmount
 	}
-	// Take first two fractional digits; then half-up rounding on third
-	var fracCents int64 = 0
-	if len(fracPart) > 0 {
-		d1 := int64(fracPart[0] - '0')
-		fracCents = d1 * 10
-		if len(fracPart) > 1 {
-			d2 := int64(fracPart[1] - '0')
-			fracCents += d2
-			if len(fracPart) > 2 {
-				if fracPart[2] >= '5' {
-					fracCents++
-				}
+	// Pad to two fractional digits; the remaining ones drive rounding
+	for len(fracPart) < 2 {
+		fracPart += "0"
+	}
+	fracCents := int64(fracPart[0]-'0')*10 + int64(fracPart[1]-'0')
+	cents := iv*100 + fracCents
+	if roundsUp(cents, fracPart[2:]) {
+		cents++
+	}
+	if negative {
+		cents = -cents
+	}
+	return cents, nil
+}
+
+// amountNoise strips what people type around an amount: the currency
+// symbol and spaces, including the non-breaking ones some locales group
+// thousands with.
+var amountNoise = strings.NewReplacer("€", "", " ", "", "\u00a0", "", "\u202f", "")
+
+// normalizeSeparators rewrites an unsigned amount with a dot as its only
+// separator, the decimal one. The same rules hint the amount in the forms:
+//   - with both a dot and a comma, the last one is the decimal separator
+//     and the other groups thousands ("1.234,56", "1,234.56");
+//   - a separator repeated without the other groups thousands ("1.234.567");
+//   - a single separator is the decimal one ("12,5", "12.50", "1.234").
+//
+// Thousands groups must have three digits, so "1.2.3" is rejected rather
+// than read as 123.
+func normalizeSeparators(s string) (string, bool) {
+	dot, comma := strings.LastIndex(s, "."), strings.LastIndex(s, ",")
+	var group, decimal string
+	switch {
+	case dot >= 0 && comma >= 0 && comma > dot:
+		group, decimal = ".", ","
+	case dot >= 0 && comma >= 0:
+		group, decimal = ",", "."
+	case strings.Count(s, ".") > 1:
+		group = "."
+	case strings.Count(s, ",") > 1:
+		group = ","
+	case comma >= 0:
+		decimal = ","
+	}
+
+	intPart, fracPart, hasDecimal := s, "", false
+	if decimal != "" {
+		if strings.Count(s, decimal) > 1 {
+			return "", false
+		}
+		intPart, fracPart, hasDecimal = strings.Cut(s, decimal)
+	} else if group == "" {
+		// Only a dot, if any: already normalized
+		return s, true
+	}
+	if group != "" {
+		groups := strings.Split(intPart, group)
+		if len(groups[0]) == 0 || len(groups[0]) > 3 {
+			return "", false
+		}
+		for _, g := range groups[1:] {
+			if len(g) != 3 {
+				return "", false
 			}
 		}
+		intPart = strings.Join(groups, "")
 	}
-	cents := iv*100 + fracCents
-	if cents <= 0 {
+	if !hasDecimal {
+		return intPart, true
+	}
+	return intPart + "." + fracPart, true
+}
+
+// roundsUp reports whether a non-negative cents value whose discarded digits
+// are rest must be incremented under half-to-even rounding.
+func roundsUp(cents int64, rest string) bool {
+	if rest == "" {
+		return false
+	}
+	switch {
+	case rest[0] > '5':
+		return true
+	case rest[0] < '5':
+		return false
+	}
+	// Exactly half only if every following digit is zero
+	if strings.Trim(rest[1:], "0") != "" {
+		return true
+	}
+	return cents%2 != 0
+}
+
+// CentsFromFloat converts a euro amount expressed as float64 to cents using
+// half-to-even rounding.
+//
+// The float is first rendered with the shortest decimal representation that
+// round-trips, so values such as 1.005 are treated as the decimal the user
+// typed rather than its binary approximation (1.00499999...).
+// Returns an error for NaN, infinities and values that overflow int64 cents.
+func CentsFromFloat(euros float64) (int64, error) {
+	if math.IsNaN(euros) || math.IsInf(euros, 0) {
 		return 0, ErrInvalidAmount
 	}
-	return cents, nil
+	return ParseSignedDecimalToCents(strconv.FormatFloat(euros, 'f', -1, 64))
+}
+
+// DivRoundHalfEven divides num by den and rounds the quotient half to even.
+// It panics if den is zero, like integer division.
+//
+// Examples:
+//
+//	DivRoundHalfEven(1000, 12) -> 83
+//	DivRoundHalfEven(30, 4) -> 8 (7.5, rounds to even)
+//	DivRoundHalfEven(-30, 4) -> -8
+func DivRoundHalfEven(num, den int64) int64 {
+	if den < 0 {
+		num, den = -num, -den
+	}
+	q := num / den
+	r := num % den
+	if r == 0 {
+		return q
+	}
+	// Work with the magnitude of the remainder to keep symmetry around zero
+	sign := int64(1)
+	if r < 0 {
+		sign = -1
+		r = -r
+	}
+	// Compare 2r with den without overflowing
+	switch {
+	case r > den-r:
+		return q + sign
+	case r < den-r:
+		return q
+	}
+	if q%2 != 0 {
+		return q + sign
+	}
+	return q
+}
+
+// DivRound divides the amount by n with half-to-even rounding.
+// It is meant for derived figures such as the monthly share of a yearly cost;
+// use Split when the parts must add back up to the original amount.
+func (m Money) DivRound(n int64) Money {
+	return Money{Cents: DivRoundHalfEven(m.Cents, n)}
+}
+
+// Split divides the amount into n parts whose sum is exactly the original
+// amount. The leftover cents are handed out one by one starting from the
+// first part, so no part differs from another by more than one cent.
+// Returns nil when n is not positive.
+func (m Money) Split(n int) []Money {
+	if n <= 0 {
+		return nil
+	}
+	base := m.Cents / int64(n)
+	rem := m.Cents % int64(n)
+	step := int64(1)
+	if rem < 0 {
+		step = -1
+		rem = -rem
+	}
+	parts := make([]Money, n)
+	for i := range parts {
+		parts[i] = Money{Cents: base}
+		if int64(i) < rem {
+			parts[i].Cents += step
+		}
+	}
+	return parts
 }
 
 // Euros returns the euro value as a float64 for display purposes.
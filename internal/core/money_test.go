@@ -1,6 +1,9 @@
 package core
 
-import "testing"
+import (
+	"math"
+	"testing"
+)
 
 func TestParseDecimalToCents(t *testing.T) {
 	cases := []struct {
@@ -13,13 +16,37 @@ func TestParseDecimalToCents(t *testing.T) {
 		{"1.23", 123, true},
 		{"1,23", 123, true},
 		{"0.01", 1, true},
-		{"1.005", 101, true}, // half-up rounding
+		{"1.005", 100, true}, // tie rounds to even
+		{"1.015", 102, true}, // tie rounds to even
+		{"1.0051", 101, true},
+		{"1.004", 100, true},
+		{"1.006", 101, true},
+		{".5", 50, true},
+		{"0.004", 0, false}, // rounds to zero
 		{" 2.50 ", 250, true},
 		{"-1", 0, false},
 		{"0", 0, false},
 		{"abc", 0, false},
 		{"1.2.3", 0, false},
 		{"", 0, false},
+		{"+1", 0, false},
+		{".", 0, false},
+		{"99999999999999999999", 0, false},
+		// Thousands separators, as hinted by the forms
+		{"1.234,56", 123456, true},
+		{"1,234.56", 123456, true},
+		{"12,5", 1250, true},
+		{"12.50", 1250, true},
+		{"1.234.567", 123456700, true},
+		{"1.234.567,8", 123456780, true},
+		{"€ 1 234,56", 123456, true},
+		{"1\u00a0234,56", 123456, true},
+		{"1,234", 123, true}, // a single separator is the decimal one
+		{"12.34,5", 0, false},
+		{"1.234,5,6", 0, false},
+		{"1,234.5.6", 0, false},
+		{".234,56", 0, false},
+		{"1234.567,00", 0, false},
 	}
 	for _, tc := range cases {
 		got, err := ParseDecimalToCents(tc.in)
@@ -34,3 +61,143 @@ func TestParseDecimalToCents(t *testing.T) {
 		}
 	}
 }
+
+func TestParseSignedDecimalToCents(t *testing.T) {
+	cases := []struct {
+		in  string
+		out int64
+		ok  bool
+	}{
+		{"0", 0, true},
+		{"12,34", 1234, true},
+		{"+12.34", 1234, true},
+		{"-12.34", -1234, true},
+		{"-0.005", 0, true},
+		{"-0.015", -2, true},
+		{"-0.025", -2, true},
+		{"-0.0251", -3, true},
+		{"2.675", 268, true},
+		{"2.665", 266, true},
+		{"-", 0, false},
+		{"--1", 0, false},
+		{"1e3", 0, false},
+		{"", 0, false},
+	}
+	for _, tc := range cases {
+		got, err := ParseSignedDecimalToCents(tc.in)
+		if tc.ok {
+			if err != nil || got != tc.out {
+				t.Fatalf("%q expected %d, got %d (err=%v)", tc.in, tc.out, got, err)
+			}
+		} else if err == nil {
+			t.Fatalf("%q expected error", tc.in)
+		}
+	}
+}
+
+func TestCentsFromFloat(t *testing.T) {
+	cases := []struct {
+		in  float64
+		out int64
+		ok  bool
+	}{
+		{0, 0, true},
+		{12.34, 1234, true},
+		{-12.34, -1234, true},
+		{1.005, 100, true}, // not 1.00499999... truncated
+		{1.015, 102, true},
+		{0.125, 12, true},
+		{0.135, 14, true},
+		{-0.125, -12, true},
+		{-0.135, -14, true},
+		{0.1 + 0.2, 30, true},
+		{1e3, 100000, true},
+		{1e-9, 0, true},
+		{math.NaN(), 0, false},
+		{math.Inf(1), 0, false},
+		{math.Inf(-1), 0, false},
+		{1e20, 0, false},
+	}
+	for _, tc := range cases {
+		got, err := CentsFromFloat(tc.in)
+		if tc.ok {
+			if err != nil || got != tc.out {
+				t.Fatalf("%v expected %d, got %d (err=%v)", tc.in, tc.out, got, err)
+			}
+		} else if err == nil {
+			t.Fatalf("%v expected error", tc.in)
+		}
+	}
+}
+
+func TestDivRoundHalfEven(t *testing.T) {
+	cases := []struct {
+		num, den, out int64
+	}{
+		{0, 12, 0},
+		{1200, 12, 100},
+		{1000, 12, 83}, // 83.33
+		{1010, 12, 84}, // 84.17
+		{30, 4, 8},     // 7.5 -> 8
+		{10, 4, 2},     // 2.5 -> 2
+		{-30, 4, -8},   // -7.5 -> -8
+		{-10, 4, -2},   // -2.5 -> -2
+		{-1000, 12, -83},
+		{10, -4, -2},
+		{-10, -4, 2},
+		{5, 10, 0},
+		{15, 10, 2},
+		{1, 3, 0},
+		{2, 3, 1},
+		{math.MaxInt64, 2, 1 << 62},
+	}
+	for _, tc := range cases {
+		if got := DivRoundHalfEven(tc.num, tc.den); got != tc.out {
+			t.Fatalf("%d/%d expected %d, got %d", tc.num, tc.den, tc.out, got)
+		}
+	}
+}
+
+func TestMoneyDivRound(t *testing.T) {
+	if got := (Money{Cents: 12999}).DivRound(12); got.Cents != 1083 {
+		t.Fatalf("expected 1083, got %d", got.Cents)
+	}
+	if got := (Money{Cents: 18}).DivRound(12); got.Cents != 2 {
+		t.Fatalf("expected 2 (1.5 to even), got %d", got.Cents)
+	}
+}
+
+func TestMoneySplit(t *testing.T) {
+	cases := []struct {
+		cents int64
+		n     int
+		out   []int64
+	}{
+		{100, 3, []int64{34, 33, 33}},
+		{101, 2, []int64{51, 50}},
+		{1000, 12, []int64{84, 84, 84, 84, 83, 83, 83, 83, 83, 83, 83, 83}},
+		{-100, 3, []int64{-34, -33, -33}},
+		{2, 4, []int64{1, 1, 0, 0}},
+		{0, 2, []int64{0, 0}},
+		{500, 1, []int64{500}},
+	}
+	for _, tc := range cases {
+		parts := (Money{Cents: tc.cents}).Split(tc.n)
+		if len(parts) != len(tc.out) {
+			t.Fatalf("split %d/%d: expected %d parts, got %d", tc.cents, tc.n, len(tc.out), len(parts))
+		}
+		var sum int64
+		for i, p := range parts {
+			if p.Cents != tc.out[i] {
+				t.Fatalf("split %d/%d: part %d expected %d, got %d", tc.cents, tc.n, i, tc.out[i], p.Cents)
+			}
+			sum += p.Cents
+		}
+		if sum != tc.cents {
+			t.Fatalf("split %d/%d: parts sum to %d", tc.cents, tc.n, sum)
+		}
+	}
+	if parts := (Money{Cents: 100}).Split(0); parts != nil {
+		t.Fatalf("expected nil for zero parts, got %v", parts)
+	}
+}
@@ -0,0 +1,112 @@
+package core
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// PriceSource tells where a price observation comes from.
+type PriceSource string
+
+const (
+	PriceFromDescription PriceSource = "description" // Expense described as the item
+	PriceFromLineItem    PriceSource = "line_item"   // Line of a shopping list or receipt
+)
+
+// PriceObservation is the unit price paid for an item on a day.
+type PriceObservation struct {
+	Date      Date
+	Quantity  float64
+	UnitPrice Money
+}
+
+// BasketPoint is the price index of the tracked items for a month, 100
+// being the price at which each item was first seen.
+type BasketPoint struct {
+	Year  int
+	Month int
+	Index float64
+	Items int // Items priced that month
+}
+
+// NormalizeItemName folds an item name or expense description for
+// matching: lower case, single spaces.
+func NormalizeItemName(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
+
+// UnitPrice divides a line total by its quantity, rounding to the cent.
+func UnitPrice(total Money, quantity float64) Money {
+	if quantity <= 0 {
+		return total
+	}
+	return Money{Cents: int64(math.Round(float64(total.Cents) / quantity))}
+}
+
+// BasketIndex computes the monthly price index of a basket of items, given
+// each item's observations. Every item's base is its average unit price in
+// the first month it was seen; a month's index is the mean of the ratios to
+// the base of the items priced in that month, so items bought rarely do
+// not distort it. Points are in chronological order.
+func BasketIndex(items [][]PriceObservation) []BasketPoint {
+	type key struct{ year, month int }
+	type acc struct {
+		sum   float64
+		items int
+	}
+	months := make(map[key]*acc)
+
+	for _, observations := range items {
+		// Average unit price per month
+		type avg struct {
+			sum   int64
+			count int
+		}
+		byMonth := make(map[key]*avg)
+		var first key
+		for _, o := range observations {
+			if o.UnitPrice.Cents <= 0 {
+				continue
+			}
+			k := key{o.Date.Year(), int(o.Date.Month())}
+			if byMonth[k] == nil {
+				byMonth[k] = &avg{}
+			}
+			byMonth[k].sum += o.UnitPrice.Cents
+			byMonth[k].count++
+			if len(byMonth) == 1 || k.year < first.year || (k.year == first.year && k.month < first.month) {
+				first = k
+			}
+		}
+		if len(byMonth) == 0 {
+			continue
+		}
+
+		base := float64(byMonth[first].sum) / float64(byMonth[first].count)
+		for k, a := range byMonth {
+			if months[k] == nil {
+				months[k] = &acc{}
+			}
+			months[k].sum += float64(a.sum) / float64(a.count) / base
+			months[k].items++
+		}
+	}
+
+	points := make([]BasketPoint, 0, len(months))
+	for k, a := range months {
+		points = append(points, BasketPoint{
+			Year:  k.year,
+			Month: k.month,
+			Index: math.Round(a.sum/float64(a.items)*1000) / 10,
+			Items: a.items,
+		})
+	}
+	sort.Slice(points, func(i, j int) bool {
+		if points[i].Year != points[j].Year {
+			return points[i].Year < points[j].Year
+		}
+		return points[i].Month < points[j].Month
+	})
+	return points
+}
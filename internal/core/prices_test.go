@@ -0,0 +1,45 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNormalizeItemName(t *testing.T) {
+	if got := NormalizeItemName("  Latte   INTERO "); got != "latte intero" {
+		t.Errorf("NormalizeItemName = %q", got)
+	}
+	if got := UnitPrice(Money{Cents: 338}, 2); got.Cents != 169 {
+		t.Errorf("UnitPrice = %d, want 169", got.Cents)
+	}
+}
+
+func TestBasketIndex(t *testing.T) {
+	day := func(y int, m time.Month, d int) Date {
+		return Date{Time: time.Date(y, m, d, 0, 0, 0, 0, time.UTC)}
+	}
+	milk := []PriceObservation{
+		{Date: day(2031, 1, 5), Quantity: 1, UnitPrice: Money{Cents: 100}},
+		{Date: day(2031, 1, 20), Quantity: 1, UnitPrice: Money{Cents: 120}},
+		{Date: day(2031, 3, 2), Quantity: 1, UnitPrice: Money{Cents: 132}},
+	}
+	bread := []PriceObservation{
+		{Date: day(2031, 2, 1), Quantity: 1, UnitPrice: Money{Cents: 250}},
+		{Date: day(2031, 3, 1), Quantity: 1, UnitPrice: Money{Cents: 300}},
+	}
+
+	points := BasketIndex([][]PriceObservation{milk, bread})
+	want := []BasketPoint{
+		{Year: 2031, Month: 1, Index: 100, Items: 1},
+		{Year: 2031, Month: 2, Index: 100, Items: 1},
+		{Year: 2031, Month: 3, Index: 120, Items: 2}, // milk 132/110, bread 300/250
+	}
+	if len(points) != len(want) {
+		t.Fatalf("points = %+v", points)
+	}
+	for i := range want {
+		if points[i] != want[i] {
+			t.Errorf("point %d = %+v, want %+v", i, points[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Quotas limiting what a household member can do with their API keys
+const (
+	QuotaDailyExpenses   = "daily_expenses"   // Expenses created per day
+	QuotaAttachmentBytes = "attachment_bytes" // Bytes of receipts uploaded
+)
+
+// ErrQuotaExceeded is returned when a change would take a member over one
+// of their quotas.
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// QuotaError reports which quota a change would exceed. It matches
+// ErrQuotaExceeded with errors.Is.
+type QuotaError struct {
+	Quota     string // QuotaDailyExpenses or QuotaAttachmentBytes
+	Limit     int64
+	Used      int64 // Before the change
+	Requested int64
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("%s: %s would reach %d, limit is %d", ErrQuotaExceeded, e.Quota, e.Used+e.Requested, e.Limit)
+}
+
+func (e *QuotaError) Is(target error) bool {
+	return target == ErrQuotaExceeded
+}
+
+type userKey struct{}
+
+// WithUser returns a context carrying the household member a request acts
+// as, identified by their API key.
+func WithUser(ctx context.Context, u User) context.Context {
+	return context.WithValue(ctx, userKey{}, u)
+}
+
+// UserFromContext returns the member stored in ctx; ok is false for
+// requests made without an API key.
+func UserFromContext(ctx context.Context) (User, bool) {
+	u, ok := ctx.Value(userKey{}).(User)
+	return u, ok && u.ID != 0
+}
+
+// QuotaLimits are the quotas of a member; 0 means unlimited.
+type QuotaLimits struct {
+	DailyExpenses   int64
+	AttachmentBytes int64
+}
+
+// QuotaOverride replaces the instance quotas for one member; a nil field
+// keeps the default.
+type QuotaOverride struct {
+	DailyExpenses   *int64
+	AttachmentBytes *int64
+}
+
+// With returns l with the fields set in o replaced.
+func (l QuotaLimits) With(o QuotaOverride) QuotaLimits {
+	if o.DailyExpenses != nil {
+		l.DailyExpenses = *o.DailyExpenses
+	}
+	if o.AttachmentBytes != nil {
+		l.AttachmentBytes = *o.AttachmentBytes
+	}
+	return l
+}
+
+// APIKey is a key a member uses to call the API as themselves. The key
+// itself is only known when created.
+type APIKey struct {
+	ID         int64
+	UserID     int64
+	Name       string
+	CreatedAt  time.Time
+	LastUsedAt time.Time // Zero when never used
+}
@@ -0,0 +1,38 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestQuotaLimits_With(t *testing.T) {
+	defaults := QuotaLimits{DailyExpenses: 50, AttachmentBytes: 1 << 20}
+	if got := defaults.With(QuotaOverride{}); got != defaults {
+		t.Errorf("no override = %+v, want the defaults", got)
+	}
+	unlimited := int64(0)
+	if got := defaults.With(QuotaOverride{DailyExpenses: &unlimited}); got != (QuotaLimits{AttachmentBytes: 1 << 20}) {
+		t.Errorf("unlimited expenses = %+v", got)
+	}
+}
+
+func TestQuotaError(t *testing.T) {
+	err := fmt.Errorf("create expense: %w", &QuotaError{Quota: QuotaDailyExpenses, Limit: 10, Used: 9, Requested: 2})
+	if !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("%v does not match ErrQuotaExceeded", err)
+	}
+	if want := "create expense: quota exceeded: daily_expenses would reach 11, limit is 10"; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestUserFromContext(t *testing.T) {
+	if _, ok := UserFromContext(context.Background()); ok {
+		t.Error("member found in an empty context")
+	}
+	if u, ok := UserFromContext(WithUser(context.Background(), User{ID: 3, Name: "Anna"})); !ok || u.Name != "Anna" {
+		t.Errorf("UserFromContext = %+v, %v", u, ok)
+	}
+}
@@ -0,0 +1,71 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"time"
+)
+
+// Receipt file types accepted for upload
+const (
+	ReceiptJPEG = "image/jpeg"
+	ReceiptPNG  = "image/png"
+	ReceiptPDF  = "application/pdf"
+)
+
+// MaxReceiptFilename is the length kept of the uploaded file name
+const MaxReceiptFilename = 200
+
+var ErrInvalidReceipt = errors.New("invalid receipt: must be a JPEG, PNG or PDF file")
+
+// Receipt is the photo or PDF of the receipt of an expense. The file and
+// its thumbnail are kept in blob storage under their keys.
+type Receipt struct {
+	ExpenseID    int64
+	Key          string
+	ThumbnailKey string // Empty for PDFs and images that could not be shrunk
+	ContentType  string
+	Filename     string // As uploaded, for downloads
+	Size         int64  // Bytes
+	CreatedAt    time.Time
+	UploadedBy   int64 // Member whose API key uploaded it, 0 for none
+}
+
+// IsPDF reports whether the receipt is a PDF document.
+func (r Receipt) IsPDF() bool {
+	return r.ContentType == ReceiptPDF
+}
+
+// receiptSignatures are the leading bytes of each accepted type
+var receiptSignatures = []struct {
+	prefix      []byte
+	contentType string
+}{
+	{[]byte("\xFF\xD8\xFF"), ReceiptJPEG},
+	{[]byte("\x89PNG\r\n\x1A\n"), ReceiptPNG},
+	{[]byte("%PDF-"), ReceiptPDF},
+}
+
+// ReceiptType returns the type of a receipt file from its content, never
+// from the name or the type declared by the client.
+func ReceiptType(data []byte) (string, error) {
+	for _, sig := range receiptSignatures {
+		if bytes.HasPrefix(data, sig.prefix) {
+			return sig.contentType, nil
+		}
+	}
+	return "", ErrInvalidReceipt
+}
+
+// ReceiptExtension returns the file extension of a receipt type.
+func ReceiptExtension(contentType string) string {
+	switch contentType {
+	case ReceiptJPEG:
+		return ".jpg"
+	case ReceiptPNG:
+		return ".png"
+	case ReceiptPDF:
+		return ".pdf"
+	}
+	return ""
+}
@@ -0,0 +1,30 @@
+package core
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestReceiptType(t *testing.T) {
+	goods := map[string]string{
+		"\xFF\xD8\xFF\xE0\x00\x10JFIF": ReceiptJPEG,
+		"\x89PNG\r\n\x1A\n\x00\x00":    ReceiptPNG,
+		"%PDF-1.7\n%âãÏÓ":              ReceiptPDF,
+	}
+	for data, want := range goods {
+		got, err := ReceiptType([]byte(data))
+		if err != nil || got != want {
+			t.Errorf("ReceiptType(%q) = %q, %v; want %q", data, got, err, want)
+		}
+		if ReceiptExtension(got) == "" {
+			t.Errorf("no extension for %q", got)
+		}
+	}
+
+	// The type comes from the content: a script named receipt.pdf is refused
+	for _, data := range []string{"", "<html><script>", "GIF89a", "%PD"} {
+		if _, err := ReceiptType([]byte(data)); !errors.Is(err, ErrInvalidReceipt) {
+			t.Errorf("ReceiptType(%q) error = %v, want ErrInvalidReceipt", data, err)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package core
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+// MaxRecurrenceInterval is the largest number of units between two
+// occurrences of a recurrent expense.
+const MaxRecurrenceInterval = 99
+
+// ErrInvalidInterval is returned for a recurrence interval out of range.
+var ErrInvalidInterval = errors.New("invalid recurrence interval (1-99)")
+
+// ErrInvalidDayOfMonth is returned for a day-of-month anchor out of range,
+// or set on a daily or weekly recurrence.
+var ErrInvalidDayOfMonth = errors.New("invalid day of month (1-31, monthly and yearly only)")
+
+// ErrNoOccurrence is returned when a recurrence has no occurrence left.
+var ErrNoOccurrence = errors.New("no upcoming occurrence")
+
+// Step returns the number of units between two occurrences: Interval, or
+// 1 when it is not set.
+func (re RecurrentExpenses) Step() int {
+	if re.Interval < 1 {
+		return 1
+	}
+	return re.Interval
+}
+
+// anchorDay returns the day of the month monthly and yearly occurrences
+// fall on, before clamping to the length of the month.
+func (re RecurrentExpenses) anchorDay() int {
+	if re.DayOfMonth > 0 {
+		return re.DayOfMonth
+	}
+	return re.StartDate.Day()
+}
+
+// occurrence returns the n-th date of the schedule counted from the start
+// date, which comes before the start date when the anchor day does.
+func (re RecurrentExpenses) occurrence(n int) Date {
+	start := re.StartDate.Time
+	step := re.Step()
+	switch re.Every {
+	case Daily:
+		return Date{Time: start.AddDate(0, 0, n*step)}
+	case Weekly:
+		return Date{Time: start.AddDate(0, 0, 7*n*step)}
+	case Monthly:
+		return clampedDate(start.Year(), int(start.Month())+n*step, re.anchorDay())
+	default:
+		return clampedDate(start.Year()+n*step, int(start.Month()), re.anchorDay())
+	}
+}
+
+// clampedDate returns day of the given month, or its last day when the
+// month is shorter. Months past 12 roll over into the following years.
+func clampedDate(year, month, day int) Date {
+	first := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	last := first.AddDate(0, 1, -1).Day()
+	if day > last {
+		day = last
+	}
+	return NewDate(first.Year(), int(first.Month()), day)
+}
+
+// NextOccurrence returns the first date of the schedule after the day of
+// after, not before the start date; a zero after gives the first one. It
+// returns false when the recurrence ends before that date.
+func (re RecurrentExpenses) NextOccurrence(after time.Time) (Date, bool) {
+	if re.Every.OccurrencesPerYear() == 0 {
+		return Date{}, false
+	}
+	start := re.StartDate.Time
+	n := 0
+	if !after.IsZero() && !after.Before(start) {
+		after = time.Date(after.Year(), after.Month(), after.Day(), 0, 0, 0, 0, time.UTC)
+		// Jump close to the answer, then step forward
+		switch re.Every {
+		case Daily:
+			n = int(after.Sub(start).Hours()/24) / re.Step()
+		case Weekly:
+			n = int(after.Sub(start).Hours()/24) / (7 * re.Step())
+		case Monthly:
+			n = ((after.Year()-start.Year())*12 + int(after.Month()) - int(start.Month())) / re.Step()
+		case Yearly:
+			n = (after.Year() - start.Year()) / re.Step()
+		}
+		if n > 0 {
+			n--
+		}
+	} else {
+		after = time.Time{}
+	}
+
+	for ; ; n++ {
+		d := re.occurrence(n)
+		if d.Before(start) || (!after.IsZero() && !d.After(after)) {
+			continue
+		}
+		if !re.EndDate.IsZero() && d.After(re.EndDate.Time) {
+			return Date{}, false
+		}
+		return d, true
+	}
+}
+
+// NextDue returns the next occurrence an expense will be created for: the
+// first one after both the last run and the skipped occurrences. It
+// returns false for a paused recurrence, or when no occurrence is left.
+func (re RecurrentExpenses) NextDue() (Date, bool) {
+	if re.Paused {
+		return Date{}, false
+	}
+	after := re.LastRun.Time
+	if re.SkipUntil.After(after) {
+		after = re.SkipUntil.Time
+	}
+	return re.NextOccurrence(after)
+}
+
+// UpcomingCharge is a future occurrence of a recurrent expense, the
+// expense the recurring processor will create on Date.
+type UpcomingCharge struct {
+	RecurrentID int64
+	Date        Date
+	Description string
+	Amount      Money
+	Primary     string
+	Secondary   string
+}
+
+// Upcoming returns the occurrences expenses will be created for from the
+// day of from to the day of until, both included; none for a paused
+// recurrence. Skipped occurrences and those already run are left out.
+func (re RecurrentExpenses) Upcoming(from, until time.Time) []Date {
+	if re.Paused {
+		return nil
+	}
+	after := re.LastRun.Time
+	if re.SkipUntil.After(after) {
+		after = re.SkipUntil.Time
+	}
+	if dayBefore := from.AddDate(0, 0, -1); dayBefore.After(after) {
+		after = dayBefore
+	}
+	until = time.Date(until.Year(), until.Month(), until.Day(), 0, 0, 0, 0, time.UTC)
+
+	var dates []Date
+	for {
+		d, ok := re.NextOccurrence(after)
+		if !ok || d.After(until) {
+			return dates
+		}
+		dates = append(dates, d)
+		after = d.Time
+	}
+}
+
+// UpcomingCharges lists the occurrences of recurrents between from and
+// until, soonest first; charges on the same day keep the order of
+// recurrents.
+func UpcomingCharges(recurrents []RecurrentExpenses, from, until time.Time) []UpcomingCharge {
+	var charges []UpcomingCharge
+	for _, re := range recurrents {
+		for _, d := range re.Upcoming(from, until) {
+			charges = append(charges, UpcomingCharge{
+				RecurrentID: re.ID,
+				Date:        d,
+				Description: re.Description,
+				Amount:      re.Amount,
+				Primary:     re.Primary,
+				Secondary:   re.Secondary,
+			})
+		}
+	}
+	sort.SliceStable(charges, func(i, j int) bool {
+		return charges[i].Date.Before(charges[j].Date.Time)
+	})
+	return charges
+}
+
+// schedule returns the recurrence of a recurrent income, to share the
+// schedule logic of recurrent expenses.
+func (ri RecurrentIncome) schedule() RecurrentExpenses {
+	return RecurrentExpenses{
+		StartDate:  ri.StartDate,
+		EndDate:    ri.EndDate,
+		Every:      ri.Every,
+		Interval:   ri.Interval,
+		DayOfMonth: ri.DayOfMonth,
+		LastRun:    ri.LastRun,
+	}
+}
+
+// Step returns the number of units between two occurrences: Interval, or
+// 1 when it is not set.
+func (ri RecurrentIncome) Step() int {
+	return ri.schedule().Step()
+}
+
+// NextDue returns the next occurrence an income will be created for, the
+// first one after the last run; false when no occurrence is left.
+func (ri RecurrentIncome) NextDue() (Date, bool) {
+	return ri.schedule().NextDue()
+}
+
+// YearlyCost returns what the recurrence costs in a year, on average.
+func (re RecurrentExpenses) YearlyCost() Money {
+	return Money{Cents: re.Amount.Cents * int64(re.Every.OccurrencesPerYear())}.DivRound(int64(re.Step()))
+}
+
+// validateRecurrence checks the start and end dates, the repetition type
+// and the schedule.
+func (re RecurrentExpenses) validateRecurrence() error {
+	if err := re.StartDate.Validate(); err != nil {
+		return errors.New("invalid start date: " + err.Error())
+	}
+
+	if !re.EndDate.IsZero() {
+		if err := re.EndDate.Validate(); err != nil {
+			return errors.New("invalid end date: " + err.Error())
+		}
+		// Ensure end date is after start date
+		if !re.EndDate.After(re.StartDate.Time) && !re.EndDate.Equal(re.StartDate.Time) {
+			return errors.New("end date must be after start date")
+		}
+	}
+
+	switch re.Every {
+	case Daily, Weekly, Monthly, Yearly:
+		// Valid repetition types
+	default:
+		return errors.New("invalid repetition type")
+	}
+	return re.validateSchedule()
+}
+
+// validateSchedule checks the interval and the day-of-month anchor.
+func (re RecurrentExpenses) validateSchedule() error {
+	if re.Interval < 0 || re.Interval > MaxRecurrenceInterval {
+		return ErrInvalidInterval
+	}
+	if re.DayOfMonth == 0 {
+		return nil
+	}
+	if re.DayOfMonth < 0 || re.DayOfMonth > 31 || (re.Every != Monthly && re.Every != Yearly) {
+		return ErrInvalidDayOfMonth
+	}
+	return nil
+}
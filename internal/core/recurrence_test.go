@@ -0,0 +1,154 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNextOccurrence(t *testing.T) {
+	day := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+	cases := []struct {
+		name  string
+		re    RecurrentExpenses
+		after time.Time
+		want  time.Time // zero when the recurrence is over
+	}{
+		{"first is the start", RecurrentExpenses{StartDate: NewDate(2025, 1, 10), Every: Monthly}, time.Time{}, day(2025, 1, 10)},
+		{"daily", RecurrentExpenses{StartDate: NewDate(2025, 1, 10), Every: Daily}, day(2025, 3, 4), day(2025, 3, 5)},
+		{"every 2 weeks", RecurrentExpenses{StartDate: NewDate(2025, 1, 6), Every: Weekly, Interval: 2}, day(2025, 1, 6), day(2025, 1, 20)},
+		{"every 2 weeks, mid period", RecurrentExpenses{StartDate: NewDate(2025, 1, 6), Every: Weekly, Interval: 2}, day(2025, 2, 10), day(2025, 2, 17)},
+		{"every 3 months", RecurrentExpenses{StartDate: NewDate(2025, 1, 15), Every: Monthly, Interval: 3}, day(2025, 1, 15), day(2025, 4, 15)},
+		{"time of day ignored", RecurrentExpenses{StartDate: NewDate(2025, 1, 15), Every: Monthly}, time.Date(2025, 2, 15, 9, 30, 0, 0, time.UTC), day(2025, 3, 15)},
+		{"end of month clamped", RecurrentExpenses{StartDate: NewDate(2025, 1, 31), Every: Monthly}, day(2025, 1, 31), day(2025, 2, 28)},
+		{"clamping does not drift", RecurrentExpenses{StartDate: NewDate(2025, 1, 31), Every: Monthly}, day(2025, 2, 28), day(2025, 3, 31)},
+		{"anchor day", RecurrentExpenses{StartDate: NewDate(2025, 1, 10), Every: Monthly, DayOfMonth: 27}, day(2025, 1, 27), day(2025, 2, 27)},
+		{"anchor before the start day", RecurrentExpenses{StartDate: NewDate(2025, 1, 10), Every: Monthly, DayOfMonth: 1}, time.Time{}, day(2025, 2, 1)},
+		{"anchor past a leap February", RecurrentExpenses{StartDate: NewDate(2024, 1, 5), Every: Monthly, DayOfMonth: 30}, day(2024, 1, 30), day(2024, 2, 29)},
+		{"yearly leap day", RecurrentExpenses{StartDate: NewDate(2024, 2, 29), Every: Yearly}, day(2024, 2, 29), day(2025, 2, 28)},
+		{"every 2 years", RecurrentExpenses{StartDate: NewDate(2023, 6, 1), Every: Yearly, Interval: 2}, day(2024, 1, 1), day(2025, 6, 1)},
+		{"ended", RecurrentExpenses{StartDate: NewDate(2025, 1, 10), EndDate: NewDate(2025, 3, 1), Every: Monthly}, day(2025, 2, 10), time.Time{}},
+	}
+	for _, tc := range cases {
+		got, ok := tc.re.NextOccurrence(tc.after)
+		if tc.want.IsZero() {
+			if ok {
+				t.Errorf("%s: got %s, want none", tc.name, got.Format("2006-01-02"))
+			}
+			continue
+		}
+		if !ok || !got.Equal(tc.want) {
+			t.Errorf("%s: got %s (%v), want %s", tc.name, got.Format("2006-01-02"), ok, tc.want.Format("2006-01-02"))
+		}
+	}
+}
+
+func TestRecurrentSchedule(t *testing.T) {
+	base := RecurrentExpenses{StartDate: NewDate(2025, 1, 1), Every: Monthly, Description: "Affitto", Amount: Money{Cents: 80000}, Primary: "Casa", Secondary: "Affitto"}
+
+	bad := []RecurrentExpenses{base, base, base}
+	bad[0].Interval = MaxRecurrenceInterval + 1
+	bad[1].DayOfMonth = 32
+	bad[2].Every, bad[2].DayOfMonth = Weekly, 3
+	for i, re := range bad {
+		if err := re.Validate(); err == nil {
+			t.Errorf("case %d: expected an error", i)
+		}
+	}
+
+	ok := base
+	ok.Interval, ok.DayOfMonth = 3, 31
+	if err := ok.Validate(); err != nil {
+		t.Errorf("valid schedule: %v", err)
+	}
+	if got := ok.YearlyCost().Cents; got != 320000 {
+		t.Errorf("yearly cost = %d, want 320000", got)
+	}
+}
+
+func TestNextDue(t *testing.T) {
+	gym := RecurrentExpenses{StartDate: NewDate(2025, 1, 5), Every: Monthly}
+	gym.LastRun = NewDate(2025, 6, 5)
+
+	if got, ok := gym.NextDue(); !ok || !got.Equal(NewDate(2025, 7, 5).Time) {
+		t.Errorf("next due = %s (%v), want 2025-07-05", got.Format("2006-01-02"), ok)
+	}
+
+	gym.SkipUntil = NewDate(2025, 7, 5)
+	if got, ok := gym.NextDue(); !ok || !got.Equal(NewDate(2025, 8, 5).Time) {
+		t.Errorf("after skip = %s (%v), want 2025-08-05", got.Format("2006-01-02"), ok)
+	}
+
+	// A skip older than the last run changes nothing
+	gym.SkipUntil = NewDate(2025, 3, 5)
+	if got, _ := gym.NextDue(); !got.Equal(NewDate(2025, 7, 5).Time) {
+		t.Errorf("stale skip = %s, want 2025-07-05", got.Format("2006-01-02"))
+	}
+
+	gym.Paused = true
+	if _, ok := gym.NextDue(); ok {
+		t.Error("paused recurrence should have nothing due")
+	}
+}
+
+func TestUpcomingCharges(t *testing.T) {
+	from := time.Date(2025, 6, 10, 15, 0, 0, 0, time.UTC)
+	until := from.AddDate(0, 0, 90)
+	rent := RecurrentExpenses{ID: 1, StartDate: NewDate(2025, 1, 10), Every: Monthly, Description: "Affitto", Amount: Money{Cents: 80000}}
+	rent.LastRun = NewDate(2025, 5, 10)
+	gym := RecurrentExpenses{ID: 2, StartDate: NewDate(2025, 6, 1), Every: Weekly, Interval: 4, Description: "Palestra", Amount: Money{Cents: 3000}}
+	gym.SkipUntil = NewDate(2025, 6, 29)
+	paused := RecurrentExpenses{ID: 3, StartDate: NewDate(2025, 1, 1), Every: Daily, Paused: true}
+	ended := RecurrentExpenses{ID: 4, StartDate: NewDate(2024, 1, 1), EndDate: NewDate(2025, 6, 30), Every: Yearly}
+
+	got := UpcomingCharges([]RecurrentExpenses{rent, gym, paused, ended}, from, until)
+	var dates []string
+	for _, c := range got {
+		dates = append(dates, fmt.Sprintf("%d:%s", c.RecurrentID, c.Date.Format("2006-01-02")))
+	}
+	// The rent of June 10 is still due today; the skipped gym session of
+	// June 29 is left out
+	want := []string{"1:2025-06-10", "1:2025-07-10", "2:2025-07-27", "1:2025-08-10", "2:2025-08-24"}
+	if strings.Join(dates, " ") != strings.Join(want, " ") {
+		t.Errorf("upcoming = %v, want %v", dates, want)
+	}
+	if got[0].Description != "Affitto" || got[0].Amount.Cents != 80000 {
+		t.Errorf("first charge = %+v", got[0])
+	}
+
+	// Once run, today's occurrence is no longer upcoming
+	rent.LastRun = NewDate(2025, 6, 10)
+	if dates := rent.Upcoming(from, until); len(dates) != 2 || !dates[0].Equal(NewDate(2025, 7, 10).Time) {
+		t.Errorf("after run = %v", dates)
+	}
+}
+
+func TestRecurrentIncome(t *testing.T) {
+	salary := RecurrentIncome{
+		StartDate:   NewDate(2031, 1, 27),
+		Every:       Monthly,
+		Description: "Stipendio",
+		Amount:      Money{Cents: 250000},
+		Category:    "Stipendio E",
+	}
+	if err := salary.Validate(); err != nil {
+		t.Fatalf("valid income: %v", err)
+	}
+
+	salary.LastRun = NewDate(2031, 1, 27)
+	if got, ok := salary.NextDue(); !ok || !got.Equal(NewDate(2031, 2, 27).Time) {
+		t.Errorf("next due = %s (%v), want 2031-02-27", got.Format("2006-01-02"), ok)
+	}
+
+	bad := []RecurrentIncome{salary, salary, salary, salary}
+	bad[0].Category = " "
+	bad[1].Every = "hourly"
+	bad[2].EndDate = NewDate(2030, 12, 31)
+	bad[3].Every, bad[3].DayOfMonth = Weekly, 5
+	for i, ri := range bad {
+		if err := ri.Validate(); err == nil {
+			t.Errorf("case %d: expected an error", i)
+		}
+	}
+}
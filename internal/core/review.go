@@ -0,0 +1,145 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrMonthClosed is returned when adding or deleting a record dated in a
+// month closed by its review.
+var ErrMonthClosed = errors.New("month is closed")
+
+// Period returns the "2006-01" key of the month of t.
+func Period(t time.Time) string {
+	return t.Format("2006-01")
+}
+
+// ParsePeriod parses a "2006-01" month key.
+func ParsePeriod(s string) (time.Time, error) {
+	t, err := time.Parse("2006-01", strings.TrimSpace(s))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid month %q", s)
+	}
+	return t, nil
+}
+
+// ReviewExpense is an expense the monthly review points at.
+type ReviewExpense struct {
+	ID int64
+	Expense
+}
+
+// CategoryOverrun is a primary category that cost more in the reviewed
+// month than in the previous one, which acts as its budget.
+type CategoryOverrun struct {
+	Primary string
+	Amount  Money
+	Budget  Money
+}
+
+// MonthReview is the end-of-month checklist: what still needs attention
+// before the month is closed.
+type MonthReview struct {
+	Period        string // "2006-01"
+	Uncategorized []ReviewExpense
+	Duplicates    [][]ReviewExpense // Groups of expenses that look the same
+	Unsynced      []ReviewExpense   // Not in Google Sheets yet
+	Overruns      []CategoryOverrun
+	ClosedAt      time.Time // Zero while the month is open
+}
+
+// Closed reports whether the month was closed.
+func (r MonthReview) Closed() bool {
+	return !r.ClosedAt.IsZero()
+}
+
+// Open returns how many checklist items are left.
+func (r MonthReview) Open() int {
+	return len(r.Uncategorized) + len(r.Duplicates) + len(r.Unsynced) + len(r.Overruns)
+}
+
+// ExpenseFingerprint identifies an expense by day, amount and description,
+// ignoring case and spacing: two expenses with the same fingerprint look
+// the same.
+func ExpenseFingerprint(e Expense) string {
+	return fmt.Sprintf("%s|%d|%s", e.Date.Format("2006-01-02"), e.Amount.Cents, strings.Join(strings.Fields(strings.ToLower(e.Description)), " "))
+}
+
+// SimilarDescriptions reports whether two descriptions likely name the
+// same purchase: ignoring case and spacing, at least two thirds of the
+// words of the shorter one appear in the other, as with "Esselunga" and
+// "Spesa Esselunga".
+func SimilarDescriptions(a, b string) bool {
+	wa, wb := strings.Fields(strings.ToLower(a)), strings.Fields(strings.ToLower(b))
+	if len(wa) > len(wb) {
+		wa, wb = wb, wa
+	}
+	if len(wa) == 0 {
+		return len(wb) == 0
+	}
+	words := make(map[string]bool, len(wb))
+	for _, w := range wb {
+		words[w] = true
+	}
+	common := 0
+	for _, w := range wa {
+		if words[w] {
+			common++
+		}
+	}
+	return 3*common >= 2*len(wa)
+}
+
+// SuspectedDuplicates groups the expenses with the same fingerprint.
+// Groups follow the order of their first expense.
+func SuspectedDuplicates(expenses []ReviewExpense) [][]ReviewExpense {
+	groups := make(map[string][]ReviewExpense)
+	var order []string
+	for _, e := range expenses {
+		k := ExpenseFingerprint(e.Expense)
+		if _, ok := groups[k]; !ok {
+			order = append(order, k)
+		}
+		groups[k] = append(groups[k], e)
+	}
+
+	var dups [][]ReviewExpense
+	for _, k := range order {
+		if len(groups[k]) > 1 {
+			dups = append(dups, groups[k])
+		}
+	}
+	return dups
+}
+
+// CategoryOverruns returns the primary categories that cost more in the
+// month than in the previous one, largest excess first. Categories that
+// cost less than InsightMinCents in the previous month have no budget.
+func CategoryOverruns(month, previous []Expense) []CategoryOverrun {
+	sum := func(expenses []Expense) map[string]int64 {
+		totals := make(map[string]int64)
+		for _, e := range expenses {
+			totals[e.Primary] += e.Amount.Cents
+		}
+		return totals
+	}
+	current, budget := sum(month), sum(previous)
+
+	var overruns []CategoryOverrun
+	for primary, cents := range current {
+		if b := budget[primary]; b >= InsightMinCents && cents > b {
+			overruns = append(overruns, CategoryOverrun{Primary: primary, Amount: Money{Cents: cents}, Budget: Money{Cents: b}})
+		}
+	}
+	sort.Slice(overruns, func(i, j int) bool {
+		a, b := overruns[i], overruns[j]
+		if ea, eb := a.Amount.Cents-a.Budget.Cents, b.Amount.Cents-b.Budget.Cents; ea != eb {
+			return ea > eb
+		}
+		return a.Primary < b.Primary
+	})
+	return overruns
+}
@@ -0,0 +1,71 @@
+package core
+
+import "testing"
+
+func TestSuspectedDuplicates(t *testing.T) {
+	e := func(id int64, day int, desc string, cents int64) ReviewExpense {
+		return ReviewExpense{ID: id, Expense: Expense{Date: NewDate(2031, 5, day), Description: desc, Amount: Money{Cents: cents}}}
+	}
+	groups := SuspectedDuplicates([]ReviewExpense{
+		e(1, 3, "Spesa Conad", 4200),
+		e(2, 3, "spesa  conad", 4200),
+		e(3, 4, "Spesa Conad", 4200), // Another day
+		e(4, 3, "Spesa Conad", 4300), // Another amount
+		e(5, 3, "Spesa conad ", 4200),
+	})
+	if len(groups) != 1 || len(groups[0]) != 3 {
+		t.Fatalf("SuspectedDuplicates = %+v, want one group of 3", groups)
+	}
+	for i, want := range []int64{1, 2, 5} {
+		if groups[0][i].ID != want {
+			t.Errorf("group[%d] = %d, want %d", i, groups[0][i].ID, want)
+		}
+	}
+}
+
+func TestSimilarDescriptions(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want bool
+	}{
+		{"Spesa Conad", " spesa  CONAD", true},
+		{"Esselunga", "Spesa Esselunga", true},
+		{"Cena da Mario", "Pizzeria da Mario", true},
+		{"Pizza con amici", "Pizza da Mario", false},
+		{"Bar", "Barbiere", false},
+		{"", "Caffè", false},
+	} {
+		if got := SimilarDescriptions(tc.a, tc.b); got != tc.want {
+			t.Errorf("SimilarDescriptions(%q, %q) = %v, want %v", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestCategoryOverruns(t *testing.T) {
+	e := func(primary string, cents int64) Expense {
+		return Expense{Primary: primary, Amount: Money{Cents: cents}}
+	}
+	month := []Expense{e("Spesa", 30000), e("Casa", 20000), e("Casa", 5000), e("Auto", 900), e("Viaggi", 50000)}
+	previous := []Expense{e("Spesa", 25000), e("Casa", 10000), e("Auto", 500)}
+
+	got := CategoryOverruns(month, previous)
+	// Auto had no budget worth the name, Viaggi none at all
+	if len(got) != 2 || got[0].Primary != "Casa" || got[1].Primary != "Spesa" {
+		t.Fatalf("CategoryOverruns = %+v, want Casa then Spesa", got)
+	}
+	if got[0].Amount.Cents != 25000 || got[0].Budget.Cents != 10000 {
+		t.Errorf("Casa = %+v", got[0])
+	}
+}
+
+func TestParsePeriod(t *testing.T) {
+	p, err := ParsePeriod(" 2031-02 ")
+	if err != nil || Period(p) != "2031-02" {
+		t.Errorf("ParsePeriod = %v, %v", p, err)
+	}
+	for _, bad := range []string{"", "2031-13", "02/2031"} {
+		if _, err := ParsePeriod(bad); err == nil {
+			t.Errorf("ParsePeriod(%q) should fail", bad)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package core
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+// Shopping list limits
+const (
+	MaxShoppingNameLength = 100
+	MaxShoppingItems      = 200
+)
+
+var (
+	ErrEmptyShoppingList = errors.New("no checked items")                // Nothing to convert
+	ErrUnpricedItem      = errors.New("checked item without a price")    // Conversion needs every checked price
+	ErrListConverted     = errors.New("shopping list already converted") // Converted lists are read-only
+)
+
+// ShoppingItem is an entry of a shopping list. Price is the amount paid for
+// the whole line, zero until it is known.
+type ShoppingItem struct {
+	ID       int64
+	Name     string
+	Quantity float64
+	Price    Money
+	Checked  bool
+}
+
+// ShoppingList is a list of things to buy. Once checked off it is converted
+// into a single expense, and the list stays linked to it as its breakdown.
+type ShoppingList struct {
+	ID        int64
+	Name      string
+	ExpenseID int64 // Set once converted
+	Items     []ShoppingItem
+}
+
+// Converted reports whether the list was turned into an expense.
+func (l ShoppingList) Converted() bool {
+	return l.ExpenseID != 0
+}
+
+// Total returns the sum of the checked items' prices.
+func (l ShoppingList) Total() Money {
+	var total Money
+	for _, item := range l.Items {
+		if item.Checked {
+			total.Cents += item.Price.Cents
+		}
+	}
+	return total
+}
+
+// ValidateConversion checks that the list can become an expense: still
+// open, with checked items that all carry a price.
+func (l ShoppingList) ValidateConversion() error {
+	if l.Converted() {
+		return ErrListConverted
+	}
+	checked := 0
+	for _, item := range l.Items {
+		if !item.Checked {
+			continue
+		}
+		if item.Price.Cents <= 0 {
+			return ErrUnpricedItem
+		}
+		checked++
+	}
+	if checked == 0 {
+		return ErrEmptyShoppingList
+	}
+	return nil
+}
+
+// ValidateShoppingName checks a list or item name.
+func ValidateShoppingName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return ErrEmptyName
+	}
+	if utf8.RuneCountInString(name) > MaxShoppingNameLength {
+		return errors.New("name too long (max 100 characters)")
+	}
+	return nil
+}
+
+// Validate checks the item name, a positive quantity and a non-negative
+// price.
+func (i ShoppingItem) Validate() error {
+	if err := ValidateShoppingName(i.Name); err != nil {
+		return err
+	}
+	if i.Quantity <= 0 || i.Quantity > MaxCalculationQuantity {
+		return errors.New("invalid quantity")
+	}
+	if i.Price.Cents < 0 {
+		return ErrInvalidAmount
+	}
+	return nil
+}
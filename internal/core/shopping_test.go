@@ -0,0 +1,31 @@
+package core
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestShoppingListConversion(t *testing.T) {
+	list := ShoppingList{Name: "Spesa sabato", Items: []ShoppingItem{
+		{Name: "Latte intero", Quantity: 2, Price: Money{Cents: 338}, Checked: true},
+		{Name: "Pane", Quantity: 1, Price: Money{Cents: 250}, Checked: true},
+		{Name: "Caffè", Quantity: 1},
+	}}
+	if err := list.ValidateConversion(); err != nil {
+		t.Fatalf("ValidateConversion: %v", err)
+	}
+	if got := list.Total().Cents; got != 588 {
+		t.Errorf("Total = %d, want 588", got)
+	}
+
+	list.Items[2].Checked = true
+	if err := list.ValidateConversion(); !errors.Is(err, ErrUnpricedItem) {
+		t.Errorf("unpriced checked item: err = %v", err)
+	}
+	if err := (ShoppingList{Items: []ShoppingItem{{Name: "Pane", Price: Money{Cents: 250}}}}).ValidateConversion(); !errors.Is(err, ErrEmptyShoppingList) {
+		t.Errorf("nothing checked: err = %v", err)
+	}
+	if err := (ShoppingList{ExpenseID: 7}).ValidateConversion(); !errors.Is(err, ErrListConverted) {
+		t.Errorf("converted: err = %v", err)
+	}
+}
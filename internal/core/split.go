@@ -0,0 +1,80 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxSplitParts caps the parts of a split expense
+const MaxSplitParts = 20
+
+// MaxSplitNoteLength is the longest note of a part
+const MaxSplitNoteLength = 60
+
+// ErrInvalidSplit is returned for parts that cannot split an expense.
+var ErrInvalidSplit = errors.New("invalid split")
+
+// SplitPart is a category line of a split expense, such as the groceries
+// and the household goods of one supermarket receipt. The parts replace
+// the categories of the expense and add up to its amount.
+type SplitPart struct {
+	Note      string // Optional, added to the description of the part
+	Amount    Money
+	Primary   string
+	Secondary string
+}
+
+// ValidateSplit checks that parts split an expense of the given total:
+// two parts at least, each with an amount and both categories, adding up
+// to the total to the cent. No parts is valid: the expense is not split.
+func ValidateSplit(total Money, parts []SplitPart) error {
+	if len(parts) == 0 {
+		return nil
+	}
+	if len(parts) == 1 {
+		return fmt.Errorf("%w: a split needs at least two parts", ErrInvalidSplit)
+	}
+	if len(parts) > MaxSplitParts {
+		return fmt.Errorf("%w: too many parts (max %d)", ErrInvalidSplit, MaxSplitParts)
+	}
+	var sum int64
+	for i, p := range parts {
+		switch {
+		case p.Amount.Cents <= 0:
+			return fmt.Errorf("%w: part %d: amount must be positive", ErrInvalidSplit, i+1)
+		case strings.TrimSpace(p.Primary) == "" || strings.TrimSpace(p.Secondary) == "":
+			return fmt.Errorf("%w: part %d: both categories are required", ErrInvalidSplit, i+1)
+		case utf8.RuneCountInString(p.Note) > MaxSplitNoteLength:
+			return fmt.Errorf("%w: part %d: note too long (max %d characters)", ErrInvalidSplit, i+1, MaxSplitNoteLength)
+		}
+		sum += p.Amount.Cents
+	}
+	if sum != total.Cents {
+		return fmt.Errorf("%w: the parts add up to %.2f instead of %.2f", ErrInvalidSplit, Money{Cents: sum}.Euros(), total.Euros())
+	}
+	return nil
+}
+
+// FlattenSplit returns the rows an expense stands for where parts cannot
+// be nested, as in the Google Sheets sync: one per part, with the date of
+// the expense, the amount and categories of the part and its note after
+// the description. An expense that is not split is its own single row.
+func FlattenSplit(e Expense, parts []SplitPart) []Expense {
+	if len(parts) == 0 {
+		return []Expense{e}
+	}
+	rows := make([]Expense, len(parts))
+	for i, p := range parts {
+		row := e
+		row.Amount = p.Amount
+		row.Primary = p.Primary
+		row.Secondary = p.Secondary
+		if note := strings.TrimSpace(p.Note); note != "" && len(e.Description)+len(note)+3 <= 200 {
+			row.Description = e.Description + " - " + note
+		}
+		rows[i] = row
+	}
+	return rows
+}
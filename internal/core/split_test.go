@@ -0,0 +1,63 @@
+package core
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateSplit(t *testing.T) {
+	total := Money{Cents: 5000}
+	valid := []SplitPart{
+		{Amount: Money{Cents: 3500}, Primary: "Casa", Secondary: "Spesa"},
+		{Note: "detersivi", Amount: Money{Cents: 1500}, Primary: "Casa", Secondary: "Pulizia"},
+	}
+	if err := ValidateSplit(total, valid); err != nil {
+		t.Fatalf("valid split refused: %v", err)
+	}
+	if err := ValidateSplit(total, nil); err != nil {
+		t.Errorf("no parts refused: %v", err)
+	}
+
+	for name, change := range map[string]func([]SplitPart) []SplitPart{
+		"one part":     func(p []SplitPart) []SplitPart { p[0].Amount.Cents = 5000; return p[:1] },
+		"wrong sum":    func(p []SplitPart) []SplitPart { p[1].Amount.Cents = 1499; return p },
+		"zero amount":  func(p []SplitPart) []SplitPart { p[0].Amount.Cents, p[1].Amount.Cents = 0, 5000; return p },
+		"no secondary": func(p []SplitPart) []SplitPart { p[1].Secondary = " "; return p },
+		"long note":    func(p []SplitPart) []SplitPart { p[0].Note = strings.Repeat("a", MaxSplitNoteLength+1); return p },
+		"too many":     func(p []SplitPart) []SplitPart { return make([]SplitPart, MaxSplitParts+1) },
+	} {
+		parts := change(append([]SplitPart(nil), valid...))
+		if err := ValidateSplit(total, parts); !errors.Is(err, ErrInvalidSplit) {
+			t.Errorf("%s: err = %v, want ErrInvalidSplit", name, err)
+		}
+	}
+}
+
+func TestFlattenSplit(t *testing.T) {
+	e := Expense{Date: NewDate(2030, 3, 14), Description: "Supermercato", Amount: Money{Cents: 5000}, Primary: "Casa", Secondary: "Spesa"}
+	if rows := FlattenSplit(e, nil); len(rows) != 1 || rows[0].Description != e.Description || rows[0].Amount != e.Amount {
+		t.Fatalf("unsplit rows = %+v, want the expense", rows)
+	}
+
+	rows := FlattenSplit(e, []SplitPart{
+		{Amount: Money{Cents: 3500}, Primary: "Casa", Secondary: "Spesa"},
+		{Note: "detersivi", Amount: Money{Cents: 1500}, Primary: "Casa", Secondary: "Pulizia"},
+	})
+	if len(rows) != 2 {
+		t.Fatalf("rows = %+v, want 2", rows)
+	}
+	if rows[0].Description != "Supermercato" || rows[0].Amount.Cents != 3500 {
+		t.Errorf("first row = %+v", rows[0])
+	}
+	if rows[1].Description != "Supermercato - detersivi" || rows[1].Secondary != "Pulizia" || rows[1].Date != e.Date {
+		t.Errorf("second row = %+v", rows[1])
+	}
+
+	// A note that would not fit is left out of the description
+	e.Description = strings.Repeat("a", 195)
+	rows = FlattenSplit(e, []SplitPart{{Note: "detersivi", Amount: Money{Cents: 5000}}, {}})
+	if rows[0].Description != e.Description {
+		t.Errorf("long description = %q, want it unchanged", rows[0].Description)
+	}
+}
@@ -6,6 +6,14 @@ type CategoryAmount struct {
 	Amount Money
 }
 
+// SubcategoryAmount represents an amount aggregated by category and
+// subcategory.
+type SubcategoryAmount struct {
+	Category    string
+	Subcategory string
+	Amount      Money
+}
+
 // MonthOverview is a compact summary for a specific year+month.
 type MonthOverview struct {
 	Year       int
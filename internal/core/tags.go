@@ -0,0 +1,90 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Tag limits
+const (
+	MaxTags      = 10
+	MaxTagLength = 30
+)
+
+// ErrTooManyTags is returned when a record carries more than MaxTags tags.
+var ErrTooManyTags = fmt.Errorf("too many tags (max %d)", MaxTags)
+
+// ParseTags splits a comma-separated list, as typed in forms and stored in
+// the database, into normalized tags.
+func ParseTags(s string) []string {
+	return NormalizeTags(strings.Split(s, ","))
+}
+
+// NormalizeTag lowercases a tag and collapses its inner whitespace.
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.Join(strings.Fields(tag), " "))
+}
+
+// NormalizeTags lowercases tags, collapses their inner whitespace and drops
+// empty and repeated ones, keeping the first occurrence order.
+func NormalizeTags(tags []string) []string {
+	var out []string
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = NormalizeTag(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		out = append(out, tag)
+	}
+	return out
+}
+
+// ValidateTags checks the number and length of tags; a comma cannot be part
+// of a tag since it separates them.
+func ValidateTags(tags []string) error {
+	if len(tags) > MaxTags {
+		return ErrTooManyTags
+	}
+	for _, tag := range tags {
+		if strings.TrimSpace(tag) == "" {
+			return errors.New("empty tag")
+		}
+		if utf8.RuneCountInString(tag) > MaxTagLength {
+			return fmt.Errorf("tag %q too long (max %d characters)", tag, MaxTagLength)
+		}
+		if strings.Contains(tag, ",") {
+			return fmt.Errorf("tag %q cannot contain commas", tag)
+		}
+	}
+	return nil
+}
+
+// TagUsage is a tag with the number of expenses and incomes carrying it.
+type TagUsage struct {
+	Name string
+	Uses int64
+}
+
+// TagMonth is what the records carrying a tag add up to in a month.
+type TagMonth struct {
+	Year     int
+	Month    int
+	Spent    Money // Expenses
+	Received Money // Incomes
+	Entries  int64 // Expenses and incomes
+}
+
+// TagSummary is what the records carrying a tag add up to over a period,
+// month by month, for cross-cutting groups such as a trip that spans
+// several months and categories.
+type TagSummary struct {
+	Tag      string
+	Spent    Money
+	Received Money
+	Entries  int64
+	Months   []TagMonth // Oldest first, only months with records
+}
@@ -0,0 +1,50 @@
+package core
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTags(t *testing.T) {
+	got := ParseTags(" Bonus , tredicesima,,BONUS, rimborso  spese ")
+	want := []string{"bonus", "tredicesima", "rimborso spese"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseTags = %q, want %q", got, want)
+	}
+	if got := ParseTags(""); got != nil {
+		t.Errorf("ParseTags(\"\") = %q, want nil", got)
+	}
+}
+
+func TestIncomeValidateTags(t *testing.T) {
+	good := Income{Date: NewDate(2025, 1, 27), Description: "Stipendio", Amount: Money{Cents: 100}, Category: "Lavoro", Subcategory: "Bonus", Tags: []string{"premio"}}
+	if err := good.Validate(); err != nil {
+		t.Fatalf("expected ok, got %v", err)
+	}
+
+	bads := map[string]func(*Income){
+		"long subcategory": func(i *Income) { i.Subcategory = strings.Repeat("x", 51) },
+		"long tag":         func(i *Income) { i.Tags = []string{strings.Repeat("x", MaxTagLength+1)} },
+		"comma in tag":     func(i *Income) { i.Tags = []string{"a,b"} },
+		"too many tags":    func(i *Income) { i.Tags = strings.Split("a,b,c,d,e,f,g,h,i,j,k", ",") },
+	}
+	for name, mutate := range bads {
+		i := good
+		mutate(&i)
+		if err := i.Validate(); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestExpenseValidateTags(t *testing.T) {
+	e := Expense{Date: NewDate(2025, 8, 3), Description: "Traghetto", Amount: Money{Cents: 8900}, Primary: "Trasporti", Secondary: "Traghetto", Tags: []string{"vacanze 2025"}}
+	if err := e.Validate(); err != nil {
+		t.Fatalf("expected ok, got %v", err)
+	}
+	e.Tags = strings.Split("a,b,c,d,e,f,g,h,i,j,k", ",")
+	if err := e.Validate(); err == nil {
+		t.Error("expected an error for too many tags")
+	}
+}
@@ -0,0 +1,64 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// MaxCategoryNameLength bounds imported category names.
+const MaxCategoryNameLength = 100
+
+var ErrInvalidTaxonomy = errors.New("invalid taxonomy")
+
+var (
+	ErrCategoryExists   = errors.New("category already exists") // Name taken under the same parent
+	ErrCategoryNotFound = errors.New("category not found")      // No category with that name
+	ErrCategoryInUse    = errors.New("category in use")         // Expenses or recurrents still file under it
+)
+
+// CategoryGroup is a primary category with its secondary categories, the
+// unit of a taxonomy import.
+type CategoryGroup struct {
+	Primary     string
+	Secondaries []string
+}
+
+// Validate checks non-empty, trimmed names without duplicate secondaries.
+func (g CategoryGroup) Validate() error {
+	if err := ValidateCategoryName(g.Primary); err != nil {
+		return err
+	}
+	seen := make(map[string]bool, len(g.Secondaries))
+	for _, s := range g.Secondaries {
+		if err := ValidateCategoryName(s); err != nil {
+			return err
+		}
+		if seen[s] {
+			return fmt.Errorf("%w: duplicate secondary %q in %q", ErrInvalidTaxonomy, s, g.Primary)
+		}
+		seen[s] = true
+	}
+	return nil
+}
+
+// Has reports whether the group lists the secondary category.
+func (g CategoryGroup) Has(secondary string) bool {
+	for _, s := range g.Secondaries {
+		if s == secondary {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateCategoryName checks a non-empty, trimmed name of bounded length.
+func ValidateCategoryName(name string) error {
+	if name == "" || strings.TrimSpace(name) != name {
+		return fmt.Errorf("%w: category name %q", ErrInvalidTaxonomy, name)
+	}
+	if len(name) > MaxCategoryNameLength {
+		return fmt.Errorf("%w: category name too long: %q", ErrInvalidTaxonomy, name)
+	}
+	return nil
+}
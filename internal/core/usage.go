@@ -0,0 +1,86 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// UtilityKind is a metered utility a bill can record consumption for.
+type UtilityKind string
+
+const (
+	UtilityElectricity UtilityKind = "electricity" // kWh
+	UtilityGas         UtilityKind = "gas"         // Standard m³
+	UtilityWater       UtilityKind = "water"       // m³
+)
+
+// UtilityKinds lists the utilities in display order.
+var UtilityKinds = []UtilityKind{UtilityElectricity, UtilityGas, UtilityWater}
+
+// MaxUsageQuantity bounds the consumption of one bill.
+const MaxUsageQuantity = 1000000
+
+var ErrInvalidUsage = errors.New("invalid usage")
+
+// Unit returns the unit consumption of the utility is measured in.
+func (k UtilityKind) Unit() string {
+	switch k {
+	case UtilityElectricity:
+		return "kWh"
+	case UtilityGas:
+		return "Smc"
+	default:
+		return "m³"
+	}
+}
+
+// ParseUtilityKind returns the utility named s.
+func ParseUtilityKind(s string) (UtilityKind, error) {
+	for _, k := range UtilityKinds {
+		if string(k) == s {
+			return k, nil
+		}
+	}
+	return "", fmt.Errorf("%w: utility %q", ErrInvalidUsage, s)
+}
+
+// UtilityUsage is the consumption billed by a utility expense.
+type UtilityUsage struct {
+	Kind     UtilityKind
+	Quantity float64
+}
+
+// Validate checks the utility and a positive quantity.
+func (u UtilityUsage) Validate() error {
+	if _, err := ParseUtilityKind(string(u.Kind)); err != nil {
+		return err
+	}
+	if u.Quantity <= 0 || u.Quantity > MaxUsageQuantity || math.IsNaN(u.Quantity) {
+		return fmt.Errorf("%w: quantity %v", ErrInvalidUsage, u.Quantity)
+	}
+	return nil
+}
+
+// UnitCost returns the price paid per unit, in euros: it needs more
+// precision than cents.
+func (u UtilityUsage) UnitCost(amount Money) float64 {
+	if u.Quantity <= 0 {
+		return 0
+	}
+	return amount.Euros() / u.Quantity
+}
+
+// SplitCostChange splits the change in cost between two bills of the same
+// utility into the part due to the tariff (the change in unit cost, at the
+// new consumption) and the part due to usage (the change in consumption,
+// at the old unit cost). The two add up to the change in amount.
+func SplitCostChange(prevAmount Money, prev UtilityUsage, amount Money, cur UtilityUsage) (tariff, usage Money) {
+	if prev.Quantity <= 0 {
+		return Money{}, Money{}
+	}
+	prevUnit := float64(prevAmount.Cents) / prev.Quantity
+	usage = Money{Cents: int64(math.Round(prevUnit * (cur.Quantity - prev.Quantity)))}
+	tariff = Money{Cents: amount.Cents - prevAmount.Cents - usage.Cents}
+	return tariff, usage
+}
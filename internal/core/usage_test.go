@@ -0,0 +1,27 @@
+package core
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSplitCostChange(t *testing.T) {
+	prev := UtilityUsage{Kind: UtilityElectricity, Quantity: 200}
+	cur := UtilityUsage{Kind: UtilityElectricity, Quantity: 250}
+
+	// €50 for 200 kWh, then €75 for 250 kWh: €0,25/kWh to €0,30/kWh
+	if got := cur.UnitCost(Money{Cents: 7500}); math.Abs(got-0.30) > 1e-9 {
+		t.Errorf("UnitCost = %v, want 0.30", got)
+	}
+	tariff, usage := SplitCostChange(Money{Cents: 5000}, prev, Money{Cents: 7500}, cur)
+	if usage.Cents != 1250 || tariff.Cents != 1250 {
+		t.Errorf("tariff = %d, usage = %d, want 1250 and 1250", tariff.Cents, usage.Cents)
+	}
+
+	if err := (UtilityUsage{Kind: "heat", Quantity: 1}).Validate(); err == nil {
+		t.Error("unknown utility accepted")
+	}
+	if err := (UtilityUsage{Kind: UtilityGas}).Validate(); err == nil {
+		t.Error("zero quantity accepted")
+	}
+}
@@ -0,0 +1,113 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Bounds of a single fuel fill.
+const (
+	MaxFuelLiters = 500
+	MaxOdometerKm = 5000000
+)
+
+var ErrInvalidFuelFill = errors.New("invalid fuel fill")
+
+// FuelFill is the metadata of a fuel expense: the liters bought and the
+// odometer reading at the pump.
+type FuelFill struct {
+	Liters   float64
+	Odometer int64 // km
+}
+
+// Validate checks positive liters and odometer reading.
+func (f FuelFill) Validate() error {
+	if f.Liters <= 0 || f.Liters > MaxFuelLiters || math.IsNaN(f.Liters) {
+		return fmt.Errorf("%w: liters %v", ErrInvalidFuelFill, f.Liters)
+	}
+	if f.Odometer <= 0 || f.Odometer > MaxOdometerKm {
+		return fmt.Errorf("%w: odometer %d", ErrInvalidFuelFill, f.Odometer)
+	}
+	return nil
+}
+
+// PricePerLiter returns the fuel price paid, in euros.
+func (f FuelFill) PricePerLiter(amount Money) float64 {
+	if f.Liters <= 0 {
+		return 0
+	}
+	return amount.Euros() / f.Liters
+}
+
+// FuelRecord is a fuel expense with its fill.
+type FuelRecord struct {
+	Date   time.Time
+	Amount Money
+	Fill   FuelFill
+}
+
+// EconomyPoint is the fuel economy of the distance driven up to a fill.
+type EconomyPoint struct {
+	Fill         int // Index of the record of the fill
+	Date         time.Time
+	Distance     int64   // km since the previous fill
+	LitersPer100 float64 // Liters of this fill per 100 km driven
+	CostPerKm    float64 // Euros of this fill per km driven
+}
+
+// FuelEconomy computes the economy between consecutive fills, ordered by
+// odometer, with the full-tank method: each fill refills what was burnt
+// since the previous one. Fills that do not advance the odometer are
+// skipped.
+func FuelEconomy(records []FuelRecord) []EconomyPoint {
+	var points []EconomyPoint
+	var prev *FuelRecord
+	for i := range records {
+		r := &records[i]
+		if prev != nil && r.Fill.Odometer > prev.Fill.Odometer {
+			distance := r.Fill.Odometer - prev.Fill.Odometer
+			points = append(points, EconomyPoint{
+				Fill:         i,
+				Date:         r.Date,
+				Distance:     distance,
+				LitersPer100: r.Fill.Liters / float64(distance) * 100,
+				CostPerKm:    r.Amount.Euros() / float64(distance),
+			})
+		}
+		if prev == nil || r.Fill.Odometer > prev.Fill.Odometer {
+			prev = r
+		}
+	}
+	return points
+}
+
+// AverageEconomy returns the liters per 100 km over all the points,
+// weighted by distance.
+func AverageEconomy(points []EconomyPoint) float64 {
+	var liters float64
+	var distance int64
+	for _, p := range points {
+		liters += p.LitersPer100 * float64(p.Distance) / 100
+		distance += p.Distance
+	}
+	if distance == 0 {
+		return 0
+	}
+	return liters / float64(distance) * 100
+}
+
+// DrivenDistance returns the km between the lowest and highest odometer
+// readings.
+func DrivenDistance(records []FuelRecord) int64 {
+	if len(records) == 0 {
+		return 0
+	}
+	lowest, highest := records[0].Fill.Odometer, records[0].Fill.Odometer
+	for _, r := range records[1:] {
+		lowest = min(lowest, r.Fill.Odometer)
+		highest = max(highest, r.Fill.Odometer)
+	}
+	return highest - lowest
+}
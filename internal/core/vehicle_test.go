@@ -0,0 +1,42 @@
+package core
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestFuelEconomy(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2031, 1, d, 0, 0, 0, 0, time.UTC) }
+	records := []FuelRecord{
+		{Date: day(1), Amount: Money{Cents: 6000}, Fill: FuelFill{Liters: 35, Odometer: 10000}},
+		{Date: day(10), Amount: Money{Cents: 5000}, Fill: FuelFill{Liters: 30, Odometer: 10500}},
+		// A reading lower than the previous one is a typo: skipped
+		{Date: day(12), Amount: Money{Cents: 1000}, Fill: FuelFill{Liters: 5, Odometer: 1060}},
+		{Date: day(20), Amount: Money{Cents: 4000}, Fill: FuelFill{Liters: 20, Odometer: 11000}},
+	}
+
+	points := FuelEconomy(records)
+	if len(points) != 2 {
+		t.Fatalf("points = %+v, want 2", points)
+	}
+	if points[0].Distance != 500 || math.Abs(points[0].LitersPer100-6) > 1e-9 || math.Abs(points[0].CostPerKm-0.10) > 1e-9 {
+		t.Errorf("first point = %+v", points[0])
+	}
+	if got := AverageEconomy(points); math.Abs(got-5) > 1e-9 {
+		t.Errorf("AverageEconomy = %v, want 5", got)
+	}
+	if got := DrivenDistance(records[:2]); got != 500 {
+		t.Errorf("DrivenDistance = %d, want 500", got)
+	}
+	if got := (FuelFill{Liters: 40, Odometer: 1}).PricePerLiter(Money{Cents: 7000}); math.Abs(got-1.75) > 1e-9 {
+		t.Errorf("PricePerLiter = %v, want 1.75", got)
+	}
+
+	if err := (FuelFill{Liters: 0, Odometer: 100}).Validate(); err == nil {
+		t.Error("zero liters accepted")
+	}
+	if err := (FuelFill{Liters: 40}).Validate(); err == nil {
+		t.Error("missing odometer accepted")
+	}
+}
@@ -0,0 +1,74 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxViewNameLength is the longest name of a saved view
+const MaxViewNameLength = 50
+
+// ErrInvalidView is returned for a saved view that cannot be stored.
+var ErrInvalidView = errors.New("invalid view")
+
+// ExpenseFilter selects expenses by category, amount, description and tag.
+// Empty fields select everything.
+type ExpenseFilter struct {
+	Primary   string
+	Secondary string
+	Min       Money  // Zero for no lower bound
+	Max       Money  // Zero for no upper bound
+	Text      string // Found in the description, ignoring case
+	Tag       string // Carried by the expense, normalized like its tags
+}
+
+// Empty reports whether the filter selects every expense.
+func (f ExpenseFilter) Empty() bool {
+	return f.Primary == "" && f.Secondary == "" && f.Min.Cents == 0 && f.Max.Cents == 0 && f.Text == "" && f.Tag == ""
+}
+
+// Matches reports whether the filter selects e.
+func (f ExpenseFilter) Matches(e Expense) bool {
+	switch {
+	case f.Primary != "" && e.Primary != f.Primary,
+		f.Secondary != "" && e.Secondary != f.Secondary,
+		f.Min.Cents > 0 && e.Amount.Cents < f.Min.Cents,
+		f.Max.Cents > 0 && e.Amount.Cents > f.Max.Cents,
+		f.Tag != "" && !slices.Contains(e.Tags, f.Tag):
+		return false
+	}
+	return f.Text == "" || strings.Contains(strings.ToLower(e.Description), strings.ToLower(f.Text))
+}
+
+// SavedView is a named filter shown in the navigation. With Notify on,
+// every new expense it selects sends an alert.
+type SavedView struct {
+	ID     int64
+	Name   string
+	Filter ExpenseFilter
+	Notify bool
+}
+
+// Validate checks that the view has a name and selects some expenses
+// rather than all of them.
+func (v SavedView) Validate() error {
+	name := strings.TrimSpace(v.Name)
+	switch {
+	case name == "":
+		return fmt.Errorf("%w: name is required", ErrInvalidView)
+	case utf8.RuneCountInString(name) > MaxViewNameLength:
+		return fmt.Errorf("%w: name too long", ErrInvalidView)
+	case v.Filter.Empty():
+		return fmt.Errorf("%w: the filter selects every expense", ErrInvalidView)
+	case v.Filter.Min.Cents < 0 || v.Filter.Max.Cents < 0:
+		return fmt.Errorf("%w: amounts must be positive", ErrInvalidView)
+	case v.Filter.Max.Cents > 0 && v.Filter.Max.Cents < v.Filter.Min.Cents:
+		return fmt.Errorf("%w: the maximum is below the minimum", ErrInvalidView)
+	case strings.Contains(v.Filter.Tag, ","):
+		return fmt.Errorf("%w: a tag cannot contain commas", ErrInvalidView)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package core
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestExpenseFilter_Matches(t *testing.T) {
+	e := Expense{Description: "Volo Milano-Parigi", Amount: Money{Cents: 25000}, Primary: "Viaggi", Secondary: "Aereo", Tags: []string{"vacanze 2025"}}
+	cases := []struct {
+		filter ExpenseFilter
+		want   bool
+	}{
+		{ExpenseFilter{Primary: "Viaggi"}, true},
+		{ExpenseFilter{Primary: "Casa"}, false},
+		{ExpenseFilter{Primary: "Viaggi", Secondary: "Treno"}, false},
+		{ExpenseFilter{Min: Money{Cents: 10000}}, true},
+		{ExpenseFilter{Min: Money{Cents: 30000}}, false},
+		{ExpenseFilter{Max: Money{Cents: 25000}}, true},
+		{ExpenseFilter{Max: Money{Cents: 20000}}, false},
+		{ExpenseFilter{Text: "parigi"}, true},
+		{ExpenseFilter{Text: "roma"}, false},
+		{ExpenseFilter{Tag: "vacanze 2025"}, true},
+		{ExpenseFilter{Tag: "lavoro"}, false},
+	}
+	for _, c := range cases {
+		if got := c.filter.Matches(e); got != c.want {
+			t.Errorf("%+v.Matches() = %v, want %v", c.filter, got, c.want)
+		}
+	}
+}
+
+func TestSavedView_Validate(t *testing.T) {
+	valid := SavedView{Name: "Grandi acquisti", Filter: ExpenseFilter{Min: Money{Cents: 10000}}}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("valid view refused: %v", err)
+	}
+	for _, v := range []SavedView{
+		{Name: " ", Filter: valid.Filter},
+		{Name: "Tutto"},
+		{Name: "Rovesciato", Filter: ExpenseFilter{Min: Money{Cents: 500}, Max: Money{Cents: 100}}},
+		{Name: "Negativo", Filter: ExpenseFilter{Min: Money{Cents: -1}}},
+	} {
+		if err := v.Validate(); !errors.Is(err, ErrInvalidView) {
+			t.Errorf("%+v: expected ErrInvalidView, got %v", v, err)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package core
+
+import (
+	"errors"
+	"time"
+)
+
+// Warranty limits
+const (
+	MaxWarrantyMonths = 120
+	MaxProofLength    = 500
+)
+
+var ErrInvalidWarranty = errors.New("invalid warranty")
+
+// Warranty records the after-sale terms of a purchase: how long the
+// warranty lasts and until when it can be returned, with a reference to
+// the proof of purchase (receipt number, invoice link).
+type Warranty struct {
+	Months         int  // 0 when there is no warranty
+	ReturnDeadline Date // Zero when it cannot be returned
+	Proof          string
+}
+
+// ExpiresOn returns the last day of the warranty for a purchase made on the
+// given date, or a zero Date when there is no warranty.
+func (w Warranty) ExpiresOn(purchase Date) Date {
+	if w.Months <= 0 {
+		return Date{}
+	}
+	return Date{Time: purchase.AddDate(0, w.Months, 0)}
+}
+
+// Active reports whether, on day now, the purchase is still under warranty
+// or can still be returned.
+func (w Warranty) Active(purchase Date, now time.Time) bool {
+	today := now.Truncate(24 * time.Hour)
+	if exp := w.ExpiresOn(purchase); !exp.IsZero() && !exp.Before(today) {
+		return true
+	}
+	return !w.ReturnDeadline.IsZero() && !w.ReturnDeadline.Before(today)
+}
+
+// Validate checks a warranty against the purchase date: at least a warranty
+// length or a return deadline, the deadline not before the purchase.
+func (w Warranty) Validate(purchase Date) error {
+	if w.Months < 0 || w.Months > MaxWarrantyMonths {
+		return errors.New("warranty length must be between 0 and 120 months")
+	}
+	if w.Months == 0 && w.ReturnDeadline.IsZero() {
+		return ErrInvalidWarranty
+	}
+	if !w.ReturnDeadline.IsZero() && w.ReturnDeadline.Before(purchase.Time) {
+		return errors.New("return deadline before the purchase date")
+	}
+	if len(w.Proof) > MaxProofLength {
+		return errors.New("proof of purchase too long (max 500 characters)")
+	}
+	return nil
+}
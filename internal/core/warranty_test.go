@@ -0,0 +1,40 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWarranty(t *testing.T) {
+	purchase := NewDate(2031, 1, 31)
+	w := Warranty{Months: 24, ReturnDeadline: NewDate(2031, 2, 14)}
+	if err := w.Validate(purchase); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if got := w.ExpiresOn(purchase).Format("2006-01-02"); got != "2033-01-31" {
+		t.Errorf("ExpiresOn = %s", got)
+	}
+	if !w.Active(purchase, time.Date(2033, 1, 31, 18, 0, 0, 0, time.UTC)) {
+		t.Error("expected active on the last warranty day")
+	}
+	if w.Active(purchase, time.Date(2033, 2, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Error("expected expired after the warranty")
+	}
+
+	returnOnly := Warranty{ReturnDeadline: NewDate(2031, 2, 14)}
+	if !returnOnly.ExpiresOn(purchase).IsZero() || returnOnly.Active(purchase, time.Date(2031, 2, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Error("return-only purchase should end with the return window")
+	}
+
+	bads := map[string]Warranty{
+		"nothing":         {},
+		"negative months": {Months: -1},
+		"too long":        {Months: MaxWarrantyMonths + 1},
+		"early deadline":  {ReturnDeadline: NewDate(2031, 1, 30)},
+	}
+	for name, w := range bads {
+		if err := w.Validate(purchase); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+)
+
+// WorkflowState is the approval state of an expense when the business
+// workflow is enabled. Expenses never moved through the workflow are drafts.
+type WorkflowState string
+
+const (
+	WorkflowDraft      WorkflowState = "draft"
+	WorkflowSubmitted  WorkflowState = "submitted"
+	WorkflowApproved   WorkflowState = "approved"
+	WorkflowReimbursed WorkflowState = "reimbursed"
+)
+
+// WorkflowStates lists the states in workflow order.
+var WorkflowStates = []WorkflowState{WorkflowDraft, WorkflowSubmitted, WorkflowApproved, WorkflowReimbursed}
+
+// WorkflowRole is who is moving an expense through the workflow. An
+// approver can also perform every submitter transition.
+type WorkflowRole string
+
+const (
+	RoleSubmitter WorkflowRole = "submitter"
+	RoleApprover  WorkflowRole = "approver"
+)
+
+var (
+	ErrInvalidWorkflowState = errors.New("invalid workflow state")
+	ErrInvalidTransition    = errors.New("invalid workflow transition")     // No transition between the two states
+	ErrTransitionForbidden  = errors.New("workflow transition not allowed") // The role cannot perform the transition
+)
+
+// workflowTransitions maps each allowed transition to the least privileged
+// role that can perform it.
+var workflowTransitions = map[[2]WorkflowState]WorkflowRole{
+	{WorkflowDraft, WorkflowSubmitted}:     RoleSubmitter,
+	{WorkflowSubmitted, WorkflowDraft}:     RoleSubmitter, // withdrawn, or sent back by an approver
+	{WorkflowSubmitted, WorkflowApproved}:  RoleApprover,
+	{WorkflowApproved, WorkflowReimbursed}: RoleApprover,
+}
+
+// ParseWorkflowState returns the state named s.
+func ParseWorkflowState(s string) (WorkflowState, error) {
+	for _, state := range WorkflowStates {
+		if string(state) == s {
+			return state, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %q", ErrInvalidWorkflowState, s)
+}
+
+// CheckWorkflowTransition reports whether role can move an expense from one
+// state to the other.
+func CheckWorkflowTransition(from, to WorkflowState, role WorkflowRole) error {
+	required, ok := workflowTransitions[[2]WorkflowState{from, to}]
+	if !ok {
+		return fmt.Errorf("%w: %s to %s", ErrInvalidTransition, from, to)
+	}
+	if required == RoleApprover && role != RoleApprover {
+		return fmt.Errorf("%w: %s to %s requires the %s role", ErrTransitionForbidden, from, to, RoleApprover)
+	}
+	return nil
+}
+
+// NextWorkflowStates returns the states role can move an expense to from
+// the given state, in workflow order.
+func NextWorkflowStates(from WorkflowState, role WorkflowRole) []WorkflowState {
+	var next []WorkflowState
+	for _, to := range WorkflowStates {
+		if CheckWorkflowTransition(from, to, role) == nil {
+			next = append(next, to)
+		}
+	}
+	return next
+}
@@ -0,0 +1,44 @@
+package core
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCheckWorkflowTransition(t *testing.T) {
+	cases := []struct {
+		from, to WorkflowState
+		role     WorkflowRole
+		want     error
+	}{
+		{WorkflowDraft, WorkflowSubmitted, RoleSubmitter, nil},
+		{WorkflowSubmitted, WorkflowDraft, RoleApprover, nil},
+		{WorkflowSubmitted, WorkflowApproved, RoleApprover, nil},
+		{WorkflowSubmitted, WorkflowApproved, RoleSubmitter, ErrTransitionForbidden},
+		{WorkflowApproved, WorkflowReimbursed, RoleSubmitter, ErrTransitionForbidden},
+		{WorkflowDraft, WorkflowApproved, RoleApprover, ErrInvalidTransition},
+		{WorkflowReimbursed, WorkflowDraft, RoleApprover, ErrInvalidTransition},
+	}
+	for _, c := range cases {
+		err := CheckWorkflowTransition(c.from, c.to, c.role)
+		if c.want == nil && err != nil || c.want != nil && !errors.Is(err, c.want) {
+			t.Errorf("%s -> %s as %s: err = %v, want %v", c.from, c.to, c.role, err, c.want)
+		}
+	}
+}
+
+func TestNextWorkflowStates(t *testing.T) {
+	if got := NextWorkflowStates(WorkflowSubmitted, RoleSubmitter); !reflect.DeepEqual(got, []WorkflowState{WorkflowDraft}) {
+		t.Errorf("submitted as submitter = %v", got)
+	}
+	if got := NextWorkflowStates(WorkflowSubmitted, RoleApprover); !reflect.DeepEqual(got, []WorkflowState{WorkflowDraft, WorkflowApproved}) {
+		t.Errorf("submitted as approver = %v", got)
+	}
+	if got := NextWorkflowStates(WorkflowReimbursed, RoleApprover); got != nil {
+		t.Errorf("reimbursed as approver = %v", got)
+	}
+	if _, err := ParseWorkflowState("paid"); !errors.Is(err, ErrInvalidWorkflowState) {
+		t.Errorf("ParseWorkflowState(paid) err = %v", err)
+	}
+}
@@ -0,0 +1,191 @@
+// Package csvimport reads expenses from CSV files, such as the exports of
+// a bank or of another expense tracker.
+package csvimport
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"spese/internal/core"
+)
+
+// MaxRows is how many data rows a file may hold
+const MaxRows = 20000
+
+// ErrMissingColumns is returned when the header lacks a required column.
+var ErrMissingColumns = errors.New("missing columns")
+
+// Row is a data row of the file: the expense it holds, or why it is
+// invalid.
+type Row struct {
+	Line    int // 1-based, the header being line 1
+	Expense core.Expense
+	Err     error
+}
+
+// Column names accepted in the header, in English and Italian, ignoring
+// case and spacing
+var columnNames = map[string]string{
+	"date":           "date",
+	"data":           "date",
+	"description":    "description",
+	"descrizione":    "description",
+	"amount":         "amount",
+	"importo":        "amount",
+	"primary":        "primary",
+	"category":       "primary",
+	"categoria":      "primary",
+	"secondary":      "secondary",
+	"subcategory":    "secondary",
+	"sottocategoria": "secondary",
+}
+
+// dateLayouts are the date formats accepted, tried in order
+var dateLayouts = []string{"2006-01-02", "02/01/2006", "2/1/2006", "02-01-2006", "02.01.2006"}
+
+// Parse reads a CSV file with a header naming its columns: date,
+// description and amount are required, primary and secondary categories
+// optional. Fields are separated by commas or semicolons, whichever the
+// header uses. Rows without categories get the uncategorized placeholder,
+// for the categorization rules to fill in. Amounts may use a decimal
+// comma, thousands separators and a currency sign; negative amounts, the
+// way banks list debits, are taken as positive.
+//
+// Parse fails only when the file as a whole cannot be read; invalid rows
+// are returned with their error.
+func Parse(r io.Reader) ([]Row, error) {
+	br := bufio.NewReader(r)
+	first, err := br.Peek(4096)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+	first = bytes.TrimPrefix(first, []byte("\ufeff"))
+	if len(bytes.TrimSpace(first)) == 0 {
+		return nil, errors.New("empty file")
+	}
+
+	cr := csv.NewReader(br)
+	if header, _, _ := bytes.Cut(first, []byte("\n")); bytes.Count(header, []byte(";")) > bytes.Count(header, []byte(",")) {
+		cr.Comma = ';'
+	}
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if field, ok := columnNames[name]; ok {
+			if _, dup := columns[field]; !dup {
+				columns[field] = i
+			}
+		}
+	}
+	var missing []string
+	for _, field := range []string{"date", "description", "amount"} {
+		if _, ok := columns[field]; !ok {
+			missing = append(missing, field)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrMissingColumns, strings.Join(missing, ", "))
+	}
+
+	var rows []Row
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, fmt.Errorf("read file: %w", err)
+			}
+			rows = append(rows, Row{Line: parseErr.Line, Err: parseErr.Err})
+			continue
+		}
+		line, _ := cr.FieldPos(0)
+		if blank(record) {
+			continue
+		}
+		if len(rows) == MaxRows {
+			return nil, fmt.Errorf("too many rows (max %d)", MaxRows)
+		}
+		e, err := parseRecord(record, columns)
+		rows = append(rows, Row{Line: line, Expense: e, Err: err})
+	}
+	return rows, nil
+}
+
+func blank(record []string) bool {
+	for _, field := range record {
+		if strings.TrimSpace(field) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func parseRecord(record []string, columns map[string]int) (core.Expense, error) {
+	field := func(name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var e core.Expense
+	date, err := parseDate(field("date"))
+	if err != nil {
+		return e, err
+	}
+	cents, err := parseAmount(field("amount"))
+	if err != nil {
+		return e, err
+	}
+	e = core.Expense{
+		Date:        core.Date{Time: date},
+		Description: strings.Join(strings.Fields(field("description")), " "),
+		Amount:      core.Money{Cents: cents},
+		Primary:     field("primary"),
+		Secondary:   field("secondary"),
+	}
+	if e.Primary == "" && e.Secondary == "" {
+		e.Primary, e.Secondary = core.UncategorizedPrimary, core.UncategorizedSecondary
+	}
+	return e, e.Validate()
+}
+
+func parseDate(s string) (time.Time, error) {
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", s)
+}
+
+// parseAmount reads an amount such as "12,50", "-1.234,56" or "€ 3.20",
+// with the separators read as the forms read them.
+func parseAmount(s string) (int64, error) {
+	s = strings.ReplaceAll(s, "EUR", "")
+	cents, err := core.ParseSignedDecimalToCents(s)
+	if err != nil || cents == 0 {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	if cents < 0 {
+		cents = -cents
+	}
+	return cents, nil
+}
@@ -0,0 +1,64 @@
+package csvimport
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"spese/internal/core"
+)
+
+func TestParse(t *testing.T) {
+	file := "\ufeffData;Descrizione;Importo;Categoria;Sottocategoria\n" +
+		"03/02/2031;Esselunga  Milano;-1.234,56;Spesa;Supermercato\n" +
+		"2031-02-04;Bar;€ 3,20;;\n" +
+		";;;;\n" +
+		"31/02/2031;Data sbagliata;10;Casa;Mutuo\n" +
+		"05/02/2031;Gratis;0,00;Casa;Mutuo\n" +
+		"06/02/2031;;12;Casa;Mutuo\n"
+
+	rows, err := Parse(strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 5 {
+		t.Fatalf("expected 5 rows (the blank one skipped), got %+v", rows)
+	}
+
+	want := core.Expense{Date: core.NewDate(2031, 2, 3), Description: "Esselunga Milano", Amount: core.Money{Cents: 123456}, Primary: "Spesa", Secondary: "Supermercato"}
+	if got := rows[0]; got.Err != nil || got.Line != 2 || !got.Expense.Date.Equal(want.Date.Time) || got.Expense.Description != want.Description ||
+		got.Expense.Amount != want.Amount || got.Expense.Primary != want.Primary || got.Expense.Secondary != want.Secondary {
+		t.Errorf("row 2 = %+v, want %+v", got, want)
+	}
+	if got := rows[1]; got.Err != nil || got.Expense.Amount.Cents != 320 || got.Expense.Primary != core.UncategorizedPrimary || got.Expense.Secondary != core.UncategorizedSecondary {
+		t.Errorf("row 3 = %+v, want 3,20 uncategorized", got)
+	}
+	for i, line := range []int{5, 6, 7} {
+		if got := rows[2+i]; got.Err == nil || got.Line != line {
+			t.Errorf("expected line %d invalid, got %+v", line, got)
+		}
+	}
+}
+
+func TestParse_Comma(t *testing.T) {
+	rows, err := Parse(strings.NewReader("date,description,amount\n2031-01-02,\"Pane, latte\",2.50\n2031-01-03,Caffè,\"1,000.50\"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0].Err != nil || rows[0].Expense.Description != "Pane, latte" || rows[0].Expense.Amount.Cents != 250 {
+		t.Fatalf("unexpected rows %+v", rows)
+	}
+	if rows[1].Err != nil || rows[1].Expense.Amount.Cents != 100050 {
+		t.Fatalf("expected 1000,50 with a thousands separator, got %+v", rows[1])
+	}
+}
+
+func TestParse_MissingColumns(t *testing.T) {
+	_, err := Parse(strings.NewReader("date;amount\n2031-01-02;2\n"))
+	if !errors.Is(err, ErrMissingColumns) || !strings.Contains(err.Error(), "description") {
+		t.Fatalf("expected the description column missing, got %v", err)
+	}
+	if _, err := Parse(strings.NewReader("  \n")); err == nil {
+		t.Fatal("expected an empty file refused")
+	}
+}
@@ -0,0 +1,137 @@
+// Package demo generates the fake dataset served in DEMO_MODE. The data is
+// plausible household spending over the last months, using the categories
+// created by the migrations, and is regenerated on every reset.
+package demo
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"spese/internal/core"
+)
+
+// Months is how many months of history the dataset covers, the current
+// month included.
+const Months = 6
+
+// Store replaces the stored dataset in a single step.
+type Store interface {
+	ReplaceDataset(ctx context.Context, expenses []core.Expense, incomes []core.Income, recurrents []core.RecurrentExpenses) error
+}
+
+// Reset replaces the stored data with a fresh dataset ending at now.
+func Reset(ctx context.Context, store Store, now time.Time) error {
+	expenses, incomes, recurrents := Dataset(now)
+	return store.ReplaceDataset(ctx, expenses, incomes, recurrents)
+}
+
+// NextReset returns the first local midnight after now.
+func NextReset(now time.Time) time.Time {
+	y, m, d := now.Date()
+	return time.Date(y, m, d+1, 0, 0, 0, 0, now.Location())
+}
+
+// bill is an expense repeated every month on a fixed day
+type bill struct {
+	day                int
+	description        string
+	minCents, maxCents int64
+	primary, secondary string
+}
+
+var monthlyBills = []bill{
+	{1, "Rata mutuo", 85000, 85000, "Casa", "Mutuo"},
+	{5, "Fibra internet", 2990, 2990, "Casa", "Internet"},
+	{10, "Bolletta luce", 5500, 9000, "Casa", "Elettricità"},
+	{15, "Ricarica telefono", 999, 999, "Casa", "Telefono"},
+	{20, "Abbonamento palestra", 4500, 4500, "Salute", "Sport"},
+}
+
+// occasional is an expense that happens on a day with a given probability
+type occasional struct {
+	chance             float64
+	weekdays           []time.Weekday // nil means any day
+	descriptions       []string
+	minCents, maxCents int64
+	primary, secondary string
+}
+
+var occasionalExpenses = []occasional{
+	{1, []time.Weekday{time.Saturday}, []string{"Spesa settimanale"}, 6000, 13000, "Spesa", "Everli"},
+	{0.5, []time.Weekday{time.Wednesday}, []string{"Mercato rionale", "Panetteria"}, 800, 2500, "Spesa", "Altre spese (non Everli)"},
+	{0.25, nil, []string{"Caffè e brioche", "Aperitivo"}, 250, 1200, "Fuori (come fuori a cena...)", "Bar"},
+	{0.6, []time.Weekday{time.Friday}, []string{"Pizzeria", "Trattoria", "Sushi"}, 3000, 7000, "Fuori (come fuori a cena...)", "Ristoranti"},
+	{0.1, nil, []string{"Benzina"}, 5000, 7000, "Trasporti", "Spese automobile"},
+	{0.4, []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday}, []string{"Biglietto metro"}, 220, 220, "Trasporti", "Trasporto locale"},
+	{0.08, nil, []string{"Farmacia"}, 500, 2500, "Salute", "Medicine"},
+	{0.15, []time.Weekday{time.Saturday, time.Sunday}, []string{"Cinema", "Museo", "Concerto"}, 1200, 4500, "Divertimento", "Divertimento familiare"},
+}
+
+// Dataset returns the fake expenses, incomes and recurrent expenses for
+// the Months ending at now. The same day always yields the same data.
+func Dataset(now time.Time) ([]core.Expense, []core.Income, []core.RecurrentExpenses) {
+	y, m, d := now.Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	rng := rand.New(rand.NewSource(int64(y*10000 + int(m)*100 + d)))
+	amount := func(min, max int64) core.Money {
+		if max <= min {
+			return core.Money{Cents: min}
+		}
+		return core.Money{Cents: min + rng.Int63n(max-min+1)}
+	}
+
+	start := time.Date(y, m-Months+1, 1, 0, 0, 0, 0, time.UTC)
+
+	var expenses []core.Expense
+	var incomes []core.Income
+	for day := start; !day.After(today); day = day.AddDate(0, 0, 1) {
+		date := core.Date{Time: day}
+
+		for _, b := range monthlyBills {
+			if day.Day() == b.day {
+				expenses = append(expenses, core.Expense{Date: date, Description: b.description, Amount: amount(b.minCents, b.maxCents), Primary: b.primary, Secondary: b.secondary})
+			}
+		}
+		for _, o := range occasionalExpenses {
+			if !onWeekday(day, o.weekdays) || rng.Float64() >= o.chance {
+				continue
+			}
+			description := o.descriptions[rng.Intn(len(o.descriptions))]
+			expenses = append(expenses, core.Expense{Date: date, Description: description, Amount: amount(o.minCents, o.maxCents), Primary: o.primary, Secondary: o.secondary})
+		}
+
+		switch day.Day() {
+		case 15:
+			if rng.Float64() < 0.4 {
+				incomes = append(incomes, core.Income{Date: date, Description: "Progetto freelance", Amount: amount(30000, 90000), Category: "Freelance E", Subcategory: "Consulenza", Tags: []string{"fattura"}})
+			}
+		case 27:
+			incomes = append(incomes,
+				core.Income{Date: date, Description: "Stipendio", Amount: core.Money{Cents: 215000}, Category: "Stipendio E", Subcategory: "Netto"},
+				core.Income{Date: date, Description: "Stipendio", Amount: core.Money{Cents: 198000}, Category: "Stipendio G", Subcategory: "Netto"},
+			)
+		}
+	}
+
+	recurrents := []core.RecurrentExpenses{
+		{StartDate: core.Date{Time: start}, Every: core.Monthly, Description: "Rata mutuo", Amount: core.Money{Cents: 85000}, Primary: "Casa", Secondary: "Mutuo"},
+		{StartDate: core.Date{Time: start}, Every: core.Monthly, Description: "Fibra internet", Amount: core.Money{Cents: 2990}, Primary: "Casa", Secondary: "Internet"},
+		{StartDate: core.Date{Time: start}, Every: core.Monthly, Description: "Abbonamento palestra", Amount: core.Money{Cents: 4500}, Primary: "Salute", Secondary: "Sport"},
+		{StartDate: core.Date{Time: start.AddDate(0, 2, 0)}, Every: core.Yearly, Description: "Assicurazione auto", Amount: core.Money{Cents: 62000}, Primary: "Trasporti", Secondary: "Spese automobile"},
+	}
+
+	return expenses, incomes, recurrents
+}
+
+func onWeekday(day time.Time, weekdays []time.Weekday) bool {
+	if weekdays == nil {
+		return true
+	}
+	for _, w := range weekdays {
+		if day.Weekday() == w {
+			return true
+		}
+	}
+	return false
+}
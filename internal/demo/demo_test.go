@@ -0,0 +1,78 @@
+package demo
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"spese/internal/core"
+)
+
+func TestDataset(t *testing.T) {
+	now := time.Date(2025, 3, 12, 15, 0, 0, 0, time.UTC)
+	expenses, incomes, recurrents := Dataset(now)
+
+	if len(expenses) < 100 || len(incomes) < 10 || len(recurrents) == 0 {
+		t.Fatalf("dataset too small: %d expenses, %d incomes, %d recurrents", len(expenses), len(incomes), len(recurrents))
+	}
+
+	first := time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC)
+	for _, e := range expenses {
+		if err := e.Validate(); err != nil {
+			t.Errorf("invalid expense %+v: %v", e, err)
+		}
+		if e.Date.Before(first) || e.Date.After(now) {
+			t.Errorf("expense %q dated %s outside the demo window", e.Description, e.Date.Format("2006-01-02"))
+		}
+	}
+	for _, i := range incomes {
+		if err := i.Validate(); err != nil {
+			t.Errorf("invalid income %+v: %v", i, err)
+		}
+	}
+	for _, re := range recurrents {
+		if err := re.Validate(); err != nil {
+			t.Errorf("invalid recurrent expense %+v: %v", re, err)
+		}
+	}
+
+	// Stable within a day, different the next one
+	again, _, _ := Dataset(now.Add(6 * time.Hour))
+	if !reflect.DeepEqual(expenses, again) {
+		t.Error("dataset changed within the same day")
+	}
+	tomorrow, _, _ := Dataset(now.AddDate(0, 0, 1))
+	if reflect.DeepEqual(expenses, tomorrow) {
+		t.Error("dataset did not change on the next day")
+	}
+}
+
+type fakeStore struct {
+	expenses []core.Expense
+	calls    int
+}
+
+func (f *fakeStore) ReplaceDataset(_ context.Context, expenses []core.Expense, _ []core.Income, _ []core.RecurrentExpenses) error {
+	f.expenses = expenses
+	f.calls++
+	return nil
+}
+
+func TestReset(t *testing.T) {
+	store := &fakeStore{}
+	if err := Reset(context.Background(), store, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if store.calls != 1 || len(store.expenses) == 0 {
+		t.Errorf("Reset stored %d expenses in %d calls", len(store.expenses), store.calls)
+	}
+}
+
+func TestNextReset(t *testing.T) {
+	loc := time.FixedZone("CET", 3600)
+	got := NextReset(time.Date(2025, 12, 31, 23, 59, 0, 0, loc))
+	if want := time.Date(2026, 1, 1, 0, 0, 0, 0, loc); !got.Equal(want) {
+		t.Errorf("NextReset = %v, want %v", got, want)
+	}
+}
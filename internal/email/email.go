@@ -0,0 +1,204 @@
+// Package email renders the emails of reports and alerts: the monthly
+// digest, budget alerts and sync failure notices. Each kind is an HTML
+// template, built from a few shared blocks and styled inline since mail
+// clients drop <style> sheets, and a plain-text template for clients that
+// do not show HTML. Templates are embedded in the binary.
+package email
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"spese/internal/core"
+	"spese/internal/events"
+)
+
+//go:embed templates/*.html templates/*.txt
+var templateFiles embed.FS
+
+// ErrUnknownKind is returned for a kind without templates.
+var ErrUnknownKind = errors.New("unknown email kind")
+
+// Kind names an email template.
+type Kind string
+
+// Email kinds
+const (
+	KindDigest      Kind = "digest"       // Monthly summary, data: Digest
+	KindBudgetAlert Kind = "budget_alert" // Spending insight, data: core.Insight
+	KindSyncFailed  Kind = "sync_failed"  // Expense the sync gave up on, data: events.SyncFailedPayload
+)
+
+// Kinds lists every email kind, in the order the preview shows them.
+var Kinds = []Kind{KindDigest, KindBudgetAlert, KindSyncFailed}
+
+// Message is a rendered email.
+type Message struct {
+	Subject string
+	HTML    string
+	Text    string
+}
+
+// Digest is the data of the monthly summary.
+type Digest struct {
+	Period     string // "2006-01"
+	Total      core.Money
+	Incomes    core.Money
+	Categories []DigestCategory // Largest first
+	Insights   []core.Insight
+}
+
+// DigestCategory is the spending of a primary category in a digest.
+type DigestCategory struct {
+	Primary string
+	Amount  core.Money
+}
+
+// Balance returns the incomes left after the expenses.
+func (d Digest) Balance() core.Money {
+	return core.Money{Cents: d.Incomes.Cents - d.Total.Cents}
+}
+
+// Share returns the percentage of the month total spent in c.
+func (d Digest) Share(c DigestCategory) int {
+	if d.Total.Cents <= 0 {
+		return 0
+	}
+	return int(c.Amount.Cents * 100 / d.Total.Cents)
+}
+
+// view is what the templates receive: the email data with the address of
+// the app, for links
+type view struct {
+	BaseURL string
+	Data    any
+}
+
+// Renderer renders the emails.
+type Renderer struct {
+	html *htmltemplate.Template
+	text *texttemplate.Template
+}
+
+// NewRenderer parses the embedded templates.
+func NewRenderer() (*Renderer, error) {
+	html, err := htmltemplate.New("").Funcs(htmltemplate.FuncMap{
+		"style":  inlineStyle,
+		"euros":  euros,
+		"period": periodLabel,
+		"date":   func(d core.Date) string { return d.Format("02/01/2006") },
+	}).ParseFS(templateFiles, "templates/*.html")
+	if err != nil {
+		return nil, fmt.Errorf("parse html templates: %w", err)
+	}
+	text, err := texttemplate.New("").Funcs(texttemplate.FuncMap{
+		"euros":  euros,
+		"period": periodLabel,
+		"date":   func(d core.Date) string { return d.Format("02/01/2006") },
+	}).ParseFS(templateFiles, "templates/*.txt")
+	if err != nil {
+		return nil, fmt.Errorf("parse text templates: %w", err)
+	}
+	return &Renderer{html: html, text: text}, nil
+}
+
+// Render renders an email of kind for data, linking to the app at
+// baseURL (e.g. "https://spese.example.com", no trailing slash).
+func (r *Renderer) Render(kind Kind, baseURL string, data any) (Message, error) {
+	if r.html.Lookup(string(kind)+"_html") == nil {
+		return Message{}, fmt.Errorf("%w: %q", ErrUnknownKind, kind)
+	}
+	v := view{BaseURL: strings.TrimSuffix(baseURL, "/"), Data: data}
+
+	var subject, text, html bytes.Buffer
+	if err := r.text.ExecuteTemplate(&subject, string(kind)+"_subject", v); err != nil {
+		return Message{}, fmt.Errorf("render %s subject: %w", kind, err)
+	}
+	if err := r.text.ExecuteTemplate(&text, string(kind)+"_text", v); err != nil {
+		return Message{}, fmt.Errorf("render %s text: %w", kind, err)
+	}
+	if err := r.html.ExecuteTemplate(&html, string(kind)+"_html", v); err != nil {
+		return Message{}, fmt.Errorf("render %s html: %w", kind, err)
+	}
+	return Message{
+		Subject: strings.TrimSpace(subject.String()),
+		HTML:    html.String(),
+		Text:    strings.TrimSpace(text.String()) + "\n",
+	}, nil
+}
+
+// Sample returns example data for kind, for the preview.
+func Sample(kind Kind, now time.Time) (any, error) {
+	period := core.Period(now)
+	switch kind {
+	case KindDigest:
+		return Digest{
+			Period:  period,
+			Total:   core.Money{Cents: 184250},
+			Incomes: core.Money{Cents: 265000},
+			Categories: []DigestCategory{
+				{Primary: "Casa", Amount: core.Money{Cents: 95000}},
+				{Primary: "Cibo", Amount: core.Money{Cents: 52300}},
+				{Primary: "Trasporti", Amount: core.Money{Cents: 21950}},
+				{Primary: "Svago", Amount: core.Money{Cents: 15000}},
+			},
+			Insights: []core.Insight{
+				{Kind: core.InsightCategoryUp, Primary: "Cibo", Period: period, Amount: core.Money{Cents: 52300}, Baseline: core.Money{Cents: 36000}},
+			},
+		}, nil
+	case KindBudgetAlert:
+		return core.Insight{
+			Kind:     core.InsightBudgetReached,
+			Primary:  "Cibo",
+			Period:   period,
+			Date:     core.Date{Time: now},
+			Amount:   core.Money{Cents: 41200},
+			Baseline: core.Money{Cents: 40800},
+			Days:     9,
+		}, nil
+	case KindSyncFailed:
+		return events.SyncFailedPayload{
+			ExpenseID: "1042",
+			Operation: "sync",
+			Error:     "append to sheets: googleapi: Error 403: The caller does not have permission",
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownKind, kind)
+}
+
+// euros formats an amount the Italian way, e.g. "€1.234,50"
+func euros(m core.Money) string {
+	cents := m.Cents
+	sign := ""
+	if cents < 0 {
+		sign, cents = "-", -cents
+	}
+	whole := strconv.FormatInt(cents/100, 10)
+	var grouped strings.Builder
+	for i, d := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			grouped.WriteByte('.')
+		}
+		grouped.WriteRune(d)
+	}
+	return fmt.Sprintf("%s€%s,%02d", sign, grouped.String(), cents%100)
+}
+
+var italianMonths = [...]string{"gennaio", "febbraio", "marzo", "aprile", "maggio", "giugno",
+	"luglio", "agosto", "settembre", "ottobre", "novembre", "dicembre"}
+
+// periodLabel turns "2006-01" into "gennaio 2006"
+func periodLabel(period string) string {
+	t, err := time.Parse("2006-01", period)
+	if err != nil {
+		return period
+	}
+	return italianMonths[t.Month()-1] + " " + strconv.Itoa(t.Year())
+}
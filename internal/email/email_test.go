@@ -0,0 +1,77 @@
+package email
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"spese/internal/core"
+	"spese/internal/events"
+)
+
+func TestRender(t *testing.T) {
+	r, err := NewRenderer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2031, 3, 22, 10, 0, 0, 0, time.UTC)
+
+	want := map[Kind][]string{
+		KindDigest:      {"Riepilogo di marzo 2031", "€1.842,50", "Casa", "51%", "+45%"},
+		KindBudgetAlert: {"avviso su Cibo", "già speso quanto in tutto il mese scorso (€408,00), 9 giorni"},
+		KindSyncFailed:  {"#1042", "The caller does not have permission"},
+	}
+	for _, kind := range Kinds {
+		data, err := Sample(kind, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg, err := r.Render(kind, "https://spese.example.com/", data)
+		if err != nil {
+			t.Fatalf("%s: %v", kind, err)
+		}
+		if msg.Subject == "" || strings.Contains(msg.Subject, "\n") {
+			t.Errorf("%s: bad subject %q", kind, msg.Subject)
+		}
+		if strings.Contains(msg.HTML, "<style") || !strings.Contains(msg.HTML, `style="`) {
+			t.Errorf("%s: expected inline styles only", kind)
+		}
+		if !strings.Contains(msg.HTML, "https://spese.example.com/avvisi") {
+			t.Errorf("%s: expected a link to the alert preferences", kind)
+		}
+		all := msg.Subject + msg.HTML + msg.Text
+		for _, s := range want[kind] {
+			if !strings.Contains(all, s) {
+				t.Errorf("%s: missing %q", kind, s)
+			}
+		}
+	}
+
+	// Values are escaped in the HTML, not in the text
+	msg, err := r.Render(KindSyncFailed, "", events.SyncFailedPayload{ExpenseID: "7", Operation: "delete", Error: "<b>boom</b>"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(msg.HTML, "<b>boom") || !strings.Contains(msg.Text, "<b>boom</b>") {
+		t.Errorf("unexpected escaping:\n%s\n%s", msg.HTML, msg.Text)
+	}
+	if !strings.Contains(msg.Text, "La cancellazione") || strings.Contains(msg.HTML, "/sync/status") {
+		t.Errorf("expected a delete notice without links:\n%s", msg.HTML)
+	}
+
+	if _, err := r.Render("weekly", "", nil); !errors.Is(err, ErrUnknownKind) {
+		t.Errorf("expected an unknown kind refused, got %v", err)
+	}
+	if _, err := Sample("weekly", now); !errors.Is(err, ErrUnknownKind) {
+		t.Errorf("expected no sample for an unknown kind, got %v", err)
+	}
+}
+
+func TestEuros(t *testing.T) {
+	for cents, want := range map[int64]string{0: "€0,00", 5: "€0,05", 123456: "€1.234,56", 100000000: "€1.000.000,00", -250: "-€2,50"} {
+		if got := euros(core.Money{Cents: cents}); got != want {
+			t.Errorf("euros(%d) = %q, want %q", cents, got, want)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package email
+
+import (
+	htmltemplate "html/template"
+	"strings"
+)
+
+// styles are the inline styles of the template blocks, matching the ink
+// theme of the web pages. Mail clients ignore stylesheets and most
+// selectors, so every element carries its own.
+var styles = map[string]string{
+	"body":      "margin:0;padding:0;background:#f4f4f4;",
+	"wrapper":   "width:100%;background:#f4f4f4;padding:24px 0;",
+	"container": "width:100%;max-width:560px;margin:0 auto;background:#ffffff;border:1px solid #e0e0e0;",
+	"header":    "padding:20px 24px;background:#000000;color:#ffffff;font:600 18px/1.3 -apple-system,Segoe UI,Helvetica,Arial,sans-serif;",
+	"content":   "padding:24px;color:#111111;font:15px/1.5 -apple-system,Segoe UI,Helvetica,Arial,sans-serif;",
+	"h1":        "margin:0 0 12px;font-size:20px;line-height:1.3;",
+	"p":         "margin:0 0 12px;",
+	"muted":     "color:#666666;font-size:13px;",
+	"stat":      "font-size:28px;font-weight:700;margin:0 0 4px;",
+	"table":     "width:100%;border-collapse:collapse;margin:0 0 16px;",
+	"th":        "text-align:left;padding:6px 0;border-bottom:2px solid #000000;font-size:13px;",
+	"td":        "padding:6px 0;border-bottom:1px solid #e0e0e0;",
+	"amount":    "padding:6px 0;border-bottom:1px solid #e0e0e0;text-align:right;white-space:nowrap;",
+	"alert":     "padding:12px 16px;margin:0 0 16px;border-left:4px solid #b26a00;background:#fff7e6;",
+	"error":     "padding:12px 16px;margin:0 0 16px;border-left:4px solid #b00020;background:#fdecee;font-family:Menlo,Consolas,monospace;font-size:13px;word-break:break-word;",
+	"button":    "display:inline-block;padding:10px 18px;background:#000000;color:#ffffff;text-decoration:none;border-radius:4px;font-weight:600;",
+	"footer":    "padding:16px 24px;color:#999999;font:12px/1.4 -apple-system,Segoe UI,Helvetica,Arial,sans-serif;",
+}
+
+// inlineStyle returns the style attribute of the named blocks, e.g.
+// {{ style "td" "muted" }}. Unknown names are ignored.
+func inlineStyle(names ...string) htmltemplate.HTMLAttr {
+	var css strings.Builder
+	for _, name := range names {
+		css.WriteString(styles[name])
+	}
+	return htmltemplate.HTMLAttr(`style="` + htmltemplate.HTMLEscapeString(css.String()) + `"`)
+}
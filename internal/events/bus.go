@@ -0,0 +1,189 @@
+// Package events provides an in-process publish/subscribe bus for domain
+// events, used to push changes to connected clients.
+package events
+
+import (
+	"sync"
+	"time"
+
+	"spese/internal/core"
+)
+
+// Event types published on the bus.
+const (
+	ExpenseCreated   = "expense.created"
+	ExpenseUpdated   = "expense.updated"
+	ExpenseDeleted   = "expense.deleted"
+	IncomeCreated    = "income.created"
+	IncomeDeleted    = "income.deleted"
+	RecurrentCreated = "recurrent.created"
+	RecurrentUpdated = "recurrent.updated"
+	RecurrentDeleted = "recurrent.deleted"
+	ReturnDue        = "purchase.return_due"
+	InsightDetected  = "insight.detected"
+	SyncFailed       = "sync.failed"
+	ViewMatched      = "view.matched"
+)
+
+// Event is a domain event as delivered to subscribers.
+type Event struct {
+	Type string    `json:"type"`
+	Time time.Time `json:"time"`
+	Data any       `json:"data,omitempty"`
+}
+
+// ExpensePayload describes a created expense.
+type ExpensePayload struct {
+	Date        string   `json:"date"` // YYYY-MM-DD
+	Description string   `json:"description"`
+	AmountCents int64    `json:"amount_cents"`
+	Primary     string   `json:"primary"`
+	Secondary   string   `json:"secondary"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+// IncomePayload describes a created income.
+type IncomePayload struct {
+	Date        string   `json:"date"` // YYYY-MM-DD
+	Description string   `json:"description"`
+	AmountCents int64    `json:"amount_cents"`
+	Category    string   `json:"category"`
+	Subcategory string   `json:"subcategory,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+// ExpenseFrom builds the payload of an expense event.
+func ExpenseFrom(e core.Expense) ExpensePayload {
+	return ExpensePayload{
+		Date:        e.Date.Format("2006-01-02"),
+		Description: e.Description,
+		AmountCents: e.Amount.Cents,
+		Primary:     e.Primary,
+		Secondary:   e.Secondary,
+		Tags:        e.Tags,
+	}
+}
+
+// IncomeFrom builds the payload of an income event.
+func IncomeFrom(i core.Income) IncomePayload {
+	return IncomePayload{
+		Date:        i.Date.Format("2006-01-02"),
+		Description: i.Description,
+		AmountCents: i.Amount.Cents,
+		Category:    i.Category,
+		Subcategory: i.Subcategory,
+		Tags:        i.Tags,
+	}
+}
+
+// ReturnDuePayload reminds that the return window of a purchase is closing.
+type ReturnDuePayload struct {
+	ID             string `json:"id"`
+	Description    string `json:"description"`
+	AmountCents    int64  `json:"amount_cents"`
+	PurchaseDate   string `json:"purchase_date"`   // YYYY-MM-DD
+	ReturnDeadline string `json:"return_deadline"` // YYYY-MM-DD
+	Proof          string `json:"proof,omitempty"`
+}
+
+// InsightPayload describes a spending insight detected in the expenses.
+type InsightPayload struct {
+	ID            int64  `json:"id"`
+	Kind          string `json:"kind"`
+	Primary       string `json:"primary"`
+	Period        string `json:"period"` // YYYY-MM
+	Date          string `json:"date"`   // YYYY-MM-DD
+	AmountCents   int64  `json:"amount_cents"`
+	BaselineCents int64  `json:"baseline_cents,omitempty"`
+}
+
+// InsightFrom builds the payload of an insight event.
+func InsightFrom(in core.Insight) InsightPayload {
+	return InsightPayload{
+		ID:            in.ID,
+		Kind:          string(in.Kind),
+		Primary:       in.Primary,
+		Period:        in.Period,
+		Date:          in.Date.Format("2006-01-02"),
+		AmountCents:   in.Amount.Cents,
+		BaselineCents: in.Baseline.Cents,
+	}
+}
+
+// SyncFailedPayload reports an expense that could not be synced after all
+// retries.
+type SyncFailedPayload struct {
+	ExpenseID string `json:"expense_id"`
+	Operation string `json:"operation"`
+	Error     string `json:"error"`
+}
+
+// ViewMatchedPayload reports a new expense selected by a saved view.
+type ViewMatchedPayload struct {
+	ViewID    int64          `json:"view_id"`
+	View      string         `json:"view"`
+	ExpenseID string         `json:"expense_id"`
+	Expense   ExpensePayload `json:"expense"`
+}
+
+// RefPayload identifies the record an event refers to.
+type RefPayload struct {
+	ID string `json:"id"`
+}
+
+// Bus fans out published events to every subscriber. Publishing never
+// blocks: a subscriber whose buffer is full misses the event.
+type Bus struct {
+	mu     sync.RWMutex
+	nextID int
+	subs   map[int]chan Event
+}
+
+// NewBus creates an empty event bus.
+func NewBus() *Bus {
+	return &Bus{subs: make(map[int]chan Event)}
+}
+
+// Subscribe registers a subscriber with the given buffer size. The returned
+// function unsubscribes and closes the channel; it is safe to call twice.
+func (b *Bus) Subscribe(buffer int) (<-chan Event, func()) {
+	ch := make(chan Event, buffer)
+
+	b.mu.Lock()
+	id := b.nextID
+	b.nextID++
+	b.subs[id] = ch
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs, id)
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// Publish delivers an event of the given type to all current subscribers.
+func (b *Bus) Publish(eventType string, data any) {
+	e := Event{Type: eventType, Time: time.Now().UTC(), Data: data}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, ch := range b.subs {
+		select {
+		case ch <- e:
+		default:
+			// Slow subscriber, drop rather than stall the publisher
+		}
+	}
+}
+
+// Subscribers returns the number of active subscribers.
+func (b *Bus) Subscribers() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subs)
+}
@@ -0,0 +1,49 @@
+package events
+
+import "testing"
+
+func TestBus_PublishSubscribe(t *testing.T) {
+	b := NewBus()
+	a, cancelA := b.Subscribe(1)
+	c, cancelC := b.Subscribe(1)
+	defer cancelC()
+
+	b.Publish(ExpenseCreated, RefPayload{ID: "1"})
+
+	for _, ch := range []<-chan Event{a, c} {
+		e := <-ch
+		if e.Type != ExpenseCreated {
+			t.Fatalf("got type %q, want %q", e.Type, ExpenseCreated)
+		}
+		if p, ok := e.Data.(RefPayload); !ok || p.ID != "1" {
+			t.Fatalf("unexpected payload %#v", e.Data)
+		}
+	}
+
+	cancelA()
+	cancelA() // idempotent
+	if _, ok := <-a; ok {
+		t.Fatalf("expected closed channel after unsubscribe")
+	}
+	if n := b.Subscribers(); n != 1 {
+		t.Fatalf("got %d subscribers, want 1", n)
+	}
+}
+
+func TestBus_DropsForSlowSubscriber(t *testing.T) {
+	b := NewBus()
+	ch, cancel := b.Subscribe(1)
+	defer cancel()
+
+	b.Publish(ExpenseCreated, nil)
+	b.Publish(ExpenseDeleted, nil) // buffer full, dropped
+
+	if e := <-ch; e.Type != ExpenseCreated {
+		t.Fatalf("got %q, want first event", e.Type)
+	}
+	select {
+	case e := <-ch:
+		t.Fatalf("unexpected event %q", e.Type)
+	default:
+	}
+}
@@ -0,0 +1,179 @@
+// Package export writes expense datasets meant to leave the app, such as
+// files for analysis notebooks or a public demo.
+package export
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+
+	"spese/internal/core"
+)
+
+// hashLength is the number of hex characters kept from each digest: enough
+// to avoid collisions within a household's merchants, short enough to read.
+const hashLength = 16
+
+// Record is an anonymized expense: the description is replaced by an opaque
+// merchant token while amounts, dates and categories are kept as they are.
+type Record struct {
+	Date        string `json:"date"`
+	Merchant    string `json:"merchant"`
+	AmountCents int64  `json:"amount_cents"`
+	Primary     string `json:"primary"`
+	Secondary   string `json:"secondary"`
+}
+
+// Anonymizer replaces descriptions with keyed hashes. The same description
+// always maps to the same token under one key, so analyses can still group
+// by merchant, while the key prevents reversing short descriptions by
+// hashing guesses.
+type Anonymizer struct {
+	key []byte
+}
+
+// NewAnonymizer returns an anonymizer using key. An empty key is replaced by
+// a random one, so tokens are stable within a single export only.
+func NewAnonymizer(key string) *Anonymizer {
+	if key != "" {
+		return &Anonymizer{key: []byte(key)}
+	}
+	random := make([]byte, 32)
+	_, _ = rand.Read(random)
+	return &Anonymizer{key: random}
+}
+
+// Hash returns the merchant token for description. Case and repeated
+// whitespace are ignored; an empty description stays empty.
+func (a *Anonymizer) Hash(description string) string {
+	normalized := strings.Join(strings.Fields(strings.ToLower(description)), " ")
+	if normalized == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, a.key)
+	mac.Write([]byte(normalized))
+	return hex.EncodeToString(mac.Sum(nil))[:hashLength]
+}
+
+// Record anonymizes a single expense.
+func (a *Anonymizer) Record(e core.Expense) Record {
+	return Record{
+		Date:        e.Date.Format("2006-01-02"),
+		Merchant:    a.Hash(e.Description),
+		AmountCents: e.Amount.Cents,
+		Primary:     e.Primary,
+		Secondary:   e.Secondary,
+	}
+}
+
+// Records anonymizes expenses, preserving their order.
+func (a *Anonymizer) Records(expenses []core.Expense) []Record {
+	records := make([]Record, 0, len(expenses))
+	for _, e := range expenses {
+		records = append(records, a.Record(e))
+	}
+	return records
+}
+
+// RecordWriter writes records one at a time, so an export can stream
+// straight from the database. Close must be called to complete the output.
+type RecordWriter interface {
+	Write(Record) error
+	Close() error
+}
+
+type csvRecordWriter struct {
+	cw     *csv.Writer
+	header bool
+}
+
+// NewCSVWriter returns a RecordWriter producing CSV with a header row.
+// Amounts are in cents.
+func NewCSVWriter(w io.Writer) RecordWriter {
+	return &csvRecordWriter{cw: csv.NewWriter(w)}
+}
+
+func (c *csvRecordWriter) writeHeader() error {
+	if c.header {
+		return nil
+	}
+	c.header = true
+	return c.cw.Write([]string{"date", "merchant", "amount_cents", "primary", "secondary"})
+}
+
+func (c *csvRecordWriter) Write(r Record) error {
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+	return c.cw.Write([]string{r.Date, r.Merchant, strconv.FormatInt(r.AmountCents, 10), r.Primary, r.Secondary})
+}
+
+func (c *csvRecordWriter) Close() error {
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+	c.cw.Flush()
+	return c.cw.Error()
+}
+
+type jsonRecordWriter struct {
+	w     io.Writer
+	count int
+}
+
+// NewJSONWriter returns a RecordWriter producing a JSON array, one record
+// per line.
+func NewJSONWriter(w io.Writer) RecordWriter {
+	return &jsonRecordWriter{w: w}
+}
+
+func (j *jsonRecordWriter) Write(r Record) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	sep := ",\n"
+	if j.count == 0 {
+		sep = "[\n"
+	}
+	j.count++
+	if _, err := io.WriteString(j.w, sep); err != nil {
+		return err
+	}
+	_, err = j.w.Write(b)
+	return err
+}
+
+func (j *jsonRecordWriter) Close() error {
+	end := "\n]\n"
+	if j.count == 0 {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(j.w, end)
+	return err
+}
+
+// WriteCSV writes records with a header row. Amounts are in cents.
+func WriteCSV(w io.Writer, records []Record) error {
+	return writeRecords(NewCSVWriter(w), records)
+}
+
+// WriteJSON writes records as a JSON array.
+func WriteJSON(w io.Writer, records []Record) error {
+	return writeRecords(NewJSONWriter(w), records)
+}
+
+func writeRecords(rw RecordWriter, records []Record) error {
+	for _, r := range records {
+		if err := rw.Write(r); err != nil {
+			return err
+		}
+	}
+	return rw.Close()
+}
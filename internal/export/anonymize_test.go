@@ -0,0 +1,97 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"spese/internal/core"
+)
+
+func TestAnonymizerHash(t *testing.T) {
+	a := NewAnonymizer("secret")
+
+	h := a.Hash("Esselunga  Milano")
+	if len(h) != hashLength {
+		t.Fatalf("hash length = %d, want %d", len(h), hashLength)
+	}
+	if strings.Contains(strings.ToLower(h), "esselunga") {
+		t.Fatalf("hash leaks the description: %s", h)
+	}
+	if got := a.Hash(" esselunga milano "); got != h {
+		t.Errorf("case and spacing should not matter: %s != %s", got, h)
+	}
+	if a.Hash("Coop") == h {
+		t.Error("different merchants share a token")
+	}
+	if a.Hash("   ") != "" {
+		t.Error("blank description should stay empty")
+	}
+
+	if NewAnonymizer("secret").Hash("Esselunga Milano") != h {
+		t.Error("same key should give the same token")
+	}
+	if NewAnonymizer("other").Hash("Esselunga Milano") == h {
+		t.Error("different keys should give different tokens")
+	}
+	if NewAnonymizer("").Hash("Esselunga Milano") == NewAnonymizer("").Hash("Esselunga Milano") {
+		t.Error("random keys should differ between anonymizers")
+	}
+}
+
+func TestWriteRecords(t *testing.T) {
+	a := NewAnonymizer("secret")
+	records := a.Records([]core.Expense{{
+		Date:        core.Date{Time: time.Date(2025, 3, 12, 0, 0, 0, 0, time.UTC)},
+		Description: "Farmacia Rossi, via Roma",
+		Amount:      core.Money{Cents: 1250},
+		Primary:     "Salute",
+		Secondary:   "Farmaci",
+	}})
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, records); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if strings.Contains(out, "Farmacia") {
+		t.Fatalf("CSV leaks the description:\n%s", out)
+	}
+	want := "date,merchant,amount_cents,primary,secondary\n2025-03-12," + a.Hash("Farmacia Rossi, via Roma") + ",1250,Salute,Farmaci\n"
+	if out != want {
+		t.Errorf("CSV =\n%s\nwant\n%s", out, want)
+	}
+
+	buf.Reset()
+	if err := WriteJSON(&buf, records); err != nil {
+		t.Fatal(err)
+	}
+	var decoded []Record
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded) != 1 || decoded[0] != records[0] {
+		t.Errorf("JSON round trip = %+v, want %+v", decoded, records)
+	}
+
+	buf.Reset()
+	if err := WriteJSON(&buf, append(records, records...)); err != nil {
+		t.Fatal(err)
+	}
+	decoded = nil
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || len(decoded) != 2 {
+		t.Errorf("JSON with two records = %q (%v)", buf.String(), err)
+	}
+
+	// Empty exports are still well formed.
+	buf.Reset()
+	if err := WriteJSON(&buf, nil); err != nil || buf.String() != "[]\n" {
+		t.Errorf("empty JSON = %q (%v), want []", buf.String(), err)
+	}
+	buf.Reset()
+	if err := WriteCSV(&buf, nil); err != nil || buf.String() != "date,merchant,amount_cents,primary,secondary\n" {
+		t.Errorf("empty CSV = %q (%v), want the header only", buf.String(), err)
+	}
+}
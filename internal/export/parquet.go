@@ -0,0 +1,352 @@
+package export
+
+import (
+	"encoding/binary"
+	"io"
+	"strings"
+	"time"
+
+	"spese/internal/core"
+)
+
+// Parquet format constants (see parquet.thrift) for the subset written
+// here: required flat columns, PLAIN encoded, uncompressed.
+const (
+	parquetMagic = "PAR1"
+
+	typeInt32     = 1
+	typeInt64     = 2
+	typeByteArray = 6
+
+	repetitionRequired = 0
+
+	convertedUTF8 = 0
+	convertedDate = 6
+
+	// LogicalType union members
+	logicalString = 1
+	logicalDate   = 6
+
+	encodingPlain = 0
+	encodingRLE   = 3
+
+	pageTypeData      = 0
+	codecUncompressed = 0
+)
+
+// rowGroupSize is the number of rows per row group; each column chunk is a
+// single data page. It also bounds the memory a writer holds, since a row
+// group is buffered until it is complete.
+const rowGroupSize = 50_000
+
+// parquetColumn describes a required column of the schema.
+type parquetColumn struct {
+	name      string
+	typ       int32
+	converted int32 // -1 when none
+	logical   int16 // LogicalType union field id, 0 when none
+}
+
+func dateColumn(name string) parquetColumn {
+	return parquetColumn{name: name, typ: typeInt32, converted: convertedDate, logical: logicalDate}
+}
+
+func int64Column(name string) parquetColumn {
+	return parquetColumn{name: name, typ: typeInt64, converted: -1}
+}
+
+func stringColumn(name string) parquetColumn {
+	return parquetColumn{name: name, typ: typeByteArray, converted: convertedUTF8, logical: logicalString}
+}
+
+// The append functions plain-encode one value onto a column page.
+
+func appendDate(page []byte, d core.Date) []byte {
+	return binary.LittleEndian.AppendUint32(page, uint32(epochDays(d)))
+}
+
+func appendInt64(page []byte, v int64) []byte {
+	return binary.LittleEndian.AppendUint64(page, uint64(v))
+}
+
+func appendString(page []byte, v string) []byte {
+	page = binary.LittleEndian.AppendUint32(page, uint32(len(v)))
+	return append(page, v...)
+}
+
+// epochDays returns the calendar date of d as days since 1970-01-01, the
+// Parquet DATE representation.
+func epochDays(d core.Date) int32 {
+	y, m, day := d.Time.Date()
+	return int32(time.Date(y, m, day, 0, 0, 0, 0, time.UTC).Unix() / 86400)
+}
+
+var expenseColumns = []parquetColumn{
+	dateColumn("date"),
+	stringColumn("description"),
+	int64Column("amount_cents"),
+	stringColumn("primary"),
+	stringColumn("secondary"),
+}
+
+// ExpenseParquetWriter writes expenses to a Parquet file one at a time, with
+// the columns date (DATE), description, amount_cents (INT64), primary and
+// secondary. Close must be called to write the footer.
+type ExpenseParquetWriter struct {
+	pw *parquetWriter
+}
+
+// NewExpenseParquetWriter returns a writer streaming expenses to w.
+func NewExpenseParquetWriter(w io.Writer) *ExpenseParquetWriter {
+	return &ExpenseParquetWriter{pw: newParquetWriter(w, expenseColumns)}
+}
+
+// Write appends e to the file.
+func (x *ExpenseParquetWriter) Write(e core.Expense) error {
+	p := x.pw.pages
+	p[0] = appendDate(p[0], e.Date)
+	p[1] = appendString(p[1], e.Description)
+	p[2] = appendInt64(p[2], e.Amount.Cents)
+	p[3] = appendString(p[3], e.Primary)
+	p[4] = appendString(p[4], e.Secondary)
+	return x.pw.endRow()
+}
+
+// Close writes the pending rows and the footer. It does not close the
+// underlying writer.
+func (x *ExpenseParquetWriter) Close() error {
+	return x.pw.close()
+}
+
+var incomeColumns = []parquetColumn{
+	dateColumn("date"),
+	stringColumn("description"),
+	int64Column("amount_cents"),
+	stringColumn("category"),
+	stringColumn("subcategory"),
+	stringColumn("tags"),
+}
+
+// IncomeParquetWriter writes incomes to a Parquet file one at a time, with
+// the columns date (DATE), description, amount_cents (INT64), category,
+// subcategory and tags (comma-separated). Close must be called to write the
+// footer.
+type IncomeParquetWriter struct {
+	pw *parquetWriter
+}
+
+// NewIncomeParquetWriter returns a writer streaming incomes to w.
+func NewIncomeParquetWriter(w io.Writer) *IncomeParquetWriter {
+	return &IncomeParquetWriter{pw: newParquetWriter(w, incomeColumns)}
+}
+
+// Write appends in to the file.
+func (x *IncomeParquetWriter) Write(in core.Income) error {
+	p := x.pw.pages
+	p[0] = appendDate(p[0], in.Date)
+	p[1] = appendString(p[1], in.Description)
+	p[2] = appendInt64(p[2], in.Amount.Cents)
+	p[3] = appendString(p[3], in.Category)
+	p[4] = appendString(p[4], in.Subcategory)
+	p[5] = appendString(p[5], strings.Join(in.Tags, ","))
+	return x.pw.endRow()
+}
+
+// Close writes the pending rows and the footer. It does not close the
+// underlying writer.
+func (x *IncomeParquetWriter) Close() error {
+	return x.pw.close()
+}
+
+// WriteExpensesParquet writes expenses as a complete Parquet file.
+func WriteExpensesParquet(w io.Writer, expenses []core.Expense) error {
+	x := NewExpenseParquetWriter(w)
+	for _, e := range expenses {
+		if err := x.Write(e); err != nil {
+			return err
+		}
+	}
+	return x.Close()
+}
+
+// WriteIncomesParquet writes incomes as a complete Parquet file.
+func WriteIncomesParquet(w io.Writer, incomes []core.Income) error {
+	x := NewIncomeParquetWriter(w)
+	for _, in := range incomes {
+		if err := x.Write(in); err != nil {
+			return err
+		}
+	}
+	return x.Close()
+}
+
+// columnChunk records where a column chunk was written, for the footer.
+type columnChunk struct {
+	offset int64
+	size   int64
+	values int64
+}
+
+// parquetWriter buffers one row group of plain-encoded pages and writes it
+// out as soon as it is full, remembering the chunk offsets for the footer.
+type parquetWriter struct {
+	w       io.Writer
+	columns []parquetColumn
+	pages   [][]byte
+	pending int // rows in pages
+	rows    int // rows written in total, pending included
+	offset  int64
+	groups  [][]columnChunk
+	err     error
+}
+
+func newParquetWriter(w io.Writer, columns []parquetColumn) *parquetWriter {
+	return &parquetWriter{w: w, columns: columns, pages: make([][]byte, len(columns))}
+}
+
+func (p *parquetWriter) write(b []byte) error {
+	if p.err != nil {
+		return p.err
+	}
+	if p.offset == 0 {
+		n, err := io.WriteString(p.w, parquetMagic)
+		p.offset += int64(n)
+		if err != nil {
+			p.err = err
+			return err
+		}
+	}
+	n, err := p.w.Write(b)
+	p.offset += int64(n)
+	p.err = err
+	return err
+}
+
+// endRow accounts for a row appended to every page, flushing the row group
+// when it is full.
+func (p *parquetWriter) endRow() error {
+	if p.err != nil {
+		return p.err
+	}
+	p.pending++
+	p.rows++
+	if p.pending == rowGroupSize {
+		return p.flush()
+	}
+	return nil
+}
+
+// flush writes the pending rows as a row group.
+func (p *parquetWriter) flush() error {
+	if p.pending == 0 {
+		return p.err
+	}
+	chunks := make([]columnChunk, len(p.columns))
+	for i, page := range p.pages {
+		var h compactWriter
+		h.beginStruct(0) // PageHeader
+		h.i32(1, pageTypeData)
+		h.i32(2, int32(len(page)))
+		h.i32(3, int32(len(page)))
+		h.beginStruct(5) // DataPageHeader
+		h.i32(1, int32(p.pending))
+		h.i32(2, encodingPlain)
+		h.i32(3, encodingRLE)
+		h.i32(4, encodingRLE)
+		h.endStruct()
+		h.endStruct()
+
+		if err := p.write(h.buf); err != nil {
+			return err
+		}
+		// The magic goes out with the first header, so the chunk starts
+		// where that header did.
+		chunks[i] = columnChunk{offset: p.offset - int64(len(h.buf)), size: int64(len(h.buf) + len(page)), values: int64(p.pending)}
+		if err := p.write(page); err != nil {
+			return err
+		}
+		p.pages[i] = page[:0]
+	}
+	p.groups = append(p.groups, chunks)
+	p.pending = 0
+	return nil
+}
+
+// close flushes the last row group and writes the footer.
+func (p *parquetWriter) close() error {
+	if err := p.flush(); err != nil {
+		return err
+	}
+	footer := parquetFooter(p.rows, p.columns, p.groups)
+	if err := p.write(footer); err != nil {
+		return err
+	}
+	if err := p.write(binary.LittleEndian.AppendUint32(nil, uint32(len(footer)))); err != nil {
+		return err
+	}
+	return p.write([]byte(parquetMagic))
+}
+
+// parquetFooter encodes the FileMetaData struct.
+func parquetFooter(rows int, columns []parquetColumn, groups [][]columnChunk) []byte {
+	var c compactWriter
+	c.beginStruct(0) // FileMetaData
+	c.i32(1, 1)      // version
+
+	c.listHeader(2, tStruct, len(columns)+1)
+	c.beginStruct(0) // root SchemaElement
+	c.binary(4, "schema")
+	c.i32(5, int32(len(columns)))
+	c.endStruct()
+	for _, col := range columns {
+		c.beginStruct(0)
+		c.i32(1, col.typ)
+		c.i32(3, repetitionRequired)
+		c.binary(4, col.name)
+		if col.converted >= 0 {
+			c.i32(6, col.converted)
+		}
+		if col.logical != 0 {
+			c.beginStruct(10) // LogicalType
+			c.beginStruct(col.logical)
+			c.endStruct()
+			c.endStruct()
+		}
+		c.endStruct()
+	}
+
+	c.i64(3, int64(rows))
+
+	c.listHeader(4, tStruct, len(groups))
+	for _, chunks := range groups {
+		c.beginStruct(0) // RowGroup
+		var total int64
+		c.listHeader(1, tStruct, len(chunks))
+		for i, chunk := range chunks {
+			total += chunk.size
+			c.beginStruct(0) // ColumnChunk
+			c.i64(2, chunk.offset)
+			c.beginStruct(3) // ColumnMetaData
+			c.i32(1, columns[i].typ)
+			c.listHeader(2, tI32, 1)
+			c.zigzag(encodingPlain)
+			c.listHeader(3, tBinary, 1)
+			c.varint(uint64(len(columns[i].name)))
+			c.buf = append(c.buf, columns[i].name...)
+			c.i32(4, codecUncompressed)
+			c.i64(5, chunk.values)
+			c.i64(6, chunk.size)
+			c.i64(7, chunk.size)
+			c.i64(9, chunk.offset)
+			c.endStruct()
+			c.endStruct()
+		}
+		c.i64(2, total)
+		c.i64(3, chunks[0].values)
+		c.endStruct()
+	}
+
+	c.binary(6, "spese")
+	c.endStruct()
+	return c.buf
+}
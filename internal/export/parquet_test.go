@@ -0,0 +1,188 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"testing"
+	"time"
+
+	"spese/internal/core"
+)
+
+// compactReader decodes Thrift compact structs into maps keyed by field id,
+// enough to check the metadata written by compactWriter.
+type compactReader struct {
+	buf []byte
+	pos int
+}
+
+func (r *compactReader) byte() byte {
+	b := r.buf[r.pos]
+	r.pos++
+	return b
+}
+
+func (r *compactReader) varint() uint64 {
+	v, n := binary.Uvarint(r.buf[r.pos:])
+	r.pos += n
+	return v
+}
+
+func (r *compactReader) zigzag() int64 {
+	v := r.varint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *compactReader) value(typ byte) any {
+	switch typ {
+	case tI32, tI64:
+		return r.zigzag()
+	case tBinary:
+		n := int(r.varint())
+		s := string(r.buf[r.pos : r.pos+n])
+		r.pos += n
+		return s
+	case tList:
+		h := r.byte()
+		n, elem := int(h>>4), h&0x0f
+		if n == 15 {
+			n = int(r.varint())
+		}
+		list := make([]any, n)
+		for i := range list {
+			list[i] = r.value(elem)
+		}
+		return list
+	case tStruct:
+		return r.structure()
+	}
+	panic(fmt.Sprintf("unsupported type %d", typ))
+}
+
+func (r *compactReader) structure() map[int16]any {
+	fields := map[int16]any{}
+	var last int16
+	for {
+		h := r.byte()
+		if h == 0 {
+			return fields
+		}
+		typ := h & 0x0f
+		id := last + int16(h>>4)
+		if h>>4 == 0 {
+			id = int16(r.zigzag())
+		}
+		fields[id] = r.value(typ)
+		last = id
+	}
+}
+
+// readParquet checks the file framing and returns the footer and the
+// decoded data page of every column chunk, in order.
+func readParquet(t *testing.T, data []byte) (map[int16]any, [][]byte) {
+	t.Helper()
+	if string(data[:4]) != parquetMagic || string(data[len(data)-4:]) != parquetMagic {
+		t.Fatalf("missing PAR1 magic")
+	}
+	size := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	footerStart := len(data) - 8 - size
+	r := &compactReader{buf: data[:len(data)-8], pos: footerStart}
+	meta := r.structure()
+	if r.pos != len(data)-8 {
+		t.Fatalf("footer decoded %d bytes, length says %d", r.pos-footerStart, size)
+	}
+
+	var pages [][]byte
+	for _, g := range meta[4].([]any) {
+		for _, c := range g.(map[int16]any)[1].([]any) {
+			cm := c.(map[int16]any)[3].(map[int16]any)
+			off := int(cm[9].(int64))
+			pr := &compactReader{buf: data, pos: off}
+			header := pr.structure()
+			n := int(header[3].(int64))
+			if got := int64(pr.pos - off + n); got != cm[7].(int64) {
+				t.Fatalf("chunk size = %d, metadata says %d", got, cm[7])
+			}
+			pages = append(pages, data[pr.pos:pr.pos+n])
+		}
+	}
+	return meta, pages
+}
+
+func TestWriteExpensesParquet(t *testing.T) {
+	expenses := []core.Expense{
+		{Date: core.Date{Time: time.Date(1970, 1, 2, 0, 0, 0, 0, time.UTC)}, Description: "Pane", Amount: core.Money{Cents: 250}, Primary: "Casa", Secondary: "Spesa"},
+		{Date: core.Date{Time: time.Date(2025, 3, 12, 18, 30, 0, 0, time.Local)}, Description: "Caffè", Amount: core.Money{Cents: -120}, Primary: "Svago", Secondary: "Bar"},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteExpensesParquet(&buf, expenses); err != nil {
+		t.Fatal(err)
+	}
+	meta, pages := readParquet(t, buf.Bytes())
+
+	if meta[3].(int64) != 2 {
+		t.Errorf("num_rows = %v, want 2", meta[3])
+	}
+	schema := meta[2].([]any)
+	if len(schema) != 6 || schema[0].(map[int16]any)[5].(int64) != 5 {
+		t.Fatalf("unexpected schema: %v", schema)
+	}
+	date := schema[1].(map[int16]any)
+	if date[4] != "date" || date[1].(int64) != typeInt32 || date[6].(int64) != convertedDate {
+		t.Errorf("date column = %v", date)
+	}
+	if _, ok := date[10].(map[int16]any)[logicalDate]; !ok {
+		t.Errorf("date column lacks the DATE logical type: %v", date[10])
+	}
+
+	if len(pages) != 5 {
+		t.Fatalf("got %d pages, want 5", len(pages))
+	}
+	if got := []int32{int32(binary.LittleEndian.Uint32(pages[0])), int32(binary.LittleEndian.Uint32(pages[0][4:]))}; got[0] != 1 || got[1] != 20159 {
+		t.Errorf("dates = %v, want [1 20159]", got)
+	}
+	wantDesc := []byte{4, 0, 0, 0, 'P', 'a', 'n', 'e', 6, 0, 0, 0}
+	wantDesc = append(wantDesc, "Caffè"...)
+	if !bytes.Equal(pages[1], wantDesc) {
+		t.Errorf("description page = %v, want %v", pages[1], wantDesc)
+	}
+	if a, b := int64(binary.LittleEndian.Uint64(pages[2])), int64(binary.LittleEndian.Uint64(pages[2][8:])); a != 250 || b != -120 {
+		t.Errorf("amounts = %d, %d", a, b)
+	}
+}
+
+func TestWriteParquetRowGroups(t *testing.T) {
+	incomes := make([]core.Income, rowGroupSize+3)
+	for i := range incomes {
+		incomes[i] = core.Income{Date: core.Date{Time: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}, Amount: core.Money{Cents: int64(i)}, Category: "Stipendio"}
+	}
+
+	var buf bytes.Buffer
+	if err := WriteIncomesParquet(&buf, incomes); err != nil {
+		t.Fatal(err)
+	}
+	meta, pages := readParquet(t, buf.Bytes())
+
+	groups := meta[4].([]any)
+	if len(groups) != 2 {
+		t.Fatalf("got %d row groups, want 2", len(groups))
+	}
+	if n := groups[1].(map[int16]any)[3].(int64); n != 3 {
+		t.Errorf("last row group has %d rows, want 3", n)
+	}
+	// amount_cents of the first row of the second group (six columns per group)
+	if v := int64(binary.LittleEndian.Uint64(pages[8])); v != rowGroupSize {
+		t.Errorf("second group starts at %d, want %d", v, rowGroupSize)
+	}
+
+	buf.Reset()
+	if err := WriteIncomesParquet(&buf, nil); err != nil {
+		t.Fatal(err)
+	}
+	meta, _ = readParquet(t, buf.Bytes())
+	if meta[3].(int64) != 0 || len(meta[4].([]any)) != 0 {
+		t.Errorf("empty file metadata = %v", meta)
+	}
+}
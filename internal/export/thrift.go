@@ -0,0 +1,83 @@
+package export
+
+import "encoding/binary"
+
+// Thrift compact protocol type ids, as used by the Parquet footer and page
+// headers.
+const (
+	tBinary = 8
+	tI32    = 5
+	tI64    = 6
+	tList   = 9
+	tStruct = 12
+)
+
+// compactWriter encodes the subset of the Thrift compact protocol needed to
+// write Parquet metadata: integer, binary, list and struct fields.
+type compactWriter struct {
+	buf    []byte
+	last   int16   // last field id written in the current struct
+	parent []int16 // last field ids of enclosing structs
+}
+
+func (c *compactWriter) varint(v uint64) {
+	c.buf = binary.AppendUvarint(c.buf, v)
+}
+
+func (c *compactWriter) zigzag(v int64) {
+	c.varint(uint64((v << 1) ^ (v >> 63)))
+}
+
+func (c *compactWriter) fieldHeader(id int16, typ byte) {
+	if delta := id - c.last; delta > 0 && delta <= 15 {
+		c.buf = append(c.buf, byte(delta)<<4|typ)
+	} else {
+		c.buf = append(c.buf, typ)
+		c.zigzag(int64(id))
+	}
+	c.last = id
+}
+
+func (c *compactWriter) i32(id int16, v int32) {
+	c.fieldHeader(id, tI32)
+	c.zigzag(int64(v))
+}
+
+func (c *compactWriter) i64(id int16, v int64) {
+	c.fieldHeader(id, tI64)
+	c.zigzag(v)
+}
+
+func (c *compactWriter) binary(id int16, v string) {
+	c.fieldHeader(id, tBinary)
+	c.varint(uint64(len(v)))
+	c.buf = append(c.buf, v...)
+}
+
+// listHeader starts a list field of n elements of type elem; the elements
+// follow without field headers.
+func (c *compactWriter) listHeader(id int16, elem byte, n int) {
+	c.fieldHeader(id, tList)
+	if n < 15 {
+		c.buf = append(c.buf, byte(n)<<4|elem)
+	} else {
+		c.buf = append(c.buf, 0xf0|elem)
+		c.varint(uint64(n))
+	}
+}
+
+// beginStruct opens a struct, either as field id or, with id 0, as a list
+// element.
+func (c *compactWriter) beginStruct(id int16) {
+	if id != 0 {
+		c.fieldHeader(id, tStruct)
+	}
+	c.parent = append(c.parent, c.last)
+	c.last = 0
+}
+
+func (c *compactWriter) endStruct() {
+	c.buf = append(c.buf, 0) // stop field
+	c.last = c.parent[len(c.parent)-1]
+	c.parent = c.parent[:len(c.parent)-1]
+}
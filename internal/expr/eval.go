@@ -0,0 +1,296 @@
+package expr
+
+import (
+	"math"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+type node interface {
+	eval(env Env) (any, error)
+}
+
+type literal struct{ v any }
+
+func (n literal) eval(Env) (any, error) { return n.v, nil }
+
+type ident struct{ name string }
+
+func (n ident) eval(env Env) (any, error) {
+	v, ok := env[n.name]
+	if !ok {
+		return nil, errorf(-1, "variable %q not set", n.name)
+	}
+	switch x := v.(type) {
+	case string, float64, bool:
+		return x, nil
+	case int:
+		return float64(x), nil
+	case int32:
+		return float64(x), nil
+	case int64:
+		return float64(x), nil
+	case float32:
+		return float64(x), nil
+	default:
+		return nil, errorf(-1, "variable %q has unsupported type %T", n.name, v)
+	}
+}
+
+type logical struct {
+	and  bool
+	l, r node
+}
+
+func (n *logical) eval(env Env) (any, error) {
+	l, err := n.l.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	// Short-circuit
+	if lb, ok := l.(bool); !ok {
+		return nil, errorf(-1, "expected bool, got %T", l)
+	} else if lb != n.and {
+		return lb, nil
+	}
+	r, err := n.r.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := r.(bool); !ok {
+		return nil, errorf(-1, "expected bool, got %T", r)
+	}
+	return r, nil
+}
+
+type not struct{ x node }
+
+func (n *not) eval(env Env) (any, error) {
+	v, err := n.x.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return nil, errorf(-1, "expected bool, got %T", v)
+	}
+	return !b, nil
+}
+
+type arith struct {
+	op   string
+	l, r node
+}
+
+func (n *arith) eval(env Env) (any, error) {
+	l, err := n.l.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	r, err := n.r.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	if ls, ok := l.(string); ok {
+		rs, ok := r.(string)
+		if !ok || n.op != "+" {
+			return nil, errorf(-1, "cannot apply '%s' to %T and %T", n.op, l, r)
+		}
+		if len(ls)+len(rs) > MaxLength {
+			return nil, errorf(-1, "string result too long")
+		}
+		return ls + rs, nil
+	}
+
+	lf, lok := l.(float64)
+	rf, rok := r.(float64)
+	if !lok || !rok {
+		return nil, errorf(-1, "cannot apply '%s' to %T and %T", n.op, l, r)
+	}
+	switch n.op {
+	case "+":
+		return lf + rf, nil
+	case "-":
+		return lf - rf, nil
+	case "*":
+		return lf * rf, nil
+	case "/":
+		if rf == 0 {
+			return nil, errorf(-1, "division by zero")
+		}
+		return lf / rf, nil
+	case "%":
+		if rf == 0 {
+			return nil, errorf(-1, "division by zero")
+		}
+		return math.Mod(lf, rf), nil
+	}
+	return nil, errorf(-1, "unknown operator %q", n.op)
+}
+
+type compare struct {
+	op   string
+	l, r node
+}
+
+func (n *compare) eval(env Env) (any, error) {
+	l, err := n.l.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	if n.op == "in" {
+		if lst, ok := n.r.(*listLit); ok {
+			for _, it := range lst.items {
+				v, err := it.eval(env)
+				if err != nil {
+					return nil, err
+				}
+				if v == l {
+					return true, nil
+				}
+			}
+			return false, nil
+		}
+	}
+
+	r, err := n.r.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return l == r, nil
+	case "!=":
+		return l != r, nil
+	}
+
+	if ls, ok := l.(string); ok {
+		rs, ok := r.(string)
+		if !ok {
+			return nil, errorf(-1, "cannot compare %T with %T", l, r)
+		}
+		switch n.op {
+		case "in":
+			return strings.Contains(rs, ls), nil
+		case "contains":
+			return strings.Contains(ls, rs), nil
+		case "startsWith":
+			return strings.HasPrefix(ls, rs), nil
+		case "endsWith":
+			return strings.HasSuffix(ls, rs), nil
+		case "<":
+			return ls < rs, nil
+		case "<=":
+			return ls <= rs, nil
+		case ">":
+			return ls > rs, nil
+		case ">=":
+			return ls >= rs, nil
+		}
+	}
+
+	lf, lok := l.(float64)
+	rf, rok := r.(float64)
+	if !lok || !rok {
+		return nil, errorf(-1, "cannot compare %T with %T", l, r)
+	}
+	switch n.op {
+	case "<":
+		return lf < rf, nil
+	case "<=":
+		return lf <= rf, nil
+	case ">":
+		return lf > rf, nil
+	case ">=":
+		return lf >= rf, nil
+	}
+	return nil, errorf(-1, "unknown operator %q", n.op)
+}
+
+type match struct {
+	x  node
+	re *regexp.Regexp
+}
+
+func (n *match) eval(env Env) (any, error) {
+	v, err := n.x.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	s, ok := v.(string)
+	if !ok {
+		return nil, errorf(-1, "'matches' needs a string, got %T", v)
+	}
+	return n.re.MatchString(s), nil
+}
+
+// listLit only appears on the right of 'in'
+type listLit struct {
+	items []node
+	types []Type
+}
+
+func (n *listLit) eval(Env) (any, error) {
+	return nil, errorf(-1, "a list can only be used on the right of 'in'")
+}
+
+type function struct {
+	params []Type
+	result Type
+	impl   func(args []any) (any, error)
+}
+
+var functions = map[string]*function{
+	"lower": {params: []Type{String}, result: String, impl: func(a []any) (any, error) {
+		return strings.ToLower(a[0].(string)), nil
+	}},
+	"upper": {params: []Type{String}, result: String, impl: func(a []any) (any, error) {
+		return strings.ToUpper(a[0].(string)), nil
+	}},
+	"trim": {params: []Type{String}, result: String, impl: func(a []any) (any, error) {
+		return strings.TrimSpace(a[0].(string)), nil
+	}},
+	"len": {params: []Type{String}, result: Number, impl: func(a []any) (any, error) {
+		return float64(utf8.RuneCountInString(a[0].(string))), nil
+	}},
+	"abs": {params: []Type{Number}, result: Number, impl: func(a []any) (any, error) {
+		return math.Abs(a[0].(float64)), nil
+	}},
+}
+
+type call struct {
+	name string
+	fn   *function
+	args []node
+}
+
+func (n *call) eval(env Env) (any, error) {
+	args := make([]any, len(n.args))
+	for i, a := range n.args {
+		v, err := a.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		if !hasType(v, n.fn.params[i]) {
+			return nil, errorf(-1, "%s() argument %d must be %s, got %T", n.name, i+1, n.fn.params[i], v)
+		}
+		args[i] = v
+	}
+	return n.fn.impl(args)
+}
+
+func hasType(v any, t Type) bool {
+	switch v.(type) {
+	case string:
+		return t == String
+	case float64:
+		return t == Number
+	case bool:
+		return t == Bool
+	}
+	return false
+}
@@ -0,0 +1,521 @@
+// Package expr implements a small, side-effect free expression language used
+// for user-defined conditions such as categorization rules.
+//
+// Expressions are type-checked at compile time against the declared
+// variables, so a stored expression can only fail at evaluation on division
+// by zero or a missing variable. There are no loops, assignments or I/O.
+//
+//	description contains "netflix" and amount > 10
+//	lower(primary) in ["casa", "bollette"] or description matches "^AMZN"
+//
+// Operators, from lowest to highest precedence:
+//
+//	or ||
+//	and &&
+//	not !
+//	== != < <= > >= in contains startsWith endsWith matches
+//	+ -    (+ also concatenates strings)
+//	* / %
+//	unary -
+//
+// Functions: lower(s), upper(s), trim(s), len(s), abs(n).
+package expr
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Limits that keep evaluation cheap regardless of user input
+const (
+	MaxLength = 2000
+	maxDepth  = 64
+)
+
+// Type is the static type of an expression or variable.
+type Type int
+
+const (
+	String Type = iota + 1
+	Number
+	Bool
+	list
+)
+
+func (t Type) String() string {
+	switch t {
+	case String:
+		return "string"
+	case Number:
+		return "number"
+	case Bool:
+		return "bool"
+	case list:
+		return "list"
+	default:
+		return "unknown"
+	}
+}
+
+// Vars declares the variables an expression may reference.
+type Vars map[string]Type
+
+// Env holds variable values for evaluation. Numbers may be any Go integer
+// or float type; they are evaluated as float64.
+type Env map[string]any
+
+// Error is a compile or evaluation error, with Pos the byte offset in the
+// source (-1 when not applicable).
+type Error struct {
+	Pos int
+	Msg string
+}
+
+func (e *Error) Error() string {
+	if e.Pos < 0 {
+		return e.Msg
+	}
+	return fmt.Sprintf("%s (at %d)", e.Msg, e.Pos)
+}
+
+func errorf(pos int, format string, args ...any) error {
+	return &Error{Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// Program is a compiled expression, safe for concurrent use.
+type Program struct {
+	src  string
+	root node
+	typ  Type
+}
+
+// Compile parses and type-checks src against vars.
+func Compile(src string, vars Vars) (*Program, error) {
+	if len(src) > MaxLength {
+		return nil, errorf(-1, "expression longer than %d characters", MaxLength)
+	}
+	if strings.TrimSpace(src) == "" {
+		return nil, errorf(-1, "empty expression")
+	}
+
+	toks, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{toks: toks, vars: vars}
+	root, typ, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, errorf(t.pos, "unexpected %q", t.text)
+	}
+	if typ == list {
+		return nil, errorf(-1, "a list can only be used on the right of 'in'")
+	}
+
+	return &Program{src: src, root: root, typ: typ}, nil
+}
+
+// CompileCondition compiles an expression that must evaluate to a bool.
+func CompileCondition(src string, vars Vars) (*Program, error) {
+	p, err := Compile(src, vars)
+	if err != nil {
+		return nil, err
+	}
+	if p.typ != Bool {
+		return nil, errorf(-1, "condition must be true or false, got %s", p.typ)
+	}
+	return p, nil
+}
+
+// Type returns the result type of the program.
+func (p *Program) Type() Type { return p.typ }
+
+// String returns the source of the program.
+func (p *Program) String() string { return p.src }
+
+// Eval evaluates the program. The result is a string, float64 or bool.
+func (p *Program) Eval(env Env) (any, error) {
+	return p.root.eval(env)
+}
+
+// EvalBool evaluates a program of type Bool.
+func (p *Program) EvalBool(env Env) (bool, error) {
+	if p.typ != Bool {
+		return false, errorf(-1, "expression is %s, not bool", p.typ)
+	}
+	v, err := p.root.eval(env)
+	if err != nil {
+		return false, err
+	}
+	return v.(bool), nil
+}
+
+// parser is a recursive-descent parser that type-checks as it goes
+type parser struct {
+	toks  []token
+	pos   int
+	depth int
+	vars  Vars
+}
+
+func (p *parser) peek() token { return p.toks[p.pos] }
+
+func (p *parser) next() token {
+	t := p.toks[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// accept consumes the next token if it is one of the given operators/keywords
+func (p *parser) accept(texts ...string) (token, bool) {
+	t := p.peek()
+	if t.kind != tokOp && t.kind != tokKeyword {
+		return t, false
+	}
+	for _, s := range texts {
+		if t.text == s {
+			p.pos++
+			return t, true
+		}
+	}
+	return t, false
+}
+
+func (p *parser) expect(text string) error {
+	if _, ok := p.accept(text); !ok {
+		t := p.peek()
+		if t.kind == tokEOF {
+			return errorf(t.pos, "expected %q, found end of expression", text)
+		}
+		return errorf(t.pos, "expected %q, found %q", text, t.text)
+	}
+	return nil
+}
+
+func (p *parser) enter() error {
+	p.depth++
+	if p.depth > maxDepth {
+		return errorf(p.peek().pos, "expression nested too deeply")
+	}
+	return nil
+}
+
+func (p *parser) leave() { p.depth-- }
+
+func (p *parser) parseExpr() (node, Type, error) {
+	if err := p.enter(); err != nil {
+		return nil, 0, err
+	}
+	defer p.leave()
+	return p.parseOr()
+}
+
+func (p *parser) parseOr() (node, Type, error) {
+	left, lt, err := p.parseAnd()
+	if err != nil {
+		return nil, 0, err
+	}
+	for {
+		op, ok := p.accept("or", "||")
+		if !ok {
+			return left, lt, nil
+		}
+		right, rt, err := p.parseAnd()
+		if err != nil {
+			return nil, 0, err
+		}
+		if lt != Bool || rt != Bool {
+			return nil, 0, errorf(op.pos, "'%s' needs bool operands, got %s and %s", op.text, lt, rt)
+		}
+		left, lt = &logical{and: false, l: left, r: right}, Bool
+	}
+}
+
+func (p *parser) parseAnd() (node, Type, error) {
+	left, lt, err := p.parseNot()
+	if err != nil {
+		return nil, 0, err
+	}
+	for {
+		op, ok := p.accept("and", "&&")
+		if !ok {
+			return left, lt, nil
+		}
+		right, rt, err := p.parseNot()
+		if err != nil {
+			return nil, 0, err
+		}
+		if lt != Bool || rt != Bool {
+			return nil, 0, errorf(op.pos, "'%s' needs bool operands, got %s and %s", op.text, lt, rt)
+		}
+		left, lt = &logical{and: true, l: left, r: right}, Bool
+	}
+}
+
+func (p *parser) parseNot() (node, Type, error) {
+	op, ok := p.accept("not", "!")
+	if !ok {
+		return p.parseComparison()
+	}
+	if err := p.enter(); err != nil {
+		return nil, 0, err
+	}
+	defer p.leave()
+
+	x, t, err := p.parseNot()
+	if err != nil {
+		return nil, 0, err
+	}
+	if t != Bool {
+		return nil, 0, errorf(op.pos, "'%s' needs a bool operand, got %s", op.text, t)
+	}
+	return &not{x: x}, Bool, nil
+}
+
+func (p *parser) parseComparison() (node, Type, error) {
+	left, lt, err := p.parseAdditive()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	op, ok := p.accept("==", "!=", "<", "<=", ">", ">=", "in", "contains", "startsWith", "endsWith", "matches")
+	if !ok {
+		return left, lt, nil
+	}
+
+	if op.text == "matches" {
+		pat := p.next()
+		if pat.kind != tokString {
+			return nil, 0, errorf(pat.pos, "'matches' needs a string literal pattern")
+		}
+		if lt != String {
+			return nil, 0, errorf(op.pos, "'matches' needs a string on the left, got %s", lt)
+		}
+		re, err := regexp.Compile(pat.text)
+		if err != nil {
+			return nil, 0, errorf(pat.pos, "invalid pattern: %v", err)
+		}
+		return &match{x: left, re: re}, Bool, nil
+	}
+
+	right, rt, err := p.parseAdditive()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	switch op.text {
+	case "==", "!=":
+		if lt != rt || lt == list {
+			return nil, 0, errorf(op.pos, "cannot compare %s with %s", lt, rt)
+		}
+	case "<", "<=", ">", ">=":
+		if lt != rt || (lt != Number && lt != String) {
+			return nil, 0, errorf(op.pos, "cannot order %s and %s", lt, rt)
+		}
+	case "in":
+		if rt != list && rt != String {
+			return nil, 0, errorf(op.pos, "'in' needs a list or string on the right, got %s", rt)
+		}
+		if rt == String && lt != String {
+			return nil, 0, errorf(op.pos, "'in' a string needs a string on the left, got %s", lt)
+		}
+		if l, ok := right.(*listLit); ok {
+			for _, it := range l.types {
+				if it != lt {
+					return nil, 0, errorf(op.pos, "list of %s cannot contain %s", it, lt)
+				}
+			}
+		}
+	default: // contains, startsWith, endsWith
+		if lt != String || rt != String {
+			return nil, 0, errorf(op.pos, "'%s' needs strings, got %s and %s", op.text, lt, rt)
+		}
+	}
+
+	if t, ok := p.accept("==", "!=", "<", "<=", ">", ">=", "in", "contains", "startsWith", "endsWith", "matches"); ok {
+		return nil, 0, errorf(t.pos, "comparisons cannot be chained, use 'and'")
+	}
+
+	return &compare{op: op.text, l: left, r: right}, Bool, nil
+}
+
+func (p *parser) parseAdditive() (node, Type, error) {
+	left, lt, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, 0, err
+	}
+	for {
+		op, ok := p.accept("+", "-")
+		if !ok {
+			return left, lt, nil
+		}
+		right, rt, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, 0, err
+		}
+		switch {
+		case lt == Number && rt == Number:
+		case op.text == "+" && lt == String && rt == String:
+		default:
+			return nil, 0, errorf(op.pos, "cannot apply '%s' to %s and %s", op.text, lt, rt)
+		}
+		left = &arith{op: op.text, l: left, r: right}
+	}
+}
+
+func (p *parser) parseMultiplicative() (node, Type, error) {
+	left, lt, err := p.parseUnary()
+	if err != nil {
+		return nil, 0, err
+	}
+	for {
+		op, ok := p.accept("*", "/", "%")
+		if !ok {
+			return left, lt, nil
+		}
+		right, rt, err := p.parseUnary()
+		if err != nil {
+			return nil, 0, err
+		}
+		if lt != Number || rt != Number {
+			return nil, 0, errorf(op.pos, "cannot apply '%s' to %s and %s", op.text, lt, rt)
+		}
+		left = &arith{op: op.text, l: left, r: right}
+	}
+}
+
+func (p *parser) parseUnary() (node, Type, error) {
+	op, ok := p.accept("-")
+	if !ok {
+		return p.parsePrimary()
+	}
+	if err := p.enter(); err != nil {
+		return nil, 0, err
+	}
+	defer p.leave()
+
+	x, t, err := p.parseUnary()
+	if err != nil {
+		return nil, 0, err
+	}
+	if t != Number {
+		return nil, 0, errorf(op.pos, "cannot negate %s", t)
+	}
+	return &arith{op: "-", l: literal{v: 0.0}, r: x}, Number, nil
+}
+
+func (p *parser) parsePrimary() (node, Type, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNumber:
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, 0, errorf(t.pos, "invalid number %q", t.text)
+		}
+		return literal{v: f}, Number, nil
+
+	case tokString:
+		return literal{v: t.text}, String, nil
+
+	case tokKeyword:
+		switch t.text {
+		case "true":
+			return literal{v: true}, Bool, nil
+		case "false":
+			return literal{v: false}, Bool, nil
+		}
+		return nil, 0, errorf(t.pos, "unexpected %q", t.text)
+
+	case tokIdent:
+		if _, ok := p.accept("("); ok {
+			return p.parseCall(t)
+		}
+		typ, ok := p.vars[t.text]
+		if !ok {
+			return nil, 0, errorf(t.pos, "unknown variable %q", t.text)
+		}
+		return ident{name: t.text}, typ, nil
+
+	case tokOp:
+		switch t.text {
+		case "(":
+			x, typ, err := p.parseExpr()
+			if err != nil {
+				return nil, 0, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, 0, err
+			}
+			return x, typ, nil
+		case "[":
+			return p.parseList(t)
+		}
+		return nil, 0, errorf(t.pos, "unexpected %q", t.text)
+
+	default:
+		return nil, 0, errorf(t.pos, "unexpected end of expression")
+	}
+}
+
+func (p *parser) parseList(open token) (node, Type, error) {
+	l := &listLit{}
+	if _, ok := p.accept("]"); ok {
+		return l, list, nil
+	}
+	for {
+		item, typ, err := p.parseAdditive()
+		if err != nil {
+			return nil, 0, err
+		}
+		if typ != String && typ != Number {
+			return nil, 0, errorf(open.pos, "lists can only hold strings or numbers")
+		}
+		l.items = append(l.items, item)
+		l.types = append(l.types, typ)
+		if _, ok := p.accept("]"); ok {
+			return l, list, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, 0, err
+		}
+	}
+}
+
+func (p *parser) parseCall(name token) (node, Type, error) {
+	fn, ok := functions[name.text]
+	if !ok {
+		return nil, 0, errorf(name.pos, "unknown function %q", name.text)
+	}
+
+	var args []node
+	if _, ok := p.accept(")"); !ok {
+		for {
+			arg, typ, err := p.parseExpr()
+			if err != nil {
+				return nil, 0, err
+			}
+			if len(args) < len(fn.params) && typ != fn.params[len(args)] {
+				return nil, 0, errorf(name.pos, "%s() argument %d must be %s, got %s", name.text, len(args)+1, fn.params[len(args)], typ)
+			}
+			args = append(args, arg)
+			if _, ok := p.accept(")"); ok {
+				break
+			}
+			if err := p.expect(","); err != nil {
+				return nil, 0, err
+			}
+		}
+	}
+	if len(args) != len(fn.params) {
+		return nil, 0, errorf(name.pos, "%s() takes %d argument(s), got %d", name.text, len(fn.params), len(args))
+	}
+
+	return &call{name: name.text, fn: fn, args: args}, fn.result, nil
+}
@@ -0,0 +1,174 @@
+package expr
+
+import (
+	"strings"
+	"testing"
+)
+
+var testVars = Vars{
+	"description": String,
+	"amount":      Number,
+	"primary":     String,
+	"recurring":   Bool,
+}
+
+var testEnv = Env{
+	"description": "NETFLIX.COM abbonamento",
+	"amount":      12.99,
+	"primary":     "Svago",
+	"recurring":   true,
+}
+
+func TestEval(t *testing.T) {
+	tests := []struct {
+		src  string
+		want any
+	}{
+		{`amount > 10`, true},
+		{`amount >= 12.99 and amount <= 13`, true},
+		{`amount * 2 - 1`, 24.98},
+		{`-amount + 20`, 7.01},
+		{`7 % 4`, 3.0},
+		{`description contains "NETFLIX"`, true},
+		{`lower(description) startsWith "netflix"`, true},
+		{`description endsWith "mento"`, true},
+		{`description matches "^NETFLIX\\.(COM|IT)"`, true},
+		{`primary in ["Casa", "Svago"]`, true},
+		{`primary in []`, false},
+		{`"Sva" in primary`, true},
+		{`amount in [1, 2, 3]`, false},
+		{`primary == "Svago" && !recurring`, false},
+		{`not (primary == "Casa") or false`, true},
+		{`primary != 'Casa'`, true},
+		{`upper(primary) + "!"`, "SVAGO!"},
+		{`len(trim("  ab  "))`, 2.0},
+		{`abs(-3)`, 3.0},
+		{`"b" > "a"`, true},
+		{`1 + 2 * 3 == 7`, true},
+		{`(1 + 2) * 3`, 9.0},
+		{`recurring`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			p, err := Compile(tt.src, testVars)
+			if err != nil {
+				t.Fatalf("Compile: %v", err)
+			}
+			got, err := p.Eval(testEnv)
+			if err != nil {
+				t.Fatalf("Eval: %v", err)
+			}
+			if f, ok := got.(float64); ok {
+				if w, ok := tt.want.(float64); ok && abs(f-w) < 1e-9 {
+					return
+				}
+			}
+			if got != tt.want {
+				t.Errorf("got %v (%T), want %v (%T)", got, got, tt.want, tt.want)
+			}
+		})
+	}
+}
+
+func abs(f float64) float64 {
+	if f < 0 {
+		return -f
+	}
+	return f
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{``, "empty expression"},
+		{`amount >`, "unexpected end"},
+		{`amount > "10"`, "cannot order number and string"},
+		{`description contains 3`, "needs strings"},
+		{`total > 10`, `unknown variable "total"`},
+		{`exec("rm")`, `unknown function "exec"`},
+		{`lower(amount)`, "argument 1 must be string"},
+		{`lower()`, "takes 1 argument"},
+		{`amount and true`, "needs bool operands"},
+		{`not amount`, "needs a bool operand"},
+		{`1 < 2 < 3`, "cannot be chained"},
+		{`description matches primary`, "string literal pattern"},
+		{`description matches "("`, "invalid pattern"},
+		{`primary in [1, 2]`, "list of number cannot contain string"},
+		{`"unterminated`, "unterminated string"},
+		{`amount # 2`, "unexpected character"},
+		{`(amount > 1`, `expected ")"`},
+		{`[1, 2]`, "right of 'in'"},
+		{`"a" - "b"`, "cannot apply '-'"},
+		{strings.Repeat("(", 100) + "1" + strings.Repeat(")", 100), "nested too deeply"},
+		{strings.Repeat("a", MaxLength+1), "longer than"},
+	}
+
+	for _, tt := range tests {
+		name := tt.src
+		if len(name) > 40 {
+			name = name[:40]
+		}
+		t.Run(name, func(t *testing.T) {
+			_, err := Compile(tt.src, testVars)
+			if err == nil {
+				t.Fatalf("Compile(%q) succeeded, want error containing %q", tt.src, tt.want)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %q, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileCondition(t *testing.T) {
+	if _, err := CompileCondition(`amount + 1`, testVars); err == nil || !strings.Contains(err.Error(), "must be true or false") {
+		t.Errorf("non-bool condition: err = %v", err)
+	}
+
+	p, err := CompileCondition(`amount > 100 or description contains "NETFLIX"`, testVars)
+	if err != nil {
+		t.Fatalf("CompileCondition: %v", err)
+	}
+	ok, err := p.EvalBool(testEnv)
+	if err != nil || !ok {
+		t.Errorf("EvalBool = %v, %v; want true", ok, err)
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	p, err := Compile(`amount / (amount - amount)`, testVars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Eval(testEnv); err == nil || !strings.Contains(err.Error(), "division by zero") {
+		t.Errorf("division by zero: err = %v", err)
+	}
+
+	p, err = Compile(`amount > 1`, testVars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Eval(Env{}); err == nil || !strings.Contains(err.Error(), "not set") {
+		t.Errorf("missing variable: err = %v", err)
+	}
+
+	// Integer env values are accepted as numbers
+	got, err := p.Eval(Env{"amount": int64(5)})
+	if err != nil || got != true {
+		t.Errorf("int64 variable: got %v, %v", got, err)
+	}
+}
+
+func TestShortCircuit(t *testing.T) {
+	// The right side would fail on an empty env; it must not be evaluated
+	p, err := Compile(`false and amount > 1`, testVars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := p.Eval(Env{}); err != nil || got != false {
+		t.Errorf("got %v, %v; want false, nil", got, err)
+	}
+}
@@ -0,0 +1,130 @@
+package expr
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokNumber
+	tokString
+	tokOp      // operators and punctuation
+	tokKeyword // and, or, not, in, contains, startsWith, endsWith, matches, true, false
+)
+
+type token struct {
+	kind tokenKind
+	text string // raw text; unquoted value for strings
+	pos  int    // byte offset in the source
+}
+
+var keywords = map[string]bool{
+	"and": true, "or": true, "not": true, "in": true,
+	"contains": true, "startsWith": true, "endsWith": true, "matches": true,
+	"true": true, "false": true,
+}
+
+// twoCharOps must be checked before single-character operators
+var twoCharOps = []string{"==", "!=", "<=", ">=", "&&", "||"}
+
+const singleCharOps = "+-*/%<>!()[],"
+
+func lex(src string) ([]token, error) {
+	var toks []token
+	i := 0
+	for i < len(src) {
+		r, size := utf8.DecodeRuneInString(src[i:])
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+
+		case r == '"' || r == '\'':
+			s, n, err := lexString(src, i)
+			if err != nil {
+				return nil, err
+			}
+			toks = append(toks, token{kind: tokString, text: s, pos: i})
+			i += n
+
+		case r >= '0' && r <= '9' || r == '.' && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '9':
+			start := i
+			dot := false
+			for i < len(src) && (src[i] >= '0' && src[i] <= '9' || src[i] == '.' && !dot) {
+				dot = dot || src[i] == '.'
+				i++
+			}
+			toks = append(toks, token{kind: tokNumber, text: src[start:i], pos: start})
+
+		case r == '_' || unicode.IsLetter(r):
+			start := i
+			for i < len(src) {
+				r, size := utf8.DecodeRuneInString(src[i:])
+				if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+					break
+				}
+				i += size
+			}
+			word := src[start:i]
+			kind := tokIdent
+			if keywords[word] {
+				kind = tokKeyword
+			}
+			toks = append(toks, token{kind: kind, text: word, pos: start})
+
+		default:
+			matched := false
+			for _, op := range twoCharOps {
+				if strings.HasPrefix(src[i:], op) {
+					toks = append(toks, token{kind: tokOp, text: op, pos: i})
+					i += 2
+					matched = true
+					break
+				}
+			}
+			if matched {
+				continue
+			}
+			if strings.ContainsRune(singleCharOps, r) {
+				toks = append(toks, token{kind: tokOp, text: string(r), pos: i})
+				i += size
+				continue
+			}
+			return nil, errorf(i, "unexpected character %q", r)
+		}
+	}
+	return append(toks, token{kind: tokEOF, pos: len(src)}), nil
+}
+
+// lexString reads a quoted string starting at src[start], supporting
+// \" \' \\ \n \t escapes. It returns the value and the consumed length.
+func lexString(src string, start int) (string, int, error) {
+	quote := src[start]
+	var b strings.Builder
+	i := start + 1
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == quote:
+			return b.String(), i + 1 - start, nil
+		case c == '\\' && i+1 < len(src):
+			switch src[i+1] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			default:
+				b.WriteByte(src[i+1])
+			}
+			i += 2
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return "", 0, errorf(start, "unterminated string")
+}
@@ -0,0 +1,145 @@
+// Package grpcserver exposes expenses, incomes and statistics over gRPC for
+// programmatic clients (CLI, mobile). The API contract is
+// proto/spese/v1/spese.proto; generated code lives in spesev1.
+package grpcserver
+
+import (
+	"context"
+	"crypto/subtle"
+	"log/slog"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+
+	"spese/internal/core"
+	"spese/internal/events"
+	"spese/internal/grpcserver/spesev1"
+	"spese/internal/sheets"
+	"spese/internal/storage"
+)
+
+// IncomeStore is the income functionality needed by IncomeService; only the
+// SQLite backend provides it.
+type IncomeStore interface {
+	AppendIncome(ctx context.Context, i core.Income) (string, error)
+	ListIncomesWithID(ctx context.Context, year int, month int) ([]storage.IncomeWithID, error)
+	DeleteIncome(ctx context.Context, id string) error
+	ReadIncomeMonthOverview(ctx context.Context, year int, month int) (core.IncomeMonthOverview, error)
+}
+
+// Deps are the backends served over gRPC. Nil optional dependencies make
+// the corresponding methods return codes.Unimplemented.
+type Deps struct {
+	ExpenseWriter   sheets.ExpenseWriter
+	ExpenseLister   sheets.ExpenseListerWithID // optional
+	ExpenseDeleter  sheets.ExpenseDeleter      // optional
+	DashboardReader sheets.DashboardReader
+	Incomes         IncomeStore // optional
+	Events          *events.Bus // optional, drives WatchMonthOverview updates
+}
+
+// requestTimeout bounds unary calls, like the HTTP handlers do
+const requestTimeout = 15 * time.Second
+
+// New builds a gRPC server with all services registered. When token is not
+// empty every call must carry "authorization: Bearer <token>" metadata.
+func New(deps Deps, token string) *grpc.Server {
+	auth := authenticator{token: token}
+	s := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(auth.unary),
+		grpc.ChainStreamInterceptor(auth.stream),
+	)
+
+	spesev1.RegisterExpenseServiceServer(s, &expenseService{deps: deps})
+	spesev1.RegisterIncomeServiceServer(s, &incomeService{deps: deps})
+	spesev1.RegisterStatsServiceServer(s, &statsService{deps: deps})
+	reflection.Register(s)
+
+	return s
+}
+
+// authenticator checks the bearer token and records the caller as actor
+type authenticator struct {
+	token string
+}
+
+func (a authenticator) check(ctx context.Context) (context.Context, error) {
+	if a.token != "" {
+		md, _ := metadata.FromIncomingContext(ctx)
+		var got string
+		if v := md.Get("authorization"); len(v) > 0 {
+			got = strings.TrimPrefix(v[0], "Bearer ")
+		}
+		if subtle.ConstantTimeCompare([]byte(got), []byte(a.token)) != 1 {
+			return ctx, status.Error(codes.Unauthenticated, "invalid or missing token")
+		}
+	}
+
+	caller := "unknown"
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		caller = p.Addr.String()
+	}
+	return core.WithActor(ctx, "grpc:"+caller), nil
+}
+
+func (a authenticator) unary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := a.check(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "gRPC authentication failed", "method", info.FullMethod)
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	return handler(ctx, req)
+}
+
+func (a authenticator) stream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := a.check(ss.Context())
+	if err != nil {
+		slog.WarnContext(ctx, "gRPC authentication failed", "method", info.FullMethod)
+		return err
+	}
+	return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+}
+
+// contextStream overrides the context of a server stream
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context { return s.ctx }
+
+// publish sends a domain event when a bus is configured
+func (d Deps) publish(eventType string, data any) {
+	if d.Events != nil {
+		d.Events.Publish(eventType, data)
+	}
+}
+
+// toCoreDate converts a request date, defaulting to today when unset
+func toCoreDate(d *spesev1.Date) core.Date {
+	if d == nil {
+		now := time.Now()
+		return core.NewDate(now.Year(), int(now.Month()), now.Day())
+	}
+	return core.NewDate(int(d.GetYear()), int(d.GetMonth()), int(d.GetDay()))
+}
+
+func fromCoreDate(d core.Date) *spesev1.Date {
+	return &spesev1.Date{Year: int32(d.Year()), Month: int32(d.Month()), Day: int32(d.Day())}
+}
+
+// validMonth rejects periods the storage layer cannot answer meaningfully
+func validMonth(year, month int32) error {
+	if year < 1 || month < 1 || month > 12 {
+		return status.Error(codes.InvalidArgument, "invalid year or month")
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package grpcserver
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"spese/internal/core"
+	"spese/internal/events"
+	"spese/internal/grpcserver/spesev1"
+)
+
+type fakeBackend struct {
+	appended []core.Expense
+}
+
+func (f *fakeBackend) Append(_ context.Context, e core.Expense) (string, error) {
+	f.appended = append(f.appended, e)
+	return "mem:1", nil
+}
+
+func (f *fakeBackend) ReadMonthOverview(_ context.Context, year int, month int) (core.MonthOverview, error) {
+	var total int64
+	for _, e := range f.appended {
+		total += e.Amount.Cents
+	}
+	return core.MonthOverview{
+		Year:       year,
+		Month:      month,
+		Total:      core.Money{Cents: total},
+		ByCategory: []core.CategoryAmount{{Name: "Casa", Amount: core.Money{Cents: total}}},
+	}, nil
+}
+
+const testToken = "secret"
+
+func dial(t *testing.T, deps Deps) *grpc.ClientConn {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	srv := New(deps, testToken)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+func authed(ctx context.Context) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+testToken)
+}
+
+func TestAuth(t *testing.T) {
+	be := &fakeBackend{}
+	client := spesev1.NewStatsServiceClient(dial(t, Deps{ExpenseWriter: be, DashboardReader: be}))
+
+	_, err := client.GetMonthOverview(context.Background(), &spesev1.GetMonthOverviewRequest{Year: 2025, Month: 1})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("missing token: got %v, want Unauthenticated", err)
+	}
+
+	bad := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer nope")
+	_, err = client.GetMonthOverview(bad, &spesev1.GetMonthOverviewRequest{Year: 2025, Month: 1})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("wrong token: got %v, want Unauthenticated", err)
+	}
+
+	if _, err := client.GetMonthOverview(authed(context.Background()), &spesev1.GetMonthOverviewRequest{Year: 2025, Month: 1}); err != nil {
+		t.Fatalf("valid token: %v", err)
+	}
+}
+
+func TestCreateExpense(t *testing.T) {
+	be := &fakeBackend{}
+	bus := events.NewBus()
+	sub, unsubscribe := bus.Subscribe(1)
+	defer unsubscribe()
+
+	client := spesev1.NewExpenseServiceClient(dial(t, Deps{ExpenseWriter: be, DashboardReader: be, Events: bus}))
+	ctx := authed(context.Background())
+
+	resp, err := client.CreateExpense(ctx, &spesev1.CreateExpenseRequest{
+		Date:              &spesev1.Date{Year: 2025, Month: 3, Day: 14},
+		Description:       "Spesa",
+		AmountCents:       1250,
+		PrimaryCategory:   "Casa",
+		SecondaryCategory: "Alimentari",
+	})
+	if err != nil {
+		t.Fatalf("CreateExpense: %v", err)
+	}
+	if resp.GetRef() != "mem:1" {
+		t.Errorf("ref = %q, want mem:1", resp.GetRef())
+	}
+	if len(be.appended) != 1 || be.appended[0].Amount.Cents != 1250 || be.appended[0].Date.Day() != 14 {
+		t.Errorf("appended = %+v", be.appended)
+	}
+
+	select {
+	case e := <-sub:
+		if e.Type != events.ExpenseCreated {
+			t.Errorf("event type = %q", e.Type)
+		}
+	case <-time.After(time.Second):
+		t.Error("no event published")
+	}
+
+	_, err = client.CreateExpense(ctx, &spesev1.CreateExpenseRequest{Description: "Zero", PrimaryCategory: "Casa"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("zero amount: got %v, want InvalidArgument", err)
+	}
+
+	_, err = client.ListExpenses(ctx, &spesev1.ListExpensesRequest{Year: 2025, Month: 3})
+	if status.Code(err) != codes.Unimplemented {
+		t.Errorf("ListExpenses without lister: got %v, want Unimplemented", err)
+	}
+}
+
+func TestIncomesUnavailable(t *testing.T) {
+	be := &fakeBackend{}
+	client := spesev1.NewIncomeServiceClient(dial(t, Deps{ExpenseWriter: be, DashboardReader: be}))
+
+	_, err := client.ListIncomes(authed(context.Background()), &spesev1.ListIncomesRequest{Year: 2025, Month: 3})
+	if status.Code(err) != codes.Unimplemented {
+		t.Errorf("got %v, want Unimplemented", err)
+	}
+}
+
+func TestWatchMonthOverview(t *testing.T) {
+	be := &fakeBackend{}
+	bus := events.NewBus()
+	conn := dial(t, Deps{ExpenseWriter: be, DashboardReader: be, Events: bus})
+
+	ctx, cancel := context.WithTimeout(authed(context.Background()), 5*time.Second)
+	defer cancel()
+
+	stream, err := spesev1.NewStatsServiceClient(conn).WatchMonthOverview(ctx, &spesev1.WatchMonthOverviewRequest{Year: 2025, Month: 3})
+	if err != nil {
+		t.Fatalf("WatchMonthOverview: %v", err)
+	}
+
+	first, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("first Recv: %v", err)
+	}
+	if first.GetExpenseTotalCents() != 0 {
+		t.Errorf("initial total = %d, want 0", first.GetExpenseTotalCents())
+	}
+
+	_, err = spesev1.NewExpenseServiceClient(conn).CreateExpense(ctx, &spesev1.CreateExpenseRequest{
+		Date:              &spesev1.Date{Year: 2025, Month: 3, Day: 1},
+		Description:       "Luce",
+		AmountCents:       4000,
+		PrimaryCategory:   "Casa",
+		SecondaryCategory: "Bollette",
+	})
+	if err != nil {
+		t.Fatalf("CreateExpense: %v", err)
+	}
+
+	next, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("second Recv: %v", err)
+	}
+	if next.GetExpenseTotalCents() != 4000 {
+		t.Errorf("updated total = %d, want 4000", next.GetExpenseTotalCents())
+	}
+	if len(next.GetExpensesByCategory()) != 1 {
+		t.Errorf("categories = %v", next.GetExpensesByCategory())
+	}
+}
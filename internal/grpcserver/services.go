@@ -0,0 +1,260 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"spese/internal/core"
+	"spese/internal/events"
+	"spese/internal/grpcserver/spesev1"
+	"spese/internal/hooks"
+)
+
+type expenseService struct {
+	spesev1.UnimplementedExpenseServiceServer
+	deps Deps
+}
+
+func (s *expenseService) CreateExpense(ctx context.Context, req *spesev1.CreateExpenseRequest) (*spesev1.CreateExpenseResponse, error) {
+	exp := core.Expense{
+		Date:        toCoreDate(req.GetDate()),
+		Description: strings.TrimSpace(req.GetDescription()),
+		Amount:      core.Money{Cents: req.GetAmountCents()},
+		Primary:     strings.TrimSpace(req.GetPrimaryCategory()),
+		Secondary:   strings.TrimSpace(req.GetSecondaryCategory()),
+	}
+	if err := exp.Validate(); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid expense: %v", err)
+	}
+
+	ref, err := s.deps.ExpenseWriter.Append(ctx, exp)
+	if errors.Is(err, hooks.ErrRejected) {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to save expense",
+			"error", err,
+			"component", "expense_grpc",
+			"operation", "append")
+		return nil, status.Error(codes.Internal, "error saving expense")
+	}
+	s.deps.publish(events.ExpenseCreated, events.ExpenseFrom(exp))
+
+	return &spesev1.CreateExpenseResponse{Ref: ref}, nil
+}
+
+func (s *expenseService) ListExpenses(ctx context.Context, req *spesev1.ListExpensesRequest) (*spesev1.ListExpensesResponse, error) {
+	if s.deps.ExpenseLister == nil {
+		return nil, status.Error(codes.Unimplemented, "listing expenses is not available with this backend")
+	}
+	if err := validMonth(req.GetYear(), req.GetMonth()); err != nil {
+		return nil, err
+	}
+
+	items, err := s.deps.ExpenseLister.ListExpensesWithID(ctx, int(req.GetYear()), int(req.GetMonth()))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to list expenses", "error", err, "component", "expense_grpc")
+		return nil, status.Error(codes.Internal, "error listing expenses")
+	}
+
+	resp := &spesev1.ListExpensesResponse{Expenses: make([]*spesev1.Expense, 0, len(items))}
+	for _, it := range items {
+		resp.Expenses = append(resp.Expenses, &spesev1.Expense{
+			Id:                it.ID,
+			Date:              fromCoreDate(it.Expense.Date),
+			Description:       it.Expense.Description,
+			AmountCents:       it.Expense.Amount.Cents,
+			PrimaryCategory:   it.Expense.Primary,
+			SecondaryCategory: it.Expense.Secondary,
+		})
+	}
+	return resp, nil
+}
+
+func (s *expenseService) DeleteExpense(ctx context.Context, req *spesev1.DeleteExpenseRequest) (*spesev1.DeleteExpenseResponse, error) {
+	if s.deps.ExpenseDeleter == nil {
+		return nil, status.Error(codes.Unimplemented, "deleting expenses is not available with this backend")
+	}
+	if strings.TrimSpace(req.GetId()) == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing expense id")
+	}
+
+	if err := s.deps.ExpenseDeleter.DeleteExpense(ctx, req.GetId()); err != nil {
+		slog.ErrorContext(ctx, "Failed to delete expense", "error", err, "expense_id", req.GetId(), "component", "expense_grpc")
+		return nil, status.Error(codes.Internal, "error deleting expense")
+	}
+	s.deps.publish(events.ExpenseDeleted, events.RefPayload{ID: req.GetId()})
+
+	return &spesev1.DeleteExpenseResponse{}, nil
+}
+
+type incomeService struct {
+	spesev1.UnimplementedIncomeServiceServer
+	deps Deps
+}
+
+// errNoIncomes is returned when the backend does not store incomes
+var errNoIncomes = status.Error(codes.Unimplemented, "incomes are not available with this backend")
+
+func (s *incomeService) CreateIncome(ctx context.Context, req *spesev1.CreateIncomeRequest) (*spesev1.CreateIncomeResponse, error) {
+	if s.deps.Incomes == nil {
+		return nil, errNoIncomes
+	}
+
+	inc := core.Income{
+		Date:        toCoreDate(req.GetDate()),
+		Description: strings.TrimSpace(req.GetDescription()),
+		Amount:      core.Money{Cents: req.GetAmountCents()},
+		Category:    strings.TrimSpace(req.GetCategory()),
+	}
+	if err := inc.Validate(); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid income: %v", err)
+	}
+
+	ref, err := s.deps.Incomes.AppendIncome(ctx, inc)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to save income", "error", err, "component", "income_grpc")
+		return nil, status.Error(codes.Internal, "error saving income")
+	}
+	s.deps.publish(events.IncomeCreated, events.IncomeFrom(inc))
+
+	return &spesev1.CreateIncomeResponse{Ref: ref}, nil
+}
+
+func (s *incomeService) ListIncomes(ctx context.Context, req *spesev1.ListIncomesRequest) (*spesev1.ListIncomesResponse, error) {
+	if s.deps.Incomes == nil {
+		return nil, errNoIncomes
+	}
+	if err := validMonth(req.GetYear(), req.GetMonth()); err != nil {
+		return nil, err
+	}
+
+	items, err := s.deps.Incomes.ListIncomesWithID(ctx, int(req.GetYear()), int(req.GetMonth()))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to list incomes", "error", err, "component", "income_grpc")
+		return nil, status.Error(codes.Internal, "error listing incomes")
+	}
+
+	resp := &spesev1.ListIncomesResponse{Incomes: make([]*spesev1.Income, 0, len(items))}
+	for _, it := range items {
+		resp.Incomes = append(resp.Incomes, &spesev1.Income{
+			Id:          it.ID,
+			Date:        fromCoreDate(it.Income.Date),
+			Description: it.Income.Description,
+			AmountCents: it.Income.Amount.Cents,
+			Category:    it.Income.Category,
+		})
+	}
+	return resp, nil
+}
+
+func (s *incomeService) DeleteIncome(ctx context.Context, req *spesev1.DeleteIncomeRequest) (*spesev1.DeleteIncomeResponse, error) {
+	if s.deps.Incomes == nil {
+		return nil, errNoIncomes
+	}
+	if strings.TrimSpace(req.GetId()) == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing income id")
+	}
+
+	if err := s.deps.Incomes.DeleteIncome(ctx, req.GetId()); err != nil {
+		slog.ErrorContext(ctx, "Failed to delete income", "error", err, "income_id", req.GetId(), "component", "income_grpc")
+		return nil, status.Error(codes.Internal, "error deleting income")
+	}
+	s.deps.publish(events.IncomeDeleted, events.RefPayload{ID: req.GetId()})
+
+	return &spesev1.DeleteIncomeResponse{}, nil
+}
+
+type statsService struct {
+	spesev1.UnimplementedStatsServiceServer
+	deps Deps
+}
+
+func (s *statsService) GetMonthOverview(ctx context.Context, req *spesev1.GetMonthOverviewRequest) (*spesev1.MonthOverview, error) {
+	if err := validMonth(req.GetYear(), req.GetMonth()); err != nil {
+		return nil, err
+	}
+	return s.overview(ctx, int(req.GetYear()), int(req.GetMonth()))
+}
+
+func (s *statsService) WatchMonthOverview(req *spesev1.WatchMonthOverviewRequest, stream spesev1.StatsService_WatchMonthOverviewServer) error {
+	if err := validMonth(req.GetYear(), req.GetMonth()); err != nil {
+		return err
+	}
+	ctx := stream.Context()
+	year, month := int(req.GetYear()), int(req.GetMonth())
+
+	// Subscribe before the first read so no change slips in between
+	var changes <-chan events.Event
+	if s.deps.Events != nil {
+		ch, unsubscribe := s.deps.Events.Subscribe(16)
+		defer unsubscribe()
+		changes = ch
+	}
+
+	send := func() error {
+		ov, err := s.overview(ctx, year, month)
+		if err != nil {
+			return err
+		}
+		return stream.Send(ov)
+	}
+
+	if err := send(); err != nil {
+		return err
+	}
+	if changes == nil {
+		return nil
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case e, ok := <-changes:
+			if !ok {
+				return nil
+			}
+			if !strings.HasPrefix(e.Type, "expense.") && !strings.HasPrefix(e.Type, "income.") {
+				continue
+			}
+			if err := send(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// overview combines the expense dashboard with income totals when available
+func (s *statsService) overview(ctx context.Context, year, month int) (*spesev1.MonthOverview, error) {
+	ov, err := s.deps.DashboardReader.ReadMonthOverview(ctx, year, month)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to read month overview", "error", err, "year", year, "month", month, "component", "stats_grpc")
+		return nil, status.Error(codes.Internal, "error reading overview")
+	}
+
+	resp := &spesev1.MonthOverview{
+		Year:              int32(year),
+		Month:             int32(month),
+		ExpenseTotalCents: ov.Total.Cents,
+	}
+	for _, c := range ov.ByCategory {
+		resp.ExpensesByCategory = append(resp.ExpensesByCategory, &spesev1.CategoryAmount{Name: c.Name, AmountCents: c.Amount.Cents})
+	}
+
+	if s.deps.Incomes != nil {
+		inc, err := s.deps.Incomes.ReadIncomeMonthOverview(ctx, year, month)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to read income overview", "error", err, "year", year, "month", month, "component", "stats_grpc")
+			return nil, status.Error(codes.Internal, "error reading overview")
+		}
+		resp.IncomeTotalCents = inc.Total.Cents
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,1238 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.7
+// 	protoc        (unknown)
+// source: spese/v1/spese.proto
+
+package spesev1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Date is a calendar date without time zone.
+type Date struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Year  int32                  `protobuf:"varint,1,opt,name=year,proto3" json:"year,omitempty"`
+	// 1-12
+	Month int32 `protobuf:"varint,2,opt,name=month,proto3" json:"month,omitempty"`
+	// 1-31
+	Day           int32 `protobuf:"varint,3,opt,name=day,proto3" json:"day,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Date) Reset() {
+	*x = Date{}
+	mi := &file_spese_v1_spese_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Date) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Date) ProtoMessage() {}
+
+func (x *Date) ProtoReflect() protoreflect.Message {
+	mi := &file_spese_v1_spese_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Date.ProtoReflect.Descriptor instead.
+func (*Date) Descriptor() ([]byte, []int) {
+	return file_spese_v1_spese_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Date) GetYear() int32 {
+	if x != nil {
+		return x.Year
+	}
+	return 0
+}
+
+func (x *Date) GetMonth() int32 {
+	if x != nil {
+		return x.Month
+	}
+	return 0
+}
+
+func (x *Date) GetDay() int32 {
+	if x != nil {
+		return x.Day
+	}
+	return 0
+}
+
+// Expense is a stored expense.
+type Expense struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Id                string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Date              *Date                  `protobuf:"bytes,2,opt,name=date,proto3" json:"date,omitempty"`
+	Description       string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	AmountCents       int64                  `protobuf:"varint,4,opt,name=amount_cents,json=amountCents,proto3" json:"amount_cents,omitempty"`
+	PrimaryCategory   string                 `protobuf:"bytes,5,opt,name=primary_category,json=primaryCategory,proto3" json:"primary_category,omitempty"`
+	SecondaryCategory string                 `protobuf:"bytes,6,opt,name=secondary_category,json=secondaryCategory,proto3" json:"secondary_category,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *Expense) Reset() {
+	*x = Expense{}
+	mi := &file_spese_v1_spese_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Expense) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Expense) ProtoMessage() {}
+
+func (x *Expense) ProtoReflect() protoreflect.Message {
+	mi := &file_spese_v1_spese_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Expense.ProtoReflect.Descriptor instead.
+func (*Expense) Descriptor() ([]byte, []int) {
+	return file_spese_v1_spese_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Expense) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Expense) GetDate() *Date {
+	if x != nil {
+		return x.Date
+	}
+	return nil
+}
+
+func (x *Expense) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Expense) GetAmountCents() int64 {
+	if x != nil {
+		return x.AmountCents
+	}
+	return 0
+}
+
+func (x *Expense) GetPrimaryCategory() string {
+	if x != nil {
+		return x.PrimaryCategory
+	}
+	return ""
+}
+
+func (x *Expense) GetSecondaryCategory() string {
+	if x != nil {
+		return x.SecondaryCategory
+	}
+	return ""
+}
+
+type CreateExpenseRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Defaults to today when unset.
+	Date              *Date  `protobuf:"bytes,1,opt,name=date,proto3" json:"date,omitempty"`
+	Description       string `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	AmountCents       int64  `protobuf:"varint,3,opt,name=amount_cents,json=amountCents,proto3" json:"amount_cents,omitempty"`
+	PrimaryCategory   string `protobuf:"bytes,4,opt,name=primary_category,json=primaryCategory,proto3" json:"primary_category,omitempty"`
+	SecondaryCategory string `protobuf:"bytes,5,opt,name=secondary_category,json=secondaryCategory,proto3" json:"secondary_category,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *CreateExpenseRequest) Reset() {
+	*x = CreateExpenseRequest{}
+	mi := &file_spese_v1_spese_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateExpenseRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateExpenseRequest) ProtoMessage() {}
+
+func (x *CreateExpenseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_spese_v1_spese_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateExpenseRequest.ProtoReflect.Descriptor instead.
+func (*CreateExpenseRequest) Descriptor() ([]byte, []int) {
+	return file_spese_v1_spese_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CreateExpenseRequest) GetDate() *Date {
+	if x != nil {
+		return x.Date
+	}
+	return nil
+}
+
+func (x *CreateExpenseRequest) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *CreateExpenseRequest) GetAmountCents() int64 {
+	if x != nil {
+		return x.AmountCents
+	}
+	return 0
+}
+
+func (x *CreateExpenseRequest) GetPrimaryCategory() string {
+	if x != nil {
+		return x.PrimaryCategory
+	}
+	return ""
+}
+
+func (x *CreateExpenseRequest) GetSecondaryCategory() string {
+	if x != nil {
+		return x.SecondaryCategory
+	}
+	return ""
+}
+
+type CreateExpenseResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Backend reference of the stored expense.
+	Ref           string `protobuf:"bytes,1,opt,name=ref,proto3" json:"ref,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateExpenseResponse) Reset() {
+	*x = CreateExpenseResponse{}
+	mi := &file_spese_v1_spese_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateExpenseResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateExpenseResponse) ProtoMessage() {}
+
+func (x *CreateExpenseResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_spese_v1_spese_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateExpenseResponse.ProtoReflect.Descriptor instead.
+func (*CreateExpenseResponse) Descriptor() ([]byte, []int) {
+	return file_spese_v1_spese_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CreateExpenseResponse) GetRef() string {
+	if x != nil {
+		return x.Ref
+	}
+	return ""
+}
+
+type ListExpensesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Year          int32                  `protobuf:"varint,1,opt,name=year,proto3" json:"year,omitempty"`
+	Month         int32                  `protobuf:"varint,2,opt,name=month,proto3" json:"month,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListExpensesRequest) Reset() {
+	*x = ListExpensesRequest{}
+	mi := &file_spese_v1_spese_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListExpensesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListExpensesRequest) ProtoMessage() {}
+
+func (x *ListExpensesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_spese_v1_spese_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListExpensesRequest.ProtoReflect.Descriptor instead.
+func (*ListExpensesRequest) Descriptor() ([]byte, []int) {
+	return file_spese_v1_spese_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListExpensesRequest) GetYear() int32 {
+	if x != nil {
+		return x.Year
+	}
+	return 0
+}
+
+func (x *ListExpensesRequest) GetMonth() int32 {
+	if x != nil {
+		return x.Month
+	}
+	return 0
+}
+
+type ListExpensesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Expenses      []*Expense             `protobuf:"bytes,1,rep,name=expenses,proto3" json:"expenses,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListExpensesResponse) Reset() {
+	*x = ListExpensesResponse{}
+	mi := &file_spese_v1_spese_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListExpensesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListExpensesResponse) ProtoMessage() {}
+
+func (x *ListExpensesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_spese_v1_spese_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListExpensesResponse.ProtoReflect.Descriptor instead.
+func (*ListExpensesResponse) Descriptor() ([]byte, []int) {
+	return file_spese_v1_spese_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListExpensesResponse) GetExpenses() []*Expense {
+	if x != nil {
+		return x.Expenses
+	}
+	return nil
+}
+
+type DeleteExpenseRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteExpenseRequest) Reset() {
+	*x = DeleteExpenseRequest{}
+	mi := &file_spese_v1_spese_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteExpenseRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteExpenseRequest) ProtoMessage() {}
+
+func (x *DeleteExpenseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_spese_v1_spese_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteExpenseRequest.ProtoReflect.Descriptor instead.
+func (*DeleteExpenseRequest) Descriptor() ([]byte, []int) {
+	return file_spese_v1_spese_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *DeleteExpenseRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type DeleteExpenseResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteExpenseResponse) Reset() {
+	*x = DeleteExpenseResponse{}
+	mi := &file_spese_v1_spese_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteExpenseResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteExpenseResponse) ProtoMessage() {}
+
+func (x *DeleteExpenseResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_spese_v1_spese_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteExpenseResponse.ProtoReflect.Descriptor instead.
+func (*DeleteExpenseResponse) Descriptor() ([]byte, []int) {
+	return file_spese_v1_spese_proto_rawDescGZIP(), []int{7}
+}
+
+// Income is a stored income.
+type Income struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Date          *Date                  `protobuf:"bytes,2,opt,name=date,proto3" json:"date,omitempty"`
+	Description   string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	AmountCents   int64                  `protobuf:"varint,4,opt,name=amount_cents,json=amountCents,proto3" json:"amount_cents,omitempty"`
+	Category      string                 `protobuf:"bytes,5,opt,name=category,proto3" json:"category,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Income) Reset() {
+	*x = Income{}
+	mi := &file_spese_v1_spese_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Income) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Income) ProtoMessage() {}
+
+func (x *Income) ProtoReflect() protoreflect.Message {
+	mi := &file_spese_v1_spese_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Income.ProtoReflect.Descriptor instead.
+func (*Income) Descriptor() ([]byte, []int) {
+	return file_spese_v1_spese_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Income) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Income) GetDate() *Date {
+	if x != nil {
+		return x.Date
+	}
+	return nil
+}
+
+func (x *Income) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Income) GetAmountCents() int64 {
+	if x != nil {
+		return x.AmountCents
+	}
+	return 0
+}
+
+func (x *Income) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+type CreateIncomeRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Defaults to today when unset.
+	Date          *Date  `protobuf:"bytes,1,opt,name=date,proto3" json:"date,omitempty"`
+	Description   string `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	AmountCents   int64  `protobuf:"varint,3,opt,name=amount_cents,json=amountCents,proto3" json:"amount_cents,omitempty"`
+	Category      string `protobuf:"bytes,4,opt,name=category,proto3" json:"category,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateIncomeRequest) Reset() {
+	*x = CreateIncomeRequest{}
+	mi := &file_spese_v1_spese_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateIncomeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateIncomeRequest) ProtoMessage() {}
+
+func (x *CreateIncomeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_spese_v1_spese_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateIncomeRequest.ProtoReflect.Descriptor instead.
+func (*CreateIncomeRequest) Descriptor() ([]byte, []int) {
+	return file_spese_v1_spese_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *CreateIncomeRequest) GetDate() *Date {
+	if x != nil {
+		return x.Date
+	}
+	return nil
+}
+
+func (x *CreateIncomeRequest) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *CreateIncomeRequest) GetAmountCents() int64 {
+	if x != nil {
+		return x.AmountCents
+	}
+	return 0
+}
+
+func (x *CreateIncomeRequest) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+type CreateIncomeResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Backend reference of the stored income.
+	Ref           string `protobuf:"bytes,1,opt,name=ref,proto3" json:"ref,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateIncomeResponse) Reset() {
+	*x = CreateIncomeResponse{}
+	mi := &file_spese_v1_spese_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateIncomeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateIncomeResponse) ProtoMessage() {}
+
+func (x *CreateIncomeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_spese_v1_spese_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateIncomeResponse.ProtoReflect.Descriptor instead.
+func (*CreateIncomeResponse) Descriptor() ([]byte, []int) {
+	return file_spese_v1_spese_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *CreateIncomeResponse) GetRef() string {
+	if x != nil {
+		return x.Ref
+	}
+	return ""
+}
+
+type ListIncomesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Year          int32                  `protobuf:"varint,1,opt,name=year,proto3" json:"year,omitempty"`
+	Month         int32                  `protobuf:"varint,2,opt,name=month,proto3" json:"month,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListIncomesRequest) Reset() {
+	*x = ListIncomesRequest{}
+	mi := &file_spese_v1_spese_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListIncomesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListIncomesRequest) ProtoMessage() {}
+
+func (x *ListIncomesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_spese_v1_spese_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListIncomesRequest.ProtoReflect.Descriptor instead.
+func (*ListIncomesRequest) Descriptor() ([]byte, []int) {
+	return file_spese_v1_spese_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ListIncomesRequest) GetYear() int32 {
+	if x != nil {
+		return x.Year
+	}
+	return 0
+}
+
+func (x *ListIncomesRequest) GetMonth() int32 {
+	if x != nil {
+		return x.Month
+	}
+	return 0
+}
+
+type ListIncomesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Incomes       []*Income              `protobuf:"bytes,1,rep,name=incomes,proto3" json:"incomes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListIncomesResponse) Reset() {
+	*x = ListIncomesResponse{}
+	mi := &file_spese_v1_spese_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListIncomesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListIncomesResponse) ProtoMessage() {}
+
+func (x *ListIncomesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_spese_v1_spese_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListIncomesResponse.ProtoReflect.Descriptor instead.
+func (*ListIncomesResponse) Descriptor() ([]byte, []int) {
+	return file_spese_v1_spese_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ListIncomesResponse) GetIncomes() []*Income {
+	if x != nil {
+		return x.Incomes
+	}
+	return nil
+}
+
+type DeleteIncomeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteIncomeRequest) Reset() {
+	*x = DeleteIncomeRequest{}
+	mi := &file_spese_v1_spese_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteIncomeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteIncomeRequest) ProtoMessage() {}
+
+func (x *DeleteIncomeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_spese_v1_spese_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteIncomeRequest.ProtoReflect.Descriptor instead.
+func (*DeleteIncomeRequest) Descriptor() ([]byte, []int) {
+	return file_spese_v1_spese_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *DeleteIncomeRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type DeleteIncomeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteIncomeResponse) Reset() {
+	*x = DeleteIncomeResponse{}
+	mi := &file_spese_v1_spese_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteIncomeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteIncomeResponse) ProtoMessage() {}
+
+func (x *DeleteIncomeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_spese_v1_spese_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteIncomeResponse.ProtoReflect.Descriptor instead.
+func (*DeleteIncomeResponse) Descriptor() ([]byte, []int) {
+	return file_spese_v1_spese_proto_rawDescGZIP(), []int{14}
+}
+
+type CategoryAmount struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	AmountCents   int64                  `protobuf:"varint,2,opt,name=amount_cents,json=amountCents,proto3" json:"amount_cents,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CategoryAmount) Reset() {
+	*x = CategoryAmount{}
+	mi := &file_spese_v1_spese_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CategoryAmount) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CategoryAmount) ProtoMessage() {}
+
+func (x *CategoryAmount) ProtoReflect() protoreflect.Message {
+	mi := &file_spese_v1_spese_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CategoryAmount.ProtoReflect.Descriptor instead.
+func (*CategoryAmount) Descriptor() ([]byte, []int) {
+	return file_spese_v1_spese_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *CategoryAmount) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CategoryAmount) GetAmountCents() int64 {
+	if x != nil {
+		return x.AmountCents
+	}
+	return 0
+}
+
+// MonthOverview aggregates a month of expenses and incomes.
+type MonthOverview struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Year               int32                  `protobuf:"varint,1,opt,name=year,proto3" json:"year,omitempty"`
+	Month              int32                  `protobuf:"varint,2,opt,name=month,proto3" json:"month,omitempty"`
+	ExpenseTotalCents  int64                  `protobuf:"varint,3,opt,name=expense_total_cents,json=expenseTotalCents,proto3" json:"expense_total_cents,omitempty"`
+	ExpensesByCategory []*CategoryAmount      `protobuf:"bytes,4,rep,name=expenses_by_category,json=expensesByCategory,proto3" json:"expenses_by_category,omitempty"`
+	// Zero when incomes are not available.
+	IncomeTotalCents int64 `protobuf:"varint,5,opt,name=income_total_cents,json=incomeTotalCents,proto3" json:"income_total_cents,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *MonthOverview) Reset() {
+	*x = MonthOverview{}
+	mi := &file_spese_v1_spese_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MonthOverview) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MonthOverview) ProtoMessage() {}
+
+func (x *MonthOverview) ProtoReflect() protoreflect.Message {
+	mi := &file_spese_v1_spese_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MonthOverview.ProtoReflect.Descriptor instead.
+func (*MonthOverview) Descriptor() ([]byte, []int) {
+	return file_spese_v1_spese_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *MonthOverview) GetYear() int32 {
+	if x != nil {
+		return x.Year
+	}
+	return 0
+}
+
+func (x *MonthOverview) GetMonth() int32 {
+	if x != nil {
+		return x.Month
+	}
+	return 0
+}
+
+func (x *MonthOverview) GetExpenseTotalCents() int64 {
+	if x != nil {
+		return x.ExpenseTotalCents
+	}
+	return 0
+}
+
+func (x *MonthOverview) GetExpensesByCategory() []*CategoryAmount {
+	if x != nil {
+		return x.ExpensesByCategory
+	}
+	return nil
+}
+
+func (x *MonthOverview) GetIncomeTotalCents() int64 {
+	if x != nil {
+		return x.IncomeTotalCents
+	}
+	return 0
+}
+
+type GetMonthOverviewRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Year          int32                  `protobuf:"varint,1,opt,name=year,proto3" json:"year,omitempty"`
+	Month         int32                  `protobuf:"varint,2,opt,name=month,proto3" json:"month,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetMonthOverviewRequest) Reset() {
+	*x = GetMonthOverviewRequest{}
+	mi := &file_spese_v1_spese_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetMonthOverviewRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetMonthOverviewRequest) ProtoMessage() {}
+
+func (x *GetMonthOverviewRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_spese_v1_spese_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetMonthOverviewRequest.ProtoReflect.Descriptor instead.
+func (*GetMonthOverviewRequest) Descriptor() ([]byte, []int) {
+	return file_spese_v1_spese_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *GetMonthOverviewRequest) GetYear() int32 {
+	if x != nil {
+		return x.Year
+	}
+	return 0
+}
+
+func (x *GetMonthOverviewRequest) GetMonth() int32 {
+	if x != nil {
+		return x.Month
+	}
+	return 0
+}
+
+type WatchMonthOverviewRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Year          int32                  `protobuf:"varint,1,opt,name=year,proto3" json:"year,omitempty"`
+	Month         int32                  `protobuf:"varint,2,opt,name=month,proto3" json:"month,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchMonthOverviewRequest) Reset() {
+	*x = WatchMonthOverviewRequest{}
+	mi := &file_spese_v1_spese_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchMonthOverviewRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchMonthOverviewRequest) ProtoMessage() {}
+
+func (x *WatchMonthOverviewRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_spese_v1_spese_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchMonthOverviewRequest.ProtoReflect.Descriptor instead.
+func (*WatchMonthOverviewRequest) Descriptor() ([]byte, []int) {
+	return file_spese_v1_spese_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *WatchMonthOverviewRequest) GetYear() int32 {
+	if x != nil {
+		return x.Year
+	}
+	return 0
+}
+
+func (x *WatchMonthOverviewRequest) GetMonth() int32 {
+	if x != nil {
+		return x.Month
+	}
+	return 0
+}
+
+var File_spese_v1_spese_proto protoreflect.FileDescriptor
+
+const file_spese_v1_spese_proto_rawDesc = "" +
+	"\n" +
+	"\x14spese/v1/spese.proto\x12\bspese.v1\"B\n" +
+	"\x04Date\x12\x12\n" +
+	"\x04year\x18\x01 \x01(\x05R\x04year\x12\x14\n" +
+	"\x05month\x18\x02 \x01(\x05R\x05month\x12\x10\n" +
+	"\x03day\x18\x03 \x01(\x05R\x03day\"\xdc\x01\n" +
+	"\aExpense\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\"\n" +
+	"\x04date\x18\x02 \x01(\v2\x0e.spese.v1.DateR\x04date\x12 \n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\x12!\n" +
+	"\famount_cents\x18\x04 \x01(\x03R\vamountCents\x12)\n" +
+	"\x10primary_category\x18\x05 \x01(\tR\x0fprimaryCategory\x12-\n" +
+	"\x12secondary_category\x18\x06 \x01(\tR\x11secondaryCategory\"\xd9\x01\n" +
+	"\x14CreateExpenseRequest\x12\"\n" +
+	"\x04date\x18\x01 \x01(\v2\x0e.spese.v1.DateR\x04date\x12 \n" +
+	"\vdescription\x18\x02 \x01(\tR\vdescription\x12!\n" +
+	"\famount_cents\x18\x03 \x01(\x03R\vamountCents\x12)\n" +
+	"\x10primary_category\x18\x04 \x01(\tR\x0fprimaryCategory\x12-\n" +
+	"\x12secondary_category\x18\x05 \x01(\tR\x11secondaryCategory\")\n" +
+	"\x15CreateExpenseResponse\x12\x10\n" +
+	"\x03ref\x18\x01 \x01(\tR\x03ref\"?\n" +
+	"\x13ListExpensesRequest\x12\x12\n" +
+	"\x04year\x18\x01 \x01(\x05R\x04year\x12\x14\n" +
+	"\x05month\x18\x02 \x01(\x05R\x05month\"E\n" +
+	"\x14ListExpensesResponse\x12-\n" +
+	"\bexpenses\x18\x01 \x03(\v2\x11.spese.v1.ExpenseR\bexpenses\"&\n" +
+	"\x14DeleteExpenseRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\x17\n" +
+	"\x15DeleteExpenseResponse\"\x9d\x01\n" +
+	"\x06Income\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\"\n" +
+	"\x04date\x18\x02 \x01(\v2\x0e.spese.v1.DateR\x04date\x12 \n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\x12!\n" +
+	"\famount_cents\x18\x04 \x01(\x03R\vamountCents\x12\x1a\n" +
+	"\bcategory\x18\x05 \x01(\tR\bcategory\"\x9a\x01\n" +
+	"\x13CreateIncomeRequest\x12\"\n" +
+	"\x04date\x18\x01 \x01(\v2\x0e.spese.v1.DateR\x04date\x12 \n" +
+	"\vdescription\x18\x02 \x01(\tR\vdescription\x12!\n" +
+	"\famount_cents\x18\x03 \x01(\x03R\vamountCents\x12\x1a\n" +
+	"\bcategory\x18\x04 \x01(\tR\bcategory\"(\n" +
+	"\x14CreateIncomeResponse\x12\x10\n" +
+	"\x03ref\x18\x01 \x01(\tR\x03ref\">\n" +
+	"\x12ListIncomesRequest\x12\x12\n" +
+	"\x04year\x18\x01 \x01(\x05R\x04year\x12\x14\n" +
+	"\x05month\x18\x02 \x01(\x05R\x05month\"A\n" +
+	"\x13ListIncomesResponse\x12*\n" +
+	"\aincomes\x18\x01 \x03(\v2\x10.spese.v1.IncomeR\aincomes\"%\n" +
+	"\x13DeleteIncomeRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\x16\n" +
+	"\x14DeleteIncomeResponse\"G\n" +
+	"\x0eCategoryAmount\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12!\n" +
+	"\famount_cents\x18\x02 \x01(\x03R\vamountCents\"\xe3\x01\n" +
+	"\rMonthOverview\x12\x12\n" +
+	"\x04year\x18\x01 \x01(\x05R\x04year\x12\x14\n" +
+	"\x05month\x18\x02 \x01(\x05R\x05month\x12.\n" +
+	"\x13expense_total_cents\x18\x03 \x01(\x03R\x11expenseTotalCents\x12J\n" +
+	"\x14expenses_by_category\x18\x04 \x03(\v2\x18.spese.v1.CategoryAmountR\x12expensesByCategory\x12,\n" +
+	"\x12income_total_cents\x18\x05 \x01(\x03R\x10incomeTotalCents\"C\n" +
+	"\x17GetMonthOverviewRequest\x12\x12\n" +
+	"\x04year\x18\x01 \x01(\x05R\x04year\x12\x14\n" +
+	"\x05month\x18\x02 \x01(\x05R\x05month\"E\n" +
+	"\x19WatchMonthOverviewRequest\x12\x12\n" +
+	"\x04year\x18\x01 \x01(\x05R\x04year\x12\x14\n" +
+	"\x05month\x18\x02 \x01(\x05R\x05month2\x83\x02\n" +
+	"\x0eExpenseService\x12P\n" +
+	"\rCreateExpense\x12\x1e.spese.v1.CreateExpenseRequest\x1a\x1f.spese.v1.CreateExpenseResponse\x12M\n" +
+	"\fListExpenses\x12\x1d.spese.v1.ListExpensesRequest\x1a\x1e.spese.v1.ListExpensesResponse\x12P\n" +
+	"\rDeleteExpense\x12\x1e.spese.v1.DeleteExpenseRequest\x1a\x1f.spese.v1.DeleteExpenseResponse2\xf9\x01\n" +
+	"\rIncomeService\x12M\n" +
+	"\fCreateIncome\x12\x1d.spese.v1.CreateIncomeRequest\x1a\x1e.spese.v1.CreateIncomeResponse\x12J\n" +
+	"\vListIncomes\x12\x1c.spese.v1.ListIncomesRequest\x1a\x1d.spese.v1.ListIncomesResponse\x12M\n" +
+	"\fDeleteIncome\x12\x1d.spese.v1.DeleteIncomeRequest\x1a\x1e.spese.v1.DeleteIncomeResponse2\xb4\x01\n" +
+	"\fStatsService\x12N\n" +
+	"\x10GetMonthOverview\x12!.spese.v1.GetMonthOverviewRequest\x1a\x17.spese.v1.MonthOverview\x12T\n" +
+	"\x12WatchMonthOverview\x12#.spese.v1.WatchMonthOverviewRequest\x1a\x17.spese.v1.MonthOverview0\x01B+Z)spese/internal/grpcserver/spesev1;spesev1b\x06proto3"
+
+var (
+	file_spese_v1_spese_proto_rawDescOnce sync.Once
+	file_spese_v1_spese_proto_rawDescData []byte
+)
+
+func file_spese_v1_spese_proto_rawDescGZIP() []byte {
+	file_spese_v1_spese_proto_rawDescOnce.Do(func() {
+		file_spese_v1_spese_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_spese_v1_spese_proto_rawDesc), len(file_spese_v1_spese_proto_rawDesc)))
+	})
+	return file_spese_v1_spese_proto_rawDescData
+}
+
+var file_spese_v1_spese_proto_msgTypes = make([]protoimpl.MessageInfo, 19)
+var file_spese_v1_spese_proto_goTypes = []any{
+	(*Date)(nil),                      // 0: spese.v1.Date
+	(*Expense)(nil),                   // 1: spese.v1.Expense
+	(*CreateExpenseRequest)(nil),      // 2: spese.v1.CreateExpenseRequest
+	(*CreateExpenseResponse)(nil),     // 3: spese.v1.CreateExpenseResponse
+	(*ListExpensesRequest)(nil),       // 4: spese.v1.ListExpensesRequest
+	(*ListExpensesResponse)(nil),      // 5: spese.v1.ListExpensesResponse
+	(*DeleteExpenseRequest)(nil),      // 6: spese.v1.DeleteExpenseRequest
+	(*DeleteExpenseResponse)(nil),     // 7: spese.v1.DeleteExpenseResponse
+	(*Income)(nil),                    // 8: spese.v1.Income
+	(*CreateIncomeRequest)(nil),       // 9: spese.v1.CreateIncomeRequest
+	(*CreateIncomeResponse)(nil),      // 10: spese.v1.CreateIncomeResponse
+	(*ListIncomesRequest)(nil),        // 11: spese.v1.ListIncomesRequest
+	(*ListIncomesResponse)(nil),       // 12: spese.v1.ListIncomesResponse
+	(*DeleteIncomeRequest)(nil),       // 13: spese.v1.DeleteIncomeRequest
+	(*DeleteIncomeResponse)(nil),      // 14: spese.v1.DeleteIncomeResponse
+	(*CategoryAmount)(nil),            // 15: spese.v1.CategoryAmount
+	(*MonthOverview)(nil),             // 16: spese.v1.MonthOverview
+	(*GetMonthOverviewRequest)(nil),   // 17: spese.v1.GetMonthOverviewRequest
+	(*WatchMonthOverviewRequest)(nil), // 18: spese.v1.WatchMonthOverviewRequest
+}
+var file_spese_v1_spese_proto_depIdxs = []int32{
+	0,  // 0: spese.v1.Expense.date:type_name -> spese.v1.Date
+	0,  // 1: spese.v1.CreateExpenseRequest.date:type_name -> spese.v1.Date
+	1,  // 2: spese.v1.ListExpensesResponse.expenses:type_name -> spese.v1.Expense
+	0,  // 3: spese.v1.Income.date:type_name -> spese.v1.Date
+	0,  // 4: spese.v1.CreateIncomeRequest.date:type_name -> spese.v1.Date
+	8,  // 5: spese.v1.ListIncomesResponse.incomes:type_name -> spese.v1.Income
+	15, // 6: spese.v1.MonthOverview.expenses_by_category:type_name -> spese.v1.CategoryAmount
+	2,  // 7: spese.v1.ExpenseService.CreateExpense:input_type -> spese.v1.CreateExpenseRequest
+	4,  // 8: spese.v1.ExpenseService.ListExpenses:input_type -> spese.v1.ListExpensesRequest
+	6,  // 9: spese.v1.ExpenseService.DeleteExpense:input_type -> spese.v1.DeleteExpenseRequest
+	9,  // 10: spese.v1.IncomeService.CreateIncome:input_type -> spese.v1.CreateIncomeRequest
+	11, // 11: spese.v1.IncomeService.ListIncomes:input_type -> spese.v1.ListIncomesRequest
+	13, // 12: spese.v1.IncomeService.DeleteIncome:input_type -> spese.v1.DeleteIncomeRequest
+	17, // 13: spese.v1.StatsService.GetMonthOverview:input_type -> spese.v1.GetMonthOverviewRequest
+	18, // 14: spese.v1.StatsService.WatchMonthOverview:input_type -> spese.v1.WatchMonthOverviewRequest
+	3,  // 15: spese.v1.ExpenseService.CreateExpense:output_type -> spese.v1.CreateExpenseResponse
+	5,  // 16: spese.v1.ExpenseService.ListExpenses:output_type -> spese.v1.ListExpensesResponse
+	7,  // 17: spese.v1.ExpenseService.DeleteExpense:output_type -> spese.v1.DeleteExpenseResponse
+	10, // 18: spese.v1.IncomeService.CreateIncome:output_type -> spese.v1.CreateIncomeResponse
+	12, // 19: spese.v1.IncomeService.ListIncomes:output_type -> spese.v1.ListIncomesResponse
+	14, // 20: spese.v1.IncomeService.DeleteIncome:output_type -> spese.v1.DeleteIncomeResponse
+	16, // 21: spese.v1.StatsService.GetMonthOverview:output_type -> spese.v1.MonthOverview
+	16, // 22: spese.v1.StatsService.WatchMonthOverview:output_type -> spese.v1.MonthOverview
+	15, // [15:23] is the sub-list for method output_type
+	7,  // [7:15] is the sub-list for method input_type
+	7,  // [7:7] is the sub-list for extension type_name
+	7,  // [7:7] is the sub-list for extension extendee
+	0,  // [0:7] is the sub-list for field type_name
+}
+
+func init() { file_spese_v1_spese_proto_init() }
+func file_spese_v1_spese_proto_init() {
+	if File_spese_v1_spese_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_spese_v1_spese_proto_rawDesc), len(file_spese_v1_spese_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   19,
+			NumExtensions: 0,
+			NumServices:   3,
+		},
+		GoTypes:           file_spese_v1_spese_proto_goTypes,
+		DependencyIndexes: file_spese_v1_spese_proto_depIdxs,
+		MessageInfos:      file_spese_v1_spese_proto_msgTypes,
+	}.Build()
+	File_spese_v1_spese_proto = out.File
+	file_spese_v1_spese_proto_goTypes = nil
+	file_spese_v1_spese_proto_depIdxs = nil
+}
@@ -0,0 +1,264 @@
+// Package hooks runs user-provided executables at fixed points of the
+// expense lifecycle, so custom categorization or notifications can be added
+// without forking. Each hook receives a JSON document on stdin.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"strings"
+	"time"
+
+	"spese/internal/core"
+	"spese/internal/events"
+	"spese/internal/sheets"
+)
+
+// Hook points.
+const (
+	// BeforeExpenseSave runs synchronously before an expense is stored. The
+	// hook may print {"expense": {...}} to replace the expense, print
+	// nothing to keep it, or exit non-zero to reject the save (stderr is
+	// reported as the reason).
+	BeforeExpenseSave = "before_expense_save"
+	// AfterExpenseSave runs in the background after an expense is stored.
+	AfterExpenseSave = "after_expense_save"
+	// AfterImport runs in the background after a bulk import completes.
+	AfterImport = "after_import"
+)
+
+// DefaultTimeout bounds a single hook execution.
+const DefaultTimeout = 5 * time.Second
+
+// ErrRejected is returned when a before_* hook refuses the operation.
+var ErrRejected = errors.New("rejected by hook")
+
+// waitDelay is how long a hook's output is waited for once it exited or
+// timed out, in case a background child it left holds the pipes open.
+const waitDelay = time.Second
+
+// maxOutput bounds what is kept of a hook's stdout and of its stderr.
+const maxOutput = 1 << 20
+
+// Input is the JSON document written to the hook's stdin.
+type Input struct {
+	Hook    string                 `json:"hook"`
+	Actor   string                 `json:"actor"`
+	Expense *events.ExpensePayload `json:"expense,omitempty"`
+	Ref     string                 `json:"ref,omitempty"`
+	Import  *ImportResult          `json:"import,omitempty"`
+}
+
+// output is what a before_expense_save hook may print on stdout.
+type output struct {
+	Expense *events.ExpensePayload `json:"expense"`
+}
+
+// ImportResult summarizes a finished import for the after_import hook.
+type ImportResult struct {
+	Source   string `json:"source"`
+	Imported int    `json:"imported"`
+	Skipped  int    `json:"skipped"`
+}
+
+// Runner executes the configured hook commands. A nil Runner, or one
+// without a command for a hook point, does nothing.
+type Runner struct {
+	commands map[string][]string
+	timeout  time.Duration
+}
+
+// NewRunner builds a runner from hook point → command line. Command lines
+// are split on whitespace and executed without a shell. Empty commands are
+// ignored; a non-positive timeout means DefaultTimeout.
+func NewRunner(commands map[string]string, timeout time.Duration) *Runner {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	r := &Runner{commands: make(map[string][]string), timeout: timeout}
+	for hook, cmd := range commands {
+		if args := strings.Fields(cmd); len(args) > 0 {
+			r.commands[hook] = args
+		}
+	}
+	return r
+}
+
+// Enabled reports whether a command is configured for the hook point.
+func (r *Runner) Enabled(hook string) bool {
+	return r != nil && len(r.commands[hook]) > 0
+}
+
+// BeforeExpenseSave lets the hook rewrite or reject an expense. The
+// returned expense is validated again, so a hook cannot store invalid data.
+func (r *Runner) BeforeExpenseSave(ctx context.Context, e core.Expense) (core.Expense, error) {
+	if !r.Enabled(BeforeExpenseSave) {
+		return e, nil
+	}
+
+	payload := events.ExpenseFrom(e)
+	stdout, err := r.run(ctx, Input{Hook: BeforeExpenseSave, Actor: core.ActorFromContext(ctx), Expense: &payload})
+	if err != nil {
+		return e, err
+	}
+	if len(bytes.TrimSpace(stdout)) == 0 {
+		return e, nil
+	}
+
+	var out output
+	if err := json.Unmarshal(stdout, &out); err != nil {
+		return e, fmt.Errorf("hook %s: invalid output: %w", BeforeExpenseSave, err)
+	}
+	if out.Expense == nil {
+		return e, nil
+	}
+
+	updated, err := expenseFromPayload(*out.Expense)
+	if err != nil {
+		return e, fmt.Errorf("hook %s: %w", BeforeExpenseSave, err)
+	}
+	if err := updated.Validate(); err != nil {
+		return e, fmt.Errorf("hook %s returned an invalid expense: %w", BeforeExpenseSave, err)
+	}
+	return updated, nil
+}
+
+// AfterExpenseSave notifies the hook of a stored expense without waiting for it.
+func (r *Runner) AfterExpenseSave(ctx context.Context, e core.Expense, ref string) {
+	if !r.Enabled(AfterExpenseSave) {
+		return
+	}
+	payload := events.ExpenseFrom(e)
+	r.runAsync(ctx, Input{Hook: AfterExpenseSave, Actor: core.ActorFromContext(ctx), Expense: &payload, Ref: ref})
+}
+
+// AfterImport notifies the hook of a finished import without waiting for it.
+func (r *Runner) AfterImport(ctx context.Context, res ImportResult) {
+	if !r.Enabled(AfterImport) {
+		return
+	}
+	r.runAsync(ctx, Input{Hook: AfterImport, Actor: core.ActorFromContext(ctx), Import: &res})
+}
+
+// runAsync runs a notification hook detached from the request lifetime
+func (r *Runner) runAsync(ctx context.Context, in Input) {
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		if _, err := r.run(ctx, in); err != nil {
+			slog.WarnContext(ctx, "Hook failed", "hook", in.Hook, "error", err, "component", "hooks")
+		}
+	}()
+}
+
+// run executes the hook with in as stdin and returns its stdout. A non-zero
+// exit yields ErrRejected carrying the hook's stderr.
+func (r *Runner) run(ctx context.Context, in Input) ([]byte, error) {
+	args := r.commands[in.Hook]
+
+	data, err := json.Marshal(in)
+	if err != nil {
+		return nil, fmt.Errorf("hook %s: encode input: %w", in.Hook, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	stdout, stderr := limitedBuffer{max: maxOutput}, limitedBuffer{max: maxOutput}
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.WaitDelay = waitDelay
+
+	start := time.Now()
+	err = cmd.Run()
+	slog.DebugContext(ctx, "Hook executed", "hook", in.Hook, "duration", time.Since(start), "component", "hooks")
+
+	if errors.Is(err, exec.ErrWaitDelay) && ctx.Err() == nil {
+		// The hook exited successfully but left a child holding its output
+		slog.WarnContext(ctx, "Hook left a background process running", "hook", in.Hook, "component", "hooks")
+		err = nil
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("hook %s: timed out after %v", in.Hook, r.timeout)
+		}
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			reason := strings.TrimSpace(stderr.String())
+			if reason == "" {
+				reason = exitErr.Error()
+			}
+			return nil, fmt.Errorf("%w: %s", ErrRejected, reason)
+		}
+		return nil, fmt.Errorf("hook %s: %w", in.Hook, err)
+	}
+	if stdout.truncated {
+		return nil, fmt.Errorf("hook %s: output longer than %d bytes", in.Hook, maxOutput)
+	}
+	return stdout.buf.Bytes(), nil
+}
+
+// limitedBuffer keeps the first max bytes written to it and drops the
+// rest without failing the writes, so a chatty hook cannot exhaust memory.
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - b.buf.Len(); len(p) > room {
+		b.truncated = true
+		if room > 0 {
+			b.buf.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+func (b *limitedBuffer) String() string {
+	return b.buf.String()
+}
+
+func expenseFromPayload(p events.ExpensePayload) (core.Expense, error) {
+	d, err := time.Parse("2006-01-02", p.Date)
+	if err != nil {
+		return core.Expense{}, fmt.Errorf("invalid date %q", p.Date)
+	}
+	return core.Expense{
+		Date:        core.NewDate(d.Year(), int(d.Month()), d.Day()),
+		Description: strings.TrimSpace(p.Description),
+		Amount:      core.Money{Cents: p.AmountCents},
+		Primary:     strings.TrimSpace(p.Primary),
+		Secondary:   strings.TrimSpace(p.Secondary),
+		Tags:        core.NormalizeTags(p.Tags),
+	}, nil
+}
+
+// ExpenseWriter applies expense hooks around another writer. It is used
+// for backends that do not go through services.ExpenseService.
+type ExpenseWriter struct {
+	Next  sheets.ExpenseWriter
+	Hooks *Runner
+}
+
+// Append runs before_expense_save, stores the expense and fires after_expense_save.
+func (w ExpenseWriter) Append(ctx context.Context, e core.Expense) (string, error) {
+	e, err := w.Hooks.BeforeExpenseSave(ctx, e)
+	if err != nil {
+		return "", err
+	}
+	ref, err := w.Next.Append(ctx, e)
+	if err != nil {
+		return "", err
+	}
+	w.Hooks.AfterExpenseSave(ctx, e, ref)
+	return ref, nil
+}
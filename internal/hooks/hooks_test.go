@@ -0,0 +1,159 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"spese/internal/core"
+)
+
+// script writes an executable shell script and returns its path
+func script(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "hook.sh")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func testExpense() core.Expense {
+	return core.Expense{
+		Date:        core.NewDate(2025, 3, 14),
+		Description: "Netflix",
+		Amount:      core.Money{Cents: 1299},
+		Primary:     "Varie",
+		Secondary:   "Varie",
+	}
+}
+
+func TestBeforeExpenseSave_Disabled(t *testing.T) {
+	var r *Runner
+	e := testExpense()
+	got, err := r.BeforeExpenseSave(context.Background(), e)
+	if err != nil || !reflect.DeepEqual(got, e) {
+		t.Fatalf("nil runner: got %+v, %v", got, err)
+	}
+
+	r = NewRunner(map[string]string{BeforeExpenseSave: "  "}, 0)
+	if r.Enabled(BeforeExpenseSave) {
+		t.Error("blank command should be disabled")
+	}
+}
+
+func TestBeforeExpenseSave_Rewrite(t *testing.T) {
+	// Recategorize anything mentioning Netflix, echoing the rest of the input
+	hook := script(t, `sed -n 's/.*"expense":\(.*\)}$/{"expense":\1}/p' | sed 's/"primary":"Varie","secondary":"Varie"/"primary":"Svago","secondary":"Abbonamenti"/'`)
+	r := NewRunner(map[string]string{BeforeExpenseSave: hook}, time.Second)
+
+	got, err := r.BeforeExpenseSave(context.Background(), testExpense())
+	if err != nil {
+		t.Fatalf("BeforeExpenseSave: %v", err)
+	}
+	if got.Primary != "Svago" || got.Secondary != "Abbonamenti" {
+		t.Errorf("categories = %q/%q, want Svago/Abbonamenti", got.Primary, got.Secondary)
+	}
+	if got.Amount.Cents != 1299 || got.Date.Day() != 14 || got.Description != "Netflix" {
+		t.Errorf("other fields changed: %+v", got)
+	}
+}
+
+func TestBeforeExpenseSave_EmptyOutputKeepsExpense(t *testing.T) {
+	r := NewRunner(map[string]string{BeforeExpenseSave: script(t, "cat > /dev/null")}, time.Second)
+
+	e := testExpense()
+	got, err := r.BeforeExpenseSave(context.Background(), e)
+	if err != nil || !reflect.DeepEqual(got, e) {
+		t.Fatalf("got %+v, %v", got, err)
+	}
+}
+
+func TestBeforeExpenseSave_Reject(t *testing.T) {
+	r := NewRunner(map[string]string{BeforeExpenseSave: script(t, "echo 'budget exceeded' >&2; exit 1")}, time.Second)
+
+	_, err := r.BeforeExpenseSave(context.Background(), testExpense())
+	if !errors.Is(err, ErrRejected) {
+		t.Fatalf("err = %v, want ErrRejected", err)
+	}
+	if !strings.Contains(err.Error(), "budget exceeded") {
+		t.Errorf("err = %v, want stderr reason", err)
+	}
+}
+
+func TestBeforeExpenseSave_InvalidOutput(t *testing.T) {
+	r := NewRunner(map[string]string{BeforeExpenseSave: script(t, `echo '{"expense":{"date":"2025-03-14","description":"x","amount_cents":0,"primary":"A","secondary":"B"}}'`)}, time.Second)
+
+	if _, err := r.BeforeExpenseSave(context.Background(), testExpense()); err == nil || errors.Is(err, ErrRejected) {
+		t.Fatalf("err = %v, want validation error", err)
+	}
+}
+
+func TestBeforeExpenseSave_Timeout(t *testing.T) {
+	r := NewRunner(map[string]string{BeforeExpenseSave: script(t, "exec sleep 5")}, 50*time.Millisecond)
+
+	_, err := r.BeforeExpenseSave(context.Background(), testExpense())
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("err = %v, want timeout", err)
+	}
+}
+
+func TestBeforeExpenseSave_BackgroundChild(t *testing.T) {
+	// A child left running keeps the output pipes open past the hook
+	for name, tc := range map[string]struct {
+		body    string
+		timeout time.Duration
+	}{
+		"timed out": {"sleep 30 & exec sleep 30", 50 * time.Millisecond},
+		"exited":    {"sleep 30 & exit 0", 3 * time.Second},
+	} {
+		t.Run(name, func(t *testing.T) {
+			r := NewRunner(map[string]string{BeforeExpenseSave: script(t, tc.body)}, tc.timeout)
+
+			start := time.Now()
+			got, err := r.BeforeExpenseSave(context.Background(), testExpense())
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Fatalf("hook returned after %v", elapsed)
+			}
+			if name == "timed out" && (err == nil || !strings.Contains(err.Error(), "timed out")) {
+				t.Errorf("err = %v, want timeout", err)
+			}
+			if name == "exited" && (err != nil || !reflect.DeepEqual(got, testExpense())) {
+				t.Errorf("got %+v, %v, want the expense unchanged", got, err)
+			}
+		})
+	}
+}
+
+func TestBeforeExpenseSave_OutputTooLong(t *testing.T) {
+	r := NewRunner(map[string]string{BeforeExpenseSave: script(t, "head -c 2000000 /dev/zero")}, time.Second)
+
+	if _, err := r.BeforeExpenseSave(context.Background(), testExpense()); err == nil || !strings.Contains(err.Error(), "longer than") {
+		t.Fatalf("err = %v, want output too long", err)
+	}
+}
+
+func TestAfterImport_ReceivesInput(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "input.json")
+	r := NewRunner(map[string]string{AfterImport: script(t, "cat > "+out)}, time.Second)
+
+	r.AfterImport(core.WithActor(context.Background(), "alice"), ImportResult{Source: "csv", Imported: 3})
+
+	var data []byte
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if data, _ = os.ReadFile(out); len(data) > 0 {
+			break
+		}
+	}
+	got := string(data)
+	for _, want := range []string{`"hook":"after_import"`, `"actor":"alice"`, `"source":"csv"`, `"imported":3`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("input %s missing %s", got, want)
+		}
+	}
+}
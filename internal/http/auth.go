@@ -0,0 +1,273 @@
+package http
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"spese/internal/core"
+)
+
+// sessionCookie holds the signed session of a logged-in browser
+const sessionCookie = "spese_session"
+
+// AuthConfig enables the login of the single user of the web UI.
+type AuthConfig struct {
+	// bcrypt hash of the password
+	PasswordHash []byte
+	// Key signing the session cookies; instances behind the same address
+	// (replicas) need the same one. Empty uses a random key, so sessions
+	// end with the process.
+	SessionSecret []byte
+	SessionTTL    time.Duration
+	// Send the cookie over HTTPS only; off for plain-HTTP local setups
+	SecureCookie bool
+	// Paths reachable without logging in, e.g. /healthz for probes
+	PublicPaths []string
+}
+
+// auth checks passwords and session cookies; nil when login is disabled
+type auth struct {
+	passwordHash []byte
+	key          []byte // HMAC key of the session cookies
+	ttl          time.Duration
+	secure       bool
+	public       map[string]bool
+	now          func() time.Time
+}
+
+// SetAuth requires a login for every route but the public paths, the
+// login page and static assets. Routes with their own bearer token (/ws,
+// /peer/changes, approvers of /workflow) keep accepting it instead.
+func (s *Server) SetAuth(cfg AuthConfig) {
+	secret := cfg.SessionSecret
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		_, _ = rand.Read(secret)
+	}
+	// Signing with the password hash too ends every session when the
+	// password changes
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(cfg.PasswordHash)
+
+	a := &auth{
+		passwordHash: cfg.PasswordHash,
+		key:          mac.Sum(nil),
+		ttl:          cfg.SessionTTL,
+		secure:       cfg.SecureCookie,
+		public:       make(map[string]bool),
+		now:          time.Now,
+	}
+	for _, p := range cfg.PublicPaths {
+		a.public[p] = true
+	}
+	s.auth = a
+}
+
+// sign returns the signature of a session expiring at expires
+func (a *auth) sign(expires string) string {
+	mac := hmac.New(sha256.New, a.key)
+	mac.Write([]byte("session|" + expires))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// newSession returns the value of a session cookie valid for the TTL
+func (a *auth) newSession() string {
+	expires := strconv.FormatInt(a.now().Add(a.ttl).Unix(), 10)
+	return expires + "." + a.sign(expires)
+}
+
+// validSession reports whether a cookie value is a session signed by this
+// server that has not expired.
+func (a *auth) validSession(value string) bool {
+	expires, sig, ok := strings.Cut(value, ".")
+	if !ok {
+		return false
+	}
+	if !hmac.Equal([]byte(sig), []byte(a.sign(expires))) {
+		return false
+	}
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	return err == nil && a.now().Before(time.Unix(unix, 0))
+}
+
+// authenticated reports whether the request comes from a logged-in
+// browser or, for scripts, carries the password as HTTP Basic auth.
+func (a *auth) authenticated(r *http.Request) bool {
+	if c, err := r.Cookie(sessionCookie); err == nil && a.validSession(c.Value) {
+		return true
+	}
+	if _, password, ok := r.BasicAuth(); ok {
+		return bcrypt.CompareHashAndPassword(a.passwordHash, []byte(password)) == nil
+	}
+	return false
+}
+
+// exempt reports whether a path is reachable without logging in
+func (s *Server) exempt(r *http.Request) bool {
+	path := r.URL.Path
+	switch {
+	case s.auth.public[path], path == "/login", strings.HasPrefix(path, "/static/"):
+		return true
+	// Authenticated by their own bearer tokens
+	case path == "/ws", path == "/peer/changes":
+		return true
+	case strings.HasPrefix(path, "/workflow") || path == "/ui/workflow-list":
+		return s.workflowApproverToken != "" && validBearerToken(r, s.workflowApproverToken)
+	}
+	return false
+}
+
+// withAuth turns away requests without a session while login is enabled.
+// Page loads are redirected to the login page, coming back afterwards;
+// HTMX requests get a 401 telling HTMX to go there, others a plain 401.
+// It wraps the whole mux, so new routes are covered without opting in.
+//
+// A request carrying a member's API key as bearer token is let in as that
+// member, login or not, on the routes keys are meant for (see apiKeyPath);
+// elsewhere it is a 403, and an unknown key is always a 401.
+func (s *Server) withAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if key, ok := bearerAPIKey(r); ok {
+			user, err := s.authenticateAPIKey(r.Context(), key)
+			if err != nil {
+				slog.WarnContext(r.Context(), "API key rejected", "error", err, "path", r.URL.Path)
+				w.Header().Set("WWW-Authenticate", `Bearer realm="spese"`)
+				writeJSONError(w, http.StatusUnauthorized, "invalid API key")
+				return
+			}
+			if !apiKeyPath(r.URL.Path) {
+				writeJSONError(w, http.StatusForbidden, "API keys only reach the expense, income and receipt API")
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(core.WithUser(r.Context(), user)))
+			return
+		}
+		if s.auth == nil || s.exempt(r) || s.auth.authenticated(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		login := "/login?" + url.Values{"next": {r.URL.RequestURI()}}.Encode()
+		switch {
+		case r.Header.Get("HX-Request") != "":
+			w.Header().Set("HX-Redirect", "/login")
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.Method == http.MethodGet && !strings.HasPrefix(r.URL.Path, "/api/"):
+			http.Redirect(w, r, login, http.StatusSeeOther)
+		default:
+			w.Header().Set("WWW-Authenticate", `Basic realm="spese"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		}
+	})
+}
+
+// apiKeyPath reports whether path is open to API keys: the expense and
+// income JSON API, batch creation and receipt upload and download, all
+// subject to the member's quotas. Household, import, category and
+// administration routes stay with the logged-in UI.
+func apiKeyPath(path string) bool {
+	switch path {
+	case "/api/v1/expenses", "/api/v1/incomes", "/api/v1/expenses:batch",
+		"/spese/ricevuta", "/spese/ricevuta/upload":
+		return true
+	}
+	return strings.HasPrefix(path, "/api/v1/expenses/") || strings.HasPrefix(path, "/api/v1/incomes/")
+}
+
+// localPath returns next when it is a path on this site, "/" otherwise,
+// so a redirect parameter cannot send the browser elsewhere
+func localPath(next string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		return "/"
+	}
+	return next
+}
+
+// handleLogin shows the login form (GET) or checks the password and starts
+// a session (POST). Form fields: password, next.
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	if s.auth == nil {
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return
+	}
+
+	render := func(status int, next, message string) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(status)
+		data := struct{ Next, Error string }{next, message}
+		if err := s.templates.ExecuteTemplate(w, "login_page", data); err != nil {
+			slog.ErrorContext(r.Context(), "Login template execution failed", "error", err, "template", "login_page")
+		}
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		render(http.StatusOK, localPath(r.URL.Query().Get("next")), "")
+	case http.MethodPost:
+		if err := r.ParseForm(); err != nil {
+			render(http.StatusBadRequest, "/", "Formato richiesta non valido")
+			return
+		}
+		next := localPath(r.Form.Get("next"))
+		if bcrypt.CompareHashAndPassword(s.auth.passwordHash, []byte(r.Form.Get("password"))) != nil {
+			slog.WarnContext(r.Context(), "Login failed", "client_ip", extractClientIP(r))
+			render(http.StatusUnauthorized, next, "Password errata")
+			return
+		}
+
+		http.SetCookie(w, &http.Cookie{
+			Name:     sessionCookie,
+			Value:    s.auth.newSession(),
+			Path:     "/",
+			MaxAge:   int(s.auth.ttl / time.Second),
+			HttpOnly: true,
+			Secure:   s.auth.secure,
+			SameSite: http.SameSiteLaxMode,
+		})
+		slog.InfoContext(r.Context(), "Login succeeded", "client_ip", extractClientIP(r))
+		http.Redirect(w, r, next, http.StatusSeeOther)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// handleLogout ends the session of the browser
+func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	secure := s.auth != nil && s.auth.secure
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+	if r.Header.Get("HX-Request") != "" {
+		w.Header().Set("HX-Redirect", "/login")
+		return
+	}
+	http.Redirect(w, r, "/login", http.StatusSeeOther)
+}
+
+// authEnabled reports whether pages should offer a logout button
+func (s *Server) authEnabled() bool {
+	return s.auth != nil
+}
@@ -0,0 +1,42 @@
+package http
+
+import (
+	"log/slog"
+	"net/http"
+)
+
+// SetDemoMode turns the server into a read-only public demo: every request
+// that could change data is refused and pages show a banner.
+func (s *Server) SetDemoMode(enabled bool) {
+	s.demoMode = enabled
+}
+
+// withDemoReadOnly rejects mutating requests while demo mode is on. It wraps
+// the whole mux, so new routes are covered without opting in.
+func (s *Server) withDemoReadOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.demoMode && !isReadOnlyRequest(r) {
+			slog.InfoContext(r.Context(), "Mutation blocked in demo mode", "method", r.Method, "path", r.URL.Path)
+			w.Header().Set("X-Demo-Mode", "true")
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`<div class="error">Modalità demo: le modifiche sono disabilitate</div>`))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isReadOnlyRequest reports whether r cannot modify data. The WebSocket
+// endpoint is a GET but accepts writes once upgraded; amount validation is
+// a POST that stores nothing.
+func isReadOnlyRequest(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return r.URL.Path != "/ws"
+	case http.MethodPost:
+		return r.URL.Path == "/api/validate/amount"
+	default:
+		return false
+	}
+}
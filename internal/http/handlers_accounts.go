@@ -0,0 +1,415 @@
+package http
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"spese/internal/adapters"
+	"spese/internal/core"
+	"spese/internal/storage"
+)
+
+// accountTransfersShown is how many of the latest transfers the page lists
+const accountTransfersShown = 50
+
+// accountStore returns the SQLite repository holding the accounts, or
+// writes a 501 and returns false for other backends.
+func (s *Server) accountStore(w http.ResponseWriter) (*storage.SQLiteRepository, bool) {
+	adapter, ok := s.expWriter.(*adapters.SQLiteAdapter)
+	if !ok {
+		w.WriteHeader(http.StatusNotImplemented)
+		_, _ = w.Write([]byte(`<div class="error">Conti disponibili solo con il backend SQLite</div>`))
+		return nil, false
+	}
+	return adapter.GetStorage(), true
+}
+
+// accounts returns the accounts, none when the backend has no accounts.
+// Forms only offer an account when some exist.
+func (s *Server) accounts(ctx context.Context) []core.Account {
+	adapter, ok := s.expWriter.(*adapters.SQLiteAdapter)
+	if !ok {
+		return nil
+	}
+	accounts, err := adapter.GetStorage().ListAccounts(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to load accounts", "error", err)
+		return nil
+	}
+	return accounts
+}
+
+// parseAccountID reads the account_id form field: the account an expense
+// or income moved, 0 when empty. ok is false, with a 422 written, for
+// anything else.
+func parseAccountID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	v := strings.TrimSpace(r.Form.Get("account_id"))
+	if v == "" {
+		return 0, true
+	}
+	id, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || id <= 0 {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`<div class="error">Conto non valido</div>`))
+		return 0, false
+	}
+	return id, true
+}
+
+type accountView struct {
+	ID           int64
+	Name         string
+	Kind         string
+	Opening      string
+	Incomes      string
+	Expenses     string
+	TransfersIn  string
+	TransfersOut string
+	Balance      string
+	Negative     bool
+}
+
+type accountTransferView struct {
+	ID          int64
+	Date        string
+	From        string
+	To          string
+	Amount      string
+	Description string
+}
+
+type accountKindOption struct {
+	Value string
+	Label string
+}
+
+type accountsView struct {
+	Accounts  []accountView
+	NetWorth  string
+	Transfers []accountTransferView
+}
+
+// loadAccounts builds the balances of the accounts and the latest
+// transfers between them.
+func loadAccounts(ctx context.Context, store *storage.SQLiteRepository) (accountsView, error) {
+	balances, err := store.AccountBalances(ctx)
+	if err != nil {
+		return accountsView{}, err
+	}
+	transfers, err := store.ListAccountTransfers(ctx, accountTransfersShown)
+	if err != nil {
+		return accountsView{}, err
+	}
+
+	view := accountsView{NetWorth: formatEuros(core.NetWorth(balances).Cents)}
+	names := make(map[int64]string, len(balances))
+	for _, b := range balances {
+		names[b.ID] = b.Name
+		balance := b.Balance()
+		view.Accounts = append(view.Accounts, accountView{
+			ID:           b.ID,
+			Name:         b.Name,
+			Kind:         b.Kind.Label(),
+			Opening:      formatEuros(b.OpeningBalance.Cents),
+			Incomes:      formatEuros(b.Incomes.Cents),
+			Expenses:     formatEuros(b.Expenses.Cents),
+			TransfersIn:  formatEuros(b.TransfersIn.Cents),
+			TransfersOut: formatEuros(b.TransfersOut.Cents),
+			Balance:      formatEuros(balance.Cents),
+			Negative:     balance.Cents < 0,
+		})
+	}
+	for _, t := range transfers {
+		view.Transfers = append(view.Transfers, accountTransferView{
+			ID:          t.ID,
+			Date:        t.Date.Format("02/01/2006"),
+			From:        names[t.From],
+			To:          names[t.To],
+			Amount:      formatEuros(t.Amount.Cents),
+			Description: t.Description,
+		})
+	}
+	return view, nil
+}
+
+// handleAccounts renders the accounts page: balances, net worth, the
+// forms adding an account and moving money between two, and the latest
+// transfers.
+func (s *Server) handleAccounts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	store, ok := s.accountStore(w)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	view, err := loadAccounts(ctx, store)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to load accounts", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento dei conti</div>`))
+		return
+	}
+
+	kinds := make([]accountKindOption, len(core.AccountKinds))
+	for i, k := range core.AccountKinds {
+		kinds[i] = accountKindOption{Value: string(k), Label: k.Label()}
+	}
+	data := struct {
+		accountsView
+		Kinds []accountKindOption
+		Today string
+	}{
+		accountsView: view,
+		Kinds:        kinds,
+		Today:        time.Now().Format("2006-01-02"),
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "accounts_page", data); err != nil {
+		slog.ErrorContext(r.Context(), "Accounts template execution failed", "error", err, "template", "accounts_page")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handleAccountList renders the balances and transfers, refreshed after
+// every change
+func (s *Server) handleAccountList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	store, ok := s.accountStore(w)
+	if !ok {
+		return
+	}
+
+	view, err := loadAccounts(r.Context(), store)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to load accounts", "error", err)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento dei conti</div>`))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "account_list", view); err != nil {
+		slog.ErrorContext(r.Context(), "Template execution failed", "error", err, "template", "account_list")
+	}
+}
+
+// handleCreateAccount adds an account. Form fields: name, kind,
+// opening_balance (may be negative, empty for zero).
+func (s *Server) handleCreateAccount(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	store, ok := s.accountStore(w)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+
+	var opening int64
+	if v := strings.TrimSpace(r.Form.Get("opening_balance")); v != "" {
+		cents, err := core.ParseSignedDecimalToCents(v)
+		if err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_, _ = w.Write([]byte(`<div class="error">Saldo iniziale non valido</div>`))
+			return
+		}
+		opening = cents
+	}
+
+	account, err := store.CreateAccount(r.Context(), core.Account{
+		Name:           sanitizeInput(r.Form.Get("name")),
+		Kind:           core.AccountKind(r.Form.Get("kind")),
+		OpeningBalance: core.Money{Cents: opening},
+	})
+	if errors.Is(err, core.ErrInvalidAccount) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`<div class="error">Conto non valido: serve un tipo e un nome di al massimo ` + strconv.Itoa(core.MaxAccountNameLength) + ` caratteri</div>`))
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to create account", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel salvataggio (il nome è già usato?)</div>`))
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Account created", "account_id", account.ID, "kind", account.Kind)
+	w.Header().Set("HX-Trigger", `{"accounts:changed": {}}`)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(`<div class="success">Conto aggiunto</div>`))
+}
+
+// handleDeleteAccount removes an account and its transfers, keeping its
+// expenses and incomes with no account. Form fields: id.
+func (s *Server) handleDeleteAccount(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	store, ok := s.accountStore(w)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+	id, ok := parseFormID(w, r, "id", "ID conto non valido")
+	if !ok {
+		return
+	}
+
+	err := store.DeleteAccount(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`<div class="error">Conto non trovato</div>`))
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to delete account", "error", err, "account_id", id)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nella rimozione del conto</div>`))
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Account deleted", "account_id", id)
+	w.Header().Set("HX-Trigger", `{"accounts:changed": {}}`)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(`<div class="success">Conto rimosso</div>`))
+}
+
+// handleCreateAccountTransfer moves money between two accounts. Form
+// fields: from, to, amount, date (YYYY-MM-DD, today when empty),
+// description.
+func (s *Server) handleCreateAccountTransfer(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	store, ok := s.accountStore(w)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+	from, ok := parseFormID(w, r, "from", "Conto di partenza non valido")
+	if !ok {
+		return
+	}
+	to, ok := parseFormID(w, r, "to", "Conto di arrivo non valido")
+	if !ok {
+		return
+	}
+	cents, err := core.ParseDecimalToCents(r.Form.Get("amount"))
+	if err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`<div class="error">Importo non valido</div>`))
+		return
+	}
+	date := core.Date{Time: time.Now()}
+	if v := strings.TrimSpace(r.Form.Get("date")); v != "" {
+		if date, err = parseDate(v); err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_, _ = w.Write([]byte(`<div class="error">Data non valida</div>`))
+			return
+		}
+	}
+
+	transfer, err := store.CreateAccountTransfer(r.Context(), core.AccountTransfer{
+		Date:        date,
+		From:        from,
+		To:          to,
+		Amount:      core.Money{Cents: cents},
+		Description: sanitizeInput(r.Form.Get("description")),
+	})
+	if errors.Is(err, core.ErrInvalidTransfer) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`<div class="error">Trasferimento non valido: ` + template.HTMLEscapeString(err.Error()) + `</div>`))
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to create account transfer", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel salvataggio del trasferimento</div>`))
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Account transfer created", "transfer_id", transfer.ID, "from", from, "to", to, "amount_cents", cents)
+	w.Header().Set("HX-Trigger", `{"accounts:changed": {}}`)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(`<div class="success">Trasferimento registrato</div>`))
+}
+
+// handleDeleteAccountTransfer removes a transfer. Form fields: id.
+func (s *Server) handleDeleteAccountTransfer(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	store, ok := s.accountStore(w)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+	id, ok := parseFormID(w, r, "id", "ID trasferimento non valido")
+	if !ok {
+		return
+	}
+
+	err := store.DeleteAccountTransfer(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`<div class="error">Trasferimento non trovato</div>`))
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to delete account transfer", "error", err, "transfer_id", id)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nella rimozione del trasferimento</div>`))
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Account transfer deleted", "transfer_id", id)
+	w.Header().Set("HX-Trigger", `{"accounts:changed": {}}`)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(`<div class="success">Trasferimento eliminato</div>`))
+}
@@ -0,0 +1,237 @@
+package http
+
+import (
+	"context"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"spese/internal/adapters"
+	"spese/internal/core"
+	"spese/internal/storage"
+)
+
+// alertTypeLabels name the alert types on the preferences page
+var alertTypeLabels = map[core.AlertType]string{
+	core.AlertBudget:    "Budget raggiunto",
+	core.AlertSpending:  "Andamento della spesa",
+	core.AlertReturnDue: "Scadenza dei resi",
+	core.AlertSync:      "Errori di sincronizzazione",
+	core.AlertView:      "Viste salvate",
+}
+
+type alertTypeOption struct {
+	Type   string
+	Label  string
+	Scoped bool
+}
+
+type alertPreferenceRow struct {
+	Type  string
+	Label string
+	Scope string
+	State string
+}
+
+// alertStore returns the SQLite repository holding alert preferences,
+// writing a 501 and returning false for other backends.
+func (s *Server) alertStore(w http.ResponseWriter) (*storage.SQLiteRepository, bool) {
+	adapter, ok := s.expWriter.(*adapters.SQLiteAdapter)
+	if !ok {
+		w.WriteHeader(http.StatusNotImplemented)
+		_, _ = w.Write([]byte(`<div class="error">Preferenze degli avvisi disponibili solo con il backend SQLite</div>`))
+		return nil, false
+	}
+	return adapter.GetStorage(), true
+}
+
+// loadAlertPreferences returns the preferences of the user for the table
+func loadAlertPreferences(ctx context.Context, store *storage.SQLiteRepository, now time.Time) ([]alertPreferenceRow, error) {
+	prefs, err := store.ListAlertPreferences(ctx, core.DefaultAlertUser)
+	if err != nil {
+		return nil, err
+	}
+	rows := make([]alertPreferenceRow, len(prefs))
+	for i, p := range prefs {
+		row := alertPreferenceRow{Type: string(p.Type), Label: alertTypeLabels[p.Type], Scope: p.Scope}
+		switch {
+		case p.Muted:
+			row.State = "Silenziati"
+		case now.Before(p.SnoozedUntil):
+			row.State = "Sospesi fino al " + p.SnoozedUntil.Local().Format("02/01/2006 15:04")
+		default:
+			row.State = "Sospensione finita"
+		}
+		rows[i] = row
+	}
+	return rows, nil
+}
+
+// handleAlerts renders the alert preferences page
+func (s *Server) handleAlerts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.alertStore(w)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	rows, err := loadAlertPreferences(ctx, store, time.Now())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list alert preferences", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento delle preferenze</div>`))
+		return
+	}
+
+	cats, _, err := s.taxReader.List(ctx)
+	if err != nil {
+		// Suggestions only: the page works without them
+		slog.WarnContext(r.Context(), "Failed to load categories for alerts page", "error", err)
+	}
+
+	data := struct {
+		Types       []alertTypeOption
+		Categories  []string
+		Preferences []alertPreferenceRow
+	}{Categories: cats, Preferences: rows}
+	for _, t := range core.AlertTypes {
+		data.Types = append(data.Types, alertTypeOption{Type: string(t), Label: alertTypeLabels[t], Scoped: t.Scoped()})
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "alerts_page", data); err != nil {
+		slog.ErrorContext(r.Context(), "Alerts template execution failed", "error", err, "template", "alerts_page")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handleAlertsList renders the preferences table, refreshed after every
+// change
+func (s *Server) handleAlertsList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.alertStore(w)
+	if !ok {
+		return
+	}
+
+	rows, err := loadAlertPreferences(r.Context(), store, time.Now())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list alert preferences", "error", err)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento delle preferenze</div>`))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "alerts_list", rows); err != nil {
+		slog.ErrorContext(r.Context(), "Template execution failed", "error", err, "template", "alerts_list")
+	}
+}
+
+// handleSetAlertPreference mutes an alert type, or snoozes it for some
+// days. Form fields: type, scope (a primary category, empty for all),
+// action (mute or snooze), days.
+func (s *Server) handleSetAlertPreference(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.alertStore(w)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+
+	pref := core.AlertPreference{
+		User:  core.DefaultAlertUser,
+		Type:  core.AlertType(strings.TrimSpace(r.Form.Get("type"))),
+		Scope: sanitizeInput(r.Form.Get("scope")),
+	}
+	message := "Avvisi silenziati"
+	switch r.Form.Get("action") {
+	case "mute":
+		pref.Muted = true
+	case "snooze":
+		days, err := strconv.Atoi(strings.TrimSpace(r.Form.Get("days")))
+		snooze := time.Duration(days) * 24 * time.Hour
+		if err != nil || days <= 0 || snooze > core.MaxAlertSnooze {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_, _ = w.Write([]byte(`<div class="error">Durata della sospensione non valida</div>`))
+			return
+		}
+		pref.SnoozedUntil = time.Now().Add(snooze)
+		message = "Avvisi sospesi fino al " + pref.SnoozedUntil.Format("02/01/2006 15:04")
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Azione non valida</div>`))
+		return
+	}
+
+	if err := store.SetAlertPreference(r.Context(), pref); err != nil {
+		slog.WarnContext(r.Context(), "Failed to set alert preference", "error", err, "type", pref.Type, "scope", pref.Scope)
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`<div class="error">Preferenza non valida: ` + template.HTMLEscapeString(err.Error()) + `</div>`))
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Alert preference set", "type", pref.Type, "scope", pref.Scope, "muted", pref.Muted)
+	w.Header().Set("HX-Trigger", `{"alerts:changed": {}}`)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(`<div class="success">` + message + `</div>`))
+}
+
+// handleDeleteAlertPreference lets the alerts of a type through again.
+// Form fields: type, scope.
+func (s *Server) handleDeleteAlertPreference(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.alertStore(w)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+	alertType := core.AlertType(strings.TrimSpace(r.Form.Get("type")))
+	scope := sanitizeInput(r.Form.Get("scope"))
+
+	if err := store.DeleteAlertPreference(r.Context(), core.DefaultAlertUser, alertType, scope); err != nil {
+		slog.WarnContext(r.Context(), "Failed to delete alert preference", "error", err, "type", alertType, "scope", scope)
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`<div class="error">Preferenza non trovata</div>`))
+		return
+	}
+
+	w.Header().Set("HX-Trigger", `{"alerts:changed": {}}`)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(`<div class="success">Avvisi riattivati</div>`))
+}
@@ -0,0 +1,44 @@
+package http
+
+import (
+	"net/http"
+	"strings"
+
+	"spese/internal/core"
+)
+
+// amountValidation is the answer of POST /api/validate/amount
+type amountValidation struct {
+	Valid     bool   `json:"valid"`
+	Cents     int64  `json:"cents,omitempty"`
+	Formatted string `json:"formatted,omitempty"` // e.g. €1234,56
+	Error     string `json:"error,omitempty"`
+}
+
+// handleValidateAmount reads the amount form field the way expense,
+// income and recurrent forms are parsed on submit, so the forms can show
+// what will be stored while the user types. Unparseable amounts answer
+// 200 with valid false; only a malformed body is a 400.
+func (s *Server) handleValidateAmount(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid form body")
+		return
+	}
+
+	amount := strings.TrimSpace(r.Form.Get("amount"))
+	if amount == "" {
+		writeJSON(w, http.StatusOK, amountValidation{Error: "Inserisci un importo"})
+		return
+	}
+	cents, err := core.ParseDecimalToCents(amount)
+	if err != nil {
+		writeJSON(w, http.StatusOK, amountValidation{Error: "Importo non valido: usa ad esempio 12,50 o 1.234,56"})
+		return
+	}
+	writeJSON(w, http.StatusOK, amountValidation{Valid: true, Cents: cents, Formatted: formatEuros(cents)})
+}
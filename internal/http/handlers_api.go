@@ -0,0 +1,1394 @@
+package http
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"spese/internal/adapters"
+	"spese/internal/core"
+	"spese/internal/events"
+	"spese/internal/hooks"
+	ports "spese/internal/sheets"
+	"spese/internal/storage"
+)
+
+// REST API limits
+const (
+	apiDefaultLimit = 50
+	apiMaxLimit     = 500
+	apiMaxBody      = 64 << 10
+)
+
+type apiExpense struct {
+	ID          string   `json:"id"`
+	Date        string   `json:"date"` // YYYY-MM-DD
+	Description string   `json:"description"`
+	AmountCents int64    `json:"amount_cents"`
+	Primary     string   `json:"primary"`
+	Secondary   string   `json:"secondary"`
+	Status      string   `json:"status"` // cleared or pending
+	Tags        []string `json:"tags,omitempty"`
+	// pending, synced or error; only when reading one expense on SQLite
+	SyncStatus string `json:"sync_status,omitempty"`
+}
+
+type apiIncome struct {
+	ID          string   `json:"id"`
+	Date        string   `json:"date"` // YYYY-MM-DD
+	Description string   `json:"description"`
+	AmountCents int64    `json:"amount_cents"`
+	Category    string   `json:"category"`
+	Subcategory string   `json:"subcategory,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+type apiRecurrent struct {
+	ID          int64  `json:"id"`
+	StartDate   string `json:"start_date"`         // YYYY-MM-DD
+	EndDate     string `json:"end_date,omitempty"` // YYYY-MM-DD, empty when indefinite
+	Every       string `json:"every"`
+	Interval    int    `json:"interval"`               // units between occurrences
+	DayOfMonth  int    `json:"day_of_month,omitempty"` // anchor day, 0 when it follows start_date
+	Description string `json:"description"`
+	AmountCents int64  `json:"amount_cents"`
+	Primary     string `json:"primary"`
+	Secondary   string `json:"secondary"`
+	Version     int64  `json:"version"`
+	Paused      bool   `json:"paused"`
+}
+
+// apiUpcomingCharge is an expense a recurrent expense will create
+type apiUpcomingCharge struct {
+	RecurrentID int64  `json:"recurrent_id"`
+	Date        string `json:"date"` // YYYY-MM-DD
+	Description string `json:"description"`
+	AmountCents int64  `json:"amount_cents"`
+	Primary     string `json:"primary"`
+	Secondary   string `json:"secondary"`
+}
+
+// apiPage is a page of a list: Total counts the items matching the
+// filters, before pagination.
+type apiPage[T any] struct {
+	Items  []T `json:"items"`
+	Total  int `json:"total"`
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
+
+type apiAmount struct {
+	Name        string `json:"name"`
+	AmountCents int64  `json:"amount_cents"`
+}
+
+type apiOverview struct {
+	Year             int         `json:"year"`
+	Month            int         `json:"month"`
+	TotalCents       int64       `json:"total_cents"`
+	ByCategory       []apiAmount `json:"by_category"`
+	IncomeTotalCents *int64      `json:"income_total_cents,omitempty"` // SQLite backend only
+	IncomeByCategory []apiAmount `json:"income_by_category,omitempty"`
+	BalanceCents     *int64      `json:"balance_cents,omitempty"`
+}
+
+type apiCategories struct {
+	Expense []storage.CategoryWithSubs `json:"expense"`
+	Income  []string                   `json:"income,omitempty"` // SQLite backend only
+}
+
+// apiSyncError is a failed attempt to write an expense to Google Sheets
+type apiSyncError struct {
+	ID        int64  `json:"id"`
+	ExpenseID int64  `json:"expense_id"`
+	Operation string `json:"operation"` // sync or delete
+	Attempt   int64  `json:"attempt"`
+	Class     string `json:"class"` // quota, auth, not_found, validation or transient
+	Message   string `json:"message"`
+	At        string `json:"at"` // RFC 3339
+}
+
+type apiSyncErrors struct {
+	Counts map[string]int64 `json:"counts"` // By class, over the retention period
+	Errors []apiSyncError   `json:"errors"`
+}
+
+// apiDeadLetter is a sync item that gave up retrying
+type apiDeadLetter struct {
+	ID          int64  `json:"id"`
+	ExpenseID   int64  `json:"expense_id"`
+	Operation   string `json:"operation"` // sync or delete
+	Date        string `json:"date,omitempty"`
+	Description string `json:"description"`
+	AmountCents int64  `json:"amount_cents"`
+	Attempts    int64  `json:"attempts"`
+	LastError   string `json:"last_error"`
+	FailedAt    string `json:"failed_at"` // RFC 3339
+}
+
+// categoryInput is the body of category changes. PATCH applies parent,
+// then name, then archived, each only when set.
+type categoryInput struct {
+	Primary   string `json:"primary"`
+	Secondary string `json:"secondary,omitempty"` // Empty for a primary category
+	Name      string `json:"name,omitempty"`      // New name
+	Parent    string `json:"parent,omitempty"`    // New primary of a secondary category
+	Archived  *bool  `json:"archived,omitempty"`  // Archive or restore
+}
+
+// apiCategoryMapping files a secondary category of Google Sheets under a
+// primary category; it is also the body of PUT /api/v1/category-mappings
+type apiCategoryMapping struct {
+	SheetName string `json:"sheet_name"`
+	Primary   string `json:"primary"`
+}
+
+type apiCategoryMappings struct {
+	Items []apiCategoryMapping `json:"items"`
+}
+
+type apiTag struct {
+	Name string `json:"name"`
+	Uses int64  `json:"uses"` // Expenses and incomes carrying the tag
+}
+
+type apiTags struct {
+	Items []apiTag `json:"items"`
+}
+
+type apiTagMonth struct {
+	Month         string `json:"month"` // YYYY-MM
+	SpentCents    int64  `json:"spent_cents"`
+	ReceivedCents int64  `json:"received_cents"`
+	Entries       int64  `json:"entries"`
+}
+
+type apiTagSummary struct {
+	Tag           string        `json:"tag"`
+	SpentCents    int64         `json:"spent_cents"`
+	ReceivedCents int64         `json:"received_cents"`
+	Entries       int64         `json:"entries"`
+	Months        []apiTagMonth `json:"months"`
+}
+
+// apiTagReport is the spending per tag of a period; From and To are months
+// as YYYY-MM, both included
+type apiTagReport struct {
+	From string          `json:"from"`
+	To   string          `json:"to"`
+	Tags []apiTagSummary `json:"tags"`
+}
+
+type incomeInput struct {
+	Date        string   `json:"date"` // YYYY-MM-DD, defaults to today
+	Description string   `json:"description"`
+	Amount      string   `json:"amount"` // decimal euros, e.g. "1500.00"
+	Category    string   `json:"category"`
+	Subcategory string   `json:"subcategory,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+// income parses and validates the input; a missing date means today.
+func (in incomeInput) income() (core.Income, error) {
+	date := core.Date{Time: time.Now()}
+	if in.Date != "" {
+		d, err := parseDate(in.Date)
+		if err != nil {
+			return core.Income{}, errors.New("invalid date")
+		}
+		date = d
+	}
+
+	cents, err := core.ParseDecimalToCents(strings.TrimSpace(in.Amount))
+	if err != nil {
+		return core.Income{}, errors.New("invalid amount")
+	}
+
+	inc := core.Income{
+		Date:        core.NewDate(date.Year(), int(date.Month()), date.Day()),
+		Description: sanitizeInput(in.Description),
+		Amount:      core.Money{Cents: cents},
+		Category:    sanitizeInput(in.Category),
+		Subcategory: sanitizeInput(in.Subcategory),
+		Tags:        core.NormalizeTags(in.Tags),
+	}
+	if err := inc.Validate(); err != nil {
+		return core.Income{}, errors.New("invalid data: " + err.Error())
+	}
+	return inc, nil
+}
+
+type recurrentInput struct {
+	StartDate   string `json:"start_date"`         // YYYY-MM-DD
+	EndDate     string `json:"end_date,omitempty"` // YYYY-MM-DD, empty for indefinite
+	Every       string `json:"every"`              // daily, weekly, monthly or yearly
+	Interval    int    `json:"interval,omitempty"` // every N units, default 1
+	DayOfMonth  int    `json:"day_of_month,omitempty"`
+	Description string `json:"description"`
+	Amount      string `json:"amount"` // decimal euros
+	Primary     string `json:"primary"`
+	Secondary   string `json:"secondary"`
+}
+
+// recurrent parses and validates the input.
+func (in recurrentInput) recurrent() (core.RecurrentExpenses, error) {
+	start, err := parseDate(strings.TrimSpace(in.StartDate))
+	if err != nil {
+		return core.RecurrentExpenses{}, errors.New("invalid start_date")
+	}
+	var end core.Date
+	if in.EndDate != "" {
+		if end, err = parseDate(strings.TrimSpace(in.EndDate)); err != nil {
+			return core.RecurrentExpenses{}, errors.New("invalid end_date")
+		}
+	}
+
+	cents, err := core.ParseDecimalToCents(strings.TrimSpace(in.Amount))
+	if err != nil {
+		return core.RecurrentExpenses{}, errors.New("invalid amount")
+	}
+
+	re := core.RecurrentExpenses{
+		StartDate:   start,
+		EndDate:     end,
+		Every:       core.RepetitionTypes(strings.ToLower(strings.TrimSpace(in.Every))),
+		Interval:    in.Interval,
+		DayOfMonth:  in.DayOfMonth,
+		Description: sanitizeInput(in.Description),
+		Amount:      core.Money{Cents: cents},
+		Primary:     sanitizeInput(in.Primary),
+		Secondary:   sanitizeInput(in.Secondary),
+	}
+	if err := re.Validate(); err != nil {
+		return core.RecurrentExpenses{}, errors.New("invalid data: " + err.Error())
+	}
+	return re, nil
+}
+
+func newAPIExpense(id string, e core.Expense) apiExpense {
+	status := string(e.Status)
+	if status == "" {
+		status = string(core.StatusCleared)
+	}
+	return apiExpense{
+		ID:          id,
+		Date:        e.Date.Format("2006-01-02"),
+		Description: e.Description,
+		AmountCents: e.Amount.Cents,
+		Primary:     e.Primary,
+		Secondary:   e.Secondary,
+		Status:      status,
+		Tags:        e.Tags,
+	}
+}
+
+func newAPIIncome(id string, i core.Income) apiIncome {
+	return apiIncome{
+		ID:          id,
+		Date:        i.Date.Format("2006-01-02"),
+		Description: i.Description,
+		AmountCents: i.Amount.Cents,
+		Category:    i.Category,
+		Subcategory: i.Subcategory,
+		Tags:        i.Tags,
+	}
+}
+
+func newAPIRecurrent(re core.RecurrentExpenses) apiRecurrent {
+	out := apiRecurrent{
+		ID:          re.ID,
+		StartDate:   re.StartDate.Format("2006-01-02"),
+		Every:       string(re.Every),
+		Interval:    re.Step(),
+		DayOfMonth:  re.DayOfMonth,
+		Description: re.Description,
+		AmountCents: re.Amount.Cents,
+		Primary:     re.Primary,
+		Secondary:   re.Secondary,
+		Version:     re.Version,
+		Paused:      re.Paused,
+	}
+	if !re.EndDate.IsZero() {
+		out.EndDate = re.EndDate.Format("2006-01-02")
+	}
+	return out
+}
+
+// apiPeriod reads the year and month query parameters. Without both the
+// current month is used; a year alone selects the whole year, returned as
+// month 0.
+func apiPeriod(r *http.Request, now time.Time) (year, month int, err error) {
+	q := r.URL.Query()
+	yearStr, monthStr := strings.TrimSpace(q.Get("year")), strings.TrimSpace(q.Get("month"))
+	if yearStr == "" && monthStr == "" {
+		return now.Year(), int(now.Month()), nil
+	}
+
+	year = now.Year()
+	if yearStr != "" {
+		if year, err = strconv.Atoi(yearStr); err != nil || year < 1900 || year > 9999 {
+			return 0, 0, errors.New("invalid year")
+		}
+	}
+	if monthStr != "" {
+		if month, err = strconv.Atoi(monthStr); err != nil || month < 1 || month > 12 {
+			return 0, 0, errors.New("invalid month")
+		}
+	}
+	return year, month, nil
+}
+
+// apiPagination reads the limit and offset query parameters
+func apiPagination(r *http.Request) (limit, offset int, err error) {
+	q := r.URL.Query()
+	limit = apiDefaultLimit
+	if v := strings.TrimSpace(q.Get("limit")); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit < 1 || limit > apiMaxLimit {
+			return 0, 0, fmt.Errorf("invalid limit: must be between 1 and %d", apiMaxLimit)
+		}
+	}
+	if v := strings.TrimSpace(q.Get("offset")); v != "" {
+		if offset, err = strconv.Atoi(v); err != nil || offset < 0 {
+			return 0, 0, errors.New("invalid offset")
+		}
+	}
+	return limit, offset, nil
+}
+
+// newAPIPage returns the page of items selected by limit and offset
+func newAPIPage[T any](items []T, limit, offset int) apiPage[T] {
+	page := apiPage[T]{Items: []T{}, Total: len(items), Limit: limit, Offset: offset}
+	if offset < len(items) {
+		page.Items = items[offset:min(offset+limit, len(items))]
+	}
+	return page
+}
+
+// matchesText reports whether the query text q is empty or found in s,
+// ignoring case
+func matchesText(s, q string) bool {
+	return q == "" || strings.Contains(strings.ToLower(s), strings.ToLower(q))
+}
+
+// apiItemID returns the ID in a /api/v1/<collection>/<id> path, or "" for
+// the collection itself
+func apiItemID(r *http.Request, collection string) string {
+	return strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/"+collection), "/")
+}
+
+// apiStore returns the SQLite repository backing the endpoints that only
+// SQLite provides, writing a 501 and returning false for other backends.
+func (s *Server) apiStore(w http.ResponseWriter, what string) (*storage.SQLiteRepository, bool) {
+	adapter, ok := s.expWriter.(*adapters.SQLiteAdapter)
+	if !ok {
+		writeJSONError(w, http.StatusNotImplemented, what+" not supported by this backend")
+		return nil, false
+	}
+	return adapter.GetStorage(), true
+}
+
+// decodeAPIBody decodes a JSON request body into v, writing a 400 on
+// failure.
+func decodeAPIBody(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, apiMaxBody)).Decode(v); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid JSON body")
+		return false
+	}
+	return true
+}
+
+// handleAPIExpenses serves /api/v1/expenses (GET lists, POST creates) and
+// /api/v1/expenses/{id} (GET, DELETE).
+func (s *Server) handleAPIExpenses(w http.ResponseWriter, r *http.Request) {
+	if id := apiItemID(r, "expenses"); id != "" {
+		switch r.Method {
+		case http.MethodGet:
+			s.apiGetExpense(w, r, id)
+		case http.MethodDelete:
+			s.apiDeleteExpense(w, r, id)
+		default:
+			w.Header().Set("Allow", "GET, DELETE")
+			writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		s.apiListExpenses(w, r)
+	case http.MethodPost:
+		s.apiCreateExpense(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// apiListExpenses lists the expenses of a month, or of a whole year,
+// newest first. Filters: primary, secondary, status, q (text in the
+// description).
+func (s *Server) apiListExpenses(w http.ResponseWriter, r *http.Request) {
+	if s.expListerWithID == nil {
+		writeJSONError(w, http.StatusNotImplemented, "listing expenses not supported by this backend")
+		return
+	}
+	year, month, err := apiPeriod(r, time.Now())
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	limit, offset, err := apiPagination(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	q := r.URL.Query()
+	primary, secondary, text := strings.TrimSpace(q.Get("primary")), strings.TrimSpace(q.Get("secondary")), strings.TrimSpace(q.Get("q"))
+	status := strings.ToLower(strings.TrimSpace(q.Get("status")))
+
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+
+	months := []int{month}
+	if month == 0 {
+		months = []int{12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1}
+	}
+	var items []apiExpense
+	for _, m := range months {
+		expenses, err := s.expListerWithID.ListExpensesWithID(ctx, year, m)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Failed to list expenses", "error", err, "year", year, "month", m, "component", "expense_api")
+			writeJSONError(w, http.StatusInternalServerError, "error listing expenses")
+			return
+		}
+		for _, e := range expenses {
+			item := newAPIExpense(e.ID, e.Expense)
+			if (primary != "" && !strings.EqualFold(item.Primary, primary)) ||
+				(secondary != "" && !strings.EqualFold(item.Secondary, secondary)) ||
+				(status != "" && item.Status != status) ||
+				!matchesText(item.Description, text) {
+				continue
+			}
+			items = append(items, item)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, newAPIPage(items, limit, offset))
+}
+
+// apiCreateExpense creates an expense from an expenseInput body
+func (s *Server) apiCreateExpense(w http.ResponseWriter, r *http.Request) {
+	var in expenseInput
+	if !decodeAPIBody(w, r, &in) {
+		return
+	}
+	exp, err := in.expense()
+	if err != nil {
+		s.countEntry("expense", "create", outcomeValidationError, 1)
+		writeJSONError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	ref, err := s.expWriter.Append(r.Context(), exp)
+	s.countEntryErr("expense", "create", err)
+	switch {
+	case errors.Is(err, hooks.ErrRejected):
+		writeJSONError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	case errors.Is(err, core.ErrMonthClosed):
+		writeJSONError(w, http.StatusConflict, err.Error())
+		return
+	case errors.Is(err, core.ErrQuotaExceeded):
+		writeQuotaExceeded(w, r, err)
+		return
+	case err != nil:
+		slog.ErrorContext(r.Context(), "Failed to save expense", "error", err, "component", "expense_api", "operation", "append")
+		writeJSONError(w, http.StatusInternalServerError, "error saving expense")
+		return
+	}
+
+	s.events.Publish(events.ExpenseCreated, events.ExpenseFrom(exp))
+
+	w.Header().Set("Location", "/api/v1/expenses/"+ref)
+	writeJSON(w, http.StatusCreated, newAPIExpense(ref, exp))
+}
+
+// apiGetExpense returns one expense (SQLite backend)
+func (s *Server) apiGetExpense(w http.ResponseWriter, r *http.Request, id string) {
+	store, ok := s.apiStore(w, "reading an expense")
+	if !ok {
+		return
+	}
+	expenseID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid expense id")
+		return
+	}
+
+	row, err := store.GetExpense(r.Context(), expenseID)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeJSONError(w, http.StatusNotFound, "expense not found")
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to get expense", "error", err, "expense_id", expenseID, "component", "expense_api")
+		writeJSONError(w, http.StatusInternalServerError, "error reading expense")
+		return
+	}
+	tags, err := store.ExpenseTags(r.Context(), expenseID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to get expense tags", "error", err, "expense_id", expenseID, "component", "expense_api")
+		writeJSONError(w, http.StatusInternalServerError, "error reading expense")
+		return
+	}
+
+	out := newAPIExpense(id, core.Expense{
+		Date:        core.Date{Time: row.Date},
+		Description: row.Description,
+		Amount:      core.Money{Cents: row.AmountCents},
+		Primary:     row.PrimaryCategory,
+		Secondary:   row.SecondaryCategory,
+		Status:      core.ExpenseStatus(row.Status),
+		Tags:        tags,
+	})
+	out.SyncStatus = row.SyncStatus.String
+	writeJSON(w, http.StatusOK, out)
+}
+
+// apiDeleteExpense deletes an expense. On SQLite it goes to the trash,
+// unless purge=true deletes it for good and from Google Sheets at once.
+func (s *Server) apiDeleteExpense(w http.ResponseWriter, r *http.Request, id string) {
+	if s.expDeleter == nil {
+		writeJSONError(w, http.StatusNotImplemented, "deleting expenses not supported by this backend")
+		return
+	}
+
+	var err error
+	adapter, sqlite := s.expWriter.(*adapters.SQLiteAdapter)
+	if sqlite && r.URL.Query().Get("purge") == "true" {
+		expenseID, parseErr := strconv.ParseInt(id, 10, 64)
+		if parseErr != nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid expense id")
+			return
+		}
+		err = adapter.GetStorage().HardDeleteAndEnqueueSync(r.Context(), expenseID)
+	} else {
+		err = s.expDeleter.DeleteExpense(r.Context(), id)
+	}
+	s.countEntryErr("expense", "delete", err)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		writeJSONError(w, http.StatusNotFound, "expense not found")
+		return
+	case errors.Is(err, core.ErrMonthClosed):
+		writeJSONError(w, http.StatusConflict, err.Error())
+		return
+	case err != nil:
+		slog.ErrorContext(r.Context(), "Failed to delete expense", "error", err, "expense_id", id, "component", "expense_api")
+		writeJSONError(w, http.StatusInternalServerError, "error deleting expense")
+		return
+	}
+
+	s.events.Publish(events.ExpenseDeleted, events.RefPayload{ID: id})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleAPIIncomes serves /api/v1/incomes (GET lists, POST creates) and
+// /api/v1/incomes/{id} (GET, DELETE). SQLite backend only.
+func (s *Server) handleAPIIncomes(w http.ResponseWriter, r *http.Request) {
+	id := apiItemID(r, "incomes")
+	switch {
+	case id == "" && r.Method == http.MethodGet:
+		s.apiListIncomes(w, r)
+	case id == "" && r.Method == http.MethodPost:
+		s.apiCreateIncome(w, r)
+	case id == "":
+		w.Header().Set("Allow", "GET, POST")
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+	case r.Method == http.MethodGet || r.Method == http.MethodDelete:
+		s.apiIncome(w, r, id)
+	default:
+		w.Header().Set("Allow", "GET, DELETE")
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// apiIncome returns (GET) or deletes (DELETE) one income
+func (s *Server) apiIncome(w http.ResponseWriter, r *http.Request, id string) {
+	store, ok := s.apiStore(w, "incomes")
+	if !ok {
+		return
+	}
+	incomeID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid income id")
+		return
+	}
+	income, err := store.GetIncome(r.Context(), incomeID)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeJSONError(w, http.StatusNotFound, "income not found")
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to get income", "error", err, "income_id", incomeID, "component", "income_api")
+		writeJSONError(w, http.StatusInternalServerError, "error reading income")
+		return
+	}
+	if r.Method == http.MethodGet {
+		writeJSON(w, http.StatusOK, newAPIIncome(id, income))
+		return
+	}
+
+	err = store.TrashIncome(r.Context(), incomeID)
+	s.countEntryErr("income", "delete", err)
+	if errors.Is(err, core.ErrMonthClosed) {
+		writeJSONError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to delete income", "error", err, "income_id", incomeID, "component", "income_api")
+		writeJSONError(w, http.StatusInternalServerError, "error deleting income")
+		return
+	}
+	s.events.Publish(events.IncomeDeleted, events.RefPayload{ID: id})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// apiListIncomes lists the incomes of a month, or of a whole year, newest
+// first. Filters: category, subcategory, tag, q (text in the description).
+func (s *Server) apiListIncomes(w http.ResponseWriter, r *http.Request) {
+	store, ok := s.apiStore(w, "incomes")
+	if !ok {
+		return
+	}
+	year, month, err := apiPeriod(r, time.Now())
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	limit, offset, err := apiPagination(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	q := r.URL.Query()
+	category, subcategory, text := strings.TrimSpace(q.Get("category")), strings.TrimSpace(q.Get("subcategory")), strings.TrimSpace(q.Get("q"))
+	tag := strings.ToLower(strings.TrimSpace(q.Get("tag")))
+
+	months := []int{month}
+	if month == 0 {
+		months = []int{12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1}
+	}
+	var items []apiIncome
+	for _, m := range months {
+		incomes, err := store.ListIncomesWithID(r.Context(), year, m)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Failed to list incomes", "error", err, "year", year, "month", m, "component", "income_api")
+			writeJSONError(w, http.StatusInternalServerError, "error listing incomes")
+			return
+		}
+		for _, i := range incomes {
+			item := newAPIIncome(i.ID, i.Income)
+			if (category != "" && !strings.EqualFold(item.Category, category)) ||
+				(subcategory != "" && !strings.EqualFold(item.Subcategory, subcategory)) ||
+				(tag != "" && !hasTag(item.Tags, tag)) ||
+				!matchesText(item.Description, text) {
+				continue
+			}
+			items = append(items, item)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, newAPIPage(items, limit, offset))
+}
+
+// hasTag reports whether tags, normalized, contain tag
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// apiCreateIncome creates an income from an incomeInput body
+func (s *Server) apiCreateIncome(w http.ResponseWriter, r *http.Request) {
+	store, ok := s.apiStore(w, "incomes")
+	if !ok {
+		return
+	}
+	var in incomeInput
+	if !decodeAPIBody(w, r, &in) {
+		return
+	}
+	inc, err := in.income()
+	if err != nil {
+		s.countEntry("income", "create", outcomeValidationError, 1)
+		writeJSONError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	ref, err := store.AppendIncome(r.Context(), inc)
+	s.countEntryErr("income", "create", err)
+	if errors.Is(err, core.ErrMonthClosed) {
+		writeJSONError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to save income", "error", err, "component", "income_api", "operation", "append")
+		writeJSONError(w, http.StatusInternalServerError, "error saving income")
+		return
+	}
+	s.events.Publish(events.IncomeCreated, events.IncomeFrom(inc))
+
+	w.Header().Set("Location", "/api/v1/incomes/"+ref)
+	writeJSON(w, http.StatusCreated, newAPIIncome(ref, inc))
+}
+
+// handleAPIRecurrents serves /api/v1/recurrents (GET lists the active
+// ones, POST creates), /api/v1/recurrents/upcoming (GET) and
+// /api/v1/recurrents/{id} (GET, DELETE). SQLite backend only.
+func (s *Server) handleAPIRecurrents(w http.ResponseWriter, r *http.Request) {
+	id := apiItemID(r, "recurrents")
+	switch {
+	case id == "upcoming" && r.Method == http.MethodGet:
+		s.apiUpcomingCharges(w, r)
+	case id == "upcoming":
+		w.Header().Set("Allow", "GET")
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+	case id == "" && r.Method == http.MethodGet:
+		s.apiListRecurrents(w, r)
+	case id == "" && r.Method == http.MethodPost:
+		s.apiCreateRecurrent(w, r)
+	case id == "":
+		w.Header().Set("Allow", "GET, POST")
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+	case r.Method == http.MethodGet || r.Method == http.MethodDelete:
+		s.apiRecurrent(w, r, id)
+	default:
+		w.Header().Set("Allow", "GET, DELETE")
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// apiListRecurrents lists the active recurrent expenses. Filters: primary,
+// q (text in the description).
+func (s *Server) apiListRecurrents(w http.ResponseWriter, r *http.Request) {
+	store, ok := s.apiStore(w, "recurrent expenses")
+	if !ok {
+		return
+	}
+	limit, offset, err := apiPagination(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	primary, text := strings.TrimSpace(r.URL.Query().Get("primary")), strings.TrimSpace(r.URL.Query().Get("q"))
+
+	recurrents, err := store.GetRecurrentExpenses(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list recurrent expenses", "error", err, "component", "recurrent_api")
+		writeJSONError(w, http.StatusInternalServerError, "error listing recurrent expenses")
+		return
+	}
+	var items []apiRecurrent
+	for _, re := range recurrents {
+		if (primary != "" && !strings.EqualFold(re.Primary, primary)) || !matchesText(re.Description, text) {
+			continue
+		}
+		items = append(items, newAPIRecurrent(re))
+	}
+
+	writeJSON(w, http.StatusOK, newAPIPage(items, limit, offset))
+}
+
+// apiUpcomingCharges lists the expenses the active recurrent expenses will
+// create from today over the next days (query parameter days, 90 by
+// default), soonest first.
+func (s *Server) apiUpcomingCharges(w http.ResponseWriter, r *http.Request) {
+	store, ok := s.apiStore(w, "recurrent expenses")
+	if !ok {
+		return
+	}
+	limit, offset, err := apiPagination(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	days, err := upcomingDays(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	recurrents, err := store.GetRecurrentExpenses(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list recurrent expenses", "error", err, "component", "recurrent_api")
+		writeJSONError(w, http.StatusInternalServerError, "error listing recurrent expenses")
+		return
+	}
+	now := time.Now()
+	var items []apiUpcomingCharge
+	for _, c := range core.UpcomingCharges(recurrents, now, now.AddDate(0, 0, days)) {
+		items = append(items, apiUpcomingCharge{
+			RecurrentID: c.RecurrentID,
+			Date:        c.Date.Format("2006-01-02"),
+			Description: c.Description,
+			AmountCents: c.Amount.Cents,
+			Primary:     c.Primary,
+			Secondary:   c.Secondary,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, newAPIPage(items, limit, offset))
+}
+
+// apiCreateRecurrent creates a recurrent expense from a recurrentInput body
+func (s *Server) apiCreateRecurrent(w http.ResponseWriter, r *http.Request) {
+	store, ok := s.apiStore(w, "recurrent expenses")
+	if !ok {
+		return
+	}
+	var in recurrentInput
+	if !decodeAPIBody(w, r, &in) {
+		return
+	}
+	re, err := in.recurrent()
+	if err != nil {
+		writeJSONError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	re.ID, err = store.CreateRecurrentExpense(r.Context(), re)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to create recurrent expense", "error", err, "component", "recurrent_api")
+		writeJSONError(w, http.StatusInternalServerError, "error saving recurrent expense")
+		return
+	}
+	ref := strconv.FormatInt(re.ID, 10)
+	s.events.Publish(events.RecurrentCreated, events.RefPayload{ID: ref})
+
+	w.Header().Set("Location", "/api/v1/recurrents/"+ref)
+	writeJSON(w, http.StatusCreated, newAPIRecurrent(re))
+}
+
+// apiRecurrent returns (GET) or stops (DELETE) one recurrent expense
+func (s *Server) apiRecurrent(w http.ResponseWriter, r *http.Request, id string) {
+	store, ok := s.apiStore(w, "recurrent expenses")
+	if !ok {
+		return
+	}
+	recurrentID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid recurrent expense id")
+		return
+	}
+	re, err := store.GetRecurrentExpenseByID(r.Context(), recurrentID)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeJSONError(w, http.StatusNotFound, "recurrent expense not found")
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to get recurrent expense", "error", err, "recurrent_id", recurrentID, "component", "recurrent_api")
+		writeJSONError(w, http.StatusInternalServerError, "error reading recurrent expense")
+		return
+	}
+	if r.Method == http.MethodGet {
+		writeJSON(w, http.StatusOK, newAPIRecurrent(*re))
+		return
+	}
+
+	if err := store.DeleteRecurrentExpense(r.Context(), recurrentID); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to delete recurrent expense", "error", err, "recurrent_id", recurrentID, "component", "recurrent_api")
+		writeJSONError(w, http.StatusInternalServerError, "error deleting recurrent expense")
+		return
+	}
+	s.events.Publish(events.RecurrentDeleted, events.RefPayload{ID: id})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleAPICategories serves /api/v1/categories: GET lists, POST creates,
+// PATCH renames, re-parents or archives, DELETE removes an unused category.
+func (s *Server) handleAPICategories(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.apiListCategories(w, r)
+	case http.MethodPost:
+		s.apiCreateCategory(w, r)
+	case http.MethodPatch:
+		s.apiUpdateCategory(w, r)
+	case http.MethodDelete:
+		s.apiDeleteCategory(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST, PATCH, DELETE")
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// apiListCategories returns the expense categories with their
+// subcategories, and the income categories with the SQLite backend.
+// Archived categories are left out.
+func (s *Server) apiListCategories(w http.ResponseWriter, r *http.Request) {
+	var resp apiCategories
+	if adapter, ok := s.taxReader.(*adapters.SQLiteAdapter); ok {
+		cats, err := adapter.GetAllCategoriesWithSubs(r.Context())
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Failed to get categories", "error", err, "component", "category_api")
+			writeJSONError(w, http.StatusInternalServerError, "error reading categories")
+			return
+		}
+		income, err := adapter.GetIncomeCategories(r.Context())
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Failed to get income categories", "error", err, "component", "category_api")
+			writeJSONError(w, http.StatusInternalServerError, "error reading categories")
+			return
+		}
+		resp = apiCategories{Expense: cats, Income: income}
+	} else {
+		primaries, secondaries, err := s.taxReader.List(r.Context())
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Failed to get categories", "error", err, "component", "category_api")
+			writeJSONError(w, http.StatusInternalServerError, "error reading categories")
+			return
+		}
+		// The sheet does not say which secondaries belong to a primary
+		for _, p := range primaries {
+			resp.Expense = append(resp.Expense, storage.CategoryWithSubs{Primary: p, Secondaries: secondaries})
+		}
+	}
+	if resp.Expense == nil {
+		resp.Expense = []storage.CategoryWithSubs{}
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// writeCategoryJSONError writes the JSON error of a failed category change
+func writeCategoryJSONError(w http.ResponseWriter, r *http.Request, err error) {
+	status := categoryErrorStatus(err)
+	if status == http.StatusInternalServerError {
+		slog.ErrorContext(r.Context(), "Category change failed", "error", err, "component", "category_api")
+		writeJSONError(w, status, "error saving category")
+		return
+	}
+	writeJSONError(w, status, err.Error())
+}
+
+// apiCreateCategory creates a category from a categoryInput body,
+// creating its primary category when missing
+func (s *Server) apiCreateCategory(w http.ResponseWriter, r *http.Request) {
+	store, ok := s.apiStore(w, "managing categories")
+	if !ok {
+		return
+	}
+	var in categoryInput
+	if !decodeAPIBody(w, r, &in) {
+		return
+	}
+	if err := store.CreateCategory(r.Context(), in.Primary, in.Secondary); err != nil {
+		writeCategoryJSONError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+// apiUpdateCategory applies a categoryInput body to an existing category
+func (s *Server) apiUpdateCategory(w http.ResponseWriter, r *http.Request) {
+	store, ok := s.apiStore(w, "managing categories")
+	if !ok {
+		return
+	}
+	var in categoryInput
+	if !decodeAPIBody(w, r, &in) {
+		return
+	}
+
+	ctx := r.Context()
+	primary := in.Primary
+	if in.Parent != "" {
+		if in.Secondary == "" {
+			writeJSONError(w, http.StatusBadRequest, "only secondary categories have a parent")
+			return
+		}
+		if err := store.MoveSecondaryCategory(ctx, primary, in.Secondary, in.Parent); err != nil {
+			writeCategoryJSONError(w, r, err)
+			return
+		}
+		primary = in.Parent
+	}
+	name := in.Secondary
+	if in.Name != "" {
+		if err := store.RenameCategory(ctx, primary, in.Secondary, in.Name); err != nil {
+			writeCategoryJSONError(w, r, err)
+			return
+		}
+		if in.Secondary == "" {
+			primary = in.Name
+		} else {
+			name = in.Name
+		}
+	}
+	if in.Archived != nil {
+		if err := store.SetCategoryArchived(ctx, primary, name, *in.Archived); err != nil {
+			writeCategoryJSONError(w, r, err)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// apiDeleteCategory removes the category named by the primary and
+// secondary query parameters, 409 while expenses still use it
+func (s *Server) apiDeleteCategory(w http.ResponseWriter, r *http.Request) {
+	store, ok := s.apiStore(w, "managing categories")
+	if !ok {
+		return
+	}
+	q := r.URL.Query()
+	if err := store.DeleteCategory(r.Context(), strings.TrimSpace(q.Get("primary")), strings.TrimSpace(q.Get("secondary"))); err != nil {
+		writeCategoryJSONError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleAPICategoryMappings lists (GET), sets (PUT) and removes (DELETE
+// ?sheet_name=) the primary categories the secondary categories of Google
+// Sheets map to, consulted by the category sync and the history import
+func (s *Server) handleAPICategoryMappings(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet, http.MethodPut, http.MethodDelete:
+	default:
+		w.Header().Set("Allow", "GET, PUT, DELETE")
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	store, ok := s.apiStore(w, "category mappings")
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	switch r.Method {
+	case http.MethodGet:
+		mappings, err := store.ListCategoryMappings(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to list category mappings", "error", err, "component", "category_api")
+			writeJSONError(w, http.StatusInternalServerError, "error reading category mappings")
+			return
+		}
+		resp := apiCategoryMappings{Items: make([]apiCategoryMapping, 0, len(mappings))}
+		for _, m := range mappings {
+			resp.Items = append(resp.Items, apiCategoryMapping{SheetName: m.SheetName, Primary: m.PrimaryCategory})
+		}
+		writeJSON(w, http.StatusOK, resp)
+	case http.MethodPut:
+		var in apiCategoryMapping
+		if !decodeAPIBody(w, r, &in) {
+			return
+		}
+		if err := store.SetCategoryMapping(ctx, strings.TrimSpace(in.SheetName), strings.TrimSpace(in.Primary)); err != nil {
+			writeCategoryJSONError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodDelete:
+		if err := store.DeleteCategoryMapping(ctx, strings.TrimSpace(r.URL.Query().Get("sheet_name"))); err != nil {
+			writeCategoryJSONError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// handleAPIOverview returns the totals of a month (year and month query
+// parameters, the current month by default), with incomes and the
+// balance on the SQLite backend
+func (s *Server) handleAPIOverview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	now := time.Now()
+	year, month, err := apiPeriod(r, now)
+	if err == nil && month == 0 {
+		err = errors.New("month is required with year")
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+
+	overview, err := s.dashReader.ReadMonthOverview(ctx, year, month)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to read month overview", "error", err, "year", year, "month", month, "component", "overview_api")
+		writeJSONError(w, http.StatusInternalServerError, "error reading overview")
+		return
+	}
+	resp := apiOverview{Year: year, Month: month, TotalCents: overview.Total.Cents, ByCategory: []apiAmount{}}
+	for _, c := range overview.ByCategory {
+		resp.ByCategory = append(resp.ByCategory, apiAmount{Name: c.Name, AmountCents: c.Amount.Cents})
+	}
+
+	if adapter, ok := s.expWriter.(*adapters.SQLiteAdapter); ok {
+		incomes, err := adapter.ReadIncomeMonthOverview(ctx, year, month)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Failed to read income overview", "error", err, "year", year, "month", month, "component", "overview_api")
+			writeJSONError(w, http.StatusInternalServerError, "error reading overview")
+			return
+		}
+		income, balance := incomes.Total.Cents, incomes.Total.Cents-overview.Total.Cents
+		resp.IncomeTotalCents, resp.BalanceCents = &income, &balance
+		resp.IncomeByCategory = []apiAmount{}
+		for _, c := range incomes.ByCategory {
+			resp.IncomeByCategory = append(resp.IncomeByCategory, apiAmount{Name: c.Name, AmountCents: c.Amount.Cents})
+		}
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleAPISyncErrors reports the failed sheet sync attempts: counts by
+// error class and the latest attempts, newest first. Query parameters:
+// expense_id (only that expense), limit.
+func (s *Server) handleAPISyncErrors(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	store, ok := s.apiStore(w, "sync errors")
+	if !ok {
+		return
+	}
+	limit, _, err := apiPagination(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+	var errs []storage.SyncError
+	if v := strings.TrimSpace(r.URL.Query().Get("expense_id")); v != "" {
+		expenseID, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || expenseID <= 0 {
+			writeJSONError(w, http.StatusBadRequest, "invalid expense_id")
+			return
+		}
+		errs, err = store.ListExpenseSyncErrors(ctx, expenseID)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to list sync errors", "error", err, "expense_id", expenseID, "component", "sync_api")
+			writeJSONError(w, http.StatusInternalServerError, "error reading sync errors")
+			return
+		}
+		if len(errs) > limit {
+			errs = errs[:limit]
+		}
+	} else if errs, err = store.ListSyncErrors(ctx, limit); err != nil {
+		slog.ErrorContext(ctx, "Failed to list sync errors", "error", err, "component", "sync_api")
+		writeJSONError(w, http.StatusInternalServerError, "error reading sync errors")
+		return
+	}
+	counts, err := store.SyncErrorCounts(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to count sync errors", "error", err, "component", "sync_api")
+		writeJSONError(w, http.StatusInternalServerError, "error reading sync errors")
+		return
+	}
+
+	resp := apiSyncErrors{Counts: make(map[string]int64), Errors: []apiSyncError{}}
+	for _, class := range ports.ErrorClasses {
+		resp.Counts[string(class)] = counts[string(class)]
+	}
+	for _, e := range errs {
+		resp.Errors = append(resp.Errors, apiSyncError{
+			ID:        e.ID,
+			ExpenseID: e.ExpenseID,
+			Operation: e.Operation,
+			Attempt:   e.Attempt,
+			Class:     e.Class,
+			Message:   e.Message,
+			At:        e.CreatedAt.UTC().Format(time.RFC3339),
+		})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleAPIDeadLetters serves the sync items that gave up retrying:
+//   - GET /api/v1/sync/dead-letters lists them, newest first, up to limit
+//   - POST /api/v1/sync/dead-letters/reprocess queues all of them again
+//   - POST /api/v1/sync/dead-letters/{id}/reprocess queues one again
+//
+// Reprocessed items start over with no attempts; the reply is
+// {"reprocessed": n}.
+func (s *Server) handleAPIDeadLetters(w http.ResponseWriter, r *http.Request) {
+	item := apiItemID(r, "sync/dead-letters")
+	method := http.MethodGet
+	if item != "" {
+		method = http.MethodPost
+	}
+	if r.Method != method {
+		w.Header().Set("Allow", method)
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	store, ok := s.apiStore(w, "sync queue")
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+
+	switch {
+	case item == "":
+		limit, _, err := apiPagination(r)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		// Failed items come first, so the first limit ones are all there
+		items, err := store.OpenSyncItems(ctx, limit)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to list dead-lettered syncs", "error", err, "component", "sync_api")
+			writeJSONError(w, http.StatusInternalServerError, "error reading sync queue")
+			return
+		}
+		resp := []apiDeadLetter{}
+		for _, it := range items {
+			if it.Status != "failed" {
+				break
+			}
+			dl := apiDeadLetter{
+				ID:          it.ID,
+				ExpenseID:   it.ExpenseID,
+				Operation:   it.Operation,
+				Description: it.Description,
+				AmountCents: it.AmountCents,
+				Attempts:    it.Attempts,
+				LastError:   it.LastError,
+				FailedAt:    it.UpdatedAt.UTC().Format(time.RFC3339),
+			}
+			if it.ExpenseDate.Valid {
+				dl.Date = it.ExpenseDate.Time.Format(time.DateOnly)
+			}
+			resp = append(resp, dl)
+		}
+		writeJSON(w, http.StatusOK, resp)
+
+	case item == "reprocess":
+		n, err := store.RetryFailedSyncs(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to reprocess dead-lettered syncs", "error", err, "component", "sync_api")
+			writeJSONError(w, http.StatusInternalServerError, "error reprocessing sync items")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]int64{"reprocessed": n})
+
+	default:
+		raw, ok := strings.CutSuffix(item, "/reprocess")
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if !ok || err != nil || id <= 0 {
+			writeJSONError(w, http.StatusNotFound, "not found")
+			return
+		}
+		if err := store.RetrySyncItem(ctx, id); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				writeJSONError(w, http.StatusNotFound, "sync item not found")
+				return
+			}
+			slog.ErrorContext(ctx, "Failed to reprocess sync item", "error", err, "id", id, "component", "sync_api")
+			writeJSONError(w, http.StatusInternalServerError, "error reprocessing sync items")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]int64{"reprocessed": 1})
+	}
+}
+
+// handleAPITags lists the tags carried by some expense or income, most used
+// first.
+func (s *Server) handleAPITags(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	store, ok := s.apiStore(w, "tags")
+	if !ok {
+		return
+	}
+
+	tags, err := store.ListTags(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list tags", "error", err, "component", "tag_api")
+		writeJSONError(w, http.StatusInternalServerError, "error listing tags")
+		return
+	}
+	resp := apiTags{Items: make([]apiTag, len(tags))}
+	for i, tag := range tags {
+		resp.Items[i] = apiTag{Name: tag.Name, Uses: tag.Uses}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleAPITagReport adds up expenses and incomes per tag, month by month,
+// largest spending first. Query parameters: tag (empty for every tag), from
+// and to (YYYY-MM, included; the last 12 months by default).
+func (s *Server) handleAPITagReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	store, ok := s.apiStore(w, "tags")
+	if !ok {
+		return
+	}
+	from, to, err := tagPeriod(r, time.Now())
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	summaries, err := store.TagReport(r.Context(), from, to, r.URL.Query().Get("tag"))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to build tag report", "error", err, "component", "tag_api")
+		writeJSONError(w, http.StatusInternalServerError, "error reading tag report")
+		return
+	}
+	resp := apiTagReport{
+		From: from.Format("2006-01"),
+		To:   to.AddDate(0, -1, 0).Format("2006-01"),
+		Tags: make([]apiTagSummary, len(summaries)),
+	}
+	for i, summary := range summaries {
+		item := apiTagSummary{
+			Tag:           summary.Tag,
+			SpentCents:    summary.Spent.Cents,
+			ReceivedCents: summary.Received.Cents,
+			Entries:       summary.Entries,
+			Months:        make([]apiTagMonth, len(summary.Months)),
+		}
+		for j, m := range summary.Months {
+			item.Months[j] = apiTagMonth{
+				Month:         fmt.Sprintf("%04d-%02d", m.Year, m.Month),
+				SpentCents:    m.Spent.Cents,
+				ReceivedCents: m.Received.Cents,
+				Entries:       m.Entries,
+			}
+		}
+		resp.Tags[i] = item
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
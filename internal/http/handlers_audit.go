@@ -0,0 +1,199 @@
+package http
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"spese/internal/adapters"
+	"spese/internal/storage"
+)
+
+const (
+	// defaultAuditDays is the period the audit page shows without filters
+	defaultAuditDays = 30
+	// maxAuditEntries caps the entries the audit page lists
+	maxAuditEntries = 200
+)
+
+// auditEntityLabels names the audited entities
+var auditEntityLabels = map[string]string{
+	storage.AuditExpense:          "Spesa",
+	storage.AuditIncome:           "Entrata",
+	storage.AuditRecurrentExpense: "Spesa ricorrente",
+	storage.AuditRecurrentIncome:  "Entrata ricorrente",
+	storage.AuditCategory:         "Categoria",
+	storage.AuditSubcategory:      "Sottocategoria",
+}
+
+// auditActionLabels names the audited actions
+var auditActionLabels = map[string]string{
+	storage.AuditCreate: "Creazione",
+	storage.AuditUpdate: "Modifica",
+	storage.AuditDelete: "Eliminazione",
+}
+
+// auditOption is an entry of the entity filter
+type auditOption struct {
+	Value    string
+	Label    string
+	Selected bool
+}
+
+// auditChangeView is a field changed by an audited change
+type auditChangeView struct {
+	Field string
+	Old   string
+	New   string
+}
+
+// auditEntryView is a row of the audit page
+type auditEntryView struct {
+	ChangedAt string
+	ChangedBy string
+	Entity    string
+	EntityID  int64
+	Action    string
+	Changes   []auditChangeView
+}
+
+// auditView is the data of the audit page
+type auditView struct {
+	From     string
+	To       string
+	EntityID string
+	Entities []auditOption
+	Entries  []auditEntryView
+	Capped   bool
+	Error    string
+}
+
+// formatAuditValue renders a field value of an audit snapshot: amounts in
+// euros, flags in words, a dash for no value
+func formatAuditValue(field string, v any) string {
+	switch val := v.(type) {
+	case nil:
+		return "—"
+	case bool:
+		if val {
+			return "sì"
+		}
+		return "no"
+	case float64: // JSON numbers
+		if strings.HasSuffix(field, "_cents") {
+			return formatEuros(int64(val))
+		}
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case string:
+		if val == "" {
+			return "—"
+		}
+		return val
+	}
+	return fmt.Sprint(v)
+}
+
+// handleAudit renders the audit log: who created, changed or deleted an
+// expense, income, recurrent or category, and which fields changed.
+// Filters: entity, id and the from/to dates, the last 30 days by default.
+func (s *Server) handleAudit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	adapter, ok := s.expWriter.(*adapters.SQLiteAdapter)
+	if !ok {
+		w.WriteHeader(http.StatusNotImplemented)
+		_, _ = w.Write([]byte(`<div class="error">Registro modifiche disponibile solo con il backend SQLite</div>`))
+		return
+	}
+	store := adapter.GetStorage()
+
+	q := r.URL.Query()
+	today := time.Now()
+	view := auditView{
+		From:     today.AddDate(0, 0, -defaultAuditDays).Format("2006-01-02"),
+		To:       today.Format("2006-01-02"),
+		EntityID: strings.TrimSpace(q.Get("id")),
+	}
+	if v := q.Get("from"); v != "" {
+		view.From = v
+	}
+	if v := q.Get("to"); v != "" {
+		view.To = v
+	}
+
+	entity := q.Get("entity")
+	if entity != "" && auditEntityLabels[entity] == "" {
+		view.Error = "Entità non valida"
+	}
+	view.Entities = append(view.Entities, auditOption{Label: "Tutte", Selected: entity == ""})
+	for _, e := range storage.AuditEntities {
+		view.Entities = append(view.Entities, auditOption{Value: e, Label: auditEntityLabels[e], Selected: e == entity})
+	}
+
+	from, errFrom := time.ParseInLocation("2006-01-02", view.From, time.Local)
+	to, errTo := time.ParseInLocation("2006-01-02", view.To, time.Local)
+	var id int64
+	if view.EntityID != "" {
+		n, err := strconv.ParseInt(view.EntityID, 10, 64)
+		if err != nil || n < 1 {
+			view.Error = "ID non valido"
+		}
+		id = n
+	}
+	switch {
+	case errFrom != nil || errTo != nil:
+		view.Error = "Date non valide"
+	case to.Before(from):
+		view.Error = "La data finale precede quella iniziale"
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if view.Error == "" {
+		entries, err := store.ListAuditLog(r.Context(), storage.AuditFilter{
+			From:     from,
+			Until:    to.AddDate(0, 0, 1),
+			Entity:   entity,
+			EntityID: id,
+			Limit:    maxAuditEntries + 1,
+		})
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Failed to list audit log", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento del registro modifiche</div>`))
+			return
+		}
+		if len(entries) > maxAuditEntries {
+			entries = entries[:maxAuditEntries]
+			view.Capped = true
+		}
+		for _, e := range entries {
+			row := auditEntryView{
+				ChangedAt: e.ChangedAt.Local().Format("02/01/2006 15:04:05"),
+				ChangedBy: e.ChangedBy,
+				Entity:    auditEntityLabels[e.Entity],
+				EntityID:  e.EntityID,
+				Action:    auditActionLabels[e.Action],
+			}
+			for _, c := range e.Changes() {
+				row.Changes = append(row.Changes, auditChangeView{
+					Field: c.Field,
+					Old:   formatAuditValue(c.Field, c.Old),
+					New:   formatAuditValue(c.Field, c.New),
+				})
+			}
+			view.Entries = append(view.Entries, row)
+		}
+	} else {
+		w.WriteHeader(http.StatusBadRequest)
+	}
+
+	if err := s.templates.ExecuteTemplate(w, "audit_page", view); err != nil {
+		slog.ErrorContext(r.Context(), "Audit template execution failed", "error", err, "template", "audit_page")
+	}
+}
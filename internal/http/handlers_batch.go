@@ -0,0 +1,169 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"spese/internal/core"
+	"spese/internal/events"
+	"spese/internal/hooks"
+	"spese/internal/services"
+	"spese/internal/sheets"
+)
+
+// Batch creation limits
+const (
+	batchMaxExpenses = 500
+	batchMaxBody     = 1 << 20
+)
+
+// Per-item outcomes of a batch
+const (
+	batchCreated  = "created"
+	batchValid    = "valid" // dry run: would be created
+	batchInvalid  = "invalid"
+	batchRejected = "rejected" // refused by the before-save hook
+	batchSkipped  = "skipped"  // valid, but not saved because another item failed
+)
+
+type batchRequest struct {
+	Expenses []expenseInput `json:"expenses"`
+}
+
+type batchItemResult struct {
+	Index  int    `json:"index"`
+	Status string `json:"status"`
+	ID     string `json:"id,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type batchResponse struct {
+	Created int               `json:"created"`
+	Results []batchItemResult `json:"results"`
+}
+
+// SetBatchWriter enables POST /api/v1/expenses:batch.
+func (s *Server) SetBatchWriter(w sheets.ExpenseBatchWriter) {
+	s.batchWriter = w
+}
+
+// handleExpenseBatch creates up to batchMaxExpenses expenses in one
+// transaction. Every item is validated first; if any is invalid or rejected
+// nothing is saved and the results say which items to fix. With
+// ?dry_run=true the items are only validated and valid ones get the status
+// "valid"; the before-save hook does not run.
+func (s *Server) handleExpenseBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if s.batchWriter == nil {
+		writeJSONError(w, http.StatusNotImplemented, "batch creation not supported by this backend")
+		return
+	}
+
+	var req batchRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, batchMaxBody)).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	if len(req.Expenses) == 0 {
+		writeJSONError(w, http.StatusBadRequest, "no expenses")
+		return
+	}
+	if len(req.Expenses) > batchMaxExpenses {
+		writeJSONError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("too many expenses: %d (max %d)", len(req.Expenses), batchMaxExpenses))
+		return
+	}
+
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+	resp := batchResponse{Results: make([]batchItemResult, len(req.Expenses))}
+	invalid := 0
+	for i, in := range req.Expenses {
+		resp.Results[i] = batchItemResult{Index: i, Status: batchSkipped}
+		if dryRun {
+			resp.Results[i].Status = batchValid
+		}
+		if _, err := in.expense(); err != nil {
+			resp.Results[i].Status, resp.Results[i].Error = batchInvalid, err.Error()
+			invalid++
+		}
+	}
+	if invalid > 0 {
+		if !dryRun {
+			s.countEntry("expense", "create", outcomeValidationError, invalid)
+		}
+		writeJSON(w, http.StatusUnprocessableEntity, resp)
+		return
+	}
+	if dryRun {
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+
+	// Parsing again cannot fail: inputs were validated above
+	expenses := make([]core.Expense, len(req.Expenses))
+	for i, in := range req.Expenses {
+		expenses[i], _ = in.expense()
+	}
+
+	refs, err := s.batchWriter.AppendBatch(r.Context(), expenses)
+	if err != nil {
+		s.countEntry("expense", "create", writeOutcome(err), len(expenses))
+	}
+	var itemErr *services.BatchItemError
+	if errors.As(err, &itemErr) && errors.Is(err, hooks.ErrRejected) {
+		slog.InfoContext(r.Context(), "Expense batch rejected by hook", "error", err, "component", "expense_batch")
+		resp.Results[itemErr.Index].Status, resp.Results[itemErr.Index].Error = batchRejected, itemErr.Err.Error()
+		writeJSON(w, http.StatusUnprocessableEntity, resp)
+		return
+	}
+	if errors.Is(err, core.ErrMonthClosed) {
+		writeJSONError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if errors.Is(err, core.ErrQuotaExceeded) {
+		writeQuotaExceeded(w, r, err)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to save expense batch",
+			"error", err,
+			"count", len(expenses),
+			"component", "expense_batch",
+			"operation", "append")
+		writeJSONError(w, http.StatusInternalServerError, "error saving expenses")
+		return
+	}
+
+	for i, exp := range expenses {
+		resp.Results[i] = batchItemResult{Index: i, Status: batchCreated, ID: refs[i]}
+		s.events.Publish(events.ExpenseCreated, events.ExpenseFrom(exp))
+	}
+	resp.Created = len(refs)
+	s.countEntry("expense", "create", outcomeSuccess, len(refs))
+
+	slog.InfoContext(r.Context(), "Expense batch created",
+		"count", len(refs),
+		"component", "expense_batch",
+		"operation", "create")
+
+	writeJSON(w, http.StatusCreated, resp)
+}
+
+// writeJSON writes v as a JSON response with the given status.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeJSONError writes {"error": msg} with the given status.
+func writeJSONError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
@@ -0,0 +1,42 @@
+package http
+
+import (
+	"log/slog"
+	"net/http"
+)
+
+// handleBulkEntry renders the keyboard-only entry page for a stack of
+// receipts. Rows are validated one by one with a dry run of the batch
+// endpoint and saved together in one transaction by the batch endpoint.
+func (s *Server) handleBulkEntry(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	primaries, secondaries, err := s.taxReader.List(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list categories", "error", err)
+	}
+
+	data := struct {
+		Today       string
+		Enabled     bool // The backend can save a batch
+		MaxRows     int
+		Primaries   []string
+		Secondaries []string
+	}{
+		Today:       s.entryDate().Format("2006-01-02"),
+		Enabled:     s.batchWriter != nil,
+		MaxRows:     batchMaxExpenses,
+		Primaries:   primaries,
+		Secondaries: secondaries,
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "bulk_entry_page", data); err != nil {
+		slog.ErrorContext(r.Context(), "Bulk entry template execution failed", "error", err, "template", "bulk_entry_page")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
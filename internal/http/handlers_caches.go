@@ -0,0 +1,81 @@
+package http
+
+import (
+	"net/http"
+	"strings"
+
+	"spese/internal/sheets"
+)
+
+// AddCaches reports the caches of c in /metrics and lets /api/v1/caches
+// clear them, e.g. those of the Google Sheets client.
+func (s *Server) AddCaches(c sheets.CacheReporter) {
+	s.caches = append(s.caches, c)
+}
+
+// apiCache is a server-side cache with its counters since startup
+type apiCache struct {
+	Name     string  `json:"name"`
+	Hits     uint64  `json:"hits"`
+	Misses   uint64  `json:"misses"`
+	HitRatio float64 `json:"hit_ratio"` // 0 before the first lookup
+	Size     int     `json:"size"`
+}
+
+func newAPICache(st sheets.CacheStats) apiCache {
+	c := apiCache{Name: st.Name, Hits: st.Hits, Misses: st.Misses, Size: st.Size}
+	if total := st.Hits + st.Misses; total > 0 {
+		c.HitRatio = float64(st.Hits) / float64(total)
+	}
+	return c
+}
+
+// cacheStats returns the counters of every cache, and of the named one
+// alone when name is set
+func (s *Server) cacheStats(name string) []apiCache {
+	caches := []apiCache{}
+	for _, c := range s.caches {
+		for _, st := range c.CacheStats() {
+			if name == "" || st.Name == name {
+				caches = append(caches, newAPICache(st))
+			}
+		}
+	}
+	return caches
+}
+
+// handleAPICaches lists the caches (GET /api/v1/caches) and empties one
+// (POST /api/v1/caches/{name}/clear), so that stale data can be ruled out
+// without a restart. Like every route it needs a login when one is set.
+func (s *Server) handleAPICaches(w http.ResponseWriter, r *http.Request) {
+	item := apiItemID(r, "caches")
+	name, action, _ := strings.Cut(item, "/")
+	method := http.MethodGet
+	if action == "clear" {
+		method = http.MethodPost
+	}
+	if r.Method != method {
+		w.Header().Set("Allow", method)
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	switch {
+	case item == "":
+		writeJSON(w, http.StatusOK, s.cacheStats(""))
+	case name == "" || action != "clear":
+		writeJSONError(w, http.StatusNotFound, "not found")
+	default:
+		cleared := false
+		for _, c := range s.caches {
+			if c.ClearCache(name) {
+				cleared = true
+			}
+		}
+		if !cleared {
+			writeJSONError(w, http.StatusNotFound, "cache not found")
+			return
+		}
+		writeJSON(w, http.StatusOK, s.cacheStats(name)[0])
+	}
+}
@@ -0,0 +1,180 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"spese/internal/adapters"
+	"spese/internal/core"
+	"spese/internal/events"
+	"spese/internal/hooks"
+)
+
+// calculatorDefaults are the description and unit used for each kind
+var calculatorDefaults = map[core.CalculationKind]struct{ Description, Unit string }{
+	core.CalculationMileage: {"Rimborso chilometrico", "km"},
+	core.CalculationPerDiem: {"Diaria", "giorni"},
+}
+
+// SetCalculatorRates sets the rates of the mileage (per km) and per-diem
+// (per day) calculators. A zero rate disables that calculator.
+func (s *Server) SetCalculatorRates(mileage, perDiem core.Money) {
+	s.calculatorRates = map[core.CalculationKind]core.Money{
+		core.CalculationMileage: mileage,
+		core.CalculationPerDiem: perDiem,
+	}
+}
+
+// formatCalculation describes a calculation, e.g. "120 km × €0,42".
+func formatCalculation(c core.ExpenseCalculation) string {
+	quantity := strings.ReplaceAll(strconv.FormatFloat(c.Quantity, 'f', -1, 64), ".", ",")
+	return fmt.Sprintf("%s %s × %s", quantity, calculatorDefaults[c.Kind].Unit, formatEuros(c.Rate.Cents))
+}
+
+// handleCalculators renders the mileage and per-diem entry forms
+func (s *Server) handleCalculators(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	primaries, secondaries, err := s.taxReader.List(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list categories", "error", err)
+	}
+
+	data := struct {
+		Today       string
+		MileageRate string
+		PerDiemRate string
+		Primaries   []string
+		Secondaries []string
+	}{
+		Today:       time.Now().Format("2006-01-02"),
+		Primaries:   primaries,
+		Secondaries: secondaries,
+	}
+	if rate := s.calculatorRates[core.CalculationMileage]; rate.Cents > 0 {
+		data.MileageRate = formatEuros(rate.Cents)
+	}
+	if rate := s.calculatorRates[core.CalculationPerDiem]; rate.Cents > 0 {
+		data.PerDiemRate = formatEuros(rate.Cents)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "calculators_page", data); err != nil {
+		slog.ErrorContext(r.Context(), "Calculators template execution failed", "error", err, "template", "calculators_page")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handleCreateCalculatedExpense writes an expense whose amount is the
+// quantity times the configured rate, keeping the inputs for audit.
+// Form fields: kind (mileage, per_diem), date, quantity, description,
+// primary, secondary.
+func (s *Server) handleCreateCalculatedExpense(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	// The inputs are stored next to the expense, so this needs SQLite
+	adapter, ok := s.expWriter.(*adapters.SQLiteAdapter)
+	if !ok {
+		w.WriteHeader(http.StatusNotImplemented)
+		_, _ = w.Write([]byte(`<div class="error">Calcolatori disponibili solo con il backend SQLite</div>`))
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+
+	kind, err := core.ParseCalculationKind(sanitizeInput(r.Form.Get("kind")))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Tipo di calcolo non valido</div>`))
+		return
+	}
+	rate := s.calculatorRates[kind]
+	if rate.Cents <= 0 {
+		w.WriteHeader(http.StatusNotImplemented)
+		_, _ = w.Write([]byte(`<div class="error">Tariffa non configurata</div>`))
+		return
+	}
+	quantity, err := core.ParseQuantity(r.Form.Get("quantity"))
+	if err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`<div class="error">Quantità non valida</div>`))
+		return
+	}
+	calc := core.ExpenseCalculation{Kind: kind, Quantity: quantity, Rate: rate}
+	if err := calc.Validate(); err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`<div class="error">Quantità non valida</div>`))
+		return
+	}
+	date, err := parseDate(strings.TrimSpace(r.Form.Get("date")))
+	if err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`<div class="error">Data non valida</div>`))
+		return
+	}
+
+	desc := sanitizeInput(r.Form.Get("description"))
+	if desc == "" {
+		desc = calculatorDefaults[kind].Description
+	}
+	exp := core.Expense{
+		Date:        date,
+		Description: desc,
+		Amount:      calc.Amount(),
+		Primary:     sanitizeInput(r.Form.Get("primary")),
+		Secondary:   sanitizeInput(r.Form.Get("secondary")),
+	}
+	if err := exp.Validate(); err != nil {
+		s.countEntry("expense", "create", outcomeValidationError, 1)
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`<div class="error">Dati non validi: ` + template.HTMLEscapeString(err.Error()) + `</div>`))
+		return
+	}
+
+	ref, err := adapter.Append(r.Context(), exp)
+	s.countEntryErr("expense", "create", err)
+	if errors.Is(err, hooks.ErrRejected) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`<div class="error">` + template.HTMLEscapeString(err.Error()) + `</div>`))
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to save calculated expense", "error", err, "kind", kind, "amount_cents", exp.Amount.Cents)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel salvataggio della spesa</div>`))
+		return
+	}
+
+	s.events.Publish(events.ExpenseCreated, events.ExpenseFrom(exp))
+
+	// The expense is saved either way; a missing audit record is only logged
+	if id, err := strconv.ParseInt(ref, 10, 64); err != nil {
+		slog.ErrorContext(r.Context(), "Unexpected expense reference", "ref", ref)
+	} else if err := adapter.GetStorage().RecordExpenseCalculation(r.Context(), id, calc); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to record expense calculation", "error", err, "expense_id", id)
+	}
+
+	slog.InfoContext(r.Context(), "Calculated expense created", "id", ref, "kind", kind, "quantity", quantity, "rate_cents", rate.Cents, "amount_cents", exp.Amount.Cents)
+	w.Header().Set("HX-Trigger", `{"dashboard:refresh": {}}`)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = fmt.Fprintf(w, `<div class="success">Spesa registrata: %s = %s</div>`,
+		template.HTMLEscapeString(formatCalculation(calc)), formatEuros(exp.Amount.Cents))
+}
@@ -0,0 +1,54 @@
+package http
+
+import (
+	"net/http"
+
+	"spese/internal/adapters"
+)
+
+// capabilities tells what the active data backend supports, so clients
+// and templates can hide the features that would answer 501
+type capabilities struct {
+	Backend     string `json:"backend"`
+	Recurrents  bool   `json:"recurrents"`   // Recurrent expenses and incomes
+	Incomes     bool   `json:"incomes"`      // Income entry and overview
+	DeleteByID  bool   `json:"delete_by_id"` // Expenses listed with an ID and deleted by it
+	Attachments bool   `json:"attachments"`  // Receipt files of expenses
+	Trash       bool   `json:"trash"`        // Deleted entries kept in /cestino
+	Search      bool   `json:"search"`       // Expense search of /expenses/search
+	YearReport  bool   `json:"year_report"`  // Category by month matrix of /report/year/{year}
+	Accounts    bool   `json:"accounts"`     // Accounts, balances and transfers of /accounts
+}
+
+// capabilities reports the features of the configured backend
+func (s *Server) capabilities() capabilities {
+	_, sqlite := s.expWriter.(*adapters.SQLiteAdapter)
+
+	s.entries.mu.Lock()
+	backend := s.entries.backend
+	s.entries.mu.Unlock()
+
+	return capabilities{
+		Backend:    backend,
+		Recurrents: sqlite,
+		Incomes:    sqlite,
+		// Google Sheets finds rows by their data, not by an ID, and lists
+		// no IDs to delete with
+		DeleteByID:  s.expDeleter != nil && s.expListerWithID != nil,
+		Attachments: sqlite && s.receipts != nil,
+		Trash:       sqlite,
+		Search:      sqlite,
+		YearReport:  sqlite,
+		Accounts:    sqlite,
+	}
+}
+
+// handleCapabilities serves GET /api/capabilities
+func (s *Server) handleCapabilities(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.capabilities())
+}
@@ -0,0 +1,261 @@
+package http
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"spese/internal/adapters"
+	"spese/internal/core"
+	"spese/internal/storage"
+)
+
+// categoriesView is the data of the category management list
+type categoriesView struct {
+	Categories []storage.CategoryAdmin
+	Primaries  []string // Targets a secondary category can move to
+}
+
+// categoryStore returns the SQLite repository holding the categories,
+// writing a 501 and returning false for other backends.
+func (s *Server) categoryStore(w http.ResponseWriter) (*storage.SQLiteRepository, bool) {
+	adapter, ok := s.expWriter.(*adapters.SQLiteAdapter)
+	if !ok {
+		w.WriteHeader(http.StatusNotImplemented)
+		_, _ = w.Write([]byte(`<div class="error">Gestione categorie disponibile solo con il backend SQLite</div>`))
+		return nil, false
+	}
+	return adapter.GetStorage(), true
+}
+
+// loadCategoriesView lists the categories for the management page
+func loadCategoriesView(r *http.Request, store *storage.SQLiteRepository) (categoriesView, error) {
+	cats, err := store.ListCategoryAdmin(r.Context())
+	if err != nil {
+		return categoriesView{}, err
+	}
+	view := categoriesView{Categories: cats}
+	for _, c := range cats {
+		if !c.Archived {
+			view.Primaries = append(view.Primaries, c.Primary)
+		}
+	}
+	return view, nil
+}
+
+// categoryErrorStatus maps the errors of the category storage methods to
+// a status code, 500 for unexpected ones.
+func categoryErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, core.ErrInvalidTaxonomy):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, core.ErrCategoryNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, core.ErrCategoryExists), errors.Is(err, core.ErrCategoryInUse):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// writeCategoryError writes the error fragment of a failed category change
+func writeCategoryError(w http.ResponseWriter, r *http.Request, err error) {
+	status := categoryErrorStatus(err)
+	msg := "Errore nel salvataggio della categoria"
+	switch status {
+	case http.StatusUnprocessableEntity:
+		msg = "Nome non valido: serve un nome di al massimo " + strconv.Itoa(core.MaxCategoryNameLength) + " caratteri"
+	case http.StatusNotFound:
+		msg = "Categoria non trovata"
+	case http.StatusConflict:
+		if errors.Is(err, core.ErrCategoryInUse) {
+			msg = "Categoria in uso: archiviala invece di eliminarla"
+		} else {
+			msg = "Esiste già una categoria con questo nome"
+		}
+	default:
+		slog.ErrorContext(r.Context(), "Category change failed", "error", err)
+	}
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(`<div class="error">` + msg + `</div>`))
+}
+
+// categoryForm parses a category change form, writing a 400 and returning
+// false when the body is malformed. Form fields: primary, secondary (empty
+// for a primary category).
+func categoryForm(w http.ResponseWriter, r *http.Request) (primary, secondary string, ok bool) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return "", "", false
+	}
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return "", "", false
+	}
+	return sanitizeInput(r.Form.Get("primary")), sanitizeInput(r.Form.Get("secondary")), true
+}
+
+// categoryChanged confirms a category change and refreshes the list
+func categoryChanged(w http.ResponseWriter, msg string) {
+	w.Header().Set("HX-Trigger", `{"categories:changed": {}}`)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(`<div class="success">` + msg + `</div>`))
+}
+
+// handleCategories renders the category management page
+func (s *Server) handleCategories(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.categoryStore(w)
+	if !ok {
+		return
+	}
+
+	view, err := loadCategoriesView(r, store)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list categories", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento delle categorie</div>`))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "categories_page", view); err != nil {
+		slog.ErrorContext(r.Context(), "Categories template execution failed", "error", err, "template", "categories_page")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handleCategoriesList renders the categories table, refreshed after
+// every change
+func (s *Server) handleCategoriesList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.categoryStore(w)
+	if !ok {
+		return
+	}
+
+	view, err := loadCategoriesView(r, store)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list categories", "error", err)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento delle categorie</div>`))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "categories_list", view); err != nil {
+		slog.ErrorContext(r.Context(), "Template execution failed", "error", err, "template", "categories_list")
+	}
+}
+
+// handleCreateCategory adds a category. Form fields: primary, secondary
+// (optional; the primary category is created when missing).
+func (s *Server) handleCreateCategory(w http.ResponseWriter, r *http.Request) {
+	primary, secondary, ok := categoryForm(w, r)
+	if !ok {
+		return
+	}
+	store, ok := s.categoryStore(w)
+	if !ok {
+		return
+	}
+
+	if err := store.CreateCategory(r.Context(), primary, secondary); err != nil {
+		writeCategoryError(w, r, err)
+		return
+	}
+	categoryChanged(w, "Categoria aggiunta")
+}
+
+// handleRenameCategory renames a category along with the expenses filed
+// under it. Form fields: primary, secondary, name.
+func (s *Server) handleRenameCategory(w http.ResponseWriter, r *http.Request) {
+	primary, secondary, ok := categoryForm(w, r)
+	if !ok {
+		return
+	}
+	store, ok := s.categoryStore(w)
+	if !ok {
+		return
+	}
+
+	if err := store.RenameCategory(r.Context(), primary, secondary, sanitizeInput(r.Form.Get("name"))); err != nil {
+		writeCategoryError(w, r, err)
+		return
+	}
+	categoryChanged(w, "Categoria rinominata")
+}
+
+// handleMoveCategory moves a secondary category under another primary
+// category. Form fields: primary, secondary, parent.
+func (s *Server) handleMoveCategory(w http.ResponseWriter, r *http.Request) {
+	primary, secondary, ok := categoryForm(w, r)
+	if !ok {
+		return
+	}
+	store, ok := s.categoryStore(w)
+	if !ok {
+		return
+	}
+
+	if err := store.MoveSecondaryCategory(r.Context(), primary, secondary, sanitizeInput(r.Form.Get("parent"))); err != nil {
+		writeCategoryError(w, r, err)
+		return
+	}
+	categoryChanged(w, "Categoria spostata")
+}
+
+// handleArchiveCategory archives or restores a category. Form fields:
+// primary, secondary, archived ("true" to archive).
+func (s *Server) handleArchiveCategory(w http.ResponseWriter, r *http.Request) {
+	primary, secondary, ok := categoryForm(w, r)
+	if !ok {
+		return
+	}
+	store, ok := s.categoryStore(w)
+	if !ok {
+		return
+	}
+
+	archived := r.Form.Get("archived") == "true"
+	if err := store.SetCategoryArchived(r.Context(), primary, secondary, archived); err != nil {
+		writeCategoryError(w, r, err)
+		return
+	}
+	if archived {
+		categoryChanged(w, "Categoria archiviata")
+	} else {
+		categoryChanged(w, "Categoria ripristinata")
+	}
+}
+
+// handleDeleteCategory removes a category no expense or recurrent expense
+// uses. Form fields: primary, secondary.
+func (s *Server) handleDeleteCategory(w http.ResponseWriter, r *http.Request) {
+	primary, secondary, ok := categoryForm(w, r)
+	if !ok {
+		return
+	}
+	store, ok := s.categoryStore(w)
+	if !ok {
+		return
+	}
+
+	if err := store.DeleteCategory(r.Context(), primary, secondary); err != nil {
+		writeCategoryError(w, r, err)
+		return
+	}
+	categoryChanged(w, "Categoria eliminata")
+}
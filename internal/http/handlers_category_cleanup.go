@@ -0,0 +1,157 @@
+package http
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"spese/internal/storage"
+)
+
+// orphanCategoryView is an orphan category pair with the merge target
+// preselected for it, 0 for none
+type orphanCategoryView struct {
+	storage.OrphanCategory
+	Suggested int64
+}
+
+// categoryCleanupView is the data of the category cleanup steps
+type categoryCleanupView struct {
+	Unused   []storage.UnusedCategory
+	Orphans  []orphanCategoryView
+	Detached []storage.DetachedCategory
+	Targets  []storage.MergeTarget
+}
+
+// loadCategoryCleanup lists the categories to tidy up; an orphan pair is
+// suggested the category of the same name under another primary
+func loadCategoryCleanup(r *http.Request, store *storage.SQLiteRepository) (categoryCleanupView, error) {
+	cleanup, err := store.CategoryCleanup(r.Context())
+	if err != nil {
+		return categoryCleanupView{}, err
+	}
+	view := categoryCleanupView{
+		Unused:   cleanup.Unused,
+		Detached: cleanup.Detached,
+		Targets:  cleanup.Targets,
+	}
+	for _, o := range cleanup.Orphans {
+		orphan := orphanCategoryView{OrphanCategory: o}
+		for _, t := range cleanup.Targets {
+			if t.Primary == o.SuggestedPrimary && t.Secondary == o.Secondary {
+				orphan.Suggested = t.ID
+				break
+			}
+		}
+		view.Orphans = append(view.Orphans, orphan)
+	}
+	return view, nil
+}
+
+// handleCategoryCleanup renders the cleanup wizard: unused categories to
+// archive or delete, orphan category pairs to merge into an existing
+// category and secondaries left without their primary to delete
+func (s *Server) handleCategoryCleanup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	store, ok := s.categoryStore(w)
+	if !ok {
+		return
+	}
+
+	view, err := loadCategoryCleanup(r, store)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list categories to clean up", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento delle categorie</div>`))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "category_cleanup_page", view); err != nil {
+		slog.ErrorContext(r.Context(), "Category cleanup template execution failed", "error", err, "template", "category_cleanup_page")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handleCategoryCleanupSteps renders the cleanup steps, refreshed after
+// every change
+func (s *Server) handleCategoryCleanupSteps(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	store, ok := s.categoryStore(w)
+	if !ok {
+		return
+	}
+
+	view, err := loadCategoryCleanup(r, store)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list categories to clean up", "error", err)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento delle categorie</div>`))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "category_cleanup_steps", view); err != nil {
+		slog.ErrorContext(r.Context(), "Template execution failed", "error", err, "template", "category_cleanup_steps")
+	}
+}
+
+// handleMergeCategory files the expenses, recurrent expenses, rules,
+// keywords and views of a category pair under another category. Form
+// fields: primary, secondary, into (ID of the target secondary category).
+func (s *Server) handleMergeCategory(w http.ResponseWriter, r *http.Request) {
+	primary, secondary, ok := categoryForm(w, r)
+	if !ok {
+		return
+	}
+	into, err := strconv.ParseInt(r.Form.Get("into"), 10, 64)
+	if err != nil || into <= 0 || primary == "" || secondary == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Scegli la categoria in cui unire</div>`))
+		return
+	}
+	store, ok := s.categoryStore(w)
+	if !ok {
+		return
+	}
+
+	moved, err := store.MergeCategory(r.Context(), primary, secondary, into)
+	if err != nil {
+		writeCategoryError(w, r, err)
+		return
+	}
+	categoryChanged(w, "Categoria unita: "+strconv.Itoa(moved)+" spese spostate")
+}
+
+// handleDeleteDetachedCategory removes a secondary category left without
+// its primary. Form field: id.
+func (s *Server) handleDeleteDetachedCategory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if err != nil || id <= 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Categoria non valida</div>`))
+		return
+	}
+	store, ok := s.categoryStore(w)
+	if !ok {
+		return
+	}
+
+	if err := store.DeleteDetachedCategory(r.Context(), id); err != nil {
+		writeCategoryError(w, r, err)
+		return
+	}
+	categoryChanged(w, "Categoria eliminata")
+}
@@ -0,0 +1,253 @@
+package http
+
+import (
+	"context"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"spese/internal/classifier"
+	"spese/internal/core"
+	"spese/internal/storage"
+)
+
+// classifierReviewLimit bounds the expenses listed for review at once
+const classifierReviewLimit = 50
+
+// classifierStore returns the classifier and the repository of the
+// expenses it reviews, writing a 501 and returning false when the
+// classifier is not enabled.
+func (s *Server) classifierStore(w http.ResponseWriter) (*classifier.Classifier, *storage.SQLiteRepository, bool) {
+	store, ok := s.ruleStore(w)
+	if !ok {
+		return nil, nil, false
+	}
+	cl := s.categorizer.Classifier()
+	if cl == nil {
+		w.WriteHeader(http.StatusNotImplemented)
+		_, _ = w.Write([]byte(`<div class="error">Classificatore non attivo: imposta CLASSIFIER_ENABLED=true</div>`))
+		return nil, nil, false
+	}
+	return cl, store, true
+}
+
+// classifierRow is the view model of an expense waiting for review
+type classifierRow struct {
+	ID         string
+	Date       string
+	Desc       string
+	Amount     string
+	Primary    string // proposal, empty when there is none
+	Secondary  string
+	Source     string // regola, parola chiave, modello
+	Confidence string // 0-1, posted back with the verdict
+	Percent    string
+}
+
+// classifierView is the review page and list
+type classifierView struct {
+	Rows          []classifierRow
+	Examples      int
+	Accepted      int64
+	Taught        int64
+	Rejected      int64
+	Categories    []string
+	Subcategories []string
+}
+
+// proposeCategory returns the rule or keyword category for e or, without
+// one, the model's best guess whatever its confidence: on the review page
+// the user decides.
+func (s *Server) proposeCategory(ctx context.Context, cl *classifier.Classifier, e core.Expense) (classifierRow, error) {
+	var row classifierRow
+	confidence := 1.0
+	suggestion, err := s.categorizer.Suggest(ctx, e)
+	if err != nil {
+		return row, err
+	}
+	switch {
+	case suggestion != nil && suggestion.Rule != nil:
+		row.Primary, row.Secondary, row.Source = suggestion.Primary, suggestion.Secondary, "regola"
+	case suggestion != nil && suggestion.Keyword != nil:
+		row.Primary, row.Secondary, row.Source = suggestion.Primary, suggestion.Secondary, "parola chiave"
+	default:
+		p, ok, err := cl.Predict(ctx, e.Description)
+		if err != nil || !ok {
+			return row, err
+		}
+		row.Primary, row.Secondary, row.Source = p.Primary, p.Secondary, "modello"
+		confidence = p.Confidence
+	}
+	row.Confidence = strconv.FormatFloat(confidence, 'f', 4, 64)
+	row.Percent = strconv.Itoa(int(confidence*100+0.5)) + "%"
+	return row, nil
+}
+
+// loadClassifierReview builds the review list with its proposals and the
+// model statistics.
+func (s *Server) loadClassifierReview(ctx context.Context, cl *classifier.Classifier, store *storage.SQLiteRepository) (classifierView, error) {
+	var view classifierView
+
+	model, err := cl.Model(ctx)
+	if err != nil {
+		return view, err
+	}
+	view.Examples = model.Examples()
+
+	counts, err := store.ClassifierFeedbackCounts(ctx)
+	if err != nil {
+		return view, err
+	}
+	view.Accepted, view.Taught, view.Rejected = counts[storage.VerdictAccepted], counts[storage.VerdictTaught], counts[storage.VerdictRejected]
+
+	expenses, err := store.ListUncategorizedExpenses(ctx, classifierReviewLimit)
+	if err != nil {
+		return view, err
+	}
+	for _, e := range expenses {
+		row, err := s.proposeCategory(ctx, cl, e.Expense)
+		if err != nil {
+			return view, err
+		}
+		row.ID = e.ID
+		row.Date = e.Expense.Date.Format("02/01/2006")
+		row.Desc = e.Expense.Description
+		row.Amount = formatEuros(e.Expense.Amount.Cents)
+		view.Rows = append(view.Rows, row)
+	}
+	return view, nil
+}
+
+// handleClassifier renders the review page of uncategorized expenses
+func (s *Server) handleClassifier(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	cl, store, ok := s.classifierStore(w)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	view, err := s.loadClassifierReview(ctx, cl, store)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to load classifier review", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento delle proposte</div>`))
+		return
+	}
+
+	view.Categories, view.Subcategories, err = s.taxReader.List(ctx)
+	if err != nil {
+		// Suggestions only: the page works without them
+		slog.WarnContext(r.Context(), "Failed to load categories for classifier page", "error", err)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "classifier_page", view); err != nil {
+		slog.ErrorContext(r.Context(), "Classifier template execution failed", "error", err, "template", "classifier_page")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handleClassifierList renders the review list, refreshed after every
+// verdict
+func (s *Server) handleClassifierList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	cl, store, ok := s.classifierStore(w)
+	if !ok {
+		return
+	}
+
+	view, err := s.loadClassifierReview(r.Context(), cl, store)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to load classifier review", "error", err)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento delle proposte</div>`))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "classifier_list", view); err != nil {
+		slog.ErrorContext(r.Context(), "Template execution failed", "error", err, "template", "classifier_list")
+	}
+}
+
+// handleClassifierVerdict applies a verdict on a proposal. Accepting sets
+// the proposed category, teaching sets the one the user picked; both
+// become training data, so the model is retrained. Rejecting leaves the
+// expense uncategorized. Form fields: expense_id, verdict (accepted,
+// taught, rejected), primary, secondary, confidence.
+func (s *Server) handleClassifierVerdict(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	cl, store, ok := s.classifierStore(w)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+	id, ok := parseFormID(w, r, "expense_id", "ID spesa non valido")
+	if !ok {
+		return
+	}
+	verdict := r.Form.Get("verdict")
+	primary := sanitizeInput(r.Form.Get("primary"))
+	secondary := sanitizeInput(r.Form.Get("secondary"))
+	confidence, _ := strconv.ParseFloat(r.Form.Get("confidence"), 64)
+	if verdict == storage.VerdictTaught {
+		// The user's own pick, not the model's
+		confidence = 0
+	}
+
+	var err error
+	switch verdict {
+	case storage.VerdictAccepted, storage.VerdictTaught:
+		if primary == "" || secondary == "" || secondary == core.UncategorizedSecondary {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_, _ = w.Write([]byte(`<div class="error">Scegli categoria e sottocategoria</div>`))
+			return
+		}
+		err = store.RecategorizeExpense(r.Context(), id, primary, secondary, verdict, confidence)
+	case storage.VerdictRejected:
+		err = store.RejectClassification(r.Context(), id, primary, secondary, confidence)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Esito non valido</div>`))
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to record classifier verdict", "error", err, "expense_id", id, "verdict", verdict)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel salvataggio</div>`))
+		return
+	}
+
+	message := "Proposta ignorata"
+	if verdict != storage.VerdictRejected {
+		cl.Invalidate()
+		message = "Categoria impostata: " + primary + " / " + secondary
+	}
+	w.Header().Set("HX-Trigger", `{"classifier:changed": {}}`)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(`<div class="success">` + template.HTMLEscapeString(message) + `</div>`))
+}
@@ -3,12 +3,15 @@ package http
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"spese/internal/adapters"
+	"spese/internal/core"
 )
 
 // handleDashboard renders the main dashboard page
@@ -24,7 +27,14 @@ func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := s.templates.ExecuteTemplate(w, "dashboard_page", nil); err != nil {
+	// Members of a shared household can filter by who paid
+	data := struct {
+		Users []core.User
+	}{
+		Users: s.householdUsers(r.Context()),
+	}
+
+	if err := s.templates.ExecuteTemplate(w, "dashboard_page", data); err != nil {
 		slog.ErrorContext(r.Context(), "Dashboard template execution failed", "error", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
@@ -112,7 +122,19 @@ func (s *Server) handleDashboardStatHero(w http.ResponseWriter, r *http.Request)
 	}
 }
 
-// handleDashboardStatPills returns the stat pills partial (expenses + savings rate)
+// dashboardUser returns the household member the dashboard is filtered
+// by, from the user query parameter; 0 shows everyone's expenses
+func dashboardUser(r *http.Request) int64 {
+	id, err := strconv.ParseInt(r.URL.Query().Get("user"), 10, 64)
+	if err != nil || id < 0 {
+		return 0
+	}
+	return id
+}
+
+// handleDashboardStatPills returns the stat pills partial (expenses + savings rate).
+// With a user query parameter the expenses are the settled ones that
+// member paid.
 func (s *Server) handleDashboardStatPills(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		w.Header().Set("Allow", "GET")
@@ -135,6 +157,15 @@ func (s *Server) handleDashboardStatPills(w http.ResponseWriter, r *http.Request
 	// Get monthly totals
 	expenses, _ := adapter.GetMonthlyExpenseTotal(ctx, year, month)
 	income, _ := adapter.GetMonthlyIncomeTotal(ctx, year, month)
+	label := "Totale Spese"
+	if user := dashboardUser(r); user != 0 {
+		paid, _, err := adapter.GetStorage().MonthTotalsByPayer(ctx, year, month)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to get totals by payer", "error", err, "user_id", user)
+		}
+		expenses = paid[user]
+		label = "Spese pagate"
+	}
 
 	balance := income - expenses
 
@@ -145,9 +176,11 @@ func (s *Server) handleDashboardStatPills(w http.ResponseWriter, r *http.Request
 	}
 
 	data := struct {
+		ExpensesLabel string
 		TotalExpenses string
 		SavingsRate   int
 	}{
+		ExpensesLabel: label,
 		TotalExpenses: formatEuros(expenses),
 		SavingsRate:   savingsRate,
 	}
@@ -261,7 +294,8 @@ func (s *Server) handleDashboardTrend(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(points)
 }
 
-// handleDashboardCategoriesList returns category breakdown as HTML partial
+// handleDashboardCategoriesList returns category breakdown as HTML partial.
+// Query parameters: period, user (only what that member paid).
 func (s *Server) handleDashboardCategoriesList(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		w.Header().Set("Allow", "GET")
@@ -283,7 +317,13 @@ func (s *Server) handleDashboardCategoriesList(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	catData, err := adapter.GetCategoryBreakdown(ctx, period)
+	var catData []adapters.CategoryTotal
+	var err error
+	if user := dashboardUser(r); user != 0 {
+		catData, err = adapter.GetPayerCategoryBreakdown(ctx, period, user)
+	} else {
+		catData, err = adapter.GetCategoryBreakdown(ctx, period)
+	}
 	if err != nil {
 		slog.ErrorContext(ctx, "Failed to get category data", "error", err, "period", period)
 		catData = []adapters.CategoryTotal{}
@@ -303,6 +343,7 @@ func (s *Server) handleDashboardCategoriesList(w http.ResponseWriter, r *http.Re
 		Amount  string
 		Percent int
 	}
+	labels := s.categoryLabels(r)
 	var cats []catView
 	for _, c := range catData {
 		percent := 0
@@ -310,7 +351,7 @@ func (s *Server) handleDashboardCategoriesList(w http.ResponseWriter, r *http.Re
 			percent = int((c.AmountCents * 100) / maxAmount)
 		}
 		cats = append(cats, catView{
-			Name:    c.Name,
+			Name:    labels.PrimaryLabel(c.Name),
 			Amount:  formatEuros(c.AmountCents),
 			Percent: percent,
 		})
@@ -365,6 +406,9 @@ func (s *Server) handleDashboardRecurrents(w http.ResponseWriter, r *http.Reques
 		if r.Frequency == "yearly" {
 			freq = "annuale"
 		}
+		if r.Interval > 1 {
+			freq = strings.ToLower(scheduleLabel(core.RepetitionTypes(r.Frequency), r.Interval, 0))
+		}
 		recs = append(recs, recView{
 			ID:          r.ID,
 			Description: r.Description,
@@ -402,15 +446,21 @@ func (s *Server) handleFormExpense(w http.ResponseWriter, r *http.Request) {
 	}
 
 	data := struct {
+		entryDateView
 		Day        int
 		Month      int
 		Categories []string
 		Subcats    []string
+		Users      []core.User
+		Accounts   []core.Account
 	}{
-		Day:        now.Day(),
-		Month:      int(now.Month()),
-		Categories: cats,
-		Subcats:    []string{},
+		entryDateView: s.entryDateView(r.Context()),
+		Day:           now.Day(),
+		Month:         int(now.Month()),
+		Categories:    cats,
+		Subcats:       []string{},
+		Users:         s.householdUsers(r.Context()),
+		Accounts:      s.accounts(r.Context()),
 	}
 
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
@@ -444,10 +494,12 @@ func (s *Server) handleFormIncome(w http.ResponseWriter, r *http.Request) {
 		Day        int
 		Month      int
 		Categories []string
+		Accounts   []core.Account
 	}{
 		Day:        now.Day(),
 		Month:      int(now.Month()),
 		Categories: categories,
+		Accounts:   s.accounts(r.Context()),
 	}
 
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
@@ -525,8 +577,11 @@ func (s *Server) handleFormRecurrentEdit(w http.ResponseWriter, r *http.Request)
 		StartDate   string
 		EndDate     string
 		Frequency   string
+		Interval    int
+		DayOfMonth  int
 		Primary     string
 		Secondary   string
+		Version     int64
 		Categories  []string
 		Subcats     []string
 	}{
@@ -536,8 +591,11 @@ func (s *Server) handleFormRecurrentEdit(w http.ResponseWriter, r *http.Request)
 		StartDate:   expense.StartDate,
 		EndDate:     expense.EndDate,
 		Frequency:   expense.Frequency,
+		Interval:    expense.Interval,
+		DayOfMonth:  expense.DayOfMonth,
 		Primary:     expense.Category,
 		Secondary:   expense.Subcategory,
+		Version:     expense.Version,
 		Categories:  cats,
 		Subcats:     subs,
 	}
@@ -805,6 +863,9 @@ func (s *Server) handleDashboardRecurrentsWithSummary(w http.ResponseWriter, r *
 		} else if r.Frequency == "daily" {
 			freq = "giornaliero"
 		}
+		if r.Interval > 1 {
+			freq = strings.ToLower(scheduleLabel(core.RepetitionTypes(r.Frequency), r.Interval, 0))
+		}
 		recs = append(recs, recView{
 			ID:          r.ID,
 			Description: r.Description,
@@ -828,3 +889,232 @@ func (s *Server) handleDashboardRecurrentsWithSummary(w http.ResponseWriter, r *
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
 }
+
+// Window of the upcoming charges in days, by default and at most
+const (
+	defaultUpcomingDays = 90
+	maxUpcomingDays     = 366
+)
+
+// upcomingDays reads the days query parameter of the upcoming charges
+func upcomingDays(r *http.Request) (int, error) {
+	v := strings.TrimSpace(r.URL.Query().Get("days"))
+	if v == "" {
+		return defaultUpcomingDays, nil
+	}
+	days, err := strconv.Atoi(v)
+	if err != nil || days < 1 || days > maxUpcomingDays {
+		return 0, fmt.Errorf("invalid days (1-%d)", maxUpcomingDays)
+	}
+	return days, nil
+}
+
+// maxDashboardUpcoming is how many upcoming charges the dashboard lists
+const maxDashboardUpcoming = 8
+
+// handleDashboardUpcoming returns the upcoming recurring charges partial:
+// the expenses the active recurrents will create in the next days (query
+// parameter days, 90 by default), soonest first.
+func (s *Server) handleDashboardUpcoming(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	days, err := upcomingDays(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 7*time.Second)
+	defer cancel()
+
+	adapter, ok := s.expLister.(*adapters.SQLiteAdapter)
+	if !ok {
+		http.Error(w, "adapter not available", http.StatusInternalServerError)
+		return
+	}
+
+	recurrents, err := adapter.GetStorage().GetRecurrentExpenses(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get recurrent expenses", "error", err)
+		recurrents = nil
+	}
+	now := time.Now()
+	charges := core.UpcomingCharges(recurrents, now, now.AddDate(0, 0, days))
+
+	type chargeView struct {
+		Date        string
+		Description string
+		Category    string
+		Amount      string
+	}
+	var total int64
+	var views []chargeView
+	for i, c := range charges {
+		total += c.Amount.Cents
+		if i >= maxDashboardUpcoming {
+			continue
+		}
+		category := c.Primary
+		if c.Secondary != "" {
+			category += " / " + c.Secondary
+		}
+		views = append(views, chargeView{
+			Date:        c.Date.Format("02/01"),
+			Description: c.Description,
+			Category:    category,
+			Amount:      formatEuros(c.Amount.Cents),
+		})
+	}
+
+	data := struct {
+		Charges []chargeView
+		More    int
+		Total   string
+		Days    int
+	}{
+		Charges: views,
+		More:    len(charges) - len(views),
+		Total:   formatEuros(total),
+		Days:    days,
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "upcoming_charges", data); err != nil {
+		slog.ErrorContext(ctx, "Upcoming charges template failed", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// defaultCalendarDays is the window of the obligation calendar by default
+const defaultCalendarDays = 60
+
+// italianWeekdays are the short day names of the obligation calendar
+var italianWeekdays = [...]string{"dom", "lun", "mar", "mer", "gio", "ven", "sab"}
+
+// handleDashboardCalendar returns the obligation calendar partial: by day,
+// what the recurrent expenses and incomes will charge and pay in the next
+// days (query parameter days, 60 by default), with the balance projected
+// from the one of the current month so far.
+func (s *Server) handleDashboardCalendar(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	days := defaultCalendarDays
+	if r.URL.Query().Get("days") != "" {
+		var err error
+		if days, err = upcomingDays(r); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 7*time.Second)
+	defer cancel()
+
+	adapter, ok := s.expLister.(*adapters.SQLiteAdapter)
+	if !ok {
+		http.Error(w, "adapter not available", http.StatusInternalServerError)
+		return
+	}
+	store := adapter.GetStorage()
+
+	now := time.Now()
+	year, month := now.Year(), int(now.Month())
+	spent, err := adapter.GetMonthlyExpenseTotal(ctx, year, month)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get month expenses for the calendar", "error", err)
+	}
+	var earned int64
+	if incomes, err := store.ReadIncomeMonthOverview(ctx, year, month); err != nil {
+		slog.ErrorContext(ctx, "Failed to get month incomes for the calendar", "error", err)
+	} else {
+		earned = incomes.Total.Cents
+	}
+	recurrents, err := store.GetRecurrentExpenses(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get recurrent expenses", "error", err)
+	}
+	recurrentIncomes, err := store.GetRecurrentIncomes(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get recurrent incomes", "error", err)
+	}
+
+	opening := core.Money{Cents: earned - spent}
+	calendar := core.ObligationCalendar(recurrents, recurrentIncomes, now, now.AddDate(0, 0, days), opening)
+
+	type entryView struct {
+		Description string
+		Category    string
+		Amount      string
+		Income      bool
+	}
+	type dayView struct {
+		Label    string // e.g. "lun 27/03"
+		Entries  []entryView
+		Net      string
+		Balance  string
+		Negative bool // The projected balance is below zero
+	}
+	views := make([]dayView, len(calendar))
+	var charged, paid int64
+	for i, d := range calendar {
+		v := dayView{
+			Label:    italianWeekdays[d.Date.Weekday()] + " " + d.Date.Format("02/01"),
+			Net:      formatEuros(d.Net.Cents),
+			Balance:  formatEuros(d.Balance.Cents),
+			Negative: d.Balance.Cents < 0,
+		}
+		if d.Net.Cents > 0 {
+			v.Net = "+" + v.Net
+		}
+		for _, e := range d.Entries {
+			v.Entries = append(v.Entries, entryView{
+				Description: e.Description,
+				Category:    e.Category,
+				Amount:      formatEuros(e.Amount.Cents),
+				Income:      e.Income,
+			})
+			if e.Income {
+				paid += e.Amount.Cents
+			} else {
+				charged += e.Amount.Cents
+			}
+		}
+		views[i] = v
+	}
+
+	closing := opening
+	if len(calendar) > 0 {
+		closing = calendar[len(calendar)-1].Balance
+	}
+	data := struct {
+		Days      int
+		MonthName string
+		Opening   string
+		Closing   string
+		Negative  bool
+		Charged   string
+		Paid      string
+		Calendar  []dayView
+	}{
+		Days:      days,
+		MonthName: italianMonths[month-1],
+		Opening:   formatEuros(opening.Cents),
+		Closing:   formatEuros(closing.Cents),
+		Negative:  closing.Cents < 0,
+		Charged:   formatEuros(charged),
+		Paid:      formatEuros(paid),
+		Calendar:  views,
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "obligation_calendar", data); err != nil {
+		slog.ErrorContext(ctx, "Obligation calendar template failed", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
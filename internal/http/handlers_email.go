@@ -0,0 +1,100 @@
+package http
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"spese/internal/email"
+)
+
+// emailLabels name the email kinds on the preview page
+var emailLabels = map[email.Kind]string{
+	email.KindDigest:      "Riepilogo mensile",
+	email.KindBudgetAlert: "Avviso di spesa",
+	email.KindSyncFailed:  "Sincronizzazione non riuscita",
+}
+
+// emailPreviewRow is the view model of an email kind on the preview page
+type emailPreviewRow struct {
+	Kind    email.Kind
+	Label   string
+	Subject string
+}
+
+// requestBaseURL returns the address the request reached the app at, for
+// absolute links
+func requestBaseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil || strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https") {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+// renderSampleEmail renders an email kind with its sample data
+func (s *Server) renderSampleEmail(r *http.Request, kind email.Kind) (email.Message, error) {
+	data, err := email.Sample(kind, time.Now())
+	if err != nil {
+		return email.Message{}, err
+	}
+	return s.emails.Render(kind, requestBaseURL(r), data)
+}
+
+// handleEmailPreviews lists the email templates with links to their
+// previews
+func (s *Server) handleEmailPreviews(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	rows := make([]emailPreviewRow, 0, len(email.Kinds))
+	for _, kind := range email.Kinds {
+		msg, err := s.renderSampleEmail(r, kind)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Email template rendering failed", "error", err, "kind", kind)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		rows = append(rows, emailPreviewRow{Kind: kind, Label: emailLabels[kind], Subject: msg.Subject})
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "email_preview_page", rows); err != nil {
+		slog.ErrorContext(r.Context(), "Email preview template execution failed", "error", err, "template", "email_preview_page")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handleEmailPreview renders an email with sample data. Query: kind,
+// format ("text" for the plain-text version, HTML otherwise).
+func (s *Server) handleEmailPreview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	msg, err := s.renderSampleEmail(r, email.Kind(r.URL.Query().Get("kind")))
+	if errors.Is(err, email.ErrUnknownKind) {
+		http.Error(w, "unknown email", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Email template rendering failed", "error", err, "kind", r.URL.Query().Get("kind"))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if r.URL.Query().Get("format") == "text" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = w.Write([]byte("Oggetto: " + msg.Subject + "\n\n" + msg.Text))
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(msg.HTML))
+}
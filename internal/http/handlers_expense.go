@@ -2,7 +2,9 @@ package http
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
 	"io"
@@ -11,14 +13,80 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
-	"sync/atomic"
 	"time"
 
 	"spese/internal/adapters"
 	"spese/internal/core"
+	"spese/internal/events"
+	"spese/internal/hooks"
 	"spese/internal/sheets"
+	"spese/internal/storage"
 )
 
+// SetEntryDefaults sets the hour before which new entries default to the
+// previous day (0 disables) and whether the expense form offers a year
+// selector.
+func (s *Server) SetEntryDefaults(dayStartHour int, yearSelection bool) {
+	s.dayStartHour = dayStartHour
+	s.yearSelection = yearSelection
+}
+
+// entryDate returns the date new entries default to.
+func (s *Server) entryDate() core.Date {
+	return core.EntryDate(s.now(), s.dayStartHour)
+}
+
+// minEntryYear is the earliest year the entry forms accept
+const minEntryYear = 2000
+
+// entryDateView is the date part of the expense form
+type entryDateView struct {
+	Date         string // Default date, YYYY-MM-DD
+	Years        []int  // Years of the selector; empty without year selection
+	ClosedMonths string // Comma-separated periods closed by their review
+}
+
+// entryDateView returns the default date of the expense form, the years
+// of its selector (the previous one and that of the default date) and the
+// closed months it warns about.
+func (s *Server) entryDateView(ctx context.Context) entryDateView {
+	def := s.entryDate()
+	v := entryDateView{Date: def.Format("2006-01-02")}
+	if s.yearSelection {
+		v.Years = []int{def.Year() - 1, def.Year()}
+	}
+	if adapter, ok := s.expWriter.(*adapters.SQLiteAdapter); ok {
+		months, err := adapter.GetStorage().ListClosedMonths(ctx)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to load closed months for the entry form", "error", err)
+		}
+		periods := make([]string, len(months))
+		for i, m := range months {
+			periods[i] = m.Period
+		}
+		v.ClosedMonths = strings.Join(periods, ",")
+	}
+	return v
+}
+
+// entryYear returns the year of a new entry: the form's year field, else
+// the year of the default date. It writes a 422 and returns false for a
+// year before minEntryYear or after the next one. Closed months are
+// refused when the entry is stored.
+func entryYear(w http.ResponseWriter, r *http.Request, def core.Date) (int, bool) {
+	v := strings.TrimSpace(r.Form.Get("year"))
+	if v == "" {
+		return def.Year(), true
+	}
+	year, err := strconv.Atoi(v)
+	if err != nil || year < minEntryYear || year > def.Year()+1 {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`<div class="error">Anno non valido</div>`))
+		return 0, false
+	}
+	return year, true
+}
+
 func (s *Server) handleCreateExpense(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		w.Header().Set("Allow", "POST")
@@ -32,9 +100,9 @@ func (s *Server) handleCreateExpense(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	now := time.Now()
-	day := now.Day()
-	month := int(now.Month())
+	def := s.entryDate()
+	day := def.Day()
+	month := def.Month()
 	if v := strings.TrimSpace(r.Form.Get("day")); v != "" {
 		if d, err := strconv.Atoi(v); err == nil {
 			day = d
@@ -45,6 +113,11 @@ func (s *Server) handleCreateExpense(w http.ResponseWriter, r *http.Request) {
 			month = m
 		}
 	}
+	year, ok := entryYear(w, r, def)
+	if !ok {
+		s.countEntry("expense", "create", outcomeValidationError, 1)
+		return
+	}
 
 	desc := sanitizeInput(r.Form.Get("description"))
 	amountStr := strings.TrimSpace(r.Form.Get("amount"))
@@ -53,25 +126,63 @@ func (s *Server) handleCreateExpense(w http.ResponseWriter, r *http.Request) {
 
 	cents, err := core.ParseDecimalToCents(amountStr)
 	if err != nil {
+		s.countEntry("expense", "create", outcomeValidationError, 1)
 		w.WriteHeader(http.StatusUnprocessableEntity)
 		_, _ = w.Write([]byte(`<div class="error">Importo non valido</div>`))
 		return
 	}
+	paidBy, ok := parsePaidBy(w, r)
+	if !ok {
+		s.countEntry("expense", "create", outcomeValidationError, 1)
+		return
+	}
+	accountID, ok := parseAccountID(w, r)
+	if !ok {
+		s.countEntry("expense", "create", outcomeValidationError, 1)
+		return
+	}
 
 	exp := core.Expense{
-		Date:        core.NewDate(time.Now().Year(), month, day),
+		Date:        core.NewDate(year, month, day),
 		Description: desc,
 		Amount:      core.Money{Cents: cents},
 		Primary:     primary,
 		Secondary:   secondary,
+		PaidBy:      paidBy,
+		AccountID:   accountID,
+		Tags:        core.ParseTags(sanitizeInput(r.Form.Get("tags"))),
 	}
 	if err := exp.Validate(); err != nil {
+		s.countEntry("expense", "create", outcomeValidationError, 1)
 		w.WriteHeader(http.StatusUnprocessableEntity)
 		_, _ = w.Write([]byte(`<div class="error">Invalid data: ` + template.HTMLEscapeString(err.Error()) + `</div>`))
 		return
 	}
+	// An expense that looks already registered is saved only once confirmed
+	if r.Form.Get("confirm_duplicate") != "true" {
+		if similar := s.similarExpenses(r.Context(), exp); len(similar) > 0 {
+			s.countEntry("expense", "create", outcomeValidationError, 1)
+			s.writeDuplicateWarning(w, r, similar)
+			return
+		}
+	}
 
 	ref, err := s.expWriter.Append(r.Context(), exp)
+	s.countEntryErr("expense", "create", err)
+	if errors.Is(err, hooks.ErrRejected) {
+		slog.InfoContext(r.Context(), "Expense rejected by hook", "error", err, "component", "expense_writer")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`<div class="error">` + template.HTMLEscapeString(err.Error()) + `</div>`))
+		return
+	}
+	if errors.Is(err, core.ErrMonthClosed) {
+		writeMonthClosed(w)
+		return
+	}
+	if errors.Is(err, core.ErrQuotaExceeded) {
+		writeQuotaExceeded(w, r, err)
+		return
+	}
 	if err != nil {
 		slog.ErrorContext(r.Context(), "Failed to save expense",
 			"error", err,
@@ -86,7 +197,7 @@ func (s *Server) handleCreateExpense(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	atomic.AddInt64(&s.appMetrics.totalExpenses, 1)
+	s.events.Publish(events.ExpenseCreated, events.ExpenseFrom(exp))
 
 	slog.InfoContext(r.Context(), "Expense created successfully",
 		"expense_description", exp.Description,
@@ -106,6 +217,49 @@ func (s *Server) handleCreateExpense(w http.ResponseWriter, r *http.Request) {
 	_, _ = w.Write([]byte(""))
 }
 
+// similarExpenses returns the stored expenses a new one may duplicate: same
+// day and amount, similar description. Only the SQLite backend can tell; a
+// failed lookup lets the expense through.
+func (s *Server) similarExpenses(ctx context.Context, exp core.Expense) []core.ReviewExpense {
+	adapter, ok := s.expWriter.(*adapters.SQLiteAdapter)
+	if !ok {
+		return nil
+	}
+	similar, err := adapter.GetStorage().FindSimilarExpenses(ctx, exp)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to look for duplicate expenses", "error", err)
+		return nil
+	}
+	return similar
+}
+
+// writeDuplicateWarning answers 409 with the similar expenses and a button
+// that saves the new one anyway.
+func (s *Server) writeDuplicateWarning(w http.ResponseWriter, r *http.Request, similar []core.ReviewExpense) {
+	type item struct {
+		Date string
+		Desc string
+		Amt  string
+		Cat  string
+	}
+	items := make([]item, len(similar))
+	for i, e := range similar {
+		items[i] = item{
+			Date: e.Date.Format("02/01/2006"),
+			Desc: e.Description,
+			Amt:  formatEuros(e.Amount.Cents),
+			Cat:  e.Primary + " / " + e.Secondary,
+		}
+	}
+	slog.InfoContext(r.Context(), "Possible duplicate expense held for confirmation", "similar", len(similar), "first_id", similar[0].ID)
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusConflict)
+	if err := s.templates.ExecuteTemplate(w, "duplicate_warning", struct{ Items []item }{items}); err != nil {
+		slog.ErrorContext(r.Context(), "Template execution failed", "error", err, "template", "duplicate_warning")
+	}
+}
+
 func (s *Server) handleDeleteExpense(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodDelete && r.Method != http.MethodPost {
 		w.Header().Set("Allow", "DELETE, POST")
@@ -186,6 +340,11 @@ func (s *Server) handleDeleteExpense(w http.ResponseWriter, r *http.Request) {
 	}
 
 	err := s.expDeleter.DeleteExpense(r.Context(), expenseID)
+	s.countEntryErr("expense", "delete", err)
+	if errors.Is(err, core.ErrMonthClosed) {
+		writeMonthClosed(w)
+		return
+	}
 	if err != nil {
 		slog.ErrorContext(r.Context(), "Failed to delete expense",
 			"error", err,
@@ -197,7 +356,7 @@ func (s *Server) handleDeleteExpense(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	atomic.AddInt64(&s.appMetrics.totalExpenses, -1)
+	s.events.Publish(events.ExpenseDeleted, events.RefPayload{ID: expenseID})
 
 	slog.InfoContext(r.Context(), "Expense deleted successfully",
 		"expense_id", expenseID,
@@ -207,14 +366,279 @@ func (s *Server) handleDeleteExpense(w http.ResponseWriter, r *http.Request) {
 	now := time.Now()
 	year := now.Year()
 	month := int(now.Month())
+	// The SQLite backend keeps the expense in the trash, so the page can
+	// offer to take it back for a few seconds
+	undo := ""
+	if _, ok := s.expWriter.(*adapters.SQLiteAdapter); ok {
+		undo = fmt.Sprintf(`,
+		"undo:show": {"message": "Spesa eliminata", "url": "/expenses/restore", "id": %q, "seconds": %d}`, expenseID, undoSeconds)
+	}
 	w.Header().Set("HX-Trigger", fmt.Sprintf(`{
 		"expense:deleted": {"year": %d, "month": %d},
-		"overview:refresh": {"year": %d, "month": %d}
-	}`, year, month, year, month))
+		"overview:refresh": {"year": %d, "month": %d}%s
+	}`, year, month, year, month, undo))
 	w.WriteHeader(http.StatusOK)
 	_, _ = w.Write([]byte(""))
 }
 
+// handleExpenseItem serves /expenses/{id}: PUT replaces the expense, and
+// GET /expenses/{id}/edit renders its inline edit form.
+func (s *Server) handleExpenseItem(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/expenses/"), "/"), "/")
+	id, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || id <= 0 || len(parts) > 2 || (len(parts) == 2 && parts[1] != "edit") {
+		http.NotFound(w, r)
+		return
+	}
+
+	if len(parts) == 2 {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", "GET")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		s.handleExpenseEdit(w, r, id)
+		return
+	}
+
+	if r.Method != http.MethodPut {
+		w.Header().Set("Allow", "PUT")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	s.handleUpdateExpense(w, r, id)
+}
+
+// handleExpenseEdit renders the inline edit form of an expense
+func (s *Server) handleExpenseEdit(w http.ResponseWriter, r *http.Request, id int64) {
+	adapter, ok := s.expWriter.(*adapters.SQLiteAdapter)
+	if !ok {
+		w.WriteHeader(http.StatusNotImplemented)
+		_, _ = w.Write([]byte(`<div class="error">Modifica delle spese disponibile solo con il backend SQLite</div>`))
+		return
+	}
+
+	expense, err := adapter.GetStorage().GetExpense(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`<div class="error">Spesa non trovata</div>`))
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to load expense", "error", err, "expense_id", id)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento della spesa</div>`))
+		return
+	}
+
+	cats, _, err := s.taxReader.List(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to load categories", "error", err)
+		// Continue without categories
+	}
+	subs, err := adapter.GetSecondariesByPrimary(r.Context(), expense.PrimaryCategory)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to load secondary categories", "error", err, "primary", expense.PrimaryCategory)
+	}
+	tags, err := adapter.GetStorage().ExpenseTags(r.Context(), id)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to load expense tags", "error", err, "expense_id", id)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento della spesa</div>`))
+		return
+	}
+
+	data := struct {
+		ID          int64
+		Version     int64
+		Date        string
+		Year        int
+		Month       int
+		Description string
+		Amount      string
+		Primary     string
+		Secondary   string
+		Categories  []string
+		Subcats     []string
+		PaidBy      int64
+		Users       []core.User
+		AccountID   int64
+		Accounts    []core.Account
+		Tags        string
+	}{
+		ID:          expense.ID,
+		Version:     expense.Version,
+		Date:        expense.Date.Format("2006-01-02"),
+		Year:        expense.Date.Year(),
+		Month:       int(expense.Date.Month()),
+		Description: expense.Description,
+		Amount:      formatDecimal(expense.AmountCents),
+		Primary:     expense.PrimaryCategory,
+		Secondary:   expense.SecondaryCategory,
+		Categories:  cats,
+		Subcats:     subs,
+		PaidBy:      expense.PaidBy.Int64,
+		Users:       s.householdUsers(r.Context()),
+		AccountID:   expense.AccountID.Int64,
+		Accounts:    s.accounts(r.Context()),
+		Tags:        strings.Join(tags, ", "),
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "expense_edit_form", data); err != nil {
+		slog.ErrorContext(r.Context(), "Template execution failed", "error", err, "template", "expense_edit_form")
+	}
+}
+
+// handleUpdateExpense replaces an expense. Form fields: date (YYYY-MM-DD),
+// description, amount, primary, secondary, paid_by (member ID, empty for
+// nobody), tags (comma-separated) and version, the version the
+// edit was based on (or an If-Match header).
+func (s *Server) handleUpdateExpense(w http.ResponseWriter, r *http.Request, id int64) {
+	adapter, ok := s.expWriter.(*adapters.SQLiteAdapter)
+	if !ok {
+		w.WriteHeader(http.StatusNotImplemented)
+		_, _ = w.Write([]byte(`<div class="error">Modifica delle spese disponibile solo con il backend SQLite</div>`))
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+
+	date, err := parseDate(strings.TrimSpace(r.Form.Get("date")))
+	if err != nil {
+		s.countEntry("expense", "update", outcomeValidationError, 1)
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`<div class="error">Data non valida</div>`))
+		return
+	}
+	cents, err := core.ParseDecimalToCents(strings.TrimSpace(r.Form.Get("amount")))
+	if err != nil {
+		s.countEntry("expense", "update", outcomeValidationError, 1)
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`<div class="error">Importo non valido</div>`))
+		return
+	}
+	paidBy, ok := parsePaidBy(w, r)
+	if !ok {
+		s.countEntry("expense", "update", outcomeValidationError, 1)
+		return
+	}
+	accountID, ok := parseAccountID(w, r)
+	if !ok {
+		s.countEntry("expense", "update", outcomeValidationError, 1)
+		return
+	}
+
+	exp := core.Expense{
+		Date:        date,
+		Description: sanitizeInput(r.Form.Get("description")),
+		Amount:      core.Money{Cents: cents},
+		Primary:     sanitizeInput(r.Form.Get("primary")),
+		Secondary:   sanitizeInput(r.Form.Get("secondary")),
+		PaidBy:      paidBy,
+		AccountID:   accountID,
+		Tags:        core.ParseTags(sanitizeInput(r.Form.Get("tags"))),
+	}
+	if err := exp.Validate(); err != nil {
+		s.countEntry("expense", "update", outcomeValidationError, 1)
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`<div class="error">Dati non validi: ` + template.HTMLEscapeString(err.Error()) + `</div>`))
+		return
+	}
+
+	version, ok := parseExpectedVersion(r)
+	if !ok {
+		w.WriteHeader(http.StatusPreconditionRequired)
+		_, _ = w.Write([]byte(`<div class="error">Versione mancante, ricarica la pagina e riprova</div>`))
+		return
+	}
+
+	ref := strconv.FormatInt(id, 10)
+	err = adapter.UpdateExpense(r.Context(), ref, exp, version)
+	s.countEntryErr("expense", "update", err)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`<div class="error">Spesa non trovata</div>`))
+		return
+	case errors.Is(err, core.ErrVersionConflict):
+		slog.InfoContext(r.Context(), "Expense update conflict", "expense_id", id, "expected_version", version)
+		w.WriteHeader(http.StatusConflict)
+		_, _ = w.Write([]byte(`<div class="error">La spesa è stata modificata altrove, ricarica la pagina</div>`))
+		return
+	case errors.Is(err, core.ErrMonthClosed):
+		writeMonthClosed(w)
+		return
+	case errors.Is(err, core.ErrInvalidSplit):
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`<div class="error">Importo diverso dalla somma delle parti: modifica prima la divisione</div>`))
+		return
+	case errors.Is(err, hooks.ErrRejected):
+		slog.InfoContext(r.Context(), "Expense update rejected by hook", "error", err, "expense_id", id)
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`<div class="error">` + template.HTMLEscapeString(err.Error()) + `</div>`))
+		return
+	case err != nil:
+		slog.ErrorContext(r.Context(), "Failed to update expense", "error", err, "expense_id", id)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nell'aggiornare la spesa</div>`))
+		return
+	}
+
+	s.events.Publish(events.ExpenseUpdated, events.RefPayload{ID: ref})
+	slog.InfoContext(r.Context(), "Expense updated", "expense_id", id, "amount_cents", exp.Amount.Cents)
+
+	w.Header().Set("HX-Trigger", fmt.Sprintf(`{
+		"overview:refresh": {"year": %d, "month": %d},
+		"dashboard:refresh": {}
+	}`, exp.Date.Year(), exp.Date.Month()))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(""))
+}
+
+// handleClearExpense marks a pending card hold as cleared, keeping its
+// date and amount. Form fields: id.
+func (s *Server) handleClearExpense(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	adapter, ok := s.expWriter.(*adapters.SQLiteAdapter)
+	if !ok {
+		w.WriteHeader(http.StatusNotImplemented)
+		_, _ = w.Write([]byte(`<div class="error">Spese in sospeso disponibili solo con il backend SQLite</div>`))
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+	id, ok := parseFormID(w, r, "id", "ID spesa non valido")
+	if !ok {
+		return
+	}
+
+	if err := adapter.GetStorage().ClearExpense(r.Context(), id); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to clear expense", "error", err, "expense_id", id)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nella contabilizzazione della spesa</div>`))
+		return
+	}
+
+	now := time.Now()
+	w.Header().Set("HX-Trigger", fmt.Sprintf(`{"overview:refresh": {"year": %d, "month": %d}}`, now.Year(), int(now.Month())))
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(`<div class="success">Spesa contabilizzata</div>`))
+}
+
 func (s *Server) getOverview(ctx context.Context, year, month int) (core.MonthOverview, error) {
 	if s.dashReader == nil {
 		return core.MonthOverview{Year: year, Month: month}, nil
@@ -296,6 +720,7 @@ func (s *Server) handleMonthOverview(w http.ResponseWriter, r *http.Request) {
 		Name, Amount string
 		Width        int
 	}
+	labels := s.categoryLabels(r)
 	data := struct {
 		Year    int
 		Month   int
@@ -304,14 +729,15 @@ func (s *Server) handleMonthOverview(w http.ResponseWriter, r *http.Request) {
 		Max     string
 		Rows    []row
 		Items   []struct {
-			ID   string
-			Day  int
-			Desc string
-			Amt  string
-			Cat  string
-			Sub  string
-		}
-	}{Year: ov.Year, Month: ov.Month, Total: formatEuros(ov.Total.Cents), MaxName: maxName, Max: formatEuros(maxCents)}
+			ID      string
+			Day     int
+			Desc    string
+			Amt     string
+			Cat     string
+			Sub     string
+			Pending bool
+		}
+	}{Year: ov.Year, Month: ov.Month, Total: formatEuros(ov.Total.Cents), MaxName: labels.PrimaryLabel(maxName), Max: formatEuros(maxCents)}
 	for _, r := range ov.ByCategory {
 		width := 0
 		if maxCents > 0 && r.Amount.Cents > 0 {
@@ -323,7 +749,7 @@ func (s *Server) handleMonthOverview(w http.ResponseWriter, r *http.Request) {
 				width = 100
 			}
 		}
-		data.Rows = append(data.Rows, row{Name: r.Name, Amount: formatEuros(r.Amount.Cents), Width: width})
+		data.Rows = append(data.Rows, row{Name: labels.PrimaryLabel(r.Name), Amount: formatEuros(r.Amount.Cents), Width: width})
 	}
 	if s.expListerWithID != nil {
 		itemsWithID, err := s.getExpensesWithID(r.Context(), year, month)
@@ -332,13 +758,14 @@ func (s *Server) handleMonthOverview(w http.ResponseWriter, r *http.Request) {
 		} else {
 			for _, e := range itemsWithID {
 				data.Items = append(data.Items, struct {
-					ID   string
-					Day  int
-					Desc string
-					Amt  string
-					Cat  string
-					Sub  string
-				}{ID: e.ID, Day: e.Expense.Date.Day(), Desc: template.HTMLEscapeString(e.Expense.Description), Amt: formatEuros(e.Expense.Amount.Cents), Cat: e.Expense.Primary, Sub: e.Expense.Secondary})
+					ID      string
+					Day     int
+					Desc    string
+					Amt     string
+					Cat     string
+					Sub     string
+					Pending bool
+				}{ID: e.ID, Day: e.Expense.Date.Day(), Desc: template.HTMLEscapeString(e.Expense.Description), Amt: formatEuros(e.Expense.Amount.Cents), Cat: labels.PrimaryLabel(e.Expense.Primary), Sub: labels.SecondaryLabel(e.Expense.Secondary), Pending: e.Expense.IsPending()})
 			}
 		}
 	}
@@ -383,9 +810,9 @@ func (s *Server) handleGetSecondaryCategories(w http.ResponseWriter, r *http.Req
 
 		_, _ = w.Write([]byte(`<option value="">Seleziona sottocategoria</option>`))
 
+		labels := s.categoryLabels(r)
 		for _, secondary := range secondaries {
-			escapedSecondary := template.HTMLEscapeString(secondary)
-			_, _ = w.Write([]byte(fmt.Sprintf(`<option value="%s">%s</option>`, escapedSecondary, escapedSecondary)))
+			_, _ = w.Write([]byte(fmt.Sprintf(`<option value="%s">%s</option>`, template.HTMLEscapeString(secondary), template.HTMLEscapeString(labels.SecondaryLabel(secondary)))))
 		}
 
 		slog.InfoContext(r.Context(), "Returned filtered secondary categories",
@@ -452,8 +879,24 @@ func (s *Server) handleGetAllCategories(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// Keys stay in primary and secondaries, as submitted with the form; the
+	// labels are what the picker shows in the request's language
+	type labeledCat struct {
+		storage.CategoryWithSubs
+		Label           string            `json:"label"`
+		SecondaryLabels map[string]string `json:"secondary_labels"`
+	}
+	labels := s.categoryLabels(r)
+	result := make([]labeledCat, len(categories))
+	for i, c := range categories {
+		result[i] = labeledCat{CategoryWithSubs: c, Label: labels.PrimaryLabel(c.Primary), SecondaryLabels: make(map[string]string, len(c.Secondaries))}
+		for _, sub := range c.Secondaries {
+			result[i].SecondaryLabels[sub] = labels.SecondaryLabel(sub)
+		}
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(categories)
+	json.NewEncoder(w).Encode(result)
 }
 
 func (s *Server) handleFormReset(w http.ResponseWriter, r *http.Request) {
@@ -471,13 +914,17 @@ func (s *Server) handleFormReset(w http.ResponseWriter, r *http.Request) {
 	}
 
 	data := struct {
+		entryDateView
 		Day        int
 		Month      int
 		Categories []string
+		Accounts   []core.Account
 	}{
-		Day:        now.Day(),
-		Month:      int(now.Month()),
-		Categories: cats,
+		entryDateView: s.entryDateView(r.Context()),
+		Day:           now.Day(),
+		Month:         int(now.Month()),
+		Categories:    cats,
+		Accounts:      s.accounts(r.Context()),
 	}
 
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
@@ -575,6 +1022,7 @@ func (s *Server) handleMonthCategories(w http.ResponseWriter, r *http.Request) {
 		Width        int
 	}
 
+	labels := s.categoryLabels(r)
 	var rows []row
 	for _, r := range ov.ByCategory {
 		width := 0
@@ -587,7 +1035,7 @@ func (s *Server) handleMonthCategories(w http.ResponseWriter, r *http.Request) {
 				width = 100
 			}
 		}
-		rows = append(rows, row{Name: r.Name, Amount: formatEuros(r.Amount.Cents), Width: width})
+		rows = append(rows, row{Name: labels.PrimaryLabel(r.Name), Amount: formatEuros(r.Amount.Cents), Width: width})
 	}
 
 	data := struct {
@@ -631,12 +1079,15 @@ func (s *Server) handleMonthExpenses(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var items []struct {
-		ID   string
-		Day  int
-		Desc string
-		Amt  string
-		Cat  string
-		Sub  string
+		ID      string
+		Day     int
+		Desc    string
+		Amt     string
+		Cat     string
+		Sub     string
+		Pending bool
+		Receipt *receiptView
+		Parts   []string // Of a split expense
 	}
 
 	if s.expListerWithID != nil {
@@ -644,21 +1095,30 @@ func (s *Server) handleMonthExpenses(w http.ResponseWriter, r *http.Request) {
 		if err != nil {
 			slog.ErrorContext(r.Context(), "List expenses with ID error", "error", err, "year", year, "month", month)
 		} else {
+			labels := s.categoryLabels(r)
+			receipts := s.monthReceipts(r.Context(), year, month)
+			splits := s.monthSplits(r.Context(), year, month)
 			for _, e := range itemsWithID {
 				items = append(items, struct {
-					ID   string
-					Day  int
-					Desc string
-					Amt  string
-					Cat  string
-					Sub  string
+					ID      string
+					Day     int
+					Desc    string
+					Amt     string
+					Cat     string
+					Sub     string
+					Pending bool
+					Receipt *receiptView
+					Parts   []string
 				}{
-					ID:   e.ID,
-					Day:  e.Expense.Date.Day(),
-					Desc: template.HTMLEscapeString(e.Expense.Description),
-					Amt:  formatEuros(e.Expense.Amount.Cents),
-					Cat:  e.Expense.Primary,
-					Sub:  e.Expense.Secondary,
+					ID:      e.ID,
+					Day:     e.Expense.Date.Day(),
+					Desc:    template.HTMLEscapeString(e.Expense.Description),
+					Amt:     formatEuros(e.Expense.Amount.Cents),
+					Cat:     labels.PrimaryLabel(e.Expense.Primary),
+					Sub:     labels.SecondaryLabel(e.Expense.Secondary),
+					Pending: e.Expense.IsPending(),
+					Receipt: receipts[e.ID],
+					Parts:   splits[e.ID],
 				})
 			}
 		}
@@ -667,16 +1127,21 @@ func (s *Server) handleMonthExpenses(w http.ResponseWriter, r *http.Request) {
 	data := struct {
 		Month int
 		Items []struct {
-			ID   string
-			Day  int
-			Desc string
-			Amt  string
-			Cat  string
-			Sub  string
+			ID      string
+			Day     int
+			Desc    string
+			Amt     string
+			Cat     string
+			Sub     string
+			Pending bool
+			Receipt *receiptView
+			Parts   []string // Of a split expense
 		}
+		Receipts bool // Receipts can be attached
 	}{
-		Month: month,
-		Items: items,
+		Month:    month,
+		Items:    items,
+		Receipts: s.receipts != nil,
 	}
 
 	if err := s.templates.ExecuteTemplate(w, "month_expenses", data); err != nil {
@@ -684,3 +1149,157 @@ func (s *Server) handleMonthExpenses(w http.ResponseWriter, r *http.Request) {
 		_, _ = w.Write([]byte(`<div class="expenses"><div class="row placeholder">Errore template</div></div>`))
 	}
 }
+
+// historyChange is the view model of a single field change
+type historyChange struct {
+	Field string
+	Old   string
+	New   string
+}
+
+// historyVersion is the view model of an expense version
+type historyVersion struct {
+	Version   int64
+	ChangedBy string
+	ChangedAt string
+	Changes   []historyChange
+}
+
+// historyFieldLabels maps stored field names to the labels shown in the panel
+var historyFieldLabels = map[string]string{
+	"date":               "Data",
+	"description":        "Descrizione",
+	"amount_cents":       "Importo",
+	"primary_category":   "Categoria",
+	"secondary_category": "Sottocategoria",
+}
+
+// handleExpenseHistory renders the version history panel of an expense (SQLite only)
+func (s *Server) handleExpenseHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+	adapter, ok := s.expWriter.(*adapters.SQLiteAdapter)
+	if !ok {
+		w.WriteHeader(http.StatusNotImplemented)
+		_, _ = w.Write([]byte(`<div class="error">Storico non disponibile</div>`))
+		return
+	}
+
+	id, err := strconv.ParseInt(strings.TrimSpace(r.URL.Query().Get("id")), 10, 64)
+	if err != nil || id <= 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">ID spesa non valido</div>`))
+		return
+	}
+
+	entries, err := adapter.GetStorage().ListExpenseVersions(r.Context(), id)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "List expense versions error", "error", err, "expense_id", id)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore caricamento storico</div>`))
+		return
+	}
+
+	versions := make([]historyVersion, 0, len(entries))
+	for _, e := range entries {
+		v := historyVersion{
+			Version:   e.Version,
+			ChangedBy: e.ChangedBy,
+			ChangedAt: e.ChangedAt.Local().Format("02/01/2006 15:04"),
+		}
+		for _, field := range []string{"date", "description", "amount_cents", "primary_category", "secondary_category"} {
+			change, ok := e.Changes[field]
+			if !ok {
+				continue
+			}
+			v.Changes = append(v.Changes, historyChange{
+				Field: historyFieldLabels[field],
+				Old:   formatHistoryValue(field, change.Old),
+				New:   formatHistoryValue(field, change.New),
+			})
+		}
+		versions = append(versions, v)
+	}
+
+	data := struct {
+		ID          int64
+		Versions    []historyVersion
+		Calculation string
+		Shopping    string   // Name of the list the expense was converted from
+		Items       []string // Its checked items
+		Lines       []string // Receipt line items
+		Usage       string   // Consumption billed by a utility expense
+		Fuel        string   // Liters and odometer reading of a fuel expense
+	}{
+		ID:       id,
+		Versions: versions,
+	}
+	if calc, err := adapter.GetStorage().GetExpenseCalculation(r.Context(), id); err != nil {
+		slog.ErrorContext(r.Context(), "Get expense calculation error", "error", err, "expense_id", id)
+	} else if calc != nil {
+		data.Calculation = formatCalculation(*calc)
+	}
+	if lines, err := adapter.GetStorage().ListExpenseLineItems(r.Context(), id); err != nil {
+		slog.ErrorContext(r.Context(), "List expense line items error", "error", err, "expense_id", id)
+	} else {
+		for _, l := range lines {
+			data.Lines = append(data.Lines, formatLineItem(l))
+		}
+	}
+	usage, err := adapter.GetStorage().GetExpenseUsage(r.Context(), id)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Get expense usage error", "error", err, "expense_id", id)
+	}
+	fill, err := adapter.GetStorage().GetFuelFill(r.Context(), id)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Get fuel fill error", "error", err, "expense_id", id)
+	}
+	if usage != nil || fill != nil {
+		if expense, err := adapter.GetStorage().GetExpense(r.Context(), id); err != nil {
+			slog.ErrorContext(r.Context(), "Get expense error", "error", err, "expense_id", id)
+		} else {
+			amount := core.Money{Cents: expense.AmountCents}
+			if usage != nil {
+				data.Usage = formatUsage(*usage, amount)
+			}
+			if fill != nil {
+				data.Fuel = formatFuelFill(*fill, amount)
+			}
+		}
+	}
+	if list, err := adapter.GetStorage().GetShoppingListByExpense(r.Context(), id); err != nil {
+		slog.ErrorContext(r.Context(), "Get expense shopping list error", "error", err, "expense_id", id)
+	} else if list != nil {
+		data.Shopping = list.Name
+		for _, item := range list.Items {
+			if item.Checked {
+				data.Items = append(data.Items, formatShoppingItem(item))
+			}
+		}
+	}
+
+	if err := s.templates.ExecuteTemplate(w, "expense_history", data); err != nil {
+		slog.ErrorContext(r.Context(), "Expense history template execution failed", "error", err)
+		_, _ = w.Write([]byte(`<div class="error">Errore template</div>`))
+	}
+}
+
+// formatHistoryValue renders a stored history value for display
+func formatHistoryValue(field string, v any) string {
+	if v == nil {
+		return ""
+	}
+	if field == "amount_cents" {
+		// JSON numbers decode as float64; amounts are whole cents
+		if f, ok := v.(float64); ok {
+			return formatEuros(int64(f))
+		}
+	}
+	return fmt.Sprint(v)
+}
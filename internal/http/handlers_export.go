@@ -0,0 +1,225 @@
+package http
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"spese/internal/adapters"
+	"spese/internal/core"
+	"spese/internal/export"
+	"spese/internal/services"
+)
+
+// maxExportMonths bounds how many months a single export may span
+const maxExportMonths = 120
+
+// SetExportHashKey makes anonymized merchant tokens stable across exports.
+// Without a key every export hashes with a fresh random one.
+func (s *Server) SetExportHashKey(key string) {
+	s.exportHashKey = key
+}
+
+// handleAnonymizedExport downloads expenses with descriptions replaced by
+// hashed merchant tokens. Query parameters: from, to (YYYY-MM-DD, default
+// the current year up to today) and format (csv or json, default csv).
+func (s *Server) handleAnonymizedExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+	format := strings.ToLower(strings.TrimSpace(q.Get("format")))
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "json" {
+		http.Error(w, "format must be csv or json", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	from := time.Date(now.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if v := strings.TrimSpace(q.Get("from")); v != "" {
+		d, err := parseDate(v)
+		if err != nil {
+			http.Error(w, "invalid from date", http.StatusBadRequest)
+			return
+		}
+		from = d.Time
+	}
+	if v := strings.TrimSpace(q.Get("to")); v != "" {
+		d, err := parseDate(v)
+		if err != nil {
+			http.Error(w, "invalid to date", http.StatusBadRequest)
+			return
+		}
+		to = d.Time
+	}
+	if to.Before(from) {
+		http.Error(w, "from must not be after to", http.StatusBadRequest)
+		return
+	}
+	months := (to.Year()-from.Year())*12 + int(to.Month()-from.Month()) + 1
+	if months > maxExportMonths {
+		http.Error(w, fmt.Sprintf("range too large: at most %d months", maxExportMonths), http.StatusBadRequest)
+		return
+	}
+
+	filename := fmt.Sprintf("spese-anonimizzate-%s-%s.%s", from.Format("20060102"), to.Format("20060102"), format)
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	w.Header().Set("Cache-Control", "no-store")
+
+	sw := newStreamWriter(w)
+	var rw export.RecordWriter
+	if format == "json" {
+		w.Header().Set("Content-Type", "application/json")
+		rw = export.NewJSONWriter(sw)
+	} else {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		rw = export.NewCSVWriter(sw)
+	}
+
+	anonymizer := export.NewAnonymizer(s.exportHashKey)
+	records := 0
+	write := func(e core.Expense) error {
+		records++
+		return rw.Write(anonymizer.Record(e))
+	}
+
+	var err error
+	if adapter, ok := s.expWriter.(*adapters.SQLiteAdapter); ok {
+		// Straight from the cursor, oldest first
+		err = adapter.GetStorage().EachExpenseBetween(r.Context(), from, to, write)
+	} else {
+		err = s.eachExpenseByMonth(r, from, to, months, write)
+	}
+	if err == nil {
+		err = rw.Close()
+	}
+	if err != nil {
+		sw.fail(r, "Anonymized export failed", err)
+		return
+	}
+	slog.InfoContext(r.Context(), "Anonymized export served", "records", records, "format", format, "from", from.Format("2006-01-02"), "to", to.Format("2006-01-02"))
+}
+
+// eachExpenseByMonth walks the expenses dated from through to month by month
+// through the lister, so every backend can be exported. Each month is sorted
+// oldest first to match the SQLite stream.
+func (s *Server) eachExpenseByMonth(r *http.Request, from, to time.Time, months int, fn func(core.Expense) error) error {
+	for m := 0; m < months; m++ {
+		month := time.Date(from.Year(), from.Month()+time.Month(m), 1, 0, 0, 0, 0, time.UTC)
+		items, err := s.expLister.ListExpenses(r.Context(), month.Year(), int(month.Month()))
+		if err != nil {
+			return fmt.Errorf("list expenses for %d-%02d: %w", month.Year(), int(month.Month()), err)
+		}
+		slices.SortStableFunc(items, func(a, b core.Expense) int {
+			return a.Date.Compare(b.Date.Time)
+		})
+		for _, e := range items {
+			if e.Date.Year() != month.Year() || e.Date.Month() != int(month.Month()) {
+				continue
+			}
+			if e.Date.Before(from) || e.Date.After(to) {
+				continue
+			}
+			if err := fn(e); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// SetParquetExporter enables /export/parquet. Without it the route answers 501.
+func (s *Server) SetParquetExporter(p *services.ParquetExporter) {
+	s.parquetExporter = p
+}
+
+// handleParquetExport downloads a full dataset as a Parquet file.
+// Query parameters: dataset (expenses or incomes, default expenses).
+func (s *Server) handleParquetExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if s.parquetExporter == nil {
+		http.Error(w, "parquet export requires the SQLite backend", http.StatusNotImplemented)
+		return
+	}
+
+	dataset := strings.TrimSpace(r.URL.Query().Get("dataset"))
+	if dataset == "" {
+		dataset = "expenses"
+	}
+	if !slices.Contains(services.ParquetDatasets, dataset) {
+		http.Error(w, "dataset must be one of "+strings.Join(services.ParquetDatasets, ", "), http.StatusBadRequest)
+		return
+	}
+
+	filename := fmt.Sprintf("spese-%s-%s.parquet", dataset, time.Now().Format("20060102"))
+	w.Header().Set("Content-Type", "application/vnd.apache.parquet")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	w.Header().Set("Cache-Control", "no-store")
+
+	sw := newStreamWriter(w)
+	if err := s.parquetExporter.Write(r.Context(), dataset, sw); err != nil {
+		sw.fail(r, "Parquet export failed", err, "dataset", dataset)
+	}
+}
+
+// streamFlushBytes is how much output an export buffers before flushing it
+// to the client.
+const streamFlushBytes = 32 << 10
+
+// streamWriter sends an export to the client while it is produced, flushing
+// every streamFlushBytes so large downloads start at once and memory stays
+// flat whatever the size of the dataset.
+type streamWriter struct {
+	w       http.ResponseWriter
+	rc      *http.ResponseController
+	written int64
+	flushed int64
+}
+
+func newStreamWriter(w http.ResponseWriter) *streamWriter {
+	return &streamWriter{w: w, rc: http.NewResponseController(w)}
+}
+
+func (sw *streamWriter) Write(p []byte) (int, error) {
+	n, err := sw.w.Write(p)
+	sw.written += int64(n)
+	if err == nil && sw.written-sw.flushed >= streamFlushBytes {
+		// ErrNotSupported only means the bytes go out when the handler returns
+		_ = sw.rc.Flush()
+		sw.flushed = sw.written
+	}
+	return n, err
+}
+
+// fail reports an export error. Before the first byte the client still gets
+// a 500; afterwards the status is gone, so the connection is aborted and the
+// client sees a truncated download rather than a file that looks complete.
+func (sw *streamWriter) fail(r *http.Request, msg string, err error, attrs ...any) {
+	attrs = append(attrs, "error", err, "bytes", sw.written)
+	if sw.written == 0 {
+		slog.ErrorContext(r.Context(), msg, attrs...)
+		sw.w.Header().Del("Content-Disposition")
+		http.Error(sw.w, "export failed", http.StatusInternalServerError)
+		return
+	}
+	if r.Context().Err() != nil {
+		slog.WarnContext(r.Context(), msg+": client went away", attrs...)
+	} else {
+		slog.ErrorContext(r.Context(), msg, attrs...)
+	}
+	panic(http.ErrAbortHandler)
+}
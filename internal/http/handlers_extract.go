@@ -0,0 +1,91 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"spese/internal/llm"
+)
+
+// extractTimeout bounds a language model call, which can be slow on a
+// local model
+const extractTimeout = 60 * time.Second
+
+// SetExtractor enables reading receipts and statement lines with a
+// language model. Without it receipts are read by the line parser and
+// /api/v1/extract answers 501.
+func (s *Server) SetExtractor(p llm.Provider) {
+	s.extractor = p
+}
+
+// extract asks the language model to read text, offering the taxonomy as
+// the categories to choose from.
+func (s *Server) extract(ctx context.Context, text string) (llm.Extraction, error) {
+	ctx, cancel := context.WithTimeout(ctx, extractTimeout)
+	defer cancel()
+
+	primaries, secondaries, err := s.taxReader.List(ctx)
+	if err != nil {
+		// The model can still read merchant and items
+		slog.WarnContext(ctx, "Failed to load categories for extraction", "error", err)
+	}
+	return s.extractor.Extract(ctx, llm.Request{Text: text, Primaries: primaries, Secondaries: secondaries})
+}
+
+type extractItem struct {
+	Description string  `json:"description"`
+	Quantity    float64 `json:"quantity"`
+	AmountCents int64   `json:"amount_cents"`
+}
+
+type extractResponse struct {
+	Merchant  string        `json:"merchant"`
+	Primary   string        `json:"primary"`
+	Secondary string        `json:"secondary"`
+	Items     []extractItem `json:"items"`
+}
+
+// handleExtract reads a receipt or statement text with the language model
+// and returns the merchant, the items and a category, for import tools.
+// Body: {"text": "..."}.
+func (s *Server) handleExtract(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if s.extractor == nil {
+		writeJSONError(w, http.StatusNotImplemented, "language model not configured")
+		return
+	}
+
+	var req struct {
+		Text string `json:"text"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, batchMaxBody)).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	out, err := s.extract(r.Context(), req.Text)
+	if errors.Is(err, llm.ErrEmptyText) {
+		writeJSONError(w, http.StatusBadRequest, "empty text")
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Extraction failed", "error", err, "component", "llm")
+		writeJSONError(w, http.StatusBadGateway, "language model unavailable")
+		return
+	}
+
+	resp := extractResponse{Merchant: out.Merchant, Primary: out.Primary, Secondary: out.Secondary, Items: make([]extractItem, len(out.Items))}
+	for i, it := range out.Items {
+		resp.Items[i] = extractItem{Description: it.Description, Quantity: it.Quantity, AmountCents: it.Amount.Cents}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
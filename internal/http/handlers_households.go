@@ -0,0 +1,321 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"spese/internal/adapters"
+	"spese/internal/core"
+	"spese/internal/storage"
+)
+
+// settlementBalance is a member's line in the settlement of a month
+type settlementBalance struct {
+	Name  string
+	Paid  string
+	Share string
+	Net   string
+	Owed  bool // The others owe them
+	Owes  bool // They owe the others
+}
+
+// settlementTransfer is a payment evening out the month
+type settlementTransfer struct {
+	From   string
+	To     string
+	Amount string
+}
+
+// settlementView is who owes whom for the expenses of a month
+type settlementView struct {
+	Year       int
+	Month      int
+	MonthName  string
+	Balances   []settlementBalance
+	Transfers  []settlementTransfer
+	Unassigned string // Settled expenses with no payer, left out of the split
+}
+
+// householdStore returns the SQLite repository holding the household
+// members, writing a 501 and returning false for other backends.
+func (s *Server) householdStore(w http.ResponseWriter) (*storage.SQLiteRepository, bool) {
+	adapter, ok := s.expWriter.(*adapters.SQLiteAdapter)
+	if !ok {
+		w.WriteHeader(http.StatusNotImplemented)
+		_, _ = w.Write([]byte(`<div class="error">Famiglia disponibile solo con il backend SQLite</div>`))
+		return nil, false
+	}
+	return adapter.GetStorage(), true
+}
+
+// householdUsers returns the household members, none when the backend
+// has no users. Forms and the dashboard only offer a payer when some
+// exist.
+func (s *Server) householdUsers(ctx context.Context) []core.User {
+	adapter, ok := s.expWriter.(*adapters.SQLiteAdapter)
+	if !ok {
+		return nil
+	}
+	users, err := adapter.GetStorage().ListUsers(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to load household members", "error", err)
+		return nil
+	}
+	return users
+}
+
+// parsePaidBy reads the paid_by form field: the ID of the member who paid,
+// 0 when empty. ok is false, with a 422 written, for anything else.
+func parsePaidBy(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	v := strings.TrimSpace(r.Form.Get("paid_by"))
+	if v == "" {
+		return 0, true
+	}
+	id, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || id <= 0 {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`<div class="error">Pagante non valido</div>`))
+		return 0, false
+	}
+	return id, true
+}
+
+// loadSettlement splits the settled expenses of a month among the members
+func loadSettlement(ctx context.Context, store *storage.SQLiteRepository, users []core.User, year, month int) (settlementView, error) {
+	first := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	year, month = first.Year(), int(first.Month())
+	view := settlementView{Year: year, Month: month, MonthName: italianMonths[month-1]}
+	paid, unassigned, err := store.MonthTotalsByPayer(ctx, year, month)
+	if err != nil {
+		return view, err
+	}
+	view.Unassigned = formatEuros(unassigned)
+
+	names := make(map[int64]string, len(users))
+	members := make([]int64, len(users))
+	for i, u := range users {
+		names[u.ID] = u.Name
+		members[i] = u.ID
+	}
+	balances, transfers := core.Settle(members, paid)
+	for _, b := range balances {
+		view.Balances = append(view.Balances, settlementBalance{
+			Name:  names[b.User],
+			Paid:  formatEuros(b.Paid.Cents),
+			Share: formatEuros(b.Share.Cents),
+			Net:   formatEuros(b.Net.Cents),
+			Owed:  b.Net.Cents > 0,
+			Owes:  b.Net.Cents < 0,
+		})
+	}
+	for _, t := range transfers {
+		view.Transfers = append(view.Transfers, settlementTransfer{
+			From:   names[t.From],
+			To:     names[t.To],
+			Amount: formatEuros(t.Amount.Cents),
+		})
+	}
+	return view, nil
+}
+
+// handleHousehold renders the household page: its members and the
+// settlement of a month. Query parameters: year, month (default current).
+func (s *Server) handleHousehold(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.householdStore(w)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	users, err := store.ListUsers(ctx)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list household members", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento della famiglia</div>`))
+		return
+	}
+	year, month := parseYearMonth(r)
+	settlement, err := loadSettlement(ctx, store, users, year, month)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to load settlement", "error", err, "year", year, "month", month)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel calcolo dei conti</div>`))
+		return
+	}
+
+	prev := time.Date(settlement.Year, time.Month(settlement.Month)-1, 1, 0, 0, 0, 0, time.UTC)
+	next := time.Date(settlement.Year, time.Month(settlement.Month)+1, 1, 0, 0, 0, 0, time.UTC)
+	data := struct {
+		Users      []core.User
+		Settlement settlementView
+		PrevYear   int
+		PrevMonth  int
+		NextYear   int
+		NextMonth  int
+	}{
+		Users:      users,
+		Settlement: settlement,
+		PrevYear:   prev.Year(),
+		PrevMonth:  int(prev.Month()),
+		NextYear:   next.Year(),
+		NextMonth:  int(next.Month()),
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "household_page", data); err != nil {
+		slog.ErrorContext(r.Context(), "Household template execution failed", "error", err, "template", "household_page")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handleHouseholdList renders the members table, refreshed after every
+// change
+func (s *Server) handleHouseholdList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.householdStore(w)
+	if !ok {
+		return
+	}
+
+	users, err := store.ListUsers(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list household members", "error", err)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento della famiglia</div>`))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "household_list", users); err != nil {
+		slog.ErrorContext(r.Context(), "Template execution failed", "error", err, "template", "household_list")
+	}
+}
+
+// handleHouseholdSettlement renders who owes whom for a month, refreshed
+// after every change to the members. Query parameters: year, month.
+func (s *Server) handleHouseholdSettlement(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.householdStore(w)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	users, err := store.ListUsers(ctx)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list household members", "error", err)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento della famiglia</div>`))
+		return
+	}
+	year, month := parseYearMonth(r)
+	settlement, err := loadSettlement(ctx, store, users, year, month)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to load settlement", "error", err, "year", year, "month", month)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel calcolo dei conti</div>`))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "household_settlement", settlement); err != nil {
+		slog.ErrorContext(r.Context(), "Template execution failed", "error", err, "template", "household_settlement")
+	}
+}
+
+// handleCreateUser adds a household member. Form fields: name.
+func (s *Server) handleCreateUser(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.householdStore(w)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+
+	user, err := store.CreateUser(r.Context(), sanitizeInput(r.Form.Get("name")))
+	if errors.Is(err, core.ErrInvalidUser) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`<div class="error">Nome non valido: serve un nome di al massimo ` + strconv.Itoa(core.MaxUserNameLength) + ` caratteri</div>`))
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to create household member", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel salvataggio (il nome è già usato?)</div>`))
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Household member created", "user_id", user.ID)
+	w.Header().Set("HX-Trigger", `{"household:changed": {}}`)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(`<div class="success">Membro aggiunto</div>`))
+}
+
+// handleDeleteUser removes a household member, keeping their expenses
+// with no payer. Form fields: id.
+func (s *Server) handleDeleteUser(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.householdStore(w)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+	id, ok := parseFormID(w, r, "id", "ID membro non valido")
+	if !ok {
+		return
+	}
+
+	if err := store.DeleteUser(r.Context(), id); err != nil {
+		slog.WarnContext(r.Context(), "Failed to delete household member", "error", err, "user_id", id)
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`<div class="error">Membro non trovato</div>`))
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Household member deleted", "user_id", id)
+	w.Header().Set("HX-Trigger", `{"household:changed": {}}`)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(`<div class="success">Membro rimosso</div>`))
+}
@@ -0,0 +1,306 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"spese/internal/core"
+	"spese/internal/hooks"
+	"spese/internal/services"
+	"spese/internal/storage"
+)
+
+// CSV import limits
+const (
+	csvImportMaxBody = 5 << 20
+	// csvPreviewNewRows is how many rows without problems the preview
+	// shows; invalid rows and duplicates are always listed
+	csvPreviewNewRows = 50
+	// csvImportBatches is how many past imports the import page lists
+	csvImportBatches = 20
+)
+
+// SetCSVImporter enables the CSV import page. Without it the import
+// routes answer 501.
+func (s *Server) SetCSVImporter(c *services.CSVImporter) {
+	s.csvImporter = c
+}
+
+// csvPreviewRow is the view model of a row of an uploaded file
+type csvPreviewRow struct {
+	Line     int
+	Date     string
+	Desc     string
+	Amount   string
+	Category string
+	Status   string
+	Class    string // "error", "duplicate" or empty for a new expense
+}
+
+// csvImporterReady writes a 501 and returns false when the import is not
+// available
+func (s *Server) csvImporterReady(w http.ResponseWriter) bool {
+	if s.csvImporter == nil {
+		w.WriteHeader(http.StatusNotImplemented)
+		_, _ = w.Write([]byte(`<div class="error">Importazione CSV disponibile solo con il backend SQLite</div>`))
+		return false
+	}
+	return true
+}
+
+// handleImport serves the upload page (GET) and turns an uploaded CSV file
+// into a preview to confirm (POST, multipart field file)
+func (s *Server) handleImport(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		if !s.csvImporterReady(w) {
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := s.templates.ExecuteTemplate(w, "csv_import_page", nil); err != nil {
+			slog.ErrorContext(r.Context(), "CSV import template execution failed", "error", err, "template", "csv_import_page")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	case http.MethodPost:
+		s.handleImportPreview(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleImportPreview(w http.ResponseWriter, r *http.Request) {
+	if !s.csvImporterReady(w) {
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, csvImportMaxBody)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			_, _ = w.Write([]byte(`<div class="error">File troppo grande (massimo 5 MB)</div>`))
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Seleziona un file CSV</div>`))
+		return
+	}
+	defer file.Close()
+
+	preview, err := s.csvImporter.Preview(r.Context(), header.Filename, file)
+	if err != nil {
+		slog.WarnContext(r.Context(), "CSV import refused", "error", err, "file", header.Filename)
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">File non valido: ` + template.HTMLEscapeString(err.Error()) + `</div>`))
+		return
+	}
+
+	data := struct {
+		Token      string
+		Filename   string
+		Valid      int
+		New        int
+		Duplicates int
+		Invalid    int
+		Rows       []csvPreviewRow
+		Hidden     int // New rows not shown
+	}{
+		Token:      preview.Token,
+		Filename:   preview.Filename,
+		Valid:      preview.Valid,
+		New:        preview.Valid - preview.Duplicates,
+		Duplicates: preview.Duplicates,
+		Invalid:    preview.Invalid,
+	}
+	shown := 0
+	for _, row := range preview.Rows {
+		item := csvPreviewRow{Line: row.Line, Status: "Nuova"}
+		if row.Err == nil || !row.Expense.Date.IsZero() {
+			item.Date = row.Expense.Date.Format("02/01/2006")
+			item.Desc = row.Expense.Description
+			item.Amount = formatEuros(row.Expense.Amount.Cents)
+			item.Category = row.Expense.Primary + " / " + row.Expense.Secondary
+		}
+		switch {
+		case row.Err != nil:
+			item.Status, item.Class = row.Err.Error(), "error"
+		case row.Duplicate:
+			item.Status, item.Class = "Già presente", "duplicate"
+		case shown == csvPreviewNewRows:
+			data.Hidden++
+			continue
+		default:
+			shown++
+		}
+		data.Rows = append(data.Rows, item)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "csv_import_preview", data); err != nil {
+		slog.ErrorContext(r.Context(), "Template execution failed", "error", err, "template", "csv_import_preview")
+	}
+}
+
+// handleImportConfirm creates the rows of a previewed file. Form fields:
+// token, duplicates ("on" to import the rows already present too).
+func (s *Server) handleImportConfirm(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.csvImporterReady(w) {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+
+	result, err := s.csvImporter.Confirm(r.Context(), r.Form.Get("token"), r.Form.Get("duplicates") == "on")
+	switch {
+	case errors.Is(err, services.ErrCSVPreviewNotFound):
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`<div class="error">Anteprima scaduta o già importata: carica di nuovo il file</div>`))
+		return
+	case errors.Is(err, services.ErrCSVNothingToImport):
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`<div class="error">Nessuna riga da importare</div>`))
+		return
+	case errors.Is(err, hooks.ErrRejected):
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`<div class="error">Importazione rifiutata: ` + template.HTMLEscapeString(err.Error()) + `</div>`))
+		return
+	case errors.Is(err, core.ErrMonthClosed):
+		writeMonthClosed(w)
+		return
+	case errors.Is(err, core.ErrQuotaExceeded):
+		writeQuotaExceeded(w, r, err)
+		return
+	case err != nil:
+		slog.ErrorContext(r.Context(), "Failed to confirm CSV import", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore durante l'importazione</div>`))
+		return
+	}
+
+	message := strconv.Itoa(result.Created) + " spese importate"
+	if result.SkippedDuplicates > 0 {
+		message += ", " + strconv.Itoa(result.SkippedDuplicates) + " già presenti saltate"
+	}
+	if result.SkippedInvalid > 0 {
+		message += ", " + strconv.Itoa(result.SkippedInvalid) + " righe non valide saltate"
+	}
+	var rollback bytes.Buffer
+	if err := s.templates.ExecuteTemplate(&rollback, "csv_import_rollback", result.BatchID); err != nil {
+		slog.ErrorContext(r.Context(), "Template execution failed", "error", err, "template", "csv_import_rollback")
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("HX-Trigger", `{"imports:changed": {}}`)
+	_, _ = w.Write([]byte(`<div class="success">` + message + `</div>`))
+	_, _ = w.Write(rollback.Bytes())
+}
+
+// importBatchView is a past import as the import page lists it
+type importBatchView struct {
+	ID         int64
+	Filename   string
+	Created    string
+	Expenses   int64
+	Remaining  int64 // Expenses of the import not deleted since
+	RolledBack string
+}
+
+// handleImportBatches renders the latest imports with their rollback
+// buttons, refreshed after every import or rollback
+func (s *Server) handleImportBatches(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.csvImporterReady(w) {
+		return
+	}
+
+	batches, err := s.csvImporter.Batches(r.Context(), csvImportBatches)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list import batches", "error", err)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento delle importazioni</div>`))
+		return
+	}
+	views := make([]importBatchView, 0, len(batches))
+	for _, b := range batches {
+		views = append(views, importBatchViewOf(b))
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "csv_import_batches", views); err != nil {
+		slog.ErrorContext(r.Context(), "Template execution failed", "error", err, "template", "csv_import_batches")
+	}
+}
+
+func importBatchViewOf(b storage.ListImportBatchesRow) importBatchView {
+	view := importBatchView{
+		ID:        b.ID,
+		Filename:  b.Filename,
+		Created:   b.CreatedAt.Local().Format("02/01/2006 15:04"),
+		Expenses:  b.Expenses,
+		Remaining: b.Remaining,
+	}
+	if b.RolledBackAt.Valid {
+		view.RolledBack = b.RolledBackAt.Time.Local().Format("02/01/2006 15:04")
+	}
+	return view
+}
+
+// handleImportRollback deletes the expenses an import created, with their
+// Google Sheets rows. Form field: id (import batch).
+func (s *Server) handleImportRollback(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.csvImporterReady(w) {
+		return
+	}
+	id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if err != nil || id <= 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Importazione non valida</div>`))
+		return
+	}
+
+	deleted, err := s.csvImporter.Rollback(r.Context(), id)
+	switch {
+	case errors.Is(err, storage.ErrImportBatchNotFound):
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`<div class="error">Importazione non trovata</div>`))
+		return
+	case errors.Is(err, storage.ErrImportBatchRolledBack):
+		w.WriteHeader(http.StatusConflict)
+		_, _ = w.Write([]byte(`<div class="error">Importazione già annullata</div>`))
+		return
+	case errors.Is(err, core.ErrMonthClosed):
+		writeMonthClosed(w)
+		return
+	case err != nil:
+		slog.ErrorContext(r.Context(), "Failed to roll back import", "error", err, "batch_id", id)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore durante l'annullamento</div>`))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("HX-Trigger", `{"imports:changed": {}}`)
+	_, _ = w.Write([]byte(`<div class="success">Importazione annullata: ` + strconv.Itoa(deleted) + ` spese eliminate</div>`))
+}
@@ -2,6 +2,7 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
 	"io"
@@ -14,8 +15,33 @@ import (
 
 	"spese/internal/adapters"
 	"spese/internal/core"
+	"spese/internal/events"
+	"spese/internal/storage"
 )
 
+// incomeItem is an income row of the monthly detail lists
+type incomeItem struct {
+	ID   string
+	Day  int
+	Desc string
+	Amt  string
+	Cat  string
+	Sub  string
+	Tags []string
+}
+
+func incomeItemFrom(inc storage.IncomeWithID) incomeItem {
+	return incomeItem{
+		ID:   inc.ID,
+		Day:  inc.Income.Date.Day(),
+		Desc: template.HTMLEscapeString(inc.Income.Description),
+		Amt:  formatEuros(inc.Income.Amount.Cents),
+		Cat:  inc.Income.Category,
+		Sub:  inc.Income.Subcategory,
+		Tags: inc.Income.Tags,
+	}
+}
+
 func (s *Server) handleIncomes(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		w.Header().Set("Allow", "GET")
@@ -45,14 +71,19 @@ func (s *Server) handleIncomes(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	year, month := parseYearMonth(r)
 	data := struct {
 		Day        int
 		Month      int
 		Categories []string
+		Accounts   []core.Account
+		Nav        monthNav
 	}{
 		Day:        now.Day(),
 		Month:      int(now.Month()),
 		Categories: categories,
+		Accounts:   s.accounts(r.Context()),
+		Nav:        newMonthNav("/entrate", year, month, now),
 	}
 
 	if err := s.templates.ExecuteTemplate(w, "income_page", data); err != nil {
@@ -74,9 +105,9 @@ func (s *Server) handleCreateIncome(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	now := time.Now()
-	day := now.Day()
-	month := int(now.Month())
+	today := s.entryDate()
+	day := today.Day()
+	month := today.Month()
 	if v := strings.TrimSpace(r.Form.Get("day")); v != "" {
 		if d, err := strconv.Atoi(v); err == nil {
 			day = d
@@ -87,25 +118,42 @@ func (s *Server) handleCreateIncome(w http.ResponseWriter, r *http.Request) {
 			month = m
 		}
 	}
+	year, ok := entryYear(w, r, today)
+	if !ok {
+		s.countEntry("income", "create", outcomeValidationError, 1)
+		return
+	}
 
 	desc := sanitizeInput(r.Form.Get("description"))
 	amountStr := strings.TrimSpace(r.Form.Get("amount"))
 	category := sanitizeInput(r.Form.Get("category"))
+	subcategory := sanitizeInput(r.Form.Get("subcategory"))
+	tags := core.ParseTags(sanitizeInput(r.Form.Get("tags")))
 
 	cents, err := core.ParseDecimalToCents(amountStr)
 	if err != nil {
+		s.countEntry("income", "create", outcomeValidationError, 1)
 		w.WriteHeader(http.StatusUnprocessableEntity)
 		_, _ = w.Write([]byte(`<div class="error">Importo non valido</div>`))
 		return
 	}
+	accountID, ok := parseAccountID(w, r)
+	if !ok {
+		s.countEntry("income", "create", outcomeValidationError, 1)
+		return
+	}
 
 	income := core.Income{
-		Date:        core.NewDate(time.Now().Year(), month, day),
+		Date:        core.NewDate(year, month, day),
 		Description: desc,
 		Amount:      core.Money{Cents: cents},
 		Category:    category,
+		Subcategory: subcategory,
+		AccountID:   accountID,
+		Tags:        tags,
 	}
 	if err := income.Validate(); err != nil {
+		s.countEntry("income", "create", outcomeValidationError, 1)
 		w.WriteHeader(http.StatusUnprocessableEntity)
 		_, _ = w.Write([]byte(`<div class="error">Dati non validi: ` + template.HTMLEscapeString(err.Error()) + `</div>`))
 		return
@@ -121,12 +169,18 @@ func (s *Server) handleCreateIncome(w http.ResponseWriter, r *http.Request) {
 	}
 
 	ref, err := adapter.AppendIncome(r.Context(), income)
+	s.countEntryErr("income", "create", err)
+	if errors.Is(err, core.ErrMonthClosed) {
+		writeMonthClosed(w)
+		return
+	}
 	if err != nil {
 		slog.ErrorContext(r.Context(), "Failed to save income",
 			"error", err,
 			"income_description", income.Description,
 			"amount_cents", income.Amount.Cents,
 			"category", income.Category,
+			"subcategory", income.Subcategory,
 			"component", "income_writer",
 			"operation", "append")
 		w.WriteHeader(http.StatusInternalServerError)
@@ -134,6 +188,8 @@ func (s *Server) handleCreateIncome(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	s.events.Publish(events.IncomeCreated, events.IncomeFrom(income))
+
 	// Log successful income creation
 	slog.InfoContext(r.Context(), "Income created successfully",
 		"income_description", income.Description,
@@ -217,6 +273,11 @@ func (s *Server) handleDeleteIncome(w http.ResponseWriter, r *http.Request) {
 	}
 
 	err := adapter.DeleteIncome(r.Context(), incomeID)
+	s.countEntryErr("income", "delete", err)
+	if errors.Is(err, core.ErrMonthClosed) {
+		writeMonthClosed(w)
+		return
+	}
 	if err != nil {
 		slog.ErrorContext(r.Context(), "Failed to delete income",
 			"error", err,
@@ -226,6 +287,7 @@ func (s *Server) handleDeleteIncome(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	s.events.Publish(events.IncomeDeleted, events.RefPayload{ID: incomeID})
 	slog.InfoContext(r.Context(), "Income deleted successfully", "income_id", incomeID)
 
 	now := time.Now()
@@ -289,13 +351,9 @@ func (s *Server) handleIncomeMonthOverview(w http.ResponseWriter, r *http.Reques
 		MaxName string
 		Max     string
 		Rows    []row
-		Items   []struct {
-			ID   string
-			Day  int
-			Desc string
-			Amt  string
-			Cat  string
-		}
+		Items   []incomeItem
+		Subs    []row
+		Tags    []row
 	}{Year: ov.Year, Month: ov.Month, Total: formatEuros(ov.Total.Cents), MaxName: maxName, Max: formatEuros(maxCents)}
 
 	for _, cat := range ov.ByCategory {
@@ -311,6 +369,12 @@ func (s *Server) handleIncomeMonthOverview(w http.ResponseWriter, r *http.Reques
 		}
 		data.Rows = append(data.Rows, row{Name: cat.Name, Amount: formatEuros(cat.Amount.Cents), Width: width})
 	}
+	for _, sub := range ov.BySubcategory {
+		data.Subs = append(data.Subs, row{Name: sub.Category + " / " + sub.Subcategory, Amount: formatEuros(sub.Amount.Cents)})
+	}
+	for _, tag := range ov.ByTag {
+		data.Tags = append(data.Tags, row{Name: tag.Name, Amount: formatEuros(tag.Amount.Cents)})
+	}
 
 	// Fetch detailed items with IDs
 	itemsWithID, err := adapter.ListIncomesWithID(r.Context(), year, month)
@@ -318,13 +382,7 @@ func (s *Server) handleIncomeMonthOverview(w http.ResponseWriter, r *http.Reques
 		slog.ErrorContext(r.Context(), "List incomes with ID error", "error", err, "year", year, "month", month)
 	} else {
 		for _, inc := range itemsWithID {
-			data.Items = append(data.Items, struct {
-				ID   string
-				Day  int
-				Desc string
-				Amt  string
-				Cat  string
-			}{ID: inc.ID, Day: inc.Income.Date.Day(), Desc: template.HTMLEscapeString(inc.Income.Description), Amt: formatEuros(inc.Income.Amount.Cents), Cat: inc.Income.Category})
+			data.Items = append(data.Items, incomeItemFrom(inc))
 		}
 	}
 
@@ -495,44 +553,20 @@ func (s *Server) handleIncomeMonthIncomes(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	var items []struct {
-		ID   string
-		Day  int
-		Desc string
-		Amt  string
-		Cat  string
-	}
+	var items []incomeItem
 
 	itemsWithID, err := adapter.ListIncomesWithID(r.Context(), year, month)
 	if err != nil {
 		slog.ErrorContext(r.Context(), "List incomes with ID error", "error", err, "year", year, "month", month)
 	} else {
 		for _, inc := range itemsWithID {
-			items = append(items, struct {
-				ID   string
-				Day  int
-				Desc string
-				Amt  string
-				Cat  string
-			}{
-				ID:   inc.ID,
-				Day:  inc.Income.Date.Day(),
-				Desc: template.HTMLEscapeString(inc.Income.Description),
-				Amt:  formatEuros(inc.Income.Amount.Cents),
-				Cat:  inc.Income.Category,
-			})
+			items = append(items, incomeItemFrom(inc))
 		}
 	}
 
 	data := struct {
 		Month int
-		Items []struct {
-			ID   string
-			Day  int
-			Desc string
-			Amt  string
-			Cat  string
-		}
+		Items []incomeItem
 	}{
 		Month: month,
 		Items: items,
@@ -567,10 +601,12 @@ func (s *Server) handleIncomeFormReset(w http.ResponseWriter, r *http.Request) {
 		Day        int
 		Month      int
 		Categories []string
+		Accounts   []core.Account
 	}{
 		Day:        now.Day(),
 		Month:      int(now.Month()),
 		Categories: categories,
+		Accounts:   s.accounts(r.Context()),
 	}
 
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
@@ -601,3 +637,28 @@ func (s *Server) handleGetIncomeCategories(w http.ResponseWriter, r *http.Reques
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(categories)
 }
+
+// handleGetIncomeSubcategories returns the subcategories already used for
+// ?category=, offered as suggestions in the income form
+func (s *Server) handleGetIncomeSubcategories(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	subcategories := []string{}
+	category := sanitizeInput(r.URL.Query().Get("category"))
+	if adapter, ok := s.expWriter.(*adapters.SQLiteAdapter); ok && category != "" {
+		subs, err := adapter.GetIncomeSubcategories(r.Context(), category)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Failed to get income subcategories", "error", err, "category", category)
+			http.Error(w, "Failed to get subcategories", http.StatusInternalServerError)
+			return
+		}
+		subcategories = append(subcategories, subs...)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(subcategories)
+}
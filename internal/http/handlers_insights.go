@@ -0,0 +1,244 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"spese/internal/adapters"
+	"spese/internal/core"
+)
+
+// insightsFeedLimit is how many insights the dashboard feed shows
+const insightsFeedLimit = 20
+
+// italianMonths are the month names used in insight messages
+var italianMonths = [...]string{"gennaio", "febbraio", "marzo", "aprile", "maggio", "giugno",
+	"luglio", "agosto", "settembre", "ottobre", "novembre", "dicembre"}
+
+// insightKindLabels name the insight kinds in the list of muted ones
+var insightKindLabels = map[core.InsightKind]string{
+	core.InsightCategoryUp:    "aumenti",
+	core.InsightCategoryBack:  "ritorni",
+	core.InsightBudgetReached: "budget raggiunto",
+}
+
+// insightMonth formats a "2006-01" period as an Italian month name, with
+// the year when it differs from the one of ref.
+func insightMonth(period string, ref time.Time) string {
+	t, err := time.Parse("2006-01", period)
+	if err != nil {
+		return period
+	}
+	name := italianMonths[t.Month()-1]
+	if t.Year() != ref.Year() {
+		name += fmt.Sprintf(" %d", t.Year())
+	}
+	return name
+}
+
+// insightMessage describes an insight in Italian
+func insightMessage(in core.Insight) string {
+	switch in.Kind {
+	case core.InsightCategoryUp:
+		return fmt.Sprintf("%s: +%d%% rispetto allo stesso periodo del mese scorso (%s contro %s)",
+			in.Primary, in.ChangePercent(), formatEuros(in.Amount.Cents), formatEuros(in.Baseline.Cents))
+	case core.InsightCategoryBack:
+		return fmt.Sprintf("Prima spesa in %s da %s (%s)", in.Primary, insightMonth(in.Since, in.Date.Time), formatEuros(in.Amount.Cents))
+	case core.InsightBudgetReached:
+		days := "giorni"
+		if in.Days == 1 {
+			days = "giorno"
+		}
+		return fmt.Sprintf("%s: già speso quanto in tutto il mese scorso (%s), %d %s prima della fine del mese",
+			in.Primary, formatEuros(in.Baseline.Cents), in.Days, days)
+	}
+	return in.Primary
+}
+
+// insightRow is the view model for an insight of the feed
+type insightRow struct {
+	ID      int64
+	Kind    string
+	Date    string
+	Message string
+}
+
+// insightMuteRow is the view model for a muted insight kind
+type insightMuteRow struct {
+	Kind    string
+	Label   string
+	Primary string
+}
+
+// handleDashboardInsights renders the insights feed of the dashboard. It
+// is empty with backends other than SQLite.
+func (s *Server) handleDashboardInsights(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	adapter, ok := s.expLister.(*adapters.SQLiteAdapter)
+	if !ok {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		return
+	}
+	store := adapter.GetStorage()
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	insights, err := store.ListInsights(ctx, insightsFeedLimit)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to list insights", "error", err)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento delle notizie</div>`))
+		return
+	}
+	mutes, err := store.ListInsightMutes(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to list insight mutes", "error", err)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento delle notizie</div>`))
+		return
+	}
+
+	rows := make([]insightRow, len(insights))
+	for i, in := range insights {
+		rows[i] = insightRow{ID: in.ID, Kind: string(in.Kind), Date: in.Date.Format("02/01"), Message: insightMessage(in)}
+	}
+	muteRows := make([]insightMuteRow, len(mutes))
+	for i, m := range mutes {
+		label := insightKindLabels[core.InsightKind(m.Kind)]
+		if label == "" {
+			label = m.Kind
+		}
+		muteRows[i] = insightMuteRow{Kind: m.Kind, Label: label, Primary: m.PrimaryCategory}
+	}
+
+	data := struct {
+		Insights []insightRow
+		Mutes    []insightMuteRow
+	}{rows, muteRows}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "insights_feed", data); err != nil {
+		slog.ErrorContext(ctx, "Template execution failed", "error", err, "template", "insights_feed")
+	}
+}
+
+// handleDismissInsight hides an insight from the feed. Form fields: id.
+func (s *Server) handleDismissInsight(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.ruleStore(w)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+	id, ok := parseFormID(w, r, "id", "ID notizia non valido")
+	if !ok {
+		return
+	}
+
+	if err := store.DismissInsight(r.Context(), id); err != nil {
+		slog.WarnContext(r.Context(), "Failed to dismiss insight", "error", err, "id", id)
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`<div class="error">Notizia non trovata</div>`))
+		return
+	}
+
+	w.Header().Set("HX-Trigger", `{"insights:changed": {}}`)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(`<div class="success">Notizia nascosta</div>`))
+}
+
+// handleMuteInsight stops showing insights of the same kind and category
+// as an insight. Form fields: id.
+func (s *Server) handleMuteInsight(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.ruleStore(w)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+	id, ok := parseFormID(w, r, "id", "ID notizia non valido")
+	if !ok {
+		return
+	}
+
+	in, err := store.GetInsight(r.Context(), id)
+	if err != nil {
+		slog.WarnContext(r.Context(), "Failed to get insight", "error", err, "id", id)
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`<div class="error">Notizia non trovata</div>`))
+		return
+	}
+	if err := store.MuteInsights(r.Context(), in.Kind, in.Primary); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to mute insights", "error", err, "kind", in.Kind, "primary", in.Primary)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel silenziare le notizie</div>`))
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Insights muted", "kind", in.Kind, "primary", in.Primary)
+	w.Header().Set("HX-Trigger", `{"insights:changed": {}}`)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(`<div class="success">Notizie silenziate</div>`))
+}
+
+// handleUnmuteInsight shows the insights of a kind and category again.
+// Form fields: kind, primary.
+func (s *Server) handleUnmuteInsight(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.ruleStore(w)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+	kind := core.InsightKind(strings.TrimSpace(r.Form.Get("kind")))
+	primary := sanitizeInput(r.Form.Get("primary"))
+
+	if err := store.UnmuteInsights(r.Context(), kind, primary); err != nil {
+		slog.WarnContext(r.Context(), "Failed to unmute insights", "error", err, "kind", kind, "primary", primary)
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`<div class="error">Notizie non silenziate</div>`))
+		return
+	}
+
+	w.Header().Set("HX-Trigger", `{"insights:changed": {}}`)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(`<div class="success">Notizie riattivate</div>`))
+}
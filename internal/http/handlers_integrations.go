@@ -0,0 +1,86 @@
+package http
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"spese/internal/adapters"
+	"spese/internal/services"
+	ports "spese/internal/sheets"
+)
+
+// integrationsView is the data of the integrations page
+type integrationsView struct {
+	Status     services.IntegrationStatus
+	Since      string // Status.Since formatted for display
+	Queued     bool   // Whether the sync queue is available (SQLite backend)
+	Pending    int64  // Items waiting to be written to Google Sheets
+	AuthErrors int64  // Recorded attempts refused for the credentials
+}
+
+// handleIntegrations renders the status of the Google Sheets credentials,
+// with the steps to fix them when Google rejects them
+func (s *Server) handleIntegrations(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	view := integrationsView{Status: s.integrationHealth.Status()}
+	if view.Status.Degraded {
+		view.Since = view.Status.Since.Format("02/01/2006 15:04")
+	}
+	if adapter, ok := s.expWriter.(*adapters.SQLiteAdapter); ok {
+		store := adapter.GetStorage()
+		view.Queued = true
+		if stats, err := store.GetSyncQueueStats(r.Context()); err == nil {
+			view.Pending = stats.PendingCount
+		} else {
+			slog.WarnContext(r.Context(), "Failed to read sync queue stats", "error", err)
+		}
+		if counts, err := store.SyncErrorCounts(r.Context()); err == nil {
+			view.AuthErrors = counts[string(ports.ErrorAuth)]
+		} else {
+			slog.WarnContext(r.Context(), "Failed to count sync errors", "error", err)
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "integrations_page", view); err != nil {
+		slog.ErrorContext(r.Context(), "Integrations template execution failed", "error", err, "template", "integrations_page")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handleRetryIntegration makes the sync items waiting for the credentials
+// ready for the next poll. The banner stays until Google Sheets accepts a
+// request, so credentials that are still wrong are not hidden.
+func (s *Server) handleRetryIntegration(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	adapter, ok := s.expWriter.(*adapters.SQLiteAdapter)
+	if !ok {
+		w.WriteHeader(http.StatusNotImplemented)
+		_, _ = w.Write([]byte(`<div class="error">Coda di sincronizzazione disponibile solo con il backend SQLite</div>`))
+		return
+	}
+
+	n, err := adapter.GetStorage().RetryDeferredSyncs(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to retry deferred syncs", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel riavvio della sincronizzazione</div>`))
+		return
+	}
+	slog.InfoContext(r.Context(), "Deferred sync items scheduled for the next poll", "count", n)
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(`<div class="success">` + strconv.FormatInt(n, 10) +
+		` spese in attesa verranno riprovate al prossimo ciclo di sincronizzazione</div>`))
+}
@@ -0,0 +1,116 @@
+package http
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"spese/internal/services"
+)
+
+// SetIntegrityChecker enables the data integrity report. Without it the
+// integrity routes answer 501.
+func (s *Server) SetIntegrityChecker(c *services.IntegrityChecker) {
+	s.integrity = c
+}
+
+// integrityKindLabels names the checks in the report
+var integrityKindLabels = map[string]string{
+	services.IntegrityUnknownCategory:  "Categoria sconosciuta",
+	services.IntegrityRecurrentDates:   "Date ricorrenza",
+	services.IntegrityNonPositive:      "Importo non positivo",
+	services.IntegrityOrphanReceipt:    "Ricevuta orfana",
+	services.IntegritySyncedNotInSheet: "Non nel foglio",
+	services.IntegrityInSheetNotSynced: "Non segnata sincronizzata",
+	services.IntegritySheetOrphan:      "Riga senza spesa",
+}
+
+// integrityIssueView is an issue of the integrity report
+type integrityIssueView struct {
+	Label   string
+	Subject string
+	Detail  string
+	Repair  string
+}
+
+// integrityView is the data of the integrity report
+type integrityView struct {
+	CheckedAt    string
+	Issues       []integrityIssueView
+	SheetChecked bool
+	SheetError   string
+}
+
+// runIntegrityCheck runs the check, writing an error fragment when it
+// cannot
+func (s *Server) runIntegrityCheck(w http.ResponseWriter, r *http.Request) (integrityView, bool) {
+	if s.integrity == nil {
+		w.WriteHeader(http.StatusNotImplemented)
+		_, _ = w.Write([]byte(`<div class="error">Controllo di integrità disponibile solo con il backend SQLite</div>`))
+		return integrityView{}, false
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+	report, err := s.integrity.Check(ctx, time.Now())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Integrity check failed", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore durante il controllo di integrità</div>`))
+		return integrityView{}, false
+	}
+
+	view := integrityView{
+		CheckedAt:    report.CheckedAt.Format("02/01/2006 15:04"),
+		SheetChecked: report.SheetChecked,
+		SheetError:   report.SheetError,
+	}
+	for _, issue := range report.Issues {
+		view.Issues = append(view.Issues, integrityIssueView{
+			Label:   integrityKindLabels[issue.Kind],
+			Subject: issue.Subject,
+			Detail:  issue.Detail,
+			Repair:  issue.Repair,
+		})
+	}
+	return view, true
+}
+
+// handleIntegrity renders the integrity report: data breaking invariants
+// the schema does not enforce, each with a suggested repair
+func (s *Server) handleIntegrity(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	view, ok := s.runIntegrityCheck(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "integrity_page", view); err != nil {
+		slog.ErrorContext(r.Context(), "Integrity template execution failed", "error", err, "template", "integrity_page")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handleIntegrityReport runs the check again and renders the report alone
+func (s *Server) handleIntegrityReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	view, ok := s.runIntegrityCheck(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "integrity_report", view); err != nil {
+		slog.ErrorContext(r.Context(), "Template execution failed", "error", err, "template", "integrity_report")
+	}
+}
@@ -0,0 +1,235 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"spese/internal/core"
+	"spese/internal/services"
+)
+
+// jobEventsKeepAlive is the time between comments keeping an idle event
+// stream open through proxies
+const jobEventsKeepAlive = 15 * time.Second
+
+// SetJobRunner reports the background jobs of r in /api/v1/jobs.
+func (s *Server) SetJobRunner(r *services.JobRunner) {
+	s.jobs = r
+}
+
+// apiJob is the status of a background job in the API
+type apiJob struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Every       string     `json:"every,omitempty"` // e.g. "1h0m0s", empty for calendar schedules
+	Queued      bool       `json:"queued"`
+	Running     bool       `json:"running"`
+	NextRun     *time.Time `json:"next_run,omitempty"` // absent for jobs run only on demand
+	LastRun     *apiJobRun `json:"last_run"`
+}
+
+func newAPIJob(st services.JobState, now time.Time) apiJob {
+	job := apiJob{
+		Name:        st.Name,
+		Description: st.Description,
+		Queued:      st.Queued,
+		Running:     st.Running,
+	}
+	if st.Every > 0 {
+		job.Every = st.Every.String()
+	}
+	if !st.NextRun.IsZero() {
+		next := st.NextRun
+		job.NextRun = &next
+	}
+	if st.LastRun != nil {
+		job.LastRun = newAPIJobRun(*st.LastRun, now)
+	}
+	return job
+}
+
+// apiJobRun is an attempt at running a background job in the API
+type apiJobRun struct {
+	ID         int64      `json:"id"`
+	Job        string     `json:"job"`
+	Source     string     `json:"source"` // schedule, manual or retry
+	Attempt    int        `json:"attempt"`
+	Status     string     `json:"status"` // running, succeeded, failed or interrupted
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	DurationMs int64      `json:"duration_ms"`
+	Result     string     `json:"result,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+func newAPIJobRun(run core.JobRun, now time.Time) *apiJobRun {
+	out := &apiJobRun{
+		ID:         run.ID,
+		Job:        run.Job,
+		Source:     string(run.Source),
+		Attempt:    run.Attempt,
+		Status:     string(run.Status),
+		StartedAt:  run.StartedAt,
+		DurationMs: run.Duration(now).Milliseconds(),
+		Result:     run.Result,
+		Error:      run.Error,
+	}
+	if !run.FinishedAt.IsZero() {
+		finished := run.FinishedAt
+		out.FinishedAt = &finished
+	}
+	return out
+}
+
+// handleAPIJobs serves /api/v1/jobs (GET lists the background jobs with
+// their last run) and, for a job:
+//   - /api/v1/jobs/{name}/runs (GET, the latest runs, newest first, up to
+//     limit)
+//   - /api/v1/jobs/{name}/run (POST, runs the job now; 202 with its state)
+//   - /api/v1/jobs/{name}/events (GET, a stream of its state, see
+//     handleAPIJobEvents)
+func (s *Server) handleAPIJobs(w http.ResponseWriter, r *http.Request) {
+	item := apiItemID(r, "jobs")
+	name, action, _ := strings.Cut(item, "/")
+	method := http.MethodGet
+	if action == "run" {
+		method = http.MethodPost
+	}
+	if r.Method != method {
+		w.Header().Set("Allow", method)
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if s.jobs == nil {
+		writeJSONError(w, http.StatusNotImplemented, "background jobs not configured")
+		return
+	}
+
+	switch {
+	case item == "":
+		now := time.Now()
+		states := s.jobs.Jobs()
+		jobs := make([]apiJob, len(states))
+		for i, st := range states {
+			jobs[i] = newAPIJob(st, now)
+		}
+		writeJSON(w, http.StatusOK, jobs)
+	case name == "" || strings.Contains(action, "/"):
+		writeJSONError(w, http.StatusNotFound, "not found")
+	case action == "runs":
+		s.handleAPIJobRuns(w, r, name)
+	case action == "run":
+		s.handleAPIRunJob(w, r, name)
+	case action == "events":
+		s.handleAPIJobEvents(w, r, name)
+	default:
+		writeJSONError(w, http.StatusNotFound, "not found")
+	}
+}
+
+// handleAPIJobRuns lists the latest runs of a job
+func (s *Server) handleAPIJobRuns(w http.ResponseWriter, r *http.Request, name string) {
+	limit, _, err := apiPagination(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	runs, err := s.jobs.Runs(r.Context(), name, limit)
+	if errors.Is(err, services.ErrUnknownJob) {
+		writeJSONError(w, http.StatusNotFound, "job not found")
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list job runs", "error", err, "job", name, "component", "jobs_api")
+		writeJSONError(w, http.StatusInternalServerError, "error listing job runs")
+		return
+	}
+	now := time.Now()
+	items := make([]*apiJobRun, len(runs))
+	for i, run := range runs {
+		items[i] = newAPIJobRun(run, now)
+	}
+	writeJSON(w, http.StatusOK, items)
+}
+
+// handleAPIRunJob queues a run of a job outside its schedule. A job
+// already queued or running, or one left to the primary node, is a 409.
+func (s *Server) handleAPIRunJob(w http.ResponseWriter, r *http.Request, name string) {
+	err := s.jobs.RunNow(name)
+	switch {
+	case errors.Is(err, services.ErrUnknownJob):
+		writeJSONError(w, http.StatusNotFound, "job not found")
+		return
+	case errors.Is(err, services.ErrJobBusy), errors.Is(err, services.ErrJobReplica):
+		writeJSONError(w, http.StatusConflict, errors.Unwrap(err).Error())
+		return
+	case err != nil:
+		writeJSONError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	slog.InfoContext(r.Context(), "Job run requested", "job", name, "client_ip", extractClientIP(r), "component", "jobs_api")
+
+	st, err := s.jobs.Job(name)
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, "job not found")
+		return
+	}
+	writeJSON(w, http.StatusAccepted, newAPIJob(st, time.Now()))
+}
+
+// handleAPIJobEvents streams the state of a job as server-sent "state"
+// events, one at connection and one at every change, until the job is
+// neither queued nor running; a job already idle gets a single event.
+func (s *Server) handleAPIJobEvents(w http.ResponseWriter, r *http.Request, name string) {
+	if _, err := s.jobs.Job(name); err != nil {
+		writeJSONError(w, http.StatusNotFound, "job not found")
+		return
+	}
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	keepAlive := time.NewTicker(jobEventsKeepAlive)
+	defer keepAlive.Stop()
+	var sent []byte
+	for {
+		// Taken before reading the state, so no change is missed
+		changed := s.jobs.Changed()
+		st, err := s.jobs.Job(name)
+		if err != nil {
+			return
+		}
+		data, err := json.Marshal(newAPIJob(st, time.Now()))
+		if err != nil {
+			return
+		}
+		if string(data) != string(sent) {
+			if _, err := fmt.Fprintf(w, "event: state\ndata: %s\n\n", data); err != nil {
+				return
+			}
+			_ = rc.Flush()
+			sent = data
+		}
+		if !st.Queued && !st.Running {
+			return
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			_ = rc.Flush()
+		case <-changed:
+		}
+	}
+}
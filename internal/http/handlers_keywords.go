@@ -0,0 +1,277 @@
+package http
+
+import (
+	"context"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"spese/internal/core"
+	"spese/internal/rules"
+	"spese/internal/storage"
+)
+
+// SetCategorizer exposes the keyword dictionary and the classifier to
+// their pages, and the categorizer to the suggestions of the expense form.
+func (s *Server) SetCategorizer(c *rules.Categorizer) {
+	s.categorizer = c
+}
+
+// keywordStore returns the keyword dictionary and the repository holding
+// its overrides, writing a 501 and returning false when either is missing.
+func (s *Server) keywordStore(w http.ResponseWriter) (*rules.Dictionary, *storage.SQLiteRepository, bool) {
+	store, ok := s.ruleStore(w)
+	if !ok {
+		return nil, nil, false
+	}
+	dictionary := s.categorizer.Dictionary()
+	if dictionary == nil {
+		w.WriteHeader(http.StatusNotImplemented)
+		_, _ = w.Write([]byte(`<div class="error">Dizionario delle parole chiave non disponibile</div>`))
+		return nil, nil, false
+	}
+	return dictionary, store, true
+}
+
+// keywordRow is the view model for a dictionary entry
+type keywordRow struct {
+	core.CategoryKeyword
+	Source   string // predefinita, modificata, personalizzata
+	Override bool   // a user row, which can be removed
+}
+
+// loadKeywords returns the dictionary entries for the keywords table.
+func loadKeywords(ctx context.Context, dictionary *rules.Dictionary) ([]keywordRow, error) {
+	keywords, err := dictionary.Keywords(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rows := make([]keywordRow, len(keywords))
+	for i, k := range keywords {
+		row := keywordRow{CategoryKeyword: k, Source: "predefinita"}
+		if !k.Builtin {
+			row.Override = true
+			row.Source = "personalizzata"
+			if dictionary.IsBuiltin(k.Keyword) {
+				row.Source = "modificata"
+			}
+		}
+		rows[i] = row
+	}
+	return rows, nil
+}
+
+// handleKeywords renders the keyword dictionary page
+func (s *Server) handleKeywords(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	dictionary, _, ok := s.keywordStore(w)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	rows, err := loadKeywords(ctx, dictionary)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list category keywords", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento delle parole chiave</div>`))
+		return
+	}
+
+	cats, subs, err := s.taxReader.List(ctx)
+	if err != nil {
+		// Suggestions only: the page works without them
+		slog.WarnContext(r.Context(), "Failed to load categories for keywords page", "error", err)
+	}
+
+	data := struct {
+		Keywords      []keywordRow
+		Categories    []string
+		Subcategories []string
+	}{
+		Keywords:      rows,
+		Categories:    cats,
+		Subcategories: subs,
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "keywords_page", data); err != nil {
+		slog.ErrorContext(r.Context(), "Keywords template execution failed", "error", err, "template", "keywords_page")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handleKeywordsList renders the keywords table, refreshed after every change
+func (s *Server) handleKeywordsList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	dictionary, _, ok := s.keywordStore(w)
+	if !ok {
+		return
+	}
+
+	rows, err := loadKeywords(r.Context(), dictionary)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list category keywords", "error", err)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento delle parole chiave</div>`))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "keywords_list", struct{ Keywords []keywordRow }{rows}); err != nil {
+		slog.ErrorContext(r.Context(), "Template execution failed", "error", err, "template", "keywords_list")
+	}
+}
+
+// handleSetKeyword adds or changes a keyword, or turns it off.
+// Form fields: keyword, primary, secondary, disabled.
+func (s *Server) handleSetKeyword(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	_, store, ok := s.keywordStore(w)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+
+	keyword := core.CategoryKeyword{
+		Keyword:   core.NormalizeKeyword(r.Form.Get("keyword")),
+		Primary:   sanitizeInput(r.Form.Get("primary")),
+		Secondary: sanitizeInput(r.Form.Get("secondary")),
+		Disabled:  r.Form.Get("disabled") == "true",
+	}
+	if err := keyword.Validate(); err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`<div class="error">Dati non validi: ` + template.HTMLEscapeString(err.Error()) + `</div>`))
+		return
+	}
+
+	if err := store.SetCategoryKeyword(r.Context(), keyword); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to set category keyword", "error", err, "keyword", keyword.Keyword)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel salvataggio della parola chiave</div>`))
+		return
+	}
+
+	message := "«" + keyword.Keyword + "» → " + keyword.Primary + " / " + keyword.Secondary
+	if keyword.Disabled {
+		message = "«" + keyword.Keyword + "» disattivata"
+	}
+	slog.InfoContext(r.Context(), "Category keyword set", "keyword", keyword.Keyword, "disabled", keyword.Disabled)
+	w.Header().Set("HX-Trigger", `{"keywords:changed": {}}`)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(`<div class="success">` + template.HTMLEscapeString(message) + `</div>`))
+}
+
+// handleDeleteKeyword removes a user keyword, restoring the builtin entry
+// it replaced if any. Form fields: keyword.
+func (s *Server) handleDeleteKeyword(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	dictionary, store, ok := s.keywordStore(w)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+	keyword := core.NormalizeKeyword(r.Form.Get("keyword"))
+
+	if err := store.DeleteCategoryKeyword(r.Context(), keyword); err != nil {
+		slog.WarnContext(r.Context(), "Failed to delete category keyword", "error", err, "keyword", keyword)
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`<div class="error">Parola chiave non trovata</div>`))
+		return
+	}
+
+	message := "Parola chiave eliminata"
+	if dictionary.IsBuiltin(keyword) {
+		message = "Parola chiave predefinita ripristinata"
+	}
+	w.Header().Set("HX-Trigger", `{"keywords:changed": {}}`)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(`<div class="success">` + message + `</div>`))
+}
+
+// handleSuggestCategory returns the category the rules, the keyword
+// dictionary or the classifier pick for a description, as JSON, or 204
+// when none does. The
+// expense form uses it to preselect a category. Query parameters:
+// description, amount.
+func (s *Server) handleSuggestCategory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	description := sanitizeInput(r.URL.Query().Get("description"))
+	if s.categorizer == nil || description == "" {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	e := core.Expense{Date: core.Date{Time: time.Now()}, Description: description}
+	if a := strings.TrimSpace(r.URL.Query().Get("amount")); a != "" {
+		if cents, err := core.ParseDecimalToCents(a); err == nil {
+			e.Amount = core.Money{Cents: cents}
+		}
+	}
+
+	suggestion, err := s.categorizer.Suggest(r.Context(), e)
+	if err != nil {
+		slog.WarnContext(r.Context(), "Category suggestion failed", "error", err)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if suggestion == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	resp := struct {
+		Primary    string  `json:"primary"`
+		Secondary  string  `json:"secondary"`
+		Source     string  `json:"source"`          // rule, keyword or model
+		Match      string  `json:"match,omitempty"` // rule name or keyword
+		Confidence float64 `json:"confidence"`
+	}{Primary: suggestion.Primary, Secondary: suggestion.Secondary, Confidence: suggestion.Confidence}
+	switch {
+	case suggestion.Rule != nil:
+		resp.Source, resp.Match = "rule", suggestion.Rule.Name
+	case suggestion.Keyword != nil:
+		resp.Source, resp.Match = "keyword", suggestion.Keyword.Keyword
+	default:
+		resp.Source = "model"
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,227 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"spese/internal/adapters"
+	"spese/internal/core"
+	"spese/internal/storage"
+)
+
+// ledgerPageSize is how many expenses each ledger page loads
+const ledgerPageSize = 100
+
+// ledgerRow is the view model of an expense in the ledger
+type ledgerRow struct {
+	Month    string // Set on the first expense of a month
+	Date     string
+	Desc     string
+	Category string
+	Amount   string
+	Pending  bool
+}
+
+// ledgerRows is a page of the ledger with the link to the next one
+type ledgerRows struct {
+	Rows    []ledgerRow
+	NextURL string // Empty on the last page
+}
+
+// ledgerQuery is a range of the ledger and the position of a page
+type ledgerQuery struct {
+	From, To core.Date
+	After    *core.LedgerCursor
+}
+
+// parseLedgerQuery reads the from, to (2006-01-02) and after parameters.
+// The range defaults to the current year.
+func parseLedgerQuery(r *http.Request, now time.Time) (ledgerQuery, error) {
+	q := ledgerQuery{
+		From: core.NewDate(now.Year(), 1, 1),
+		To:   core.NewDate(now.Year(), 12, 31),
+	}
+	params := r.URL.Query()
+	if v := strings.TrimSpace(params.Get("from")); v != "" {
+		d, err := parseDate(v)
+		if err != nil {
+			return q, errors.New("data di inizio non valida")
+		}
+		q.From = d
+	}
+	if v := strings.TrimSpace(params.Get("to")); v != "" {
+		d, err := parseDate(v)
+		if err != nil {
+			return q, errors.New("data di fine non valida")
+		}
+		q.To = d
+	}
+	if q.To.Before(q.From.Time) {
+		return q, errors.New("la data di fine precede quella di inizio")
+	}
+	if v := strings.TrimSpace(params.Get("after")); v != "" {
+		c, err := core.ParseLedgerCursor(v)
+		if err != nil {
+			return q, errors.New("pagina non valida")
+		}
+		q.After = &c
+	}
+	return q, nil
+}
+
+// rowsURL returns the link of the page starting after c
+func (q ledgerQuery) rowsURL(c core.LedgerCursor) string {
+	v := url.Values{
+		"from":  {q.From.Format("2006-01-02")},
+		"to":    {q.To.Format("2006-01-02")},
+		"after": {c.String()},
+	}
+	return "/ui/ledger-rows?" + v.Encode()
+}
+
+// ledgerStore returns the SQLite repository the ledger reads, writing a
+// 501 and returning false for other backends.
+func (s *Server) ledgerStore(w http.ResponseWriter) (*storage.SQLiteRepository, bool) {
+	adapter, ok := s.expWriter.(*adapters.SQLiteAdapter)
+	if !ok {
+		w.WriteHeader(http.StatusNotImplemented)
+		_, _ = w.Write([]byte(`<div class="error">Registro delle spese disponibile solo con il backend SQLite</div>`))
+		return nil, false
+	}
+	return adapter.GetStorage(), true
+}
+
+// loadLedgerRows returns a page of the ledger. Month headings continue
+// from the previous page, whose last expense is the cursor.
+func loadLedgerRows(ctx context.Context, store *storage.SQLiteRepository, q ledgerQuery) (ledgerRows, error) {
+	page, err := store.ListLedger(ctx, q.From, q.To, q.After, ledgerPageSize)
+	if err != nil {
+		return ledgerRows{}, err
+	}
+
+	var view ledgerRows
+	month := ""
+	if q.After != nil {
+		month = core.Period(q.After.Date.Time)
+	}
+	for _, e := range page.Expenses {
+		row := ledgerRow{
+			Date:     e.Expense.Date.Format("02/01/2006"),
+			Desc:     e.Expense.Description,
+			Category: e.Expense.Primary + " / " + e.Expense.Secondary,
+			Amount:   formatEuros(e.Expense.Amount.Cents),
+			Pending:  e.Expense.Status == core.StatusPending,
+		}
+		if p := core.Period(e.Expense.Date.Time); p != month {
+			row.Month = reviewMonthLabel(e.Expense.Date.Time)
+			month = p
+		}
+		view.Rows = append(view.Rows, row)
+	}
+	if page.Next != nil {
+		view.NextURL = q.rowsURL(*page.Next)
+	}
+	return view, nil
+}
+
+// handleLedger renders the expenses of a date range across months, the
+// current year by default, with the first page of rows
+func (s *Server) handleLedger(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.ledgerStore(w)
+	if !ok {
+		return
+	}
+
+	q, err := parseLedgerQuery(r, time.Now())
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Intervallo non valido: ` + err.Error() + `</div>`))
+		return
+	}
+	// The page always starts from the newest expense
+	q.After = nil
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	count, total, err := store.LedgerTotals(ctx, q.From, q.To)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to load ledger totals", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento del registro</div>`))
+		return
+	}
+	rows, err := loadLedgerRows(ctx, store, q)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list ledger expenses", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento del registro</div>`))
+		return
+	}
+
+	data := struct {
+		From  string
+		To    string
+		Count int
+		Total string
+		Page  ledgerRows
+	}{
+		From:  q.From.Format("2006-01-02"),
+		To:    q.To.Format("2006-01-02"),
+		Count: count,
+		Total: formatEuros(total.Cents),
+		Page:  rows,
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "ledger_page", data); err != nil {
+		slog.ErrorContext(r.Context(), "Ledger template execution failed", "error", err, "template", "ledger_page")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handleLedgerRows renders the page of ledger rows after a cursor, loaded
+// as the previous page scrolls into view
+func (s *Server) handleLedgerRows(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.ledgerStore(w)
+	if !ok {
+		return
+	}
+
+	q, err := parseLedgerQuery(r, time.Now())
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Intervallo non valido: ` + err.Error() + `</div>`))
+		return
+	}
+
+	rows, err := loadLedgerRows(r.Context(), store, q)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list ledger expenses", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento del registro</div>`))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "ledger_rows", rows); err != nil {
+		slog.ErrorContext(r.Context(), "Template execution failed", "error", err, "template", "ledger_rows")
+	}
+}
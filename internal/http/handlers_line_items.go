@@ -0,0 +1,278 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"spese/internal/adapters"
+	"spese/internal/core"
+	"spese/internal/llm"
+	"spese/internal/storage"
+)
+
+// lineItemBlankRows is how many empty rows the line item editor offers
+const lineItemBlankRows = 3
+
+type lineItemRow struct {
+	Description string
+	Quantity    string
+	Amount      string // As typed in the form ("3,38")
+	Primary     string
+	Secondary   string
+}
+
+type lineItemsEditor struct {
+	Rows    []lineItemRow
+	Skipped []string // Receipt lines that were not read as items
+
+	// What the language model read besides the items, when it read them
+	Merchant  string
+	Primary   string
+	Secondary string
+	Model     bool
+}
+
+// lineItemStore returns the SQLite repository holding line items, writing a
+// 501 and returning false for other backends.
+func (s *Server) lineItemStore(w http.ResponseWriter) (*storage.SQLiteRepository, bool) {
+	adapter, ok := s.expWriter.(*adapters.SQLiteAdapter)
+	if !ok {
+		w.WriteHeader(http.StatusNotImplemented)
+		_, _ = w.Write([]byte(`<div class="error">Righe dello scontrino disponibili solo con il backend SQLite</div>`))
+		return nil, false
+	}
+	return adapter.GetStorage(), true
+}
+
+// formatLineItem describes a line item, e.g. "Latte intero ×2 €3,38 · Casa".
+func formatLineItem(l core.LineItem) string {
+	s := l.Description
+	if l.Quantity != 1 {
+		s += " ×" + formatQuantity(l.Quantity)
+	}
+	s += " " + formatEuros(l.Amount.Cents)
+	if l.Primary != "" {
+		s += " · " + l.Primary
+		if l.Secondary != "" {
+			s += " / " + l.Secondary
+		}
+	}
+	return s
+}
+
+// newLineItemsEditor returns the editor rows for lines, followed by blank ones
+func newLineItemsEditor(lines []core.LineItem) lineItemsEditor {
+	editor := lineItemsEditor{Rows: make([]lineItemRow, 0, len(lines)+lineItemBlankRows)}
+	for _, l := range lines {
+		editor.Rows = append(editor.Rows, lineItemRow{
+			Description: l.Description,
+			Quantity:    formatQuantity(l.Quantity),
+			Amount:      strings.TrimPrefix(formatEuros(l.Amount.Cents), "€"),
+			Primary:     l.Primary,
+			Secondary:   l.Secondary,
+		})
+	}
+	for range lineItemBlankRows {
+		editor.Rows = append(editor.Rows, lineItemRow{})
+	}
+	return editor
+}
+
+// handleLineItems renders the line item editor of an expense.
+// Query parameters: id.
+func (s *Server) handleLineItems(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.lineItemStore(w)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil || id <= 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">ID spesa non valido</div>`))
+		return
+	}
+	expense, err := store.GetExpense(r.Context(), id)
+	if err != nil {
+		slog.WarnContext(r.Context(), "Failed to load expense", "error", err, "expense_id", id)
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`<div class="error">Spesa non trovata</div>`))
+		return
+	}
+	lines, err := store.ListExpenseLineItems(r.Context(), id)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list line items", "error", err, "expense_id", id)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento delle righe</div>`))
+		return
+	}
+	primaries, secondaries, err := s.taxReader.List(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list categories", "error", err)
+	}
+
+	data := struct {
+		ID          int64
+		Date        string
+		Desc        string
+		Category    string
+		Amount      string
+		Editor      lineItemsEditor
+		Primaries   []string
+		Secondaries []string
+	}{
+		ID:          id,
+		Date:        expense.Date.Format("02/01/2006"),
+		Desc:        expense.Description,
+		Category:    expense.PrimaryCategory + " / " + expense.SecondaryCategory,
+		Amount:      formatEuros(expense.AmountCents),
+		Editor:      newLineItemsEditor(lines),
+		Primaries:   primaries,
+		Secondaries: secondaries,
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "line_items_page", data); err != nil {
+		slog.ErrorContext(r.Context(), "Line items template execution failed", "error", err, "template", "line_items_page")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handleParseReceipt reads line items from pasted receipt text and renders
+// them in the editor, without saving. The language model reads the text
+// when configured, the line parser otherwise or when the model finds no
+// items. Form fields: text.
+func (s *Server) handleParseReceipt(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+
+	text := r.Form.Get("text")
+	var editor lineItemsEditor
+	if s.extractor != nil {
+		out, err := s.extract(r.Context(), text)
+		if err == nil && len(out.Items) > 0 {
+			editor = newLineItemsEditor(out.Items)
+			editor.Merchant, editor.Primary, editor.Secondary, editor.Model = out.Merchant, out.Primary, out.Secondary, true
+		} else if err != nil && !errors.Is(err, llm.ErrEmptyText) {
+			// The line parser still reads well formatted receipts
+			slog.WarnContext(r.Context(), "Extraction failed, falling back to the receipt parser", "error", err, "component", "llm")
+		}
+	}
+	if !editor.Model {
+		lines, skipped := core.ParseReceiptLines(text)
+		editor = newLineItemsEditor(lines)
+		editor.Skipped = skipped
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "line_items_rows", editor); err != nil {
+		slog.ErrorContext(r.Context(), "Template execution failed", "error", err, "template", "line_items_rows")
+	}
+}
+
+// handleSaveLineItems replaces the line items of an expense. Form fields:
+// expense_id, then description, quantity, amount, primary and secondary
+// repeated once per row; blank rows are ignored.
+func (s *Server) handleSaveLineItems(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.lineItemStore(w)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+	expenseID, ok := parseFormID(w, r, "expense_id", "ID spesa non valido")
+	if !ok {
+		return
+	}
+
+	field := func(name string, i int) string {
+		if values := r.Form[name]; i < len(values) {
+			return sanitizeInput(values[i])
+		}
+		return ""
+	}
+	var lines []core.LineItem
+	var sum int64
+	for i := range r.Form["description"] {
+		desc, amount := field("description", i), strings.TrimSpace(field("amount", i))
+		if desc == "" && amount == "" {
+			continue
+		}
+		line := core.LineItem{
+			Description: desc,
+			Quantity:    1,
+			Primary:     field("primary", i),
+			Secondary:   field("secondary", i),
+		}
+		if v := field("quantity", i); v != "" {
+			quantity, err := core.ParseQuantity(v)
+			if err != nil {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				_, _ = fmt.Fprintf(w, `<div class="error">Riga %d: quantità non valida</div>`, i+1)
+				return
+			}
+			line.Quantity = quantity
+		}
+		cents, err := core.ParseDecimalToCents(amount)
+		if err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_, _ = fmt.Fprintf(w, `<div class="error">Riga %d: importo non valido</div>`, i+1)
+			return
+		}
+		line.Amount = core.Money{Cents: cents}
+		sum += cents
+		lines = append(lines, line)
+	}
+
+	if err := store.SetExpenseLineItems(r.Context(), expenseID, lines); err != nil {
+		slog.WarnContext(r.Context(), "Failed to save line items", "error", err, "expense_id", expenseID)
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`<div class="error">Righe non valide: ` + template.HTMLEscapeString(err.Error()) + `</div>`))
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Line items saved", "expense_id", expenseID, "lines", len(lines), "sum_cents", sum)
+	w.Header().Set("HX-Trigger", `{"dashboard:refresh": {}}`)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if len(lines) == 0 {
+		_, _ = w.Write([]byte(`<div class="success">Righe rimosse</div>`))
+		return
+	}
+	expense, err := store.GetExpense(r.Context(), expenseID)
+	if err == nil && sum > expense.AmountCents {
+		_, _ = fmt.Fprintf(w, `<div class="success">%d righe salvate: %s, più dell'importo della spesa (%s). Nei totali per categoria sono ridotte in proporzione.</div>`,
+			len(lines), formatEuros(sum), formatEuros(expense.AmountCents))
+		return
+	}
+	_, _ = fmt.Fprintf(w, `<div class="success">%d righe salvate: %s</div>`, len(lines), formatEuros(sum))
+}
@@ -0,0 +1,191 @@
+package http
+
+import (
+	"html/template"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"spese/internal/adapters"
+	"spese/internal/core"
+	"spese/internal/storage"
+)
+
+// localeCookie remembers the language picked at /lingua
+const localeCookie = "spese_lang"
+
+// requestLocale returns the language category names are shown in: the
+// one picked at /lingua, else the browser's preferred one.
+func requestLocale(r *http.Request) core.Locale {
+	if c, err := r.Cookie(localeCookie); err == nil {
+		if l, ok := core.ParseLocale(c.Value); ok {
+			return l
+		}
+	}
+	return core.NegotiateLocale(r.Header.Get("Accept-Language"))
+}
+
+// categoryLabels returns the category display names for the request. They
+// live in SQLite; with other backends, or on error, the keys are shown.
+func (s *Server) categoryLabels(r *http.Request) core.CategoryLabels {
+	adapter, ok := s.expWriter.(*adapters.SQLiteAdapter)
+	if !ok {
+		return core.CategoryLabels{}
+	}
+	labels, err := adapter.GetStorage().CategoryLabels(r.Context(), requestLocale(r))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to load category labels", "error", err)
+		return core.CategoryLabels{}
+	}
+	return labels
+}
+
+// handleSetLocale stores the language picked in a cookie and goes back to
+// the page given as next. Query parameters: lang (it, en), next.
+func (s *Server) handleSetLocale(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	locale, ok := core.ParseLocale(r.URL.Query().Get("lang"))
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Lingua non supportata</div>`))
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     localeCookie,
+		Value:    string(locale),
+		Path:     "/",
+		MaxAge:   int(365 * 24 * time.Hour / time.Second),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	http.Redirect(w, r, localPath(r.URL.Query().Get("next")), http.StatusSeeOther)
+}
+
+type translationRow struct {
+	Kind    string
+	Name    string
+	Parent  string // Primary category of a secondary
+	Display string
+}
+
+// translationStore returns the SQLite repository holding translations,
+// writing a 501 and returning false for other backends.
+func (s *Server) translationStore(w http.ResponseWriter) (*storage.SQLiteRepository, bool) {
+	adapter, ok := s.expWriter.(*adapters.SQLiteAdapter)
+	if !ok {
+		w.WriteHeader(http.StatusNotImplemented)
+		_, _ = w.Write([]byte(`<div class="error">Traduzioni disponibili solo con il backend SQLite</div>`))
+		return nil, false
+	}
+	return adapter.GetStorage(), true
+}
+
+// handleTranslations renders the category names of a locale for editing.
+// Query parameters: lang (default en).
+func (s *Server) handleTranslations(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.translationStore(w)
+	if !ok {
+		return
+	}
+
+	locale := core.LocaleEnglish
+	if l, ok := core.ParseLocale(r.URL.Query().Get("lang")); ok {
+		locale = l
+	}
+
+	categories, err := store.GetAllCategoriesWithSubs(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list categories", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento delle categorie</div>`))
+		return
+	}
+	labels, err := store.CategoryLabels(r.Context(), locale)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to load category labels", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento delle traduzioni</div>`))
+		return
+	}
+
+	data := struct {
+		Locale  string
+		Locales []string
+		Current string // Language the UI shows category names in
+		Rows    []translationRow
+	}{Locale: string(locale), Current: string(requestLocale(r))}
+	for _, l := range core.Locales {
+		data.Locales = append(data.Locales, string(l))
+	}
+	for _, c := range categories {
+		data.Rows = append(data.Rows, translationRow{Kind: storage.CategoryKindPrimary, Name: c.Primary, Display: labels.Primary[c.Primary]})
+		for _, sub := range c.Secondaries {
+			data.Rows = append(data.Rows, translationRow{Kind: storage.CategoryKindSecondary, Name: sub, Parent: c.Primary, Display: labels.Secondary[sub]})
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "translations_page", data); err != nil {
+		slog.ErrorContext(r.Context(), "Translations template execution failed", "error", err, "template", "translations_page")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handleSetTranslation sets the display name of a category in a locale.
+// Form fields: kind (primary, secondary), name, locale, display_name (empty
+// removes the translation).
+func (s *Server) handleSetTranslation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.translationStore(w)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+
+	kind := r.Form.Get("kind")
+	name := r.Form.Get("name")
+	locale, ok := core.ParseLocale(r.Form.Get("locale"))
+	if !ok || name == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Categoria o lingua non valida</div>`))
+		return
+	}
+	display := sanitizeInput(r.Form.Get("display_name"))
+
+	if err := store.SetCategoryTranslation(r.Context(), kind, name, locale, display); err != nil {
+		slog.WarnContext(r.Context(), "Failed to set category translation", "error", err, "kind", kind, "name", name, "locale", locale)
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`<div class="error">Traduzione non valida: ` + template.HTMLEscapeString(err.Error()) + `</div>`))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if display == "" {
+		_, _ = w.Write([]byte(`<div class="success">Traduzione di «` + template.HTMLEscapeString(name) + `» rimossa</div>`))
+		return
+	}
+	_, _ = w.Write([]byte(`<div class="success">«` + template.HTMLEscapeString(name) + `» → «` + template.HTMLEscapeString(display) + `»</div>`))
+}
@@ -0,0 +1,91 @@
+package http
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"spese/internal/core"
+	"spese/internal/services"
+)
+
+// peerMaxBody bounds a pushed batch of changes
+const peerMaxBody = 4 << 20
+
+// SetPeerSync enables /peer/changes for another instance presenting the
+// service token. Without it the route answers 501.
+func (s *Server) SetPeerSync(p *services.PeerSyncService) {
+	s.peerSync = p
+}
+
+// handlePeerChanges serves the change log to a peer (GET, query params
+// since and limit) and applies changes pushed by it (POST, JSON body
+// {"changes": [...]}). Both directions answer JSON.
+func (s *Server) handlePeerChanges(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		w.Header().Set("Allow", "GET, POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if s.peerSync == nil {
+		http.Error(w, "peer sync not configured", http.StatusNotImplemented)
+		return
+	}
+
+	if !validBearerToken(r, s.peerSync.Token()) {
+		slog.WarnContext(r.Context(), "Peer authentication failed", "client_ip", extractClientIP(r))
+		w.Header().Set("WWW-Authenticate", `Bearer realm="spese"`)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		s.servePeerChanges(w, r)
+		return
+	}
+
+	var body services.PeerChangeSet
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, peerMaxBody)).Decode(&body); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+
+	ctx := core.WithActor(r.Context(), "peer:"+extractClientIP(r))
+	applied, err := s.peerSync.Apply(ctx, body.Changes)
+	if err != nil {
+		slog.WarnContext(r.Context(), "Rejected peer changes", "error", err, "changes", len(body.Changes))
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Peer changes applied", "received", len(body.Changes), "applied", applied)
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(struct {
+		Applied int `json:"applied"`
+	}{applied})
+}
+
+func (s *Server) servePeerChanges(w http.ResponseWriter, r *http.Request) {
+	var since int64
+	if v := r.URL.Query().Get("since"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 {
+			http.Error(w, "invalid since cursor", http.StatusBadRequest)
+			return
+		}
+		since = n
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	page, err := s.peerSync.Changes(r.Context(), since, limit)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list peer changes", "error", err)
+		http.Error(w, "failed to list changes", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(page)
+}
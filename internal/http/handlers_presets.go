@@ -0,0 +1,154 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"spese/internal/adapters"
+	"spese/internal/core"
+	"spese/internal/presets"
+)
+
+type presetRecurrentView struct {
+	Index     int
+	Desc      string
+	Every     string
+	Amount    string // Suggested amount, as typed in the form
+	Secondary string
+}
+
+type presetView struct {
+	ID          string
+	Name        string
+	Description string
+	Categories  []string // "Primary: secondary, ..."
+	Recurrents  []presetRecurrentView
+}
+
+// handlePresets renders the installable preset bundles
+func (s *Server) handlePresets(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	bundles, err := presets.List()
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to load presets", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento dei preset</div>`))
+		return
+	}
+
+	now := time.Now()
+	data := struct {
+		Start   string // First day of next month
+		Presets []presetView
+	}{Start: time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.Local).Format("2006-01-02")}
+	for _, b := range bundles {
+		view := presetView{ID: b.ID, Name: b.Name, Description: b.Description}
+		for _, c := range b.Categories {
+			view.Categories = append(view.Categories, c.Primary+": "+strings.Join(c.Secondaries, ", "))
+		}
+		for i, rec := range b.Recurrents {
+			view.Recurrents = append(view.Recurrents, presetRecurrentView{
+				Index:     i,
+				Desc:      rec.Description,
+				Every:     repetitionLabels[string(rec.Every)],
+				Amount:    strings.TrimPrefix(formatEuros(rec.AmountCents), "€"),
+				Secondary: rec.Primary + " / " + rec.Secondary,
+			})
+		}
+		data.Presets = append(data.Presets, view)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "presets_page", data); err != nil {
+		slog.ErrorContext(r.Context(), "Presets template execution failed", "error", err, "template", "presets_page")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handleInstallPreset imports the categories of a preset and creates the
+// recurrents picked. Form fields: preset, start_date, recurrent (repeated,
+// index of a picked recurrent) and amount_N for each picked index N.
+func (s *Server) handleInstallPreset(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	adapter, ok := s.expWriter.(*adapters.SQLiteAdapter)
+	if !ok {
+		w.WriteHeader(http.StatusNotImplemented)
+		_, _ = w.Write([]byte(`<div class="error">Preset disponibili solo con il backend SQLite</div>`))
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+
+	bundle, err := presets.Get(sanitizeInput(r.Form.Get("preset")))
+	if errors.Is(err, presets.ErrNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`<div class="error">Preset non trovato</div>`))
+		return
+	} else if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to load preset", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento del preset</div>`))
+		return
+	}
+
+	start, err := parseDate(r.Form.Get("start_date"))
+	if err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`<div class="error">Data inizio non valida</div>`))
+		return
+	}
+
+	amounts := make(map[int]core.Money)
+	for _, v := range r.Form["recurrent"] {
+		i, err := strconv.Atoi(v)
+		if err != nil || i < 0 || i >= len(bundle.Recurrents) {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`<div class="error">Ricorrente non valida</div>`))
+			return
+		}
+		cents, err := core.ParseDecimalToCents(strings.TrimSpace(r.Form.Get(fmt.Sprintf("amount_%d", i))))
+		if err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_, _ = w.Write([]byte(`<div class="error">Importo non valido per «` + template.HTMLEscapeString(bundle.Recurrents[i].Description) + `»</div>`))
+			return
+		}
+		amounts[i] = core.Money{Cents: cents}
+	}
+
+	res, err := presets.Install(r.Context(), adapter.GetStorage(), bundle, start, amounts)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to install preset", "error", err, "preset", bundle.ID)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nell'installazione del preset</div>`))
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Preset installed", "preset", bundle.ID, "categories", res.Categories, "recurrents", res.Recurrents, "skipped", res.Skipped)
+	msg := fmt.Sprintf("«%s» installato: %d categorie e %d ricorrenti aggiunte", bundle.Name, res.Categories, res.Recurrents)
+	if res.Skipped > 0 {
+		msg += fmt.Sprintf(", %d ricorrenti già presenti", res.Skipped)
+	}
+	w.Header().Set("HX-Trigger", `{"dashboard:refresh": {}}`)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(`<div class="success">` + template.HTMLEscapeString(msg) + `</div>`))
+}
@@ -0,0 +1,219 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"spese/internal/adapters"
+	"spese/internal/core"
+	"spese/internal/storage"
+)
+
+type itemPriceView struct {
+	ID       int64
+	Name     string
+	Count    int
+	First    string // Unit price when first seen
+	Last     string // Latest unit price
+	LastDate string
+	Change   string // Latest against first, e.g. "+12,5%"
+}
+
+type basketPointView struct {
+	Month string
+	Index string
+	Items int
+	Width int // Bar length, relative to the highest index
+}
+
+type pricesView struct {
+	Items  []itemPriceView
+	Basket []basketPointView
+}
+
+// priceStore returns the SQLite repository holding the price history,
+// writing a 501 and returning false for other backends.
+func (s *Server) priceStore(w http.ResponseWriter) (*storage.SQLiteRepository, bool) {
+	adapter, ok := s.expWriter.(*adapters.SQLiteAdapter)
+	if !ok {
+		w.WriteHeader(http.StatusNotImplemented)
+		_, _ = w.Write([]byte(`<div class="error">Storico prezzi disponibile solo con il backend SQLite</div>`))
+		return nil, false
+	}
+	return adapter.GetStorage(), true
+}
+
+// formatPercent renders a ratio change as a signed percentage with a
+// decimal comma, e.g. "+12,5%".
+func formatPercent(ratio float64) string {
+	return strings.ReplaceAll(fmt.Sprintf("%+.1f%%", (ratio-1)*100), ".", ",")
+}
+
+// loadPrices builds the item table and the basket index
+func loadPrices(ctx context.Context, store *storage.SQLiteRepository) (pricesView, error) {
+	history, err := store.ListItemPriceHistory(ctx)
+	if err != nil {
+		return pricesView{}, err
+	}
+
+	var view pricesView
+	series := make([][]core.PriceObservation, 0, len(history))
+	for _, item := range history {
+		v := itemPriceView{ID: item.ID, Name: item.Name, Count: len(item.Prices)}
+		if n := len(item.Prices); n > 0 {
+			first, last := item.Prices[0], item.Prices[n-1]
+			v.First = formatEuros(first.UnitPrice.Cents)
+			v.Last = formatEuros(last.UnitPrice.Cents)
+			v.LastDate = last.Date.Format("02/01/2006")
+			if n > 1 {
+				v.Change = formatPercent(float64(last.UnitPrice.Cents) / float64(first.UnitPrice.Cents))
+			}
+			series = append(series, item.Prices)
+		}
+		view.Items = append(view.Items, v)
+	}
+
+	points := core.BasketIndex(series)
+	highest := 0.0
+	for _, p := range points {
+		highest = max(highest, p.Index)
+	}
+	for _, p := range points {
+		view.Basket = append(view.Basket, basketPointView{
+			Month: fmt.Sprintf("%02d/%d", p.Month, p.Year),
+			Index: strings.ReplaceAll(strconv.FormatFloat(p.Index, 'f', 1, 64), ".", ","),
+			Items: p.Items,
+			Width: int(p.Index / highest * 100),
+		})
+	}
+	return view, nil
+}
+
+// handlePrices renders the price history of the tracked items and the
+// inflation of the basket they make up
+func (s *Server) handlePrices(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.priceStore(w)
+	if !ok {
+		return
+	}
+
+	view, err := loadPrices(r.Context(), store)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to load price history", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento dei prezzi</div>`))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "prices_page", view); err != nil {
+		slog.ErrorContext(r.Context(), "Prices template execution failed", "error", err, "template", "prices_page")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handlePricesList renders the items and basket, refreshed after every change
+func (s *Server) handlePricesList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.priceStore(w)
+	if !ok {
+		return
+	}
+
+	view, err := loadPrices(r.Context(), store)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to load price history", "error", err)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento dei prezzi</div>`))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "prices_list", view); err != nil {
+		slog.ErrorContext(r.Context(), "Template execution failed", "error", err, "template", "prices_list")
+	}
+}
+
+// handleTrackItem starts tracking the price of an item. Form fields: name.
+func (s *Server) handleTrackItem(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.priceStore(w)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+
+	name := sanitizeInput(r.Form.Get("name"))
+	id, matched, err := store.TrackItem(r.Context(), name)
+	if err != nil {
+		slog.WarnContext(r.Context(), "Failed to track item", "error", err)
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`<div class="error">Articolo non valido: ` + template.HTMLEscapeString(err.Error()) + `</div>`))
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Item tracked", "item_id", id, "matched", matched)
+	w.Header().Set("HX-Trigger", `{"prices:changed": {}}`)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = fmt.Fprintf(w, `<div class="success">Articolo monitorato: %d spese trovate</div>`, matched)
+}
+
+// handleUntrackItem stops tracking an item. Form fields: id.
+func (s *Server) handleUntrackItem(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.priceStore(w)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+	id, ok := parseFormID(w, r, "id", "ID articolo non valido")
+	if !ok {
+		return
+	}
+
+	if err := store.UntrackItem(r.Context(), id); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to untrack item", "error", err, "item_id", id)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nella rimozione dell'articolo</div>`))
+		return
+	}
+
+	w.Header().Set("HX-Trigger", `{"prices:changed": {}}`)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(`<div class="success">Articolo rimosso</div>`))
+}
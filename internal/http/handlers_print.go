@@ -0,0 +1,133 @@
+package http
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"spese/internal/core"
+)
+
+// statementRow is an expense of the printed statement
+type statementRow struct {
+	Date     string
+	Desc     string
+	Category string
+	Amount   string
+	Pending  bool
+}
+
+// statementCategory is a primary category of the statement summary
+type statementCategory struct {
+	Name   string
+	Amount string
+	Share  int // Percent of the month total
+}
+
+// statementView is the data of the printed statement
+type statementView struct {
+	Label      string // e.g. "maggio 2031"
+	Year       int
+	Month      int
+	Rows       []statementRow
+	Categories []statementCategory
+	Total      string
+	PrintedAt  string
+}
+
+// handleMonthStatement renders the statement of a month for printing: the
+// full list of expenses, a summary by category and a signature line,
+// without navigation. Path: /print/{year}/{month}.
+func (s *Server) handleMonthStatement(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	year, errY := strconv.Atoi(r.PathValue("year"))
+	month, errM := strconv.Atoi(r.PathValue("month"))
+	if errY != nil || errM != nil || year < 1900 || year > 9999 || month < 1 || month > 12 {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Mese non valido</div>`))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	expenses, err := s.expLister.ListExpenses(ctx, year, month)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list expenses for the statement", "error", err, "year", year, "month", month)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento delle spese</div>`))
+		return
+	}
+
+	view := newStatementView(year, month, expenses, time.Now())
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "print_statement_page", view); err != nil {
+		slog.ErrorContext(r.Context(), "Template execution failed", "error", err, "template", "print_statement_page")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// newStatementView lists the expenses by date and sums them by primary
+// category, largest first. Card holds not settled yet are listed but left
+// out of the totals.
+func newStatementView(year, month int, expenses []core.Expense, now time.Time) statementView {
+	sorted := make([]core.Expense, len(expenses))
+	copy(sorted, expenses)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Date.Before(sorted[j].Date.Time)
+	})
+
+	view := statementView{
+		Label:     reviewMonthLabel(time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)),
+		Year:      year,
+		Month:     month,
+		Rows:      make([]statementRow, len(sorted)),
+		PrintedAt: now.Format("02/01/2006"),
+	}
+	var total int64
+	byPrimary := make(map[string]int64)
+	for i, e := range sorted {
+		view.Rows[i] = statementRow{
+			Date:     e.Date.Format("02/01"),
+			Desc:     e.Description,
+			Category: strings.Trim(strings.TrimSpace(e.Primary+" / "+e.Secondary), "/ "),
+			Amount:   formatEuros(e.Amount.Cents),
+			Pending:  e.IsPending(),
+		}
+		if e.IsPending() {
+			continue
+		}
+		total += e.Amount.Cents
+		primary := e.Primary
+		if primary == "" {
+			primary = "Senza categoria"
+		}
+		byPrimary[primary] += e.Amount.Cents
+	}
+
+	for name, cents := range byPrimary {
+		share := 0
+		if total > 0 {
+			share = int(cents * 100 / total)
+		}
+		view.Categories = append(view.Categories, statementCategory{Name: name, Amount: formatEuros(cents), Share: share})
+	}
+	sort.Slice(view.Categories, func(i, j int) bool {
+		ci, cj := byPrimary[view.Categories[i].Name], byPrimary[view.Categories[j].Name]
+		if ci != cj {
+			return ci > cj
+		}
+		return view.Categories[i].Name < view.Categories[j].Name
+	})
+	view.Total = formatEuros(total)
+	return view
+}
@@ -0,0 +1,400 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"spese/internal/adapters"
+	"spese/internal/core"
+	"spese/internal/services"
+	"spese/internal/storage"
+)
+
+// maxAPIKeyName is the longest label of an API key
+const maxAPIKeyName = 50
+
+// SetQuotas enables the quotas of the members calling with API keys and
+// the page managing keys and quotas.
+func (s *Server) SetQuotas(q *services.Quotas) {
+	s.quotas = q
+}
+
+// bearerAPIKey returns the API key a request carries as bearer token.
+// Other bearer tokens (WebSocket, peer, approver) are left alone.
+func bearerAPIKey(r *http.Request) (string, bool) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || !storage.IsAPIKey(token) {
+		return "", false
+	}
+	return token, true
+}
+
+// authenticateAPIKey returns the member owning key. Keys live in SQLite,
+// so with other backends every key is unknown.
+func (s *Server) authenticateAPIKey(ctx context.Context, key string) (core.User, error) {
+	adapter, ok := s.expWriter.(*adapters.SQLiteAdapter)
+	if !ok {
+		return core.User{}, errors.New("API keys require the SQLite backend")
+	}
+	return adapter.GetStorage().AuthenticateAPIKey(ctx, key)
+}
+
+// writeQuotaExceeded answers a change a quota refused with a 429: a JSON
+// error for API clients, an error message for forms. Over the daily quota
+// Retry-After points to midnight, when the count starts again.
+func writeQuotaExceeded(w http.ResponseWriter, r *http.Request, err error) {
+	var quotaErr *core.QuotaError
+	errors.As(err, &quotaErr)
+
+	message := "Quota superata"
+	if quotaErr != nil {
+		switch quotaErr.Quota {
+		case core.QuotaDailyExpenses:
+			now := time.Now()
+			midnight := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+			w.Header().Set("Retry-After", strconv.Itoa(int(midnight.Sub(now).Seconds())+1))
+			message = fmt.Sprintf("Quota giornaliera superata: %d spese su %d già inserite oggi", quotaErr.Used, quotaErr.Limit)
+		case core.QuotaAttachmentBytes:
+			message = fmt.Sprintf("Spazio per le ricevute esaurito: %s usati su %s", formatBytes(quotaErr.Used), formatBytes(quotaErr.Limit))
+		}
+	}
+
+	if r.Header.Get("HX-Request") == "" && (strings.HasPrefix(r.URL.Path, "/api/") || strings.Contains(r.Header.Get("Accept"), "application/json")) {
+		body := map[string]any{"error": err.Error()}
+		if quotaErr != nil {
+			body["quota"] = quotaErr.Quota
+			body["limit"] = quotaErr.Limit
+			body["used"] = quotaErr.Used
+			body["requested"] = quotaErr.Requested
+		}
+		writeJSON(w, http.StatusTooManyRequests, body)
+		return
+	}
+	w.WriteHeader(http.StatusTooManyRequests)
+	_, _ = w.Write([]byte(`<div class="error">` + template.HTMLEscapeString(message) + `</div>`))
+}
+
+type apiKeyView struct {
+	ID       int64
+	Name     string
+	Created  string
+	LastUsed string // Empty when never used
+}
+
+// quotaMemberView is a member's line on the quotas page
+type quotaMemberView struct {
+	ID              int64
+	Name            string
+	Expenses        string // Today's expenses against the limit
+	ExpensesNear    bool   // Past the soft limit
+	Attachments     string
+	AttachmentsNear bool
+	// Overrides as form values, empty for the instance default
+	DailyOverride      string
+	AttachmentOverride string // Megabytes
+	Keys               []apiKeyView
+}
+
+type quotasView struct {
+	DefaultExpenses    string
+	DefaultAttachments string
+	Members            []quotaMemberView
+}
+
+// formatQuota renders usage against a limit, e.g. "3 / 100"
+func formatQuota(used, limit int64, format func(int64) string) string {
+	if limit == 0 {
+		return format(used) + " (senza limite)"
+	}
+	return format(used) + " / " + format(limit)
+}
+
+// loadQuotas builds the quotas page from the usage of every member
+func (s *Server) loadQuotas(ctx context.Context) (quotasView, error) {
+	usage, err := s.quotas.Usage(ctx)
+	if err != nil {
+		return quotasView{}, err
+	}
+	count := func(n int64) string { return strconv.FormatInt(n, 10) }
+	defaults := s.quotas.Defaults()
+	view := quotasView{
+		DefaultExpenses:    formatQuota(0, defaults.DailyExpenses, count),
+		DefaultAttachments: formatQuota(0, defaults.AttachmentBytes, formatBytes),
+	}
+	if defaults.DailyExpenses > 0 {
+		view.DefaultExpenses = count(defaults.DailyExpenses)
+	}
+	if defaults.AttachmentBytes > 0 {
+		view.DefaultAttachments = formatBytes(defaults.AttachmentBytes)
+	}
+	for _, u := range usage {
+		m := quotaMemberView{
+			ID:              u.User.ID,
+			Name:            u.User.Name,
+			Expenses:        formatQuota(u.ExpensesToday, u.Limits.DailyExpenses, count),
+			ExpensesNear:    s.quotas.NearLimit(u.ExpensesToday, u.Limits.DailyExpenses),
+			Attachments:     formatQuota(u.AttachmentBytes, u.Limits.AttachmentBytes, formatBytes),
+			AttachmentsNear: s.quotas.NearLimit(u.AttachmentBytes, u.Limits.AttachmentBytes),
+		}
+		if v := u.Override.DailyExpenses; v != nil {
+			m.DailyOverride = count(*v)
+		}
+		if v := u.Override.AttachmentBytes; v != nil {
+			m.AttachmentOverride = count(*v >> 20)
+		}
+		for _, k := range u.Keys {
+			kv := apiKeyView{ID: k.ID, Name: k.Name, Created: k.CreatedAt.Local().Format("02/01/2006")}
+			if !k.LastUsedAt.IsZero() {
+				kv.LastUsed = k.LastUsedAt.Local().Format("02/01/2006 15:04")
+			}
+			m.Keys = append(m.Keys, kv)
+		}
+		view.Members = append(view.Members, m)
+	}
+	return view, nil
+}
+
+// quotasAvailable writes a 501 and returns false when quotas are not
+// enabled (backends other than SQLite).
+func (s *Server) quotasAvailable(w http.ResponseWriter) bool {
+	if s.quotas == nil {
+		w.WriteHeader(http.StatusNotImplemented)
+		_, _ = w.Write([]byte(`<div class="error">Chiavi API e quote disponibili solo con il backend SQLite</div>`))
+		return false
+	}
+	return true
+}
+
+// handleQuotas renders the page of the members' API keys, quotas and
+// today's usage.
+func (s *Server) handleQuotas(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.quotasAvailable(w) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	view, err := s.loadQuotas(ctx)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to load quotas", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento delle quote</div>`))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "quotas_page", view); err != nil {
+		slog.ErrorContext(r.Context(), "Quotas template execution failed", "error", err, "template", "quotas_page")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handleQuotaList renders the members table, refreshed after every change
+func (s *Server) handleQuotaList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.quotasAvailable(w) {
+		return
+	}
+
+	view, err := s.loadQuotas(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to load quotas", "error", err)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento delle quote</div>`))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "quota_list", view); err != nil {
+		slog.ErrorContext(r.Context(), "Template execution failed", "error", err, "template", "quota_list")
+	}
+}
+
+// parseQuotaField reads an optional non-negative quota: nil when empty,
+// so the instance default applies.
+func parseQuotaField(v string, scale int64) (*int64, error) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return nil, nil
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n < 0 || n > (1<<62)/scale {
+		return nil, fmt.Errorf("invalid quota %q", v)
+	}
+	n *= scale
+	return &n, nil
+}
+
+// handleSetQuota sets the quotas of a member. Form fields: user_id,
+// daily_expenses and attachment_mb; empty keeps the default, 0 removes
+// the limit.
+func (s *Server) handleSetQuota(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.quotasAvailable(w) {
+		return
+	}
+	store, ok := s.householdStore(w)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+	userID, ok := parseFormID(w, r, "user_id", "ID membro non valido")
+	if !ok {
+		return
+	}
+	var override core.QuotaOverride
+	var err error
+	if override.DailyExpenses, err = parseQuotaField(r.Form.Get("daily_expenses"), 1); err == nil {
+		override.AttachmentBytes, err = parseQuotaField(r.Form.Get("attachment_mb"), 1<<20)
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`<div class="error">Le quote sono numeri interi da 0 in su (0 = senza limite)</div>`))
+		return
+	}
+
+	if err := store.SetQuotaOverride(r.Context(), userID, override); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to set quota", "error", err, "user_id", userID)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel salvataggio delle quote</div>`))
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Quota set", "user_id", userID)
+	w.Header().Set("HX-Trigger", `{"quotas:changed": {}}`)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(`<div class="success">Quote salvate</div>`))
+}
+
+// handleCreateAPIKey gives a member a new API key, shown only in this
+// response. Form fields: user_id, name.
+func (s *Server) handleCreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.quotasAvailable(w) {
+		return
+	}
+	store, ok := s.householdStore(w)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+	userID, ok := parseFormID(w, r, "user_id", "ID membro non valido")
+	if !ok {
+		return
+	}
+	name := sanitizeInput(r.Form.Get("name"))
+	if name == "" || len([]rune(name)) > maxAPIKeyName {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`<div class="error">Dai alla chiave un nome di al massimo ` + strconv.Itoa(maxAPIKeyName) + ` caratteri</div>`))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	users, err := store.ListUsers(ctx)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list household members", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nella creazione della chiave</div>`))
+		return
+	}
+	var member string
+	for _, u := range users {
+		if u.ID == userID {
+			member = u.Name
+		}
+	}
+	if member == "" {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`<div class="error">Membro non trovato</div>`))
+		return
+	}
+
+	key, secret, err := store.CreateAPIKey(ctx, userID, name)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to create API key", "error", err, "user_id", userID)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nella creazione della chiave</div>`))
+		return
+	}
+
+	slog.InfoContext(r.Context(), "API key created", "user_id", userID, "key_id", key.ID)
+	w.Header().Set("HX-Trigger", `{"quotas:changed": {}}`)
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = fmt.Fprintf(w, `<div class="success">Chiave "%s" per %s: <code>%s</code><br />Copiala ora, non sarà più mostrata.</div>`,
+		template.HTMLEscapeString(key.Name), template.HTMLEscapeString(member), secret)
+}
+
+// handleRevokeAPIKey deletes an API key. Form fields: id.
+func (s *Server) handleRevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.quotasAvailable(w) {
+		return
+	}
+	store, ok := s.householdStore(w)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+	id, ok := parseFormID(w, r, "id", "ID chiave non valido")
+	if !ok {
+		return
+	}
+
+	if err := store.DeleteAPIKey(r.Context(), id); err != nil {
+		slog.WarnContext(r.Context(), "Failed to revoke API key", "error", err, "key_id", id)
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`<div class="error">Chiave non trovata</div>`))
+		return
+	}
+
+	slog.InfoContext(r.Context(), "API key revoked", "key_id", id)
+	w.Header().Set("HX-Trigger", `{"quotas:changed": {}}`)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(`<div class="success">Chiave revocata</div>`))
+}
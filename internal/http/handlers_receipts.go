@@ -0,0 +1,424 @@
+package http
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"spese/internal/adapters"
+	"spese/internal/core"
+	"spese/internal/receipts"
+	"spese/internal/services"
+	"spese/internal/storage"
+)
+
+// receiptFormOverhead is the room left in an upload for the multipart
+// boundaries and the other fields
+const receiptFormOverhead = 64 << 10
+
+// receiptView is a receipt as shown in the expense list
+type receiptView struct {
+	ExpenseID string
+	Thumbnail bool // Otherwise a PDF or an image without thumbnail
+	PDF       bool
+	Filename  string
+	Size      string // e.g. "1,2 MB"
+}
+
+func newReceiptView(rec core.Receipt) *receiptView {
+	return &receiptView{
+		ExpenseID: strconv.FormatInt(rec.ExpenseID, 10),
+		Thumbnail: rec.ThumbnailKey != "",
+		PDF:       rec.IsPDF(),
+		Filename:  rec.Filename,
+		Size:      formatBytes(rec.Size),
+	}
+}
+
+// formatBytes formats a file size the Italian way, e.g. "350 KB", "1,2 MB".
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return strings.Replace(strconv.FormatFloat(float64(n)/(1<<20), 'f', 1, 64), ".", ",", 1) + " MB"
+	case n >= 1<<10:
+		return strconv.FormatInt(n>>10, 10) + " KB"
+	}
+	return strconv.FormatInt(n, 10) + " B"
+}
+
+// SetReceiptStore enables receipt attachments, kept in blobs, refusing
+// files larger than maxBytes. Receipts need the SQLite backend.
+func (s *Server) SetReceiptStore(blobs receipts.Store, maxBytes int64) {
+	adapter, ok := s.expWriter.(*adapters.SQLiteAdapter)
+	if !ok || blobs == nil {
+		return
+	}
+	s.receipts = blobs
+	s.receiptMaxBytes = maxBytes
+	s.receiptCleaner = services.NewReceiptCleaner(adapter.GetStorage(), blobs)
+}
+
+// receiptStore returns the SQLite repository holding receipts, writing a
+// 501 and returning false when receipts are not enabled.
+func (s *Server) receiptStore(w http.ResponseWriter) (*storage.SQLiteRepository, bool) {
+	adapter, ok := s.expWriter.(*adapters.SQLiteAdapter)
+	if !ok || s.receipts == nil {
+		w.WriteHeader(http.StatusNotImplemented)
+		_, _ = w.Write([]byte(`<div class="error">Ricevute disponibili solo con il backend SQLite</div>`))
+		return nil, false
+	}
+	return adapter.GetStorage(), true
+}
+
+// cleanReceipts deletes the files of replaced and removed receipts right
+// away; what fails is retried by the background cleaner.
+func (s *Server) cleanReceipts(ctx context.Context) {
+	if _, err := s.receiptCleaner.Clean(ctx); err != nil {
+		slog.WarnContext(ctx, "Failed to delete old receipt files", "error", err)
+	}
+}
+
+// monthReceipts returns the receipts of the expenses of a month by expense
+// ID, or nil when receipts are not enabled.
+func (s *Server) monthReceipts(ctx context.Context, year, month int) map[string]*receiptView {
+	adapter, ok := s.expWriter.(*adapters.SQLiteAdapter)
+	if !ok || s.receipts == nil {
+		return nil
+	}
+	from := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	list, err := adapter.GetStorage().ReceiptsBetween(ctx, from, from.AddDate(0, 1, 0))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to list receipts", "error", err, "year", year, "month", month)
+		return nil
+	}
+	views := make(map[string]*receiptView, len(list))
+	for id, rec := range list {
+		views[strconv.FormatInt(id, 10)] = newReceiptView(rec)
+	}
+	return views
+}
+
+// renderReceipt writes the receipt panel of an expense and refreshes its
+// badge in the expense list.
+func (s *Server) renderReceipt(w http.ResponseWriter, r *http.Request, id int64, receipt *receiptView) {
+	data := struct {
+		ID      string
+		Receipt *receiptView
+		MaxMB   int64
+	}{
+		ID:      strconv.FormatInt(id, 10),
+		Receipt: receipt,
+		MaxMB:   s.receiptMaxBytes >> 20,
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "expense_receipt", data); err != nil {
+		slog.ErrorContext(r.Context(), "Template execution failed", "error", err, "template", "expense_receipt")
+	}
+}
+
+// handleExpenseReceipt renders the receipt panel of an expense: the file
+// attached, if any, and the upload form.
+func (s *Server) handleExpenseReceipt(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.receiptStore(w)
+	if !ok {
+		return
+	}
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil || id <= 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">ID spesa non valido</div>`))
+		return
+	}
+
+	rec, err := store.ExpenseReceipt(r.Context(), id)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		s.renderReceipt(w, r, id, nil)
+	case err != nil:
+		slog.ErrorContext(r.Context(), "Failed to load receipt", "error", err, "expense_id", id)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento della ricevuta</div>`))
+	default:
+		s.renderReceipt(w, r, id, newReceiptView(rec))
+	}
+}
+
+// handleUploadReceipt attaches a JPEG, PNG or PDF receipt to an expense,
+// replacing the previous one. Form fields: id, file (multipart). The type
+// is read from the content; photos get a thumbnail for the expense list.
+func (s *Server) handleUploadReceipt(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.receiptStore(w)
+	if !ok {
+		return
+	}
+
+	tooLarge := func() {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		_, _ = fmt.Fprintf(w, `<div class="error">Ricevuta troppo grande (massimo %d MB)</div>`, s.receiptMaxBytes>>20)
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, s.receiptMaxBytes+receiptFormOverhead)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			tooLarge()
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Seleziona la foto o il PDF della ricevuta</div>`))
+		return
+	}
+	defer file.Close()
+
+	id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if err != nil || id <= 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">ID spesa non valido</div>`))
+		return
+	}
+
+	data, err := io.ReadAll(io.LimitReader(file, s.receiptMaxBytes+1))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Impossibile leggere il file</div>`))
+		return
+	}
+	if int64(len(data)) > s.receiptMaxBytes {
+		tooLarge()
+		return
+	}
+	contentType, err := core.ReceiptType(data)
+	if err != nil {
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		_, _ = w.Write([]byte(`<div class="error">Formato non supportato: carica una foto JPEG o PNG o un PDF</div>`))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancel()
+
+	// Check the expense before writing files that would be left behind
+	if _, err := store.GetExpense(ctx, id); errors.Is(err, sql.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`<div class="error">Spesa non trovata</div>`))
+		return
+	} else if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to get expense", "error", err, "expense_id", id)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel salvataggio della ricevuta</div>`))
+		return
+	}
+
+	// The bytes are held until the receipt is stored, so parallel uploads
+	// cannot go over the quota together
+	release, err := s.quotas.ReserveAttachment(ctx, id, int64(len(data)))
+	if errors.Is(err, core.ErrQuotaExceeded) {
+		writeQuotaExceeded(w, r, err)
+		return
+	} else if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to reserve attachment quota", "error", err, "expense_id", id)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel salvataggio della ricevuta</div>`))
+		return
+	}
+	defer release()
+
+	rec := core.Receipt{
+		ExpenseID:   id,
+		ContentType: contentType,
+		Filename:    receiptFilename(header.Filename, contentType),
+		Size:        int64(len(data)),
+	}
+	// Receipts uploaded with an API key count against the member's quota
+	if user, ok := core.UserFromContext(ctx); ok {
+		rec.UploadedBy = user.ID
+	}
+	err = s.putReceipt(ctx, &rec, data)
+	if err == nil {
+		err = store.SetExpenseReceipt(ctx, rec)
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to save receipt", "error", err, "expense_id", id)
+		// Files written before the failure are not referenced by anything
+		for _, key := range []string{rec.Key, rec.ThumbnailKey} {
+			if key != "" {
+				_ = s.receipts.Delete(ctx, key)
+			}
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel salvataggio della ricevuta</div>`))
+		return
+	}
+	s.cleanReceipts(ctx)
+
+	slog.InfoContext(r.Context(), "Receipt attached", "expense_id", id, "content_type", contentType, "size", rec.Size)
+	s.renderReceipt(w, r, id, newReceiptView(rec))
+}
+
+// putReceipt writes the file of rec and, for photos, its thumbnail to blob
+// storage, setting their keys once written.
+func (s *Server) putReceipt(ctx context.Context, rec *core.Receipt, data []byte) error {
+	key, err := receipts.NewKey(rec.ExpenseID, rec.ContentType, false)
+	if err != nil {
+		return err
+	}
+	if err := s.receipts.Put(ctx, key, rec.ContentType, data); err != nil {
+		return fmt.Errorf("store receipt: %w", err)
+	}
+	rec.Key = key
+	if rec.IsPDF() {
+		return nil
+	}
+
+	thumb, err := receipts.Thumbnail(data)
+	if err != nil {
+		// The receipt is kept; the list links it without a preview
+		slog.WarnContext(ctx, "Receipt photo without thumbnail", "error", err, "expense_id", rec.ExpenseID)
+		return nil
+	}
+	if key, err = receipts.NewKey(rec.ExpenseID, rec.ContentType, true); err != nil {
+		return err
+	}
+	if err := s.receipts.Put(ctx, key, core.ReceiptJPEG, thumb); err != nil {
+		return fmt.Errorf("store thumbnail: %w", err)
+	}
+	rec.ThumbnailKey = key
+	return nil
+}
+
+// receiptFilename keeps the base name of the uploaded file for downloads,
+// or makes one up from the type.
+func receiptFilename(name, contentType string) string {
+	name = strings.TrimSpace(filepath.Base(strings.ReplaceAll(name, `\`, "/")))
+	if name == "" || name == "." || name == "/" {
+		name = "ricevuta" + core.ReceiptExtension(contentType)
+	}
+	if runes := []rune(name); len(runes) > core.MaxReceiptFilename {
+		name = string(runes[:core.MaxReceiptFilename])
+	}
+	return name
+}
+
+// handleDeleteReceipt removes the receipt of an expense. Form field: id.
+func (s *Server) handleDeleteReceipt(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.receiptStore(w)
+	if !ok {
+		return
+	}
+	id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if err != nil || id <= 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">ID spesa non valido</div>`))
+		return
+	}
+
+	if err := store.DeleteExpenseReceipt(r.Context(), id); errors.Is(err, sql.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`<div class="error">Ricevuta non trovata</div>`))
+		return
+	} else if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to delete receipt", "error", err, "expense_id", id)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nella rimozione della ricevuta</div>`))
+		return
+	}
+	s.cleanReceipts(r.Context())
+
+	slog.InfoContext(r.Context(), "Receipt removed", "expense_id", id)
+	s.renderReceipt(w, r, id, nil)
+}
+
+// handleDownloadReceipt sends the receipt file of an expense: photos are
+// shown in the browser, PDFs downloaded. With thumbnail=1 it sends the
+// thumbnail of a photo instead.
+func (s *Server) handleDownloadReceipt(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.receiptStore(w)
+	if !ok {
+		return
+	}
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil || id <= 0 {
+		http.Error(w, "invalid expense id", http.StatusBadRequest)
+		return
+	}
+
+	rec, err := store.ExpenseReceipt(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to load receipt", "error", err, "expense_id", id)
+		http.Error(w, "error loading receipt", http.StatusInternalServerError)
+		return
+	}
+
+	key, contentType := rec.Key, rec.ContentType
+	disposition := "inline"
+	if rec.IsPDF() {
+		disposition = "attachment"
+	}
+	if r.URL.Query().Get("thumbnail") == "1" {
+		if rec.ThumbnailKey == "" {
+			http.NotFound(w, r)
+			return
+		}
+		key, contentType = rec.ThumbnailKey, core.ReceiptJPEG
+	}
+
+	blob, err := s.receipts.Get(r.Context(), key)
+	if errors.Is(err, receipts.ErrNotFound) {
+		slog.WarnContext(r.Context(), "Receipt file missing from storage", "expense_id", id, "key", key)
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to read receipt", "error", err, "expense_id", id, "key", key)
+		http.Error(w, "error reading receipt", http.StatusBadGateway)
+		return
+	}
+	defer blob.Close()
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": rec.Filename}))
+	w.Header().Set("Cache-Control", "private, max-age=3600")
+	if key == rec.Key {
+		w.Header().Set("Content-Length", strconv.FormatInt(rec.Size, 10))
+	}
+	if _, err := io.Copy(w, blob); err != nil {
+		slog.WarnContext(r.Context(), "Receipt download interrupted", "error", err, "expense_id", id)
+	}
+}
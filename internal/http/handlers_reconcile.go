@@ -0,0 +1,153 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"spese/internal/services"
+)
+
+// SetReconcileService enables the SQLite/Sheets reconciliation pages.
+// Without it the reconciliation routes answer 501.
+func (s *Server) SetReconcileService(rs *services.ReconcileService) {
+	s.reconciler = rs
+}
+
+// reconcileItem is the view model for a single amount divergence
+type reconcileItem struct {
+	ID       int64
+	Date     string
+	Desc     string
+	DBAmt    string
+	SheetAmt string
+	Row      int
+}
+
+// handleReconcile renders the list of amount divergences for a month
+func (s *Server) handleReconcile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if s.reconciler == nil {
+		w.WriteHeader(http.StatusNotImplemented)
+		_, _ = w.Write([]byte(`<div class="error">Riconciliazione non disponibile: serve il backend SQLite con Google Sheets configurato</div>`))
+		return
+	}
+
+	year, month := parseYearMonth(r)
+	if month < 1 || month > 12 {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Mese non valido</div>`))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+
+	divergences, err := s.reconciler.FindAmountDivergences(ctx, year, month)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Reconciliation failed", "error", err, "year", year, "month", month)
+		w.WriteHeader(http.StatusBadGateway)
+		_, _ = w.Write([]byte(`<div class="error">Errore durante il confronto con Google Sheets</div>`))
+		return
+	}
+
+	items := make([]reconcileItem, 0, len(divergences))
+	for _, d := range divergences {
+		items = append(items, reconcileItem{
+			ID:       d.ExpenseID,
+			Date:     d.Date.Format("02/01/2006"),
+			Desc:     d.Description,
+			DBAmt:    formatEuros(d.DBCents),
+			SheetAmt: formatEuros(d.SheetCents),
+			Row:      d.SheetRow,
+		})
+	}
+
+	data := struct {
+		Year  int
+		Month int
+		Items []reconcileItem
+	}{
+		Year:  year,
+		Month: month,
+		Items: items,
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "reconcile_page", data); err != nil {
+		slog.ErrorContext(r.Context(), "Reconcile template execution failed", "error", err, "template", "reconcile_page")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handleReconcileResolve applies a resolution to one divergence.
+// Form fields: id (expense ID), action ("sheet" repairs the sheet from the
+// database, "db" accepts the sheet value into the database).
+func (s *Server) handleReconcileResolve(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if s.reconciler == nil {
+		w.WriteHeader(http.StatusNotImplemented)
+		_, _ = w.Write([]byte(`<div class="error">Riconciliazione non disponibile</div>`))
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+
+	id, err := strconv.ParseInt(sanitizeInput(r.Form.Get("id")), 10, 64)
+	if err != nil || id <= 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">ID spesa non valido</div>`))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+
+	action := strings.TrimSpace(r.Form.Get("action"))
+	var message string
+	switch action {
+	case "sheet":
+		err = s.reconciler.RepairSheet(ctx, id)
+		message = "Foglio aggiornato con l'importo del database"
+	case "db":
+		err = s.reconciler.AcceptSheetValue(ctx, id)
+		message = "Importo del foglio salvato nel database"
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Azione non valida</div>`))
+		return
+	}
+
+	if err != nil {
+		if errors.Is(err, services.ErrDivergenceNotFound) {
+			w.WriteHeader(http.StatusConflict)
+			_, _ = w.Write([]byte(`<div class="error">Importi già allineati, ricarica la pagina</div>`))
+			return
+		}
+		slog.ErrorContext(r.Context(), "Reconciliation resolve failed", "error", err, "expense_id", id, "action", action)
+		w.WriteHeader(http.StatusBadGateway)
+		_, _ = w.Write([]byte(`<div class="error">Errore durante la correzione</div>`))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(`<div class="success">` + message + `</div>`))
+}
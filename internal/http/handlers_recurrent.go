@@ -2,6 +2,9 @@ package http
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 	"html/template"
 	"log/slog"
 	"net/http"
@@ -11,6 +14,7 @@ import (
 
 	"spese/internal/adapters"
 	"spese/internal/core"
+	"spese/internal/events"
 	"spese/internal/storage"
 )
 
@@ -130,6 +134,9 @@ func (s *Server) handleCreateRecurrentExpense(w http.ResponseWriter, r *http.Req
 		Primary:     primary,
 		Secondary:   secondary,
 	}
+	if !parseSchedule(w, r, &re.Interval, &re.DayOfMonth) {
+		return
+	}
 
 	if err := re.Validate(); err != nil {
 		w.WriteHeader(http.StatusUnprocessableEntity)
@@ -159,6 +166,7 @@ func (s *Server) handleCreateRecurrentExpense(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	s.events.Publish(events.RecurrentCreated, events.RefPayload{ID: strconv.FormatInt(id, 10)})
 	slog.InfoContext(r.Context(), "Recurrent expense created", "id", id, "description", re.Description)
 
 	w.Header().Set("HX-Trigger", `{
@@ -235,6 +243,9 @@ func (s *Server) handleUpdateRecurrentExpense(w http.ResponseWriter, r *http.Req
 		Primary:     primary,
 		Secondary:   secondary,
 	}
+	if !parseSchedule(w, r, &re.Interval, &re.DayOfMonth) {
+		return
+	}
 
 	if err := re.Validate(); err != nil {
 		w.WriteHeader(http.StatusUnprocessableEntity)
@@ -242,9 +253,20 @@ func (s *Server) handleUpdateRecurrentExpense(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	// The version the edit was based on guards against overwriting changes
+	// made from another tab in the meantime
+	version, ok := parseExpectedVersion(r)
+	if !ok {
+		w.WriteHeader(http.StatusPreconditionRequired)
+		_, _ = w.Write([]byte(`<div class="error">Versione mancante, ricarica la pagina e riprova</div>`))
+		return
+	}
+	re.Version = version
+
 	// Get repository
 	var repo interface {
 		UpdateRecurrentExpense(ctx context.Context, id int64, re core.RecurrentExpenses) error
+		GetRecurrentExpenseByID(ctx context.Context, id int64) (*core.RecurrentExpenses, error)
 	}
 
 	if adapter, ok := s.expWriter.(*adapters.SQLiteAdapter); ok {
@@ -257,12 +279,25 @@ func (s *Server) handleUpdateRecurrentExpense(w http.ResponseWriter, r *http.Req
 	}
 
 	if err := repo.UpdateRecurrentExpense(r.Context(), id, re); err != nil {
+		if errors.Is(err, core.ErrVersionConflict) {
+			current, getErr := repo.GetRecurrentExpenseByID(r.Context(), id)
+			if getErr != nil {
+				slog.ErrorContext(r.Context(), "Failed to load recurrent expense after conflict", "error", getErr, "id", id)
+				w.WriteHeader(http.StatusConflict)
+				_, _ = w.Write([]byte(`<div class="error">La spesa ricorrente è stata modificata altrove, ricarica la pagina</div>`))
+				return
+			}
+			slog.InfoContext(r.Context(), "Recurrent expense update conflict", "id", id, "expected_version", version, "current_version", current.Version)
+			s.writeRecurrentConflict(w, r, re, *current)
+			return
+		}
 		slog.ErrorContext(r.Context(), "Failed to update recurrent expense", "error", err, "id", id)
 		w.WriteHeader(http.StatusInternalServerError)
 		_, _ = w.Write([]byte(`<div class="error">Errore nell'aggiornare la spesa ricorrente</div>`))
 		return
 	}
 
+	s.events.Publish(events.RecurrentUpdated, events.RefPayload{ID: strconv.FormatInt(id, 10)})
 	slog.InfoContext(r.Context(), "Recurrent expense updated", "id", id)
 
 	// Trigger client refresh for HTMX
@@ -271,6 +306,146 @@ func (s *Server) handleUpdateRecurrentExpense(w http.ResponseWriter, r *http.Req
 	_, _ = w.Write([]byte(``))
 }
 
+// conflictField is one field of the merge form shown on a version conflict
+type conflictField struct {
+	Name        string // form field name
+	Label       string
+	Mine        string // value submitted by the user
+	Theirs      string // value currently stored
+	MineLabel   string
+	TheirsLabel string
+}
+
+// Differs reports whether the two sides disagree and the user has to choose
+func (f conflictField) Differs() bool {
+	return f.Mine != f.Theirs
+}
+
+// repetitionLabels maps repetition types to their Italian labels
+var repetitionLabels = map[string]string{
+	"daily":   "Giornaliera",
+	"weekly":  "Settimanale",
+	"monthly": "Mensile",
+	"yearly":  "Annuale",
+}
+
+// repetitionUnits maps repetition types to the Italian plural of their unit
+var repetitionUnits = map[core.RepetitionTypes]string{
+	core.Daily:   "giorni",
+	core.Weekly:  "settimane",
+	core.Monthly: "mesi",
+	core.Yearly:  "anni",
+}
+
+// scheduleLabel describes a recurrence schedule in Italian: "Mensile",
+// "Ogni 2 settimane", "Ogni 3 mesi, giorno 31".
+func scheduleLabel(every core.RepetitionTypes, interval, dayOfMonth int) string {
+	label := repetitionLabels[string(every)]
+	if interval > 1 {
+		label = fmt.Sprintf("Ogni %d %s", interval, repetitionUnits[every])
+	}
+	if dayOfMonth > 0 {
+		label += fmt.Sprintf(", giorno %d", dayOfMonth)
+	}
+	return label
+}
+
+// parseSchedule reads the optional interval and day_of_month form fields
+// of a recurrent expense or income. It writes a 422 and returns false when
+// one is not a number; ranges are checked by Validate.
+func parseSchedule(w http.ResponseWriter, r *http.Request, interval, dayOfMonth *int) bool {
+	for _, f := range []struct {
+		name  string
+		dest  *int
+		label string
+	}{
+		{"interval", interval, "Intervallo non valido"},
+		{"day_of_month", dayOfMonth, "Giorno del mese non valido"},
+	} {
+		v := strings.TrimSpace(r.Form.Get(f.name))
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_, _ = w.Write([]byte(`<div class="error">` + f.label + `</div>`))
+			return false
+		}
+		*f.dest = n
+	}
+	return true
+}
+
+// writeRecurrentConflict renders a 409 merge form comparing the submitted
+// values with the ones currently stored. Submitting it retries the update
+// against the current version with the values picked for each field.
+func (s *Server) writeRecurrentConflict(w http.ResponseWriter, r *http.Request, mine, theirs core.RecurrentExpenses) {
+	dateValue := func(d core.Date) string {
+		if d.IsZero() {
+			return ""
+		}
+		return d.Format("2006-01-02")
+	}
+	dateLabel := func(d core.Date) string {
+		if d.IsZero() {
+			return "(senza fine)"
+		}
+		return d.Format("02/01/2006")
+	}
+	dayValue := func(day int) string {
+		if day == 0 {
+			return ""
+		}
+		return strconv.Itoa(day)
+	}
+	dayLabel := func(day int) string {
+		if day == 0 {
+			return "(come la data inizio)"
+		}
+		return strconv.Itoa(day)
+	}
+
+	fields := []conflictField{
+		{"description", "Descrizione", mine.Description, theirs.Description, mine.Description, theirs.Description},
+		{"amount", "Importo", formatDecimal(mine.Amount.Cents), formatDecimal(theirs.Amount.Cents), formatEuros(mine.Amount.Cents), formatEuros(theirs.Amount.Cents)},
+		{"repetition_type", "Frequenza", string(mine.Every), string(theirs.Every), repetitionLabels[string(mine.Every)], repetitionLabels[string(theirs.Every)]},
+		{"interval", "Intervallo", strconv.Itoa(mine.Step()), strconv.Itoa(theirs.Step()), scheduleLabel(mine.Every, mine.Step(), 0), scheduleLabel(theirs.Every, theirs.Step(), 0)},
+		{"day_of_month", "Giorno del mese", dayValue(mine.DayOfMonth), dayValue(theirs.DayOfMonth), dayLabel(mine.DayOfMonth), dayLabel(theirs.DayOfMonth)},
+		{"primary", "Categoria", mine.Primary, theirs.Primary, mine.Primary, theirs.Primary},
+		{"secondary", "Sottocategoria", mine.Secondary, theirs.Secondary, mine.Secondary, theirs.Secondary},
+		{"start_date", "Data inizio", dateValue(mine.StartDate), dateValue(theirs.StartDate), dateLabel(mine.StartDate), dateLabel(theirs.StartDate)},
+		{"end_date", "Data fine", dateValue(mine.EndDate), dateValue(theirs.EndDate), dateLabel(mine.EndDate), dateLabel(theirs.EndDate)},
+	}
+
+	// The bottom-sheet form shows responses inside itself; swap the whole form
+	// instead so the merge form does not end up nested in it
+	inSheet := r.Header.Get("HX-Trigger") == "recurrent-edit-form"
+	if inSheet {
+		w.Header().Set("HX-Retarget", "#recurrent-edit-form")
+		w.Header().Set("HX-Reswap", "outerHTML")
+	}
+
+	data := struct {
+		ID      int64
+		Version int64
+		InSheet bool
+		Fields  []conflictField
+	}{
+		ID:      theirs.ID,
+		Version: theirs.Version,
+		InSheet: inSheet,
+		Fields:  fields,
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusConflict)
+	if err := s.templates.ExecuteTemplate(w, "recurrent_conflict", data); err != nil {
+		slog.ErrorContext(r.Context(), "Template execution failed", "error", err, "template", "recurrent_conflict")
+		_, _ = w.Write([]byte(`<div class="error">La spesa ricorrente è stata modificata altrove, ricarica la pagina</div>`))
+	}
+}
+
 func (s *Server) handleDeleteRecurrentExpense(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodDelete && r.Method != http.MethodPost {
 		w.Header().Set("Allow", "DELETE, POST")
@@ -308,6 +483,7 @@ func (s *Server) handleDeleteRecurrentExpense(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	s.events.Publish(events.RecurrentDeleted, events.RefPayload{ID: strconv.FormatInt(id, 10)})
 	slog.InfoContext(r.Context(), "Recurrent expense deleted", "id", id)
 
 	// Trigger client refresh for HTMX
@@ -317,6 +493,61 @@ func (s *Server) handleDeleteRecurrentExpense(w http.ResponseWriter, r *http.Req
 	_, _ = w.Write([]byte(``))
 }
 
+// handleRecurrentState pauses, resumes or skips the next occurrence of a
+// recurrent expense, depending on the path; the list reloads on the
+// recurrent:updated trigger.
+func (s *Server) handleRecurrentState(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">ID non valido</div>`))
+		return
+	}
+
+	adapter, ok := s.expWriter.(*adapters.SQLiteAdapter)
+	if !ok {
+		w.WriteHeader(http.StatusNotImplemented)
+		_, _ = w.Write([]byte(`<div class="error">Spese ricorrenti non disponibili</div>`))
+		return
+	}
+	repo := adapter.GetStorage()
+
+	action := strings.TrimPrefix(r.URL.Path, "/recurrent/")
+	switch action {
+	case "pause":
+		err = repo.PauseRecurrentExpense(r.Context(), id)
+	case "resume":
+		err = repo.ResumeRecurrentExpense(r.Context(), id, time.Now())
+	default:
+		_, err = repo.SkipNextRecurrentOccurrence(r.Context(), id)
+	}
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`<div class="error">Spesa ricorrente non trovata</div>`))
+		return
+	case errors.Is(err, core.ErrNoOccurrence):
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`<div class="error">Nessuna prossima spesa da saltare</div>`))
+		return
+	case err != nil:
+		slog.ErrorContext(r.Context(), "Failed to change recurrent expense state", "error", err, "id", id, "action", action)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nell'aggiornare la spesa ricorrente</div>`))
+		return
+	}
+
+	s.events.Publish(events.RecurrentUpdated, events.RefPayload{ID: strconv.FormatInt(id, 10)})
+	w.Header().Set("HX-Trigger", `{"recurrent:updated": {}}`)
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func (s *Server) handleRecurrentExpensesList(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		w.Header().Set("Allow", "GET")
@@ -388,6 +619,9 @@ func (s *Server) handleRecurrentMonthlyOverview(w http.ResponseWriter, r *http.R
 	categoryTotals := make(map[string]int64)
 
 	for _, expense := range expenses {
+		if expense.Paused {
+			continue
+		}
 		// Convert to monthly amount based on frequency
 		monthlyCents := int64(0)
 		switch expense.Every {
@@ -398,8 +632,9 @@ func (s *Server) handleRecurrentMonthlyOverview(w http.ResponseWriter, r *http.R
 		case "monthly":
 			monthlyCents = expense.Amount.Cents
 		case "yearly":
-			monthlyCents = expense.Amount.Cents / 12
+			monthlyCents = expense.Amount.DivRound(12).Cents
 		}
+		monthlyCents = core.Money{Cents: monthlyCents}.DivRound(int64(expense.Step())).Cents
 
 		totalCents += monthlyCents
 		categoryTotals[expense.Primary] += monthlyCents
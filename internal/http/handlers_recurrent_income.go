@@ -0,0 +1,344 @@
+package http
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"spese/internal/adapters"
+	"spese/internal/core"
+	"spese/internal/storage"
+)
+
+// recurrentIncomeView is a row of the recurrent incomes list
+type recurrentIncomeView struct {
+	ID          int64
+	Schedule    string
+	Description string
+	Category    string
+	Subcategory string
+	Start       string
+	End         string // Empty when indefinite
+	Amount      string
+	Next        string // Date of the next income to be created, empty if none
+}
+
+// recurrentIncomeForm fills the create and edit forms of a recurrent income
+type recurrentIncomeForm struct {
+	core.RecurrentIncome
+	AmountText  string
+	StartText   string
+	EndText     string
+	Categories  []string
+	Frequencies []frequencyOption
+}
+
+// frequencyOption is a choice of the repetition type select
+type frequencyOption struct {
+	Value, Label string
+	Selected     bool
+}
+
+// recurrentIncomeStore returns the storage of recurrent incomes, writing a
+// 501 when the backend is not SQLite.
+func (s *Server) recurrentIncomeStore(w http.ResponseWriter) (*storage.SQLiteRepository, bool) {
+	adapter, ok := s.expWriter.(*adapters.SQLiteAdapter)
+	if !ok {
+		w.WriteHeader(http.StatusNotImplemented)
+		_, _ = w.Write([]byte(`<div class="error">Entrate ricorrenti non disponibili</div>`))
+		return nil, false
+	}
+	return adapter.GetStorage(), true
+}
+
+// newRecurrentIncomeForm prepares a form with ri's values, or the defaults
+// of a new monthly income when ri is zero
+func newRecurrentIncomeForm(ri core.RecurrentIncome, categories []string) recurrentIncomeForm {
+	form := recurrentIncomeForm{RecurrentIncome: ri, Categories: categories}
+	if ri.Every == "" {
+		form.Every = core.Monthly
+	}
+	if ri.Amount.Cents > 0 {
+		form.AmountText = strings.TrimPrefix(formatEuros(ri.Amount.Cents), "€")
+	}
+	if !ri.StartDate.IsZero() {
+		form.StartText = ri.StartDate.Format("2006-01-02")
+	}
+	if !ri.EndDate.IsZero() {
+		form.EndText = ri.EndDate.Format("2006-01-02")
+	}
+	for _, every := range []core.RepetitionTypes{core.Daily, core.Weekly, core.Monthly, core.Yearly} {
+		form.Frequencies = append(form.Frequencies, frequencyOption{
+			Value:    string(every),
+			Label:    repetitionLabels[string(every)],
+			Selected: every == form.Every,
+		})
+	}
+	return form
+}
+
+// parseRecurrentIncome reads a recurrent income from the form. It writes a
+// 422 and returns false when a field is invalid.
+func parseRecurrentIncome(w http.ResponseWriter, r *http.Request) (core.RecurrentIncome, bool) {
+	invalid := func(msg string) (core.RecurrentIncome, bool) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`<div class="error">` + template.HTMLEscapeString(msg) + `</div>`))
+		return core.RecurrentIncome{}, false
+	}
+
+	start, err := parseDate(r.Form.Get("start_date"))
+	if err != nil {
+		return invalid("Data inizio non valida")
+	}
+	var end core.Date
+	if v := r.Form.Get("end_date"); v != "" {
+		if end, err = parseDate(v); err != nil {
+			return invalid("Data fine non valida")
+		}
+	}
+	cents, err := core.ParseDecimalToCents(strings.TrimSpace(r.Form.Get("amount")))
+	if err != nil {
+		return invalid("Importo non valido")
+	}
+
+	ri := core.RecurrentIncome{
+		StartDate:   start,
+		EndDate:     end,
+		Every:       core.RepetitionTypes(r.Form.Get("repetition_type")),
+		Description: sanitizeInput(r.Form.Get("description")),
+		Amount:      core.Money{Cents: cents},
+		Category:    sanitizeInput(r.Form.Get("category")),
+		Subcategory: sanitizeInput(r.Form.Get("subcategory")),
+	}
+	if !parseSchedule(w, r, &ri.Interval, &ri.DayOfMonth) {
+		return core.RecurrentIncome{}, false
+	}
+	if err := ri.Validate(); err != nil {
+		return invalid("Dati non validi: " + err.Error())
+	}
+	return ri, true
+}
+
+// handleRecurrentIncomesList renders the list of recurrent incomes,
+// refreshed after every change
+func (s *Server) handleRecurrentIncomesList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	store, ok := s.recurrentIncomeStore(w)
+	if !ok {
+		return
+	}
+
+	incomes, err := store.GetRecurrentIncomes(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to get recurrent incomes", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento delle entrate ricorrenti</div>`))
+		return
+	}
+
+	views := make([]recurrentIncomeView, len(incomes))
+	for i, ri := range incomes {
+		views[i] = recurrentIncomeView{
+			ID:          ri.ID,
+			Schedule:    scheduleLabel(ri.Every, ri.Step(), ri.DayOfMonth),
+			Description: ri.Description,
+			Category:    ri.Category,
+			Subcategory: ri.Subcategory,
+			Start:       ri.StartDate.Format("02/01/2006"),
+			Amount:      formatEuros(ri.Amount.Cents),
+		}
+		if !ri.EndDate.IsZero() {
+			views[i].End = ri.EndDate.Format("02/01/2006")
+		}
+		if next, ok := ri.NextDue(); ok {
+			views[i].Next = next.Format("02/01/2006")
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "recurrent_incomes_list", views); err != nil {
+		slog.ErrorContext(r.Context(), "Template execution failed", "error", err, "template", "recurrent_incomes_list")
+	}
+}
+
+// handleRecurrentIncomeForm renders the form of a new recurrent income, or
+// with ?id= the inline edit form of an existing one
+func (s *Server) handleRecurrentIncomeForm(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	store, ok := s.recurrentIncomeStore(w)
+	if !ok {
+		return
+	}
+
+	categories, err := store.GetIncomeCategories(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to load income categories", "error", err)
+	}
+
+	name := "recurrent_income_form"
+	var ri core.RecurrentIncome
+	if v := r.URL.Query().Get("id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`<div class="error">ID non valido</div>`))
+			return
+		}
+		if ri, err = store.GetRecurrentIncomeByID(r.Context(), id); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`<div class="error">Entrata ricorrente non trovata</div>`))
+				return
+			}
+			slog.ErrorContext(r.Context(), "Failed to get recurrent income", "error", err, "id", id)
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento dell'entrata ricorrente</div>`))
+			return
+		}
+		name = "recurrent_income_edit_form"
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, name, newRecurrentIncomeForm(ri, categories)); err != nil {
+		slog.ErrorContext(r.Context(), "Template execution failed", "error", err, "template", name)
+	}
+}
+
+// handleCreateRecurrentIncome stores a new recurrent income
+func (s *Server) handleCreateRecurrentIncome(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+	store, ok := s.recurrentIncomeStore(w)
+	if !ok {
+		return
+	}
+	ri, ok := parseRecurrentIncome(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := store.CreateRecurrentIncome(r.Context(), ri)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to create recurrent income", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel salvare l'entrata ricorrente</div>`))
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Recurrent income created", "id", id, "description", ri.Description)
+	w.Header().Set("HX-Trigger", `{"recurrent-income:created": {}}`)
+	w.WriteHeader(http.StatusCreated)
+	_, _ = w.Write([]byte(`<div class="success">Entrata ricorrente salvata</div>`))
+}
+
+// handleUpdateRecurrentIncome saves the inline edit form of a recurrent
+// income; the version field guards against overwriting a newer change
+func (s *Server) handleUpdateRecurrentIncome(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut && r.Method != http.MethodPost {
+		w.Header().Set("Allow", "PUT, POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">ID non valido</div>`))
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+	version, ok := parseExpectedVersion(r)
+	if !ok {
+		w.WriteHeader(http.StatusPreconditionRequired)
+		_, _ = w.Write([]byte(`<div class="error">Versione mancante, ricarica la pagina</div>`))
+		return
+	}
+	store, ok := s.recurrentIncomeStore(w)
+	if !ok {
+		return
+	}
+	ri, ok := parseRecurrentIncome(w, r)
+	if !ok {
+		return
+	}
+	ri.Version = version
+
+	switch err := store.UpdateRecurrentIncome(r.Context(), id, ri); {
+	case errors.Is(err, core.ErrVersionConflict):
+		w.WriteHeader(http.StatusConflict)
+		_, _ = w.Write([]byte(`<div class="error">L'entrata ricorrente è stata modificata altrove, ricarica la pagina</div>`))
+		return
+	case errors.Is(err, sql.ErrNoRows):
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`<div class="error">Entrata ricorrente non trovata</div>`))
+		return
+	case err != nil:
+		slog.ErrorContext(r.Context(), "Failed to update recurrent income", "error", err, "id", id)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel salvare l'entrata ricorrente</div>`))
+		return
+	}
+
+	w.Header().Set("HX-Trigger", `{"recurrent-income:updated": {}}`)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(fmt.Sprintf(`<div class="success">%s aggiornata</div>`, template.HTMLEscapeString(ri.Description))))
+}
+
+// handleDeleteRecurrentIncome stops a recurrent income; the incomes it
+// already created are kept
+func (s *Server) handleDeleteRecurrentIncome(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete && r.Method != http.MethodPost {
+		w.Header().Set("Allow", "DELETE, POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">ID non valido</div>`))
+		return
+	}
+	store, ok := s.recurrentIncomeStore(w)
+	if !ok {
+		return
+	}
+
+	if err := store.DeleteRecurrentIncome(r.Context(), id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`<div class="error">Entrata ricorrente non trovata</div>`))
+			return
+		}
+		slog.ErrorContext(r.Context(), "Failed to delete recurrent income", "error", err, "id", id)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nell'eliminare l'entrata ricorrente</div>`))
+		return
+	}
+
+	w.Header().Set("HX-Trigger", `{"recurrent-income:deleted": {}}`)
+	w.WriteHeader(http.StatusOK)
+}
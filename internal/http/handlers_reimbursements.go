@@ -0,0 +1,302 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"spese/internal/adapters"
+	"spese/internal/core"
+	"spese/internal/storage"
+)
+
+// reimbursementCandidateWindow is how far back the link form offers
+// expenses and incomes.
+const reimbursementCandidateWindow = 90 * 24 * time.Hour
+
+type reimbursementView struct {
+	IncomeID string
+	Date     string
+	Desc     string
+	Category string
+	Amount   string
+	Linked   string
+	Net      string
+	Links    []reimbursementLinkView
+}
+
+type reimbursementLinkView struct {
+	ExpenseID     string
+	Date          string
+	Desc          string
+	Category      string
+	ExpenseAmount string
+	Amount        string
+}
+
+// selectOption is an entry of a record picker in a form
+type selectOption struct {
+	ID    string
+	Label string
+}
+
+type reimbursementsData struct {
+	Reimbursements []reimbursementView
+	Income         string // Sum of the linked incomes
+	Linked         string // Sum of the linked expense amounts
+	Net            string
+}
+
+// reimbursementStore returns the SQLite repository holding reimbursement
+// links, writing a 501 and returning false for other backends.
+func (s *Server) reimbursementStore(w http.ResponseWriter) (*storage.SQLiteRepository, bool) {
+	adapter, ok := s.expWriter.(*adapters.SQLiteAdapter)
+	if !ok {
+		w.WriteHeader(http.StatusNotImplemented)
+		_, _ = w.Write([]byte(`<div class="error">Rimborsi disponibili solo con il backend SQLite</div>`))
+		return nil, false
+	}
+	return adapter.GetStorage(), true
+}
+
+// loadReimbursements builds the reimbursable report
+func loadReimbursements(ctx context.Context, store *storage.SQLiteRepository) (reimbursementsData, error) {
+	list, err := store.ListReimbursements(ctx)
+	if err != nil {
+		return reimbursementsData{}, err
+	}
+
+	var data reimbursementsData
+	var income, linked int64
+	for _, rb := range list {
+		view := reimbursementView{
+			IncomeID: strconv.FormatInt(rb.IncomeID, 10),
+			Date:     rb.Income.Date.Format("02/01/2006"),
+			Desc:     rb.Income.Description,
+			Category: rb.Income.Category,
+			Amount:   formatEuros(rb.Income.Amount.Cents),
+			Linked:   formatEuros(rb.Linked.Cents),
+			Net:      formatEuros(rb.Net().Cents),
+		}
+		for _, l := range rb.Links {
+			view.Links = append(view.Links, reimbursementLinkView{
+				ExpenseID:     strconv.FormatInt(l.ExpenseID, 10),
+				Date:          l.Expense.Date.Format("02/01/2006"),
+				Desc:          l.Expense.Description,
+				Category:      l.Expense.Primary + " / " + l.Expense.Secondary,
+				ExpenseAmount: formatEuros(l.Expense.Amount.Cents),
+				Amount:        formatEuros(l.Amount.Cents),
+			})
+		}
+		data.Reimbursements = append(data.Reimbursements, view)
+		income += rb.Income.Amount.Cents
+		linked += rb.Linked.Cents
+	}
+	data.Income = formatEuros(income)
+	data.Linked = formatEuros(linked)
+	data.Net = formatEuros(income - linked)
+	return data, nil
+}
+
+// handleReimbursements renders the reimbursable report with the link form
+func (s *Server) handleReimbursements(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.reimbursementStore(w)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	report, err := loadReimbursements(ctx, store)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list reimbursements", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento dei rimborsi</div>`))
+		return
+	}
+
+	now := time.Now()
+	expenses, incomes, err := store.ListReimbursementCandidates(ctx, now.Add(-reimbursementCandidateWindow), now)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list reimbursement candidates", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento dei movimenti</div>`))
+		return
+	}
+
+	data := struct {
+		Report   reimbursementsData
+		Incomes  []selectOption
+		Expenses []selectOption
+	}{Report: report}
+	for _, inc := range incomes {
+		data.Incomes = append(data.Incomes, selectOption{
+			ID:    inc.ID,
+			Label: fmt.Sprintf("%s · %s · %s", inc.Income.Date.Format("02/01"), inc.Income.Description, formatEuros(inc.Income.Amount.Cents)),
+		})
+	}
+	for _, e := range expenses {
+		data.Expenses = append(data.Expenses, selectOption{
+			ID:    e.ID,
+			Label: fmt.Sprintf("%s · %s · %s", e.Expense.Date.Format("02/01"), e.Expense.Description, formatEuros(e.Expense.Amount.Cents)),
+		})
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "reimbursements_page", data); err != nil {
+		slog.ErrorContext(r.Context(), "Reimbursements template execution failed", "error", err, "template", "reimbursements_page")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handleReimbursementsList renders the report, refreshed after every change
+func (s *Server) handleReimbursementsList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.reimbursementStore(w)
+	if !ok {
+		return
+	}
+
+	report, err := loadReimbursements(r.Context(), store)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list reimbursements", "error", err)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento dei rimborsi</div>`))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "reimbursements_list", report); err != nil {
+		slog.ErrorContext(r.Context(), "Template execution failed", "error", err, "template", "reimbursements_list")
+	}
+}
+
+// handleLinkReimbursement links an income to one or more expenses.
+// Form fields: income_id, expense_id (repeated).
+func (s *Server) handleLinkReimbursement(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.reimbursementStore(w)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+
+	incomeID, ok := parseFormID(w, r, "income_id", "ID entrata non valido")
+	if !ok {
+		return
+	}
+	var expenseIDs []int64
+	for _, v := range r.Form["expense_id"] {
+		id, err := strconv.ParseInt(sanitizeInput(v), 10, 64)
+		if err != nil || id <= 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`<div class="error">ID spesa non valido</div>`))
+			return
+		}
+		expenseIDs = append(expenseIDs, id)
+	}
+	if len(expenseIDs) == 0 {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`<div class="error">Seleziona almeno una spesa</div>`))
+		return
+	}
+
+	links, err := store.LinkReimbursement(r.Context(), incomeID, expenseIDs)
+	if errors.Is(err, core.ErrNothingToReimburse) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`<div class="error">Nessun importo da collegare: entrata o spese già rimborsate</div>`))
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to link reimbursement", "error", err, "income_id", incomeID)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel collegamento del rimborso</div>`))
+		return
+	}
+
+	var total int64
+	for _, l := range links {
+		total += l.Amount.Cents
+	}
+	slog.InfoContext(r.Context(), "Reimbursement linked", "income_id", incomeID, "expenses", len(links), "amount_cents", total)
+	w.Header().Set("HX-Trigger", `{"reimbursements:changed": {}}`)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = fmt.Fprintf(w, `<div class="success">Collegate %d spese per %s</div>`, len(links), formatEuros(total))
+}
+
+// handleUnlinkReimbursement removes a link. Form fields: income_id, expense_id.
+func (s *Server) handleUnlinkReimbursement(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.reimbursementStore(w)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+	incomeID, ok := parseFormID(w, r, "income_id", "ID entrata non valido")
+	if !ok {
+		return
+	}
+	expenseID, ok := parseFormID(w, r, "expense_id", "ID spesa non valido")
+	if !ok {
+		return
+	}
+
+	if err := store.UnlinkReimbursement(r.Context(), incomeID, expenseID); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to unlink reimbursement", "error", err, "income_id", incomeID, "expense_id", expenseID)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nella rimozione del collegamento</div>`))
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Reimbursement unlinked", "income_id", incomeID, "expense_id", expenseID)
+	w.Header().Set("HX-Trigger", `{"reimbursements:changed": {}}`)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(`<div class="success">Collegamento rimosso</div>`))
+}
+
+// parseFormID reads a positive id from an already parsed form, writing a
+// 400 with message when invalid
+func parseFormID(w http.ResponseWriter, r *http.Request, field, message string) (int64, bool) {
+	id, err := strconv.ParseInt(sanitizeInput(r.Form.Get(field)), 10, 64)
+	if err != nil || id <= 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">` + message + `</div>`))
+		return 0, false
+	}
+	return id, true
+}
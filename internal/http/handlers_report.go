@@ -0,0 +1,198 @@
+package http
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"spese/internal/adapters"
+	"spese/internal/storage"
+)
+
+// yearReportLine is the expenses of a primary category in a year, or the
+// totals of all of them
+type yearReportLine struct {
+	Name     string
+	Months   [12]int64
+	Total    int64
+	Previous int64 // Total of the year before
+}
+
+// yearReportRow is a yearReportLine formatted for the page
+type yearReportRow struct {
+	Name     string
+	Months   [12]string // Empty for months without expenses
+	Total    string
+	Previous string
+	Delta    string // Change from the year before, signed
+	DeltaPct string // e.g. "+12%"; empty when nothing was spent the year before
+	Up       bool   // More was spent than the year before
+}
+
+// yearReportView is the data of the year report page
+type yearReportView struct {
+	Year     int
+	PrevYear int
+	NextYear int
+	Months   []string // Short month names
+	Rows     []yearReportRow
+	Totals   yearReportRow
+}
+
+// handleYearReport serves /report/year/{year}: the expenses of a year per
+// primary category and month, with the yearly totals and the change from
+// the year before, as a page or, with format=csv, as a CSV of cents
+func (s *Server) handleYearReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	adapter, ok := s.expWriter.(*adapters.SQLiteAdapter)
+	if !ok {
+		w.WriteHeader(http.StatusNotImplemented)
+		_, _ = w.Write([]byte(`<div class="error">Report annuale disponibile solo con il backend SQLite</div>`))
+		return
+	}
+	year, err := strconv.Atoi(r.PathValue("year"))
+	if err != nil || year < 1900 || year > 9999 {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Anno non valido</div>`))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	current, err := adapter.GetStorage().ExpenseYearByCategory(ctx, year)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to load the year report", "error", err, "year", year)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento del report</div>`))
+		return
+	}
+	previous, err := adapter.GetStorage().ExpenseYearByCategory(ctx, year-1)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to load the year report", "error", err, "year", year-1)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento del report</div>`))
+		return
+	}
+	lines, totals := newYearReportLines(current, previous)
+
+	if r.URL.Query().Get("format") == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="spese-%d.csv"`, year))
+		writeYearReportCSV(w, year, lines, totals)
+		return
+	}
+
+	view := yearReportView{Year: year, PrevYear: year - 1, NextYear: year + 1, Totals: newYearReportRow(totals)}
+	for _, name := range italianMonths {
+		view.Months = append(view.Months, name[:3])
+	}
+	for _, line := range lines {
+		view.Rows = append(view.Rows, newYearReportRow(line))
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "year_report_page", view); err != nil {
+		slog.ErrorContext(r.Context(), "Template execution failed", "error", err, "template", "year_report_page")
+	}
+}
+
+// newYearReportLines pairs the categories of a year with those of the
+// year before, largest yearly total first. Categories spent on only the
+// year before are kept, so the deltas add up to the change of the total.
+func newYearReportLines(current, previous []storage.CategoryYear) ([]yearReportLine, yearReportLine) {
+	byName := make(map[string]*yearReportLine)
+	line := func(name string) *yearReportLine {
+		if byName[name] == nil {
+			byName[name] = &yearReportLine{Name: name}
+		}
+		return byName[name]
+	}
+	for _, c := range current {
+		l := line(c.Name)
+		for i, m := range c.Months {
+			l.Months[i] = m.Cents
+			l.Total += m.Cents
+		}
+	}
+	for _, c := range previous {
+		l := line(c.Name)
+		for _, m := range c.Months {
+			l.Previous += m.Cents
+		}
+	}
+
+	totals := yearReportLine{Name: "Totale"}
+	lines := make([]yearReportLine, 0, len(byName))
+	for _, l := range byName {
+		lines = append(lines, *l)
+		for i, cents := range l.Months {
+			totals.Months[i] += cents
+		}
+		totals.Total += l.Total
+		totals.Previous += l.Previous
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		if lines[i].Total != lines[j].Total {
+			return lines[i].Total > lines[j].Total
+		}
+		return lines[i].Name < lines[j].Name
+	})
+	return lines, totals
+}
+
+func newYearReportRow(l yearReportLine) yearReportRow {
+	row := yearReportRow{
+		Name:     l.Name,
+		Total:    formatEuros(l.Total),
+		Previous: formatEuros(l.Previous),
+		Up:       l.Total > l.Previous,
+	}
+	for i, cents := range l.Months {
+		if cents != 0 {
+			row.Months[i] = formatEuros(cents)
+		}
+	}
+	delta := l.Total - l.Previous
+	row.Delta = formatEuros(delta)
+	if delta > 0 {
+		row.Delta = "+" + row.Delta
+	}
+	if l.Previous > 0 {
+		row.DeltaPct = fmt.Sprintf("%+d%%", delta*100/l.Previous)
+	}
+	return row
+}
+
+// writeYearReportCSV writes a line per category and the totals, with the
+// amounts in cents
+func writeYearReportCSV(w http.ResponseWriter, year int, lines []yearReportLine, totals yearReportLine) {
+	cw := csv.NewWriter(w)
+	header := []string{"category"}
+	for m := 1; m <= 12; m++ {
+		header = append(header, fmt.Sprintf("%d-%02d", year, m))
+	}
+	header = append(header, "total_cents", "previous_total_cents", "delta_cents")
+	_ = cw.Write(header)
+	totals.Name = "total"
+	for _, l := range append(lines, totals) {
+		record := []string{l.Name}
+		for _, cents := range l.Months {
+			record = append(record, strconv.FormatInt(cents, 10))
+		}
+		record = append(record,
+			strconv.FormatInt(l.Total, 10),
+			strconv.FormatInt(l.Previous, 10),
+			strconv.FormatInt(l.Total-l.Previous, 10))
+		_ = cw.Write(record)
+	}
+	cw.Flush()
+}
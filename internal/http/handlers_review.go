@@ -0,0 +1,258 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"spese/internal/core"
+	"spese/internal/services"
+)
+
+// reviewHistoryMonths is how many months back the review page lists
+const reviewHistoryMonths = 12
+
+// SetMonthReviewer enables the monthly review page and the closing of
+// months. Without it the review routes answer 501.
+func (s *Server) SetMonthReviewer(m *services.MonthReviewer) {
+	s.monthReviewer = m
+}
+
+// writeMonthClosed answers a change to a record of a closed month
+func writeMonthClosed(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusConflict)
+	_, _ = w.Write([]byte(`<div class="error">Il mese è chiuso: riaprilo dalla revisione mensile</div>`))
+}
+
+type reviewItem struct {
+	Date     string
+	Desc     string
+	Amount   string
+	Category string
+}
+
+type reviewOverrun struct {
+	Primary string
+	Amount  string
+	Budget  string
+	Excess  string
+}
+
+type reviewMonth struct {
+	Period   string
+	Label    string
+	Closed   bool
+	Selected bool
+}
+
+// monthReviewView is the checklist of a month and the list of months
+// with their review state
+type monthReviewView struct {
+	Period        string
+	Label         string
+	Uncategorized []reviewItem
+	Duplicates    [][]reviewItem
+	Unsynced      []reviewItem
+	Overruns      []reviewOverrun
+	Open          int
+	Closed        bool
+	ClosedAt      string
+	Months        []reviewMonth
+}
+
+func newReviewItems(expenses []core.ReviewExpense) []reviewItem {
+	items := make([]reviewItem, len(expenses))
+	for i, e := range expenses {
+		items[i] = reviewItem{
+			Date:     e.Date.Format("02/01"),
+			Desc:     e.Description,
+			Amount:   formatEuros(e.Amount.Cents),
+			Category: strings.TrimSpace(e.Primary + " / " + e.Secondary),
+		}
+	}
+	return items
+}
+
+// reviewMonthLabel names a month, as in "maggio 2031"
+func reviewMonthLabel(t time.Time) string {
+	return fmt.Sprintf("%s %d", italianMonths[t.Month()-1], t.Year())
+}
+
+// reviewPeriod returns the month asked for in the month parameter, the
+// previous one by default: the month to review once it is over.
+func reviewPeriod(r *http.Request, now time.Time) (time.Time, error) {
+	if month := r.URL.Query().Get("month"); month != "" {
+		return core.ParsePeriod(month)
+	}
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0), nil
+}
+
+// loadMonthReview builds the review page of the month of start
+func (s *Server) loadMonthReview(ctx context.Context, start, now time.Time) (monthReviewView, error) {
+	review, err := s.monthReviewer.Review(ctx, start.Year(), int(start.Month()))
+	if err != nil {
+		return monthReviewView{}, err
+	}
+	closed, err := s.monthReviewer.ClosedMonths(ctx)
+	if err != nil {
+		return monthReviewView{}, err
+	}
+
+	view := monthReviewView{
+		Period:        review.Period,
+		Label:         reviewMonthLabel(start),
+		Uncategorized: newReviewItems(review.Uncategorized),
+		Unsynced:      newReviewItems(review.Unsynced),
+		Open:          review.Open(),
+		Closed:        review.Closed(),
+	}
+	if view.Closed {
+		view.ClosedAt = review.ClosedAt.Local().Format("02/01/2006 15:04")
+	}
+	for _, group := range review.Duplicates {
+		view.Duplicates = append(view.Duplicates, newReviewItems(group))
+	}
+	for _, o := range review.Overruns {
+		view.Overruns = append(view.Overruns, reviewOverrun{
+			Primary: o.Primary,
+			Amount:  formatEuros(o.Amount.Cents),
+			Budget:  formatEuros(o.Budget.Cents),
+			Excess:  formatEuros(o.Amount.Cents - o.Budget.Cents),
+		})
+	}
+
+	// The last months, and older ones that were closed
+	months := make(map[string]time.Time)
+	first := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < reviewHistoryMonths; i++ {
+		m := first.AddDate(0, -i, 0)
+		months[core.Period(m)] = m
+	}
+	months[review.Period] = start
+	for period := range closed {
+		if m, err := core.ParsePeriod(period); err == nil {
+			months[period] = m
+		}
+	}
+	for period, m := range months {
+		_, isClosed := closed[period]
+		view.Months = append(view.Months, reviewMonth{Period: period, Label: reviewMonthLabel(m), Closed: isClosed, Selected: period == review.Period})
+	}
+	sort.Slice(view.Months, func(i, j int) bool { return view.Months[i].Period > view.Months[j].Period })
+	return view, nil
+}
+
+// handleMonthReview renders the end-of-month review of the month
+// parameter ("2006-01"), the previous month by default
+func (s *Server) handleMonthReview(w http.ResponseWriter, r *http.Request) {
+	s.renderMonthReview(w, r, "month_review_page")
+}
+
+// handleMonthReviewBody renders the checklist and the months, refreshed
+// after a month is closed or reopened
+func (s *Server) handleMonthReviewBody(w http.ResponseWriter, r *http.Request) {
+	s.renderMonthReview(w, r, "month_review_body")
+}
+
+func (s *Server) renderMonthReview(w http.ResponseWriter, r *http.Request, name string) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if s.monthReviewer == nil {
+		w.WriteHeader(http.StatusNotImplemented)
+		_, _ = w.Write([]byte(`<div class="error">Revisione mensile disponibile solo con il backend SQLite</div>`))
+		return
+	}
+
+	now := time.Now()
+	start, err := reviewPeriod(r, now)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Mese non valido</div>`))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	view, err := s.loadMonthReview(ctx, start, now)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to load month review", "error", err, "period", core.Period(start))
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento della revisione</div>`))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, name, view); err != nil {
+		slog.ErrorContext(r.Context(), "Template execution failed", "error", err, "template", name)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handleCloseMonth closes a reviewed month. Form field: month.
+func (s *Server) handleCloseMonth(w http.ResponseWriter, r *http.Request) {
+	s.changeMonthReview(w, r, true)
+}
+
+// handleReopenMonth reopens a closed month. Form field: month.
+func (s *Server) handleReopenMonth(w http.ResponseWriter, r *http.Request) {
+	s.changeMonthReview(w, r, false)
+}
+
+func (s *Server) changeMonthReview(w http.ResponseWriter, r *http.Request, closeMonth bool) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if s.monthReviewer == nil {
+		w.WriteHeader(http.StatusNotImplemented)
+		_, _ = w.Write([]byte(`<div class="error">Revisione mensile disponibile solo con il backend SQLite</div>`))
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+	start, err := core.ParsePeriod(r.Form.Get("month"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Mese non valido</div>`))
+		return
+	}
+	period := core.Period(start)
+
+	message := "Mese di " + reviewMonthLabel(start) + " chiuso"
+	if closeMonth {
+		err = s.monthReviewer.Close(r.Context(), period)
+	} else {
+		err = s.monthReviewer.Reopen(r.Context(), period)
+		message = "Mese di " + reviewMonthLabel(start) + " riaperto"
+	}
+	if err != nil {
+		slog.WarnContext(r.Context(), "Failed to change month review", "error", err, "period", period, "close", closeMonth)
+		if closeMonth {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`<div class="error">Errore nella chiusura del mese</div>`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`<div class="error">Il mese non è chiuso</div>`))
+		return
+	}
+
+	w.Header().Set("HX-Trigger", `{"review:changed": {}}`)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(`<div class="success">` + message + `</div>`))
+}
@@ -0,0 +1,200 @@
+package http
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"spese/internal/services"
+)
+
+// SetRuleReprocessor enables applying rules to the stored expenses.
+func (s *Server) SetRuleReprocessor(p *services.RuleReprocessor) {
+	s.ruleReprocessor = p
+}
+
+// ruleReprocessorReady writes a 501 and returns false when rules cannot be
+// applied to the stored expenses.
+func (s *Server) ruleReprocessorReady(w http.ResponseWriter) bool {
+	if s.ruleReprocessor == nil {
+		w.WriteHeader(http.StatusNotImplemented)
+		_, _ = w.Write([]byte(`<div class="error">Riapplicazione delle regole disponibile solo con il backend SQLite</div>`))
+		return false
+	}
+	return true
+}
+
+// ruleScope reads the scope parameter, uncategorized expenses by default
+func ruleScope(r *http.Request) string {
+	if r.FormValue("scope") == services.RuleScopeAll {
+		return services.RuleScopeAll
+	}
+	return services.RuleScopeUncategorized
+}
+
+// handleRuleReprocess renders the preview of applying a rule to the stored
+// expenses, with the changes to pick. Query parameters: id, scope
+// (uncategorized or all).
+func (s *Server) handleRuleReprocess(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.ruleReprocessorReady(w) {
+		return
+	}
+
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil || id <= 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">ID regola non valido</div>`))
+		return
+	}
+	preview, err := s.ruleReprocessor.Preview(r.Context(), id, ruleScope(r))
+	if errors.Is(err, services.ErrRuleNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`<div class="error">Regola non trovata</div>`))
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to preview category rule", "error", err, "rule_id", id)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nella valutazione della regola</div>`))
+		return
+	}
+
+	type proposal struct {
+		ID     int64
+		Date   string
+		Desc   string
+		Amount string
+		From   string
+	}
+	proposals := make([]proposal, len(preview.Proposals))
+	for i, p := range preview.Proposals {
+		proposals[i] = proposal{
+			ID:     p.ID,
+			Date:   p.Date.Format("02/01/2006"),
+			Desc:   p.Description,
+			Amount: formatEuros(p.Amount.Cents),
+			From:   p.FromPrimary + " / " + p.FromSecondary,
+		}
+	}
+	data := struct {
+		services.RulePreview
+		All       bool
+		Proposals []proposal
+		Status    ruleReprocessStatus
+	}{
+		RulePreview: preview,
+		All:         preview.Scope == services.RuleScopeAll,
+		Proposals:   proposals,
+		Status:      s.ruleReprocessStatus(id),
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "rule_reprocess_page", data); err != nil {
+		slog.ErrorContext(r.Context(), "Rule reprocess template execution failed", "error", err, "template", "rule_reprocess_page")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handleStartRuleReprocess applies a rule to the picked expenses in the
+// background and renders its progress. Form fields: id, scope, expense_id
+// repeated once per picked expense.
+func (s *Server) handleStartRuleReprocess(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.ruleReprocessorReady(w) {
+		return
+	}
+
+	id, ok := parseRuleID(w, r)
+	if !ok {
+		return
+	}
+	var ids []int64
+	for _, v := range r.Form["expense_id"] {
+		expenseID, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || expenseID <= 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`<div class="error">ID spesa non valido</div>`))
+			return
+		}
+		ids = append(ids, expenseID)
+	}
+	if len(ids) == 0 {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`<div class="error">Nessuna spesa selezionata</div>`))
+		return
+	}
+
+	err := s.ruleReprocessor.Start(r.Context(), id, ruleScope(r), ids)
+	switch {
+	case errors.Is(err, services.ErrRuleNotFound):
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`<div class="error">Regola non trovata</div>`))
+		return
+	case errors.Is(err, services.ErrRuleReprocessRunning):
+		w.WriteHeader(http.StatusConflict)
+		_, _ = w.Write([]byte(`<div class="error">La regola è già in corso di applicazione</div>`))
+		return
+	case err != nil:
+		slog.ErrorContext(r.Context(), "Failed to start applying category rule", "error", err, "rule_id", id)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nell'avvio della riapplicazione</div>`))
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Applying category rule to stored expenses", "rule_id", id, "selected", len(ids))
+	s.renderRuleReprocessStatus(w, r, id)
+}
+
+// handleRuleReprocessStatus renders the progress of applying a rule, polled
+// while it runs. Query parameters: id.
+func (s *Server) handleRuleReprocessStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.ruleReprocessorReady(w) {
+		return
+	}
+
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil || id <= 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">ID regola non valido</div>`))
+		return
+	}
+	s.renderRuleReprocessStatus(w, r, id)
+}
+
+// ruleReprocessStatus is the view model of the progress panel
+type ruleReprocessStatus struct {
+	services.RuleReprocessStatus
+	Started bool // False before the first run
+}
+
+func (s *Server) ruleReprocessStatus(id int64) ruleReprocessStatus {
+	status, started := s.ruleReprocessor.Status(id)
+	status.RuleID = id
+	return ruleReprocessStatus{RuleReprocessStatus: status, Started: started}
+}
+
+func (s *Server) renderRuleReprocessStatus(w http.ResponseWriter, r *http.Request, id int64) {
+	data := s.ruleReprocessStatus(id)
+	if data.Started && !data.Running {
+		w.Header().Set("HX-Trigger", `{"dashboard:refresh": {}}`)
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "rule_reprocess_status", data); err != nil {
+		slog.ErrorContext(r.Context(), "Template execution failed", "error", err, "template", "rule_reprocess_status")
+	}
+}
@@ -0,0 +1,326 @@
+package http
+
+import (
+	"context"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"spese/internal/adapters"
+	"spese/internal/core"
+	"spese/internal/rules"
+	"spese/internal/storage"
+)
+
+// ruleStore returns the SQLite repository holding categorization rules,
+// writing a 501 and returning false for other backends.
+func (s *Server) ruleStore(w http.ResponseWriter) (*storage.SQLiteRepository, bool) {
+	adapter, ok := s.expWriter.(*adapters.SQLiteAdapter)
+	if !ok {
+		w.WriteHeader(http.StatusNotImplemented)
+		_, _ = w.Write([]byte(`<div class="error">Regole disponibili solo con il backend SQLite</div>`))
+		return nil, false
+	}
+	return adapter.GetStorage(), true
+}
+
+// handleRules renders the categorization rules page
+func (s *Server) handleRules(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.ruleStore(w)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	list, err := store.ListCategoryRules(ctx)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list category rules", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento delle regole</div>`))
+		return
+	}
+
+	cats, subs, err := s.taxReader.List(ctx)
+	if err != nil {
+		// Suggestions only: the page works without them
+		slog.WarnContext(r.Context(), "Failed to load categories for rules page", "error", err)
+	}
+
+	vars := make([]string, 0, len(rules.ExpenseVars))
+	for name := range rules.ExpenseVars {
+		vars = append(vars, name)
+	}
+	sort.Strings(vars)
+
+	data := struct {
+		Rules         []core.CategoryRule
+		Categories    []string
+		Subcategories []string
+		Vars          []string
+	}{
+		Rules:         list,
+		Categories:    cats,
+		Subcategories: subs,
+		Vars:          vars,
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "rules_page", data); err != nil {
+		slog.ErrorContext(r.Context(), "Rules template execution failed", "error", err, "template", "rules_page")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handleRulesList renders the rules table, refreshed after every change
+func (s *Server) handleRulesList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.ruleStore(w)
+	if !ok {
+		return
+	}
+
+	list, err := store.ListCategoryRules(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list category rules", "error", err)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento delle regole</div>`))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "rules_list", struct{ Rules []core.CategoryRule }{list}); err != nil {
+		slog.ErrorContext(r.Context(), "Template execution failed", "error", err, "template", "rules_list")
+	}
+}
+
+// handleCreateRule validates and stores a categorization rule.
+// Form fields: name, expression, primary, secondary, priority.
+func (s *Server) handleCreateRule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.ruleStore(w)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+
+	rule := core.CategoryRule{
+		Name:       sanitizeInput(r.Form.Get("name")),
+		Expression: strings.TrimSpace(r.Form.Get("expression")),
+		Primary:    sanitizeInput(r.Form.Get("primary")),
+		Secondary:  sanitizeInput(r.Form.Get("secondary")),
+		Active:     true,
+	}
+	if p := strings.TrimSpace(r.Form.Get("priority")); p != "" {
+		priority, err := strconv.ParseInt(p, 10, 64)
+		if err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_, _ = w.Write([]byte(`<div class="error">Priorità non valida</div>`))
+			return
+		}
+		rule.Priority = priority
+	}
+
+	if err := rule.Validate(); err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`<div class="error">Dati non validi: ` + template.HTMLEscapeString(err.Error()) + `</div>`))
+		return
+	}
+	if _, err := rules.CompileExpenseCondition(rule.Expression); err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`<div class="error">Condizione non valida: ` + template.HTMLEscapeString(err.Error()) + `</div>`))
+		return
+	}
+
+	id, err := store.CreateCategoryRule(r.Context(), rule)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to create category rule", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel salvataggio della regola</div>`))
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Category rule created", "rule_id", id, "name", rule.Name)
+	w.Header().Set("HX-Trigger", `{"rules:changed": {}, "rules:created": {}}`)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(`<div class="success">Regola salvata` + s.ruleReprocessLink(id) + `</div>`))
+}
+
+// handleToggleRule enables or disables a rule. Form fields: id, active.
+func (s *Server) handleToggleRule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.ruleStore(w)
+	if !ok {
+		return
+	}
+
+	id, ok := parseRuleID(w, r)
+	if !ok {
+		return
+	}
+	active := r.Form.Get("active") == "true"
+
+	if err := store.SetCategoryRuleActive(r.Context(), id, active); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to update category rule", "error", err, "rule_id", id)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nell'aggiornamento della regola</div>`))
+		return
+	}
+
+	message := "Regola disattivata"
+	if active {
+		message = "Regola attivata" + s.ruleReprocessLink(id)
+	}
+	w.Header().Set("HX-Trigger", `{"rules:changed": {}}`)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(`<div class="success">` + message + `</div>`))
+}
+
+// ruleReprocessLink offers applying a new or reactivated rule to the stored
+// expenses, when possible.
+func (s *Server) ruleReprocessLink(id int64) string {
+	if s.ruleReprocessor == nil {
+		return ""
+	}
+	return ` · <a href="/regole/riprocessa?id=` + strconv.FormatInt(id, 10) + `">applica alle spese passate</a>`
+}
+
+// handleDeleteRule removes a rule. Form fields: id.
+func (s *Server) handleDeleteRule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.ruleStore(w)
+	if !ok {
+		return
+	}
+
+	id, ok := parseRuleID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := store.DeleteCategoryRule(r.Context(), id); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to delete category rule", "error", err, "rule_id", id)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nell'eliminazione della regola</div>`))
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Category rule deleted", "rule_id", id)
+	w.Header().Set("HX-Trigger", `{"rules:changed": {}}`)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(`<div class="success">Regola eliminata</div>`))
+}
+
+// handleTestRule evaluates a condition against a sample expense without
+// saving anything. Errors are reported with 200 so HTMX shows them.
+// Form fields: expression, sample_description, sample_amount,
+// sample_primary, sample_date.
+func (s *Server) handleTestRule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fail := func(msg string) {
+		_, _ = w.Write([]byte(`<div class="error">` + template.HTMLEscapeString(msg) + `</div>`))
+	}
+
+	prog, err := rules.CompileExpenseCondition(r.Form.Get("expression"))
+	if err != nil {
+		fail("Condizione non valida: " + err.Error())
+		return
+	}
+
+	sample := core.Expense{
+		Description: sanitizeInput(r.Form.Get("sample_description")),
+		Primary:     sanitizeInput(r.Form.Get("sample_primary")),
+		Date:        core.Date{Time: time.Now()},
+	}
+	if a := strings.TrimSpace(r.Form.Get("sample_amount")); a != "" {
+		cents, err := core.ParseDecimalToCents(a)
+		if err != nil {
+			fail("Importo di prova non valido")
+			return
+		}
+		sample.Amount = core.Money{Cents: cents}
+	}
+	if d := strings.TrimSpace(r.Form.Get("sample_date")); d != "" {
+		date, err := parseDate(d)
+		if err != nil {
+			fail("Data di prova non valida")
+			return
+		}
+		sample.Date = date
+	}
+
+	matched, err := prog.EvalBool(rules.ExpenseEnv(sample))
+	if err != nil {
+		fail("Errore di valutazione: " + err.Error())
+		return
+	}
+
+	if matched {
+		_, _ = w.Write([]byte(`<div class="success">La spesa di prova corrisponde alla condizione</div>`))
+		return
+	}
+	_, _ = w.Write([]byte(`<div class="caption">La spesa di prova non corrisponde alla condizione</div>`))
+}
+
+// parseRuleID reads the rule id form field, writing a 400 when invalid
+func parseRuleID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return 0, false
+	}
+	id, err := strconv.ParseInt(sanitizeInput(r.Form.Get("id")), 10, 64)
+	if err != nil || id <= 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">ID regola non valido</div>`))
+		return 0, false
+	}
+	return id, true
+}
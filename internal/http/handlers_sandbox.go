@@ -0,0 +1,92 @@
+package http
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"spese/internal/sheets"
+)
+
+// maxSandboxRows caps a copy to the sandbox, which writes them in one request
+const maxSandboxRows = 1000
+
+// SetSheetSandbox enables the page filling the sandbox spreadsheet the
+// sync writes to. Without it the page answers 501.
+func (s *Server) SetSheetSandbox(sb sheets.SandboxCopier) {
+	s.sandbox = sb
+}
+
+// sheetSandboxReady writes a 501 and returns false when no sandbox is
+// configured
+func (s *Server) sheetSandboxReady(w http.ResponseWriter) bool {
+	if s.sandbox == nil {
+		w.WriteHeader(http.StatusNotImplemented)
+		_, _ = w.Write([]byte(`<div class="error">Foglio di prova non configurato: imposta GOOGLE_SANDBOX_SPREADSHEET_ID</div>`))
+		return false
+	}
+	return true
+}
+
+// handleSheetSandbox renders the page copying production rows to the
+// sandbox spreadsheet
+func (s *Server) handleSheetSandbox(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.sheetSandboxReady(w) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	data := struct{ Max int }{maxSandboxRows}
+	if err := s.templates.ExecuteTemplate(w, "sheet_sandbox_page", data); err != nil {
+		slog.ErrorContext(r.Context(), "Sheet sandbox template execution failed", "error", err, "template", "sheet_sandbox_page")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handleCopyToSandbox appends the last rows of the production expenses
+// sheet to the sandbox. Form field: rows.
+func (s *Server) handleCopyToSandbox(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.sheetSandboxReady(w) {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(r.Form.Get("rows")))
+	if err != nil || n < 1 || n > maxSandboxRows {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Numero di righe non valido: da 1 a ` + strconv.Itoa(maxSandboxRows) + `</div>`))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	copied, err := s.sandbox.CopyRecentRows(ctx, n)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to copy rows to the sandbox spreadsheet", "error", err, "rows", n)
+		w.WriteHeader(http.StatusBadGateway)
+		_, _ = w.Write([]byte(`<div class="error">Errore durante la copia verso il foglio di prova</div>`))
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Rows copied to the sandbox spreadsheet", "requested", n, "copied", copied)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(`<div class="success">Righe copiate nel foglio di prova: ` + strconv.Itoa(copied) + `</div>`))
+}
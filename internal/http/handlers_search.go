@@ -0,0 +1,133 @@
+package http
+
+import (
+	"context"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"spese/internal/adapters"
+	"spese/internal/core"
+	"spese/internal/storage"
+)
+
+// searchResultsLimit caps the expenses a search lists
+const searchResultsLimit = 100
+
+// searchView is the data of the search results
+type searchView struct {
+	Query  bool // Whether any filter was given
+	Rows   []ledgerRow
+	Count  int
+	Total  string
+	Capped bool
+}
+
+// parseExpenseSearch reads q, from, to (2006-01-02), category, min and
+// max; the returned message is shown when a parameter is invalid
+func parseExpenseSearch(r *http.Request) (storage.ExpenseSearch, string) {
+	q := r.URL.Query()
+	search := storage.ExpenseSearch{
+		Text:     sanitizeInput(q.Get("q")),
+		Category: sanitizeInput(q.Get("category")),
+	}
+	for _, bound := range []struct {
+		param string
+		dst   *core.Date
+	}{{"from", &search.From}, {"to", &search.To}} {
+		v := strings.TrimSpace(q.Get(bound.param))
+		if v == "" {
+			continue
+		}
+		d, err := parseDate(v)
+		if err != nil {
+			return search, "Data non valida: " + v
+		}
+		*bound.dst = d
+	}
+	if !search.From.IsZero() && !search.To.IsZero() && search.To.Before(search.From.Time) {
+		return search, "La data finale precede quella iniziale"
+	}
+	for _, bound := range []struct {
+		param string
+		dst   *core.Money
+	}{{"min", &search.Min}, {"max", &search.Max}} {
+		v := strings.TrimSpace(q.Get(bound.param))
+		if v == "" {
+			continue
+		}
+		cents, err := core.ParseDecimalToCents(v)
+		if err != nil {
+			return search, "Importo non valido: " + v
+		}
+		*bound.dst = core.Money{Cents: cents}
+	}
+	if search.Max.Cents > 0 && search.Max.Cents < search.Min.Cents {
+		return search, "L'importo massimo è minore del minimo"
+	}
+	return search, ""
+}
+
+// handleExpenseSearch serves GET /expenses/search: the newest expenses
+// whose description contains q, dated between from and to, of a primary
+// or secondary category and with an amount between min and max, as the
+// fragment the search box of the index page swaps in while typing
+func (s *Server) handleExpenseSearch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	adapter, ok := s.expWriter.(*adapters.SQLiteAdapter)
+	if !ok {
+		w.WriteHeader(http.StatusNotImplemented)
+		_, _ = w.Write([]byte(`<div class="error">Ricerca disponibile solo con il backend SQLite</div>`))
+		return
+	}
+
+	search, invalid := parseExpenseSearch(r)
+	if invalid != "" {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">` + template.HTMLEscapeString(invalid) + `</div>`))
+		return
+	}
+
+	var view searchView
+	view.Query = search != storage.ExpenseSearch{}
+	if view.Query {
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		expenses, err := adapter.GetStorage().SearchExpenses(ctx, search, searchResultsLimit+1)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Failed to search expenses", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`<div class="error">Errore nella ricerca delle spese</div>`))
+			return
+		}
+		if len(expenses) > searchResultsLimit {
+			expenses = expenses[:searchResultsLimit]
+			view.Capped = true
+		}
+		var total int64
+		for _, e := range expenses {
+			view.Rows = append(view.Rows, ledgerRow{
+				Date:     e.Expense.Date.Format("02/01/2006"),
+				Desc:     e.Expense.Description,
+				Category: e.Expense.Primary + " / " + e.Expense.Secondary,
+				Amount:   formatEuros(e.Expense.Amount.Cents),
+				Pending:  e.Expense.Status == core.StatusPending,
+			})
+			total += e.Expense.Amount.Cents
+		}
+		view.Count = len(expenses)
+		view.Total = formatEuros(total)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "expense_search_results", view); err != nil {
+		slog.ErrorContext(r.Context(), "Search template execution failed", "error", err, "template", "expense_search_results")
+	}
+}
@@ -0,0 +1,192 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"spese/internal/services"
+)
+
+// SetHistoryImporter enables the backfill page of the Google Sheets
+// history. Without it the page answers 501.
+func (s *Server) SetHistoryImporter(h *services.HistoryImporter) {
+	s.historyImporter = h
+}
+
+// sheetHistoryRow is the view model of a year on the backfill page
+type sheetHistoryRow struct {
+	Year    int
+	State   string
+	Detail  string
+	Percent int
+	Action  string // Label of the import button; empty when there is none
+}
+
+// sheetHistoryList is the view model of the years table
+type sheetHistoryList struct {
+	Years   []sheetHistoryRow
+	Running bool // Keeps the table polling while an import runs
+}
+
+func newSheetHistoryRow(st services.HistoryYearStatus) sheetHistoryRow {
+	p := st.Progress
+	row := sheetHistoryRow{Year: st.Year, Percent: st.Percent()}
+	switch {
+	case st.Running:
+		row.State = "In corso"
+		row.Detail = fmt.Sprintf("Righe %d di %d", p.NextRow, p.TotalRows)
+	case p.Completed():
+		row.State = "Importato"
+		row.Detail = fmt.Sprintf("%d spese, il %s", p.Expenses, p.CompletedAt.Local().Format("02/01/2006 15:04"))
+	case st.Started:
+		row.State = "Interrotto"
+		row.Detail = fmt.Sprintf("Righe %d di %d", p.NextRow, p.TotalRows)
+		if p.LastError != "" {
+			row.Detail += ": " + p.LastError
+		}
+		row.Action = "Riprendi"
+	case st.Existing > 0:
+		row.State = "Non importabile"
+		row.Detail = fmt.Sprintf("%d spese già presenti nel database", st.Existing)
+	default:
+		row.State = "Da importare"
+		row.Action = "Importa"
+	}
+	return row
+}
+
+// loadSheetHistory returns the years table
+func (s *Server) loadSheetHistory(ctx context.Context) (sheetHistoryList, error) {
+	statuses, err := s.historyImporter.Status(ctx)
+	if err != nil {
+		return sheetHistoryList{}, err
+	}
+	var list sheetHistoryList
+	for _, st := range statuses {
+		list.Years = append(list.Years, newSheetHistoryRow(st))
+		list.Running = list.Running || st.Running
+	}
+	return list, nil
+}
+
+// historyImporterReady writes a 501 and returns false when the backfill
+// is not available
+func (s *Server) historyImporterReady(w http.ResponseWriter) bool {
+	if s.historyImporter == nil {
+		w.WriteHeader(http.StatusNotImplemented)
+		_, _ = w.Write([]byte(`<div class="error">Importazione dello storico non disponibile: serve il backend SQLite con Google Sheets configurato</div>`))
+		return false
+	}
+	return true
+}
+
+// handleSheetHistory renders the backfill page of the Google Sheets
+// history, a row per past year
+func (s *Server) handleSheetHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.historyImporterReady(w) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+
+	list, err := s.loadSheetHistory(ctx)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to load sheet history status", "error", err)
+		w.WriteHeader(http.StatusBadGateway)
+		_, _ = w.Write([]byte(`<div class="error">Errore durante la lettura dei fogli di Google Sheets</div>`))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "sheet_history_page", list); err != nil {
+		slog.ErrorContext(r.Context(), "Sheet history template execution failed", "error", err, "template", "sheet_history_page")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handleSheetHistoryList renders the years table, polled while an import
+// runs
+func (s *Server) handleSheetHistoryList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.historyImporterReady(w) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+
+	list, err := s.loadSheetHistory(ctx)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to load sheet history status", "error", err)
+		_, _ = w.Write([]byte(`<div class="error">Errore durante la lettura dei fogli di Google Sheets</div>`))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "sheet_history_list", list); err != nil {
+		slog.ErrorContext(r.Context(), "Template execution failed", "error", err, "template", "sheet_history_list")
+	}
+}
+
+// handleImportSheetHistory starts or resumes the import of a year in the
+// background. Form field: year.
+func (s *Server) handleImportSheetHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.historyImporterReady(w) {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+	year, err := strconv.Atoi(strings.TrimSpace(r.Form.Get("year")))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Anno non valido</div>`))
+		return
+	}
+
+	err = s.historyImporter.Start(r.Context(), year)
+	switch {
+	case errors.Is(err, services.ErrHistoryImportRunning):
+		w.WriteHeader(http.StatusConflict)
+		_, _ = w.Write([]byte(`<div class="error">Importazione del ` + strconv.Itoa(year) + ` già in corso</div>`))
+		return
+	case errors.Is(err, services.ErrHistoryYearNotPast):
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`<div class="error">Si possono importare solo gli anni passati</div>`))
+		return
+	case err != nil:
+		slog.ErrorContext(r.Context(), "Failed to start sheet history import", "error", err, "year", year)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nell'avvio dell'importazione</div>`))
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Sheet history import started", "year", year)
+	w.Header().Set("HX-Trigger", `{"history:changed": {}}`)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(`<div class="success">Importazione del ` + strconv.Itoa(year) + ` avviata</div>`))
+}
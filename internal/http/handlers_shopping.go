@@ -0,0 +1,553 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"spese/internal/adapters"
+	"spese/internal/core"
+	"spese/internal/events"
+	"spese/internal/hooks"
+)
+
+type shoppingListView struct {
+	ID        int64
+	Name      string
+	ExpenseID int64 // Zero while open
+	Items     int
+	Total     string // Sum of the checked items
+	Selected  bool
+}
+
+type shoppingItemView struct {
+	ID       int64
+	Name     string
+	Quantity string
+	Price    string // As typed in the form ("3,38"), empty until known
+	Checked  bool
+}
+
+type shoppingDetailView struct {
+	ID          int64
+	Name        string
+	ExpenseID   int64
+	Items       []shoppingItemView
+	Today       string
+	Primaries   []string
+	Secondaries []string
+}
+
+// shoppingAdapter returns the SQLite adapter holding shopping lists, writing
+// a 501 and returning false for other backends.
+func (s *Server) shoppingAdapter(w http.ResponseWriter) (*adapters.SQLiteAdapter, bool) {
+	adapter, ok := s.expWriter.(*adapters.SQLiteAdapter)
+	if !ok {
+		w.WriteHeader(http.StatusNotImplemented)
+		_, _ = w.Write([]byte(`<div class="error">Liste della spesa disponibili solo con il backend SQLite</div>`))
+		return nil, false
+	}
+	return adapter, true
+}
+
+// formatQuantity renders a quantity with a decimal comma, e.g. "1,5".
+func formatQuantity(q float64) string {
+	return strings.ReplaceAll(strconv.FormatFloat(q, 'f', -1, 64), ".", ",")
+}
+
+// formatShoppingItem describes a bought item, e.g. "Latte intero ×2 €3,38".
+func formatShoppingItem(item core.ShoppingItem) string {
+	s := item.Name
+	if item.Quantity != 1 {
+		s += " ×" + formatQuantity(item.Quantity)
+	}
+	return s + " " + formatEuros(item.Price.Cents)
+}
+
+// parseItemPrice parses an optional line price; empty means not known yet.
+func parseItemPrice(s string) (core.Money, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return core.Money{}, nil
+	}
+	cents, err := core.ParseDecimalToCents(s)
+	if err != nil {
+		return core.Money{}, err
+	}
+	return core.Money{Cents: cents}, nil
+}
+
+// loadShoppingLists lists the shopping lists, marking the selected one
+func loadShoppingLists(ctx context.Context, adapter *adapters.SQLiteAdapter, selected int64) ([]shoppingListView, error) {
+	lists, err := adapter.GetStorage().ListShoppingLists(ctx)
+	if err != nil {
+		return nil, err
+	}
+	views := make([]shoppingListView, len(lists))
+	for i, l := range lists {
+		views[i] = shoppingListView{
+			ID:        l.ID,
+			Name:      l.Name,
+			ExpenseID: l.ExpenseID,
+			Items:     l.Items,
+			Total:     formatEuros(l.Checked.Cents),
+			Selected:  l.ID == selected,
+		}
+	}
+	return views, nil
+}
+
+// loadShoppingDetail loads a list with its items and the categories offered
+// by the conversion form
+func (s *Server) loadShoppingDetail(ctx context.Context, adapter *adapters.SQLiteAdapter, id int64) (shoppingDetailView, error) {
+	list, err := adapter.GetStorage().GetShoppingList(ctx, id)
+	if err != nil {
+		return shoppingDetailView{}, err
+	}
+
+	view := shoppingDetailView{
+		ID:        list.ID,
+		Name:      list.Name,
+		ExpenseID: list.ExpenseID,
+		Items:     make([]shoppingItemView, len(list.Items)),
+		Today:     time.Now().Format("2006-01-02"),
+	}
+	for i, item := range list.Items {
+		view.Items[i] = shoppingItemView{
+			ID:       item.ID,
+			Name:     item.Name,
+			Quantity: formatQuantity(item.Quantity),
+			Checked:  item.Checked,
+		}
+		if item.Price.Cents > 0 {
+			view.Items[i].Price = strings.TrimPrefix(formatEuros(item.Price.Cents), "€")
+		}
+	}
+	if !list.Converted() {
+		view.Primaries, view.Secondaries, err = s.taxReader.List(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to list categories", "error", err)
+		}
+	}
+	return view, nil
+}
+
+// handleShopping renders the shopping lists with the selected one (query
+// parameter id, defaulting to the first open list)
+func (s *Server) handleShopping(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	adapter, ok := s.shoppingAdapter(w)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	selected, _ := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	lists, err := loadShoppingLists(ctx, adapter, selected)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list shopping lists", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento delle liste</div>`))
+		return
+	}
+	if selected == 0 && len(lists) > 0 && lists[0].ExpenseID == 0 {
+		selected = lists[0].ID
+		lists[0].Selected = true
+	}
+
+	data := struct {
+		Lists  []shoppingListView
+		Detail *shoppingDetailView
+	}{Lists: lists}
+	if selected > 0 {
+		detail, err := s.loadShoppingDetail(ctx, adapter, selected)
+		if err != nil {
+			slog.WarnContext(r.Context(), "Failed to load shopping list", "error", err, "list_id", selected)
+		} else {
+			data.Detail = &detail
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "shopping_page", data); err != nil {
+		slog.ErrorContext(r.Context(), "Shopping template execution failed", "error", err, "template", "shopping_page")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handleShoppingLists renders the lists, refreshed after every change.
+// Query parameters: id (selected list).
+func (s *Server) handleShoppingLists(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	adapter, ok := s.shoppingAdapter(w)
+	if !ok {
+		return
+	}
+
+	selected, _ := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	lists, err := loadShoppingLists(r.Context(), adapter, selected)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list shopping lists", "error", err)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento delle liste</div>`))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "shopping_lists", lists); err != nil {
+		slog.ErrorContext(r.Context(), "Template execution failed", "error", err, "template", "shopping_lists")
+	}
+}
+
+// handleShoppingDetail renders one list with its items, refreshed after
+// every change. Query parameters: id.
+func (s *Server) handleShoppingDetail(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	adapter, ok := s.shoppingAdapter(w)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil || id <= 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">ID lista non valido</div>`))
+		return
+	}
+	detail, err := s.loadShoppingDetail(r.Context(), adapter, id)
+	if err != nil {
+		slog.WarnContext(r.Context(), "Failed to load shopping list", "error", err, "list_id", id)
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`<div class="error">Lista non trovata</div>`))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "shopping_detail", detail); err != nil {
+		slog.ErrorContext(r.Context(), "Template execution failed", "error", err, "template", "shopping_detail")
+	}
+}
+
+// handleCreateShoppingList creates a list and opens it. Form fields: name.
+func (s *Server) handleCreateShoppingList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	adapter, ok := s.shoppingAdapter(w)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+
+	id, err := adapter.GetStorage().CreateShoppingList(r.Context(), sanitizeInput(r.Form.Get("name")))
+	if err != nil {
+		slog.WarnContext(r.Context(), "Failed to create shopping list", "error", err)
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`<div class="error">Lista non valida: ` + template.HTMLEscapeString(err.Error()) + `</div>`))
+		return
+	}
+
+	w.Header().Set("HX-Redirect", fmt.Sprintf("/liste?id=%d", id))
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(`<div class="success">Lista creata</div>`))
+}
+
+// handleDeleteShoppingList removes an open list. Form fields: list_id.
+func (s *Server) handleDeleteShoppingList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	adapter, ok := s.shoppingAdapter(w)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+	listID, ok := parseFormID(w, r, "list_id", "ID lista non valido")
+	if !ok {
+		return
+	}
+
+	if err := adapter.GetStorage().DeleteShoppingList(r.Context(), listID); err != nil {
+		slog.WarnContext(r.Context(), "Failed to delete shopping list", "error", err, "list_id", listID)
+		w.WriteHeader(http.StatusConflict)
+		_, _ = w.Write([]byte(`<div class="error">Solo le liste non convertite possono essere eliminate</div>`))
+		return
+	}
+
+	w.Header().Set("HX-Redirect", "/liste")
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(`<div class="success">Lista eliminata</div>`))
+}
+
+// handleAddShoppingItem appends an item to an open list.
+// Form fields: list_id, name, quantity (default 1), price (optional).
+func (s *Server) handleAddShoppingItem(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	adapter, ok := s.shoppingAdapter(w)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+	listID, ok := parseFormID(w, r, "list_id", "ID lista non valido")
+	if !ok {
+		return
+	}
+
+	item := core.ShoppingItem{Name: sanitizeInput(r.Form.Get("name")), Quantity: 1}
+	if v := strings.TrimSpace(r.Form.Get("quantity")); v != "" {
+		quantity, err := core.ParseQuantity(v)
+		if err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_, _ = w.Write([]byte(`<div class="error">Quantità non valida</div>`))
+			return
+		}
+		item.Quantity = quantity
+	}
+	price, err := parseItemPrice(r.Form.Get("price"))
+	if err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`<div class="error">Prezzo non valido</div>`))
+		return
+	}
+	item.Price = price
+
+	if err := adapter.GetStorage().AddShoppingItem(r.Context(), listID, item); err != nil {
+		slog.WarnContext(r.Context(), "Failed to add shopping item", "error", err, "list_id", listID)
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`<div class="error">Articolo non valido: ` + template.HTMLEscapeString(err.Error()) + `</div>`))
+		return
+	}
+
+	w.Header().Set("HX-Trigger", `{"shopping:changed": {}}`)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(`<div class="success">Articolo aggiunto</div>`))
+}
+
+// handleUpdateShoppingItem checks an item off and sets its price.
+// Form fields: id, checked (present when checked), price (optional).
+func (s *Server) handleUpdateShoppingItem(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	adapter, ok := s.shoppingAdapter(w)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+	itemID, ok := parseFormID(w, r, "id", "ID articolo non valido")
+	if !ok {
+		return
+	}
+	price, err := parseItemPrice(r.Form.Get("price"))
+	if err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`<div class="error">Prezzo non valido</div>`))
+		return
+	}
+	checked := r.Form.Get("checked") != ""
+
+	if err := adapter.GetStorage().UpdateShoppingItem(r.Context(), itemID, checked, price); err != nil {
+		slog.WarnContext(r.Context(), "Failed to update shopping item", "error", err, "item_id", itemID)
+		w.WriteHeader(http.StatusConflict)
+		_, _ = w.Write([]byte(`<div class="error">Articolo non modificabile</div>`))
+		return
+	}
+
+	// Only the totals change; re-rendering the items would steal the focus
+	w.Header().Set("HX-Trigger", `{"shopping:checked": {}}`)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleDeleteShoppingItem removes an item from an open list.
+// Form fields: id.
+func (s *Server) handleDeleteShoppingItem(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	adapter, ok := s.shoppingAdapter(w)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+	itemID, ok := parseFormID(w, r, "id", "ID articolo non valido")
+	if !ok {
+		return
+	}
+
+	if err := adapter.GetStorage().DeleteShoppingItem(r.Context(), itemID); err != nil {
+		slog.WarnContext(r.Context(), "Failed to delete shopping item", "error", err, "item_id", itemID)
+		w.WriteHeader(http.StatusConflict)
+		_, _ = w.Write([]byte(`<div class="error">Articolo non modificabile</div>`))
+		return
+	}
+
+	w.Header().Set("HX-Trigger", `{"shopping:changed": {}}`)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(`<div class="success">Articolo rimosso</div>`))
+}
+
+// handleConvertShoppingList writes a single expense for the checked items
+// of a list, which stays linked to it as the breakdown.
+// Form fields: list_id, date, description (defaults to the list name),
+// primary, secondary.
+func (s *Server) handleConvertShoppingList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	adapter, ok := s.shoppingAdapter(w)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+	listID, ok := parseFormID(w, r, "list_id", "ID lista non valido")
+	if !ok {
+		return
+	}
+
+	store := adapter.GetStorage()
+	list, err := store.GetShoppingList(r.Context(), listID)
+	if err != nil {
+		slog.WarnContext(r.Context(), "Failed to load shopping list", "error", err, "list_id", listID)
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`<div class="error">Lista non trovata</div>`))
+		return
+	}
+	switch err := list.ValidateConversion(); {
+	case errors.Is(err, core.ErrListConverted):
+		w.WriteHeader(http.StatusConflict)
+		_, _ = w.Write([]byte(`<div class="error">Lista già convertita</div>`))
+		return
+	case errors.Is(err, core.ErrUnpricedItem):
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`<div class="error">Indica il prezzo di tutti gli articoli spuntati</div>`))
+		return
+	case err != nil:
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`<div class="error">Nessun articolo spuntato</div>`))
+		return
+	}
+
+	date, err := parseDate(strings.TrimSpace(r.Form.Get("date")))
+	if err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`<div class="error">Data non valida</div>`))
+		return
+	}
+	desc := sanitizeInput(r.Form.Get("description"))
+	if desc == "" {
+		desc = list.Name
+	}
+	exp := core.Expense{
+		Date:        date,
+		Description: desc,
+		Amount:      list.Total(),
+		Primary:     sanitizeInput(r.Form.Get("primary")),
+		Secondary:   sanitizeInput(r.Form.Get("secondary")),
+	}
+	if err := exp.Validate(); err != nil {
+		s.countEntry("expense", "create", outcomeValidationError, 1)
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`<div class="error">Dati non validi: ` + template.HTMLEscapeString(err.Error()) + `</div>`))
+		return
+	}
+
+	ref, err := adapter.Append(r.Context(), exp)
+	s.countEntryErr("expense", "create", err)
+	if errors.Is(err, hooks.ErrRejected) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`<div class="error">` + template.HTMLEscapeString(err.Error()) + `</div>`))
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to save shopping list expense", "error", err, "list_id", listID, "amount_cents", exp.Amount.Cents)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel salvataggio della spesa</div>`))
+		return
+	}
+
+	s.events.Publish(events.ExpenseCreated, events.ExpenseFrom(exp))
+
+	// The expense is saved either way; a missing link only loses the breakdown
+	if id, err := strconv.ParseInt(ref, 10, 64); err != nil {
+		slog.ErrorContext(r.Context(), "Unexpected expense reference", "ref", ref)
+	} else if err := store.MarkShoppingListConverted(r.Context(), listID, id); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to link shopping list to expense", "error", err, "list_id", listID, "expense_id", id)
+	}
+
+	slog.InfoContext(r.Context(), "Shopping list converted", "list_id", listID, "expense_id", ref, "amount_cents", exp.Amount.Cents)
+	w.Header().Set("HX-Trigger", `{"shopping:changed": {}, "dashboard:refresh": {}}`)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = fmt.Fprintf(w, `<div class="success">Spesa registrata: %s</div>`, formatEuros(exp.Amount.Cents))
+}
@@ -0,0 +1,241 @@
+package http
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"spese/internal/adapters"
+	"spese/internal/core"
+	"spese/internal/storage"
+)
+
+// splitBlankRows is how many empty rows the split editor offers
+const splitBlankRows = 2
+
+type splitRow struct {
+	Note      string
+	Amount    string // As typed in the form ("3,38")
+	Primary   string
+	Secondary string
+}
+
+// splitStore returns the SQLite repository holding split expenses, writing a
+// 501 and returning false for other backends.
+func (s *Server) splitStore(w http.ResponseWriter) (*storage.SQLiteRepository, bool) {
+	adapter, ok := s.expWriter.(*adapters.SQLiteAdapter)
+	if !ok {
+		w.WriteHeader(http.StatusNotImplemented)
+		_, _ = w.Write([]byte(`<div class="error">Spese divise disponibili solo con il backend SQLite</div>`))
+		return nil, false
+	}
+	return adapter.GetStorage(), true
+}
+
+// formatSplitPart describes a part, e.g. "€12,00 · Casa / Pulizia · detersivi".
+func formatSplitPart(p core.SplitPart) string {
+	s := formatEuros(p.Amount.Cents) + " · " + p.Primary + " / " + p.Secondary
+	if p.Note != "" {
+		s += " · " + p.Note
+	}
+	return s
+}
+
+// monthSplits returns the described parts of the month's split expenses by
+// expense ID, nil for backends without them.
+func (s *Server) monthSplits(ctx context.Context, year, month int) map[string][]string {
+	adapter, ok := s.expWriter.(*adapters.SQLiteAdapter)
+	if !ok {
+		return nil
+	}
+	splits, err := adapter.GetStorage().MonthSplits(ctx, year, month)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to list split expenses", "error", err, "year", year, "month", month)
+		return nil
+	}
+	parts := make(map[string][]string, len(splits))
+	for id, list := range splits {
+		key := strconv.FormatInt(id, 10)
+		for _, p := range list {
+			parts[key] = append(parts[key], formatSplitPart(p))
+		}
+	}
+	return parts
+}
+
+// handleSplit renders the split editor of an expense.
+// Query parameters: id.
+func (s *Server) handleSplit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.splitStore(w)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil || id <= 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">ID spesa non valido</div>`))
+		return
+	}
+	expense, err := store.GetExpense(r.Context(), id)
+	if err != nil {
+		slog.WarnContext(r.Context(), "Failed to load expense", "error", err, "expense_id", id)
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`<div class="error">Spesa non trovata</div>`))
+		return
+	}
+	parts, err := store.ListExpenseSplit(r.Context(), id)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list split parts", "error", err, "expense_id", id)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento delle parti</div>`))
+		return
+	}
+	primaries, secondaries, err := s.taxReader.List(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list categories", "error", err)
+	}
+
+	rows := make([]splitRow, 0, len(parts)+splitBlankRows)
+	for _, p := range parts {
+		rows = append(rows, splitRow{
+			Note:      p.Note,
+			Amount:    strings.TrimPrefix(formatEuros(p.Amount.Cents), "€"),
+			Primary:   p.Primary,
+			Secondary: p.Secondary,
+		})
+	}
+	if len(parts) == 0 {
+		// Start from the categories of the expense
+		rows = append(rows, splitRow{Primary: expense.PrimaryCategory, Secondary: expense.SecondaryCategory})
+	}
+	for range splitBlankRows {
+		rows = append(rows, splitRow{})
+	}
+
+	data := struct {
+		ID          int64
+		Date        string
+		Desc        string
+		Category    string
+		Amount      string
+		Pending     bool
+		Split       bool
+		Rows        []splitRow
+		MaxNote     int
+		Primaries   []string
+		Secondaries []string
+	}{
+		ID:          id,
+		Date:        expense.Date.Format("02/01/2006"),
+		Desc:        expense.Description,
+		Category:    expense.PrimaryCategory + " / " + expense.SecondaryCategory,
+		Amount:      formatEuros(expense.AmountCents),
+		Pending:     expense.Status == string(core.StatusPending),
+		Split:       len(parts) > 0,
+		Rows:        rows,
+		MaxNote:     core.MaxSplitNoteLength,
+		Primaries:   primaries,
+		Secondaries: secondaries,
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "split_page", data); err != nil {
+		slog.ErrorContext(r.Context(), "Split template execution failed", "error", err, "template", "split_page")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handleSaveSplit replaces the parts of a split expense; no parts removes
+// the split. Form fields: expense_id, then note, amount, primary and
+// secondary repeated once per row; blank rows are ignored.
+func (s *Server) handleSaveSplit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.splitStore(w)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+	expenseID, ok := parseFormID(w, r, "expense_id", "ID spesa non valido")
+	if !ok {
+		return
+	}
+
+	field := func(name string, i int) string {
+		if values := r.Form[name]; i < len(values) {
+			return sanitizeInput(values[i])
+		}
+		return ""
+	}
+	var parts []core.SplitPart
+	for i := range r.Form["amount"] {
+		note, amount := field("note", i), strings.TrimSpace(field("amount", i))
+		primary, secondary := field("primary", i), field("secondary", i)
+		if amount == "" && note == "" {
+			continue
+		}
+		cents, err := core.ParseDecimalToCents(amount)
+		if err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_, _ = fmt.Fprintf(w, `<div class="error">Parte %d: importo non valido</div>`, i+1)
+			return
+		}
+		parts = append(parts, core.SplitPart{
+			Note:      note,
+			Amount:    core.Money{Cents: cents},
+			Primary:   primary,
+			Secondary: secondary,
+		})
+	}
+
+	err := store.SetExpenseSplit(r.Context(), expenseID, parts)
+	switch {
+	case errors.Is(err, core.ErrInvalidSplit):
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`<div class="error">Divisione non valida: ` + template.HTMLEscapeString(err.Error()) + `</div>`))
+		return
+	case errors.Is(err, core.ErrMonthClosed):
+		writeMonthClosed(w)
+		return
+	case errors.Is(err, sql.ErrNoRows):
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`<div class="error">Spesa non trovata</div>`))
+		return
+	case err != nil:
+		slog.ErrorContext(r.Context(), "Failed to save split", "error", err, "expense_id", expenseID)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel salvataggio della divisione</div>`))
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Expense split saved", "expense_id", expenseID, "parts", len(parts))
+	w.Header().Set("HX-Trigger", `{"dashboard:refresh": {}}`)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if len(parts) == 0 {
+		_, _ = w.Write([]byte(`<div class="success">Divisione rimossa</div>`))
+		return
+	}
+	_, _ = fmt.Fprintf(w, `<div class="success">Spesa divisa in %d parti</div>`, len(parts))
+}
@@ -0,0 +1,150 @@
+package http
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"spese/internal/adapters"
+	"spese/internal/storage"
+)
+
+const (
+	// sqlConsoleRows caps the rows the console shows
+	sqlConsoleRows = 500
+	// sqlConsoleCSVRows caps the rows of a CSV download
+	sqlConsoleCSVRows = 50000
+	// sqlConsoleTimeout is how long a console query may run
+	sqlConsoleTimeout = 10 * time.Second
+)
+
+// SetSQLConsole turns on the read-only SQL console at /admin/sql. It is
+// off by default: the console reads every table.
+func (s *Server) SetSQLConsole(enabled bool) {
+	s.sqlConsole = enabled
+}
+
+// sqlConsoleCell is a value of the console results
+type sqlConsoleCell struct {
+	Value string
+	Null  bool
+}
+
+// sqlConsoleView is the data of the console results
+type sqlConsoleView struct {
+	Query     string
+	Columns   []string
+	Rows      [][]sqlConsoleCell
+	Truncated bool
+	Limit     int
+	Elapsed   string
+}
+
+// handleSQLConsole serves /admin/sql: GET renders the console, POST runs
+// the query field on the read-only connection and renders its rows, or
+// downloads them as CSV with format=csv
+func (s *Server) handleSQLConsole(w http.ResponseWriter, r *http.Request) {
+	if !s.sqlConsole {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		w.Header().Set("Allow", "GET, POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	adapter, ok := s.expWriter.(*adapters.SQLiteAdapter)
+	if !ok {
+		w.WriteHeader(http.StatusNotImplemented)
+		_, _ = w.Write([]byte(`<div class="error">Console SQL disponibile solo con il backend SQLite</div>`))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if r.Method == http.MethodGet {
+		data := struct{ Limit, TimeoutSeconds int }{sqlConsoleRows, int(sqlConsoleTimeout.Seconds())}
+		if err := s.templates.ExecuteTemplate(w, "sql_console_page", data); err != nil {
+			slog.ErrorContext(r.Context(), "SQL console template execution failed", "error", err, "template", "sql_console_page")
+		}
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+	query := strings.TrimSpace(r.Form.Get("query"))
+	if query == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Scrivi una query</div>`))
+		return
+	}
+	asCSV := r.Form.Get("format") == "csv"
+	limit := sqlConsoleRows
+	if asCSV {
+		limit = sqlConsoleCSVRows
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), sqlConsoleTimeout)
+	defer cancel()
+	start := time.Now()
+	res, err := adapter.GetStorage().ConsoleQuery(ctx, query, limit)
+	elapsed := time.Since(start)
+	slog.InfoContext(r.Context(), "SQL console query", "query", query, "rows", len(res.Rows), "duration", elapsed, "error", err)
+	switch {
+	case errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded):
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">La query ha superato il limite di ` + strconv.Itoa(int(sqlConsoleTimeout.Seconds())) + ` secondi</div>`))
+		return
+	case errors.Is(err, storage.ErrConsoleQuery):
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Si può eseguire una sola istruzione SELECT, WITH, VALUES o EXPLAIN</div>`))
+		return
+	case err != nil:
+		// SQLite errors are the user's syntax or names, worth showing
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Errore: ` + template.HTMLEscapeString(err.Error()) + `</div>`))
+		return
+	}
+
+	if asCSV {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="query.csv"`)
+		cw := csv.NewWriter(w)
+		_ = cw.Write(res.Columns)
+		record := make([]string, len(res.Columns))
+		for _, row := range res.Rows {
+			for i, v := range row {
+				record[i] = v.String // Empty for NULL
+			}
+			_ = cw.Write(record)
+		}
+		cw.Flush()
+		return
+	}
+
+	view := sqlConsoleView{
+		Query:     query,
+		Columns:   res.Columns,
+		Truncated: res.Truncated,
+		Limit:     limit,
+		Elapsed:   elapsed.Round(time.Millisecond).String(),
+	}
+	for _, row := range res.Rows {
+		cells := make([]sqlConsoleCell, len(row))
+		for i, v := range row {
+			cells[i] = sqlConsoleCell{Value: v.String, Null: !v.Valid}
+		}
+		view.Rows = append(view.Rows, cells)
+	}
+	if err := s.templates.ExecuteTemplate(w, "sql_console_results", view); err != nil {
+		slog.ErrorContext(r.Context(), "SQL console template execution failed", "error", err, "template", "sql_console_results")
+	}
+}
@@ -0,0 +1,209 @@
+package http
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"spese/internal/adapters"
+	"spese/internal/storage"
+)
+
+// maxSyncStatusItems bounds the queue items listed on the sync status page
+const maxSyncStatusItems = 200
+
+// syncItemView is a queue item of the sync status page
+type syncItemView struct {
+	ID          int64
+	Delete      bool   // Whether the item removes the expense from the sheet
+	Failed      bool   // Whether the item gave up retrying
+	Status      string // Italian label of the status
+	Date        string
+	Description string
+	Amount      string
+	Attempts    int64
+	NextRetry   string // When a deferred item is tried again, empty if due
+	LastError   string
+}
+
+// syncStatusView is the data of the sync status page
+type syncStatusView struct {
+	Items   []syncItemView
+	Pending int64
+	Failed  int64
+}
+
+// syncStatusStore returns the storage of the sync queue, writing a 501 when
+// the backend has none
+func (s *Server) syncStatusStore(w http.ResponseWriter) (*storage.SQLiteRepository, bool) {
+	adapter, ok := s.expWriter.(*adapters.SQLiteAdapter)
+	if !ok {
+		w.WriteHeader(http.StatusNotImplemented)
+		_, _ = w.Write([]byte(`<div class="error">Coda di sincronizzazione disponibile solo con il backend SQLite</div>`))
+		return nil, false
+	}
+	return adapter.GetStorage(), true
+}
+
+// syncFailures returns the queue items that gave up retrying, for the
+// header badge; zero without a sync queue or when it cannot be read
+func (s *Server) syncFailures() int64 {
+	adapter, ok := s.expWriter.(*adapters.SQLiteAdapter)
+	if !ok {
+		return 0
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	stats, err := adapter.GetStorage().GetSyncQueueStats(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to read sync queue stats", "error", err)
+		return 0
+	}
+	return stats.FailedCount
+}
+
+// loadSyncStatus reads the queue items not written to the sheet yet
+func loadSyncStatus(ctx context.Context, store *storage.SQLiteRepository) (syncStatusView, error) {
+	items, err := store.OpenSyncItems(ctx, maxSyncStatusItems)
+	if err != nil {
+		return syncStatusView{}, err
+	}
+	var view syncStatusView
+	for _, it := range items {
+		row := syncItemView{
+			ID:          it.ID,
+			Delete:      it.Operation == "delete",
+			Failed:      it.Status == "failed",
+			Description: it.Description,
+			Amount:      formatEuros(it.AmountCents),
+			Attempts:    it.Attempts,
+			LastError:   it.LastError,
+		}
+		if it.ExpenseDate.Valid {
+			row.Date = it.ExpenseDate.Time.Format("02/01/2006")
+		}
+		if next, ok := it.NextRetryAt.(time.Time); ok && it.Status == "pending" && next.After(time.Now()) {
+			row.NextRetry = next.Local().Format("02/01 15:04")
+		}
+		switch it.Status {
+		case "failed":
+			row.Status = "Fallita"
+			view.Failed++
+		case "processing":
+			row.Status = "In corso"
+		default:
+			row.Status = "In attesa"
+			view.Pending++
+		}
+		view.Items = append(view.Items, row)
+	}
+	return view, nil
+}
+
+// handleSyncStatus renders the expenses waiting to be written to Google
+// Sheets and the ones that failed, with a button to retry them
+func (s *Server) handleSyncStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	store, ok := s.syncStatusStore(w)
+	if !ok {
+		return
+	}
+
+	view, err := loadSyncStatus(r.Context(), store)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to load sync status", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento della coda di sincronizzazione</div>`))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "sync_status_page", view); err != nil {
+		slog.ErrorContext(r.Context(), "Sync status template execution failed", "error", err, "template", "sync_status_page")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handleSyncStatusList renders the queue table, reloaded after a retry
+func (s *Server) handleSyncStatusList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	store, ok := s.syncStatusStore(w)
+	if !ok {
+		return
+	}
+
+	view, err := loadSyncStatus(r.Context(), store)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to load sync status", "error", err)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento della coda di sincronizzazione</div>`))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "sync_status_list", view); err != nil {
+		slog.ErrorContext(r.Context(), "Template execution failed", "error", err, "template", "sync_status_list")
+	}
+}
+
+// handleRetrySync makes queue items ready for the next poll of the sync
+// processor. Form field: id, the item to retry; without it every failed
+// item is retried.
+func (s *Server) handleRetrySync(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	store, ok := s.syncStatusStore(w)
+	if !ok {
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+
+	message := "Le sincronizzazioni fallite verranno riprovate al prossimo ciclo"
+	if raw := r.FormValue("id"); raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || id <= 0 {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_, _ = w.Write([]byte(`<div class="error">Elemento non valido</div>`))
+			return
+		}
+		if err := store.RetrySyncItem(r.Context(), id); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`<div class="error">Elemento non in attesa di sincronizzazione</div>`))
+				return
+			}
+			slog.ErrorContext(r.Context(), "Failed to retry sync item", "error", err, "id", id)
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`<div class="error">Errore nel riavvio della sincronizzazione</div>`))
+			return
+		}
+		message = "La spesa verrà sincronizzata al prossimo ciclo"
+	} else if _, err := store.RetryFailedSyncs(r.Context()); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to retry failed syncs", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel riavvio della sincronizzazione</div>`))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("HX-Trigger", `{"sync:changed": {}}`)
+	_, _ = w.Write([]byte(`<div class="success">` + message + `</div>`))
+}
@@ -0,0 +1,234 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"spese/internal/adapters"
+	"spese/internal/core"
+	"spese/internal/storage"
+)
+
+// tagReportMonths is the period of the tag report when none is given,
+// ending with the current month
+const tagReportMonths = 12
+
+type tagMonthView struct {
+	Label    string // e.g. "agosto 2025"
+	Spent    string
+	Received string // Empty without incomes
+	Entries  int64
+	Width    int // Spending bar, relative to the highest month of the tag
+}
+
+type tagSummaryView struct {
+	Tag      string
+	Spent    string
+	Received string // Empty without incomes
+	Entries  int64
+	Months   []tagMonthView
+}
+
+// tagReportView is the tag report of a period; From and To are months as
+// YYYY-MM, both included
+type tagReportView struct {
+	Tag  string
+	From string
+	To   string
+	Tags []tagSummaryView
+}
+
+// tagStore returns the SQLite repository holding tags, writing a 501 and
+// returning false for other backends.
+func (s *Server) tagStore(w http.ResponseWriter) (*storage.SQLiteRepository, bool) {
+	adapter, ok := s.expWriter.(*adapters.SQLiteAdapter)
+	if !ok {
+		w.WriteHeader(http.StatusNotImplemented)
+		_, _ = w.Write([]byte(`<div class="error">Tag disponibili solo con il backend SQLite</div>`))
+		return nil, false
+	}
+	return adapter.GetStorage(), true
+}
+
+// tagPeriod reads the from and to query parameters, months as YYYY-MM, both
+// included. It returns the first day of from and the first day after to;
+// by default the period is the last tagReportMonths months.
+func tagPeriod(r *http.Request, now time.Time) (from, to time.Time, err error) {
+	q := r.URL.Query()
+	end := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if v := strings.TrimSpace(q.Get("to")); v != "" {
+		if end, err = time.Parse("2006-01", v); err != nil {
+			return time.Time{}, time.Time{}, errors.New("invalid to month, use YYYY-MM")
+		}
+	}
+	start := end.AddDate(0, 1-tagReportMonths, 0)
+	if v := strings.TrimSpace(q.Get("from")); v != "" {
+		if start, err = time.Parse("2006-01", v); err != nil {
+			return time.Time{}, time.Time{}, errors.New("invalid from month, use YYYY-MM")
+		}
+	}
+	if start.After(end) {
+		return time.Time{}, time.Time{}, errors.New("from is after to")
+	}
+	return start, end.AddDate(0, 1, 0), nil
+}
+
+// loadTagReport builds the tag report from from (included) to to
+// (excluded); an empty tag reports every tag
+func loadTagReport(ctx context.Context, store *storage.SQLiteRepository, from, to time.Time, tag string) (tagReportView, error) {
+	view := tagReportView{
+		Tag:  tag,
+		From: from.Format("2006-01"),
+		To:   to.AddDate(0, -1, 0).Format("2006-01"),
+	}
+
+	summaries, err := store.TagReport(ctx, from, to, view.Tag)
+	if err != nil {
+		return tagReportView{}, err
+	}
+	for _, summary := range summaries {
+		row := tagSummaryView{
+			Tag:     summary.Tag,
+			Spent:   formatEuros(summary.Spent.Cents),
+			Entries: summary.Entries,
+		}
+		if summary.Received.Cents > 0 {
+			row.Received = formatEuros(summary.Received.Cents)
+		}
+		var top int64
+		for _, m := range summary.Months {
+			top = max(top, m.Spent.Cents)
+		}
+		for _, m := range summary.Months {
+			month := tagMonthView{
+				Label:   fmt.Sprintf("%s %d", italianMonths[m.Month-1], m.Year),
+				Spent:   formatEuros(m.Spent.Cents),
+				Entries: m.Entries,
+			}
+			if m.Received.Cents > 0 {
+				month.Received = formatEuros(m.Received.Cents)
+			}
+			if top > 0 {
+				month.Width = int(m.Spent.Cents * 100 / top)
+			}
+			row.Months = append(row.Months, month)
+		}
+		view.Tags = append(view.Tags, row)
+	}
+	return view, nil
+}
+
+// handleTags renders the tag report page
+func (s *Server) handleTags(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.tagStore(w)
+	if !ok {
+		return
+	}
+
+	from, to, err := tagPeriod(r, time.Now())
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Periodo non valido</div>`))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	report, err := loadTagReport(ctx, store, from, to, core.NormalizeTag(sanitizeInput(r.URL.Query().Get("tag"))))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to load tag report", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento del report dei tag</div>`))
+		return
+	}
+	tags, err := store.ListTags(ctx)
+	if err != nil {
+		// Suggestions only: the page works without them
+		slog.WarnContext(r.Context(), "Failed to list tags", "error", err)
+	}
+
+	data := struct {
+		Report tagReportView
+		Tags   []core.TagUsage
+	}{Report: report, Tags: tags}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "tags_page", data); err != nil {
+		slog.ErrorContext(r.Context(), "Tags template execution failed", "error", err, "template", "tags_page")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handleTagReport renders the spending of each tag month by month. Query
+// parameters: tag (empty for every tag), from and to (YYYY-MM, included).
+func (s *Server) handleTagReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.tagStore(w)
+	if !ok {
+		return
+	}
+
+	from, to, err := tagPeriod(r, time.Now())
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Periodo non valido</div>`))
+		return
+	}
+
+	report, err := loadTagReport(r.Context(), store, from, to, core.NormalizeTag(sanitizeInput(r.URL.Query().Get("tag"))))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to load tag report", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento del report dei tag</div>`))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "tag_report", report); err != nil {
+		slog.ErrorContext(r.Context(), "Template execution failed", "error", err, "template", "tag_report")
+	}
+}
+
+// handleGetTags returns the tag names in use, most used first, for the
+// autocompletion of the expense and income forms
+func (s *Server) handleGetTags(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	names := []string{}
+	if adapter, ok := s.expWriter.(*adapters.SQLiteAdapter); ok {
+		tags, err := adapter.GetStorage().ListTags(r.Context())
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Failed to list tags", "error", err)
+			http.Error(w, "Failed to get tags", http.StatusInternalServerError)
+			return
+		}
+		for _, tag := range tags {
+			names = append(names, tag.Name)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(names)
+}
@@ -0,0 +1,206 @@
+package http
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"spese/internal/adapters"
+	"spese/internal/core"
+	"spese/internal/storage"
+)
+
+// undoSeconds is how long the page offers to undo a deletion
+const undoSeconds = 10
+
+// trashItemView is a row of the trash page
+type trashItemView struct {
+	Kind        string
+	KindLabel   string
+	ID          int64
+	Date        string
+	Description string
+	Amount      string
+	Category    string
+	DeletedAt   string
+}
+
+// trashView is the data of the trash page
+type trashView struct {
+	Items         []trashItemView
+	RetentionDays int
+}
+
+// SetTrashRetention sets the days the trash page says items are kept
+// before the purge; 0 keeps them forever.
+func (s *Server) SetTrashRetention(days int) {
+	s.trashRetentionDays = days
+}
+
+// trashStore returns the SQLite repository the trash lives in, writing
+// 501 for the other backends
+func (s *Server) trashStore(w http.ResponseWriter) (*storage.SQLiteRepository, bool) {
+	adapter, ok := s.expWriter.(*adapters.SQLiteAdapter)
+	if !ok {
+		w.WriteHeader(http.StatusNotImplemented)
+		_, _ = w.Write([]byte(`<div class="error">Cestino disponibile solo con il backend SQLite</div>`))
+		return nil, false
+	}
+	return adapter.GetStorage(), true
+}
+
+// handleTrash lists the deleted expenses and incomes not purged yet, with
+// a button restoring each
+func (s *Server) handleTrash(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	store, ok := s.trashStore(w)
+	if !ok {
+		return
+	}
+
+	items, err := store.ListTrash(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list trash", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento del cestino</div>`))
+		return
+	}
+
+	view := trashView{RetentionDays: s.trashRetentionDays}
+	for _, item := range items {
+		row := trashItemView{
+			Kind:        item.Kind,
+			KindLabel:   "Spesa",
+			ID:          item.ID,
+			Date:        item.Date.Format("02/01/2006"),
+			Description: item.Description,
+			Amount:      formatEuros(item.AmountCents),
+			Category:    item.Category,
+			DeletedAt:   item.DeletedAt.Local().Format("02/01/2006 15:04"),
+		}
+		if item.Kind == storage.TrashKindIncome {
+			row.KindLabel = "Entrata"
+		}
+		if item.Subcategory != "" {
+			row.Category += " / " + item.Subcategory
+		}
+		view.Items = append(view.Items, row)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "trash_page", view); err != nil {
+		slog.ErrorContext(r.Context(), "Trash template execution failed", "error", err, "template", "trash_page")
+	}
+}
+
+// handleTrashRestore takes an expense or income out of the trash; the
+// form posts its kind and id
+func (s *Server) handleTrashRestore(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	store, ok := s.trashStore(w)
+	if !ok {
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+
+	id, err := strconv.ParseInt(sanitizeInput(r.Form.Get("id")), 10, 64)
+	if err != nil || id <= 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">ID non valido</div>`))
+		return
+	}
+
+	kind := r.Form.Get("kind")
+	var message string
+	switch kind {
+	case storage.TrashKindExpense:
+		err = store.RestoreExpense(r.Context(), id)
+		message = "Spesa ripristinata"
+	case storage.TrashKindIncome:
+		err = store.RestoreIncome(r.Context(), id)
+		message = "Entrata ripristinata"
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Tipo non valido</div>`))
+		return
+	}
+
+	switch {
+	case errors.Is(err, storage.ErrNotInTrash):
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`<div class="error">Elemento non più nel cestino, ricarica la pagina</div>`))
+		return
+	case errors.Is(err, core.ErrMonthClosed):
+		writeMonthClosed(w)
+		return
+	case err != nil:
+		slog.ErrorContext(r.Context(), "Failed to restore from trash", "error", err, "kind", kind, "id", id)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel ripristino</div>`))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(`<div class="success">` + message + `</div>`))
+}
+
+// handleExpenseRestore takes a just deleted expense out of the trash: the
+// "Annulla" of the toast shown after a deletion posts its id here
+func (s *Server) handleExpenseRestore(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	store, ok := s.trashStore(w)
+	if !ok {
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+	id, err := strconv.ParseInt(sanitizeInput(r.Form.Get("id")), 10, 64)
+	if err != nil || id <= 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">ID spesa non valido</div>`))
+		return
+	}
+
+	err = store.RestoreExpense(r.Context(), id)
+	switch {
+	case errors.Is(err, storage.ErrNotInTrash):
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`<div class="error">Spesa non più nel cestino</div>`))
+		return
+	case errors.Is(err, core.ErrMonthClosed):
+		writeMonthClosed(w)
+		return
+	case err != nil:
+		slog.ErrorContext(r.Context(), "Failed to restore expense", "error", err, "id", id)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel ripristino della spesa</div>`))
+		return
+	}
+
+	w.Header().Set("HX-Trigger", `{
+		"overview:refresh": {},
+		"dashboard:refresh": {}
+	}`)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(`<div class="success">Spesa ripristinata</div>`))
+}
@@ -0,0 +1,293 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"spese/internal/adapters"
+	"spese/internal/core"
+	"spese/internal/storage"
+)
+
+// usageCandidateWindow is how far back the form offers bills
+const usageCandidateWindow = 365 * 24 * time.Hour
+
+// utilityLabels are the display names of the utilities
+var utilityLabels = map[core.UtilityKind]string{
+	core.UtilityElectricity: "Luce",
+	core.UtilityGas:         "Gas",
+	core.UtilityWater:       "Acqua",
+}
+
+type usageBillView struct {
+	ExpenseID string
+	Date      string
+	Desc      string
+	Amount    string
+	Quantity  string // With its unit
+	UnitCost  string
+	Tariff    string // Cost change due to the tariff, empty for the first bill
+	Usage     string // Cost change due to consumption
+	Width     int    // Unit cost bar, relative to the highest of the utility
+}
+
+type usageGroupView struct {
+	Label string
+	Bills []usageBillView
+}
+
+// usageStore returns the SQLite repository holding consumptions, writing a
+// 501 and returning false for other backends.
+func (s *Server) usageStore(w http.ResponseWriter) (*storage.SQLiteRepository, bool) {
+	adapter, ok := s.expWriter.(*adapters.SQLiteAdapter)
+	if !ok {
+		w.WriteHeader(http.StatusNotImplemented)
+		_, _ = w.Write([]byte(`<div class="error">Consumi disponibili solo con il backend SQLite</div>`))
+		return nil, false
+	}
+	return adapter.GetStorage(), true
+}
+
+// formatUsage describes a consumption, e.g. "250 kWh · €0,3000/kWh".
+func formatUsage(u core.UtilityUsage, amount core.Money) string {
+	return formatQuantity(u.Quantity) + " " + u.Kind.Unit() + " · " + formatUnitCost(u.UnitCost(amount), u.Kind)
+}
+
+// formatUnitCost renders a cost per unit to the hundredth of a cent.
+func formatUnitCost(euros float64, kind core.UtilityKind) string {
+	return "€" + strings.ReplaceAll(strconv.FormatFloat(euros, 'f', 4, 64), ".", ",") + "/" + kind.Unit()
+}
+
+// formatEuroChange renders a signed amount, e.g. "+€12,50".
+func formatEuroChange(m core.Money) string {
+	if m.Cents > 0 {
+		return "+" + formatEuros(m.Cents)
+	}
+	return formatEuros(m.Cents)
+}
+
+// loadUsage groups the bills by utility, comparing each with the previous
+func loadUsage(ctx context.Context, store *storage.SQLiteRepository) ([]usageGroupView, error) {
+	bills, err := store.ListUtilityBills(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byKind := make(map[core.UtilityKind][]storage.UtilityBill)
+	for _, b := range bills {
+		byKind[b.Usage.Kind] = append(byKind[b.Usage.Kind], b)
+	}
+
+	var groups []usageGroupView
+	for _, kind := range core.UtilityKinds {
+		kindBills := byKind[kind]
+		if len(kindBills) == 0 {
+			continue
+		}
+		highest := 0.0
+		for _, b := range kindBills {
+			highest = max(highest, b.Usage.UnitCost(b.Expense.Amount))
+		}
+
+		group := usageGroupView{Label: utilityLabels[kind]}
+		for i, b := range kindBills {
+			unit := b.Usage.UnitCost(b.Expense.Amount)
+			view := usageBillView{
+				ExpenseID: strconv.FormatInt(b.ExpenseID, 10),
+				Date:      b.Expense.Date.Format("02/01/2006"),
+				Desc:      b.Expense.Description,
+				Amount:    formatEuros(b.Expense.Amount.Cents),
+				Quantity:  formatQuantity(b.Usage.Quantity) + " " + kind.Unit(),
+				UnitCost:  formatUnitCost(unit, kind),
+			}
+			if highest > 0 {
+				view.Width = int(unit / highest * 100)
+			}
+			if i > 0 {
+				prev := kindBills[i-1]
+				tariff, usage := core.SplitCostChange(prev.Expense.Amount, prev.Usage, b.Expense.Amount, b.Usage)
+				view.Tariff = formatEuroChange(tariff)
+				view.Usage = formatEuroChange(usage)
+			}
+			group.Bills = append(group.Bills, view)
+		}
+		groups = append(groups, group)
+	}
+	return groups, nil
+}
+
+// handleUsage renders the consumption report with the form to record one
+func (s *Server) handleUsage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.usageStore(w)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	groups, err := loadUsage(ctx, store)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list utility usage", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento dei consumi</div>`))
+		return
+	}
+	now := time.Now()
+	expenses, err := store.ListExpensesWithIDByDateRange(ctx, now.Add(-usageCandidateWindow), now)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list bills", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento delle bollette</div>`))
+		return
+	}
+
+	data := struct {
+		Groups    []usageGroupView
+		Bills     []selectOption
+		Utilities []selectOption
+	}{Groups: groups}
+	for _, e := range expenses {
+		data.Bills = append(data.Bills, selectOption{
+			ID:    e.ID,
+			Label: fmt.Sprintf("%s · %s · %s", e.Expense.Date.Format("02/01/2006"), e.Expense.Description, formatEuros(e.Expense.Amount.Cents)),
+		})
+	}
+	for _, kind := range core.UtilityKinds {
+		data.Utilities = append(data.Utilities, selectOption{
+			ID:    string(kind),
+			Label: utilityLabels[kind] + " (" + kind.Unit() + ")",
+		})
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "usage_page", data); err != nil {
+		slog.ErrorContext(r.Context(), "Usage template execution failed", "error", err, "template", "usage_page")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handleUsageList renders the report, refreshed after every change
+func (s *Server) handleUsageList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.usageStore(w)
+	if !ok {
+		return
+	}
+
+	groups, err := loadUsage(r.Context(), store)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list utility usage", "error", err)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento dei consumi</div>`))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "usage_list", groups); err != nil {
+		slog.ErrorContext(r.Context(), "Template execution failed", "error", err, "template", "usage_list")
+	}
+}
+
+// handleSetUsage records the consumption billed by an expense.
+// Form fields: expense_id, kind (electricity, gas, water), quantity.
+func (s *Server) handleSetUsage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.usageStore(w)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+	expenseID, ok := parseFormID(w, r, "expense_id", "ID spesa non valido")
+	if !ok {
+		return
+	}
+
+	kind, err := core.ParseUtilityKind(sanitizeInput(r.Form.Get("kind")))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Utenza non valida</div>`))
+		return
+	}
+	quantity, err := core.ParseQuantity(r.Form.Get("quantity"))
+	if err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`<div class="error">Consumo non valido</div>`))
+		return
+	}
+	usage := core.UtilityUsage{Kind: kind, Quantity: quantity}
+
+	if err := store.SetExpenseUsage(r.Context(), expenseID, usage); err != nil {
+		slog.WarnContext(r.Context(), "Failed to set utility usage", "error", err, "expense_id", expenseID)
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`<div class="error">Consumo non valido: ` + template.HTMLEscapeString(err.Error()) + `</div>`))
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Utility usage recorded", "expense_id", expenseID, "kind", kind, "quantity", quantity)
+	w.Header().Set("HX-Trigger", `{"usage:changed": {}}`)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(`<div class="success">Consumo registrato</div>`))
+}
+
+// handleDeleteUsage removes the consumption of an expense. Form fields:
+// expense_id.
+func (s *Server) handleDeleteUsage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.usageStore(w)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+	expenseID, ok := parseFormID(w, r, "expense_id", "ID spesa non valido")
+	if !ok {
+		return
+	}
+
+	if err := store.DeleteExpenseUsage(r.Context(), expenseID); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to delete utility usage", "error", err, "expense_id", expenseID)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nella rimozione del consumo</div>`))
+		return
+	}
+
+	w.Header().Set("HX-Trigger", `{"usage:changed": {}}`)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(`<div class="success">Consumo rimosso</div>`))
+}
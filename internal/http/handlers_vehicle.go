@@ -0,0 +1,377 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"spese/internal/adapters"
+	"spese/internal/core"
+	"spese/internal/storage"
+)
+
+// defaultVehicleCategory is the secondary category of the vehicle costs
+const defaultVehicleCategory = "Spese automobile"
+
+// vehicleCandidateWindow is how far back the form offers fuel expenses
+const vehicleCandidateWindow = 365 * 24 * time.Hour
+
+// SetVehicleCategory sets the secondary category whose expenses make up the
+// vehicle cost center.
+func (s *Server) SetVehicleCategory(category string) {
+	s.vehicleCategory = category
+}
+
+type fuelView struct {
+	ExpenseID     string
+	Date          string
+	Desc          string
+	Amount        string
+	Liters        string
+	Odometer      string
+	PricePerLiter string
+	Economy       string // L/100 km since the previous fill, empty for the first
+	Width         int    // Economy bar, relative to the highest of the year
+}
+
+type vehicleCostView struct {
+	Date   string
+	Desc   string
+	Amount string
+}
+
+type vehicleRecurrentView struct {
+	Desc   string
+	Every  string
+	Amount string
+	Yearly string
+}
+
+type vehicleView struct {
+	Year            int
+	Category        string
+	Total           string
+	FuelTotal       string
+	OtherTotal      string
+	Distance        string // Empty without two odometer readings
+	CostPerKm       string
+	Economy         string
+	Fills           []fuelView
+	Others          []vehicleCostView
+	Recurrents      []vehicleRecurrentView
+	RecurrentYearly string
+}
+
+// vehicleStore returns the SQLite repository holding fuel fills, writing a
+// 501 and returning false for other backends.
+func (s *Server) vehicleStore(w http.ResponseWriter) (*storage.SQLiteRepository, bool) {
+	adapter, ok := s.expWriter.(*adapters.SQLiteAdapter)
+	if !ok {
+		w.WriteHeader(http.StatusNotImplemented)
+		_, _ = w.Write([]byte(`<div class="error">Costi auto disponibili solo con il backend SQLite</div>`))
+		return nil, false
+	}
+	return adapter.GetStorage(), true
+}
+
+// formatKm renders a distance with a thousands separator, e.g. "12.345 km".
+func formatKm(km int64) string {
+	digits := strconv.FormatInt(km, 10)
+	var b strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(d)
+	}
+	return b.String() + " km"
+}
+
+// formatFixed renders a number with a decimal comma.
+func formatFixed(v float64, decimals int) string {
+	return strings.ReplaceAll(strconv.FormatFloat(v, 'f', decimals, 64), ".", ",")
+}
+
+// formatFuelFill describes a fill, e.g. "40 L · 12.345 km · €1,750/L".
+func formatFuelFill(f core.FuelFill, amount core.Money) string {
+	return fmt.Sprintf("%s L · %s · €%s/L", formatQuantity(f.Liters), formatKm(f.Odometer), formatFixed(f.PricePerLiter(amount), 3))
+}
+
+// parseOdometer reads a km reading, accepting "12.345" and "12 345".
+func parseOdometer(s string) (int64, error) {
+	s = strings.NewReplacer(".", "", " ", "").Replace(strings.TrimSpace(s))
+	return strconv.ParseInt(s, 10, 64)
+}
+
+// loadVehicle builds the cost report of a calendar year
+func (s *Server) loadVehicle(ctx context.Context, store *storage.SQLiteRepository, year int) (vehicleView, error) {
+	view := vehicleView{Year: year, Category: s.vehicleCategory}
+	from := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(year, 12, 31, 23, 59, 59, 0, time.UTC)
+
+	expenses, err := store.ListVehicleExpenses(ctx, s.vehicleCategory, from, to)
+	if err != nil {
+		return vehicleView{}, err
+	}
+
+	var total, fuel int64
+	var fills []storage.VehicleExpense
+	for _, e := range expenses {
+		total += e.Expense.Amount.Cents
+		if e.Fill == nil {
+			view.Others = append(view.Others, vehicleCostView{
+				Date:   e.Expense.Date.Format("02/01/2006"),
+				Desc:   e.Expense.Description,
+				Amount: formatEuros(e.Expense.Amount.Cents),
+			})
+			continue
+		}
+		fuel += e.Expense.Amount.Cents
+		fills = append(fills, e)
+	}
+	view.Total = formatEuros(total)
+	view.FuelTotal = formatEuros(fuel)
+	view.OtherTotal = formatEuros(total - fuel)
+
+	// Economy follows the odometer, not the order fills were entered in
+	sort.SliceStable(fills, func(i, j int) bool { return fills[i].Fill.Odometer < fills[j].Fill.Odometer })
+	records := make([]core.FuelRecord, len(fills))
+	for i, f := range fills {
+		records[i] = core.FuelRecord{Date: f.Expense.Date.Time, Amount: f.Expense.Amount, Fill: *f.Fill}
+		view.Fills = append(view.Fills, fuelView{
+			ExpenseID:     strconv.FormatInt(f.ExpenseID, 10),
+			Date:          f.Expense.Date.Format("02/01/2006"),
+			Desc:          f.Expense.Description,
+			Amount:        formatEuros(f.Expense.Amount.Cents),
+			Liters:        formatQuantity(f.Fill.Liters) + " L",
+			Odometer:      formatKm(f.Fill.Odometer),
+			PricePerLiter: "€" + formatFixed(f.Fill.PricePerLiter(f.Expense.Amount), 3) + "/L",
+		})
+	}
+	points := core.FuelEconomy(records)
+	highest := 0.0
+	for _, p := range points {
+		highest = max(highest, p.LitersPer100)
+	}
+	for _, p := range points {
+		view.Fills[p.Fill].Economy = formatFixed(p.LitersPer100, 1) + " L/100 km"
+		view.Fills[p.Fill].Width = int(p.LitersPer100 / highest * 100)
+	}
+
+	if distance := core.DrivenDistance(records); distance > 0 {
+		view.Distance = formatKm(distance)
+		view.CostPerKm = "€" + formatFixed(core.Money{Cents: total}.Euros()/float64(distance), 3) + "/km"
+	}
+	if avg := core.AverageEconomy(points); avg > 0 {
+		view.Economy = formatFixed(avg, 1) + " L/100 km"
+	}
+
+	recurrents, err := store.GetRecurrentExpenses(ctx)
+	if err != nil {
+		return vehicleView{}, err
+	}
+	var yearly int64
+	for _, re := range recurrents {
+		if re.Secondary != s.vehicleCategory || re.Paused || (!re.EndDate.IsZero() && re.EndDate.Before(from)) {
+			continue
+		}
+		perYear := re.YearlyCost().Cents
+		yearly += perYear
+		view.Recurrents = append(view.Recurrents, vehicleRecurrentView{
+			Desc:   re.Description,
+			Every:  scheduleLabel(re.Every, re.Step(), re.DayOfMonth),
+			Amount: formatEuros(re.Amount.Cents),
+			Yearly: formatEuros(perYear),
+		})
+	}
+	if len(view.Recurrents) > 0 {
+		view.RecurrentYearly = formatEuros(yearly)
+	}
+	return view, nil
+}
+
+// vehicleYear reads the year query parameter, defaulting to the current one
+func vehicleYear(r *http.Request) int {
+	year := time.Now().Year()
+	if v := strings.TrimSpace(r.URL.Query().Get("year")); v != "" {
+		if y, err := strconv.Atoi(v); err == nil && y >= 1900 && y <= 9999 {
+			year = y
+		}
+	}
+	return year
+}
+
+// handleVehicle renders the vehicle cost report with the form to record a
+// fuel fill
+func (s *Server) handleVehicle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.vehicleStore(w)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	report, err := s.loadVehicle(ctx, store, vehicleYear(r))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to load vehicle costs", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento dei costi auto</div>`))
+		return
+	}
+	now := time.Now()
+	candidates, err := store.ListVehicleExpenses(ctx, s.vehicleCategory, now.Add(-vehicleCandidateWindow), now)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list vehicle expenses", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento delle spese auto</div>`))
+		return
+	}
+
+	data := struct {
+		Report   vehicleView
+		PrevYear int
+		NextYear int
+		Expenses []selectOption
+	}{Report: report, PrevYear: report.Year - 1, NextYear: report.Year + 1}
+	// Newest first, as in the other pickers
+	for i := len(candidates) - 1; i >= 0; i-- {
+		e := candidates[i]
+		data.Expenses = append(data.Expenses, selectOption{
+			ID:    strconv.FormatInt(e.ExpenseID, 10),
+			Label: fmt.Sprintf("%s · %s · %s", e.Expense.Date.Format("02/01/2006"), e.Expense.Description, formatEuros(e.Expense.Amount.Cents)),
+		})
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "vehicle_page", data); err != nil {
+		slog.ErrorContext(r.Context(), "Vehicle template execution failed", "error", err, "template", "vehicle_page")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handleVehicleReport renders the report of a year, refreshed after every
+// change
+func (s *Server) handleVehicleReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.vehicleStore(w)
+	if !ok {
+		return
+	}
+
+	report, err := s.loadVehicle(r.Context(), store, vehicleYear(r))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to load vehicle costs", "error", err)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento dei costi auto</div>`))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "vehicle_report", report); err != nil {
+		slog.ErrorContext(r.Context(), "Template execution failed", "error", err, "template", "vehicle_report")
+	}
+}
+
+// handleSetFuelFill records the liters and odometer reading of a fuel
+// expense. Form fields: expense_id, liters, odometer.
+func (s *Server) handleSetFuelFill(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.vehicleStore(w)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+	expenseID, ok := parseFormID(w, r, "expense_id", "ID spesa non valido")
+	if !ok {
+		return
+	}
+
+	liters, err := core.ParseQuantity(r.Form.Get("liters"))
+	if err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`<div class="error">Litri non validi</div>`))
+		return
+	}
+	odometer, err := parseOdometer(r.Form.Get("odometer"))
+	if err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`<div class="error">Chilometraggio non valido</div>`))
+		return
+	}
+	fill := core.FuelFill{Liters: liters, Odometer: odometer}
+
+	if err := store.SetFuelFill(r.Context(), expenseID, fill); err != nil {
+		slog.WarnContext(r.Context(), "Failed to set fuel fill", "error", err, "expense_id", expenseID)
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`<div class="error">Rifornimento non valido: ` + template.HTMLEscapeString(err.Error()) + `</div>`))
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Fuel fill recorded", "expense_id", expenseID, "liters", liters, "odometer", odometer)
+	w.Header().Set("HX-Trigger", `{"vehicle:changed": {}}`)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(`<div class="success">Rifornimento registrato</div>`))
+}
+
+// handleDeleteFuelFill removes the fuel fill of an expense, which stays
+// among the vehicle costs when in their category. Form fields: expense_id.
+func (s *Server) handleDeleteFuelFill(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.vehicleStore(w)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+	expenseID, ok := parseFormID(w, r, "expense_id", "ID spesa non valido")
+	if !ok {
+		return
+	}
+
+	if err := store.DeleteFuelFill(r.Context(), expenseID); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to delete fuel fill", "error", err, "expense_id", expenseID)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nella rimozione del rifornimento</div>`))
+		return
+	}
+
+	w.Header().Set("HX-Trigger", `{"vehicle:changed": {}}`)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(`<div class="success">Rifornimento rimosso</div>`))
+}
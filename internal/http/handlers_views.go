@@ -0,0 +1,373 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"spese/internal/adapters"
+	"spese/internal/core"
+	"spese/internal/storage"
+)
+
+// viewExpensesLimit is how many of the latest matching expenses a saved
+// view shows
+const viewExpensesLimit = 200
+
+// savedViewRow is a saved view in the table, with its filter spelled out
+type savedViewRow struct {
+	ID     int64
+	Name   string
+	Filter string
+	Notify bool
+}
+
+// viewFilterLabel describes what a filter selects
+func viewFilterLabel(f core.ExpenseFilter) string {
+	var parts []string
+	if f.Primary != "" {
+		category := f.Primary
+		if f.Secondary != "" {
+			category += " / " + f.Secondary
+		}
+		parts = append(parts, category)
+	} else if f.Secondary != "" {
+		parts = append(parts, "… / "+f.Secondary)
+	}
+	if f.Min.Cents > 0 {
+		parts = append(parts, "da "+formatEuros(f.Min.Cents))
+	}
+	if f.Max.Cents > 0 {
+		parts = append(parts, "fino a "+formatEuros(f.Max.Cents))
+	}
+	if f.Text != "" {
+		parts = append(parts, "«"+f.Text+"»")
+	}
+	if f.Tag != "" {
+		parts = append(parts, "#"+f.Tag)
+	}
+	return strings.Join(parts, " · ")
+}
+
+// viewStore returns the SQLite repository holding saved views, writing a
+// 501 and returning false for other backends.
+func (s *Server) viewStore(w http.ResponseWriter) (*storage.SQLiteRepository, bool) {
+	adapter, ok := s.expWriter.(*adapters.SQLiteAdapter)
+	if !ok {
+		w.WriteHeader(http.StatusNotImplemented)
+		_, _ = w.Write([]byte(`<div class="error">Viste salvate disponibili solo con il backend SQLite</div>`))
+		return nil, false
+	}
+	return adapter.GetStorage(), true
+}
+
+// loadSavedViewRows returns the saved views for the table
+func loadSavedViewRows(ctx context.Context, store *storage.SQLiteRepository) ([]savedViewRow, error) {
+	views, err := store.ListSavedViews(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rows := make([]savedViewRow, len(views))
+	for i, v := range views {
+		rows[i] = savedViewRow{ID: v.ID, Name: v.Name, Filter: viewFilterLabel(v.Filter), Notify: v.Notify}
+	}
+	return rows, nil
+}
+
+// handleViews renders the saved views page
+func (s *Server) handleViews(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.viewStore(w)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	rows, err := loadSavedViewRows(ctx, store)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list saved views", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento delle viste</div>`))
+		return
+	}
+
+	cats, subs, err := s.taxReader.List(ctx)
+	if err != nil {
+		// Suggestions only: the page works without them
+		slog.WarnContext(r.Context(), "Failed to load categories for views page", "error", err)
+	}
+
+	tags, err := store.ListTags(ctx)
+	if err != nil {
+		slog.WarnContext(r.Context(), "Failed to load tags for views page", "error", err)
+	}
+
+	data := struct {
+		Views         []savedViewRow
+		Categories    []string
+		Subcategories []string
+		Tags          []core.TagUsage
+	}{Views: rows, Categories: cats, Subcategories: subs, Tags: tags}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "views_page", data); err != nil {
+		slog.ErrorContext(r.Context(), "Views template execution failed", "error", err, "template", "views_page")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handleViewsList renders the saved views table, refreshed after every
+// change
+func (s *Server) handleViewsList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.viewStore(w)
+	if !ok {
+		return
+	}
+
+	rows, err := loadSavedViewRows(r.Context(), store)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list saved views", "error", err)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento delle viste</div>`))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "views_list", rows); err != nil {
+		slog.ErrorContext(r.Context(), "Template execution failed", "error", err, "template", "views_list")
+	}
+}
+
+// handleCreateView stores a saved view. Form fields: name, primary,
+// secondary, min, max (decimal amounts), text, tag, notify ("on" to alert
+// on new matches).
+func (s *Server) handleCreateView(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.viewStore(w)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+
+	view := core.SavedView{
+		Name: sanitizeInput(r.Form.Get("name")),
+		Filter: core.ExpenseFilter{
+			Primary:   sanitizeInput(r.Form.Get("primary")),
+			Secondary: sanitizeInput(r.Form.Get("secondary")),
+			Text:      sanitizeInput(r.Form.Get("text")),
+			Tag:       core.NormalizeTag(sanitizeInput(r.Form.Get("tag"))),
+		},
+		Notify: r.Form.Get("notify") == "on",
+	}
+	for _, bound := range []struct {
+		field string
+		dst   *core.Money
+	}{{"min", &view.Filter.Min}, {"max", &view.Filter.Max}} {
+		v := strings.TrimSpace(r.Form.Get(bound.field))
+		if v == "" {
+			continue
+		}
+		cents, err := core.ParseDecimalToCents(v)
+		if err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_, _ = w.Write([]byte(`<div class="error">Importo non valido: ` + template.HTMLEscapeString(v) + `</div>`))
+			return
+		}
+		*bound.dst = core.Money{Cents: cents}
+	}
+
+	id, err := store.CreateSavedView(r.Context(), view)
+	if err != nil {
+		if errors.Is(err, core.ErrInvalidView) {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_, _ = w.Write([]byte(`<div class="error">Vista non valida: ` + template.HTMLEscapeString(err.Error()) + `</div>`))
+			return
+		}
+		slog.ErrorContext(r.Context(), "Failed to create saved view", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel salvataggio della vista (il nome è già usato?)</div>`))
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Saved view created", "view_id", id, "name", view.Name, "notify", view.Notify)
+	w.Header().Set("HX-Trigger", `{"views:changed": {}}`)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(`<div class="success">Vista salvata</div>`))
+}
+
+// handleSetViewNotify turns the alerts of a view on or off. Form fields:
+// id, notify ("true" or "false").
+func (s *Server) handleSetViewNotify(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.viewStore(w)
+	if !ok {
+		return
+	}
+
+	id, ok := parseViewID(w, r)
+	if !ok {
+		return
+	}
+	notify := r.Form.Get("notify") == "true"
+
+	if err := store.SetSavedViewNotify(r.Context(), id, notify); err != nil {
+		slog.WarnContext(r.Context(), "Failed to update saved view", "error", err, "view_id", id)
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`<div class="error">Vista non trovata</div>`))
+		return
+	}
+
+	message := "Avvisi della vista disattivati"
+	if notify {
+		message = "Avvisi della vista attivati"
+	}
+	w.Header().Set("HX-Trigger", `{"views:changed": {}}`)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(`<div class="success">` + message + `</div>`))
+}
+
+// handleDeleteView removes a saved view. Form fields: id.
+func (s *Server) handleDeleteView(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.viewStore(w)
+	if !ok {
+		return
+	}
+
+	id, ok := parseViewID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := store.DeleteSavedView(r.Context(), id); err != nil {
+		slog.WarnContext(r.Context(), "Failed to delete saved view", "error", err, "view_id", id)
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`<div class="error">Vista non trovata</div>`))
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Saved view deleted", "view_id", id)
+	w.Header().Set("HX-Trigger", `{"views:changed": {}}`)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(`<div class="success">Vista eliminata</div>`))
+}
+
+// handleViewExpenses renders the latest expenses a saved view selects.
+// Query parameters: id.
+func (s *Server) handleViewExpenses(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.viewStore(w)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseInt(strings.TrimSpace(r.URL.Query().Get("id")), 10, 64)
+	if err != nil || id <= 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">ID vista non valido</div>`))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	view, err := store.GetSavedView(ctx, id)
+	if err != nil {
+		slog.WarnContext(r.Context(), "Failed to get saved view", "error", err, "view_id", id)
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`<div class="error">Vista non trovata</div>`))
+		return
+	}
+	expenses, err := store.ListViewExpenses(ctx, view.Filter, viewExpensesLimit)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list view expenses", "error", err, "view_id", id)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento delle spese</div>`))
+		return
+	}
+
+	data := struct {
+		Name   string
+		Filter string
+		Limit  int
+		Count  int
+		Total  string
+		Page   ledgerRows
+	}{Name: view.Name, Filter: viewFilterLabel(view.Filter), Limit: viewExpensesLimit, Count: len(expenses)}
+	var total int64
+	for _, e := range expenses {
+		data.Page.Rows = append(data.Page.Rows, ledgerRow{
+			Date:     e.Expense.Date.Format("02/01/2006"),
+			Desc:     e.Expense.Description,
+			Category: e.Expense.Primary + " / " + e.Expense.Secondary,
+			Amount:   formatEuros(e.Expense.Amount.Cents),
+			Pending:  e.Expense.Status == core.StatusPending,
+		})
+		total += e.Expense.Amount.Cents
+	}
+	data.Total = formatEuros(total)
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "view_expenses_page", data); err != nil {
+		slog.ErrorContext(r.Context(), "View expenses template execution failed", "error", err, "template", "view_expenses_page")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// parseViewID reads the view id form field, writing a 400 when invalid
+func parseViewID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return 0, false
+	}
+	id, err := strconv.ParseInt(sanitizeInput(r.Form.Get("id")), 10, 64)
+	if err != nil || id <= 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">ID vista non valido</div>`))
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,243 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"spese/internal/adapters"
+	"spese/internal/core"
+	"spese/internal/storage"
+)
+
+// warrantyCandidateWindow is how far back the form offers purchases
+const warrantyCandidateWindow = 180 * 24 * time.Hour
+
+type warrantyView struct {
+	ExpenseID string
+	Date      string
+	Desc      string
+	Category  string
+	Amount    string
+	Proof     string
+	Expires   string // Empty without warranty
+	ReturnBy  string // Empty when the return window is closed or absent
+	DaysLeft  int    // Days left to return
+}
+
+// warrantyStore returns the SQLite repository holding warranties, writing a
+// 501 and returning false for other backends.
+func (s *Server) warrantyStore(w http.ResponseWriter) (*storage.SQLiteRepository, bool) {
+	adapter, ok := s.expWriter.(*adapters.SQLiteAdapter)
+	if !ok {
+		w.WriteHeader(http.StatusNotImplemented)
+		_, _ = w.Write([]byte(`<div class="error">Garanzie disponibili solo con il backend SQLite</div>`))
+		return nil, false
+	}
+	return adapter.GetStorage(), true
+}
+
+// loadWarranties lists the purchases still under warranty or returnable
+func loadWarranties(ctx context.Context, store *storage.SQLiteRepository, now time.Time) ([]warrantyView, error) {
+	items, err := store.ListActiveWarranties(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	views := make([]warrantyView, 0, len(items))
+	for _, item := range items {
+		view := warrantyView{
+			ExpenseID: strconv.FormatInt(item.ExpenseID, 10),
+			Date:      item.Expense.Date.Format("02/01/2006"),
+			Desc:      item.Expense.Description,
+			Category:  item.Expense.Primary + " / " + item.Expense.Secondary,
+			Amount:    formatEuros(item.Expense.Amount.Cents),
+			Proof:     item.Warranty.Proof,
+		}
+		if exp := item.Warranty.ExpiresOn(item.Expense.Date); !exp.IsZero() {
+			view.Expires = exp.Format("02/01/2006")
+		}
+		if rd := item.Warranty.ReturnDeadline; !rd.IsZero() && !rd.Before(today) {
+			view.ReturnBy = rd.Format("02/01/2006")
+			view.DaysLeft = int(rd.Sub(today).Hours() / 24)
+		}
+		views = append(views, view)
+	}
+	return views, nil
+}
+
+// handleWarranties renders the warranty report with the form to record one
+func (s *Server) handleWarranties(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.warrantyStore(w)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	items, err := loadWarranties(ctx, store, now)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list warranties", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento delle garanzie</div>`))
+		return
+	}
+	purchases, err := store.ListExpensesWithIDByDateRange(ctx, now.Add(-warrantyCandidateWindow), now)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list purchases", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento degli acquisti</div>`))
+		return
+	}
+
+	data := struct {
+		Items     []warrantyView
+		Purchases []selectOption
+	}{Items: items}
+	for _, e := range purchases {
+		data.Purchases = append(data.Purchases, selectOption{
+			ID:    e.ID,
+			Label: fmt.Sprintf("%s · %s · %s", e.Expense.Date.Format("02/01"), e.Expense.Description, formatEuros(e.Expense.Amount.Cents)),
+		})
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "warranties_page", data); err != nil {
+		slog.ErrorContext(r.Context(), "Warranties template execution failed", "error", err, "template", "warranties_page")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handleWarrantiesList renders the report, refreshed after every change
+func (s *Server) handleWarrantiesList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.warrantyStore(w)
+	if !ok {
+		return
+	}
+
+	items, err := loadWarranties(r.Context(), store, time.Now())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list warranties", "error", err)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento delle garanzie</div>`))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "warranties_list", items); err != nil {
+		slog.ErrorContext(r.Context(), "Template execution failed", "error", err, "template", "warranties_list")
+	}
+}
+
+// handleSetWarranty records the warranty of a purchase.
+// Form fields: expense_id, months, return_deadline (YYYY-MM-DD), proof.
+func (s *Server) handleSetWarranty(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.warrantyStore(w)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+	expenseID, ok := parseFormID(w, r, "expense_id", "ID spesa non valido")
+	if !ok {
+		return
+	}
+
+	var warranty core.Warranty
+	if v := strings.TrimSpace(r.Form.Get("months")); v != "" {
+		months, err := strconv.Atoi(v)
+		if err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_, _ = w.Write([]byte(`<div class="error">Durata della garanzia non valida</div>`))
+			return
+		}
+		warranty.Months = months
+	}
+	if v := strings.TrimSpace(r.Form.Get("return_deadline")); v != "" {
+		deadline, err := parseDate(v)
+		if err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_, _ = w.Write([]byte(`<div class="error">Data di reso non valida</div>`))
+			return
+		}
+		warranty.ReturnDeadline = deadline
+	}
+	warranty.Proof = sanitizeInput(r.Form.Get("proof"))
+
+	if err := store.SetExpenseWarranty(r.Context(), expenseID, warranty); err != nil {
+		slog.WarnContext(r.Context(), "Failed to set warranty", "error", err, "expense_id", expenseID)
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`<div class="error">Garanzia non valida: ` + template.HTMLEscapeString(err.Error()) + `</div>`))
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Warranty recorded", "expense_id", expenseID, "months", warranty.Months)
+	w.Header().Set("HX-Trigger", `{"warranties:changed": {}}`)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(`<div class="success">Garanzia registrata</div>`))
+}
+
+// handleDeleteWarranty removes the warranty of a purchase. Form fields:
+// expense_id.
+func (s *Server) handleDeleteWarranty(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.warrantyStore(w)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+	expenseID, ok := parseFormID(w, r, "expense_id", "ID spesa non valido")
+	if !ok {
+		return
+	}
+
+	if err := store.DeleteExpenseWarranty(r.Context(), expenseID); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to delete warranty", "error", err, "expense_id", expenseID)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nella rimozione della garanzia</div>`))
+		return
+	}
+
+	w.Header().Set("HX-Trigger", `{"warranties:changed": {}}`)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(`<div class="success">Garanzia rimossa</div>`))
+}
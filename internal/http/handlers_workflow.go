@@ -0,0 +1,298 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"spese/internal/adapters"
+	"spese/internal/core"
+	"spese/internal/storage"
+)
+
+// workflowListLimit caps the expenses shown for a state; drafts include
+// every expense never submitted.
+const workflowListLimit = 200
+
+// workflowStateLabels are the Italian names of the workflow states
+var workflowStateLabels = map[core.WorkflowState]string{
+	core.WorkflowDraft:      "Bozza",
+	core.WorkflowSubmitted:  "Inviata",
+	core.WorkflowApproved:   "Approvata",
+	core.WorkflowReimbursed: "Rimborsata",
+}
+
+// workflowActionLabel names the button moving an expense from one state to
+// another.
+func workflowActionLabel(from, to core.WorkflowState) string {
+	switch to {
+	case core.WorkflowSubmitted:
+		return "Invia"
+	case core.WorkflowApproved:
+		return "Approva"
+	case core.WorkflowReimbursed:
+		return "Segna rimborsata"
+	case core.WorkflowDraft:
+		if from == core.WorkflowSubmitted {
+			return "Riporta in bozza"
+		}
+	}
+	return workflowStateLabels[to]
+}
+
+type workflowTab struct {
+	State  string
+	Label  string
+	Count  int64
+	Active bool
+}
+
+type workflowAction struct {
+	To    string
+	Label string
+}
+
+type workflowItemView struct {
+	ID        string
+	Date      string
+	Desc      string
+	Category  string
+	Amount    string
+	UpdatedBy string
+}
+
+type workflowListData struct {
+	State   string
+	Label   string
+	Items   []workflowItemView
+	Actions []workflowAction
+}
+
+// SetWorkflow enables the expense approval workflow (SQLite backend).
+// Requests carrying approverToken as a bearer token act as approvers; an
+// empty token leaves only the submitter transitions available.
+func (s *Server) SetWorkflow(approverToken string) {
+	s.workflowEnabled = true
+	s.workflowApproverToken = approverToken
+}
+
+// workflowRole returns the role of the caller: approver with the approver
+// token, submitter otherwise.
+func (s *Server) workflowRole(r *http.Request) core.WorkflowRole {
+	if s.workflowApproverToken != "" && validBearerToken(r, s.workflowApproverToken) {
+		return core.RoleApprover
+	}
+	return core.RoleSubmitter
+}
+
+// workflowStore returns the SQLite repository holding workflow states,
+// writing a 404 when the workflow is disabled and a 501 for other backends.
+func (s *Server) workflowStore(w http.ResponseWriter) (*storage.SQLiteRepository, bool) {
+	if !s.workflowEnabled {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`<div class="error">Workflow spese non abilitato</div>`))
+		return nil, false
+	}
+	adapter, ok := s.expWriter.(*adapters.SQLiteAdapter)
+	if !ok {
+		w.WriteHeader(http.StatusNotImplemented)
+		_, _ = w.Write([]byte(`<div class="error">Workflow spese disponibile solo con il backend SQLite</div>`))
+		return nil, false
+	}
+	return adapter.GetStorage(), true
+}
+
+// workflowStateParam reads the state query parameter, defaulting to
+// submitted, the state waiting for someone to act.
+func workflowStateParam(r *http.Request) (core.WorkflowState, error) {
+	v := strings.TrimSpace(r.URL.Query().Get("state"))
+	if v == "" {
+		return core.WorkflowSubmitted, nil
+	}
+	return core.ParseWorkflowState(v)
+}
+
+// loadWorkflowList builds the list of expenses in a state with the actions
+// available to role.
+func loadWorkflowList(ctx context.Context, store *storage.SQLiteRepository, state core.WorkflowState, role core.WorkflowRole) (workflowListData, error) {
+	items, err := store.ListExpensesByWorkflowState(ctx, state, workflowListLimit)
+	if err != nil {
+		return workflowListData{}, err
+	}
+
+	data := workflowListData{State: string(state), Label: workflowStateLabels[state]}
+	for _, item := range items {
+		data.Items = append(data.Items, workflowItemView{
+			ID:        item.ID,
+			Date:      item.Expense.Date.Format("02/01/2006"),
+			Desc:      item.Expense.Description,
+			Category:  item.Expense.Primary + " / " + item.Expense.Secondary,
+			Amount:    formatEuros(item.Expense.Amount.Cents),
+			UpdatedBy: item.UpdatedBy,
+		})
+	}
+	for _, to := range core.NextWorkflowStates(state, role) {
+		data.Actions = append(data.Actions, workflowAction{To: string(to), Label: workflowActionLabel(state, to)})
+	}
+	return data, nil
+}
+
+// handleWorkflow renders the workflow page: a tab per state with its count
+// and the expenses in the selected state.
+func (s *Server) handleWorkflow(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.workflowStore(w)
+	if !ok {
+		return
+	}
+	state, err := workflowStateParam(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Stato non valido</div>`))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	counts, err := store.CountExpensesByWorkflowState(ctx)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to count workflow states", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento del workflow</div>`))
+		return
+	}
+	role := s.workflowRole(r)
+	list, err := loadWorkflowList(ctx, store, state, role)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list workflow expenses", "error", err, "state", state)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento del workflow</div>`))
+		return
+	}
+
+	data := struct {
+		Tabs     []workflowTab
+		List     workflowListData
+		Approver bool
+		Token    string // Forwarded on HTMX requests so the approver role sticks
+	}{List: list, Approver: role == core.RoleApprover}
+	if data.Approver {
+		data.Token = s.workflowApproverToken
+	}
+	for _, st := range core.WorkflowStates {
+		data.Tabs = append(data.Tabs, workflowTab{
+			State:  string(st),
+			Label:  workflowStateLabels[st],
+			Count:  counts[st],
+			Active: st == state,
+		})
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "workflow_page", data); err != nil {
+		slog.ErrorContext(r.Context(), "Workflow template execution failed", "error", err, "template", "workflow_page")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handleWorkflowList renders the expenses in a state, refreshed after every
+// transition
+func (s *Server) handleWorkflowList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.workflowStore(w)
+	if !ok {
+		return
+	}
+	state, err := workflowStateParam(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Stato non valido</div>`))
+		return
+	}
+
+	list, err := loadWorkflowList(r.Context(), store, state, s.workflowRole(r))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list workflow expenses", "error", err, "state", state)
+		_, _ = w.Write([]byte(`<div class="error">Errore nel caricamento del workflow</div>`))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "workflow_list", list); err != nil {
+		slog.ErrorContext(r.Context(), "Template execution failed", "error", err, "template", "workflow_list")
+	}
+}
+
+// handleWorkflowTransition moves an expense to another state.
+// Form fields: id, to.
+func (s *Server) handleWorkflowTransition(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, ok := s.workflowStore(w)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Formato richiesta non valido</div>`))
+		return
+	}
+	id, ok := parseFormID(w, r, "id", "ID spesa non valido")
+	if !ok {
+		return
+	}
+	to, err := core.ParseWorkflowState(sanitizeInput(r.Form.Get("to")))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<div class="error">Stato non valido</div>`))
+		return
+	}
+
+	role := s.workflowRole(r)
+	ctx := r.Context()
+	if role == core.RoleApprover {
+		ctx = core.WithActor(ctx, "approver:"+extractClientIP(r))
+	}
+
+	from, err := store.TransitionExpenseWorkflow(ctx, id, to, role)
+	switch {
+	case errors.Is(err, core.ErrTransitionForbidden):
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`<div class="error">Operazione riservata a chi approva</div>`))
+		return
+	case errors.Is(err, core.ErrInvalidTransition):
+		w.WriteHeader(http.StatusConflict)
+		_, _ = fmt.Fprintf(w, `<div class="error">Una spesa %s non può diventare %s</div>`,
+			strings.ToLower(workflowStateLabels[from]), strings.ToLower(workflowStateLabels[to]))
+		return
+	case err != nil:
+		slog.ErrorContext(r.Context(), "Failed to move expense through workflow", "error", err, "expense_id", id, "to", to)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<div class="error">Errore nell'aggiornamento della spesa</div>`))
+		return
+	}
+
+	w.Header().Set("HX-Trigger", `{"workflow:changed": {}}`)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = fmt.Fprintf(w, `<div class="success">Spesa %d: %s</div>`, id, workflowStateLabels[to])
+}
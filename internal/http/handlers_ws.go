@@ -0,0 +1,268 @@
+package http
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"spese/internal/core"
+	"spese/internal/events"
+	"spese/internal/hooks"
+)
+
+// WebSocket session tuning
+const (
+	wsPingInterval  = 30 * time.Second // server ping period
+	wsReadTimeout   = 60 * time.Second // max silence from the client (pong or any message)
+	wsWriteTimeout  = 10 * time.Second
+	wsMaxMessage    = 64 << 10
+	wsEventBuffer   = 32
+	wsCreateTimeout = 15 * time.Second
+)
+
+// wsMessage is the envelope of every message exchanged over /ws.
+// Client requests carry a Ref that is echoed back in the matching reply.
+type wsMessage struct {
+	Type  string          `json:"type"`
+	Ref   string          `json:"ref,omitempty"`
+	Data  json.RawMessage `json:"data,omitempty"`
+	Error string          `json:"error,omitempty"`
+}
+
+// expenseInput is a JSON expense, the payload of an "expense.create" request
+// and an item of the batch API
+type expenseInput struct {
+	Date        string   `json:"date"` // YYYY-MM-DD, defaults to today
+	Description string   `json:"description"`
+	Amount      string   `json:"amount"` // decimal euros, e.g. "12.50"
+	Primary     string   `json:"primary"`
+	Secondary   string   `json:"secondary"`
+	Status      string   `json:"status,omitempty"` // "pending" for card holds, default "cleared"
+	Tags        []string `json:"tags,omitempty"`
+}
+
+// expense parses and validates the input; a missing date means today.
+func (in expenseInput) expense() (core.Expense, error) {
+	date := core.Date{Time: time.Now()}
+	if in.Date != "" {
+		d, err := parseDate(in.Date)
+		if err != nil {
+			return core.Expense{}, errors.New("invalid date")
+		}
+		date = d
+	}
+
+	cents, err := core.ParseDecimalToCents(strings.TrimSpace(in.Amount))
+	if err != nil {
+		return core.Expense{}, errors.New("invalid amount")
+	}
+
+	exp := core.Expense{
+		Date:        core.NewDate(date.Year(), int(date.Month()), date.Day()),
+		Description: sanitizeInput(in.Description),
+		Amount:      core.Money{Cents: cents},
+		Primary:     sanitizeInput(in.Primary),
+		Secondary:   sanitizeInput(in.Secondary),
+		Status:      core.ExpenseStatus(strings.ToLower(strings.TrimSpace(in.Status))),
+		Tags:        core.NormalizeTags(in.Tags),
+	}
+	if err := exp.Validate(); err != nil {
+		return core.Expense{}, errors.New("invalid data: " + err.Error())
+	}
+	return exp, nil
+}
+
+// SetWebSocketToken enables /ws, accepting clients that present this token.
+func (s *Server) SetWebSocketToken(token string) {
+	s.wsToken = token
+}
+
+// handleWebSocket upgrades authenticated clients to a WebSocket session that
+// streams domain events and accepts expense creation requests.
+func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if s.wsToken == "" {
+		http.Error(w, "WebSocket not configured", http.StatusNotImplemented)
+		return
+	}
+
+	if !validBearerToken(r, s.wsToken) {
+		slog.WarnContext(r.Context(), "WebSocket authentication failed", "client_ip", extractClientIP(r))
+		w.Header().Set("WWW-Authenticate", `Bearer realm="spese"`)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	clientIP := extractClientIP(r)
+	ctx := core.WithActor(r.Context(), "ws:"+clientIP)
+
+	websocket.Server{
+		// Native clients send no Origin; the token is what authenticates them
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(ws *websocket.Conn) {
+			s.serveWebSocket(ctx, ws, clientIP)
+		},
+	}.ServeHTTP(w, r)
+}
+
+// validBearerToken checks the token from the Authorization header or, for
+// clients that cannot set headers on the upgrade request, the token query param.
+func validBearerToken(r *http.Request, want string) bool {
+	got := r.URL.Query().Get("token")
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		got = strings.TrimPrefix(auth, "Bearer ")
+	}
+	return got != "" && subtle.ConstantTimeCompare([]byte(got), []byte(want)) == 1
+}
+
+// serveWebSocket runs a session: a single writer (this goroutine) forwards
+// events, replies and pings, while a reader goroutine handles client requests.
+func (s *Server) serveWebSocket(ctx context.Context, ws *websocket.Conn, clientIP string) {
+	defer ws.Close()
+	ws.MaxPayloadBytes = wsMaxMessage
+
+	sub, unsubscribe := s.events.Subscribe(wsEventBuffer)
+	defer unsubscribe()
+
+	slog.InfoContext(ctx, "WebSocket session started", "client_ip", clientIP)
+	defer slog.InfoContext(ctx, "WebSocket session ended", "client_ip", clientIP)
+
+	replies := make(chan wsMessage, 8)
+	readerDone := make(chan struct{})
+	stop := make(chan struct{})
+	defer close(stop)
+
+	go func() {
+		defer close(readerDone)
+		s.readWebSocket(ctx, ws, clientIP, replies, stop)
+	}()
+
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+
+	send := func(v any) bool {
+		_ = ws.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+		if err := websocket.JSON.Send(ws, v); err != nil {
+			slog.DebugContext(ctx, "WebSocket write failed", "error", err, "client_ip", clientIP)
+			return false
+		}
+		return true
+	}
+
+	for {
+		select {
+		case <-readerDone:
+			return
+		case <-s.closing:
+			return
+		case e, ok := <-sub:
+			if !ok || !send(e) {
+				return
+			}
+		case msg := <-replies:
+			if !send(msg) {
+				return
+			}
+		case <-ping.C:
+			if !send(wsMessage{Type: "ping"}) {
+				return
+			}
+		}
+	}
+}
+
+// readWebSocket handles client messages until the connection fails or the
+// client stays silent longer than wsReadTimeout.
+func (s *Server) readWebSocket(ctx context.Context, ws *websocket.Conn, clientIP string, replies chan<- wsMessage, stop <-chan struct{}) {
+	for {
+		_ = ws.SetReadDeadline(time.Now().Add(wsReadTimeout))
+
+		var msg wsMessage
+		if err := websocket.JSON.Receive(ws, &msg); err != nil {
+			return
+		}
+
+		var reply wsMessage
+		switch msg.Type {
+		case "pong":
+			continue // read deadline already refreshed
+		case "ping":
+			reply = wsMessage{Type: "pong", Ref: msg.Ref}
+		case "expense.create":
+			reply = s.wsCreateExpense(ctx, clientIP, msg)
+		default:
+			reply = wsMessage{Type: "error", Ref: msg.Ref, Error: "unknown message type"}
+		}
+
+		select {
+		case replies <- reply:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// wsCreateExpense validates and stores an expense sent over the socket,
+// applying the same rules and rate limit as the HTML form.
+func (s *Server) wsCreateExpense(ctx context.Context, clientIP string, msg wsMessage) wsMessage {
+	fail := func(reason string) wsMessage {
+		return wsMessage{Type: "error", Ref: msg.Ref, Error: reason}
+	}
+
+	if !s.rateLimiter.allow(clientIP, s.metrics) {
+		return fail("rate limit exceeded")
+	}
+
+	var in expenseInput
+	if err := json.Unmarshal(msg.Data, &in); err != nil {
+		return fail("invalid payload")
+	}
+
+	exp, err := in.expense()
+	if err != nil {
+		s.countEntry("expense", "create", outcomeValidationError, 1)
+		return fail(err.Error())
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, wsCreateTimeout)
+	defer cancel()
+
+	ref, err := s.expWriter.Append(ctx, exp)
+	s.countEntryErr("expense", "create", err)
+	if errors.Is(err, hooks.ErrRejected) {
+		return fail(err.Error())
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to save expense",
+			"error", err,
+			"expense_description", exp.Description,
+			"amount_cents", exp.Amount.Cents,
+			"component", "expense_ws",
+			"operation", "append")
+		return fail("error saving expense")
+	}
+
+	s.events.Publish(events.ExpenseCreated, events.ExpenseFrom(exp))
+
+	slog.InfoContext(ctx, "Expense created successfully",
+		"expense_description", exp.Description,
+		"amount_cents", exp.Amount.Cents,
+		"sheets_ref", ref,
+		"component", "expense_ws",
+		"operation", "create")
+
+	data, _ := json.Marshal(map[string]string{"ref": ref})
+	return wsMessage{Type: "ack", Ref: msg.Ref, Data: data}
+}
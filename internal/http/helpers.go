@@ -20,12 +20,12 @@ func parseYearMonth(r *http.Request) (year, month int) {
 	month = int(now.Month())
 
 	if v := strings.TrimSpace(r.URL.Query().Get("year")); v != "" {
-		if y, err := strconv.Atoi(v); err == nil {
+		if y, err := strconv.Atoi(v); err == nil && y >= 1 && y <= 9999 {
 			year = y
 		}
 	}
 	if v := strings.TrimSpace(r.URL.Query().Get("month")); v != "" {
-		if m, err := strconv.Atoi(v); err == nil {
+		if m, err := strconv.Atoi(v); err == nil && m >= 1 && m <= 12 {
 			month = m
 		}
 	}
@@ -33,6 +33,53 @@ func parseYearMonth(r *http.Request) (year, month int) {
 	return year, month
 }
 
+// monthNav moves a month page to the previous or next month, rolling over
+// the year, or to any month picked by name and year. Links carry the period
+// in the query string, so every month can be bookmarked.
+type monthNav struct {
+	Path                string // Page the links point to, e.g. "/entrate"
+	Year, Month         int
+	Label               string // e.g. "maggio 2031"
+	PrevYear, PrevMonth int
+	NextYear, NextMonth int
+	Current             bool // Whether the month is the current one
+	Months              []monthOption
+}
+
+// monthOption is an entry of the month picker
+type monthOption struct {
+	Value    int
+	Name     string
+	Selected bool
+}
+
+// newMonthNav builds the navigation of path around year and month
+func newMonthNav(path string, year, month int, now time.Time) monthNav {
+	start := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	prev := start.AddDate(0, -1, 0)
+	next := start.AddDate(0, 1, 0)
+	nav := monthNav{
+		Path:      path,
+		Year:      year,
+		Month:     month,
+		Label:     reviewMonthLabel(start),
+		PrevYear:  prev.Year(),
+		PrevMonth: int(prev.Month()),
+		NextYear:  next.Year(),
+		NextMonth: int(next.Month()),
+		Current:   year == now.Year() && month == int(now.Month()),
+	}
+	for i, name := range italianMonths {
+		nav.Months = append(nav.Months, monthOption{Value: i + 1, Name: name, Selected: i+1 == month})
+	}
+	return nav
+}
+
+// Query returns the period as a query string, for the partials of the page
+func (n monthNav) Query() string {
+	return fmt.Sprintf("year=%d&month=%d", n.Year, n.Month)
+}
+
 // parseDate parses a date string in YYYY-MM-DD format.
 func parseDate(dateStr string) (core.Date, error) {
 	parsedTime, err := time.Parse("2006-01-02", dateStr)
@@ -42,6 +89,23 @@ func parseDate(dateStr string) (core.Date, error) {
 	return core.Date{Time: parsedTime}, nil
 }
 
+// parseExpectedVersion returns the record version a write is based on, taken
+// from the "version" form field or, failing that, the If-Match header
+// (e.g. `"3"` or `W/"3"`). ok is false when neither carries a valid version.
+func parseExpectedVersion(r *http.Request) (version int64, ok bool) {
+	raw := strings.TrimSpace(r.Form.Get("version"))
+	if raw == "" {
+		raw = strings.TrimSpace(r.Header.Get("If-Match"))
+		raw = strings.TrimPrefix(raw, "W/")
+		raw = strings.Trim(raw, `"`)
+	}
+	v, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || v <= 0 {
+		return 0, false
+	}
+	return v, true
+}
+
 // formatEuros formats cents as a Euro currency string (e.g., "€12,34").
 func formatEuros(cents int64) string {
 	neg := cents < 0
@@ -0,0 +1,342 @@
+package http
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"spese/internal/adapters"
+	"spese/internal/core"
+	"spese/internal/hooks"
+)
+
+// Outcomes of a write counted in the entry metrics
+const (
+	outcomeSuccess         = "success"
+	outcomeValidationError = "validation_error"
+	outcomeStorageError    = "storage_error"
+)
+
+// entryMetrics counts the writes of expenses and incomes by operation and
+// outcome. The backend label is the same for every series of a server.
+type entryMetrics struct {
+	mu      sync.Mutex
+	backend string
+	counts  map[string]*prometheus.CounterVec // By kind: expense or income
+}
+
+func newEntryMetrics(backend string) *entryMetrics {
+	m := &entryMetrics{backend: backend, counts: make(map[string]*prometheus.CounterVec)}
+	for _, kind := range []string{"expense", "income"} {
+		m.counts[kind] = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: kind + "_operations_total",
+			Help: strings.ToUpper(kind[:1]) + kind[1:] + " writes by operation, backend and outcome",
+		}, []string{"operation", "backend", "outcome"})
+	}
+	return m
+}
+
+func (m *entryMetrics) add(kind, operation, outcome string, n int) {
+	if n <= 0 {
+		return
+	}
+	m.mu.Lock()
+	backend := m.backend
+	m.mu.Unlock()
+	m.counts[kind].WithLabelValues(operation, backend, outcome).Add(float64(n))
+}
+
+// backendName guesses the storage label of the entry metrics from the
+// expense writer; SetBackendName overrides it.
+func backendName(exp any) string {
+	switch exp.(type) {
+	case *adapters.SQLiteAdapter:
+		return "sqlite"
+	case *adapters.MemoryAdapter:
+		return "memory"
+	}
+	return "sheets"
+}
+
+// SetBackendName labels the expense and income counters of /metrics with
+// the configured data backend, e.g. "sqlite" or "sheets".
+func (s *Server) SetBackendName(name string) {
+	s.entries.mu.Lock()
+	s.entries.backend = name
+	s.entries.mu.Unlock()
+}
+
+// countEntry records n writes of an expense or an income with the given
+// outcome.
+func (s *Server) countEntry(kind, operation, outcome string, n int) {
+	s.entries.add(kind, operation, outcome, n)
+}
+
+// countEntryErr records the outcome of a write from the error the storage
+// returned: rejections of the data (hooks, closed months, stale versions,
+// quotas, missing rows) are validation errors, anything else a storage error.
+func (s *Server) countEntryErr(kind, operation string, err error) {
+	s.countEntry(kind, operation, writeOutcome(err), 1)
+}
+
+func writeOutcome(err error) string {
+	switch {
+	case err == nil:
+		return outcomeSuccess
+	case errors.Is(err, hooks.ErrRejected),
+		errors.Is(err, core.ErrMonthClosed),
+		errors.Is(err, core.ErrVersionConflict),
+		errors.Is(err, core.ErrQuotaExceeded),
+		errors.Is(err, sql.ErrNoRows):
+		return outcomeValidationError
+	}
+	return outcomeStorageError
+}
+
+// serverMetrics are the Prometheus series of /metrics. Each server has a
+// registry of its own, so that servers built side by side (tests) do not
+// share counters.
+type serverMetrics struct {
+	registry *prometheus.Registry
+	handler  http.Handler
+
+	requests         *prometheus.CounterVec   // By route, method and status code
+	requestDuration  *prometheus.HistogramVec // By route and method
+	queryDuration    *prometheus.HistogramVec // SQLite queries, by sqlc name
+	outboundDuration *prometheus.HistogramVec // Outbound requests, by integration
+}
+
+// newServerMetrics registers the series of s: request counters and
+// latencies, entry writes, the security counters, the outbound
+// integrations, the sync queue lag, the caches, the database size and the
+// Go runtime.
+func newServerMetrics(s *Server) *serverMetrics {
+	m := &serverMetrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "HTTP requests by route, method and status code",
+		}, []string{"route", "method", "code"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "Time to serve HTTP requests, by route and method",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"route", "method"}),
+		queryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "sqlite_query_duration_seconds",
+			Help:    "Time to run SQLite queries, by query name",
+			Buckets: prometheus.ExponentialBuckets(0.0005, 4, 8), // 0.5ms to 8s
+		}, []string{"query"}),
+		outboundDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "outbound_request_duration_seconds",
+			Help:    "Time of the requests sent to outbound integrations such as Google Sheets",
+			Buckets: []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+		}, []string{"integration"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests, m.requestDuration, m.queryDuration, m.outboundDuration,
+		s.entries.counts["expense"], s.entries.counts["income"],
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "rate_limit_hits_total",
+			Help: "Total rate limit hits",
+		}, func() float64 { return float64(atomic.LoadInt64(&s.metrics.rateLimitHits)) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "suspicious_requests_total",
+			Help: "Total suspicious requests detected",
+		}, func() float64 { return float64(atomic.LoadInt64(&s.metrics.suspiciousRequests)) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "active_rate_limit_clients",
+			Help: "Currently tracked rate limit clients",
+		}, func() float64 {
+			s.rateLimiter.mu.Lock()
+			defer s.rateLimiter.mu.Unlock()
+			return float64(len(s.rateLimiter.clients))
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "uptime_seconds",
+			Help: "Application uptime in seconds",
+		}, func() float64 { return time.Since(s.appMetrics.uptime).Seconds() }),
+		outboundCollector{s},
+		syncLagCollector{s},
+		cacheCollector{s},
+		databaseCollector{s},
+	)
+	m.handler = promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+	return m
+}
+
+// observeRequest records a request served by the handler of route, the
+// pattern it was registered with.
+func (m *serverMetrics) observeRequest(route, method string, code int, elapsed time.Duration) {
+	if route == "" {
+		route = "unmatched"
+	}
+	m.requests.WithLabelValues(route, method, strconv.Itoa(code)).Inc()
+	m.requestDuration.WithLabelValues(route, method).Observe(elapsed.Seconds())
+}
+
+// ObserveQuery records the duration of a SQLite query; pass it to
+// storage.SQLiteRepository.ObserveQueries.
+func (s *Server) ObserveQuery(name string, elapsed time.Duration, _ error) {
+	s.prom.queryDuration.WithLabelValues(name).Observe(elapsed.Seconds())
+}
+
+// ObserveOutbound records the duration of a request to an outbound
+// integration; pass it to httpclient.Factory.ObserveRequests.
+func (s *Server) ObserveOutbound(integration string, elapsed time.Duration) {
+	s.prom.outboundDuration.WithLabelValues(integration).Observe(elapsed.Seconds())
+}
+
+var (
+	outboundRequestsDesc = prometheus.NewDesc("outbound_requests_total",
+		"Requests sent to outbound integrations", []string{"integration"}, nil)
+	outboundErrorsDesc = prometheus.NewDesc("outbound_errors_total",
+		"Outbound requests without a response or with a 5xx", []string{"integration"}, nil)
+	outboundSecondsDesc = prometheus.NewDesc("outbound_request_seconds_total",
+		"Time spent on outbound requests", []string{"integration"}, nil)
+	outboundRetriesDesc = prometheus.NewDesc("outbound_retries_total",
+		"Outbound requests sent again after a 429 or 503", []string{"integration"}, nil)
+	outboundExhaustedDesc = prometheus.NewDesc("outbound_retries_exhausted_total",
+		"Outbound requests still refused after the retries", []string{"integration"}, nil)
+)
+
+// outboundCollector exports the request counts of the outbound
+// integrations, read from SetOutboundStats when scraped.
+type outboundCollector struct{ s *Server }
+
+func (c outboundCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- outboundRequestsDesc
+	ch <- outboundErrorsDesc
+	ch <- outboundSecondsDesc
+	ch <- outboundRetriesDesc
+	ch <- outboundExhaustedDesc
+}
+
+func (c outboundCollector) Collect(ch chan<- prometheus.Metric) {
+	if c.s.outboundStats == nil {
+		return
+	}
+	for _, st := range c.s.outboundStats() {
+		ch <- prometheus.MustNewConstMetric(outboundRequestsDesc, prometheus.CounterValue, float64(st.Requests), st.Name)
+		ch <- prometheus.MustNewConstMetric(outboundErrorsDesc, prometheus.CounterValue, float64(st.Errors), st.Name)
+		ch <- prometheus.MustNewConstMetric(outboundSecondsDesc, prometheus.CounterValue, st.Duration.Seconds(), st.Name)
+		ch <- prometheus.MustNewConstMetric(outboundRetriesDesc, prometheus.CounterValue, float64(st.Retries), st.Name)
+		ch <- prometheus.MustNewConstMetric(outboundExhaustedDesc, prometheus.CounterValue, float64(st.RetriesExhausted), st.Name)
+	}
+}
+
+var syncLagDesc = prometheus.NewDesc("sync_queue_lag_seconds",
+	"Age of the oldest expense waiting to be written to Google Sheets", nil, nil)
+
+// syncLagCollector exports how far the sync to Google Sheets is behind,
+// read from the queue when scraped; nothing without a SQLite backend or
+// when the queue cannot be read.
+type syncLagCollector struct{ s *Server }
+
+func (c syncLagCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- syncLagDesc
+}
+
+func (c syncLagCollector) Collect(ch chan<- prometheus.Metric) {
+	adapter, ok := c.s.expWriter.(*adapters.SQLiteAdapter)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	lag, err := adapter.GetStorage().SyncQueueLag(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to read sync queue lag", "error", err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(syncLagDesc, prometheus.GaugeValue, lag.Seconds())
+}
+
+var (
+	cacheHitsDesc = prometheus.NewDesc("cache_hits_total",
+		"Lookups served by a server-side cache", []string{"cache"}, nil)
+	cacheMissesDesc = prometheus.NewDesc("cache_misses_total",
+		"Lookups a server-side cache could not serve", []string{"cache"}, nil)
+	cacheEntriesDesc = prometheus.NewDesc("cache_entries",
+		"Entries held by a server-side cache", []string{"cache"}, nil)
+)
+
+// cacheCollector exports the counters of the caches added with AddCaches,
+// read when scraped.
+type cacheCollector struct{ s *Server }
+
+func (c cacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cacheHitsDesc
+	ch <- cacheMissesDesc
+	ch <- cacheEntriesDesc
+}
+
+func (c cacheCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, reporter := range c.s.caches {
+		for _, st := range reporter.CacheStats() {
+			ch <- prometheus.MustNewConstMetric(cacheHitsDesc, prometheus.CounterValue, float64(st.Hits), st.Name)
+			ch <- prometheus.MustNewConstMetric(cacheMissesDesc, prometheus.CounterValue, float64(st.Misses), st.Name)
+			ch <- prometheus.MustNewConstMetric(cacheEntriesDesc, prometheus.GaugeValue, float64(st.Size), st.Name)
+		}
+	}
+}
+
+var (
+	dbSizeDesc = prometheus.NewDesc("sqlite_database_size_bytes",
+		"Size of the SQLite database file, free pages included", nil, nil)
+	dbFreelistDesc = prometheus.NewDesc("sqlite_freelist_size_bytes",
+		"Free pages of the SQLite database a vacuum can give back", nil, nil)
+	dbWALDesc = prometheus.NewDesc("sqlite_wal_size_bytes",
+		"Size of the SQLite write-ahead log", nil, nil)
+	dbCheckpointDesc = prometheus.NewDesc("sqlite_checkpoint_duration_seconds",
+		"Time the last maintenance took to checkpoint the WAL", nil, nil)
+	dbMaintenanceDesc = prometheus.NewDesc("sqlite_maintenance_last_run_timestamp_seconds",
+		"When the last database maintenance finished", nil, nil)
+)
+
+// databaseCollector exports the size of the SQLite database and the last
+// maintenance run, read when scraped; nothing without a SQLite backend.
+type databaseCollector struct{ s *Server }
+
+func (c databaseCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- dbSizeDesc
+	ch <- dbFreelistDesc
+	ch <- dbWALDesc
+	ch <- dbCheckpointDesc
+	ch <- dbMaintenanceDesc
+}
+
+func (c databaseCollector) Collect(ch chan<- prometheus.Metric) {
+	adapter, ok := c.s.expWriter.(*adapters.SQLiteAdapter)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	stats, err := adapter.GetStorage().DatabaseStats(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to read database size", "error", err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(dbSizeDesc, prometheus.GaugeValue, float64(stats.SizeBytes))
+	ch <- prometheus.MustNewConstMetric(dbFreelistDesc, prometheus.GaugeValue, float64(stats.FreelistBytes))
+	ch <- prometheus.MustNewConstMetric(dbWALDesc, prometheus.GaugeValue, float64(stats.WALBytes))
+	if last := stats.LastMaintenance; last != nil {
+		ch <- prometheus.MustNewConstMetric(dbCheckpointDesc, prometheus.GaugeValue, last.CheckpointDuration.Seconds())
+		ch <- prometheus.MustNewConstMetric(dbMaintenanceDesc, prometheus.GaugeValue, float64(last.At.Unix()))
+	}
+}
@@ -0,0 +1,70 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"spese/internal/storage"
+)
+
+// readYourWritesCookie records when the session last wrote, in Unix
+// milliseconds.
+const readYourWritesCookie = "spese_rw"
+
+// SetReadYourWritesWindow lets reads be served by read replicas, except for
+// sessions that wrote within the last window: those keep reading from the
+// primary, so a user immediately sees the expense they just created even
+// while replicas catch up. Zero keeps every read on the primary.
+func (s *Server) SetReadYourWritesWindow(window time.Duration) {
+	s.readYourWritesWindow = window
+}
+
+// withReadYourWrites marks writes with a cookie and decides where reads go:
+// sessions without a recent write may use replicas, the others read from
+// the primary, forwarded to the primary node when this one is a replica.
+func (s *Server) withReadYourWrites(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.readYourWritesWindow <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !isReadOnlyRequest(r) {
+			http.SetCookie(w, &http.Cookie{
+				Name:     readYourWritesCookie,
+				Value:    strconv.FormatInt(time.Now().UnixMilli(), 10),
+				Path:     "/",
+				MaxAge:   int((s.readYourWritesWindow + time.Second - 1) / time.Second),
+				HttpOnly: true,
+				SameSite: http.SameSiteLaxMode,
+			})
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !s.wroteRecently(r) {
+			next.ServeHTTP(w, r.WithContext(storage.AllowReplicaReads(r.Context())))
+			return
+		}
+		if s.replication != nil && !s.replication.IsPrimary() && r.Header.Get(forwardedHeader) == "" {
+			s.forwardToPrimary(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// wroteRecently reports whether the session's last write falls within the
+// read-your-writes window.
+func (s *Server) wroteRecently(r *http.Request) bool {
+	c, err := r.Cookie(readYourWritesCookie)
+	if err != nil {
+		return false
+	}
+	ms, err := strconv.ParseInt(c.Value, 10, 64)
+	if err != nil {
+		return false
+	}
+	return time.Since(time.UnixMilli(ms)) < s.readYourWritesWindow
+}
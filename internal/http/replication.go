@@ -0,0 +1,108 @@
+package http
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"time"
+
+	"spese/internal/replication"
+)
+
+// forwardedHeader marks requests forwarded to the primary. A replica that
+// receives one (the primary moved meanwhile) answers 503 rather than
+// forwarding it again, so requests cannot loop between nodes.
+const forwardedHeader = "X-Spese-Forwarded"
+
+// SetReplication makes the server aware of the replication layer: writes
+// reaching a replica are forwarded to the primary, at primaryURL when set
+// or else at the host LiteFS advertises on the server's port, and health
+// checks report the replication state. maxLag > 0 marks the node not ready
+// when it falls further behind.
+func (s *Server) SetReplication(m *replication.Monitor, primaryURL string, maxLag time.Duration) {
+	s.replication = m
+	s.primaryURL = strings.TrimRight(primaryURL, "/")
+	s.maxReplicationLag = maxLag
+}
+
+// withPrimaryRouting forwards requests that could change data to the
+// primary when this node is a replica. It wraps the whole mux, like the
+// demo guard, so new routes are covered without opting in.
+func (s *Server) withPrimaryRouting(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.replication == nil || isReadOnlyRequest(r) || s.replication.IsPrimary() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		s.forwardToPrimary(w, r)
+	})
+}
+
+// forwardToPrimary proxies r to the primary node, answering 503 when the
+// primary is unknown or r was already forwarded once.
+func (s *Server) forwardToPrimary(w http.ResponseWriter, r *http.Request) {
+	target := s.primaryTarget()
+	if target == nil || r.Header.Get(forwardedHeader) != "" {
+		slog.WarnContext(r.Context(), "Request for the primary received by replica with no reachable primary", "method", r.Method, "path", r.URL.Path)
+		w.Header().Set("Retry-After", "5")
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte(`<div class="error">Nodo primario non disponibile, riprova tra poco</div>`))
+		return
+	}
+
+	slog.DebugContext(r.Context(), "Forwarding request to primary", "method", r.Method, "path", r.URL.Path, "primary", target.Host)
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		slog.ErrorContext(r.Context(), "Forwarding to primary failed", "error", err, "primary", target.Host)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusBadGateway)
+		_, _ = w.Write([]byte(`<div class="error">Nodo primario non raggiungibile</div>`))
+	}
+	r.Header.Set(forwardedHeader, "1")
+	proxy.ServeHTTP(w, r)
+}
+
+// primaryTarget returns where writes go, or nil when the primary is unknown.
+func (s *Server) primaryTarget() *url.URL {
+	raw := s.primaryURL
+	if raw == "" {
+		host := s.replication.PrimaryHost()
+		if host == "" {
+			return nil
+		}
+		_, port, _ := strings.Cut(s.Addr, ":")
+		raw = "http://" + host
+		if port != "" {
+			raw += ":" + port
+		}
+	}
+	target, err := url.Parse(raw)
+	if err != nil || target.Host == "" {
+		return nil
+	}
+	return target
+}
+
+// replicationHealth describes the replication state for health checks and
+// reports whether the node is within the allowed lag.
+func (s *Server) replicationHealth() (map[string]interface{}, bool) {
+	st := s.replication.Status()
+	info := map[string]interface{}{
+		"mode":    string(st.Mode),
+		"primary": st.Primary,
+	}
+	if st.PrimaryHost != "" {
+		info["primary_host"] = st.PrimaryHost
+	}
+	if !st.LagKnown {
+		info["lag"] = "unknown"
+		return info, true
+	}
+	info["lag"] = st.Lag.String()
+	info["lag_ms"] = st.Lag.Milliseconds()
+	return info, s.maxReplicationLag <= 0 || st.Lag <= s.maxReplicationLag
+}
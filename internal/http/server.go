@@ -1,18 +1,29 @@
 package http
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
 	"fmt"
 	"html/template"
 	"io/fs"
 	"log/slog"
+	"net"
 	"net/http"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"spese/internal/adapters"
+	"spese/internal/core"
+	"spese/internal/email"
+	"spese/internal/events"
+	"spese/internal/httpclient"
+	"spese/internal/llm"
+	"spese/internal/receipts"
+	"spese/internal/replication"
+	"spese/internal/rules"
+	"spese/internal/services"
 	"spese/internal/sheets"
 	appweb "spese/web"
 )
@@ -28,19 +39,118 @@ type Server struct {
 	expDeleter      sheets.ExpenseDeleter
 	rateLimiter     *rateLimiter
 
+	// Domain events pushed to connected clients
+	events *events.Bus
+
+	// Optional services, wired after construction
+	reconciler      *services.ReconcileService
+	parquetExporter *services.ParquetExporter
+	peerSync        *services.PeerSyncService
+	batchWriter     sheets.ExpenseBatchWriter  // nil when the backend cannot batch
+	categorizer     *rules.Categorizer         // rules, keywords and classifier; nil without SQLite
+	extractor       llm.Provider               // language model reading receipts; nil when disabled
+	monthReviewer   *services.MonthReviewer    // end-of-month review and closing; nil without SQLite
+	historyImporter *services.HistoryImporter  // Google Sheets history backfill; nil without SQLite and Sheets
+	ruleReprocessor *services.RuleReprocessor  // Applies rules to stored expenses; nil without SQLite
+	csvImporter     *services.CSVImporter      // CSV upload with preview; nil without SQLite
+	sandbox         sheets.SandboxCopier       // sandbox spreadsheet of the sync; nil when not configured
+	integrity       *services.IntegrityChecker // data integrity report; nil without SQLite
+	wsToken         string                     // bearer token for /ws; empty disables the endpoint
+	emails          *email.Renderer            // report and alert emails, previewed in /admin/email
+
+	// Expense approval workflow; the token grants the approver role
+	workflowEnabled       bool
+	workflowApproverToken string
+
+	// Receipt files of expenses; nil when receipts are not enabled
+	receipts        receipts.Store
+	receiptMaxBytes int64
+	receiptCleaner  *services.ReceiptCleaner
+
+	// Rates of the mileage and per-diem calculators; zero disables one
+	calculatorRates map[core.CalculationKind]core.Money
+
+	// Secondary category of the vehicle cost center
+	vehicleCategory string
+
+	// Days deleted entries stay in the trash; 0 keeps them forever
+	trashRetentionDays int
+
+	// Whether /admin/sql runs read-only queries; off by default
+	sqlConsole bool
+
+	// Limits of the members calling with API keys; nil without SQLite
+	quotas *services.Quotas
+
+	// Hour before which new entries default to the previous day, and
+	// whether the expense form offers a year selector
+	dayStartHour  int
+	yearSelection bool
+	// Clock of the default entry date; time.Now but in tests
+	now func() time.Time
+
+	// Key for anonymized export merchant tokens; empty means random per export
+	exportHashKey string
+
+	// Read-only public demo: mutations are refused, pages show a banner
+	demoMode bool
+
+	// Login of the web UI; nil leaves every route open
+	auth *auth
+
+	// Litestream/LiteFS state; nil when the database is not replicated
+	replication       *replication.Monitor
+	primaryURL        string // where replicas forward writes; empty derives it from LiteFS
+	maxReplicationLag time.Duration
+
+	// How long after a write a session keeps reading from the primary;
+	// zero keeps every read on the primary
+	readYourWritesWindow time.Duration
+
+	// closing is closed on shutdown to end hijacked (WebSocket) connections,
+	// which http.Server.Shutdown does not track
+	closing chan struct{}
+
 	shutdownOnce sync.Once
 
 	// Security and application metrics
 	metrics    *securityMetrics
 	appMetrics *applicationMetrics
+	entries    *entryMetrics
+	prom       *serverMetrics // Series of /metrics
+	// Request counts of the outbound integrations; nil when not set
+	outboundStats func() []httpclient.Stats
+	// Server-side caches reported in /metrics and cleared by /api/v1/caches
+	caches []sheets.CacheReporter
+
+	// Whether Google Sheets accepts the credentials; nil when not tracked
+	integrationHealth *services.IntegrationHealth
+
+	// Background jobs reported by /api/v1/jobs; nil when not configured
+	jobs *services.JobRunner
 }
 
-// applicationMetrics tracks application performance and usage
+// applicationMetrics tracks application uptime
 type applicationMetrics struct {
-	totalRequests       int64
-	totalExpenses       int64
-	averageResponseTime int64 // in microseconds
-	uptime              time.Time
+	uptime time.Time
+}
+
+// Events returns the bus on which the server publishes domain events, so
+// other front ends can share it.
+func (s *Server) Events() *events.Bus {
+	return s.events
+}
+
+// SetOutboundStats reports the requests of the outbound integrations in
+// /metrics, e.g. httpclient.Factory.Stats.
+func (s *Server) SetOutboundStats(stats func() []httpclient.Stats) {
+	s.outboundStats = stats
+}
+
+// SetIntegrationHealth shows a banner on every page while h reports that
+// Google Sheets rejects the credentials.
+func (s *Server) SetIntegrationHealth(h *services.IntegrationHealth) {
+	s.integrationHealth = h
 }
 
 // GetSecurityMetrics returns current security metrics (useful for monitoring)
@@ -61,6 +171,9 @@ func (s *Server) Shutdown(ctx context.Context) error {
 			s.rateLimiter.stop()
 		}
 
+		// End WebSocket sessions
+		close(s.closing)
+
 		// Shutdown HTTP server
 		shutdownErr = s.Server.Shutdown(ctx)
 	})
@@ -84,9 +197,15 @@ func NewServer(addr string, ew sheets.ExpenseWriter, tr sheets.TaxonomyReader, d
 		expListerWithID: lrwid,
 		expDeleter:      ed,
 		rateLimiter:     newRateLimiter(),
+		events:          events.NewBus(),
+		closing:         make(chan struct{}),
 		metrics:         &securityMetrics{},
 		appMetrics:      &applicationMetrics{uptime: time.Now()},
+		entries:         newEntryMetrics(backendName(ew)),
+		vehicleCategory: defaultVehicleCategory,
+		now:             time.Now,
 	}
+	s.prom = newServerMetrics(s)
 
 	// Parse embedded templates at startup with custom functions.
 	funcMap := template.FuncMap{
@@ -96,6 +215,27 @@ func NewServer(addr string, ew sheets.ExpenseWriter, tr sheets.TaxonomyReader, d
 		"formatDate": func(day, month, year int) string { // Format date components as DD/MM/YYYY
 			return fmt.Sprintf("%02d/%02d/%d", day, month, year)
 		},
+		"demoMode": func() bool { // Whether pages should show the demo banner
+			return s.demoMode
+		},
+		"sqlConsole": func() bool { // Whether admin pages should link the SQL console
+			return s.sqlConsole
+		},
+		"integrationDegraded": func() bool { // Whether pages should show the credentials banner
+			return s.integrationHealth.Status().Degraded
+		},
+		"authEnabled":  s.authEnabled,  // Whether pages should offer a logout button
+		"syncFailures": s.syncFailures, // Failed sheet syncs counted by the header badge
+		"capabilities": s.capabilities, // Features of the backend, to hide the unsupported ones
+		"schedule": func(re core.RecurrentExpenses) string { // Italian label of a recurrence schedule
+			return scheduleLabel(re.Every, re.Step(), re.DayOfMonth)
+		},
+		"nextDue": func(re core.RecurrentExpenses) *core.Date { // Next occurrence to be created, nil if none
+			if d, ok := re.NextDue(); ok {
+				return &d
+			}
+			return nil
+		},
 		"not": func(v bool) bool { // Logical NOT for template conditionals
 			return !v
 		},
@@ -122,6 +262,13 @@ func NewServer(addr string, ew sheets.ExpenseWriter, tr sheets.TaxonomyReader, d
 	}
 	s.templates = t
 
+	emails, err := email.NewRenderer()
+	if err != nil {
+		slog.Error("Failed parsing email templates", "error", err)
+		panic(fmt.Sprintf("Failed to parse email templates: %v", err))
+	}
+	s.emails = emails
+
 	// Static assets (served from embedded FS)
 	if sub, err := fs.Sub(appweb.StaticFS, "static"); err == nil {
 		static := http.StripPrefix("/static/", http.FileServer(http.FS(sub)))
@@ -139,14 +286,23 @@ func NewServer(addr string, ew sheets.ExpenseWriter, tr sheets.TaxonomyReader, d
 	mux.HandleFunc("/", s.withSecurityHeaders(s.handleDashboard))
 	mux.HandleFunc("/healthz", s.handleHealth)  // Updated to server method
 	mux.HandleFunc("/readyz", s.handleReady)    // Updated to server method
-	mux.HandleFunc("/metrics", s.handleMetrics) // Metrics endpoint (no auth for now)
+	mux.HandleFunc("/metrics", s.handleMetrics) // Metrics endpoint (public unless AUTH_PUBLIC_PATHS drops it)
+	// Login of the web UI, when enabled
+	mux.HandleFunc("/login", s.withSecurityHeaders(s.handleLogin))
+	mux.HandleFunc("/logout", s.withSecurityHeaders(s.handleLogout))
 	mux.HandleFunc("/expenses", s.withSecurityHeaders(s.handleCreateExpense))
 	mux.HandleFunc("/expenses/delete", s.withSecurityHeaders(s.handleDeleteExpense))
+	mux.HandleFunc("/expenses/restore", s.withSecurityHeaders(s.handleExpenseRestore))
+	mux.HandleFunc("/expenses/clear", s.withSecurityHeaders(s.handleClearExpense))
+	mux.HandleFunc("/expenses/search", s.withSecurityHeaders(s.handleExpenseSearch))
+	// PUT /expenses/{id} and GET /expenses/{id}/edit
+	mux.HandleFunc("/expenses/", s.withSecurityHeaders(s.handleExpenseItem))
 	// UI partials
 	mux.HandleFunc("/ui/month-overview", s.withSecurityHeaders(s.handleMonthOverview))
 	mux.HandleFunc("/ui/month-total", s.withSecurityHeaders(s.handleMonthTotal))
 	mux.HandleFunc("/ui/month-categories", s.withSecurityHeaders(s.handleMonthCategories))
 	mux.HandleFunc("/ui/month-expenses", s.withSecurityHeaders(s.handleMonthExpenses))
+	mux.HandleFunc("/ui/expense-history", s.withSecurityHeaders(s.handleExpenseHistory))
 	mux.HandleFunc("/ui/notifications", s.withSecurityHeaders(s.handleNotifications))
 	mux.HandleFunc("/ui/form-reset", s.withSecurityHeaders(s.handleFormReset))
 	mux.HandleFunc("/ui/recurrent-form-reset", s.withSecurityHeaders(s.handleRecurrentFormReset))
@@ -155,17 +311,29 @@ func NewServer(addr string, ew sheets.ExpenseWriter, tr sheets.TaxonomyReader, d
 	mux.HandleFunc("/api/categories/secondary", s.withSecurityHeaders(s.handleGetSecondaryCategories))
 	mux.HandleFunc("/api/categories", s.withSecurityHeaders(s.handleGetAllCategories))
 	mux.HandleFunc("/api/income-categories", s.withSecurityHeaders(s.handleGetIncomeCategories))
+	mux.HandleFunc("/api/income-subcategories", s.withSecurityHeaders(s.handleGetIncomeSubcategories))
+	// Features the active backend supports
+	mux.HandleFunc("/api/capabilities", s.withSecurityHeaders(s.handleCapabilities))
 
 	// Recurrent expenses routes
 	mux.HandleFunc("/recurrent", s.withSecurityHeaders(s.handleRecurrentExpenses))
 	mux.HandleFunc("/recurrent/create", s.withSecurityHeaders(s.handleCreateRecurrentExpense))
 	mux.HandleFunc("/recurrent/update", s.withSecurityHeaders(s.handleUpdateRecurrentExpense))
 	mux.HandleFunc("/recurrent/delete", s.withSecurityHeaders(s.handleDeleteRecurrentExpense))
+	mux.HandleFunc("/recurrent/pause", s.withSecurityHeaders(s.handleRecurrentState))
+	mux.HandleFunc("/recurrent/resume", s.withSecurityHeaders(s.handleRecurrentState))
+	mux.HandleFunc("/recurrent/skip", s.withSecurityHeaders(s.handleRecurrentState))
 	// Pattern for editing specific recurrent expense
 	mux.HandleFunc("/recurrent/", s.withSecurityHeaders(s.handleRecurrentExpenseEdit))
 
 	// Income routes
 	mux.HandleFunc("/entrate", s.withSecurityHeaders(s.handleIncomes))
+	// Recurrent incomes (SQLite backend)
+	mux.HandleFunc("/ui/recurrent-income-form", s.withSecurityHeaders(s.handleRecurrentIncomeForm))
+	mux.HandleFunc("/ui/recurrent-incomes-list", s.withSecurityHeaders(s.handleRecurrentIncomesList))
+	mux.HandleFunc("/recurrent-incomes/create", s.withSecurityHeaders(s.handleCreateRecurrentIncome))
+	mux.HandleFunc("/recurrent-incomes/update", s.withSecurityHeaders(s.handleUpdateRecurrentIncome))
+	mux.HandleFunc("/recurrent-incomes/delete", s.withSecurityHeaders(s.handleDeleteRecurrentIncome))
 	mux.HandleFunc("/incomes", s.withSecurityHeaders(s.handleCreateIncome))
 	mux.HandleFunc("/incomes/delete", s.withSecurityHeaders(s.handleDeleteIncome))
 	// Income UI partials
@@ -183,7 +351,14 @@ func NewServer(addr string, ew sheets.ExpenseWriter, tr sheets.TaxonomyReader, d
 	mux.HandleFunc("/ui/dashboard/categories", s.withSecurityHeaders(s.handleDashboardCategoriesList))
 	mux.HandleFunc("/ui/dashboard/recurrents", s.withSecurityHeaders(s.handleDashboardRecurrentsWithSummary))
 	mux.HandleFunc("/ui/dashboard/projections", s.withSecurityHeaders(s.handleDashboardProjections))
+	mux.HandleFunc("/ui/dashboard/upcoming", s.withSecurityHeaders(s.handleDashboardUpcoming))
+	mux.HandleFunc("/ui/dashboard/calendar", s.withSecurityHeaders(s.handleDashboardCalendar))
 	mux.HandleFunc("/ui/dashboard/income-breakdown", s.withSecurityHeaders(s.handleDashboardIncomeBreakdown))
+	mux.HandleFunc("/ui/dashboard/insights", s.withSecurityHeaders(s.handleDashboardInsights))
+	// Spending insights dismiss and mute controls (SQLite backend)
+	mux.HandleFunc("/insights/dismiss", s.withSecurityHeaders(s.handleDismissInsight))
+	mux.HandleFunc("/insights/mute", s.withSecurityHeaders(s.handleMuteInsight))
+	mux.HandleFunc("/insights/unmute", s.withSecurityHeaders(s.handleUnmuteInsight))
 	// Dashboard API endpoints (JSON)
 	mux.HandleFunc("/api/dashboard/trend", s.withSecurityHeaders(s.handleDashboardTrend))
 	// Form partials for bottom sheet
@@ -191,9 +366,219 @@ func NewServer(addr string, ew sheets.ExpenseWriter, tr sheets.TaxonomyReader, d
 	mux.HandleFunc("/ui/form/income", s.withSecurityHeaders(s.handleFormIncome))
 	mux.HandleFunc("/ui/form/recurring", s.withSecurityHeaders(s.handleFormRecurring))
 	mux.HandleFunc("/ui/form/recurrent-edit", s.withSecurityHeaders(s.handleFormRecurrentEdit))
+	// Google Sheets credentials status and retry of the waiting sync
+	mux.HandleFunc("/integrazioni", s.withSecurityHeaders(s.handleIntegrations))
+	mux.HandleFunc("/integrazioni/retry", s.withSecurityHeaders(s.handleRetryIntegration))
+	// Sheet sync queue status and manual retry of failed items
+	mux.HandleFunc("/sync/status", s.withSecurityHeaders(s.handleSyncStatus))
+	mux.HandleFunc("/sync/retry", s.withSecurityHeaders(s.handleRetrySync))
+	// Previews of the report and alert emails with sample data
+	mux.HandleFunc("/admin/email", s.withSecurityHeaders(s.handleEmailPreviews))
+	mux.HandleFunc("/admin/email/preview", s.withSecurityHeaders(s.handleEmailPreview))
+	mux.HandleFunc("/admin/sql", s.withSecurityHeaders(s.handleSQLConsole))
+	mux.HandleFunc("/ui/sync-status", s.withSecurityHeaders(s.handleSyncStatusList))
+	// Data integrity report with repair suggestions
+	mux.HandleFunc("/integrita", s.withSecurityHeaders(s.handleIntegrity))
+	mux.HandleFunc("/ui/integrity", s.withSecurityHeaders(s.handleIntegrityReport))
+	// Audit log of the changes to expenses, incomes, recurrents and categories
+	mux.HandleFunc("/audit", s.withSecurityHeaders(s.handleAudit))
+	// Deleted expenses and incomes waiting for the purge
+	mux.HandleFunc("/cestino", s.withSecurityHeaders(s.handleTrash))
+	mux.HandleFunc("/cestino/ripristina", s.withSecurityHeaders(s.handleTrashRestore))
+	// SQLite/Sheets reconciliation
+	mux.HandleFunc("/riconciliazione", s.withSecurityHeaders(s.handleReconcile))
+	mux.HandleFunc("/riconciliazione/resolve", s.withSecurityHeaders(s.handleReconcileResolve))
+	// Backfill of the Google Sheets history of past years
+	mux.HandleFunc("/storico-fogli", s.withSecurityHeaders(s.handleSheetHistory))
+	mux.HandleFunc("/storico-fogli/import", s.withSecurityHeaders(s.handleImportSheetHistory))
+	mux.HandleFunc("/ui/sheet-history", s.withSecurityHeaders(s.handleSheetHistoryList))
+	// Sandbox spreadsheet the sync writes to while testing
+	mux.HandleFunc("/sandbox-fogli", s.withSecurityHeaders(s.handleSheetSandbox))
+	mux.HandleFunc("/sandbox-fogli/copy", s.withSecurityHeaders(s.handleCopyToSandbox))
+	// Categorization rules (SQLite backend)
+	mux.HandleFunc("/regole", s.withSecurityHeaders(s.handleRules))
+	mux.HandleFunc("/regole/create", s.withSecurityHeaders(s.handleCreateRule))
+	mux.HandleFunc("/regole/toggle", s.withSecurityHeaders(s.handleToggleRule))
+	mux.HandleFunc("/regole/delete", s.withSecurityHeaders(s.handleDeleteRule))
+	mux.HandleFunc("/regole/test", s.withSecurityHeaders(s.handleTestRule))
+	mux.HandleFunc("/regole/riprocessa", s.withSecurityHeaders(s.handleRuleReprocess))
+	mux.HandleFunc("/regole/riprocessa/avvia", s.withSecurityHeaders(s.handleStartRuleReprocess))
+	mux.HandleFunc("/ui/regole/riprocessa/stato", s.withSecurityHeaders(s.handleRuleReprocessStatus))
+	mux.HandleFunc("/ui/rules-list", s.withSecurityHeaders(s.handleRulesList))
+	mux.HandleFunc("/regole/parole-chiave", s.withSecurityHeaders(s.handleKeywords))
+	mux.HandleFunc("/regole/parole-chiave/set", s.withSecurityHeaders(s.handleSetKeyword))
+	mux.HandleFunc("/regole/parole-chiave/delete", s.withSecurityHeaders(s.handleDeleteKeyword))
+	mux.HandleFunc("/ui/keywords-list", s.withSecurityHeaders(s.handleKeywordsList))
+	mux.HandleFunc("/api/categories/suggest", s.withSecurityHeaders(s.handleSuggestCategory))
+	mux.HandleFunc("/api/validate/amount", s.withSecurityHeaders(s.handleValidateAmount))
+	mux.HandleFunc("/classificatore", s.withSecurityHeaders(s.handleClassifier))
+	mux.HandleFunc("/classificatore/verdict", s.withSecurityHeaders(s.handleClassifierVerdict))
+	mux.HandleFunc("/ui/classifier-list", s.withSecurityHeaders(s.handleClassifierList))
+	// Reimbursement links between incomes and expenses (SQLite backend)
+	mux.HandleFunc("/rimborsi", s.withSecurityHeaders(s.handleReimbursements))
+	mux.HandleFunc("/rimborsi/link", s.withSecurityHeaders(s.handleLinkReimbursement))
+	mux.HandleFunc("/rimborsi/unlink", s.withSecurityHeaders(s.handleUnlinkReimbursement))
+	mux.HandleFunc("/ui/reimbursements-list", s.withSecurityHeaders(s.handleReimbursementsList))
+	// Warranty and return deadlines of purchases (SQLite backend)
+	mux.HandleFunc("/garanzie", s.withSecurityHeaders(s.handleWarranties))
+	mux.HandleFunc("/garanzie/set", s.withSecurityHeaders(s.handleSetWarranty))
+	mux.HandleFunc("/garanzie/delete", s.withSecurityHeaders(s.handleDeleteWarranty))
+	mux.HandleFunc("/ui/warranties-list", s.withSecurityHeaders(s.handleWarrantiesList))
+	// Alert mute and snooze preferences (SQLite backend)
+	mux.HandleFunc("/avvisi", s.withSecurityHeaders(s.handleAlerts))
+	mux.HandleFunc("/avvisi/set", s.withSecurityHeaders(s.handleSetAlertPreference))
+	mux.HandleFunc("/avvisi/delete", s.withSecurityHeaders(s.handleDeleteAlertPreference))
+	mux.HandleFunc("/ui/alerts-list", s.withSecurityHeaders(s.handleAlertsList))
+	// CSV import of expenses with a preview to confirm (SQLite backend)
+	mux.HandleFunc("/import", s.withSecurityHeaders(s.handleImport))
+	mux.HandleFunc("/import/confirm", s.withSecurityHeaders(s.handleImportConfirm))
+	// Rollback of a whole CSV import (SQLite backend)
+	mux.HandleFunc("/import/rollback", s.withSecurityHeaders(s.handleImportRollback))
+	mux.HandleFunc("/ui/import-batches", s.withSecurityHeaders(s.handleImportBatches))
+	// Expenses across months, a page at a time (SQLite backend)
+	mux.HandleFunc("/ledger", s.withSecurityHeaders(s.handleLedger))
+	mux.HandleFunc("/ui/ledger-rows", s.withSecurityHeaders(s.handleLedgerRows))
+	// Saved filters linked from the navigation, optionally alerting on
+	// new matches (SQLite backend)
+	mux.HandleFunc("/viste", s.withSecurityHeaders(s.handleViews))
+	mux.HandleFunc("/viste/create", s.withSecurityHeaders(s.handleCreateView))
+	mux.HandleFunc("/viste/notify", s.withSecurityHeaders(s.handleSetViewNotify))
+	mux.HandleFunc("/viste/delete", s.withSecurityHeaders(s.handleDeleteView))
+	mux.HandleFunc("/viste/spese", s.withSecurityHeaders(s.handleViewExpenses))
+	mux.HandleFunc("/ui/views-list", s.withSecurityHeaders(s.handleViewsList))
+	// Household members and the monthly settlement (SQLite backend)
+	mux.HandleFunc("/famiglia", s.withSecurityHeaders(s.handleHousehold))
+	mux.HandleFunc("/famiglia/create", s.withSecurityHeaders(s.handleCreateUser))
+	mux.HandleFunc("/famiglia/delete", s.withSecurityHeaders(s.handleDeleteUser))
+	mux.HandleFunc("/ui/household-list", s.withSecurityHeaders(s.handleHouseholdList))
+	mux.HandleFunc("/ui/household-settlement", s.withSecurityHeaders(s.handleHouseholdSettlement))
+	// API keys of the members and their quotas (SQLite backend)
+	mux.HandleFunc("/famiglia/quote", s.withSecurityHeaders(s.handleQuotas))
+	mux.HandleFunc("/famiglia/quote/limits", s.withSecurityHeaders(s.handleSetQuota))
+	mux.HandleFunc("/famiglia/quote/keys", s.withSecurityHeaders(s.handleCreateAPIKey))
+	mux.HandleFunc("/famiglia/quote/keys/revoke", s.withSecurityHeaders(s.handleRevokeAPIKey))
+	mux.HandleFunc("/ui/quota-list", s.withSecurityHeaders(s.handleQuotaList))
+	// Accounts, their balances and the transfers between them (SQLite backend)
+	mux.HandleFunc("/accounts", s.withSecurityHeaders(s.handleAccounts))
+	mux.HandleFunc("/accounts/create", s.withSecurityHeaders(s.handleCreateAccount))
+	mux.HandleFunc("/accounts/delete", s.withSecurityHeaders(s.handleDeleteAccount))
+	mux.HandleFunc("/accounts/transfers", s.withSecurityHeaders(s.handleCreateAccountTransfer))
+	mux.HandleFunc("/accounts/transfers/delete", s.withSecurityHeaders(s.handleDeleteAccountTransfer))
+	mux.HandleFunc("/ui/account-list", s.withSecurityHeaders(s.handleAccountList))
+	// End-of-month review and closing of months (SQLite backend)
+	mux.HandleFunc("/revisione", s.withSecurityHeaders(s.handleMonthReview))
+	mux.HandleFunc("/revisione/close", s.withSecurityHeaders(s.handleCloseMonth))
+	mux.HandleFunc("/revisione/reopen", s.withSecurityHeaders(s.handleReopenMonth))
+	mux.HandleFunc("/ui/month-review", s.withSecurityHeaders(s.handleMonthReviewBody))
+	// Monthly statement for printing, without navigation
+	mux.HandleFunc("/print/{year}/{month}", s.withSecurityHeaders(s.handleMonthStatement))
+	// Expenses of a year by category and month (SQLite backend)
+	mux.HandleFunc("/report/year/{year}", s.withSecurityHeaders(s.handleYearReport))
+	// Receipt photos and PDFs attached to expenses (SQLite backend)
+	mux.HandleFunc("/spese/ricevuta", s.withSecurityHeaders(s.handleDownloadReceipt))
+	mux.HandleFunc("/spese/ricevuta/upload", s.withSecurityHeaders(s.handleUploadReceipt))
+	mux.HandleFunc("/spese/ricevuta/delete", s.withSecurityHeaders(s.handleDeleteReceipt))
+	mux.HandleFunc("/ui/expense-receipt", s.withSecurityHeaders(s.handleExpenseReceipt))
+	// Keyboard-only entry of many expenses, saved by the batch endpoint
+	mux.HandleFunc("/bulk-entry", s.withSecurityHeaders(s.handleBulkEntry))
+	// Mileage and per-diem calculators (SQLite backend)
+	mux.HandleFunc("/calcolatori", s.withSecurityHeaders(s.handleCalculators))
+	mux.HandleFunc("/expenses/calculated", s.withSecurityHeaders(s.handleCreateCalculatedExpense))
+	// Shopping lists converted into expenses (SQLite backend)
+	mux.HandleFunc("/liste", s.withSecurityHeaders(s.handleShopping))
+	mux.HandleFunc("/liste/create", s.withSecurityHeaders(s.handleCreateShoppingList))
+	mux.HandleFunc("/liste/delete", s.withSecurityHeaders(s.handleDeleteShoppingList))
+	mux.HandleFunc("/liste/items/add", s.withSecurityHeaders(s.handleAddShoppingItem))
+	mux.HandleFunc("/liste/items/update", s.withSecurityHeaders(s.handleUpdateShoppingItem))
+	mux.HandleFunc("/liste/items/delete", s.withSecurityHeaders(s.handleDeleteShoppingItem))
+	mux.HandleFunc("/liste/convert", s.withSecurityHeaders(s.handleConvertShoppingList))
+	mux.HandleFunc("/ui/shopping-lists", s.withSecurityHeaders(s.handleShoppingLists))
+	mux.HandleFunc("/ui/shopping-list", s.withSecurityHeaders(s.handleShoppingDetail))
+	// Receipt line items of an expense (SQLite backend)
+	mux.HandleFunc("/spese/righe", s.withSecurityHeaders(s.handleLineItems))
+	mux.HandleFunc("/spese/righe/parse", s.withSecurityHeaders(s.handleParseReceipt))
+	mux.HandleFunc("/spese/righe/save", s.withSecurityHeaders(s.handleSaveLineItems))
+	mux.HandleFunc("/spese/dividi", s.withSecurityHeaders(s.handleSplit))
+	mux.HandleFunc("/spese/dividi/save", s.withSecurityHeaders(s.handleSaveSplit))
+	// Price history of tracked items (SQLite backend)
+	mux.HandleFunc("/prezzi", s.withSecurityHeaders(s.handlePrices))
+	mux.HandleFunc("/prezzi/items/add", s.withSecurityHeaders(s.handleTrackItem))
+	mux.HandleFunc("/prezzi/items/delete", s.withSecurityHeaders(s.handleUntrackItem))
+	mux.HandleFunc("/ui/prices-list", s.withSecurityHeaders(s.handlePricesList))
+	// Utility consumption and cost per unit (SQLite backend)
+	mux.HandleFunc("/consumi", s.withSecurityHeaders(s.handleUsage))
+	mux.HandleFunc("/consumi/set", s.withSecurityHeaders(s.handleSetUsage))
+	mux.HandleFunc("/consumi/delete", s.withSecurityHeaders(s.handleDeleteUsage))
+	mux.HandleFunc("/ui/usage-list", s.withSecurityHeaders(s.handleUsageList))
+	// Vehicle cost center with fuel economy (SQLite backend)
+	mux.HandleFunc("/auto", s.withSecurityHeaders(s.handleVehicle))
+	mux.HandleFunc("/auto/rifornimenti/set", s.withSecurityHeaders(s.handleSetFuelFill))
+	mux.HandleFunc("/auto/rifornimenti/delete", s.withSecurityHeaders(s.handleDeleteFuelFill))
+	mux.HandleFunc("/ui/vehicle-report", s.withSecurityHeaders(s.handleVehicleReport))
+	// Preset bundles of categories and recurrents (SQLite backend)
+	mux.HandleFunc("/preset", s.withSecurityHeaders(s.handlePresets))
+	mux.HandleFunc("/preset/install", s.withSecurityHeaders(s.handleInstallPreset))
+	// Category management: create, rename, re-parent, archive and delete
+	// (SQLite backend)
+	mux.HandleFunc("/categorie", s.withSecurityHeaders(s.handleCategories))
+	mux.HandleFunc("/categorie/create", s.withSecurityHeaders(s.handleCreateCategory))
+	mux.HandleFunc("/categorie/rename", s.withSecurityHeaders(s.handleRenameCategory))
+	mux.HandleFunc("/categorie/move", s.withSecurityHeaders(s.handleMoveCategory))
+	mux.HandleFunc("/categorie/archive", s.withSecurityHeaders(s.handleArchiveCategory))
+	mux.HandleFunc("/categorie/delete", s.withSecurityHeaders(s.handleDeleteCategory))
+	mux.HandleFunc("/ui/categories-list", s.withSecurityHeaders(s.handleCategoriesList))
+	// Category cleanup: unused categories, orphan pairs to merge and
+	// secondaries without their primary (SQLite backend)
+	mux.HandleFunc("/categorie/pulizia", s.withSecurityHeaders(s.handleCategoryCleanup))
+	mux.HandleFunc("/categorie/merge", s.withSecurityHeaders(s.handleMergeCategory))
+	mux.HandleFunc("/categorie/detached/delete", s.withSecurityHeaders(s.handleDeleteDetachedCategory))
+	mux.HandleFunc("/ui/category-cleanup", s.withSecurityHeaders(s.handleCategoryCleanupSteps))
+	// Tag report and tag autocompletion (SQLite backend)
+	mux.HandleFunc("/tag", s.withSecurityHeaders(s.handleTags))
+	mux.HandleFunc("/ui/tag-report", s.withSecurityHeaders(s.handleTagReport))
+	mux.HandleFunc("/api/tags", s.withSecurityHeaders(s.handleGetTags))
+	// Category display names per locale (SQLite backend)
+	mux.HandleFunc("/lingua", s.withSecurityHeaders(s.handleSetLocale))
+	mux.HandleFunc("/categorie/traduzioni", s.withSecurityHeaders(s.handleTranslations))
+	mux.HandleFunc("/categorie/traduzioni/set", s.withSecurityHeaders(s.handleSetTranslation))
+	// Business approval workflow (SQLite backend, when enabled)
+	mux.HandleFunc("/workflow", s.withSecurityHeaders(s.handleWorkflow))
+	mux.HandleFunc("/workflow/transition", s.withSecurityHeaders(s.handleWorkflowTransition))
+	mux.HandleFunc("/ui/workflow-list", s.withSecurityHeaders(s.handleWorkflowList))
+	// Dataset downloads
+	mux.HandleFunc("/export/anonymized", s.withSecurityHeaders(s.handleAnonymizedExport))
+	mux.HandleFunc("/export/parquet", s.withSecurityHeaders(s.handleParquetExport))
+	// Companion app channel (events + expense creation)
+	mux.HandleFunc("/ws", s.withSecurityHeaders(s.handleWebSocket))
+	// Sync with another self-hosted instance
+	mux.HandleFunc("/peer/changes", s.withSecurityHeaders(s.handlePeerChanges))
+	// Versioned JSON API for programmatic clients
+	mux.HandleFunc("/api/v1/expenses", s.withSecurityHeaders(s.handleAPIExpenses))
+	mux.HandleFunc("/api/v1/expenses/", s.withSecurityHeaders(s.handleAPIExpenses))
+	mux.HandleFunc("/api/v1/incomes", s.withSecurityHeaders(s.handleAPIIncomes))
+	mux.HandleFunc("/api/v1/incomes/", s.withSecurityHeaders(s.handleAPIIncomes))
+	mux.HandleFunc("/api/v1/recurrents", s.withSecurityHeaders(s.handleAPIRecurrents))
+	mux.HandleFunc("/api/v1/recurrents/", s.withSecurityHeaders(s.handleAPIRecurrents))
+	mux.HandleFunc("/api/v1/categories", s.withSecurityHeaders(s.handleAPICategories))
+	mux.HandleFunc("/api/v1/category-mappings", s.withSecurityHeaders(s.handleAPICategoryMappings))
+	mux.HandleFunc("/api/v1/overview", s.withSecurityHeaders(s.handleAPIOverview))
+	mux.HandleFunc("/api/v1/sync/errors", s.withSecurityHeaders(s.handleAPISyncErrors))
+	mux.HandleFunc("/api/v1/sync/dead-letters", s.withSecurityHeaders(s.handleAPIDeadLetters))
+	mux.HandleFunc("/api/v1/sync/dead-letters/", s.withSecurityHeaders(s.handleAPIDeadLetters))
+	mux.HandleFunc("/api/v1/tags", s.withSecurityHeaders(s.handleAPITags))
+	mux.HandleFunc("/api/v1/tags/report", s.withSecurityHeaders(s.handleAPITagReport))
+	mux.HandleFunc("/api/v1/jobs", s.withSecurityHeaders(s.handleAPIJobs))
+	mux.HandleFunc("/api/v1/jobs/", s.withSecurityHeaders(s.handleAPIJobs))
+	mux.HandleFunc("/api/v1/caches", s.withSecurityHeaders(s.handleAPICaches))
+	mux.HandleFunc("/api/v1/caches/", s.withSecurityHeaders(s.handleAPICaches))
+	// Atomic creation of many expenses (importer, offline queue, scripts)
+	mux.HandleFunc("/api/v1/expenses:batch", s.withSecurityHeaders(s.handleExpenseBatch))
+	mux.HandleFunc("/api/v1/extract", s.withSecurityHeaders(s.handleExtract))
 	// Old expense page (for direct access)
 	mux.HandleFunc("/spese", s.withSecurityHeaders(s.handleIndex))
 
+	s.Handler = s.withAuth(s.withDemoReadOnly(s.withReadYourWrites(s.withPrimaryRouting(mux))))
+
 	return s
 }
 
@@ -222,6 +607,17 @@ func (s *Server) withSecurityHeaders(next http.HandlerFunc) http.HandlerFunc {
 
 		// Add request context with metadata and request ID
 		ctx := context.WithValue(r.Context(), "request_id", requestID)
+		// Record who performs changes (expense history): the member of the
+		// API key, else the client address
+		if user, ok := core.UserFromContext(ctx); ok {
+			ctx = core.WithActor(ctx, "key:"+user.Name)
+		} else {
+			ctx = core.WithActor(ctx, "web:"+clientIP)
+		}
+		ctx, span := startRequestSpan(ctx, r, requestID)
+		rw := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		defer func() { endRequestSpan(span, rw.statusCode) }()
+		w = rw
 		r = r.WithContext(ctx)
 
 		// Enhanced structured request logging
@@ -280,19 +676,13 @@ func (s *Server) withSecurityHeaders(next http.HandlerFunc) http.HandlerFunc {
 			w.Header().Set("Strict-Transport-Security", "max-age=31536000; includeSubDomains; preload")
 		}
 
-		// Track total requests
-		atomic.AddInt64(&s.appMetrics.totalRequests, 1)
-
-		// Create a custom response writer to capture status code
-		rw := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
 		next(rw, r)
 
 		// Enhanced request completion logging
 		duration := time.Since(start)
 		durationMs := duration.Milliseconds()
 
-		// Update average response time
-		atomic.StoreInt64(&s.appMetrics.averageResponseTime, durationMs*1000) // convert to microseconds
+		s.prom.observeRequest(r.Pattern, r.Method, rw.statusCode, duration)
 
 		// Use appropriate log level based on status code
 		logLevel := slog.LevelInfo
@@ -304,6 +694,7 @@ func (s *Server) withSecurityHeaders(next http.HandlerFunc) http.HandlerFunc {
 
 		slog.Log(ctx, logLevel, "HTTP request completed",
 			"request_id", requestID,
+			"trace_id", traceID(ctx),
 			"method", r.Method,
 			"path", r.URL.Path,
 			"query", r.URL.RawQuery,
@@ -326,6 +717,22 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+// Unwrap lets http.ResponseController reach the underlying writer, e.g.
+// to flush event streams
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// Hijack lets WebSocket upgrades take over the underlying connection
+func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := rw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response writer does not support hijacking")
+	}
+	rw.statusCode = http.StatusSwitchingProtocols
+	return h.Hijack()
+}
+
 // handleHealth performs basic liveness check
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
@@ -336,6 +743,17 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 		"timestamp": time.Now().Format(time.RFC3339),
 		"uptime":    time.Since(s.appMetrics.uptime).String(),
 	}
+	if s.replication != nil {
+		health["replication"], _ = s.replicationHealth()
+	}
+	if status := s.integrationHealth.Status(); status.Degraded {
+		// Reported without failing the check: the app works, only the sync waits
+		health["sheets"] = map[string]interface{}{
+			"status":     "degraded",
+			"since":      status.Since.Format(time.RFC3339),
+			"last_error": status.LastError,
+		}
+	}
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(health)
@@ -385,6 +803,16 @@ func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
 		httpStatus = http.StatusServiceUnavailable
 	}
 
+	// Check replication lag
+	if s.replication != nil {
+		info, ok := s.replicationHealth()
+		checks["replication"] = info
+		if !ok {
+			status = "not_ready"
+			httpStatus = http.StatusServiceUnavailable
+		}
+	}
+
 	// Check rate limiter
 	s.rateLimiter.mu.Lock()
 	activeClients := len(s.rateLimiter.clients)
@@ -405,48 +833,9 @@ func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
-// handleMetrics provides application and security metrics in plain text format
+// handleMetrics serves the metrics in the Prometheus text format
 func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
-
-	// Security metrics
-	rateLimitHits, _, suspiciousRequests := s.GetSecurityMetrics()
-
-	// Application metrics
-	totalRequests := atomic.LoadInt64(&s.appMetrics.totalRequests)
-	totalExpenses := atomic.LoadInt64(&s.appMetrics.totalExpenses)
-	uptime := time.Since(s.appMetrics.uptime)
-
-	// Rate limiter statistics
-	s.rateLimiter.mu.Lock()
-	activeClients := len(s.rateLimiter.clients)
-	s.rateLimiter.mu.Unlock()
-
-	w.WriteHeader(http.StatusOK)
-
-	// Write metrics in Prometheus-like format
-	fmt.Fprintf(w, "# HELP http_requests_total Total number of HTTP requests\n")
-	fmt.Fprintf(w, "# TYPE http_requests_total counter\n")
-	fmt.Fprintf(w, "http_requests_total %d\n\n", totalRequests)
-
-	fmt.Fprintf(w, "# HELP expenses_total Total number of expenses created\n")
-	fmt.Fprintf(w, "# TYPE expenses_total counter\n")
-	fmt.Fprintf(w, "expenses_total %d\n\n", totalExpenses)
-	fmt.Fprintf(w, "# HELP rate_limit_hits_total Total rate limit hits\n")
-	fmt.Fprintf(w, "# TYPE rate_limit_hits_total counter\n")
-	fmt.Fprintf(w, "rate_limit_hits_total %d\n\n", rateLimitHits)
-
-	fmt.Fprintf(w, "# HELP suspicious_requests_total Total suspicious requests detected\n")
-	fmt.Fprintf(w, "# TYPE suspicious_requests_total counter\n")
-	fmt.Fprintf(w, "suspicious_requests_total %d\n\n", suspiciousRequests)
-
-	fmt.Fprintf(w, "# HELP active_rate_limit_clients Currently tracked rate limit clients\n")
-	fmt.Fprintf(w, "# TYPE active_rate_limit_clients gauge\n")
-	fmt.Fprintf(w, "active_rate_limit_clients %d\n\n", activeClients)
-
-	fmt.Fprintf(w, "# HELP uptime_seconds Application uptime in seconds\n")
-	fmt.Fprintf(w, "# TYPE uptime_seconds gauge\n")
-	fmt.Fprintf(w, "uptime_seconds %.0f\n\n", uptime.Seconds())
+	s.prom.handler.ServeHTTP(w, r)
 }
 
 func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
@@ -460,6 +849,7 @@ func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
 	}
 
 	now := time.Now()
+	year, month := parseYearMonth(r)
 
 	// For hierarchical categories, load only primaries initially
 	var cats, subs []string
@@ -482,16 +872,35 @@ func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Saved views are linked from the navigation
+	var views []core.SavedView
+	if adapter, ok := s.expWriter.(*adapters.SQLiteAdapter); ok {
+		views, err = adapter.GetStorage().ListSavedViews(r.Context())
+		if err != nil {
+			slog.WarnContext(r.Context(), "Failed to load saved views for navigation", "error", err)
+		}
+	}
+
 	data := struct {
+		entryDateView
 		Day        int
 		Month      int
 		Categories []string
 		Subcats    []string
+		Views      []core.SavedView
+		Users      []core.User
+		Accounts   []core.Account
+		Nav        monthNav
 	}{
-		Day:        now.Day(),
-		Month:      int(now.Month()),
-		Categories: cats,
-		Subcats:    subs,
+		entryDateView: s.entryDateView(r.Context()),
+		Day:           now.Day(),
+		Month:         int(now.Month()),
+		Categories:    cats,
+		Subcats:       subs,
+		Views:         views,
+		Users:         s.householdUsers(r.Context()),
+		Accounts:      s.accounts(r.Context()),
+		Nav:           newMonthNav("/spese", year, month, now),
 	}
 
 	if err := s.templates.ExecuteTemplate(w, "index_page", data); err != nil {
@@ -1,18 +1,57 @@
 package http
 
 import (
+	"bytes"
 	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"database/sql"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"html"
+	"image"
+	"image/jpeg"
+	"image/png"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
 	"spese/internal/core"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/net/websocket"
+
+	"spese/internal/adapters"
+	"spese/internal/classifier"
+	"spese/internal/export"
+	"spese/internal/httpclient"
+	"spese/internal/llm"
+	"spese/internal/receipts"
+	"spese/internal/replication"
+	"spese/internal/rules"
+	"spese/internal/services"
 	ports "spese/internal/sheets"
+	"spese/internal/sheets/google"
+	"spese/internal/storage"
 )
 
 type fakeTax struct{ cats, subs []string }
@@ -668,3 +707,5778 @@ func TestClientIPExtraction(t *testing.T) {
 		})
 	}
 }
+
+// Test reconciliation routes without a configured reconcile service
+func TestHandleReconcile_NotConfigured(t *testing.T) {
+	chdirRepoRoot(t)
+	srv := NewServer(":0", fakeExp{}, fakeTax{}, fakeDash{}, fakeList{}, nil, nil)
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/riconciliazione", nil)
+	srv.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusNotImplemented {
+		t.Fatalf("expected 501, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/riconciliazione", nil)
+	srv.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/riconciliazione/resolve", strings.NewReader("id=1&action=sheet"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	srv.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusNotImplemented {
+		t.Fatalf("expected 501, got %d", rr.Code)
+	}
+}
+
+func TestHandleExpenseHistory_NonSQLite(t *testing.T) {
+	chdirRepoRoot(t)
+	srv := NewServer(":0", fakeExp{}, fakeTax{}, fakeDash{}, fakeList{}, nil, nil)
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/ui/expense-history?id=1", nil)
+	srv.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusNotImplemented {
+		t.Fatalf("expected 501, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/ui/expense-history?id=1", nil)
+	srv.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", rr.Code)
+	}
+}
+
+func TestHandleRules_NonSQLite(t *testing.T) {
+	chdirRepoRoot(t)
+	srv := NewServer(":0", fakeExp{}, fakeTax{}, fakeDash{}, fakeList{}, nil, nil)
+
+	rr := httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/regole", nil))
+	if rr.Code != http.StatusNotImplemented {
+		t.Fatalf("expected 501, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/regole/create", nil))
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", rr.Code)
+	}
+}
+
+func TestHandleTestRule(t *testing.T) {
+	chdirRepoRoot(t)
+	srv := NewServer(":0", fakeExp{}, fakeTax{}, fakeDash{}, fakeList{}, nil, nil)
+
+	cases := []struct {
+		expression string
+		want       string
+	}{
+		{`description contains "Esse" and amount > 20`, "corrisponde alla condizione"},
+		{`amount > 100`, "non corrisponde"},
+		{`amount contains "x"`, "Condizione non valida"},
+	}
+	for _, c := range cases {
+		form := url.Values{"expression": {c.expression}, "sample_description": {"Esselunga"}, "sample_amount": {"25.00"}}
+		req := httptest.NewRequest(http.MethodPost, "/regole/test", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%q: expected 200, got %d", c.expression, rr.Code)
+		}
+		if !strings.Contains(rr.Body.String(), c.want) {
+			t.Errorf("%q: body %q does not contain %q", c.expression, rr.Body.String(), c.want)
+		}
+	}
+}
+
+func TestFormatHistoryValue(t *testing.T) {
+	cases := []struct {
+		field string
+		in    any
+		want  string
+	}{
+		{"amount_cents", float64(1234), formatEuros(1234)},
+		{"description", "Pane", "Pane"},
+		{"date", "2025-03-01", "2025-03-01"},
+		{"secondary_category", nil, ""},
+	}
+	for _, c := range cases {
+		if got := formatHistoryValue(c.field, c.in); got != c.want {
+			t.Fatalf("formatHistoryValue(%q, %v) = %q, want %q", c.field, c.in, got, c.want)
+		}
+	}
+}
+
+func TestParseExpectedVersion(t *testing.T) {
+	cases := []struct {
+		name    string
+		form    string
+		ifMatch string
+		want    int64
+		ok      bool
+	}{
+		{"form field", "version=3", "", 3, true},
+		{"form wins over header", "version=3", `"5"`, 3, true},
+		{"strong etag", "", `"7"`, 7, true},
+		{"weak etag", "", `W/"7"`, 7, true},
+		{"missing", "", "", 0, false},
+		{"not a number", "version=abc", "", 0, false},
+		{"zero", "version=0", "", 0, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/recurrent/update?id=1", strings.NewReader(c.form))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if c.ifMatch != "" {
+				req.Header.Set("If-Match", c.ifMatch)
+			}
+			if err := req.ParseForm(); err != nil {
+				t.Fatalf("parse form: %v", err)
+			}
+			got, ok := parseExpectedVersion(req)
+			if got != c.want || ok != c.ok {
+				t.Fatalf("parseExpectedVersion = (%d, %v), want (%d, %v)", got, ok, c.want, c.ok)
+			}
+		})
+	}
+}
+
+func TestHandleUpdateRecurrentExpense_MissingVersion(t *testing.T) {
+	chdirRepoRoot(t)
+	srv := NewServer(":0", fakeExp{}, fakeTax{}, fakeDash{}, fakeList{}, nil, nil)
+
+	form := "start_date=2025-01-01&repetition_type=monthly&description=Netflix&amount=12.99&primary=Casa&secondary=TV"
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPut, "/recurrent/update?id=1", strings.NewReader(form))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	srv.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusPreconditionRequired {
+		t.Fatalf("expected 428, got %d", rr.Code)
+	}
+}
+
+func TestHandleWebSocket_Auth(t *testing.T) {
+	chdirRepoRoot(t)
+	srv := NewServer(":0", fakeExp{}, fakeTax{}, fakeDash{}, fakeList{}, nil, nil)
+
+	rr := httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/ws", nil))
+	if rr.Code != http.StatusNotImplemented {
+		t.Fatalf("expected 501 when not configured, got %d", rr.Code)
+	}
+
+	srv.SetWebSocketToken("secret")
+	for _, target := range []string{"/ws", "/ws?token=wrong"} {
+		rr = httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, target, nil))
+		if rr.Code != http.StatusUnauthorized {
+			t.Fatalf("%s: expected 401, got %d", target, rr.Code)
+		}
+	}
+}
+
+func TestHandleWebSocket_Session(t *testing.T) {
+	chdirRepoRoot(t)
+	srv := NewServer(":0", fakeExp{}, fakeTax{}, fakeDash{}, fakeList{}, nil, nil)
+	srv.SetWebSocketToken("secret")
+	ts := httptest.NewServer(srv.Handler)
+	defer ts.Close()
+
+	cfg, err := websocket.NewConfig("ws"+strings.TrimPrefix(ts.URL, "http")+"/ws", ts.URL)
+	if err != nil {
+		t.Fatalf("config: %v", err)
+	}
+	cfg.Header.Set("Authorization", "Bearer secret")
+	ws, err := websocket.DialConfig(cfg)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer ws.Close()
+	_ = ws.SetDeadline(time.Now().Add(5 * time.Second))
+
+	receive := func() wsMessage {
+		t.Helper()
+		var m wsMessage
+		if err := websocket.JSON.Receive(ws, &m); err != nil {
+			t.Fatalf("receive: %v", err)
+		}
+		return m
+	}
+
+	if err := websocket.JSON.Send(ws, wsMessage{Type: "ping", Ref: "p1"}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if m := receive(); m.Type != "pong" || m.Ref != "p1" {
+		t.Fatalf("expected pong p1, got %+v", m)
+	}
+
+	// Invalid payloads are rejected without closing the session
+	bad := wsMessage{Type: "expense.create", Ref: "c0", Data: []byte(`{"amount":"0","description":"x","primary":"a","secondary":"b"}`)}
+	if err := websocket.JSON.Send(ws, bad); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if m := receive(); m.Type != "error" || m.Ref != "c0" {
+		t.Fatalf("expected error c0, got %+v", m)
+	}
+
+	create := wsMessage{Type: "expense.create", Ref: "c1", Data: []byte(`{"date":"2025-03-02","amount":"2.50","description":"Pane","primary":"Casa","secondary":"Spesa"}`)}
+	if err := websocket.JSON.Send(ws, create); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+
+	// The ack and the broadcast event may arrive in either order
+	var gotAck, gotEvent bool
+	for i := 0; i < 2; i++ {
+		m := receive()
+		switch m.Type {
+		case "ack":
+			gotAck = m.Ref == "c1" && strings.Contains(string(m.Data), "mem:1")
+		case "expense.created":
+			gotEvent = true
+		default:
+			t.Fatalf("unexpected message %+v", m)
+		}
+	}
+	if !gotAck || !gotEvent {
+		t.Fatalf("expected ack and event, got ack=%v event=%v", gotAck, gotEvent)
+	}
+}
+
+func TestHandleAnonymizedExport(t *testing.T) {
+	chdirRepoRoot(t)
+	list := fakeList{items: []core.Expense{
+		{Date: core.Date{Time: time.Date(2025, 3, 12, 0, 0, 0, 0, time.UTC)}, Description: "Esselunga Milano", Amount: core.Money{Cents: 4520}, Primary: "Casa", Secondary: "Spesa"},
+		{Date: core.Date{Time: time.Date(2025, 4, 2, 0, 0, 0, 0, time.UTC)}, Description: "Cinema", Amount: core.Money{Cents: 900}, Primary: "Svago", Secondary: "Uscite"},
+	}}
+	srv := NewServer(":0", fakeExp{}, fakeTax{}, fakeDash{}, list, nil, nil)
+	srv.SetExportHashKey("test-key")
+
+	rr := httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/export/anonymized?from=2025-03-01&to=2025-03-31", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	if ct := rr.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	body := rr.Body.String()
+	if strings.Contains(body, "Esselunga") || strings.Contains(body, "Cinema") {
+		t.Fatalf("export leaks descriptions:\n%s", body)
+	}
+	lines := strings.Split(strings.TrimSpace(body), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[1], "2025-03-12,") || !strings.HasSuffix(lines[1], ",4520,Casa,Spesa") {
+		t.Errorf("unexpected export:\n%s", body)
+	}
+
+	rr = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/export/anonymized?format=xml", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("unknown format: status = %d, want 400", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/export/anonymized?from=2025-05-01&to=2025-04-01", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("inverted range: status = %d, want 400", rr.Code)
+	}
+}
+
+func TestHandleParquetExport_NotConfigured(t *testing.T) {
+	chdirRepoRoot(t)
+	srv := NewServer(":0", fakeExp{}, fakeTax{}, fakeDash{}, fakeList{}, nil, nil)
+
+	rr := httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/export/parquet?dataset=incomes", nil))
+	if rr.Code != http.StatusNotImplemented {
+		t.Errorf("status = %d, want 501", rr.Code)
+	}
+}
+
+func TestExportsStreamFromSQLite(t *testing.T) {
+	chdirRepoRoot(t)
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	adapter := adapters.NewSQLiteAdapter(repo, services.NewExpenseService(repo))
+	srv := NewServer(":0", adapter, fakeTax{}, fakeDash{}, adapter, adapter, adapter)
+	srv.SetParquetExporter(services.NewParquetExporter(repo, ""))
+	ctx := context.Background()
+
+	// Enough rows to cross several flushes
+	for day := 1; day <= 28; day++ {
+		for i := 0; i < 40; i++ {
+			e := core.Expense{Date: core.NewDate(2030, 2, day), Description: fmt.Sprintf("Negozio %d", i), Amount: core.Money{Cents: int64(100 + i)}, Primary: "Casa", Secondary: "Spesa"}
+			if _, err := adapter.Append(ctx, e); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if _, err := adapter.Append(ctx, core.Expense{Date: core.NewDate(2030, 3, 1), Description: "Fuori", Amount: core.Money{Cents: 1}, Primary: "Casa", Secondary: "Spesa"}); err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		return rr
+	}
+
+	rr := get("/export/anonymized?from=2030-02-01&to=2030-02-28")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("csv: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	lines := strings.Split(strings.TrimSpace(rr.Body.String()), "\n")
+	if len(lines) != 1+28*40 {
+		t.Fatalf("csv: got %d lines, want header and %d rows", len(lines), 28*40)
+	}
+	if !strings.HasPrefix(lines[1], "2030-02-01,") || !strings.HasPrefix(lines[len(lines)-1], "2030-02-28,") {
+		t.Errorf("csv not oldest first: %q ... %q", lines[1], lines[len(lines)-1])
+	}
+	if !rr.Flushed {
+		t.Error("csv export was not flushed while streaming")
+	}
+
+	rr = get("/export/anonymized?from=2030-02-01&to=2030-02-28&format=json")
+	var records []export.Record
+	if err := json.Unmarshal(rr.Body.Bytes(), &records); err != nil || len(records) != 28*40 {
+		t.Fatalf("json: %d records, err %v", len(records), err)
+	}
+
+	rr = get("/export/parquet?dataset=expenses")
+	if data := rr.Body.Bytes(); rr.Code != http.StatusOK || len(data) < 12 || string(data[:4]) != "PAR1" || string(data[len(data)-4:]) != "PAR1" {
+		t.Fatalf("parquet: status = %d, %d bytes", rr.Code, len(data))
+	}
+
+	// A failure before the first byte is still a proper error response
+	failing := NewServer(":0", fakeExp{}, fakeTax{}, fakeDash{}, fakeListErr{}, nil, nil)
+	rr = httptest.NewRecorder()
+	failing.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/export/anonymized?format=json", nil))
+	if rr.Code != http.StatusInternalServerError || rr.Header().Get("Content-Disposition") != "" {
+		t.Errorf("failed export: status = %d, Content-Disposition = %q", rr.Code, rr.Header().Get("Content-Disposition"))
+	}
+}
+
+func TestDemoModeReadOnly(t *testing.T) {
+	chdirRepoRoot(t)
+	srv := NewServer(":0", fakeExp{}, fakeTax{}, fakeDash{}, fakeList{}, nil, nil)
+	srv.SetDemoMode(true)
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodPost, "/expenses", strings.NewReader("description=x")),
+		httptest.NewRequest(http.MethodPost, "/regole/delete", nil),
+		httptest.NewRequest(http.MethodDelete, "/api/anything", nil),
+		httptest.NewRequest(http.MethodGet, "/ws", nil),
+	} {
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, req)
+		if rr.Code != http.StatusForbidden || rr.Header().Get("X-Demo-Mode") != "true" {
+			t.Errorf("%s %s: status = %d, want 403 with X-Demo-Mode", req.Method, req.URL.Path, rr.Code)
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/spese", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("GET /spese: status = %d, want 200", rr.Code)
+	}
+	if !strings.Contains(rr.Body.String(), `class="demo-banner"`) {
+		t.Error("demo banner missing from page")
+	}
+
+	srv.SetDemoMode(false)
+	rr = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/spese", nil))
+	if strings.Contains(rr.Body.String(), `class="demo-banner"`) {
+		t.Error("demo banner shown outside demo mode")
+	}
+}
+
+type fakePeerStore struct{ applied []storage.PeerRecord }
+
+func (f *fakePeerStore) PeerChanges(_ context.Context, cursor int64, _ int) ([]storage.PeerRecord, int64, bool, error) {
+	return []storage.PeerRecord{{Kind: storage.PeerKindExpense, UID: "u1", Version: 1, ModifiedAt: "2025-03-10T08:00:00.000Z", Deleted: true}}, cursor + 1, false, nil
+}
+
+func (f *fakePeerStore) ApplyPeerChanges(_ context.Context, records []storage.PeerRecord) (int, error) {
+	f.applied = append(f.applied, records...)
+	return len(records), nil
+}
+
+func (f *fakePeerStore) PeerSyncCursors(context.Context, string) (int64, int64, error) {
+	return 0, 0, nil
+}
+
+func (f *fakePeerStore) SavePeerSyncCursors(context.Context, string, int64, int64) error {
+	return nil
+}
+
+func TestHandlePeerChanges(t *testing.T) {
+	chdirRepoRoot(t)
+	srv := NewServer(":0", fakeExp{}, fakeTax{}, fakeDash{}, fakeList{}, nil, nil)
+
+	rr := httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/peer/changes", nil))
+	if rr.Code != http.StatusNotImplemented {
+		t.Fatalf("unconfigured: status = %d, want 501", rr.Code)
+	}
+
+	store := &fakePeerStore{}
+	srv.SetPeerSync(services.NewPeerSyncService(store, "", "secret"))
+
+	rr = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/peer/changes", nil))
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("no token: status = %d, want 401", rr.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/peer/changes?since=4", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rr = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, req)
+	var page services.PeerChangeSet
+	if err := json.Unmarshal(rr.Body.Bytes(), &page); err != nil || rr.Code != http.StatusOK {
+		t.Fatalf("pull: status = %d, body %q", rr.Code, rr.Body.String())
+	}
+	if page.Cursor != 5 || len(page.Changes) != 1 || !page.Changes[0].Deleted {
+		t.Errorf("pull page = %+v", page)
+	}
+
+	body := `{"changes":[{"kind":"income","uid":"u2","version":1,"modified_at":"2025-03-10T08:00:00.000Z","date":"2025-03-01","description":"Stipendio","amount_cents":100,"category":"Lavoro"}]}`
+	req = httptest.NewRequest(http.MethodPost, "/peer/changes", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer secret")
+	rr = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"applied":1`) || len(store.applied) != 1 {
+		t.Errorf("push: status = %d, body %q", rr.Code, rr.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/peer/changes", strings.NewReader(`{"changes":[{"kind":"income","uid":"u3"}]}`))
+	req.Header.Set("Authorization", "Bearer secret")
+	rr = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("invalid push: status = %d, want 422", rr.Code)
+	}
+}
+
+func TestReplicaForwardsWrites(t *testing.T) {
+	chdirRepoRoot(t)
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".primary"), []byte("primary-node"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	monitor := replication.NewMonitor(replication.ModeLiteFS, dir, filepath.Join(dir, "spese.db"))
+
+	var forwarded *http.Request
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwarded = r
+		_, _ = w.Write([]byte("saved on primary"))
+	}))
+	defer primary.Close()
+
+	srv := NewServer(":0", fakeExp{}, fakeTax{}, fakeDash{}, fakeList{}, nil, nil)
+	srv.SetReplication(monitor, primary.URL, 0)
+
+	rr := httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/expenses", strings.NewReader("description=x")))
+	if rr.Code != http.StatusOK || rr.Body.String() != "saved on primary" {
+		t.Fatalf("POST on replica: status = %d, body %q", rr.Code, rr.Body.String())
+	}
+	if forwarded == nil || forwarded.URL.Path != "/expenses" || forwarded.Header.Get("X-Spese-Forwarded") == "" {
+		t.Errorf("forwarded request = %+v", forwarded)
+	}
+
+	// Reads stay local
+	forwarded = nil
+	rr = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/spese", nil))
+	if rr.Code != http.StatusOK || forwarded != nil {
+		t.Errorf("GET on replica: status = %d, forwarded = %v", rr.Code, forwarded != nil)
+	}
+
+	// A request already forwarded once is not bounced again
+	req := httptest.NewRequest(http.MethodPost, "/expenses", nil)
+	req.Header.Set("X-Spese-Forwarded", "1")
+	rr = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("re-forwarded POST: status = %d, want 503", rr.Code)
+	}
+
+	// Lag beyond the limit makes the replica not ready
+	if err := os.WriteFile(filepath.Join(dir, ".lag"), []byte("90000"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	srv.SetReplication(monitor, primary.URL, 30*time.Second)
+	rr = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rr.Code != http.StatusServiceUnavailable || !strings.Contains(rr.Body.String(), `"lag":"1m30s"`) {
+		t.Errorf("readyz with lag: status = %d, body %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestReadYourWritesStickiness(t *testing.T) {
+	chdirRepoRoot(t)
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".primary"), []byte("primary-node"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	monitor := replication.NewMonitor(replication.ModeLiteFS, dir, filepath.Join(dir, "spese.db"))
+
+	var forwarded []string
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwarded = append(forwarded, r.Method+" "+r.URL.Path)
+		_, _ = w.Write([]byte("from primary"))
+	}))
+	defer primary.Close()
+
+	srv := NewServer(":0", fakeExp{}, fakeTax{}, fakeDash{}, fakeList{}, nil, nil)
+	srv.SetReplication(monitor, primary.URL, 0)
+	srv.SetReadYourWritesWindow(10 * time.Second)
+
+	// A write sets the stickiness cookie
+	rr := httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/expenses", strings.NewReader("description=x")))
+	var cookie *http.Cookie
+	for _, c := range rr.Result().Cookies() {
+		if c.Name == "spese_rw" {
+			cookie = c
+		}
+	}
+	if cookie == nil || cookie.MaxAge != 10 {
+		t.Fatalf("write cookie = %+v, want spese_rw lasting 10s", cookie)
+	}
+
+	// The writer's next read goes to the primary
+	forwarded = nil
+	req := httptest.NewRequest(http.MethodGet, "/spese", nil)
+	req.AddCookie(cookie)
+	rr = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, req)
+	if rr.Body.String() != "from primary" || len(forwarded) != 1 || forwarded[0] != "GET /spese" {
+		t.Errorf("read after write: body %q, forwarded %v", rr.Body.String(), forwarded)
+	}
+
+	// Other sessions, and the writer once the window has passed, read locally
+	expired := &http.Cookie{Name: "spese_rw", Value: strconv.FormatInt(time.Now().Add(-time.Minute).UnixMilli(), 10)}
+	for name, c := range map[string]*http.Cookie{"no cookie": nil, "expired": expired} {
+		forwarded = nil
+		req := httptest.NewRequest(http.MethodGet, "/spese", nil)
+		if c != nil {
+			req.AddCookie(c)
+		}
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK || len(forwarded) != 0 {
+			t.Errorf("%s: status = %d, forwarded %v", name, rr.Code, forwarded)
+		}
+	}
+}
+
+type fakeBatch struct{ calls [][]core.Expense }
+
+func (f *fakeBatch) AppendBatch(ctx context.Context, expenses []core.Expense) ([]string, error) {
+	f.calls = append(f.calls, expenses)
+	refs := make([]string, len(expenses))
+	for i := range expenses {
+		refs[i] = strconv.Itoa(100 + i)
+	}
+	return refs, nil
+}
+
+func TestHandleExpenseBatch(t *testing.T) {
+	chdirRepoRoot(t)
+	srv := NewServer(":0", fakeExp{}, fakeTax{}, fakeDash{}, fakeList{}, nil, nil)
+
+	post := func(body string) (*httptest.ResponseRecorder, batchResponse) {
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/v1/expenses:batch", strings.NewReader(body)))
+		var resp batchResponse
+		_ = json.Unmarshal(rr.Body.Bytes(), &resp)
+		return rr, resp
+	}
+	valid := `{"date":"2025-04-02","description":"Pane","amount":"2.50","primary":"Casa","secondary":"Spesa"}`
+
+	if rr, _ := post(`{"expenses":[` + valid + `]}`); rr.Code != http.StatusNotImplemented {
+		t.Errorf("without batch writer: status = %d, want 501", rr.Code)
+	}
+
+	batch := &fakeBatch{}
+	srv.SetBatchWriter(batch)
+
+	// An invalid item fails the batch before anything is saved
+	rr, resp := post(`{"expenses":[` + valid + `,{"date":"2025-04-02","description":"Latte","amount":"abc","primary":"Casa","secondary":"Spesa"}]}`)
+	if rr.Code != http.StatusUnprocessableEntity || len(batch.calls) != 0 {
+		t.Fatalf("invalid batch: status = %d, calls = %d", rr.Code, len(batch.calls))
+	}
+	if len(resp.Results) != 2 || resp.Results[0].Status != batchSkipped || resp.Results[1].Status != batchInvalid || resp.Results[1].Error != "invalid amount" {
+		t.Errorf("invalid batch results = %+v", resp.Results)
+	}
+
+	// A dry run validates every item and saves nothing
+	rr = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/v1/expenses:batch?dry_run=true",
+		strings.NewReader(`{"expenses":[`+valid+`,{"date":"2025-04-02","description":"Latte","amount":"1.20","primary":"Casa"}]}`)))
+	_ = json.Unmarshal(rr.Body.Bytes(), &resp)
+	if rr.Code != http.StatusUnprocessableEntity || len(batch.calls) != 0 || resp.Results[0].Status != batchValid || resp.Results[1].Status != batchInvalid {
+		t.Fatalf("dry run: status = %d, calls = %d, results = %+v", rr.Code, len(batch.calls), resp.Results)
+	}
+	rr = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/v1/expenses:batch?dry_run=true", strings.NewReader(`{"expenses":[`+valid+`]}`)))
+	if rr.Code != http.StatusOK || len(batch.calls) != 0 {
+		t.Fatalf("valid dry run: status = %d, calls = %d", rr.Code, len(batch.calls))
+	}
+
+	rr, resp = post(`{"expenses":[` + valid + `,` + valid + `]}`)
+	if rr.Code != http.StatusCreated || resp.Created != 2 || len(batch.calls) != 1 {
+		t.Fatalf("valid batch: status = %d, body %s", rr.Code, rr.Body.String())
+	}
+	if resp.Results[1].Status != batchCreated || resp.Results[1].ID != "101" || batch.calls[0][0].Amount.Cents != 250 {
+		t.Errorf("valid batch results = %+v, saved %+v", resp.Results, batch.calls[0])
+	}
+
+	if rr, _ := post(`{"expenses":[]}`); rr.Code != http.StatusBadRequest {
+		t.Errorf("empty batch: status = %d, want 400", rr.Code)
+	}
+}
+
+func TestHandleBulkEntry(t *testing.T) {
+	chdirRepoRoot(t)
+	srv := NewServer(":0", fakeExp{}, fakeTax{}, fakeDash{}, fakeList{}, nil, nil)
+
+	rr := httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/bulk-entry", nil))
+	if rr.Code != http.StatusOK || strings.Contains(rr.Body.String(), `id="bulk-entry"`) {
+		t.Fatalf("without batch writer: status = %d, form shown = %v", rr.Code, strings.Contains(rr.Body.String(), `id="bulk-entry"`))
+	}
+
+	srv.SetBatchWriter(&fakeBatch{})
+	rr = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/bulk-entry", nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `id="bulk-entry"`) || !strings.Contains(rr.Body.String(), `data-max-rows="500"`) {
+		t.Fatalf("bulk entry page: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/bulk-entry", nil))
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST: status = %d, want 405", rr.Code)
+	}
+}
+
+func TestHandleExpenseBatch_PendingHold(t *testing.T) {
+	chdirRepoRoot(t)
+	ctx := context.Background()
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	adapter := adapters.NewSQLiteAdapter(repo, services.NewExpenseService(repo))
+	srv := NewServer(":0", adapter, fakeTax{}, fakeDash{}, fakeList{}, nil, nil)
+	srv.SetBatchWriter(adapter)
+
+	post := func(body string) (*httptest.ResponseRecorder, batchResponse) {
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/v1/expenses:batch", strings.NewReader(body)))
+		var resp batchResponse
+		_ = json.Unmarshal(rr.Body.Bytes(), &resp)
+		return rr, resp
+	}
+	queued := func(id string) bool {
+		t.Helper()
+		pending, err := repo.GetPendingSyncExpenses(ctx, 1000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, p := range pending {
+			if strconv.FormatInt(p.ID, 10) == id {
+				return true
+			}
+		}
+		return false
+	}
+
+	if rr, resp := post(`{"expenses":[{"date":"2031-06-01","description":"Benzina","amount":"50.00","primary":"Trasporti","secondary":"Carburante","status":"settled"}]}`); rr.Code != http.StatusUnprocessableEntity || resp.Results[0].Status != batchInvalid {
+		t.Fatalf("unknown status: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+
+	rr, resp := post(`{"expenses":[{"date":"2031-06-01","description":"Benzina","amount":"50.00","primary":"Trasporti","secondary":"Carburante","status":"pending"}]}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("pending hold: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	holdID := resp.Results[0].ID
+	if queued(holdID) {
+		t.Error("pending hold queued for Sheets sync")
+	}
+
+	overview, err := repo.ReadMonthOverview(ctx, 2031, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if overview.Total.Cents != 5000 {
+		t.Errorf("total = %d, want 5000 with pending included", overview.Total.Cents)
+	}
+	repo.SetExcludePending(true)
+	overview, err = repo.ReadMonthOverview(ctx, 2031, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if overview.Total.Cents != 0 || len(overview.ByCategory) != 0 {
+		t.Errorf("overview without pending = %+v", overview)
+	}
+
+	// The settled transaction replaces the hold instead of adding an expense
+	rr, resp = post(`{"expenses":[{"date":"2031-06-04","description":"benzina ","amount":"43.20","primary":"Altro","secondary":"Altro"}]}`)
+	if rr.Code != http.StatusCreated || resp.Results[0].ID != holdID {
+		t.Fatalf("settled: status = %d, body = %s, want id %s", rr.Code, rr.Body.String(), holdID)
+	}
+	id, _ := strconv.ParseInt(holdID, 10, 64)
+	settled, err := repo.GetExpense(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settled.Status != "cleared" || settled.AmountCents != 4320 || settled.Date.Day() != 4 || settled.PrimaryCategory != "Trasporti" {
+		t.Errorf("settled expense = %+v", settled)
+	}
+	if !queued(holdID) {
+		t.Error("settled expense not queued for Sheets sync")
+	}
+	overview, err = repo.ReadMonthOverview(ctx, 2031, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if overview.Total.Cents != 4320 {
+		t.Errorf("total = %d, want 4320", overview.Total.Cents)
+	}
+
+	// A hold with no settled match is cleared by hand
+	_, resp = post(`{"expenses":[{"date":"2031-06-10","description":"Autostrada","amount":"12.00","primary":"Trasporti","secondary":"Pedaggi","status":"pending"}]}`)
+	tollID := resp.Results[0].ID
+	rr = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/expenses/clear", strings.NewReader(url.Values{"id": {tollID}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	srv.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Header().Get("HX-Trigger"), "overview:refresh") {
+		t.Fatalf("clear: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	if !queued(tollID) {
+		t.Error("cleared expense not queued for Sheets sync")
+	}
+}
+
+func TestHandleReimbursements(t *testing.T) {
+	chdirRepoRoot(t)
+	ctx := context.Background()
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	srv := NewServer(":0", adapters.NewSQLiteAdapter(repo, nil), fakeTax{}, fakeDash{}, fakeList{}, nil, nil)
+
+	post := func(path string, form url.Values) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		srv.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// A month without seeded data, so the totals only hold these records
+	train, err := repo.AppendAndEnqueueSync(ctx, core.Expense{Date: core.NewDate(2031, 5, 3), Description: "Treno trasferta", Amount: core.Money{Cents: 10000}, Primary: "Trasporti", Secondary: "Treno"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hotel, err := repo.AppendAndEnqueueSync(ctx, core.Expense{Date: core.NewDate(2031, 5, 4), Description: "Hotel trasferta", Amount: core.Money{Cents: 5000}, Primary: "Viaggi", Secondary: "Hotel"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	refund, err := repo.AppendIncome(ctx, core.Income{Date: core.NewDate(2031, 5, 20), Description: "Rimborso trasferta", Amount: core.Money{Cents: 12000}, Category: "Rimborsi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := post("/rimborsi/link", url.Values{"income_id": {refund}, "expense_id": {train, hotel}})
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "Collegate 2 spese per €120,00") {
+		t.Fatalf("link: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	if !strings.Contains(rr.Header().Get("HX-Trigger"), "reimbursements:changed") {
+		t.Errorf("link: missing HX-Trigger, got %q", rr.Header().Get("HX-Trigger"))
+	}
+
+	// The income is fully allocated: the train is covered, the hotel in part
+	list, err := repo.ListReimbursements(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || len(list[0].Links) != 2 || list[0].Links[0].Amount.Cents != 10000 || list[0].Links[1].Amount.Cents != 2000 || list[0].Net().Cents != 0 {
+		t.Fatalf("reimbursements = %+v", list)
+	}
+	if rr := post("/rimborsi/link", url.Values{"income_id": {refund}, "expense_id": {hotel}}); rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("link with nothing left: status = %d, want 422", rr.Code)
+	}
+
+	for _, path := range []string{"/rimborsi", "/ui/reimbursements-list"} {
+		rr = httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "Treno trasferta") || !strings.Contains(rr.Body.String(), "€20,00") {
+			t.Fatalf("%s: status = %d, body = %s", path, rr.Code, rr.Body.String())
+		}
+	}
+
+	overview, err := repo.ReadMonthOverview(ctx, 2031, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if overview.Total.Cents != 15000 {
+		t.Errorf("total = %d, want 15000 with reimbursements included", overview.Total.Cents)
+	}
+	repo.SetExcludeReimbursed(true)
+	overview, err = repo.ReadMonthOverview(ctx, 2031, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if overview.Total.Cents != 3000 || len(overview.ByCategory) != 1 || overview.ByCategory[0].Name != "Viaggi" || overview.ByCategory[0].Amount.Cents != 3000 {
+		t.Errorf("overview without reimbursed = %+v", overview)
+	}
+
+	if rr := post("/rimborsi/unlink", url.Values{"income_id": {refund}, "expense_id": {hotel}}); rr.Code != http.StatusOK {
+		t.Fatalf("unlink: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+
+	// Deleting a linked expense drops its link
+	trainID, _ := strconv.ParseInt(train, 10, 64)
+	if err := repo.HardDeleteExpense(ctx, trainID); err != nil {
+		t.Fatal(err)
+	}
+	if list, err := repo.ListReimbursements(ctx); err != nil || len(list) != 0 {
+		t.Errorf("after deleting the expense: reimbursements = %+v, err = %v", list, err)
+	}
+}
+
+func TestHandleWorkflow(t *testing.T) {
+	chdirRepoRoot(t)
+	ctx := context.Background()
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	srv := NewServer(":0", adapters.NewSQLiteAdapter(repo, nil), fakeTax{}, fakeDash{}, fakeList{}, nil, nil)
+
+	transition := func(id, to, token string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/workflow/transition", strings.NewReader(url.Values{"id": {id}, "to": {to}}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		srv.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	id, err := repo.AppendAndEnqueueSync(ctx, core.Expense{Date: core.NewDate(2031, 7, 2), Description: "Consulenza cliente", Amount: core.Money{Cents: 8000}, Primary: "Lavoro", Secondary: "Trasferte"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rr := transition(id, "submitted", ""); rr.Code != http.StatusNotFound {
+		t.Fatalf("workflow disabled: status = %d, want 404", rr.Code)
+	}
+	srv.SetWorkflow("approver-secret")
+
+	if rr := transition(id, "approved", "approver-secret"); rr.Code != http.StatusConflict {
+		t.Errorf("draft to approved: status = %d, want 409", rr.Code)
+	}
+	rr := transition(id, "submitted", "")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Header().Get("HX-Trigger"), "workflow:changed") {
+		t.Fatalf("submit: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	if rr := transition(id, "approved", "wrong"); rr.Code != http.StatusForbidden {
+		t.Errorf("approve without approver token: status = %d, want 403", rr.Code)
+	}
+	if rr := transition(id, "approved", "approver-secret"); rr.Code != http.StatusOK {
+		t.Fatalf("approve: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+
+	expenseID, _ := strconv.ParseInt(id, 10, 64)
+	if state, err := repo.GetExpenseWorkflowState(ctx, expenseID); err != nil || state != core.WorkflowApproved {
+		t.Fatalf("state = %q, err = %v", state, err)
+	}
+
+	// The approved tab lists the expense with the approver's actions only
+	// for the approver
+	for token, wantAction := range map[string]bool{"": false, "approver-secret": true} {
+		rr = httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/workflow?state=approved", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		srv.Handler.ServeHTTP(rr, req)
+		body := rr.Body.String()
+		if rr.Code != http.StatusOK || !strings.Contains(body, "Consulenza cliente") || !strings.Contains(body, "Approvata (1)") {
+			t.Fatalf("approved tab: status = %d, body = %s", rr.Code, body)
+		}
+		if got := strings.Contains(body, "Segna rimborsata"); got != wantAction {
+			t.Errorf("token %q: reimburse action shown = %v, want %v", token, got, wantAction)
+		}
+	}
+	rr = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/ui/workflow-list?state=submitted", nil))
+	if rr.Code != http.StatusOK || strings.Contains(rr.Body.String(), "Consulenza cliente") {
+		t.Errorf("submitted list: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestHandleCreateCalculatedExpense(t *testing.T) {
+	chdirRepoRoot(t)
+	ctx := context.Background()
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	srv := NewServer(":0", adapters.NewSQLiteAdapter(repo, services.NewExpenseService(repo)), fakeTax{}, fakeDash{}, fakeList{}, nil, nil)
+	srv.SetCalculatorRates(core.Money{Cents: 42}, core.Money{})
+
+	post := func(form url.Values) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/expenses/calculated", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		srv.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	form := url.Values{"kind": {"mileage"}, "quantity": {"123,5"}, "date": {"2031-08-05"}, "primary": {"Trasporti"}, "secondary": {"Auto"}}
+
+	rr := post(form)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "123,5 km × €0,42 = €51,87") {
+		t.Fatalf("mileage: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+
+	expenses, err := repo.ListExpensesWithID(ctx, 2031, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expenses) != 1 || expenses[0].Expense.Amount.Cents != 5187 || expenses[0].Expense.Description != "Rimborso chilometrico" {
+		t.Fatalf("expenses = %+v", expenses)
+	}
+	id, _ := strconv.ParseInt(expenses[0].ID, 10, 64)
+	calc, err := repo.GetExpenseCalculation(ctx, id)
+	if err != nil || calc == nil || calc.Quantity != 123.5 || calc.Rate.Cents != 42 {
+		t.Fatalf("calculation = %+v, err = %v", calc, err)
+	}
+
+	rr = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/ui/expense-history?id="+expenses[0].ID, nil))
+	if !strings.Contains(rr.Body.String(), "Calcolata: 123,5 km × €0,42") {
+		t.Errorf("history does not show the calculation: %s", rr.Body.String())
+	}
+
+	form.Set("kind", "per_diem")
+	if rr := post(form); rr.Code != http.StatusNotImplemented {
+		t.Errorf("per-diem without rate: status = %d, want 501", rr.Code)
+	}
+	form.Set("kind", "mileage")
+	form.Set("quantity", "-3")
+	if rr := post(form); rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("negative quantity: status = %d, want 422", rr.Code)
+	}
+}
+
+func TestHandleWarranties(t *testing.T) {
+	chdirRepoRoot(t)
+	ctx := context.Background()
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	srv := NewServer(":0", adapters.NewSQLiteAdapter(repo, nil), fakeTax{}, fakeDash{}, fakeList{}, nil, nil)
+
+	post := func(path string, form url.Values) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		srv.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	today := time.Now()
+	purchase := core.Date{Time: time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, -2)}
+	id, err := repo.AppendAndEnqueueSync(ctx, core.Expense{Date: purchase, Description: "Aspirapolvere", Amount: core.Money{Cents: 24900}, Primary: "Casa", Secondary: "Elettrodomestici"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rr := post("/garanzie/set", url.Values{"expense_id": {id}}); rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("no terms: status = %d, want 422", rr.Code)
+	}
+	deadline := purchase.AddDate(0, 0, 14).Format("2006-01-02")
+	rr := post("/garanzie/set", url.Values{"expense_id": {id}, "months": {"24"}, "return_deadline": {deadline}, "proof": {"fattura 2031/118"}})
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Header().Get("HX-Trigger"), "warranties:changed") {
+		t.Fatalf("set: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/garanzie", nil))
+	body := rr.Body.String()
+	if rr.Code != http.StatusOK || !strings.Contains(body, "Aspirapolvere") || !strings.Contains(body, "fattura 2031/118") || !strings.Contains(body, "12 giorni") {
+		t.Fatalf("report: status = %d, body = %s", rr.Code, body)
+	}
+
+	if rr := post("/garanzie/delete", url.Values{"expense_id": {id}}); rr.Code != http.StatusOK {
+		t.Fatalf("delete: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/ui/warranties-list", nil))
+	if !strings.Contains(rr.Body.String(), "Nessun acquisto in garanzia") {
+		t.Errorf("list after delete: %s", rr.Body.String())
+	}
+}
+
+func TestHandleReimbursements_NonSQLite(t *testing.T) {
+	chdirRepoRoot(t)
+	srv := NewServer(":0", fakeExp{}, fakeTax{}, fakeDash{}, fakeList{}, nil, nil)
+
+	rr := httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/rimborsi", nil))
+	if rr.Code != http.StatusNotImplemented {
+		t.Fatalf("expected 501, got %d", rr.Code)
+	}
+}
+
+func TestHandleConvertShoppingList(t *testing.T) {
+	chdirRepoRoot(t)
+	ctx := context.Background()
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	srv := NewServer(":0", adapters.NewSQLiteAdapter(repo, services.NewExpenseService(repo)), fakeTax{}, fakeDash{}, fakeList{}, nil, nil)
+
+	post := func(path string, form url.Values) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		srv.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := post("/liste/create", url.Values{"name": {"Spesa sabato"}})
+	if rr.Code != http.StatusOK || !strings.HasPrefix(rr.Header().Get("HX-Redirect"), "/liste?id=") {
+		t.Fatalf("create: status = %d, redirect = %q", rr.Code, rr.Header().Get("HX-Redirect"))
+	}
+	listID := strings.TrimPrefix(rr.Header().Get("HX-Redirect"), "/liste?id=")
+	for _, item := range []url.Values{
+		{"name": {"Latte intero"}, "quantity": {"2"}},
+		{"name": {"Pane"}, "price": {"2,50"}},
+		{"name": {"Caffè"}},
+	} {
+		item.Set("list_id", listID)
+		if rr := post("/liste/items/add", item); rr.Code != http.StatusOK {
+			t.Fatalf("add %s: status = %d, body = %s", item.Get("name"), rr.Code, rr.Body.String())
+		}
+	}
+	id, _ := strconv.ParseInt(listID, 10, 64)
+	list, err := repo.GetShoppingList(ctx, id)
+	if err != nil || len(list.Items) != 3 {
+		t.Fatalf("list = %+v, err = %v", list, err)
+	}
+	itemID := func(i int) string { return strconv.FormatInt(list.Items[i].ID, 10) }
+
+	convert := url.Values{"list_id": {listID}, "date": {"2031-09-06"}, "primary": {"Casa"}, "secondary": {"Spesa"}}
+	if rr := post("/liste/convert", convert); rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("nothing checked: status = %d, want 422", rr.Code)
+	}
+	post("/liste/items/update", url.Values{"id": {itemID(0)}, "checked": {"1"}})
+	if rr := post("/liste/convert", convert); rr.Code != http.StatusUnprocessableEntity || !strings.Contains(rr.Body.String(), "prezzo") {
+		t.Errorf("unpriced item: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	if rr := post("/liste/items/update", url.Values{"id": {itemID(0)}, "checked": {"1"}, "price": {"3,38"}}); rr.Code != http.StatusNoContent {
+		t.Fatalf("update: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	post("/liste/items/update", url.Values{"id": {itemID(1)}, "checked": {"1"}, "price": {"2,50"}})
+
+	rr = post("/liste/convert", convert)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "€5,88") {
+		t.Fatalf("convert: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	expenses, err := repo.ListExpensesWithID(ctx, 2031, 9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expenses) != 1 || expenses[0].Expense.Amount.Cents != 588 || expenses[0].Expense.Description != "Spesa sabato" {
+		t.Fatalf("expenses = %+v", expenses)
+	}
+
+	if rr := post("/liste/convert", convert); rr.Code != http.StatusConflict {
+		t.Errorf("second conversion: status = %d, want 409", rr.Code)
+	}
+	if rr := post("/liste/items/update", url.Values{"id": {itemID(2)}, "checked": {"1"}}); rr.Code != http.StatusConflict {
+		t.Errorf("update after conversion: status = %d, want 409", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/ui/expense-history?id="+expenses[0].ID, nil))
+	body := rr.Body.String()
+	if !strings.Contains(body, "Spesa sabato") || !strings.Contains(body, "Latte intero ×2 €3,38") || strings.Contains(body, "Caffè") {
+		t.Errorf("history does not show the checked items: %s", body)
+	}
+
+	rr = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/liste?id="+listID, nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "Convertita nella spesa #"+expenses[0].ID) {
+		t.Errorf("page: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestHandlePrices(t *testing.T) {
+	chdirRepoRoot(t)
+	ctx := context.Background()
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	adapter := adapters.NewSQLiteAdapter(repo, services.NewExpenseService(repo))
+	srv := NewServer(":0", adapter, fakeTax{}, fakeDash{}, fakeList{}, nil, nil)
+
+	post := func(path string, form url.Values) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		srv.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	appendExpense := func(day string, desc string, cents int64) {
+		t.Helper()
+		date, _ := parseDate(day)
+		if _, err := adapter.Append(ctx, core.Expense{Date: date, Description: desc, Amount: core.Money{Cents: cents}, Primary: "Casa", Secondary: "Spesa"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	appendExpense("2031-01-10", "Latte intero", 100)
+	appendExpense("2031-01-12", "Pane e latte", 400)
+	rr := post("/prezzi/items/add", url.Values{"name": {"latte  INTERO"}})
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "1 spese trovate") {
+		t.Fatalf("track: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	// Once tracked, new expenses described as the item give a price
+	appendExpense("2031-03-04", "latte intero", 130)
+
+	// Line items of converted lists are tracked on their own
+	id, err := repo.CreateShoppingList(ctx, "Spesa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.AddShoppingItem(ctx, id, core.ShoppingItem{Name: "Uova", Quantity: 2, Price: core.Money{Cents: 300}}); err != nil {
+		t.Fatal(err)
+	}
+	list, _ := repo.GetShoppingList(ctx, id)
+	if err := repo.UpdateShoppingItem(ctx, list.Items[0].ID, true, core.Money{Cents: 300}); err != nil {
+		t.Fatal(err)
+	}
+	if rr := post("/liste/convert", url.Values{"list_id": {strconv.FormatInt(id, 10)}, "date": {"2031-03-05"}, "primary": {"Casa"}, "secondary": {"Spesa"}}); rr.Code != http.StatusOK {
+		t.Fatalf("convert: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+
+	history, err := repo.ListItemPriceHistory(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || history[0].Name != "latte INTERO" || len(history[0].Prices) != 2 || history[1].Name != "Uova" || history[1].Prices[0].UnitPrice.Cents != 150 {
+		t.Fatalf("history = %+v", history)
+	}
+
+	rr = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/prezzi", nil))
+	body := rr.Body.String()
+	if rr.Code != http.StatusOK || !strings.Contains(body, "30,0%") || !strings.Contains(body, "115,0") || !strings.Contains(body, "03/2031") {
+		t.Errorf("page: status = %d, body = %s", rr.Code, body)
+	}
+
+	if rr := post("/prezzi/items/delete", url.Values{"id": {strconv.FormatInt(history[0].ID, 10)}}); rr.Code != http.StatusOK {
+		t.Errorf("untrack: status = %d", rr.Code)
+	}
+	if history, _ := repo.ListItemPriceHistory(ctx); len(history) != 1 {
+		t.Errorf("after untrack: history = %+v", history)
+	}
+}
+
+func TestHandleSaveLineItems(t *testing.T) {
+	chdirRepoRoot(t)
+	ctx := context.Background()
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	adapter := adapters.NewSQLiteAdapter(repo, services.NewExpenseService(repo))
+	srv := NewServer(":0", adapter, fakeTax{}, fakeDash{}, fakeList{}, nil, nil)
+
+	post := func(path string, form url.Values) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		srv.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	date, _ := parseDate("2031-02-14")
+	ref, err := adapter.Append(ctx, core.Expense{Date: date, Description: "Supermercato", Amount: core.Money{Cents: 1000}, Primary: "Casa", Secondary: "Spesa"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := post("/spese/righe/parse", url.Values{"text": {"DETERSIVO 3,00\nLATTE x2 2,00\nTOTALE 5,00"}})
+	if body := rr.Body.String(); rr.Code != http.StatusOK || !strings.Contains(body, `value="DETERSIVO"`) || !strings.Contains(body, "Righe ignorate: TOTALE 5,00") {
+		t.Fatalf("parse: status = %d, body = %s", rr.Code, body)
+	}
+
+	form := url.Values{
+		"expense_id":  {ref},
+		"description": {"Detersivo", "Latte", ""},
+		"quantity":    {"", "2", ""},
+		"amount":      {"3,00", "2,00", ""},
+		"primary":     {"Pulizie", "", ""},
+		"secondary":   {"Casa", "", ""},
+	}
+	if rr := post("/spese/righe/save", form); rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "2 righe salvate: €5,00") {
+		t.Fatalf("save: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/ui/expense-history?id="+ref, nil))
+	if !strings.Contains(rr.Body.String(), "Detersivo €3,00 · Pulizie / Casa") || !strings.Contains(rr.Body.String(), "Latte ×2 €2,00") {
+		t.Errorf("history does not show the line items: %s", rr.Body.String())
+	}
+
+	categories := func() map[string]int64 {
+		t.Helper()
+		overview, err := repo.ReadMonthOverview(ctx, 2031, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if overview.Total.Cents != 1000 {
+			t.Errorf("total = %d, want the expense amount", overview.Total.Cents)
+		}
+		m := make(map[string]int64)
+		for _, c := range overview.ByCategory {
+			m[c.Name] = c.Amount.Cents
+		}
+		return m
+	}
+	if got := categories(); got["Casa"] != 1000 || len(got) != 1 {
+		t.Errorf("line item categories off: %v", got)
+	}
+	repo.SetLineItemCategories(true)
+	if got := categories(); got["Casa"] != 700 || got["Pulizie"] != 300 {
+		t.Errorf("line item categories on: %v", got)
+	}
+
+	// Lines above the expense amount are scaled down to it
+	form["amount"] = []string{"8,00", "4,00", ""}
+	if rr := post("/spese/righe/save", form); rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "in proporzione") {
+		t.Fatalf("save over total: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	if got := categories(); got["Casa"] != 333 || got["Pulizie"] != 667 {
+		t.Errorf("over total: %v", got)
+	}
+
+	form["amount"] = []string{"tre", "4,00", ""}
+	if rr := post("/spese/righe/save", form); rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("invalid amount: status = %d, want 422", rr.Code)
+	}
+}
+
+func TestHandleUtilityUsage(t *testing.T) {
+	chdirRepoRoot(t)
+	ctx := context.Background()
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	adapter := adapters.NewSQLiteAdapter(repo, services.NewExpenseService(repo))
+	srv := NewServer(":0", adapter, fakeTax{}, fakeDash{}, fakeList{}, nil, nil)
+
+	post := func(path string, form url.Values) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		srv.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	var refs []string
+	for _, bill := range []struct {
+		date  string
+		cents int64
+	}{{"2031-01-31", 5000}, {"2031-03-31", 7500}} {
+		date, _ := parseDate(bill.date)
+		ref, err := adapter.Append(ctx, core.Expense{Date: date, Description: "Bolletta luce", Amount: core.Money{Cents: bill.cents}, Primary: "Casa", Secondary: "Utenze"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		refs = append(refs, ref)
+	}
+
+	if rr := post("/consumi/set", url.Values{"expense_id": {refs[0]}, "kind": {"electricity"}, "quantity": {"0"}}); rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("zero consumption: status = %d, want 422", rr.Code)
+	}
+	if rr := post("/consumi/set", url.Values{"expense_id": {refs[0]}, "kind": {"steam"}, "quantity": {"200"}}); rr.Code != http.StatusBadRequest {
+		t.Errorf("unknown utility: status = %d, want 400", rr.Code)
+	}
+	for i, quantity := range []string{"200", "250"} {
+		rr := post("/consumi/set", url.Values{"expense_id": {refs[i]}, "kind": {"electricity"}, "quantity": {quantity}})
+		if rr.Code != http.StatusOK || rr.Header().Get("HX-Trigger") == "" {
+			t.Fatalf("set: status = %d, body = %s", rr.Code, rr.Body.String())
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/ui/usage-list", nil))
+	body := rr.Body.String()
+	for _, want := range []string{"Luce", "€0,2500/kWh", "€0,3000/kWh", "250 kWh", "€12,50"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("usage list missing %q: %s", want, body)
+		}
+	}
+
+	rr = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/ui/expense-history?id="+refs[1], nil))
+	if !strings.Contains(rr.Body.String(), "Consumo: 250 kWh · €0,3000/kWh") {
+		t.Errorf("history does not show the consumption: %s", rr.Body.String())
+	}
+
+	if rr := post("/consumi/delete", url.Values{"expense_id": {refs[0]}}); rr.Code != http.StatusOK {
+		t.Fatalf("delete: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	bills, err := repo.ListUtilityBills(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bills) != 1 || bills[0].Usage.Quantity != 250 {
+		t.Errorf("bills after delete = %+v", bills)
+	}
+}
+
+func TestHandleVehicleCosts(t *testing.T) {
+	chdirRepoRoot(t)
+	ctx := context.Background()
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	adapter := adapters.NewSQLiteAdapter(repo, services.NewExpenseService(repo))
+	srv := NewServer(":0", adapter, fakeTax{}, fakeDash{}, fakeList{}, nil, nil)
+
+	post := func(path string, form url.Values) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		srv.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	var refs []string
+	for _, e := range []struct {
+		date, desc string
+		cents      int64
+	}{{"2031-01-05", "Benzina", 6000}, {"2031-01-20", "Benzina", 5000}, {"2031-02-01", "Assicurazione auto", 40000}} {
+		date, _ := parseDate(e.date)
+		ref, err := adapter.Append(ctx, core.Expense{Date: date, Description: e.desc, Amount: core.Money{Cents: e.cents}, Primary: "Trasporti", Secondary: "Spese automobile"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		refs = append(refs, ref)
+	}
+	start, _ := parseDate("2031-01-01")
+	if _, err := repo.CreateRecurrentExpense(ctx, core.RecurrentExpenses{StartDate: start, Every: core.Monthly, Description: "Box auto", Amount: core.Money{Cents: 2000}, Primary: "Trasporti", Secondary: "Spese automobile"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if rr := post("/auto/rifornimenti/set", url.Values{"expense_id": {refs[0]}, "liters": {"35"}, "odometer": {"km"}}); rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("bad odometer: status = %d, want 422", rr.Code)
+	}
+	for i, fill := range []url.Values{{"liters": {"35"}, "odometer": {"10.000"}}, {"liters": {"30"}, "odometer": {"10500"}}} {
+		fill.Set("expense_id", refs[i])
+		if rr := post("/auto/rifornimenti/set", fill); rr.Code != http.StatusOK || rr.Header().Get("HX-Trigger") == "" {
+			t.Fatalf("set fill: status = %d, body = %s", rr.Code, rr.Body.String())
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/ui/vehicle-report?year=2031", nil))
+	body := rr.Body.String()
+	for _, want := range []string{"€510,00", "€110,00", "€400,00", "500 km", "€1,020/km", "6,0 L/100 km", "Assicurazione auto", "Box auto", "€240,00"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("vehicle report missing %q: %s", want, body)
+		}
+	}
+
+	rr = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/ui/expense-history?id="+refs[1], nil))
+	if !strings.Contains(rr.Body.String(), "Rifornimento: 30 L · 10.500 km · €1,667/L") {
+		t.Errorf("history does not show the fuel fill: %s", rr.Body.String())
+	}
+
+	if rr := post("/auto/rifornimenti/delete", url.Values{"expense_id": {refs[1]}}); rr.Code != http.StatusOK {
+		t.Fatalf("delete fill: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	id, _ := strconv.ParseInt(refs[1], 10, 64)
+	if fill, err := repo.GetFuelFill(ctx, id); err != nil || fill != nil {
+		t.Errorf("fill after delete = %+v, %v", fill, err)
+	}
+}
+
+func TestHandleInstallPreset(t *testing.T) {
+	chdirRepoRoot(t)
+	ctx := context.Background()
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	adapter := adapters.NewSQLiteAdapter(repo, services.NewExpenseService(repo))
+	srv := NewServer(":0", adapter, fakeTax{}, fakeDash{}, fakeList{}, nil, nil)
+
+	post := func(path string, form url.Values) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		srv.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/preset", nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "Retta nido") || !strings.Contains(rr.Body.String(), `value="400,00"`) {
+		t.Fatalf("presets page: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+
+	if rr := post("/preset/install", url.Values{"preset": {"missing"}, "start_date": {"2031-09-01"}}); rr.Code != http.StatusNotFound {
+		t.Errorf("unknown preset: status = %d, want 404", rr.Code)
+	}
+	form := url.Values{"preset": {"bimbi-nido"}, "start_date": {"2031-09-01"}, "recurrent": {"0"}, "amount_0": {"520,00"}, "amount_1": {"45,00"}}
+	rr = post("/preset/install", form)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "3 categorie e 1 ricorrenti aggiunte") {
+		t.Fatalf("install: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	secondaries, err := repo.GetSecondariesByPrimary(ctx, "Bimbi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(secondaries) != 7 {
+		t.Errorf("Bimbi secondaries = %v, want the 4 from the migrations plus 3", secondaries)
+	}
+
+	// Installing again adds nothing
+	rr = post("/preset/install", form)
+	if !strings.Contains(rr.Body.String(), "0 categorie e 0 ricorrenti aggiunte, 1 ricorrenti già presenti") {
+		t.Errorf("reinstall: body = %s", rr.Body.String())
+	}
+	recurrents, err := repo.GetRecurrentExpenses(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nido := 0
+	for _, re := range recurrents {
+		if re.Description == "Retta nido" {
+			nido++
+			if re.Amount.Cents != 52000 || re.Secondary != "Nido" {
+				t.Errorf("recurrent = %+v", re)
+			}
+		}
+	}
+	if nido != 1 {
+		t.Errorf("Retta nido recurrents = %d, want 1", nido)
+	}
+}
+
+func TestCategoryTranslations(t *testing.T) {
+	chdirRepoRoot(t)
+	ctx := context.Background()
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	adapter := adapters.NewSQLiteAdapter(repo, services.NewExpenseService(repo))
+	srv := NewServer(":0", adapter, adapter, fakeDash{}, fakeList{}, adapter, adapter)
+
+	post := func(path string, form url.Values) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		srv.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	monthExpenses := func(header, value string) string {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/ui/month-expenses?year=2031&month=1", nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		srv.Handler.ServeHTTP(rr, req)
+		return rr.Body.String()
+	}
+
+	date, _ := parseDate("2031-01-15")
+	ref, err := adapter.Append(ctx, core.Expense{Date: date, Description: "Bolletta luce", Amount: core.Money{Cents: 5000}, Primary: "Casa", Secondary: "Utenze"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if body := monthExpenses("", ""); !strings.Contains(body, "Casa / Utenze") {
+		t.Errorf("italian labels: %s", body)
+	}
+	// Seeded primary is translated, untranslated secondary falls back to its key
+	if body := monthExpenses("Accept-Language", "en-GB,en;q=0.9,it;q=0.5"); !strings.Contains(body, "Home / Utenze") {
+		t.Errorf("english labels: %s", body)
+	}
+
+	if rr := post("/categorie/traduzioni/set", url.Values{"kind": {"secondary"}, "name": {"Utenze"}, "locale": {"fr"}, "display_name": {"Services"}}); rr.Code != http.StatusBadRequest {
+		t.Errorf("unsupported locale: status = %d, want 400", rr.Code)
+	}
+	if rr := post("/categorie/traduzioni/set", url.Values{"kind": {"secondary"}, "name": {"Utenze"}, "locale": {"en"}, "display_name": {"Utilities"}}); rr.Code != http.StatusOK {
+		t.Fatalf("set translation: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	if body := monthExpenses("Cookie", localeCookie+"=en"); !strings.Contains(body, "Home / Utilities") {
+		t.Errorf("translated secondary: %s", body)
+	}
+
+	// The stored keys are untouched, only the display label changes
+	id, _ := strconv.ParseInt(ref, 10, 64)
+	stored, err := repo.GetExpense(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.PrimaryCategory != "Casa" || stored.SecondaryCategory != "Utenze" {
+		t.Errorf("stored expense = %+v", stored)
+	}
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/categories", nil)
+	req.Header.Set("Accept-Language", "en")
+	srv.Handler.ServeHTTP(rr, req)
+	for _, want := range []string{`"primary":"Casa"`, `"label":"Home"`, `"Mutuo":"Mortgage"`} {
+		if !strings.Contains(rr.Body.String(), want) {
+			t.Errorf("api categories missing %s: %s", want, rr.Body.String())
+		}
+	}
+
+	if rr := post("/categorie/traduzioni/set", url.Values{"kind": {"secondary"}, "name": {"Utenze"}, "locale": {"en"}, "display_name": {""}}); !strings.Contains(rr.Body.String(), "rimossa") {
+		t.Errorf("remove translation: body = %s", rr.Body.String())
+	}
+	if body := monthExpenses("Cookie", localeCookie+"=en"); !strings.Contains(body, "Home / Utenze") {
+		t.Errorf("removed translation: %s", body)
+	}
+
+	rr = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/lingua?lang=en&next=//evil.example", nil))
+	if rr.Code != http.StatusSeeOther || rr.Header().Get("Location") != "/" || !strings.Contains(rr.Header().Get("Set-Cookie"), localeCookie+"=en") {
+		t.Errorf("set locale: status = %d, headers = %v", rr.Code, rr.Header())
+	}
+}
+
+func TestHandleCategoryKeywords(t *testing.T) {
+	chdirRepoRoot(t)
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	adapter := adapters.NewSQLiteAdapter(repo, services.NewExpenseService(repo))
+	srv := NewServer(":0", adapter, fakeTax{}, fakeDash{}, fakeList{}, nil, nil)
+
+	post := func(path string, form url.Values) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		srv.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	suggest := func(description string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/categories/suggest?description="+url.QueryEscape(description), nil))
+		return rr
+	}
+
+	// Without a categorizer the pages are unavailable and nothing is suggested
+	if rr := post("/regole/parole-chiave/set", url.Values{"keyword": {"bar sport"}}); rr.Code != http.StatusNotImplemented {
+		t.Errorf("no dictionary: status = %d, want 501", rr.Code)
+	}
+	if rr := suggest("Esselunga"); rr.Code != http.StatusNoContent {
+		t.Errorf("no categorizer: status = %d, want 204", rr.Code)
+	}
+
+	dictionary, err := rules.NewDictionary(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	categorizer := rules.NewCategorizer(repo)
+	categorizer.SetDictionary(dictionary)
+	srv.SetCategorizer(categorizer)
+
+	rr := suggest("POS ESSELUNGA Viale Piave")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"secondary":"Altre spese (non Everli)","source":"keyword","match":"esselunga"`) {
+		t.Fatalf("builtin suggestion: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+
+	if rr := post("/regole/parole-chiave/set", url.Values{"keyword": {"!!"}, "primary": {"Fuori (come fuori a cena...)"}, "secondary": {"Bar"}}); rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("empty keyword: status = %d, want 422", rr.Code)
+	}
+	if rr := post("/regole/parole-chiave/set", url.Values{"keyword": {"Bar  Sport"}, "primary": {"Fuori (come fuori a cena...)"}, "secondary": {"Bar"}}); rr.Code != http.StatusOK || rr.Header().Get("HX-Trigger") == "" {
+		t.Fatalf("add keyword: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	if rr := suggest("Caffè BAR SPORT"); !strings.Contains(rr.Body.String(), `"match":"bar sport"`) {
+		t.Errorf("user keyword: body = %s", rr.Body.String())
+	}
+
+	if rr := post("/regole/parole-chiave/set", url.Values{"keyword": {"esselunga"}, "disabled": {"true"}}); rr.Code != http.StatusOK {
+		t.Fatalf("disable keyword: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	if rr := suggest("Esselunga"); rr.Code != http.StatusNoContent {
+		t.Errorf("disabled keyword: status = %d, want 204", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/regole/parole-chiave", nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `id="keywords-list"`) {
+		t.Fatalf("keywords page: status = %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/ui/keywords-list", nil))
+	body := rr.Body.String()
+	for _, want := range []string{"<code>bar sport</code>", "personalizzata", "modificata", "Ripristina", "<code>trenitalia</code>"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("keywords list missing %q", want)
+		}
+	}
+
+	if rr := post("/regole/parole-chiave/delete", url.Values{"keyword": {"esselunga"}}); !strings.Contains(rr.Body.String(), "ripristinata") {
+		t.Errorf("restore builtin: body = %s", rr.Body.String())
+	}
+	if rr := suggest("Esselunga"); rr.Code != http.StatusOK {
+		t.Errorf("restored keyword: status = %d, want 200", rr.Code)
+	}
+	if rr := post("/regole/parole-chiave/delete", url.Values{"keyword": {"esselunga"}}); rr.Code != http.StatusNotFound {
+		t.Errorf("delete builtin without override: status = %d, want 404", rr.Code)
+	}
+
+	// Rules come before keywords
+	if _, err := repo.CreateCategoryRule(context.Background(), core.CategoryRule{Name: "Spesa online", Expression: `description contains "Esselunga"`, Primary: "Spesa", Secondary: "Everli"}); err != nil {
+		t.Fatal(err)
+	}
+	if rr := suggest("Esselunga"); !strings.Contains(rr.Body.String(), `"source":"rule","match":"Spesa online"`) {
+		t.Errorf("rule suggestion: body = %s", rr.Body.String())
+	}
+}
+
+func TestHandleClassifier(t *testing.T) {
+	chdirRepoRoot(t)
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	adapter := adapters.NewSQLiteAdapter(repo, services.NewExpenseService(repo))
+	srv := NewServer(":0", adapter, fakeTax{}, fakeDash{}, fakeList{}, nil, nil)
+	ctx := context.Background()
+
+	post := func(form url.Values) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/classificatore/verdict", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		srv.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		return rr
+	}
+
+	if rr := get("/classificatore"); rr.Code != http.StatusNotImplemented {
+		t.Errorf("no classifier: status = %d, want 501", rr.Code)
+	}
+
+	date := core.Date{Time: time.Date(2031, 3, 10, 0, 0, 0, 0, time.UTC)}
+	history := []struct{ desc, primary, secondary string }{
+		{"Pizzeria Vesuvio", "Fuori (come fuori a cena...)", "Ristoranti"},
+		{"Cena pizzeria", "Fuori (come fuori a cena...)", "Ristoranti"},
+		{"Pizzeria sotto casa", "Fuori (come fuori a cena...)", "Ristoranti"},
+		{"Benzina Eni", "Trasporti", "Carburante"},
+		{"Benzina autostrada", "Trasporti", "Carburante"},
+		{"Affitto marzo", "Casa", "Affitto"},
+	}
+	for _, h := range history {
+		if _, err := adapter.Append(ctx, core.Expense{Date: date, Description: h.desc, Amount: core.Money{Cents: 2500}, Primary: h.primary, Secondary: h.secondary}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var refs []string
+	for _, desc := range []string{"Pizzeria da Mario", "Xyzzy qwerty"} {
+		ref, err := adapter.Append(ctx, core.Expense{Date: date, Description: desc, Amount: core.Money{Cents: 3000}, Primary: core.UncategorizedPrimary, Secondary: core.UncategorizedSecondary})
+		if err != nil {
+			t.Fatal(err)
+		}
+		refs = append(refs, ref)
+	}
+
+	categorizer := rules.NewCategorizer(repo)
+	categorizer.SetClassifier(classifier.New(repo, time.Hour), 0.6)
+	srv.SetCategorizer(categorizer)
+
+	rr := get("/classificatore")
+	body := rr.Body.String()
+	if rr.Code != http.StatusOK || !strings.Contains(body, `id="classifier-list"`) {
+		t.Fatalf("classifier page: status = %d, body = %s", rr.Code, body)
+	}
+	for _, want := range []string{"Pizzeria da Mario", "Ristoranti <small class=\"caption\">(modello,", "Xyzzy qwerty", "Nessuna proposta"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("classifier page missing %q", want)
+		}
+	}
+
+	rr = get("/api/categories/suggest?description=" + url.QueryEscape("Pizzeria Vesuvio"))
+	if !strings.Contains(rr.Body.String(), `"secondary":"Ristoranti","source":"model"`) {
+		t.Errorf("model suggestion: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+
+	if rr := post(url.Values{"expense_id": {refs[0]}, "verdict": {"maybe"}}); rr.Code != http.StatusBadRequest {
+		t.Errorf("invalid verdict: status = %d, want 400", rr.Code)
+	}
+	if rr := post(url.Values{"expense_id": {refs[1]}, "verdict": {"taught"}, "primary": {"Altre spese"}, "secondary": {"Unknown"}}); rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("teach Unknown: status = %d, want 422", rr.Code)
+	}
+
+	rr = post(url.Values{"expense_id": {refs[0]}, "verdict": {"accepted"}, "primary": {"Fuori (come fuori a cena...)"}, "secondary": {"Ristoranti"}, "confidence": {"0.9"}})
+	if rr.Code != http.StatusOK || rr.Header().Get("HX-Trigger") == "" {
+		t.Fatalf("accept: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	id, _ := strconv.ParseInt(refs[0], 10, 64)
+	e, err := repo.GetExpense(ctx, id)
+	if err != nil || e.SecondaryCategory != "Ristoranti" {
+		t.Fatalf("accepted expense = %+v, %v", e, err)
+	}
+
+	if rr := post(url.Values{"expense_id": {refs[1]}, "verdict": {"rejected"}}); rr.Code != http.StatusOK {
+		t.Fatalf("reject: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+
+	body = get("/ui/classifier-list").Body.String()
+	if strings.Contains(body, "Pizzeria da Mario") || strings.Contains(body, "Xyzzy qwerty") {
+		t.Errorf("reviewed expenses still listed: %s", body)
+	}
+	if !strings.Contains(body, "Nessuna spesa da categorizzare") {
+		t.Errorf("review list not empty: %s", body)
+	}
+}
+
+type fakeExtractor struct {
+	out llm.Extraction
+	err error
+}
+
+func (f fakeExtractor) Extract(ctx context.Context, req llm.Request) (llm.Extraction, error) {
+	return f.out, f.err
+}
+
+func TestHandleExtract(t *testing.T) {
+	chdirRepoRoot(t)
+	srv := NewServer(":0", fakeExp{}, fakeTax{cats: []string{"Spesa"}, subs: []string{"Supermercato"}}, fakeDash{}, fakeList{}, nil, nil)
+
+	post := func(path, contentType, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		srv.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	receipt := url.Values{"text": {"LATTE 1,69\nTOTALE 1,69"}}.Encode()
+
+	if rr := post("/api/v1/extract", "application/json", `{"text": "PANE 2,50"}`); rr.Code != http.StatusNotImplemented {
+		t.Errorf("no model: status = %d, want 501", rr.Code)
+	}
+
+	srv.SetExtractor(fakeExtractor{out: llm.Extraction{
+		Merchant: "Esselunga", Primary: "Spesa", Secondary: "Supermercato",
+		Items: []core.LineItem{{Description: "Latte intero", Quantity: 2, Amount: core.Money{Cents: 338}}},
+	}})
+	rr := post("/api/v1/extract", "application/json", `{"text": "LATTE INT x2 3,38"}`)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"merchant":"Esselunga","primary":"Spesa","secondary":"Supermercato","items":[{"description":"Latte intero","quantity":2,"amount_cents":338}]`) {
+		t.Fatalf("extract: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	if rr := post("/api/v1/extract", "application/json", `{`); rr.Code != http.StatusBadRequest {
+		t.Errorf("bad body: status = %d, want 400", rr.Code)
+	}
+
+	rr = post("/spese/righe/parse", "application/x-www-form-urlencoded", receipt)
+	if body := rr.Body.String(); !strings.Contains(body, "Esercente: Esselunga") || !strings.Contains(body, `value="Latte intero"`) {
+		t.Errorf("parse with model: body = %s", body)
+	}
+
+	// A failing model falls back to the line parser
+	srv.SetExtractor(fakeExtractor{err: errors.New("connection refused")})
+	if rr := post("/api/v1/extract", "application/json", `{"text": "PANE 2,50"}`); rr.Code != http.StatusBadGateway {
+		t.Errorf("model down: status = %d, want 502", rr.Code)
+	}
+	rr = post("/spese/righe/parse", "application/x-www-form-urlencoded", receipt)
+	if body := rr.Body.String(); strings.Contains(body, "Letto dal modello") || !strings.Contains(body, `value="LATTE"`) || !strings.Contains(body, "Righe ignorate: TOTALE 1,69") {
+		t.Errorf("fallback parse: body = %s", body)
+	}
+}
+
+func TestHandleInsights(t *testing.T) {
+	chdirRepoRoot(t)
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	adapter := adapters.NewSQLiteAdapter(repo, services.NewExpenseService(repo))
+	srv := NewServer(":0", adapter, fakeTax{}, fakeDash{}, adapter, nil, nil)
+	ctx := context.Background()
+
+	post := func(path string, form url.Values) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		srv.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	feed := func() string {
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/ui/dashboard/insights", nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("feed: status = %d", rr.Code)
+		}
+		return rr.Body.String()
+	}
+
+	if body := feed(); strings.Contains(body, "Da notare") {
+		t.Errorf("empty feed should render nothing, got %s", body)
+	}
+
+	insights := []core.Insight{
+		{Kind: core.InsightCategoryUp, Primary: "Fuori", Period: "2031-03", Date: core.NewDate(2031, 3, 12), Amount: core.Money{Cents: 14000}, Baseline: core.Money{Cents: 10000}},
+		{Kind: core.InsightCategoryBack, Primary: "Viaggi", Period: "2031-03", Date: core.NewDate(2031, 3, 14), Amount: core.Money{Cents: 30000}, Since: "2030-11"},
+		{Kind: core.InsightBudgetReached, Primary: "Spesa", Period: "2031-03", Date: core.NewDate(2031, 3, 25), Amount: core.Money{Cents: 40000}, Baseline: core.Money{Cents: 38000}, Days: 6},
+	}
+	if recorded, err := repo.RecordInsights(ctx, insights); err != nil || len(recorded) != 3 {
+		t.Fatalf("RecordInsights = %v, %v", recorded, err)
+	}
+
+	body := feed()
+	for _, want := range []string{
+		"40% rispetto allo stesso periodo del mese scorso (€140,00 contro €100,00)",
+		"Prima spesa in Viaggi da novembre 2030 (€300,00)",
+		"Spesa: già speso quanto in tutto il mese scorso (€380,00), 6 giorni prima della fine del mese",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("feed missing %q in %s", want, body)
+		}
+	}
+	// Newest first
+	if strings.Index(body, "Spesa:") > strings.Index(body, "Fuori:") {
+		t.Error("feed should list the newest insight first")
+	}
+
+	listed, err := repo.ListInsights(ctx, 10)
+	if err != nil || len(listed) != 3 {
+		t.Fatalf("ListInsights = %v, %v", listed, err)
+	}
+	ids := make(map[core.InsightKind]string)
+	for _, in := range listed {
+		ids[in.Kind] = strconv.FormatInt(in.ID, 10)
+	}
+
+	rr := post("/insights/dismiss", url.Values{"id": {ids[core.InsightCategoryUp]}})
+	if rr.Code != http.StatusOK || rr.Header().Get("HX-Trigger") != `{"insights:changed": {}}` {
+		t.Errorf("dismiss: status = %d, trigger = %q", rr.Code, rr.Header().Get("HX-Trigger"))
+	}
+	if strings.Contains(feed(), "Fuori:") {
+		t.Error("dismissed insight still in the feed")
+	}
+	if rr := post("/insights/dismiss", url.Values{"id": {"9999"}}); rr.Code != http.StatusNotFound {
+		t.Errorf("dismiss unknown: status = %d, want 404", rr.Code)
+	}
+
+	if rr := post("/insights/mute", url.Values{"id": {ids[core.InsightCategoryBack]}}); rr.Code != http.StatusOK {
+		t.Errorf("mute: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	body = feed()
+	if strings.Contains(body, "Prima spesa in Viaggi") || !strings.Contains(body, "Viaggi · ritorni") {
+		t.Errorf("muted insight: feed = %s", body)
+	}
+	// Muted kinds are not recorded again
+	again := insights[1]
+	again.Period, again.Date = "2031-04", core.NewDate(2031, 4, 2)
+	if recorded, err := repo.RecordInsights(ctx, []core.Insight{again}); err != nil || len(recorded) != 0 {
+		t.Errorf("RecordInsights muted = %v, %v", recorded, err)
+	}
+
+	if rr := post("/insights/unmute", url.Values{"kind": {"category_back"}, "primary": {"Viaggi"}}); rr.Code != http.StatusOK {
+		t.Errorf("unmute: status = %d", rr.Code)
+	}
+	if !strings.Contains(feed(), "Prima spesa in Viaggi") {
+		t.Error("unmuted insight should be back in the feed")
+	}
+	if rr := post("/insights/unmute", url.Values{"kind": {"category_back"}, "primary": {"Viaggi"}}); rr.Code != http.StatusNotFound {
+		t.Errorf("unmute twice: status = %d, want 404", rr.Code)
+	}
+
+	// Other backends have no feed and no controls
+	other := NewServer(":0", fakeExp{}, fakeTax{}, fakeDash{}, fakeList{}, nil, nil)
+	rr = httptest.NewRecorder()
+	other.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/ui/dashboard/insights", nil))
+	if rr.Code != http.StatusOK || rr.Body.Len() != 0 {
+		t.Errorf("other backend feed: status = %d, body = %q", rr.Code, rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/insights/dismiss", strings.NewReader("id=1"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	other.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusNotImplemented {
+		t.Errorf("other backend dismiss: status = %d, want 501", rr.Code)
+	}
+}
+
+func TestHandleAlerts(t *testing.T) {
+	chdirRepoRoot(t)
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	adapter := adapters.NewSQLiteAdapter(repo, services.NewExpenseService(repo))
+	srv := NewServer(":0", adapter, fakeTax{cats: []string{"Spesa", "Casa"}}, fakeDash{}, fakeList{}, nil, nil)
+	ctx := context.Background()
+
+	post := func(path string, form url.Values) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		srv.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/avvisi", nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "Tutti gli avvisi sono attivi") || !strings.Contains(rr.Body.String(), `<option value="Spesa">`) {
+		t.Fatalf("alerts page: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+
+	rr = post("/avvisi/set", url.Values{"type": {"budget"}, "scope": {"Spesa"}, "action": {"mute"}})
+	if rr.Code != http.StatusOK || rr.Header().Get("HX-Trigger") != `{"alerts:changed": {}}` {
+		t.Errorf("mute: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	rr = post("/avvisi/set", url.Values{"type": {"sync"}, "action": {"snooze"}, "days": {"7"}})
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "Avvisi sospesi fino al") {
+		t.Errorf("snooze: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	for name, form := range map[string]url.Values{
+		"unknown type":     {"type": {"weather"}, "action": {"mute"}},
+		"sync by category": {"type": {"sync"}, "scope": {"Spesa"}, "action": {"mute"}},
+		"snooze too long":  {"type": {"budget"}, "action": {"snooze"}, "days": {"400"}},
+	} {
+		if rr := post("/avvisi/set", form); rr.Code != http.StatusUnprocessableEntity {
+			t.Errorf("%s: status = %d, want 422", name, rr.Code)
+		}
+	}
+	if rr := post("/avvisi/set", url.Values{"type": {"budget"}, "action": {"forget"}}); rr.Code != http.StatusBadRequest {
+		t.Errorf("unknown action: status = %d, want 400", rr.Code)
+	}
+
+	prefs, err := repo.ListAlertPreferences(ctx, core.DefaultAlertUser)
+	if err != nil || len(prefs) != 2 {
+		t.Fatalf("preferences = %+v, %v", prefs, err)
+	}
+	if !core.AlertSilenced(prefs, core.AlertBudget, "Spesa", time.Now()) || core.AlertSilenced(prefs, core.AlertBudget, "Casa", time.Now()) {
+		t.Errorf("budget mute should cover Spesa only: %+v", prefs)
+	}
+	if !core.AlertSilenced(prefs, core.AlertSync, "", time.Now().AddDate(0, 0, 6)) || core.AlertSilenced(prefs, core.AlertSync, "", time.Now().AddDate(0, 0, 8)) {
+		t.Errorf("sync should be snoozed for a week: %+v", prefs)
+	}
+
+	rr = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/ui/alerts-list", nil))
+	body := rr.Body.String()
+	for _, want := range []string{"Budget raggiunto", "Silenziati", "Errori di sincronizzazione", "Sospesi fino al"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("alerts list missing %q", want)
+		}
+	}
+
+	if rr := post("/avvisi/delete", url.Values{"type": {"budget"}, "scope": {"Spesa"}}); rr.Code != http.StatusOK {
+		t.Errorf("delete: status = %d", rr.Code)
+	}
+	if rr := post("/avvisi/delete", url.Values{"type": {"budget"}, "scope": {"Spesa"}}); rr.Code != http.StatusNotFound {
+		t.Errorf("delete twice: status = %d, want 404", rr.Code)
+	}
+}
+
+func TestHandleMonthReview(t *testing.T) {
+	chdirRepoRoot(t)
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	adapter := adapters.NewSQLiteAdapter(repo, services.NewExpenseService(repo))
+	srv := NewServer(":0", adapter, fakeTax{}, fakeDash{}, fakeList{}, adapter, nil)
+	ctx := context.Background()
+
+	post := func(path string, form url.Values) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		srv.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		return rr
+	}
+
+	// Not wired: the review is unavailable
+	if rr := get("/revisione"); rr.Code != http.StatusNotImplemented {
+		t.Errorf("without reviewer: status = %d, want 501", rr.Code)
+	}
+	srv.SetMonthReviewer(services.NewMonthReviewer(repo, false))
+
+	var ref string
+	for _, e := range []core.Expense{
+		{Date: core.NewDate(2032, 5, 3), Description: "Bonifico misterioso", Amount: core.Money{Cents: 2500}, Primary: core.UncategorizedPrimary, Secondary: core.UncategorizedSecondary},
+		{Date: core.NewDate(2032, 5, 4), Description: "Pizza", Amount: core.Money{Cents: 1800}, Primary: "Ristoranti", Secondary: "Pizzeria"},
+		{Date: core.NewDate(2032, 5, 4), Description: "pizza", Amount: core.Money{Cents: 1800}, Primary: "Ristoranti", Secondary: "Pizzeria"},
+	} {
+		if ref, err = repo.AppendAndEnqueueSync(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rr := get("/revisione?month=2032-05")
+	body := rr.Body.String()
+	if rr.Code != http.StatusOK {
+		t.Fatalf("review page: status = %d, body = %s", rr.Code, body)
+	}
+	for _, want := range []string{"maggio 2032", "Bonifico misterioso", "2 da controllare", "Chiudi il mese", `hx-get="/ui/month-review?month=2032-05"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("review page missing %q", want)
+		}
+	}
+	if rr := get("/revisione?month=maggio"); rr.Code != http.StatusBadRequest {
+		t.Errorf("bad month: status = %d, want 400", rr.Code)
+	}
+
+	rr = post("/revisione/close", url.Values{"month": {"2032-05"}})
+	if rr.Code != http.StatusOK || rr.Header().Get("HX-Trigger") != `{"review:changed": {}}` {
+		t.Fatalf("close: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	body = get("/ui/month-review?month=2032-05").Body.String()
+	if !strings.Contains(body, "Mese chiuso il") || !strings.Contains(body, "Riapri il mese") || !strings.Contains(body, "Rivisto e chiuso") {
+		t.Errorf("closed month body = %s", body)
+	}
+
+	// Records of a closed month cannot be deleted
+	if rr := post("/expenses/delete", url.Values{"id": {ref}}); rr.Code != http.StatusConflict {
+		t.Errorf("delete in closed month: status = %d, want 409", rr.Code)
+	}
+
+	if rr := post("/revisione/reopen", url.Values{"month": {"2032-05"}}); rr.Code != http.StatusOK {
+		t.Errorf("reopen: status = %d", rr.Code)
+	}
+	if rr := post("/revisione/reopen", url.Values{"month": {"2032-05"}}); rr.Code != http.StatusNotFound {
+		t.Errorf("reopen twice: status = %d, want 404", rr.Code)
+	}
+	if rr := post("/expenses/delete", url.Values{"id": {ref}}); rr.Code != http.StatusOK {
+		t.Errorf("delete after reopen: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestHandleAPIv1(t *testing.T) {
+	chdirRepoRoot(t)
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	adapter := adapters.NewSQLiteAdapter(repo, services.NewExpenseService(repo))
+	srv := NewServer(":0", adapter, adapter, adapter, adapter, adapter, adapter)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		srv.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	decode := func(rr *httptest.ResponseRecorder, v any) {
+		t.Helper()
+		if err := json.Unmarshal(rr.Body.Bytes(), v); err != nil {
+			t.Fatalf("decode %s: %v", rr.Body.String(), err)
+		}
+	}
+
+	// Expenses: create, list with filters and pages, read, delete
+	var ids []string
+	for _, body := range []string{
+		`{"date": "2031-07-02", "description": "Spesa Conad", "amount": "42.50", "primary": "Spesa", "secondary": "Supermercato"}`,
+		`{"date": "2031-07-05", "description": "Pizza", "amount": "18", "primary": "Ristoranti", "secondary": "Pizzeria"}`,
+		`{"date": "2031-07-09", "description": "Spesa Esselunga", "amount": "30", "primary": "Spesa", "secondary": "Supermercato"}`,
+		`{"date": "2031-09-01", "description": "Spesa Coop", "amount": "12", "primary": "Spesa", "secondary": "Supermercato"}`,
+	} {
+		rr := do(http.MethodPost, "/api/v1/expenses", body)
+		var created apiExpense
+		decode(rr, &created)
+		if rr.Code != http.StatusCreated || rr.Header().Get("Location") != "/api/v1/expenses/"+created.ID || created.Status != "cleared" {
+			t.Fatalf("create expense: status = %d, location = %q, body = %s", rr.Code, rr.Header().Get("Location"), rr.Body.String())
+		}
+		ids = append(ids, created.ID)
+	}
+	if rr := do(http.MethodPost, "/api/v1/expenses", `{"description": "", "amount": "1"}`); rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("invalid expense: status = %d, want 422", rr.Code)
+	}
+	if rr := do(http.MethodPost, "/api/v1/expenses", `{`); rr.Code != http.StatusBadRequest {
+		t.Errorf("bad JSON: status = %d, want 400", rr.Code)
+	}
+
+	var page apiPage[apiExpense]
+	rr := do(http.MethodGet, "/api/v1/expenses?year=2031&month=7&primary=spesa", "")
+	decode(rr, &page)
+	if rr.Code != http.StatusOK || page.Total != 2 || len(page.Items) != 2 || page.Items[0].AmountCents != 3000 {
+		t.Errorf("filtered list = %d %+v", rr.Code, page)
+	}
+	page = apiPage[apiExpense]{}
+	decode(do(http.MethodGet, "/api/v1/expenses?year=2031&q=spesa&limit=2&offset=2", ""), &page)
+	if page.Total != 3 || len(page.Items) != 1 || page.Items[0].Description != "Spesa Conad" || page.Limit != 2 || page.Offset != 2 {
+		t.Errorf("whole year, second page = %+v", page)
+	}
+	for _, query := range []string{"month=13", "year=abc", "limit=0", "limit=501", "offset=-1"} {
+		if rr := do(http.MethodGet, "/api/v1/expenses?"+query, ""); rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", query, rr.Code)
+		}
+	}
+
+	var one apiExpense
+	rr = do(http.MethodGet, "/api/v1/expenses/"+ids[1], "")
+	decode(rr, &one)
+	if rr.Code != http.StatusOK || one.Description != "Pizza" || one.Date != "2031-07-05" || one.SyncStatus != "pending" {
+		t.Errorf("get expense = %d %+v", rr.Code, one)
+	}
+	if rr := do(http.MethodDelete, "/api/v1/expenses/"+ids[1], ""); rr.Code != http.StatusNoContent {
+		t.Errorf("delete expense: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	for _, method := range []string{http.MethodGet, http.MethodDelete} {
+		if rr := do(method, "/api/v1/expenses/"+ids[1], ""); rr.Code != http.StatusNotFound {
+			t.Errorf("%s deleted expense: status = %d, want 404", method, rr.Code)
+		}
+	}
+	// purge=true skips the trash
+	if rr := do(http.MethodDelete, "/api/v1/expenses/"+ids[3]+"?purge=true", ""); rr.Code != http.StatusNoContent {
+		t.Errorf("purge expense: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	trash, err := repo.ListTrash(context.Background())
+	if err != nil || len(trash) != 1 || strconv.FormatInt(trash[0].ID, 10) != ids[1] {
+		t.Errorf("trash after purge = %+v, %v", trash, err)
+	}
+	if rr := do(http.MethodDelete, "/api/v1/expenses/x?purge=true", ""); rr.Code != http.StatusBadRequest {
+		t.Errorf("purge bad id: status = %d, want 400", rr.Code)
+	}
+	if rr := do(http.MethodPut, "/api/v1/expenses/"+ids[0], ""); rr.Code != http.StatusMethodNotAllowed || rr.Header().Get("Allow") != "GET, DELETE" {
+		t.Errorf("PUT expense: status = %d, Allow = %q", rr.Code, rr.Header().Get("Allow"))
+	}
+
+	// Incomes
+	rr = do(http.MethodPost, "/api/v1/incomes", `{"date": "2031-07-27", "description": "Stipendio luglio", "amount": "2000", "category": "Stipendio", "tags": ["Lavoro"]}`)
+	var income apiIncome
+	decode(rr, &income)
+	if rr.Code != http.StatusCreated || income.AmountCents != 200000 || len(income.Tags) != 1 || income.Tags[0] != "lavoro" {
+		t.Fatalf("create income = %d %s", rr.Code, rr.Body.String())
+	}
+	var incomes apiPage[apiIncome]
+	decode(do(http.MethodGet, "/api/v1/incomes?year=2031&month=7&tag=lavoro", ""), &incomes)
+	if incomes.Total != 1 || incomes.Items[0].ID != income.ID {
+		t.Errorf("incomes = %+v", incomes)
+	}
+
+	// Overview of the month, with incomes and balance
+	var overview apiOverview
+	rr = do(http.MethodGet, "/api/v1/overview?year=2031&month=7", "")
+	decode(rr, &overview)
+	if rr.Code != http.StatusOK || overview.TotalCents != 7250 || overview.IncomeTotalCents == nil || *overview.IncomeTotalCents != 200000 || *overview.BalanceCents != 192750 {
+		t.Errorf("overview = %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodGet, "/api/v1/overview?year=2031", ""); rr.Code != http.StatusBadRequest {
+		t.Errorf("overview without month: status = %d, want 400", rr.Code)
+	}
+
+	if rr := do(http.MethodDelete, "/api/v1/incomes/"+income.ID, ""); rr.Code != http.StatusNoContent {
+		t.Errorf("delete income: status = %d", rr.Code)
+	}
+	if rr := do(http.MethodGet, "/api/v1/incomes/"+income.ID, ""); rr.Code != http.StatusNotFound {
+		t.Errorf("deleted income: status = %d, want 404", rr.Code)
+	}
+
+	// Recurrent expenses
+	rr = do(http.MethodPost, "/api/v1/recurrents", `{"start_date": "2031-01-01", "every": "monthly", "description": "Palestra", "amount": "45", "primary": "Sport", "secondary": "Palestra"}`)
+	var recurrent apiRecurrent
+	decode(rr, &recurrent)
+	if rr.Code != http.StatusCreated || recurrent.ID == 0 || recurrent.Every != "monthly" {
+		t.Fatalf("create recurrent = %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodPost, "/api/v1/recurrents", `{"start_date": "2031-01-01", "every": "hourly", "description": "X", "amount": "1", "primary": "A", "secondary": "B"}`); rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("invalid recurrent: status = %d, want 422", rr.Code)
+	}
+	var recurrents apiPage[apiRecurrent]
+	decode(do(http.MethodGet, "/api/v1/recurrents?q=palestra", ""), &recurrents)
+	if recurrents.Total != 1 || recurrents.Items[0].ID != recurrent.ID {
+		t.Errorf("recurrents = %+v", recurrents)
+	}
+	path := "/api/v1/recurrents/" + strconv.FormatInt(recurrent.ID, 10)
+	if rr := do(http.MethodGet, path, ""); rr.Code != http.StatusOK {
+		t.Errorf("get recurrent: status = %d", rr.Code)
+	}
+	if rr := do(http.MethodDelete, path, ""); rr.Code != http.StatusNoContent {
+		t.Errorf("delete recurrent: status = %d", rr.Code)
+	}
+	if rr := do(http.MethodGet, "/api/v1/recurrents/999999", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("missing recurrent: status = %d, want 404", rr.Code)
+	}
+
+	// Categories
+	var cats apiCategories
+	rr = do(http.MethodGet, "/api/v1/categories", "")
+	decode(rr, &cats)
+	if rr.Code != http.StatusOK || len(cats.Expense) == 0 || len(cats.Income) == 0 {
+		t.Errorf("categories = %d %s", rr.Code, rr.Body.String())
+	}
+
+	// Other backends: SQLite-only resources answer 501
+	sheetsSrv := NewServer(":0", fakeExp{}, fakeTax{cats: []string{"Spesa"}, subs: []string{"Varie"}}, fakeDash{}, fakeList{}, nil, nil)
+	for _, path := range []string{"/api/v1/incomes", "/api/v1/recurrents", "/api/v1/expenses/1", "/api/v1/expenses"} {
+		rr := httptest.NewRecorder()
+		sheetsSrv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		if rr.Code != http.StatusNotImplemented {
+			t.Errorf("%s on Sheets: status = %d, want 501", path, rr.Code)
+		}
+	}
+	rr = httptest.NewRecorder()
+	sheetsSrv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/categories", nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"secondaries":["Varie"]`) {
+		t.Errorf("categories on Sheets = %d %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestHandleUpdateExpense(t *testing.T) {
+	chdirRepoRoot(t)
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	adapter := adapters.NewSQLiteAdapter(repo, services.NewExpenseService(repo))
+	srv := NewServer(":0", adapter, fakeTax{}, fakeDash{}, fakeList{}, adapter, nil)
+	ctx := context.Background()
+
+	ref, err := repo.AppendAndEnqueueSync(ctx, core.Expense{Date: core.NewDate(2031, 3, 10), Description: "Spesa", Amount: core.Money{Cents: 4200}, Primary: "Spesa", Secondary: "Supermercato"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, _ := strconv.ParseInt(ref, 10, 64)
+	// Already written to Google Sheets
+	queued, err := repo.DequeueSyncBatch(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, item := range queued {
+		if err := repo.MarkSyncComplete(ctx, item.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := repo.MarkSynced(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+
+	put := func(path string, form url.Values) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPut, path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		srv.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	edit := url.Values{
+		"date":        {"2031-04-02"},
+		"description": {"Spesa grande"},
+		"amount":      {"55,10"},
+		"primary":     {"Spesa"},
+		"secondary":   {"Everli"},
+	}
+
+	rr := httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/expenses/"+ref+"/edit", nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `name="version" value="1"`) || !strings.Contains(rr.Body.String(), `value="2031-03-10"`) {
+		t.Fatalf("edit form: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+
+	if rr := put("/expenses/"+ref, edit); rr.Code != http.StatusPreconditionRequired {
+		t.Errorf("without version: status = %d, want 428", rr.Code)
+	}
+
+	edit.Set("version", "1")
+	if rr := put("/expenses/"+ref, edit); rr.Code != http.StatusOK {
+		t.Fatalf("update: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	got, err := repo.GetExpense(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Description != "Spesa grande" || got.AmountCents != 5510 || got.SecondaryCategory != "Everli" || got.Date.Format("2006-01-02") != "2031-04-02" || got.Version != 2 {
+		t.Fatalf("expense not updated: %+v", got)
+	}
+	// The old row is removed from the sheet and the new one written
+	queued, err = repo.DequeueSyncBatch(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queued) != 2 || queued[0].Operation != "delete" || queued[1].Operation != "sync" {
+		t.Fatalf("expected delete then sync queued, got %+v", queued)
+	}
+
+	// Based on the old version
+	if rr := put("/expenses/"+ref, edit); rr.Code != http.StatusConflict {
+		t.Errorf("stale version: status = %d, want 409", rr.Code)
+	}
+	if rr := put("/expenses/999999", edit); rr.Code != http.StatusNotFound {
+		t.Errorf("missing expense: status = %d, want 404", rr.Code)
+	}
+	edit.Set("version", "2")
+	edit.Set("amount", "abc")
+	if rr := put("/expenses/"+ref, edit); rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("invalid amount: status = %d, want 422", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/expenses/"+ref, nil))
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST: status = %d, want 405", rr.Code)
+	}
+}
+
+type fakeHistoryReader struct{ rows map[int][]core.Expense }
+
+func (f fakeHistoryReader) ListExpenseYears(ctx context.Context) ([]int, error) {
+	return []int{2018, 2019}, nil
+}
+
+func (f fakeHistoryReader) ListYearExpenses(ctx context.Context, year int) ([]core.Expense, error) {
+	return f.rows[year], nil
+}
+
+func TestHandleSheetHistory(t *testing.T) {
+	chdirRepoRoot(t)
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	adapter := adapters.NewSQLiteAdapter(repo, services.NewExpenseService(repo))
+	srv := NewServer(":0", adapter, fakeTax{}, fakeDash{}, fakeList{}, adapter, nil)
+
+	rr := httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/storico-fogli", nil))
+	if rr.Code != http.StatusNotImplemented {
+		t.Fatalf("without importer: status = %d, want 501", rr.Code)
+	}
+
+	rows := map[int][]core.Expense{2018: {{Date: core.NewDate(2018, 5, 2), Description: "Vecchia spesa", Amount: core.Money{Cents: 1250}, Primary: "Casa", Secondary: "Mutuo"}}}
+	srv.SetHistoryImporter(services.NewHistoryImporter(repo, fakeHistoryReader{rows: rows}))
+
+	rr = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/storico-fogli", nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "Da importare") {
+		t.Fatalf("page: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+
+	post := func(year string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/storico-fogli/import", strings.NewReader(url.Values{"year": {year}}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		srv.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	if rr := post("2999"); rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("future year: status = %d, want 422", rr.Code)
+	}
+	if rr := post("abc"); rr.Code != http.StatusBadRequest {
+		t.Fatalf("bad year: status = %d, want 400", rr.Code)
+	}
+	rr = post("2018")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Header().Get("HX-Trigger"), "history:changed") {
+		t.Fatalf("import: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+
+	// The import runs in the background; the table shows its progress
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		rr = httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/ui/sheet-history", nil))
+		if strings.Contains(rr.Body.String(), "Importato") {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("import not completed: %s", rr.Body.String())
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if strings.Contains(rr.Body.String(), "every 2s") {
+		t.Fatalf("expected polling to stop after the import: %s", rr.Body.String())
+	}
+	if n, err := repo.CountYearExpenses(context.Background(), 2018); err != nil || n != 1 {
+		t.Fatalf("expected 1 expense in 2018, got %d (%v)", n, err)
+	}
+}
+
+func TestHandleLedger(t *testing.T) {
+	chdirRepoRoot(t)
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	adapter := adapters.NewSQLiteAdapter(repo, services.NewExpenseService(repo))
+	srv := NewServer(":0", adapter, fakeTax{}, fakeDash{}, fakeList{}, adapter, nil)
+	ctx := context.Background()
+
+	// 230 expenses over three months, several per day, plus one on each
+	// side of the range
+	for i := 0; i < 230; i++ {
+		e := core.Expense{Date: core.NewDate(2031, 3+i%3, 1+i%28), Description: "Spesa " + strconv.Itoa(i), Amount: core.Money{Cents: 100}, Primary: "Casa", Secondary: "Mutuo"}
+		if _, err := repo.Append(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, d := range []core.Date{core.NewDate(2031, 2, 28), core.NewDate(2031, 6, 1)} {
+		if _, err := repo.Append(ctx, core.Expense{Date: d, Description: "Fuori", Amount: core.Money{Cents: 100}, Primary: "Casa", Secondary: "Mutuo"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		return rr
+	}
+	if rr := get("/ledger?from=2031-05-31&to=2031-03-01"); rr.Code != http.StatusBadRequest {
+		t.Fatalf("reversed range: status = %d, want 400", rr.Code)
+	}
+	if rr := get("/ui/ledger-rows?from=2031-03-01&to=2031-05-31&after=x"); rr.Code != http.StatusBadRequest {
+		t.Fatalf("bad cursor: status = %d, want 400", rr.Code)
+	}
+
+	rr := get("/ledger?from=2031-03-01&to=2031-05-31")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "230 spese, totale €230,00") {
+		t.Fatalf("ledger: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+
+	// Follow the pages through their last row, as scrolling does
+	next := regexp.MustCompile(`hx-get="([^"]+)" hx-trigger="revealed"`)
+	seen := make(map[string]bool)
+	body, pages := rr.Body.String(), 1
+	for {
+		for _, m := range regexp.MustCompile(`<td>(Spesa \d+|Fuori)</td>`).FindAllStringSubmatch(body, -1) {
+			if seen[m[1]] {
+				t.Fatalf("%s listed twice", m[1])
+			}
+			seen[m[1]] = true
+		}
+		m := next.FindStringSubmatch(body)
+		if m == nil {
+			break
+		}
+		rr := get(html.UnescapeString(m[1]))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("page %d: status = %d, body = %s", pages+1, rr.Code, rr.Body.String())
+		}
+		body = rr.Body.String()
+		pages++
+	}
+	if pages != 3 || len(seen) != 230 || seen["Fuori"] {
+		t.Fatalf("expected the 230 expenses of the range in 3 pages, got %d in %d", len(seen), pages)
+	}
+}
+
+func TestHandleImport(t *testing.T) {
+	chdirRepoRoot(t)
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	expenses := services.NewExpenseService(repo)
+	adapter := adapters.NewSQLiteAdapter(repo, expenses)
+	srv := NewServer(":0", adapter, fakeTax{}, fakeDash{}, fakeList{}, adapter, nil)
+
+	upload := func(content string) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		part, err := mw.CreateFormFile("file", "banca.csv")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = part.Write([]byte(content))
+		_ = mw.Close()
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/import", &body)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		srv.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := upload("data;importo\n2031-01-02;3\n"); rr.Code != http.StatusNotImplemented {
+		t.Fatalf("without importer: status = %d, want 501", rr.Code)
+	}
+	srv.SetCSVImporter(services.NewCSVImporter(repo, expenses))
+
+	if rr := upload("data;importo\n2031-01-02;3\n"); rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), "description") {
+		t.Fatalf("missing column: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+
+	rr := upload("data;descrizione;importo;categoria;sottocategoria\n02/06/2031;Libreria;18,00;Svago;Libri\n03/06/2031;Pizza;abc;Cibo;Ristorante\n")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "1 nuove, 0 già presenti, 1 non valide") {
+		t.Fatalf("preview: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	m := regexp.MustCompile(`name="token" value="([0-9a-f]+)"`).FindStringSubmatch(rr.Body.String())
+	if m == nil {
+		t.Fatalf("preview without token: %s", rr.Body.String())
+	}
+	if n, _ := repo.CountYearExpenses(context.Background(), 2031); n != 0 {
+		t.Fatalf("expected nothing saved before confirming, got %d", n)
+	}
+
+	confirm := func() *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/import/confirm", strings.NewReader(url.Values{"token": {m[1]}}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		srv.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	rr = confirm()
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "1 spese importate, 1 righe non valide saltate") {
+		t.Fatalf("confirm: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	if n, _ := repo.CountYearExpenses(context.Background(), 2031); n != 1 {
+		t.Fatalf("expected 1 expense imported, got %d", n)
+	}
+	if rr := confirm(); rr.Code != http.StatusNotFound {
+		t.Fatalf("second confirm: status = %d, want 404", rr.Code)
+	}
+}
+
+func TestHandleViews(t *testing.T) {
+	chdirRepoRoot(t)
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	adapter := adapters.NewSQLiteAdapter(repo, services.NewExpenseService(repo))
+	srv := NewServer(":0", adapter, fakeTax{}, fakeDash{}, fakeList{}, adapter, nil)
+	ctx := context.Background()
+
+	for _, e := range []core.Expense{
+		{Date: core.NewDate(2031, 5, 2), Description: "Divano", Amount: core.Money{Cents: 89000}, Primary: "Casa", Secondary: "Arredo"},
+		{Date: core.NewDate(2031, 5, 3), Description: "Lampada", Amount: core.Money{Cents: 4000}, Primary: "Casa", Secondary: "Arredo"},
+		{Date: core.NewDate(2031, 5, 4), Description: "Bici", Amount: core.Money{Cents: 60000}, Primary: "Svago", Secondary: "Arredo"},
+	} {
+		if _, err := repo.Append(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	post := func(path string, form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		return rr
+	}
+
+	if rr := post("/viste/create", url.Values{"name": {"Tutto"}}); rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("empty filter: status = %d, want 422", rr.Code)
+	}
+	if rr := post("/viste/create", url.Values{"name": {"X"}, "min": {"dieci"}}); rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("bad amount: status = %d, want 422", rr.Code)
+	}
+	rr := post("/viste/create", url.Values{"name": {"Grandi acquisti"}, "primary": {"Casa"}, "secondary": {"Arredo"}, "min": {"500"}, "notify": {"on"}})
+	if rr.Code != http.StatusOK || rr.Header().Get("HX-Trigger") == "" {
+		t.Fatalf("create: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+
+	views, err := repo.ListSavedViews(ctx)
+	if err != nil || len(views) != 1 || !views[0].Notify || views[0].Filter.Min.Cents != 50000 {
+		t.Fatalf("views = %+v, %v", views, err)
+	}
+	id := strconv.FormatInt(views[0].ID, 10)
+
+	// The view is linked from the navigation
+	if rr := get("/spese"); !strings.Contains(rr.Body.String(), `href="/viste/spese?id=`+id+`"`) {
+		t.Errorf("index does not link the view")
+	}
+
+	rr = get("/viste/spese?id=" + id)
+	body := rr.Body.String()
+	if rr.Code != http.StatusOK || !strings.Contains(body, "Divano") || strings.Contains(body, "Lampada") || strings.Contains(body, "Bici") {
+		t.Fatalf("view expenses: status = %d, body = %s", rr.Code, body)
+	}
+	if !strings.Contains(body, "1 spese, totale €890,00") {
+		t.Errorf("view totals missing: %s", body)
+	}
+
+	if rr := post("/viste/notify", url.Values{"id": {id}, "notify": {"false"}}); rr.Code != http.StatusOK {
+		t.Fatalf("notify: status = %d", rr.Code)
+	}
+	if v, err := repo.GetSavedView(ctx, views[0].ID); err != nil || v.Notify {
+		t.Errorf("view after notify off = %+v, %v", v, err)
+	}
+	if rr := post("/viste/delete", url.Values{"id": {id}}); rr.Code != http.StatusOK {
+		t.Fatalf("delete: status = %d", rr.Code)
+	}
+	if rr := post("/viste/delete", url.Values{"id": {id}}); rr.Code != http.StatusNotFound {
+		t.Fatalf("delete again: status = %d, want 404", rr.Code)
+	}
+	if rr := get("/viste/spese?id=" + id); rr.Code != http.StatusNotFound {
+		t.Fatalf("deleted view: status = %d, want 404", rr.Code)
+	}
+}
+
+func TestAuth(t *testing.T) {
+	chdirRepoRoot(t)
+	hash, err := bcrypt.GenerateFromPassword([]byte("s3greta"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := NewServer(":0", fakeExp{}, fakeTax{}, fakeDash{}, fakeList{}, nil, nil)
+	srv.SetAuth(AuthConfig{PasswordHash: hash, SessionTTL: time.Hour, SecureCookie: true, PublicPaths: []string{"/healthz"}})
+
+	do := func(req *http.Request, cookie *http.Cookie) *httptest.ResponseRecorder {
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	login := func(password, next string) *httptest.ResponseRecorder {
+		form := url.Values{"password": {password}, "next": {next}}
+		req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return do(req, nil)
+	}
+
+	// Without a session pages redirect to the login, other requests get 401
+	rr := do(httptest.NewRequest(http.MethodGet, "/spese", nil), nil)
+	if rr.Code != http.StatusSeeOther || rr.Header().Get("Location") != "/login?next=%2Fspese" {
+		t.Fatalf("page: status = %d, location = %q", rr.Code, rr.Header().Get("Location"))
+	}
+	if rr := do(httptest.NewRequest(http.MethodPost, "/expenses", nil), nil); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("post: status = %d, want 401", rr.Code)
+	}
+	htmx := httptest.NewRequest(http.MethodPost, "/expenses", nil)
+	htmx.Header.Set("HX-Request", "true")
+	if rr := do(htmx, nil); rr.Code != http.StatusUnauthorized || rr.Header().Get("HX-Redirect") != "/login" {
+		t.Fatalf("htmx: status = %d, HX-Redirect = %q", rr.Code, rr.Header().Get("HX-Redirect"))
+	}
+
+	// Public paths, the login page and static assets stay open
+	for _, path := range []string{"/healthz", "/login", "/static/style.css"} {
+		if rr := do(httptest.NewRequest(http.MethodGet, path, nil), nil); rr.Code != http.StatusOK {
+			t.Errorf("%s: status = %d, want 200", path, rr.Code)
+		}
+	}
+	if rr := do(httptest.NewRequest(http.MethodGet, "/metrics", nil), nil); rr.Code != http.StatusSeeOther {
+		t.Errorf("/metrics not listed as public: status = %d, want 303", rr.Code)
+	}
+
+	if rr := login("sbagliata", "/spese"); rr.Code != http.StatusUnauthorized || len(rr.Result().Cookies()) != 0 {
+		t.Fatalf("wrong password: status = %d", rr.Code)
+	}
+	// Redirects stay on this site
+	if rr := login("s3greta", "//evil.example"); rr.Header().Get("Location") != "/" {
+		t.Errorf("foreign next: location = %q, want /", rr.Header().Get("Location"))
+	}
+	rr = login("s3greta", "/spese")
+	if rr.Code != http.StatusSeeOther || rr.Header().Get("Location") != "/spese" {
+		t.Fatalf("login: status = %d, location = %q", rr.Code, rr.Header().Get("Location"))
+	}
+	var session *http.Cookie
+	for _, c := range rr.Result().Cookies() {
+		if c.Name == sessionCookie {
+			session = c
+		}
+	}
+	if session == nil || !session.HttpOnly || !session.Secure || session.SameSite != http.SameSiteLaxMode {
+		t.Fatalf("session cookie = %+v", session)
+	}
+	if rr := do(httptest.NewRequest(http.MethodGet, "/spese", nil), session); rr.Code != http.StatusOK {
+		t.Fatalf("with session: status = %d, want 200", rr.Code)
+	}
+
+	// A tampered or expired session is refused
+	forged := &http.Cookie{Name: sessionCookie, Value: "9999999999." + strings.SplitN(session.Value, ".", 2)[1]}
+	if rr := do(httptest.NewRequest(http.MethodGet, "/spese", nil), forged); rr.Code != http.StatusSeeOther {
+		t.Errorf("forged session: status = %d, want 303", rr.Code)
+	}
+	srv.auth.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if rr := do(httptest.NewRequest(http.MethodGet, "/spese", nil), session); rr.Code != http.StatusSeeOther {
+		t.Errorf("expired session: status = %d, want 303", rr.Code)
+	}
+	srv.auth.now = time.Now
+
+	// Scripts can send the password as Basic auth
+	req := httptest.NewRequest(http.MethodGet, "/api/categories", nil)
+	req.SetBasicAuth("", "s3greta")
+	if rr := do(req, nil); rr.Code != http.StatusOK {
+		t.Errorf("basic auth: status = %d, want 200", rr.Code)
+	}
+	if rr := do(httptest.NewRequest(http.MethodGet, "/api/categories", nil), nil); rr.Code != http.StatusUnauthorized || rr.Header().Get("WWW-Authenticate") == "" {
+		t.Errorf("api without auth: status = %d, want 401 with a challenge", rr.Code)
+	}
+
+	rr = do(httptest.NewRequest(http.MethodPost, "/logout", nil), session)
+	if rr.Code != http.StatusSeeOther || len(rr.Result().Cookies()) != 1 || rr.Result().Cookies()[0].MaxAge >= 0 {
+		t.Errorf("logout: status = %d, cookies = %v", rr.Code, rr.Result().Cookies())
+	}
+}
+
+type fakeSandbox struct{ requested []int }
+
+func (f *fakeSandbox) CopyRecentRows(ctx context.Context, n int) (int, error) {
+	f.requested = append(f.requested, n)
+	return min(n, 3), nil
+}
+
+func TestSheetSandbox(t *testing.T) {
+	chdirRepoRoot(t)
+	srv := NewServer(":0", fakeExp{}, fakeTax{}, fakeDash{}, fakeList{}, nil, nil)
+
+	rr := httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/sandbox-fogli", nil))
+	if rr.Code != http.StatusNotImplemented {
+		t.Fatalf("without sandbox: status = %d, want 501", rr.Code)
+	}
+
+	sb := &fakeSandbox{}
+	srv.SetSheetSandbox(sb)
+	rr = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/sandbox-fogli", nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "Foglio di prova") {
+		t.Fatalf("page: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+
+	copyRows := func(rows string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/sandbox-fogli/copy", strings.NewReader(url.Values{"rows": {rows}}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	for _, rows := range []string{"0", "1001", "tante"} {
+		if rr := copyRows(rows); rr.Code != http.StatusBadRequest {
+			t.Errorf("rows=%s: status = %d, want 400", rows, rr.Code)
+		}
+	}
+	rr = copyRows("50")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "Righe copiate nel foglio di prova: 3") {
+		t.Fatalf("copy: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	if len(sb.requested) != 1 || sb.requested[0] != 50 {
+		t.Errorf("requested = %v, want [50]", sb.requested)
+	}
+}
+
+func TestHandleHousehold(t *testing.T) {
+	chdirRepoRoot(t)
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	adapter := adapters.NewSQLiteAdapter(repo, services.NewExpenseService(repo))
+	srv := NewServer(":0", adapter, fakeTax{}, fakeDash{}, adapter, adapter, nil)
+	ctx := context.Background()
+
+	post := func(path string, form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		return rr
+	}
+
+	if rr := post("/famiglia/create", url.Values{"name": {" "}}); rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("empty name: status = %d, want 422", rr.Code)
+	}
+	for _, name := range []string{"Anna", "Bruno"} {
+		if rr := post("/famiglia/create", url.Values{"name": {name}}); rr.Code != http.StatusOK || rr.Header().Get("HX-Trigger") == "" {
+			t.Fatalf("create %s: status = %d, body = %s", name, rr.Code, rr.Body.String())
+		}
+	}
+	if rr := post("/famiglia/create", url.Values{"name": {"anna"}}); rr.Code != http.StatusInternalServerError {
+		t.Fatalf("duplicate name: status = %d, want 500", rr.Code)
+	}
+	users, err := repo.ListUsers(ctx)
+	if err != nil || len(users) != 2 {
+		t.Fatalf("users = %+v, %v", users, err)
+	}
+	anna, bruno := users[0].ID, users[1].ID
+
+	for _, e := range []core.Expense{
+		{Date: core.NewDate(2031, 5, 2), Description: "Spesa", Amount: core.Money{Cents: 10000}, Primary: "Casa", Secondary: "Cibo", PaidBy: anna},
+		{Date: core.NewDate(2031, 5, 3), Description: "Cinema", Amount: core.Money{Cents: 2000}, Primary: "Svago", Secondary: "Cinema", PaidBy: bruno},
+		{Date: core.NewDate(2031, 5, 4), Description: "Caffè", Amount: core.Money{Cents: 500}, Primary: "Svago", Secondary: "Bar"},
+	} {
+		if _, err := repo.Append(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rr := get("/famiglia?year=2031&month=5")
+	body := rr.Body.String()
+	if rr.Code != http.StatusOK || !strings.Contains(body, "Bruno deve €40,00 a Anna") {
+		t.Fatalf("settlement: status = %d, body = %s", rr.Code, body)
+	}
+	if !strings.Contains(body, "escluse dai conti: €5,00") {
+		t.Errorf("unassigned total missing: %s", body)
+	}
+
+	// The payer is set from the expense form and offered on the forms
+	if rr := post("/expenses", url.Values{"description": {"Pane"}, "amount": {"3"}, "primary": {"Casa"}, "secondary": {"Cibo"}, "paid_by": {"x"}}); rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("bad payer: status = %d, want 422", rr.Code)
+	}
+	payer := strconv.FormatInt(bruno, 10)
+	if rr := post("/expenses", url.Values{"description": {"Pane"}, "amount": {"3"}, "primary": {"Casa"}, "secondary": {"Cibo"}, "paid_by": {payer}}); rr.Code != http.StatusOK {
+		t.Fatalf("create expense: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	if body := get("/ui/form/expense").Body.String(); !strings.Contains(body, `name="paid_by"`) {
+		t.Errorf("expense form has no payer: %s", body)
+	}
+
+	// The dashboard filters by payer
+	if body := get("/").Body.String(); !strings.Contains(body, `id="dashboard-user"`) {
+		t.Errorf("dashboard has no payer filter")
+	}
+	body = get("/ui/dashboard/stat-pills?user=" + payer).Body.String()
+	if !strings.Contains(body, "Spese pagate") || !strings.Contains(body, "€3,00") {
+		t.Errorf("stat pills for payer = %s", body)
+	}
+	body = get("/ui/dashboard/categories?period=month&user=" + strconv.FormatInt(anna, 10)).Body.String()
+	if strings.Contains(body, "Casa") {
+		t.Errorf("categories for a payer with no expenses this month = %s", body)
+	}
+
+	// Removing a member keeps their expenses with no payer
+	if rr := post("/famiglia/delete", url.Values{"id": {strconv.FormatInt(anna, 10)}}); rr.Code != http.StatusOK {
+		t.Fatalf("delete: status = %d", rr.Code)
+	}
+	if rr := post("/famiglia/delete", url.Values{"id": {strconv.FormatInt(anna, 10)}}); rr.Code != http.StatusNotFound {
+		t.Fatalf("delete again: status = %d, want 404", rr.Code)
+	}
+	body = get("/ui/household-settlement?year=2031&month=5").Body.String()
+	if !strings.Contains(body, "escluse dai conti: €105,00") || !strings.Contains(body, "Conti in pari") {
+		t.Errorf("settlement after delete = %s", body)
+	}
+}
+
+func TestHandleMetrics_Outbound(t *testing.T) {
+	chdirRepoRoot(t)
+	srv := NewServer(":0", fakeExp{}, fakeTax{}, fakeDash{}, fakeList{}, nil, nil)
+	srv.SetOutboundStats(func() []httpclient.Stats {
+		return []httpclient.Stats{{Name: "sheets", Requests: 3, Errors: 1, Duration: 1500 * time.Millisecond}}
+	})
+
+	rr := httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rr.Body.String()
+	for _, want := range []string{
+		`outbound_requests_total{integration="sheets"} 3`,
+		`outbound_errors_total{integration="sheets"} 1`,
+		`outbound_request_seconds_total{integration="sheets"} 1.5`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics lack %q:\n%s", want, body)
+		}
+	}
+}
+
+// Expense writes are counted by operation and outcome, labeled with the
+// configured backend
+func TestHandleMetrics_EntryOutcomes(t *testing.T) {
+	chdirRepoRoot(t)
+	metrics := func(srv *Server) string {
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		return rr.Body.String()
+	}
+	post := func(srv *Server, form string) {
+		req := httptest.NewRequest(http.MethodPost, "/expenses", strings.NewReader(form))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		srv.Handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	srv := NewServer(":0", fakeExp{}, fakeTax{}, fakeDash{}, fakeList{}, nil, nil)
+	srv.SetBackendName("sheets")
+	post(srv, "day=2&month=3&description=ok&amount=1.23&primary=A&secondary=X")
+	post(srv, "day=2&month=3&description=ok&amount=4.56&primary=A&secondary=X")
+	post(srv, "description=x&amount=abc&primary=A&secondary=X")
+	body := metrics(srv)
+	for _, want := range []string{
+		"# TYPE expense_operations_total counter",
+		`expense_operations_total{backend="sheets",operation="create",outcome="success"} 2`,
+		`expense_operations_total{backend="sheets",operation="create",outcome="validation_error"} 1`,
+		`http_requests_total{code="200",method="POST",route="/expenses"} 2`,
+		`http_request_duration_seconds_count{method="POST",route="/expenses"} 3`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics lack %q:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "expenses_total") {
+		t.Errorf("metrics still report expenses_total:\n%s", body)
+	}
+
+	failing := NewServer(":0", fakeExpErr{}, fakeTax{}, fakeDash{}, fakeList{}, nil, nil)
+	post(failing, "day=2&month=3&description=ok&amount=1.23&primary=A&secondary=X")
+	if want := `expense_operations_total{backend="sheets",operation="create",outcome="storage_error"} 1`; !strings.Contains(metrics(failing), want) {
+		t.Errorf("metrics lack %q", want)
+	}
+}
+
+// With SQLite the queries are timed by name, the sync queue reports how
+// far behind it is and the database its size and last maintenance
+func TestHandleMetrics_SQLite(t *testing.T) {
+	chdirRepoRoot(t)
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	adapter := adapters.NewSQLiteAdapter(repo, services.NewExpenseService(repo))
+	srv := NewServer(":0", adapter, fakeTax{}, fakeDash{}, adapter, adapter, adapter)
+	repo.ObserveQueries(srv.ObserveQuery)
+
+	req := httptest.NewRequest(http.MethodPost, "/expenses", strings.NewReader("day=2&month=3&description=ok&amount=1.23&primary=A&secondary=X"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	srv.Handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	rr := httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rr.Body.String()
+	for _, want := range []string{
+		`sqlite_query_duration_seconds_count{query="CreateExpense"} 1`,
+		`sqlite_query_duration_seconds_count{query="EnqueueSync"} 1`,
+		"# TYPE sync_queue_lag_seconds gauge",
+		"# TYPE sqlite_database_size_bytes gauge",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics lack %q:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "sqlite_checkpoint_duration_seconds") {
+		t.Error("checkpoint duration reported before any maintenance")
+	}
+
+	// The first maintenance switches to incremental vacuum, later ones
+	// only vacuum the free pages
+	ctx := context.Background()
+	if err := repo.EnableWAL(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for i, wantConverted := range []bool{true, false} {
+		res, err := repo.Maintain(ctx)
+		if err != nil || res.Converted != wantConverted || res.CheckpointBusy {
+			t.Fatalf("maintenance %d = %+v, %v", i, res, err)
+		}
+	}
+	stats, err := repo.DatabaseStats(ctx)
+	if err != nil || stats.SizeBytes == 0 || stats.FreelistBytes != 0 || stats.LastMaintenance == nil {
+		t.Fatalf("database stats = %+v, %v", stats, err)
+	}
+	rr = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, want := range []string{"sqlite_checkpoint_duration_seconds ", "sqlite_maintenance_last_run_timestamp_seconds ", "sqlite_wal_size_bytes "} {
+		if !strings.Contains(rr.Body.String(), want) {
+			t.Errorf("metrics lack %q after maintenance", want)
+		}
+	}
+}
+
+// A request continues the caller's trace down to its queries, and the sync
+// item it enqueues carries the trace on to the sheet write
+func TestTracing_RequestToSyncQueue(t *testing.T) {
+	chdirRepoRoot(t)
+	spans := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	adapter := adapters.NewSQLiteAdapter(repo, services.NewExpenseService(repo))
+	srv := NewServer(":0", adapter, fakeTax{}, fakeDash{}, adapter, adapter, adapter)
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodPost, "/expenses", strings.NewReader("day=2&month=3&description=ok&amount=1.23&primary=A&secondary=X"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	srv.Handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	var server, query bool
+	for _, span := range spans.Ended() {
+		if span.SpanContext().TraceID().String() != traceID {
+			continue
+		}
+		switch span.Name() {
+		case "/expenses":
+			server = true
+		case "sqlite CreateExpense":
+			query = true
+		}
+	}
+	if !server || !query {
+		t.Errorf("expected the request and query spans in trace %s, got %d spans", traceID, len(spans.Ended()))
+	}
+
+	items, err := repo.DequeueSyncBatch(context.Background(), 10)
+	if err != nil || len(items) != 1 {
+		t.Fatalf("expected one sync item, got %d (%v)", len(items), err)
+	}
+	ctx := storage.SyncTraceContext(context.Background(), items[0])
+	if got := trace.SpanContextFromContext(ctx).TraceID().String(); got != traceID {
+		t.Errorf("sync item continues trace %q, want %s", got, traceID)
+	}
+}
+
+func TestWriteOutcome(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want string
+	}{
+		{nil, outcomeSuccess},
+		{fmt.Errorf("wrap: %w", core.ErrMonthClosed), outcomeValidationError},
+		{core.ErrVersionConflict, outcomeValidationError},
+		{sql.ErrNoRows, outcomeValidationError},
+		{context.DeadlineExceeded, outcomeStorageError},
+	} {
+		if got := writeOutcome(tc.err); got != tc.want {
+			t.Errorf("writeOutcome(%v) = %q, want %q", tc.err, got, tc.want)
+		}
+	}
+}
+
+// The in-memory backend serves the expense pages without a database
+func TestMemoryBackend_CreateListDelete(t *testing.T) {
+	chdirRepoRoot(t)
+	adapter := adapters.NewMemoryAdapter([]core.CategoryGroup{{Primary: "Casa", Secondaries: []string{"Spesa"}}})
+	srv := NewServer(":0", adapter, adapter, adapter, adapter, adapter, adapter)
+	year := time.Now().Year()
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/expenses", strings.NewReader("day=2&month=3&description=Pane&amount=3.50&primary=Casa&secondary=Spesa"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	srv.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("create: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	monthURL := "/ui/month-expenses?year=" + strconv.Itoa(year) + "&month=3"
+	rr = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, monthURL, nil))
+	if !strings.Contains(rr.Body.String(), "Pane") {
+		t.Fatalf("month expenses missing the new expense: %s", rr.Body.String())
+	}
+	overview, err := adapter.ReadMonthOverview(context.Background(), year, 3)
+	if err != nil || overview.Total.Cents != 350 {
+		t.Fatalf("overview = %+v, %v", overview, err)
+	}
+
+	list, _ := adapter.ListExpensesWithID(context.Background(), year, 3)
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/expenses/delete", strings.NewReader("id="+list[0].ID))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	srv.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("delete: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, monthURL, nil))
+	if strings.Contains(rr.Body.String(), "Pane") {
+		t.Fatalf("deleted expense still listed: %s", rr.Body.String())
+	}
+}
+
+func TestHandleCategories(t *testing.T) {
+	chdirRepoRoot(t)
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	adapter := adapters.NewSQLiteAdapter(repo, services.NewExpenseService(repo))
+	srv := NewServer(":0", adapter, fakeTax{}, fakeDash{}, adapter, adapter, nil)
+	ctx := context.Background()
+
+	do := func(method, path string, form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	post := func(path string, form url.Values) *httptest.ResponseRecorder {
+		return do(http.MethodPost, path, form)
+	}
+
+	if rr := post("/categorie/create", url.Values{"primary": {"Animali"}, "secondary": {"Veterinario"}}); rr.Code != http.StatusOK || rr.Header().Get("HX-Trigger") == "" {
+		t.Fatalf("create: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	if rr := post("/categorie/create", url.Values{"primary": {"Animali"}, "secondary": {"Veterinario"}}); rr.Code != http.StatusConflict {
+		t.Fatalf("duplicate: status = %d, want 409", rr.Code)
+	}
+	if rr := post("/categorie/create", url.Values{"primary": {" "}}); rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("empty name: status = %d, want 422", rr.Code)
+	}
+
+	id, err := repo.Append(ctx, core.Expense{Date: core.NewDate(2031, 5, 2), Description: "Vaccino", Amount: core.Money{Cents: 6000}, Primary: "Animali", Secondary: "Veterinario"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expenseID, _ := strconv.ParseInt(id, 10, 64)
+	category := func() (string, string) {
+		t.Helper()
+		e, err := repo.GetExpense(ctx, expenseID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return e.PrimaryCategory, e.SecondaryCategory
+	}
+
+	if body := do(http.MethodGet, "/categorie", nil).Body.String(); !strings.Contains(body, "Veterinario") {
+		t.Fatalf("page misses the new category: %s", body)
+	}
+
+	// Renaming and moving carry the expense along, with its history
+	if rr := post("/categorie/rename", url.Values{"primary": {"Animali"}, "secondary": {"Veterinario"}, "name": {"Vet"}}); rr.Code != http.StatusOK {
+		t.Fatalf("rename: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	if rr := post("/categorie/move", url.Values{"primary": {"Animali"}, "secondary": {"Vet"}, "parent": {"Casa"}}); rr.Code != http.StatusOK {
+		t.Fatalf("move: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	if p, s := category(); p != "Casa" || s != "Vet" {
+		t.Fatalf("expense category = %s / %s, want Casa / Vet", p, s)
+	}
+	versions, err := repo.ListExpenseVersions(ctx, expenseID)
+	if err != nil || len(versions) != 3 {
+		t.Fatalf("versions = %d, %v; want 3", len(versions), err)
+	}
+	if rr := post("/categorie/move", url.Values{"primary": {"Casa"}, "secondary": {"Vet"}, "parent": {"Nessuna"}}); rr.Code != http.StatusNotFound {
+		t.Fatalf("move to missing parent: status = %d, want 404", rr.Code)
+	}
+
+	// Used categories are archived rather than deleted
+	if rr := post("/categorie/delete", url.Values{"primary": {"Casa"}, "secondary": {"Vet"}}); rr.Code != http.StatusConflict {
+		t.Fatalf("delete used: status = %d, want 409", rr.Code)
+	}
+	if rr := post("/categorie/archive", url.Values{"primary": {"Casa"}, "secondary": {"Vet"}, "archived": {"true"}}); rr.Code != http.StatusOK {
+		t.Fatalf("archive: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	secondaries, err := repo.GetSecondariesByPrimary(ctx, "Casa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range secondaries {
+		if s == "Vet" {
+			t.Fatalf("archived category still offered: %v", secondaries)
+		}
+	}
+	if body := do(http.MethodGet, "/ui/categories-list", nil).Body.String(); !strings.Contains(body, "archiviata") {
+		t.Errorf("list does not show the archived category: %s", body)
+	}
+
+	if rr := post("/categorie/rename", url.Values{"primary": {"Animali"}, "name": {"Casa"}}); rr.Code != http.StatusConflict {
+		t.Fatalf("rename onto existing: status = %d, want 409", rr.Code)
+	}
+	if rr := post("/categorie/delete", url.Values{"primary": {"Animali"}}); rr.Code != http.StatusOK {
+		t.Fatalf("delete unused: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+
+	// The JSON API
+	api := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	if rr := api(http.MethodPost, "/api/v1/categories", `{"primary": "Libri"}`); rr.Code != http.StatusCreated {
+		t.Fatalf("API create: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	if rr := api(http.MethodPatch, "/api/v1/categories", `{"primary": "Libri", "name": "Letture", "archived": true}`); rr.Code != http.StatusNoContent {
+		t.Fatalf("API update: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	if rr := api(http.MethodGet, "/api/v1/categories", ""); strings.Contains(rr.Body.String(), "Letture") {
+		t.Errorf("archived category listed: %s", rr.Body.String())
+	}
+	if rr := api(http.MethodDelete, "/api/v1/categories?primary=Casa&secondary=Vet", ""); rr.Code != http.StatusConflict {
+		t.Fatalf("API delete used: status = %d, want 409", rr.Code)
+	}
+	if rr := api(http.MethodDelete, "/api/v1/categories?primary=Letture", ""); rr.Code != http.StatusNoContent {
+		t.Fatalf("API delete: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+
+	// Other backends have no category management
+	mem := adapters.NewMemoryAdapter(nil)
+	memSrv := NewServer(":0", mem, mem, mem, mem, mem, mem)
+	rr := httptest.NewRecorder()
+	memSrv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/categorie", nil))
+	if rr.Code != http.StatusNotImplemented {
+		t.Errorf("memory backend: status = %d, want 501", rr.Code)
+	}
+}
+
+func TestCategoryCleanup(t *testing.T) {
+	chdirRepoRoot(t)
+	path := filepath.Join(t.TempDir(), "spese.db")
+	repo, err := storage.NewSQLiteRepository(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	adapter := adapters.NewSQLiteAdapter(repo, services.NewExpenseService(repo))
+	srv := NewServer(":0", adapter, fakeTax{}, fakeDash{}, adapter, adapter, nil)
+	ctx := context.Background()
+
+	do := func(method, path string, form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// An unused category, an expense left under a removed primary category
+	// and a secondary category whose primary is gone
+	if err := repo.CreateCategory(ctx, "Animali", "Veterinario"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.CreateCategory(ctx, "Casa", "Giardino"); err != nil {
+		t.Fatal(err)
+	}
+	id, err := repo.Append(ctx, core.Expense{Date: core.NewDate(2031, 5, 2), Description: "Tosaerba", Amount: core.Money{Cents: 9000}, Primary: "Esterni", Secondary: "Giardino"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expenseID, _ := strconv.ParseInt(id, 10, 64)
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	res, err := db.Exec(`INSERT INTO secondary_categories (name, primary_category_id) VALUES ('Sperduta', 99999)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	detachedID, _ := res.LastInsertId()
+
+	cleanup, err := repo.CategoryCleanup(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var unused, orphan bool
+	for _, u := range cleanup.Unused {
+		// The primary category stands for its unused secondaries
+		if u.Primary == "Animali" {
+			unused = unused || u.Secondary == ""
+			if u.Secondary != "" {
+				t.Errorf("secondary of an unused primary listed on its own: %+v", u)
+			}
+		}
+	}
+	var target int64
+	for _, o := range cleanup.Orphans {
+		if o.Primary == "Esterni" && o.Secondary == "Giardino" {
+			orphan = o.Expenses == 1 && o.SuggestedPrimary == "Casa"
+		}
+	}
+	for _, tg := range cleanup.Targets {
+		if tg.Primary == "Casa" && tg.Secondary == "Giardino" {
+			target = tg.ID
+		}
+	}
+	if !unused || !orphan || target == 0 {
+		t.Fatalf("cleanup = %+v; want Animali unused and Esterni / Giardino orphan", cleanup)
+	}
+	if len(cleanup.Detached) != 1 || cleanup.Detached[0].Name != "Sperduta" {
+		t.Fatalf("detached = %+v", cleanup.Detached)
+	}
+
+	rr := do(http.MethodGet, "/categorie/pulizia", nil)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "Esterni /") || !strings.Contains(rr.Body.String(), "Sperduta") {
+		t.Fatalf("page: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	if !strings.Contains(rr.Body.String(), fmt.Sprintf(`value="%d" selected`, target)) {
+		t.Errorf("suggested target not preselected: %s", rr.Body.String())
+	}
+
+	// Merging moves the expense, with its history, and the pair disappears
+	if rr := do(http.MethodPost, "/categorie/merge", url.Values{"primary": {"Esterni"}, "secondary": {"Giardino"}, "into": {"0"}}); rr.Code != http.StatusBadRequest {
+		t.Fatalf("merge without target: status = %d, want 400", rr.Code)
+	}
+	if rr := do(http.MethodPost, "/categorie/merge", url.Values{"primary": {"Casa"}, "secondary": {"Giardino"}, "into": {strconv.FormatInt(target, 10)}}); rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("merge into itself: status = %d, want 422", rr.Code)
+	}
+	rr = do(http.MethodPost, "/categorie/merge", url.Values{"primary": {"Esterni"}, "secondary": {"Giardino"}, "into": {strconv.FormatInt(target, 10)}})
+	if rr.Code != http.StatusOK || rr.Header().Get("HX-Trigger") == "" || !strings.Contains(rr.Body.String(), "1 spese") {
+		t.Fatalf("merge: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	e, err := repo.GetExpense(ctx, expenseID)
+	if err != nil || e.PrimaryCategory != "Casa" || e.SecondaryCategory != "Giardino" {
+		t.Fatalf("merged expense = %+v, %v", e, err)
+	}
+	if versions, err := repo.ListExpenseVersions(ctx, expenseID); err != nil || len(versions) != 2 {
+		t.Errorf("versions = %d, %v; want 2", len(versions), err)
+	}
+
+	if rr := do(http.MethodPost, "/categorie/detached/delete", url.Values{"id": {strconv.FormatInt(detachedID, 10)}}); rr.Code != http.StatusOK {
+		t.Fatalf("delete detached: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodPost, "/categorie/detached/delete", url.Values{"id": {strconv.FormatInt(target, 10)}}); rr.Code != http.StatusNotFound {
+		t.Fatalf("delete attached category: status = %d, want 404", rr.Code)
+	}
+
+	body := do(http.MethodGet, "/ui/category-cleanup", nil).Body.String()
+	if strings.Contains(body, "Esterni /") || strings.Contains(body, "Sperduta") {
+		t.Errorf("cleaned up categories still listed: %s", body)
+	}
+}
+
+func TestAPISyncErrors(t *testing.T) {
+	chdirRepoRoot(t)
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	adapter := adapters.NewSQLiteAdapter(repo, services.NewExpenseService(repo))
+	srv := NewServer(":0", adapter, fakeTax{}, fakeDash{}, adapter, adapter, nil)
+	ctx := context.Background()
+
+	if _, err := repo.AppendAndEnqueueSync(ctx, core.Expense{Date: core.NewDate(2031, 3, 5), Description: "Spesa", Amount: core.Money{Cents: 1000}, Primary: "Casa", Secondary: "Spesa"}); err != nil {
+		t.Fatal(err)
+	}
+	items, err := repo.DequeueSyncBatch(ctx, 1)
+	if err != nil || len(items) != 1 {
+		t.Fatalf("queue = %+v, %v", items, err)
+	}
+	if err := repo.RecordSyncError(ctx, items[0], "auth", "sheets credentials rejected"); err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		return rr
+	}
+	rr := get("/api/v1/sync/errors")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	var resp apiSyncErrors
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Counts["auth"] != 1 || resp.Counts["quota"] != 0 || len(resp.Errors) != 1 || resp.Errors[0].Class != "auth" {
+		t.Errorf("report = %+v", resp)
+	}
+	expenseID := strconv.FormatInt(items[0].ExpenseID, 10)
+	if err := json.Unmarshal(get("/api/v1/sync/errors?expense_id="+expenseID+"0").Body.Bytes(), &resp); err != nil || len(resp.Errors) != 0 {
+		t.Errorf("errors of another expense = %+v, %v", resp.Errors, err)
+	}
+	if rr := get("/api/v1/sync/errors?expense_id=x"); rr.Code != http.StatusBadRequest {
+		t.Errorf("bad expense_id: status = %d, want 400", rr.Code)
+	}
+}
+
+func TestIntegrationBanner(t *testing.T) {
+	chdirRepoRoot(t)
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	adapter := adapters.NewSQLiteAdapter(repo, services.NewExpenseService(repo))
+	srv := NewServer(":0", adapter, fakeTax{}, fakeDash{}, adapter, adapter, nil)
+	health := services.NewIntegrationHealth()
+	srv.SetIntegrationHealth(health)
+	ctx := context.Background()
+
+	if _, err := repo.AppendAndEnqueueSync(ctx, core.Expense{Date: core.NewDate(2031, 3, 5), Description: "Spesa", Amount: core.Money{Cents: 1000}, Primary: "Casa", Secondary: "Spesa"}); err != nil {
+		t.Fatal(err)
+	}
+	items, err := repo.DequeueSyncBatch(ctx, 1)
+	if err != nil || len(items) != 1 {
+		t.Fatalf("queue = %+v, %v", items, err)
+	}
+	if err := repo.DeferSyncItem(ctx, items[0].ID, "credentials rejected", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		return rr
+	}
+	if rr := get("/integrazioni"); rr.Code != http.StatusOK || strings.Contains(rr.Body.String(), "integration-banner") {
+		t.Fatalf("healthy page: status = %d, banner shown = %v", rr.Code, strings.Contains(rr.Body.String(), "integration-banner"))
+	}
+
+	health.MarkDegraded(errors.New("oauth2: invalid_grant"))
+	rr := get("/integrazioni")
+	body := rr.Body.String()
+	if rr.Code != http.StatusOK || !strings.Contains(body, "integration-banner") || !strings.Contains(body, "invalid_grant") {
+		t.Errorf("degraded page: status = %d, body = %s", rr.Code, body)
+	}
+	if body := get("/healthz").Body.String(); !strings.Contains(body, `"degraded"`) {
+		t.Errorf("healthz = %s, want the degraded sheets", body)
+	}
+
+	rr = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/integrazioni/retry", nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "1 spese") {
+		t.Errorf("retry: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	if items, err := repo.DequeueSyncBatch(ctx, 1); err != nil || len(items) != 1 {
+		t.Errorf("queue after retry = %+v, %v, want the item ready", items, err)
+	}
+
+	health.MarkHealthy()
+	if strings.Contains(get("/integrazioni").Body.String(), "integration-banner") {
+		t.Error("banner shown after the credentials were accepted")
+	}
+}
+
+func TestSyncStatus(t *testing.T) {
+	chdirRepoRoot(t)
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	adapter := adapters.NewSQLiteAdapter(repo, services.NewExpenseService(repo))
+	srv := NewServer(":0", adapter, fakeTax{}, fakeDash{}, adapter, adapter, nil)
+	ctx := context.Background()
+
+	for _, desc := range []string{"Bolletta luce", "Affitto"} {
+		if _, err := repo.AppendAndEnqueueSync(ctx, core.Expense{Date: core.NewDate(2031, 3, 5), Description: desc, Amount: core.Money{Cents: 4250}, Primary: "Casa", Secondary: "Utenze"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	items, err := repo.DequeueSyncBatch(ctx, 2)
+	if err != nil || len(items) != 2 {
+		t.Fatalf("queue = %+v, %v", items, err)
+	}
+	failed := items[0]
+	if err := repo.MarkSyncFailed(ctx, failed.ID, "googleapi: Error 400: invalid range"); err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		return rr
+	}
+	retry := func(form string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/sync/retry", strings.NewReader(form))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := get("/sync/status")
+	body := rr.Body.String()
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status page: status = %d, body = %s", rr.Code, body)
+	}
+	for _, want := range []string{"Bolletta luce", "Affitto", "05/03/2031", "42,50", "invalid range", "Riprova ora", `class="sync-badge"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("status page misses %q", want)
+		}
+	}
+	if failedAt := strings.Index(body, "Fallita"); failedAt < 0 || failedAt > strings.Index(body, "In attesa") {
+		t.Error("failed item not listed first")
+	}
+	if !strings.Contains(get("/recurrent").Body.String(), `class="sync-badge"`) {
+		t.Error("badge not shown on the other pages")
+	}
+
+	if rr := retry("id=abc"); rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("invalid id: status = %d", rr.Code)
+	}
+	if rr := retry("id=999"); rr.Code != http.StatusNotFound {
+		t.Errorf("missing item: status = %d", rr.Code)
+	}
+	rr = retry(fmt.Sprintf("id=%d", failed.ID))
+	if rr.Code != http.StatusOK || rr.Header().Get("HX-Trigger") == "" {
+		t.Fatalf("retry: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	open, err := repo.OpenSyncItems(ctx, 10)
+	if err != nil || len(open) != 2 || open[0].Status != "pending" || open[0].Attempts != 0 || open[0].LastError != "" {
+		t.Errorf("queue after retry = %+v, %v", open, err)
+	}
+	if strings.Contains(get("/ui/sync-status").Body.String(), "Fallita") || strings.Contains(get("/sync/status").Body.String(), `class="sync-badge"`) {
+		t.Error("retried item still shown as failed")
+	}
+	if rr := retry(""); rr.Code != http.StatusOK {
+		t.Errorf("retry all: status = %d", rr.Code)
+	}
+
+	memSrv := NewServer(":0", &fakeExp{}, fakeTax{}, fakeDash{}, nil, nil, nil)
+	rr = httptest.NewRecorder()
+	memSrv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/sync/status", nil))
+	if rr.Code != http.StatusNotImplemented {
+		t.Errorf("without a queue: status = %d", rr.Code)
+	}
+}
+
+func TestAPIDeadLetters(t *testing.T) {
+	chdirRepoRoot(t)
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	adapter := adapters.NewSQLiteAdapter(repo, services.NewExpenseService(repo))
+	srv := NewServer(":0", adapter, fakeTax{}, fakeDash{}, adapter, adapter, nil)
+	ctx := context.Background()
+
+	for _, desc := range []string{"Bolletta luce", "Affitto", "Spesa"} {
+		if _, err := repo.AppendAndEnqueueSync(ctx, core.Expense{Date: core.NewDate(2031, 3, 5), Description: desc, Amount: core.Money{Cents: 4250}, Primary: "Casa", Secondary: "Utenze"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	items, err := repo.DequeueSyncBatch(ctx, 2)
+	if err != nil || len(items) != 2 {
+		t.Fatalf("queue = %+v, %v", items, err)
+	}
+	for _, it := range items {
+		if err := repo.MarkSyncFailed(ctx, it.ID, "googleapi: Error 400: invalid range"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	api := func(method, path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, httptest.NewRequest(method, path, nil))
+		return rr
+	}
+	var letters []apiDeadLetter
+	rr := api(http.MethodGet, "/api/v1/sync/dead-letters")
+	if err := json.Unmarshal(rr.Body.Bytes(), &letters); err != nil || len(letters) != 2 {
+		t.Fatalf("dead letters: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	if dl := letters[0]; dl.Attempts != 1 || dl.LastError == "" || dl.Date != "2031-03-05" || dl.AmountCents != 4250 || dl.Operation != "sync" {
+		t.Errorf("dead letter = %+v", dl)
+	}
+	if rr := api(http.MethodGet, "/api/v1/sync/dead-letters?limit=1"); !strings.Contains(rr.Body.String(), `"id":`+strconv.FormatInt(letters[0].ID, 10)) || strings.Count(rr.Body.String(), `"id"`) != 1 {
+		t.Errorf("limited dead letters = %s", rr.Body.String())
+	}
+
+	if rr := api(http.MethodPost, fmt.Sprintf("/api/v1/sync/dead-letters/%d/reprocess", letters[0].ID)); rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"reprocessed":1`) {
+		t.Errorf("reprocess one: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	if rr := api(http.MethodPost, "/api/v1/sync/dead-letters/999/reprocess"); rr.Code != http.StatusNotFound {
+		t.Errorf("reprocess a missing item: status = %d", rr.Code)
+	}
+	if rr := api(http.MethodPost, "/api/v1/sync/dead-letters/abc/reprocess"); rr.Code != http.StatusNotFound {
+		t.Errorf("reprocess an invalid id: status = %d", rr.Code)
+	}
+	if rr := api(http.MethodPost, "/api/v1/sync/dead-letters/reprocess"); rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"reprocessed":1`) {
+		t.Errorf("reprocess all: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	if rr := api(http.MethodGet, "/api/v1/sync/dead-letters"); rr.Body.String() != "[]\n" && rr.Body.String() != "[]" {
+		t.Errorf("dead letters after reprocessing = %s", rr.Body.String())
+	}
+	if stats, err := repo.GetSyncQueueStats(ctx); err != nil || stats.PendingCount != 3 || stats.FailedCount != 0 {
+		t.Errorf("queue = %+v, %v, want every item pending", stats, err)
+	}
+	if rr := api(http.MethodPost, "/api/v1/sync/dead-letters"); rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST list: status = %d", rr.Code)
+	}
+}
+
+func TestAPICategoryMappings(t *testing.T) {
+	chdirRepoRoot(t)
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	adapter := adapters.NewSQLiteAdapter(repo, services.NewExpenseService(repo))
+	srv := NewServer(":0", adapter, fakeTax{}, fakeDash{}, adapter, adapter, nil)
+	ctx := context.Background()
+
+	api := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// The mapping formerly compiled in is seeded by the migration
+	rr := api(http.MethodGet, "/api/v1/category-mappings", "")
+	var resp apiCategoryMappings
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil || rr.Code != http.StatusOK {
+		t.Fatalf("list: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	if !slices.Contains(resp.Items, apiCategoryMapping{SheetName: "Mutuo", Primary: "Casa"}) {
+		t.Errorf("seeded mappings = %+v, want Mutuo in Casa", resp.Items)
+	}
+
+	if rr := api(http.MethodPut, "/api/v1/category-mappings", `{"sheet_name": "Palestra", "primary": "Salute"}`); rr.Code != http.StatusNoContent {
+		t.Fatalf("set: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	if primary, ok, err := repo.MappedPrimary(ctx, "Palestra"); err != nil || !ok || primary != "Salute" {
+		t.Errorf("MappedPrimary(Palestra) = %q, %v, %v", primary, ok, err)
+	}
+	if rr := api(http.MethodPut, "/api/v1/category-mappings", `{"sheet_name": "Palestra", "primary": "Nessuna"}`); rr.Code != http.StatusNotFound {
+		t.Errorf("unknown primary: status = %d, want 404", rr.Code)
+	}
+	if rr := api(http.MethodPut, "/api/v1/category-mappings", `{"sheet_name": "", "primary": "Salute"}`); rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("empty sheet name: status = %d, want 422", rr.Code)
+	}
+
+	// Renaming the primary category keeps the mapping pointing at it
+	if err := repo.RenameCategory(ctx, "Salute", "", "Benessere"); err != nil {
+		t.Fatal(err)
+	}
+	if primary, _, _ := repo.MappedPrimary(ctx, "Palestra"); primary != "Benessere" {
+		t.Errorf("mapping after rename = %q, want Benessere", primary)
+	}
+
+	if rr := api(http.MethodDelete, "/api/v1/category-mappings?sheet_name=Palestra", ""); rr.Code != http.StatusNoContent {
+		t.Fatalf("delete: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	if rr := api(http.MethodDelete, "/api/v1/category-mappings?sheet_name=Palestra", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("delete again: status = %d, want 404", rr.Code)
+	}
+	if rr := api(http.MethodPost, "/api/v1/category-mappings", "{}"); rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST: status = %d, want 405", rr.Code)
+	}
+}
+
+func TestValidateAmount(t *testing.T) {
+	chdirRepoRoot(t)
+	mem := adapters.NewMemoryAdapter(nil)
+	srv := NewServer(":0", mem, mem, mem, mem, mem, mem)
+	srv.SetDemoMode(true) // validating stores nothing, so demos allow it
+
+	tests := []struct {
+		amount    string
+		valid     bool
+		cents     int64
+		formatted string
+	}{
+		{"1.234,56", true, 123456, "€1234,56"},
+		{"12,5", true, 1250, "€12,50"},
+		{"12.50", true, 1250, "€12,50"},
+		{"1.2.3", false, 0, ""},
+		{"0", false, 0, ""},
+		{"", false, 0, ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/api/validate/amount", strings.NewReader(url.Values{"amount": {tt.amount}}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, req)
+		var got amountValidation
+		if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil || rr.Code != http.StatusOK {
+			t.Fatalf("%q: status = %d, body = %s", tt.amount, rr.Code, rr.Body.String())
+		}
+		if got.Valid != tt.valid || got.Cents != tt.cents || got.Formatted != tt.formatted || got.Valid == (got.Error != "") {
+			t.Errorf("%q: got %+v", tt.amount, got)
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/validate/amount", nil))
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET: status = %d, want 405", rr.Code)
+	}
+}
+
+func TestTagReport(t *testing.T) {
+	chdirRepoRoot(t)
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	adapter := adapters.NewSQLiteAdapter(repo, services.NewExpenseService(repo))
+	srv := NewServer(":0", adapter, fakeTax{}, fakeDash{}, adapter, adapter, nil)
+	ctx := context.Background()
+
+	api := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// A trip spanning two months and two categories
+	var refs []string
+	for _, body := range []string{
+		`{"date": "2025-07-30", "description": "Traghetto", "amount": "89.00", "primary": "Trasporti", "secondary": "Traghetto", "tags": ["Vacanze  2025"]}`,
+		`{"date": "2025-08-02", "description": "Cena al porto", "amount": "45.50", "primary": "Svago", "secondary": "Ristoranti", "tags": ["vacanze 2025", "cene"]}`,
+		`{"date": "2025-08-03", "description": "Spesa", "amount": "30.00", "primary": "Casa", "secondary": "Spesa"}`,
+	} {
+		rr := api(http.MethodPost, "/api/v1/expenses", body)
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("create expense: status = %d, body = %s", rr.Code, rr.Body.String())
+		}
+		refs = append(refs, rr.Header().Get("Location"))
+	}
+	if _, err := repo.AppendIncome(ctx, core.Income{
+		Date: core.NewDate(2025, 8, 10), Description: "Rimborso traghetto", Amount: core.Money{Cents: 2000},
+		Category: "Rimborsi", Tags: []string{"vacanze 2025"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	rr := api(http.MethodGet, refs[1], "")
+	var expense apiExpense
+	if err := json.Unmarshal(rr.Body.Bytes(), &expense); err != nil || rr.Code != http.StatusOK {
+		t.Fatalf("get expense: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	if !slices.Equal(expense.Tags, []string{"vacanze 2025", "cene"}) {
+		t.Errorf("expense tags = %q, want [vacanze 2025 cene]", expense.Tags)
+	}
+
+	rr = api(http.MethodGet, "/api/tags", "")
+	var names []string
+	if err := json.Unmarshal(rr.Body.Bytes(), &names); err != nil || !slices.Equal(names, []string{"vacanze 2025", "cene"}) {
+		t.Errorf("autocompletion = %s, want vacanze 2025 then cene", rr.Body.String())
+	}
+
+	rr = api(http.MethodGet, "/api/v1/tags/report?from=2025-07&to=2025-08&tag=Vacanze%202025", "")
+	var report apiTagReport
+	if err := json.Unmarshal(rr.Body.Bytes(), &report); err != nil || rr.Code != http.StatusOK {
+		t.Fatalf("report: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	want := apiTagSummary{
+		Tag: "vacanze 2025", SpentCents: 13450, ReceivedCents: 2000, Entries: 3,
+		Months: []apiTagMonth{
+			{Month: "2025-07", SpentCents: 8900, Entries: 1},
+			{Month: "2025-08", SpentCents: 4550, ReceivedCents: 2000, Entries: 2},
+		},
+	}
+	if len(report.Tags) != 1 || !reflect.DeepEqual(report.Tags[0], want) {
+		t.Errorf("report = %+v, want %+v", report.Tags, want)
+	}
+
+	// Without a tag every tag is reported, largest spending first
+	if err := json.Unmarshal(api(http.MethodGet, "/api/v1/tags/report?from=2025-01&to=2025-12", "").Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Tags) != 2 || report.Tags[0].Tag != "vacanze 2025" || report.Tags[1].Tag != "cene" {
+		t.Errorf("all tags = %+v", report.Tags)
+	}
+	if rr := api(http.MethodGet, "/api/v1/tags/report?from=2025-09&to=2025-08", ""); rr.Code != http.StatusBadRequest {
+		t.Errorf("reversed period: status = %d, want 400", rr.Code)
+	}
+
+	rr = api(http.MethodGet, "/ui/tag-report?from=2025-07&to=2025-08&tag=vacanze+2025", "")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "luglio 2025") || !strings.Contains(rr.Body.String(), "€134,50") {
+		t.Errorf("tag report partial: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+
+	// Saved views filter on tags too
+	if _, err := repo.CreateSavedView(ctx, core.SavedView{Name: "Vacanze", Filter: core.ExpenseFilter{Tag: "vacanze 2025"}}); err != nil {
+		t.Fatal(err)
+	}
+	views, err := repo.ListSavedViews(ctx)
+	if err != nil || len(views) != 1 {
+		t.Fatalf("saved views = %+v, %v", views, err)
+	}
+	matched, err := repo.ListViewExpenses(ctx, views[0].Filter, 10)
+	if err != nil || len(matched) != 2 {
+		t.Errorf("view expenses = %+v, %v, want the two tagged ones", matched, err)
+	}
+
+	// Deleting an expense drops it from the report
+	if rr := api(http.MethodDelete, refs[0], ""); rr.Code != http.StatusNoContent {
+		t.Fatalf("delete: status = %d", rr.Code)
+	}
+	if err := json.Unmarshal(api(http.MethodGet, "/api/v1/tags/report?from=2025-07&to=2025-08&tag=vacanze+2025", "").Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Tags) != 1 || report.Tags[0].SpentCents != 4550 {
+		t.Errorf("report after delete = %+v", report.Tags)
+	}
+}
+
+func TestReceipts(t *testing.T) {
+	chdirRepoRoot(t)
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	adapter := adapters.NewSQLiteAdapter(repo, services.NewExpenseService(repo))
+	srv := NewServer(":0", adapter, fakeTax{}, fakeDash{}, adapter, adapter, adapter)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		return rr
+	}
+	upload := func(id, name string, content []byte) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		_ = mw.WriteField("id", id)
+		part, err := mw.CreateFormFile("file", name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = part.Write(content)
+		_ = mw.Close()
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/spese/ricevuta/upload", &body)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		srv.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := get("/ui/expense-receipt?id=1"); rr.Code != http.StatusNotImplemented {
+		t.Errorf("without storage: status = %d, want 501", rr.Code)
+	}
+	blobs, err := receipts.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.SetReceiptStore(blobs, 1<<20)
+
+	ref, err := adapter.Append(context.Background(), core.Expense{Date: core.NewDate(2031, 5, 4), Description: "Ferramenta", Amount: core.Money{Cents: 1290}, Primary: "Casa", Secondary: "Manutenzione"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, 600, 900))
+	var photo bytes.Buffer
+	if err := png.Encode(&photo, img); err != nil {
+		t.Fatal(err)
+	}
+
+	if rr := upload(ref, "script.pdf", []byte("<script>alert(1)</script>")); rr.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("HTML upload: status = %d, want 415", rr.Code)
+	}
+	if rr := upload(ref, "big.pdf", append([]byte("%PDF-1.7\n"), make([]byte, 1<<20)...)); rr.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("large upload: status = %d, want 413", rr.Code)
+	}
+	if rr := upload("999999", "scontrino.png", photo.Bytes()); rr.Code != http.StatusNotFound {
+		t.Errorf("unknown expense: status = %d, want 404", rr.Code)
+	}
+
+	rr := upload(ref, `C:\foto\scontrino.png`, photo.Bytes())
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `hx-swap-oob="true"`) || !strings.Contains(rr.Body.String(), "thumbnail=1") {
+		t.Fatalf("upload: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+
+	// The month list shows the thumbnail
+	if rr := get("/ui/month-expenses?year=2031&month=5"); !strings.Contains(rr.Body.String(), "/spese/ricevuta?id="+ref+"&amp;thumbnail=1") {
+		t.Errorf("month list without thumbnail: %s", rr.Body.String())
+	}
+
+	rr = get("/spese/ricevuta?id=" + ref)
+	if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != core.ReceiptPNG || !bytes.Equal(rr.Body.Bytes(), photo.Bytes()) {
+		t.Fatalf("download: status = %d, type = %q", rr.Code, rr.Header().Get("Content-Type"))
+	}
+	if got := rr.Header().Get("Content-Disposition"); got != `inline; filename=scontrino.png` {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+	rr = get("/spese/ricevuta?id=" + ref + "&thumbnail=1")
+	if thumb, err := jpeg.DecodeConfig(rr.Body); err != nil || thumb.Height != receipts.ThumbnailSize {
+		t.Errorf("thumbnail: %+v, %v", thumb, err)
+	}
+
+	// A PDF replaces the photo, whose files are deleted
+	id, _ := strconv.ParseInt(ref, 10, 64)
+	old, err := repo.ExpenseReceipt(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rr := upload(ref, "fattura.pdf", []byte("%PDF-1.7\n")); rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "PDF") {
+		t.Fatalf("replace: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	if _, err := blobs.Get(context.Background(), old.Key); !errors.Is(err, receipts.ErrNotFound) {
+		t.Errorf("replaced photo still stored: %v", err)
+	}
+	if rr := get("/spese/ricevuta?id=" + ref); !strings.HasPrefix(rr.Header().Get("Content-Disposition"), "attachment") {
+		t.Errorf("PDF Content-Disposition = %q", rr.Header().Get("Content-Disposition"))
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/spese/ricevuta/delete", strings.NewReader("id="+ref))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "Carica") {
+		t.Fatalf("delete: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	if rr := get("/spese/ricevuta?id=" + ref); rr.Code != http.StatusNotFound {
+		t.Errorf("download after delete: status = %d, want 404", rr.Code)
+	}
+}
+
+type recordingExp struct{ saved []core.Expense }
+
+func (f *recordingExp) Append(ctx context.Context, e core.Expense) (string, error) {
+	f.saved = append(f.saved, e)
+	return "mem:" + strconv.Itoa(len(f.saved)), nil
+}
+
+func TestCreateExpenseYearSelection(t *testing.T) {
+	chdirRepoRoot(t)
+	ew := &recordingExp{}
+	srv := NewServer(":0", ew, fakeTax{cats: []string{"A"}, subs: []string{"X"}}, fakeDash{}, fakeList{}, nil, nil)
+	year := time.Now().Year()
+
+	post := func(body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/expenses", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		srv.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	form := func() string {
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/ui/form/expense", nil))
+		return rr.Body.String()
+	}
+	last := func() core.Expense { return ew.saved[len(ew.saved)-1] }
+
+	if strings.Contains(form(), `aria-label="Anno"`) {
+		t.Error("year selector shown without year selection")
+	}
+	srv.SetEntryDefaults(0, true)
+	if body := form(); !strings.Contains(body, fmt.Sprintf(`<option value="%d">`, year-1)) {
+		t.Error("year selector missing from the form")
+	}
+
+	// A December expense recorded in January
+	if rr := post(fmt.Sprintf("day=31&month=12&year=%d&description=ok&amount=1&primary=A&secondary=X", year-1)); rr.Code != 200 {
+		t.Fatalf("status = %d", rr.Code)
+	}
+	if got := last().Date; !got.Equal(core.NewDate(year-1, 12, 31).Time) {
+		t.Errorf("date = %v, want December 31st of last year", got.Format("2006-01-02"))
+	}
+	// Without a year field the default date's year is kept
+	if rr := post("day=2&month=3&description=ok&amount=1&primary=A&secondary=X"); rr.Code != 200 {
+		t.Fatalf("status = %d", rr.Code)
+	}
+	if got := last().Date.Year(); got != year {
+		t.Errorf("year = %d, want %d", got, year)
+	}
+	for _, bad := range []string{strconv.Itoa(year + 2), "1999", "abc"} {
+		if rr := post("day=1&month=1&year=" + bad + "&description=ok&amount=1&primary=A&secondary=X"); rr.Code != http.StatusUnprocessableEntity {
+			t.Errorf("year %s: status = %d, want 422", bad, rr.Code)
+		}
+	}
+}
+
+// fakeGoogle serves the OAuth token endpoint and the values API of
+// Google Sheets, recording the ranges written.
+type fakeGoogle struct {
+	mu     sync.Mutex
+	writes []string
+}
+
+func (f *fakeGoogle) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.URL.Path == "/token" {
+		_, _ = w.Write([]byte(`{"access_token":"token","token_type":"Bearer","expires_in":3600}`))
+		return
+	}
+	_, rng, _ := strings.Cut(r.URL.Path, "/values/")
+	if r.Method == http.MethodPut {
+		f.mu.Lock()
+		f.writes = append(f.writes, rng)
+		f.mu.Unlock()
+	}
+	_, _ = w.Write([]byte(`{}`))
+}
+
+// newFakeGoogleClient returns a Google Sheets client configured from the
+// environment, as in production, whose requests all go to f.
+func newFakeGoogleClient(t *testing.T, f *fakeGoogle) *google.Client {
+	t.Helper()
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pemKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	creds, err := json.Marshal(map[string]string{
+		"type":           "service_account",
+		"client_email":   "spese@example.iam.gserviceaccount.com",
+		"private_key_id": "key",
+		"private_key":    string(pemKey),
+		"token_uri":      srv.URL + "/token",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GOOGLE_SPREADSHEET_ID", "sheet-id")
+	t.Setenv("GOOGLE_SERVICE_ACCOUNT_JSON", string(creds))
+	t.Setenv("GOOGLE_SHEET_NAME", "Expenses")
+
+	target, _ := url.Parse(srv.URL)
+	base := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		r = r.Clone(r.Context())
+		r.URL.Scheme, r.URL.Host = target.Scheme, target.Host
+		return http.DefaultTransport.RoundTrip(r)
+	})}
+	c, err := google.NewFromEnvWithClient(context.Background(), base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestCreateExpenseNewYearNight(t *testing.T) {
+	chdirRepoRoot(t)
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	adapter := adapters.NewSQLiteAdapter(repo, services.NewExpenseService(repo))
+	srv := NewServer(":0", adapter, fakeTax{cats: []string{"A"}, subs: []string{"X"}}, fakeDash{}, adapter, adapter, adapter)
+	srv.SetEntryDefaults(4, false)
+	// 1 January at 00:30 of the year of the sheets client
+	year := time.Now().Year()
+	srv.now = func() time.Time { return time.Date(year, time.January, 1, 0, 30, 0, 0, time.Local) }
+	ctx := context.Background()
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/expenses", strings.NewReader("description=Cenone&amount=45&primary=A&secondary=X"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	srv.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	stored, err := repo.ListExpenses(ctx, year-1, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != 1 || !stored[0].Date.Equal(core.NewDate(year-1, 12, 31).Time) {
+		t.Fatalf("stored = %+v, want one expense of December 31st of last year", stored)
+	}
+
+	f := &fakeGoogle{}
+	client := newFakeGoogleClient(t, f)
+	n, err := services.NewSyncProcessor(repo, client, client, services.DefaultSyncProcessorConfig()).SyncNow(ctx)
+	if err != nil || n != 1 {
+		t.Fatalf("synced %d, %v; want 1", n, err)
+	}
+	sheet := fmt.Sprintf("%d Expenses!", year-1)
+	if len(f.writes) == 0 {
+		t.Fatal("nothing written to the sheets")
+	}
+	for _, rng := range f.writes {
+		if !strings.HasPrefix(rng, sheet) {
+			t.Errorf("wrote %s, want the sheet %q", rng, sheet)
+		}
+	}
+}
+
+func TestCreateInPastYearClosedMonth(t *testing.T) {
+	chdirRepoRoot(t)
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	adapter := adapters.NewSQLiteAdapter(repo, services.NewExpenseService(repo))
+	srv := NewServer(":0", adapter, fakeTax{cats: []string{"A"}, subs: []string{"X"}}, fakeDash{}, adapter, adapter, adapter)
+	ctx := context.Background()
+	last := time.Now().Year() - 1
+
+	if err := repo.CloseMonth(ctx, fmt.Sprintf("%d-12", last)); err != nil {
+		t.Fatal(err)
+	}
+	post := func(path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		srv.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/ui/form/expense", nil))
+	if !strings.Contains(rr.Body.String(), fmt.Sprintf(`data-closed-months="%d-12"`, last)) {
+		t.Error("form does not know the closed month")
+	}
+
+	if rr := post("/expenses", fmt.Sprintf("day=31&month=12&year=%d&description=Cenone&amount=80&primary=A&secondary=X", last)); rr.Code != http.StatusConflict {
+		t.Errorf("closed month: status = %d, want 409", rr.Code)
+	}
+	if rr := post("/expenses", fmt.Sprintf("day=30&month=11&year=%d&description=Regali&amount=80&primary=A&secondary=X", last)); rr.Code != 200 {
+		t.Fatalf("open month: status = %d: %s", rr.Code, rr.Body.String())
+	}
+	items, err := repo.ListExpensesWithID(ctx, last, 11)
+	if err != nil || len(items) != 1 || items[0].Expense.Description != "Regali" {
+		t.Errorf("expenses of November %d = %+v, %v", last, items, err)
+	}
+
+	if rr := post("/incomes", fmt.Sprintf("day=31&month=12&year=%d&description=Tredicesima&amount=900&category=Stipendio", last)); rr.Code != http.StatusConflict {
+		t.Errorf("income in closed month: status = %d, want 409", rr.Code)
+	}
+}
+
+func TestRecurrentPauseResumeSkip(t *testing.T) {
+	chdirRepoRoot(t)
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	adapter := adapters.NewSQLiteAdapter(repo, services.NewExpenseService(repo))
+	srv := NewServer(":0", adapter, fakeTax{}, fakeDash{}, adapter, adapter, adapter)
+	ctx := context.Background()
+
+	id, err := repo.CreateRecurrentExpense(ctx, core.RecurrentExpenses{
+		StartDate:   core.NewDate(time.Now().Year()+1, 1, 15),
+		Every:       core.Monthly,
+		Description: "Palestra",
+		Amount:      core.Money{Cents: 4500},
+		Primary:     "Salute",
+		Secondary:   "Palestra",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	post := func(action string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, fmt.Sprintf("/recurrent/%s?id=%d", action, id), nil))
+		return rr
+	}
+	list := func() string {
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/ui/recurrent-expenses-list", nil))
+		return rr.Body.String()
+	}
+
+	if rr := post("pause"); rr.Code != http.StatusNoContent || !strings.Contains(rr.Header().Get("HX-Trigger"), "recurrent:updated") {
+		t.Fatalf("pause: %d %q", rr.Code, rr.Header().Get("HX-Trigger"))
+	}
+	if body := list(); !strings.Contains(body, "In pausa") || !strings.Contains(body, "/recurrent/resume?id=") {
+		t.Fatalf("paused recurrent not shown as paused:\n%s", body)
+	}
+	if got := adapter.GetRecurrentMonthlyTotal(ctx); got != 0 {
+		t.Errorf("monthly total with the only recurrent paused = %d, want 0", got)
+	}
+
+	if rr := post("resume"); rr.Code != http.StatusNoContent {
+		t.Fatalf("resume: %d", rr.Code)
+	}
+	if rr := post("skip"); rr.Code != http.StatusNoContent {
+		t.Fatalf("skip: %d", rr.Code)
+	}
+	want := fmt.Sprintf("Prossima: 15/02/%d", time.Now().Year()+1)
+	if body := list(); !strings.Contains(body, want) {
+		t.Fatalf("expected %q after skipping:\n%s", want, body)
+	}
+
+	rr := httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/recurrent/skip?id=999", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("unknown id: expected 404, got %d", rr.Code)
+	}
+	rr = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/recurrent/pause?id=%d", id), nil))
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET: expected 405, got %d", rr.Code)
+	}
+}
+
+func TestMonthNav(t *testing.T) {
+	now := time.Date(2031, 5, 20, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		year, month  int
+		prevY, prevM int
+		nextY, nextM int
+		label        string
+		current      bool
+	}{
+		{2031, 5, 2031, 4, 2031, 6, "maggio 2031", true},
+		{2030, 1, 2029, 12, 2030, 2, "gennaio 2030", false},
+		{2029, 12, 2029, 11, 2030, 1, "dicembre 2029", false},
+	}
+	for _, c := range cases {
+		nav := newMonthNav("/", c.year, c.month, now)
+		if nav.PrevYear != c.prevY || nav.PrevMonth != c.prevM || nav.NextYear != c.nextY || nav.NextMonth != c.nextM {
+			t.Errorf("%d-%02d: prev %d-%02d next %d-%02d", c.year, c.month, nav.PrevYear, nav.PrevMonth, nav.NextYear, nav.NextMonth)
+		}
+		if nav.Label != c.label || nav.Current != c.current {
+			t.Errorf("%d-%02d: label %q current %v", c.year, c.month, nav.Label, nav.Current)
+		}
+		if len(nav.Months) != 12 || !nav.Months[c.month-1].Selected {
+			t.Errorf("%d-%02d: month not selected in picker", c.year, c.month)
+		}
+	}
+
+	for query, want := range map[string][2]int{
+		"year=2019&month=12": {2019, 12},
+		"year=2019&month=13": {2019, int(time.Now().Month())},
+		"year=0&month=3":     {time.Now().Year(), 3},
+	} {
+		year, month := parseYearMonth(httptest.NewRequest(http.MethodGet, "/?"+query, nil))
+		if year != want[0] || month != want[1] {
+			t.Errorf("%s: got %d-%d, want %d-%d", query, year, month, want[0], want[1])
+		}
+	}
+}
+
+func TestIndexMonthNavigation(t *testing.T) {
+	chdirRepoRoot(t)
+	srv := NewServer(":0", fakeExp{}, fakeTax{}, fakeDash{}, fakeList{}, nil, nil)
+
+	for path, wants := range map[string][]string{
+		"/spese?year=2019&month=1": {
+			"Panoramica di gennaio 2019",
+			`hx-get="/ui/month-total?year=2019&amp;month=1"`,
+			`href="/spese?year=2018&month=12"`,
+			`href="/spese?year=2019&month=2"`,
+			`<option value="1" selected>gennaio</option>`,
+			"Mese corrente",
+		},
+		"/entrate?year=2020&month=12": {
+			"Panoramica Entrate di dicembre 2020",
+			`hx-get="/ui/income-month-incomes?year=2020&amp;month=12"`,
+			`href="/entrate?year=2021&month=1"`,
+		},
+	} {
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s: status %d", path, rr.Code)
+		}
+		for _, want := range wants {
+			if !strings.Contains(rr.Body.String(), want) {
+				t.Errorf("%s: missing %q", path, want)
+			}
+		}
+	}
+}
+
+func TestRecurrentIncomesCRUD(t *testing.T) {
+	chdirRepoRoot(t)
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	adapter := adapters.NewSQLiteAdapter(repo, services.NewExpenseService(repo))
+	srv := NewServer(":0", adapter, fakeTax{}, fakeDash{}, adapter, adapter, adapter)
+	ctx := context.Background()
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		srv.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	form := "amount=2500&description=Stipendio&category=Stipendio+E&start_date=2031-01-27&repetition_type=monthly&interval=1"
+	if rr := send(http.MethodPost, "/recurrent-incomes/create", form); rr.Code != http.StatusCreated || !strings.Contains(rr.Header().Get("HX-Trigger"), "recurrent-income:created") {
+		t.Fatalf("create: %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := send(http.MethodPost, "/recurrent-incomes/create", "amount=2500&description=Bonus&start_date=2031-01-27&repetition_type=monthly"); rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("create without category: expected 422, got %d", rr.Code)
+	}
+
+	body := send(http.MethodGet, "/ui/recurrent-incomes-list", "").Body.String()
+	for _, want := range []string{"Stipendio", "Mensile", "Prossima: 27/01/2031", "€2500,00"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("list missing %q:\n%s", want, body)
+		}
+	}
+
+	incomes, err := repo.GetRecurrentIncomes(ctx)
+	if err != nil || len(incomes) != 1 {
+		t.Fatalf("stored incomes = %v, %v", incomes, err)
+	}
+	id := incomes[0].ID
+	if rr := send(http.MethodGet, fmt.Sprintf("/ui/recurrent-income-form?id=%d", id), ""); !strings.Contains(rr.Body.String(), `name="version" value="1"`) {
+		t.Errorf("edit form without version:\n%s", rr.Body.String())
+	}
+
+	update := strings.Replace(form, "amount=2500", "amount=2600", 1) + "&version=1"
+	if rr := send(http.MethodPut, fmt.Sprintf("/recurrent-incomes/update?id=%d", id), update); rr.Code != http.StatusOK {
+		t.Fatalf("update: %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := send(http.MethodPut, fmt.Sprintf("/recurrent-incomes/update?id=%d", id), update); rr.Code != http.StatusConflict {
+		t.Errorf("stale update: expected 409, got %d", rr.Code)
+	}
+	if got, _ := repo.GetRecurrentIncomeByID(ctx, id); got.Amount.Cents != 260000 {
+		t.Errorf("amount after update = %d, want 260000", got.Amount.Cents)
+	}
+
+	if rr := send(http.MethodDelete, fmt.Sprintf("/recurrent-incomes/delete?id=%d", id), ""); rr.Code != http.StatusOK {
+		t.Fatalf("delete: %d", rr.Code)
+	}
+	if rr := send(http.MethodDelete, fmt.Sprintf("/recurrent-incomes/delete?id=%d", id), ""); rr.Code != http.StatusNotFound {
+		t.Errorf("second delete: expected 404, got %d", rr.Code)
+	}
+	if body := send(http.MethodGet, "/ui/recurrent-incomes-list", "").Body.String(); !strings.Contains(body, "Nessuna entrata ricorrente") {
+		t.Errorf("list after delete:\n%s", body)
+	}
+}
+
+func TestUpcomingCharges(t *testing.T) {
+	chdirRepoRoot(t)
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	adapter := adapters.NewSQLiteAdapter(repo, services.NewExpenseService(repo))
+	srv := NewServer(":0", adapter, fakeTax{}, fakeDash{}, adapter, adapter, adapter)
+	ctx := context.Background()
+
+	now := time.Now()
+	today := core.NewDate(now.Year(), int(now.Month()), now.Day())
+	if _, err := repo.CreateRecurrentExpense(ctx, core.RecurrentExpenses{
+		StartDate: today, Every: core.Weekly, Description: "Pulizie", Amount: core.Money{Cents: 1000}, Primary: "Casa", Secondary: "Servizi",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	pausedID, err := repo.CreateRecurrentExpense(ctx, core.RecurrentExpenses{
+		StartDate: today, Every: core.Daily, Description: "Giornale", Amount: core.Money{Cents: 150}, Primary: "Svago", Secondary: "Giornali",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.PauseRecurrentExpense(ctx, pausedID); err != nil {
+		t.Fatal(err)
+	}
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		return rr
+	}
+
+	rr := get("/api/v1/recurrents/upcoming?days=30")
+	var page apiPage[apiUpcomingCharge]
+	if err := json.Unmarshal(rr.Body.Bytes(), &page); err != nil || rr.Code != http.StatusOK {
+		t.Fatalf("upcoming: %d %s", rr.Code, rr.Body.String())
+	}
+	if page.Total != 5 || page.Items[0].Date != today.Format("2006-01-02") || page.Items[4].Date != today.AddDate(0, 0, 28).Format("2006-01-02") {
+		t.Fatalf("upcoming in 30 days = %+v", page)
+	}
+	if c := page.Items[1]; c.Description != "Pulizie" || c.AmountCents != 1000 || c.Primary != "Casa" {
+		t.Errorf("charge = %+v", c)
+	}
+	if rr := get("/api/v1/recurrents/upcoming?days=0"); rr.Code != http.StatusBadRequest {
+		t.Errorf("days=0: status = %d, want 400", rr.Code)
+	}
+
+	// 13 weekly charges in 90 days: the dashboard shows the first 8
+	body := get("/ui/dashboard/upcoming").Body.String()
+	for _, want := range []string{"Prossimi 90 giorni", "€130", "Casa / Servizi", "e altri 5 addebiti"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("upcoming partial lacks %q:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "Giornale") {
+		t.Errorf("paused recurrent listed as upcoming:\n%s", body)
+	}
+}
+
+func TestAPIJobs(t *testing.T) {
+	chdirRepoRoot(t)
+	srv := NewServer(":0", fakeExp{}, fakeTax{}, fakeDash{}, fakeList{}, nil, nil)
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		return rr
+	}
+	if rr := get("/api/v1/jobs"); rr.Code != http.StatusNotImplemented {
+		t.Fatalf("jobs without a runner: status = %d, want 501", rr.Code)
+	}
+
+	runner := services.NewJobRunner(nil, 1, 1)
+	if err := runner.Register(services.Job{
+		Name:        "receipt-cleanup",
+		Description: "Deletes old receipt files",
+		Every:       time.Hour,
+		Run:         func(context.Context) (string, error) { return "2 files", nil },
+	}); err != nil {
+		t.Fatal(err)
+	}
+	srv.SetJobRunner(runner)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go runner.Start(ctx)
+
+	var jobs []apiJob
+	deadline := time.Now().Add(time.Second)
+	for {
+		rr := get("/api/v1/jobs")
+		if err := json.Unmarshal(rr.Body.Bytes(), &jobs); err != nil {
+			t.Fatalf("jobs: %d %s", rr.Code, rr.Body.String())
+		}
+		if len(jobs) == 1 && jobs[0].LastRun != nil && jobs[0].LastRun.Status == "succeeded" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job did not run: %s", rr.Body.String())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if j := jobs[0]; j.Name != "receipt-cleanup" || j.Every != "1h0m0s" || j.LastRun.Result != "2 files" || j.LastRun.Source != "schedule" || j.LastRun.FinishedAt == nil {
+		t.Errorf("job = %+v, last run %+v", j, *j.LastRun)
+	}
+
+	var runs []apiJobRun
+	if rr := get("/api/v1/jobs/receipt-cleanup/runs"); json.Unmarshal(rr.Body.Bytes(), &runs) != nil || len(runs) != 1 {
+		t.Errorf("runs: %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := get("/api/v1/jobs/backup/runs"); rr.Code != http.StatusNotFound {
+		t.Errorf("runs of an unknown job: status = %d, want 404", rr.Code)
+	}
+	rr := httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/v1/jobs", nil))
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST: status = %d, want 405", rr.Code)
+	}
+}
+
+func TestAPIRunJob(t *testing.T) {
+	chdirRepoRoot(t)
+	srv := NewServer(":0", fakeExp{}, fakeTax{}, fakeDash{}, fakeList{}, nil, nil)
+	runner := services.NewJobRunner(nil, 1, 1)
+	release := make(chan struct{})
+	if err := runner.Register(services.Job{
+		Name:        "sheet-sync",
+		Description: "Writes the sync queue to Google Sheets now",
+		Run: func(context.Context) (string, error) {
+			<-release
+			return "4 expenses", nil
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	srv.SetJobRunner(runner)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go runner.Start(ctx)
+
+	post := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, path, nil))
+		return rr
+	}
+	var rr *httptest.ResponseRecorder
+	deadline := time.Now().Add(time.Second)
+	for rr = post("/api/v1/jobs/sheet-sync/run"); rr.Code == http.StatusServiceUnavailable && time.Now().Before(deadline); rr = post("/api/v1/jobs/sheet-sync/run") {
+		time.Sleep(5 * time.Millisecond)
+	}
+	var job apiJob
+	if err := json.Unmarshal(rr.Body.Bytes(), &job); err != nil || rr.Code != http.StatusAccepted || !(job.Queued || job.Running) || job.NextRun != nil {
+		t.Fatalf("run: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	if rr := post("/api/v1/jobs/sheet-sync/run"); rr.Code != http.StatusConflict {
+		t.Errorf("run while running: status = %d, want 409", rr.Code)
+	}
+	if rr := post("/api/v1/jobs/backup/run"); rr.Code != http.StatusNotFound {
+		t.Errorf("run of an unknown job: status = %d, want 404", rr.Code)
+	}
+	rr = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/jobs/sheet-sync/run", nil))
+	if rr.Code != http.StatusMethodNotAllowed || rr.Header().Get("Allow") != "POST" {
+		t.Errorf("GET run: status = %d, Allow = %q", rr.Code, rr.Header().Get("Allow"))
+	}
+
+	// The stream follows the run until it ends
+	ts := httptest.NewServer(srv.Handler)
+	defer ts.Close()
+	resp, err := http.Get(ts.URL + "/api/v1/jobs/sheet-sync/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); resp.StatusCode != http.StatusOK || ct != "text/event-stream" {
+		t.Fatalf("events: status = %d, content type = %q", resp.StatusCode, ct)
+	}
+	close(release)
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := strings.Split(strings.TrimSpace(string(body)), "\n\n")
+	last, ok := strings.CutPrefix(events[len(events)-1], "event: state\ndata: ")
+	if !ok || len(events) < 2 {
+		t.Fatalf("events = %q", body)
+	}
+	if err := json.Unmarshal([]byte(last), &job); err != nil || job.Running || job.Queued || job.LastRun == nil ||
+		job.LastRun.Source != "manual" || job.LastRun.Result != "4 expenses" {
+		t.Errorf("last event = %s", last)
+	}
+}
+
+func TestIntegrityReport(t *testing.T) {
+	chdirRepoRoot(t)
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	adapter := adapters.NewSQLiteAdapter(repo, services.NewExpenseService(repo))
+	srv := NewServer(":0", adapter, fakeTax{}, fakeDash{}, adapter, adapter, nil)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		return rr
+	}
+	if rr := get("/integrita"); rr.Code != http.StatusNotImplemented {
+		t.Fatalf("without a checker: status %d, want 501", rr.Code)
+	}
+
+	srv.SetIntegrityChecker(services.NewIntegrityChecker(repo, nil))
+	if _, err := repo.AppendAndEnqueueSync(context.Background(), core.Expense{Date: core.NewDate(2031, 3, 5), Description: "Cena", Amount: core.Money{Cents: 4250}, Primary: "Casa", Secondary: "Categoria inventata"}); err != nil {
+		t.Fatal(err)
+	}
+	rr := get("/integrita")
+	body := rr.Body.String()
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rr.Code, body)
+	}
+	for _, want := range []string{"Integrità dei dati", "Categoria sconosciuta", "Categoria inventata", "Google Sheets non è configurato"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("page missing %q", want)
+		}
+	}
+
+	rr = get("/ui/integrity")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `id="integrity-report"`) || strings.Contains(rr.Body.String(), "<html") {
+		t.Errorf("report fragment: status %d, body %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestCapabilities(t *testing.T) {
+	chdirRepoRoot(t)
+	get := func(srv *Server, path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		return rr
+	}
+
+	// A backend without IDs nor SQLite: every feature off, and the dashboard
+	// leaves out the recurrent expenses
+	srv := NewServer(":0", fakeExp{}, fakeTax{}, fakeDash{}, fakeList{}, nil, nil)
+	srv.SetBackendName("sheets")
+	rr := get(srv, "/api/capabilities")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	var got capabilities
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got != (capabilities{Backend: "sheets"}) {
+		t.Fatalf("unexpected capabilities %+v", got)
+	}
+	if body := get(srv, "/").Body.String(); strings.Contains(body, "/ui/dashboard/recurrents") {
+		t.Fatal("expected the recurrent expenses hidden from the dashboard")
+	}
+
+	path := filepath.Join(t.TempDir(), "spese.db")
+	repo, err := storage.NewSQLiteRepository(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	adapter := adapters.NewSQLiteAdapter(repo, services.NewExpenseService(repo))
+	srv = NewServer(":0", adapter, fakeTax{}, fakeDash{}, adapter, adapter, adapter)
+	rr = get(srv, "/api/capabilities")
+	got = capabilities{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got != (capabilities{Backend: "sqlite", Recurrents: true, Incomes: true, DeleteByID: true, Trash: true, Search: true, YearReport: true, Accounts: true}) {
+		t.Fatalf("unexpected capabilities %+v", got)
+	}
+	if body := get(srv, "/").Body.String(); !strings.Contains(body, "/ui/dashboard/recurrents") {
+		t.Fatal("expected the recurrent expenses on the dashboard")
+	}
+
+	rr = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/capabilities", nil))
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", rr.Code)
+	}
+}
+
+func TestEmailPreview(t *testing.T) {
+	chdirRepoRoot(t)
+	srv := NewServer(":0", fakeExp{}, fakeTax{}, fakeDash{}, fakeList{}, nil, nil)
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Host = "spese.example.com"
+		srv.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := get("/admin/email")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "/admin/email/preview?kind=digest") {
+		t.Fatalf("expected the list of emails, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	rr = get("/admin/email/preview?kind=sync_failed")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "http://spese.example.com/sync/status") {
+		t.Fatalf("expected the HTML email linking to the app, got %d: %s", rr.Code, rr.Body.String())
+	}
+	rr = get("/admin/email/preview?kind=budget_alert&format=text")
+	if rr.Code != http.StatusOK || !strings.HasPrefix(rr.Body.String(), "Oggetto: Spese: avviso su Cibo") {
+		t.Fatalf("expected the text email, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := get("/admin/email/preview?kind=weekly"); rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown email, got %d", rr.Code)
+	}
+}
+
+func TestMonthStatement(t *testing.T) {
+	chdirRepoRoot(t)
+	day := func(d int) core.Date { return core.Date{Time: time.Date(2031, 3, d, 0, 0, 0, 0, time.UTC)} }
+	list := fakeList{items: []core.Expense{
+		{Date: day(20), Description: "Spesa <Coop>", Amount: core.Money{Cents: 4250}, Primary: "Cibo", Secondary: "Supermercato"},
+		{Date: day(3), Description: "Affitto", Amount: core.Money{Cents: 75000}, Primary: "Casa", Secondary: "Affitto"},
+		{Date: day(25), Description: "Hotel", Amount: core.Money{Cents: 12000}, Primary: "Viaggi", Status: core.StatusPending},
+		{Date: day(12), Description: "Pizza", Amount: core.Money{Cents: 2750}, Primary: "Cibo", Secondary: "Ristorante"},
+	}}
+	srv := NewServer(":0", fakeExp{}, fakeTax{}, fakeDash{}, list, nil, nil)
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		return rr
+	}
+
+	rr := get("/print/2031/3")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rr.Code, rr.Body.String())
+	}
+	body := rr.Body.String()
+	for _, want := range []string{
+		"Estratto conto di marzo 2031",
+		"@media print",
+		"Spesa &lt;Coop&gt;",
+		"<td>Casa</td><td class=\"num\">€750,00</td><td class=\"num\">91%</td>",
+		"<td>Cibo</td><td class=\"num\">€70,00</td><td class=\"num\">8%</td>",
+		"totale €820,00",
+		"in attesa, esclusa dal totale",
+		"Firma",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("statement lacks %q:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "topbar") || strings.Contains(body, `<td>Viaggi</td><td class="num">`) {
+		t.Error("expected no navigation and card holds out of the summary")
+	}
+	if strings.Index(body, "Affitto") > strings.Index(body, "Pizza") {
+		t.Error("expected the expenses in date order")
+	}
+
+	for _, path := range []string{"/print/2031/13", "/print/anno/3"} {
+		if rr := get(path); rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", path, rr.Code)
+		}
+	}
+	if rr := get("/spese?year=2031&month=3"); !strings.Contains(rr.Body.String(), `href="/print/2031/3"`) {
+		t.Error("expected a link to the statement on the expenses page")
+	}
+}
+
+func TestObligationCalendar(t *testing.T) {
+	chdirRepoRoot(t)
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	adapter := adapters.NewSQLiteAdapter(repo, services.NewExpenseService(repo))
+	srv := NewServer(":0", adapter, fakeTax{}, fakeDash{}, adapter, adapter, adapter)
+	ctx := context.Background()
+
+	now := time.Now()
+	today := core.NewDate(now.Year(), int(now.Month()), now.Day())
+	if _, err := repo.CreateRecurrentExpense(ctx, core.RecurrentExpenses{
+		StartDate: today, Every: core.Weekly, Description: "Pulizie", Amount: core.Money{Cents: 1000}, Primary: "Casa", Secondary: "Servizi",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.CreateRecurrentIncome(ctx, core.RecurrentIncome{
+		StartDate: core.Date{Time: today.AddDate(0, 0, 3)}, Every: core.Monthly, Description: "Stipendio", Amount: core.Money{Cents: 100000}, Category: "Lavoro",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		return rr
+	}
+
+	// Charges on day 0 and 7, the salary on day 3: the balance dips
+	// below zero before the salary and closes at 1000 - 20
+	rr := get("/ui/dashboard/calendar?days=10")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("calendar: %d %s", rr.Code, rr.Body.String())
+	}
+	body := rr.Body.String()
+	for _, want := range []string{
+		"saldo -€10,00", "€1000,00 · saldo €990,00", "saldo €980,00",
+		"−€10,00", "Stipendio", "Casa / Servizi", "calendar-day__balance--negative",
+		"Entrate €1000,00 · addebiti €20,00",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("calendar lacks %q:\n%s", want, body)
+		}
+	}
+	if got := strings.Count(body, `class="calendar-day"`); got != 3 {
+		t.Errorf("calendar days = %d, want 3", got)
+	}
+	if rr := get("/ui/dashboard/calendar?days=0"); rr.Code != http.StatusBadRequest {
+		t.Errorf("days=0: status = %d, want 400", rr.Code)
+	}
+	if body := get("/").Body.String(); !strings.Contains(body, `hx-get="/ui/dashboard/calendar"`) {
+		t.Error("dashboard does not load the obligation calendar")
+	}
+}
+
+func TestAuditLog(t *testing.T) {
+	chdirRepoRoot(t)
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	adapter := adapters.NewSQLiteAdapter(repo, services.NewExpenseService(repo))
+	srv := NewServer(":0", adapter, fakeTax{}, fakeDash{}, adapter, adapter, adapter)
+	ctx := core.WithActor(context.Background(), "web:10.0.0.7")
+
+	now := time.Now()
+	idStr, err := repo.Append(ctx, core.Expense{
+		Date: core.NewDate(now.Year(), int(now.Month()), now.Day()), Description: "Spesa Esselunga",
+		Amount: core.Money{Cents: 4250}, Primary: "Casa", Secondary: "Spesa",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, _ := strconv.ParseInt(idStr, 10, 64)
+	if err := repo.UpdateExpenseAmount(core.WithActor(context.Background(), "sheets"), id, 5000); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.HardDeleteExpense(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		return rr
+	}
+
+	rr := get(fmt.Sprintf("/audit?entity=expense&id=%d", id))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("audit: %d %s", rr.Code, rr.Body.String())
+	}
+	body := rr.Body.String()
+	for _, want := range []string{
+		"Creazione", "Modifica", "Eliminazione", "web:10.0.0.7", "sheets",
+		"amount_cents</strong>: €42,50 → €50,00", "description</strong>: — → Spesa Esselunga",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("audit page lacks %q:\n%s", want, body)
+		}
+	}
+	if got := strings.Count(body, "<tr>"); got != 4 {
+		t.Errorf("audit rows = %d, want 3 and the header", got)
+	}
+	if body := get("/audit?entity=income").Body.String(); !strings.Contains(body, "Nessuna modifica nel periodo") {
+		t.Error("income filter lists expense changes")
+	}
+	if rr := get("/audit?from=2026-13-01"); rr.Code != http.StatusBadRequest {
+		t.Errorf("bad date: status = %d, want 400", rr.Code)
+	}
+
+	// Retention removes the entries older than the cutoff
+	n, err := repo.PruneAuditLog(context.Background(), time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Errorf("pruned %d entries, want 3", n)
+	}
+}
+
+func TestTrash(t *testing.T) {
+	chdirRepoRoot(t)
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	adapter := adapters.NewSQLiteAdapter(repo, services.NewExpenseService(repo))
+	srv := NewServer(":0", adapter, fakeTax{}, fakeDash{}, adapter, adapter, adapter)
+	srv.SetTrashRetention(30)
+	ctx := context.Background()
+
+	now := time.Now()
+	today := core.NewDate(now.Year(), int(now.Month()), now.Day())
+	expenseID, err := adapter.Append(ctx, core.Expense{
+		Date: today, Description: "Cena pizzeria", Amount: core.Money{Cents: 3600}, Primary: "Svago", Secondary: "Ristoranti",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	incomeID, err := repo.AppendIncome(ctx, core.Income{
+		Date: today, Description: "Rimborso biglietto", Amount: core.Money{Cents: 1200}, Category: "Rimborsi",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := adapter.DeleteExpense(ctx, expenseID); err != nil {
+		t.Fatal(err)
+	}
+	if err := adapter.DeleteIncome(ctx, incomeID); err != nil {
+		t.Fatal(err)
+	}
+
+	expenses, _ := repo.ListExpensesWithID(ctx, now.Year(), int(now.Month()))
+	incomes, _ := repo.ListIncomesWithID(ctx, now.Year(), int(now.Month()))
+	if len(expenses) != 0 || len(incomes) != 0 {
+		t.Fatalf("trashed entries still listed: %d expenses, %d incomes", len(expenses), len(incomes))
+	}
+	openItems := func() []storage.ListOpenSyncItemsRow {
+		items, err := repo.OpenSyncItems(ctx, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return items
+	}
+	// The pending sync is cancelled, and nothing is deleted from the sheet yet
+	if items := openItems(); len(items) != 0 {
+		t.Fatalf("open sync items after trashing = %+v, want none", items)
+	}
+
+	rr := httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/cestino", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("trash: %d %s", rr.Code, rr.Body.String())
+	}
+	for _, want := range []string{"Cena pizzeria", "Rimborso biglietto", "€36,00", "Dopo 30 giorni"} {
+		if !strings.Contains(rr.Body.String(), want) {
+			t.Errorf("trash page lacks %q", want)
+		}
+	}
+
+	restore := func(kind, id string) *httptest.ResponseRecorder {
+		form := url.Values{"kind": {kind}, "id": {id}}
+		req := httptest.NewRequest(http.MethodPost, "/cestino/ripristina", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	if rr := restore("expense", expenseID); rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "Spesa ripristinata") {
+		t.Fatalf("restore expense: %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := restore("expense", expenseID); rr.Code != http.StatusNotFound {
+		t.Errorf("restoring twice: status = %d, want 404", rr.Code)
+	}
+	if rr := restore("receipt", incomeID); rr.Code != http.StatusBadRequest {
+		t.Errorf("unknown kind: status = %d, want 400", rr.Code)
+	}
+	expenses, _ = repo.ListExpensesWithID(ctx, now.Year(), int(now.Month()))
+	if len(expenses) != 1 {
+		t.Fatalf("restored expense not listed: %d expenses", len(expenses))
+	}
+	// Not in the sheet yet, so its sync is queued again
+	if items := openItems(); len(items) != 1 || items[0].Operation != "sync" {
+		t.Fatalf("open sync items after restoring = %+v, want one sync", items)
+	}
+
+	// Deleting from the page offers to undo, which restores from the trash
+	post := func(path string, form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	rr = post("/expenses/delete", url.Values{"id": {expenseID}})
+	var trigger map[string]map[string]any
+	if err := json.Unmarshal([]byte(rr.Header().Get("HX-Trigger")), &trigger); rr.Code != http.StatusOK || err != nil {
+		t.Fatalf("delete: %d, trigger %q: %v", rr.Code, rr.Header().Get("HX-Trigger"), err)
+	}
+	if undo := trigger["undo:show"]; undo["url"] != "/expenses/restore" || undo["id"] != expenseID || undo["seconds"] != float64(10) {
+		t.Errorf("undo trigger = %+v", undo)
+	}
+	rr = post("/expenses/restore", url.Values{"id": {expenseID}})
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Header().Get("HX-Trigger"), "overview:refresh") {
+		t.Fatalf("undo: %d %q %s", rr.Code, rr.Header().Get("HX-Trigger"), rr.Body.String())
+	}
+	if rr := post("/expenses/restore", url.Values{"id": {expenseID}}); rr.Code != http.StatusNotFound {
+		t.Errorf("undoing twice: status = %d, want 404", rr.Code)
+	}
+	if rr := post("/expenses/restore", url.Values{"id": {"x"}}); rr.Code != http.StatusBadRequest {
+		t.Errorf("undo bad id: status = %d, want 400", rr.Code)
+	}
+	if items, _ := repo.ListTrash(ctx); len(items) != 1 || items[0].Kind != storage.TrashKindIncome {
+		t.Fatalf("trash after undo = %+v, want the income only", items)
+	}
+
+	// Purging deletes for good and only then removes the row from the sheet
+	id, _ := strconv.ParseInt(expenseID, 10, 64)
+	if err := repo.MarkSynced(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	if err := adapter.DeleteExpense(ctx, expenseID); err != nil {
+		t.Fatal(err)
+	}
+	n, err := repo.PurgeTrash(ctx, time.Now().AddDate(0, 0, -30))
+	if err != nil || n != 0 {
+		t.Fatalf("purge within retention = %d, %v; want 0", n, err)
+	}
+	n, err = repo.PurgeTrash(ctx, time.Now().Add(time.Minute))
+	if err != nil || n != 2 {
+		t.Fatalf("purge = %d, %v; want 2", n, err)
+	}
+	var deletes int
+	for _, item := range openItems() {
+		if item.Operation == "delete" {
+			deletes++
+		}
+	}
+	if deletes != 1 {
+		t.Errorf("delete items after purge = %d, want 1", deletes)
+	}
+	if items, _ := repo.ListTrash(ctx); len(items) != 0 {
+		t.Errorf("trash after purge = %+v, want empty", items)
+	}
+}
+
+func TestExpenseSearch(t *testing.T) {
+	chdirRepoRoot(t)
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	adapter := adapters.NewSQLiteAdapter(repo, services.NewExpenseService(repo))
+	srv := NewServer(":0", adapter, fakeTax{}, fakeDash{}, adapter, adapter, adapter)
+	ctx := context.Background()
+
+	for _, e := range []core.Expense{
+		{Date: core.NewDate(2031, 3, 2), Description: "Spesa Conad", Amount: core.Money{Cents: 4250}, Primary: "Casa", Secondary: "Supermercato"},
+		{Date: core.NewDate(2031, 3, 9), Description: "Pizza da Gino", Amount: core.Money{Cents: 1800}, Primary: "Svago", Secondary: "Ristoranti"},
+		{Date: core.NewDate(2031, 4, 1), Description: "Spesa Esselunga", Amount: core.Money{Cents: 9000}, Primary: "Casa", Secondary: "Supermercato"},
+		{Date: core.NewDate(2031, 5, 20), Description: "Cena spesa condivisa", Amount: core.Money{Cents: 3000}, Primary: "Svago", Secondary: "Ristoranti"},
+	} {
+		if _, err := adapter.Append(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	trashed, err := adapter.Append(ctx, core.Expense{Date: core.NewDate(2031, 3, 3), Description: "Spesa annullata", Amount: core.Money{Cents: 500}, Primary: "Casa", Secondary: "Supermercato"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := adapter.DeleteExpense(ctx, trashed); err != nil {
+		t.Fatal(err)
+	}
+
+	search := func(query string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/expenses/search?"+query, nil))
+		return rr
+	}
+	for _, tc := range []struct {
+		query string
+		want  []string
+		not   []string
+	}{
+		{"q=SPESA&from=2031-01-01", []string{"3 spese", "Spesa Conad", "Spesa Esselunga", "Cena spesa condivisa"}, []string{"Pizza", "annullata"}},
+		{"q=spesa&from=2031-03-01&to=2031-04-30", []string{"2 spese", "totale €132,50"}, []string{"Cena"}},
+		{"category=Ristoranti&from=2031-01-01", []string{"2 spese", "Pizza da Gino", "Cena spesa condivisa"}, []string{"Conad"}},
+		{"category=Casa&min=50", []string{"1 spese", "Spesa Esselunga"}, []string{"Conad"}},
+		{"max=20,00&from=2031-01-01", []string{"1 spese", "Pizza da Gino"}, nil},
+		{"q=conad+SPE&from=2031-01-01", []string{"1 spese", "Spesa Conad"}, []string{"Esselunga"}},
+		{"q=pizz%C3%A0&from=2031-01-01", []string{"1 spese", "Pizza da Gino"}, nil},
+		{"q=sselunga", []string{"Nessuna spesa trovata"}, nil},
+		{"q=%22%2A%3A", []string{"Nessuna spesa trovata"}, nil},
+		{"q=nulla", []string{"Nessuna spesa trovata"}, nil},
+		{"q=", nil, []string{"spese", "Nessuna"}},
+	} {
+		rr := search(tc.query)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d, body = %s", tc.query, rr.Code, rr.Body.String())
+		}
+		for _, want := range tc.want {
+			if !strings.Contains(rr.Body.String(), want) {
+				t.Errorf("%s: results lack %q in %s", tc.query, want, rr.Body.String())
+			}
+		}
+		for _, not := range tc.not {
+			if strings.Contains(rr.Body.String(), not) {
+				t.Errorf("%s: results contain %q", tc.query, not)
+			}
+		}
+	}
+	for _, query := range []string{"from=ieri", "from=2031-05-01&to=2031-04-01", "min=abc", "min=50&max=10"} {
+		if rr := search(query); rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", query, rr.Code)
+		}
+	}
+	rr := httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/spese", nil))
+	if !strings.Contains(rr.Body.String(), `hx-get="/expenses/search"`) {
+		t.Error("index page lacks the search box")
+	}
+}
+
+func TestYearReport(t *testing.T) {
+	chdirRepoRoot(t)
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	adapter := adapters.NewSQLiteAdapter(repo, services.NewExpenseService(repo))
+	srv := NewServer(":0", adapter, fakeTax{}, fakeDash{}, adapter, adapter, adapter)
+	ctx := context.Background()
+
+	for _, e := range []core.Expense{
+		{Date: core.NewDate(2030, 5, 2), Description: "Spesa", Amount: core.Money{Cents: 10000}, Primary: "Casa", Secondary: "Supermercato"},
+		{Date: core.NewDate(2030, 8, 1), Description: "Treno", Amount: core.Money{Cents: 3000}, Primary: "Trasporti", Secondary: "Treno"},
+		{Date: core.NewDate(2031, 1, 9), Description: "Spesa", Amount: core.Money{Cents: 4000}, Primary: "Casa", Secondary: "Supermercato"},
+		{Date: core.NewDate(2031, 1, 20), Description: "Detersivo", Amount: core.Money{Cents: 1000}, Primary: "Casa", Secondary: "Supermercato"},
+		{Date: core.NewDate(2031, 12, 3), Description: "Spesa", Amount: core.Money{Cents: 7000}, Primary: "Casa", Secondary: "Supermercato"},
+		{Date: core.NewDate(2031, 3, 15), Description: "Cinema", Amount: core.Money{Cents: 1250}, Primary: "Svago", Secondary: "Cinema"},
+	} {
+		if _, err := adapter.Append(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		return rr
+	}
+	rr := get("/report/year/2031")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("report: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	body := rr.Body.String()
+	for _, want := range []string{
+		"Spese del 2031",
+		"<th>gen</th>", "<th>dic</th>",
+		"€50,00", "€70,00", // Casa in January and December
+		"€120,00",                // Casa in 2031
+		"&#43;€20,00 (&#43;20%)", // Casa against 2030
+		"-€30,00 (-100%)",        // Trasporti, only in 2030
+		"€132,50",                // Total of 2031
+		"&#43;€2,50 (&#43;1%)",   // Total against 2030
+		`href="/report/year/2030"`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("report lacks %q", want)
+		}
+	}
+	if strings.Index(body, "Casa") > strings.Index(body, "Svago") || strings.Index(body, "Svago") > strings.Index(body, "Trasporti") {
+		t.Error("categories not sorted by their 2031 total")
+	}
+
+	rr = get("/report/year/2031?format=csv")
+	if rr.Code != http.StatusOK || !strings.HasPrefix(rr.Header().Get("Content-Type"), "text/csv") {
+		t.Fatalf("csv: status = %d, type %q", rr.Code, rr.Header().Get("Content-Type"))
+	}
+	lines := strings.Split(strings.TrimSpace(rr.Body.String()), "\n")
+	if len(lines) != 5 || !strings.HasPrefix(lines[0], "category,2031-01,2031-02,") || !strings.HasSuffix(lines[0], ",total_cents,previous_total_cents,delta_cents") {
+		t.Fatalf("csv = %q", rr.Body.String())
+	}
+	if want := "Casa,5000,0,0,0,0,0,0,0,0,0,0,7000,12000,10000,2000"; lines[1] != want {
+		t.Errorf("csv Casa = %q, want %q", lines[1], want)
+	}
+	if want := "total,5000,0,1250,0,0,0,0,0,0,0,0,7000,13250,13000,250"; lines[4] != want {
+		t.Errorf("csv total = %q, want %q", lines[4], want)
+	}
+
+	if rr := get("/report/year/abc"); rr.Code != http.StatusBadRequest {
+		t.Errorf("bad year: status = %d, want 400", rr.Code)
+	}
+	rr = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/report/year/2031", nil))
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST: status = %d, want 405", rr.Code)
+	}
+}
+
+func TestSQLConsole(t *testing.T) {
+	chdirRepoRoot(t)
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	adapter := adapters.NewSQLiteAdapter(repo, services.NewExpenseService(repo))
+	srv := NewServer(":0", adapter, fakeTax{}, fakeDash{}, adapter, adapter, adapter)
+	if _, err := adapter.Append(context.Background(), core.Expense{Date: core.NewDate(2031, 3, 2), Description: "Spesa Conad", Amount: core.Money{Cents: 4250}, Primary: "Casa", Secondary: "Supermercato"}); err != nil {
+		t.Fatal(err)
+	}
+
+	post := func(form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/admin/sql", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	if rr := post(url.Values{"query": {"SELECT 1"}}); rr.Code != http.StatusNotFound {
+		t.Fatalf("disabled console: status = %d, want 404", rr.Code)
+	}
+	srv.SetSQLConsole(true)
+
+	rr := httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/sql", nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `hx-post="/admin/sql"`) {
+		t.Fatalf("page: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+
+	rr = post(url.Values{"query": {"SELECT description, amount_cents, NULL AS note FROM expenses WHERE date >= '2031-01-01';"}})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("select: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	for _, want := range []string{"1 righe", "<th>amount_cents</th>", "Spesa Conad", "4250", "NULL"} {
+		if !strings.Contains(rr.Body.String(), want) {
+			t.Errorf("select results lack %q in %s", want, rr.Body.String())
+		}
+	}
+
+	rr = post(url.Values{"query": {"SELECT description, amount_cents FROM expenses WHERE date >= '2031-01-01'"}, "format": {"csv"}})
+	if rr.Code != http.StatusOK || !strings.HasPrefix(rr.Header().Get("Content-Type"), "text/csv") {
+		t.Fatalf("csv: status = %d, type %q", rr.Code, rr.Header().Get("Content-Type"))
+	}
+	if want := "description,amount_cents\nSpesa Conad,4250\n"; rr.Body.String() != want {
+		t.Errorf("csv = %q, want %q", rr.Body.String(), want)
+	}
+
+	for _, query := range []string{
+		"DELETE FROM expenses",
+		"SELECT 1; DELETE FROM expenses",
+		"WITH x AS (SELECT 1) DELETE FROM expenses",
+		"SELECT * FROM nowhere",
+		"",
+	} {
+		if rr := post(url.Values{"query": {query}}); rr.Code != http.StatusBadRequest {
+			t.Errorf("%q: status = %d, want 400", query, rr.Code)
+		}
+	}
+	if n, err := repo.CountYearExpenses(context.Background(), 2031); err != nil || n != 1 {
+		t.Errorf("expenses after refused writes = %d, %v", n, err)
+	}
+
+	rr = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodDelete, "/admin/sql", nil))
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("DELETE: status = %d, want 405", rr.Code)
+	}
+}
+
+// fakeCaches is a cache reporter counting the clears of its one cache
+type fakeCaches struct{ cleared int }
+
+func (f *fakeCaches) CacheStats() []ports.CacheStats {
+	return []ports.CacheStats{{Name: "sheets_taxonomy", Hits: 3, Misses: 1, Size: 1 - f.cleared}}
+}
+
+func (f *fakeCaches) ClearCache(name string) bool {
+	if name != "sheets_taxonomy" {
+		return false
+	}
+	f.cleared = 1
+	return true
+}
+
+func TestAPICaches(t *testing.T) {
+	chdirRepoRoot(t)
+	srv := NewServer(":0", fakeExp{}, fakeTax{}, fakeDash{}, fakeList{}, nil, nil)
+	caches := &fakeCaches{}
+	srv.AddCaches(caches)
+	do := func(method, path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, httptest.NewRequest(method, path, nil))
+		return rr
+	}
+
+	var list []apiCache
+	if rr := do(http.MethodGet, "/api/v1/caches"); json.Unmarshal(rr.Body.Bytes(), &list) != nil || len(list) != 1 {
+		t.Fatalf("caches: %d %s", rr.Code, rr.Body.String())
+	}
+	if c := list[0]; c.Name != "sheets_taxonomy" || c.HitRatio != 0.75 || c.Size != 1 {
+		t.Errorf("cache = %+v", c)
+	}
+
+	if rr := do(http.MethodGet, "/api/v1/caches/sheets_taxonomy/clear"); rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET clear: status = %d, want 405", rr.Code)
+	}
+	if rr := do(http.MethodPost, "/api/v1/caches/backup/clear"); rr.Code != http.StatusNotFound {
+		t.Errorf("clear unknown cache: status = %d, want 404", rr.Code)
+	}
+	var cleared apiCache
+	if rr := do(http.MethodPost, "/api/v1/caches/sheets_taxonomy/clear"); json.Unmarshal(rr.Body.Bytes(), &cleared) != nil || cleared.Size != 0 {
+		t.Errorf("clear: %d %s", rr.Code, rr.Body.String())
+	}
+
+	body := do(http.MethodGet, "/metrics").Body.String()
+	for _, want := range []string{
+		`cache_hits_total{cache="sheets_taxonomy"} 3`,
+		`cache_misses_total{cache="sheets_taxonomy"} 1`,
+		`cache_entries{cache="sheets_taxonomy"} 0`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics lack %q", want)
+		}
+	}
+}
+
+func TestAPIKeyQuotas(t *testing.T) {
+	chdirRepoRoot(t)
+	ctx := context.Background()
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	service := services.NewExpenseService(repo)
+	adapter := adapters.NewSQLiteAdapter(repo, service)
+	srv := NewServer(":0", adapter, fakeTax{}, fakeDash{}, adapter, adapter, adapter)
+	quotas := services.NewQuotas(repo, core.QuotaLimits{DailyExpenses: 2}, 50)
+	service.SetQuotas(quotas)
+	srv.SetQuotas(quotas)
+	anna, err := repo.CreateUser(ctx, "Anna")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	form := func(path string, values url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(values.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	create := func(key string) *httptest.ResponseRecorder {
+		body := `{"date":"2030-06-01","description":"Pane","amount":"2.50","primary":"Casa","secondary":"Supermercato"}`
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/expenses", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if key != "" {
+			req.Header.Set("Authorization", "Bearer "+key)
+		}
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := form("/famiglia/quote/keys", url.Values{"user_id": {strconv.FormatInt(anna.ID, 10)}, "name": {"Telefono"}})
+	key := regexp.MustCompile(`spk_[A-Za-z0-9_-]+`).FindString(rr.Body.String())
+	if rr.Code != http.StatusOK || key == "" {
+		t.Fatalf("create key: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+
+	if rr := create("spk_0000"); rr.Code != http.StatusUnauthorized {
+		t.Errorf("unknown key: status = %d, want 401", rr.Code)
+	}
+	for i := 0; i < 2; i++ {
+		if rr := create(key); rr.Code != http.StatusCreated {
+			t.Fatalf("expense %d: status = %d, body = %s", i, rr.Code, rr.Body.String())
+		}
+	}
+	rr = create(key)
+	var body struct {
+		Quota string `json:"quota"`
+		Limit int64  `json:"limit"`
+		Used  int64  `json:"used"`
+	}
+	if rr.Code != http.StatusTooManyRequests || rr.Header().Get("Retry-After") == "" {
+		t.Fatalf("over the quota: status = %d, Retry-After %q", rr.Code, rr.Header().Get("Retry-After"))
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil || body.Quota != core.QuotaDailyExpenses || body.Limit != 2 || body.Used != 2 {
+		t.Errorf("over the quota: body = %s", rr.Body.String())
+	}
+	if rr := create(""); rr.Code != http.StatusCreated {
+		t.Errorf("without a key: status = %d, want 201", rr.Code)
+	}
+
+	// Keys do not reach the quotas they are subject to
+	req := httptest.NewRequest(http.MethodPost, "/famiglia/quote/limits", strings.NewReader("user_id=1&daily_expenses=0"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "Bearer "+key)
+	rr = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("quota change with a key: status = %d, want 403", rr.Code)
+	}
+	// Nor anything else outside the expense, income and receipt API
+	for _, path := range []string{"/famiglia/delete", "/import/rollback", "/api/v1/jobs"} {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader("id=1"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Authorization", "Bearer "+key)
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, req)
+		if rr.Code != http.StatusForbidden {
+			t.Errorf("POST %s with a key: status = %d, want 403", path, rr.Code)
+		}
+	}
+	if users, err := repo.ListUsers(ctx); err != nil || len(users) != 1 {
+		t.Errorf("members after the refused delete = %+v, %v", users, err)
+	}
+
+	if rr := form("/famiglia/quote/limits", url.Values{"user_id": {strconv.FormatInt(anna.ID, 10)}, "daily_expenses": {"-1"}}); rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("negative quota: status = %d, want 422", rr.Code)
+	}
+	if rr := form("/famiglia/quote/limits", url.Values{"user_id": {strconv.FormatInt(anna.ID, 10)}, "daily_expenses": {"3"}}); rr.Code != http.StatusOK {
+		t.Fatalf("set quota: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	if rr := create(key); rr.Code != http.StatusCreated {
+		t.Errorf("raised quota: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/famiglia/quote", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("page: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	for _, want := range []string{"Anna", "Spese oggi: 3 / 3", "vicino al limite", "Telefono", `value="3"`} {
+		if !strings.Contains(rr.Body.String(), want) {
+			t.Errorf("page lacks %q", want)
+		}
+	}
+
+	keys, err := repo.ListAPIKeys(ctx)
+	if err != nil || len(keys) != 1 {
+		t.Fatalf("keys = %+v, %v", keys, err)
+	}
+	if rr := form("/famiglia/quote/keys/revoke", url.Values{"id": {strconv.FormatInt(keys[0].ID, 10)}}); rr.Code != http.StatusOK {
+		t.Fatalf("revoke: status = %d", rr.Code)
+	}
+	if rr := create(key); rr.Code != http.StatusUnauthorized {
+		t.Errorf("revoked key: status = %d, want 401", rr.Code)
+	}
+}
+
+func TestAccounts(t *testing.T) {
+	chdirRepoRoot(t)
+	ctx := context.Background()
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	adapter := adapters.NewSQLiteAdapter(repo, services.NewExpenseService(repo))
+	srv := NewServer(":0", adapter, fakeTax{}, fakeDash{}, adapter, adapter, adapter)
+
+	post := func(path string, values url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(values.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		return rr
+	}
+
+	if body := get("/ui/form/expense").Body.String(); strings.Contains(body, `name="account_id"`) {
+		t.Error("expense form offers an account before any exists")
+	}
+	if rr := post("/accounts/create", url.Values{"name": {"Conto"}, "kind": {"savings"}}); rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("unknown kind: status = %d, want 422", rr.Code)
+	}
+	if rr := post("/accounts/create", url.Values{"name": {"Conto"}, "kind": {"checking"}, "opening_balance": {"1000,50"}}); rr.Code != http.StatusOK || rr.Header().Get("HX-Trigger") == "" {
+		t.Fatalf("create checking: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	if rr := post("/accounts/create", url.Values{"name": {"Carta"}, "kind": {"credit_card"}, "opening_balance": {"-20"}}); rr.Code != http.StatusOK {
+		t.Fatalf("create card: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	if rr := post("/accounts/create", url.Values{"name": {"conto"}, "kind": {"cash"}}); rr.Code != http.StatusInternalServerError {
+		t.Errorf("duplicate name: status = %d, want 500", rr.Code)
+	}
+	accounts, err := repo.ListAccounts(ctx)
+	if err != nil || len(accounts) != 2 {
+		t.Fatalf("accounts = %+v, %v", accounts, err)
+	}
+	card, checking := strconv.FormatInt(accounts[0].ID, 10), strconv.FormatInt(accounts[1].ID, 10)
+
+	if body := get("/ui/form/expense").Body.String(); !strings.Contains(body, `name="account_id"`) {
+		t.Error("expense form lacks the account field")
+	}
+	if rr := post("/expenses", url.Values{"description": {"Pane"}, "amount": {"3"}, "primary": {"Casa"}, "secondary": {"Cibo"}, "account_id": {"x"}}); rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("bad account: status = %d, want 422", rr.Code)
+	}
+	if rr := post("/expenses", url.Values{"description": {"Cena"}, "amount": {"45"}, "primary": {"Casa"}, "secondary": {"Cibo"}, "account_id": {card}}); rr.Code != http.StatusOK {
+		t.Fatalf("expense: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	if rr := post("/incomes", url.Values{"description": {"Stipendio"}, "amount": {"200"}, "category": {"Stipendio"}, "account_id": {checking}}); rr.Code != http.StatusOK {
+		t.Fatalf("income: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+
+	transfer := url.Values{"date": {"2030-03-01"}, "from": {checking}, "to": {card}, "amount": {"65"}, "description": {"Saldo carta"}}
+	if rr := post("/accounts/transfers", url.Values{"from": {checking}, "to": {checking}, "amount": {"1"}}); rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("transfer to itself: status = %d, want 422", rr.Code)
+	}
+	if rr := post("/accounts/transfers", url.Values{"from": {checking}, "to": {"999"}, "amount": {"1"}}); rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("transfer to an unknown account: status = %d, want 422", rr.Code)
+	}
+	if rr := post("/accounts/transfers", transfer); rr.Code != http.StatusOK {
+		t.Fatalf("transfer: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+
+	// Conto: 1000,50 + 200 - 65; Carta: -20 - 45 + 65
+	rr := get("/accounts")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("page: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	for _, want := range []string{"€1135,50", "€0,00", "Patrimonio netto: <strong>€1135,50</strong>", "Saldo carta", "Carta di credito"} {
+		if !strings.Contains(rr.Body.String(), want) {
+			t.Errorf("page lacks %q", want)
+		}
+	}
+
+	// Removing the checking account keeps its income and drops the transfer
+	if rr := post("/accounts/delete", url.Values{"id": {checking}}); rr.Code != http.StatusOK {
+		t.Fatalf("delete: status = %d", rr.Code)
+	}
+	if rr := post("/accounts/delete", url.Values{"id": {checking}}); rr.Code != http.StatusNotFound {
+		t.Errorf("delete again: status = %d, want 404", rr.Code)
+	}
+	balances, err := repo.AccountBalances(ctx)
+	if err != nil || len(balances) != 1 || balances[0].Balance().Cents != -6500 {
+		t.Errorf("balances = %+v, %v", balances, err)
+	}
+	if transfers, err := repo.ListAccountTransfers(ctx, 10); err != nil || len(transfers) != 0 {
+		t.Errorf("transfers = %+v, %v", transfers, err)
+	}
+
+	if rr := get("/accounts/create"); rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET create: status = %d, want 405", rr.Code)
+	}
+	sheets := NewServer(":0", fakeExp{}, fakeTax{}, fakeDash{}, nil, nil, nil)
+	rr = httptest.NewRecorder()
+	sheets.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/accounts", nil))
+	if rr.Code != http.StatusNotImplemented {
+		t.Errorf("other backend: status = %d, want 501", rr.Code)
+	}
+}
+
+func TestExpenseSplits(t *testing.T) {
+	chdirRepoRoot(t)
+	ctx := context.Background()
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	adapter := adapters.NewSQLiteAdapter(repo, services.NewExpenseService(repo))
+	srv := NewServer(":0", adapter, fakeTax{}, fakeDash{}, adapter, adapter, adapter)
+
+	post := func(path string, values url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(values.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		return rr
+	}
+	drain := func() []storage.SyncQueue {
+		queued, err := repo.DequeueSyncBatch(ctx, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, item := range queued {
+			if err := repo.MarkSyncComplete(ctx, item.ID); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return queued
+	}
+
+	ref, err := repo.AppendAndEnqueueSync(ctx, core.Expense{Date: core.NewDate(2031, 3, 10), Description: "Supermercato", Amount: core.Money{Cents: 5000}, Primary: "Spesa", Secondary: "Supermercato"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, _ := strconv.ParseInt(ref, 10, 64)
+	// Already written to Google Sheets
+	drain()
+	if err := repo.MarkSynced(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+
+	if rr := get("/spese/dividi?id=" + ref); rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `value="Supermercato"`) {
+		t.Fatalf("editor: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+
+	split := url.Values{
+		"expense_id": {ref},
+		"note":       {"", "detersivi", ""},
+		"amount":     {"35", "15", ""},
+		"primary":    {"Spesa", "Casa", ""},
+		"secondary":  {"Supermercato", "Pulizia", ""},
+	}
+	wrong := url.Values{}
+	for k, v := range split {
+		wrong[k] = append([]string(nil), v...)
+	}
+	wrong["amount"][1] = "14,99"
+	if rr := post("/spese/dividi/save", wrong); rr.Code != http.StatusUnprocessableEntity || !strings.Contains(rr.Body.String(), "49.99") {
+		t.Errorf("wrong sum: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	if rr := post("/spese/dividi/save", split); rr.Code != http.StatusOK || rr.Header().Get("HX-Trigger") == "" {
+		t.Fatalf("save: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+
+	// The single row in the sheet is replaced by one per part
+	if queued := drain(); len(queued) != 2 || queued[0].Operation != "delete" || queued[1].Operation != "sync" {
+		t.Fatalf("queued after splitting = %+v, want delete then sync", queued)
+	}
+
+	overview, err := repo.ReadMonthOverview(ctx, 2031, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byCategory := map[string]int64{}
+	for _, c := range overview.ByCategory {
+		byCategory[c.Name] = c.Amount.Cents
+	}
+	if overview.Total.Cents != 5000 || byCategory["Spesa"] != 3500 || byCategory["Casa"] != 1500 {
+		t.Errorf("overview = %+v, want Spesa 3500 and Casa 1500", overview)
+	}
+
+	body := get("/ui/month-expenses?year=2031&month=3").Body.String()
+	for _, want := range []string{"Divisa in 2 parti", "€15,00 · Casa / Pulizia · detersivi", "/spese/dividi?id=" + ref} {
+		if !strings.Contains(body, want) {
+			t.Errorf("month list lacks %q", want)
+		}
+	}
+
+	// The amount follows the parts
+	edit := url.Values{"date": {"2031-03-10"}, "description": {"Supermercato"}, "amount": {"60"}, "primary": {"Spesa"}, "secondary": {"Supermercato"}, "version": {"2"}}
+	req := httptest.NewRequest(http.MethodPut, "/expenses/"+ref, strings.NewReader(edit.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr := httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("amount change: status = %d, want 422", rr.Code)
+	}
+
+	// Deleting removes every row of the parts
+	if err := repo.MarkSynced(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.HardDeleteAndEnqueueSync(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	queued := drain()
+	if len(queued) != 2 || queued[0].Operation != "delete" || queued[1].Operation != "delete" {
+		t.Fatalf("queued after deleting = %+v, want two deletes", queued)
+	}
+	if parts, err := repo.ListExpenseSplit(ctx, id); err != nil || len(parts) != 0 {
+		t.Errorf("parts left after deleting: %+v, %v", parts, err)
+	}
+}
+
+func TestRuleReprocess(t *testing.T) {
+	chdirRepoRoot(t)
+	ctx := context.Background()
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	adapter := adapters.NewSQLiteAdapter(repo, services.NewExpenseService(repo))
+	srv := NewServer(":0", adapter, fakeTax{}, fakeDash{}, adapter, adapter, adapter)
+
+	post := func(path string, values url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(values.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		return rr
+	}
+
+	ref, err := repo.AppendAndEnqueueSync(ctx, core.Expense{Date: core.NewDate(2031, 4, 2), Description: "Abbonamento ZqFlix", Amount: core.Money{Cents: 1299}, Primary: core.UncategorizedPrimary, Secondary: core.UncategorizedSecondary})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ruleID, err := repo.CreateCategoryRule(ctx, core.CategoryRule{Name: "ZqFlix", Expression: `description contains "ZqFlix"`, Primary: "Svago", Secondary: "Streaming", Active: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rule := strconv.FormatInt(ruleID, 10)
+
+	if rr := get("/regole/riprocessa?id=" + rule); rr.Code != http.StatusNotImplemented {
+		t.Errorf("without reprocessor: status = %d, want 501", rr.Code)
+	}
+	srv.SetRuleReprocessor(services.NewRuleReprocessor(repo))
+
+	rr := get("/regole/riprocessa?id=" + rule)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `name="expense_id" value="`+ref+`"`) || !strings.Contains(rr.Body.String(), "Abbonamento ZqFlix") {
+		t.Fatalf("preview: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	if rr := get("/regole/riprocessa?id=999999"); rr.Code != http.StatusNotFound {
+		t.Errorf("unknown rule: status = %d, want 404", rr.Code)
+	}
+	if rr := post("/regole/riprocessa/avvia", url.Values{"id": {rule}}); rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("no selection: status = %d, want 422", rr.Code)
+	}
+
+	if rr := post("/regole/riprocessa/avvia", url.Values{"id": {rule}, "scope": {"uncategorized"}, "expense_id": {ref}}); rr.Code != http.StatusOK {
+		t.Fatalf("start: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	body := ""
+	for time.Now().Before(deadline) {
+		rr := get("/ui/regole/riprocessa/stato?id=" + rule)
+		if body = rr.Body.String(); !strings.Contains(body, "every 2s") {
+			if rr.Header().Get("HX-Trigger") == "" {
+				t.Errorf("finished status lacks HX-Trigger")
+			}
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !strings.Contains(body, "Ricategorizzate 1 spese su 1") {
+		t.Errorf("status body = %s", body)
+	}
+	id, _ := strconv.ParseInt(ref, 10, 64)
+	if e, err := repo.GetExpense(ctx, id); err != nil || e.PrimaryCategory != "Svago" {
+		t.Errorf("expense after applying = %+v, %v", e, err)
+	}
+	// Nothing is left to propose
+	if rr := get("/regole/riprocessa?id=" + rule); strings.Contains(rr.Body.String(), `name="expense_id"`) {
+		t.Errorf("preview after applying still proposes changes")
+	}
+}
+
+func TestCreateExpense_Duplicate(t *testing.T) {
+	chdirRepoRoot(t)
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	adapter := adapters.NewSQLiteAdapter(repo, services.NewExpenseService(repo))
+	srv := NewServer(":0", adapter, fakeTax{}, fakeDash{}, adapter, adapter, adapter)
+
+	post := func(values url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/expenses", strings.NewReader(values.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	expense := func(desc, amount string) url.Values {
+		return url.Values{"day": {"2"}, "month": {"3"}, "description": {desc}, "amount": {amount}, "primary": {"Spesa"}, "secondary": {"Supermercato"}}
+	}
+
+	if rr := post(expense("Spesa Esselunga", "42,10")); rr.Code != http.StatusOK {
+		t.Fatalf("first: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	rr := post(expense("esselunga", "42.10"))
+	if rr.Code != http.StatusConflict || !strings.Contains(rr.Body.String(), "Spesa Esselunga") || !strings.Contains(rr.Body.String(), `"confirm_duplicate": "true"`) {
+		t.Fatalf("similar: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	// Another amount or description is not a duplicate
+	if rr := post(expense("Esselunga", "42.20")); rr.Code != http.StatusOK {
+		t.Errorf("other amount: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	if rr := post(expense("Farmacia", "42.10")); rr.Code != http.StatusOK {
+		t.Errorf("other description: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+
+	confirmed := expense("esselunga", "42.10")
+	confirmed.Set("confirm_duplicate", "true")
+	if rr := post(confirmed); rr.Code != http.StatusOK || rr.Header().Get("HX-Trigger") == "" {
+		t.Errorf("save anyway: status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	year := time.Now().Year()
+	similar, err := repo.FindSimilarExpenses(context.Background(), core.Expense{Date: core.NewDate(year, 3, 2), Description: "Esselunga", Amount: core.Money{Cents: 4210}})
+	if err != nil || len(similar) != 2 {
+		t.Errorf("similar after saving anyway = %d, %v, want 2", len(similar), err)
+	}
+}
@@ -0,0 +1,59 @@
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("spese/internal/http")
+
+// startRequestSpan starts the span of a request, continuing the trace of
+// the caller when it sent a traceparent header. The request ID is set on
+// the span and put in the baggage, so it travels with the trace context to
+// the outbound calls made while serving the request.
+func startRequestSpan(ctx context.Context, r *http.Request, requestID string) (context.Context, trace.Span) {
+	ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(r.Header))
+	if member, err := baggage.NewMember("request_id", requestID); err == nil {
+		if bag, err := baggage.FromContext(ctx).SetMember(member); err == nil {
+			ctx = baggage.ContextWithBaggage(ctx, bag)
+		}
+	}
+
+	name := r.Pattern
+	if name == "" {
+		name = r.Method
+	}
+	return tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(r.Method),
+			semconv.HTTPRoute(r.Pattern),
+			semconv.URLPath(r.URL.Path),
+			attribute.String("request_id", requestID)))
+}
+
+// endRequestSpan records the status code of a request and ends its span;
+// server errors mark the span failed.
+func endRequestSpan(span trace.Span, code int) {
+	span.SetAttributes(semconv.HTTPResponseStatusCode(code))
+	if code >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(code))
+	}
+	span.End()
+}
+
+// traceID returns the ID of the trace in ctx, empty outside a trace
+func traceID(ctx context.Context) string {
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		return sc.TraceID().String()
+	}
+	return ""
+}
@@ -0,0 +1,155 @@
+package httpclient
+
+import (
+	"log/slog"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// Factory hands out the clients of the outbound integrations. They share
+// one transport, so its connection pool, and count their requests under
+// the name of the integration.
+type Factory struct {
+	transport http.RoundTripper
+
+	mu    sync.Mutex
+	stats map[string]*Stats
+	// Receives the duration of every request; nil when not set
+	observe func(name string, elapsed time.Duration)
+}
+
+// Stats counts the requests of an integration.
+type Stats struct {
+	Name     string
+	Requests int64
+	Errors   int64         // Failed to get a response, or got a 5xx
+	Duration time.Duration // Summed over all requests
+	// Requests sent again after a 429 or 503, and requests returned still
+	// refused once the retries ran out or the server asked to wait longer
+	Retries          int64
+	RetriesExhausted int64
+}
+
+// NewFactory creates a factory over a transport configured by opts;
+// opts.Timeout is ignored, each client sets its own.
+func NewFactory(opts Options) (*Factory, error) {
+	transport, err := NewTransport(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Factory{transport: transport, stats: make(map[string]*Stats)}, nil
+}
+
+// Client returns a client for the integration called name, whose requests
+// time out after timeout (none when zero). Each request is traced in a
+// span named after the integration, e.g. "sheets POST", and carries the
+// trace context to the server.
+func (f *Factory) Client(name string, timeout time.Duration) *http.Client {
+	traced := otelhttp.NewTransport(f.transport,
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return name + " " + r.Method
+		}))
+	return &http.Client{
+		Transport: &instrumented{next: traced, name: name, factory: f},
+		Timeout:   timeout,
+	}
+}
+
+// RetryingClient is Client retrying the requests refused with 429 or 503
+// as policy says. The timeout bounds all the attempts of a request,
+// delays included.
+func (f *Factory) RetryingClient(name string, timeout time.Duration, policy RetryPolicy) *http.Client {
+	client := f.Client(name, timeout)
+	if policy.MaxRetries > 0 {
+		client.Transport = &retrying{next: client.Transport, policy: policy, name: name, factory: f, sleep: sleepContext}
+	}
+	return client
+}
+
+// Stats returns the counts of every integration that sent a request, by
+// name.
+func (f *Factory) Stats() []Stats {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	stats := make([]Stats, 0, len(f.stats))
+	for _, s := range f.stats {
+		stats = append(stats, *s)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Name < stats[j].Name })
+	return stats
+}
+
+// ObserveRequests passes the duration of every request, with the name of
+// its integration, to observe, e.g. for a latency histogram.
+func (f *Factory) ObserveRequests(observe func(name string, elapsed time.Duration)) {
+	f.mu.Lock()
+	f.observe = observe
+	f.mu.Unlock()
+}
+
+// record counts a request of an integration.
+func (f *Factory) record(name string, elapsed time.Duration, failed bool) {
+	f.mu.Lock()
+	s, ok := f.stats[name]
+	if !ok {
+		s = &Stats{Name: name}
+		f.stats[name] = s
+	}
+	s.Requests++
+	s.Duration += elapsed
+	if failed {
+		s.Errors++
+	}
+	observe := f.observe
+	f.mu.Unlock()
+
+	if observe != nil {
+		observe(name, elapsed)
+	}
+}
+
+// recordRetry counts a retry of an integration, or a request given up
+func (f *Factory) recordRetry(name string, retried bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s, ok := f.stats[name]
+	if !ok {
+		s = &Stats{Name: name}
+		f.stats[name] = s
+	}
+	if retried {
+		s.Retries++
+	} else {
+		s.RetriesExhausted++
+	}
+}
+
+// instrumented is a transport counting the requests it sends.
+type instrumented struct {
+	next    http.RoundTripper
+	name    string
+	factory *Factory
+}
+
+func (t *instrumented) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	elapsed := time.Since(start)
+	failed := err != nil || resp.StatusCode >= 500
+	t.factory.record(t.name, elapsed, failed)
+
+	if failed {
+		attrs := []any{"integration", t.name, "method", req.Method, "host", req.URL.Host, "duration_ms", elapsed.Milliseconds()}
+		if err != nil {
+			attrs = append(attrs, "error", err)
+		} else {
+			attrs = append(attrs, "status", resp.StatusCode)
+		}
+		slog.WarnContext(req.Context(), "Outbound request failed", attrs...)
+	}
+	return resp, err
+}
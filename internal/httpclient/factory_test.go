@@ -0,0 +1,64 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestFactory_Stats(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	f, err := NewFactory(Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	observed := make(map[string]int)
+	f.ObserveRequests(func(name string, _ time.Duration) { observed[name]++ })
+	sheets := f.Client("sheets", time.Second)
+	peer := f.Client("peer", time.Second)
+	if sheets.Timeout != time.Second {
+		t.Errorf("timeout = %v", sheets.Timeout)
+	}
+
+	for _, req := range []struct {
+		client *http.Client
+		path   string
+	}{{sheets, "/ok"}, {sheets, "/fail"}, {peer, "/ok"}} {
+		resp, err := req.client.Get(srv.URL + req.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	// Nobody listens there: the request fails without a response
+	srv.Close()
+	if _, err := peer.Get(srv.URL); err == nil {
+		t.Fatal("request to a closed server succeeded")
+	}
+
+	stats := f.Stats()
+	if len(stats) != 2 {
+		t.Fatalf("stats = %+v", stats)
+	}
+	for i, want := range []Stats{{Name: "peer", Requests: 2, Errors: 1}, {Name: "sheets", Requests: 2, Errors: 1}} {
+		got := stats[i]
+		if got.Name != want.Name || got.Requests != want.Requests || got.Errors != want.Errors {
+			t.Errorf("stats[%d] = %+v, want %+v", i, got, want)
+		}
+		if got.Duration <= 0 {
+			t.Errorf("stats[%d] has no duration", i)
+		}
+		if observed[got.Name] != int(got.Requests) {
+			t.Errorf("observed %d requests of %s", observed[got.Name], got.Name)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+// Package httpclient builds the HTTP clients of the outbound integrations
+// (Google APIs, the peer instance, the language model), so that pooling,
+// timeouts, proxy and certificate settings apply to all of them and their
+// requests are counted alike.
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Options configures an outbound client.
+type Options struct {
+	// PEM file of CA certificates trusted besides the system ones, e.g. the
+	// root of a proxy inspecting TLS; empty trusts the system ones only
+	CAFile string
+	// Overall timeout of a request; zero means none
+	Timeout time.Duration
+	// Retries of the requests refused with 429 or 503; the zero value
+	// sends every request once
+	Retry RetryPolicy
+}
+
+// OptionsFromEnv returns the options set in the environment:
+// OUTBOUND_CA_FILE for the CA bundle.
+func OptionsFromEnv() Options {
+	return Options{CAFile: strings.TrimSpace(os.Getenv("OUTBOUND_CA_FILE"))}
+}
+
+// New returns a client going through the proxy named by HTTPS_PROXY,
+// HTTP_PROXY and NO_PROXY, and trusting the CAs of opts.CAFile.
+func New(opts Options) (*http.Client, error) {
+	transport, err := NewTransport(opts)
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{Transport: transport, Timeout: opts.Timeout}
+	if opts.Retry.MaxRetries > 0 {
+		client.Transport = &retrying{next: transport, policy: opts.Retry, name: "default", sleep: sleepContext}
+	}
+	return client, nil
+}
+
+// NewTransport returns the transport of New, for clients that wrap it.
+// Connections are pooled and kept alive across requests to the same host.
+func NewTransport(opts Options) (*http.Transport, error) {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second, // TCP connection timeout
+		KeepAlive: 30 * time.Second, // Keep-alive probe interval
+	}
+	transport := &http.Transport{
+		Proxy:       http.ProxyFromEnvironment,
+		DialContext: dialer.DialContext,
+
+		// Connection pooling settings
+		MaxIdleConns:        100,              // Total max idle connections across all hosts
+		MaxIdleConnsPerHost: 10,               // Max idle connections per host
+		MaxConnsPerHost:     50,               // Max total connections per host
+		IdleConnTimeout:     90 * time.Second, // How long idle connections stay open
+
+		// TLS and response timeouts
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: 30 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+
+		ForceAttemptHTTP2: true,
+	}
+	if opts.CAFile == "" {
+		return transport, nil
+	}
+
+	pool, err := LoadCAFile(opts.CAFile)
+	if err != nil {
+		return nil, err
+	}
+	transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	return transport, nil
+}
+
+// LoadCAFile returns the system certificate pool with the certificates of
+// a PEM file added.
+func LoadCAFile(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read CA file: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.New("CA file has no PEM certificates: " + path)
+	}
+	return pool, nil
+}
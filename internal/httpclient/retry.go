@@ -0,0 +1,145 @@
+package httpclient
+
+import (
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy retries the requests an API refuses for now: 429 Too Many
+// Requests and 503 Service Unavailable. A Retry-After header sets the
+// delay; otherwise it grows exponentially from BaseDelay, with jitter so
+// that clients refused together do not come back together.
+type RetryPolicy struct {
+	MaxRetries int           // Retries after the first attempt; 0 disables retrying
+	BaseDelay  time.Duration // Delay before the first retry, doubled for each further one
+	MaxDelay   time.Duration // Longest delay; a longer Retry-After is not waited for
+}
+
+// DefaultRetryPolicy retries 3 times, after about 1, 2 and 4 seconds.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{MaxRetries: 3, BaseDelay: time.Second, MaxDelay: 30 * time.Second}
+}
+
+// retryable reports whether a response is worth retrying
+func retryable(resp *http.Response) bool {
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable
+}
+
+// delay returns how long to wait before retry number retry (0 for the
+// first), and false when the server asks for longer than MaxDelay.
+// jitter returns a random duration in [0, d).
+func (p RetryPolicy) delay(retry int, retryAfter string, now time.Time, jitter func(d time.Duration) time.Duration) (time.Duration, bool) {
+	if d, ok := parseRetryAfter(retryAfter, now); ok {
+		return d, d <= p.MaxDelay
+	}
+	d := p.BaseDelay << retry
+	if d > p.MaxDelay || d <= 0 {
+		d = p.MaxDelay
+	}
+	// Equal jitter: half the delay is kept, the other half is random
+	half := d / 2
+	if half <= 0 {
+		return d, true
+	}
+	return half + jitter(half), true
+}
+
+// parseRetryAfter reads a Retry-After header, in seconds or as an HTTP date
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if d := at.Sub(now); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// retrying is a transport retrying the requests refused with 429 or 503.
+// Requests whose body cannot be replayed are sent once.
+type retrying struct {
+	next   http.RoundTripper
+	policy RetryPolicy
+	name   string
+	// factory counts the retries; nil for clients outside a factory
+	factory *Factory
+	sleep   func(*http.Request, time.Duration) bool
+}
+
+func (t *retrying) RoundTrip(req *http.Request) (*http.Response, error) {
+	for retry := 0; ; retry++ {
+		resp, err := t.next.RoundTrip(req)
+		if err != nil || !retryable(resp) {
+			return resp, err
+		}
+		if retry >= t.policy.MaxRetries || (req.Body != nil && req.Body != http.NoBody && req.GetBody == nil) {
+			t.record(false)
+			return resp, nil
+		}
+		wait, ok := t.policy.delay(retry, resp.Header.Get("Retry-After"), time.Now(), func(d time.Duration) time.Duration {
+			return rand.N(d)
+		})
+		if deadline, has := req.Context().Deadline(); has && time.Now().Add(wait).After(deadline) {
+			ok = false
+		}
+		if !ok {
+			// The server wants more time than the request has: let the
+			// caller decide, e.g. the sync queue puts the item back
+			t.record(false)
+			return resp, nil
+		}
+
+		slog.InfoContext(req.Context(), "Retrying outbound request",
+			"integration", t.name, "method", req.Method, "host", req.URL.Host,
+			"status", resp.StatusCode, "retry", retry+1, "delay_ms", wait.Milliseconds())
+		// Drain the refused response so its connection can be reused
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		resp.Body.Close()
+
+		if !t.sleep(req, wait) {
+			return nil, req.Context().Err()
+		}
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+		t.record(true)
+	}
+}
+
+// record counts a retry, or a request given up while still refused
+func (t *retrying) record(retried bool) {
+	if t.factory != nil {
+		t.factory.recordRetry(t.name, retried)
+	}
+}
+
+// sleepContext waits for d, returning false when the request is cancelled
+// first
+func sleepContext(req *http.Request, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-req.Context().Done():
+		return false
+	}
+}
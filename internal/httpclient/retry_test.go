@@ -0,0 +1,95 @@
+package httpclient
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	p := RetryPolicy{MaxRetries: 5, BaseDelay: time.Second, MaxDelay: 10 * time.Second}
+	now := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	none := func(time.Duration) time.Duration { return 0 }
+	most := func(d time.Duration) time.Duration { return d - 1 }
+
+	tests := []struct {
+		name       string
+		retry      int
+		retryAfter string
+		jitter     func(time.Duration) time.Duration
+		want       time.Duration
+		wantOK     bool
+	}{
+		{"first retry, no jitter", 0, "", none, 500 * time.Millisecond, true},
+		{"first retry, most jitter", 0, "", most, time.Second - 1, true},
+		{"third retry", 2, "", none, 2 * time.Second, true},
+		{"capped", 6, "", none, 5 * time.Second, true},
+		{"retry-after seconds", 0, "3", none, 3 * time.Second, true},
+		{"retry-after date", 0, now.Add(4 * time.Second).Format(http.TimeFormat), none, 4 * time.Second, true},
+		{"retry-after too long", 0, "60", none, time.Minute, false},
+		{"retry-after unreadable", 1, "soon", none, time.Second, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := p.delay(tt.retry, tt.retryAfter, now, tt.jitter)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("delay = %v, %v; want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestFactory_RetryingClient(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != "row" {
+			t.Errorf("attempt %d got body %q", calls.Load()+1, body)
+		}
+		switch {
+		case r.URL.Path == "/busy":
+			w.Header().Set("Retry-After", "120")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case calls.Add(1) < 3:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer srv.Close()
+
+	f, err := NewFactory(Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := f.RetryingClient("sheets", 5*time.Second, RetryPolicy{MaxRetries: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond})
+
+	// Refused twice, then accepted, with the body sent each time
+	resp, err := client.Post(srv.URL+"/append", "text/plain", strings.NewReader("row"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || calls.Load() != 3 {
+		t.Fatalf("status %d after %d attempts", resp.StatusCode, calls.Load())
+	}
+
+	// A Retry-After longer than the policy allows is returned at once
+	resp, err = client.Post(srv.URL+"/busy", "text/plain", strings.NewReader("row"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("status %d", resp.StatusCode)
+	}
+
+	stats := f.Stats()
+	if len(stats) != 1 || stats[0].Requests != 4 || stats[0].Retries != 2 || stats[0].RetriesExhausted != 1 {
+		t.Fatalf("stats = %+v", stats)
+	}
+}
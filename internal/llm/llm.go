@@ -0,0 +1,274 @@
+// Package llm asks a language model to read messy text, such as OCR'd
+// receipts or bank statement lines, and extract the merchant, the items
+// and a category. It is optional and disabled by default: nothing is sent
+// anywhere unless a provider is configured.
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"spese/internal/core"
+)
+
+// Supported providers. Both speak the OpenAI chat completions API, which
+// Ollama serves under /v1.
+const (
+	ProviderOpenAI = "openai"
+	ProviderOllama = "ollama"
+)
+
+// Limits of an extraction
+const (
+	MaxTextLength  = 8000    // characters of text sent to the model
+	maxResponse    = 1 << 20 // bytes read from the provider
+	DefaultTimeout = 30 * time.Second
+)
+
+// Defaults per provider
+var (
+	defaultBaseURLs = map[string]string{
+		ProviderOpenAI: "https://api.openai.com/v1",
+		ProviderOllama: "http://localhost:11434/v1",
+	}
+	defaultModels = map[string]string{
+		ProviderOpenAI: "gpt-4o-mini",
+		ProviderOllama: "llama3.2",
+	}
+)
+
+// ErrEmptyText is returned when there is nothing to read.
+var ErrEmptyText = errors.New("empty text")
+
+// Request is the text to read and the taxonomy the categories must come
+// from.
+type Request struct {
+	Text        string
+	Primaries   []string
+	Secondaries []string
+}
+
+// Extraction is what the model read from the text. Categories outside the
+// request's taxonomy and items that are not valid line items are dropped,
+// so the caller can use the result as it is.
+type Extraction struct {
+	Merchant  string
+	Primary   string // suggested category, empty when none fits
+	Secondary string
+	Items     []core.LineItem
+}
+
+// Provider extracts structured data from text.
+type Provider interface {
+	Extract(ctx context.Context, req Request) (Extraction, error)
+}
+
+// Config selects and configures a provider. BaseURL and Model default per
+// provider; the API key is sent as a bearer token when set. HTTPClient,
+// when set, sends the requests instead of a plain client with Timeout.
+type Config struct {
+	Provider   string
+	BaseURL    string
+	APIKey     string
+	Model      string
+	Timeout    time.Duration
+	HTTPClient *http.Client
+}
+
+// Client is a Provider for OpenAI-compatible chat completion endpoints.
+type Client struct {
+	baseURL string
+	apiKey  string
+	model   string
+	client  *http.Client
+}
+
+// New returns the client for cfg, or nil when no provider is configured.
+func New(cfg Config) (*Client, error) {
+	if cfg.Provider == "" {
+		return nil, nil
+	}
+	baseURL, ok := defaultBaseURLs[cfg.Provider]
+	if !ok {
+		return nil, fmt.Errorf("unknown provider %q", cfg.Provider)
+	}
+	if cfg.BaseURL != "" {
+		baseURL = cfg.BaseURL
+	}
+	model := cfg.Model
+	if model == "" {
+		model = defaultModels[cfg.Provider]
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	client := cfg.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: timeout}
+	}
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  cfg.APIKey,
+		model:   model,
+		client:  client,
+	}, nil
+}
+
+// Model returns the model the client asks.
+func (c *Client) Model() string {
+	return c.model
+}
+
+const systemPrompt = `You read Italian receipts and bank statement lines, often garbled by OCR.
+Reply with a JSON object only, with these fields:
+- "merchant": the shop or payee name, "" if unknown
+- "primary", "secondary": the category of the whole purchase, chosen only from the lists given, "" if none fits
+- "items": the purchased lines, each {"description": string, "quantity": number, "amount": number}, where amount is the line total in euros; leave out totals, taxes, change and payment lines
+Do not invent items or amounts that are not in the text.`
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model          string        `json:"model"`
+	Messages       []chatMessage `json:"messages"`
+	Temperature    float64       `json:"temperature"`
+	ResponseFormat struct {
+		Type string `json:"type"`
+	} `json:"response_format"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+}
+
+// extractionJSON is the object the model is asked to reply with.
+type extractionJSON struct {
+	Merchant  string `json:"merchant"`
+	Primary   string `json:"primary"`
+	Secondary string `json:"secondary"`
+	Items     []struct {
+		Description string          `json:"description"`
+		Quantity    json.RawMessage `json:"quantity"`
+		Amount      json.RawMessage `json:"amount"`
+	} `json:"items"`
+}
+
+// rawNumber returns a number the model wrote either as a JSON number or as
+// a string, possibly with a decimal comma.
+func rawNumber(raw json.RawMessage) string {
+	return strings.Trim(strings.TrimSpace(string(raw)), `"€ `)
+}
+
+// Extract sends the text to the model and returns what it read. The text
+// is cut to MaxTextLength characters.
+func (c *Client) Extract(ctx context.Context, req Request) (Extraction, error) {
+	text := strings.TrimSpace(req.Text)
+	if text == "" {
+		return Extraction{}, ErrEmptyText
+	}
+	if utf8.RuneCountInString(text) > MaxTextLength {
+		text = string([]rune(text)[:MaxTextLength])
+	}
+
+	var prompt strings.Builder
+	prompt.WriteString("Categories: " + strings.Join(req.Primaries, "; ") + "\n")
+	prompt.WriteString("Subcategories: " + strings.Join(req.Secondaries, "; ") + "\n\n")
+	prompt.WriteString("Text:\n" + text)
+
+	body := chatRequest{
+		Model: c.model,
+		Messages: []chatMessage{
+			{Role: "system", Content: systemPrompt},
+			{Role: "user", Content: prompt.String()},
+		},
+	}
+	body.ResponseFormat.Type = "json_object"
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return Extraction{}, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/chat/completions", bytes.NewReader(payload))
+	if err != nil {
+		return Extraction{}, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		return Extraction{}, fmt.Errorf("call provider: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponse))
+	if err != nil {
+		return Extraction{}, fmt.Errorf("read provider response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return Extraction{}, fmt.Errorf("provider returned %s: %s", resp.Status, strings.TrimSpace(string(data[:min(len(data), 200)])))
+	}
+
+	var chat chatResponse
+	if err := json.Unmarshal(data, &chat); err != nil {
+		return Extraction{}, fmt.Errorf("decode provider response: %w", err)
+	}
+	if len(chat.Choices) == 0 {
+		return Extraction{}, errors.New("provider returned no choices")
+	}
+	return parseExtraction(chat.Choices[0].Message.Content, req)
+}
+
+// parseExtraction decodes the model's reply, keeping only what fits the
+// request: known categories and valid line items.
+func parseExtraction(content string, req Request) (Extraction, error) {
+	// Some models wrap the object in a Markdown code block
+	content = strings.TrimSpace(content)
+	content = strings.TrimPrefix(content, "```json")
+	content = strings.Trim(content, "`\n ")
+
+	var raw extractionJSON
+	if err := json.Unmarshal([]byte(content), &raw); err != nil {
+		return Extraction{}, fmt.Errorf("decode extraction: %w", err)
+	}
+
+	out := Extraction{Merchant: strings.TrimSpace(raw.Merchant)}
+	if slices.Contains(req.Primaries, raw.Primary) && slices.Contains(req.Secondaries, raw.Secondary) {
+		out.Primary, out.Secondary = raw.Primary, raw.Secondary
+	}
+	for _, it := range raw.Items {
+		item := core.LineItem{Description: strings.TrimSpace(it.Description), Quantity: 1}
+		if q, err := core.ParseQuantity(rawNumber(it.Quantity)); err == nil && q > 0 {
+			item.Quantity = q
+		}
+		cents, err := core.ParseDecimalToCents(rawNumber(it.Amount))
+		if err != nil {
+			continue
+		}
+		item.Amount = core.Money{Cents: cents}
+		if item.Validate() != nil {
+			continue
+		}
+		out.Items = append(out.Items, item)
+		if len(out.Items) == core.MaxLineItems {
+			break
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,80 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	c, err := New(Config{})
+	if c != nil || err != nil {
+		t.Errorf("no provider: %v, %v", c, err)
+	}
+	if _, err := New(Config{Provider: "gemini"}); err == nil {
+		t.Error("unknown provider should fail")
+	}
+	c, err = New(Config{Provider: ProviderOllama})
+	if err != nil || c.baseURL != "http://localhost:11434/v1" || c.Model() != "llama3.2" {
+		t.Errorf("ollama defaults: %+v, %v", c, err)
+	}
+}
+
+func TestClientExtract(t *testing.T) {
+	var got chatRequest
+	reply := `{"merchant": "Esselunga", "primary": "Spesa", "secondary": "Supermercato", "items": [
+		{"description": "Latte intero", "quantity": 2, "amount": 3.38},
+		{"description": "Pane", "quantity": "1", "amount": "2,50"},
+		{"description": "Totale", "amount": 0},
+		{"description": "", "amount": 1}
+	]}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" || r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+		resp := map[string]any{"choices": []any{map[string]any{"message": map[string]string{"role": "assistant", "content": "```json\n" + reply + "\n```"}}}}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+
+	c, err := New(Config{Provider: ProviderOpenAI, BaseURL: srv.URL + "/v1/", APIKey: "secret", Model: "test-model"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := Request{Text: "ESSELUNGA\nLATTE INT x2 3,38\nPANE 2,50\nTOTALE 5,88", Primaries: []string{"Spesa"}, Secondaries: []string{"Supermercato"}}
+	out, err := c.Extract(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Model != "test-model" || got.ResponseFormat.Type != "json_object" || len(got.Messages) != 2 || !strings.Contains(got.Messages[1].Content, "Subcategories: Supermercato") {
+		t.Errorf("request = %+v", got)
+	}
+	if out.Merchant != "Esselunga" || out.Primary != "Spesa" || out.Secondary != "Supermercato" {
+		t.Errorf("extraction = %+v", out)
+	}
+	if len(out.Items) != 2 || out.Items[0].Quantity != 2 || out.Items[0].Amount.Cents != 338 || out.Items[1].Amount.Cents != 250 {
+		t.Errorf("items = %+v", out.Items)
+	}
+
+	// Categories outside the taxonomy are dropped
+	req.Secondaries = []string{"Everli"}
+	if out, err := c.Extract(context.Background(), req); err != nil || out.Primary != "" || out.Secondary != "" {
+		t.Errorf("unknown category: %+v, %v", out, err)
+	}
+
+	if _, err := c.Extract(context.Background(), Request{Text: "  "}); err != ErrEmptyText {
+		t.Errorf("empty text: %v", err)
+	}
+
+	c.apiKey = "wrong"
+	if _, err := c.Extract(context.Background(), req); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("provider error: %v", err)
+	}
+}
@@ -0,0 +1,194 @@
+// Package presets holds optional bundles of categories and recurrent
+// expenses for a household situation, such as having small children. A
+// bundle is a JSON data package embedded in the binary; installing it
+// imports its categories into the taxonomy and creates the recurrents the
+// user picked, so installing the same bundle twice adds nothing new.
+package presets
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+
+	"spese/internal/core"
+)
+
+//go:embed bundles/*.json
+var bundleFiles embed.FS
+
+var ErrNotFound = errors.New("preset not found")
+
+// Category is a primary category with the secondaries a bundle adds.
+type Category struct {
+	Primary     string   `json:"primary"`
+	Secondaries []string `json:"secondaries"`
+}
+
+// Recurrent is a suggested recurrent expense; its amount is a typical
+// figure the user adjusts before installing.
+type Recurrent struct {
+	Description string               `json:"description"`
+	Every       core.RepetitionTypes `json:"every"`
+	AmountCents int64                `json:"amount_cents"`
+	Primary     string               `json:"primary"`
+	Secondary   string               `json:"secondary"`
+}
+
+// Bundle is an installable preset.
+type Bundle struct {
+	ID          string      `json:"id"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Categories  []Category  `json:"categories"`
+	Recurrents  []Recurrent `json:"recurrents"`
+}
+
+// Taxonomy returns the categories of the bundle for a taxonomy import.
+func (b Bundle) Taxonomy() []core.CategoryGroup {
+	groups := make([]core.CategoryGroup, len(b.Categories))
+	for i, c := range b.Categories {
+		groups[i] = core.CategoryGroup{Primary: c.Primary, Secondaries: c.Secondaries}
+	}
+	return groups
+}
+
+// Validate checks the categories and that every recurrent uses one of them.
+func (b Bundle) Validate() error {
+	if b.ID == "" || b.Name == "" {
+		return fmt.Errorf("preset %q: missing id or name", b.ID)
+	}
+	groups := b.Taxonomy()
+	for _, g := range groups {
+		if err := g.Validate(); err != nil {
+			return fmt.Errorf("preset %q: %w", b.ID, err)
+		}
+	}
+	for _, r := range b.Recurrents {
+		if r.Every.OccurrencesPerYear() == 0 || r.AmountCents <= 0 || r.Description == "" {
+			return fmt.Errorf("preset %q: invalid recurrent %q", b.ID, r.Description)
+		}
+		found := false
+		for _, g := range groups {
+			found = found || (g.Primary == r.Primary && g.Has(r.Secondary))
+		}
+		if !found {
+			return fmt.Errorf("preset %q: recurrent %q uses a category outside the bundle", b.ID, r.Description)
+		}
+	}
+	return nil
+}
+
+// List returns the embedded bundles ordered by ID.
+func List() ([]Bundle, error) {
+	names, err := bundleFiles.ReadDir("bundles")
+	if err != nil {
+		return nil, err
+	}
+	bundles := make([]Bundle, 0, len(names))
+	for _, entry := range names {
+		data, err := bundleFiles.ReadFile(path.Join("bundles", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var b Bundle
+		if err := json.Unmarshal(data, &b); err != nil {
+			return nil, fmt.Errorf("preset %s: %w", entry.Name(), err)
+		}
+		if err := b.Validate(); err != nil {
+			return nil, err
+		}
+		bundles = append(bundles, b)
+	}
+	sort.Slice(bundles, func(i, j int) bool { return bundles[i].ID < bundles[j].ID })
+	return bundles, nil
+}
+
+// Get returns the bundle with the given ID.
+func Get(id string) (Bundle, error) {
+	bundles, err := List()
+	if err != nil {
+		return Bundle{}, err
+	}
+	for _, b := range bundles {
+		if b.ID == id {
+			return b, nil
+		}
+	}
+	return Bundle{}, fmt.Errorf("%w: %q", ErrNotFound, id)
+}
+
+// Store imports categories and creates recurrent expenses.
+type Store interface {
+	ImportTaxonomy(ctx context.Context, groups []core.CategoryGroup) (int, error)
+	GetRecurrentExpenses(ctx context.Context) ([]core.RecurrentExpenses, error)
+	CreateRecurrentExpense(ctx context.Context, re core.RecurrentExpenses) (int64, error)
+}
+
+// Result counts what an installation added.
+type Result struct {
+	Categories int // Primary and secondary categories created
+	Recurrents int // Recurrent expenses created
+	Skipped    int // Picked recurrents already present
+}
+
+// Install imports the categories of the bundle and creates the recurrents
+// picked in amounts, by index in the bundle with the amount to use,
+// starting on start. A picked recurrent is skipped when an active one with
+// the same description and secondary category already exists.
+func Install(ctx context.Context, store Store, b Bundle, start core.Date, amounts map[int]core.Money) (Result, error) {
+	var res Result
+	created, err := store.ImportTaxonomy(ctx, b.Taxonomy())
+	if err != nil {
+		return res, fmt.Errorf("import categories: %w", err)
+	}
+	res.Categories = created
+	if len(amounts) == 0 {
+		return res, nil
+	}
+
+	existing, err := store.GetRecurrentExpenses(ctx)
+	if err != nil {
+		return res, fmt.Errorf("list recurrent expenses: %w", err)
+	}
+	for i, r := range b.Recurrents {
+		amount, ok := amounts[i]
+		if !ok {
+			continue
+		}
+		if hasRecurrent(existing, r, start) {
+			res.Skipped++
+			continue
+		}
+		re := core.RecurrentExpenses{
+			StartDate:   start,
+			Every:       r.Every,
+			Description: r.Description,
+			Amount:      amount,
+			Primary:     r.Primary,
+			Secondary:   r.Secondary,
+		}
+		if err := re.Validate(); err != nil {
+			return res, fmt.Errorf("recurrent %q: %w", r.Description, err)
+		}
+		if _, err := store.CreateRecurrentExpense(ctx, re); err != nil {
+			return res, fmt.Errorf("create recurrent %q: %w", r.Description, err)
+		}
+		res.Recurrents++
+	}
+	return res, nil
+}
+
+// hasRecurrent reports whether an active recurrent already covers r
+func hasRecurrent(existing []core.RecurrentExpenses, r Recurrent, start core.Date) bool {
+	for _, e := range existing {
+		if e.Description == r.Description && e.Secondary == r.Secondary &&
+			(e.EndDate.IsZero() || !e.EndDate.Before(start.Time)) {
+			return true
+		}
+	}
+	return false
+}
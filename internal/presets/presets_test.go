@@ -0,0 +1,86 @@
+package presets
+
+import (
+	"context"
+	"testing"
+
+	"spese/internal/core"
+)
+
+type fakeStore struct {
+	groups     []core.CategoryGroup
+	recurrents []core.RecurrentExpenses
+}
+
+func (f *fakeStore) ImportTaxonomy(_ context.Context, groups []core.CategoryGroup) (int, error) {
+	f.groups = append(f.groups, groups...)
+	return len(groups), nil
+}
+
+func (f *fakeStore) GetRecurrentExpenses(context.Context) ([]core.RecurrentExpenses, error) {
+	return f.recurrents, nil
+}
+
+func (f *fakeStore) CreateRecurrentExpense(_ context.Context, re core.RecurrentExpenses) (int64, error) {
+	f.recurrents = append(f.recurrents, re)
+	return int64(len(f.recurrents)), nil
+}
+
+func TestList(t *testing.T) {
+	bundles, err := List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bundles) < 2 || bundles[0].ID != "bimbi-nido" {
+		t.Fatalf("bundles = %+v", bundles)
+	}
+	if _, err := Get("missing"); err == nil {
+		t.Error("unknown preset found")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	b := Bundle{
+		ID:         "x",
+		Name:       "X",
+		Categories: []Category{{Primary: "Bimbi", Secondaries: []string{"Nido"}}},
+		Recurrents: []Recurrent{{Description: "Retta", Every: core.Monthly, AmountCents: 100, Primary: "Bimbi", Secondary: "Scuola"}},
+	}
+	if err := b.Validate(); err == nil {
+		t.Error("recurrent outside the bundle categories accepted")
+	}
+	b.Recurrents[0].Secondary = "Nido"
+	if err := b.Validate(); err != nil {
+		t.Errorf("valid bundle: %v", err)
+	}
+}
+
+func TestInstall(t *testing.T) {
+	ctx := context.Background()
+	b, err := Get("bimbi-nido")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &fakeStore{}
+	start := core.NewDate(2031, 9, 1)
+
+	// Only the picked recurrents are created, with the amount given
+	res, err := Install(ctx, store, b, start, map[int]core.Money{0: {Cents: 52000}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Recurrents != 1 || len(store.recurrents) != 1 || store.recurrents[0].Amount.Cents != 52000 {
+		t.Fatalf("first install: %+v, recurrents %+v", res, store.recurrents)
+	}
+	if len(store.groups) != 1 || store.groups[0].Primary != "Bimbi" {
+		t.Errorf("imported groups = %+v", store.groups)
+	}
+
+	res, err = Install(ctx, store, b, start, map[int]core.Money{0: {Cents: 52000}, 1: {Cents: 4500}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Recurrents != 1 || res.Skipped != 1 || len(store.recurrents) != 2 {
+		t.Errorf("second install: %+v, recurrents %+v", res, store.recurrents)
+	}
+}
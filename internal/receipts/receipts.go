@@ -0,0 +1,94 @@
+// Package receipts keeps the photos and PDFs of expense receipts in blob
+// storage, either a local directory or a bucket of an S3-compatible service
+// (AWS S3, MinIO, Cloudflare R2, Backblaze B2), and shrinks photos into
+// thumbnails for the expense list.
+package receipts
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"spese/internal/core"
+)
+
+// Supported storages
+const (
+	StorageLocal = "local"
+	StorageS3    = "s3"
+)
+
+// DefaultTimeout bounds a call to an S3-compatible service.
+const DefaultTimeout = 30 * time.Second
+
+// ErrNotFound is returned when no blob has the key.
+var ErrNotFound = errors.New("blob not found")
+
+// Store keeps blobs under keys such as "receipts/12/3f9a.jpg".
+type Store interface {
+	Put(ctx context.Context, key, contentType string, data []byte) error
+	// Get returns the blob content; the caller closes it.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes a blob; deleting a missing blob is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// Config selects and configures a store. HTTPClient, when set, sends the
+// requests to S3 instead of a plain client with DefaultTimeout.
+type Config struct {
+	Storage    string // StorageLocal or StorageS3
+	Dir        string
+	Endpoint   string // e.g. https://s3.eu-south-1.amazonaws.com or http://minio:9000
+	Bucket     string
+	Region     string
+	AccessKey  string
+	SecretKey  string
+	HTTPClient *http.Client
+}
+
+// New returns the store for cfg, or nil when no storage is configured.
+func New(cfg Config) (Store, error) {
+	switch cfg.Storage {
+	case "":
+		return nil, nil
+	case StorageLocal:
+		return NewLocalStore(cfg.Dir)
+	case StorageS3:
+		return NewS3Store(cfg)
+	}
+	return nil, fmt.Errorf("unknown storage %q", cfg.Storage)
+}
+
+// NewKey returns a fresh key for a receipt file of the given type, or for
+// its thumbnail. Keys are random, so a replaced receipt never shares a key
+// with the new one.
+func NewKey(expenseID int64, contentType string, thumbnail bool) (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("random key: %w", err)
+	}
+	if thumbnail {
+		return fmt.Sprintf("receipts/%d/%s-thumb.jpg", expenseID, hex.EncodeToString(b)), nil
+	}
+	return fmt.Sprintf("receipts/%d/%s%s", expenseID, hex.EncodeToString(b), core.ReceiptExtension(contentType)), nil
+}
+
+// validKey reports whether key is safe as a file path and an object name:
+// relative, without empty, "." or ".." segments.
+func validKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, `\`) {
+		return false
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return false
+		}
+	}
+	return true
+}
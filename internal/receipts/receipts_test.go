@@ -0,0 +1,167 @@
+package receipts
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"spese/internal/core"
+)
+
+func TestLocalStore(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := NewKey(12, core.ReceiptPDF, false)
+	if err != nil || !strings.HasPrefix(key, "receipts/12/") || !strings.HasSuffix(key, ".pdf") {
+		t.Fatalf("NewKey = %q, %v", key, err)
+	}
+	if err := store.Put(ctx, key, core.ReceiptPDF, []byte("%PDF-1.7")); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	rc, err := store.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	got, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(got) != "%PDF-1.7" {
+		t.Errorf("Get = %q", got)
+	}
+
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := store.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after delete: %v, want ErrNotFound", err)
+	}
+	if err := store.Delete(ctx, key); err != nil {
+		t.Errorf("Delete of a missing blob: %v", err)
+	}
+
+	for _, bad := range []string{"", "/etc/passwd", "receipts/../../x", "receipts//x", `receipts\x`} {
+		if err := store.Put(ctx, bad, core.ReceiptPDF, nil); err == nil {
+			t.Errorf("Put(%q): expected error", bad)
+		}
+	}
+}
+
+// The get-vanilla case of the AWS Signature Version 4 test suite
+func TestSignV4(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	signV4(req, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		"AKIDEXAMPLE", "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "us-east-1", "service",
+		time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+		"SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("Authorization =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestS3Store(t *testing.T) {
+	ctx := context.Background()
+	var mu sync.Mutex
+	objects := map[string][]byte{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AK/") || r.Header.Get("X-Amz-Content-Sha256") == "" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case http.MethodPut:
+			objects[r.URL.Path], _ = io.ReadAll(r.Body)
+		case http.MethodGet:
+			data, ok := objects[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write(data)
+		case http.MethodDelete:
+			delete(objects, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer srv.Close()
+
+	store, err := New(Config{Storage: StorageS3, Endpoint: srv.URL, Bucket: "spese", AccessKey: "AK", SecretKey: "SK"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put(ctx, "receipts/3/a.jpg", core.ReceiptJPEG, []byte("photo")); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if _, ok := objects["/spese/receipts/3/a.jpg"]; !ok {
+		t.Fatalf("objects = %v, want a path-style key", objects)
+	}
+	rc, err := store.Get(ctx, "receipts/3/a.jpg")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	got, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(got) != "photo" {
+		t.Errorf("Get = %q", got)
+	}
+	if err := store.Delete(ctx, "receipts/3/a.jpg"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := store.Get(ctx, "receipts/3/a.jpg"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after delete: %v, want ErrNotFound", err)
+	}
+
+	denied, _ := New(Config{Storage: StorageS3, Endpoint: srv.URL, Bucket: "spese", AccessKey: "other", SecretKey: "SK"})
+	if err := denied.Put(ctx, "receipts/3/b.jpg", core.ReceiptJPEG, nil); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("Put with other credentials: %v, want a 403 error", err)
+	}
+}
+
+func TestThumbnail(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 800, 400))
+	for y := 0; y < 400; y++ {
+		for x := 0; x < 800; x++ {
+			img.Set(x, y, color.NRGBA{R: 200, A: 255})
+		}
+	}
+	var photo bytes.Buffer
+	if err := png.Encode(&photo, img); err != nil {
+		t.Fatal(err)
+	}
+
+	thumb, err := Thumbnail(photo.Bytes())
+	if err != nil {
+		t.Fatalf("Thumbnail: %v", err)
+	}
+	got, err := jpeg.Decode(bytes.NewReader(thumb))
+	if err != nil {
+		t.Fatalf("thumbnail is not a JPEG: %v", err)
+	}
+	if b := got.Bounds(); b.Dx() != ThumbnailSize || b.Dy() != ThumbnailSize/2 {
+		t.Errorf("thumbnail size = %dx%d", b.Dx(), b.Dy())
+	}
+	if r, g, _, _ := got.At(80, 40).RGBA(); r>>8 < 180 || g>>8 > 40 {
+		t.Errorf("thumbnail color = %v, want red", got.At(80, 40))
+	}
+
+	if _, err := Thumbnail([]byte("%PDF-1.7")); err == nil {
+		t.Error("Thumbnail of a PDF: expected error")
+	}
+}
@@ -0,0 +1,144 @@
+// Package replication reports the state of the layer replicating the SQLite
+// database, Litestream or LiteFS, so the server sends writes to the primary
+// node and health checks can expose replication lag.
+package replication
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Mode is the replication layer the database runs under.
+type Mode string
+
+const (
+	ModeNone       Mode = ""
+	ModeLitestream Mode = "litestream" // streamed backup; this node is always the primary
+	ModeLiteFS     Mode = "litefs"     // FUSE cluster; only the lease holder can write
+)
+
+// Status is a snapshot of the replication state.
+type Status struct {
+	Mode        Mode          `json:"mode"`
+	Primary     bool          `json:"primary"`
+	PrimaryHost string        `json:"primary_host,omitempty"` // LiteFS replicas only
+	Lag         time.Duration `json:"-"`
+	LagKnown    bool          `json:"-"`
+}
+
+// Monitor reads the replication state from the files the replication layer
+// maintains. It holds no state, so it reflects primary changes immediately.
+type Monitor struct {
+	mode   Mode
+	dir    string // LiteFS mount directory
+	dbPath string
+}
+
+// NewMonitor creates a monitor for the database at dbPath. liteFSDir is the
+// LiteFS mount directory; empty means the directory holding the database.
+func NewMonitor(mode Mode, liteFSDir, dbPath string) *Monitor {
+	if liteFSDir == "" {
+		liteFSDir = filepath.Dir(dbPath)
+	}
+	return &Monitor{mode: mode, dir: liteFSDir, dbPath: dbPath}
+}
+
+// Mode returns the configured replication layer.
+func (m *Monitor) Mode() Mode {
+	return m.mode
+}
+
+// IsPrimary reports whether this node may write to the database. A nil
+// monitor (no replication) is always primary.
+func (m *Monitor) IsPrimary() bool {
+	if m == nil || m.mode != ModeLiteFS {
+		return true
+	}
+	_, err := os.Stat(filepath.Join(m.dir, ".primary"))
+	return os.IsNotExist(err)
+}
+
+// PrimaryHost returns the hostname of the LiteFS primary as advertised to
+// replicas, or "" on the primary itself or when it is unknown.
+func (m *Monitor) PrimaryHost() string {
+	if m == nil || m.mode != ModeLiteFS {
+		return ""
+	}
+	data, err := os.ReadFile(filepath.Join(m.dir, ".primary"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// Status returns the current replication state. Lag is known on LiteFS
+// replicas exposing the ".lag" file, and for Litestream as the age of WAL
+// writes it has not yet copied.
+func (m *Monitor) Status() Status {
+	if m == nil {
+		return Status{Primary: true}
+	}
+
+	st := Status{Mode: m.mode, Primary: m.IsPrimary()}
+	switch m.mode {
+	case ModeLiteFS:
+		st.PrimaryHost = m.PrimaryHost()
+		if st.Primary {
+			st.LagKnown = true // the primary is the source of truth
+		} else if lag, ok := m.liteFSLag(); ok {
+			st.Lag, st.LagKnown = lag, true
+		}
+	case ModeLitestream:
+		st.Lag, st.LagKnown = m.litestreamLag()
+	}
+	return st
+}
+
+// liteFSLag reads the replica lag LiteFS publishes in the ".lag" file, in
+// milliseconds or as a duration string.
+func (m *Monitor) liteFSLag() (time.Duration, bool) {
+	data, err := os.ReadFile(filepath.Join(m.dir, ".lag"))
+	if err != nil {
+		return 0, false
+	}
+	s := strings.TrimSpace(string(data))
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Duration(ms) * time.Millisecond, true
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return d, true
+	}
+	return 0, false
+}
+
+// litestreamLag compares the database WAL with the newest file in the
+// shadow WAL Litestream keeps next to it (".<name>-litestream"): writes
+// newer than the shadow copy are not replicated yet.
+func (m *Monitor) litestreamLag() (time.Duration, bool) {
+	wal, err := os.Stat(m.dbPath + "-wal")
+	if err != nil {
+		return 0, false
+	}
+
+	shadow := filepath.Join(filepath.Dir(m.dbPath), "."+filepath.Base(m.dbPath)+"-litestream")
+	var newest time.Time
+	_ = filepath.WalkDir(shadow, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil && info.ModTime().After(newest) {
+			newest = info.ModTime()
+		}
+		return nil
+	})
+	if newest.IsZero() {
+		return 0, false // Litestream has not started replicating this database
+	}
+	if lag := wal.ModTime().Sub(newest); lag > 0 {
+		return lag, true
+	}
+	return 0, true
+}
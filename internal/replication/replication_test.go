@@ -0,0 +1,77 @@
+package replication
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMonitor_LiteFS(t *testing.T) {
+	dir := t.TempDir()
+	m := NewMonitor(ModeLiteFS, "", filepath.Join(dir, "spese.db"))
+
+	if st := m.Status(); !st.Primary || st.PrimaryHost != "" || !st.LagKnown || st.Lag != 0 {
+		t.Errorf("primary status = %+v", st)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, ".primary"), []byte("node-a\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	st := m.Status()
+	if st.Primary || st.PrimaryHost != "node-a" {
+		t.Errorf("replica status = %+v", st)
+	}
+	if st.LagKnown {
+		t.Errorf("lag known without a .lag file: %+v", st)
+	}
+
+	for content, want := range map[string]time.Duration{"1500": 1500 * time.Millisecond, "2s\n": 2 * time.Second} {
+		if err := os.WriteFile(filepath.Join(dir, ".lag"), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if st := m.Status(); !st.LagKnown || st.Lag != want {
+			t.Errorf(".lag %q: status = %+v, want lag %v", content, st, want)
+		}
+	}
+}
+
+func TestMonitor_Litestream(t *testing.T) {
+	dir := t.TempDir()
+	db := filepath.Join(dir, "spese.db")
+	m := NewMonitor(ModeLitestream, "", db)
+
+	if st := m.Status(); !st.Primary || st.LagKnown {
+		t.Errorf("without WAL: status = %+v", st)
+	}
+
+	now := time.Now()
+	shadow := filepath.Join(dir, ".spese.db-litestream", "generations", "abc", "wal")
+	if err := os.MkdirAll(shadow, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for path, mtime := range map[string]time.Time{
+		db + "-wal":                           now,
+		filepath.Join(shadow, "00000001.wal"): now.Add(-10 * time.Second),
+		filepath.Join(dir, ".spese.db-litestream", "generation"): now.Add(-time.Hour),
+	} {
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	st := m.Status()
+	if !st.Primary || !st.LagKnown || st.Lag != 10*time.Second {
+		t.Errorf("status = %+v, want 10s lag", st)
+	}
+}
+
+func TestMonitor_Nil(t *testing.T) {
+	var m *Monitor
+	if !m.IsPrimary() || !m.Status().Primary {
+		t.Error("nil monitor must report primary")
+	}
+}
@@ -0,0 +1,144 @@
+package rules
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+
+	"spese/internal/core"
+)
+
+//go:embed dictionaries/*.json
+var dictionaryFiles embed.FS
+
+// dictionaryFile is the layout of an embedded keyword dictionary.
+type dictionaryFile struct {
+	Name     string `json:"name"`
+	Keywords []struct {
+		Keyword   string `json:"keyword"`
+		Primary   string `json:"primary"`
+		Secondary string `json:"secondary"`
+	} `json:"keywords"`
+}
+
+// BuiltinKeywords returns the curated keywords of the embedded
+// dictionaries, ordered by keyword. A keyword listed twice is an error.
+func BuiltinKeywords() ([]core.CategoryKeyword, error) {
+	entries, err := dictionaryFiles.ReadDir("dictionaries")
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var keywords []core.CategoryKeyword
+	for _, entry := range entries {
+		data, err := dictionaryFiles.ReadFile(path.Join("dictionaries", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var file dictionaryFile
+		if err := json.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("dictionary %s: %w", entry.Name(), err)
+		}
+		for _, k := range file.Keywords {
+			keyword := core.CategoryKeyword{Keyword: core.NormalizeKeyword(k.Keyword), Primary: k.Primary, Secondary: k.Secondary, Builtin: true}
+			if err := keyword.Validate(); err != nil {
+				return nil, fmt.Errorf("dictionary %s: keyword %q: %w", entry.Name(), k.Keyword, err)
+			}
+			if seen[keyword.Keyword] {
+				return nil, fmt.Errorf("dictionary %s: duplicate keyword %q", entry.Name(), keyword.Keyword)
+			}
+			seen[keyword.Keyword] = true
+			keywords = append(keywords, keyword)
+		}
+	}
+
+	sort.Slice(keywords, func(i, j int) bool { return keywords[i].Keyword < keywords[j].Keyword })
+	return keywords, nil
+}
+
+// KeywordStore provides the user overrides of the builtin keywords.
+type KeywordStore interface {
+	ListCategoryKeywords(ctx context.Context) ([]core.CategoryKeyword, error)
+}
+
+// Dictionary matches expense descriptions against the builtin keywords
+// merged with the user overrides. A nil Dictionary matches nothing.
+type Dictionary struct {
+	builtin []core.CategoryKeyword
+	store   KeywordStore
+}
+
+// NewDictionary loads the builtin keywords; overrides are read from store
+// on every lookup, so edits apply at once. store may be nil.
+func NewDictionary(store KeywordStore) (*Dictionary, error) {
+	builtin, err := BuiltinKeywords()
+	if err != nil {
+		return nil, err
+	}
+	return &Dictionary{builtin: builtin, store: store}, nil
+}
+
+// IsBuiltin reports whether keyword ships with the app, so removing its
+// override restores it instead of deleting it.
+func (d *Dictionary) IsBuiltin(keyword string) bool {
+	if d == nil {
+		return false
+	}
+	i := sort.Search(len(d.builtin), func(i int) bool { return d.builtin[i].Keyword >= keyword })
+	return i < len(d.builtin) && d.builtin[i].Keyword == keyword
+}
+
+// Keywords returns the builtin keywords with the overrides applied, ordered
+// by keyword. An override replaces the builtin entry with the same
+// keyword; disabled entries are included.
+func (d *Dictionary) Keywords(ctx context.Context) ([]core.CategoryKeyword, error) {
+	if d == nil {
+		return nil, nil
+	}
+
+	byKeyword := make(map[string]core.CategoryKeyword, len(d.builtin))
+	for _, k := range d.builtin {
+		byKeyword[k.Keyword] = k
+	}
+	if d.store != nil {
+		overrides, err := d.store.ListCategoryKeywords(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, k := range overrides {
+			byKeyword[k.Keyword] = k
+		}
+	}
+
+	keywords := make([]core.CategoryKeyword, 0, len(byKeyword))
+	for _, k := range byKeyword {
+		keywords = append(keywords, k)
+	}
+	sort.Slice(keywords, func(i, j int) bool { return keywords[i].Keyword < keywords[j].Keyword })
+	return keywords, nil
+}
+
+// Lookup returns the enabled keyword found in description, or nil. When
+// several match the longest wins, so "eni station" beats "eni".
+func (d *Dictionary) Lookup(ctx context.Context, description string) (*core.CategoryKeyword, error) {
+	keywords, err := d.Keywords(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var best *core.CategoryKeyword
+	for i := range keywords {
+		k := &keywords[i]
+		if k.Disabled || !k.MatchesDescription(description) {
+			continue
+		}
+		if best == nil || len(k.Keyword) > len(best.Keyword) {
+			best = k
+		}
+	}
+	return best, nil
+}
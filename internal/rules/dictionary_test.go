@@ -0,0 +1,118 @@
+package rules
+
+import (
+	"context"
+	"testing"
+
+	"spese/internal/core"
+)
+
+type fakeKeywords []core.CategoryKeyword
+
+func (f fakeKeywords) ListCategoryKeywords(context.Context) ([]core.CategoryKeyword, error) {
+	return f, nil
+}
+
+func TestBuiltinKeywords(t *testing.T) {
+	keywords, err := BuiltinKeywords()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keywords) == 0 {
+		t.Fatal("no builtin keywords")
+	}
+	for i, k := range keywords {
+		if !k.Builtin {
+			t.Errorf("%q is not marked builtin", k.Keyword)
+		}
+		if i > 0 && keywords[i-1].Keyword >= k.Keyword {
+			t.Errorf("keywords not sorted at %q", k.Keyword)
+		}
+	}
+}
+
+func TestDictionaryLookup(t *testing.T) {
+	d, err := NewDictionary(fakeKeywords{
+		{Keyword: "coop", Disabled: true},
+		{Keyword: "bar sport", Primary: "Fuori (come fuori a cena...)", Secondary: "Bar"},
+		{Keyword: "esselunga", Primary: "Spesa", Secondary: "Everli"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	cases := []struct {
+		desc, secondary string
+	}{
+		{"ESSELUNGA Viale Piave", "Everli"}, // override replaces the builtin
+		{"Treno TRENITALIA Milano", "Trasporto locale"},
+		{"Caffè al Bar Sport", "Bar"}, // user keyword
+		{"Spesa COOP", ""},            // builtin disabled
+		{"Regalo per Anna", ""},
+	}
+	for _, c := range cases {
+		k, err := d.Lookup(ctx, c.desc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := ""
+		if k != nil {
+			got = k.Secondary
+		}
+		if got != c.secondary {
+			t.Errorf("Lookup(%q) = %q, want %q", c.desc, got, c.secondary)
+		}
+	}
+
+	// The longest keyword wins
+	d, _ = NewDictionary(fakeKeywords{{Keyword: "apple", Primary: "Spesa", Secondary: "Frutta"}})
+	if k, _ := d.Lookup(ctx, "Apple Store Milano"); k == nil || k.Keyword != "apple store" {
+		t.Errorf("longest match: got %+v", k)
+	}
+
+	var none *Dictionary
+	if k, err := none.Lookup(ctx, "esselunga"); k != nil || err != nil {
+		t.Errorf("nil dictionary: %+v, %v", k, err)
+	}
+}
+
+func TestCategorizer_ApplyDictionary(t *testing.T) {
+	d, err := NewDictionary(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewCategorizer(fakeStore{
+		{ID: 1, Name: "treni", Expression: `lower(description) contains "trenitalia"`, Primary: "Viaggi", Secondary: "Vacanza"},
+	})
+	c.SetDictionary(d)
+	ctx := context.Background()
+
+	// A missing category is filled in from the dictionary
+	imported := core.Expense{Date: core.NewDate(2025, 3, 12), Description: "POS ESSELUNGA 1234", Amount: core.Money{Cents: 3000}}
+	got, err := c.Apply(ctx, imported)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Primary != "Spesa" || got.Secondary != "Altre spese (non Everli)" {
+		t.Errorf("imported expense: got %s/%s", got.Primary, got.Secondary)
+	}
+
+	// A category picked by the user is kept
+	picked := imported
+	picked.Primary, picked.Secondary = "Regali", "Altri regali"
+	if got, _ := c.Apply(ctx, picked); got.Primary != "Regali" {
+		t.Errorf("picked category replaced by a keyword: %s", got.Primary)
+	}
+
+	// Rules win over the dictionary
+	train := picked
+	train.Description = "Trenitalia Frecciarossa"
+	s, err := c.Suggest(ctx, train)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s == nil || s.Rule == nil || s.Primary != "Viaggi" {
+		t.Errorf("suggestion = %+v, want the rule", s)
+	}
+}
@@ -0,0 +1,201 @@
+// Package rules evaluates user-defined expressions over expense fields. It
+// backs categorization rules and is meant to be reused for any other
+// condition written by users (alerts, notification routing).
+package rules
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"spese/internal/classifier"
+	"spese/internal/core"
+	"spese/internal/expr"
+)
+
+// ExpenseVars are the fields available to expressions about an expense.
+var ExpenseVars = expr.Vars{
+	"description":  expr.String,
+	"amount":       expr.Number, // euros, e.g. 12.5
+	"amount_cents": expr.Number,
+	"primary":      expr.String,
+	"secondary":    expr.String,
+	"day":          expr.Number,
+	"month":        expr.Number,
+	"year":         expr.Number,
+	"weekday":      expr.Number, // 1 = Monday … 7 = Sunday
+}
+
+// ExpenseEnv binds the fields of e to ExpenseVars.
+func ExpenseEnv(e core.Expense) expr.Env {
+	weekday := int(e.Date.Weekday())
+	if weekday == 0 {
+		weekday = 7
+	}
+	return expr.Env{
+		"description":  e.Description,
+		"amount":       float64(e.Amount.Cents) / 100,
+		"amount_cents": e.Amount.Cents,
+		"primary":      e.Primary,
+		"secondary":    e.Secondary,
+		"day":          e.Date.Day(),
+		"month":        e.Date.Month(),
+		"year":         e.Date.Year(),
+		"weekday":      weekday,
+	}
+}
+
+// CompileExpenseCondition compiles a bool expression over ExpenseVars.
+func CompileExpenseCondition(src string) (*expr.Program, error) {
+	return expr.CompileCondition(strings.TrimSpace(src), ExpenseVars)
+}
+
+// Store provides the categorization rules to evaluate.
+type Store interface {
+	ListActiveCategoryRules(ctx context.Context) ([]core.CategoryRule, error)
+}
+
+// Categorizer assigns categories from the first matching rule, falling
+// back to the keyword dictionary and then to the classifier for expenses
+// without a category. A nil Categorizer leaves expenses unchanged.
+type Categorizer struct {
+	store      Store
+	dictionary *Dictionary
+
+	// Local model consulted last; its proposals below minConfidence are
+	// dropped
+	classifier    *classifier.Classifier
+	minConfidence float64
+}
+
+// NewCategorizer creates a categorizer reading rules from store.
+func NewCategorizer(store Store) *Categorizer {
+	return &Categorizer{store: store}
+}
+
+// SetDictionary enables the keyword dictionary as a fallback to the rules.
+func (c *Categorizer) SetDictionary(d *Dictionary) {
+	c.dictionary = d
+}
+
+// SetClassifier enables the classifier after the dictionary, for
+// proposals with at least minConfidence (0 to 1).
+func (c *Categorizer) SetClassifier(cl *classifier.Classifier, minConfidence float64) {
+	c.classifier = cl
+	c.minConfidence = minConfidence
+}
+
+// Classifier returns the classifier, nil when not enabled.
+func (c *Categorizer) Classifier() *classifier.Classifier {
+	if c == nil {
+		return nil
+	}
+	return c.classifier
+}
+
+// Dictionary returns the keyword dictionary, nil when not enabled.
+func (c *Categorizer) Dictionary() *Dictionary {
+	if c == nil {
+		return nil
+	}
+	return c.dictionary
+}
+
+// Match returns the first active rule whose condition holds for e, or nil.
+// Rules that fail to compile or evaluate are logged and skipped, so a
+// broken rule never blocks saving an expense.
+func (c *Categorizer) Match(ctx context.Context, e core.Expense) (*core.CategoryRule, error) {
+	if c == nil {
+		return nil, nil
+	}
+
+	list, err := c.store.ListActiveCategoryRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	env := ExpenseEnv(e)
+	for i := range list {
+		rule := &list[i]
+		prog, err := CompileExpenseCondition(rule.Expression)
+		if err != nil {
+			slog.WarnContext(ctx, "Skipping invalid category rule", "rule_id", rule.ID, "error", err, "component", "rules")
+			continue
+		}
+		ok, err := prog.EvalBool(env)
+		if err != nil {
+			slog.WarnContext(ctx, "Category rule evaluation failed", "rule_id", rule.ID, "error", err, "component", "rules")
+			continue
+		}
+		if ok {
+			return rule, nil
+		}
+	}
+	return nil, nil
+}
+
+// Suggestion is the category picked for an expense, with the rule or the
+// dictionary keyword that picked it; with neither, the classifier did.
+type Suggestion struct {
+	Primary    string
+	Secondary  string
+	Rule       *core.CategoryRule
+	Keyword    *core.CategoryKeyword
+	Confidence float64 // classifier probability, 1 for rules and keywords
+}
+
+// Suggest returns the category of the first matching rule or, failing
+// that, of the dictionary keyword found in the description or, failing
+// that, the classifier's confident proposal; nil when none applies.
+func (c *Categorizer) Suggest(ctx context.Context, e core.Expense) (*Suggestion, error) {
+	if c == nil {
+		return nil, nil
+	}
+
+	rule, err := c.Match(ctx, e)
+	if err != nil {
+		return nil, err
+	}
+	if rule != nil {
+		return &Suggestion{Primary: rule.Primary, Secondary: rule.Secondary, Rule: rule, Confidence: 1}, nil
+	}
+
+	keyword, err := c.dictionary.Lookup(ctx, e.Description)
+	if err != nil {
+		return nil, err
+	}
+	if keyword != nil {
+		return &Suggestion{Primary: keyword.Primary, Secondary: keyword.Secondary, Keyword: keyword, Confidence: 1}, nil
+	}
+
+	prediction, ok, err := c.classifier.Predict(ctx, e.Description)
+	if err != nil || !ok || prediction.Confidence < c.minConfidence {
+		return nil, err
+	}
+	return &Suggestion{Primary: prediction.Primary, Secondary: prediction.Secondary, Confidence: prediction.Confidence}, nil
+}
+
+// Apply returns e with the categories of the first matching rule. The
+// dictionary and the classifier only fill in categories that are missing
+// or Unknown, as in imported rows: a category picked by the user is never
+// replaced by a keyword or a guess.
+func (c *Categorizer) Apply(ctx context.Context, e core.Expense) (core.Expense, error) {
+	suggestion, err := c.Suggest(ctx, e)
+	if err != nil || suggestion == nil {
+		return e, err
+	}
+
+	switch {
+	case suggestion.Rule != nil:
+		slog.DebugContext(ctx, "Category rule matched", "rule_id", suggestion.Rule.ID, "rule", suggestion.Rule.Name, "component", "rules")
+	case !e.IsUncategorized():
+		return e, nil
+	case suggestion.Keyword != nil:
+		slog.DebugContext(ctx, "Category keyword matched", "keyword", suggestion.Keyword.Keyword, "component", "rules")
+	default:
+		slog.DebugContext(ctx, "Category proposed by the classifier", "confidence", suggestion.Confidence, "component", "rules")
+	}
+	e.Primary = suggestion.Primary
+	e.Secondary = suggestion.Secondary
+	return e, nil
+}
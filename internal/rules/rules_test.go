@@ -0,0 +1,97 @@
+package rules
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"spese/internal/classifier"
+	"spese/internal/core"
+)
+
+type fakeStore []core.CategoryRule
+
+func (f fakeStore) ListActiveCategoryRules(context.Context) ([]core.CategoryRule, error) {
+	return f, nil
+}
+
+func TestCategorizer_Apply(t *testing.T) {
+	store := fakeStore{
+		{ID: 1, Name: "broken", Expression: `amount contains "x"`, Primary: "X", Secondary: "X"},
+		{ID: 2, Name: "weekend", Expression: `weekday >= 6 and amount_cents < 5000`, Primary: "Svago", Secondary: "Uscite"},
+		{ID: 3, Name: "spesa", Expression: `lower(description) contains "esselunga"`, Primary: "Casa", Secondary: "Alimentari"},
+	}
+	c := NewCategorizer(store)
+
+	base := core.Expense{
+		Date:        core.NewDate(2025, 3, 12), // Wednesday
+		Description: "ESSELUNGA Milano",
+		Amount:      core.Money{Cents: 3000},
+		Primary:     "Varie",
+		Secondary:   "Varie",
+	}
+
+	got, err := c.Apply(context.Background(), base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Primary != "Casa" || got.Secondary != "Alimentari" {
+		t.Errorf("weekday expense: got %s/%s, want Casa/Alimentari", got.Primary, got.Secondary)
+	}
+
+	// Saturday: the earlier weekend rule wins
+	sat := base
+	sat.Date = core.NewDate(2025, 3, 15)
+	got, _ = c.Apply(context.Background(), sat)
+	if got.Primary != "Svago" {
+		t.Errorf("weekend expense: got %s, want Svago", got.Primary)
+	}
+
+	// No match keeps the categories
+	other := base
+	other.Description = "Farmacia"
+	got, _ = c.Apply(context.Background(), other)
+	if got.Primary != "Varie" || got.Secondary != "Varie" {
+		t.Errorf("unmatched expense changed: %s/%s", got.Primary, got.Secondary)
+	}
+
+	// A nil categorizer is a no-op
+	var none *Categorizer
+	if got, err := none.Apply(context.Background(), base); err != nil || !reflect.DeepEqual(got, base) {
+		t.Errorf("nil categorizer: %+v, %v", got, err)
+	}
+}
+
+type fakeHistory []core.Expense
+
+func (f fakeHistory) ListTrainingExpenses(context.Context) ([]core.Expense, error) {
+	return f, nil
+}
+
+func TestCategorizer_ApplyClassifier(t *testing.T) {
+	var history fakeHistory
+	for _, desc := range []string{"Pizzeria Gino", "Cena pizzeria", "Pizzeria asporto", "Sushi cena", "Trattoria cena"} {
+		history = append(history, core.Expense{Description: desc, Primary: "Fuori (come fuori a cena...)", Secondary: "Ristoranti"})
+	}
+	history = append(history, core.Expense{Description: "Libreria Feltrinelli", Primary: "Divertimento", Secondary: "Libri e"})
+
+	c := NewCategorizer(fakeStore{})
+	c.SetClassifier(classifier.New(history, time.Hour), 0.6)
+	ctx := context.Background()
+
+	unknown := core.Expense{Date: core.NewDate(2025, 3, 12), Description: "PIZZERIA da Mario", Amount: core.Money{Cents: 3000}, Primary: core.UncategorizedPrimary, Secondary: core.UncategorizedSecondary}
+	got, err := c.Apply(ctx, unknown)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Secondary != "Ristoranti" {
+		t.Errorf("unknown expense: got %s/%s, want Ristoranti", got.Primary, got.Secondary)
+	}
+
+	// Proposals below the threshold are dropped
+	c.SetClassifier(classifier.New(history, time.Hour), 0.9999)
+	if got, _ := c.Apply(ctx, unknown); got.Secondary != core.UncategorizedSecondary {
+		t.Errorf("low confidence proposal applied: %s", got.Secondary)
+	}
+}
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"spese/internal/core"
+	"spese/internal/events"
+	"spese/internal/storage"
+)
+
+// Alert is a notification for the user, published on the event bus as
+// Event with Data unless the user silenced its type.
+type Alert struct {
+	Type  core.AlertType
+	Scope string // Primary category the alert is about, if any
+	Event string
+	Data  any
+}
+
+// AlertRouter delivers alerts to a user, dropping those the user muted or
+// snoozed on the preferences page.
+type AlertRouter struct {
+	storage *storage.SQLiteRepository
+	bus     *events.Bus
+	user    string
+	now     func() time.Time
+}
+
+// NewAlertRouter creates a router delivering the alerts of user on bus.
+func NewAlertRouter(storage *storage.SQLiteRepository, bus *events.Bus, user string) *AlertRouter {
+	return &AlertRouter{storage: storage, bus: bus, user: user, now: time.Now}
+}
+
+// Send publishes an alert unless the user's preferences silence it, and
+// reports whether it went out. When the preferences cannot be read the
+// alert is sent: a missed warning is worse than an unwanted one.
+func (r *AlertRouter) Send(ctx context.Context, alert Alert) bool {
+	prefs, err := r.storage.ListAlertPreferences(ctx, r.user)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to load alert preferences", "error", err, "user", r.user)
+	}
+	if core.AlertSilenced(prefs, alert.Type, alert.Scope, r.now()) {
+		slog.DebugContext(ctx, "Alert silenced", "type", alert.Type, "scope", alert.Scope, "user", r.user)
+		return false
+	}
+	if r.bus != nil {
+		r.bus.Publish(alert.Event, alert.Data)
+	}
+	return true
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"spese/internal/core"
+	"spese/internal/events"
+)
+
+func TestAlertRouter_Send(t *testing.T) {
+	ctx := context.Background()
+	repo := newPeerRepo(t, "alerts")
+	bus := events.NewBus()
+	sub, unsubscribe := bus.Subscribe(8)
+	defer unsubscribe()
+
+	now := time.Date(2031, 3, 10, 9, 0, 0, 0, time.UTC)
+	router := NewAlertRouter(repo, bus, core.DefaultAlertUser)
+	router.now = func() time.Time { return now }
+
+	prefs := []core.AlertPreference{
+		{User: core.DefaultAlertUser, Type: core.AlertBudget, Scope: "Spesa", Muted: true},
+		{User: core.DefaultAlertUser, Type: core.AlertSync, SnoozedUntil: now.AddDate(0, 0, 7)},
+		// Someone else's preferences do not count
+		{User: "other", Type: core.AlertReturnDue, Muted: true},
+	}
+	for _, p := range prefs {
+		if err := repo.SetAlertPreference(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cases := []struct {
+		name  string
+		alert Alert
+		sent  bool
+	}{
+		{"muted category", Alert{Type: core.AlertBudget, Scope: "Spesa", Event: events.InsightDetected}, false},
+		{"other category", Alert{Type: core.AlertBudget, Scope: "Casa", Event: events.InsightDetected}, true},
+		{"other type", Alert{Type: core.AlertSpending, Scope: "Spesa", Event: events.InsightDetected}, true},
+		{"snoozed", Alert{Type: core.AlertSync, Event: events.SyncFailed}, false},
+		{"other user's mute", Alert{Type: core.AlertReturnDue, Scope: "Casa", Event: events.ReturnDue}, true},
+	}
+	for _, tc := range cases {
+		if sent := router.Send(ctx, tc.alert); sent != tc.sent {
+			t.Errorf("%s: sent = %v, want %v", tc.name, sent, tc.sent)
+		}
+	}
+	if got := len(sub); got != 3 {
+		t.Errorf("published %d events, want 3", got)
+	}
+
+	// The snooze ends after a week
+	now = now.AddDate(0, 0, 7)
+	if !router.Send(ctx, Alert{Type: core.AlertSync, Event: events.SyncFailed}) {
+		t.Error("sync alert should go out once the snooze ends")
+	}
+
+	// Removing the mute lets budget alerts through again
+	if err := repo.DeleteAlertPreference(ctx, core.DefaultAlertUser, core.AlertBudget, "Spesa"); err != nil {
+		t.Fatal(err)
+	}
+	if !router.Send(ctx, Alert{Type: core.AlertBudget, Scope: "Spesa", Event: events.InsightDetected}) {
+		t.Error("budget alert should go out once unmuted")
+	}
+	if err := repo.DeleteAlertPreference(ctx, core.DefaultAlertUser, core.AlertBudget, "Spesa"); err == nil {
+		t.Error("deleting a missing preference should fail")
+	}
+}
+
+func TestInsightDetector_Alerts(t *testing.T) {
+	ctx := context.Background()
+	repo := newPeerRepo(t, "insight-alerts")
+	bus := events.NewBus()
+	sub, unsubscribe := bus.Subscribe(8)
+	defer unsubscribe()
+
+	for _, e := range []core.Expense{
+		{Date: core.NewDate(2031, 2, 10), Description: "Spesa", Amount: core.Money{Cents: 10000}, Primary: "Spesa", Secondary: "Varie"},
+		{Date: core.NewDate(2031, 3, 5), Description: "Spesa", Amount: core.Money{Cents: 15000}, Primary: "Spesa", Secondary: "Varie"},
+	} {
+		if _, err := repo.AppendAndEnqueueSync(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := repo.SetAlertPreference(ctx, core.AlertPreference{User: core.DefaultAlertUser, Type: core.AlertBudget, Muted: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	detector := NewInsightDetector(repo, NewAlertRouter(repo, bus, core.DefaultAlertUser))
+	if n, err := detector.Detect(ctx, time.Date(2031, 3, 10, 9, 0, 0, 0, time.UTC)); err != nil || n != 2 {
+		t.Fatalf("Detect = %d, %v, want 2", n, err)
+	}
+	// Both are recorded for the feed, only the spending one is an alert
+	if len(sub) != 1 {
+		t.Fatalf("published %d events, want 1", len(sub))
+	}
+	e := <-sub
+	payload, ok := e.Data.(events.InsightPayload)
+	if e.Type != events.InsightDetected || !ok || payload.Kind != string(core.InsightCategoryUp) || payload.Primary != "Spesa" || payload.ID == 0 {
+		t.Errorf("event = %+v", e)
+	}
+}
+
+func TestViewWatcher_AlertsOnNewMatches(t *testing.T) {
+	ctx := context.Background()
+	repo := newPeerRepo(t, "view-alerts")
+	bus := events.NewBus()
+	sub, unsubscribe := bus.Subscribe(8)
+	defer unsubscribe()
+
+	for _, v := range []core.SavedView{
+		{Name: "Grandi acquisti", Filter: core.ExpenseFilter{Min: core.Money{Cents: 50000}}, Notify: true},
+		// Views without notifications stay quiet
+		{Name: "Cene", Filter: core.ExpenseFilter{Text: "cena"}},
+	} {
+		if _, err := repo.CreateSavedView(ctx, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	svc := NewExpenseService(repo)
+	svc.SetViewWatcher(NewViewWatcher(repo, NewAlertRouter(repo, bus, core.DefaultAlertUser)))
+	if _, err := svc.CreateExpenses(ctx, []core.Expense{
+		{Date: core.NewDate(2031, 4, 2), Description: "Cena fuori", Amount: core.Money{Cents: 6000}, Primary: "Svago", Secondary: "Ristoranti"},
+		{Date: core.NewDate(2031, 4, 3), Description: "Divano", Amount: core.Money{Cents: 89000}, Primary: "Casa", Secondary: "Arredo"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := len(sub); got != 1 {
+		t.Fatalf("published %d events, want 1", got)
+	}
+	e := <-sub
+	p, ok := e.Data.(events.ViewMatchedPayload)
+	if e.Type != events.ViewMatched || !ok || p.View != "Grandi acquisti" || p.Expense.Description != "Divano" {
+		t.Errorf("event = %+v", e)
+	}
+
+	// Muting view alerts silences them
+	if err := repo.SetAlertPreference(ctx, core.AlertPreference{User: core.DefaultAlertUser, Type: core.AlertView, Muted: true}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.CreateExpense(ctx, core.Expense{Date: core.NewDate(2031, 4, 4), Description: "Frigo", Amount: core.Money{Cents: 70000}, Primary: "Casa", Secondary: "Arredo"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(sub); got != 0 {
+		t.Errorf("published %d events while muted, want 0", got)
+	}
+}
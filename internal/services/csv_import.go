@@ -0,0 +1,224 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	"spese/internal/core"
+	"spese/internal/csvimport"
+	"spese/internal/storage"
+)
+
+// csvPreviewTTL is how long an uploaded file waits to be confirmed
+const csvPreviewTTL = time.Hour
+
+var (
+	// ErrCSVPreviewNotFound is returned when confirming an import whose
+	// preview expired or was confirmed already.
+	ErrCSVPreviewNotFound = errors.New("import preview not found or expired")
+	// ErrCSVNothingToImport is returned when confirming an import without
+	// rows to create.
+	ErrCSVNothingToImport = errors.New("no rows to import")
+)
+
+// CSVImportRow is a row of an uploaded file as the preview shows it
+type CSVImportRow struct {
+	csvimport.Row
+	Duplicate bool // An expense with the same day, amount and description exists
+}
+
+// CSVImportPreview is an uploaded file waiting to be confirmed
+type CSVImportPreview struct {
+	Token      string
+	Filename   string
+	Rows       []CSVImportRow
+	Valid      int // Rows that can be created, duplicates included
+	Duplicates int
+	Invalid    int
+
+	expires time.Time
+}
+
+// CSVImportResult tells what a confirmed import created
+type CSVImportResult struct {
+	BatchID           int64 // Import batch to roll the import back with
+	Created           int
+	SkippedDuplicates int
+	SkippedInvalid    int
+}
+
+// CSVImporter imports expenses from CSV files in two steps: the upload is
+// parsed and checked into a preview, and the rows are created only once
+// the preview is confirmed. Previews are kept in memory until confirmed or
+// expired.
+type CSVImporter struct {
+	storage  *storage.SQLiteRepository
+	expenses *ExpenseService
+	now      func() time.Time
+
+	mu       sync.Mutex
+	previews map[string]*CSVImportPreview
+}
+
+// NewCSVImporter creates an importer saving the confirmed rows through
+// expenses.
+func NewCSVImporter(storage *storage.SQLiteRepository, expenses *ExpenseService) *CSVImporter {
+	return &CSVImporter{storage: storage, expenses: expenses, now: time.Now, previews: make(map[string]*CSVImportPreview)}
+}
+
+// Preview parses an uploaded file and checks its rows: invalid ones, those
+// dated in a closed month, and duplicates of expenses in the database. A
+// row is a duplicate while the database has more expenses with its day,
+// amount and description than the rows before it in the file, so a file
+// overlapping an earlier import is recognized while two equal coffees on
+// the same day are not.
+func (c *CSVImporter) Preview(ctx context.Context, filename string, r io.Reader) (*CSVImportPreview, error) {
+	rows, err := csvimport.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var from, to core.Date
+	for _, row := range rows {
+		if row.Err != nil {
+			continue
+		}
+		if from.IsZero() || row.Expense.Date.Before(from.Time) {
+			from = row.Expense.Date
+		}
+		if to.IsZero() || row.Expense.Date.After(to.Time) {
+			to = row.Expense.Date
+		}
+	}
+	existing := map[string]int{}
+	if !from.IsZero() {
+		if existing, err = c.storage.ExpenseFingerprints(ctx, from, to); err != nil {
+			return nil, err
+		}
+	}
+	closedMonths, err := c.storage.ListClosedMonths(ctx)
+	if err != nil {
+		return nil, err
+	}
+	closed := make(map[string]bool, len(closedMonths))
+	for _, m := range closedMonths {
+		closed[m.Period] = true
+	}
+
+	preview := &CSVImportPreview{Filename: filename, Rows: make([]CSVImportRow, len(rows))}
+	for i, row := range rows {
+		if row.Err == nil && closed[core.Period(row.Expense.Date.Time)] {
+			row.Err = fmt.Errorf("%w: %s", core.ErrMonthClosed, core.Period(row.Expense.Date.Time))
+		}
+		item := CSVImportRow{Row: row}
+		if row.Err != nil {
+			preview.Invalid++
+		} else {
+			preview.Valid++
+			if key := core.ExpenseFingerprint(row.Expense); existing[key] > 0 {
+				existing[key]--
+				item.Duplicate = true
+				preview.Duplicates++
+			}
+		}
+		preview.Rows[i] = item
+	}
+
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return nil, fmt.Errorf("generate token: %w", err)
+	}
+	preview.Token = hex.EncodeToString(token)
+
+	now := c.now()
+	preview.expires = now.Add(csvPreviewTTL)
+	c.mu.Lock()
+	for t, p := range c.previews {
+		if now.After(p.expires) {
+			delete(c.previews, t)
+		}
+	}
+	c.previews[preview.Token] = preview
+	c.mu.Unlock()
+
+	slog.InfoContext(ctx, "CSV import previewed", "file", filename, "rows", len(rows), "valid", preview.Valid, "duplicates", preview.Duplicates, "invalid", preview.Invalid)
+	return preview, nil
+}
+
+// Confirm creates the valid rows of a preview in one transaction, the
+// duplicates only when asked to. A preview is confirmed once; when saving
+// fails, or there is nothing to create, it can be confirmed again. A row rejected by the before-save hook
+// fails the import with an error naming its line.
+func (c *CSVImporter) Confirm(ctx context.Context, token string, includeDuplicates bool) (CSVImportResult, error) {
+	c.mu.Lock()
+	preview, ok := c.previews[token]
+	if ok && c.now().After(preview.expires) {
+		ok = false
+	}
+	// Taken out while saving, so a second confirmation cannot create the
+	// rows twice
+	delete(c.previews, token)
+	c.mu.Unlock()
+	if !ok {
+		return CSVImportResult{}, ErrCSVPreviewNotFound
+	}
+
+	var result CSVImportResult
+	var expenses []core.Expense
+	var lines []int
+	for _, row := range preview.Rows {
+		switch {
+		case row.Err != nil:
+			result.SkippedInvalid++
+		case row.Duplicate && !includeDuplicates:
+			result.SkippedDuplicates++
+		default:
+			expenses = append(expenses, row.Expense)
+			lines = append(lines, row.Line)
+		}
+	}
+	if len(expenses) == 0 {
+		c.restore(preview)
+		return result, ErrCSVNothingToImport
+	}
+
+	batchID, refs, err := c.expenses.CreateImportedExpenses(ctx, preview.Filename, expenses)
+	if err != nil {
+		c.restore(preview)
+		var itemErr *BatchItemError
+		if errors.As(err, &itemErr) {
+			return result, fmt.Errorf("line %d: %w", lines[itemErr.Index], itemErr.Err)
+		}
+		return result, err
+	}
+	result.BatchID = batchID
+	result.Created = len(refs)
+
+	slog.InfoContext(ctx, "CSV import confirmed", "file", preview.Filename, "batch_id", batchID, "created", result.Created, "skipped_duplicates", result.SkippedDuplicates, "skipped_invalid", result.SkippedInvalid)
+	return result, nil
+}
+
+// Batches lists the latest limit imports, newest first.
+func (c *CSVImporter) Batches(ctx context.Context, limit int64) ([]storage.ListImportBatchesRow, error) {
+	return c.storage.ListImportBatches(ctx, limit)
+}
+
+// Rollback deletes the expenses an import created, removing their rows
+// from Google Sheets through the sync queue, and returns how many.
+func (c *CSVImporter) Rollback(ctx context.Context, batchID int64) (int, error) {
+	return c.storage.RollbackImportBatch(ctx, batchID)
+}
+
+// restore puts back a preview taken out by Confirm
+func (c *CSVImporter) restore(preview *CSVImportPreview) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.previews[preview.Token] = preview
+}
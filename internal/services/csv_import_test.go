@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"spese/internal/core"
+	"spese/internal/storage"
+)
+
+func TestCSVImporter(t *testing.T) {
+	ctx := context.Background()
+	repo := newPeerRepo(t, "csv")
+	importer := NewCSVImporter(repo, NewExpenseService(repo))
+
+	if _, err := repo.Append(ctx, core.Expense{Date: core.NewDate(2031, 3, 2), Description: "Bar centrale", Amount: core.Money{Cents: 320}, Primary: "Cibo", Secondary: "Bar"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.CloseMonth(ctx, "2031-01"); err != nil {
+		t.Fatal(err)
+	}
+
+	file := "data;descrizione;importo\n" +
+		"2031-03-02;bar  CENTRALE;3,20\n" + // Already in the database
+		"2031-03-02;Bar centrale;3,20\n" + // A second coffee that day
+		"2031-03-05;Libreria;18,00\n" +
+		"2031-01-10;Mese chiuso;5,00\n" +
+		"2031-03-06;Senza importo;\n"
+	preview, err := importer.Preview(ctx, "banca.csv", strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preview.Valid != 3 || preview.Duplicates != 1 || preview.Invalid != 2 {
+		t.Fatalf("expected 3 valid rows, 1 duplicate and 2 invalid, got %d/%d/%d", preview.Valid, preview.Duplicates, preview.Invalid)
+	}
+	if !preview.Rows[0].Duplicate || preview.Rows[1].Duplicate {
+		t.Fatalf("expected only the first coffee marked duplicate: %+v", preview.Rows[:2])
+	}
+	if !errors.Is(preview.Rows[3].Err, core.ErrMonthClosed) {
+		t.Fatalf("expected the row in a closed month refused, got %v", preview.Rows[3].Err)
+	}
+
+	result, err := importer.Confirm(ctx, preview.Token, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Created != 2 || result.SkippedDuplicates != 1 || result.SkippedInvalid != 2 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	list, err := repo.ListExpenses(ctx, 2031, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 3 {
+		t.Fatalf("expected 3 expenses in March, got %+v", list)
+	}
+	for _, e := range list {
+		if e.Description == "Libreria" && (e.Primary != core.UncategorizedPrimary || e.Secondary != core.UncategorizedSecondary) {
+			t.Fatalf("expected the row without categories left uncategorized, got %+v", e)
+		}
+	}
+
+	if _, err := importer.Confirm(ctx, preview.Token, false); !errors.Is(err, ErrCSVPreviewNotFound) {
+		t.Fatalf("expected a preview confirmed once, got %v", err)
+	}
+
+	// Uploading the same file again finds every row imported
+	preview, err = importer.Preview(ctx, "banca.csv", strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preview.Duplicates != 3 {
+		t.Fatalf("expected 3 duplicates on the second upload, got %d", preview.Duplicates)
+	}
+	if _, err := importer.Confirm(ctx, preview.Token, false); !errors.Is(err, ErrCSVNothingToImport) {
+		t.Fatalf("expected nothing to import, got %v", err)
+	}
+	// The preview is kept, to import the duplicates after all
+	if result, err := importer.Confirm(ctx, preview.Token, true); err != nil || result.Created != 3 {
+		t.Fatalf("expected the 3 duplicates created, got %+v (%v)", result, err)
+	}
+}
+
+func TestCSVImporter_Rollback(t *testing.T) {
+	ctx := context.Background()
+	repo := newPeerRepo(t, "csv-rollback")
+	importer := NewCSVImporter(repo, NewExpenseService(repo))
+
+	file := "data;descrizione;importo\n" +
+		"2031-04-02;Bar;3,20\n" +
+		"2031-04-03;Libreria;18,00\n"
+	preview, err := importer.Preview(ctx, "banca.csv", strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := importer.Confirm(ctx, preview.Token, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.BatchID == 0 || result.Created != 2 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	// The first row reached the sheet, the second is still queued
+	items, err := repo.DequeueSyncBatch(ctx, 10)
+	if err != nil || len(items) != 2 {
+		t.Fatalf("expected 2 sync items, got %d (%v)", len(items), err)
+	}
+	if err := repo.MarkSyncComplete(ctx, items[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.MarkSynced(ctx, items[0].ExpenseID); err != nil {
+		t.Fatal(err)
+	}
+
+	deleted, err := importer.Rollback(ctx, result.BatchID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 2 {
+		t.Fatalf("expected 2 expenses deleted, got %d", deleted)
+	}
+	list, err := repo.ListExpenses(ctx, 2031, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 0 {
+		t.Fatalf("expected the imported expenses gone, got %+v", list)
+	}
+	open, err := repo.OpenSyncItems(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(open) != 1 || open[0].Operation != "delete" || open[0].ExpenseID != items[0].ExpenseID {
+		t.Fatalf("expected only a delete item for the synced expense, got %+v", open)
+	}
+
+	batches, err := importer.Batches(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(batches) != 1 || !batches[0].RolledBackAt.Valid || batches[0].Remaining != 0 || batches[0].Expenses != 2 {
+		t.Fatalf("unexpected batches %+v", batches)
+	}
+	if _, err := importer.Rollback(ctx, result.BatchID); !errors.Is(err, storage.ErrImportBatchRolledBack) {
+		t.Fatalf("expected a batch rolled back once, got %v", err)
+	}
+	if _, err := importer.Rollback(ctx, 999); !errors.Is(err, storage.ErrImportBatchNotFound) {
+		t.Fatalf("expected an unknown batch refused, got %v", err)
+	}
+}
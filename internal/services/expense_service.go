@@ -4,14 +4,21 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"strconv"
 
 	"spese/internal/core"
+	"spese/internal/hooks"
+	"spese/internal/rules"
 	"spese/internal/storage"
 )
 
 // ExpenseService orchestrates expense operations with SQLite sync queue
 type ExpenseService struct {
 	storage *storage.SQLiteRepository
+	hooks   *hooks.Runner
+	rules   *rules.Categorizer
+	views   *ViewWatcher
+	quotas  *Quotas
 }
 
 func NewExpenseService(storage *storage.SQLiteRepository) *ExpenseService {
@@ -20,26 +27,177 @@ func NewExpenseService(storage *storage.SQLiteRepository) *ExpenseService {
 	}
 }
 
-// CreateExpense saves an expense and enqueues it for sync atomically
+// SetHooks enables user hooks around expense creation
+func (s *ExpenseService) SetHooks(h *hooks.Runner) {
+	s.hooks = h
+}
+
+// SetCategorizer enables categorization rules on new expenses
+func (s *ExpenseService) SetCategorizer(c *rules.Categorizer) {
+	s.rules = c
+}
+
+// SetViewWatcher sends saved view alerts for new expenses
+func (s *ExpenseService) SetViewWatcher(w *ViewWatcher) {
+	s.views = w
+}
+
+// SetQuotas limits the expenses members create with their API keys
+func (s *ExpenseService) SetQuotas(q *Quotas) {
+	s.quotas = q
+}
+
+// CreateExpense saves an expense and enqueues it for sync atomically.
+// Categorization rules run first, then the before-save hook; an expense
+// the hook accepts counts against the daily quota of the member in ctx.
 func (s *ExpenseService) CreateExpense(ctx context.Context, e core.Expense) (string, error) {
+	if categorized, err := s.rules.Apply(ctx, e); err != nil {
+		slog.WarnContext(ctx, "Category rules unavailable, keeping categories", "error", err)
+	} else {
+		e = categorized
+	}
+
+	e, err := s.hooks.BeforeExpenseSave(ctx, e)
+	if err != nil {
+		return "", err
+	}
+	release, err := s.quotas.ReserveExpenses(ctx, 1)
+	if err != nil {
+		return "", err
+	}
+
 	// Use atomic transaction: save expense + enqueue sync in single transaction
 	ref, err := s.storage.AppendAndEnqueueSync(ctx, e)
 	if err != nil {
+		release()
 		return "", fmt.Errorf("save expense: %w", err)
 	}
 
 	slog.DebugContext(ctx, "Created expense and enqueued sync", "id", ref)
+	s.hooks.AfterExpenseSave(ctx, e, ref)
+	s.views.Check(ctx, []core.Expense{e}, []string{ref})
 	return ref, nil
 }
 
-// DeleteExpense hard deletes an expense and enqueues delete sync atomically
+// BatchItemError reports the item of a batch that stopped it.
+type BatchItemError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchItemError) Error() string {
+	return fmt.Sprintf("item %d: %v", e.Index, e.Err)
+}
+
+func (e *BatchItemError) Unwrap() error {
+	return e.Err
+}
+
+// CreateExpenses saves several expenses in one transaction, running the
+// categorization rules and the before-save hook on each first. A hook
+// rejecting any item aborts the whole batch with a *BatchItemError; the
+// batch must fit the daily quota as a whole.
+func (s *ExpenseService) CreateExpenses(ctx context.Context, expenses []core.Expense) ([]string, error) {
+	prepared, err := s.prepareBatch(ctx, expenses)
+	if err != nil {
+		return nil, err
+	}
+	release, err := s.quotas.ReserveExpenses(ctx, len(prepared))
+	if err != nil {
+		return nil, err
+	}
+
+	refs, err := s.storage.AppendBatchAndEnqueueSync(ctx, prepared)
+	if err != nil {
+		release()
+		return nil, fmt.Errorf("save expenses: %w", err)
+	}
+
+	slog.DebugContext(ctx, "Created expense batch and enqueued sync", "count", len(refs))
+	s.afterBatch(ctx, prepared, refs)
+	return refs, nil
+}
+
+// CreateImportedExpenses saves the expenses of a file import like
+// CreateExpenses, tagged with an import batch for filename that can be
+// rolled back as a whole. It returns the batch ID and the expense IDs.
+func (s *ExpenseService) CreateImportedExpenses(ctx context.Context, filename string, expenses []core.Expense) (int64, []string, error) {
+	prepared, err := s.prepareBatch(ctx, expenses)
+	if err != nil {
+		return 0, nil, err
+	}
+	release, err := s.quotas.ReserveExpenses(ctx, len(prepared))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	batchID, refs, err := s.storage.AppendImportBatchAndEnqueueSync(ctx, filename, prepared)
+	if err != nil {
+		release()
+		return 0, nil, fmt.Errorf("save expenses: %w", err)
+	}
+
+	slog.DebugContext(ctx, "Created import batch and enqueued sync", "batch_id", batchID, "count", len(refs))
+	s.afterBatch(ctx, prepared, refs)
+	return batchID, refs, nil
+}
+
+// prepareBatch runs the categorization rules and the before-save hook on
+// each expense of a batch
+func (s *ExpenseService) prepareBatch(ctx context.Context, expenses []core.Expense) ([]core.Expense, error) {
+	prepared := make([]core.Expense, len(expenses))
+	for i, e := range expenses {
+		if categorized, err := s.rules.Apply(ctx, e); err != nil {
+			slog.WarnContext(ctx, "Category rules unavailable, keeping categories", "error", err)
+		} else {
+			e = categorized
+		}
+
+		e, err := s.hooks.BeforeExpenseSave(ctx, e)
+		if err != nil {
+			return nil, &BatchItemError{Index: i, Err: err}
+		}
+		prepared[i] = e
+	}
+	return prepared, nil
+}
+
+// afterBatch runs the after-save hook and the saved view alerts for a
+// stored batch
+func (s *ExpenseService) afterBatch(ctx context.Context, prepared []core.Expense, refs []string) {
+	for i, e := range prepared {
+		s.hooks.AfterExpenseSave(ctx, e, refs[i])
+	}
+	s.views.Check(ctx, prepared, refs)
+}
+
+// UpdateExpense replaces an expense edited by the user, based on the given
+// version. The before-save hook runs first; categorization rules do not,
+// the user picked the categories.
+func (s *ExpenseService) UpdateExpense(ctx context.Context, id int64, e core.Expense, version int64) error {
+	e, err := s.hooks.BeforeExpenseSave(ctx, e)
+	if err != nil {
+		return err
+	}
+
+	if err := s.storage.UpdateExpense(ctx, id, e, version); err != nil {
+		return fmt.Errorf("update expense: %w", err)
+	}
+
+	ref := strconv.FormatInt(id, 10)
+	slog.DebugContext(ctx, "Updated expense", "id", ref)
+	s.hooks.AfterExpenseSave(ctx, e, ref)
+	return nil
+}
+
+// DeleteExpense moves an expense to the trash; it is deleted from Google
+// Sheets when the trash is purged
 func (s *ExpenseService) DeleteExpense(ctx context.Context, id int64) error {
-	// Use atomic transaction: delete expense + enqueue delete sync
-	if err := s.storage.HardDeleteAndEnqueueSync(ctx, id); err != nil {
+	if err := s.storage.TrashExpense(ctx, id); err != nil {
 		return fmt.Errorf("delete expense: %w", err)
 	}
 
-	slog.DebugContext(ctx, "Deleted expense and enqueued sync", "id", id)
+	slog.DebugContext(ctx, "Moved expense to trash", "id", id)
 	return nil
 }
 
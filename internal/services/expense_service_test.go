@@ -1,7 +1,15 @@
 package services
 
 import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
+
+	"spese/internal/core"
+	"spese/internal/hooks"
 )
 
 func TestNewExpenseService(t *testing.T) {
@@ -38,3 +46,37 @@ func TestExpenseService_Close(t *testing.T) {
 		}
 	})
 }
+
+func TestExpenseService_CreateExpenses(t *testing.T) {
+	ctx := context.Background()
+	repo := newPeerRepo(t, "batch")
+	service := NewExpenseService(repo)
+
+	hook := filepath.Join(t.TempDir(), "hook.sh")
+	if err := os.WriteFile(hook, []byte("#!/bin/sh\nif grep -q Rifiutata; then echo 'not allowed' >&2; exit 1; fi\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	service.SetHooks(hooks.NewRunner(map[string]string{hooks.BeforeExpenseSave: hook}, 5*time.Second))
+
+	expense := func(desc string) core.Expense {
+		return core.Expense{Date: core.NewDate(2025, 4, 2), Description: desc, Amount: core.Money{Cents: 990}, Primary: "Casa", Secondary: "Spesa"}
+	}
+
+	refs, err := service.CreateExpenses(ctx, []core.Expense{expense("Batch uno"), expense("Batch due")})
+	if err != nil || len(refs) != 2 || refs[0] == refs[1] {
+		t.Fatalf("CreateExpenses = %v, %v; want two ids", refs, err)
+	}
+	if n := len(findExpenses(t, repo, "Batch uno")) + len(findExpenses(t, repo, "Batch due")); n != 2 {
+		t.Errorf("stored %d batch expenses, want 2", n)
+	}
+
+	// One rejected item keeps the whole batch out
+	_, err = service.CreateExpenses(ctx, []core.Expense{expense("Batch tre"), expense("Rifiutata")})
+	var itemErr *BatchItemError
+	if !errors.As(err, &itemErr) || itemErr.Index != 1 || !errors.Is(err, hooks.ErrRejected) {
+		t.Fatalf("err = %v, want item 1 rejected", err)
+	}
+	if found := findExpenses(t, repo, "Batch tre"); len(found) != 0 {
+		t.Errorf("rejected batch stored %+v", found)
+	}
+}
@@ -0,0 +1,309 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"spese/internal/core"
+	ports "spese/internal/sheets"
+	"spese/internal/storage"
+)
+
+// unknownSecondary is the catch-all secondary category of the legacy
+// mapping, given to history rows without a category
+const unknownSecondary = "Unknown"
+
+// historyChunkRows is how many sheet rows are stored per transaction: an
+// interrupted import loses at most one chunk of work
+const historyChunkRows = 200
+
+var (
+	// ErrHistoryYearNotPast is returned when importing the current year or a
+	// later one, which are left to the sync.
+	ErrHistoryYearNotPast = errors.New("only past years can be imported")
+	// ErrHistoryImportRunning is returned when the year is being imported
+	// already.
+	ErrHistoryImportRunning = errors.New("history import already running")
+	// ErrHistoryYearHasExpenses is returned when a year never imported has
+	// expenses in the database, which the import would duplicate.
+	ErrHistoryYearHasExpenses = errors.New("year has expenses in the database")
+)
+
+// HistoryImportReport tells what a history import did
+type HistoryImportReport struct {
+	Imported map[int]int // Expenses imported per year
+	Skipped  []int       // Years with expenses in the database already
+}
+
+// HistoryYearStatus is the import state of a year with an expenses sheet
+type HistoryYearStatus struct {
+	Year     int
+	Started  bool
+	Running  bool
+	Progress storage.ImportedHistoryYear // Set once Started
+	Existing int                         // Expenses in the database when never started
+}
+
+// Percent returns how much of the sheet was stored, from 0 to 100.
+func (s HistoryYearStatus) Percent() int {
+	switch {
+	case s.Progress.Completed():
+		return 100
+	case s.Progress.TotalRows == 0:
+		return 0
+	}
+	return s.Progress.NextRow * 100 / s.Progress.TotalRows
+}
+
+// HistoryImporter copies the expenses sheets of past years from Google
+// Sheets into the database, so the history predating the app can be
+// queried locally. Rows are stored in chunks behind a checkpoint, so an
+// interrupted import resumes where it stopped and a completed year is
+// never imported again.
+type HistoryImporter struct {
+	storage *storage.SQLiteRepository
+	sheets  ports.HistoryReader
+	now     func() time.Time
+	chunk   int
+
+	mu      sync.Mutex
+	running map[int]bool
+}
+
+// NewHistoryImporter creates an importer reading the history from sheets.
+func NewHistoryImporter(storage *storage.SQLiteRepository, sheets ports.HistoryReader) *HistoryImporter {
+	return &HistoryImporter{storage: storage, sheets: sheets, now: time.Now, chunk: historyChunkRows, running: make(map[int]bool)}
+}
+
+// Import copies every past year with an expenses sheet that was not
+// imported completely, resuming the interrupted ones. Years never started
+// that have expenses in the database are skipped, as they would otherwise
+// end up twice. The current year is left to the sync.
+func (h *HistoryImporter) Import(ctx context.Context) (HistoryImportReport, error) {
+	report := HistoryImportReport{Imported: make(map[int]int)}
+
+	years, err := h.sheets.ListExpenseYears(ctx)
+	if err != nil {
+		return report, fmt.Errorf("list expense sheets: %w", err)
+	}
+	done, err := h.storage.ListImportedHistoryYears(ctx)
+	if err != nil {
+		return report, err
+	}
+	completed := make(map[int]bool, len(done))
+	for _, y := range done {
+		completed[y.Year] = y.Completed()
+	}
+
+	currentYear := h.now().Year()
+	for _, year := range years {
+		if year >= currentYear || completed[year] {
+			continue
+		}
+
+		n, err := h.ImportYear(ctx, year)
+		switch {
+		case errors.Is(err, ErrHistoryYearHasExpenses):
+			slog.WarnContext(ctx, "Skipping history year with expenses in the database", "year", year)
+			report.Skipped = append(report.Skipped, year)
+			continue
+		case errors.Is(err, ErrHistoryImportRunning):
+			continue
+		case err != nil:
+			return report, err
+		}
+		report.Imported[year] = n
+	}
+	return report, nil
+}
+
+// ImportYear imports a past year, resuming from its checkpoint, and
+// returns how many expenses this run stored. A completed year stores
+// nothing.
+func (h *HistoryImporter) ImportYear(ctx context.Context, year int) (int, error) {
+	if err := h.claim(year); err != nil {
+		return 0, err
+	}
+	defer h.release(year)
+	return h.importYear(ctx, year)
+}
+
+// Start imports a past year in the background, for the admin page: the
+// progress is followed through Status. The import outlives the request;
+// if the process stops, the next run resumes from the checkpoint.
+func (h *HistoryImporter) Start(ctx context.Context, year int) error {
+	if err := h.claim(year); err != nil {
+		return err
+	}
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		defer h.release(year)
+		n, err := h.importYear(ctx, year)
+		if err != nil {
+			slog.ErrorContext(ctx, "Google Sheets history import failed", "error", err, "year", year, "imported", n)
+		}
+	}()
+	return nil
+}
+
+// Status returns the import state of the past years with an expenses
+// sheet, oldest first.
+func (h *HistoryImporter) Status(ctx context.Context) ([]HistoryYearStatus, error) {
+	years, err := h.sheets.ListExpenseYears(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list expense sheets: %w", err)
+	}
+	done, err := h.storage.ListImportedHistoryYears(ctx)
+	if err != nil {
+		return nil, err
+	}
+	progress := make(map[int]storage.ImportedHistoryYear, len(done))
+	for _, y := range done {
+		progress[y.Year] = y
+	}
+
+	h.mu.Lock()
+	running := make(map[int]bool, len(h.running))
+	for year := range h.running {
+		running[year] = true
+	}
+	h.mu.Unlock()
+
+	currentYear := h.now().Year()
+	var statuses []HistoryYearStatus
+	for _, year := range years {
+		if year >= currentYear {
+			continue
+		}
+		status := HistoryYearStatus{Year: year, Running: running[year]}
+		status.Progress, status.Started = progress[year]
+		if !status.Started {
+			if status.Existing, err = h.storage.CountYearExpenses(ctx, year); err != nil {
+				return nil, err
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+func (h *HistoryImporter) claim(year int) error {
+	if year >= h.now().Year() {
+		return fmt.Errorf("%w: %d", ErrHistoryYearNotPast, year)
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.running[year] {
+		return fmt.Errorf("%w: %d", ErrHistoryImportRunning, year)
+	}
+	h.running[year] = true
+	return nil
+}
+
+func (h *HistoryImporter) release(year int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.running, year)
+}
+
+// importYear stores the rows of the sheet of a year past the checkpoint,
+// a chunk per transaction. A failure is recorded on the year and the
+// checkpoint stays at the last stored chunk.
+func (h *HistoryImporter) importYear(ctx context.Context, year int) (int, error) {
+	progress, started, err := h.storage.GetHistoryImport(ctx, year)
+	if err != nil {
+		return 0, err
+	}
+	if progress.Completed() {
+		return 0, nil
+	}
+	if !started {
+		count, err := h.storage.CountYearExpenses(ctx, year)
+		if err != nil {
+			return 0, err
+		}
+		if count > 0 {
+			return 0, fmt.Errorf("%w: %d has %d", ErrHistoryYearHasExpenses, year, count)
+		}
+	}
+
+	rows, err := h.sheets.ListYearExpenses(ctx, year)
+	if err != nil {
+		err = fmt.Errorf("read expenses of %d: %w", year, err)
+		if started {
+			h.fail(ctx, year, err)
+		}
+		return 0, err
+	}
+	if started && progress.TotalRows != len(rows) {
+		slog.WarnContext(ctx, "History sheet changed since its import started", "year", year, "rows", len(rows), "previous_rows", progress.TotalRows)
+	}
+	primaries, err := h.storage.CategoryMappings(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if err := h.storage.StartHistoryImport(ctx, year, len(rows)); err != nil {
+		return 0, err
+	}
+
+	imported := 0
+	for from := progress.NextRow; from < len(rows); {
+		next := min(from+h.chunk, len(rows))
+		expenses := make([]core.Expense, 0, next-from)
+		for _, e := range rows[from:next] {
+			// Days missing from the sheet roll the date over to another year
+			if e.Date.Year() != year {
+				slog.WarnContext(ctx, "Skipping history row with an invalid date", "year", year, "description", e.Description)
+				continue
+			}
+			// The database only holds positive amounts
+			if e.Amount.Cents <= 0 {
+				slog.WarnContext(ctx, "Skipping history row without an amount", "year", year, "description", e.Description)
+				continue
+			}
+			expenses = append(expenses, mapLegacyCategories(e, primaries))
+		}
+		if err := h.storage.ImportHistoryRows(ctx, year, from, next, expenses); err != nil {
+			h.fail(ctx, year, err)
+			return imported, err
+		}
+		imported += len(expenses)
+		from = next
+	}
+
+	if err := h.storage.CompleteHistoryImport(ctx, year); err != nil {
+		return imported, err
+	}
+	return imported, nil
+}
+
+// fail records the error that stopped the import of a year, even when it
+// was the context being canceled.
+func (h *HistoryImporter) fail(ctx context.Context, year int, cause error) {
+	if err := h.storage.FailHistoryImport(context.WithoutCancel(ctx), year, cause.Error()); err != nil {
+		slog.WarnContext(ctx, "Failed to record history import error", "error", err, "year", year)
+	}
+}
+
+// mapLegacyCategories files an expense from an old sheet under the primary
+// category its secondary category maps to in primaries, the category
+// mappings. Unknown secondary categories keep the primary of the sheet;
+// rows without categories go to the catch-all.
+func mapLegacyCategories(e core.Expense, primaries map[string]string) core.Expense {
+	e.Primary = strings.TrimSpace(e.Primary)
+	e.Secondary = strings.TrimSpace(e.Secondary)
+	if e.Secondary == "" {
+		e.Secondary = unknownSecondary
+	}
+	if primary, ok := primaries[e.Secondary]; ok {
+		e.Primary = primary
+	} else if e.Primary == "" {
+		e.Primary = primaries[unknownSecondary]
+	}
+	return e
+}
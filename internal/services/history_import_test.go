@@ -0,0 +1,184 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"spese/internal/core"
+)
+
+type fakeHistory struct {
+	years map[int][]core.Expense
+	err   error
+}
+
+func (f fakeHistory) ListExpenseYears(ctx context.Context) ([]int, error) {
+	return []int{2030, 2031, 2033}, nil
+}
+
+func (f fakeHistory) ListYearExpenses(ctx context.Context, year int) ([]core.Expense, error) {
+	return f.years[year], f.err
+}
+
+func TestHistoryImporter_Import(t *testing.T) {
+	ctx := context.Background()
+	repo := newPeerRepo(t, "history")
+
+	expense := func(year, month, day int, desc, primary, secondary string) core.Expense {
+		return core.Expense{Date: core.NewDate(year, month, day), Description: desc, Amount: core.Money{Cents: 1000}, Primary: primary, Secondary: secondary}
+	}
+	sheets := fakeHistory{years: map[int][]core.Expense{
+		2030: {
+			expense(2030, 1, 5, "Spesa vecchia", "Cibo", "Supermercato"),
+			expense(2030, 2, 8, "Crociera", "Viaggi", "Crociera"),
+			expense(2030, 3, 9, "Senza categoria", "", ""),
+			expense(2030, 1, 0, "Giorno mancante", "Casa", "Mutuo"),
+		},
+		2031: {expense(2031, 1, 1, "Doppione", "Casa", "Mutuo")},
+		2033: {expense(2033, 1, 1, "Anno in corso", "Casa", "Mutuo")},
+	}}
+	// 2031 already has expenses entered in the app
+	if _, err := repo.Append(ctx, expense(2031, 6, 1, "Dall'app", "Casa", "Mutuo")); err != nil {
+		t.Fatal(err)
+	}
+
+	importer := NewHistoryImporter(repo, sheets)
+	importer.now = func() time.Time { return time.Date(2033, 3, 1, 0, 0, 0, 0, time.UTC) }
+
+	report, err := importer.Import(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Imported) != 1 || report.Imported[2030] != 3 {
+		t.Fatalf("expected 3 expenses imported for 2030, got %v", report.Imported)
+	}
+	if len(report.Skipped) != 1 || report.Skipped[0] != 2031 {
+		t.Fatalf("expected 2031 skipped, got %v", report.Skipped)
+	}
+
+	want := map[string][2]string{
+		"Spesa vecchia":   {"Spesa", "Supermercato"},
+		"Crociera":        {"Viaggi", "Crociera"},
+		"Senza categoria": {"Altre spese", "Unknown"},
+	}
+	for month := 1; month <= 3; month++ {
+		list, err := repo.ListExpenses(ctx, 2030, month)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range list {
+			cats, ok := want[e.Description]
+			if !ok {
+				t.Fatalf("unexpected expense imported: %+v", e)
+			}
+			if e.Primary != cats[0] || e.Secondary != cats[1] {
+				t.Fatalf("%s: expected %v, got %s / %s", e.Description, cats, e.Primary, e.Secondary)
+			}
+			delete(want, e.Description)
+		}
+	}
+	if len(want) != 0 {
+		t.Fatalf("expenses not imported: %v", want)
+	}
+
+	// The history is in the spreadsheet already: nothing to sync
+	pending, err := repo.GetPendingSyncExpenses(ctx, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	imported, err := repo.ListExpensesWithID(ctx, 2030, 1)
+	if err != nil || len(imported) != 1 {
+		t.Fatalf("expected the January expense, got %v (%v)", imported, err)
+	}
+	for _, p := range pending {
+		if strconv.FormatInt(p.ID, 10) == imported[0].ID {
+			t.Fatalf("imported expense pending sync: %+v", p)
+		}
+	}
+
+	// A second run finds nothing left to import
+	report, err = importer.Import(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Imported) != 0 {
+		t.Fatalf("expected nothing imported on the second run, got %v", report.Imported)
+	}
+	if n, err := repo.CountYearExpenses(ctx, 2030); err != nil || n != 3 {
+		t.Fatalf("expected 3 expenses in 2030, got %d (%v)", n, err)
+	}
+}
+
+func TestHistoryImporter_ResumesInterruptedYear(t *testing.T) {
+	ctx := context.Background()
+	repo := newPeerRepo(t, "history-resume")
+
+	var rows []core.Expense
+	for day := 1; day <= 7; day++ {
+		rows = append(rows, core.Expense{Date: core.NewDate(2031, 4, day), Description: "Riga " + strconv.Itoa(day), Amount: core.Money{Cents: int64(day) * 100}, Primary: "Casa", Secondary: "Mutuo"})
+	}
+	rows[4].Amount.Cents = 0 // Skipped, but still a row of the sheet
+
+	// A previous run stored the first chunk and stopped reading the sheet
+	if err := repo.StartHistoryImport(ctx, 2031, len(rows)); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.ImportHistoryRows(ctx, 2031, 0, 3, rows[:3]); err != nil {
+		t.Fatal(err)
+	}
+	importer := NewHistoryImporter(repo, fakeHistory{err: errors.New("quota exceeded")})
+	importer.now = func() time.Time { return time.Date(2033, 3, 1, 0, 0, 0, 0, time.UTC) }
+	importer.chunk = 3
+	if _, err := importer.ImportYear(ctx, 2031); err == nil {
+		t.Fatal("expected the sheet read error")
+	}
+	progress, started, err := repo.GetHistoryImport(ctx, 2031)
+	if err != nil || !started {
+		t.Fatalf("expected the import of 2031 recorded, got %v (%v)", started, err)
+	}
+	if progress.NextRow != 3 || progress.Expenses != 3 || progress.Completed() || progress.LastError == "" {
+		t.Fatalf("expected the import stopped at row 3 with its error, got %+v", progress)
+	}
+
+	// Resuming stores the rest only, chunk by chunk
+	importer.sheets = fakeHistory{years: map[int][]core.Expense{2031: rows}}
+	n, err := importer.ImportYear(ctx, 2031)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Fatalf("expected 3 expenses imported on resume, got %d", n)
+	}
+	if count, err := repo.CountYearExpenses(ctx, 2031); err != nil || count != 6 {
+		t.Fatalf("expected 6 expenses in 2031, got %d (%v)", count, err)
+	}
+	progress, _, err = repo.GetHistoryImport(ctx, 2031)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !progress.Completed() || progress.NextRow != 7 || progress.Expenses != 6 || progress.LastError != "" {
+		t.Fatalf("expected the import completed, got %+v", progress)
+	}
+
+	// A stale chunk is refused rather than stored twice
+	if err := repo.ImportHistoryRows(ctx, 2031, 3, 6, rows[3:6]); err == nil {
+		t.Fatal("expected a chunk behind the checkpoint refused")
+	}
+	if n, err := importer.ImportYear(ctx, 2031); err != nil || n != 0 {
+		t.Fatalf("expected a completed year left alone, got %d (%v)", n, err)
+	}
+
+	if _, err := importer.ImportYear(ctx, 2033); !errors.Is(err, ErrHistoryYearNotPast) {
+		t.Fatalf("expected the current year refused, got %v", err)
+	}
+	statuses, err := importer.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) != 2 || statuses[0].Year != 2030 || statuses[0].Started || statuses[1].Year != 2031 || statuses[1].Percent() != 100 {
+		t.Fatalf("expected 2030 not started and 2031 completed, got %+v", statuses)
+	}
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"spese/internal/core"
+	"spese/internal/events"
+	"spese/internal/storage"
+)
+
+// InsightDetector records the spending insights of the current month,
+// which the dashboard shows as a feed, and sends each new one as a budget
+// or spending alert. Each insight is recorded once, so detection can run
+// as often as needed.
+type InsightDetector struct {
+	storage *storage.SQLiteRepository
+	alerts  *AlertRouter // nil sends no alerts
+}
+
+// NewInsightDetector creates a detector over the repository's expenses.
+func NewInsightDetector(storage *storage.SQLiteRepository, alerts *AlertRouter) *InsightDetector {
+	return &InsightDetector{storage: storage, alerts: alerts}
+}
+
+// Detect records the insights about the month of now and returns how many
+// were new.
+func (d *InsightDetector) Detect(ctx context.Context, now time.Time) (int, error) {
+	endOfDay := time.Date(now.Year(), now.Month(), now.Day(), 23, 59, 59, 0, time.UTC)
+	expenses, err := d.storage.ListExpensesByDateRange(ctx, core.InsightHistoryStart(now), endOfDay)
+	if err != nil {
+		return 0, fmt.Errorf("list expenses for insights: %w", err)
+	}
+	recorded, err := d.storage.RecordInsights(ctx, core.DetectInsights(expenses, now))
+	if d.alerts != nil {
+		for _, in := range recorded {
+			d.alerts.Send(ctx, Alert{
+				Type:  core.AlertForInsight(in.Kind),
+				Scope: in.Primary,
+				Event: events.InsightDetected,
+				Data:  events.InsightFrom(in),
+			})
+		}
+	}
+	return len(recorded), err
+}
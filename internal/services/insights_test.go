@@ -0,0 +1,63 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"spese/internal/core"
+)
+
+func TestInsightDetector_Detect(t *testing.T) {
+	ctx := context.Background()
+	repo := newPeerRepo(t, "insights")
+
+	add := func(date core.Date, primary string, cents int64) {
+		t.Helper()
+		if _, err := repo.AppendAndEnqueueSync(ctx, core.Expense{Date: date, Description: "Spesa", Amount: core.Money{Cents: cents}, Primary: primary, Secondary: "Varie"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	add(core.NewDate(2031, 2, 10), "Spesa", 10000)
+	add(core.NewDate(2031, 3, 5), "Spesa", 15000)
+	add(core.NewDate(2030, 11, 20), "Viaggi", 30000)
+	add(core.NewDate(2031, 3, 8), "Viaggi", 5000)
+
+	detector := NewInsightDetector(repo, nil)
+	now := time.Date(2031, 3, 10, 9, 0, 0, 0, time.UTC)
+	n, err := detector.Detect(ctx, now)
+	if err != nil || n != 3 {
+		t.Fatalf("Detect = %d, %v, want 3 (Spesa up and budget reached, Viaggi back)", n, err)
+	}
+	// Each insight is recorded once
+	if n, err := detector.Detect(ctx, now.Add(time.Hour)); err != nil || n != 0 {
+		t.Errorf("second run = %d, %v, want 0", n, err)
+	}
+
+	insights, err := repo.ListInsights(ctx, 10)
+	if err != nil || len(insights) != 3 {
+		t.Fatalf("ListInsights = %+v, %v", insights, err)
+	}
+	back := insights[0]
+	if back.Kind != core.InsightCategoryBack || back.Primary != "Viaggi" || back.Since != "2030-11" || back.Date.Format("2006-01-02") != "2031-03-08" {
+		t.Errorf("newest insight = %+v", back)
+	}
+
+	// Dismissed and muted insights leave the feed
+	if err := repo.DismissInsight(ctx, back.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.MuteInsights(ctx, core.InsightBudgetReached, "Spesa"); err != nil {
+		t.Fatal(err)
+	}
+	insights, _ = repo.ListInsights(ctx, 10)
+	if len(insights) != 1 || insights[0].Kind != core.InsightCategoryUp {
+		t.Errorf("feed after dismiss and mute = %+v", insights)
+	}
+	if err := repo.UnmuteInsights(ctx, core.InsightBudgetReached, "Spesa"); err != nil {
+		t.Fatal(err)
+	}
+	if insights, _ = repo.ListInsights(ctx, 10); len(insights) != 2 {
+		t.Errorf("feed after unmute = %+v", insights)
+	}
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"sync"
+	"time"
+)
+
+// IntegrationStatus is the state of the Google Sheets integration as seen
+// by the sync processor.
+type IntegrationStatus struct {
+	Degraded  bool
+	Since     time.Time // When the integration became degraded
+	LastError string    // Last error that rejected the credentials
+	Failures  int       // Requests refused since Since
+}
+
+// IntegrationHealth tracks whether Google Sheets accepts the configured
+// credentials, so an expired or revoked token shows up in the UI instead of
+// only piling up sync errors. It is safe for concurrent use; a nil
+// IntegrationHealth reports a healthy integration and ignores updates.
+type IntegrationHealth struct {
+	mu     sync.RWMutex
+	status IntegrationStatus
+}
+
+// NewIntegrationHealth creates a tracker for a healthy integration.
+func NewIntegrationHealth() *IntegrationHealth {
+	return &IntegrationHealth{}
+}
+
+// MarkDegraded records that Google Sheets refused the credentials with err.
+func (h *IntegrationHealth) MarkDegraded(err error) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.status.Degraded {
+		h.status = IntegrationStatus{Degraded: true, Since: time.Now()}
+	}
+	h.status.Failures++
+	if err != nil {
+		h.status.LastError = err.Error()
+	}
+}
+
+// MarkHealthy records a request Google Sheets accepted, ending a degraded
+// state.
+func (h *IntegrationHealth) MarkHealthy() {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.status = IntegrationStatus{}
+}
+
+// Status returns the current state of the integration.
+func (h *IntegrationHealth) Status() IntegrationStatus {
+	if h == nil {
+		return IntegrationStatus{}
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.status
+}
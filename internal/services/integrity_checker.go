@@ -0,0 +1,276 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"spese/internal/core"
+	"spese/internal/sheets"
+	"spese/internal/storage"
+)
+
+// Integrity checks, the Kind of an IntegrityIssue
+const (
+	IntegrityUnknownCategory  = "unknown_category"
+	IntegrityRecurrentDates   = "recurrent_dates"
+	IntegrityNonPositive      = "non_positive_amount"
+	IntegrityOrphanReceipt    = "orphan_receipt"
+	IntegritySyncedNotInSheet = "synced_not_in_sheet"
+	IntegrityInSheetNotSynced = "in_sheet_not_synced"
+	IntegritySheetOrphan      = "sheet_row_without_expense"
+)
+
+// maxUnknownCategoryIssues bounds the expenses listed by the category check
+const maxUnknownCategoryIssues = 200
+
+// IntegrityIssue is a broken invariant of the data, with the repair the
+// report suggests. Subject, Detail and Repair are shown as they are.
+type IntegrityIssue struct {
+	Kind    string
+	Subject string // What the issue is about, e.g. "Spesa #12"
+	Detail  string
+	Repair  string
+}
+
+// IntegrityReport is the outcome of an integrity check.
+type IntegrityReport struct {
+	CheckedAt time.Time
+	Issues    []IntegrityIssue
+	// SheetChecked tells whether the sync flags were compared with the
+	// expenses sheet; SheetError is why not, empty without a sheet.
+	SheetChecked bool
+	SheetError   string
+}
+
+// Counts returns the issues per kind.
+func (r IntegrityReport) Counts() map[string]int {
+	counts := make(map[string]int)
+	for _, issue := range r.Issues {
+		counts[issue.Kind]++
+	}
+	return counts
+}
+
+// Summary describes the issues in a line, e.g. "3 issues: 1
+// orphan_receipt, 2 unknown_category".
+func (r IntegrityReport) Summary() string {
+	if len(r.Issues) == 0 {
+		return "no issues"
+	}
+	counts := r.Counts()
+	kinds := make([]string, 0, len(counts))
+	for kind := range counts {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	parts := make([]string, len(kinds))
+	for i, kind := range kinds {
+		parts[i] = fmt.Sprintf("%d %s", counts[kind], kind)
+	}
+	return fmt.Sprintf("%d issues: %s", len(r.Issues), strings.Join(parts, ", "))
+}
+
+// IntegrityChecker verifies invariants the schema cannot enforce, or that
+// databases older than its constraints may break: expenses with unknown
+// categories, recurrents ending before they start, non-positive amounts,
+// receipts of deleted expenses and sync flags that disagree with the
+// expenses sheet. It only reports; each issue suggests a repair.
+type IntegrityChecker struct {
+	storage *storage.SQLiteRepository
+	sheet   sheets.AmountReconciler // nil skips the sheet comparison
+}
+
+// NewIntegrityChecker creates a checker; sheet is the expenses sheet the
+// sync fills, nil without Google Sheets.
+func NewIntegrityChecker(storage *storage.SQLiteRepository, sheet sheets.AmountReconciler) *IntegrityChecker {
+	return &IntegrityChecker{storage: storage, sheet: sheet}
+}
+
+// Check runs every check and returns the issues found. The sync flags are
+// compared with the sheet for the expenses of the year of now, the one
+// the sheet holds; a sheet that cannot be read is noted in the report
+// rather than failing the check.
+func (c *IntegrityChecker) Check(ctx context.Context, now time.Time) (IntegrityReport, error) {
+	report := IntegrityReport{CheckedAt: now}
+	checks := []func(context.Context) ([]IntegrityIssue, error){
+		c.checkCategories,
+		c.checkRecurrentDates,
+		c.checkAmounts,
+		c.checkReceipts,
+	}
+	for _, check := range checks {
+		issues, err := check(ctx)
+		if err != nil {
+			return report, err
+		}
+		report.Issues = append(report.Issues, issues...)
+	}
+
+	if c.sheet != nil {
+		issues, err := c.checkSyncFlags(ctx, now.Year())
+		if err != nil {
+			report.SheetError = err.Error()
+		} else {
+			report.SheetChecked = true
+			report.Issues = append(report.Issues, issues...)
+		}
+	}
+	return report, nil
+}
+
+func (c *IntegrityChecker) checkCategories(ctx context.Context) ([]IntegrityIssue, error) {
+	rows, err := c.storage.ExpensesWithUnknownCategory(ctx, maxUnknownCategoryIssues)
+	if err != nil {
+		return nil, err
+	}
+	issues := make([]IntegrityIssue, 0, len(rows))
+	for _, row := range rows {
+		issues = append(issues, IntegrityIssue{
+			Kind:    IntegrityUnknownCategory,
+			Subject: fmt.Sprintf("Spesa #%d del %s", row.ID, row.Date.Format("02/01/2006")),
+			Detail:  fmt.Sprintf("%q usa la categoria sconosciuta %s / %s", row.Description, row.PrimaryCategory, row.SecondaryCategory),
+			Repair:  "Modifica la spesa scegliendo una categoria esistente, oppure crea la categoria o una mappatura verso una esistente",
+		})
+	}
+	return issues, nil
+}
+
+func (c *IntegrityChecker) checkRecurrentDates(ctx context.Context) ([]IntegrityIssue, error) {
+	rows, err := c.storage.RecurrentsEndingBeforeStart(ctx)
+	if err != nil {
+		return nil, err
+	}
+	issues := make([]IntegrityIssue, 0, len(rows))
+	for _, row := range rows {
+		subject, repair := "Spesa ricorrente", "Correggi la data di fine da /recurrent o elimina la spesa ricorrente"
+		if row.Kind == "income" {
+			subject, repair = "Entrata ricorrente", "Correggi la data di fine da /entrate o elimina l'entrata ricorrente"
+		}
+		issues = append(issues, IntegrityIssue{
+			Kind:    IntegrityRecurrentDates,
+			Subject: fmt.Sprintf("%s #%d", subject, row.ID),
+			Detail:  fmt.Sprintf("%q finisce il %s, prima dell'inizio il %s: non viene mai eseguita", row.Description, row.EndDate, row.StartDate),
+			Repair:  repair,
+		})
+	}
+	return issues, nil
+}
+
+func (c *IntegrityChecker) checkAmounts(ctx context.Context) ([]IntegrityIssue, error) {
+	rows, err := c.storage.NonPositiveAmounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	issues := make([]IntegrityIssue, 0, len(rows))
+	for _, row := range rows {
+		issues = append(issues, IntegrityIssue{
+			Kind:    IntegrityNonPositive,
+			Subject: fmt.Sprintf("%s #%d", row.TableName, row.ID),
+			Detail:  fmt.Sprintf("Importo di %.2f €: deve essere positivo", core.Money{Cents: row.AmountCents}.Euros()),
+			Repair:  "Correggi l'importo; un rimborso va registrato come entrata o collegato alla spesa da /rimborsi",
+		})
+	}
+	return issues, nil
+}
+
+func (c *IntegrityChecker) checkReceipts(ctx context.Context) ([]IntegrityIssue, error) {
+	rows, err := c.storage.OrphanReceipts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	issues := make([]IntegrityIssue, 0, len(rows))
+	for _, row := range rows {
+		name := row.Filename
+		if name == "" {
+			name = row.BlobKey
+		}
+		issues = append(issues, IntegrityIssue{
+			Kind:    IntegrityOrphanReceipt,
+			Subject: fmt.Sprintf("Ricevuta %s", name),
+			Detail:  fmt.Sprintf("Allegata alla spesa #%d, che non esiste più", row.ExpenseID),
+			Repair:  "Elimina la riga della ricevuta dal database; il file viene poi cancellato dallo storage",
+		})
+	}
+	return issues, nil
+}
+
+// checkSyncFlags compares the sync flag of the expenses of year with the
+// rows of the sheet tagged with their ID. Expenses with an open queue item
+// are on their way and not compared.
+func (c *IntegrityChecker) checkSyncFlags(ctx context.Context, year int) ([]IntegrityIssue, error) {
+	from := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+	expenses, err := c.storage.ExpenseSyncFlags(ctx, from, from.AddDate(1, 0, 0))
+	if err != nil {
+		return nil, err
+	}
+	rows, err := c.sheet.ListAmountsByID(ctx, year)
+	if err != nil {
+		return nil, fmt.Errorf("list sheet rows: %w", err)
+	}
+	exists := func(id int64) bool {
+		_, err := c.storage.GetExpense(ctx, id)
+		return err == nil
+	}
+	return diffSyncFlags(expenses, rows, exists), nil
+}
+
+// diffSyncFlags reports expenses marked synced without a sheet row, sheet
+// rows of expenses not marked synced, and sheet rows whose ID matches no
+// expense of the year for which exists reports no expense at all.
+func diffSyncFlags(expenses []storage.ListExpenseSyncFlagsRow, rows []sheets.SheetAmountRow, exists func(id int64) bool) []IntegrityIssue {
+	inSheet := make(map[int64]int, len(rows))
+	for _, row := range rows {
+		if _, seen := inSheet[row.ID]; !seen {
+			inSheet[row.ID] = row.Row
+		}
+	}
+
+	var issues []IntegrityIssue
+	known := make(map[int64]bool, len(expenses))
+	for _, e := range expenses {
+		known[e.ID] = true
+		if e.Queued != 0 {
+			continue
+		}
+		subject := fmt.Sprintf("Spesa #%d del %s", e.ID, e.Date.Format("02/01/2006"))
+		row, found := inSheet[e.ID]
+		synced := e.SyncStatus.String == "synced"
+		switch {
+		case synced && !found:
+			issues = append(issues, IntegrityIssue{
+				Kind:    IntegritySyncedNotInSheet,
+				Subject: subject,
+				Detail:  fmt.Sprintf("%q risulta sincronizzata ma nel foglio non c'è una riga con il suo ID", e.Description),
+				Repair:  "Se la riga è stata cancellata dal foglio, modifica la spesa per accodarla di nuovo; le spese sincronizzate prima della colonna ID non hanno la riga etichettata",
+			})
+		case !synced && found:
+			issues = append(issues, IntegrityIssue{
+				Kind:    IntegrityInSheetNotSynced,
+				Subject: subject,
+				Detail:  fmt.Sprintf("%q è nel foglio alla riga %d ma risulta non sincronizzata", e.Description, row),
+				Repair:  "Controlla la riga nel foglio: se corrisponde alla spesa è già stata scritta, e riaccodarla scriverebbe un doppione",
+			})
+		}
+	}
+
+	for _, row := range rows {
+		if known[row.ID] {
+			continue
+		}
+		known[row.ID] = true
+		if exists(row.ID) {
+			// An expense of another year synced into this year's sheet
+			continue
+		}
+		issues = append(issues, IntegrityIssue{
+			Kind:    IntegritySheetOrphan,
+			Subject: fmt.Sprintf("Riga %d del foglio", row.Row),
+			Detail:  fmt.Sprintf("Porta l'ID %d, che non corrisponde a nessuna spesa", row.ID),
+			Repair:  "La spesa è stata eliminata senza togliere la riga: cancellala dal foglio",
+		})
+	}
+	return issues
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"spese/internal/core"
+	"spese/internal/sheets"
+	"spese/internal/storage"
+)
+
+// sheetRows is an expenses sheet holding the given ID-tagged rows
+type sheetRows []sheets.SheetAmountRow
+
+func (s sheetRows) ListAmountsByID(context.Context, int) ([]sheets.SheetAmountRow, error) {
+	return s, nil
+}
+
+func (s sheetRows) UpdateAmount(context.Context, int, int, int64) error { return nil }
+
+func TestIntegrityChecker(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "spese.db")
+	repo, err := storage.NewSQLiteRepository(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	if err := repo.CreateCategory(ctx, "Casa", "Affitto"); err != nil {
+		t.Fatal(err)
+	}
+
+	add := func(secondary string) int64 {
+		ref, err := repo.AppendAndEnqueueSync(ctx, core.Expense{Date: core.NewDate(2031, 3, 5), Description: "Spesa", Amount: core.Money{Cents: 1000}, Primary: "Casa", Secondary: secondary})
+		if err != nil {
+			t.Fatal(err)
+		}
+		id, err := strconv.ParseInt(ref, 10, 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+	// Synced, but the sheet has no row for it
+	synced := add("Affitto")
+	items, err := repo.DequeueSyncBatch(ctx, 10)
+	if err != nil || len(items) != 1 {
+		t.Fatalf("queue = %v, %v", items, err)
+	}
+	if err := repo.MarkSyncComplete(ctx, items[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.MarkSynced(ctx, synced); err != nil {
+		t.Fatal(err)
+	}
+	// Unknown category, still queued so not compared with the sheet
+	unknown := add("Spesa")
+
+	// Rows the schema or its triggers would refuse
+	db, err := sql.Open("sqlite", path+"?_pragma=ignore_check_constraints(1)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, stmt := range []string{
+		`INSERT INTO expenses (date, description, amount_cents, primary_category, secondary_category) VALUES ('2031-04-01', 'Storno', -500, 'Casa', 'Affitto')`,
+		`INSERT INTO recurrent_expenses (start_date, end_date, repetition_type, description, amount_cents, primary_category, secondary_category) VALUES ('2031-05-01', '2031-01-01', 'monthly', 'Palestra', 3000, 'Casa', 'Affitto')`,
+		`INSERT INTO receipts (expense_id, blob_key, content_type, filename, size_bytes) VALUES (9999, 'receipts/9999', 'image/jpeg', 'scontrino.jpg', 10)`,
+	} {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+
+	checker := NewIntegrityChecker(repo, sheetRows{{ID: 4242, Row: 7, Cents: 100}})
+	report, err := checker.Check(ctx, time.Date(2031, 6, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.SheetChecked {
+		t.Errorf("sheet not checked: %s", report.SheetError)
+	}
+	// The seeded expenses use categories of their own
+	found := false
+	for _, issue := range report.Issues {
+		if issue.Kind == IntegrityUnknownCategory && strings.HasPrefix(issue.Subject, fmt.Sprintf("Spesa #%d ", unknown)) {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expense %d with an unknown category not reported", unknown)
+	}
+	want := map[string]int{
+		IntegrityRecurrentDates:   1,
+		IntegrityNonPositive:      1,
+		IntegrityOrphanReceipt:    1,
+		IntegritySyncedNotInSheet: 1,
+		IntegritySheetOrphan:      1,
+	}
+	counts := report.Counts()
+	for kind, n := range want {
+		if counts[kind] != n {
+			t.Errorf("%s: %d issues, want %d (report: %+v)", kind, counts[kind], n, report.Issues)
+		}
+	}
+	for _, issue := range report.Issues {
+		if issue.Repair == "" {
+			t.Errorf("%s issue without a repair suggestion", issue.Kind)
+		}
+	}
+}
+
+func TestDiffSyncFlags(t *testing.T) {
+	flag := func(id int64, status string, queued int64) storage.ListExpenseSyncFlagsRow {
+		return storage.ListExpenseSyncFlagsRow{ID: id, SyncStatus: sql.NullString{String: status, Valid: true}, Queued: queued}
+	}
+	expenses := []storage.ListExpenseSyncFlagsRow{
+		flag(1, "synced", 0),  // in the sheet
+		flag(2, "synced", 0),  // missing from the sheet
+		flag(3, "pending", 0), // in the sheet, not marked synced
+		flag(4, "pending", 1), // queued: not compared
+		flag(5, "pending", 0), // waiting, not in the sheet
+	}
+	rows := []sheets.SheetAmountRow{{ID: 1, Row: 2}, {ID: 3, Row: 3}, {ID: 4, Row: 4}, {ID: 8, Row: 5}, {ID: 9, Row: 6}}
+	// 8 is an expense of another year, 9 was deleted
+	exists := func(id int64) bool { return id == 8 }
+
+	issues := diffSyncFlags(expenses, rows, exists)
+	want := []string{IntegritySyncedNotInSheet, IntegrityInSheetNotSynced, IntegritySheetOrphan}
+	if len(issues) != len(want) {
+		t.Fatalf("got %+v, want kinds %v", issues, want)
+	}
+	for i, kind := range want {
+		if issues[i].Kind != kind {
+			t.Errorf("issue %d = %s, want %s", i, issues[i].Kind, kind)
+		}
+	}
+	if issues[2].Subject != "Riga 6 del foglio" {
+		t.Errorf("orphan row subject = %q", issues[2].Subject)
+	}
+}
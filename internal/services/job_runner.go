@@ -0,0 +1,455 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"spese/internal/core"
+)
+
+// Errors of the JobRunner
+var (
+	ErrUnknownJob  = errors.New("unknown job")                       // no job is registered with the name
+	ErrJobBusy     = errors.New("job already queued or running")     // RunNow of a job that has not finished
+	ErrJobReplica  = errors.New("job runs on the primary node only") // RunNow of a PrimaryOnly job on a replica
+	ErrJobsStopped = errors.New("job runner not running")            // RunNow before Start or after it returned
+)
+
+// JobStore records the runs of the background jobs; SQLiteRepository
+// implements it.
+type JobStore interface {
+	StartJobRun(ctx context.Context, job string, source core.JobSource, attempt int, at time.Time) (int64, error)
+	FinishJobRun(ctx context.Context, run core.JobRun, keep int) error
+	LatestJobRuns(ctx context.Context) ([]core.JobRun, error)
+	JobRuns(ctx context.Context, job string, limit int) ([]core.JobRun, error)
+	InterruptJobRuns(ctx context.Context, at time.Time) (int64, error)
+}
+
+// Job is a unit of background work run by the JobRunner on a schedule.
+type Job struct {
+	Name        string
+	Description string
+
+	// Every is the time between scheduled runs; Next, when set, returns
+	// the time of the run after now instead, e.g. every night at 3. A job
+	// with neither runs only when triggered with RunNow.
+	Every time.Duration
+	Next  func(now time.Time) time.Time
+
+	// SkipStartup waits for the first scheduled time instead of running
+	// the job when the runner starts.
+	SkipStartup bool
+
+	// PrimaryOnly jobs do not run on a replica node.
+	PrimaryOnly bool
+
+	// Run does the work and returns a summary of it for the status, such
+	// as "3 expenses"; empty when there was nothing to do.
+	Run func(ctx context.Context) (string, error)
+}
+
+// JobState is the status of a registered job.
+type JobState struct {
+	Name        string
+	Description string
+	Every       time.Duration // zero for jobs with a calendar schedule
+	Queued      bool
+	Running     bool
+	NextRun     time.Time    // zero for jobs run only on demand
+	LastRun     *core.JobRun // nil before the first run
+}
+
+// Defaults of the JobRunner
+const (
+	defaultJobRetryDelay = 30 * time.Second
+	defaultJobKeepRuns   = 50
+)
+
+// JobRunner runs the registered jobs on their schedule with a pool of
+// workers, never two runs of the same job at once. A failed run is tried
+// again with exponential backoff until maxAttempts; every attempt is
+// recorded in the store, which keeps the latest runs of each job.
+type JobRunner struct {
+	store       JobStore
+	workers     int
+	maxAttempts int
+	retryDelay  time.Duration // before the second attempt, doubling after
+	keepRuns    int
+	tick        time.Duration
+	isPrimary   func() bool
+	now         func() time.Time
+
+	mu     sync.Mutex
+	jobs   []*jobEntry
+	byName map[string]*jobEntry
+	queue  chan jobRequest
+	// changed is closed, and replaced, whenever a job is queued, starts
+	// or finishes
+	changed chan struct{}
+}
+
+type jobEntry struct {
+	Job
+	next    time.Time
+	queued  bool
+	running bool
+	last    *core.JobRun
+}
+
+type jobRequest struct {
+	entry   *jobEntry
+	source  core.JobSource
+	attempt int
+}
+
+// NewJobRunner returns a runner with the given number of workers and
+// attempts per run (at least 1 each). A nil store keeps the runs in memory
+// only.
+func NewJobRunner(store JobStore, workers, maxAttempts int) *JobRunner {
+	if workers < 1 {
+		workers = 1
+	}
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	return &JobRunner{
+		store:       store,
+		workers:     workers,
+		maxAttempts: maxAttempts,
+		retryDelay:  defaultJobRetryDelay,
+		keepRuns:    defaultJobKeepRuns,
+		tick:        time.Second,
+		now:         time.Now,
+		byName:      make(map[string]*jobEntry),
+		changed:     make(chan struct{}),
+	}
+}
+
+// SetPrimaryCheck skips the PrimaryOnly jobs while isPrimary reports a
+// replica node.
+func (r *JobRunner) SetPrimaryCheck(isPrimary func() bool) {
+	r.isPrimary = isPrimary
+}
+
+// Register adds a job; jobs are registered before Start.
+func (r *JobRunner) Register(job Job) error {
+	if job.Name == "" || job.Run == nil {
+		return errors.New("register job: name and run are required")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.byName[job.Name]; ok {
+		return fmt.Errorf("register job %s: already registered", job.Name)
+	}
+	e := &jobEntry{Job: job}
+	r.jobs = append(r.jobs, e)
+	r.byName[job.Name] = e
+	return nil
+}
+
+// nextRun returns the scheduled run of a job after now, zero for a job
+// run only on demand.
+func (e *jobEntry) nextRun(now time.Time) time.Time {
+	switch {
+	case e.Next != nil:
+		return e.Next(now)
+	case e.Every > 0:
+		return now.Add(e.Every)
+	}
+	return time.Time{}
+}
+
+// notify wakes up the watchers of Changed; r.mu is held.
+func (r *JobRunner) notify() {
+	close(r.changed)
+	r.changed = make(chan struct{})
+}
+
+// Changed returns a channel closed at the next change of the state of a
+// job: when it is queued, starts or finishes.
+func (r *JobRunner) Changed() <-chan struct{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.changed
+}
+
+// Start runs the jobs until ctx is done, then waits for the runs in
+// progress to stop.
+func (r *JobRunner) Start(ctx context.Context) error {
+	now := r.now()
+	if r.store != nil {
+		if n, err := r.store.InterruptJobRuns(ctx, now); err != nil {
+			slog.ErrorContext(ctx, "Failed to mark interrupted job runs", "error", err, "component", "job_runner")
+		} else if n > 0 {
+			slog.WarnContext(ctx, "Job runs interrupted by the last shutdown", "count", n, "component", "job_runner")
+		}
+	}
+	var latest map[string]core.JobRun
+	if r.store != nil {
+		runs, err := r.store.LatestJobRuns(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to load the last job runs", "error", err, "component", "job_runner")
+		}
+		latest = make(map[string]core.JobRun, len(runs))
+		for _, run := range runs {
+			latest[run.Job] = run
+		}
+	}
+
+	r.mu.Lock()
+	r.queue = make(chan jobRequest, len(r.jobs))
+	for _, e := range r.jobs {
+		e.next = now
+		if e.SkipStartup || (e.Every <= 0 && e.Next == nil) {
+			e.next = e.nextRun(now)
+		}
+		if run, ok := latest[e.Name]; ok {
+			e.last = &run
+		}
+	}
+	r.mu.Unlock()
+
+	slog.InfoContext(ctx, "Starting job runner", "jobs", len(r.jobs), "workers", r.workers, "component", "job_runner")
+
+	var wg sync.WaitGroup
+	for i := 0; i < r.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.work(ctx)
+		}()
+	}
+
+	ticker := time.NewTicker(r.tick)
+	defer ticker.Stop()
+	for {
+		r.schedule(ctx)
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			r.mu.Lock()
+			r.queue = nil
+			r.mu.Unlock()
+			slog.Info("Job runner stopped", "component", "job_runner")
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// schedule queues the jobs whose time has come.
+func (r *JobRunner) schedule(ctx context.Context) {
+	now := r.now()
+	r.mu.Lock()
+	var due []*jobEntry
+	for _, e := range r.jobs {
+		if e.next.IsZero() || now.Before(e.next) {
+			continue
+		}
+		e.next = e.nextRun(now)
+		if e.PrimaryOnly && r.isPrimary != nil && !r.isPrimary() {
+			continue
+		}
+		due = append(due, e)
+	}
+	r.mu.Unlock()
+
+	for _, e := range due {
+		if !r.enqueue(jobRequest{entry: e, source: core.JobScheduled, attempt: 1}) {
+			slog.InfoContext(ctx, "Job still running, scheduled run skipped", "job", e.Name, "component", "job_runner")
+		}
+	}
+}
+
+// enqueue queues a run unless one of the same job is queued or running.
+func (r *JobRunner) enqueue(req jobRequest) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.queue == nil || req.entry.queued || req.entry.running {
+		return false
+	}
+	req.entry.queued = true
+	// Never blocks: the queue holds one request per job
+	r.queue <- req
+	r.notify()
+	return true
+}
+
+// RunNow queues a run of a job outside its schedule, recorded as manual.
+// The run is not retried when it fails.
+func (r *JobRunner) RunNow(name string) error {
+	r.mu.Lock()
+	e, ok := r.byName[name]
+	started := r.queue != nil
+	r.mu.Unlock()
+	switch {
+	case !ok:
+		return fmt.Errorf("%w: %s", ErrUnknownJob, name)
+	case !started:
+		return ErrJobsStopped
+	case e.PrimaryOnly && r.isPrimary != nil && !r.isPrimary():
+		return fmt.Errorf("run %s: %w", name, ErrJobReplica)
+	}
+	if !r.enqueue(jobRequest{entry: e, source: core.JobManual, attempt: 1}) {
+		return fmt.Errorf("run %s: %w", name, ErrJobBusy)
+	}
+	slog.Info("Job triggered", "job", name, "component", "job_runner")
+	return nil
+}
+
+func (r *JobRunner) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case req := <-r.queue:
+			r.run(ctx, req)
+		}
+	}
+}
+
+// run runs one attempt of a job and records it; a failed attempt is
+// retried after a delay doubling at each attempt.
+func (r *JobRunner) run(ctx context.Context, req jobRequest) {
+	e := req.entry
+	r.mu.Lock()
+	e.queued, e.running = false, true
+	r.notify()
+	r.mu.Unlock()
+
+	run := core.JobRun{Job: e.Name, Source: req.source, Attempt: req.attempt, Status: core.JobRunning, StartedAt: r.now()}
+	if r.store != nil {
+		id, err := r.store.StartJobRun(ctx, e.Name, req.source, req.attempt, run.StartedAt)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to record job run", "error", err, "job", e.Name, "component", "job_runner")
+		}
+		run.ID = id
+	}
+	r.mu.Lock()
+	started := run
+	e.last = &started
+	r.mu.Unlock()
+
+	result, err := runJob(ctx, e.Job)
+	run.FinishedAt = r.now()
+	run.Result = result
+	run.Status = core.JobSucceeded
+	if err != nil {
+		run.Status, run.Error = core.JobFailed, err.Error()
+	}
+	if ctx.Err() != nil && err != nil {
+		run.Status = core.JobInterrupted
+	}
+
+	if r.store != nil && run.ID != 0 {
+		// The run is recorded even when shutdown cancelled ctx
+		recordCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		if err := r.store.FinishJobRun(recordCtx, run, r.keepRuns); err != nil {
+			slog.ErrorContext(ctx, "Failed to record job run", "error", err, "job", e.Name, "component", "job_runner")
+		}
+		cancel()
+	}
+	r.mu.Lock()
+	e.running = false
+	e.last = &run
+	r.notify()
+	r.mu.Unlock()
+
+	switch {
+	case run.Status == core.JobInterrupted:
+		slog.InfoContext(ctx, "Job interrupted by shutdown", "job", e.Name, "component", "job_runner")
+	case err != nil:
+		slog.ErrorContext(ctx, "Job failed", "error", err, "job", e.Name, "attempt", req.attempt, "component", "job_runner")
+		if req.source != core.JobManual && req.attempt < r.maxAttempts {
+			delay := r.retryDelay << (req.attempt - 1)
+			retry := jobRequest{entry: e, source: core.JobRetry, attempt: req.attempt + 1}
+			time.AfterFunc(delay, func() {
+				if ctx.Err() == nil {
+					r.enqueue(retry)
+				}
+			})
+		}
+	case result != "":
+		slog.InfoContext(ctx, "Job finished", "job", e.Name, "result", result, "duration", run.Duration(run.FinishedAt), "component", "job_runner")
+	}
+}
+
+// runJob runs a job, turning a panic into an error.
+func runJob(ctx context.Context, job Job) (result string, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return job.Run(ctx)
+}
+
+// Jobs returns the status of the registered jobs, by name.
+func (r *JobRunner) Jobs() []JobState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	states := make([]JobState, 0, len(r.jobs))
+	for _, e := range r.jobs {
+		states = append(states, e.state())
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Name < states[j].Name })
+	return states
+}
+
+// Job returns the status of a registered job.
+func (r *JobRunner) Job(name string) (JobState, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e, ok := r.byName[name]
+	if !ok {
+		return JobState{}, fmt.Errorf("%w: %s", ErrUnknownJob, name)
+	}
+	return e.state(), nil
+}
+
+// state returns the status of a job; the runner mutex is held.
+func (e *jobEntry) state() JobState {
+	st := JobState{
+		Name:        e.Name,
+		Description: e.Description,
+		Queued:      e.queued,
+		Running:     e.running,
+		NextRun:     e.next,
+	}
+	if e.Next == nil {
+		st.Every = e.Every
+	}
+	if e.last != nil {
+		last := *e.last
+		st.LastRun = &last
+	}
+	return st
+}
+
+// Runs returns the latest runs of a job, newest first: the recorded ones,
+// or only the last one without a store.
+func (r *JobRunner) Runs(ctx context.Context, name string, limit int) ([]core.JobRun, error) {
+	r.mu.Lock()
+	e, ok := r.byName[name]
+	var last *core.JobRun
+	if ok && e.last != nil {
+		l := *e.last
+		last = &l
+	}
+	r.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownJob, name)
+	}
+	if r.store != nil {
+		return r.store.JobRuns(ctx, name, limit)
+	}
+	if last == nil {
+		return nil, nil
+	}
+	return []core.JobRun{*last}, nil
+}
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"spese/internal/core"
+)
+
+// waitFor polls cond until it holds or a second passes
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestJobRunner(t *testing.T) {
+	repo := newPeerRepo(t, "jobs")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// A run left running by a previous process
+	if _, err := repo.StartJobRun(ctx, "flaky", core.JobScheduled, 1, time.Now().Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	runner := NewJobRunner(repo, 2, 3)
+	runner.tick, runner.retryDelay = 5*time.Millisecond, 5*time.Millisecond
+
+	var flakyCalls, nightlyCalls, replicaCalls atomic.Int32
+	primary := false
+	runner.SetPrimaryCheck(func() bool { return primary })
+	for _, job := range []Job{
+		{Name: "flaky", Every: time.Hour, Run: func(ctx context.Context) (string, error) {
+			if flakyCalls.Add(1) == 1 {
+				return "", errors.New("sheets unavailable")
+			}
+			return "3 expenses", nil
+		}},
+		{Name: "nightly", Every: time.Hour, SkipStartup: true, Run: func(ctx context.Context) (string, error) {
+			nightlyCalls.Add(1)
+			return "", nil
+		}},
+		{Name: "primary-only", Every: time.Hour, PrimaryOnly: true, Run: func(ctx context.Context) (string, error) {
+			replicaCalls.Add(1)
+			return "", nil
+		}},
+		{Name: "broken", Every: time.Hour, Run: func(ctx context.Context) (string, error) {
+			panic("nil map")
+		}},
+	} {
+		if err := runner.Register(job); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := runner.Register(Job{Name: "flaky", Every: time.Hour, Run: func(context.Context) (string, error) { return "", nil }}); err == nil {
+		t.Error("registering a job twice should fail")
+	}
+
+	done := make(chan error)
+	go func() { done <- runner.Start(ctx) }()
+
+	state := func(name string) JobState {
+		for _, st := range runner.Jobs() {
+			if st.Name == name {
+				return st
+			}
+		}
+		t.Fatalf("job %s not listed", name)
+		return JobState{}
+	}
+	waitFor(t, "the retry of flaky", func() bool {
+		last := state("flaky").LastRun
+		return last != nil && last.Status == core.JobSucceeded
+	})
+	waitFor(t, "the attempts of broken", func() bool {
+		last := state("broken").LastRun
+		return last != nil && last.Attempt == 3 && last.Status == core.JobFailed
+	})
+
+	runs, err := runner.Runs(ctx, "flaky", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 3 {
+		t.Fatalf("flaky runs = %+v, want 3", runs)
+	}
+	if r := runs[0]; r.Source != core.JobRetry || r.Attempt != 2 || r.Result != "3 expenses" || r.FinishedAt.IsZero() {
+		t.Errorf("retry = %+v", r)
+	}
+	if r := runs[1]; r.Status != core.JobFailed || r.Error != "sheets unavailable" || r.Source != core.JobScheduled {
+		t.Errorf("first attempt = %+v", r)
+	}
+	if r := runs[2]; r.Status != core.JobInterrupted {
+		t.Errorf("run of the previous process = %+v, want interrupted", r)
+	}
+	if last := state("broken").LastRun; last.Error != "panic: nil map" {
+		t.Errorf("panicking job error = %q", last.Error)
+	}
+
+	if n := nightlyCalls.Load(); n != 0 {
+		t.Errorf("job skipping startup ran %d times", n)
+	}
+	if st := state("nightly"); st.Every != time.Hour || st.LastRun != nil || time.Until(st.NextRun) < 50*time.Minute {
+		t.Errorf("nightly state = %+v", st)
+	}
+	if n := replicaCalls.Load(); n != 0 {
+		t.Errorf("primary-only job ran %d times on a replica", n)
+	}
+	if _, err := runner.Runs(ctx, "missing", 10); !errors.Is(err, ErrUnknownJob) {
+		t.Errorf("runs of an unknown job: %v", err)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Start = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("runner did not stop")
+	}
+}
+
+func TestJobRunnerRunNow(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	runner := NewJobRunner(nil, 1, 3)
+	runner.tick = 5 * time.Millisecond
+	release := make(chan struct{})
+	var calls atomic.Int32
+	if err := runner.Register(Job{Name: "sheet-sync", Run: func(ctx context.Context) (string, error) {
+		calls.Add(1)
+		<-release
+		return "", errors.New("quota exceeded")
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := runner.Register(Job{Name: "peer-sync", PrimaryOnly: true, Run: func(context.Context) (string, error) { return "", nil }}); err != nil {
+		t.Fatal(err)
+	}
+	var replica atomic.Bool
+	runner.SetPrimaryCheck(func() bool { return !replica.Load() })
+	if err := runner.RunNow("sheet-sync"); !errors.Is(err, ErrJobsStopped) {
+		t.Errorf("RunNow before Start = %v", err)
+	}
+
+	done := make(chan error)
+	go func() { done <- runner.Start(ctx) }()
+	waitFor(t, "the runner to start", func() bool { return !errors.Is(runner.RunNow("missing"), ErrJobsStopped) })
+
+	// A job without a schedule waits for RunNow
+	time.Sleep(20 * time.Millisecond)
+	if st, _ := runner.Job("sheet-sync"); calls.Load() != 0 || !st.NextRun.IsZero() {
+		t.Fatalf("on-demand job ran by itself: calls = %d, state = %+v", calls.Load(), st)
+	}
+
+	changed := runner.Changed()
+	if err := runner.RunNow("sheet-sync"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-changed:
+	case <-time.After(time.Second):
+		t.Fatal("no change notified for the queued run")
+	}
+	if err := runner.RunNow("sheet-sync"); !errors.Is(err, ErrJobBusy) {
+		t.Errorf("RunNow of a queued job = %v, want busy", err)
+	}
+	if err := runner.RunNow("missing"); !errors.Is(err, ErrUnknownJob) {
+		t.Errorf("RunNow of an unknown job = %v", err)
+	}
+	close(release)
+	waitFor(t, "the manual run", func() bool {
+		st, _ := runner.Job("sheet-sync")
+		return st.LastRun != nil && st.LastRun.Status == core.JobFailed && !st.Running
+	})
+	if st, _ := runner.Job("sheet-sync"); st.LastRun.Source != core.JobManual {
+		t.Errorf("last run = %+v, want manual", st.LastRun)
+	}
+
+	// Failed manual runs are not retried
+	time.Sleep(20 * time.Millisecond)
+	if n := calls.Load(); n != 1 {
+		t.Errorf("manual run ran %d times", n)
+	}
+
+	replica.Store(true)
+	if err := runner.RunNow("peer-sync"); !errors.Is(err, ErrJobReplica) {
+		t.Errorf("RunNow of a primary-only job on a replica = %v", err)
+	}
+
+	cancel()
+	<-done
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"spese/internal/core"
+	"spese/internal/storage"
+)
+
+// MonthReviewer builds the end-of-month review checklist and closes the
+// months that were reviewed.
+type MonthReviewer struct {
+	storage     *storage.SQLiteRepository
+	syncEnabled bool // Pending rows count as unsynced only when Sheets sync runs
+}
+
+// NewMonthReviewer creates a reviewer over the repository. With
+// syncEnabled, expenses still waiting for Google Sheets are flagged too.
+func NewMonthReviewer(storage *storage.SQLiteRepository, syncEnabled bool) *MonthReviewer {
+	return &MonthReviewer{storage: storage, syncEnabled: syncEnabled}
+}
+
+// Review returns the checklist of the month of the given year.
+func (m *MonthReviewer) Review(ctx context.Context, year, month int) (core.MonthReview, error) {
+	start := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	review := core.MonthReview{Period: core.Period(start)}
+
+	all, unsynced, err := m.storage.ListReviewExpenses(ctx, year, month, m.syncEnabled)
+	if err != nil {
+		return review, err
+	}
+	prev := start.AddDate(0, -1, 0)
+	previous, err := m.storage.ListExpenses(ctx, prev.Year(), int(prev.Month()))
+	if err != nil {
+		return review, fmt.Errorf("list previous month expenses: %w", err)
+	}
+
+	expenses := make([]core.Expense, len(all))
+	for i, e := range all {
+		expenses[i] = e.Expense
+		if e.IsUncategorized() {
+			review.Uncategorized = append(review.Uncategorized, e)
+		}
+	}
+	review.Duplicates = core.SuspectedDuplicates(all)
+	review.Unsynced = unsynced
+	review.Overruns = core.CategoryOverruns(expenses, previous)
+
+	review.ClosedAt, err = m.storage.MonthClosedAt(ctx, review.Period)
+	if err != nil {
+		return review, err
+	}
+	return review, nil
+}
+
+// Close closes the month of the given "2006-01" period.
+func (m *MonthReviewer) Close(ctx context.Context, period string) error {
+	return m.storage.CloseMonth(ctx, period)
+}
+
+// Reopen reopens a closed month.
+func (m *MonthReviewer) Reopen(ctx context.Context, period string) error {
+	return m.storage.ReopenMonth(ctx, period)
+}
+
+// ClosedMonths returns when each closed month was closed, by period.
+func (m *MonthReviewer) ClosedMonths(ctx context.Context) (map[string]time.Time, error) {
+	months, err := m.storage.ListClosedMonths(ctx)
+	if err != nil {
+		return nil, err
+	}
+	closed := make(map[string]time.Time, len(months))
+	for _, mo := range months {
+		closed[mo.Period] = mo.ClosedAt
+	}
+	return closed, nil
+}
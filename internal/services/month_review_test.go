@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"spese/internal/core"
+)
+
+func TestMonthReviewer_Review(t *testing.T) {
+	ctx := context.Background()
+	repo := newPeerRepo(t, "review")
+
+	add := func(day int, month int, desc, primary, secondary string, cents int64) int64 {
+		t.Helper()
+		ref, err := repo.AppendAndEnqueueSync(ctx, core.Expense{Date: core.NewDate(2032, month, day), Description: desc, Amount: core.Money{Cents: cents}, Primary: primary, Secondary: secondary})
+		if err != nil {
+			t.Fatal(err)
+		}
+		id, err := strconv.ParseInt(ref, 10, 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+	add(10, 4, "Spesa", "Spesa", "Supermercato", 10000)
+	add(3, 5, "Spesa", "Spesa", "Supermercato", 9000)
+	add(3, 5, "  spesa ", "Spesa", "Supermercato", 9000)
+	unknown := add(7, 5, "Bonifico", core.UncategorizedPrimary, core.UncategorizedSecondary, 2000)
+	if err := repo.MarkSyncError(ctx, unknown); err != nil {
+		t.Fatal(err)
+	}
+
+	reviewer := NewMonthReviewer(repo, false)
+	review, err := reviewer.Review(ctx, 2032, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if review.Period != "2032-05" || review.Closed() {
+		t.Errorf("review = %s closed %v, want open 2032-05", review.Period, review.Closed())
+	}
+	if len(review.Uncategorized) != 1 || review.Uncategorized[0].ID != unknown {
+		t.Errorf("Uncategorized = %+v, want the bank transfer", review.Uncategorized)
+	}
+	if len(review.Duplicates) != 1 || len(review.Duplicates[0]) != 2 {
+		t.Errorf("Duplicates = %+v, want the two groceries", review.Duplicates)
+	}
+	// Without Sheets sync only failed rows are unsynced
+	if len(review.Unsynced) != 1 || review.Unsynced[0].ID != unknown {
+		t.Errorf("Unsynced = %+v, want the failed row", review.Unsynced)
+	}
+	if len(review.Overruns) != 1 || review.Overruns[0].Primary != "Spesa" || review.Overruns[0].Amount.Cents != 18000 || review.Overruns[0].Budget.Cents != 10000 {
+		t.Errorf("Overruns = %+v, want Spesa 180 over 100", review.Overruns)
+	}
+	if synced, err := NewMonthReviewer(repo, true).Review(ctx, 2032, 5); err != nil || len(synced.Unsynced) != 3 {
+		t.Errorf("with sync, Unsynced = %d, %v, want 3", len(synced.Unsynced), err)
+	}
+
+	// A closed month refuses new and deleted expenses until reopened
+	if err := reviewer.Close(ctx, "2032-05"); err != nil {
+		t.Fatal(err)
+	}
+	if review, err := reviewer.Review(ctx, 2032, 5); err != nil || !review.Closed() {
+		t.Errorf("after close, Closed = %v, %v", review.Closed(), err)
+	}
+	if _, err := repo.AppendAndEnqueueSync(ctx, core.Expense{Date: core.NewDate(2032, 5, 20), Description: "Tardi", Amount: core.Money{Cents: 100}, Primary: "Spesa", Secondary: "Supermercato"}); !errors.Is(err, core.ErrMonthClosed) {
+		t.Errorf("append in closed month err = %v, want ErrMonthClosed", err)
+	}
+	if err := repo.HardDeleteAndEnqueueSync(ctx, unknown); !errors.Is(err, core.ErrMonthClosed) {
+		t.Errorf("delete in closed month err = %v, want ErrMonthClosed", err)
+	}
+	if _, err := repo.AppendIncome(ctx, core.Income{Date: core.NewDate(2032, 5, 27), Description: "Stipendio", Amount: core.Money{Cents: 100}, Category: "Stipendio"}); !errors.Is(err, core.ErrMonthClosed) {
+		t.Errorf("income in closed month err = %v, want ErrMonthClosed", err)
+	}
+	// Other months stay open
+	if _, err := repo.AppendAndEnqueueSync(ctx, core.Expense{Date: core.NewDate(2032, 6, 1), Description: "Dopo", Amount: core.Money{Cents: 100}, Primary: "Spesa", Secondary: "Supermercato"}); err != nil {
+		t.Errorf("append in open month: %v", err)
+	}
+
+	closed, err := reviewer.ClosedMonths(ctx)
+	if err != nil || closed["2032-05"].IsZero() {
+		t.Errorf("ClosedMonths = %v, %v", closed, err)
+	}
+	if err := reviewer.Reopen(ctx, "2032-05"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.HardDeleteAndEnqueueSync(ctx, unknown); err != nil {
+		t.Errorf("delete after reopen: %v", err)
+	}
+	if err := reviewer.Reopen(ctx, "2032-05"); err == nil {
+		t.Error("reopening an open month should fail")
+	}
+}
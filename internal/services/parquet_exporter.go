@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"spese/internal/core"
+	"spese/internal/export"
+)
+
+// ParquetDatasets lists the datasets ParquetExporter can write; each one is
+// exported to "<name>.parquet".
+var ParquetDatasets = []string{"expenses", "incomes"}
+
+// ParquetStore walks the full datasets to export one entry at a time, so
+// an export never holds a whole table in memory.
+type ParquetStore interface {
+	EachExpense(ctx context.Context, fn func(core.Expense) error) error
+	EachIncome(ctx context.Context, fn func(core.Income) error) error
+}
+
+// ParquetExporter writes expenses and incomes as Parquet files for analysis
+// tools such as DuckDB or pandas, on demand or to a directory on a schedule.
+type ParquetExporter struct {
+	store ParquetStore
+	dir   string // destination of ExportToDir; empty disables it
+}
+
+// NewParquetExporter creates an exporter reading from store. dir is where
+// ExportToDir writes and may be empty for on-demand use only.
+func NewParquetExporter(store ParquetStore, dir string) *ParquetExporter {
+	return &ParquetExporter{store: store, dir: dir}
+}
+
+// Write writes dataset ("expenses" or "incomes") as Parquet to w, streaming
+// rows from the store as they are read. On error w may hold a partial file.
+func (p *ParquetExporter) Write(ctx context.Context, dataset string, w io.Writer) error {
+	switch dataset {
+	case "expenses":
+		x := export.NewExpenseParquetWriter(w)
+		if err := p.store.EachExpense(ctx, x.Write); err != nil {
+			return err
+		}
+		return x.Close()
+	case "incomes":
+		x := export.NewIncomeParquetWriter(w)
+		if err := p.store.EachIncome(ctx, x.Write); err != nil {
+			return err
+		}
+		return x.Close()
+	default:
+		return fmt.Errorf("unknown dataset %q", dataset)
+	}
+}
+
+// ExportToDir writes every dataset into the configured directory. Files
+// are replaced atomically, so readers never see a partial export.
+func (p *ParquetExporter) ExportToDir(ctx context.Context) error {
+	if p.dir == "" {
+		return fmt.Errorf("parquet export directory not configured")
+	}
+	if err := os.MkdirAll(p.dir, 0o755); err != nil {
+		return fmt.Errorf("create export directory: %w", err)
+	}
+
+	for _, dataset := range ParquetDatasets {
+		path := filepath.Join(p.dir, dataset+".parquet")
+		if err := p.writeFile(ctx, dataset, path); err != nil {
+			return fmt.Errorf("export %s: %w", dataset, err)
+		}
+		slog.InfoContext(ctx, "Parquet export written", "dataset", dataset, "path", path, "component", "parquet_export")
+	}
+	return nil
+}
+
+func (p *ParquetExporter) writeFile(ctx context.Context, dataset, path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+dataset+"-*.parquet")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op after a successful rename
+
+	if err := p.Write(ctx, dataset, tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"spese/internal/core"
+)
+
+type fakeParquetStore struct {
+	expenses []core.Expense
+	incomes  []core.Income
+	err      error
+}
+
+// The Each methods hand over every entry before failing with err, like a
+// cursor that breaks mid-export.
+func (f fakeParquetStore) EachExpense(_ context.Context, fn func(core.Expense) error) error {
+	for _, e := range f.expenses {
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return f.err
+}
+
+func (f fakeParquetStore) EachIncome(_ context.Context, fn func(core.Income) error) error {
+	for _, in := range f.incomes {
+		if err := fn(in); err != nil {
+			return err
+		}
+	}
+	return f.err
+}
+
+func TestParquetExporter_ExportToDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "exports")
+	store := fakeParquetStore{
+		expenses: []core.Expense{{Date: core.NewDate(2025, 3, 10), Description: "Pane", Amount: core.Money{Cents: 250}, Primary: "Casa", Secondary: "Spesa"}},
+		incomes:  []core.Income{{Date: core.NewDate(2025, 3, 1), Description: "Stipendio", Amount: core.Money{Cents: 200000}, Category: "Lavoro"}},
+	}
+
+	if err := NewParquetExporter(store, dir).ExportToDir(context.Background()); err != nil {
+		t.Fatalf("ExportToDir: %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d files, want expenses.parquet and incomes.parquet only", len(entries))
+	}
+	for _, name := range []string{"expenses.parquet", "incomes.parquet"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(data) < 12 || string(data[:4]) != "PAR1" || string(data[len(data)-4:]) != "PAR1" {
+			t.Errorf("%s is not a Parquet file", name)
+		}
+	}
+}
+
+func TestParquetExporter_Errors(t *testing.T) {
+	dir := t.TempDir()
+	storeErr := errors.New("db down")
+
+	store := fakeParquetStore{
+		expenses: []core.Expense{{Date: core.NewDate(2025, 3, 10), Description: "Pane", Amount: core.Money{Cents: 250}}},
+		err:      storeErr,
+	}
+	err := NewParquetExporter(store, dir).ExportToDir(context.Background())
+	if !errors.Is(err, storeErr) {
+		t.Errorf("ExportToDir error = %v, want %v", err, storeErr)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("failed export left %d files behind", len(entries))
+	}
+
+	if err := NewParquetExporter(fakeParquetStore{}, "").ExportToDir(context.Background()); err == nil {
+		t.Error("ExportToDir without a directory should fail")
+	}
+	if err := NewParquetExporter(fakeParquetStore{}, "").Write(context.Background(), "budgets", nil); err == nil {
+		t.Error("unknown dataset should fail")
+	}
+}
@@ -0,0 +1,266 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"spese/internal/core"
+	"spese/internal/storage"
+)
+
+// Peer sync limits
+const (
+	PeerBatchSize    = 500  // changes per pull or push request
+	PeerMaxBatchSize = 1000 // largest batch a peer may request or send
+
+	// peerTimeLayout is the modified_at format written by the database
+	// triggers; a fixed layout keeps string comparison chronological.
+	peerTimeLayout = "2006-01-02T15:04:05.000Z"
+	peerMaxUIDLen  = 1024 // content-derived uids of pre-existing records are long
+)
+
+// PeerChangeSet is a page of changes exchanged over /peer/changes.
+type PeerChangeSet struct {
+	Changes []storage.PeerRecord `json:"changes"`
+	Cursor  int64                `json:"cursor,omitempty"` // pass as "since" for the next page
+	More    bool                 `json:"more,omitempty"`
+}
+
+// PeerStore keeps the change log and applies changes from peers.
+type PeerStore interface {
+	PeerChanges(ctx context.Context, cursor int64, limit int) ([]storage.PeerRecord, int64, bool, error)
+	ApplyPeerChanges(ctx context.Context, records []storage.PeerRecord) (int, error)
+	PeerSyncCursors(ctx context.Context, peer string) (pull, push int64, err error)
+	SavePeerSyncCursors(ctx context.Context, peer string, pull, push int64) error
+}
+
+// PeerSyncService lets two self-hosted instances converge on the same
+// expenses and incomes. Each side serves its change log and accepts
+// changes (the /peer/changes endpoint); the side configured with a peer
+// URL drives the exchange with Sync, pulling then pushing.
+type PeerSyncService struct {
+	store   PeerStore
+	peerURL string // base URL of the other instance; empty when only serving
+	token   string
+	client  *http.Client
+}
+
+// NewPeerSyncService creates the service. peerURL may be empty for an
+// instance that only serves the endpoint; token authenticates both ways.
+func NewPeerSyncService(store PeerStore, peerURL, token string) *PeerSyncService {
+	return &PeerSyncService{
+		store:   store,
+		peerURL: strings.TrimRight(peerURL, "/"),
+		token:   token,
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// SetHTTPClient sends the requests to the peer with client, e.g. one going
+// through an outbound proxy.
+func (p *PeerSyncService) SetHTTPClient(client *http.Client) {
+	p.client = client
+}
+
+// Token returns the shared secret peers must present.
+func (p *PeerSyncService) Token() string {
+	return p.token
+}
+
+// Changes returns up to limit local changes after the since cursor.
+func (p *PeerSyncService) Changes(ctx context.Context, since int64, limit int) (PeerChangeSet, error) {
+	if limit <= 0 || limit > PeerMaxBatchSize {
+		limit = PeerBatchSize
+	}
+	records, cursor, more, err := p.store.PeerChanges(ctx, since, limit)
+	if err != nil {
+		return PeerChangeSet{}, err
+	}
+	return PeerChangeSet{Changes: records, Cursor: cursor, More: more}, nil
+}
+
+// Apply validates and merges changes received from a peer, returning how
+// many modified local data.
+func (p *PeerSyncService) Apply(ctx context.Context, records []storage.PeerRecord) (int, error) {
+	if len(records) > PeerMaxBatchSize {
+		return 0, fmt.Errorf("too many changes: %d (max %d)", len(records), PeerMaxBatchSize)
+	}
+	for i, rec := range records {
+		if err := ValidatePeerRecord(rec); err != nil {
+			return 0, fmt.Errorf("change %d: %w", i, err)
+		}
+	}
+	if len(records) == 0 {
+		return 0, nil
+	}
+	return p.store.ApplyPeerChanges(ctx, records)
+}
+
+// ValidatePeerRecord checks a record received from a peer before it is
+// applied; live records must be valid expenses or incomes.
+func ValidatePeerRecord(rec storage.PeerRecord) error {
+	if rec.Kind != storage.PeerKindExpense && rec.Kind != storage.PeerKindIncome {
+		return fmt.Errorf("unknown kind %q", rec.Kind)
+	}
+	if rec.UID == "" || len(rec.UID) > peerMaxUIDLen {
+		return errors.New("invalid uid")
+	}
+	if rec.Version < 1 {
+		return errors.New("invalid version")
+	}
+	if _, err := time.Parse(peerTimeLayout, rec.ModifiedAt); err != nil {
+		return fmt.Errorf("invalid modified_at %q", rec.ModifiedAt)
+	}
+	if rec.Deleted {
+		return nil
+	}
+
+	t, err := time.Parse("2006-01-02", rec.Date)
+	if err != nil {
+		return fmt.Errorf("invalid date %q", rec.Date)
+	}
+	date := core.Date{Time: t}
+	amount := core.Money{Cents: rec.AmountCents}
+	if rec.Kind == storage.PeerKindIncome {
+		if rec.Tags != strings.Join(core.ParseTags(rec.Tags), ",") {
+			return fmt.Errorf("tags %q not normalized", rec.Tags)
+		}
+		return core.Income{Date: date, Description: rec.Description, Amount: amount, Category: rec.Category, Subcategory: rec.Subcategory, Tags: core.ParseTags(rec.Tags)}.Validate()
+	}
+	return core.Expense{Date: date, Description: rec.Description, Amount: amount, Primary: rec.Primary, Secondary: rec.Secondary, Status: core.ExpenseStatus(rec.Status)}.Validate()
+}
+
+// Sync exchanges changes with the configured peer: it pulls and applies
+// the peer's changes, then pushes local ones, saving both cursors as it
+// goes so an interrupted sync resumes where it stopped. Changes pulled
+// from the peer are pushed back once and ignored there as unchanged.
+func (p *PeerSyncService) Sync(ctx context.Context) error {
+	if p.peerURL == "" {
+		return errors.New("peer URL not configured")
+	}
+
+	pull, push, err := p.store.PeerSyncCursors(ctx, p.peerURL)
+	if err != nil {
+		return err
+	}
+	ctx = core.WithActor(ctx, "peer:"+p.peerURL)
+
+	pulled := 0
+	for {
+		page, err := p.fetchChanges(ctx, pull)
+		if err != nil {
+			return fmt.Errorf("pull: %w", err)
+		}
+		n, err := p.Apply(ctx, page.Changes)
+		if err != nil {
+			return fmt.Errorf("apply pulled changes: %w", err)
+		}
+		pulled += n
+		if page.Cursor > pull {
+			pull = page.Cursor
+			if err := p.store.SavePeerSyncCursors(ctx, p.peerURL, pull, push); err != nil {
+				return err
+			}
+		}
+		if !page.More {
+			break
+		}
+	}
+
+	pushed := 0
+	for {
+		records, cursor, more, err := p.store.PeerChanges(ctx, push, PeerBatchSize)
+		if err != nil {
+			return err
+		}
+		if cursor == push {
+			break
+		}
+		if len(records) > 0 {
+			n, err := p.sendChanges(ctx, records)
+			if err != nil {
+				return fmt.Errorf("push: %w", err)
+			}
+			pushed += n
+		}
+		push = cursor
+		if err := p.store.SavePeerSyncCursors(ctx, p.peerURL, pull, push); err != nil {
+			return err
+		}
+		if !more {
+			break
+		}
+	}
+
+	slog.InfoContext(ctx, "Peer sync completed",
+		"peer", p.peerURL,
+		"pulled", pulled,
+		"pushed", pushed,
+		"component", "peer_sync")
+	return nil
+}
+
+func (p *PeerSyncService) fetchChanges(ctx context.Context, since int64) (PeerChangeSet, error) {
+	q := url.Values{}
+	q.Set("since", strconv.FormatInt(since, 10))
+	q.Set("limit", strconv.Itoa(PeerBatchSize))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.peerURL+"/peer/changes?"+q.Encode(), nil)
+	if err != nil {
+		return PeerChangeSet{}, err
+	}
+
+	var page PeerChangeSet
+	if err := p.do(req, &page); err != nil {
+		return PeerChangeSet{}, err
+	}
+	return page, nil
+}
+
+func (p *PeerSyncService) sendChanges(ctx context.Context, records []storage.PeerRecord) (int, error) {
+	body, err := json.Marshal(PeerChangeSet{Changes: records})
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.peerURL+"/peer/changes", bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	var result struct {
+		Applied int `json:"applied"`
+	}
+	if err := p.do(req, &result); err != nil {
+		return 0, err
+	}
+	return result.Applied, nil
+}
+
+// do sends an authenticated request and decodes the JSON response into out.
+func (p *PeerSyncService) do(req *http.Request, out any) error {
+	req.Header.Set("Authorization", "Bearer "+p.token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("peer responded %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
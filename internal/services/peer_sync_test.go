@@ -0,0 +1,272 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"spese/internal/core"
+	"spese/internal/storage"
+)
+
+// peerHandler is a minimal /peer/changes endpoint backed by svc.
+func peerHandler(t *testing.T, svc *PeerSyncService) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+svc.Token() {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Method == http.MethodGet {
+			since, _ := strconv.ParseInt(r.URL.Query().Get("since"), 10, 64)
+			limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+			page, err := svc.Changes(r.Context(), since, limit)
+			if err != nil {
+				t.Errorf("Changes: %v", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(page)
+			return
+		}
+		var body PeerChangeSet
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		n, err := svc.Apply(r.Context(), body.Changes)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]int{"applied": n})
+	})
+}
+
+func newPeerRepo(t *testing.T, name string) *storage.SQLiteRepository {
+	t.Helper()
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), name+".db"))
+	if err != nil {
+		t.Fatalf("open %s: %v", name, err)
+	}
+	t.Cleanup(func() { repo.Close() })
+	return repo
+}
+
+// findExpenses returns the expenses with the given description; migrations
+// seed a shared history, so the tests look for their own records.
+func findExpenses(t *testing.T, repo *storage.SQLiteRepository, description string) []core.Expense {
+	t.Helper()
+	all, err := repo.ListAllExpenses(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var found []core.Expense
+	for _, e := range all {
+		if e.Description == description {
+			found = append(found, e)
+		}
+	}
+	return found
+}
+
+// expenseTags returns the comma-separated tags of the March 2025 expense
+// with the given description.
+func expenseTags(t *testing.T, repo *storage.SQLiteRepository, description string) string {
+	t.Helper()
+	expenses, err := repo.ListExpensesWithID(context.Background(), 2025, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range expenses {
+		if e.Expense.Description != description {
+			continue
+		}
+		id, _ := strconv.ParseInt(e.ID, 10, 64)
+		tags, err := repo.ExpenseTags(context.Background(), id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.Join(tags, ",")
+	}
+	t.Fatalf("no expense %q", description)
+	return ""
+}
+
+func TestPeerSyncService_Converges(t *testing.T) {
+	ctx := context.Background()
+	laptop := newPeerRepo(t, "laptop")
+	server := newPeerRepo(t, "server")
+
+	remote := httptest.NewServer(peerHandler(t, NewPeerSyncService(server, "", "secret")))
+	defer remote.Close()
+	syncer := NewPeerSyncService(laptop, remote.URL, "secret")
+
+	if _, err := laptop.AppendAndEnqueueSync(ctx, core.Expense{Date: core.NewDate(2025, 3, 10), Description: "Pane", Amount: core.Money{Cents: 250}, Primary: "Casa", Secondary: "Spesa", Tags: []string{"colazione"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := server.AppendIncome(ctx, core.Income{Date: core.NewDate(2025, 3, 1), Description: "Stipendio", Amount: core.Money{Cents: 200000}, Category: "Lavoro", Subcategory: "Bonus", Tags: []string{"premio", "q1"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	seeded, err := server.ListAllExpenses(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := syncer.Sync(ctx); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	for name, repo := range map[string]*storage.SQLiteRepository{"laptop": laptop, "server": server} {
+		expenses, _ := repo.ListAllExpenses(ctx)
+		incomes, _ := repo.ListAllIncomes(ctx)
+		// The seeded history is recognised on both sides, not duplicated
+		if len(expenses) != len(seeded)+1 || len(findExpenses(t, repo, "Pane")) != 1 || len(incomes) != 1 || incomes[0].Description != "Stipendio" {
+			t.Errorf("%s after first sync: %d expenses (want %d), %d incomes", name, len(expenses), len(seeded)+1, len(incomes))
+		}
+		if len(incomes) == 1 && (incomes[0].Subcategory != "Bonus" || strings.Join(incomes[0].Tags, ",") != "premio,q1") {
+			t.Errorf("%s income = %+v, want subcategory and tags synced", name, incomes[0])
+		}
+		if tags := expenseTags(t, repo, "Pane"); tags != "colazione" {
+			t.Errorf("%s expense tags = %q, want colazione", name, tags)
+		}
+	}
+
+	// An edit on one side and a delete on the other both propagate
+	laptopExpenses, _ := laptop.ListExpensesWithID(ctx, 2025, 3)
+	var id int64
+	for _, e := range laptopExpenses {
+		if e.Expense.Description == "Pane" {
+			id, _ = strconv.ParseInt(e.ID, 10, 64)
+		}
+	}
+	if err := laptop.UpdateExpenseAmount(ctx, id, 300); err != nil {
+		t.Fatal(err)
+	}
+	serverIncomes, _ := server.ListIncomesWithID(ctx, 2025, 3)
+	incomeID, _ := strconv.ParseInt(serverIncomes[0].ID, 10, 64)
+	if err := server.HardDeleteIncome(ctx, incomeID); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := syncer.Sync(ctx); err != nil {
+		t.Fatalf("second Sync: %v", err)
+	}
+	for name, repo := range map[string]*storage.SQLiteRepository{"laptop": laptop, "server": server} {
+		expenses := findExpenses(t, repo, "Pane")
+		incomes, _ := repo.ListAllIncomes(ctx)
+		if len(expenses) != 1 || expenses[0].Amount.Cents != 300 {
+			t.Errorf("%s: expenses = %+v, want one at 300 cents", name, expenses)
+		}
+		if len(incomes) != 0 {
+			t.Errorf("%s: incomes = %+v, want the deleted income gone", name, incomes)
+		}
+	}
+
+	// A stale copy of the deleted income does not bring it back
+	stale := storage.PeerRecord{Kind: storage.PeerKindIncome, Version: 1, ModifiedAt: "2020-01-01T00:00:00.000Z", Date: "2025-03-01", Description: "Stipendio", AmountCents: 200000, Category: "Lavoro"}
+	page, err := NewPeerSyncService(laptop, "", "secret").Changes(ctx, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, rec := range page.Changes {
+		if rec.Kind == storage.PeerKindIncome {
+			stale.UID = rec.UID
+			if !rec.Deleted {
+				t.Errorf("income change = %+v, want a tombstone", rec)
+			}
+		}
+	}
+	if n, err := laptop.ApplyPeerChanges(ctx, []storage.PeerRecord{stale}); err != nil || n != 0 {
+		t.Errorf("stale apply = %d, %v; want 0 changes", n, err)
+	}
+
+	// A change of the tags alone is a change of the expense
+	stored, err := laptop.GetExpense(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := laptop.UpdateExpense(ctx, id, core.Expense{Date: core.NewDate(2025, 3, 10), Description: "Pane", Amount: core.Money{Cents: 300}, Primary: "Casa", Secondary: "Spesa", Tags: []string{"colazione", "bar"}}, stored.Version); err != nil {
+		t.Fatal(err)
+	}
+	if err := syncer.Sync(ctx); err != nil {
+		t.Fatalf("third Sync: %v", err)
+	}
+	if tags := expenseTags(t, server, "Pane"); tags != "colazione,bar" {
+		t.Errorf("server expense tags = %q, want the edited tags", tags)
+	}
+
+	// Nothing left to exchange
+	if err := syncer.Sync(ctx); err != nil {
+		t.Fatalf("fourth Sync: %v", err)
+	}
+	if expenses, _ := server.ListAllExpenses(ctx); len(expenses) != len(seeded)+1 {
+		t.Errorf("server has %d expenses after idle sync, want %d", len(expenses), len(seeded)+1)
+	}
+}
+
+func TestPeerSyncService_Unauthorized(t *testing.T) {
+	remote := httptest.NewServer(peerHandler(t, NewPeerSyncService(newPeerRepo(t, "server"), "", "secret")))
+	defer remote.Close()
+
+	err := NewPeerSyncService(newPeerRepo(t, "laptop"), remote.URL, "wrong").Sync(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("Sync with wrong token: err = %v, want 401", err)
+	}
+}
+
+func TestValidatePeerRecord(t *testing.T) {
+	valid := storage.PeerRecord{
+		Kind:        storage.PeerKindExpense,
+		UID:         "0123abcd",
+		Version:     1,
+		ModifiedAt:  "2025-03-10T08:00:00.000Z",
+		Date:        "2025-03-10",
+		Description: "Pane",
+		AmountCents: 250,
+		Primary:     "Casa",
+		Secondary:   "Spesa",
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(*storage.PeerRecord)
+		want   string
+	}{
+		{"valid", func(*storage.PeerRecord) {}, ""},
+		{"tombstone needs no data", func(r *storage.PeerRecord) {
+			*r = storage.PeerRecord{Kind: r.Kind, UID: r.UID, Version: 2, ModifiedAt: r.ModifiedAt, Deleted: true}
+		}, ""},
+		{"unknown kind", func(r *storage.PeerRecord) { r.Kind = "account" }, "unknown kind"},
+		{"missing uid", func(r *storage.PeerRecord) { r.UID = "" }, "invalid uid"},
+		{"zero version", func(r *storage.PeerRecord) { r.Version = 0 }, "invalid version"},
+		{"other time format", func(r *storage.PeerRecord) { r.ModifiedAt = "2025-03-10T08:00:00Z" }, "invalid modified_at"},
+		{"bad date", func(r *storage.PeerRecord) { r.Date = "10/03/2025" }, "invalid date"},
+		{"zero amount", func(r *storage.PeerRecord) { r.AmountCents = 0 }, "amount"},
+		{"income without category", func(r *storage.PeerRecord) { r.Kind = storage.PeerKindIncome }, "category"},
+		{"unnormalized tags", func(r *storage.PeerRecord) {
+			r.Kind, r.Category, r.Tags = storage.PeerKindIncome, "Lavoro", "Bonus,q1"
+		}, "not normalized"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := valid
+			tt.mutate(&rec)
+			err := ValidatePeerRecord(rec)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"spese/internal/core"
+	"spese/internal/storage"
+)
+
+// Quotas enforces the limits of the household members calling the API
+// with their keys: expenses created per day and bytes of receipts stored.
+// Requests without a key, such as the web UI, are not limited. Past the
+// soft limit (a percentage of a quota) changes still go through but are
+// logged, and the member is flagged on the usage page.
+type Quotas struct {
+	store       *storage.SQLiteRepository
+	defaults    core.QuotaLimits
+	softPercent int64
+	now         func() time.Time
+}
+
+// NewQuotas creates quotas with the instance defaults, which members can
+// override one by one. softPercent is the share of a quota, 1 to 100,
+// from which usage is reported as close to the limit.
+func NewQuotas(store *storage.SQLiteRepository, defaults core.QuotaLimits, softPercent int) *Quotas {
+	return &Quotas{store: store, defaults: defaults, softPercent: int64(softPercent), now: time.Now}
+}
+
+// Defaults returns the instance quotas.
+func (q *Quotas) Defaults() core.QuotaLimits {
+	return q.defaults
+}
+
+// Limits returns the quotas of a member: the defaults with their
+// overrides applied.
+func (q *Quotas) Limits(ctx context.Context, userID int64) (core.QuotaLimits, error) {
+	o, err := q.store.QuotaOverride(ctx, userID)
+	if err != nil {
+		return core.QuotaLimits{}, err
+	}
+	return q.defaults.With(o), nil
+}
+
+// NearLimit reports whether used is past the soft limit of a quota.
+func (q *Quotas) NearLimit(used, limit int64) bool {
+	return limit > 0 && q.softPercent > 0 && used*100 >= limit*q.softPercent
+}
+
+// today is the day daily quotas are counted on, in local time
+func (q *Quotas) today() string {
+	return q.now().Format("2006-01-02")
+}
+
+// ReserveExpenses counts n new expenses against the daily quota of the
+// member in ctx, failing with a *core.QuotaError when they do not fit.
+// release gives them back and must be called if the expenses are not
+// saved after all. Without a member, or on a nil Quotas, nothing is
+// counted.
+func (q *Quotas) ReserveExpenses(ctx context.Context, n int) (release func(), err error) {
+	user, ok := core.UserFromContext(ctx)
+	if q == nil || !ok || n <= 0 {
+		return func() {}, nil
+	}
+	limits, err := q.Limits(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	day := q.today()
+	total, err := q.store.ConsumeDailyExpenses(ctx, user.ID, day, int64(n), limits.DailyExpenses)
+	if err != nil {
+		slog.WarnContext(ctx, "Expense quota exceeded", "error", err, "user_id", user.ID, "component", "quotas")
+		return nil, err
+	}
+	if q.NearLimit(total, limits.DailyExpenses) {
+		slog.WarnContext(ctx, "Expense quota nearly used", "user_id", user.ID, "expenses", total, "limit", limits.DailyExpenses, "component", "quotas")
+	}
+
+	return func() {
+		// The request may be over, the count must still be given back
+		ctx := context.WithoutCancel(ctx)
+		if err := q.store.ReleaseDailyExpenses(ctx, user.ID, day, int64(n)); err != nil {
+			slog.ErrorContext(ctx, "Failed to release expense quota", "error", err, "user_id", user.ID, "component", "quotas")
+		}
+	}, nil
+}
+
+// ReserveAttachment holds size bytes of the attachment quota of the member
+// in ctx for the receipt of an expense, failing with a *core.QuotaError
+// when they do not fit. The receipt it replaces, if any, is not counted.
+// release must be called once the upload is over: the stored receipt
+// counts in place of the reservation, and a failed upload gives the bytes
+// back. Without a member or a limit, or on a nil Quotas, nothing is held.
+func (q *Quotas) ReserveAttachment(ctx context.Context, expenseID, size int64) (release func(), err error) {
+	user, ok := core.UserFromContext(ctx)
+	if q == nil || !ok {
+		return func() {}, nil
+	}
+	limits, err := q.Limits(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if limits.AttachmentBytes == 0 {
+		return func() {}, nil
+	}
+
+	id, err := q.store.ReserveAttachmentBytes(ctx, user.ID, expenseID, size, limits.AttachmentBytes)
+	if err != nil {
+		slog.WarnContext(ctx, "Attachment quota exceeded", "error", err, "user_id", user.ID, "component", "quotas")
+		return nil, err
+	}
+	if used, err := q.store.AttachmentBytes(ctx, user.ID, expenseID); err == nil && q.NearLimit(used+size, limits.AttachmentBytes) {
+		slog.WarnContext(ctx, "Attachment quota nearly used", "user_id", user.ID, "bytes", used+size, "limit", limits.AttachmentBytes, "component", "quotas")
+	}
+
+	return func() {
+		// The request may be over, the reservation must still be dropped
+		ctx := context.WithoutCancel(ctx)
+		if err := q.store.ReleaseAttachmentBytes(ctx, id); err != nil {
+			slog.ErrorContext(ctx, "Failed to release attachment quota", "error", err, "user_id", user.ID, "component", "quotas")
+		}
+	}, nil
+}
+
+// QuotaUsage is where a member stands against their quotas.
+type QuotaUsage struct {
+	User            core.User
+	Limits          core.QuotaLimits
+	Override        core.QuotaOverride
+	ExpensesToday   int64
+	AttachmentBytes int64
+	Keys            []core.APIKey
+}
+
+// Usage returns the usage of every member, by name.
+func (q *Quotas) Usage(ctx context.Context) ([]QuotaUsage, error) {
+	users, err := q.store.ListUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := q.store.QuotaOverrides(ctx)
+	if err != nil {
+		return nil, err
+	}
+	expenses, err := q.store.DailyExpenses(ctx, q.today())
+	if err != nil {
+		return nil, err
+	}
+	attachments, err := q.store.AttachmentBytesByUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := q.store.ListAPIKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := make([]QuotaUsage, len(users))
+	for i, u := range users {
+		usage[i] = QuotaUsage{
+			User:            u,
+			Limits:          q.defaults.With(overrides[u.ID]),
+			Override:        overrides[u.ID],
+			ExpensesToday:   expenses[u.ID],
+			AttachmentBytes: attachments[u.ID],
+		}
+		for _, k := range keys {
+			if k.UserID == u.ID {
+				usage[i].Keys = append(usage[i].Keys, k)
+			}
+		}
+	}
+	return usage, nil
+}
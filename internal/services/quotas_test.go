@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"spese/internal/core"
+	"spese/internal/storage"
+)
+
+func TestQuotas(t *testing.T) {
+	ctx := context.Background()
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	anna, err := repo.CreateUser(ctx, "Anna")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	quotas := NewQuotas(repo, core.QuotaLimits{DailyExpenses: 3, AttachmentBytes: 1000}, 80)
+	quotas.now = func() time.Time { return time.Date(2030, 5, 4, 10, 0, 0, 0, time.Local) }
+	service := NewExpenseService(repo)
+	service.SetQuotas(quotas)
+	defer service.Close()
+
+	expense := core.Expense{Date: core.NewDate(2030, 5, 4), Description: "Pane", Amount: core.Money{Cents: 250}, Primary: "Casa", Secondary: "Supermercato"}
+
+	// Without a member nothing is counted
+	if _, err := service.CreateExpense(ctx, expense); err != nil {
+		t.Fatal(err)
+	}
+
+	keyCtx := core.WithUser(ctx, anna)
+	if _, err := service.CreateExpenses(keyCtx, []core.Expense{expense, expense}); err != nil {
+		t.Fatal(err)
+	}
+	// A reservation given back, as after a failed save, frees its place
+	release, err := quotas.ReserveExpenses(keyCtx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+	_, err = service.CreateExpenses(keyCtx, []core.Expense{expense, expense})
+	var quotaErr *core.QuotaError
+	if !errors.As(err, &quotaErr) || !errors.Is(err, core.ErrQuotaExceeded) {
+		t.Fatalf("over the quota: err = %v, want a quota error", err)
+	}
+	if quotaErr.Quota != core.QuotaDailyExpenses || quotaErr.Limit != 3 || quotaErr.Used != 2 || quotaErr.Requested != 2 {
+		t.Errorf("quota error = %+v", quotaErr)
+	}
+	if _, err := service.CreateExpense(keyCtx, expense); err != nil {
+		t.Fatalf("last expense of the day: %v", err)
+	}
+	if _, err := service.CreateExpense(keyCtx, expense); !errors.Is(err, core.ErrQuotaExceeded) {
+		t.Fatalf("expense past the quota: err = %v", err)
+	}
+
+	usage, err := quotas.Usage(ctx)
+	if err != nil || len(usage) != 1 {
+		t.Fatalf("usage = %+v, %v", usage, err)
+	}
+	if u := usage[0]; u.ExpensesToday != 3 || !quotas.NearLimit(3, u.Limits.DailyExpenses) || quotas.NearLimit(2, u.Limits.DailyExpenses) {
+		t.Errorf("usage = %+v", u)
+	}
+
+	// Overrides replace the defaults, 0 removing the limit
+	unlimited := int64(0)
+	if err := repo.SetQuotaOverride(ctx, anna.ID, core.QuotaOverride{DailyExpenses: &unlimited}); err != nil {
+		t.Fatal(err)
+	}
+	if limits, err := quotas.Limits(ctx, anna.ID); err != nil || limits.DailyExpenses != 0 || limits.AttachmentBytes != 1000 {
+		t.Errorf("limits = %+v, %v", limits, err)
+	}
+	if _, err := service.CreateExpense(keyCtx, expense); err != nil {
+		t.Fatalf("unlimited member: %v", err)
+	}
+
+	// Receipts count against the member who uploaded them; a replaced
+	// receipt is not counted twice
+	refs, err := service.CreateExpenses(ctx, []core.Expense{expense, expense})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids [2]int64
+	for i, ref := range refs {
+		if ids[i], err = strconv.ParseInt(ref, 10, 64); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rec := core.Receipt{ExpenseID: ids[0], ContentType: core.ReceiptPDF, Filename: "a.pdf", Key: "a", Size: 600, UploadedBy: anna.ID}
+	if err := repo.SetExpenseReceipt(ctx, rec); err != nil {
+		t.Fatal(err)
+	}
+	release, err = quotas.ReserveAttachment(keyCtx, ids[0], 900)
+	if err != nil {
+		t.Fatalf("replacing a receipt: %v", err)
+	}
+	release()
+	if _, err := quotas.ReserveAttachment(keyCtx, ids[1], 500); !errors.As(err, &quotaErr) || quotaErr.Used != 600 {
+		t.Errorf("second receipt: err = %v, want a quota error with 600 used", err)
+	}
+	if _, err := quotas.ReserveAttachment(ctx, ids[1], 5000); err != nil {
+		t.Errorf("web upload: %v", err)
+	}
+
+	// An upload in progress holds its bytes until released
+	release, err = quotas.ReserveAttachment(keyCtx, ids[1], 300)
+	if err != nil {
+		t.Fatalf("upload within the quota: %v", err)
+	}
+	if _, err := quotas.ReserveAttachment(keyCtx, ids[1]+1000, 200); !errors.As(err, &quotaErr) || quotaErr.Used != 900 {
+		t.Errorf("parallel upload: err = %v, want a quota error with 900 used", err)
+	}
+	release()
+	release, err = quotas.ReserveAttachment(keyCtx, ids[1]+1000, 200)
+	if err != nil {
+		t.Errorf("after release: %v", err)
+	} else {
+		release()
+	}
+}
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"spese/internal/receipts"
+	"spese/internal/storage"
+)
+
+// receiptCleanBatch is how many blobs a cleaning pass deletes at most
+const receiptCleanBatch = 100
+
+// ReceiptCleaner deletes from blob storage the receipt files no longer
+// referenced: those of replaced or removed receipts and of deleted
+// expenses. The database queues them, since storage cannot take part in
+// its transactions.
+type ReceiptCleaner struct {
+	storage *storage.SQLiteRepository
+	blobs   receipts.Store
+}
+
+// NewReceiptCleaner creates a cleaner deleting queued blobs from blobs.
+func NewReceiptCleaner(storage *storage.SQLiteRepository, blobs receipts.Store) *ReceiptCleaner {
+	return &ReceiptCleaner{storage: storage, blobs: blobs}
+}
+
+// Clean deletes up to receiptCleanBatch queued blobs and returns how many
+// went. A blob that cannot be deleted stays queued for the next pass.
+func (c *ReceiptCleaner) Clean(ctx context.Context) (int, error) {
+	keys, err := c.storage.PendingBlobDeletions(ctx, receiptCleanBatch)
+	if err != nil {
+		return 0, fmt.Errorf("list blobs to delete: %w", err)
+	}
+
+	deleted := 0
+	for _, key := range keys {
+		if err := c.blobs.Delete(ctx, key); err != nil {
+			slog.WarnContext(ctx, "Failed to delete receipt file", "key", key, "error", err)
+			continue
+		}
+		if err := c.storage.BlobDeleted(ctx, key); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"spese/internal/core"
+	"spese/internal/receipts"
+)
+
+func TestReceiptCleaner(t *testing.T) {
+	ctx := context.Background()
+	repo := newPeerRepo(t, "receipts")
+	blobs, err := receipts.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cleaner := NewReceiptCleaner(repo, blobs)
+
+	ref, err := repo.AppendAndEnqueueSync(ctx, core.Expense{Date: core.NewDate(2031, 3, 1), Description: "Cuffie", Amount: core.Money{Cents: 19900}, Primary: "Casa", Secondary: "Elettronica"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, _ := strconv.ParseInt(ref, 10, 64)
+	attach := func(key string) {
+		t.Helper()
+		if err := blobs.Put(ctx, key, core.ReceiptPDF, []byte("%PDF-1.7")); err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.SetExpenseReceipt(ctx, core.Receipt{ExpenseID: id, Key: key, ContentType: core.ReceiptPDF, Size: 8}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	exists := func(key string) bool {
+		rc, err := blobs.Get(ctx, key)
+		if err == nil {
+			rc.Close()
+		}
+		return !errors.Is(err, receipts.ErrNotFound)
+	}
+
+	attach("receipts/1/first.pdf")
+	if n, err := cleaner.Clean(ctx); err != nil || n != 0 {
+		t.Fatalf("nothing to clean: n = %d, err = %v", n, err)
+	}
+
+	// A replaced receipt leaves its file behind for the cleaner
+	attach("receipts/1/second.pdf")
+	if n, err := cleaner.Clean(ctx); err != nil || n != 1 {
+		t.Fatalf("after replace: n = %d, err = %v", n, err)
+	}
+	if exists("receipts/1/first.pdf") || !exists("receipts/1/second.pdf") {
+		t.Error("replace: the old file should be gone and the new one kept")
+	}
+
+	// Deleting the expense queues its receipt through the trigger
+	if err := repo.HardDeleteExpense(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.ExpenseReceipt(ctx, id); err == nil {
+		t.Error("receipt kept after its expense was deleted")
+	}
+	if n, err := cleaner.Clean(ctx); err != nil || n != 1 || exists("receipts/1/second.pdf") {
+		t.Errorf("after expense delete: n = %d, err = %v", n, err)
+	}
+}
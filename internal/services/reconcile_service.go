@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"spese/internal/core"
+	"spese/internal/sheets"
+	"spese/internal/storage"
+)
+
+// ErrDivergenceNotFound is returned when a repair is requested for an expense
+// whose amount no longer differs between SQLite and Google Sheets.
+var ErrDivergenceNotFound = errors.New("no amount divergence for expense")
+
+// AmountDivergence describes an expense whose amount in Google Sheets differs
+// from the one stored in SQLite.
+type AmountDivergence struct {
+	ExpenseID   int64
+	Date        core.Date
+	Description string
+	DBCents     int64
+	SheetCents  int64
+	SheetRow    int
+}
+
+// ReconcileService compares expenses stored in SQLite with the rows synced to
+// Google Sheets (matched through the hidden ID column) and repairs amounts.
+type ReconcileService struct {
+	storage *storage.SQLiteRepository
+	sheets  sheets.AmountReconciler
+}
+
+// NewReconcileService creates a new reconciliation service
+func NewReconcileService(storage *storage.SQLiteRepository, sheetsReconciler sheets.AmountReconciler) *ReconcileService {
+	return &ReconcileService{
+		storage: storage,
+		sheets:  sheetsReconciler,
+	}
+}
+
+// FindAmountDivergences returns the expenses of the given month whose amount
+// differs from the one found in the sheet of their year. Expenses without an ID-tagged row
+// (e.g. synced before the ID column existed) are ignored, and so are split
+// expenses, whose rows hold the amounts of their parts.
+func (s *ReconcileService) FindAmountDivergences(ctx context.Context, year, month int) ([]AmountDivergence, error) {
+	expenses, err := s.storage.ListExpensesWithID(ctx, year, month)
+	if err != nil {
+		return nil, fmt.Errorf("list expenses: %w", err)
+	}
+	splits, err := s.storage.MonthSplits(ctx, year, month)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.sheets.ListAmountsByID(ctx, year)
+	if err != nil {
+		return nil, fmt.Errorf("list sheet amounts: %w", err)
+	}
+	kept := expenses[:0]
+	for _, e := range expenses {
+		if id, err := strconv.ParseInt(e.ID, 10, 64); err == nil && len(splits[id]) > 0 {
+			continue
+		}
+		kept = append(kept, e)
+	}
+	return diffAmounts(kept, rows), nil
+}
+
+// RepairSheet overwrites the sheet amount with the value stored in SQLite.
+func (s *ReconcileService) RepairSheet(ctx context.Context, expenseID int64) error {
+	expense, row, err := s.findDivergence(ctx, expenseID)
+	if err != nil {
+		return err
+	}
+	if err := s.sheets.UpdateAmount(ctx, expense.Date.Year(), row.Row, expense.AmountCents); err != nil {
+		return fmt.Errorf("update sheet amount: %w", err)
+	}
+
+	slog.InfoContext(ctx, "Repaired sheet amount from database",
+		"expense_id", expenseID,
+		"row", row.Row,
+		"sheet_cents", row.Cents,
+		"db_cents", expense.AmountCents)
+	return nil
+}
+
+// AcceptSheetValue stores the sheet amount in SQLite.
+func (s *ReconcileService) AcceptSheetValue(ctx context.Context, expenseID int64) error {
+	expense, row, err := s.findDivergence(ctx, expenseID)
+	if err != nil {
+		return err
+	}
+	if row.Cents <= 0 {
+		return fmt.Errorf("sheet amount %d is not a valid expense amount: %w", row.Cents, core.ErrInvalidAmount)
+	}
+	if err := s.storage.UpdateExpenseAmount(ctx, expenseID, row.Cents); err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "Accepted sheet amount into database",
+		"expense_id", expenseID,
+		"row", row.Row,
+		"sheet_cents", row.Cents,
+		"db_cents", expense.AmountCents)
+	return nil
+}
+
+// findDivergence loads the expense and its sheet row, failing if the amounts match.
+func (s *ReconcileService) findDivergence(ctx context.Context, expenseID int64) (*storage.Expense, sheets.SheetAmountRow, error) {
+	expense, err := s.storage.GetExpense(ctx, expenseID)
+	if err != nil {
+		return nil, sheets.SheetAmountRow{}, err
+	}
+	parts, err := s.storage.ListExpenseSplit(ctx, expenseID)
+	if err != nil {
+		return nil, sheets.SheetAmountRow{}, err
+	}
+	if len(parts) > 0 {
+		return nil, sheets.SheetAmountRow{}, fmt.Errorf("%w %d", ErrDivergenceNotFound, expenseID)
+	}
+	rows, err := s.sheets.ListAmountsByID(ctx, expense.Date.Year())
+	if err != nil {
+		return nil, sheets.SheetAmountRow{}, fmt.Errorf("list sheet amounts: %w", err)
+	}
+	for _, row := range rows {
+		if row.ID == expenseID && row.Cents != expense.AmountCents {
+			return expense, row, nil
+		}
+	}
+	return nil, sheets.SheetAmountRow{}, fmt.Errorf("%w %d", ErrDivergenceNotFound, expenseID)
+}
+
+// diffAmounts pairs expenses with sheet rows by ID and keeps the mismatches.
+func diffAmounts(expenses []storage.ExpenseWithID, rows []sheets.SheetAmountRow) []AmountDivergence {
+	byID := make(map[int64]sheets.SheetAmountRow, len(rows))
+	for _, row := range rows {
+		// Keep the first row if an ID was duplicated by hand in the sheet
+		if _, seen := byID[row.ID]; !seen {
+			byID[row.ID] = row
+		}
+	}
+
+	var out []AmountDivergence
+	for _, e := range expenses {
+		id, err := strconv.ParseInt(e.ID, 10, 64)
+		if err != nil {
+			continue
+		}
+		row, ok := byID[id]
+		if !ok || row.Cents == e.Expense.Amount.Cents {
+			continue
+		}
+		out = append(out, AmountDivergence{
+			ExpenseID:   id,
+			Date:        e.Expense.Date,
+			Description: e.Expense.Description,
+			DBCents:     e.Expense.Amount.Cents,
+			SheetCents:  row.Cents,
+			SheetRow:    row.Row,
+		})
+	}
+	return out
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"spese/internal/core"
+	"spese/internal/sheets"
+	"spese/internal/storage"
+)
+
+func TestNewReconcileService(t *testing.T) {
+	service := NewReconcileService(nil, nil)
+
+	if service == nil {
+		t.Fatal("NewReconcileService should return a non-nil service")
+	}
+	if service.storage != nil || service.sheets != nil {
+		t.Error("NewReconcileService should keep nil dependencies as nil")
+	}
+}
+
+func TestDiffAmounts(t *testing.T) {
+	expense := func(id string, cents int64) storage.ExpenseWithID {
+		return storage.ExpenseWithID{
+			ID: id,
+			Expense: core.Expense{
+				Date:        core.NewDate(2025, 3, 10),
+				Description: "Spesa " + id,
+				Amount:      core.Money{Cents: cents},
+			},
+		}
+	}
+	expenses := []storage.ExpenseWithID{
+		expense("1", 1000), // matches
+		expense("2", 2000), // diverges
+		expense("3", 3000), // not in sheet
+		expense("x", 4000), // invalid ID
+	}
+	rows := []sheets.SheetAmountRow{
+		{ID: 1, Row: 2, Cents: 1000},
+		{ID: 2, Row: 3, Cents: 2050},
+		{ID: 2, Row: 9, Cents: 2000}, // duplicate ID, first row wins
+		{ID: 99, Row: 4, Cents: 100}, // not in this month
+	}
+
+	got := diffAmounts(expenses, rows)
+	if len(got) != 1 {
+		t.Fatalf("expected 1 divergence, got %d: %+v", len(got), got)
+	}
+	d := got[0]
+	if d.ExpenseID != 2 || d.DBCents != 2000 || d.SheetCents != 2050 || d.SheetRow != 3 {
+		t.Fatalf("unexpected divergence: %+v", d)
+	}
+	if d.Description != "Spesa 2" {
+		t.Fatalf("unexpected description: %q", d.Description)
+	}
+}
+
+// yearSheets holds the ID-tagged rows of the expenses sheet of each year
+// and records the amounts written.
+type yearSheets struct {
+	rows    map[int]sheetRows
+	updated []string // year/row=cents
+}
+
+func (s *yearSheets) ListAmountsByID(_ context.Context, year int) ([]sheets.SheetAmountRow, error) {
+	return s.rows[year], nil
+}
+
+func (s *yearSheets) UpdateAmount(_ context.Context, year, row int, cents int64) error {
+	s.updated = append(s.updated, strconv.Itoa(year)+"/"+strconv.Itoa(row)+"="+strconv.FormatInt(cents, 10))
+	return nil
+}
+
+func TestReconcilePastYear(t *testing.T) {
+	ctx := context.Background()
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	ref, err := repo.AppendAndEnqueueSync(ctx, core.Expense{Date: core.NewDate(2030, 12, 20), Description: "Regali", Amount: core.Money{Cents: 1000}, Primary: "Casa", Secondary: "Spesa"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, _ := strconv.ParseInt(ref, 10, 64)
+	sheet := &yearSheets{rows: map[int]sheetRows{
+		2030: {{ID: id, Row: 7, Cents: 1200}},
+		2031: {{ID: id, Row: 2, Cents: 1000}}, // Another year's sheet must not be read
+	}}
+	svc := NewReconcileService(repo, sheet)
+
+	got, err := svc.FindAmountDivergences(ctx, 2030, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].ExpenseID != id || got[0].SheetRow != 7 || got[0].SheetCents != 1200 {
+		t.Fatalf("divergences = %+v, want the row of the 2030 sheet", got)
+	}
+	if err := svc.RepairSheet(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	if len(sheet.updated) != 1 || sheet.updated[0] != "2030/7=1000" {
+		t.Errorf("updated = %v, want row 7 of the 2030 sheet", sheet.updated)
+	}
+}
@@ -14,9 +14,10 @@ import (
 	"time"
 )
 
-// RecurringProcessor handles the automatic creation of expenses from recurring expense templates.
-// It processes configured recurrent expenses and creates actual expense entries
-// based on their frequency (daily, weekly, monthly, yearly) and date ranges.
+// RecurringProcessor handles the automatic creation of expenses and incomes from recurring templates.
+// It processes configured recurrent expenses and incomes and creates actual entries
+// based on their schedule (every N days, weeks, months or years, optionally
+// on a given day of the month) and date ranges.
 type RecurringProcessor struct {
 	storage        *storage.SQLiteRepository // Database access for recurrent expenses
 	expenseService *ExpenseService           // Service for creating regular expenses
@@ -36,6 +37,7 @@ func (p *RecurringProcessor) ProcessDueExpenses(ctx context.Context, now time.Ti
 	if p.storage == nil || p.expenseService == nil {
 		return 0, fmt.Errorf("processor not properly initialized")
 	}
+	ctx = core.WithActor(ctx, "recurring")
 
 	// Get all active recurring expenses
 	recurrentExpenses, err := p.storage.GetActiveRecurrentExpensesForProcessing(ctx, now)
@@ -114,113 +116,72 @@ func (p *RecurringProcessor) ProcessDueExpenses(ctx context.Context, now time.Ti
 	return processedCount, nil
 }
 
-// isDueForProcessing determines if a recurring expense should be processed
-func (p *RecurringProcessor) isDueForProcessing(ctx context.Context, dbExpense *core.RecurrentExpenses, now time.Time) (bool, error) {
-	// Get last execution date from database
-	var lastExecution time.Time
-
-	// This requires accessing the DB record directly - we need to modify the repository method
-	// For now, we'll use a simple approach: check if we've processed today
-
-	// Get the raw DB record to access last_execution_date
-	rawExpense, err := p.storage.GetRecurrentExpenseRaw(ctx, dbExpense.ID)
-	if err != nil {
-		return false, fmt.Errorf("get raw expense: %w", err)
-	}
-
-	if lastExecDate, ok := rawExpense.LastExecutionDate.(time.Time); ok && !lastExecDate.IsZero() {
-		lastExecution = lastExecDate
-	}
-
-	switch dbExpense.Every {
-	case core.Daily:
-		return p.isDueDaily(lastExecution, now), nil
-	case core.Weekly:
-		return p.isDueWeekly(lastExecution, now), nil
-	case core.Monthly:
-		return p.isDueMonthly(lastExecution, now, dbExpense.StartDate.Day()), nil
-	case core.Yearly:
-		return p.isDueYearly(lastExecution, now, dbExpense.StartDate.Month(), dbExpense.StartDate.Day()), nil
-	default:
-		return false, fmt.Errorf("unknown repetition type: %s", dbExpense.Every)
+// ProcessDueIncomes creates the incomes of the recurrent incomes that are
+// due, like ProcessDueExpenses does for expenses
+func (p *RecurringProcessor) ProcessDueIncomes(ctx context.Context, now time.Time) (int, error) {
+	if p.storage == nil {
+		return 0, fmt.Errorf("processor not properly initialized")
 	}
-}
+	ctx = core.WithActor(ctx, "recurring")
 
-// isDueDaily checks if a daily recurring expense is due
-func (p *RecurringProcessor) isDueDaily(lastExecution, now time.Time) bool {
-	// If never executed, it's due
-	if lastExecution.IsZero() {
-		return true
+	recurrentIncomes, err := p.storage.GetActiveRecurrentIncomesForProcessing(ctx, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get active recurring incomes: %w", err)
 	}
 
-	// Due if last execution was before today
-	lastDate := lastExecution.Format("2006-01-02")
-	nowDate := now.Format("2006-01-02")
-	return lastDate != nowDate
-}
-
-// isDueWeekly checks if a weekly recurring expense is due
-func (p *RecurringProcessor) isDueWeekly(lastExecution, now time.Time) bool {
-	// If never executed, it's due
-	if lastExecution.IsZero() {
-		return true
-	}
+	today := core.NewDate(now.Year(), int(now.Month()), now.Day())
+	processedCount := 0
+	for _, ri := range recurrentIncomes {
+		next, ok := ri.NextDue()
+		if !ok || next.After(today.Time) {
+			continue
+		}
 
-	// Due if 7 or more days have passed
-	daysSince := now.Sub(lastExecution).Hours() / 24
-	return daysSince >= 7
-}
+		income := core.Income{
+			Date:        core.Date{Time: now},
+			Description: ri.Description,
+			Amount:      ri.Amount,
+			Category:    ri.Category,
+			Subcategory: ri.Subcategory,
+		}
+		if _, err := p.storage.AppendIncome(ctx, income); err != nil {
+			slog.ErrorContext(ctx, "Failed to create income from recurring template",
+				"recurrent_income_id", ri.ID,
+				"description", ri.Description,
+				"error", err)
+			continue
+		}
 
-// isDueMonthly checks if a monthly recurring expense is due
-func (p *RecurringProcessor) isDueMonthly(lastExecution, now time.Time, targetDay int) bool {
-	// If never executed, it's due
-	if lastExecution.IsZero() {
-		return true
-	}
+		if err := p.storage.UpdateRecurrentIncomeLastExecution(ctx, ri.ID, now); err != nil {
+			slog.ErrorContext(ctx, "Failed to update last execution date",
+				"recurrent_income_id", ri.ID,
+				"error", err)
+			// Continue anyway - income was created successfully
+		}
 
-	// Already processed this month?
-	if lastExecution.Year() == now.Year() && lastExecution.Month() == now.Month() {
-		return false
+		processedCount++
+		slog.InfoContext(ctx, "Created income from recurring template",
+			"recurrent_income_id", ri.ID,
+			"description", ri.Description,
+			"amount_cents", ri.Amount.Cents,
+			"frequency", ri.Every)
 	}
 
-	// Check if we've reached the target day of the month
-	// Handle case where target day doesn't exist in current month (e.g., Feb 31)
-	targetDayThisMonth := targetDay
-	lastDayOfMonth := time.Date(now.Year(), now.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
-	if targetDay > lastDayOfMonth {
-		targetDayThisMonth = lastDayOfMonth
-	}
+	slog.InfoContext(ctx, "Recurring income processing complete",
+		"processed", processedCount,
+		"total_checked", len(recurrentIncomes))
 
-	return now.Day() >= targetDayThisMonth
+	return processedCount, nil
 }
 
-// isDueYearly checks if a yearly recurring expense is due
-func (p *RecurringProcessor) isDueYearly(lastExecution, now time.Time, targetMonth, targetDay int) bool {
-	// If never executed, it's due
-	if lastExecution.IsZero() {
-		return true
-	}
-
-	// Already processed this year?
-	if lastExecution.Year() == now.Year() {
-		return false
-	}
-
-	// Check if we've reached the target month and day
-	if int(now.Month()) < targetMonth {
-		return false
-	}
-
-	if int(now.Month()) == targetMonth {
-		// Handle case where target day doesn't exist in target month
-		lastDayOfMonth := time.Date(now.Year(), now.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
-		targetDayThisMonth := targetDay
-		if targetDay > lastDayOfMonth {
-			targetDayThisMonth = lastDayOfMonth
-		}
-		return now.Day() >= targetDayThisMonth
+// isDueForProcessing determines if a recurring expense should be processed:
+// the first date of its schedule after the last execution and the skipped
+// occurrences has come
+func (p *RecurringProcessor) isDueForProcessing(ctx context.Context, dbExpense *core.RecurrentExpenses, now time.Time) (bool, error) {
+	next, ok := dbExpense.NextDue()
+	if !ok {
+		return false, nil
 	}
-
-	// We're past the target month
-	return true
+	today := core.NewDate(now.Year(), int(now.Month()), now.Day())
+	return !next.After(today.Time), nil
 }
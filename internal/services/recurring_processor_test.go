@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"spese/internal/core"
+)
+
+func TestRecurringProcessorInterval(t *testing.T) {
+	ctx := context.Background()
+	repo := newPeerRepo(t, "recurring")
+	processor := NewRecurringProcessor(repo, NewExpenseService(repo))
+
+	if _, err := repo.CreateRecurrentExpense(ctx, core.RecurrentExpenses{
+		StartDate:   core.NewDate(2031, 1, 6),
+		Every:       core.Weekly,
+		Interval:    2,
+		Description: "Pulizie",
+		Amount:      core.Money{Cents: 6000},
+		Primary:     "Casa",
+		Secondary:   "Pulizie",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	day := func(m time.Month, d int) time.Time { return time.Date(2031, m, d, 9, 0, 0, 0, time.UTC) }
+	for _, step := range []struct {
+		now  time.Time
+		want int
+	}{
+		{day(1, 6), 1},
+		{day(1, 7), 0},
+		{day(1, 13), 0}, // One week in: not due every other week
+		{day(1, 19), 0},
+		{day(1, 20), 1},
+		{day(2, 5), 1}, // Late run catches up with Feb 3rd
+		{day(2, 16), 0},
+		{day(2, 17), 1},
+	} {
+		n, err := processor.ProcessDueExpenses(ctx, step.now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != step.want {
+			t.Errorf("%s: processed %d, want %d", step.now.Format("2006-01-02"), n, step.want)
+		}
+	}
+}
+
+func TestRecurringProcessorPauseAndSkip(t *testing.T) {
+	ctx := context.Background()
+	repo := newPeerRepo(t, "paused")
+	processor := NewRecurringProcessor(repo, NewExpenseService(repo))
+
+	id, err := repo.CreateRecurrentExpense(ctx, core.RecurrentExpenses{
+		StartDate:   core.NewDate(2031, 1, 10),
+		Every:       core.Monthly,
+		Description: "Palestra",
+		Amount:      core.Money{Cents: 4500},
+		Primary:     "Salute",
+		Secondary:   "Palestra",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	day := func(m time.Month, d int) time.Time { return time.Date(2031, m, d, 9, 0, 0, 0, time.UTC) }
+	run := func(now time.Time, want int) {
+		t.Helper()
+		n, err := processor.ProcessDueExpenses(ctx, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != want {
+			t.Errorf("%s: processed %d, want %d", now.Format("2006-01-02"), n, want)
+		}
+	}
+
+	run(day(1, 10), 1)
+
+	skipped, err := repo.SkipNextRecurrentOccurrence(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !skipped.Equal(core.NewDate(2031, 2, 10).Time) {
+		t.Errorf("skipped %s, want 2031-02-10", skipped.Format("2006-01-02"))
+	}
+	run(day(2, 10), 0)
+	run(day(3, 10), 1)
+
+	// Frozen for the summer: nothing runs, and resuming does not catch up
+	if err := repo.PauseRecurrentExpense(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	run(day(6, 10), 0)
+	if err := repo.ResumeRecurrentExpense(ctx, id, day(9, 2)); err != nil {
+		t.Fatal(err)
+	}
+	run(day(9, 2), 0)
+	run(day(9, 10), 1)
+}
+
+func TestRecurringProcessorIncomes(t *testing.T) {
+	ctx := context.Background()
+	repo := newPeerRepo(t, "incomes")
+	processor := NewRecurringProcessor(repo, NewExpenseService(repo))
+
+	if _, err := repo.CreateRecurrentIncome(ctx, core.RecurrentIncome{
+		StartDate:   core.NewDate(2031, 1, 27),
+		Every:       core.Monthly,
+		Description: "Stipendio",
+		Amount:      core.Money{Cents: 250000},
+		Category:    "Stipendio E",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	day := func(m time.Month, d int) time.Time { return time.Date(2031, m, d, 9, 0, 0, 0, time.UTC) }
+	for _, step := range []struct {
+		now  time.Time
+		want int
+	}{
+		{day(1, 26), 0},
+		{day(1, 27), 1},
+		{day(1, 28), 0},
+		{day(2, 27), 1},
+	} {
+		n, err := processor.ProcessDueIncomes(ctx, step.now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != step.want {
+			t.Errorf("%s: processed %d, want %d", step.now.Format("2006-01-02"), n, step.want)
+		}
+	}
+
+	incomes, err := repo.ListIncomes(ctx, 2031, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(incomes) != 1 || incomes[0].Description != "Stipendio" || incomes[0].Category != "Stipendio E" || incomes[0].Amount.Cents != 250000 {
+		t.Errorf("February incomes = %+v", incomes)
+	}
+}
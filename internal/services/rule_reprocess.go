@@ -0,0 +1,232 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"spese/internal/core"
+	"spese/internal/rules"
+	"spese/internal/storage"
+)
+
+// Scopes of the expenses a rule is applied to
+const (
+	RuleScopeUncategorized = "uncategorized"
+	RuleScopeAll           = "all"
+)
+
+// ruleReprocessChunk is how many changes are applied per transaction
+const ruleReprocessChunk = 200
+
+var (
+	// ErrRuleNotFound is returned for an unknown rule ID.
+	ErrRuleNotFound = errors.New("category rule not found")
+	// ErrRuleReprocessRunning is returned when the rule is already being
+	// applied.
+	ErrRuleReprocessRunning = errors.New("rule already being applied")
+	// ErrInvalidRuleScope is returned for a scope other than
+	// RuleScopeUncategorized and RuleScopeAll.
+	ErrInvalidRuleScope = errors.New("invalid rule scope")
+)
+
+// RuleProposal is an expense a rule would move to its categories.
+type RuleProposal struct {
+	ID            int64
+	Version       int64
+	Date          core.Date
+	Description   string
+	Amount        core.Money
+	FromPrimary   string
+	FromSecondary string
+}
+
+// RulePreview lists the changes applying a rule to the stored expenses
+// would make.
+type RulePreview struct {
+	Rule      core.CategoryRule
+	Scope     string
+	Checked   int // Expenses the condition was evaluated on
+	Failed    int // Expenses the condition could not be evaluated on
+	Proposals []RuleProposal
+}
+
+// RuleReprocessStatus is the progress of applying a rule in the background.
+type RuleReprocessStatus struct {
+	RuleID     int64
+	Running    bool
+	Total      int // Changes selected
+	Done       int // Changes processed so far
+	Applied    int // Changes made; the others changed meanwhile
+	Error      string
+	StartedAt  time.Time
+	FinishedAt time.Time
+}
+
+// RuleReprocessor applies a categorization rule to the expenses stored
+// before it was added or changed: a preview lists the expenses the rule
+// matches, and the ones picked from it are moved to the rule's categories
+// in the background, a chunk per transaction. Unlike new expenses, which
+// take the first matching rule, only the given rule is evaluated.
+type RuleReprocessor struct {
+	storage *storage.SQLiteRepository
+	chunk   int
+
+	mu     sync.Mutex
+	status map[int64]*RuleReprocessStatus
+}
+
+// NewRuleReprocessor creates a reprocessor of the rules in storage.
+func NewRuleReprocessor(storage *storage.SQLiteRepository) *RuleReprocessor {
+	return &RuleReprocessor{storage: storage, chunk: ruleReprocessChunk, status: make(map[int64]*RuleReprocessStatus)}
+}
+
+// Preview evaluates the rule on the expenses of scope, oldest first,
+// without changing anything. Expenses already in the rule's categories
+// are not proposed, nor are split expenses and those of closed months.
+func (p *RuleReprocessor) Preview(ctx context.Context, ruleID int64, scope string) (RulePreview, error) {
+	if scope != RuleScopeUncategorized && scope != RuleScopeAll {
+		return RulePreview{}, fmt.Errorf("%w: %q", ErrInvalidRuleScope, scope)
+	}
+	rule, err := p.rule(ctx, ruleID)
+	if err != nil {
+		return RulePreview{}, err
+	}
+	prog, err := rules.CompileExpenseCondition(rule.Expression)
+	if err != nil {
+		return RulePreview{}, fmt.Errorf("compile rule %d: %w", ruleID, err)
+	}
+	candidates, err := p.storage.ListRuleCandidates(ctx, scope == RuleScopeUncategorized)
+	if err != nil {
+		return RulePreview{}, err
+	}
+
+	preview := RulePreview{Rule: rule, Scope: scope, Checked: len(candidates)}
+	for _, c := range candidates {
+		e := c.Expense
+		if e.Primary == rule.Primary && e.Secondary == rule.Secondary {
+			continue
+		}
+		matched, err := prog.EvalBool(rules.ExpenseEnv(e))
+		if err != nil {
+			if preview.Failed == 0 {
+				slog.WarnContext(ctx, "Category rule evaluation failed", "rule_id", ruleID, "expense_id", c.ID, "error", err, "component", "rules")
+			}
+			preview.Failed++
+			continue
+		}
+		if !matched {
+			continue
+		}
+		preview.Proposals = append(preview.Proposals, RuleProposal{
+			ID:            c.ID,
+			Version:       c.Version,
+			Date:          e.Date,
+			Description:   e.Description,
+			Amount:        e.Amount,
+			FromPrimary:   e.Primary,
+			FromSecondary: e.Secondary,
+		})
+	}
+	return preview, nil
+}
+
+// Start applies the rule to the proposals of a fresh preview whose expense
+// is in ids, in the background; the progress is followed through Status.
+// Expenses changed since the preview was shown are no longer proposed or
+// are skipped when applying.
+func (p *RuleReprocessor) Start(ctx context.Context, ruleID int64, scope string, ids []int64) error {
+	preview, err := p.Preview(ctx, ruleID, scope)
+	if err != nil {
+		return err
+	}
+	picked := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		picked[id] = true
+	}
+	var changes []storage.CategoryChange
+	for _, proposal := range preview.Proposals {
+		if picked[proposal.ID] {
+			changes = append(changes, storage.CategoryChange{
+				ID:        proposal.ID,
+				Version:   proposal.Version,
+				Primary:   preview.Rule.Primary,
+				Secondary: preview.Rule.Secondary,
+			})
+		}
+	}
+
+	status, err := p.claim(ruleID, len(changes))
+	if err != nil {
+		return err
+	}
+	ctx = core.WithActor(context.WithoutCancel(ctx), "rule:"+preview.Rule.Name)
+	go p.apply(ctx, status, changes)
+	return nil
+}
+
+// Status returns the progress of the last run of the rule, false when it
+// was never applied since the process started.
+func (p *RuleReprocessor) Status(ruleID int64) (RuleReprocessStatus, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	status, ok := p.status[ruleID]
+	if !ok {
+		return RuleReprocessStatus{}, false
+	}
+	return *status, true
+}
+
+func (p *RuleReprocessor) apply(ctx context.Context, status *RuleReprocessStatus, changes []storage.CategoryChange) {
+	var applyErr error
+	for start := 0; start < len(changes); start += p.chunk {
+		chunk := changes[start:min(start+p.chunk, len(changes))]
+		n, err := p.storage.RecategorizeExpenses(ctx, chunk)
+		if err != nil {
+			applyErr = err
+			break
+		}
+		p.mu.Lock()
+		status.Done += len(chunk)
+		status.Applied += n
+		p.mu.Unlock()
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	status.Running = false
+	status.FinishedAt = time.Now()
+	if applyErr != nil {
+		status.Error = applyErr.Error()
+		slog.ErrorContext(ctx, "Applying category rule failed", "rule_id", status.RuleID, "applied", status.Applied, "error", applyErr, "component", "rules")
+		return
+	}
+	slog.InfoContext(ctx, "Category rule applied to stored expenses", "rule_id", status.RuleID, "selected", status.Total, "applied", status.Applied, "component", "rules")
+}
+
+func (p *RuleReprocessor) claim(ruleID int64, total int) (*RuleReprocessStatus, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if current, ok := p.status[ruleID]; ok && current.Running {
+		return nil, fmt.Errorf("%w: %d", ErrRuleReprocessRunning, ruleID)
+	}
+	status := &RuleReprocessStatus{RuleID: ruleID, Running: true, Total: total, StartedAt: time.Now()}
+	p.status[ruleID] = status
+	return status, nil
+}
+
+func (p *RuleReprocessor) rule(ctx context.Context, id int64) (core.CategoryRule, error) {
+	list, err := p.storage.ListCategoryRules(ctx)
+	if err != nil {
+		return core.CategoryRule{}, err
+	}
+	for _, rule := range list {
+		if rule.ID == id {
+			return rule, nil
+		}
+	}
+	return core.CategoryRule{}, fmt.Errorf("%w: %d", ErrRuleNotFound, id)
+}
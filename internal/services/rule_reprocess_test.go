@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"spese/internal/core"
+	"spese/internal/storage"
+)
+
+func TestRuleReprocessor(t *testing.T) {
+	ctx := context.Background()
+	repo := newPeerRepo(t, "rules")
+
+	add := func(day int, desc, primary, secondary string) int64 {
+		t.Helper()
+		ref, err := repo.AppendAndEnqueueSync(ctx, core.Expense{Date: core.NewDate(2031, 2, day), Description: desc, Amount: core.Money{Cents: 1299}, Primary: primary, Secondary: secondary})
+		if err != nil {
+			t.Fatal(err)
+		}
+		id, err := strconv.ParseInt(ref, 10, 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+	unknown := add(1, "Abbonamento ZqFlix", core.UncategorizedPrimary, core.UncategorizedSecondary)
+	other := add(2, "ZqFlix famiglia", "Casa", "Altro")
+	add(3, "ZqFlix", "Svago", "Streaming")
+	add(4, "Bonifico", core.UncategorizedPrimary, core.UncategorizedSecondary)
+
+	// The expenses are already in Google Sheets
+	queued, err := repo.DequeueSyncBatch(ctx, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, item := range queued {
+		if err := repo.MarkSyncComplete(ctx, item.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, id := range []int64{unknown, other} {
+		if err := repo.MarkSynced(ctx, id); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ruleID, err := repo.CreateCategoryRule(ctx, core.CategoryRule{Name: "ZqFlix", Expression: `description contains "ZqFlix"`, Primary: "Svago", Secondary: "Streaming", Active: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := NewRuleReprocessor(repo)
+
+	if _, err := p.Preview(ctx, ruleID+100, RuleScopeAll); !errors.Is(err, ErrRuleNotFound) {
+		t.Errorf("unknown rule err = %v, want ErrRuleNotFound", err)
+	}
+	if _, err := p.Preview(ctx, ruleID, "some"); !errors.Is(err, ErrInvalidRuleScope) {
+		t.Errorf("bad scope err = %v, want ErrInvalidRuleScope", err)
+	}
+
+	preview, err := p.Preview(ctx, ruleID, RuleScopeUncategorized)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(preview.Proposals) != 1 || preview.Proposals[0].ID != unknown {
+		t.Errorf("uncategorized proposals = %+v, want the subscription", preview.Proposals)
+	}
+	// Expenses already in the rule's categories are not proposed
+	preview, err = p.Preview(ctx, ruleID, RuleScopeAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(preview.Proposals) != 2 || preview.Proposals[0].ID != unknown || preview.Proposals[1].ID != other || preview.Proposals[1].FromPrimary != "Casa" {
+		t.Fatalf("all proposals = %+v, want the subscription and the family plan", preview.Proposals)
+	}
+
+	// Only the picked expenses are changed
+	if err := p.Start(ctx, ruleID, RuleScopeAll, []int64{unknown}); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	status, _ := p.Status(ruleID)
+	for status.Running && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		status, _ = p.Status(ruleID)
+	}
+	if status.Running || status.Error != "" || status.Total != 1 || status.Done != 1 || status.Applied != 1 {
+		t.Fatalf("status = %+v, want one change applied", status)
+	}
+
+	for id, want := range map[int64]string{unknown: "Svago", other: "Casa"} {
+		e, err := repo.GetExpense(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e.PrimaryCategory != want {
+			t.Errorf("expense %d primary = %q, want %q", id, e.PrimaryCategory, want)
+		}
+	}
+	// The synced row is replaced in the sheet, as for an edit
+	queued, err = repo.DequeueSyncBatch(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queued) != 2 || queued[0].Operation != "delete" || queued[1].Operation != "sync" || queued[1].ExpenseID != unknown {
+		t.Errorf("queued = %+v, want delete then sync of the subscription", queued)
+	}
+
+	// A change proposed for an older version is skipped
+	e, err := repo.GetExpense(ctx, other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := repo.RecategorizeExpenses(ctx, []storage.CategoryChange{{ID: other, Version: e.Version - 1, Primary: "Svago", Secondary: "Streaming"}})
+	if err != nil || n != 0 {
+		t.Errorf("stale change applied = %d, %v, want 0", n, err)
+	}
+}
@@ -2,16 +2,26 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"strconv"
 	"sync"
 	"time"
 
 	"spese/internal/core"
+	"spese/internal/events"
 	"spese/internal/sheets"
 	"spese/internal/storage"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("spese/internal/services")
+
 // SyncProcessorConfig holds configuration for the sync processor
 type SyncProcessorConfig struct {
 	// PollInterval is how often to check for pending items (default: 10s)
@@ -28,6 +38,10 @@ type SyncProcessorConfig struct {
 
 	// CleanupAge is how old completed items must be before cleanup (default: 24h)
 	CleanupAge time.Duration
+
+	// PullInterval is how often rows edited or added in the sheet are
+	// pulled back into the database; zero disables the pull (default: 0)
+	PullInterval time.Duration
 }
 
 // DefaultSyncProcessorConfig returns sensible defaults
@@ -41,6 +55,18 @@ func DefaultSyncProcessorConfig() SyncProcessorConfig {
 	}
 }
 
+// rateLimitDelay is how long an item Google throttled waits before it is
+// picked up again; it does not count as a failed attempt
+const rateLimitDelay = time.Minute
+
+// authRetryDelay is how long an item waits after Google rejected the
+// credentials, again without counting an attempt
+const authRetryDelay = 15 * time.Minute
+
+// syncErrorRetention is how long failed attempts stay in the sync error
+// report
+const syncErrorRetention = 30 * 24 * time.Hour
+
 // SyncProcessor handles SQLite-based sync queue processing
 type SyncProcessor struct {
 	storage *storage.SQLiteRepository
@@ -48,11 +74,24 @@ type SyncProcessor struct {
 	deleter sheets.ExpenseDeleter
 	config  SyncProcessorConfig
 
+	// isPrimary reports whether this node may write; nil means always
+	isPrimary func() bool
+
+	// alerts reports items that failed for good; nil sends no alerts
+	alerts *AlertRouter
+
+	// health is marked degraded while Google Sheets rejects the
+	// credentials; nil tracks nothing
+	health *IntegrationHealth
+
 	// Lifecycle management
 	mu      sync.Mutex
 	running bool
 	stopCh  chan struct{}
 	doneCh  chan struct{}
+
+	// batchMu keeps SyncNow and the poll from dequeuing the same items
+	batchMu sync.Mutex
 }
 
 // NewSyncProcessor creates a new sync processor
@@ -62,6 +101,9 @@ func NewSyncProcessor(
 	deleter sheets.ExpenseDeleter,
 	config SyncProcessorConfig,
 ) *SyncProcessor {
+	if config.MaxRetries < 1 {
+		config.MaxRetries = DefaultSyncProcessorConfig().MaxRetries
+	}
 	return &SyncProcessor{
 		storage: storage,
 		sheets:  sheetsWriter,
@@ -70,6 +112,17 @@ func NewSyncProcessor(
 	}
 }
 
+// SetPrimaryCheck makes the processor idle while check reports that this
+// node is a read-only replica, as under LiteFS.
+func (p *SyncProcessor) SetPrimaryCheck(check func() bool) {
+	p.isPrimary = check
+}
+
+// canWrite reports whether the queue may be processed on this node
+func (p *SyncProcessor) canWrite() bool {
+	return p.isPrimary == nil || p.isPrimary()
+}
+
 // Start begins the processing loop. Returns an error if already running.
 func (p *SyncProcessor) Start(ctx context.Context) error {
 	p.mu.Lock()
@@ -82,11 +135,6 @@ func (p *SyncProcessor) Start(ctx context.Context) error {
 	p.doneCh = make(chan struct{})
 	p.mu.Unlock()
 
-	// Reset any stale processing items from previous crashes
-	if err := p.storage.ResetStaleProcessing(ctx); err != nil {
-		slog.WarnContext(ctx, "Failed to reset stale processing items", "error", err)
-	}
-
 	go p.runLoop(ctx)
 
 	slog.InfoContext(ctx, "Sync processor started",
@@ -141,8 +189,21 @@ func (p *SyncProcessor) runLoop(ctx context.Context) {
 	cleanupTicker := time.NewTicker(p.config.CleanupInterval)
 	defer cleanupTicker.Stop()
 
-	// Process immediately on startup
-	p.processBatch(ctx)
+	// A nil channel never fires, leaving the pull disabled
+	var pullC <-chan time.Time
+	if _, ok := p.sheets.(sheets.EditPuller); ok && p.config.PullInterval > 0 {
+		pullTicker := time.NewTicker(p.config.PullInterval)
+		defer pullTicker.Stop()
+		pullC = pullTicker.C
+	}
+
+	// Reconcile the queue with the sheet and process immediately on startup
+	if p.canWrite() {
+		if _, err := p.StartupSyncCheck(ctx); err != nil {
+			slog.WarnContext(ctx, "Startup sync check failed", "error", err)
+		}
+		p.processBatch(ctx)
+	}
 
 	for {
 		select {
@@ -151,15 +212,204 @@ func (p *SyncProcessor) runLoop(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case <-pollTicker.C:
-			p.processBatch(ctx)
+			if p.canWrite() {
+				p.processBatch(ctx)
+			}
 		case <-cleanupTicker.C:
-			p.cleanupCompleted(ctx)
+			if p.canWrite() {
+				p.cleanupCompleted(ctx)
+			}
+		case <-pullC:
+			if p.canWrite() {
+				if _, err := p.PullEdits(ctx); err != nil {
+					slog.WarnContext(ctx, "Pulling sheet edits failed", "error", err)
+				}
+			}
+		}
+	}
+}
+
+// StartupSyncCheck reconciles the queue with the sheet after a restart:
+// append items whose sync key is already in the sheet, written before the
+// process stopped without completing them, are marked done instead of
+// being appended again. Stale processing items are then reset to pending.
+// It returns how many items were reconciled.
+func (p *SyncProcessor) StartupSyncCheck(ctx context.Context) (int, error) {
+	p.batchMu.Lock()
+	defer p.batchMu.Unlock()
+
+	reconciled := 0
+	if w, ok := p.sheets.(sheets.IdempotentExpenseWriter); ok {
+		n, err := p.reconcileSyncKeys(ctx, w)
+		if err != nil {
+			return 0, err
+		}
+		reconciled = n
+	}
+
+	// Reset any stale processing items from previous crashes
+	if err := p.storage.ResetStaleProcessing(ctx); err != nil {
+		return reconciled, err
+	}
+	return reconciled, nil
+}
+
+// reconcileSyncKeys completes the unfinished append items whose expense
+// version is already in the sheet
+func (p *SyncProcessor) reconcileSyncKeys(ctx context.Context, w sheets.IdempotentExpenseWriter) (int, error) {
+	items, err := p.storage.UnfinishedSyncAppends(ctx)
+	if err != nil || len(items) == 0 {
+		return 0, err
+	}
+	keys, err := w.SyncKeys(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("read sync keys: %w", err)
+	}
+
+	reconciled := 0
+	for _, item := range items {
+		expense, err := p.storage.GetExpense(ctx, item.ExpenseID)
+		if err != nil {
+			// Deleted meanwhile: the item fails on its own when processed
+			continue
+		}
+		if !keys[sheets.SyncKey(expense.ID, expense.Version)] {
+			continue
+		}
+		if err := p.storage.MarkSyncComplete(ctx, item.ID); err != nil {
+			return reconciled, err
+		}
+		if err := p.storage.MarkSynced(ctx, item.ExpenseID); err != nil {
+			slog.WarnContext(ctx, "Failed to mark expense as synced",
+				"expense_id", item.ExpenseID, "error", err)
+		}
+		reconciled++
+	}
+	if reconciled > 0 {
+		slog.InfoContext(ctx, "Completed sync items already in Google Sheets",
+			"count", reconciled)
+	}
+	return reconciled, nil
+}
+
+// PullEdits stores the rows edited or added by hand in the expenses sheet,
+// so changes made there are not lost, and returns how many were stored.
+// An edited row keeps the year of its expense, an added row gets the
+// sheet's. Rows that are not valid expenses, of expenses deleted or with
+// changes still queued, or in closed months are left as they are; the
+// others are acknowledged in the sheet once stored. A replica or a sheet
+// that cannot report edits pulls nothing.
+func (p *SyncProcessor) PullEdits(ctx context.Context) (int, error) {
+	puller, ok := p.sheets.(sheets.EditPuller)
+	if !ok || !p.canWrite() {
+		return 0, nil
+	}
+	p.batchMu.Lock()
+	defer p.batchMu.Unlock()
+
+	edits, err := puller.ListEdits(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("list sheet edits: %w", err)
+	}
+	ctx = core.WithActor(ctx, "sheets")
+
+	pulled := 0
+	for _, edit := range edits {
+		e := edit.Expense
+		var stored *storage.Expense
+		if edit.ID != 0 {
+			current, err := p.storage.GetExpense(ctx, edit.ID)
+			if err != nil {
+				slog.InfoContext(ctx, "Sheet row of a missing expense, not pulled",
+					"row", edit.Row, "expense_id", edit.ID)
+				continue
+			}
+			parts, err := p.storage.ListExpenseSplit(ctx, edit.ID)
+			if err != nil {
+				return pulled, err
+			}
+			if len(parts) > 0 {
+				// A row is one part: the split is changed in the app
+				slog.InfoContext(ctx, "Sheet row of a split expense, not pulled",
+					"row", edit.Row, "expense_id", edit.ID)
+				continue
+			}
+			e.Date = core.NewDate(current.Date.Year(), e.Date.Month(), e.Date.Day())
+			if err := e.Validate(); err != nil {
+				slog.WarnContext(ctx, "Edited sheet row is not a valid expense, not pulled",
+					"row", edit.Row, "expense_id", edit.ID, "error", err)
+				continue
+			}
+			stored, err = p.storage.ApplySheetEdit(ctx, edit.ID, e)
+			if errors.Is(err, core.ErrMonthClosed) {
+				slog.WarnContext(ctx, "Edited sheet row is in a closed month, not pulled",
+					"row", edit.Row, "expense_id", edit.ID)
+				continue
+			}
+			if err != nil {
+				return pulled, err
+			}
+			if stored == nil {
+				slog.InfoContext(ctx, "Expense has changes queued for the sheet, row edit not pulled",
+					"row", edit.Row, "expense_id", edit.ID)
+				continue
+			}
+		} else {
+			if err := e.Validate(); err != nil {
+				slog.WarnContext(ctx, "Sheet row added by hand is not a valid expense, not pulled",
+					"row", edit.Row, "error", err)
+				continue
+			}
+			stored, err = p.storage.CreateSheetExpense(ctx, e)
+			if errors.Is(err, core.ErrMonthClosed) {
+				slog.WarnContext(ctx, "Sheet row added by hand is in a closed month, not pulled",
+					"row", edit.Row)
+				continue
+			}
+			if err != nil {
+				return pulled, err
+			}
 		}
+
+		pulled++
+		if err := puller.AckEdit(ctx, edit, stored.ID, stored.Version); err != nil {
+			// The row is pulled again next time; for an added row that
+			// stores a second expense, so stop here
+			return pulled, fmt.Errorf("acknowledge sheet row %d: %w", edit.Row, err)
+		}
+		slog.InfoContext(ctx, "Pulled expense from Google Sheets",
+			"row", edit.Row, "expense_id", stored.ID, "added", edit.ID == 0)
 	}
+	return pulled, nil
+}
+
+// SyncNow writes a batch of the queue to Google Sheets at once, the items
+// waiting for a retry included, instead of waiting for the next poll. It
+// returns how many items were written; a replica writes nothing.
+func (p *SyncProcessor) SyncNow(ctx context.Context) (int, error) {
+	if !p.canWrite() {
+		return 0, nil
+	}
+	if _, err := p.storage.RetryDeferredSyncs(ctx); err != nil {
+		return 0, err
+	}
+	before, err := p.storage.GetSyncQueueStats(ctx)
+	if err != nil {
+		return 0, err
+	}
+	p.processBatch(ctx)
+	after, err := p.storage.GetSyncQueueStats(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return int(after.CompletedCount - before.CompletedCount), nil
 }
 
 // processBatch processes a single batch of pending items
 func (p *SyncProcessor) processBatch(ctx context.Context) {
+	p.batchMu.Lock()
+	defer p.batchMu.Unlock()
+
 	// Fetch pending items
 	items, err := p.storage.DequeueSyncBatch(ctx, int64(p.config.BatchSize))
 	if err != nil {
@@ -173,7 +423,7 @@ func (p *SyncProcessor) processBatch(ctx context.Context) {
 
 	slog.DebugContext(ctx, "Processing sync batch", "count", len(items))
 
-	for _, item := range items {
+	for i, item := range items {
 		// Check if we should stop
 		select {
 		case <-p.stopCh:
@@ -183,6 +433,14 @@ func (p *SyncProcessor) processBatch(ctx context.Context) {
 		default:
 		}
 
+		// Leave the rest of the batch queued while the write budget is
+		// spent, rather than send requests Google would refuse
+		if p.writeBudgetSpent() {
+			slog.InfoContext(ctx, "Sheets write budget spent, deferring the rest of the batch",
+				"deferred", len(items)-i)
+			return
+		}
+
 		// Mark as processing
 		if err := p.storage.MarkSyncProcessing(ctx, item.ID); err != nil {
 			slog.ErrorContext(ctx, "Failed to mark item as processing",
@@ -190,23 +448,104 @@ func (p *SyncProcessor) processBatch(ctx context.Context) {
 			continue
 		}
 
-		// Process the item
-		var processErr error
-		switch item.Operation {
-		case "sync":
-			processErr = p.processSyncItem(ctx, item)
-		case "delete":
-			processErr = p.processDeleteItem(ctx, item)
-		default:
-			processErr = fmt.Errorf("unknown operation: %s", item.Operation)
-		}
+		processErr := p.processItem(ctx, item)
 
-		// Handle result
-		if processErr != nil {
-			p.handleFailure(ctx, item, processErr)
-		} else {
+		// Handle result: the class of the error picks the retry policy
+		if processErr == nil {
 			p.handleSuccess(ctx, item)
+			continue
 		}
+		switch class := sheets.Classify(processErr); {
+		case class == sheets.ErrorQuota:
+			p.handleRateLimited(ctx, item, processErr)
+			return
+		case class == sheets.ErrorAuth:
+			// The rest of the batch would be refused the same way
+			p.handleAuthError(ctx, item, processErr)
+			return
+		case class == sheets.ErrorNotFound && item.Operation == "delete":
+			slog.InfoContext(ctx, "Expense to delete is not in Google Sheets, nothing to do",
+				"id", item.ID, "expense_id", item.ExpenseID)
+			p.handleSuccess(ctx, item)
+		default:
+			p.handleFailure(ctx, item, class, processErr)
+		}
+	}
+}
+
+// processItem writes an item to the sheet, in a span continuing the trace
+// of the request that enqueued it
+func (p *SyncProcessor) processItem(ctx context.Context, item storage.SyncQueue) error {
+	ctx, span := tracer.Start(storage.SyncTraceContext(ctx, item), "sync "+item.Operation,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.Int64("sync.item_id", item.ID),
+			attribute.Int64("sync.attempt", item.Attempts+1),
+			attribute.Int64("expense.id", item.ExpenseID)))
+	defer span.End()
+
+	var err error
+	switch item.Operation {
+	case "sync":
+		err = p.processSyncItem(ctx, item)
+	case "delete":
+		err = p.processDeleteItem(ctx, item)
+	default:
+		err = fmt.Errorf("unknown operation: %s", item.Operation)
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
+
+// writeBudgetSpent reports whether the sheets writer is rate limited and
+// has no write left for now
+func (p *SyncProcessor) writeBudgetSpent() bool {
+	throttle, ok := p.sheets.(sheets.WriteThrottle)
+	return ok && throttle.WritesAvailable() == 0
+}
+
+// handleRateLimited puts back an item Google refused for exceeding the
+// quota. Throttling says nothing about the expense, so neither the attempt
+// counts nor the expense is marked as a sync error.
+func (p *SyncProcessor) handleRateLimited(ctx context.Context, item storage.SyncQueue, processErr error) {
+	slog.WarnContext(ctx, "Sheets write rate limited, deferring sync item",
+		"id", item.ID,
+		"operation", item.Operation,
+		"retry_in", rateLimitDelay,
+		"error", processErr)
+	p.recordError(ctx, item, sheets.ErrorQuota, processErr)
+	if err := p.storage.DeferSyncItem(ctx, item.ID, processErr.Error(), rateLimitDelay); err != nil {
+		slog.ErrorContext(ctx, "Failed to defer sync item",
+			"id", item.ID, "error", err)
+	}
+}
+
+// handleAuthError puts back an item Google refused for rejected
+// credentials. Only fixing the credentials helps, so the item waits
+// without using up its attempts.
+func (p *SyncProcessor) handleAuthError(ctx context.Context, item storage.SyncQueue, processErr error) {
+	slog.ErrorContext(ctx, "Sheets credentials rejected, deferring sync item",
+		"id", item.ID,
+		"operation", item.Operation,
+		"retry_in", authRetryDelay,
+		"error", processErr)
+	p.recordError(ctx, item, sheets.ErrorAuth, processErr)
+	p.health.MarkDegraded(processErr)
+	if err := p.storage.DeferSyncItem(ctx, item.ID, processErr.Error(), authRetryDelay); err != nil {
+		slog.ErrorContext(ctx, "Failed to defer sync item",
+			"id", item.ID, "error", err)
+	}
+}
+
+// recordError keeps a failed attempt with its class for the sync error
+// report
+func (p *SyncProcessor) recordError(ctx context.Context, item storage.SyncQueue, class sheets.ErrorClass, processErr error) {
+	if err := p.storage.RecordSyncError(ctx, item, string(class), processErr.Error()); err != nil {
+		slog.ErrorContext(ctx, "Failed to record sync error",
+			"id", item.ID, "error", err)
 	}
 }
 
@@ -218,6 +557,14 @@ func (p *SyncProcessor) processSyncItem(ctx context.Context, item storage.SyncQu
 		return fmt.Errorf("get expense %d: %w", item.ExpenseID, err)
 	}
 
+	if isStaleSyncItem(item, expense) {
+		slog.InfoContext(ctx, "Skipping stale sync item",
+			"expense_id", item.ExpenseID,
+			"item_version", item.ExpenseVersion,
+			"expense_version", expense.Version)
+		return nil
+	}
+
 	// Convert to core.Expense
 	coreExpense := core.Expense{
 		Date:        core.Date{Time: expense.Date},
@@ -227,15 +574,44 @@ func (p *SyncProcessor) processSyncItem(ctx context.Context, item storage.SyncQu
 		Secondary:   expense.SecondaryCategory,
 	}
 
+	// A split expense is written as one row per part
+	parts, err := p.storage.ListExpenseSplit(ctx, item.ExpenseID)
+	if err != nil {
+		return err
+	}
+	rows := core.FlattenSplit(coreExpense, parts)
+
 	// Add timestamp for uniqueness (matching existing sync_worker.go logic)
 	timestampMs := time.Now().UnixMilli()
-	coreExpense.Description = fmt.Sprintf("%s [ts:%d]", expense.Description, timestampMs)
+	for i := range rows {
+		rows[i].Description = fmt.Sprintf("%s [ts:%d]", rows[i].Description, timestampMs)
+	}
 
-	// Sync to Google Sheets
-	ref, err := p.sheets.Append(ctx, coreExpense)
+	// Sync to Google Sheets, tagging the row with the expense ID and the sync
+	// key when supported; a row already carrying the key is not written again
+	var ref string
+	appended := true
+	if len(rows) > 1 {
+		ref, appended, err = p.appendSplit(ctx, expense, rows)
+	} else {
+		switch w := p.sheets.(type) {
+		case sheets.IdempotentExpenseWriter:
+			ref, appended, err = w.AppendOnce(ctx, expense.ID, expense.Version, rows[0])
+		case sheets.ExpenseWriterWithID:
+			ref, err = w.AppendWithID(ctx, expense.ID, rows[0])
+		default:
+			ref, err = p.sheets.Append(ctx, rows[0])
+		}
+	}
 	if err != nil {
 		return fmt.Errorf("append to sheets: %w", err)
 	}
+	if !appended {
+		slog.InfoContext(ctx, "Expense already in Google Sheets, append skipped",
+			"expense_id", item.ExpenseID,
+			"sync_key", sheets.SyncKey(expense.ID, expense.Version),
+			"sheets_ref", ref)
+	}
 
 	// Mark expense as synced in expenses table
 	if err := p.storage.MarkSynced(ctx, item.ExpenseID); err != nil {
@@ -251,6 +627,44 @@ func (p *SyncProcessor) processSyncItem(ctx context.Context, item storage.SyncQu
 	return nil
 }
 
+// appendSplit writes the rows of a split expense, together when the sheet
+// supports it. Otherwise they go one by one, and a retry after a failure
+// midway writes again the rows already appended.
+func (p *SyncProcessor) appendSplit(ctx context.Context, expense *storage.Expense, rows []core.Expense) (string, bool, error) {
+	if w, ok := p.sheets.(sheets.SplitExpenseWriter); ok {
+		return w.AppendSplitOnce(ctx, expense.ID, expense.Version, rows)
+	}
+	var first string
+	for i, row := range rows {
+		var ref string
+		var err error
+		if w, ok := p.sheets.(sheets.ExpenseWriterWithID); ok {
+			ref, err = w.AppendWithID(ctx, expense.ID, row)
+		} else {
+			ref, err = p.sheets.Append(ctx, row)
+		}
+		if err != nil {
+			return "", false, fmt.Errorf("part %d: %w", i+1, err)
+		}
+		if i == 0 {
+			first = ref
+		}
+	}
+	return first, true, nil
+}
+
+// isStaleSyncItem reports whether the item was enqueued for an older version
+// of an expense that has already been synced; the newer version's item carries
+// the current data, so the old one must not write again.
+func isStaleSyncItem(item storage.SyncQueue, expense *storage.Expense) bool {
+	version, ok := item.ExpenseVersion.(int64)
+	if !ok {
+		// Items enqueued before versions were tracked
+		return false
+	}
+	return version < expense.Version && expense.SyncStatus.String == "synced"
+}
+
 // processDeleteItem deletes an expense from Google Sheets
 func (p *SyncProcessor) processDeleteItem(ctx context.Context, item storage.SyncQueue) error {
 	if p.deleter == nil {
@@ -314,24 +728,43 @@ func (p *SyncProcessor) processDeleteItem(ctx context.Context, item storage.Sync
 	return nil
 }
 
-// handleSuccess marks an item as completed
+// handleSuccess marks an item as completed; Google Sheets accepted the
+// request, so the credentials work again
 func (p *SyncProcessor) handleSuccess(ctx context.Context, item storage.SyncQueue) {
+	p.health.MarkHealthy()
 	if err := p.storage.MarkSyncComplete(ctx, item.ID); err != nil {
 		slog.ErrorContext(ctx, "Failed to mark sync complete",
 			"id", item.ID, "error", err)
 	}
 }
 
-// handleFailure handles a failed sync attempt with retry logic
-func (p *SyncProcessor) handleFailure(ctx context.Context, item storage.SyncQueue, processErr error) {
+// SetAlertRouter sends a sync alert for every item that fails after all
+// retries.
+func (p *SyncProcessor) SetAlertRouter(alerts *AlertRouter) {
+	p.alerts = alerts
+}
+
+// SetIntegrationHealth marks h degraded while Google Sheets rejects the
+// credentials, and healthy again after the next accepted request.
+func (p *SyncProcessor) SetIntegrationHealth(h *IntegrationHealth) {
+	p.health = h
+}
+
+// handleFailure handles a failed sync attempt with retry logic. Transient
+// errors are retried with backoff up to MaxRetries; validation and
+// not-found errors fail at once, since retrying sends the same request.
+func (p *SyncProcessor) handleFailure(ctx context.Context, item storage.SyncQueue, class sheets.ErrorClass, processErr error) {
 	slog.WarnContext(ctx, "Sync processing failed",
 		"id", item.ID,
 		"operation", item.Operation,
 		"attempt", item.Attempts+1,
+		"class", class,
 		"error", processErr)
+	p.recordError(ctx, item, class, processErr)
 
-	if item.Attempts+1 >= int64(p.config.MaxRetries) {
-		// Max retries exceeded - mark as failed
+	permanent := class == sheets.ErrorValidation || class == sheets.ErrorNotFound
+	if permanent || item.Attempts+1 >= int64(p.config.MaxRetries) {
+		// Max retries exceeded, or retrying cannot help - mark as failed
 		if err := p.storage.MarkSyncFailed(ctx, item.ID, processErr.Error()); err != nil {
 			slog.ErrorContext(ctx, "Failed to mark sync as failed",
 				"id", item.ID, "error", err)
@@ -345,10 +778,23 @@ func (p *SyncProcessor) handleFailure(ctx context.Context, item storage.SyncQueu
 			}
 		}
 
-		slog.ErrorContext(ctx, "Sync item failed permanently after max retries",
+		slog.ErrorContext(ctx, "Sync item failed permanently",
 			"id", item.ID,
 			"expense_id", item.ExpenseID,
+			"class", class,
 			"attempts", item.Attempts+1)
+
+		if p.alerts != nil {
+			p.alerts.Send(ctx, Alert{
+				Type:  core.AlertSync,
+				Event: events.SyncFailed,
+				Data: events.SyncFailedPayload{
+					ExpenseID: strconv.FormatInt(item.ExpenseID, 10),
+					Operation: item.Operation,
+					Error:     processErr.Error(),
+				},
+			})
+		}
 	} else {
 		// Schedule retry with exponential backoff
 		if err := p.storage.IncrementSyncAttempt(ctx, item.ID, processErr.Error()); err != nil {
@@ -358,12 +804,15 @@ func (p *SyncProcessor) handleFailure(ctx context.Context, item storage.SyncQueu
 	}
 }
 
-// cleanupCompleted removes old completed items
+// cleanupCompleted removes old completed items and old failed attempts
 func (p *SyncProcessor) cleanupCompleted(ctx context.Context) {
 	cutoff := time.Now().Add(-p.config.CleanupAge)
 	if err := p.storage.CleanupCompletedSyncs(ctx, cutoff); err != nil {
 		slog.ErrorContext(ctx, "Failed to cleanup completed syncs", "error", err)
 	}
+	if err := p.storage.CleanupSyncErrors(ctx, time.Now().Add(-syncErrorRetention)); err != nil {
+		slog.ErrorContext(ctx, "Failed to cleanup sync errors", "error", err)
+	}
 }
 
 // Stats returns current queue statistics
@@ -371,7 +820,8 @@ func (p *SyncProcessor) Stats(ctx context.Context) (*storage.GetSyncQueueStatsRo
 	return p.storage.GetSyncQueueStats(ctx)
 }
 
-// RetryFailed resets all failed items for retry
-func (p *SyncProcessor) RetryFailed(ctx context.Context) error {
+// RetryFailed resets all failed items for retry, returning how many there
+// were
+func (p *SyncProcessor) RetryFailed(ctx context.Context) (int64, error) {
 	return p.storage.RetryFailedSyncs(ctx)
 }
@@ -2,8 +2,18 @@ package services
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
+
+	"spese/internal/core"
+	"spese/internal/sheets"
+	"spese/internal/storage"
 )
 
 func TestNewSyncProcessor(t *testing.T) {
@@ -114,3 +124,473 @@ func TestSyncProcessorConfig_CustomValues(t *testing.T) {
 		t.Errorf("expected custom CleanupAge 12h, got %v", processor.config.CleanupAge)
 	}
 }
+
+func TestIsStaleSyncItem(t *testing.T) {
+	synced := sql.NullString{String: "synced", Valid: true}
+	pending := sql.NullString{String: "pending", Valid: true}
+
+	cases := []struct {
+		name        string
+		itemVersion interface{}
+		expense     storage.Expense
+		stale       bool
+	}{
+		{"same version", int64(2), storage.Expense{Version: 2, SyncStatus: synced}, false},
+		{"older version already synced", int64(1), storage.Expense{Version: 2, SyncStatus: synced}, true},
+		{"older version not synced yet", int64(1), storage.Expense{Version: 2, SyncStatus: pending}, false},
+		{"legacy item without version", nil, storage.Expense{Version: 3, SyncStatus: synced}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			item := storage.SyncQueue{ExpenseVersion: tc.itemVersion}
+			if got := isStaleSyncItem(item, &tc.expense); got != tc.stale {
+				t.Errorf("expected stale=%v, got %v", tc.stale, got)
+			}
+		})
+	}
+}
+
+func TestSyncProcessor_PrimaryCheck(t *testing.T) {
+	processor := NewSyncProcessor(nil, nil, nil, DefaultSyncProcessorConfig())
+	if !processor.canWrite() {
+		t.Error("processor without primary check should write")
+	}
+
+	primary := false
+	processor.SetPrimaryCheck(func() bool { return primary })
+	if processor.canWrite() {
+		t.Error("processor should idle on a replica")
+	}
+	primary = true
+	if !processor.canWrite() {
+		t.Error("processor should write once promoted to primary")
+	}
+}
+
+// throttledWriter is a rate-limited sheets writer: Google refuses its
+// appends, and it reports the writes it has left.
+type throttledWriter struct {
+	available int
+	appends   int
+}
+
+func (w *throttledWriter) Append(context.Context, core.Expense) (string, error) {
+	w.appends++
+	return "", fmt.Errorf("append: %w", sheets.ErrRateLimited)
+}
+
+func (w *throttledWriter) WritesAvailable() int {
+	return w.available
+}
+
+func TestSyncProcessor_RateLimited(t *testing.T) {
+	ctx := context.Background()
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	expense := core.Expense{Date: core.NewDate(2031, 3, 5), Description: "Spesa", Amount: core.Money{Cents: 1000}, Primary: "Casa", Secondary: "Spesa"}
+	if _, err := repo.AppendAndEnqueueSync(ctx, expense); err != nil {
+		t.Fatal(err)
+	}
+
+	// No budget left: nothing is sent and the item stays queued
+	writer := &throttledWriter{available: 0}
+	processor := NewSyncProcessor(repo, writer, nil, DefaultSyncProcessorConfig())
+	processor.stopCh = make(chan struct{})
+	processor.processBatch(ctx)
+	if writer.appends != 0 {
+		t.Fatalf("appends with no write budget = %d, want 0", writer.appends)
+	}
+
+	// Google refuses the write: the item is deferred, not failed
+	writer.available = 10
+	processor.config.MaxRetries = 1
+	processor.processBatch(ctx)
+	if writer.appends != 1 {
+		t.Fatalf("appends = %d, want 1", writer.appends)
+	}
+	stats, err := repo.GetSyncQueueStats(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.PendingCount != 1 || stats.FailedCount != 0 {
+		t.Errorf("queue after a 429 = %+v, want the item pending", stats)
+	}
+	items, err := repo.ListExpensesWithID(ctx, 2031, 3)
+	if err != nil || len(items) != 1 {
+		t.Fatalf("expenses = %+v, %v", items, err)
+	}
+	id, _ := strconv.ParseInt(items[0].ID, 10, 64)
+	stored, err := repo.GetExpense(ctx, id)
+	if err != nil || stored.SyncStatus.String == "error" {
+		t.Errorf("expense sync status = %q, %v; a 429 must not mark it as an error", stored.SyncStatus.String, err)
+	}
+
+	// The deferred item waits for its retry time
+	processor.processBatch(ctx)
+	if writer.appends != 1 {
+		t.Errorf("deferred item retried immediately: appends = %d", writer.appends)
+	}
+}
+
+// failingWriter is a sheets writer refusing every append with err
+type failingWriter struct {
+	err error
+}
+
+func (w failingWriter) Append(context.Context, core.Expense) (string, error) {
+	return "", fmt.Errorf("append: %w", w.err)
+}
+
+func TestSyncProcessor_ErrorClasses(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantClass   sheets.ErrorClass
+		wantPending int64
+		wantFailed  int64
+	}{
+		{"transient errors are retried", errors.New("connection reset"), sheets.ErrorTransient, 1, 0},
+		{"rejected credentials wait for a fix", sheets.ErrUnauthorized, sheets.ErrorAuth, 1, 0},
+		{"invalid data fails at once", sheets.ErrInvalid, sheets.ErrorValidation, 0, 1},
+		{"a missing sheet fails at once", sheets.ErrNotFound, sheets.ErrorNotFound, 0, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer repo.Close()
+			expense := core.Expense{Date: core.NewDate(2031, 3, 5), Description: "Spesa", Amount: core.Money{Cents: 1000}, Primary: "Casa", Secondary: "Spesa"}
+			if _, err := repo.AppendAndEnqueueSync(ctx, expense); err != nil {
+				t.Fatal(err)
+			}
+
+			processor := NewSyncProcessor(repo, failingWriter{err: tt.err}, nil, DefaultSyncProcessorConfig())
+			processor.stopCh = make(chan struct{})
+			processor.processBatch(ctx)
+
+			stats, err := repo.GetSyncQueueStats(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stats.PendingCount != tt.wantPending || stats.FailedCount != tt.wantFailed {
+				t.Errorf("queue = %+v, want %d pending and %d failed", stats, tt.wantPending, tt.wantFailed)
+			}
+			errs, err := repo.ListSyncErrors(ctx, 10)
+			if err != nil || len(errs) != 1 {
+				t.Fatalf("sync errors = %+v, %v", errs, err)
+			}
+			if errs[0].Class != string(tt.wantClass) || errs[0].Attempt != 1 || errs[0].Message == "" {
+				t.Errorf("recorded error = %+v, want class %s", errs[0], tt.wantClass)
+			}
+			byExpense, err := repo.ListExpenseSyncErrors(ctx, errs[0].ExpenseID)
+			if err != nil || len(byExpense) != 1 {
+				t.Errorf("errors of the expense = %+v, %v", byExpense, err)
+			}
+		})
+	}
+}
+
+// acceptingWriter is a sheets writer accepting every append
+type acceptingWriter struct{}
+
+func (acceptingWriter) Append(context.Context, core.Expense) (string, error) {
+	return "Expenses!A2", nil
+}
+
+func TestSyncProcessor_IntegrationHealth(t *testing.T) {
+	ctx := context.Background()
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	expense := core.Expense{Date: core.NewDate(2031, 3, 5), Description: "Spesa", Amount: core.Money{Cents: 1000}, Primary: "Casa", Secondary: "Spesa"}
+	if _, err := repo.AppendAndEnqueueSync(ctx, expense); err != nil {
+		t.Fatal(err)
+	}
+
+	health := NewIntegrationHealth()
+	processor := NewSyncProcessor(repo, failingWriter{err: sheets.ErrUnauthorized}, nil, DefaultSyncProcessorConfig())
+	processor.SetIntegrationHealth(health)
+	processor.stopCh = make(chan struct{})
+	processor.processBatch(ctx)
+
+	status := health.Status()
+	if !status.Degraded || status.Failures != 1 || status.LastError == "" || status.Since.IsZero() {
+		t.Fatalf("status after rejected credentials = %+v, want degraded", status)
+	}
+
+	// The deferred item waits for the retry delay unless retried by hand
+	n, err := repo.RetryDeferredSyncs(ctx)
+	if err != nil || n != 1 {
+		t.Fatalf("RetryDeferredSyncs = %d, %v, want 1", n, err)
+	}
+	processor.sheets = acceptingWriter{}
+	processor.processBatch(ctx)
+
+	if status := health.Status(); status.Degraded {
+		t.Errorf("status after an accepted write = %+v, want healthy", status)
+	}
+}
+
+func TestIntegrationHealth_Nil(t *testing.T) {
+	var health *IntegrationHealth
+	health.MarkDegraded(errors.New("denied"))
+	health.MarkHealthy()
+	if health.Status().Degraded {
+		t.Error("nil health reported degraded")
+	}
+}
+
+func TestSyncProcessor_SyncNow(t *testing.T) {
+	ctx := context.Background()
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	expense := core.Expense{Date: core.NewDate(2031, 3, 5), Description: "Spesa", Amount: core.Money{Cents: 1000}, Primary: "Casa", Secondary: "Spesa"}
+	if _, err := repo.AppendAndEnqueueSync(ctx, expense); err != nil {
+		t.Fatal(err)
+	}
+
+	// Deferred for 15 minutes by the rejected credentials
+	processor := NewSyncProcessor(repo, failingWriter{err: sheets.ErrUnauthorized}, nil, DefaultSyncProcessorConfig())
+	processor.processBatch(ctx)
+
+	processor.sheets = acceptingWriter{}
+	n, err := processor.SyncNow(ctx)
+	if err != nil || n != 1 {
+		t.Fatalf("SyncNow = %d, %v, want the deferred item written", n, err)
+	}
+	if stats, err := repo.GetSyncQueueStats(ctx); err != nil || stats.PendingCount != 0 {
+		t.Errorf("queue = %+v, %v", stats, err)
+	}
+
+	processor.SetPrimaryCheck(func() bool { return false })
+	if n, err := processor.SyncNow(ctx); err != nil || n != 0 {
+		t.Errorf("SyncNow on a replica = %d, %v", n, err)
+	}
+}
+
+// keyedWriter keeps the sync keys of the rows it appends, like the hidden
+// column of the expenses sheet
+type keyedWriter struct {
+	keys    map[string]bool
+	appends int
+}
+
+func (w *keyedWriter) Append(context.Context, core.Expense) (string, error) {
+	w.appends++
+	return "Expenses!A2:H2", nil
+}
+
+func (w *keyedWriter) AppendOnce(ctx context.Context, id, version int64, e core.Expense) (string, bool, error) {
+	key := sheets.SyncKey(id, version)
+	if w.keys[key] {
+		return "Expenses!A2:H2", false, nil
+	}
+	w.keys[key] = true
+	ref, err := w.Append(ctx, e)
+	return ref, true, err
+}
+
+func (w *keyedWriter) SyncKeys(context.Context) (map[string]bool, error) {
+	return w.keys, nil
+}
+
+func TestSyncProcessor_Idempotent(t *testing.T) {
+	ctx := context.Background()
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	expense := core.Expense{Date: core.NewDate(2031, 3, 5), Description: "Spesa", Amount: core.Money{Cents: 1000}, Primary: "Casa", Secondary: "Spesa"}
+	if _, err := repo.AppendAndEnqueueSync(ctx, expense); err != nil {
+		t.Fatal(err)
+	}
+	items, err := repo.DequeueSyncBatch(ctx, 10)
+	if err != nil || len(items) != 1 {
+		t.Fatalf("queue = %v, %v", items, err)
+	}
+	item := items[0]
+
+	writer := &keyedWriter{keys: map[string]bool{}}
+	processor := NewSyncProcessor(repo, writer, nil, DefaultSyncProcessorConfig())
+	for range 2 {
+		if err := processor.processSyncItem(ctx, item); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if writer.appends != 1 {
+		t.Fatalf("redelivered item appended %d times, want 1", writer.appends)
+	}
+
+	// The row was written but the process stopped before completing the
+	// item: the startup check completes it without appending again
+	n, err := processor.StartupSyncCheck(ctx)
+	if err != nil || n != 1 {
+		t.Fatalf("StartupSyncCheck = %d, %v, want 1 item reconciled", n, err)
+	}
+	if stats, err := repo.GetSyncQueueStats(ctx); err != nil || stats.PendingCount != 0 || stats.CompletedCount != 1 {
+		t.Errorf("queue = %+v, %v", stats, err)
+	}
+	if got, err := repo.GetExpense(ctx, item.ExpenseID); err != nil || got.SyncStatus.String != "synced" {
+		t.Errorf("expense = %+v, %v, want synced", got, err)
+	}
+	if writer.appends != 1 {
+		t.Errorf("appends = %d after the startup check, want 1", writer.appends)
+	}
+}
+
+// splitWriter is a keyedWriter writing the rows of split expenses together
+type splitWriter struct {
+	keyedWriter
+	rows []core.Expense
+}
+
+func (w *splitWriter) AppendSplitOnce(_ context.Context, id, version int64, rows []core.Expense) (string, bool, error) {
+	key := sheets.SyncKey(id, version)
+	if w.keys[key] {
+		return "Expenses!A2:H2", false, nil
+	}
+	w.keys[key] = true
+	w.rows = append(w.rows, rows...)
+	return "Expenses!A2:H3", true, nil
+}
+
+func TestSyncProcessor_Split(t *testing.T) {
+	ctx := context.Background()
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	expense := core.Expense{Date: core.NewDate(2031, 3, 5), Description: "Supermercato", Amount: core.Money{Cents: 5000}, Primary: "Spesa", Secondary: "Supermercato"}
+	ref, err := repo.AppendAndEnqueueSync(ctx, expense)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, _ := strconv.ParseInt(ref, 10, 64)
+	if err := repo.SetExpenseSplit(ctx, id, []core.SplitPart{
+		{Amount: core.Money{Cents: 3500}, Primary: "Spesa", Secondary: "Supermercato"},
+		{Note: "detersivi", Amount: core.Money{Cents: 1500}, Primary: "Casa", Secondary: "Pulizia"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	items, err := repo.DequeueSyncBatch(ctx, 10)
+	if err != nil || len(items) != 1 {
+		t.Fatalf("queue = %v, %v", items, err)
+	}
+
+	writer := &splitWriter{keyedWriter: keyedWriter{keys: map[string]bool{}}}
+	processor := NewSyncProcessor(repo, writer, nil, DefaultSyncProcessorConfig())
+	for range 2 {
+		if err := processor.processSyncItem(ctx, items[0]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if writer.appends != 0 || len(writer.rows) != 2 {
+		t.Fatalf("appends = %d, rows = %+v, want the two parts written once", writer.appends, writer.rows)
+	}
+	if r := writer.rows[1]; !strings.HasPrefix(r.Description, "Supermercato - detersivi [ts:") || r.Amount.Cents != 1500 || r.Primary != "Casa" {
+		t.Errorf("second row = %+v", r)
+	}
+}
+
+// pullWriter is a keyedWriter whose sheet reports edits made by hand
+type pullWriter struct {
+	keyedWriter
+	edits []sheets.SheetEdit
+	acked map[int]int64 // Row to the expense ID it was tagged with
+}
+
+func (w *pullWriter) ListEdits(context.Context) ([]sheets.SheetEdit, error) {
+	return w.edits, nil
+}
+
+func (w *pullWriter) AckEdit(_ context.Context, edit sheets.SheetEdit, id, version int64) error {
+	w.acked[edit.Row] = id
+	return nil
+}
+
+func TestSyncProcessor_PullEdits(t *testing.T) {
+	ctx := context.Background()
+	repo, err := storage.NewSQLiteRepository(filepath.Join(t.TempDir(), "spese.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	writer := &pullWriter{keyedWriter: keyedWriter{keys: map[string]bool{}}, acked: map[int]int64{}}
+	processor := NewSyncProcessor(repo, writer, nil, DefaultSyncProcessorConfig())
+
+	create := func(e core.Expense) *storage.Expense {
+		t.Helper()
+		ref, err := repo.AppendAndEnqueueSync(ctx, e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		id, _ := strconv.ParseInt(ref, 10, 64)
+		stored, err := repo.GetExpense(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return stored
+	}
+	synced := create(core.Expense{Date: core.NewDate(2031, 3, 5), Description: "Spesa", Amount: core.Money{Cents: 1000}, Primary: "Casa", Secondary: "Spesa"})
+	processor.processBatch(ctx)
+	queued := create(core.Expense{Date: core.NewDate(2031, 3, 6), Description: "In coda", Amount: core.Money{Cents: 500}, Primary: "Casa", Secondary: "Spesa"})
+	if err := repo.CloseMonth(ctx, "2031-02"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The sheet holds this year's rows: the edit keeps the expense's year
+	expense := func(month, day int, desc string, cents int64) core.Expense {
+		return core.Expense{Date: core.NewDate(2030, month, day), Description: desc, Amount: core.Money{Cents: cents}, Primary: "Casa", Secondary: "Spesa"}
+	}
+	writer.edits = []sheets.SheetEdit{
+		{Row: 2, ID: synced.ID, Expense: expense(3, 7, "Spesa corretta", 1250)},
+		{Row: 3, ID: queued.ID, Expense: expense(3, 6, "In coda", 900)},
+		{Row: 4, ID: 99999, Expense: expense(3, 8, "Eliminata", 100)},
+		{Row: 5, Expense: expense(3, 9, "Aggiunta a mano", 700)},
+		{Row: 6, Expense: expense(3, 10, "", 700)},
+		{Row: 7, Expense: expense(2, 10, "Mese chiuso", 700)},
+	}
+	writer.edits[5].Expense.Date = core.NewDate(2031, 2, 10)
+
+	n, err := processor.PullEdits(ctx)
+	if err != nil || n != 2 {
+		t.Fatalf("PullEdits = %d, %v, want 2", n, err)
+	}
+	if len(writer.acked) != 2 || writer.acked[2] != synced.ID || writer.acked[5] == 0 {
+		t.Fatalf("acked rows = %v, want rows 2 and 5", writer.acked)
+	}
+
+	got, err := repo.GetExpense(ctx, synced.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.AmountCents != 1250 || got.Description != "Spesa corretta" || got.Date.Year() != 2031 || got.Date.Day() != 7 {
+		t.Errorf("edited expense = %+v", got)
+	}
+	if got.Version != synced.Version+1 || got.SyncStatus.String != "synced" {
+		t.Errorf("edited expense version %d status %q, want %d synced", got.Version, got.SyncStatus.String, synced.Version+1)
+	}
+	if got, err := repo.GetExpense(ctx, queued.ID); err != nil || got.AmountCents != 500 {
+		t.Errorf("queued expense = %+v, %v, want its amount kept", got, err)
+	}
+	added, err := repo.GetExpense(ctx, writer.acked[5])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added.Description != "Aggiunta a mano" || added.Date.Year() != 2030 || added.SyncStatus.String != "synced" {
+		t.Errorf("added expense = %+v", added)
+	}
+}
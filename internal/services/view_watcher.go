@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+
+	"spese/internal/core"
+	"spese/internal/events"
+	"spese/internal/storage"
+)
+
+// ViewWatcher sends a view alert for every new expense matching a saved
+// view with notifications on.
+type ViewWatcher struct {
+	storage *storage.SQLiteRepository
+	alerts  *AlertRouter
+}
+
+// NewViewWatcher creates a watcher over the repository's saved views.
+func NewViewWatcher(storage *storage.SQLiteRepository, alerts *AlertRouter) *ViewWatcher {
+	return &ViewWatcher{storage: storage, alerts: alerts}
+}
+
+// Check sends the alerts for new expenses, refs holding their IDs in the
+// same order, and returns how many went out. A nil watcher sends none.
+func (w *ViewWatcher) Check(ctx context.Context, expenses []core.Expense, refs []string) int {
+	if w == nil || w.alerts == nil || len(expenses) == 0 {
+		return 0
+	}
+	views, err := w.storage.ListSavedViews(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to load saved views for alerts", "error", err)
+		return 0
+	}
+
+	sent := 0
+	for _, v := range views {
+		if !v.Notify {
+			continue
+		}
+		for i, e := range expenses {
+			if !v.Filter.Matches(e) {
+				continue
+			}
+			if w.alerts.Send(ctx, Alert{
+				Type:  core.AlertView,
+				Event: events.ViewMatched,
+				Data: events.ViewMatchedPayload{
+					ViewID:    v.ID,
+					View:      v.Name,
+					ExpenseID: refs[i],
+					Expense:   events.ExpenseFrom(e),
+				},
+			}) {
+				sent++
+			}
+		}
+	}
+	return sent
+}
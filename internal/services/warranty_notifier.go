@@ -0,0 +1,70 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"spese/internal/core"
+	"spese/internal/events"
+	"spese/internal/storage"
+)
+
+// WarrantyNotifier reminds, once per purchase, that a return window is
+// about to close. Reminders are logged and sent through the alert router,
+// which pushes them to WebSocket clients unless return alerts are muted or
+// snoozed for the purchase's category.
+type WarrantyNotifier struct {
+	storage *storage.SQLiteRepository
+	alerts  *AlertRouter
+	notice  int // Days before the deadline the reminder goes out
+}
+
+// NewWarrantyNotifier creates a notifier sending reminders noticeDays days
+// before each return deadline.
+func NewWarrantyNotifier(storage *storage.SQLiteRepository, alerts *AlertRouter, noticeDays int) *WarrantyNotifier {
+	return &WarrantyNotifier{storage: storage, alerts: alerts, notice: noticeDays}
+}
+
+// NotifyReturnDeadlines sends the reminders of the deadlines from today to
+// the notice period and returns how many went out. Deadlines already past
+// are not reminded.
+func (n *WarrantyNotifier) NotifyReturnDeadlines(ctx context.Context, now time.Time) (int, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	items, err := n.storage.ListReturnsToRemind(ctx, today, today.AddDate(0, 0, n.notice))
+	if err != nil {
+		return 0, fmt.Errorf("list returns to remind: %w", err)
+	}
+
+	sent := 0
+	for _, item := range items {
+		deadline := item.Warranty.ReturnDeadline.Format("2006-01-02")
+		if n.alerts != nil {
+			n.alerts.Send(ctx, Alert{
+				Type:  core.AlertReturnDue,
+				Scope: item.Expense.Primary,
+				Event: events.ReturnDue,
+				Data: events.ReturnDuePayload{
+					ID:             strconv.FormatInt(item.ExpenseID, 10),
+					Description:    item.Expense.Description,
+					AmountCents:    item.Expense.Amount.Cents,
+					PurchaseDate:   item.Expense.Date.Format("2006-01-02"),
+					ReturnDeadline: deadline,
+					Proof:          item.Warranty.Proof,
+				},
+			})
+		}
+		slog.InfoContext(ctx, "Return window closing",
+			"expense_id", item.ExpenseID,
+			"description", item.Expense.Description,
+			"return_deadline", deadline)
+
+		if err := n.storage.MarkReturnReminded(ctx, item.ExpenseID); err != nil {
+			return sent, err
+		}
+		sent++
+	}
+	return sent, nil
+}
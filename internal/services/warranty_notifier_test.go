@@ -0,0 +1,62 @@
+package services
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"spese/internal/core"
+	"spese/internal/events"
+)
+
+func TestWarrantyNotifier_NotifyReturnDeadlines(t *testing.T) {
+	ctx := context.Background()
+	repo := newPeerRepo(t, "warranty")
+	bus := events.NewBus()
+	sub, unsubscribe := bus.Subscribe(4)
+	defer unsubscribe()
+
+	add := func(desc string, day int, w core.Warranty) int64 {
+		t.Helper()
+		ref, err := repo.AppendAndEnqueueSync(ctx, core.Expense{Date: core.NewDate(2031, 3, day), Description: desc, Amount: core.Money{Cents: 19900}, Primary: "Casa", Secondary: "Elettronica"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		id, _ := strconv.ParseInt(ref, 10, 64)
+		if err := repo.SetExpenseWarranty(ctx, id, w); err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+	headphones := add("Cuffie", 1, core.Warranty{Months: 24, ReturnDeadline: core.NewDate(2031, 3, 15), Proof: "scontrino 0042"})
+	add("Lampada", 5, core.Warranty{ReturnDeadline: core.NewDate(2031, 4, 4)})
+	add("Forno", 2, core.Warranty{Months: 24})
+
+	notifier := NewWarrantyNotifier(repo, NewAlertRouter(repo, bus, core.DefaultAlertUser), 3)
+	now := time.Date(2031, 3, 12, 9, 0, 0, 0, time.UTC)
+	sent, err := notifier.NotifyReturnDeadlines(ctx, now)
+	if err != nil || sent != 1 {
+		t.Fatalf("sent = %d, err = %v, want 1", sent, err)
+	}
+	e := <-sub
+	payload, ok := e.Data.(events.ReturnDuePayload)
+	if e.Type != events.ReturnDue || !ok || payload.ID != strconv.FormatInt(headphones, 10) || payload.ReturnDeadline != "2031-03-15" || payload.Proof != "scontrino 0042" {
+		t.Fatalf("event = %+v", e)
+	}
+
+	// Each deadline is reminded once
+	if sent, err := notifier.NotifyReturnDeadlines(ctx, now.Add(24*time.Hour)); err != nil || sent != 0 {
+		t.Errorf("second run: sent = %d, err = %v, want 0", sent, err)
+	}
+
+	// After its return window the lamp leaves the report; the others are
+	// still under warranty
+	active, err := repo.ListActiveWarranties(ctx, time.Date(2031, 4, 10, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(active) != 2 || active[0].Expense.Description != "Forno" || active[1].Expense.Description != "Cuffie" {
+		t.Errorf("active warranties = %+v", active)
+	}
+}
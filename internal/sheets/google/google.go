@@ -5,37 +5,79 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
-	"net"
 	"net/http"
 	"os"
+	"sort"
 	"spese/internal/core"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"spese/internal/httpclient"
 	ports "spese/internal/sheets"
 
+	"golang.org/x/oauth2"
+	goauth "golang.org/x/oauth2/google"
 	goption "google.golang.org/api/option"
 	gsheet "google.golang.org/api/sheets/v4"
 )
 
+// RequestTimeout bounds a request to the Sheets API
+const RequestTimeout = 60 * time.Second
+
 type Client struct {
 	svc                *gsheet.Service
 	spreadsheetID      string
 	expensesSheet      string
+	expensesBase       string // Without year, to find the sheets of past years
+	year               int    // Year of expensesSheet
 	categoriesSheet    string
 	subcategoriesSheet string
 	// Preferred: base name without year (e.g. "Dashboard"); code prefixes year.
 	dashboardBase string
 	// Legacy fallback: pattern or plain prefix (e.g. "%d Dashboard" or "Dashboard").
 	dashboardPrefix string
+	// How amounts are written to (and read back from) the expenses sheet.
+	amountFormat AmountFormat
+	// Write budget shared with the other clients of the same credentials; nil is unlimited
+	writes *WriteLimiter
 
-	// Row count cache for performance (avoids repeated read requests)
+	// Row count cache by expenses sheet, for performance (avoids repeated read requests)
 	mu                 sync.Mutex
-	cachedRowCount     int
-	cacheExpiresAt     time.Time
+	rowCounts          map[string]rowCount
 	cacheValidDuration time.Duration
+	rowCountStats      cacheCounters
+
+	// Taxonomy and month overview caches, see google_cache.go
+	readCaches readCaches
+}
+
+// rowCount is the last reserved row of an expenses sheet
+type rowCount struct {
+	row     int
+	expires time.Time
+}
+
+// AmountFormat controls how expense amounts are represented in the expenses sheet.
+type AmountFormat string
+
+const (
+	AmountFormatDot   AmountFormat = "dot"   // Decimal string with dot separator, e.g. "12.34"
+	AmountFormatComma AmountFormat = "comma" // Decimal string with comma separator, e.g. "12,34"
+	AmountFormatCents AmountFormat = "cents" // Integer cents, e.g. "1234"; the sheet formats the column
+)
+
+// ParseAmountFormat validates a GOOGLE_AMOUNT_FORMAT value. Empty means AmountFormatDot.
+func ParseAmountFormat(s string) (AmountFormat, error) {
+	switch f := AmountFormat(strings.ToLower(strings.TrimSpace(s))); f {
+	case "":
+		return AmountFormatDot, nil
+	case AmountFormatDot, AmountFormatComma, AmountFormatCents:
+		return f, nil
+	default:
+		return "", fmt.Errorf("invalid amount format %q: must be one of dot, comma, cents", s)
+	}
 }
 
 // Ensure interface conformance
@@ -45,6 +87,13 @@ var (
 	_ ports.DashboardReader = (*Client)(nil)
 	_ ports.ExpenseLister   = (*Client)(nil)
 	_ ports.ExpenseDeleter  = (*Client)(nil)
+
+	_ ports.ExpenseWriterWithID = (*Client)(nil)
+	_ ports.AmountReconciler    = (*Client)(nil)
+	_ ports.HistoryReader       = (*Client)(nil)
+	_ ports.WriteThrottle       = (*Client)(nil)
+	_ ports.EditPuller          = (*Client)(nil)
+	_ ports.SplitExpenseWriter  = (*Client)(nil)
 )
 
 // NewFromEnv creates a Sheets client using environment variables and ADC.
@@ -53,7 +102,16 @@ var (
 // Optional sheet names: GOOGLE_SHEET_NAME (default "Spese"),
 // GOOGLE_CATEGORIES_SHEET_NAME (default "Categories"),
 // GOOGLE_SUBCATEGORIES_SHEET_NAME (default "Subcategories").
+// Optional GOOGLE_AMOUNT_FORMAT (dot|comma|cents, default "dot") selects how
+// amounts are written to the expenses sheet.
 func NewFromEnv(ctx context.Context) (*Client, error) {
+	return NewFromEnvWithClient(ctx, nil)
+}
+
+// NewFromEnvWithClient is NewFromEnv sending the API and token requests
+// through base, e.g. a client of an httpclient.Factory. A nil base uses a
+// client configured by httpclient.OptionsFromEnv.
+func NewFromEnvWithClient(ctx context.Context, base *http.Client) (*Client, error) {
 	spreadsheetID := strings.TrimSpace(os.Getenv("GOOGLE_SPREADSHEET_ID"))
 	if spreadsheetID == "" {
 		return nil, errors.New("missing GOOGLE_SPREADSHEET_ID")
@@ -73,7 +131,12 @@ func NewFromEnv(ctx context.Context) (*Client, error) {
 		subsBase = "Dashboard"
 	}
 
-	svc, err := newSheetsService(ctx)
+	amountFormat, err := ParseAmountFormat(os.Getenv("GOOGLE_AMOUNT_FORMAT"))
+	if err != nil {
+		return nil, err
+	}
+
+	svc, err := newSheetsService(ctx, base)
 	if err != nil {
 		return nil, fmt.Errorf("sheets service: %w", err)
 	}
@@ -96,17 +159,21 @@ func NewFromEnv(ctx context.Context) (*Client, error) {
 		svc:                svc,
 		spreadsheetID:      spreadsheetID,
 		expensesSheet:      expenses,
+		expensesBase:       trimYearPrefix(expensesBase),
+		year:               currentYear,
 		categoriesSheet:    cats,
 		subcategoriesSheet: subs,
 		dashboardBase:      dashBase,
 		dashboardPrefix:    dashPrefix,
+		amountFormat:       amountFormat,
 		cacheValidDuration: 2 * time.Minute, // Cache row count for 2 minutes to reduce API calls
 	}, nil
 }
 
 // newSheetsService initializes a Sheets Service using Service Account credentials.
 // Uses GOOGLE_SERVICE_ACCOUNT_JSON, GOOGLE_SERVICE_ACCOUNT_FILE, or GOOGLE_APPLICATION_CREDENTIALS.
-func newSheetsService(ctx context.Context) (*gsheet.Service, error) {
+// Requests go through base, or a client from the environment when nil.
+func newSheetsService(ctx context.Context, base *http.Client) (*gsheet.Service, error) {
 	serviceAccountJSON := strings.TrimSpace(os.Getenv("GOOGLE_SERVICE_ACCOUNT_JSON"))
 	serviceAccountFile := strings.TrimSpace(os.Getenv("GOOGLE_SERVICE_ACCOUNT_FILE"))
 
@@ -144,11 +211,25 @@ func newSheetsService(ctx context.Context) (*gsheet.Service, error) {
 		"credentials_size", len(credentialsJSON),
 		"scope", gsheet.SpreadsheetsScope)
 
-	// Try without custom HTTP client first to debug
-	service, err := gsheet.NewService(ctx,
-		goption.WithCredentialsJSON(credentialsJSON),
-		goption.WithScopes(gsheet.SpreadsheetsScope))
+	// Token and API requests both go through the outbound client, so its
+	// pooling, proxy and CA settings apply to them
+	if base == nil {
+		opts := httpclient.OptionsFromEnv()
+		opts.Timeout = RequestTimeout
+		opts.Retry = httpclient.DefaultRetryPolicy()
+		if base, err = httpclient.New(opts); err != nil {
+			return nil, fmt.Errorf("outbound http client: %w", err)
+		}
+	}
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, base)
+	creds, err := goauth.CredentialsFromJSON(ctx, credentialsJSON, gsheet.SpreadsheetsScope)
+	if err != nil {
+		return nil, fmt.Errorf("parse service account credentials: %w", err)
+	}
+	client := oauth2.NewClient(ctx, creds.TokenSource)
+	client.Timeout = base.Timeout
 
+	service, err := gsheet.NewService(ctx, goption.WithHTTPClient(client))
 	if err != nil {
 		return nil, fmt.Errorf("create sheets service: %w", err)
 	}
@@ -157,137 +238,260 @@ func newSheetsService(ctx context.Context) (*gsheet.Service, error) {
 	return service, nil
 }
 
-// newHTTPClientWithPooling creates an HTTP client optimized for Google Sheets API
-// with connection pooling, proper timeouts, and keep-alive settings
-func newHTTPClientWithPooling() *http.Client {
-	// Create a custom dialer with timeouts
-	dialer := &net.Dialer{
-		Timeout:   30 * time.Second, // TCP connection timeout
-		KeepAlive: 30 * time.Second, // Keep-alive probe interval
-	}
-
-	transport := &http.Transport{
-		// Use custom dialer
-		DialContext: dialer.DialContext,
-
-		// Connection pooling settings
-		MaxIdleConns:        100,              // Total max idle connections across all hosts
-		MaxIdleConnsPerHost: 10,               // Max idle connections per host (Google APIs)
-		MaxConnsPerHost:     50,               // Max total connections per host
-		IdleConnTimeout:     90 * time.Second, // How long idle connections stay open
-
-		// TLS and response timeouts
-		TLSHandshakeTimeout:   10 * time.Second, // TLS handshake timeout
-		ResponseHeaderTimeout: 30 * time.Second, // Time to read response headers
-		ExpectContinueTimeout: 1 * time.Second,  // 100-continue timeout
-
-		// Keep-alive and HTTP/2 settings
-		DisableKeepAlives: false, // Enable HTTP keep-alive
-		ForceAttemptHTTP2: true,  // Prefer HTTP/2
-	}
-
-	return &http.Client{
-		Transport: transport,
-		Timeout:   60 * time.Second, // Overall request timeout
+// expensesSheetFor returns the expenses sheet of year: expenses are written
+// to the sheet of the year of their date, not always to the current one.
+func (c *Client) expensesSheetFor(year int) string {
+	if year == 0 || year == c.year || c.expensesBase == "" {
+		return c.expensesSheet
 	}
+	return yearPrefixedName(c.expensesBase, year)
 }
 
-// getNextRow returns the next available row number, using cached row count when valid
+// getNextRow returns the next available row number of sheet, using cached row count when valid
 // If cache is expired or this is the first call, it reads column A from the sheet
-func (c *Client) getNextRow(ctx context.Context) (int, error) {
+func (c *Client) getNextRow(ctx context.Context, sheet string) (int, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	// Check if cache is still valid
-	if time.Now().Before(c.cacheExpiresAt) && c.cachedRowCount > 0 {
+	// Check if cache is still valid. The row returned is reserved: the
+	// next call gets the one after it.
+	cached := c.rowCounts[sheet]
+	if time.Now().Before(cached.expires) && cached.row > 0 {
+		c.rowCountStats.hit()
 		slog.DebugContext(ctx, "Using cached row count",
-			"cached_row_count", c.cachedRowCount,
-			"expires_in", time.Until(c.cacheExpiresAt).Round(time.Second))
-		return c.cachedRowCount + 1, nil
+			"sheet", sheet,
+			"cached_row_count", cached.row,
+			"expires_in", time.Until(cached.expires).Round(time.Second))
+		cached.row++
+		c.rowCounts[sheet] = cached
+		return cached.row, nil
 	}
 
 	// Cache miss or expired: read from sheet
+	c.rowCountStats.miss()
 	slog.InfoContext(ctx, "Row count cache expired or invalid, refreshing from sheet",
-		"cached_row_count", c.cachedRowCount,
-		"expires_at", c.cacheExpiresAt.Format(time.RFC3339))
+		"sheet", sheet,
+		"cached_row_count", cached.row,
+		"expires_at", cached.expires.Format(time.RFC3339))
 
-	rng := fmt.Sprintf("%s!A:A", c.expensesSheet)
+	rng := fmt.Sprintf("%s!A:A", sheet)
 	resp, err := c.svc.Spreadsheets.Values.Get(c.spreadsheetID, rng).Context(ctx).Do()
 	if err != nil {
-		return 0, fmt.Errorf("failed to get sheet dimensions for %s: %w", c.expensesSheet, err)
+		return 0, fmt.Errorf("failed to get sheet dimensions for %s: %w", sheet, apiError(err))
 	}
 
-	// Update cache
-	c.cachedRowCount = len(resp.Values)
-	c.cacheExpiresAt = time.Now().Add(c.cacheValidDuration)
-
-	nextRow := c.cachedRowCount + 1
+	// Update cache, reserving the next row
+	rows := len(resp.Values)
+	nextRow := rows + 1
+	if c.rowCounts == nil {
+		c.rowCounts = make(map[string]rowCount)
+	}
+	c.rowCounts[sheet] = rowCount{row: nextRow, expires: time.Now().Add(c.cacheValidDuration)}
 
 	slog.InfoContext(ctx, "Updated row count cache",
-		"row_count", c.cachedRowCount,
+		"sheet", sheet,
+		"row_count", rows,
 		"next_row", nextRow,
-		"cache_expires_at", c.cacheExpiresAt.Format(time.RFC3339))
+		"cache_expires_at", c.rowCounts[sheet].expires.Format(time.RFC3339))
 
 	return nextRow, nil
 }
 
-// InvalidateRowCache clears the cached row count (called after successful appends)
+// InvalidateRowCache clears the cached row counts (called after successful appends)
 func (c *Client) InvalidateRowCache() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.cacheExpiresAt = time.Now() // Expire cache immediately
+	clear(c.rowCounts) // Expire cache immediately
 	slog.DebugContext(context.Background(), "Row count cache invalidated")
 }
 
 func (c *Client) Append(ctx context.Context, e core.Expense) (string, error) {
+	return c.appendRow(ctx, e, "", "")
+}
+
+// AppendWithID appends the expense and stores its database ID in column I.
+// The column is meant to be hidden in the sheet; it lets reconciliation match
+// rows to expenses without relying on description and amount.
+func (c *Client) AppendWithID(ctx context.Context, id int64, e core.Expense) (string, error) {
+	return c.appendRow(ctx, e, strconv.FormatInt(id, 10), "")
+}
+
+// AppendOnce implements ports.IdempotentExpenseWriter: the row gets the ID
+// in column I and the sync key in the hidden column J, and is not appended
+// again when a row already carries the key, as when a sync is retried after
+// a write whose reply was lost.
+func (c *Client) AppendOnce(ctx context.Context, id, version int64, e core.Expense) (string, bool, error) {
+	if c.svc == nil {
+		return "", false, errors.New("sheets service not initialized")
+	}
+	key := ports.SyncKey(id, version)
+	sheet := c.expensesSheetFor(e.Date.Year())
+	rows, err := c.readSyncKeys(ctx, sheet)
+	if err != nil {
+		return "", false, err
+	}
+	if row, ok := rows[key]; ok {
+		return fmt.Sprintf("%s!A%d:H%d", sheet, row, row), false, nil
+	}
+	ref, err := c.appendRow(ctx, e, strconv.FormatInt(id, 10), key)
+	if err != nil {
+		return "", false, err
+	}
+	return ref, true, nil
+}
+
+// AppendSplitOnce implements ports.SplitExpenseWriter: the rows are written
+// in one request, all tagged with the ID and sync key of the expense.
+func (c *Client) AppendSplitOnce(ctx context.Context, id, version int64, rows []core.Expense) (string, bool, error) {
+	if c.svc == nil {
+		return "", false, errors.New("sheets service not initialized")
+	}
+	for _, e := range rows {
+		if err := e.Validate(); err != nil {
+			return "", false, fmt.Errorf("%w: validation failed: %w", ports.ErrInvalid, err)
+		}
+	}
+	if len(rows) == 0 {
+		return "", false, fmt.Errorf("%w: no rows to write", ports.ErrInvalid)
+	}
+	key := ports.SyncKey(id, version)
+	// The rows of a split share the date of the expense
+	sheet := c.expensesSheetFor(rows[0].Date.Year())
+	keys, err := c.readSyncKeys(ctx, sheet)
+	if err != nil {
+		return "", false, err
+	}
+	if row, ok := keys[key]; ok {
+		return fmt.Sprintf("%s!A%d:H%d", sheet, row, row), false, nil
+	}
+
+	nextRow, err := c.getNextRow(ctx, sheet)
+	if err != nil {
+		return "", false, err
+	}
+	last := nextRow + len(rows) - 1
+	ref := strconv.FormatInt(id, 10)
+	values := make([][]any, len(rows))
+	tags := make([][]any, len(rows))
+	for i, e := range rows {
+		sum := rowChecksum(e.Date.Month(), e.Date.Day(), e.Description, e.Amount.Cents, e.Primary, e.Secondary)
+		values[i] = []any{e.Date.Month(), e.Date.Day(), e.Description, formatAmount(e.Amount.Cents, c.amountFormat)}
+		tags[i] = []any{e.Primary, e.Secondary, ref, key, sum}
+	}
+	data := []*gsheet.ValueRange{
+		{Range: fmt.Sprintf("%s!A%d:D%d", sheet, nextRow, last), Values: values},
+		{Range: fmt.Sprintf("%s!G%d:K%d", sheet, nextRow, last), Values: tags},
+	}
+	if err := c.waitWrite(ctx); err != nil {
+		return "", false, err
+	}
+	_, err = c.svc.Spreadsheets.Values.BatchUpdate(c.spreadsheetID, &gsheet.BatchUpdateValuesRequest{
+		ValueInputOption: "USER_ENTERED",
+		Data:             data,
+	}).Context(ctx).Do()
+	// Only the first of the rows was reserved
+	c.InvalidateRowCache()
+	if err != nil {
+		return "", false, fmt.Errorf("write rows %d-%d of %s: %w", nextRow, last, sheet, apiError(err))
+	}
+	c.invalidateOverviews()
+	return fmt.Sprintf("%s!A%d:H%d", sheet, nextRow, last), true, nil
+}
+
+// SyncKeys implements ports.IdempotentExpenseWriter
+func (c *Client) SyncKeys(ctx context.Context) (map[string]bool, error) {
+	if c.svc == nil {
+		return nil, errors.New("sheets service not initialized")
+	}
+	rows, err := c.readSyncKeys(ctx, c.expensesSheet)
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[string]bool, len(rows))
+	for key := range rows {
+		keys[key] = true
+	}
+	return keys, nil
+}
+
+// readSyncKeys maps the sync keys of column J of sheet to their 1-based rows
+func (c *Client) readSyncKeys(ctx context.Context, sheet string) (map[string]int, error) {
+	rng := fmt.Sprintf("%s!J:J", sheet)
+	resp, err := c.svc.Spreadsheets.Values.Get(c.spreadsheetID, rng).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", rng, apiError(err))
+	}
+	return parseSyncKeys(resp.Values), nil
+}
+
+func (c *Client) appendRow(ctx context.Context, e core.Expense, id, key string) (string, error) {
 	if err := e.Validate(); err != nil {
-		return "", fmt.Errorf("validation failed: %w", err)
+		return "", fmt.Errorf("%w: validation failed: %w", ports.ErrInvalid, err)
 	}
 	if c.svc == nil {
 		return "", errors.New("sheets service not initialized")
 	}
 
-	// Convert cents to decimal string
-	euros := float64(e.Amount.Cents) / 100.0
+	// Write the exact amount as a string so the sheet mirrors the stored cents
+	amount := formatAmount(e.Amount.Cents, c.amountFormat)
 
 	// Get next row using cached row count (reduces API calls significantly)
-	nextRow, err := c.getNextRow(ctx)
+	sheet := c.expensesSheetFor(e.Date.Year())
+	nextRow, err := c.getNextRow(ctx, sheet)
 	if err != nil {
 		return "", err
 	}
 
 	// Update only the specific columns we want, skipping E and F
 	// Update A:D (Month, Day, Description, Amount)
-	dataRange1 := fmt.Sprintf("%s!A%d:D%d", c.expensesSheet, nextRow, nextRow)
-	vr1 := &gsheet.ValueRange{Values: [][]any{{e.Date.Month(), e.Date.Day(), e.Description, euros}}}
+	dataRange1 := fmt.Sprintf("%s!A%d:D%d", sheet, nextRow, nextRow)
+	vr1 := &gsheet.ValueRange{Values: [][]any{{e.Date.Month(), e.Date.Day(), e.Description, amount}}}
 
+	if err := c.waitWrite(ctx); err != nil {
+		return "", err
+	}
 	_, err = c.svc.Spreadsheets.Values.Update(c.spreadsheetID, dataRange1, vr1).
 		ValueInputOption("USER_ENTERED").Context(ctx).Do()
 	if err != nil {
 		// Invalidate cache on write failure in case row was actually written
 		c.InvalidateRowCache()
-		return "", fmt.Errorf("failed to update A:D in sheet %s: %w", c.expensesSheet, err)
+		return "", fmt.Errorf("failed to update A:D in sheet %s: %w", sheet, apiError(err))
 	}
 
-	// Update G:H (Primary, Secondary categories)
-	dataRange2 := fmt.Sprintf("%s!G%d:H%d", c.expensesSheet, nextRow, nextRow)
+	// Update G:H (Primary, Secondary categories), plus the hidden ID in I,
+	// sync key in J and checksum in K when the ID is known
+	dataRange2 := fmt.Sprintf("%s!G%d:H%d", sheet, nextRow, nextRow)
 	vr2 := &gsheet.ValueRange{Values: [][]any{{e.Primary, e.Secondary}}}
+	if id != "" {
+		sum := rowChecksum(e.Date.Month(), e.Date.Day(), e.Description, e.Amount.Cents, e.Primary, e.Secondary)
+		dataRange2 = fmt.Sprintf("%s!G%d:K%d", sheet, nextRow, nextRow)
+		vr2 = &gsheet.ValueRange{Values: [][]any{{e.Primary, e.Secondary, id, key, sum}}}
+	}
 
+	// The second half of the row waits without ctx: giving up here would
+	// leave a row without categories
+	if err := c.waitWrite(context.WithoutCancel(ctx)); err != nil {
+		return "", err
+	}
 	_, err = c.svc.Spreadsheets.Values.Update(c.spreadsheetID, dataRange2, vr2).
 		ValueInputOption("USER_ENTERED").Context(ctx).Do()
 	if err != nil {
 		// Invalidate cache on write failure
 		c.InvalidateRowCache()
-		return "", fmt.Errorf("failed to update G:H in sheet %s: %w", c.expensesSheet, err)
+		return "", fmt.Errorf("failed to update %s: %w", dataRange2, apiError(err))
 	}
 
+	c.invalidateOverviews()
+
 	// Return reference in the format expected by callers
-	ref := fmt.Sprintf("%s!A%d:H%d", c.expensesSheet, nextRow, nextRow)
+	ref := fmt.Sprintf("%s!A%d:H%d", sheet, nextRow, nextRow)
 
 	return ref, nil
 }
 
-func (c *Client) List(ctx context.Context) ([]string, []string, error) {
+// readTaxonomy reads the categories and subcategories sheets; List serves
+// them from the taxonomy cache.
+func (c *Client) readTaxonomy(ctx context.Context) ([]string, []string, error) {
 	if c.svc == nil {
 		return nil, nil, errors.New("sheets service not initialized")
 	}
@@ -333,9 +537,10 @@ func (c *Client) readCol(ctx context.Context, sheetName, col string) ([]string,
 	return uniq, nil
 }
 
-// ReadMonthOverview reads the dashboard sheet for the given year and month
-// and extracts totals by primary category and the grand total for that month.
-func (c *Client) ReadMonthOverview(ctx context.Context, year int, month int) (core.MonthOverview, error) {
+// readMonthOverview reads the dashboard sheet for the given year and month
+// and extracts totals by primary category and the grand total for that
+// month; ReadMonthOverview serves it from the overview cache.
+func (c *Client) readMonthOverview(ctx context.Context, year int, month int) (core.MonthOverview, error) {
 	if c.svc == nil {
 		return core.MonthOverview{}, errors.New("sheets service not initialized")
 	}
@@ -392,14 +597,33 @@ func yearPrefixedName(base string, year int) string {
 	if base == "" {
 		return base
 	}
-	if len(base) >= 5 {
-		if y, err := strconv.Atoi(base[0:4]); err == nil && base[4] == ' ' && y > 1900 && y < 3000 {
-			return base
-		}
+	if _, _, ok := splitYearPrefix(base); ok {
+		return base
 	}
 	return fmt.Sprintf("%d %s", year, base)
 }
 
+// splitYearPrefix splits a "<year> <rest>" sheet name.
+func splitYearPrefix(name string) (int, string, bool) {
+	if len(name) < 5 || name[4] != ' ' {
+		return 0, "", false
+	}
+	y, err := strconv.Atoi(name[0:4])
+	if err != nil || y <= 1900 || y >= 3000 {
+		return 0, "", false
+	}
+	return y, strings.TrimSpace(name[5:]), true
+}
+
+// trimYearPrefix drops the year a configured sheet name may start with.
+func trimYearPrefix(name string) string {
+	name = strings.TrimSpace(name)
+	if _, rest, ok := splitYearPrefix(name); ok {
+		return rest
+	}
+	return name
+}
+
 // readMonthOverviewFromExpenses scans the expenses sheet for the given month and
 // aggregates totals by primary category. Year is inferred by the sheet name and
 // only used for the returned struct.
@@ -424,14 +648,7 @@ func (c *Client) readMonthOverviewFromExpenses(ctx context.Context, year int, mo
 			continue
 		}
 		// Amount in col D (index 3) can come as number or string
-		cents, ok := parseEurosToCents(cols[3])
-		if !ok {
-			// Try fallback for numbers formatted without decimal separator
-			if f, ferr := strconv.ParseFloat(strings.TrimSpace(cols[3]), 64); ferr == nil {
-				cents = int64((f * 100.0) + 0.5)
-				ok = true
-			}
-		}
+		cents, ok := c.parseAmountCell(cols[3])
 		if !ok {
 			continue
 		}
@@ -479,8 +696,14 @@ func (c *Client) ListExpenses(ctx context.Context, year int, month int) ([]core.
 	if err != nil {
 		return nil, fmt.Errorf("read %s: %w", rng, err)
 	}
+	return c.parseExpenseRows(resp.Values, year, month), nil
+}
+
+// parseExpenseRows converts raw A:H values of the expenses sheet of a year
+// into expenses. Month 0 keeps the rows of every month.
+func (c *Client) parseExpenseRows(values [][]interface{}, year int, month int) []core.Expense {
 	var out []core.Expense
-	for i, row := range resp.Values {
+	for i, row := range values {
 		cols := toStrings(row)
 		if len(cols) < 7 {
 			continue
@@ -492,19 +715,12 @@ func (c *Client) ListExpenses(ctx context.Context, year int, month int) ([]core.
 			}
 		}
 		m, err := strconv.Atoi(strings.TrimSpace(cols[0]))
-		if err != nil || m != month {
+		if err != nil || m < 1 || m > 12 || (month != 0 && m != month) {
 			continue
 		}
 		day, _ := strconv.Atoi(strings.TrimSpace(cols[1]))
 		desc := strings.TrimSpace(cols[2])
-		cents, ok := parseEurosToCents(cols[3])
-		if !ok {
-			// Try simple float parsing
-			if f, ferr := strconv.ParseFloat(strings.TrimSpace(cols[3]), 64); ferr == nil {
-				cents = int64((f * 100.0) + 0.5)
-				ok = true
-			}
-		}
+		cents, ok := c.parseAmountCell(cols[3])
 		if !ok {
 			continue
 		}
@@ -514,7 +730,7 @@ func (c *Client) ListExpenses(ctx context.Context, year int, month int) ([]core.
 			secondary = strings.TrimSpace(cols[7])
 		}
 		e := core.Expense{
-			Date:        core.NewDate(time.Now().Year(), month, day),
+			Date:        core.NewDate(year, m, day),
 			Description: desc,
 			Amount:      core.Money{Cents: cents},
 			Primary:     primary,
@@ -526,7 +742,142 @@ func (c *Client) ListExpenses(ctx context.Context, year int, month int) ([]core.
 		}
 		out = append(out, e)
 	}
-	return out, nil
+	return out
+}
+
+// ListExpenseYears implements ports.HistoryReader by looking for the
+// "<year> <name>" expenses sheets in the spreadsheet.
+func (c *Client) ListExpenseYears(ctx context.Context) ([]int, error) {
+	if c.svc == nil {
+		return nil, errors.New("sheets service not initialized")
+	}
+	spreadsheet, err := c.svc.Spreadsheets.Get(c.spreadsheetID).Fields("sheets.properties.title").Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("get spreadsheet metadata: %w", err)
+	}
+	titles := make([]string, 0, len(spreadsheet.Sheets))
+	for _, sheet := range spreadsheet.Sheets {
+		if sheet.Properties != nil {
+			titles = append(titles, sheet.Properties.Title)
+		}
+	}
+	return expenseSheetYears(titles, c.expensesBase), nil
+}
+
+// ListYearExpenses implements ports.HistoryReader by scanning the expenses
+// sheet of the given year.
+func (c *Client) ListYearExpenses(ctx context.Context, year int) ([]core.Expense, error) {
+	if c.svc == nil {
+		return nil, errors.New("sheets service not initialized")
+	}
+	rng := fmt.Sprintf("%s!A:H", yearPrefixedName(c.expensesBase, year))
+	resp, err := c.svc.Spreadsheets.Values.Get(c.spreadsheetID, rng).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", rng, err)
+	}
+	return c.parseExpenseRows(resp.Values, year, 0), nil
+}
+
+// expenseSheetYears returns the years of the sheets titled "<year> <base>",
+// oldest first.
+func expenseSheetYears(titles []string, base string) []int {
+	var years []int
+	for _, title := range titles {
+		if year, rest, ok := splitYearPrefix(title); ok && rest == base {
+			years = append(years, year)
+		}
+	}
+	sort.Ints(years)
+	return years
+}
+
+// ListAmountsByID implements ports.AmountReconciler by scanning the expenses
+// sheet of year for rows carrying a storage ID in the hidden column I.
+func (c *Client) ListAmountsByID(ctx context.Context, year int) ([]ports.SheetAmountRow, error) {
+	if c.svc == nil {
+		return nil, errors.New("sheets service not initialized")
+	}
+	rng := fmt.Sprintf("%s!A:I", c.expensesSheetFor(year))
+	resp, err := c.svc.Spreadsheets.Values.Get(c.spreadsheetID, rng).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", rng, err)
+	}
+	return c.parseAmountRows(resp.Values), nil
+}
+
+// parseAmountRows extracts ID-tagged rows from raw A:I values.
+func (c *Client) parseAmountRows(values [][]interface{}) []ports.SheetAmountRow {
+	var out []ports.SheetAmountRow
+	for i, row := range values {
+		cols := toStrings(row)
+		if len(cols) < 9 {
+			continue
+		}
+		id, err := strconv.ParseInt(strings.TrimSpace(cols[8]), 10, 64)
+		if err != nil || id <= 0 {
+			continue
+		}
+		cents, ok := c.parseAmountCell(cols[3])
+		if !ok {
+			continue
+		}
+		out = append(out, ports.SheetAmountRow{ID: id, Row: i + 1, Cents: cents})
+	}
+	return out
+}
+
+// parseSyncKeys maps the sync keys of raw J:J values to their 1-based rows;
+// the header and other values without the "<id>v<version>" shape are skipped.
+func parseSyncKeys(values [][]interface{}) map[string]int {
+	rows := make(map[string]int)
+	for i, row := range values {
+		cols := toStrings(row)
+		if len(cols) == 0 {
+			continue
+		}
+		key := strings.TrimSpace(cols[0])
+		id, version, ok := strings.Cut(key, "v")
+		if !ok || !isDigits(id) || !isDigits(version) {
+			continue
+		}
+		if _, seen := rows[key]; !seen {
+			rows[key] = i + 1
+		}
+	}
+	return rows
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// UpdateAmount implements ports.AmountReconciler
+func (c *Client) UpdateAmount(ctx context.Context, year, row int, cents int64) error {
+	if c.svc == nil {
+		return errors.New("sheets service not initialized")
+	}
+	if row < 1 {
+		return fmt.Errorf("invalid row: %d", row)
+	}
+	rng := fmt.Sprintf("%s!D%d", c.expensesSheetFor(year), row)
+	vr := &gsheet.ValueRange{Values: [][]any{{formatAmount(cents, c.amountFormat)}}}
+	if err := c.waitWrite(ctx); err != nil {
+		return err
+	}
+	if _, err := c.svc.Spreadsheets.Values.Update(c.spreadsheetID, rng, vr).
+		ValueInputOption("USER_ENTERED").Context(ctx).Do(); err != nil {
+		return fmt.Errorf("update %s: %w", rng, apiError(err))
+	}
+	c.invalidateOverviews()
+	return nil
 }
 
 // DeleteExpense implements ports.ExpenseDeleter
@@ -544,14 +895,15 @@ func (c *Client) DeleteExpenseByData(ctx context.Context, expenseData core.Expen
 
 	// Validate expense data
 	if err := expenseData.Validate(); err != nil {
-		return fmt.Errorf("invalid expense data for deletion: %w", err)
+		return fmt.Errorf("%w: invalid expense data for deletion: %w", ports.ErrInvalid, err)
 	}
 
-	// Read all data from the expenses sheet
-	rng := fmt.Sprintf("%s!A:H", c.expensesSheet)
+	// Read all data from the expenses sheet of the expense's year
+	sheet := c.expensesSheetFor(expenseData.Date.Year())
+	rng := fmt.Sprintf("%s!A:H", sheet)
 	resp, err := c.svc.Spreadsheets.Values.Get(c.spreadsheetID, rng).Context(ctx).Do()
 	if err != nil {
-		return fmt.Errorf("failed to read expenses sheet %s: %w", c.expensesSheet, err)
+		return fmt.Errorf("failed to read expenses sheet %s: %w", sheet, apiError(err))
 	}
 
 	// Find the row that matches the expense data
@@ -592,14 +944,7 @@ func (c *Client) DeleteExpenseByData(ctx context.Context, expenseData core.Expen
 		}
 
 		// Match amount (column D) - convert to cents for comparison
-		cents, ok := parseEurosToCents(cols[3])
-		if !ok {
-			// Try simple float parsing as fallback
-			if f, ferr := strconv.ParseFloat(strings.TrimSpace(cols[3]), 64); ferr == nil {
-				cents = int64((f * 100.0) + 0.5)
-				ok = true
-			}
-		}
+		cents, ok := c.parseAmountCell(cols[3])
 		if !ok || cents != expenseData.Amount.Cents {
 			continue
 		}
@@ -632,7 +977,7 @@ func (c *Client) DeleteExpenseByData(ctx context.Context, expenseData core.Expen
 	// Check for multiple matches
 	if len(matchingRows) > 1 {
 		slog.WarnContext(ctx, "Multiple matching rows found for expense deletion",
-			"sheet", c.expensesSheet,
+			"sheet", sheet,
 			"matching_rows", matchingRows,
 			"using_row", targetRow,
 			"expense", map[string]interface{}{
@@ -650,7 +995,7 @@ func (c *Client) DeleteExpenseByData(ctx context.Context, expenseData core.Expen
 	if targetRow == -1 {
 		// Log the search details for debugging
 		slog.WarnContext(ctx, "Expense not found in Google Sheets for deletion",
-			"sheet", c.expensesSheet,
+			"sheet", sheet,
 			"month", expenseData.Date.Month,
 			"day", expenseData.Date.Day,
 			"description", expenseData.Description,
@@ -659,15 +1004,15 @@ func (c *Client) DeleteExpenseByData(ctx context.Context, expenseData core.Expen
 			"secondary", expenseData.Secondary,
 			"total_rows_scanned", len(resp.Values))
 
-		return fmt.Errorf("expense not found in Google Sheets: month=%d day=%d description=%s amount=%.2f primary=%s secondary=%s",
-			expenseData.Date.Month(), expenseData.Date.Day(), expenseData.Description,
+		return fmt.Errorf("%w: expense not found in Google Sheets: month=%d day=%d description=%s amount=%.2f primary=%s secondary=%s",
+			ports.ErrNotFound, expenseData.Date.Month(), expenseData.Date.Day(), expenseData.Description,
 			float64(expenseData.Amount.Cents)/100.0, expenseData.Primary, expenseData.Secondary)
 	}
 
 	// Get the sheet ID for the batchUpdate API
-	sheetId := c.getSheetId(ctx, c.expensesSheet)
+	sheetId := c.getSheetId(ctx, sheet)
 	if sheetId == 0 {
-		return fmt.Errorf("could not determine sheet ID for %s", c.expensesSheet)
+		return fmt.Errorf("could not determine sheet ID for %s", sheet)
 	}
 
 	// Delete the found row using the batchUpdate API
@@ -686,19 +1031,25 @@ func (c *Client) DeleteExpenseByData(ctx context.Context, expenseData core.Expen
 		},
 	}
 
+	if err := c.waitWrite(ctx); err != nil {
+		return err
+	}
 	_, err = c.svc.Spreadsheets.BatchUpdate(c.spreadsheetID, deleteRequest).Context(ctx).Do()
 	if err != nil {
 		slog.ErrorContext(ctx, "Google Sheets API delete request failed",
-			"sheet", c.expensesSheet,
+			"sheet", sheet,
 			"sheet_id", sheetId,
 			"target_row", targetRow,
 			"spreadsheet_id", c.spreadsheetID,
 			"error", err)
-		return fmt.Errorf("failed to delete row %d from sheet %s: %w", targetRow, c.expensesSheet, err)
+		return fmt.Errorf("failed to delete row %d from sheet %s: %w", targetRow, sheet, apiError(err))
 	}
+	c.invalidateOverviews()
+	// Rows below moved up one
+	c.InvalidateRowCache()
 
 	slog.InfoContext(ctx, "Successfully deleted expense from Google Sheets",
-		"sheet", c.expensesSheet,
+		"sheet", sheet,
 		"row", targetRow,
 		"month", expenseData.Date.Month,
 		"day", expenseData.Date.Day,
@@ -743,17 +1094,50 @@ func safeGet(arr []string, idx int) string {
 	return arr[idx]
 }
 
+// formatAmount renders cents in the given sheet format without going through float64.
+func formatAmount(cents int64, format AmountFormat) string {
+	if format == AmountFormatCents {
+		return strconv.FormatInt(cents, 10)
+	}
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	sep := "."
+	if format == AmountFormatComma {
+		sep = ","
+	}
+	return fmt.Sprintf("%s%d%s%02d", sign, cents/100, sep, cents%100)
+}
+
+// parseAmountCell reads an amount from the expenses sheet honoring the
+// configured amount format.
+func (c *Client) parseAmountCell(s string) (int64, bool) {
+	if c.amountFormat == AmountFormatCents {
+		cents, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+		return cents, err == nil
+	}
+	return parseEurosToCents(s)
+}
+
 func parseEurosToCents(s string) (int64, bool) {
 	s = strings.TrimSpace(s)
 	if s == "" {
 		return 0, false
 	}
-	// Normalize decimal comma
-	s = strings.ReplaceAll(s, ",", ".")
-	f, err := strconv.ParseFloat(s, 64)
+	// Decimal strings are rounded exactly, without going through float64
+	if cents, err := core.ParseSignedDecimalToCents(s); err == nil {
+		return cents, true
+	}
+	// Fallback for other numeric notations (e.g. exponent) returned by the API
+	f, err := strconv.ParseFloat(strings.ReplaceAll(s, ",", "."), 64)
+	if err != nil {
+		return 0, false
+	}
+	cents, err := core.CentsFromFloat(f)
 	if err != nil {
 		return 0, false
 	}
-	cents := int64((f * 100.0) + 0.5)
 	return cents, true
 }
@@ -0,0 +1,174 @@
+package google
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"spese/internal/core"
+	ports "spese/internal/sheets"
+)
+
+// Names of the caches of a client, as reported by CacheStats
+const (
+	CacheRowCount = "sheets_row_count" // Rows of the expenses sheet, for appends
+	CacheTaxonomy = "sheets_taxonomy"  // Categories and subcategories
+	CacheOverview = "sheets_overview"  // Month overviews, by year and month
+)
+
+const (
+	// taxonomyTTL is how long the categories are served without reading
+	// the sheets again; they change rarely
+	taxonomyTTL = 5 * time.Minute
+	// overviewTTL is how long a month overview is served without reading
+	// the dashboard again; writes through the client drop it sooner
+	overviewTTL = time.Minute
+)
+
+var _ ports.CacheReporter = (*Client)(nil)
+
+// cacheCounters counts the lookups of a cache
+type cacheCounters struct {
+	hits, misses atomic.Uint64
+}
+
+func (c *cacheCounters) hit()  { c.hits.Add(1) }
+func (c *cacheCounters) miss() { c.misses.Add(1) }
+
+type monthKey struct{ year, month int }
+
+type cachedOverview struct {
+	overview core.MonthOverview
+	expires  time.Time
+}
+
+// readCaches holds the reads served without calling the API. Entries are
+// filled outside the lock, so two concurrent misses both read the sheet.
+type readCaches struct {
+	mu sync.Mutex
+
+	cats, subs      []string
+	taxonomyExpires time.Time
+	taxonomyStats   cacheCounters
+
+	overviews     map[monthKey]cachedOverview
+	overviewStats cacheCounters
+}
+
+// List returns the categories and subcategories, read from the sheets at
+// most every taxonomyTTL.
+func (c *Client) List(ctx context.Context) ([]string, []string, error) {
+	rc := &c.readCaches
+	rc.mu.Lock()
+	if time.Now().Before(rc.taxonomyExpires) {
+		// Copies, so callers sorting them do not reorder the cache
+		cats, subs := slices.Clone(rc.cats), slices.Clone(rc.subs)
+		rc.mu.Unlock()
+		rc.taxonomyStats.hit()
+		return cats, subs, nil
+	}
+	rc.mu.Unlock()
+	rc.taxonomyStats.miss()
+
+	cats, subs, err := c.readTaxonomy(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	rc.mu.Lock()
+	rc.cats, rc.subs = slices.Clone(cats), slices.Clone(subs)
+	rc.taxonomyExpires = time.Now().Add(taxonomyTTL)
+	rc.mu.Unlock()
+	return cats, subs, nil
+}
+
+// ReadMonthOverview reads the dashboard sheet for the given year and month
+// and extracts totals by primary category and the grand total for that
+// month. Overviews are kept for overviewTTL, until the client writes a row.
+func (c *Client) ReadMonthOverview(ctx context.Context, year int, month int) (core.MonthOverview, error) {
+	rc := &c.readCaches
+	key := monthKey{year, month}
+	rc.mu.Lock()
+	if cached, ok := rc.overviews[key]; ok && time.Now().Before(cached.expires) {
+		rc.mu.Unlock()
+		rc.overviewStats.hit()
+		return cached.overview, nil
+	}
+	rc.mu.Unlock()
+	rc.overviewStats.miss()
+
+	ov, err := c.readMonthOverview(ctx, year, month)
+	if err != nil {
+		return core.MonthOverview{}, err
+	}
+	rc.mu.Lock()
+	if rc.overviews == nil {
+		rc.overviews = make(map[monthKey]cachedOverview)
+	}
+	rc.overviews[key] = cachedOverview{overview: ov, expires: time.Now().Add(overviewTTL)}
+	rc.mu.Unlock()
+	return ov, nil
+}
+
+// invalidateOverviews drops the cached month overviews after a write
+// changed the totals.
+func (c *Client) invalidateOverviews() {
+	c.readCaches.mu.Lock()
+	c.readCaches.overviews = nil
+	c.readCaches.mu.Unlock()
+}
+
+// CacheStats implements ports.CacheReporter. The size of the row count cache
+// is the number of expenses sheets held, that of the taxonomy cache 1 while it
+// holds a value, that of the overview cache the number of months held.
+func (c *Client) CacheStats() []ports.CacheStats {
+	now := time.Now()
+	c.mu.Lock()
+	rowCount := 0
+	for _, cached := range c.rowCounts {
+		if now.Before(cached.expires) && cached.row > 0 {
+			rowCount++
+		}
+	}
+	c.mu.Unlock()
+
+	rc := &c.readCaches
+	rc.mu.Lock()
+	taxonomy := 0
+	if now.Before(rc.taxonomyExpires) {
+		taxonomy = 1
+	}
+	overviews := 0
+	for _, cached := range rc.overviews {
+		if now.Before(cached.expires) {
+			overviews++
+		}
+	}
+	rc.mu.Unlock()
+
+	return []ports.CacheStats{
+		{Name: CacheRowCount, Hits: c.rowCountStats.hits.Load(), Misses: c.rowCountStats.misses.Load(), Size: rowCount},
+		{Name: CacheTaxonomy, Hits: rc.taxonomyStats.hits.Load(), Misses: rc.taxonomyStats.misses.Load(), Size: taxonomy},
+		{Name: CacheOverview, Hits: rc.overviewStats.hits.Load(), Misses: rc.overviewStats.misses.Load(), Size: overviews},
+	}
+}
+
+// ClearCache implements ports.CacheReporter; the next read of a cleared
+// cache goes to the sheet.
+func (c *Client) ClearCache(name string) bool {
+	switch name {
+	case CacheRowCount:
+		c.InvalidateRowCache()
+	case CacheTaxonomy:
+		c.readCaches.mu.Lock()
+		c.readCaches.cats, c.readCaches.subs = nil, nil
+		c.readCaches.taxonomyExpires = time.Time{}
+		c.readCaches.mu.Unlock()
+	case CacheOverview:
+		c.invalidateOverviews()
+	default:
+		return false
+	}
+	return true
+}
@@ -1,10 +1,16 @@
 package google
 
 import (
+	"context"
 	"testing"
 	"time"
+
+	"spese/internal/core"
 )
 
+// testSheet is the expenses sheet of the row count tests
+const testSheet = "2031 Expenses"
+
 func TestRowCacheExpiration(t *testing.T) {
 	c := &Client{
 		cacheValidDuration: 100 * time.Millisecond, // Short TTL for testing
@@ -12,7 +18,7 @@ func TestRowCacheExpiration(t *testing.T) {
 
 	// Initial state: cache should be expired
 	c.mu.Lock()
-	isValid := time.Now().Before(c.cacheExpiresAt)
+	isValid := time.Now().Before(c.rowCounts[testSheet].expires)
 	c.mu.Unlock()
 	if isValid {
 		t.Error("cache should start expired")
@@ -20,20 +26,19 @@ func TestRowCacheExpiration(t *testing.T) {
 
 	// Manually set cache to valid state
 	c.mu.Lock()
-	c.cachedRowCount = 10
-	c.cacheExpiresAt = time.Now().Add(c.cacheValidDuration)
+	c.rowCounts = map[string]rowCount{testSheet: {row: 10, expires: time.Now().Add(c.cacheValidDuration)}}
 	c.mu.Unlock()
 
 	// Cache should be valid now
 	c.mu.Lock()
-	isValid = time.Now().Before(c.cacheExpiresAt)
-	rowCount := c.cachedRowCount
+	isValid = time.Now().Before(c.rowCounts[testSheet].expires)
+	row := c.rowCounts[testSheet].row
 	c.mu.Unlock()
 	if !isValid {
 		t.Error("cache should be valid immediately after update")
 	}
-	if rowCount != 10 {
-		t.Errorf("cached row count should be 10, got %d", rowCount)
+	if row != 10 {
+		t.Errorf("cached row count should be 10, got %d", row)
 	}
 
 	// Wait for cache to expire
@@ -41,7 +46,7 @@ func TestRowCacheExpiration(t *testing.T) {
 
 	// Cache should be expired now
 	c.mu.Lock()
-	isValid = time.Now().Before(c.cacheExpiresAt)
+	isValid = time.Now().Before(c.rowCounts[testSheet].expires)
 	c.mu.Unlock()
 	if isValid {
 		t.Error("cache should be expired after TTL")
@@ -55,13 +60,12 @@ func TestInvalidateRowCache(t *testing.T) {
 
 	// Set cache to valid state
 	c.mu.Lock()
-	c.cachedRowCount = 42
-	c.cacheExpiresAt = time.Now().Add(c.cacheValidDuration)
+	c.rowCounts = map[string]rowCount{testSheet: {row: 42, expires: time.Now().Add(c.cacheValidDuration)}}
 	c.mu.Unlock()
 
 	// Verify cache is valid
 	c.mu.Lock()
-	isValid := time.Now().Before(c.cacheExpiresAt)
+	isValid := time.Now().Before(c.rowCounts[testSheet].expires)
 	c.mu.Unlock()
 	if !isValid {
 		t.Error("cache should be valid before invalidation")
@@ -72,7 +76,7 @@ func TestInvalidateRowCache(t *testing.T) {
 
 	// Verify cache is now expired
 	c.mu.Lock()
-	isValid = time.Now().Before(c.cacheExpiresAt)
+	isValid = time.Now().Before(c.rowCounts[testSheet].expires)
 	c.mu.Unlock()
 	if isValid {
 		t.Error("cache should be expired after invalidation")
@@ -88,12 +92,8 @@ func TestCacheInitialState(t *testing.T) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	if c.cachedRowCount != 0 {
-		t.Errorf("initial cachedRowCount should be 0, got %d", c.cachedRowCount)
-	}
-
-	if time.Now().Before(c.cacheExpiresAt) {
-		t.Error("initial cacheExpiresAt should be in the past (expired)")
+	if len(c.rowCounts) != 0 {
+		t.Errorf("initial rowCounts should be empty, got %v", c.rowCounts)
 	}
 
 	if c.cacheValidDuration != 2*time.Minute {
@@ -120,8 +120,7 @@ func TestCacheNextRowCalculation(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			c.mu.Lock()
-			c.cachedRowCount = tt.cachedRowCount
-			c.cacheExpiresAt = time.Now().Add(c.cacheValidDuration)
+			c.rowCounts = map[string]rowCount{testSheet: {row: tt.cachedRowCount, expires: time.Now().Add(c.cacheValidDuration)}}
 			c.mu.Unlock()
 
 			nextRow := tt.cachedRowCount + 1
@@ -145,8 +144,7 @@ func TestCacheMutexProtection(t *testing.T) {
 	go func() {
 		for i := 0; i < 100; i++ {
 			c.mu.Lock()
-			c.cachedRowCount = i
-			c.cacheExpiresAt = time.Now().Add(c.cacheValidDuration)
+			c.rowCounts = map[string]rowCount{testSheet: {row: i, expires: time.Now().Add(c.cacheValidDuration)}}
 			c.mu.Unlock()
 		}
 		done <- struct{}{}
@@ -156,8 +154,7 @@ func TestCacheMutexProtection(t *testing.T) {
 	go func() {
 		for i := 0; i < 100; i++ {
 			c.mu.Lock()
-			_ = c.cachedRowCount
-			_ = c.cacheExpiresAt
+			_ = c.rowCounts[testSheet]
 			c.mu.Unlock()
 		}
 		done <- struct{}{}
@@ -189,13 +186,12 @@ func TestCacheValidityCheck(t *testing.T) {
 
 	// Set cache with specific timestamp
 	c.mu.Lock()
-	c.cachedRowCount = 50
-	c.cacheExpiresAt = time.Now().Add(50 * time.Millisecond) // Expires in 50ms
+	c.rowCounts = map[string]rowCount{testSheet: {row: 50, expires: time.Now().Add(50 * time.Millisecond)}} // Expires in 50ms
 	c.mu.Unlock()
 
 	// Immediately check: should be valid
 	c.mu.Lock()
-	valid := time.Now().Before(c.cacheExpiresAt)
+	valid := time.Now().Before(c.rowCounts[testSheet].expires)
 	c.mu.Unlock()
 	if !valid {
 		t.Error("cache should be valid immediately after setting")
@@ -206,9 +202,83 @@ func TestCacheValidityCheck(t *testing.T) {
 
 	// Check again: should be expired
 	c.mu.Lock()
-	valid = time.Now().Before(c.cacheExpiresAt)
+	valid = time.Now().Before(c.rowCounts[testSheet].expires)
 	c.mu.Unlock()
 	if valid {
 		t.Error("cache should be expired after TTL")
 	}
 }
+
+func TestGetNextRowReservesRow(t *testing.T) {
+	c := &Client{cacheValidDuration: time.Minute}
+	c.rowCounts = map[string]rowCount{
+		testSheet:       {row: 10, expires: time.Now().Add(time.Minute)},
+		"2030 Expenses": {row: 3, expires: time.Now().Add(time.Minute)},
+	}
+
+	for _, want := range []int{11, 12} {
+		got, err := c.getNextRow(context.Background(), testSheet)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("next row = %d, want %d", got, want)
+		}
+	}
+	// Each sheet has its own count
+	if got, err := c.getNextRow(context.Background(), "2030 Expenses"); err != nil || got != 4 {
+		t.Errorf("next row of the other sheet = %d %v, want 4", got, err)
+	}
+	if st := c.CacheStats()[0]; st.Name != CacheRowCount || st.Hits != 3 || st.Size != 2 {
+		t.Errorf("row count stats = %+v", st)
+	}
+}
+
+func TestTaxonomyCache(t *testing.T) {
+	c := &Client{} // No service: a miss fails
+	c.readCaches.cats = []string{"Casa"}
+	c.readCaches.subs = []string{"Spesa"}
+	c.readCaches.taxonomyExpires = time.Now().Add(time.Minute)
+
+	cats, subs, err := c.List(context.Background())
+	if err != nil || len(cats) != 1 || len(subs) != 1 {
+		t.Fatalf("cached List = %v %v %v", cats, subs, err)
+	}
+	cats[0] = "Changed"
+	if c.readCaches.cats[0] != "Casa" {
+		t.Error("List returned the cached slice")
+	}
+
+	if !c.ClearCache(CacheTaxonomy) {
+		t.Fatal("taxonomy cache not cleared")
+	}
+	if _, _, err := c.List(context.Background()); err == nil {
+		t.Error("List served a cleared cache")
+	}
+	st := c.CacheStats()[1]
+	if st.Name != CacheTaxonomy || st.Hits != 1 || st.Misses != 1 || st.Size != 0 {
+		t.Errorf("taxonomy stats = %+v", st)
+	}
+	if c.ClearCache("unknown") {
+		t.Error("unknown cache reported cleared")
+	}
+}
+
+func TestOverviewCacheInvalidation(t *testing.T) {
+	c := &Client{}
+	c.readCaches.overviews = map[monthKey]cachedOverview{
+		{2025, 3}: {overview: core.MonthOverview{Year: 2025, Month: 3}, expires: time.Now().Add(time.Minute)},
+	}
+
+	ov, err := c.ReadMonthOverview(context.Background(), 2025, 3)
+	if err != nil || ov.Month != 3 {
+		t.Fatalf("cached overview = %+v %v", ov, err)
+	}
+	if st := c.CacheStats()[2]; st.Hits != 1 || st.Size != 1 {
+		t.Errorf("overview stats = %+v", st)
+	}
+	c.invalidateOverviews()
+	if _, err := c.ReadMonthOverview(context.Background(), 2025, 3); err == nil {
+		t.Error("overview served after a write")
+	}
+}
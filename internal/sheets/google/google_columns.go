@@ -0,0 +1,130 @@
+package google
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrEmptyHeader is returned when the expenses sheet has no header row to
+// check the column layout against.
+var ErrEmptyHeader = errors.New("expenses sheet header row is empty")
+
+// ExpenseColumn is a column of the expenses sheet the client reads or
+// writes, with the header names it is known by.
+type ExpenseColumn struct {
+	Letter   string
+	Name     string
+	Aliases  []string // Other accepted header names, compared ignoring case
+	Optional bool     // An empty header is fine, e.g. for the hidden ID column
+}
+
+// ExpenseColumns is the layout of the expenses sheet: rows are written to
+// and parsed from these fixed columns. E and F (Currency, EUR) are left to
+// the sheet's formulas.
+var ExpenseColumns = []ExpenseColumn{
+	{Letter: "A", Name: "Month", Aliases: []string{"Mese"}},
+	{Letter: "B", Name: "Day", Aliases: []string{"Giorno"}},
+	{Letter: "C", Name: "Expense", Aliases: []string{"Description", "Descrizione", "Spesa"}},
+	{Letter: "D", Name: "Amount", Aliases: []string{"Importo"}},
+	{Letter: "G", Name: "Primary", Aliases: []string{"Categoria", "Category", "Primary category"}},
+	{Letter: "H", Name: "Secondary", Aliases: []string{"Sottocategoria", "Subcategory", "Secondary category"}},
+	{Letter: "I", Name: "ID", Optional: true},
+	{Letter: "J", Name: "Sync key", Optional: true},
+	{Letter: "K", Name: "Checksum", Optional: true},
+}
+
+// ColumnProblem is a column of the layout the header row does not match.
+type ColumnProblem struct {
+	Column ExpenseColumn
+	Found  string // Letter of the column holding its header; empty when missing
+	Header string // What the expected column holds instead
+}
+
+func (p ColumnProblem) String() string {
+	switch {
+	case p.Found != "":
+		return fmt.Sprintf("column %s moved from %s to %s", p.Column.Name, p.Column.Letter, p.Found)
+	case p.Header != "":
+		return fmt.Sprintf("column %s missing: %s holds %q", p.Column.Name, p.Column.Letter, p.Header)
+	default:
+		return fmt.Sprintf("column %s missing: %s is empty", p.Column.Name, p.Column.Letter)
+	}
+}
+
+// matches reports whether header names the column.
+func (c ExpenseColumn) matches(header string) bool {
+	h := strings.Join(strings.Fields(strings.ToLower(header)), " ")
+	if h == strings.ToLower(c.Name) {
+		return true
+	}
+	for _, a := range c.Aliases {
+		if h == strings.ToLower(a) {
+			return true
+		}
+	}
+	return false
+}
+
+// columnLetter returns the letter of the 0-based column i (A to ZZ).
+func columnLetter(i int) string {
+	if i < 26 {
+		return string(rune('A' + i))
+	}
+	return columnLetter(i/26-1) + string(rune('A'+i%26))
+}
+
+// columnIndex returns the 0-based index of a column letter.
+func columnIndex(letter string) int {
+	i := 0
+	for _, r := range letter {
+		i = i*26 + int(r-'A') + 1
+	}
+	return i - 1
+}
+
+// CheckHeader compares a header row with the layout and returns the
+// columns whose header is not where the client writes them, telling
+// whether each moved elsewhere or is gone.
+func CheckHeader(header []string, layout []ExpenseColumn) []ColumnProblem {
+	var problems []ColumnProblem
+	for _, col := range layout {
+		cell := safeGet(header, columnIndex(col.Letter))
+		if col.matches(cell) || (col.Optional && strings.TrimSpace(cell) == "") {
+			continue
+		}
+		p := ColumnProblem{Column: col, Header: strings.TrimSpace(cell)}
+		for i, h := range header {
+			if col.matches(h) {
+				p.Found = columnLetter(i)
+				break
+			}
+		}
+		problems = append(problems, p)
+	}
+	return problems
+}
+
+// CheckExpenseHeader reads the header row of the current expenses sheet
+// and checks it against ExpenseColumns, so a reorganized sheet is caught
+// before categories are written into the wrong columns.
+func (c *Client) CheckExpenseHeader(ctx context.Context) ([]ColumnProblem, error) {
+	if c.svc == nil {
+		return nil, errors.New("sheets service not initialized")
+	}
+	rng := fmt.Sprintf("%s!1:1", c.expensesSheet)
+	resp, err := c.svc.Spreadsheets.Values.Get(c.spreadsheetID, rng).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", rng, apiError(err))
+	}
+	if len(resp.Values) == 0 || len(resp.Values[0]) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrEmptyHeader, c.expensesSheet)
+	}
+	return CheckHeader(toStrings(resp.Values[0]), ExpenseColumns), nil
+}
+
+// ExpensesSheet returns the name of the expenses sheet of the current year.
+func (c *Client) ExpensesSheet() string {
+	return c.expensesSheet
+}
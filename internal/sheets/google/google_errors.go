@@ -0,0 +1,40 @@
+package google
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	ports "spese/internal/sheets"
+
+	"golang.org/x/oauth2"
+	"google.golang.org/api/googleapi"
+)
+
+// apiError marks the error of a Sheets request with the ports error of its
+// class, so callers can pick a retry policy: quota (429) errors are
+// retried later without counting a failure, rejected credentials wait for
+// a fix, missing resources and refused requests are not retried. Other
+// errors are returned as they are.
+func apiError(err error) error {
+	var retrieveErr *oauth2.RetrieveError
+	if errors.As(err, &retrieveErr) {
+		// invalid_grant and friends: the token can no longer be refreshed
+		return fmt.Errorf("%w: %w", ports.ErrUnauthorized, err)
+	}
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+	switch apiErr.Code {
+	case http.StatusTooManyRequests:
+		return fmt.Errorf("%w: %w", ports.ErrRateLimited, err)
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("%w: %w", ports.ErrUnauthorized, err)
+	case http.StatusNotFound:
+		return fmt.Errorf("%w: %w", ports.ErrNotFound, err)
+	case http.StatusBadRequest:
+		return fmt.Errorf("%w: %w", ports.ErrInvalid, err)
+	}
+	return err
+}
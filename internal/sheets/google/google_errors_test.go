@@ -0,0 +1,40 @@
+package google
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	ports "spese/internal/sheets"
+
+	"golang.org/x/oauth2"
+	"google.golang.org/api/googleapi"
+)
+
+func TestAPIError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ports.ErrorClass
+	}{
+		{"quota", fmt.Errorf("update: %w", &googleapi.Error{Code: 429, Message: "Quota exceeded"}), ports.ErrorQuota},
+		{"unauthenticated", &googleapi.Error{Code: 401}, ports.ErrorAuth},
+		{"permission denied", &googleapi.Error{Code: 403, Message: "Permission denied"}, ports.ErrorAuth},
+		{"expired grant", fmt.Errorf("get: %w", &oauth2.RetrieveError{ErrorCode: "invalid_grant"}), ports.ErrorAuth},
+		{"missing sheet", &googleapi.Error{Code: 404}, ports.ErrorNotFound},
+		{"bad range", &googleapi.Error{Code: 400}, ports.ErrorValidation},
+		{"server error", &googleapi.Error{Code: 503}, ports.ErrorTransient},
+		{"network", errors.New("connection reset"), ports.ErrorTransient},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := apiError(tt.err)
+			if got := ports.Classify(err); got != tt.want {
+				t.Errorf("class = %s, want %s", got, tt.want)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Errorf("original error lost: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package google
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Default write budget, below the Sheets API quota of 60 write requests per
+// minute per user so that reads and other tools keep some room
+const (
+	DefaultWriteLimit  = 50
+	DefaultWriteWindow = 100 * time.Second
+)
+
+// WriteLimiter spaces out write requests to the Sheets API: at most limit
+// writes in any window, refilled continuously. One limiter is shared by all
+// clients using the same credentials, since Google counts the quota per
+// project and user, not per spreadsheet.
+type WriteLimiter struct {
+	mu       sync.Mutex
+	limit    float64
+	interval time.Duration // Time to earn back one write
+	tokens   float64
+	last     time.Time
+	now      func() time.Time
+}
+
+// NewWriteLimiter allows limit writes per window. A limit of 0 or less
+// returns nil, which never waits.
+func NewWriteLimiter(limit int, window time.Duration) *WriteLimiter {
+	if limit <= 0 || window <= 0 {
+		return nil
+	}
+	return &WriteLimiter{
+		limit:    float64(limit),
+		interval: window / time.Duration(limit),
+		tokens:   float64(limit),
+		now:      time.Now,
+	}
+}
+
+// refill adds the writes earned since the last call; callers hold the lock
+func (l *WriteLimiter) refill() {
+	now := l.now()
+	if !l.last.IsZero() {
+		l.tokens += float64(now.Sub(l.last)) / float64(l.interval)
+		if l.tokens > l.limit {
+			l.tokens = l.limit
+		}
+	}
+	l.last = now
+}
+
+// reserve takes a write and returns how long to wait before making it
+func (l *WriteLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill()
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens * float64(l.interval))
+}
+
+// Wait blocks until a write may be made, or ctx is done.
+func (l *WriteLimiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	delay := l.reserve()
+	if delay == 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// Give the write back: it was never made
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// Available returns how many writes can be made right now without waiting.
+func (l *WriteLimiter) Available() int {
+	if l == nil {
+		return -1
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill()
+	if l.tokens < 0 {
+		return 0
+	}
+	return int(l.tokens)
+}
+
+// SetWriteLimiter makes every write of the client, and of the clients it
+// creates with ForSpreadsheet afterwards, wait for the limiter.
+func (c *Client) SetWriteLimiter(l *WriteLimiter) {
+	c.writes = l
+}
+
+// WritesAvailable implements ports.WriteThrottle: the writes that can be
+// made now, -1 when unlimited.
+func (c *Client) WritesAvailable() int {
+	return c.writes.Available()
+}
+
+// waitWrite waits for the write budget before a write request
+func (c *Client) waitWrite(ctx context.Context) error {
+	if err := c.writes.Wait(ctx); err != nil {
+		return fmt.Errorf("wait for the sheets write budget: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package google
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWriteLimiter(t *testing.T) {
+	now := time.Date(2031, 3, 5, 12, 0, 0, 0, time.UTC)
+	l := NewWriteLimiter(5, 10*time.Second)
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 5; i++ {
+		if err := l.Wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := l.Available(); got != 0 {
+		t.Fatalf("available after the whole budget = %d, want 0", got)
+	}
+
+	// One write is earned back every window/limit
+	now = now.Add(4 * time.Second)
+	if got := l.Available(); got != 2 {
+		t.Errorf("available after 4s = %d, want 2", got)
+	}
+	now = now.Add(time.Hour)
+	if got := l.Available(); got != 5 {
+		t.Errorf("available after an hour = %d, want the limit 5", got)
+	}
+
+	// A write over budget waits until ctx gives up, and is given back
+	for i := 0; i < 5; i++ {
+		_ = l.Wait(context.Background())
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("wait over budget = %v, want deadline exceeded", err)
+	}
+	if got := l.Available(); got != 0 {
+		t.Errorf("available after a cancelled wait = %d, want 0", got)
+	}
+
+	var unlimited *WriteLimiter
+	if NewWriteLimiter(0, time.Minute) != nil || unlimited.Wait(context.Background()) != nil || unlimited.Available() != -1 {
+		t.Error("a zero limit must not limit")
+	}
+}
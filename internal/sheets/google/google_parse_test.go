@@ -56,3 +56,248 @@ func TestParseDashboard_Example2025_July(t *testing.T) {
 		t.Fatalf("Groceries cents got %d", got)
 	}
 }
+
+func TestParseEurosToCents(t *testing.T) {
+	cases := []struct {
+		in  string
+		out int64
+		ok  bool
+	}{
+		{"12.34", 1234, true},
+		{"12,34", 1234, true},
+		{"-12.34", -1234, true},
+		{"-0.125", -12, true}, // tie rounds to even, not towards +inf
+		{"0.135", 14, true},
+		{"1.005", 100, true},
+		{"1e3", 100000, true},
+		{"", 0, false},
+		{"abc", 0, false},
+	}
+	for _, tc := range cases {
+		got, ok := parseEurosToCents(tc.in)
+		if ok != tc.ok || got != tc.out {
+			t.Fatalf("%q expected (%d, %v), got (%d, %v)", tc.in, tc.out, tc.ok, got, ok)
+		}
+	}
+}
+
+func TestFormatAmount(t *testing.T) {
+	cases := []struct {
+		cents  int64
+		format AmountFormat
+		out    string
+	}{
+		{1234, AmountFormatDot, "12.34"},
+		{1234, AmountFormatComma, "12,34"},
+		{1234, AmountFormatCents, "1234"},
+		{5, AmountFormatDot, "0.05"},
+		{100, AmountFormatComma, "1,00"},
+		{-1205, AmountFormatDot, "-12.05"},
+		{1234, "", "12.34"},
+	}
+	for _, tc := range cases {
+		if got := formatAmount(tc.cents, tc.format); got != tc.out {
+			t.Fatalf("formatAmount(%d, %q) expected %q, got %q", tc.cents, tc.format, tc.out, got)
+		}
+		// Round-trip through the reader for the same format
+		c := &Client{amountFormat: tc.format}
+		if back, ok := c.parseAmountCell(tc.out); !ok || back != tc.cents {
+			t.Fatalf("parseAmountCell(%q, %q) expected %d, got %d (ok=%v)", tc.out, tc.format, tc.cents, back, ok)
+		}
+	}
+}
+
+func TestParseAmountFormat(t *testing.T) {
+	cases := []struct {
+		in  string
+		out AmountFormat
+		ok  bool
+	}{
+		{"", AmountFormatDot, true},
+		{"dot", AmountFormatDot, true},
+		{" Comma ", AmountFormatComma, true},
+		{"CENTS", AmountFormatCents, true},
+		{"float", "", false},
+	}
+	for _, tc := range cases {
+		got, err := ParseAmountFormat(tc.in)
+		if (err == nil) != tc.ok || got != tc.out {
+			t.Fatalf("%q expected (%q, ok=%v), got (%q, err=%v)", tc.in, tc.out, tc.ok, got, err)
+		}
+	}
+}
+
+func TestParseAmountRows(t *testing.T) {
+	c := &Client{amountFormat: AmountFormatComma}
+	values := [][]interface{}{
+		{"Month", "Day", "Expense", "Amount", "Currency", "EUR", "Primary", "Secondary", "ID"},
+		{"3", "10", "Spesa", "12,34", "", "", "Casa", "Affitto", "42"},
+		{"3", "11", "Senza ID", "5,00", "", "", "Casa", "Affitto"},
+		{"3", "12", "ID non valido", "5,00", "", "", "Casa", "Affitto", "abc"},
+		{"3", "13", "Altra", "-1,50", "", "", "Casa", "Affitto", "43"},
+	}
+	rows := c.parseAmountRows(values)
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 rows, got %d: %+v", len(rows), rows)
+	}
+	if rows[0].ID != 42 || rows[0].Row != 2 || rows[0].Cents != 1234 {
+		t.Fatalf("unexpected first row: %+v", rows[0])
+	}
+	if rows[1].ID != 43 || rows[1].Row != 5 || rows[1].Cents != -150 {
+		t.Fatalf("unexpected second row: %+v", rows[1])
+	}
+}
+
+func TestParseSyncKeys(t *testing.T) {
+	values := [][]interface{}{
+		{"Sync key"},
+		{"42v1"},
+		{},
+		{" 43v2 "},
+		{"note"},
+		{"42v1"},
+		{"v3"},
+	}
+	rows := parseSyncKeys(values)
+	if len(rows) != 2 || rows["42v1"] != 2 || rows["43v2"] != 4 {
+		t.Fatalf("unexpected keys: %v", rows)
+	}
+}
+
+func TestParseEdits(t *testing.T) {
+	c := &Client{amountFormat: AmountFormatComma, year: 2026}
+	sum := func(month, day int, desc string, cents int64, primary, secondary string) string {
+		return rowChecksum(month, day, desc, cents, primary, secondary)
+	}
+	values := [][]interface{}{
+		{"Month", "Day", "Expense", "Amount", "Currency", "EUR", "Primary", "Secondary", "ID", "Sync key", "Checksum"},
+		{"1", "2", "Prima della colonna", "5,00", "", "", "Casa", "Affitto"},
+		{"1", "3", "Vecchia riga [ts:1]", "5,00", "", "", "Casa", "Affitto", "40"},
+		{"1", "5", "Intatta [ts:2]", "12,34", "", "", "Spesa", "Supermercato", "41", "41v1", sum(1, 5, "Intatta [ts:2]", 1234, "Spesa", "Supermercato")},
+		{"1", "6", "Modificata [ts:3]", "15,00", "", "", "Spesa", "Supermercato", "42", "42v1", sum(1, 6, "Modificata [ts:3]", 1234, "Spesa", "Supermercato")},
+		{"1", "7", "Versione vecchia [ts:4]", "9,00", "", "", "Casa", "Mutuo", "43", "43v1", "hvecchio"},
+		{"1", "8", "Versione nuova [ts:5]", "9,00", "", "", "Casa", "Mutuo", "43", "43v2", sum(1, 8, "Versione nuova [ts:5]", 900, "Casa", "Mutuo")},
+		{"2", "1", "Aggiunta a mano", "7,50", "", "", "Svago", "Cinema"},
+		{"2", "2", "Scritta dalla sync [ts:6]", "1,00", "", "", "Svago", "Cinema"},
+		{"", "", "", "", "", "", ""},
+	}
+
+	edits := c.parseEdits(values)
+	if len(edits) != 2 {
+		t.Fatalf("expected 2 edits, got %d: %+v", len(edits), edits)
+	}
+	edited := edits[0]
+	if edited.ID != 42 || edited.Row != 5 || edited.Expense.Amount.Cents != 1500 || edited.Expense.Description != "Modificata" {
+		t.Fatalf("unexpected edited row: %+v", edited)
+	}
+	if edited.Checksum != sum(1, 6, "Modificata [ts:3]", 1500, "Spesa", "Supermercato") {
+		t.Fatalf("checksum of the edited row not recomputed: %+v", edited)
+	}
+	added := edits[1]
+	if added.ID != 0 || added.Row != 8 || added.Expense.Date.Year() != 2026 || added.Expense.Date.Month() != 2 || added.Expense.Primary != "Svago" {
+		t.Fatalf("unexpected added row: %+v", added)
+	}
+}
+
+func TestParseExpenseRows(t *testing.T) {
+	c := &Client{amountFormat: AmountFormatComma}
+	values := [][]interface{}{
+		{"Month", "Day", "Expense", "Amount", "Currency", "EUR", "Primary", "Secondary"},
+		{"1", "5", "Spesa", "12,34", "", "", "Spesa", "Supermercato"},
+		{"3", "10", "Benzina", "40,00", "", "", "Trasporti"},
+		{"13", "1", "Mese non valido", "1,00", "", "", "Casa", "Mutuo"},
+		{"4", "2", "Importo non valido", "abc", "", "", "Casa", "Mutuo"},
+		{"", "", "", "0", "", "", ""},
+	}
+
+	all := c.parseExpenseRows(values, 2019, 0)
+	if len(all) != 2 {
+		t.Fatalf("expected 2 expenses, got %d: %+v", len(all), all)
+	}
+	if all[0].Date.Year() != 2019 || all[0].Date.Month() != 1 || all[0].Date.Day() != 5 || all[0].Amount.Cents != 1234 || all[0].Secondary != "Supermercato" {
+		t.Fatalf("unexpected first expense: %+v", all[0])
+	}
+	if all[1].Primary != "Trasporti" || all[1].Secondary != "" {
+		t.Fatalf("unexpected second expense: %+v", all[1])
+	}
+
+	march := c.parseExpenseRows(values, 2019, 3)
+	if len(march) != 1 || march[0].Description != "Benzina" {
+		t.Fatalf("expected only the March expense, got %+v", march)
+	}
+}
+
+func TestExpenseSheetYears(t *testing.T) {
+	titles := []string{"2024 Expenses", "Dashboard", "2022 Expenses", "2024 Dashboard", "Expenses", "2023 Expenses old"}
+	got := expenseSheetYears(titles, trimYearPrefix("2025 Expenses"))
+	if len(got) != 2 || got[0] != 2022 || got[1] != 2024 {
+		t.Fatalf("expected [2022 2024], got %v", got)
+	}
+}
+
+func TestCheckHeader(t *testing.T) {
+	documented := []string{"Month", "Day", "Expense", "Amount", "Currency", "EUR", "Primary", "Secondary"}
+	if got := CheckHeader(documented, ExpenseColumns); len(got) != 0 {
+		t.Fatalf("documented layout: unexpected problems %v", got)
+	}
+	italian := []string{"mese", "GIORNO", "Descrizione", " Importo ", "", "", "Categoria", "Sottocategoria", "ID"}
+	if got := CheckHeader(italian, ExpenseColumns); len(got) != 0 {
+		t.Fatalf("italian headers: unexpected problems %v", got)
+	}
+
+	// A "Note" column inserted before the categories shifts them right
+	shifted := []string{"Month", "Day", "Expense", "Amount", "Currency", "EUR", "Note", "Primary", "Secondary"}
+	got := CheckHeader(shifted, ExpenseColumns)
+	want := []string{
+		"column Primary moved from G to H",
+		"column Secondary moved from H to I",
+		`column ID missing: I holds "Secondary"`,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("shifted: got %v, want %v", got, want)
+	}
+	for i, p := range got {
+		if p.String() != want[i] {
+			t.Errorf("problem %d = %q, want %q", i, p.String(), want[i])
+		}
+	}
+
+	if got := CheckHeader([]string{"Month", "Day"}, ExpenseColumns); len(got) != 4 || got[0].String() != "column Expense missing: C is empty" {
+		t.Errorf("short header: got %v", got)
+	}
+}
+
+func TestColumnLetter(t *testing.T) {
+	for i, want := range map[int]string{0: "A", 8: "I", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ"} {
+		if got := columnLetter(i); got != want {
+			t.Errorf("columnLetter(%d) = %s, want %s", i, got, want)
+		}
+		if got := columnIndex(want); got != i {
+			t.Errorf("columnIndex(%s) = %d, want %d", want, got, i)
+		}
+	}
+}
+
+func TestRecentRows(t *testing.T) {
+	values := [][]interface{}{
+		{1, 2, "Pane", 1.5, "", "", "Casa", "Spesa", "10"},
+		{1, 3, "Latte", 2},
+		{},
+		{1, 4, "Cinema", 9, "", "", "Svago", "Cinema", "12"},
+		{"", "", ""},
+	}
+	got := recentRows(values, 2)
+	if len(got) != 2 || got[0][2] != "Latte" || got[1][2] != "Cinema" {
+		t.Fatalf("recentRows(2) = %v, want Latte and Cinema in sheet order", got)
+	}
+	all := recentRows(values, 10)
+	if len(all) != 3 {
+		t.Fatalf("recentRows(10) returned %d rows, want the 3 with a description", len(all))
+	}
+
+	// Short rows are padded, so the G:I block stays rectangular
+	tail := columns(all, 6, 9)
+	if len(tail[1]) != 3 || tail[1][0] != "" || tail[2][2] != "12" {
+		t.Errorf("columns(6, 9) = %v", tail)
+	}
+}
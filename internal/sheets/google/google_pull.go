@@ -0,0 +1,143 @@
+package google
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"spese/internal/core"
+	ports "spese/internal/sheets"
+
+	gsheet "google.golang.org/api/sheets/v4"
+)
+
+// rowChecksum fingerprints the values of an expenses sheet row as the sync
+// wrote them. The "h" keeps spreadsheets from reading it as a number.
+func rowChecksum(month, day int, description string, cents int64, primary, secondary string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d|%d|%s|%d|%s|%s",
+		month, day, strings.TrimSpace(description), cents, strings.TrimSpace(primary), strings.TrimSpace(secondary))))
+	return "h" + hex.EncodeToString(sum[:8])
+}
+
+// trimSyncTimestamp removes the " [ts:<ms>]" suffix the sync adds to the
+// descriptions it writes.
+func trimSyncTimestamp(description string) string {
+	if i := strings.LastIndex(description, " [ts:"); i >= 0 && strings.HasSuffix(description, "]") {
+		return description[:i]
+	}
+	return description
+}
+
+// ListEdits implements ports.EditPuller
+func (c *Client) ListEdits(ctx context.Context) ([]ports.SheetEdit, error) {
+	if c.svc == nil {
+		return nil, errors.New("sheets service not initialized")
+	}
+	rng := fmt.Sprintf("%s!A:K", c.expensesSheet)
+	resp, err := c.svc.Spreadsheets.Values.Get(c.spreadsheetID, rng).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", rng, apiError(err))
+	}
+	return c.parseEdits(resp.Values), nil
+}
+
+// parseEdits finds the edited and added rows of raw A:K values. Rows with
+// an ID but no checksum were written before the checksum column and have
+// nothing to compare with; of the rows sharing an ID, which an expense
+// edited in the app leaves behind, only the last one counts. Rows without
+// an ID are added by hand when they follow the first checksummed row and
+// lack the sync timestamp: the earlier ones predate the column.
+func (c *Client) parseEdits(values [][]interface{}) []ports.SheetEdit {
+	last := make(map[int64]int)
+	for i, row := range values {
+		cols := toStrings(row)
+		if len(cols) < 9 {
+			continue
+		}
+		if id, err := strconv.ParseInt(strings.TrimSpace(cols[8]), 10, 64); err == nil && id > 0 {
+			last[id] = i
+		}
+	}
+
+	var out []ports.SheetEdit
+	tracked := false
+	for i, row := range values {
+		cols := toStrings(row)
+		for len(cols) < 11 {
+			cols = append(cols, "")
+		}
+		month, err := strconv.Atoi(strings.TrimSpace(cols[0]))
+		if err != nil || month < 1 || month > 12 {
+			// Header or blank row
+			continue
+		}
+		day, err := strconv.Atoi(strings.TrimSpace(cols[1]))
+		if err != nil || day < 1 || day > 31 {
+			continue
+		}
+		cents, ok := c.parseAmountCell(cols[3])
+		if !ok {
+			continue
+		}
+		desc := strings.TrimSpace(cols[2])
+		primary := strings.TrimSpace(cols[6])
+		secondary := strings.TrimSpace(cols[7])
+		edit := ports.SheetEdit{
+			Row: i + 1,
+			Expense: core.Expense{
+				Date:        core.NewDate(c.year, month, day),
+				Description: trimSyncTimestamp(desc),
+				Amount:      core.Money{Cents: cents},
+				Primary:     primary,
+				Secondary:   secondary,
+			},
+			Checksum: rowChecksum(month, day, desc, cents, primary, secondary),
+		}
+
+		stored := strings.TrimSpace(cols[10])
+		id, err := strconv.ParseInt(strings.TrimSpace(cols[8]), 10, 64)
+		if err == nil && id > 0 {
+			if stored == "" {
+				continue
+			}
+			tracked = true
+			if last[id] != i || stored == edit.Checksum {
+				continue
+			}
+			edit.ID = id
+			out = append(out, edit)
+			continue
+		}
+		if !tracked || desc != edit.Expense.Description || (desc == "" && cents == 0) {
+			continue
+		}
+		out = append(out, edit)
+	}
+	return out
+}
+
+// AckEdit implements ports.EditPuller, writing the ID, sync key and
+// checksum to I:K of the row. The row is addressed by number, so a sheet
+// sorted between ListEdits and AckEdit tags the wrong row.
+func (c *Client) AckEdit(ctx context.Context, edit ports.SheetEdit, id, version int64) error {
+	if c.svc == nil {
+		return errors.New("sheets service not initialized")
+	}
+	if edit.Row < 1 {
+		return fmt.Errorf("invalid row: %d", edit.Row)
+	}
+	rng := fmt.Sprintf("%s!I%d:K%d", c.expensesSheet, edit.Row, edit.Row)
+	vr := &gsheet.ValueRange{Values: [][]any{{strconv.FormatInt(id, 10), ports.SyncKey(id, version), edit.Checksum}}}
+	if err := c.waitWrite(ctx); err != nil {
+		return err
+	}
+	if _, err := c.svc.Spreadsheets.Values.Update(c.spreadsheetID, rng, vr).
+		ValueInputOption("USER_ENTERED").Context(ctx).Do(); err != nil {
+		return fmt.Errorf("update %s: %w", rng, apiError(err))
+	}
+	return nil
+}
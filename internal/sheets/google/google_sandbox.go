@@ -0,0 +1,142 @@
+package google
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	ports "spese/internal/sheets"
+
+	gsheet "google.golang.org/api/sheets/v4"
+)
+
+var _ ports.SandboxCopier = (*Sandbox)(nil)
+
+// ForSpreadsheet returns a client for another spreadsheet with the same
+// credentials, sheet names and amount format, e.g. a sandbox copy of the
+// production one.
+func (c *Client) ForSpreadsheet(spreadsheetID string) *Client {
+	return &Client{
+		svc:                c.svc,
+		spreadsheetID:      spreadsheetID,
+		expensesSheet:      c.expensesSheet,
+		year:               c.year,
+		expensesBase:       c.expensesBase,
+		categoriesSheet:    c.categoriesSheet,
+		subcategoriesSheet: c.subcategoriesSheet,
+		dashboardBase:      c.dashboardBase,
+		dashboardPrefix:    c.dashboardPrefix,
+		amountFormat:       c.amountFormat,
+		writes:             c.writes,
+		cacheValidDuration: c.cacheValidDuration,
+	}
+}
+
+// SpreadsheetID returns the ID of the spreadsheet the client works on.
+func (c *Client) SpreadsheetID() string {
+	return c.spreadsheetID
+}
+
+// Sandbox pairs the production spreadsheet with the sandbox one the sync
+// writes to while sync changes are tested.
+type Sandbox struct {
+	prod    *Client
+	sandbox *Client
+}
+
+// NewSandbox creates the sandbox of prod in the spreadsheet spreadsheetID,
+// which needs an expenses sheet with the same name.
+func NewSandbox(prod *Client, spreadsheetID string) *Sandbox {
+	return &Sandbox{prod: prod, sandbox: prod.ForSpreadsheet(spreadsheetID)}
+}
+
+// Client returns the client of the sandbox spreadsheet.
+func (s *Sandbox) Client() *Client {
+	return s.sandbox
+}
+
+// CopyRecentRows appends the last n rows of the production expenses sheet
+// to the sandbox's, columns A:D and G:I as the sync writes them, so its
+// formulas compute E and F. Production is only read.
+func (s *Sandbox) CopyRecentRows(ctx context.Context, n int) (int, error) {
+	if s.prod.svc == nil {
+		return 0, errors.New("sheets service not initialized")
+	}
+	if n <= 0 {
+		return 0, nil
+	}
+
+	// Unformatted values round-trip: amounts come back as numbers, not "€ 1,00"
+	rng := fmt.Sprintf("%s!A2:I", s.prod.expensesSheet)
+	resp, err := s.prod.svc.Spreadsheets.Values.Get(s.prod.spreadsheetID, rng).
+		ValueRenderOption("UNFORMATTED_VALUE").Context(ctx).Do()
+	if err != nil {
+		return 0, fmt.Errorf("read %s: %w", rng, err)
+	}
+	rows := recentRows(resp.Values, n)
+	if len(rows) == 0 {
+		return 0, nil
+	}
+
+	dst := s.sandbox
+	nextRow, err := dst.getNextRow(ctx, dst.expensesSheet)
+	if err != nil {
+		return 0, err
+	}
+	last := nextRow + len(rows) - 1
+	data := []*gsheet.ValueRange{
+		{Range: fmt.Sprintf("%s!A%d:D%d", dst.expensesSheet, nextRow, last), Values: columns(rows, 0, 4)},
+		{Range: fmt.Sprintf("%s!G%d:I%d", dst.expensesSheet, nextRow, last), Values: columns(rows, 6, 9)},
+	}
+	if err := dst.waitWrite(ctx); err != nil {
+		return 0, err
+	}
+	_, err = dst.svc.Spreadsheets.Values.BatchUpdate(dst.spreadsheetID, &gsheet.BatchUpdateValuesRequest{
+		ValueInputOption: "USER_ENTERED",
+		Data:             data,
+	}).Context(ctx).Do()
+	dst.InvalidateRowCache()
+	if err != nil {
+		return 0, fmt.Errorf("write rows %d-%d of the sandbox %s: %w", nextRow, last, dst.expensesSheet, apiError(err))
+	}
+	return len(rows), nil
+}
+
+// recentRows returns the last n rows with a description, skipping the
+// blank rows a sheet keeps below or between its data.
+func recentRows(values [][]interface{}, n int) [][]interface{} {
+	var rows [][]interface{}
+	for i := len(values) - 1; i >= 0 && len(rows) < n; i-- {
+		if strings.TrimSpace(fmt.Sprint(cell(values[i], 2))) == "" {
+			continue
+		}
+		rows = append(rows, values[i])
+	}
+	// Back to sheet order
+	for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+		rows[i], rows[j] = rows[j], rows[i]
+	}
+	return rows
+}
+
+// columns returns the cells from..to-1 of every row, empty where a row is
+// shorter.
+func columns(rows [][]interface{}, from, to int) [][]interface{} {
+	out := make([][]interface{}, len(rows))
+	for i, row := range rows {
+		out[i] = make([]interface{}, 0, to-from)
+		for j := from; j < to; j++ {
+			out[i] = append(out[i], cell(row, j))
+		}
+	}
+	return out
+}
+
+// cell returns the j-th cell of a row, "" past its end.
+func cell(row []interface{}, j int) interface{} {
+	if j < len(row) {
+		return row[j]
+	}
+	return ""
+}
@@ -0,0 +1,150 @@
+package google
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"spese/internal/core"
+
+	goption "google.golang.org/api/option"
+	gsheet "google.golang.org/api/sheets/v4"
+)
+
+// fakeSheets serves the values API of one spreadsheet: reads return the
+// rows of the sheet of the range, writes are recorded by range.
+type fakeSheets struct {
+	mu     sync.Mutex
+	rows   map[string]int // Rows in each sheet
+	reads  []string       // Ranges read, in order
+	writes []string       // Ranges written, in order
+}
+
+func (f *fakeSheets) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	_, rng, ok := strings.Cut(r.URL.Path, "/values")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	rng = strings.TrimPrefix(rng, "/")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.Method == http.MethodGet:
+		f.reads = append(f.reads, rng)
+		sheet, _, _ := strings.Cut(rng, "!")
+		values := make([][]any, f.rows[sheet])
+		for i := range values {
+			values[i] = []any{""}
+		}
+		json.NewEncoder(w).Encode(gsheet.ValueRange{Range: rng, Values: values})
+		return
+	case r.Method == http.MethodPut:
+		f.writes = append(f.writes, rng)
+	case rng == ":batchUpdate":
+		var req gsheet.BatchUpdateValuesRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for _, d := range req.Data {
+			f.writes = append(f.writes, d.Range)
+		}
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	w.Write([]byte("{}"))
+}
+
+// newFakeClient returns a client of year whose API requests go to f.
+func newFakeClient(t *testing.T, f *fakeSheets, year int) *Client {
+	t.Helper()
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	svc, err := gsheet.NewService(context.Background(),
+		goption.WithHTTPClient(srv.Client()), goption.WithEndpoint(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Client{
+		svc:                svc,
+		spreadsheetID:      "sheet-id",
+		expensesSheet:      yearPrefixedName("Expenses", year),
+		expensesBase:       "Expenses",
+		year:               year,
+		amountFormat:       AmountFormatDot,
+		cacheValidDuration: time.Minute,
+	}
+}
+
+func TestAppendWritesToSheetOfExpenseYear(t *testing.T) {
+	f := &fakeSheets{rows: map[string]int{"2031 Expenses": 5, "2030 Expenses": 40}}
+	c := newFakeClient(t, f, 2031)
+	ctx := context.Background()
+	expense := func(year int) core.Expense {
+		return core.Expense{
+			Date:        core.NewDate(year, 12, 31),
+			Description: "Cenone",
+			Amount:      core.Money{Cents: 4500},
+			Primary:     "Casa",
+			Secondary:   "Spesa",
+		}
+	}
+
+	ref, err := c.Append(ctx, expense(2030))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ref != "2030 Expenses!A41:H41" {
+		t.Errorf("ref = %q, want the next row of the 2030 sheet", ref)
+	}
+	if _, _, err := c.AppendOnce(ctx, 7, 1, expense(2030)); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := c.AppendSplitOnce(ctx, 8, 1, []core.Expense{expense(2030), expense(2030)}); err != nil {
+		t.Fatal(err)
+	}
+	// The row cache of one sheet does not leak into the other
+	if ref, err := c.AppendWithID(ctx, 9, expense(2031)); err != nil || ref != "2031 Expenses!A6:H6" {
+		t.Errorf("current year ref = %q %v, want the next row of the 2031 sheet", ref, err)
+	}
+
+	want := []string{
+		"2030 Expenses!A41:D41", "2030 Expenses!G41:H41",
+		"2030 Expenses!A42:D42", "2030 Expenses!G42:K42",
+		"2030 Expenses!A43:D44", "2030 Expenses!G43:K44",
+		"2031 Expenses!A6:D6", "2031 Expenses!G6:K6",
+	}
+	if strings.Join(f.writes, ",") != strings.Join(want, ",") {
+		t.Errorf("writes = %q\nwant %q", f.writes, want)
+	}
+}
+
+func TestReconcileReadsSheetOfYear(t *testing.T) {
+	f := &fakeSheets{}
+	c := newFakeClient(t, f, 2031)
+	ctx := context.Background()
+
+	if _, err := c.ListAmountsByID(ctx, 2030); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.UpdateAmount(ctx, 2030, 7, 1000); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.ListAmountsByID(ctx, 2031); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(f.reads, ","); got != "2030 Expenses!A:I,2031 Expenses!A:I" {
+		t.Errorf("reads = %s", got)
+	}
+	if got := strings.Join(f.writes, ","); got != "2030 Expenses!D7" {
+		t.Errorf("writes = %s", got)
+	}
+}
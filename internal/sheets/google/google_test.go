@@ -144,7 +144,7 @@ func TestNewSheetsService_MissingServiceAccount(t *testing.T) {
 		os.Unsetenv(k)
 	}
 
-	_, err := newSheetsService(context.Background())
+	_, err := newSheetsService(context.Background(), nil)
 	if err == nil {
 		t.Fatal("expected error for missing service account")
 	}
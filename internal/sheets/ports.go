@@ -2,21 +2,161 @@ package sheets
 
 import (
 	"context"
+	"errors"
+	"strconv"
+
 	"spese/internal/core"
 )
 
+// ErrRateLimited marks a write refused because the API quota is used up;
+// it succeeds when retried later.
+var ErrRateLimited = errors.New("sheets write rate limited")
+
+var (
+	ErrUnauthorized = errors.New("sheets credentials rejected") // Expired, revoked or lacking access
+	ErrNotFound     = errors.New("sheets resource not found")   // Spreadsheet, sheet or row missing
+	ErrInvalid      = errors.New("sheets request invalid")      // Data or request the API refuses
+)
+
+// ErrorClass groups the errors of a sheets write by how the write should be
+// retried.
+type ErrorClass string
+
+const (
+	ErrorQuota      ErrorClass = "quota"      // Retry later without counting an attempt
+	ErrorAuth       ErrorClass = "auth"       // Retry once the credentials are fixed
+	ErrorNotFound   ErrorClass = "not_found"  // Retrying finds nothing more
+	ErrorValidation ErrorClass = "validation" // Retrying sends the same bad data
+	ErrorTransient  ErrorClass = "transient"  // Network, server and unknown errors: retry with backoff
+)
+
+// ErrorClasses lists the classes in the order reports show them.
+var ErrorClasses = []ErrorClass{ErrorQuota, ErrorAuth, ErrorNotFound, ErrorValidation, ErrorTransient}
+
+// Classify returns the class of a sheets error, ErrorTransient for errors
+// the adapters did not mark.
+func Classify(err error) ErrorClass {
+	switch {
+	case errors.Is(err, ErrRateLimited):
+		return ErrorQuota
+	case errors.Is(err, ErrUnauthorized):
+		return ErrorAuth
+	case errors.Is(err, ErrNotFound):
+		return ErrorNotFound
+	case errors.Is(err, ErrInvalid):
+		return ErrorValidation
+	default:
+		return ErrorTransient
+	}
+}
+
+// CacheStats are the counters of a server-side cache
+type CacheStats struct {
+	Name   string
+	Hits   uint64
+	Misses uint64 // Lookups that went to the sheet
+	Size   int    // Entries held now
+}
+
+// CacheReporter is implemented by adapters caching what they read, so the
+// caches can be monitored and cleared when they serve stale data.
+type CacheReporter interface {
+	CacheStats() []CacheStats
+	// ClearCache empties the named cache, reporting false for an unknown
+	// name
+	ClearCache(name string) bool
+}
+
 // ExpenseWithID represents an expense with its storage ID
 type ExpenseWithID struct {
 	ID      string
 	Expense core.Expense
 }
 
+// SheetAmountRow is an expenses sheet row that carries the storage ID in its
+// hidden ID column, used to compare amounts against the database.
+type SheetAmountRow struct {
+	ID    int64 // Storage ID read from the hidden column
+	Row   int   // 1-based sheet row number
+	Cents int64 // Amount currently in the sheet
+}
+
+// SheetEdit is a row of the expenses sheet changed by hand since the sync
+// wrote it, or added by hand.
+type SheetEdit struct {
+	Row      int          // 1-based sheet row number
+	ID       int64        // Storage ID of the row, 0 for a row added in the sheet
+	Expense  core.Expense // Values in the sheet; the year is the sheet's
+	Checksum string       // Checksum of the values, stored once the edit is applied
+}
+
+// SyncKey is the idempotency token of an expense version in the sheet. The
+// "v" keeps spreadsheets from reading it as a number or a time.
+func SyncKey(id, version int64) string {
+	return strconv.FormatInt(id, 10) + "v" + strconv.FormatInt(version, 10)
+}
+
 // Ports for outbound adapters.
 type (
 	ExpenseWriter interface {
 		Append(ctx context.Context, e core.Expense) (rowRef string, err error)
 	}
 
+	// ExpenseBatchWriter appends several expenses atomically, returning their
+	// references in input order.
+	ExpenseBatchWriter interface {
+		AppendBatch(ctx context.Context, expenses []core.Expense) (rowRefs []string, err error)
+	}
+
+	// ExpenseWriterWithID appends an expense together with its storage ID,
+	// written to a hidden column so the row can be matched back later.
+	ExpenseWriterWithID interface {
+		AppendWithID(ctx context.Context, id int64, e core.Expense) (rowRef string, err error)
+	}
+
+	// IdempotentExpenseWriter appends an expense at most once per sync key
+	// (see SyncKey), written to a hidden column and looked up before
+	// appending, so a retried sync does not duplicate the row.
+	IdempotentExpenseWriter interface {
+		// AppendOnce appends the expense unless a row already carries its
+		// sync key; appended is false when the existing row was returned.
+		AppendOnce(ctx context.Context, id, version int64, e core.Expense) (rowRef string, appended bool, err error)
+		// SyncKeys returns the sync keys found in the sheet.
+		SyncKeys(ctx context.Context) (map[string]bool, error)
+	}
+
+	// SplitExpenseWriter appends the rows of a split expense, one per part
+	// (see core.FlattenSplit), at most once per sync key like
+	// IdempotentExpenseWriter. The rows are written together: a failure
+	// leaves none of them.
+	SplitExpenseWriter interface {
+		AppendSplitOnce(ctx context.Context, id, version int64, rows []core.Expense) (rowRef string, appended bool, err error)
+	}
+
+	// EditPuller finds the rows of the expenses sheet edited or added by
+	// hand, comparing each row tagged with a storage ID with the checksum
+	// the sync stored next to it.
+	EditPuller interface {
+		// ListEdits returns the edited rows and the rows added after the
+		// first checksummed one.
+		ListEdits(ctx context.Context) ([]SheetEdit, error)
+		// AckEdit tags the row of edit with the ID and version the expense
+		// was stored as and with the checksum of its values, so it is not
+		// pulled again until edited anew.
+		AckEdit(ctx context.Context, edit SheetEdit, id, version int64) error
+	}
+
+	// AmountReconciler reads and fixes amounts of rows tagged with a storage
+	// ID, in the expenses sheet of a year.
+	AmountReconciler interface {
+		// ListAmountsByID returns every row of the sheet of year that has a
+		// storage ID.
+		ListAmountsByID(ctx context.Context, year int) ([]SheetAmountRow, error)
+		// UpdateAmount overwrites the amount of the given 1-based row of the
+		// sheet of year.
+		UpdateAmount(ctx context.Context, year, row int, cents int64) error
+	}
+
 	TaxonomyReader interface {
 		List(ctx context.Context) (categories []string, subcategories []string, err error)
 	}
@@ -45,6 +185,31 @@ type (
 		DeleteExpense(ctx context.Context, id string) error
 	}
 
+	// HistoryReader reads the expenses sheets of past years, for the
+	// one-time import of the history into the database.
+	HistoryReader interface {
+		// ListExpenseYears returns the years with an expenses sheet, oldest first.
+		ListExpenseYears(ctx context.Context) ([]int, error)
+		// ListYearExpenses returns every expense in the sheet of a year.
+		ListYearExpenses(ctx context.Context, year int) ([]core.Expense, error)
+	}
+
+	// SandboxCopier fills the sandbox spreadsheet the sync writes to while
+	// testing, with rows of the production one.
+	SandboxCopier interface {
+		// CopyRecentRows appends the last n rows of the production expenses
+		// sheet to the sandbox and returns how many were copied.
+		CopyRecentRows(ctx context.Context, n int) (int, error)
+	}
+
+	// WriteThrottle reports the remaining write budget of a rate-limited
+	// writer, so a worker can hold back work instead of queueing requests.
+	WriteThrottle interface {
+		// WritesAvailable returns the writes that can be made without
+		// waiting, -1 when there is no limit.
+		WritesAvailable() int
+	}
+
 	// RecurrentExpenseWriter manages recurrent expenses.
 	RecurrentExpenseWriter interface {
 		// SaveRecurrentExpense creates a new recurrent expense.
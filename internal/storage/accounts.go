@@ -0,0 +1,157 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"spese/internal/core"
+)
+
+// accountID returns the account column of an expense or income, NULL when
+// none is set.
+func accountID(id int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: id, Valid: id != 0}
+}
+
+func accountFromRow(row Account) core.Account {
+	return core.Account{
+		ID:             row.ID,
+		Name:           row.Name,
+		Kind:           core.AccountKind(row.Kind),
+		OpeningBalance: core.Money{Cents: row.OpeningBalanceCents},
+	}
+}
+
+// CreateAccount adds an account. Names are unique, ignoring case.
+func (r *SQLiteRepository) CreateAccount(ctx context.Context, a core.Account) (core.Account, error) {
+	a.Name = strings.TrimSpace(a.Name)
+	if err := a.Validate(); err != nil {
+		return core.Account{}, err
+	}
+	row, err := r.queries.CreateAccount(ctx, CreateAccountParams{
+		Name:                a.Name,
+		Kind:                string(a.Kind),
+		OpeningBalanceCents: a.OpeningBalance.Cents,
+	})
+	if err != nil {
+		return core.Account{}, fmt.Errorf("create account: %w", err)
+	}
+	return accountFromRow(row), nil
+}
+
+// ListAccounts returns the accounts by name.
+func (r *SQLiteRepository) ListAccounts(ctx context.Context) ([]core.Account, error) {
+	rows, err := r.reader(ctx).ListAccounts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list accounts: %w", err)
+	}
+	accounts := make([]core.Account, len(rows))
+	for i, row := range rows {
+		accounts[i] = accountFromRow(row)
+	}
+	return accounts, nil
+}
+
+// DeleteAccount removes an account and its transfers; its expenses and
+// incomes are kept with no account.
+func (r *SQLiteRepository) DeleteAccount(ctx context.Context, id int64) error {
+	n, err := r.queries.DeleteAccount(ctx, id)
+	if err != nil {
+		return fmt.Errorf("delete account: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("delete account %d: %w", id, sql.ErrNoRows)
+	}
+	return nil
+}
+
+// AccountBalances returns what moved through each account, by name.
+func (r *SQLiteRepository) AccountBalances(ctx context.Context) ([]core.AccountBalance, error) {
+	rows, err := r.reader(ctx).ListAccountBalances(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list account balances: %w", err)
+	}
+	balances := make([]core.AccountBalance, len(rows))
+	for i, row := range rows {
+		balances[i] = core.AccountBalance{
+			Account: core.Account{
+				ID:             row.ID,
+				Name:           row.Name,
+				Kind:           core.AccountKind(row.Kind),
+				OpeningBalance: core.Money{Cents: row.OpeningBalanceCents},
+			},
+			Incomes:      core.Money{Cents: row.IncomesCents},
+			Expenses:     core.Money{Cents: row.ExpensesCents},
+			TransfersIn:  core.Money{Cents: row.TransfersInCents},
+			TransfersOut: core.Money{Cents: row.TransfersOutCents},
+		}
+	}
+	return balances, nil
+}
+
+// CreateAccountTransfer records money moved between two existing
+// accounts.
+func (r *SQLiteRepository) CreateAccountTransfer(ctx context.Context, t core.AccountTransfer) (core.AccountTransfer, error) {
+	t.Description = strings.TrimSpace(t.Description)
+	if err := t.Validate(); err != nil {
+		return core.AccountTransfer{}, err
+	}
+	for _, id := range []int64{t.From, t.To} {
+		if _, err := r.queries.GetAccount(ctx, id); errors.Is(err, sql.ErrNoRows) {
+			return core.AccountTransfer{}, fmt.Errorf("%w: unknown account %d", core.ErrInvalidTransfer, id)
+		} else if err != nil {
+			return core.AccountTransfer{}, fmt.Errorf("get account: %w", err)
+		}
+	}
+
+	row, err := r.queries.CreateAccountTransfer(ctx, CreateAccountTransferParams{
+		Date:          t.Date.Format("2006-01-02"),
+		FromAccountID: t.From,
+		ToAccountID:   t.To,
+		AmountCents:   t.Amount.Cents,
+		Description:   t.Description,
+	})
+	if err != nil {
+		return core.AccountTransfer{}, fmt.Errorf("create account transfer: %w", err)
+	}
+	return transferFromRow(row), nil
+}
+
+// ListAccountTransfers returns the latest limit transfers, newest first.
+func (r *SQLiteRepository) ListAccountTransfers(ctx context.Context, limit int) ([]core.AccountTransfer, error) {
+	rows, err := r.reader(ctx).ListAccountTransfers(ctx, int64(limit))
+	if err != nil {
+		return nil, fmt.Errorf("list account transfers: %w", err)
+	}
+	transfers := make([]core.AccountTransfer, len(rows))
+	for i, row := range rows {
+		transfers[i] = transferFromRow(row)
+	}
+	return transfers, nil
+}
+
+// DeleteAccountTransfer removes a transfer, moving its amount back.
+func (r *SQLiteRepository) DeleteAccountTransfer(ctx context.Context, id int64) error {
+	n, err := r.queries.DeleteAccountTransfer(ctx, id)
+	if err != nil {
+		return fmt.Errorf("delete account transfer: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("delete account transfer %d: %w", id, sql.ErrNoRows)
+	}
+	return nil
+}
+
+func transferFromRow(row AccountTransfer) core.AccountTransfer {
+	return core.AccountTransfer{
+		ID:          row.ID,
+		Date:        core.Date{Time: row.Date},
+		From:        row.FromAccountID,
+		To:          row.ToAccountID,
+		Amount:      core.Money{Cents: row.AmountCents},
+		Description: row.Description,
+	}
+}
@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"spese/internal/core"
+)
+
+// ExpenseCategoryTotalsByMonths returns the expenses per primary category
+// of the months of from through to, both included, largest first. It reads
+// the monthly aggregates, so the cost does not grow with the entries.
+func (r *SQLiteRepository) ExpenseCategoryTotalsByMonths(ctx context.Context, from, to time.Time) ([]core.CategoryAmount, error) {
+	return r.aggregateCategoryTotals(ctx, "expense", from, to)
+}
+
+// IncomeCategoryTotalsByMonths returns the incomes per category of the
+// months of from through to, both included, largest first.
+func (r *SQLiteRepository) IncomeCategoryTotalsByMonths(ctx context.Context, from, to time.Time) ([]core.CategoryAmount, error) {
+	return r.aggregateCategoryTotals(ctx, "income", from, to)
+}
+
+func (r *SQLiteRepository) aggregateCategoryTotals(ctx context.Context, kind string, from, to time.Time) ([]core.CategoryAmount, error) {
+	rows, err := r.reader(ctx).SumAggregatesByCategory(ctx, SumAggregatesByCategoryParams{
+		Kind:       kind,
+		FromPeriod: from.Year()*100 + int(from.Month()),
+		ToPeriod:   to.Year()*100 + int(to.Month()),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("sum %s aggregates: %w", kind, err)
+	}
+	totals := make([]core.CategoryAmount, len(rows))
+	for i, row := range rows {
+		totals[i] = core.CategoryAmount{Name: row.Category, Amount: core.Money{Cents: row.TotalAmount}}
+	}
+	return totals, nil
+}
+
+// CategoryYear is what went to a category in each month of a year
+type CategoryYear struct {
+	Name   string
+	Months [12]core.Money // January first
+}
+
+// ExpenseYearByCategory returns the expenses of a year per primary
+// category and month, by category name, from the monthly aggregates.
+// Categories without expenses that year are left out.
+func (r *SQLiteRepository) ExpenseYearByCategory(ctx context.Context, year int) ([]CategoryYear, error) {
+	rows, err := r.reader(ctx).GetYearAggregates(ctx, GetYearAggregatesParams{Kind: "expense", Year: int64(year)})
+	if err != nil {
+		return nil, fmt.Errorf("get year aggregates: %w", err)
+	}
+	var categories []CategoryYear
+	for _, row := range rows {
+		if row.Month < 1 || row.Month > 12 {
+			continue
+		}
+		if len(categories) == 0 || categories[len(categories)-1].Name != row.Category {
+			categories = append(categories, CategoryYear{Name: row.Category})
+		}
+		categories[len(categories)-1].Months[row.Month-1] = core.Money{Cents: row.TotalCents}
+	}
+	return categories, nil
+}
+
+// RebuildMonthlyAggregates recomputes the monthly aggregates from the
+// expenses and incomes when they disagree, and returns how many months
+// and categories were wrong. The triggers keep the aggregates right, so
+// anything but 0 means a write bypassed them, such as a restore of an
+// older database file.
+func (r *SQLiteRepository) RebuildMonthlyAggregates(ctx context.Context) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	txQueries := r.withTx(tx)
+
+	stale, err := txQueries.CountStaleAggregates(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("count stale aggregates: %w", err)
+	}
+	if stale == 0 {
+		return 0, nil
+	}
+	if err := txQueries.ClearMonthlyAggregates(ctx); err != nil {
+		return 0, fmt.Errorf("clear aggregates: %w", err)
+	}
+	if err := txQueries.FillMonthlyAggregates(ctx); err != nil {
+		return 0, fmt.Errorf("fill aggregates: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit: %w", err)
+	}
+	slog.WarnContext(ctx, "Monthly aggregates rebuilt", "stale", stale)
+	return stale, nil
+}
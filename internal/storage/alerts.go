@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"spese/internal/core"
+)
+
+// SetAlertPreference mutes or snoozes an alert type for a user, replacing
+// the preference with the same scope.
+func (r *SQLiteRepository) SetAlertPreference(ctx context.Context, p core.AlertPreference) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	err := r.queries.SetAlertPreference(ctx, SetAlertPreferenceParams{
+		UserID:       p.User,
+		AlertType:    string(p.Type),
+		Scope:        p.Scope,
+		Muted:        p.Muted,
+		SnoozedUntil: sql.NullTime{Time: p.SnoozedUntil, Valid: !p.SnoozedUntil.IsZero()},
+	})
+	if err != nil {
+		return fmt.Errorf("set alert preference: %w", err)
+	}
+	return nil
+}
+
+// ListAlertPreferences returns the preferences of a user, expired snoozes
+// included.
+func (r *SQLiteRepository) ListAlertPreferences(ctx context.Context, user string) ([]core.AlertPreference, error) {
+	rows, err := r.reader(ctx).ListAlertPreferences(ctx, user)
+	if err != nil {
+		return nil, fmt.Errorf("list alert preferences: %w", err)
+	}
+	prefs := make([]core.AlertPreference, len(rows))
+	for i, row := range rows {
+		prefs[i] = core.AlertPreference{
+			User:         row.UserID,
+			Type:         core.AlertType(row.AlertType),
+			Scope:        row.Scope,
+			Muted:        row.Muted,
+			SnoozedUntil: row.SnoozedUntil.Time,
+		}
+	}
+	return prefs, nil
+}
+
+// DeleteAlertPreference lets the alerts a preference silenced through
+// again.
+func (r *SQLiteRepository) DeleteAlertPreference(ctx context.Context, user string, t core.AlertType, scope string) error {
+	n, err := r.queries.DeleteAlertPreference(ctx, DeleteAlertPreferenceParams{UserID: user, AlertType: string(t), Scope: scope})
+	if err != nil {
+		return fmt.Errorf("delete alert preference: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("alert preference not found: %s %s", t, scope)
+	}
+	return nil
+}
@@ -0,0 +1,372 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"spese/internal/core"
+)
+
+// Entities recorded in the audit log
+const (
+	AuditExpense          = "expense"
+	AuditIncome           = "income"
+	AuditRecurrentExpense = "recurrent_expense"
+	AuditRecurrentIncome  = "recurrent_income"
+	AuditCategory         = "category"    // Primary category
+	AuditSubcategory      = "subcategory" // Secondary category
+)
+
+// AuditEntities lists the entities of the audit log, for filters
+var AuditEntities = []string{AuditExpense, AuditIncome, AuditRecurrentExpense, AuditRecurrentIncome, AuditCategory, AuditSubcategory}
+
+// Actions recorded in the audit log
+const (
+	AuditCreate = "create"
+	AuditUpdate = "update"
+	AuditDelete = "delete"
+)
+
+// auditSnapshot is the state of an audited row: field name to value.
+// A nil snapshot is a row that does not exist (before a creation, after
+// a deletion).
+type auditSnapshot map[string]any
+
+// AuditEntry is a decoded row of the audit log
+type AuditEntry struct {
+	ID        int64
+	Entity    string
+	EntityID  int64
+	Action    string
+	ChangedBy string
+	ChangedAt time.Time
+	Before    map[string]any // nil for a creation
+	After     map[string]any // nil for a deletion
+}
+
+// AuditChange is a field of an audited row that a change set, modified or
+// cleared. Old is nil for a creation, New for a deletion.
+type AuditChange struct {
+	Field string
+	Old   any
+	New   any
+}
+
+// Changes returns the fields the entry changed, sorted by name
+func (e AuditEntry) Changes() []AuditChange {
+	fields := make(map[string]bool)
+	for f := range e.Before {
+		fields[f] = true
+	}
+	for f := range e.After {
+		fields[f] = true
+	}
+	var changes []AuditChange
+	for f := range fields {
+		old, updated := e.Before[f], e.After[f]
+		if e.Before != nil && e.After != nil && reflect.DeepEqual(old, updated) {
+			continue
+		}
+		changes = append(changes, AuditChange{Field: f, Old: old, New: updated})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
+
+// AuditFilter selects the entries of the audit log
+type AuditFilter struct {
+	From, Until time.Time // Changes made in [From, Until)
+	Entity      string    // Empty for all entities
+	EntityID    int64     // 0 for all rows
+	Limit       int
+}
+
+// auditDate formats a date column for the audit log. Nullable date columns
+// scan as time.Time, string or nil depending on the stored value.
+func auditDate(v any) any {
+	switch d := v.(type) {
+	case time.Time:
+		if d.IsZero() {
+			return nil
+		}
+		return d.Format("2006-01-02")
+	case string:
+		if len(d) >= 10 {
+			return d[:10]
+		}
+		if d == "" {
+			return nil
+		}
+		return d
+	case []byte:
+		return auditDate(string(d))
+	}
+	return v
+}
+
+func expenseSnapshot(e Expense) auditSnapshot {
+	s := auditSnapshot{
+		"date":               e.Date.Format("2006-01-02"),
+		"description":        e.Description,
+		"amount_cents":       e.AmountCents,
+		"primary_category":   e.PrimaryCategory,
+		"secondary_category": e.SecondaryCategory,
+		"status":             e.Status,
+		"paid_by":            nil,
+		"account_id":         nil,
+	}
+	if e.PaidBy.Valid {
+		s["paid_by"] = e.PaidBy.Int64
+	}
+	if e.AccountID.Valid {
+		s["account_id"] = e.AccountID.Int64
+	}
+	return s
+}
+
+func incomeSnapshot(i Income) auditSnapshot {
+	s := auditSnapshot{
+		"date":         i.Date.Format("2006-01-02"),
+		"description":  i.Description,
+		"amount_cents": i.AmountCents,
+		"category":     i.Category,
+		"subcategory":  i.Subcategory,
+		"tags":         i.Tags,
+		"account_id":   nil,
+	}
+	if i.AccountID.Valid {
+		s["account_id"] = i.AccountID.Int64
+	}
+	return s
+}
+
+// recurrentExpenseSnapshot leaves out the last execution date: the
+// recurring processor moves it at every occurrence, which is not a change
+// of the configuration.
+func recurrentExpenseSnapshot(re RecurrentExpense) auditSnapshot {
+	return auditSnapshot{
+		"start_date":         auditDate(re.StartDate),
+		"end_date":           auditDate(re.EndDate),
+		"repetition_type":    re.RepetitionType,
+		"repeat_interval":    re.RepeatInterval,
+		"day_of_month":       re.DayOfMonth,
+		"description":        re.Description,
+		"amount_cents":       re.AmountCents,
+		"primary_category":   re.PrimaryCategory,
+		"secondary_category": re.SecondaryCategory,
+		"is_active":          re.IsActive,
+		"paused":             re.Paused,
+		"skip_until":         auditDate(re.SkipUntil),
+	}
+}
+
+func recurrentIncomeSnapshot(ri RecurrentIncome) auditSnapshot {
+	return auditSnapshot{
+		"start_date":      auditDate(ri.StartDate),
+		"end_date":        auditDate(ri.EndDate),
+		"repetition_type": ri.RepetitionType,
+		"repeat_interval": ri.RepeatInterval,
+		"day_of_month":    ri.DayOfMonth,
+		"description":     ri.Description,
+		"amount_cents":    ri.AmountCents,
+		"category":        ri.Category,
+		"subcategory":     ri.Subcategory,
+		"is_active":       ri.IsActive,
+	}
+}
+
+func categorySnapshot(c PrimaryCategory) auditSnapshot {
+	return auditSnapshot{"name": c.Name, "archived": c.Archived}
+}
+
+func subcategorySnapshot(c SecondaryCategory, primary string) auditSnapshot {
+	return auditSnapshot{"name": c.Name, "primary_category": primary, "archived": c.Archived}
+}
+
+// recordAudit stores a change of a row in the audit log; the actor is taken
+// from ctx. A nil before records a creation, a nil after a deletion. An
+// update leaving every field as it was is not recorded.
+func recordAudit(ctx context.Context, q *Queries, entity string, id int64, before, after auditSnapshot) error {
+	action := AuditUpdate
+	switch {
+	case before == nil && after == nil:
+		return nil
+	case before == nil:
+		action = AuditCreate
+	case after == nil:
+		action = AuditDelete
+	case reflect.DeepEqual(before, after):
+		return nil
+	}
+	beforeJSON, err := encodeSnapshot(before)
+	if err != nil {
+		return err
+	}
+	afterJSON, err := encodeSnapshot(after)
+	if err != nil {
+		return err
+	}
+	if err := q.CreateAuditEntry(ctx, CreateAuditEntryParams{
+		Entity:     entity,
+		EntityID:   id,
+		Action:     action,
+		ChangedBy:  core.ActorFromContext(ctx),
+		BeforeJson: beforeJSON,
+		AfterJson:  afterJSON,
+	}); err != nil {
+		return fmt.Errorf("create audit entry: %w", err)
+	}
+	return nil
+}
+
+func encodeSnapshot(s auditSnapshot) (sql.NullString, error) {
+	if s == nil {
+		return sql.NullString{}, nil
+	}
+	payload, err := json.Marshal(s)
+	if err != nil {
+		return sql.NullString{}, fmt.Errorf("encode audit snapshot: %w", err)
+	}
+	return sql.NullString{String: string(payload), Valid: true}, nil
+}
+
+// ListAuditLog returns the changes matching f, newest first
+func (r *SQLiteRepository) ListAuditLog(ctx context.Context, f AuditFilter) ([]AuditEntry, error) {
+	rows, err := r.reader(ctx).ListAuditEntries(ctx, ListAuditEntriesParams{
+		ChangedFrom:  f.From.UTC(),
+		ChangedUntil: f.Until.UTC(),
+		Entity:       f.Entity,
+		EntityID:     f.EntityID,
+		MaxEntries:   int64(f.Limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list audit log: %w", err)
+	}
+
+	entries := make([]AuditEntry, 0, len(rows))
+	for _, row := range rows {
+		entry := AuditEntry{
+			ID:        row.ID,
+			Entity:    row.Entity,
+			EntityID:  row.EntityID,
+			Action:    row.Action,
+			ChangedBy: row.ChangedBy,
+			ChangedAt: row.ChangedAt,
+		}
+		if row.BeforeJson.Valid {
+			if err := json.Unmarshal([]byte(row.BeforeJson.String), &entry.Before); err != nil {
+				return nil, fmt.Errorf("decode audit entry %d: %w", row.ID, err)
+			}
+		}
+		if row.AfterJson.Valid {
+			if err := json.Unmarshal([]byte(row.AfterJson.String), &entry.After); err != nil {
+				return nil, fmt.Errorf("decode audit entry %d: %w", row.ID, err)
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// PruneAuditLog removes the changes made before olderThan and returns how
+// many it removed.
+func (r *SQLiteRepository) PruneAuditLog(ctx context.Context, olderThan time.Time) (int64, error) {
+	n, err := r.queries.PruneAuditLog(ctx, olderThan.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("prune audit log: %w", err)
+	}
+	return n, nil
+}
+
+// auditExpense records a change of an expense; a nil old is a creation, a
+// nil updated a deletion.
+func auditExpense(ctx context.Context, q *Queries, id int64, old, updated *Expense) error {
+	var before, after auditSnapshot
+	if old != nil {
+		before = expenseSnapshot(*old)
+	}
+	if updated != nil {
+		after = expenseSnapshot(*updated)
+	}
+	return recordAudit(ctx, q, AuditExpense, id, before, after)
+}
+
+// auditIncome records a change of an income; a nil old is a creation, a
+// nil updated a deletion.
+func auditIncome(ctx context.Context, q *Queries, id int64, old, updated *Income) error {
+	var before, after auditSnapshot
+	if old != nil {
+		before = incomeSnapshot(*old)
+	}
+	if updated != nil {
+		after = incomeSnapshot(*updated)
+	}
+	return recordAudit(ctx, q, AuditIncome, id, before, after)
+}
+
+// auditRecurrentExpense records a change of a recurrent expense, reading
+// its new state back unless it was deleted; a nil old is a creation.
+func auditRecurrentExpense(ctx context.Context, q *Queries, id int64, old *RecurrentExpense, deleted bool) error {
+	var before, after auditSnapshot
+	if old != nil {
+		before = recurrentExpenseSnapshot(*old)
+	}
+	if !deleted {
+		row, err := q.GetRecurrentExpenseByID(ctx, id)
+		if err != nil {
+			return fmt.Errorf("get recurrent expense: %w", err)
+		}
+		after = recurrentExpenseSnapshot(row)
+	}
+	return recordAudit(ctx, q, AuditRecurrentExpense, id, before, after)
+}
+
+// auditRecurrentIncome records a change of a recurrent income, reading its
+// new state back unless it was deleted; a nil old is a creation.
+func auditRecurrentIncome(ctx context.Context, q *Queries, id int64, old *RecurrentIncome, deleted bool) error {
+	var before, after auditSnapshot
+	if old != nil {
+		before = recurrentIncomeSnapshot(*old)
+	}
+	if !deleted {
+		row, err := q.GetRecurrentIncomeByID(ctx, id)
+		if err != nil {
+			return fmt.Errorf("get recurrent income: %w", err)
+		}
+		after = recurrentIncomeSnapshot(row)
+	}
+	return recordAudit(ctx, q, AuditRecurrentIncome, id, before, after)
+}
+
+// recurrentsInCategory returns the active recurrent expenses filed under
+// primary, and under secondary too when set, so a category change moving
+// them in bulk can record each.
+func recurrentsInCategory(ctx context.Context, q *Queries, primary, secondary string) ([]RecurrentExpense, error) {
+	rows, err := q.GetRecurrentExpenses(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get recurrent expenses: %w", err)
+	}
+	var matched []RecurrentExpense
+	for _, row := range rows {
+		if row.PrimaryCategory == primary && (secondary == "" || row.SecondaryCategory == secondary) {
+			matched = append(matched, row)
+		}
+	}
+	return matched, nil
+}
+
+// auditRecurrentsMoved records the recurrent expenses a category change
+// moved; olds are their rows before it.
+func auditRecurrentsMoved(ctx context.Context, q *Queries, olds []RecurrentExpense) error {
+	for _, old := range olds {
+		if err := auditRecurrentExpense(ctx, q, old.ID, &old, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"spese/internal/core"
+)
+
+// RecordExpenseCalculation stores the inputs a calculated expense was
+// computed from.
+func (r *SQLiteRepository) RecordExpenseCalculation(ctx context.Context, expenseID int64, c core.ExpenseCalculation) error {
+	if err := r.queries.CreateExpenseCalculation(ctx, CreateExpenseCalculationParams{
+		ExpenseID: expenseID,
+		Kind:      string(c.Kind),
+		Quantity:  c.Quantity,
+		RateCents: c.Rate.Cents,
+	}); err != nil {
+		return fmt.Errorf("record expense calculation: %w", err)
+	}
+	return nil
+}
+
+// GetExpenseCalculation returns the inputs of a calculated expense, or nil
+// when the expense was entered directly.
+func (r *SQLiteRepository) GetExpenseCalculation(ctx context.Context, expenseID int64) (*core.ExpenseCalculation, error) {
+	row, err := r.reader(ctx).GetExpenseCalculation(ctx, expenseID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get expense calculation: %w", err)
+	}
+	return &core.ExpenseCalculation{
+		Kind:     core.CalculationKind(row.Kind),
+		Quantity: row.Quantity,
+		Rate:     core.Money{Cents: row.RateCents},
+	}, nil
+}
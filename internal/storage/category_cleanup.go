@@ -0,0 +1,230 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"spese/internal/core"
+)
+
+// CategoryCleanup lists the categories worth tidying up.
+type CategoryCleanup struct {
+	// Unused are the categories no expense or recurrent expense uses; an
+	// unused primary category stands for its secondaries too.
+	Unused []UnusedCategory
+	// Orphans are the category pairs in use that are not among the
+	// categories, e.g. left by a primary category removed or renamed.
+	Orphans []OrphanCategory
+	// Detached are the secondary categories whose primary category no
+	// longer exists.
+	Detached []DetachedCategory
+	// Targets are the secondary categories not archived, which the
+	// orphans can be merged into.
+	Targets []MergeTarget
+}
+
+// UnusedCategory is a category nothing is filed under.
+type UnusedCategory struct {
+	Primary   string
+	Secondary string // Empty for a primary category
+	Archived  bool
+}
+
+// OrphanCategory is a category pair in use but missing from the categories.
+type OrphanCategory struct {
+	Primary    string
+	Secondary  string
+	Expenses   int64
+	Recurrents int64
+	// SuggestedPrimary is a primary category with a secondary of the same
+	// name, empty when none has one.
+	SuggestedPrimary string
+}
+
+// DetachedCategory is a secondary category left without its primary.
+type DetachedCategory struct {
+	ID   int64
+	Name string
+}
+
+// MergeTarget is a secondary category other categories can merge into.
+type MergeTarget struct {
+	ID        int64
+	Primary   string
+	Secondary string
+}
+
+// CategoryCleanup finds the unused, orphan and detached categories, with
+// the categories to merge into.
+func (r *SQLiteRepository) CategoryCleanup(ctx context.Context) (CategoryCleanup, error) {
+	q := r.reader(ctx)
+	var cleanup CategoryCleanup
+
+	unused, err := q.ListUnusedCategories(ctx)
+	if err != nil {
+		return cleanup, fmt.Errorf("list unused categories: %w", err)
+	}
+	unusedPrimaries := make(map[string]bool)
+	for _, row := range unused {
+		if row.SecondaryName == "" {
+			unusedPrimaries[row.PrimaryName] = true
+		}
+	}
+	for _, row := range unused {
+		if row.SecondaryName != "" && unusedPrimaries[row.PrimaryName] {
+			continue
+		}
+		cleanup.Unused = append(cleanup.Unused, UnusedCategory{
+			Primary:   row.PrimaryName,
+			Secondary: row.SecondaryName,
+			Archived:  row.Archived,
+		})
+	}
+
+	orphans, err := q.ListOrphanCategoryPairs(ctx)
+	if err != nil {
+		return cleanup, fmt.Errorf("list orphan categories: %w", err)
+	}
+	for _, row := range orphans {
+		cleanup.Orphans = append(cleanup.Orphans, OrphanCategory{
+			Primary:          row.PrimaryCategory,
+			Secondary:        row.SecondaryCategory,
+			Expenses:         row.ExpenseCount,
+			Recurrents:       row.RecurrentCount,
+			SuggestedPrimary: row.SuggestedPrimary,
+		})
+	}
+
+	detached, err := q.ListDetachedSecondaryCategories(ctx)
+	if err != nil {
+		return cleanup, fmt.Errorf("list detached categories: %w", err)
+	}
+	for _, row := range detached {
+		cleanup.Detached = append(cleanup.Detached, DetachedCategory{ID: row.ID, Name: row.Name})
+	}
+
+	targets, err := q.ListActiveCategoryPairs(ctx)
+	if err != nil {
+		return cleanup, fmt.Errorf("list categories: %w", err)
+	}
+	for _, row := range targets {
+		cleanup.Targets = append(cleanup.Targets, MergeTarget{ID: row.ID, Primary: row.PrimaryName, Secondary: row.SecondaryName})
+	}
+	return cleanup, nil
+}
+
+// MergeCategory files everything under the category pair primary /
+// secondary into the secondary category intoID, and removes the pair from
+// the categories when it is there. Expenses (with a history entry each),
+// recurrent expenses, rules, keywords and saved views follow; rows already
+// written to Google Sheets keep the old names. It returns how many
+// expenses moved.
+func (r *SQLiteRepository) MergeCategory(ctx context.Context, primary, secondary string, intoID int64) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	q := r.withTx(tx)
+
+	into, err := q.GetCategoryPairByID(ctx, intoID)
+	if err != nil {
+		return 0, categoryLookupError(err, fmt.Sprintf("#%d", intoID))
+	}
+	if into.PrimaryName == primary && into.SecondaryName == secondary {
+		return 0, fmt.Errorf("%w: cannot merge %q into itself", core.ErrInvalidTaxonomy, secondary)
+	}
+
+	expenses, err := q.ListExpensesInCategory(ctx, ListExpensesInCategoryParams{
+		PrimaryCategory:   primary,
+		SecondaryCategory: secondary,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("list expenses of %q: %w", secondary, err)
+	}
+	for _, e := range expenses {
+		if err := recategorize(ctx, q, e, into.PrimaryName, into.SecondaryName); err != nil {
+			return 0, err
+		}
+	}
+
+	recurrents, err := recurrentsInCategory(ctx, q, primary, secondary)
+	if err != nil {
+		return 0, err
+	}
+	if err := q.MoveRecurrentExpensesCategory(ctx, MoveRecurrentExpensesCategoryParams{
+		NewPrimary: into.PrimaryName, NewSecondary: into.SecondaryName, OldPrimary: primary, OldSecondary: secondary,
+	}); err != nil {
+		return 0, fmt.Errorf("move category of recurrent expenses: %w", err)
+	}
+	if err := auditRecurrentsMoved(ctx, q, recurrents); err != nil {
+		return 0, err
+	}
+	if err := q.MoveCategoryRulesCategory(ctx, MoveCategoryRulesCategoryParams{
+		NewPrimary: into.PrimaryName, NewSecondary: into.SecondaryName, OldPrimary: primary, OldSecondary: secondary,
+	}); err != nil {
+		return 0, fmt.Errorf("move category of rules: %w", err)
+	}
+	if err := q.MoveCategoryKeywordsCategory(ctx, MoveCategoryKeywordsCategoryParams{
+		NewPrimary: into.PrimaryName, NewSecondary: into.SecondaryName, OldPrimary: primary, OldSecondary: secondary,
+	}); err != nil {
+		return 0, fmt.Errorf("move category of keywords: %w", err)
+	}
+	if err := q.MoveSavedViewsCategory(ctx, MoveSavedViewsCategoryParams{
+		NewPrimary: into.PrimaryName, NewSecondary: into.SecondaryName, OldPrimary: primary, OldSecondary: secondary,
+	}); err != nil {
+		return 0, fmt.Errorf("move category of saved views: %w", err)
+	}
+
+	sc, err := q.GetSecondaryCategoryByName(ctx, GetSecondaryCategoryByNameParams{PrimaryName: primary, Name: secondary})
+	switch {
+	case err == nil:
+		if err := q.DeleteSecondaryCategoryByID(ctx, sc.ID); err != nil {
+			return 0, fmt.Errorf("delete secondary category: %w", err)
+		}
+		if err := recordAudit(ctx, q, AuditSubcategory, sc.ID, subcategorySnapshot(sc, primary), nil); err != nil {
+			return 0, err
+		}
+	case !errors.Is(err, sql.ErrNoRows):
+		return 0, fmt.Errorf("get secondary category %q: %w", secondary, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit transaction: %w", err)
+	}
+	slog.InfoContext(ctx, "Category merged",
+		"primary", primary, "secondary", secondary,
+		"into_primary", into.PrimaryName, "into_secondary", into.SecondaryName,
+		"expenses", len(expenses))
+	return len(expenses), nil
+}
+
+// DeleteDetachedCategory removes a secondary category whose primary
+// category no longer exists; core.ErrCategoryNotFound when id is not one.
+func (r *SQLiteRepository) DeleteDetachedCategory(ctx context.Context, id int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	q := r.withTx(tx)
+
+	sc, err := q.DeleteDetachedSecondaryCategory(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: detached category #%d", core.ErrCategoryNotFound, id)
+	}
+	if err != nil {
+		return fmt.Errorf("delete detached category: %w", err)
+	}
+	if err := recordAudit(ctx, q, AuditSubcategory, sc.ID, subcategorySnapshot(sc, ""), nil); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	slog.InfoContext(ctx, "Detached category deleted", "id", id)
+	return nil
+}
@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"spese/internal/core"
+)
+
+// ListCategoryMappings returns the primary category of every secondary
+// category of Google Sheets, ordered by primary category.
+func (r *SQLiteRepository) ListCategoryMappings(ctx context.Context) ([]CategoryMapping, error) {
+	mappings, err := r.reader(ctx).ListCategoryMappings(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list category mappings: %w", err)
+	}
+	return mappings, nil
+}
+
+// CategoryMappings returns the primary category of every secondary
+// category of Google Sheets, keyed by the sheet name.
+func (r *SQLiteRepository) CategoryMappings(ctx context.Context) (map[string]string, error) {
+	mappings, err := r.ListCategoryMappings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	primaries := make(map[string]string, len(mappings))
+	for _, m := range mappings {
+		primaries[m.SheetName] = m.PrimaryCategory
+	}
+	return primaries, nil
+}
+
+// MappedPrimary returns the primary category a secondary category from
+// Google Sheets belongs to, and false when it is not mapped.
+func (r *SQLiteRepository) MappedPrimary(ctx context.Context, secondary string) (string, bool, error) {
+	primary, err := r.reader(ctx).GetCategoryMapping(ctx, strings.TrimSpace(secondary))
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("get category mapping %q: %w", secondary, err)
+	}
+	return primary, true, nil
+}
+
+// SetCategoryMapping files the secondary category sheetName of Google
+// Sheets under primary, which must exist, replacing any previous mapping.
+func (r *SQLiteRepository) SetCategoryMapping(ctx context.Context, sheetName, primary string) error {
+	if err := core.ValidateCategoryName(sheetName); err != nil {
+		return err
+	}
+	if err := core.ValidateCategoryName(primary); err != nil {
+		return err
+	}
+	if _, err := r.queries.GetPrimaryCategoryByName(ctx, primary); err != nil {
+		return categoryLookupError(err, primary)
+	}
+	err := r.queries.UpsertCategoryMapping(ctx, UpsertCategoryMappingParams{
+		SheetName:       sheetName,
+		PrimaryCategory: primary,
+	})
+	if err != nil {
+		return fmt.Errorf("set category mapping: %w", err)
+	}
+	return nil
+}
+
+// DeleteCategoryMapping removes the mapping of a secondary category of
+// Google Sheets, returning core.ErrCategoryNotFound when there is none.
+func (r *SQLiteRepository) DeleteCategoryMapping(ctx context.Context, sheetName string) error {
+	n, err := r.queries.DeleteCategoryMapping(ctx, sheetName)
+	if err != nil {
+		return fmt.Errorf("delete category mapping: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: %q", core.ErrCategoryNotFound, sheetName)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"spese/internal/core"
+)
+
+func categoryRuleFromRow(row CategoryRule) core.CategoryRule {
+	return core.CategoryRule{
+		ID:         row.ID,
+		Name:       row.Name,
+		Expression: row.Expression,
+		Primary:    row.PrimaryCategory,
+		Secondary:  row.SecondaryCategory,
+		Priority:   row.Priority,
+		Active:     row.IsActive,
+	}
+}
+
+// CreateCategoryRule stores a new, active rule and returns its ID.
+func (r *SQLiteRepository) CreateCategoryRule(ctx context.Context, rule core.CategoryRule) (int64, error) {
+	row, err := r.queries.CreateCategoryRule(ctx, CreateCategoryRuleParams{
+		Name:              rule.Name,
+		Expression:        rule.Expression,
+		PrimaryCategory:   rule.Primary,
+		SecondaryCategory: rule.Secondary,
+		Priority:          rule.Priority,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("create category rule: %w", err)
+	}
+	return row.ID, nil
+}
+
+// ListCategoryRules returns every rule in evaluation order.
+func (r *SQLiteRepository) ListCategoryRules(ctx context.Context) ([]core.CategoryRule, error) {
+	rows, err := r.reader(ctx).ListCategoryRules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list category rules: %w", err)
+	}
+	rules := make([]core.CategoryRule, 0, len(rows))
+	for _, row := range rows {
+		rules = append(rules, categoryRuleFromRow(row))
+	}
+	return rules, nil
+}
+
+// ListActiveCategoryRules returns the enabled rules in evaluation order.
+func (r *SQLiteRepository) ListActiveCategoryRules(ctx context.Context) ([]core.CategoryRule, error) {
+	rows, err := r.reader(ctx).ListActiveCategoryRules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list active category rules: %w", err)
+	}
+	rules := make([]core.CategoryRule, 0, len(rows))
+	for _, row := range rows {
+		rules = append(rules, categoryRuleFromRow(row))
+	}
+	return rules, nil
+}
+
+// SetCategoryRuleActive enables or disables a rule.
+func (r *SQLiteRepository) SetCategoryRuleActive(ctx context.Context, id int64, active bool) error {
+	n, err := r.queries.SetCategoryRuleActive(ctx, SetCategoryRuleActiveParams{IsActive: active, ID: id})
+	if err != nil {
+		return fmt.Errorf("update category rule: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("category rule not found: %d", id)
+	}
+	return nil
+}
+
+// DeleteCategoryRule removes a rule.
+func (r *SQLiteRepository) DeleteCategoryRule(ctx context.Context, id int64) error {
+	n, err := r.queries.DeleteCategoryRule(ctx, id)
+	if err != nil {
+		return fmt.Errorf("delete category rule: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("category rule not found: %d", id)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"spese/internal/core"
+)
+
+// maxTrainingExpenses bounds the classifier's training set to the most
+// recent expenses, which also reflect the current taxonomy best.
+const maxTrainingExpenses = 5000
+
+// Verdicts of a reviewed classifier proposal
+const (
+	VerdictAccepted = "accepted" // the proposal was applied
+	VerdictRejected = "rejected" // the expense stays uncategorized
+	VerdictTaught   = "taught"   // the user picked another category
+)
+
+// ListTrainingExpenses returns the most recent categorized expenses, with
+// their description and categories only.
+func (r *SQLiteRepository) ListTrainingExpenses(ctx context.Context) ([]core.Expense, error) {
+	rows, err := r.reader(ctx).ListTrainingExpenses(ctx, ListTrainingExpensesParams{
+		Uncategorized: core.UncategorizedSecondary,
+		MaxRows:       maxTrainingExpenses,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list training expenses: %w", err)
+	}
+	expenses := make([]core.Expense, len(rows))
+	for i, row := range rows {
+		expenses[i] = core.Expense{Description: row.Description, Primary: row.PrimaryCategory, Secondary: row.SecondaryCategory}
+	}
+	return expenses, nil
+}
+
+// ListUncategorizedExpenses returns up to limit expenses in the Unknown
+// placeholder category not reviewed yet, newest first.
+func (r *SQLiteRepository) ListUncategorizedExpenses(ctx context.Context, limit int) ([]ExpenseWithID, error) {
+	rows, err := r.reader(ctx).ListUncategorizedExpenses(ctx, ListUncategorizedExpensesParams{
+		SecondaryCategory: core.UncategorizedSecondary,
+		Limit:             int64(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list uncategorized expenses: %w", err)
+	}
+	expenses := make([]ExpenseWithID, len(rows))
+	for i, row := range rows {
+		expenses[i] = ExpenseWithID{
+			ID:        strconv.FormatInt(row.ID, 10),
+			Expense:   expenseFromRow(row),
+			CreatedAt: row.CreatedAt.Time,
+		}
+	}
+	return expenses, nil
+}
+
+// RecategorizeExpense sets the categories of an expense from a reviewed
+// proposal, records the change in the expense history and the verdict
+// (accepted or taught) with the model's confidence.
+func (r *SQLiteRepository) RecategorizeExpense(ctx context.Context, id int64, primary, secondary, verdict string, confidence float64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	txQueries := r.withTx(tx)
+
+	old, err := txQueries.GetExpense(ctx, id)
+	if err != nil {
+		return fmt.Errorf("get expense: %w", err)
+	}
+	if _, err := txQueries.UpdateExpenseCategory(ctx, UpdateExpenseCategoryParams{
+		PrimaryCategory:   primary,
+		SecondaryCategory: secondary,
+		ID:                id,
+	}); err != nil {
+		return fmt.Errorf("update expense category: %w", err)
+	}
+
+	updated := old
+	updated.PrimaryCategory = primary
+	updated.SecondaryCategory = secondary
+	if err := recordExpenseVersion(ctx, txQueries, id, old.Version+1, diffExpenses(&old, updated)); err != nil {
+		return err
+	}
+	if err := auditExpense(ctx, txQueries, id, &old, &updated); err != nil {
+		return err
+	}
+	if err := txQueries.UpsertClassifierFeedback(ctx, UpsertClassifierFeedbackParams{
+		ExpenseID:         id,
+		Verdict:           verdict,
+		PrimaryCategory:   primary,
+		SecondaryCategory: secondary,
+		Confidence:        confidence,
+	}); err != nil {
+		return fmt.Errorf("record classifier feedback: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+
+	slog.InfoContext(ctx, "Expense recategorized", "id", id, "primary", primary, "secondary", secondary, "verdict", verdict)
+	return nil
+}
+
+// RejectClassification records that the user turned down the proposal for
+// an expense, which leaves the review list uncategorized.
+func (r *SQLiteRepository) RejectClassification(ctx context.Context, id int64, primary, secondary string, confidence float64) error {
+	if _, err := r.queries.GetExpense(ctx, id); err != nil {
+		return fmt.Errorf("get expense: %w", err)
+	}
+	if err := r.queries.UpsertClassifierFeedback(ctx, UpsertClassifierFeedbackParams{
+		ExpenseID:         id,
+		Verdict:           VerdictRejected,
+		PrimaryCategory:   primary,
+		SecondaryCategory: secondary,
+		Confidence:        confidence,
+	}); err != nil {
+		return fmt.Errorf("record classifier feedback: %w", err)
+	}
+	return nil
+}
+
+// ClassifierFeedbackCounts returns how many proposals got each verdict.
+func (r *SQLiteRepository) ClassifierFeedbackCounts(ctx context.Context) (map[string]int64, error) {
+	rows, err := r.reader(ctx).CountClassifierFeedback(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("count classifier feedback: %w", err)
+	}
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Verdict] = row.Total
+	}
+	return counts, nil
+}
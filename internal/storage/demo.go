@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"spese/internal/core"
+)
+
+// ReplaceDataset wipes expenses, incomes, recurrent expenses and incomes, categorization
+// rules and their bookkeeping, then stores the given records, all in one
+// transaction so readers never observe an empty database. Categories are
+// left untouched; the audit log starts over, without entries for the
+// records stored. Used to reset the demo dataset.
+func (r *SQLiteRepository) ReplaceDataset(ctx context.Context, expenses []core.Expense, incomes []core.Income, recurrents []core.RecurrentExpenses) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	q := r.withTx(tx)
+
+	for _, wipe := range []func(context.Context) error{
+		q.DeleteAllExpenseVersions,
+		q.DeleteAllSyncQueue,
+		q.DeleteAllSyncErrors,
+		q.DeleteAllExpenses,
+		q.DeleteAllIncomes,
+		q.DeleteAllRecurrentExpenses,
+		q.DeleteAllRecurrentIncomes,
+		q.DeleteAllCategoryRules,
+		q.DeleteAllExpenseReimbursements,
+		q.DeleteAllExpenseWorkflow,
+		q.DeleteAllExpenseCalculations,
+		q.DeleteAllPurchaseWarranties,
+		q.DeleteAllShoppingListItems,
+		q.DeleteAllShoppingLists,
+		q.DeleteAllItemPrices,
+		q.DeleteAllItems,
+		q.DeleteAllExpenseLineItems,
+		q.DeleteAllExpenseSplits,
+		q.DeleteAllUtilityUsage,
+		q.DeleteAllFuelFills,
+		q.DeleteAllCategoryKeywords,
+		q.DeleteAllClassifierFeedback,
+		q.DeleteAllInsights,
+		q.DeleteAllInsightMutes,
+		q.DeleteAllAlertPreferences,
+		q.DeleteAllMonthReviews,
+		q.DeleteAllSheetHistoryImports,
+		q.DeleteAllSavedViews,
+		q.DeleteAllExpenseTags,
+		q.DeleteAllIncomeTags,
+		q.DeleteAllTags,
+		q.DeleteAllUsers,
+		q.DeleteAllAccounts,
+		q.DeleteAllAuditLog,
+		// After the deletes above, whose triggers log tombstones
+		q.DeleteAllPeerTombstones,
+		q.DeleteAllPeerChangelog,
+	} {
+		if err := wipe(ctx); err != nil {
+			return fmt.Errorf("clear dataset: %w", err)
+		}
+	}
+
+	for _, e := range expenses {
+		expense, err := q.CreateExpense(ctx, CreateExpenseParams{
+			Date:              fmt.Sprintf("%04d-%02d-%02d", e.Date.Year(), e.Date.Month(), e.Date.Day()),
+			Description:       e.Description,
+			AmountCents:       e.Amount.Cents,
+			PrimaryCategory:   e.Primary,
+			SecondaryCategory: e.Secondary,
+			Status:            expenseStatus(e),
+		})
+		if err != nil {
+			return fmt.Errorf("create expense: %w", err)
+		}
+		if err := recordExpenseVersion(ctx, q, expense.ID, expense.Version, diffExpenses(nil, expense)); err != nil {
+			return err
+		}
+		if err := setExpenseTags(ctx, q, expense.ID, e.Tags); err != nil {
+			return err
+		}
+	}
+
+	for _, i := range incomes {
+		income, err := q.CreateIncome(ctx, CreateIncomeParams{
+			Date:        fmt.Sprintf("%04d-%02d-%02d", i.Date.Year(), i.Date.Month(), i.Date.Day()),
+			Description: i.Description,
+			AmountCents: i.Amount.Cents,
+			Category:    i.Category,
+			Subcategory: i.Subcategory,
+			Tags:        strings.Join(core.NormalizeTags(i.Tags), ","),
+		})
+		if err != nil {
+			return fmt.Errorf("create income: %w", err)
+		}
+		if err := setIncomeTags(ctx, q, income.ID, income.Tags); err != nil {
+			return err
+		}
+	}
+
+	for _, re := range recurrents {
+		var endDate interface{}
+		if !re.EndDate.IsZero() {
+			endDate = re.EndDate.Time
+		}
+		if _, err := q.CreateRecurrentExpense(ctx, CreateRecurrentExpenseParams{
+			StartDate:         re.StartDate.Time,
+			EndDate:           endDate,
+			RepetitionType:    string(re.Every),
+			Description:       re.Description,
+			AmountCents:       re.Amount.Cents,
+			PrimaryCategory:   re.Primary,
+			SecondaryCategory: re.Secondary,
+			RepeatInterval:    int64(re.Step()),
+			DayOfMonth:        int64(re.DayOfMonth),
+		}); err != nil {
+			return fmt.Errorf("create recurrent expense: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"spese/internal/core"
+)
+
+// FieldChange holds the previous and the new value of a modified expense field.
+// Old is nil for the version that created the expense.
+type FieldChange struct {
+	Old any `json:"old"`
+	New any `json:"new"`
+}
+
+// ExpenseVersionEntry is a decoded row of the expense history
+type ExpenseVersionEntry struct {
+	Version   int64
+	ChangedBy string
+	ChangedAt time.Time
+	Changes   map[string]FieldChange
+}
+
+// diffExpenses returns the changed fields between old and updated.
+// A nil old expense yields every field as new (creation).
+func diffExpenses(old *Expense, updated Expense) map[string]FieldChange {
+	changes := make(map[string]FieldChange)
+	add := func(field string, oldValue, newValue any) {
+		if old != nil && oldValue == newValue {
+			return
+		}
+		var prev any
+		if old != nil {
+			prev = oldValue
+		}
+		changes[field] = FieldChange{Old: prev, New: newValue}
+	}
+
+	var o Expense
+	if old != nil {
+		o = *old
+	}
+	add("date", o.Date.Format("2006-01-02"), updated.Date.Format("2006-01-02"))
+	add("description", o.Description, updated.Description)
+	add("amount_cents", o.AmountCents, updated.AmountCents)
+	add("primary_category", o.PrimaryCategory, updated.PrimaryCategory)
+	add("secondary_category", o.SecondaryCategory, updated.SecondaryCategory)
+	add("status", o.Status, updated.Status)
+	return changes
+}
+
+// recordExpenseVersion stores a history row; the actor is taken from ctx.
+func recordExpenseVersion(ctx context.Context, q *Queries, expenseID, version int64, changes map[string]FieldChange) error {
+	payload, err := json.Marshal(changes)
+	if err != nil {
+		return fmt.Errorf("encode changes: %w", err)
+	}
+	if err := q.CreateExpenseVersion(ctx, CreateExpenseVersionParams{
+		ExpenseID: expenseID,
+		Version:   version,
+		ChangedBy: core.ActorFromContext(ctx),
+		Changes:   string(payload),
+	}); err != nil {
+		return fmt.Errorf("create expense version: %w", err)
+	}
+	return nil
+}
+
+// ListExpenseVersions returns the history of an expense, newest first.
+// History is kept after the expense is deleted.
+func (r *SQLiteRepository) ListExpenseVersions(ctx context.Context, expenseID int64) ([]ExpenseVersionEntry, error) {
+	rows, err := r.reader(ctx).ListExpenseVersions(ctx, expenseID)
+	if err != nil {
+		return nil, fmt.Errorf("list expense versions: %w", err)
+	}
+
+	entries := make([]ExpenseVersionEntry, 0, len(rows))
+	for _, row := range rows {
+		var changes map[string]FieldChange
+		if err := json.Unmarshal([]byte(row.Changes), &changes); err != nil {
+			return nil, fmt.Errorf("decode changes of version %d: %w", row.Version, err)
+		}
+		entries = append(entries, ExpenseVersionEntry{
+			Version:   row.Version,
+			ChangedBy: row.ChangedBy,
+			ChangedAt: row.ChangedAt,
+			Changes:   changes,
+		})
+	}
+	return entries, nil
+}
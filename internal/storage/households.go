@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"spese/internal/core"
+)
+
+// paidBy returns the payer column of an expense, NULL when nobody is set.
+func paidBy(e core.Expense) sql.NullInt64 {
+	return sql.NullInt64{Int64: e.PaidBy, Valid: e.PaidBy != 0}
+}
+
+// CreateUser adds a household member. Names are unique, ignoring case.
+func (r *SQLiteRepository) CreateUser(ctx context.Context, name string) (core.User, error) {
+	u := core.User{Name: strings.TrimSpace(name)}
+	if err := u.Validate(); err != nil {
+		return core.User{}, err
+	}
+	row, err := r.queries.CreateUser(ctx, u.Name)
+	if err != nil {
+		return core.User{}, fmt.Errorf("create user: %w", err)
+	}
+	return core.User{ID: row.ID, Name: row.Name}, nil
+}
+
+// ListUsers returns the household members by name.
+func (r *SQLiteRepository) ListUsers(ctx context.Context) ([]core.User, error) {
+	rows, err := r.reader(ctx).ListUsers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list users: %w", err)
+	}
+	users := make([]core.User, len(rows))
+	for i, row := range rows {
+		users[i] = core.User{ID: row.ID, Name: row.Name}
+	}
+	return users, nil
+}
+
+// DeleteUser removes a household member; their expenses are kept with no
+// payer.
+func (r *SQLiteRepository) DeleteUser(ctx context.Context, id int64) error {
+	n, err := r.queries.DeleteUser(ctx, id)
+	if err != nil {
+		return fmt.Errorf("delete user: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("user not found: %d", id)
+	}
+	return nil
+}
+
+// MonthTotalsByPayer returns the settled expenses of a month per payer,
+// and the total of those without one. Card holds are left out until
+// settled, as their amount can still change.
+func (r *SQLiteRepository) MonthTotalsByPayer(ctx context.Context, year, month int) (map[int64]int64, int64, error) {
+	rows, err := r.reader(ctx).GetMonthTotalsByPayer(ctx, GetMonthTotalsByPayerParams{
+		PRINTF:   int64(year),
+		PRINTF_2: int64(month),
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("get month totals by payer: %w", err)
+	}
+	paid := make(map[int64]int64, len(rows))
+	var unassigned int64
+	for _, row := range rows {
+		if !row.PaidBy.Valid {
+			unassigned = row.TotalAmount
+			continue
+		}
+		paid[row.PaidBy.Int64] = row.TotalAmount
+	}
+	return paid, unassigned, nil
+}
+
+// PayerCategoryTotals returns what a member paid per primary category
+// between two dates, both included, largest first.
+func (r *SQLiteRepository) PayerCategoryTotals(ctx context.Context, userID int64, start, end time.Time) ([]core.CategoryAmount, error) {
+	rows, err := r.reader(ctx).GetPayerCategoryTotals(ctx, GetPayerCategoryTotalsParams{
+		PaidBy:    sql.NullInt64{Int64: userID, Valid: true},
+		StartDate: start.Format("2006-01-02"),
+		EndDate:   end.Format("2006-01-02"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get payer category totals: %w", err)
+	}
+	totals := make([]core.CategoryAmount, len(rows))
+	for i, row := range rows {
+		totals[i] = core.CategoryAmount{Name: row.PrimaryCategory, Amount: core.Money{Cents: row.TotalAmount}}
+	}
+	return totals, nil
+}
@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"spese/internal/core"
+)
+
+var (
+	ErrImportBatchNotFound   = errors.New("import batch not found")           // No import batch with that ID
+	ErrImportBatchRolledBack = errors.New("import batch already rolled back") // Rollback already done
+)
+
+// AppendImportBatchAndEnqueueSync saves the expenses of an import like
+// AppendBatchAndEnqueueSync, tagging the ones it creates with a new import
+// batch for filename so the import can be rolled back as a whole. Settled
+// card holds are not tagged: rolling back must not remove the hold. It
+// returns the batch ID and the stored expense IDs.
+func (r *SQLiteRepository) AppendImportBatchAndEnqueueSync(ctx context.Context, filename string, expenses []core.Expense) (int64, []string, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	q := r.withTx(tx)
+
+	batch, err := q.CreateImportBatch(ctx, filename)
+	if err != nil {
+		return 0, nil, fmt.Errorf("create import batch: %w", err)
+	}
+	saved, created, err := appendBatch(ctx, q, expenses)
+	if err != nil {
+		return 0, nil, err
+	}
+	var tagged int64
+	for i, expense := range saved {
+		if !created[i] {
+			continue
+		}
+		if err := q.AddImportBatchExpense(ctx, AddImportBatchExpenseParams{BatchID: batch.ID, ExpenseID: expense.ID}); err != nil {
+			return 0, nil, fmt.Errorf("tag imported expense: %w", err)
+		}
+		tagged++
+	}
+	if err := q.SetImportBatchExpenses(ctx, SetImportBatchExpensesParams{Expenses: tagged, ID: batch.ID}); err != nil {
+		return 0, nil, fmt.Errorf("update import batch: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, nil, fmt.Errorf("commit transaction: %w", err)
+	}
+	slog.InfoContext(ctx, "Import batch saved", "batch_id", batch.ID, "filename", filename, "expenses", tagged)
+	return batch.ID, savedRefs(ctx, saved), nil
+}
+
+// ListImportBatches returns the latest limit import batches, newest first.
+func (r *SQLiteRepository) ListImportBatches(ctx context.Context, limit int64) ([]ListImportBatchesRow, error) {
+	batches, err := r.reader(ctx).ListImportBatches(ctx, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list import batches: %w", err)
+	}
+	return batches, nil
+}
+
+// RollbackImportBatch deletes the expenses an import created that still
+// exist, all or none: a closed month stops the whole rollback. Expenses
+// not written to Google Sheets yet have their sync items cancelled, the
+// others get a delete item removing their row. It returns how many
+// expenses were deleted.
+func (r *SQLiteRepository) RollbackImportBatch(ctx context.Context, id int64) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	q := r.withTx(tx)
+
+	batch, err := q.GetImportBatch(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("%w: #%d", ErrImportBatchNotFound, id)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("get import batch: %w", err)
+	}
+	if batch.RolledBackAt.Valid {
+		return 0, fmt.Errorf("%w: #%d", ErrImportBatchRolledBack, id)
+	}
+
+	expenses, err := q.ListImportBatchExpenses(ctx, id)
+	if err != nil {
+		return 0, fmt.Errorf("list imported expenses: %w", err)
+	}
+	for _, expense := range expenses {
+		if err := checkMonthOpen(ctx, q, expense.Date); err != nil {
+			return 0, err
+		}
+		cancelled, err := q.CancelPendingSyncItems(ctx, expense.ID)
+		if err != nil {
+			return 0, fmt.Errorf("cancel sync items: %w", err)
+		}
+		rows, err := sheetRows(ctx, q, expense)
+		if err != nil {
+			return 0, err
+		}
+		if err := q.HardDeleteExpense(ctx, expense.ID); err != nil {
+			return 0, fmt.Errorf("delete expense: %w", err)
+		}
+		if err := auditExpense(ctx, q, expense.ID, &expense, nil); err != nil {
+			return 0, err
+		}
+
+		// Card holds never reached the sheet, nor did an expense whose sync
+		// was still waiting
+		reached := expense.SyncStatus.String == "synced" || cancelled == 0
+		if expense.Status == string(core.StatusPending) || !reached {
+			continue
+		}
+		if err := enqueueSheetDelete(ctx, q, expense.ID, rows); err != nil {
+			return 0, err
+		}
+	}
+
+	if _, err := q.MarkImportBatchRolledBack(ctx, id); err != nil {
+		return 0, fmt.Errorf("mark import batch rolled back: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit transaction: %w", err)
+	}
+	slog.InfoContext(ctx, "Import batch rolled back",
+		"batch_id", id, "filename", batch.Filename, "expenses", len(expenses))
+	return len(expenses), nil
+}
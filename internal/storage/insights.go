@@ -0,0 +1,132 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"spese/internal/core"
+)
+
+// RecordInsights stores the insights not recorded yet, skipping the kinds
+// muted for their category, and returns the new ones with their ID.
+func (r *SQLiteRepository) RecordInsights(ctx context.Context, insights []core.Insight) ([]core.Insight, error) {
+	mutes, err := r.queries.ListInsightMutes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list insight mutes: %w", err)
+	}
+	muted := make(map[string]bool, len(mutes))
+	for _, m := range mutes {
+		muted[m.Kind+"\x00"+m.PrimaryCategory] = true
+	}
+
+	var recorded []core.Insight
+	for _, in := range insights {
+		if muted[string(in.Kind)+"\x00"+in.Primary] {
+			continue
+		}
+		id, err := r.queries.CreateInsight(ctx, CreateInsightParams{
+			Kind:            string(in.Kind),
+			PrimaryCategory: in.Primary,
+			Period:          in.Period,
+			OccurredOn:      in.Date.Time,
+			AmountCents:     in.Amount.Cents,
+			BaselineCents:   in.Baseline.Cents,
+			Days:            int64(in.Days),
+			SincePeriod:     in.Since,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			// Already recorded
+			continue
+		}
+		if err != nil {
+			return recorded, fmt.Errorf("record insight: %w", err)
+		}
+		in.ID = id
+		recorded = append(recorded, in)
+	}
+	return recorded, nil
+}
+
+// insightFromRow converts a stored insight
+func insightFromRow(row Insight) core.Insight {
+	return core.Insight{
+		ID:       row.ID,
+		Kind:     core.InsightKind(row.Kind),
+		Primary:  row.PrimaryCategory,
+		Period:   row.Period,
+		Date:     core.Date{Time: row.OccurredOn},
+		Amount:   core.Money{Cents: row.AmountCents},
+		Baseline: core.Money{Cents: row.BaselineCents},
+		Days:     int(row.Days),
+		Since:    row.SincePeriod,
+	}
+}
+
+// ListInsights returns up to limit insights neither dismissed nor muted,
+// newest first.
+func (r *SQLiteRepository) ListInsights(ctx context.Context, limit int) ([]core.Insight, error) {
+	rows, err := r.reader(ctx).ListInsights(ctx, int64(limit))
+	if err != nil {
+		return nil, fmt.Errorf("list insights: %w", err)
+	}
+	insights := make([]core.Insight, len(rows))
+	for i, row := range rows {
+		insights[i] = insightFromRow(row)
+	}
+	return insights, nil
+}
+
+// GetInsight returns an insight, dismissed or not.
+func (r *SQLiteRepository) GetInsight(ctx context.Context, id int64) (core.Insight, error) {
+	row, err := r.reader(ctx).GetInsight(ctx, id)
+	if err != nil {
+		return core.Insight{}, fmt.Errorf("get insight: %w", err)
+	}
+	return insightFromRow(row), nil
+}
+
+// DismissInsight hides an insight from the feed.
+func (r *SQLiteRepository) DismissInsight(ctx context.Context, id int64) error {
+	n, err := r.queries.DismissInsight(ctx, id)
+	if err != nil {
+		return fmt.Errorf("dismiss insight: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("insight not found: %d", id)
+	}
+	return nil
+}
+
+// MuteInsights hides the insights of a kind for a primary category and
+// stops recording new ones.
+func (r *SQLiteRepository) MuteInsights(ctx context.Context, kind core.InsightKind, primary string) error {
+	if err := r.queries.MuteInsight(ctx, MuteInsightParams{Kind: string(kind), PrimaryCategory: primary}); err != nil {
+		return fmt.Errorf("mute insights: %w", err)
+	}
+	return nil
+}
+
+// UnmuteInsights shows the insights of a kind for a primary category
+// again; those missed while muted are recorded at the next detection if
+// their month is still current.
+func (r *SQLiteRepository) UnmuteInsights(ctx context.Context, kind core.InsightKind, primary string) error {
+	n, err := r.queries.UnmuteInsight(ctx, UnmuteInsightParams{Kind: string(kind), PrimaryCategory: primary})
+	if err != nil {
+		return fmt.Errorf("unmute insights: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("insights not muted: %s %s", kind, primary)
+	}
+	return nil
+}
+
+// ListInsightMutes returns the muted insight kinds, by category.
+func (r *SQLiteRepository) ListInsightMutes(ctx context.Context) ([]InsightMute, error) {
+	mutes, err := r.reader(ctx).ListInsightMutes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list insight mutes: %w", err)
+	}
+	return mutes, nil
+}
@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ExpensesWithUnknownCategory returns up to limit expenses whose category
+// pair is not among the known categories, newest first.
+func (r *SQLiteRepository) ExpensesWithUnknownCategory(ctx context.Context, limit int) ([]ListExpensesWithUnknownCategoryRow, error) {
+	rows, err := r.reader(ctx).ListExpensesWithUnknownCategory(ctx, int64(limit))
+	if err != nil {
+		return nil, fmt.Errorf("list expenses with unknown category: %w", err)
+	}
+	return rows, nil
+}
+
+// RecurrentsEndingBeforeStart returns the recurrent expenses and incomes
+// whose end date comes before the start.
+func (r *SQLiteRepository) RecurrentsEndingBeforeStart(ctx context.Context) ([]ListRecurrentsEndingBeforeStartRow, error) {
+	rows, err := r.reader(ctx).ListRecurrentsEndingBeforeStart(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list recurrents ending before start: %w", err)
+	}
+	return rows, nil
+}
+
+// NonPositiveAmounts returns the rows whose amount must be positive but is
+// not.
+func (r *SQLiteRepository) NonPositiveAmounts(ctx context.Context) ([]ListNonPositiveAmountsRow, error) {
+	rows, err := r.reader(ctx).ListNonPositiveAmounts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list non-positive amounts: %w", err)
+	}
+	return rows, nil
+}
+
+// OrphanReceipts returns the receipts of expenses that no longer exist.
+func (r *SQLiteRepository) OrphanReceipts(ctx context.Context) ([]ListOrphanReceiptsRow, error) {
+	rows, err := r.reader(ctx).ListOrphanReceipts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list orphan receipts: %w", err)
+	}
+	return rows, nil
+}
+
+// ExpenseSyncFlags returns the sync flag of the cleared expenses dated in
+// [from, to), with whether a queue item is still open for them.
+func (r *SQLiteRepository) ExpenseSyncFlags(ctx context.Context, from, to time.Time) ([]ListExpenseSyncFlagsRow, error) {
+	rows, err := r.reader(ctx).ListExpenseSyncFlags(ctx, ListExpenseSyncFlagsParams{
+		FromDate: from.Format("2006-01-02"),
+		ToDate:   to.Format("2006-01-02"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list expense sync flags: %w", err)
+	}
+	return rows, nil
+}
@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"spese/internal/core"
+)
+
+// StartJobRun records the start of a run of a background job and returns
+// its ID.
+func (r *SQLiteRepository) StartJobRun(ctx context.Context, job string, source core.JobSource, attempt int, at time.Time) (int64, error) {
+	row, err := r.queries.CreateJobRun(ctx, CreateJobRunParams{
+		Job:       job,
+		Source:    string(source),
+		Attempt:   int64(attempt),
+		StartedAt: at,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("start job run: %w", err)
+	}
+	return row.ID, nil
+}
+
+// FinishJobRun records how a run ended, then deletes the runs of the job
+// but the latest keep.
+func (r *SQLiteRepository) FinishJobRun(ctx context.Context, run core.JobRun, keep int) error {
+	if err := r.queries.FinishJobRun(ctx, FinishJobRunParams{
+		Status:     string(run.Status),
+		FinishedAt: sql.NullTime{Time: run.FinishedAt, Valid: !run.FinishedAt.IsZero()},
+		Result:     run.Result,
+		Error:      run.Error,
+		ID:         run.ID,
+	}); err != nil {
+		return fmt.Errorf("finish job run: %w", err)
+	}
+	if err := r.queries.PruneJobRuns(ctx, PruneJobRunsParams{Job: run.Job, Keep: int64(keep)}); err != nil {
+		return fmt.Errorf("prune job runs: %w", err)
+	}
+	return nil
+}
+
+// LatestJobRuns returns the latest run of each job, by job name.
+func (r *SQLiteRepository) LatestJobRuns(ctx context.Context) ([]core.JobRun, error) {
+	rows, err := r.reader(ctx).GetLatestJobRuns(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get latest job runs: %w", err)
+	}
+	runs := make([]core.JobRun, len(rows))
+	for i, row := range rows {
+		runs[i] = jobRunFromRow(row)
+	}
+	return runs, nil
+}
+
+// JobRuns returns the latest runs of a job, newest first.
+func (r *SQLiteRepository) JobRuns(ctx context.Context, job string, limit int) ([]core.JobRun, error) {
+	rows, err := r.reader(ctx).GetJobRuns(ctx, GetJobRunsParams{Job: job, Limit: int64(limit)})
+	if err != nil {
+		return nil, fmt.Errorf("get job runs: %w", err)
+	}
+	runs := make([]core.JobRun, len(rows))
+	for i, row := range rows {
+		runs[i] = jobRunFromRow(row)
+	}
+	return runs, nil
+}
+
+// InterruptJobRuns marks the runs still recorded as running as
+// interrupted, at startup, and returns how many there were.
+func (r *SQLiteRepository) InterruptJobRuns(ctx context.Context, at time.Time) (int64, error) {
+	n, err := r.queries.InterruptRunningJobs(ctx, sql.NullTime{Time: at, Valid: true})
+	if err != nil {
+		return 0, fmt.Errorf("interrupt running jobs: %w", err)
+	}
+	return n, nil
+}
+
+func jobRunFromRow(row Job) core.JobRun {
+	run := core.JobRun{
+		ID:        row.ID,
+		Job:       row.Job,
+		Source:    core.JobSource(row.Source),
+		Attempt:   int(row.Attempt),
+		Status:    core.JobStatus(row.Status),
+		StartedAt: row.StartedAt,
+		Result:    row.Result,
+		Error:     row.Error,
+	}
+	if row.FinishedAt.Valid {
+		run.FinishedAt = row.FinishedAt.Time
+	}
+	return run
+}
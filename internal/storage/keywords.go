@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"spese/internal/core"
+)
+
+// ListCategoryKeywords returns the user overrides of the keyword
+// dictionaries, ordered by keyword.
+func (r *SQLiteRepository) ListCategoryKeywords(ctx context.Context) ([]core.CategoryKeyword, error) {
+	rows, err := r.reader(ctx).ListCategoryKeywords(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list category keywords: %w", err)
+	}
+	keywords := make([]core.CategoryKeyword, 0, len(rows))
+	for _, row := range rows {
+		keywords = append(keywords, core.CategoryKeyword{
+			Keyword:   row.Keyword,
+			Primary:   row.PrimaryCategory,
+			Secondary: row.SecondaryCategory,
+			Disabled:  row.Disabled,
+		})
+	}
+	return keywords, nil
+}
+
+// SetCategoryKeyword stores an override, replacing the one with the same
+// keyword.
+func (r *SQLiteRepository) SetCategoryKeyword(ctx context.Context, k core.CategoryKeyword) error {
+	if err := k.Validate(); err != nil {
+		return err
+	}
+	if k.Disabled {
+		k.Primary, k.Secondary = "", ""
+	}
+	err := r.queries.UpsertCategoryKeyword(ctx, UpsertCategoryKeywordParams{
+		Keyword:           k.Keyword,
+		PrimaryCategory:   k.Primary,
+		SecondaryCategory: k.Secondary,
+		Disabled:          k.Disabled,
+	})
+	if err != nil {
+		return fmt.Errorf("set category keyword: %w", err)
+	}
+	return nil
+}
+
+// DeleteCategoryKeyword removes an override, restoring the builtin entry
+// if there is one.
+func (r *SQLiteRepository) DeleteCategoryKeyword(ctx context.Context, keyword string) error {
+	n, err := r.queries.DeleteCategoryKeyword(ctx, keyword)
+	if err != nil {
+		return fmt.Errorf("delete category keyword: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("category keyword not found: %s", keyword)
+	}
+	return nil
+}
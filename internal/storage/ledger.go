@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+
+	"spese/internal/core"
+)
+
+// LedgerPage is a page of the expenses of a date range, newest first
+type LedgerPage struct {
+	Expenses []ExpenseWithID
+	Next     *core.LedgerCursor // Where the next page starts; nil on the last one
+}
+
+// ListLedger returns up to limit expenses dated between from and to,
+// inclusive, newest first, starting right after the cursor (from the
+// newest when nil). Pages are keyed on the position of their last expense
+// rather than an offset, so deep pages cost the same as the first and
+// expenses added meanwhile do not shift them.
+func (r *SQLiteRepository) ListLedger(ctx context.Context, from, to core.Date, after *core.LedgerCursor, limit int) (LedgerPage, error) {
+	params := ListLedgerExpensesParams{
+		FromDate:  from.Format("2006-01-02"),
+		ToDate:    to.Format("2006-01-02"),
+		AfterDate: to.Format("2006-01-02"),
+		AfterID:   math.MaxInt64,
+		PageSize:  int64(limit) + 1, // One more tells whether a next page exists
+	}
+	if after != nil {
+		params.AfterDate = after.Date.Format("2006-01-02")
+		params.AfterID = after.ID
+	}
+
+	rows, err := r.reader(ctx).ListLedgerExpenses(ctx, params)
+	if err != nil {
+		return LedgerPage{}, fmt.Errorf("list ledger expenses: %w", err)
+	}
+
+	var page LedgerPage
+	if len(rows) > limit {
+		rows = rows[:limit]
+		last := rows[len(rows)-1]
+		page.Next = &core.LedgerCursor{Date: core.Date{Time: last.Date}, ID: last.ID}
+	}
+	page.Expenses = make([]ExpenseWithID, len(rows))
+	for i, e := range rows {
+		page.Expenses[i] = ExpenseWithID{
+			ID:        strconv.FormatInt(e.ID, 10),
+			Expense:   expenseFromRow(e),
+			CreatedAt: e.CreatedAt.Time,
+		}
+	}
+	return page, nil
+}
+
+// LedgerTotals returns how many expenses are dated between from and to,
+// inclusive, and their total.
+func (r *SQLiteRepository) LedgerTotals(ctx context.Context, from, to core.Date) (int, core.Money, error) {
+	row, err := r.reader(ctx).GetLedgerTotals(ctx, GetLedgerTotalsParams{
+		FromDate: from.Format("2006-01-02"),
+		ToDate:   to.Format("2006-01-02"),
+	})
+	if err != nil {
+		return 0, core.Money{}, fmt.Errorf("get ledger totals: %w", err)
+	}
+	return int(row.Expenses), core.Money{Cents: row.TotalCents}, nil
+}
+
+// ExpenseFingerprints returns how many expenses dated between from and
+// to, inclusive, share each fingerprint (see core.ExpenseFingerprint).
+func (r *SQLiteRepository) ExpenseFingerprints(ctx context.Context, from, to core.Date) (map[string]int, error) {
+	rows, err := r.reader(ctx).ListExpenseFingerprints(ctx, ListExpenseFingerprintsParams{
+		FromDate: from.Format("2006-01-02"),
+		ToDate:   to.Format("2006-01-02"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list expense fingerprints: %w", err)
+	}
+	counts := make(map[string]int, len(rows))
+	for _, row := range rows {
+		counts[core.ExpenseFingerprint(core.Expense{
+			Date:        core.Date{Time: row.Date},
+			Description: row.Description,
+			Amount:      core.Money{Cents: row.AmountCents},
+		})]++
+	}
+	return counts, nil
+}
+
+// FindSimilarExpenses returns the live expenses dated on the day of e, with
+// its amount and a similar description (see core.SimilarDescriptions),
+// oldest first.
+func (r *SQLiteRepository) FindSimilarExpenses(ctx context.Context, e core.Expense) ([]core.ReviewExpense, error) {
+	rows, err := r.reader(ctx).FindSimilarExpenses(ctx, FindSimilarExpensesParams{
+		Date:        e.Date.Format("2006-01-02"),
+		AmountCents: e.Amount.Cents,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("find similar expenses: %w", err)
+	}
+	var similar []core.ReviewExpense
+	for _, row := range rows {
+		if core.SimilarDescriptions(row.Description, e.Description) {
+			similar = append(similar, core.ReviewExpense{ID: row.ID, Expense: expenseFromRow(row)})
+		}
+	}
+	return similar, nil
+}
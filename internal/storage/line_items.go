@@ -0,0 +1,170 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+
+	"spese/internal/core"
+)
+
+// SetLineItemCategories makes ReadMonthOverview count the line items of an
+// expense under their own primary categories instead of the expense's.
+func (r *SQLiteRepository) SetLineItemCategories(enabled bool) {
+	r.lineItemCategories = enabled
+}
+
+// SetExpenseLineItems replaces the line items of an expense; no lines
+// removes them. Their unit prices replace the ones the expense gave to the
+// price history.
+func (r *SQLiteRepository) SetExpenseLineItems(ctx context.Context, expenseID int64, lines []core.LineItem) error {
+	if len(lines) > core.MaxLineItems {
+		return fmt.Errorf("too many line items (max %d)", core.MaxLineItems)
+	}
+	for i, l := range lines {
+		if err := l.Validate(); err != nil {
+			return fmt.Errorf("line %d: %w", i+1, err)
+		}
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	txQueries := r.withTx(tx)
+
+	expense, err := txQueries.GetExpense(ctx, expenseID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("expense not found: %d", expenseID)
+	}
+	if err != nil {
+		return fmt.Errorf("get expense: %w", err)
+	}
+	if err := txQueries.DeleteExpenseLineItems(ctx, expenseID); err != nil {
+		return fmt.Errorf("delete line items: %w", err)
+	}
+	if err := txQueries.DeleteItemPricesByExpense(ctx, DeleteItemPricesByExpenseParams{
+		ExpenseID: expenseID,
+		Source:    string(core.PriceFromLineItem),
+	}); err != nil {
+		return fmt.Errorf("delete item prices: %w", err)
+	}
+
+	for i, l := range lines {
+		if err := txQueries.CreateExpenseLineItem(ctx, CreateExpenseLineItemParams{
+			ExpenseID:         expenseID,
+			Position:          int64(i),
+			Description:       l.Description,
+			Quantity:          l.Quantity,
+			AmountCents:       l.Amount.Cents,
+			PrimaryCategory:   l.Primary,
+			SecondaryCategory: l.Secondary,
+		}); err != nil {
+			return fmt.Errorf("create line item: %w", err)
+		}
+		if err := recordLineItemPrice(ctx, txQueries, expense, l.Description, l.Quantity, l.Amount); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// ListExpenseLineItems returns the line items of an expense in order.
+func (r *SQLiteRepository) ListExpenseLineItems(ctx context.Context, expenseID int64) ([]core.LineItem, error) {
+	rows, err := r.reader(ctx).ListExpenseLineItems(ctx, expenseID)
+	if err != nil {
+		return nil, fmt.Errorf("list line items: %w", err)
+	}
+	lines := make([]core.LineItem, len(rows))
+	for i, row := range rows {
+		lines[i] = core.LineItem{
+			Description: row.Description,
+			Quantity:    row.Quantity,
+			Amount:      core.Money{Cents: row.AmountCents},
+			Primary:     row.PrimaryCategory,
+			Secondary:   row.SecondaryCategory,
+		}
+	}
+	return lines, nil
+}
+
+// applyLineItemCategories moves the part of each expense its line items
+// account for to their primary categories. The month total is unchanged.
+// Split expenses are skipped: their parts already set the categories.
+func (r *SQLiteRepository) applyLineItemCategories(ctx context.Context, overview *core.MonthOverview, split map[int64]bool) error {
+	rows, err := r.reader(ctx).ListMonthLineItems(ctx, ListMonthLineItemsParams{
+		PRINTF:   int64(overview.Year),
+		PRINTF_2: int64(overview.Month),
+	})
+	if err != nil {
+		return fmt.Errorf("list month line items: %w", err)
+	}
+
+	deltas := make(map[string]int64)
+	for start := 0; start < len(rows); {
+		end := start
+		for end < len(rows) && rows[end].ExpenseID == rows[start].ExpenseID {
+			end++
+		}
+		expense := rows[start]
+		lines := make([]core.LineItem, end-start)
+		for i, row := range rows[start:end] {
+			lines[i] = core.LineItem{Amount: core.Money{Cents: row.AmountCents}, Primary: row.PrimaryCategory}
+		}
+		start = end
+
+		if split[expense.ExpenseID] {
+			continue
+		}
+		// Excluded card holds are not in the categories to move from
+		if r.excludePending && expense.Status == string(core.StatusPending) {
+			continue
+		}
+		for i, amount := range core.AllocateLineItems(core.Money{Cents: expense.ExpenseAmountCents}, lines) {
+			if lines[i].Primary == "" || lines[i].Primary == expense.ExpensePrimaryCategory {
+				continue
+			}
+			deltas[expense.ExpensePrimaryCategory] -= amount.Cents
+			deltas[lines[i].Primary] += amount.Cents
+		}
+	}
+	moveCategoryAmounts(overview, deltas)
+	return nil
+}
+
+// moveCategoryAmounts adds deltas by primary category to the month
+// categories, dropping the ones left empty and keeping the largest first.
+func moveCategoryAmounts(overview *core.MonthOverview, deltas map[string]int64) {
+	if len(deltas) == 0 {
+		return
+	}
+
+	categories := overview.ByCategory[:0]
+	for _, c := range overview.ByCategory {
+		c.Amount.Cents += deltas[c.Name]
+		delete(deltas, c.Name)
+		if c.Amount.Cents > 0 {
+			categories = append(categories, c)
+		}
+	}
+	for name, cents := range deltas {
+		if cents > 0 {
+			categories = append(categories, core.CategoryAmount{Name: name, Amount: core.Money{Cents: cents}})
+		}
+	}
+	sort.SliceStable(categories, func(i, j int) bool {
+		if categories[i].Amount.Cents != categories[j].Amount.Cents {
+			return categories[i].Amount.Cents > categories[j].Amount.Cents
+		}
+		return categories[i].Name < categories[j].Name
+	})
+	overview.ByCategory = categories
+}
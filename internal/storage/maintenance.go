@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// autoVacuumIncremental is the auto_vacuum mode that lets
+// PRAGMA incremental_vacuum give free pages back to the file system
+const autoVacuumIncremental = 2
+
+// MaintenanceResult is what a run of Maintain did
+type MaintenanceResult struct {
+	At                 time.Time
+	CheckpointDuration time.Duration
+	CheckpointBusy     bool  // Readers kept the WAL from being truncated
+	CheckpointedFrames int64 // WAL frames written back to the database
+	FreedBytes         int64 // Space given back by the vacuum
+	Converted          bool  // The run switched the database to incremental auto-vacuum
+}
+
+// DatabaseStats is the size of the database files
+type DatabaseStats struct {
+	SizeBytes     int64 // Pages of the database file, free ones included
+	FreelistBytes int64 // Free pages a vacuum can give back
+	WALBytes      int64 // The write-ahead log, 0 outside WAL mode
+	// The last run of Maintain in this process; nil before the first
+	LastMaintenance *MaintenanceResult
+}
+
+// Maintain keeps a long-running database compact: it writes the WAL back
+// and truncates it, gives free pages back to the file system and refreshes
+// the statistics the query planner uses. The first run on a database made
+// before incremental auto-vacuum switches it over with a full VACUUM,
+// which rewrites the whole file once.
+func (r *SQLiteRepository) Maintain(ctx context.Context) (MaintenanceResult, error) {
+	res := MaintenanceResult{At: time.Now()}
+
+	// In rollback journal mode the checkpoint does nothing and reports -1
+	start := time.Now()
+	var busy, logFrames, checkpointed int64
+	if err := r.db.QueryRowContext(ctx, "PRAGMA wal_checkpoint(TRUNCATE)").Scan(&busy, &logFrames, &checkpointed); err != nil {
+		return res, fmt.Errorf("checkpoint WAL: %w", err)
+	}
+	res.CheckpointDuration = time.Since(start)
+	res.CheckpointBusy = busy != 0
+	res.CheckpointedFrames = max(checkpointed, 0)
+
+	before, err := r.pageStats(ctx)
+	if err != nil {
+		return res, err
+	}
+	var mode int
+	if err := r.db.QueryRowContext(ctx, "PRAGMA auto_vacuum").Scan(&mode); err != nil {
+		return res, fmt.Errorf("read auto_vacuum: %w", err)
+	}
+	if mode != autoVacuumIncremental {
+		// The mode applies on the next VACUUM, which must run on the
+		// connection that set it
+		conn, err := r.db.Conn(ctx)
+		if err != nil {
+			return res, fmt.Errorf("get connection: %w", err)
+		}
+		defer conn.Close()
+		if _, err := conn.ExecContext(ctx, "PRAGMA auto_vacuum = INCREMENTAL"); err != nil {
+			return res, fmt.Errorf("set auto_vacuum: %w", err)
+		}
+		if _, err := conn.ExecContext(ctx, "VACUUM"); err != nil {
+			return res, fmt.Errorf("vacuum: %w", err)
+		}
+		res.Converted = true
+	} else if _, err := r.db.ExecContext(ctx, "PRAGMA incremental_vacuum"); err != nil {
+		return res, fmt.Errorf("incremental vacuum: %w", err)
+	}
+	after, err := r.pageStats(ctx)
+	if err != nil {
+		return res, err
+	}
+	res.FreedBytes = max(before.SizeBytes-after.SizeBytes, 0)
+
+	if _, err := r.db.ExecContext(ctx, "ANALYZE"); err != nil {
+		return res, fmt.Errorf("analyze: %w", err)
+	}
+
+	r.lastMaintenance.Store(&res)
+	slog.InfoContext(ctx, "Database maintenance done",
+		"checkpoint_duration", res.CheckpointDuration,
+		"checkpoint_busy", res.CheckpointBusy,
+		"checkpointed_frames", res.CheckpointedFrames,
+		"freed_bytes", res.FreedBytes,
+		"converted_to_incremental_vacuum", res.Converted,
+		"size_bytes", after.SizeBytes)
+	return res, nil
+}
+
+// DatabaseStats returns the size of the database and of its WAL.
+func (r *SQLiteRepository) DatabaseStats(ctx context.Context) (DatabaseStats, error) {
+	stats, err := r.pageStats(ctx)
+	if err != nil {
+		return stats, err
+	}
+
+	var seq int
+	var name, file string
+	if err := r.db.QueryRowContext(ctx, "PRAGMA database_list").Scan(&seq, &name, &file); err != nil {
+		return stats, fmt.Errorf("read database file: %w", err)
+	}
+	if file != "" {
+		info, err := os.Stat(file + "-wal")
+		switch {
+		case err == nil:
+			stats.WALBytes = info.Size()
+		case !errors.Is(err, fs.ErrNotExist):
+			return stats, fmt.Errorf("stat WAL: %w", err)
+		}
+	}
+	stats.LastMaintenance = r.lastMaintenance.Load()
+	return stats, nil
+}
+
+// pageStats reads the database and free list sizes from the page counts
+func (r *SQLiteRepository) pageStats(ctx context.Context) (DatabaseStats, error) {
+	var pageSize, pages, free int64
+	for _, p := range []struct {
+		pragma string
+		dst    *int64
+	}{{"page_size", &pageSize}, {"page_count", &pages}, {"freelist_count", &free}} {
+		if err := r.db.QueryRowContext(ctx, "PRAGMA "+p.pragma).Scan(p.dst); err != nil {
+			return DatabaseStats{}, fmt.Errorf("read %s: %w", p.pragma, err)
+		}
+	}
+	return DatabaseStats{SizeBytes: pages * pageSize, FreelistBytes: free * pageSize}, nil
+}
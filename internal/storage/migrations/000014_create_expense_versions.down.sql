@@ -0,0 +1,3 @@
+ALTER TABLE sync_queue DROP COLUMN expense_version;
+DROP INDEX IF EXISTS idx_expense_versions_expense_id;
+DROP TABLE IF EXISTS expense_versions;
@@ -0,0 +1,16 @@
+-- Expense versions: one row per modification with the field diff
+CREATE TABLE expense_versions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    expense_id INTEGER NOT NULL,
+    version INTEGER NOT NULL,
+    changed_by TEXT NOT NULL DEFAULT 'system',
+    -- JSON object: {"field": {"old": ..., "new": ...}}
+    changes TEXT NOT NULL,
+    changed_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- History is kept after hard deletes, so no foreign key on expense_id
+CREATE INDEX idx_expense_versions_expense_id ON expense_versions(expense_id, version);
+
+-- Expense version the sync item was enqueued for, used to skip stale items
+ALTER TABLE sync_queue ADD COLUMN expense_version INTEGER NULL;
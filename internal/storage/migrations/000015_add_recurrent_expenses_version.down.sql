@@ -0,0 +1 @@
+ALTER TABLE recurrent_expenses DROP COLUMN version;
@@ -0,0 +1 @@
+ALTER TABLE recurrent_expenses ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
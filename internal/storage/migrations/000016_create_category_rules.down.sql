@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_category_rules_active;
+DROP TABLE IF EXISTS category_rules;
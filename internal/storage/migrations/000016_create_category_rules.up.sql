@@ -0,0 +1,14 @@
+-- Categorization rules: the first active rule (by priority, then id) whose
+-- expression matches a new expense sets its categories
+CREATE TABLE category_rules (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    expression TEXT NOT NULL,
+    primary_category TEXT NOT NULL,
+    secondary_category TEXT NOT NULL,
+    priority INTEGER NOT NULL DEFAULT 0,
+    is_active BOOLEAN NOT NULL DEFAULT 1,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_category_rules_active ON category_rules(is_active, priority);
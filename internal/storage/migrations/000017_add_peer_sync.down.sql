@@ -0,0 +1,18 @@
+DROP TRIGGER IF EXISTS incomes_peer_delete;
+DROP TRIGGER IF EXISTS incomes_peer_update;
+DROP TRIGGER IF EXISTS incomes_peer_insert;
+DROP TRIGGER IF EXISTS expenses_peer_delete;
+DROP TRIGGER IF EXISTS expenses_peer_update;
+DROP TRIGGER IF EXISTS expenses_peer_insert;
+
+DROP TABLE IF EXISTS peer_sync_state;
+DROP TABLE IF EXISTS peer_changelog;
+DROP TABLE IF EXISTS peer_tombstones;
+
+DROP INDEX IF EXISTS idx_incomes_uid;
+DROP INDEX IF EXISTS idx_expenses_uid;
+
+ALTER TABLE incomes DROP COLUMN modified_at;
+ALTER TABLE incomes DROP COLUMN uid;
+ALTER TABLE expenses DROP COLUMN modified_at;
+ALTER TABLE expenses DROP COLUMN uid;
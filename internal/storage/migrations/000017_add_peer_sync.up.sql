@@ -0,0 +1,114 @@
+-- Peer sync: records get an instance-independent uid, and triggers keep a
+-- change log (cursor for peers) and tombstones for deleted records.
+ALTER TABLE expenses ADD COLUMN uid TEXT NULL;
+ALTER TABLE expenses ADD COLUMN modified_at TEXT NULL;
+ALTER TABLE incomes ADD COLUMN uid TEXT NULL;
+ALTER TABLE incomes ADD COLUMN modified_at TEXT NULL;
+
+-- Existing records get a uid derived from their content (plus a counter for
+-- identical rows), so instances holding the same data, such as the seeded
+-- history, recognise each other's records instead of duplicating them.
+UPDATE expenses SET uid = (
+    SELECT lower(hex(k.date || '|' || k.amount_cents || '|' || k.description || '|' || k.primary_category || '|' || k.secondary_category)) || '-' || k.n
+    FROM (
+        SELECT id, date, amount_cents, description, primary_category, secondary_category,
+               ROW_NUMBER() OVER (PARTITION BY date, amount_cents, description, primary_category, secondary_category ORDER BY id) AS n
+        FROM expenses
+    ) k
+    WHERE k.id = expenses.id
+), modified_at = strftime('%Y-%m-%dT%H:%M:%fZ', COALESCE(created_at, 'now'));
+
+UPDATE incomes SET uid = (
+    SELECT lower(hex(k.date || '|' || k.amount_cents || '|' || k.description || '|' || k.category)) || '-' || k.n
+    FROM (
+        SELECT id, date, amount_cents, description, category,
+               ROW_NUMBER() OVER (PARTITION BY date, amount_cents, description, category ORDER BY id) AS n
+        FROM incomes
+    ) k
+    WHERE k.id = incomes.id
+), modified_at = strftime('%Y-%m-%dT%H:%M:%fZ', COALESCE(created_at, 'now'));
+
+CREATE UNIQUE INDEX idx_expenses_uid ON expenses(uid);
+CREATE UNIQUE INDEX idx_incomes_uid ON incomes(uid);
+
+-- Deleted records, kept so deletes propagate and are not resurrected
+CREATE TABLE peer_tombstones (
+    kind TEXT NOT NULL CHECK (kind IN ('expense', 'income')),
+    uid TEXT NOT NULL,
+    version INTEGER NOT NULL,
+    deleted_at TEXT NOT NULL,
+    PRIMARY KEY (kind, uid)
+);
+
+-- Latest change per record; seq is the cursor peers pull from
+CREATE TABLE peer_changelog (
+    seq INTEGER PRIMARY KEY AUTOINCREMENT,
+    kind TEXT NOT NULL CHECK (kind IN ('expense', 'income')),
+    uid TEXT NOT NULL,
+    UNIQUE (kind, uid)
+);
+
+INSERT INTO peer_changelog (kind, uid) SELECT 'expense', uid FROM expenses ORDER BY id;
+INSERT INTO peer_changelog (kind, uid) SELECT 'income', uid FROM incomes ORDER BY id;
+
+-- Sync progress per peer: last change seq pulled from it and pushed to it
+CREATE TABLE peer_sync_state (
+    peer TEXT PRIMARY KEY,
+    pull_cursor INTEGER NOT NULL DEFAULT 0,
+    push_cursor INTEGER NOT NULL DEFAULT 0,
+    last_sync_at DATETIME NULL
+);
+
+-- Inserts keep a uid and modified_at given by a peer, or assign new ones
+CREATE TRIGGER expenses_peer_insert AFTER INSERT ON expenses
+BEGIN
+    UPDATE expenses
+    SET uid = COALESCE(NEW.uid, lower(hex(randomblob(16)))),
+        modified_at = COALESCE(NEW.modified_at, strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+    WHERE id = NEW.id;
+    INSERT OR REPLACE INTO peer_changelog (kind, uid) SELECT 'expense', uid FROM expenses WHERE id = NEW.id;
+END;
+
+-- Only data columns count as changes, not sync bookkeeping
+CREATE TRIGGER expenses_peer_update AFTER UPDATE OF date, description, amount_cents, primary_category, secondary_category, version ON expenses
+BEGIN
+    UPDATE expenses
+    SET modified_at = CASE WHEN NEW.modified_at IS OLD.modified_at THEN strftime('%Y-%m-%dT%H:%M:%fZ', 'now') ELSE NEW.modified_at END
+    WHERE id = NEW.id;
+    INSERT OR REPLACE INTO peer_changelog (kind, uid) VALUES ('expense', NEW.uid);
+END;
+
+CREATE TRIGGER expenses_peer_delete AFTER DELETE ON expenses
+WHEN OLD.uid IS NOT NULL
+BEGIN
+    INSERT INTO peer_tombstones (kind, uid, version, deleted_at)
+    VALUES ('expense', OLD.uid, OLD.version, strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+    ON CONFLICT (kind, uid) DO UPDATE SET version = max(version, excluded.version), deleted_at = excluded.deleted_at;
+    INSERT OR REPLACE INTO peer_changelog (kind, uid) VALUES ('expense', OLD.uid);
+END;
+
+CREATE TRIGGER incomes_peer_insert AFTER INSERT ON incomes
+BEGIN
+    UPDATE incomes
+    SET uid = COALESCE(NEW.uid, lower(hex(randomblob(16)))),
+        modified_at = COALESCE(NEW.modified_at, strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+    WHERE id = NEW.id;
+    INSERT OR REPLACE INTO peer_changelog (kind, uid) SELECT 'income', uid FROM incomes WHERE id = NEW.id;
+END;
+
+CREATE TRIGGER incomes_peer_update AFTER UPDATE OF date, description, amount_cents, category, version ON incomes
+BEGIN
+    UPDATE incomes
+    SET modified_at = CASE WHEN NEW.modified_at IS OLD.modified_at THEN strftime('%Y-%m-%dT%H:%M:%fZ', 'now') ELSE NEW.modified_at END
+    WHERE id = NEW.id;
+    INSERT OR REPLACE INTO peer_changelog (kind, uid) VALUES ('income', NEW.uid);
+END;
+
+CREATE TRIGGER incomes_peer_delete AFTER DELETE ON incomes
+WHEN OLD.uid IS NOT NULL
+BEGIN
+    INSERT INTO peer_tombstones (kind, uid, version, deleted_at)
+    VALUES ('income', OLD.uid, OLD.version, strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+    ON CONFLICT (kind, uid) DO UPDATE SET version = max(version, excluded.version), deleted_at = excluded.deleted_at;
+    INSERT OR REPLACE INTO peer_changelog (kind, uid) VALUES ('income', OLD.uid);
+END;
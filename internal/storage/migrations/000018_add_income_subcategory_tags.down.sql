@@ -0,0 +1,13 @@
+DROP TRIGGER IF EXISTS incomes_peer_update;
+CREATE TRIGGER incomes_peer_update AFTER UPDATE OF date, description, amount_cents, category, version ON incomes
+BEGIN
+    UPDATE incomes
+    SET modified_at = CASE WHEN NEW.modified_at IS OLD.modified_at THEN strftime('%Y-%m-%dT%H:%M:%fZ', 'now') ELSE NEW.modified_at END
+    WHERE id = NEW.id;
+    INSERT OR REPLACE INTO peer_changelog (kind, uid) VALUES ('income', NEW.uid);
+END;
+
+DROP INDEX IF EXISTS idx_incomes_category_subcategory;
+
+ALTER TABLE incomes DROP COLUMN tags;
+ALTER TABLE incomes DROP COLUMN subcategory;
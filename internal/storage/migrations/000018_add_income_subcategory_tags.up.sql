@@ -0,0 +1,17 @@
+-- Optional second-level category and free tags for incomes, so salary,
+-- bonus and reimbursements can be told apart. Tags are stored normalized
+-- and comma-separated.
+ALTER TABLE incomes ADD COLUMN subcategory TEXT NOT NULL DEFAULT '';
+ALTER TABLE incomes ADD COLUMN tags TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX idx_incomes_category_subcategory ON incomes(category, subcategory);
+
+-- Peer sync must notice changes to the new columns
+DROP TRIGGER incomes_peer_update;
+CREATE TRIGGER incomes_peer_update AFTER UPDATE OF date, description, amount_cents, category, subcategory, tags, version ON incomes
+BEGIN
+    UPDATE incomes
+    SET modified_at = CASE WHEN NEW.modified_at IS OLD.modified_at THEN strftime('%Y-%m-%dT%H:%M:%fZ', 'now') ELSE NEW.modified_at END
+    WHERE id = NEW.id;
+    INSERT OR REPLACE INTO peer_changelog (kind, uid) VALUES ('income', NEW.uid);
+END;
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS expense_reimbursements_income_delete;
+DROP TRIGGER IF EXISTS expense_reimbursements_expense_delete;
+DROP INDEX IF EXISTS idx_expense_reimbursements_income;
+DROP TABLE IF EXISTS expense_reimbursements;
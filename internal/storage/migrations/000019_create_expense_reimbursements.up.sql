@@ -0,0 +1,22 @@
+-- Reimbursement links: the part of an expense covered by an income (e.g. a
+-- company refund). Foreign keys are not enforced, so triggers drop the links
+-- when either side is deleted.
+CREATE TABLE expense_reimbursements (
+    expense_id INTEGER NOT NULL,
+    income_id INTEGER NOT NULL,
+    amount_cents INTEGER NOT NULL CHECK (amount_cents > 0),
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (expense_id, income_id)
+);
+
+CREATE INDEX idx_expense_reimbursements_income ON expense_reimbursements(income_id);
+
+CREATE TRIGGER expense_reimbursements_expense_delete AFTER DELETE ON expenses
+BEGIN
+    DELETE FROM expense_reimbursements WHERE expense_id = OLD.id;
+END;
+
+CREATE TRIGGER expense_reimbursements_income_delete AFTER DELETE ON incomes
+BEGIN
+    DELETE FROM expense_reimbursements WHERE income_id = OLD.id;
+END;
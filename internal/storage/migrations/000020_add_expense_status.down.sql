@@ -0,0 +1,12 @@
+DROP TRIGGER IF EXISTS expenses_peer_update;
+CREATE TRIGGER expenses_peer_update AFTER UPDATE OF date, description, amount_cents, primary_category, secondary_category, version ON expenses
+BEGIN
+    UPDATE expenses
+    SET modified_at = CASE WHEN NEW.modified_at IS OLD.modified_at THEN strftime('%Y-%m-%dT%H:%M:%fZ', 'now') ELSE NEW.modified_at END
+    WHERE id = NEW.id;
+    INSERT OR REPLACE INTO peer_changelog (kind, uid) VALUES ('expense', NEW.uid);
+END;
+
+DROP INDEX IF EXISTS idx_expenses_pending;
+
+ALTER TABLE expenses DROP COLUMN status;
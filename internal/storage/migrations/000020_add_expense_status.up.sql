@@ -0,0 +1,16 @@
+-- Settlement status: card holds are imported as pending and cleared when
+-- the settled transaction arrives. Pending expenses are not synced to
+-- Google Sheets until cleared.
+ALTER TABLE expenses ADD COLUMN status TEXT NOT NULL DEFAULT 'cleared' CHECK (status IN ('pending', 'cleared'));
+
+CREATE INDEX idx_expenses_pending ON expenses(date) WHERE status = 'pending';
+
+-- Peer sync must notice changes to the new column
+DROP TRIGGER expenses_peer_update;
+CREATE TRIGGER expenses_peer_update AFTER UPDATE OF date, description, amount_cents, primary_category, secondary_category, status, version ON expenses
+BEGIN
+    UPDATE expenses
+    SET modified_at = CASE WHEN NEW.modified_at IS OLD.modified_at THEN strftime('%Y-%m-%dT%H:%M:%fZ', 'now') ELSE NEW.modified_at END
+    WHERE id = NEW.id;
+    INSERT OR REPLACE INTO peer_changelog (kind, uid) VALUES ('expense', NEW.uid);
+END;
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS expense_workflow_expense_delete;
+DROP INDEX IF EXISTS idx_expense_workflow_state;
+DROP TABLE IF EXISTS expense_workflow;
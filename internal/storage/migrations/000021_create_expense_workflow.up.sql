@@ -0,0 +1,16 @@
+-- Business workflow state of an expense. Expenses without a row are
+-- drafts; foreign keys are not enforced, so a trigger drops the row with
+-- its expense.
+CREATE TABLE expense_workflow (
+    expense_id INTEGER PRIMARY KEY,
+    state TEXT NOT NULL CHECK (state IN ('draft', 'submitted', 'approved', 'reimbursed')),
+    updated_by TEXT NOT NULL DEFAULT '',
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_expense_workflow_state ON expense_workflow(state);
+
+CREATE TRIGGER expense_workflow_expense_delete AFTER DELETE ON expenses
+BEGIN
+    DELETE FROM expense_workflow WHERE expense_id = OLD.id;
+END;
@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS expense_calculations_expense_delete;
+DROP TABLE IF EXISTS expense_calculations;
@@ -0,0 +1,15 @@
+-- Inputs of calculated expenses (mileage, per-diem), kept for audit with
+-- the rate in force when the expense was entered. Foreign keys are not
+-- enforced, so a trigger drops the row with its expense.
+CREATE TABLE expense_calculations (
+    expense_id INTEGER PRIMARY KEY,
+    kind TEXT NOT NULL CHECK (kind IN ('mileage', 'per_diem')),
+    quantity REAL NOT NULL CHECK (quantity > 0),
+    rate_cents INTEGER NOT NULL CHECK (rate_cents > 0),
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER expense_calculations_expense_delete AFTER DELETE ON expenses
+BEGIN
+    DELETE FROM expense_calculations WHERE expense_id = OLD.id;
+END;
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS purchase_warranties_expense_delete;
+DROP INDEX IF EXISTS idx_purchase_warranties_return;
+DROP TABLE IF EXISTS purchase_warranties;
@@ -0,0 +1,19 @@
+-- Warranty length and return deadline of purchases. return_notified_at is
+-- set once the return reminder went out. Foreign keys are not enforced, so
+-- a trigger drops the row with its expense.
+CREATE TABLE purchase_warranties (
+    expense_id INTEGER PRIMARY KEY,
+    warranty_months INTEGER NOT NULL DEFAULT 0 CHECK (warranty_months >= 0),
+    return_deadline DATE NULL,
+    proof TEXT NOT NULL DEFAULT '',
+    return_notified_at DATETIME NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_purchase_warranties_return ON purchase_warranties(return_deadline)
+    WHERE return_notified_at IS NULL;
+
+CREATE TRIGGER purchase_warranties_expense_delete AFTER DELETE ON expenses
+BEGIN
+    DELETE FROM purchase_warranties WHERE expense_id = OLD.id;
+END;
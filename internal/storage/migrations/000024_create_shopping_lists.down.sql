@@ -0,0 +1,6 @@
+DROP TRIGGER IF EXISTS shopping_lists_expense_delete;
+DROP TRIGGER IF EXISTS shopping_lists_delete;
+DROP INDEX IF EXISTS idx_shopping_list_items_list;
+DROP TABLE IF EXISTS shopping_list_items;
+DROP INDEX IF EXISTS idx_shopping_lists_expense;
+DROP TABLE IF EXISTS shopping_lists;
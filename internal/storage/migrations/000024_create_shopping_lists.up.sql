@@ -0,0 +1,37 @@
+-- Shopping lists and their items. A checked-off list is converted into a
+-- single expense: expense_id links it and its items stay as the breakdown.
+-- price_cents is the amount paid for the whole line, 0 until known.
+CREATE TABLE shopping_lists (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    expense_id INTEGER NULL,
+    converted_at DATETIME NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_shopping_lists_expense ON shopping_lists(expense_id);
+
+CREATE TABLE shopping_list_items (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    list_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    quantity REAL NOT NULL DEFAULT 1 CHECK (quantity > 0),
+    price_cents INTEGER NOT NULL DEFAULT 0 CHECK (price_cents >= 0),
+    checked BOOLEAN NOT NULL DEFAULT 0,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_shopping_list_items_list ON shopping_list_items(list_id);
+
+-- Foreign keys are not enforced: items go with their list, and deleting the
+-- expense reopens the list so it can be converted again.
+CREATE TRIGGER shopping_lists_delete AFTER DELETE ON shopping_lists
+BEGIN
+    DELETE FROM shopping_list_items WHERE list_id = OLD.id;
+END;
+
+CREATE TRIGGER shopping_lists_expense_delete AFTER DELETE ON expenses
+BEGIN
+    UPDATE shopping_lists SET expense_id = NULL, converted_at = NULL
+    WHERE expense_id = OLD.id;
+END;
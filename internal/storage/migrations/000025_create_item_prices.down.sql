@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS item_prices_expense_update;
+DROP TRIGGER IF EXISTS item_prices_expense_delete;
+DROP TRIGGER IF EXISTS items_delete;
+DROP INDEX IF EXISTS idx_item_prices_expense;
+DROP INDEX IF EXISTS idx_item_prices_item_date;
+DROP TABLE IF EXISTS item_prices;
+DROP TABLE IF EXISTS items;
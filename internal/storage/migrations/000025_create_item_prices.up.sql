@@ -0,0 +1,45 @@
+-- Items whose price is tracked over time, matched on normalized_name (lower
+-- case, single spaces), and the unit prices observed for them. An expense
+-- described as a known item gives a price; so does every line item of a
+-- converted shopping list, which also adds the item when it is new.
+CREATE TABLE items (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    normalized_name TEXT NOT NULL UNIQUE,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE item_prices (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    item_id INTEGER NOT NULL,
+    expense_id INTEGER NOT NULL,
+    date DATE NOT NULL,
+    quantity REAL NOT NULL DEFAULT 1 CHECK (quantity > 0),
+    unit_price_cents INTEGER NOT NULL CHECK (unit_price_cents > 0),
+    source TEXT NOT NULL CHECK (source IN ('description', 'line_item')),
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_item_prices_item_date ON item_prices(item_id, date);
+CREATE INDEX idx_item_prices_expense ON item_prices(expense_id);
+
+-- Foreign keys are not enforced: prices go with their item and expense. An
+-- expense whose description or amount changes no longer vouches for the
+-- price it gave.
+CREATE TRIGGER items_delete AFTER DELETE ON items
+BEGIN
+    DELETE FROM item_prices WHERE item_id = OLD.id;
+END;
+
+CREATE TRIGGER item_prices_expense_delete AFTER DELETE ON expenses
+BEGIN
+    DELETE FROM item_prices WHERE expense_id = OLD.id;
+END;
+
+CREATE TRIGGER item_prices_expense_update AFTER UPDATE OF date, description, amount_cents ON expenses
+BEGIN
+    DELETE FROM item_prices
+    WHERE expense_id = NEW.id AND source = 'description'
+      AND (NEW.description IS NOT OLD.description OR NEW.amount_cents IS NOT OLD.amount_cents);
+    UPDATE item_prices SET date = NEW.date WHERE expense_id = NEW.id;
+END;
@@ -0,0 +1,21 @@
+-- Receipt lines of an expense, each with optional categories. The expense
+-- amount stays authoritative; lines only refine its category breakdown.
+-- Foreign keys are not enforced, so a trigger drops the lines with their
+-- expense.
+CREATE TABLE expense_line_items (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    expense_id INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    description TEXT NOT NULL,
+    quantity REAL NOT NULL DEFAULT 1 CHECK (quantity > 0),
+    amount_cents INTEGER NOT NULL CHECK (amount_cents > 0),
+    primary_category TEXT NOT NULL DEFAULT '',
+    secondary_category TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (expense_id, position)
+);
+
+CREATE TRIGGER expense_line_items_expense_delete AFTER DELETE ON expenses
+BEGIN
+    DELETE FROM expense_line_items WHERE expense_id = OLD.id;
+END;
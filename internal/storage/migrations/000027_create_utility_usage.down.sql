@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS utility_usage_expense_delete;
+DROP TABLE IF EXISTS utility_usage;
@@ -0,0 +1,13 @@
+-- Consumption billed by utility expenses (kWh, Smc, m³). Foreign keys are
+-- not enforced, so a trigger drops the row with its expense.
+CREATE TABLE utility_usage (
+    expense_id INTEGER PRIMARY KEY,
+    kind TEXT NOT NULL CHECK (kind IN ('electricity', 'gas', 'water')),
+    quantity REAL NOT NULL CHECK (quantity > 0),
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER utility_usage_expense_delete AFTER DELETE ON expenses
+BEGIN
+    DELETE FROM utility_usage WHERE expense_id = OLD.id;
+END;
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS fuel_fills_expense_delete;
+DROP INDEX IF EXISTS idx_fuel_fills_odometer;
+DROP TABLE IF EXISTS fuel_fills;
@@ -0,0 +1,15 @@
+-- Liters and odometer reading of fuel expenses. Foreign keys are not
+-- enforced, so a trigger drops the row with its expense.
+CREATE TABLE fuel_fills (
+    expense_id INTEGER PRIMARY KEY,
+    liters REAL NOT NULL CHECK (liters > 0),
+    odometer_km INTEGER NOT NULL CHECK (odometer_km > 0),
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_fuel_fills_odometer ON fuel_fills(odometer_km);
+
+CREATE TRIGGER fuel_fills_expense_delete AFTER DELETE ON expenses
+BEGIN
+    DELETE FROM fuel_fills WHERE expense_id = OLD.id;
+END;
@@ -0,0 +1,63 @@
+-- Display names of categories per locale. The stored category name stays
+-- the key used by expenses, rules and Sheets sync; only the UI shows the
+-- translation. Names without a row are shown as they are.
+CREATE TABLE category_translations (
+    kind TEXT NOT NULL CHECK (kind IN ('primary', 'secondary')),
+    name TEXT NOT NULL,
+    locale TEXT NOT NULL,
+    display_name TEXT NOT NULL,
+    PRIMARY KEY (kind, name, locale)
+);
+
+-- English names of the categories created by the migrations
+INSERT INTO category_translations (kind, name, locale, display_name) VALUES
+('primary', 'Casa', 'en', 'Home'),
+('primary', 'Salute', 'en', 'Health'),
+('primary', 'Spesa', 'en', 'Groceries'),
+('primary', 'Trasporti', 'en', 'Transport'),
+('primary', 'Fuori (come fuori a cena...)', 'en', 'Eating out'),
+('primary', 'Viaggi', 'en', 'Travel'),
+('primary', 'Bimbi', 'en', 'Kids'),
+('primary', 'Vestiti', 'en', 'Clothes'),
+('primary', 'Divertimento', 'en', 'Entertainment'),
+('primary', 'Regali', 'en', 'Gifts'),
+('primary', 'Tasse e Percentuali', 'en', 'Taxes and fees'),
+('primary', 'Altre spese', 'en', 'Other expenses'),
+('primary', 'Lavoro', 'en', 'Work'),
+('secondary', 'Mobili', 'en', 'Furniture'),
+('secondary', 'Internet', 'en', 'Internet'),
+('secondary', 'Elettricità', 'en', 'Electricity'),
+('secondary', 'Spese condominiali', 'en', 'Condo fees'),
+('secondary', 'Mutuo', 'en', 'Mortgage'),
+('secondary', 'Telefono', 'en', 'Phone'),
+('secondary', 'Assicurazioni', 'en', 'Insurance'),
+('secondary', 'Pulizia', 'en', 'Cleaning'),
+('secondary', 'Altre spese (non Everli)', 'en', 'Other groceries (not Everli)'),
+('secondary', 'Spese automobile', 'en', 'Car expenses'),
+('secondary', 'Trasporto locale', 'en', 'Local transport'),
+('secondary', 'Car sharing', 'en', 'Car sharing'),
+('secondary', 'Servizi taxi', 'en', 'Taxi'),
+('secondary', 'Dottori', 'en', 'Doctors'),
+('secondary', 'Medicine', 'en', 'Medicines'),
+('secondary', 'Personale', 'en', 'Personal care'),
+('secondary', 'Sport', 'en', 'Sport'),
+('secondary', 'Assicurazione sanitaria', 'en', 'Health insurance'),
+('secondary', 'Tasse statali', 'en', 'State taxes'),
+('secondary', 'Divertimento familiare', 'en', 'Family entertainment'),
+('secondary', 'Altri divertimenti', 'en', 'Other entertainment'),
+('secondary', 'Tech', 'en', 'Tech'),
+('secondary', 'Ristoranti', 'en', 'Restaurants'),
+('secondary', 'Cibo a casa', 'en', 'Food delivery'),
+('secondary', 'Bar', 'en', 'Bar'),
+('secondary', 'Banche', 'en', 'Banks'),
+('secondary', 'Consulting', 'en', 'Consulting'),
+('secondary', 'Altre tasse e percentuali', 'en', 'Other taxes and fees'),
+('secondary', 'Brokers', 'en', 'Brokers'),
+('secondary', 'Vacanza estiva', 'en', 'Summer holiday'),
+('secondary', 'Vacanza', 'en', 'Holiday'),
+('secondary', 'Vestiti bimbi', 'en', 'Kids'' clothes'),
+('secondary', 'Baby sitter', 'en', 'Babysitter'),
+('secondary', 'Corsi bimbi', 'en', 'Kids'' classes'),
+('secondary', 'Cura bimbi', 'en', 'Childcare'),
+('secondary', 'Roba bimbi', 'en', 'Kids'' stuff'),
+('secondary', 'Altri regali', 'en', 'Other gifts');
@@ -0,0 +1,10 @@
+-- User overrides of the builtin keyword dictionaries (internal/rules).
+-- A row replaces the builtin entry with the same keyword, adds a new one,
+-- or, when disabled, turns the builtin keyword off.
+CREATE TABLE category_keywords (
+    keyword TEXT PRIMARY KEY,
+    primary_category TEXT NOT NULL DEFAULT '',
+    secondary_category TEXT NOT NULL DEFAULT '',
+    disabled BOOLEAN NOT NULL DEFAULT 0,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS classifier_feedback_expense_delete;
+DROP TABLE IF EXISTS classifier_feedback;
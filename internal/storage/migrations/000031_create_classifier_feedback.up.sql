@@ -0,0 +1,17 @@
+-- Outcome of the classifier proposals reviewed by the user: accepted,
+-- rejected, or taught (the user picked another category). Reviewed
+-- expenses leave the review list. Foreign keys are not enforced, so a
+-- trigger drops the row with its expense.
+CREATE TABLE classifier_feedback (
+    expense_id INTEGER PRIMARY KEY,
+    verdict TEXT NOT NULL CHECK (verdict IN ('accepted', 'rejected', 'taught')),
+    primary_category TEXT NOT NULL DEFAULT '',
+    secondary_category TEXT NOT NULL DEFAULT '',
+    confidence REAL NOT NULL DEFAULT 0,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER classifier_feedback_expense_delete AFTER DELETE ON expenses
+BEGIN
+    DELETE FROM classifier_feedback WHERE expense_id = OLD.id;
+END;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS insight_mutes;
+DROP INDEX IF EXISTS idx_insights_feed;
+DROP TABLE IF EXISTS insights;
@@ -0,0 +1,27 @@
+-- Spending insights detected from the expenses, one per kind, primary
+-- category and month, shown in the dashboard feed until dismissed.
+CREATE TABLE insights (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    kind TEXT NOT NULL CHECK (kind IN ('category_up', 'category_back', 'budget_reached')),
+    primary_category TEXT NOT NULL,
+    period TEXT NOT NULL,
+    occurred_on DATE NOT NULL,
+    amount_cents INTEGER NOT NULL DEFAULT 0,
+    baseline_cents INTEGER NOT NULL DEFAULT 0,
+    days INTEGER NOT NULL DEFAULT 0,
+    since_period TEXT NOT NULL DEFAULT '',
+    dismissed_at DATETIME NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (kind, primary_category, period)
+);
+
+CREATE INDEX idx_insights_feed ON insights(occurred_on) WHERE dismissed_at IS NULL;
+
+-- Insight kinds the user muted for a primary category: they are neither
+-- recorded nor shown.
+CREATE TABLE insight_mutes (
+    kind TEXT NOT NULL,
+    primary_category TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (kind, primary_category)
+);
@@ -0,0 +1,12 @@
+-- Alert types a user muted or snoozed, for one primary category or, with
+-- an empty scope, for all of them. The notification router drops the
+-- alerts they cover.
+CREATE TABLE alert_preferences (
+    user_id TEXT NOT NULL,
+    alert_type TEXT NOT NULL CHECK (alert_type IN ('budget', 'spending', 'return_due', 'sync')),
+    scope TEXT NOT NULL DEFAULT '',
+    muted BOOLEAN NOT NULL DEFAULT 0,
+    snoozed_until DATETIME NULL,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, alert_type, scope)
+);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS month_reviews;
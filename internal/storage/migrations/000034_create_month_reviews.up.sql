@@ -0,0 +1,6 @@
+-- Months closed at the end of their review. Expenses and incomes dated in
+-- a closed month can no longer be added or deleted until it is reopened.
+CREATE TABLE month_reviews (
+    period TEXT PRIMARY KEY,
+    closed_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
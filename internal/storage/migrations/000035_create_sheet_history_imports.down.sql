@@ -0,0 +1 @@
+DROP TABLE IF EXISTS sheet_history_imports;
@@ -0,0 +1,7 @@
+-- Years of the Google Sheets history imported into the database, so the
+-- one-time import never copies a year twice.
+CREATE TABLE sheet_history_imports (
+    year INTEGER PRIMARY KEY,
+    expenses INTEGER NOT NULL,
+    imported_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,4 @@
+ALTER TABLE sheet_history_imports DROP COLUMN completed_at;
+ALTER TABLE sheet_history_imports DROP COLUMN last_error;
+ALTER TABLE sheet_history_imports DROP COLUMN next_row;
+ALTER TABLE sheet_history_imports DROP COLUMN total_rows;
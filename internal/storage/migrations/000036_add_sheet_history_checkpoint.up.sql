@@ -0,0 +1,11 @@
+-- Checkpoint of the history import: the rows of the year sheet are stored
+-- in chunks and next_row moves on with each of them, so an import
+-- interrupted mid-way resumes where it stopped. imported_at is when the
+-- import of the year started; completed_at stays NULL until it finishes.
+ALTER TABLE sheet_history_imports ADD COLUMN total_rows INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE sheet_history_imports ADD COLUMN next_row INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE sheet_history_imports ADD COLUMN last_error TEXT NOT NULL DEFAULT '';
+ALTER TABLE sheet_history_imports ADD COLUMN completed_at DATETIME;
+
+-- Years recorded so far were imported whole
+UPDATE sheet_history_imports SET total_rows = expenses, next_row = expenses, completed_at = imported_at;
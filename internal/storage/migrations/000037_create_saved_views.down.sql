@@ -0,0 +1,14 @@
+CREATE TABLE alert_preferences_old (
+    user_id TEXT NOT NULL,
+    alert_type TEXT NOT NULL CHECK (alert_type IN ('budget', 'spending', 'return_due', 'sync')),
+    scope TEXT NOT NULL DEFAULT '',
+    muted BOOLEAN NOT NULL DEFAULT 0,
+    snoozed_until DATETIME NULL,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, alert_type, scope)
+);
+INSERT INTO alert_preferences_old SELECT user_id, alert_type, scope, muted, snoozed_until, updated_at FROM alert_preferences WHERE alert_type != 'view';
+DROP TABLE alert_preferences;
+ALTER TABLE alert_preferences_old RENAME TO alert_preferences;
+
+DROP TABLE IF EXISTS saved_views;
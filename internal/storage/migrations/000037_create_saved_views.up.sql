@@ -0,0 +1,29 @@
+-- Named expense filters shown in the navigation. With notify set, new
+-- expenses the filter selects send an alert. Zero amounts and empty texts
+-- do not filter.
+CREATE TABLE saved_views (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    primary_category TEXT NOT NULL DEFAULT '',
+    secondary_category TEXT NOT NULL DEFAULT '',
+    min_cents INTEGER NOT NULL DEFAULT 0,
+    max_cents INTEGER NOT NULL DEFAULT 0,
+    text TEXT NOT NULL DEFAULT '',
+    notify BOOLEAN NOT NULL DEFAULT 0,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Saved views send alerts of their own type, which can be muted or snoozed
+-- like the others
+CREATE TABLE alert_preferences_new (
+    user_id TEXT NOT NULL,
+    alert_type TEXT NOT NULL CHECK (alert_type IN ('budget', 'spending', 'return_due', 'sync', 'view')),
+    scope TEXT NOT NULL DEFAULT '',
+    muted BOOLEAN NOT NULL DEFAULT 0,
+    snoozed_until DATETIME NULL,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, alert_type, scope)
+);
+INSERT INTO alert_preferences_new SELECT user_id, alert_type, scope, muted, snoozed_until, updated_at FROM alert_preferences;
+DROP TABLE alert_preferences;
+ALTER TABLE alert_preferences_new RENAME TO alert_preferences;
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS users_delete;
+DROP INDEX IF EXISTS idx_expenses_paid_by;
+ALTER TABLE expenses DROP COLUMN paid_by;
+DROP TABLE IF EXISTS users;
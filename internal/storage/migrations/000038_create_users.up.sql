@@ -0,0 +1,18 @@
+-- Members of a household sharing the instance. An expense records who
+-- paid it, so the month can be settled between them; expenses without a
+-- payer are shared costs nobody advanced. Foreign keys are not enforced,
+-- so a trigger unassigns the expenses of a removed member.
+CREATE TABLE users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE COLLATE NOCASE,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE expenses ADD COLUMN paid_by INTEGER NULL;
+
+CREATE INDEX idx_expenses_paid_by ON expenses(paid_by, date) WHERE paid_by IS NOT NULL;
+
+CREATE TRIGGER users_delete AFTER DELETE ON users
+BEGIN
+    UPDATE expenses SET paid_by = NULL WHERE paid_by = OLD.id;
+END;
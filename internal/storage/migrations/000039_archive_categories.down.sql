@@ -0,0 +1,2 @@
+ALTER TABLE secondary_categories DROP COLUMN archived;
+ALTER TABLE primary_categories DROP COLUMN archived;
@@ -0,0 +1,4 @@
+-- Archived categories are no longer offered for new expenses, while the
+-- expenses already filed under them keep their names
+ALTER TABLE primary_categories ADD COLUMN archived BOOLEAN NOT NULL DEFAULT 0;
+ALTER TABLE secondary_categories ADD COLUMN archived BOOLEAN NOT NULL DEFAULT 0;
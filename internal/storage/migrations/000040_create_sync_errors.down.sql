@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_sync_errors_created_at;
+DROP INDEX IF EXISTS idx_sync_errors_expense;
+DROP TABLE IF EXISTS sync_errors;
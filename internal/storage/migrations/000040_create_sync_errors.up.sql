@@ -0,0 +1,14 @@
+-- One row per failed sync attempt, with the class driving its retry policy
+CREATE TABLE sync_errors (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    queue_id INTEGER NOT NULL,
+    expense_id INTEGER NOT NULL,
+    operation TEXT NOT NULL,
+    attempt INTEGER NOT NULL,
+    class TEXT NOT NULL CHECK (class IN ('quota', 'auth', 'not_found', 'validation', 'transient')),
+    message TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_sync_errors_expense ON sync_errors(expense_id);
+CREATE INDEX idx_sync_errors_created_at ON sync_errors(created_at);
@@ -0,0 +1,83 @@
+-- Primary category of each secondary category found in Google Sheets,
+-- including those of past years, consulted when the sheet categories are
+-- synced and when the history is imported. Editable through the API, so a
+-- new sheet category does not need a release.
+CREATE TABLE category_mappings (
+    sheet_name TEXT PRIMARY KEY,
+    primary_category TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- The mapping previously compiled into the application; the legacy names
+-- are those of sheets older than the current categories
+INSERT INTO category_mappings (sheet_name, primary_category) VALUES
+('Mutuo', 'Casa'),
+('Spese condominiali', 'Casa'),
+('Internet', 'Casa'),
+('Mobili', 'Casa'),
+('Assicurazioni', 'Casa'),
+('Pulizia', 'Casa'),
+('Elettricità', 'Casa'),
+('Telefono', 'Casa'),
+('Bollette', 'Casa'),
+('Affitto', 'Casa'),
+('Assicurazione sanitaria', 'Salute'),
+('Dottori', 'Salute'),
+('Medicine', 'Salute'),
+('Personale', 'Salute'),
+('Sport', 'Salute'),
+('Medico', 'Salute'),
+('Farmacia', 'Salute'),
+('Everli', 'Spesa'),
+('Altre spese (non Everli)', 'Spesa'),
+('Supermercato', 'Spesa'),
+('Trasporto locale', 'Trasporti'),
+('Car sharing', 'Trasporti'),
+('Spese automobile', 'Trasporti'),
+('Servizi taxi', 'Trasporti'),
+('Benzina', 'Trasporti'),
+('Trasporto Pubblico', 'Trasporti'),
+('Ristoranti', 'Fuori (come fuori a cena...)'),
+('Bar', 'Fuori (come fuori a cena...)'),
+('Cibo a casa', 'Fuori (come fuori a cena...)'),
+('Ristorante', 'Fuori (come fuori a cena...)'),
+('Vacanza', 'Viaggi'),
+('Vacanza estiva', 'Viaggi'),
+('Cura bimbi', 'Bimbi'),
+('Roba bimbi', 'Bimbi'),
+('Corsi bimbi', 'Bimbi'),
+('Baby sitter', 'Bimbi'),
+('Vestiti e', 'Vestiti'),
+('Vestiti g', 'Vestiti'),
+('Vestiti bimbi', 'Vestiti'),
+('Abbigliamento', 'Vestiti'),
+('Scarpe', 'Vestiti'),
+('Tech', 'Divertimento'),
+('Libri e', 'Divertimento'),
+('Divertimento e', 'Divertimento'),
+('Learning e', 'Divertimento'),
+('Giochi e', 'Divertimento'),
+('Giochi g', 'Divertimento'),
+('Learning g', 'Divertimento'),
+('Divertimento familiare', 'Divertimento'),
+('Altri divertimenti', 'Divertimento'),
+('Cinema', 'Divertimento'),
+('Hobby', 'Divertimento'),
+('Altri regali', 'Regali'),
+('Compleanno', 'Regali'),
+('Natale', 'Regali'),
+('Brokers', 'Tasse e Percentuali'),
+('Banche', 'Tasse e Percentuali'),
+('Consulting', 'Tasse e Percentuali'),
+('Altre tasse e percentuali', 'Tasse e Percentuali'),
+('IRPEF', 'Tasse e Percentuali'),
+('IMU', 'Tasse e Percentuali'),
+('Tasse statali', 'Altre spese'),
+('2DM', 'Altre spese'),
+('Unknown', 'Altre spese'),
+('Varie', 'Altre spese'),
+('Azioni', 'Altre spese'),
+('Crypto', 'Altre spese'),
+('Lavoro g', 'Lavoro'),
+('Lavoro e', 'Lavoro');
@@ -0,0 +1,6 @@
+ALTER TABLE saved_views DROP COLUMN tag;
+DROP TRIGGER IF EXISTS incomes_delete_tags;
+DROP TRIGGER IF EXISTS expenses_delete_tags;
+DROP TABLE IF EXISTS income_tags;
+DROP TABLE IF EXISTS expense_tags;
+DROP TABLE IF EXISTS tags;
@@ -0,0 +1,57 @@
+-- Free labels shared by expenses and incomes, for cross-cutting groups
+-- such as "vacanze 2025" that categories do not capture. Names are
+-- normalized by the application (lowercase, single spaces). Foreign keys
+-- are not enforced, so triggers drop the links of removed records.
+CREATE TABLE tags (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE expense_tags (
+    expense_id INTEGER NOT NULL,
+    tag_id INTEGER NOT NULL,
+    PRIMARY KEY (expense_id, tag_id)
+);
+
+CREATE INDEX idx_expense_tags_tag ON expense_tags(tag_id);
+
+-- incomes.tags stays as the comma-separated copy shown in lists and sent
+-- to peers; income_tags is written alongside it for reporting
+CREATE TABLE income_tags (
+    income_id INTEGER NOT NULL,
+    tag_id INTEGER NOT NULL,
+    PRIMARY KEY (income_id, tag_id)
+);
+
+CREATE INDEX idx_income_tags_tag ON income_tags(tag_id);
+
+CREATE TRIGGER expenses_delete_tags AFTER DELETE ON expenses
+BEGIN
+    DELETE FROM expense_tags WHERE expense_id = OLD.id;
+END;
+
+CREATE TRIGGER incomes_delete_tags AFTER DELETE ON incomes
+BEGIN
+    DELETE FROM income_tags WHERE income_id = OLD.id;
+END;
+
+-- Link the tags incomes already carry
+WITH RECURSIVE split(income_id, tag, rest) AS (
+    SELECT id, '', tags || ',' FROM incomes WHERE tags <> ''
+    UNION ALL
+    SELECT income_id, trim(substr(rest, 1, instr(rest, ',') - 1)), substr(rest, instr(rest, ',') + 1)
+    FROM split WHERE rest <> ''
+)
+INSERT OR IGNORE INTO tags (name) SELECT DISTINCT tag FROM split WHERE tag <> '';
+
+WITH RECURSIVE split(income_id, tag, rest) AS (
+    SELECT id, '', tags || ',' FROM incomes WHERE tags <> ''
+    UNION ALL
+    SELECT income_id, trim(substr(rest, 1, instr(rest, ',') - 1)), substr(rest, instr(rest, ',') + 1)
+    FROM split WHERE rest <> ''
+)
+INSERT OR IGNORE INTO income_tags (income_id, tag_id)
+SELECT split.income_id, tags.id FROM split JOIN tags ON tags.name = split.tag;
+
+ALTER TABLE saved_views ADD COLUMN tag TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS receipts_expense_delete;
+DROP TABLE IF EXISTS blob_deletions;
+DROP TABLE IF EXISTS receipts;
@@ -0,0 +1,30 @@
+-- Receipt photo or PDF of an expense, kept in blob storage (a local
+-- directory or an S3-compatible bucket) under blob_key. thumbnail_key is
+-- empty for PDFs and for images that could not be shrunk.
+CREATE TABLE receipts (
+    expense_id INTEGER PRIMARY KEY,
+    blob_key TEXT NOT NULL,
+    thumbnail_key TEXT NOT NULL DEFAULT '',
+    content_type TEXT NOT NULL,
+    filename TEXT NOT NULL DEFAULT '',
+    size_bytes INTEGER NOT NULL CHECK (size_bytes >= 0),
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Blobs no longer referenced, deleted from storage by the application:
+-- blob storage cannot take part in a database transaction
+CREATE TABLE blob_deletions (
+    blob_key TEXT PRIMARY KEY,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Foreign keys are not enforced: drop the receipt with its expense and
+-- queue its files for deletion
+CREATE TRIGGER receipts_expense_delete AFTER DELETE ON expenses
+BEGIN
+    INSERT OR IGNORE INTO blob_deletions (blob_key)
+    SELECT blob_key FROM receipts WHERE expense_id = OLD.id;
+    INSERT OR IGNORE INTO blob_deletions (blob_key)
+    SELECT thumbnail_key FROM receipts WHERE expense_id = OLD.id AND thumbnail_key != '';
+    DELETE FROM receipts WHERE expense_id = OLD.id;
+END;
@@ -0,0 +1,2 @@
+ALTER TABLE recurrent_expenses DROP COLUMN day_of_month;
+ALTER TABLE recurrent_expenses DROP COLUMN repeat_interval;
@@ -0,0 +1,6 @@
+-- Custom recurrence schedules: every repeat_interval units of
+-- repetition_type (every 2 weeks, every 3 months), and for monthly and
+-- yearly ones the day of the month they fall on, clamped to the last day
+-- of shorter months. 0 keeps the day of start_date.
+ALTER TABLE recurrent_expenses ADD COLUMN repeat_interval INTEGER NOT NULL DEFAULT 1 CHECK (repeat_interval BETWEEN 1 AND 99);
+ALTER TABLE recurrent_expenses ADD COLUMN day_of_month INTEGER NOT NULL DEFAULT 0 CHECK (day_of_month BETWEEN 0 AND 31);
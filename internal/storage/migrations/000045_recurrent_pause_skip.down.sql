@@ -0,0 +1,2 @@
+ALTER TABLE recurrent_expenses DROP COLUMN skip_until;
+ALTER TABLE recurrent_expenses DROP COLUMN paused;
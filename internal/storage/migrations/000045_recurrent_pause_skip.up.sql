@@ -0,0 +1,5 @@
+-- Recurrent expenses can be paused without being stopped, and have their
+-- occurrences up to skip_until (included) skipped. Resuming skips the
+-- occurrences that fell during the pause.
+ALTER TABLE recurrent_expenses ADD COLUMN paused BOOLEAN NOT NULL DEFAULT 0;
+ALTER TABLE recurrent_expenses ADD COLUMN skip_until DATE NULL;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_recurrent_incomes_active;
+DROP TABLE IF EXISTS recurrent_incomes;
@@ -0,0 +1,22 @@
+-- Recurrent incomes, such as a monthly salary. The schedule works like that
+-- of recurrent expenses; the recurring processor creates an income for each
+-- occurrence and records it in last_execution_date.
+CREATE TABLE recurrent_incomes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    start_date DATE NOT NULL,
+    end_date DATE NULL,
+    repetition_type TEXT NOT NULL CHECK (repetition_type IN ('daily', 'weekly', 'monthly', 'yearly')),
+    repeat_interval INTEGER NOT NULL DEFAULT 1 CHECK (repeat_interval BETWEEN 1 AND 99),
+    day_of_month INTEGER NOT NULL DEFAULT 0 CHECK (day_of_month BETWEEN 0 AND 31),
+    description TEXT NOT NULL,
+    amount_cents INTEGER NOT NULL CHECK (amount_cents > 0),
+    category TEXT NOT NULL,
+    subcategory TEXT NOT NULL DEFAULT '',
+    is_active BOOLEAN NOT NULL DEFAULT 1,
+    last_execution_date DATE NULL,
+    version INTEGER NOT NULL DEFAULT 1,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_recurrent_incomes_active ON recurrent_incomes(is_active);
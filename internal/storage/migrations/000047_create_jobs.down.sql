@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_jobs_job;
+DROP TABLE IF EXISTS jobs;
@@ -0,0 +1,15 @@
+-- Runs of the background jobs, one row per attempt: the job runner records
+-- when each started and how it ended, and keeps the latest runs of each job.
+CREATE TABLE jobs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    job TEXT NOT NULL,
+    source TEXT NOT NULL CHECK (source IN ('schedule', 'manual', 'retry')),
+    attempt INTEGER NOT NULL DEFAULT 1 CHECK (attempt >= 1),
+    status TEXT NOT NULL CHECK (status IN ('running', 'succeeded', 'failed', 'interrupted')),
+    started_at DATETIME NOT NULL,
+    finished_at DATETIME NULL,
+    result TEXT NOT NULL DEFAULT '',
+    error TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX idx_jobs_job ON jobs(job, id);
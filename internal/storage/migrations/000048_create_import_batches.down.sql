@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_import_batch_expenses_expense;
+DROP TABLE IF EXISTS import_batch_expenses;
+DROP TABLE IF EXISTS import_batches;
@@ -0,0 +1,17 @@
+-- CSV imports, each with the expenses it created, so a whole import can be
+-- rolled back at once.
+CREATE TABLE import_batches (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    filename TEXT NOT NULL,
+    expenses INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    rolled_back_at DATETIME NULL
+);
+
+CREATE TABLE import_batch_expenses (
+    batch_id INTEGER NOT NULL REFERENCES import_batches(id) ON DELETE CASCADE,
+    expense_id INTEGER NOT NULL,
+    PRIMARY KEY (batch_id, expense_id)
+);
+
+CREATE INDEX idx_import_batch_expenses_expense ON import_batch_expenses(expense_id);
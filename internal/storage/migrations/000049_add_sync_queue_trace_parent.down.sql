@@ -0,0 +1 @@
+ALTER TABLE sync_queue DROP COLUMN trace_parent;
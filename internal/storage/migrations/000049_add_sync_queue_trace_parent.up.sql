@@ -0,0 +1,3 @@
+-- W3C traceparent of the request that enqueued the item, so the sync to
+-- the sheet continues its trace
+ALTER TABLE sync_queue ADD COLUMN trace_parent TEXT NULL;
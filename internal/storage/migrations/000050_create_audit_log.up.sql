@@ -0,0 +1,17 @@
+-- Every create, update and delete of expenses, incomes, recurrents and
+-- categories, with who made it and the row before and after as JSON (NULL
+-- before a creation and after a deletion). Entries older than the
+-- retention are pruned by the audit-retention job.
+CREATE TABLE audit_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    entity TEXT NOT NULL CHECK (entity IN ('expense', 'income', 'recurrent_expense', 'recurrent_income', 'category', 'subcategory')),
+    entity_id INTEGER NOT NULL,
+    action TEXT NOT NULL CHECK (action IN ('create', 'update', 'delete')),
+    changed_by TEXT NOT NULL DEFAULT 'system',
+    changed_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    before_json TEXT NULL,
+    after_json TEXT NULL
+);
+
+CREATE INDEX idx_audit_log_changed_at ON audit_log(changed_at);
+CREATE INDEX idx_audit_log_entity ON audit_log(entity, entity_id);
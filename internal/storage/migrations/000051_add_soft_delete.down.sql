@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_incomes_deleted_at;
+DROP INDEX IF EXISTS idx_expenses_deleted_at;
+ALTER TABLE incomes DROP COLUMN deleted_at;
+ALTER TABLE expenses DROP COLUMN deleted_at;
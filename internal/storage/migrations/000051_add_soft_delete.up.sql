@@ -0,0 +1,7 @@
+-- Deleted expenses and incomes stay in the trash until the trash-purge job
+-- removes them for good; deleted_at is NULL for live rows
+ALTER TABLE expenses ADD COLUMN deleted_at DATETIME NULL;
+ALTER TABLE incomes ADD COLUMN deleted_at DATETIME NULL;
+
+CREATE INDEX idx_expenses_deleted_at ON expenses(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_incomes_deleted_at ON incomes(deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,3 @@
+-- Searches walk the live expenses of a date range newest first; the
+-- partial index serves both the range and the order without the trash
+CREATE INDEX idx_expenses_live_date ON expenses(date, id) WHERE deleted_at IS NULL;
@@ -0,0 +1,8 @@
+DROP TRIGGER IF EXISTS incomes_fts_update;
+DROP TRIGGER IF EXISTS incomes_fts_delete;
+DROP TRIGGER IF EXISTS incomes_fts_insert;
+DROP TRIGGER IF EXISTS expenses_fts_update;
+DROP TRIGGER IF EXISTS expenses_fts_delete;
+DROP TRIGGER IF EXISTS expenses_fts_insert;
+DROP TABLE IF EXISTS incomes_fts;
+DROP TABLE IF EXISTS expenses_fts;
@@ -0,0 +1,47 @@
+-- Full-text indexes of the expense and income descriptions. The tables
+-- keep only the index and read the text from their row (external
+-- content); the triggers keep them in step with every write. Accents are
+-- folded, so "caffe" finds "Caffè".
+CREATE VIRTUAL TABLE expenses_fts USING fts5(
+    description,
+    content = 'expenses',
+    content_rowid = 'id',
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
+CREATE VIRTUAL TABLE incomes_fts USING fts5(
+    description,
+    content = 'incomes',
+    content_rowid = 'id',
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
+CREATE TRIGGER expenses_fts_insert AFTER INSERT ON expenses BEGIN
+    INSERT INTO expenses_fts(rowid, description) VALUES (new.id, new.description);
+END;
+
+CREATE TRIGGER expenses_fts_delete AFTER DELETE ON expenses BEGIN
+    INSERT INTO expenses_fts(expenses_fts, rowid, description) VALUES ('delete', old.id, old.description);
+END;
+
+CREATE TRIGGER expenses_fts_update AFTER UPDATE OF description ON expenses BEGIN
+    INSERT INTO expenses_fts(expenses_fts, rowid, description) VALUES ('delete', old.id, old.description);
+    INSERT INTO expenses_fts(rowid, description) VALUES (new.id, new.description);
+END;
+
+CREATE TRIGGER incomes_fts_insert AFTER INSERT ON incomes BEGIN
+    INSERT INTO incomes_fts(rowid, description) VALUES (new.id, new.description);
+END;
+
+CREATE TRIGGER incomes_fts_delete AFTER DELETE ON incomes BEGIN
+    INSERT INTO incomes_fts(incomes_fts, rowid, description) VALUES ('delete', old.id, old.description);
+END;
+
+CREATE TRIGGER incomes_fts_update AFTER UPDATE OF description ON incomes BEGIN
+    INSERT INTO incomes_fts(incomes_fts, rowid, description) VALUES ('delete', old.id, old.description);
+    INSERT INTO incomes_fts(rowid, description) VALUES (new.id, new.description);
+END;
+
+-- Index what is already there
+INSERT INTO expenses_fts(expenses_fts) VALUES ('rebuild');
+INSERT INTO incomes_fts(incomes_fts) VALUES ('rebuild');
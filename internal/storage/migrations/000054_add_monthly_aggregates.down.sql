@@ -0,0 +1,10 @@
+DROP TRIGGER IF EXISTS incomes_aggregates_update_new;
+DROP TRIGGER IF EXISTS incomes_aggregates_update_old;
+DROP TRIGGER IF EXISTS incomes_aggregates_delete;
+DROP TRIGGER IF EXISTS incomes_aggregates_insert;
+DROP TRIGGER IF EXISTS expenses_aggregates_update_new;
+DROP TRIGGER IF EXISTS expenses_aggregates_update_old;
+DROP TRIGGER IF EXISTS expenses_aggregates_delete;
+DROP TRIGGER IF EXISTS expenses_aggregates_insert;
+DROP VIEW IF EXISTS monthly_aggregates_fresh;
+DROP TABLE IF EXISTS monthly_aggregates;
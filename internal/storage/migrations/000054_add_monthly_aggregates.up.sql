@@ -0,0 +1,121 @@
+-- Totals per month and category of the live (not trashed) expenses, by
+-- primary category, and incomes, so the overviews read a handful of rows
+-- instead of summing every entry of the month. Triggers keep the table
+-- in step with every write; rows of a month and category left without
+-- entries are removed.
+CREATE TABLE monthly_aggregates (
+    kind TEXT NOT NULL CHECK (kind IN ('expense', 'income')),
+    year INTEGER NOT NULL,
+    month INTEGER NOT NULL,
+    category TEXT NOT NULL,
+    total_cents INTEGER NOT NULL,
+    entries INTEGER NOT NULL,
+    PRIMARY KEY (kind, year, month, category)
+);
+
+-- What the table should hold, recomputed from the entries; the rebuild
+-- job compares the two and copies this over
+CREATE VIEW monthly_aggregates_fresh AS
+SELECT 'expense' AS kind,
+       CAST(strftime('%Y', date) AS INTEGER) AS year,
+       CAST(strftime('%m', date) AS INTEGER) AS month,
+       primary_category AS category,
+       SUM(amount_cents) AS total_cents,
+       COUNT(*) AS entries
+FROM expenses
+WHERE deleted_at IS NULL
+GROUP BY 1, 2, 3, 4
+UNION ALL
+SELECT 'income',
+       CAST(strftime('%Y', date) AS INTEGER),
+       CAST(strftime('%m', date) AS INTEGER),
+       category,
+       SUM(amount_cents),
+       COUNT(*)
+FROM incomes
+WHERE deleted_at IS NULL
+GROUP BY 1, 2, 3, 4;
+
+CREATE TRIGGER expenses_aggregates_insert AFTER INSERT ON expenses
+WHEN new.deleted_at IS NULL BEGIN
+    INSERT INTO monthly_aggregates (kind, year, month, category, total_cents, entries)
+    VALUES ('expense', CAST(strftime('%Y', new.date) AS INTEGER), CAST(strftime('%m', new.date) AS INTEGER), new.primary_category, new.amount_cents, 1)
+    ON CONFLICT (kind, year, month, category) DO UPDATE SET
+        total_cents = total_cents + excluded.total_cents,
+        entries = entries + excluded.entries;
+END;
+
+CREATE TRIGGER expenses_aggregates_delete AFTER DELETE ON expenses
+WHEN old.deleted_at IS NULL BEGIN
+    UPDATE monthly_aggregates SET total_cents = total_cents - old.amount_cents, entries = entries - 1
+    WHERE kind = 'expense' AND year = CAST(strftime('%Y', old.date) AS INTEGER)
+      AND month = CAST(strftime('%m', old.date) AS INTEGER) AND category = old.primary_category;
+    DELETE FROM monthly_aggregates
+    WHERE kind = 'expense' AND year = CAST(strftime('%Y', old.date) AS INTEGER)
+      AND month = CAST(strftime('%m', old.date) AS INTEGER) AND category = old.primary_category
+      AND entries <= 0;
+END;
+
+-- An update takes the old values out and puts the new ones in; trashing
+-- and restoring are updates of deleted_at
+CREATE TRIGGER expenses_aggregates_update_old AFTER UPDATE OF date, amount_cents, primary_category, deleted_at ON expenses
+WHEN old.deleted_at IS NULL BEGIN
+    UPDATE monthly_aggregates SET total_cents = total_cents - old.amount_cents, entries = entries - 1
+    WHERE kind = 'expense' AND year = CAST(strftime('%Y', old.date) AS INTEGER)
+      AND month = CAST(strftime('%m', old.date) AS INTEGER) AND category = old.primary_category;
+    DELETE FROM monthly_aggregates
+    WHERE kind = 'expense' AND year = CAST(strftime('%Y', old.date) AS INTEGER)
+      AND month = CAST(strftime('%m', old.date) AS INTEGER) AND category = old.primary_category
+      AND entries <= 0;
+END;
+
+CREATE TRIGGER expenses_aggregates_update_new AFTER UPDATE OF date, amount_cents, primary_category, deleted_at ON expenses
+WHEN new.deleted_at IS NULL BEGIN
+    INSERT INTO monthly_aggregates (kind, year, month, category, total_cents, entries)
+    VALUES ('expense', CAST(strftime('%Y', new.date) AS INTEGER), CAST(strftime('%m', new.date) AS INTEGER), new.primary_category, new.amount_cents, 1)
+    ON CONFLICT (kind, year, month, category) DO UPDATE SET
+        total_cents = total_cents + excluded.total_cents,
+        entries = entries + excluded.entries;
+END;
+
+CREATE TRIGGER incomes_aggregates_insert AFTER INSERT ON incomes
+WHEN new.deleted_at IS NULL BEGIN
+    INSERT INTO monthly_aggregates (kind, year, month, category, total_cents, entries)
+    VALUES ('income', CAST(strftime('%Y', new.date) AS INTEGER), CAST(strftime('%m', new.date) AS INTEGER), new.category, new.amount_cents, 1)
+    ON CONFLICT (kind, year, month, category) DO UPDATE SET
+        total_cents = total_cents + excluded.total_cents,
+        entries = entries + excluded.entries;
+END;
+
+CREATE TRIGGER incomes_aggregates_delete AFTER DELETE ON incomes
+WHEN old.deleted_at IS NULL BEGIN
+    UPDATE monthly_aggregates SET total_cents = total_cents - old.amount_cents, entries = entries - 1
+    WHERE kind = 'income' AND year = CAST(strftime('%Y', old.date) AS INTEGER)
+      AND month = CAST(strftime('%m', old.date) AS INTEGER) AND category = old.category;
+    DELETE FROM monthly_aggregates
+    WHERE kind = 'income' AND year = CAST(strftime('%Y', old.date) AS INTEGER)
+      AND month = CAST(strftime('%m', old.date) AS INTEGER) AND category = old.category
+      AND entries <= 0;
+END;
+
+CREATE TRIGGER incomes_aggregates_update_old AFTER UPDATE OF date, amount_cents, category, deleted_at ON incomes
+WHEN old.deleted_at IS NULL BEGIN
+    UPDATE monthly_aggregates SET total_cents = total_cents - old.amount_cents, entries = entries - 1
+    WHERE kind = 'income' AND year = CAST(strftime('%Y', old.date) AS INTEGER)
+      AND month = CAST(strftime('%m', old.date) AS INTEGER) AND category = old.category;
+    DELETE FROM monthly_aggregates
+    WHERE kind = 'income' AND year = CAST(strftime('%Y', old.date) AS INTEGER)
+      AND month = CAST(strftime('%m', old.date) AS INTEGER) AND category = old.category
+      AND entries <= 0;
+END;
+
+CREATE TRIGGER incomes_aggregates_update_new AFTER UPDATE OF date, amount_cents, category, deleted_at ON incomes
+WHEN new.deleted_at IS NULL BEGIN
+    INSERT INTO monthly_aggregates (kind, year, month, category, total_cents, entries)
+    VALUES ('income', CAST(strftime('%Y', new.date) AS INTEGER), CAST(strftime('%m', new.date) AS INTEGER), new.category, new.amount_cents, 1)
+    ON CONFLICT (kind, year, month, category) DO UPDATE SET
+        total_cents = total_cents + excluded.total_cents,
+        entries = entries + excluded.entries;
+END;
+
+INSERT INTO monthly_aggregates SELECT * FROM monthly_aggregates_fresh;
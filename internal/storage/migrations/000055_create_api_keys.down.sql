@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS users_delete_access;
+DROP INDEX IF EXISTS idx_receipts_uploaded_by;
+ALTER TABLE receipts DROP COLUMN uploaded_by;
+DROP TABLE IF EXISTS user_daily_usage;
+DROP TABLE IF EXISTS user_quotas;
+DROP INDEX IF EXISTS idx_api_keys_user;
+DROP TABLE IF EXISTS api_keys;
@@ -0,0 +1,43 @@
+-- API keys of the household members. A request carrying a key acts as its
+-- member and counts against their quotas; only the SHA-256 of the key is
+-- stored, the key itself is shown once when created.
+CREATE TABLE api_keys (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    key_hash TEXT NOT NULL UNIQUE,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_used_at DATETIME NULL
+);
+
+CREATE INDEX idx_api_keys_user ON api_keys(user_id);
+
+-- Per-member overrides of the instance quotas; NULL keeps the default and
+-- 0 means unlimited
+CREATE TABLE user_quotas (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    daily_expenses INTEGER NULL CHECK (daily_expenses >= 0),
+    attachment_bytes INTEGER NULL CHECK (attachment_bytes >= 0)
+);
+
+-- Expenses created with a member's keys per day (local date)
+CREATE TABLE user_daily_usage (
+    user_id INTEGER NOT NULL,
+    day TEXT NOT NULL,
+    expenses INTEGER NOT NULL DEFAULT 0 CHECK (expenses >= 0),
+    PRIMARY KEY (user_id, day)
+);
+
+-- Who uploaded a receipt with their key; NULL for the web UI
+ALTER TABLE receipts ADD COLUMN uploaded_by INTEGER NULL;
+
+CREATE INDEX idx_receipts_uploaded_by ON receipts(uploaded_by) WHERE uploaded_by IS NOT NULL;
+
+-- Foreign keys are not enforced, so the cascade is done here
+CREATE TRIGGER users_delete_access AFTER DELETE ON users
+BEGIN
+    DELETE FROM api_keys WHERE user_id = OLD.id;
+    DELETE FROM user_quotas WHERE user_id = OLD.id;
+    DELETE FROM user_daily_usage WHERE user_id = OLD.id;
+    UPDATE receipts SET uploaded_by = NULL WHERE uploaded_by = OLD.id;
+END;
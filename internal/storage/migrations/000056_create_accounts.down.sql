@@ -0,0 +1,8 @@
+DROP TRIGGER IF EXISTS accounts_delete;
+DROP INDEX IF EXISTS idx_incomes_account;
+DROP INDEX IF EXISTS idx_expenses_account;
+ALTER TABLE incomes DROP COLUMN account_id;
+ALTER TABLE expenses DROP COLUMN account_id;
+DROP INDEX IF EXISTS idx_account_transfers_date;
+DROP TABLE IF EXISTS account_transfers;
+DROP TABLE IF EXISTS accounts;
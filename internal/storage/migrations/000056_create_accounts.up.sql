@@ -0,0 +1,39 @@
+-- Accounts holding the household's money: a checking account, cash, a
+-- credit card. Expenses and incomes optionally say which account they
+-- moved, and transfers move money between two accounts; the balance of an
+-- account is its opening balance plus what came in minus what went out.
+-- Foreign keys are not enforced, so a trigger unassigns the expenses and
+-- incomes of a removed account and drops its transfers.
+CREATE TABLE accounts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE COLLATE NOCASE,
+    kind TEXT NOT NULL CHECK (kind IN ('checking', 'cash', 'credit_card')),
+    opening_balance_cents INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE account_transfers (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    date DATE NOT NULL,
+    from_account_id INTEGER NOT NULL REFERENCES accounts(id),
+    to_account_id INTEGER NOT NULL REFERENCES accounts(id),
+    amount_cents INTEGER NOT NULL CHECK (amount_cents > 0),
+    description TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (from_account_id <> to_account_id)
+);
+
+CREATE INDEX idx_account_transfers_date ON account_transfers(date DESC, id DESC);
+
+ALTER TABLE expenses ADD COLUMN account_id INTEGER NULL;
+ALTER TABLE incomes ADD COLUMN account_id INTEGER NULL;
+
+CREATE INDEX idx_expenses_account ON expenses(account_id) WHERE account_id IS NOT NULL;
+CREATE INDEX idx_incomes_account ON incomes(account_id) WHERE account_id IS NOT NULL;
+
+CREATE TRIGGER accounts_delete AFTER DELETE ON accounts
+BEGIN
+    UPDATE expenses SET account_id = NULL WHERE account_id = OLD.id;
+    UPDATE incomes SET account_id = NULL WHERE account_id = OLD.id;
+    DELETE FROM account_transfers WHERE from_account_id = OLD.id OR to_account_id = OLD.id;
+END;
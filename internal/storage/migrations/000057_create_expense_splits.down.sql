@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS expense_splits_expense_delete;
+DROP TABLE IF EXISTS expense_splits;
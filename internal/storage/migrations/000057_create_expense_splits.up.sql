@@ -0,0 +1,21 @@
+-- Category lines of a split expense, such as the groceries and the
+-- household goods of one supermarket receipt. Unlike line items the parts
+-- replace the categories of the expense and add up to its amount, which
+-- the application checks. Foreign keys are not enforced, so a trigger
+-- drops the parts with their expense.
+CREATE TABLE expense_splits (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    expense_id INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    note TEXT NOT NULL DEFAULT '',
+    amount_cents INTEGER NOT NULL CHECK (amount_cents > 0),
+    primary_category TEXT NOT NULL,
+    secondary_category TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (expense_id, position)
+);
+
+CREATE TRIGGER expense_splits_expense_delete AFTER DELETE ON expenses
+BEGIN
+    DELETE FROM expense_splits WHERE expense_id = OLD.id;
+END;
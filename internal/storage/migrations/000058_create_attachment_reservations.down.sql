@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_attachment_reservations_user;
+DROP TABLE IF EXISTS attachment_reservations;
@@ -0,0 +1,12 @@
+-- Bytes of attachment quota held by receipt uploads in progress, so that
+-- concurrent uploads of a member cannot each fit the quota and together
+-- go over it. A reservation is dropped once its receipt is stored or the
+-- upload fails; one left behind by a crash stops counting after an hour.
+CREATE TABLE attachment_reservations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    size_bytes INTEGER NOT NULL CHECK (size_bytes >= 0),
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_attachment_reservations_user ON attachment_reservations(user_id, created_at);
@@ -9,6 +9,107 @@ import (
 	"time"
 )
 
+type Account struct {
+	ID                  int64     `db:"id" json:"id"`
+	Name                string    `db:"name" json:"name"`
+	Kind                string    `db:"kind" json:"kind"`
+	OpeningBalanceCents int64     `db:"opening_balance_cents" json:"opening_balance_cents"`
+	CreatedAt           time.Time `db:"created_at" json:"created_at"`
+}
+
+type AccountTransfer struct {
+	ID            int64     `db:"id" json:"id"`
+	Date          time.Time `db:"date" json:"date"`
+	FromAccountID int64     `db:"from_account_id" json:"from_account_id"`
+	ToAccountID   int64     `db:"to_account_id" json:"to_account_id"`
+	AmountCents   int64     `db:"amount_cents" json:"amount_cents"`
+	Description   string    `db:"description" json:"description"`
+	CreatedAt     time.Time `db:"created_at" json:"created_at"`
+}
+
+type AlertPreference struct {
+	UserID       string       `db:"user_id" json:"user_id"`
+	AlertType    string       `db:"alert_type" json:"alert_type"`
+	Scope        string       `db:"scope" json:"scope"`
+	Muted        bool         `db:"muted" json:"muted"`
+	SnoozedUntil sql.NullTime `db:"snoozed_until" json:"snoozed_until"`
+	UpdatedAt    time.Time    `db:"updated_at" json:"updated_at"`
+}
+
+type ApiKey struct {
+	ID         int64        `db:"id" json:"id"`
+	UserID     int64        `db:"user_id" json:"user_id"`
+	Name       string       `db:"name" json:"name"`
+	KeyHash    string       `db:"key_hash" json:"key_hash"`
+	CreatedAt  time.Time    `db:"created_at" json:"created_at"`
+	LastUsedAt sql.NullTime `db:"last_used_at" json:"last_used_at"`
+}
+
+type AttachmentReservation struct {
+	ID        int64     `db:"id" json:"id"`
+	UserID    int64     `db:"user_id" json:"user_id"`
+	SizeBytes int64     `db:"size_bytes" json:"size_bytes"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+type AuditLog struct {
+	ID         int64          `db:"id" json:"id"`
+	Entity     string         `db:"entity" json:"entity"`
+	EntityID   int64          `db:"entity_id" json:"entity_id"`
+	Action     string         `db:"action" json:"action"`
+	ChangedBy  string         `db:"changed_by" json:"changed_by"`
+	ChangedAt  time.Time      `db:"changed_at" json:"changed_at"`
+	BeforeJson sql.NullString `db:"before_json" json:"before_json"`
+	AfterJson  sql.NullString `db:"after_json" json:"after_json"`
+}
+
+type BlobDeletion struct {
+	BlobKey   string    `db:"blob_key" json:"blob_key"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+type CategoryKeyword struct {
+	Keyword           string    `db:"keyword" json:"keyword"`
+	PrimaryCategory   string    `db:"primary_category" json:"primary_category"`
+	SecondaryCategory string    `db:"secondary_category" json:"secondary_category"`
+	Disabled          bool      `db:"disabled" json:"disabled"`
+	CreatedAt         time.Time `db:"created_at" json:"created_at"`
+}
+
+type CategoryMapping struct {
+	SheetName       string    `db:"sheet_name" json:"sheet_name"`
+	PrimaryCategory string    `db:"primary_category" json:"primary_category"`
+	CreatedAt       time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time `db:"updated_at" json:"updated_at"`
+}
+
+type CategoryRule struct {
+	ID                int64     `db:"id" json:"id"`
+	Name              string    `db:"name" json:"name"`
+	Expression        string    `db:"expression" json:"expression"`
+	PrimaryCategory   string    `db:"primary_category" json:"primary_category"`
+	SecondaryCategory string    `db:"secondary_category" json:"secondary_category"`
+	Priority          int64     `db:"priority" json:"priority"`
+	IsActive          bool      `db:"is_active" json:"is_active"`
+	CreatedAt         time.Time `db:"created_at" json:"created_at"`
+}
+
+type CategoryTranslation struct {
+	Kind        string `db:"kind" json:"kind"`
+	Name        string `db:"name" json:"name"`
+	Locale      string `db:"locale" json:"locale"`
+	DisplayName string `db:"display_name" json:"display_name"`
+}
+
+type ClassifierFeedback struct {
+	ExpenseID         int64     `db:"expense_id" json:"expense_id"`
+	Verdict           string    `db:"verdict" json:"verdict"`
+	PrimaryCategory   string    `db:"primary_category" json:"primary_category"`
+	SecondaryCategory string    `db:"secondary_category" json:"secondary_category"`
+	Confidence        float64   `db:"confidence" json:"confidence"`
+	CreatedAt         time.Time `db:"created_at" json:"created_at"`
+}
+
 type Expense struct {
 	ID                int64          `db:"id" json:"id"`
 	Date              time.Time      `db:"date" json:"date"`
@@ -20,6 +121,91 @@ type Expense struct {
 	CreatedAt         sql.NullTime   `db:"created_at" json:"created_at"`
 	SyncedAt          interface{}    `db:"synced_at" json:"synced_at"`
 	SyncStatus        sql.NullString `db:"sync_status" json:"sync_status"`
+	Uid               sql.NullString `db:"uid" json:"uid"`
+	ModifiedAt        sql.NullString `db:"modified_at" json:"modified_at"`
+	Status            string         `db:"status" json:"status"`
+	PaidBy            sql.NullInt64  `db:"paid_by" json:"paid_by"`
+	DeletedAt         sql.NullTime   `db:"deleted_at" json:"deleted_at"`
+	AccountID         sql.NullInt64  `db:"account_id" json:"account_id"`
+}
+
+type ExpenseCalculation struct {
+	ExpenseID int64     `db:"expense_id" json:"expense_id"`
+	Kind      string    `db:"kind" json:"kind"`
+	Quantity  float64   `db:"quantity" json:"quantity"`
+	RateCents int64     `db:"rate_cents" json:"rate_cents"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+type ExpenseLineItem struct {
+	ID                int64     `db:"id" json:"id"`
+	ExpenseID         int64     `db:"expense_id" json:"expense_id"`
+	Position          int64     `db:"position" json:"position"`
+	Description       string    `db:"description" json:"description"`
+	Quantity          float64   `db:"quantity" json:"quantity"`
+	AmountCents       int64     `db:"amount_cents" json:"amount_cents"`
+	PrimaryCategory   string    `db:"primary_category" json:"primary_category"`
+	SecondaryCategory string    `db:"secondary_category" json:"secondary_category"`
+	CreatedAt         time.Time `db:"created_at" json:"created_at"`
+}
+
+type ExpenseReimbursement struct {
+	ExpenseID   int64     `db:"expense_id" json:"expense_id"`
+	IncomeID    int64     `db:"income_id" json:"income_id"`
+	AmountCents int64     `db:"amount_cents" json:"amount_cents"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+}
+
+type ExpenseSplit struct {
+	ID                int64     `db:"id" json:"id"`
+	ExpenseID         int64     `db:"expense_id" json:"expense_id"`
+	Position          int64     `db:"position" json:"position"`
+	Note              string    `db:"note" json:"note"`
+	AmountCents       int64     `db:"amount_cents" json:"amount_cents"`
+	PrimaryCategory   string    `db:"primary_category" json:"primary_category"`
+	SecondaryCategory string    `db:"secondary_category" json:"secondary_category"`
+	CreatedAt         time.Time `db:"created_at" json:"created_at"`
+}
+
+type ExpenseTag struct {
+	ExpenseID int64 `db:"expense_id" json:"expense_id"`
+	TagID     int64 `db:"tag_id" json:"tag_id"`
+}
+
+type ExpenseVersion struct {
+	ID        int64     `db:"id" json:"id"`
+	ExpenseID int64     `db:"expense_id" json:"expense_id"`
+	Version   int64     `db:"version" json:"version"`
+	ChangedBy string    `db:"changed_by" json:"changed_by"`
+	Changes   string    `db:"changes" json:"changes"`
+	ChangedAt time.Time `db:"changed_at" json:"changed_at"`
+}
+
+type ExpenseWorkflow struct {
+	ExpenseID int64     `db:"expense_id" json:"expense_id"`
+	State     string    `db:"state" json:"state"`
+	UpdatedBy string    `db:"updated_by" json:"updated_by"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+type FuelFill struct {
+	ExpenseID  int64     `db:"expense_id" json:"expense_id"`
+	Liters     float64   `db:"liters" json:"liters"`
+	OdometerKm int64     `db:"odometer_km" json:"odometer_km"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+}
+
+type ImportBatch struct {
+	ID           int64        `db:"id" json:"id"`
+	Filename     string       `db:"filename" json:"filename"`
+	Expenses     int64        `db:"expenses" json:"expenses"`
+	CreatedAt    time.Time    `db:"created_at" json:"created_at"`
+	RolledBackAt sql.NullTime `db:"rolled_back_at" json:"rolled_back_at"`
+}
+
+type ImportBatchExpense struct {
+	BatchID   int64 `db:"batch_id" json:"batch_id"`
+	ExpenseID int64 `db:"expense_id" json:"expense_id"`
 }
 
 type Income struct {
@@ -32,6 +218,12 @@ type Income struct {
 	CreatedAt   sql.NullTime   `db:"created_at" json:"created_at"`
 	SyncedAt    interface{}    `db:"synced_at" json:"synced_at"`
 	SyncStatus  sql.NullString `db:"sync_status" json:"sync_status"`
+	Uid         sql.NullString `db:"uid" json:"uid"`
+	ModifiedAt  sql.NullString `db:"modified_at" json:"modified_at"`
+	Subcategory string         `db:"subcategory" json:"subcategory"`
+	Tags        string         `db:"tags" json:"tags"`
+	DeletedAt   sql.NullTime   `db:"deleted_at" json:"deleted_at"`
+	AccountID   sql.NullInt64  `db:"account_id" json:"account_id"`
 }
 
 type IncomeCategory struct {
@@ -40,10 +232,111 @@ type IncomeCategory struct {
 	CreatedAt sql.NullTime `db:"created_at" json:"created_at"`
 }
 
+type IncomeTag struct {
+	IncomeID int64 `db:"income_id" json:"income_id"`
+	TagID    int64 `db:"tag_id" json:"tag_id"`
+}
+
+type Insight struct {
+	ID              int64        `db:"id" json:"id"`
+	Kind            string       `db:"kind" json:"kind"`
+	PrimaryCategory string       `db:"primary_category" json:"primary_category"`
+	Period          string       `db:"period" json:"period"`
+	OccurredOn      time.Time    `db:"occurred_on" json:"occurred_on"`
+	AmountCents     int64        `db:"amount_cents" json:"amount_cents"`
+	BaselineCents   int64        `db:"baseline_cents" json:"baseline_cents"`
+	Days            int64        `db:"days" json:"days"`
+	SincePeriod     string       `db:"since_period" json:"since_period"`
+	DismissedAt     sql.NullTime `db:"dismissed_at" json:"dismissed_at"`
+	CreatedAt       time.Time    `db:"created_at" json:"created_at"`
+}
+
+type InsightMute struct {
+	Kind            string    `db:"kind" json:"kind"`
+	PrimaryCategory string    `db:"primary_category" json:"primary_category"`
+	CreatedAt       time.Time `db:"created_at" json:"created_at"`
+}
+
+type Item struct {
+	ID             int64     `db:"id" json:"id"`
+	Name           string    `db:"name" json:"name"`
+	NormalizedName string    `db:"normalized_name" json:"normalized_name"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+}
+
+type ItemPrice struct {
+	ID             int64     `db:"id" json:"id"`
+	ItemID         int64     `db:"item_id" json:"item_id"`
+	ExpenseID      int64     `db:"expense_id" json:"expense_id"`
+	Date           time.Time `db:"date" json:"date"`
+	Quantity       float64   `db:"quantity" json:"quantity"`
+	UnitPriceCents int64     `db:"unit_price_cents" json:"unit_price_cents"`
+	Source         string    `db:"source" json:"source"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+}
+
+type Job struct {
+	ID         int64        `db:"id" json:"id"`
+	Job        string       `db:"job" json:"job"`
+	Source     string       `db:"source" json:"source"`
+	Attempt    int64        `db:"attempt" json:"attempt"`
+	Status     string       `db:"status" json:"status"`
+	StartedAt  time.Time    `db:"started_at" json:"started_at"`
+	FinishedAt sql.NullTime `db:"finished_at" json:"finished_at"`
+	Result     string       `db:"result" json:"result"`
+	Error      string       `db:"error" json:"error"`
+}
+
+type MonthReview struct {
+	Period   string    `db:"period" json:"period"`
+	ClosedAt time.Time `db:"closed_at" json:"closed_at"`
+}
+
+type PeerChangelog struct {
+	Seq  int64  `db:"seq" json:"seq"`
+	Kind string `db:"kind" json:"kind"`
+	Uid  string `db:"uid" json:"uid"`
+}
+
+type PeerSyncState struct {
+	Peer       string       `db:"peer" json:"peer"`
+	PullCursor int64        `db:"pull_cursor" json:"pull_cursor"`
+	PushCursor int64        `db:"push_cursor" json:"push_cursor"`
+	LastSyncAt sql.NullTime `db:"last_sync_at" json:"last_sync_at"`
+}
+
+type PeerTombstone struct {
+	Kind      string `db:"kind" json:"kind"`
+	Uid       string `db:"uid" json:"uid"`
+	Version   int64  `db:"version" json:"version"`
+	DeletedAt string `db:"deleted_at" json:"deleted_at"`
+}
+
 type PrimaryCategory struct {
 	ID        int64        `db:"id" json:"id"`
 	Name      string       `db:"name" json:"name"`
 	CreatedAt sql.NullTime `db:"created_at" json:"created_at"`
+	Archived  bool         `db:"archived" json:"archived"`
+}
+
+type PurchaseWarranty struct {
+	ExpenseID        int64        `db:"expense_id" json:"expense_id"`
+	WarrantyMonths   int64        `db:"warranty_months" json:"warranty_months"`
+	ReturnDeadline   sql.NullTime `db:"return_deadline" json:"return_deadline"`
+	Proof            string       `db:"proof" json:"proof"`
+	ReturnNotifiedAt sql.NullTime `db:"return_notified_at" json:"return_notified_at"`
+	CreatedAt        time.Time    `db:"created_at" json:"created_at"`
+}
+
+type Receipt struct {
+	ExpenseID    int64         `db:"expense_id" json:"expense_id"`
+	BlobKey      string        `db:"blob_key" json:"blob_key"`
+	ThumbnailKey string        `db:"thumbnail_key" json:"thumbnail_key"`
+	ContentType  string        `db:"content_type" json:"content_type"`
+	Filename     string        `db:"filename" json:"filename"`
+	SizeBytes    int64         `db:"size_bytes" json:"size_bytes"`
+	CreatedAt    time.Time     `db:"created_at" json:"created_at"`
+	UploadedBy   sql.NullInt64 `db:"uploaded_by" json:"uploaded_by"`
 }
 
 type RecurrentExpense struct {
@@ -59,6 +352,42 @@ type RecurrentExpense struct {
 	LastExecutionDate interface{}  `db:"last_execution_date" json:"last_execution_date"`
 	CreatedAt         sql.NullTime `db:"created_at" json:"created_at"`
 	UpdatedAt         sql.NullTime `db:"updated_at" json:"updated_at"`
+	Version           int64        `db:"version" json:"version"`
+	RepeatInterval    int64        `db:"repeat_interval" json:"repeat_interval"`
+	DayOfMonth        int64        `db:"day_of_month" json:"day_of_month"`
+	Paused            bool         `db:"paused" json:"paused"`
+	SkipUntil         interface{}  `db:"skip_until" json:"skip_until"`
+}
+
+type RecurrentIncome struct {
+	ID                int64        `db:"id" json:"id"`
+	StartDate         time.Time    `db:"start_date" json:"start_date"`
+	EndDate           interface{}  `db:"end_date" json:"end_date"`
+	RepetitionType    string       `db:"repetition_type" json:"repetition_type"`
+	RepeatInterval    int64        `db:"repeat_interval" json:"repeat_interval"`
+	DayOfMonth        int64        `db:"day_of_month" json:"day_of_month"`
+	Description       string       `db:"description" json:"description"`
+	AmountCents       int64        `db:"amount_cents" json:"amount_cents"`
+	Category          string       `db:"category" json:"category"`
+	Subcategory       string       `db:"subcategory" json:"subcategory"`
+	IsActive          bool         `db:"is_active" json:"is_active"`
+	LastExecutionDate interface{}  `db:"last_execution_date" json:"last_execution_date"`
+	Version           int64        `db:"version" json:"version"`
+	CreatedAt         sql.NullTime `db:"created_at" json:"created_at"`
+	UpdatedAt         sql.NullTime `db:"updated_at" json:"updated_at"`
+}
+
+type SavedView struct {
+	ID                int64     `db:"id" json:"id"`
+	Name              string    `db:"name" json:"name"`
+	PrimaryCategory   string    `db:"primary_category" json:"primary_category"`
+	SecondaryCategory string    `db:"secondary_category" json:"secondary_category"`
+	MinCents          int64     `db:"min_cents" json:"min_cents"`
+	MaxCents          int64     `db:"max_cents" json:"max_cents"`
+	Text              string    `db:"text" json:"text"`
+	Notify            bool      `db:"notify" json:"notify"`
+	CreatedAt         time.Time `db:"created_at" json:"created_at"`
+	Tag               string    `db:"tag" json:"tag"`
 }
 
 type SecondaryCategory struct {
@@ -66,6 +395,46 @@ type SecondaryCategory struct {
 	Name              string       `db:"name" json:"name"`
 	PrimaryCategoryID int64        `db:"primary_category_id" json:"primary_category_id"`
 	CreatedAt         sql.NullTime `db:"created_at" json:"created_at"`
+	Archived          bool         `db:"archived" json:"archived"`
+}
+
+type SheetHistoryImport struct {
+	Year        int64        `db:"year" json:"year"`
+	Expenses    int64        `db:"expenses" json:"expenses"`
+	ImportedAt  time.Time    `db:"imported_at" json:"imported_at"`
+	TotalRows   int64        `db:"total_rows" json:"total_rows"`
+	NextRow     int64        `db:"next_row" json:"next_row"`
+	LastError   string       `db:"last_error" json:"last_error"`
+	CompletedAt sql.NullTime `db:"completed_at" json:"completed_at"`
+}
+
+type ShoppingList struct {
+	ID          int64         `db:"id" json:"id"`
+	Name        string        `db:"name" json:"name"`
+	ExpenseID   sql.NullInt64 `db:"expense_id" json:"expense_id"`
+	ConvertedAt sql.NullTime  `db:"converted_at" json:"converted_at"`
+	CreatedAt   time.Time     `db:"created_at" json:"created_at"`
+}
+
+type ShoppingListItem struct {
+	ID         int64     `db:"id" json:"id"`
+	ListID     int64     `db:"list_id" json:"list_id"`
+	Name       string    `db:"name" json:"name"`
+	Quantity   float64   `db:"quantity" json:"quantity"`
+	PriceCents int64     `db:"price_cents" json:"price_cents"`
+	Checked    bool      `db:"checked" json:"checked"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+}
+
+type SyncError struct {
+	ID        int64     `db:"id" json:"id"`
+	QueueID   int64     `db:"queue_id" json:"queue_id"`
+	ExpenseID int64     `db:"expense_id" json:"expense_id"`
+	Operation string    `db:"operation" json:"operation"`
+	Attempt   int64     `db:"attempt" json:"attempt"`
+	Class     string    `db:"class" json:"class"`
+	Message   string    `db:"message" json:"message"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
 }
 
 type SyncQueue struct {
@@ -86,4 +455,37 @@ type SyncQueue struct {
 	UpdatedAt          time.Time   `db:"updated_at" json:"updated_at"`
 	ProcessedAt        interface{} `db:"processed_at" json:"processed_at"`
 	NextRetryAt        interface{} `db:"next_retry_at" json:"next_retry_at"`
+	ExpenseVersion     interface{} `db:"expense_version" json:"expense_version"`
+	TraceParent        interface{} `db:"trace_parent" json:"trace_parent"`
+}
+
+type Tag struct {
+	ID        int64     `db:"id" json:"id"`
+	Name      string    `db:"name" json:"name"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+type User struct {
+	ID        int64     `db:"id" json:"id"`
+	Name      string    `db:"name" json:"name"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+type UserDailyUsage struct {
+	UserID   int64  `db:"user_id" json:"user_id"`
+	Day      string `db:"day" json:"day"`
+	Expenses int64  `db:"expenses" json:"expenses"`
+}
+
+type UserQuota struct {
+	UserID          int64         `db:"user_id" json:"user_id"`
+	DailyExpenses   sql.NullInt64 `db:"daily_expenses" json:"daily_expenses"`
+	AttachmentBytes sql.NullInt64 `db:"attachment_bytes" json:"attachment_bytes"`
+}
+
+type UtilityUsage struct {
+	ExpenseID int64     `db:"expense_id" json:"expense_id"`
+	Kind      string    `db:"kind" json:"kind"`
+	Quantity  float64   `db:"quantity" json:"quantity"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
 }
@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"spese/internal/core"
+)
+
+// ReviewedMonth is a month closed by its end-of-month review
+type ReviewedMonth struct {
+	Period   string // "2006-01"
+	ClosedAt time.Time
+}
+
+// CloseMonth closes a month: its expenses and incomes can no longer be
+// added or deleted. Closing a closed month keeps its first closing time.
+func (r *SQLiteRepository) CloseMonth(ctx context.Context, period string) error {
+	if _, err := core.ParsePeriod(period); err != nil {
+		return err
+	}
+	if err := r.queries.CloseMonth(ctx, period); err != nil {
+		return fmt.Errorf("close month: %w", err)
+	}
+	slog.InfoContext(ctx, "Month closed", "period", period)
+	return nil
+}
+
+// ReopenMonth lets the records of a closed month change again
+func (r *SQLiteRepository) ReopenMonth(ctx context.Context, period string) error {
+	n, err := r.queries.ReopenMonth(ctx, period)
+	if err != nil {
+		return fmt.Errorf("reopen month: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("month not closed: %s", period)
+	}
+	slog.InfoContext(ctx, "Month reopened", "period", period)
+	return nil
+}
+
+// MonthClosedAt returns when a month was closed, or the zero time while it
+// is open.
+func (r *SQLiteRepository) MonthClosedAt(ctx context.Context, period string) (time.Time, error) {
+	review, err := r.reader(ctx).GetMonthReview(ctx, period)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("get month review: %w", err)
+	}
+	return review.ClosedAt, nil
+}
+
+// ListClosedMonths returns the closed months, latest first
+func (r *SQLiteRepository) ListClosedMonths(ctx context.Context) ([]ReviewedMonth, error) {
+	rows, err := r.reader(ctx).ListMonthReviews(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list month reviews: %w", err)
+	}
+	months := make([]ReviewedMonth, len(rows))
+	for i, row := range rows {
+		months[i] = ReviewedMonth{Period: row.Period, ClosedAt: row.ClosedAt}
+	}
+	return months, nil
+}
+
+// ListReviewExpenses returns the expenses of a month with their IDs, and
+// those of them not synced to Google Sheets: failed ones, and pending ones
+// too when withPending is set. Card holds are never synced and are left
+// out of the latter.
+func (r *SQLiteRepository) ListReviewExpenses(ctx context.Context, year, month int, withPending bool) (all, unsynced []core.ReviewExpense, err error) {
+	rows, err := r.reader(ctx).GetExpensesByMonth(ctx, GetExpensesByMonthParams{
+		PRINTF:   int64(year),
+		PRINTF_2: int64(month),
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("get expenses by month: %w", err)
+	}
+	for _, row := range rows {
+		e := core.ReviewExpense{ID: row.ID, Expense: expenseFromRow(row)}
+		all = append(all, e)
+		if row.Status == string(core.StatusPending) {
+			continue
+		}
+		switch row.SyncStatus.String {
+		case "error":
+			unsynced = append(unsynced, e)
+		case "pending":
+			if withPending {
+				unsynced = append(unsynced, e)
+			}
+		}
+	}
+	return all, unsynced, nil
+}
+
+// checkMonthOpen returns core.ErrMonthClosed when the month of date was
+// closed by its review.
+func checkMonthOpen(ctx context.Context, q *Queries, date time.Time) error {
+	period := core.Period(date)
+	_, err := q.GetMonthReview(ctx, period)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("get month review: %w", err)
+	}
+	return fmt.Errorf("%w: %s", core.ErrMonthClosed, period)
+}
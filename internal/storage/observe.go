@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// QueryObserver receives how long each query of the repository took,
+// under its sqlc name (e.g. "GetExpense"), and whether it failed.
+type QueryObserver func(name string, elapsed time.Duration, err error)
+
+// ObserveQueries passes every query run through the generated queries to
+// observe, e.g. for a latency histogram. Queries inside a transaction are
+// observed too; the few statements run on the connections directly are
+// not.
+func (r *SQLiteRepository) ObserveQueries(observe QueryObserver) {
+	r.observer.Store(&observe)
+}
+
+// observed wraps a connection or a transaction of the repository with its
+// query observer.
+func (r *SQLiteRepository) observed(db DBTX) DBTX {
+	return observedDB{DBTX: db, observer: &r.observer}
+}
+
+// withTx returns the queries running in tx, observed like the others.
+func (r *SQLiteRepository) withTx(tx *sql.Tx) *Queries {
+	return New(r.observed(tx))
+}
+
+// observedDB times the statements sent to DBTX and traces each in a span.
+// Rows are timed until the first is ready, not while they are scanned.
+type observedDB struct {
+	DBTX
+	observer *atomic.Pointer[QueryObserver]
+}
+
+func (db observedDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	name := queryName(query)
+	ctx, span := startQuerySpan(ctx, name)
+	start := time.Now()
+	res, err := db.DBTX.ExecContext(ctx, query, args...)
+	db.observe(name, start, err)
+	endQuerySpan(span, err)
+	return res, err
+}
+
+func (db observedDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	name := queryName(query)
+	ctx, span := startQuerySpan(ctx, name)
+	start := time.Now()
+	rows, err := db.DBTX.QueryContext(ctx, query, args...)
+	db.observe(name, start, err)
+	endQuerySpan(span, err)
+	return rows, err
+}
+
+func (db observedDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	name := queryName(query)
+	ctx, span := startQuerySpan(ctx, name)
+	start := time.Now()
+	row := db.DBTX.QueryRowContext(ctx, query, args...)
+	db.observe(name, start, row.Err())
+	endQuerySpan(span, row.Err())
+	return row
+}
+
+func (db observedDB) observe(name string, start time.Time, err error) {
+	if observe := db.observer.Load(); observe != nil {
+		(*observe)(name, time.Since(start), err)
+	}
+}
+
+// queryName reads the name sqlc puts at the top of each query, e.g.
+// "-- name: GetExpense :one"; "other" for queries without one.
+func queryName(query string) string {
+	rest, ok := strings.CutPrefix(query, "-- name: ")
+	if !ok {
+		return "other"
+	}
+	name, _, _ := strings.Cut(rest, " ")
+	return name
+}
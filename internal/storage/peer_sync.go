@@ -0,0 +1,467 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"spese/internal/core"
+)
+
+// Peer record kinds, matching the peer_changelog.kind column.
+const (
+	PeerKindExpense = "expense"
+	PeerKindIncome  = "income"
+)
+
+// PeerRecord is the latest state of an expense or income as exchanged
+// between two instances. Records are identified by UID, never by the local
+// auto-increment ID, and a deleted record travels as a tombstone.
+type PeerRecord struct {
+	Kind        string `json:"kind"`
+	UID         string `json:"uid"`
+	Version     int64  `json:"version"`
+	ModifiedAt  string `json:"modified_at"`
+	Deleted     bool   `json:"deleted,omitempty"`
+	Date        string `json:"date,omitempty"` // YYYY-MM-DD
+	Description string `json:"description,omitempty"`
+	AmountCents int64  `json:"amount_cents,omitempty"`
+	Primary     string `json:"primary,omitempty"`     // expenses only
+	Secondary   string `json:"secondary,omitempty"`   // expenses only
+	Status      string `json:"status,omitempty"`      // expenses only, empty means cleared
+	Category    string `json:"category,omitempty"`    // incomes only
+	Subcategory string `json:"subcategory,omitempty"` // incomes only
+	Tags        string `json:"tags,omitempty"`        // comma-separated
+}
+
+// sameContent reports whether both records carry the same data.
+func (p PeerRecord) sameContent(o PeerRecord) bool {
+	return p.Deleted == o.Deleted &&
+		p.Date == o.Date &&
+		p.Description == o.Description &&
+		p.AmountCents == o.AmountCents &&
+		p.Primary == o.Primary &&
+		p.Secondary == o.Secondary &&
+		p.Status == o.Status &&
+		p.Category == o.Category &&
+		p.Subcategory == o.Subcategory &&
+		p.Tags == o.Tags
+}
+
+// wins reports whether the incoming record replaces the local one:
+// last writer wins on version, and on equal versions the later
+// modification wins so concurrent edits converge on both sides.
+func (p PeerRecord) wins(local PeerRecord) bool {
+	if p.Version != local.Version {
+		return p.Version > local.Version
+	}
+	return p.ModifiedAt > local.ModifiedAt && !p.sameContent(local)
+}
+
+func peerRecordFromExpense(e Expense, tags []string) PeerRecord {
+	status := ""
+	if e.Status == string(core.StatusPending) {
+		status = e.Status
+	}
+	return PeerRecord{
+		Kind:        PeerKindExpense,
+		UID:         e.Uid.String,
+		Version:     e.Version,
+		ModifiedAt:  e.ModifiedAt.String,
+		Date:        e.Date.Format("2006-01-02"),
+		Description: e.Description,
+		AmountCents: e.AmountCents,
+		Primary:     e.PrimaryCategory,
+		Secondary:   e.SecondaryCategory,
+		Status:      status,
+		Tags:        strings.Join(tags, ","),
+	}
+}
+
+func peerRecordFromIncome(i Income) PeerRecord {
+	return PeerRecord{
+		Kind:        PeerKindIncome,
+		UID:         i.Uid.String,
+		Version:     i.Version,
+		ModifiedAt:  i.ModifiedAt.String,
+		Date:        i.Date.Format("2006-01-02"),
+		Description: i.Description,
+		AmountCents: i.AmountCents,
+		Category:    i.Category,
+		Subcategory: i.Subcategory,
+		Tags:        i.Tags,
+	}
+}
+
+func peerRecordFromTombstone(t PeerTombstone) PeerRecord {
+	return PeerRecord{
+		Kind:       t.Kind,
+		UID:        t.Uid,
+		Version:    t.Version,
+		ModifiedAt: t.DeletedAt,
+		Deleted:    true,
+	}
+}
+
+// PeerChanges returns the current state of records changed after cursor, at
+// most limit of them, the cursor to pass on the next call and whether more
+// changes follow.
+func (r *SQLiteRepository) PeerChanges(ctx context.Context, cursor int64, limit int) ([]PeerRecord, int64, bool, error) {
+	rows, err := r.reader(ctx).ListPeerChanges(ctx, ListPeerChangesParams{Seq: cursor, Limit: int64(limit)})
+	if err != nil {
+		return nil, cursor, false, fmt.Errorf("list peer changes: %w", err)
+	}
+
+	records := make([]PeerRecord, 0, len(rows))
+	for _, row := range rows {
+		rec, found, err := loadPeerRecord(ctx, r.reader(ctx), row.Kind, row.Uid)
+		if err != nil {
+			return nil, cursor, false, err
+		}
+		cursor = row.Seq
+		if found {
+			records = append(records, rec)
+		}
+	}
+	return records, cursor, len(rows) == limit, nil
+}
+
+// loadPeerRecord returns the live record or its tombstone.
+func loadPeerRecord(ctx context.Context, q *Queries, kind, uid string) (PeerRecord, bool, error) {
+	key := sql.NullString{String: uid, Valid: true}
+
+	var (
+		rec PeerRecord
+		err error
+	)
+	switch kind {
+	case PeerKindExpense:
+		var e Expense
+		e, err = q.GetExpenseByUID(ctx, key)
+		if err == nil {
+			var tags []string
+			if tags, err = q.ListExpenseTags(ctx, e.ID); err != nil {
+				return PeerRecord{}, false, fmt.Errorf("list tags of expense %s: %w", uid, err)
+			}
+			rec = peerRecordFromExpense(e, tags)
+		}
+	case PeerKindIncome:
+		var i Income
+		i, err = q.GetIncomeByUID(ctx, key)
+		rec = peerRecordFromIncome(i)
+	default:
+		return PeerRecord{}, false, fmt.Errorf("unknown peer record kind %q", kind)
+	}
+	if err == nil {
+		return rec, true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return PeerRecord{}, false, fmt.Errorf("get %s %s: %w", kind, uid, err)
+	}
+
+	t, err := q.GetPeerTombstone(ctx, GetPeerTombstoneParams{Kind: kind, Uid: uid})
+	if errors.Is(err, sql.ErrNoRows) {
+		return PeerRecord{}, false, nil
+	}
+	if err != nil {
+		return PeerRecord{}, false, fmt.Errorf("get tombstone %s %s: %w", kind, uid, err)
+	}
+	return peerRecordFromTombstone(t), true, nil
+}
+
+// ApplyPeerChanges merges records received from a peer in one transaction
+// and returns how many changed local data. A record replaces the local one
+// only when it wins last-writer-wins; deletes leave a tombstone so an older
+// copy cannot bring the record back. New and deleted expenses are queued
+// for the Google Sheets sync like local ones.
+func (r *SQLiteRepository) ApplyPeerChanges(ctx context.Context, records []PeerRecord) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	q := r.withTx(tx)
+
+	applied := 0
+	for _, rec := range records {
+		changed, err := applyPeerRecord(ctx, q, rec)
+		if err != nil {
+			return 0, err
+		}
+		if changed {
+			applied++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit transaction: %w", err)
+	}
+	return applied, nil
+}
+
+func applyPeerRecord(ctx context.Context, q *Queries, rec PeerRecord) (bool, error) {
+	local, found, err := loadPeerRecord(ctx, q, rec.Kind, rec.UID)
+	if err != nil {
+		return false, err
+	}
+	if found && !rec.wins(local) {
+		return false, nil
+	}
+
+	key := sql.NullString{String: rec.UID, Valid: true}
+	modifiedAt := sql.NullString{String: rec.ModifiedAt, Valid: rec.ModifiedAt != ""}
+
+	if rec.Deleted {
+		if found && !local.Deleted {
+			if err := deletePeerRecord(ctx, q, rec.Kind, key); err != nil {
+				return false, err
+			}
+		}
+		if err := q.UpsertPeerTombstone(ctx, UpsertPeerTombstoneParams{
+			Kind:      rec.Kind,
+			Uid:       rec.UID,
+			Version:   rec.Version,
+			DeletedAt: rec.ModifiedAt,
+		}); err != nil {
+			return false, fmt.Errorf("store tombstone %s %s: %w", rec.Kind, rec.UID, err)
+		}
+		return found && !local.Deleted, nil
+	}
+
+	if found && local.Deleted {
+		if err := q.DeletePeerTombstone(ctx, DeletePeerTombstoneParams{Kind: rec.Kind, Uid: rec.UID}); err != nil {
+			return false, fmt.Errorf("clear tombstone %s %s: %w", rec.Kind, rec.UID, err)
+		}
+	}
+	exists := found && !local.Deleted
+
+	switch rec.Kind {
+	case PeerKindExpense:
+		if exists {
+			var old Expense
+			old, err = q.GetExpenseByUID(ctx, key)
+			if err != nil {
+				break
+			}
+			err = q.UpdateExpenseFromPeer(ctx, UpdateExpenseFromPeerParams{
+				Date:              rec.Date,
+				Description:       rec.Description,
+				AmountCents:       rec.AmountCents,
+				PrimaryCategory:   rec.Primary,
+				SecondaryCategory: rec.Secondary,
+				Status:            peerExpenseStatus(rec),
+				Version:           rec.Version,
+				ModifiedAt:        modifiedAt,
+				Uid:               key,
+			})
+			if err == nil {
+				err = recordPeerExpenseVersion(ctx, q, &old, key)
+			}
+			// A card hold cleared by the peer gets its first Sheets sync
+			if err == nil && old.Status == string(core.StatusPending) && peerExpenseStatus(rec) == string(core.StatusCleared) {
+				err = enqueuePeerExpense(ctx, q, key)
+			}
+			break
+		}
+		err = q.CreateExpenseFromPeer(ctx, CreateExpenseFromPeerParams{
+			Uid:               key,
+			Date:              rec.Date,
+			Description:       rec.Description,
+			AmountCents:       rec.AmountCents,
+			PrimaryCategory:   rec.Primary,
+			SecondaryCategory: rec.Secondary,
+			Status:            peerExpenseStatus(rec),
+			Version:           rec.Version,
+			ModifiedAt:        modifiedAt,
+		})
+		if err == nil {
+			err = recordPeerExpenseVersion(ctx, q, nil, key)
+		}
+		if err == nil && peerExpenseStatus(rec) == string(core.StatusCleared) {
+			err = enqueuePeerExpense(ctx, q, key)
+		}
+	case PeerKindIncome:
+		if exists {
+			var old Income
+			old, err = q.GetIncomeByUID(ctx, key)
+			if err != nil {
+				break
+			}
+			err = q.UpdateIncomeFromPeer(ctx, UpdateIncomeFromPeerParams{
+				Date:        rec.Date,
+				Description: rec.Description,
+				AmountCents: rec.AmountCents,
+				Category:    rec.Category,
+				Subcategory: rec.Subcategory,
+				Tags:        rec.Tags,
+				Version:     rec.Version,
+				ModifiedAt:  modifiedAt,
+				Uid:         key,
+			})
+			if err == nil {
+				err = auditPeerIncome(ctx, q, &old, key)
+			}
+			break
+		}
+		err = q.CreateIncomeFromPeer(ctx, CreateIncomeFromPeerParams{
+			Uid:         key,
+			Date:        rec.Date,
+			Description: rec.Description,
+			AmountCents: rec.AmountCents,
+			Category:    rec.Category,
+			Subcategory: rec.Subcategory,
+			Tags:        rec.Tags,
+			Version:     rec.Version,
+			ModifiedAt:  modifiedAt,
+		})
+		if err == nil {
+			err = auditPeerIncome(ctx, q, nil, key)
+		}
+	}
+	if err == nil && rec.Kind == PeerKindExpense {
+		err = linkPeerExpenseTags(ctx, q, key, rec.Tags)
+	}
+	if err == nil && rec.Kind == PeerKindIncome {
+		err = linkPeerIncomeTags(ctx, q, key)
+	}
+	if err != nil {
+		return false, fmt.Errorf("apply %s %s: %w", rec.Kind, rec.UID, err)
+	}
+
+	slog.DebugContext(ctx, "Applied peer change", "kind", rec.Kind, "uid", rec.UID, "version", rec.Version)
+	return true, nil
+}
+
+// peerExpenseStatus returns the status to store for an expense record;
+// peers without statuses send none, meaning cleared.
+func peerExpenseStatus(rec PeerRecord) string {
+	if rec.Status == "" {
+		return string(core.StatusCleared)
+	}
+	return rec.Status
+}
+
+// recordPeerExpenseVersion adds the history row for an expense written by
+// a peer; old is nil when the expense was created.
+func recordPeerExpenseVersion(ctx context.Context, q *Queries, old *Expense, key sql.NullString) error {
+	updated, err := q.GetExpenseByUID(ctx, key)
+	if err != nil {
+		return fmt.Errorf("get expense %s: %w", key.String, err)
+	}
+	if err := recordExpenseVersion(ctx, q, updated.ID, updated.Version, diffExpenses(old, updated)); err != nil {
+		return err
+	}
+	return auditExpense(ctx, q, updated.ID, old, &updated)
+}
+
+// auditPeerIncome records the change of an income written by a peer; old
+// is nil when the income was created.
+func auditPeerIncome(ctx context.Context, q *Queries, old *Income, key sql.NullString) error {
+	updated, err := q.GetIncomeByUID(ctx, key)
+	if err != nil {
+		return fmt.Errorf("get income %s: %w", key.String, err)
+	}
+	return auditIncome(ctx, q, updated.ID, old, &updated)
+}
+
+// deletePeerRecord removes a live record, queueing the Sheets delete for
+// expenses.
+func deletePeerRecord(ctx context.Context, q *Queries, kind string, key sql.NullString) error {
+	if kind == PeerKindIncome {
+		income, err := q.GetIncomeByUID(ctx, key)
+		if err != nil {
+			return fmt.Errorf("get income %s: %w", key.String, err)
+		}
+		if err := q.DeleteIncomeByUID(ctx, key); err != nil {
+			return fmt.Errorf("delete income %s: %w", key.String, err)
+		}
+		return auditIncome(ctx, q, income.ID, &income, nil)
+	}
+
+	expense, err := q.GetExpenseByUID(ctx, key)
+	if err != nil {
+		return fmt.Errorf("get expense %s: %w", key.String, err)
+	}
+	rows, err := sheetRows(ctx, q, expense)
+	if err != nil {
+		return err
+	}
+	if err := q.DeleteExpenseByUID(ctx, key); err != nil {
+		return fmt.Errorf("delete expense %s: %w", key.String, err)
+	}
+	if err := auditExpense(ctx, q, expense.ID, &expense, nil); err != nil {
+		return err
+	}
+	if expense.Status == string(core.StatusPending) {
+		// Card holds never reached the sheet
+		return nil
+	}
+	return enqueueSheetDelete(ctx, q, expense.ID, rows)
+}
+
+// enqueuePeerExpense queues a newly received expense for the Sheets sync.
+// Updates are not queued: the Sheets sync appends rows, so re-sending an
+// edited expense would duplicate it.
+func enqueuePeerExpense(ctx context.Context, q *Queries, key sql.NullString) error {
+	expense, err := q.GetExpenseByUID(ctx, key)
+	if err != nil {
+		return fmt.Errorf("get expense %s: %w", key.String, err)
+	}
+	if _, err := q.EnqueueSync(ctx, EnqueueSyncParams{
+		ExpenseID:      expense.ID,
+		ExpenseVersion: expense.Version,
+		TraceParent:    traceParent(ctx),
+	}); err != nil {
+		return fmt.Errorf("enqueue sync: %w", err)
+	}
+	return nil
+}
+
+// linkPeerExpenseTags links a received expense to the comma-separated tags
+// of its record.
+func linkPeerExpenseTags(ctx context.Context, q *Queries, key sql.NullString, tags string) error {
+	expense, err := q.GetExpenseByUID(ctx, key)
+	if err != nil {
+		return fmt.Errorf("get expense %s: %w", key.String, err)
+	}
+	return setExpenseTags(ctx, q, expense.ID, core.ParseTags(tags))
+}
+
+// linkPeerIncomeTags links a received income to the tags of its tags
+// column.
+func linkPeerIncomeTags(ctx context.Context, q *Queries, key sql.NullString) error {
+	income, err := q.GetIncomeByUID(ctx, key)
+	if err != nil {
+		return fmt.Errorf("get income %s: %w", key.String, err)
+	}
+	return setIncomeTags(ctx, q, income.ID, income.Tags)
+}
+
+// PeerSyncCursors returns the pull and push cursors stored for peer, zero
+// when it was never synced.
+func (r *SQLiteRepository) PeerSyncCursors(ctx context.Context, peer string) (pull, push int64, err error) {
+	state, err := r.queries.GetPeerSyncState(ctx, peer)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("get peer sync state: %w", err)
+	}
+	return state.PullCursor, state.PushCursor, nil
+}
+
+// SavePeerSyncCursors records sync progress with peer.
+func (r *SQLiteRepository) SavePeerSyncCursors(ctx context.Context, peer string, pull, push int64) error {
+	if err := r.queries.SavePeerSyncState(ctx, SavePeerSyncStateParams{
+		Peer:       peer,
+		PullCursor: pull,
+		PushCursor: push,
+	}); err != nil {
+		return fmt.Errorf("save peer sync state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"spese/internal/core"
+)
+
+// SetExcludePending makes ReadMonthOverview leave card holds that are not
+// yet settled out of the month total and categories.
+func (r *SQLiteRepository) SetExcludePending(exclude bool) {
+	r.excludePending = exclude
+}
+
+// expenseStatus returns the status stored for e, cleared when unset.
+func expenseStatus(e core.Expense) string {
+	if e.Status == "" {
+		return string(core.StatusCleared)
+	}
+	return string(e.Status)
+}
+
+// settlePendingMatch clears the card hold replaced by the settled expense e,
+// giving it e's date and amount. ok is false when no pending expense
+// matches.
+func settlePendingMatch(ctx context.Context, q *Queries, e core.Expense, dateStr string) (settled Expense, ok bool, err error) {
+	hold, err := q.FindPendingExpenseMatch(ctx, FindPendingExpenseMatchParams{
+		Description: e.Description,
+		SettledDate: dateStr,
+		AmountCents: e.Amount.Cents,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return Expense{}, false, nil
+	}
+	if err != nil {
+		return Expense{}, false, fmt.Errorf("find pending expense: %w", err)
+	}
+
+	settled, err = settleHold(ctx, q, hold, dateStr, e.Amount.Cents)
+	if err != nil {
+		return Expense{}, false, err
+	}
+	slog.InfoContext(ctx, "Pending expense cleared by settled transaction",
+		"id", hold.ID,
+		"hold_cents", hold.AmountCents,
+		"settled_cents", settled.AmountCents)
+	return settled, true, nil
+}
+
+// settleHold marks hold cleared with the settled date and amount, records
+// the change and queues the expense for its first Sheets sync.
+func settleHold(ctx context.Context, q *Queries, hold Expense, dateStr string, cents int64) (Expense, error) {
+	n, err := q.SettleExpense(ctx, SettleExpenseParams{
+		Date:        dateStr,
+		AmountCents: cents,
+		ID:          hold.ID,
+	})
+	if err != nil {
+		return Expense{}, fmt.Errorf("settle expense: %w", err)
+	}
+	if n == 0 {
+		return Expense{}, fmt.Errorf("expense %d is not pending", hold.ID)
+	}
+
+	settled, err := q.GetExpense(ctx, hold.ID)
+	if err != nil {
+		return Expense{}, fmt.Errorf("get expense: %w", err)
+	}
+	if err := recordExpenseVersion(ctx, q, settled.ID, settled.Version, diffExpenses(&hold, settled)); err != nil {
+		return Expense{}, err
+	}
+	if err := auditExpense(ctx, q, settled.ID, &hold, &settled); err != nil {
+		return Expense{}, err
+	}
+	if err := recordDescriptionPrice(ctx, q, settled); err != nil {
+		return Expense{}, err
+	}
+	if _, err := q.EnqueueSync(ctx, EnqueueSyncParams{
+		ExpenseID:      settled.ID,
+		ExpenseVersion: settled.Version,
+		TraceParent:    traceParent(ctx),
+	}); err != nil {
+		return Expense{}, fmt.Errorf("enqueue sync: %w", err)
+	}
+	return settled, nil
+}
+
+// ClearExpense marks a pending expense as cleared, keeping its date and
+// amount, and queues it for the Sheets sync.
+func (r *SQLiteRepository) ClearExpense(ctx context.Context, id int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	txQueries := r.withTx(tx)
+
+	hold, err := txQueries.GetExpense(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("expense not found: %d", id)
+	}
+	if err != nil {
+		return fmt.Errorf("get expense: %w", err)
+	}
+	if _, err := settleHold(ctx, txQueries, hold, hold.Date.Format("2006-01-02"), hold.AmountCents); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+
+	slog.InfoContext(ctx, "Pending expense cleared", "id", id)
+	return nil
+}
+
+// excludePendingAmounts subtracts the month's pending expenses from the
+// overview.
+func (r *SQLiteRepository) excludePendingAmounts(ctx context.Context, overview *core.MonthOverview) error {
+	sums, err := r.reader(ctx).GetPendingCategorySums(ctx, GetPendingCategorySumsParams{
+		PRINTF:   int64(overview.Year),
+		PRINTF_2: int64(overview.Month),
+	})
+	if err != nil {
+		return fmt.Errorf("get pending category sums: %w", err)
+	}
+	amounts := make(map[string]int64, len(sums))
+	for _, s := range sums {
+		amounts[s.PrimaryCategory] = s.TotalAmount
+	}
+	subtractCategoryAmounts(overview, amounts)
+	return nil
+}
@@ -0,0 +1,165 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"spese/internal/core"
+)
+
+// ItemPriceHistory is a tracked item with its unit prices, oldest first.
+type ItemPriceHistory struct {
+	ID     int64
+	Name   string
+	Prices []core.PriceObservation
+}
+
+// TrackItem starts tracking the price of an item, picking up the past
+// expenses described as it. Tracking an item already known refreshes those
+// prices. Returns the item ID and how many expenses matched.
+func (r *SQLiteRepository) TrackItem(ctx context.Context, name string) (int64, int, error) {
+	name = strings.Join(strings.Fields(name), " ")
+	if err := core.ValidateShoppingName(name); err != nil {
+		return 0, 0, err
+	}
+	normalized := core.NormalizeItemName(name)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	txQueries := r.withTx(tx)
+
+	id, err := txQueries.UpsertItem(ctx, UpsertItemParams{Name: name, NormalizedName: normalized})
+	if err != nil {
+		return 0, 0, fmt.Errorf("upsert item: %w", err)
+	}
+	if err := txQueries.DeleteItemPricesBySource(ctx, DeleteItemPricesBySourceParams{
+		ItemID: id,
+		Source: string(core.PriceFromDescription),
+	}); err != nil {
+		return 0, 0, fmt.Errorf("delete item prices: %w", err)
+	}
+
+	expenses, err := txQueries.ListAllExpenses(ctx)
+	if err != nil {
+		return 0, 0, fmt.Errorf("list expenses: %w", err)
+	}
+	matched := 0
+	for _, e := range expenses {
+		if e.Status == string(core.StatusPending) || core.NormalizeItemName(e.Description) != normalized {
+			continue
+		}
+		if err := createDescriptionPrice(ctx, txQueries, id, e); err != nil {
+			return 0, 0, err
+		}
+		matched++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("commit transaction: %w", err)
+	}
+	return id, matched, nil
+}
+
+// UntrackItem stops tracking an item, dropping its price history.
+func (r *SQLiteRepository) UntrackItem(ctx context.Context, id int64) error {
+	n, err := r.queries.DeleteItem(ctx, id)
+	if err != nil {
+		return fmt.Errorf("delete item: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("item not found: %d", id)
+	}
+	return nil
+}
+
+// ListItemPriceHistory returns every tracked item with its prices, by name.
+func (r *SQLiteRepository) ListItemPriceHistory(ctx context.Context) ([]ItemPriceHistory, error) {
+	q := r.reader(ctx)
+	items, err := q.ListItems(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list items: %w", err)
+	}
+	prices, err := q.ListItemPrices(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list item prices: %w", err)
+	}
+
+	byItem := make(map[int64][]core.PriceObservation, len(items))
+	for _, p := range prices {
+		byItem[p.ItemID] = append(byItem[p.ItemID], core.PriceObservation{
+			Date:      core.Date{Time: p.Date},
+			Quantity:  p.Quantity,
+			UnitPrice: core.Money{Cents: p.UnitPriceCents},
+		})
+	}
+
+	history := make([]ItemPriceHistory, len(items))
+	for i, item := range items {
+		history[i] = ItemPriceHistory{ID: item.ID, Name: item.Name, Prices: byItem[item.ID]}
+	}
+	return history, nil
+}
+
+// recordDescriptionPrice records the price given by an expense described as
+// a tracked item, if any.
+func recordDescriptionPrice(ctx context.Context, q *Queries, e Expense) error {
+	id, err := q.GetItemIDByNormalizedName(ctx, core.NormalizeItemName(e.Description))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("get item: %w", err)
+	}
+	return createDescriptionPrice(ctx, q, id, e)
+}
+
+func createDescriptionPrice(ctx context.Context, q *Queries, itemID int64, e Expense) error {
+	if e.AmountCents <= 0 {
+		return nil
+	}
+	if err := q.CreateItemPrice(ctx, CreateItemPriceParams{
+		ItemID:         itemID,
+		ExpenseID:      e.ID,
+		Date:           e.Date,
+		Quantity:       1,
+		UnitPriceCents: e.AmountCents,
+		Source:         string(core.PriceFromDescription),
+	}); err != nil {
+		return fmt.Errorf("create item price: %w", err)
+	}
+	return nil
+}
+
+// recordLineItemPrice records the unit price of a bought line, tracking the
+// item when it is new.
+func recordLineItemPrice(ctx context.Context, q *Queries, expense Expense, name string, quantity float64, total core.Money) error {
+	if total.Cents <= 0 {
+		return nil
+	}
+	id, err := q.UpsertItem(ctx, UpsertItemParams{Name: name, NormalizedName: core.NormalizeItemName(name)})
+	if err != nil {
+		return fmt.Errorf("upsert item: %w", err)
+	}
+	unit := core.UnitPrice(total, quantity)
+	if unit.Cents <= 0 {
+		return nil
+	}
+	if err := q.CreateItemPrice(ctx, CreateItemPriceParams{
+		ItemID:         id,
+		ExpenseID:      expense.ID,
+		Date:           expense.Date,
+		Quantity:       quantity,
+		UnitPriceCents: unit.Cents,
+		Source:         string(core.PriceFromLineItem),
+	}); err != nil {
+		return fmt.Errorf("create item price: %w", err)
+	}
+	return nil
+}
@@ -6,75 +6,530 @@ package storage
 
 import (
 	"context"
+	"database/sql"
+	"time"
 )
 
 type Querier interface {
+	AddExpenseTag(ctx context.Context, arg AddExpenseTagParams) error
+	AddImportBatchExpense(ctx context.Context, arg AddImportBatchExpenseParams) error
+	AddIncomeTag(ctx context.Context, arg AddIncomeTagParams) error
+	AdvanceSheetHistoryImport(ctx context.Context, arg AdvanceSheetHistoryImportParams) error
+	// Completes the sync items of an expense not picked up yet, for an
+	// expense removed before reaching the sheet.
+	CancelPendingSyncItems(ctx context.Context, expenseID int64) (int64, error)
 	// Removes completed items older than the specified timestamp.
 	CleanupCompletedSyncs(ctx context.Context, processedAt interface{}) error
+	// Removes failed attempts older than the specified timestamp.
+	CleanupSyncErrors(ctx context.Context, createdAt time.Time) error
+	ClearMonthlyAggregates(ctx context.Context) error
+	CloseMonth(ctx context.Context, period string) error
+	CompleteSheetHistoryImport(ctx context.Context, year int64) error
+	// Adds count expenses to the day of a member unless that goes over
+	// max_expenses (0 for no limit); no row comes back when it would.
+	ConsumeDailyExpenses(ctx context.Context, arg ConsumeDailyExpensesParams) (int64, error)
+	CountClassifierFeedback(ctx context.Context) ([]CountClassifierFeedbackRow, error)
+	CountExpensesByWorkflowState(ctx context.Context) ([]CountExpensesByWorkflowStateRow, error)
+	CountExpensesInYear(ctx context.Context, printf interface{}) (int64, error)
+	// Queue items of an expense not written to the sheet yet.
+	CountOpenSyncItemsForExpense(ctx context.Context, expenseID int64) (int64, error)
+	CountPrimaryCategoryReferences(ctx context.Context, primaryCategory string) (int64, error)
+	CountSecondaryCategoryReferences(ctx context.Context, arg CountSecondaryCategoryReferencesParams) (int64, error)
+	// Months and categories whose aggregate differs from their entries,
+	// missing and leftover rows included.
+	CountStaleAggregates(ctx context.Context) (int64, error)
+	CountSyncErrorsByClass(ctx context.Context) ([]CountSyncErrorsByClassRow, error)
+	// Accounts and the transfers between them
+	CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error)
+	CreateAccountTransfer(ctx context.Context, arg CreateAccountTransferParams) (AccountTransfer, error)
+	CreateApiKey(ctx context.Context, arg CreateApiKeyParams) (ApiKey, error)
+	// Audit log
+	// Records a change to an expense, income, recurrent or category.
+	CreateAuditEntry(ctx context.Context, arg CreateAuditEntryParams) error
+	// Category Rules queries
+	// Stores a categorization rule.
+	CreateCategoryRule(ctx context.Context, arg CreateCategoryRuleParams) (CategoryRule, error)
 	CreateExpense(ctx context.Context, arg CreateExpenseParams) (Expense, error)
+	CreateExpenseCalculation(ctx context.Context, arg CreateExpenseCalculationParams) error
+	CreateExpenseFromPeer(ctx context.Context, arg CreateExpenseFromPeerParams) error
+	CreateExpenseLineItem(ctx context.Context, arg CreateExpenseLineItemParams) error
+	CreateExpenseSplit(ctx context.Context, arg CreateExpenseSplitParams) error
+	// Expense Versions queries
+	// Records a modification of an expense with its field diff.
+	CreateExpenseVersion(ctx context.Context, arg CreateExpenseVersionParams) error
+	CreateHistoryExpense(ctx context.Context, arg CreateHistoryExpenseParams) (Expense, error)
+	CreateImportBatch(ctx context.Context, filename string) (ImportBatch, error)
 	// Income queries
 	CreateIncome(ctx context.Context, arg CreateIncomeParams) (Income, error)
+	CreateIncomeFromPeer(ctx context.Context, arg CreateIncomeFromPeerParams) error
+	// Records an insight unless the same kind was already recorded for the
+	// category and month.
+	CreateInsight(ctx context.Context, arg CreateInsightParams) (int64, error)
+	CreateItemPrice(ctx context.Context, arg CreateItemPriceParams) error
+	CreateJobRun(ctx context.Context, arg CreateJobRunParams) (Job, error)
 	CreatePrimaryCategory(ctx context.Context, name string) (PrimaryCategory, error)
 	// Recurrent Expenses queries
 	CreateRecurrentExpense(ctx context.Context, arg CreateRecurrentExpenseParams) (RecurrentExpense, error)
+	CreateRecurrentIncome(ctx context.Context, arg CreateRecurrentIncomeParams) (RecurrentIncome, error)
+	CreateSavedView(ctx context.Context, arg CreateSavedViewParams) (int64, error)
 	CreateSecondaryCategory(ctx context.Context, arg CreateSecondaryCategoryParams) (SecondaryCategory, error)
+	CreateShoppingList(ctx context.Context, name string) (int64, error)
+	CreateShoppingListItem(ctx context.Context, arg CreateShoppingListItemParams) error
+	// Household members
+	CreateUser(ctx context.Context, name string) (User, error)
 	DeactivateRecurrentExpense(ctx context.Context, id int64) error
+	DeactivateRecurrentIncome(ctx context.Context, id int64) (int64, error)
+	// Puts an item back to pending for a later poll without counting an attempt,
+	// e.g. when Google Sheets throttled the write.
+	DeferSyncItem(ctx context.Context, arg DeferSyncItemParams) error
+	DeleteAccount(ctx context.Context, id int64) (int64, error)
+	DeleteAccountTransfer(ctx context.Context, id int64) (int64, error)
+	DeleteAlertPreference(ctx context.Context, arg DeleteAlertPreferenceParams) (int64, error)
+	DeleteAllAccounts(ctx context.Context) error
+	DeleteAllAlertPreferences(ctx context.Context) error
+	DeleteAllAuditLog(ctx context.Context) error
+	DeleteAllCategoryKeywords(ctx context.Context) error
+	DeleteAllCategoryRules(ctx context.Context) error
+	DeleteAllClassifierFeedback(ctx context.Context) error
+	DeleteAllExpenseCalculations(ctx context.Context) error
+	DeleteAllExpenseLineItems(ctx context.Context) error
+	DeleteAllExpenseReimbursements(ctx context.Context) error
+	DeleteAllExpenseSplits(ctx context.Context) error
+	DeleteAllExpenseTags(ctx context.Context) error
+	DeleteAllExpenseVersions(ctx context.Context) error
+	DeleteAllExpenseWorkflow(ctx context.Context) error
+	DeleteAllExpenses(ctx context.Context) error
+	DeleteAllFuelFills(ctx context.Context) error
+	DeleteAllIncomeTags(ctx context.Context) error
+	DeleteAllIncomes(ctx context.Context) error
+	DeleteAllInsightMutes(ctx context.Context) error
+	DeleteAllInsights(ctx context.Context) error
+	DeleteAllItemPrices(ctx context.Context) error
+	DeleteAllItems(ctx context.Context) error
+	DeleteAllMonthReviews(ctx context.Context) error
+	DeleteAllPeerChangelog(ctx context.Context) error
+	DeleteAllPeerTombstones(ctx context.Context) error
+	DeleteAllPurchaseWarranties(ctx context.Context) error
+	DeleteAllRecurrentExpenses(ctx context.Context) error
+	DeleteAllRecurrentIncomes(ctx context.Context) error
+	DeleteAllSavedViews(ctx context.Context) error
+	DeleteAllSheetHistoryImports(ctx context.Context) error
+	DeleteAllShoppingListItems(ctx context.Context) error
+	DeleteAllShoppingLists(ctx context.Context) error
+	DeleteAllSyncErrors(ctx context.Context) error
+	DeleteAllSyncQueue(ctx context.Context) error
+	DeleteAllTags(ctx context.Context) error
+	DeleteAllUsers(ctx context.Context) error
+	DeleteAllUtilityUsage(ctx context.Context) error
+	DeleteApiKey(ctx context.Context, id int64) (int64, error)
+	DeleteBlobDeletion(ctx context.Context, blobKey string) error
+	DeleteCategoryKeyword(ctx context.Context, keyword string) (int64, error)
+	// Removes the mapping of a Google Sheets secondary category.
+	DeleteCategoryMapping(ctx context.Context, sheetName string) (int64, error)
+	// Removes a rule.
+	DeleteCategoryRule(ctx context.Context, id int64) (int64, error)
+	DeleteCategoryTranslation(ctx context.Context, arg DeleteCategoryTranslationParams) (int64, error)
+	// Removes a secondary category whose primary category no longer exists.
+	DeleteDetachedSecondaryCategory(ctx context.Context, id int64) (SecondaryCategory, error)
+	DeleteExpenseByUID(ctx context.Context, uid sql.NullString) error
+	DeleteExpenseLineItems(ctx context.Context, expenseID int64) error
+	DeleteExpenseReimbursement(ctx context.Context, arg DeleteExpenseReimbursementParams) (int64, error)
+	DeleteExpenseSplits(ctx context.Context, expenseID int64) error
+	DeleteExpenseTags(ctx context.Context, expenseID int64) error
+	DeleteFuelFill(ctx context.Context, expenseID int64) (int64, error)
+	DeleteIncomeByUID(ctx context.Context, uid sql.NullString) error
+	DeleteIncomeTags(ctx context.Context, incomeID int64) error
+	DeleteItem(ctx context.Context, id int64) (int64, error)
+	DeleteItemPricesByExpense(ctx context.Context, arg DeleteItemPricesByExpenseParams) error
+	DeleteItemPricesBySource(ctx context.Context, arg DeleteItemPricesBySourceParams) error
+	DeletePeerTombstone(ctx context.Context, arg DeletePeerTombstoneParams) error
 	DeletePrimaryCategory(ctx context.Context, name string) error
+	DeletePrimaryCategoryByID(ctx context.Context, id int64) error
+	DeletePurchaseWarranty(ctx context.Context, expenseID int64) (int64, error)
+	DeleteReceipt(ctx context.Context, expenseID int64) (int64, error)
 	DeleteRecurrentExpense(ctx context.Context, id int64) error
+	DeleteSavedView(ctx context.Context, id int64) (int64, error)
+	// Foreign keys are not enforced, so the cascade of the schema never runs.
+	DeleteSecondaryCategoriesByPrimaryID(ctx context.Context, primaryCategoryID int64) error
 	DeleteSecondaryCategory(ctx context.Context, name string) error
+	DeleteSecondaryCategoryByID(ctx context.Context, id int64) error
+	// Converted lists are kept as the breakdown of their expense.
+	DeleteShoppingList(ctx context.Context, id int64) (int64, error)
+	DeleteShoppingListItem(ctx context.Context, id int64) (int64, error)
+	DeleteUser(ctx context.Context, id int64) (int64, error)
+	DeleteUtilityUsage(ctx context.Context, expenseID int64) (int64, error)
 	// Fetches a batch of pending items ready for processing.
 	DequeueSyncBatch(ctx context.Context, limit int64) ([]SyncQueue, error)
+	DismissInsight(ctx context.Context, id int64) (int64, error)
 	// Enqueues a delete operation with full expense data.
 	EnqueueDelete(ctx context.Context, arg EnqueueDeleteParams) (SyncQueue, error)
 	// Sync Queue queries
 	// Enqueues a sync operation for an expense.
-	EnqueueSync(ctx context.Context, expenseID int64) (SyncQueue, error)
+	EnqueueSync(ctx context.Context, arg EnqueueSyncParams) (SyncQueue, error)
+	FailSheetHistoryImport(ctx context.Context, arg FailSheetHistoryImportParams) error
+	// Recomputes the aggregates from the entries; run after
+	// ClearMonthlyAggregates.
+	FillMonthlyAggregates(ctx context.Context) error
+	// The card hold a settled transaction replaces: same description, dated up
+	// to 10 days before it, closest amount first.
+	FindPendingExpenseMatch(ctx context.Context, arg FindPendingExpenseMatchParams) (Expense, error)
+	// Live expenses of a day with an amount, to warn about a new expense that
+	// looks like one of them.
+	FindSimilarExpenses(ctx context.Context, arg FindSimilarExpensesParams) ([]Expense, error)
+	FinishJobRun(ctx context.Context, arg FinishJobRunParams) error
+	GetAccount(ctx context.Context, id int64) (Account, error)
 	GetActiveRecurrentExpensesByDate(ctx context.Context, arg GetActiveRecurrentExpensesByDateParams) ([]RecurrentExpense, error)
 	GetActiveRecurrentExpensesForProcessing(ctx context.Context, arg GetActiveRecurrentExpensesForProcessingParams) ([]RecurrentExpense, error)
+	GetActiveRecurrentIncomesForProcessing(ctx context.Context, arg GetActiveRecurrentIncomesForProcessingParams) ([]RecurrentIncome, error)
 	GetAllCategoriesWithSubs(ctx context.Context) ([]GetAllCategoriesWithSubsRow, error)
+	// The key with the given hash and its member.
+	GetApiKeyUser(ctx context.Context, keyHash string) (GetApiKeyUserRow, error)
+	// Bytes of the receipts a member uploaded, leaving out the receipt of
+	// except_expense (the one an upload would replace).
+	GetAttachmentBytes(ctx context.Context, arg GetAttachmentBytesParams) (int64, error)
 	GetCategoriesOrderedByUsage(ctx context.Context) ([]GetCategoriesOrderedByUsageRow, error)
+	// Returns the primary category a Google Sheets secondary category maps to.
+	GetCategoryMapping(ctx context.Context, sheetName string) (string, error)
+	// Names of a secondary category and of its primary category.
+	GetCategoryPairByID(ctx context.Context, id int64) (GetCategoryPairByIDRow, error)
 	GetCategorySums(ctx context.Context, arg GetCategorySumsParams) ([]GetCategorySumsRow, error)
+	GetDailyExpenses(ctx context.Context, arg GetDailyExpensesParams) (int64, error)
 	GetExpense(ctx context.Context, id int64) (Expense, error)
+	GetExpenseByUID(ctx context.Context, uid sql.NullString) (Expense, error)
+	GetExpenseCalculation(ctx context.Context, expenseID int64) (ExpenseCalculation, error)
+	GetExpenseReimbursedTotal(ctx context.Context, expenseID int64) (int64, error)
+	GetExpenseWorkflowState(ctx context.Context, expenseID int64) (string, error)
 	GetExpensesByMonth(ctx context.Context, arg GetExpensesByMonthParams) ([]Expense, error)
+	GetFuelFill(ctx context.Context, expenseID int64) (FuelFill, error)
+	GetImportBatch(ctx context.Context, id int64) (ImportBatch, error)
 	GetIncome(ctx context.Context, id int64) (Income, error)
+	GetIncomeByUID(ctx context.Context, uid sql.NullString) (Income, error)
 	GetIncomeCategories(ctx context.Context) ([]string, error)
 	GetIncomeCategorySums(ctx context.Context, arg GetIncomeCategorySumsParams) ([]GetIncomeCategorySumsRow, error)
 	GetIncomeMonthTotal(ctx context.Context, arg GetIncomeMonthTotalParams) (int64, error)
+	GetIncomeReimbursedTotal(ctx context.Context, incomeID int64) (int64, error)
+	GetIncomeSubcategories(ctx context.Context, category string) ([]string, error)
+	GetIncomeSubcategorySums(ctx context.Context, arg GetIncomeSubcategorySumsParams) ([]GetIncomeSubcategorySumsRow, error)
 	GetIncomesByMonth(ctx context.Context, arg GetIncomesByMonthParams) ([]Income, error)
+	GetInsight(ctx context.Context, id int64) (Insight, error)
+	GetItemIDByNormalizedName(ctx context.Context, normalizedName string) (int64, error)
+	GetJobRuns(ctx context.Context, arg GetJobRunsParams) ([]Job, error)
+	// Latest run of each job
+	GetLatestJobRuns(ctx context.Context) ([]Job, error)
+	GetLedgerTotals(ctx context.Context, arg GetLedgerTotalsParams) (GetLedgerTotalsRow, error)
+	GetMonthReview(ctx context.Context, period string) (MonthReview, error)
 	GetMonthTotal(ctx context.Context, arg GetMonthTotalParams) (int64, error)
+	// Settled expenses of a month per payer; a NULL payer collects the
+	// expenses nobody is set on.
+	GetMonthTotalsByPayer(ctx context.Context, arg GetMonthTotalsByPayerParams) ([]GetMonthTotalsByPayerRow, error)
+	// Expenses paid by a member per primary category between two dates.
+	GetPayerCategoryTotals(ctx context.Context, arg GetPayerCategoryTotalsParams) ([]GetPayerCategoryTotalsRow, error)
+	GetPeerSyncState(ctx context.Context, peer string) (PeerSyncState, error)
+	GetPeerTombstone(ctx context.Context, arg GetPeerTombstoneParams) (PeerTombstone, error)
+	// Amounts of card holds not yet settled per primary category.
+	GetPendingCategorySums(ctx context.Context, arg GetPendingCategorySumsParams) ([]GetPendingCategorySumsRow, error)
 	GetPendingSyncExpenses(ctx context.Context, limit int64) ([]GetPendingSyncExpensesRow, error)
 	// Primary Categories queries
 	GetPrimaryCategories(ctx context.Context) ([]string, error)
+	GetPrimaryCategoryByName(ctx context.Context, name string) (PrimaryCategory, error)
+	GetReceipt(ctx context.Context, expenseID int64) (Receipt, error)
 	GetRecurrentExpenseByID(ctx context.Context, id int64) (RecurrentExpense, error)
 	GetRecurrentExpenses(ctx context.Context) ([]RecurrentExpense, error)
+	GetRecurrentIncomeByID(ctx context.Context, id int64) (RecurrentIncome, error)
+	GetRecurrentIncomes(ctx context.Context) ([]RecurrentIncome, error)
+	// Reimbursed amounts per primary category for expenses in the month.
+	GetReimbursedCategorySums(ctx context.Context, arg GetReimbursedCategorySumsParams) ([]GetReimbursedCategorySumsRow, error)
+	// Bytes held by a member's uploads in progress, as counted by
+	// ReserveAttachmentBytes.
+	GetReservedAttachmentBytes(ctx context.Context, userID int64) (int64, error)
+	GetSavedView(ctx context.Context, id int64) (SavedView, error)
 	GetSecondariesByPrimary(ctx context.Context, name string) ([]string, error)
 	// Secondary Categories queries
 	GetSecondaryCategories(ctx context.Context) ([]string, error)
+	GetSecondaryCategoryByName(ctx context.Context, arg GetSecondaryCategoryByNameParams) (SecondaryCategory, error)
+	GetSheetHistoryImport(ctx context.Context, year int64) (SheetHistoryImport, error)
+	GetShoppingList(ctx context.Context, id int64) (ShoppingList, error)
+	GetShoppingListByExpense(ctx context.Context, expenseID sql.NullInt64) (ShoppingList, error)
 	// Gets a single sync queue item by ID.
 	GetSyncQueueItem(ctx context.Context, id int64) (SyncQueue, error)
+	// Returns the age in seconds of the oldest item not synced yet, 0 when none wait.
+	GetSyncQueueLag(ctx context.Context) (int64, error)
 	// Returns counts by status for monitoring.
 	GetSyncQueueStats(ctx context.Context) (GetSyncQueueStatsRow, error)
+	GetTrashedExpense(ctx context.Context, id int64) (Expense, error)
+	GetUserQuota(ctx context.Context, userID int64) (UserQuota, error)
+	GetUtilityUsage(ctx context.Context, expenseID int64) (UtilityUsage, error)
+	// Totals per category and month of one kind of entry in a year.
+	GetYearAggregates(ctx context.Context, arg GetYearAggregatesParams) ([]GetYearAggregatesRow, error)
 	HardDeleteExpense(ctx context.Context, id int64) error
 	HardDeleteIncome(ctx context.Context, id int64) error
-	// Increments attempt count and schedules next retry with exponential backoff.
+	// Increments attempt count and schedules next retry with exponential backoff,
+	// at most an hour.
 	IncrementSyncAttempt(ctx context.Context, arg IncrementSyncAttemptParams) error
+	// Runs left running by a process that stopped
+	InterruptRunningJobs(ctx context.Context, finishedAt sql.NullTime) (int64, error)
+	// What moved through each account: live incomes, live settled expenses
+	// (card holds wait until they clear) and transfers.
+	ListAccountBalances(ctx context.Context) ([]ListAccountBalancesRow, error)
+	ListAccountTransfers(ctx context.Context, limit int64) ([]AccountTransfer, error)
+	ListAccounts(ctx context.Context) ([]Account, error)
+	// Secondary categories not archived, with their primary category.
+	ListActiveCategoryPairs(ctx context.Context) ([]ListActiveCategoryPairsRow, error)
+	// Returns the active rules in evaluation order.
+	ListActiveCategoryRules(ctx context.Context) ([]CategoryRule, error)
+	ListAlertPreferences(ctx context.Context, userID string) ([]AlertPreference, error)
+	ListAllExpenses(ctx context.Context) ([]Expense, error)
+	ListAllIncomes(ctx context.Context) ([]Income, error)
+	ListApiKeys(ctx context.Context) ([]ApiKey, error)
+	ListAttachmentBytesByUser(ctx context.Context) ([]ListAttachmentBytesByUserRow, error)
+	// Returns the changes made in [changed_from, changed_until), newest first,
+	// optionally of one entity type (empty for all) and one row (0 for all).
+	ListAuditEntries(ctx context.Context, arg ListAuditEntriesParams) ([]AuditLog, error)
+	ListBlobDeletions(ctx context.Context, limit int64) ([]string, error)
+	// Lists every category, archived ones included, with the expenses filed under it.
+	ListCategoryAdmin(ctx context.Context) ([]ListCategoryAdminRow, error)
+	ListCategoryKeywords(ctx context.Context) ([]CategoryKeyword, error)
+	// Lists the primary category of every secondary category of Google Sheets.
+	ListCategoryMappings(ctx context.Context) ([]CategoryMapping, error)
+	// Returns all rules in evaluation order.
+	ListCategoryRules(ctx context.Context) ([]CategoryRule, error)
+	ListCategoryTranslations(ctx context.Context, locale string) ([]CategoryTranslation, error)
+	ListDailyUsage(ctx context.Context, day string) ([]UserDailyUsage, error)
+	// Secondary categories whose primary category no longer exists.
+	ListDetachedSecondaryCategories(ctx context.Context) ([]ListDetachedSecondaryCategoriesRow, error)
+	// What identifies the expenses between two dates, to find duplicates of
+	// imported rows.
+	ListExpenseFingerprints(ctx context.Context, arg ListExpenseFingerprintsParams) ([]ListExpenseFingerprintsRow, error)
+	ListExpenseLineItems(ctx context.Context, expenseID int64) ([]ExpenseLineItem, error)
+	// Every link with its expense, grouped by income.
+	ListExpenseReimbursements(ctx context.Context) ([]ListExpenseReimbursementsRow, error)
+	ListExpenseSplits(ctx context.Context, expenseID int64) ([]ExpenseSplit, error)
+	// Returns the failed sync attempts of an expense, newest first.
+	ListExpenseSyncErrors(ctx context.Context, expenseID int64) ([]SyncError, error)
+	// Sync flag of the cleared expenses in [from_date, to_date), and whether
+	// a queue item is still open for them.
+	ListExpenseSyncFlags(ctx context.Context, arg ListExpenseSyncFlagsParams) ([]ListExpenseSyncFlagsRow, error)
+	// Tags of an expense in the order they were given.
+	ListExpenseTags(ctx context.Context, expenseID int64) ([]string, error)
+	// Returns the history of an expense, newest first.
+	ListExpenseVersions(ctx context.Context, expenseID int64) ([]ExpenseVersion, error)
+	// The live expenses dated from_date through to_date, oldest first.
+	ListExpensesBetweenDates(ctx context.Context, arg ListExpensesBetweenDatesParams) ([]Expense, error)
 	ListExpensesByDateRange(ctx context.Context, arg ListExpensesByDateRangeParams) ([]Expense, error)
+	ListExpensesByPrimaryCategory(ctx context.Context, primaryCategory string) ([]Expense, error)
+	// Expenses in a workflow state, newest first. Expenses without a workflow row are drafts.
+	ListExpensesByWorkflowState(ctx context.Context, arg ListExpensesByWorkflowStateParams) ([]ListExpensesByWorkflowStateRow, error)
+	ListExpensesInCategory(ctx context.Context, arg ListExpensesInCategoryParams) ([]Expense, error)
+	// Expenses in the trash since before the cutoff.
+	ListExpensesToPurge(ctx context.Context, deletedAt sql.NullTime) ([]int64, error)
+	// Expenses whose category pair is not among the known categories; none
+	// while no categories were loaded yet.
+	ListExpensesWithUnknownCategory(ctx context.Context, limit int64) ([]ListExpensesWithUnknownCategoryRow, error)
+	// The latest expenses a saved view selects; zero amounts and empty texts
+	// do not filter.
+	ListFilteredExpenses(ctx context.Context, arg ListFilteredExpensesParams) ([]Expense, error)
+	// Expenses created by an import that still exist.
+	ListImportBatchExpenses(ctx context.Context, batchID int64) ([]Expense, error)
+	// Latest imports, newest first, with how many of their expenses still exist.
+	ListImportBatches(ctx context.Context, limit int64) ([]ListImportBatchesRow, error)
+	ListIncomesByDateRange(ctx context.Context, arg ListIncomesByDateRangeParams) ([]Income, error)
+	// Incomes in the trash since before the cutoff.
+	ListIncomesToPurge(ctx context.Context, deletedAt sql.NullTime) ([]int64, error)
+	ListInsightMutes(ctx context.Context) ([]InsightMute, error)
+	// Insights not dismissed nor muted, newest first.
+	ListInsights(ctx context.Context, limit int64) ([]Insight, error)
+	ListItemPrices(ctx context.Context) ([]ListItemPricesRow, error)
+	ListItems(ctx context.Context) ([]Item, error)
+	// A page of the expenses between two dates, newest first, starting right
+	// after the expense (after_date, after_id) in that order.
+	ListLedgerExpenses(ctx context.Context, arg ListLedgerExpensesParams) ([]Expense, error)
+	// Line items of the month's expenses, grouped by expense.
+	ListMonthLineItems(ctx context.Context, arg ListMonthLineItemsParams) ([]ListMonthLineItemsRow, error)
+	ListMonthReviews(ctx context.Context) ([]MonthReview, error)
+	// Parts of the month's split expenses, grouped by expense.
+	ListMonthSplits(ctx context.Context, arg ListMonthSplitsParams) ([]ListMonthSplitsRow, error)
+	// Amounts that must be positive but are not, left by databases older
+	// than the CHECK constraints or written with them disabled.
+	ListNonPositiveAmounts(ctx context.Context) ([]ListNonPositiveAmountsRow, error)
+	// Returns the queue items not written yet, failed first, with the expense
+	// they refer to; deleted expenses use the data stored in the item.
+	ListOpenSyncItems(ctx context.Context, limit int64) ([]ListOpenSyncItemsRow, error)
+	// Category pairs used by expenses or recurrent expenses that are not among
+	// the categories, e.g. left by a removed or renamed primary category, with
+	// the first primary category holding a secondary of the same name.
+	ListOrphanCategoryPairs(ctx context.Context) ([]ListOrphanCategoryPairsRow, error)
+	// Receipts of expenses that no longer exist.
+	ListOrphanReceipts(ctx context.Context) ([]ListOrphanReceiptsRow, error)
+	// Latest changes after the cursor, oldest first.
+	ListPeerChanges(ctx context.Context, arg ListPeerChangesParams) ([]PeerChangelog, error)
+	ListPurchaseWarranties(ctx context.Context) ([]ListPurchaseWarrantiesRow, error)
+	// Receipts of the expenses dated in the range, end excluded.
+	ListReceiptsBetween(ctx context.Context, arg ListReceiptsBetweenParams) ([]Receipt, error)
+	// Recurrent expenses and incomes whose end date comes before the start.
+	ListRecurrentsEndingBeforeStart(ctx context.Context) ([]ListRecurrentsEndingBeforeStartRow, error)
+	// Return deadlines within the range whose reminder was not sent yet.
+	ListReturnDeadlinesToNotify(ctx context.Context, arg ListReturnDeadlinesToNotifyParams) ([]ListReturnDeadlinesToNotifyRow, error)
+	ListSavedViews(ctx context.Context) ([]SavedView, error)
+	ListSheetHistoryImports(ctx context.Context) ([]SheetHistoryImport, error)
+	ListShoppingListItems(ctx context.Context, listID int64) ([]ShoppingListItem, error)
+	// Open lists first, then the most recent conversions.
+	ListShoppingLists(ctx context.Context, limit int64) ([]ListShoppingListsRow, error)
+	// The IDs of the split expenses.
+	ListSplitExpenseIDs(ctx context.Context) ([]int64, error)
+	// Returns the latest failed sync attempts, newest first.
+	ListSyncErrors(ctx context.Context, limit int64) ([]SyncError, error)
+	// Tags in use by expenses or incomes, most used first, for autocompletion.
+	ListTags(ctx context.Context) ([]ListTagsRow, error)
+	// Most recent categorized expenses, the classifier's training set.
+	ListTrainingExpenses(ctx context.Context, arg ListTrainingExpensesParams) ([]ListTrainingExpensesRow, error)
+	ListTrashedExpenses(ctx context.Context) ([]Expense, error)
+	ListTrashedIncomes(ctx context.Context) ([]Income, error)
+	// Expenses in the placeholder category whose proposal was not reviewed yet.
+	ListUncategorizedExpenses(ctx context.Context, arg ListUncategorizedExpensesParams) ([]Expense, error)
+	// Append items not completed yet, for the startup reconciliation with the sheet
+	ListUnfinishedSyncAppends(ctx context.Context) ([]SyncQueue, error)
+	// Categories no expense or recurrent expense uses; a primary category
+	// comes with an empty secondary_name.
+	ListUnusedCategories(ctx context.Context) ([]ListUnusedCategoriesRow, error)
+	ListUserQuotas(ctx context.Context) ([]UserQuota, error)
+	ListUsers(ctx context.Context) ([]User, error)
+	ListUtilityUsage(ctx context.Context) ([]ListUtilityUsageRow, error)
+	// Expenses of the vehicle cost center, with their fuel fill if any.
+	ListVehicleExpenses(ctx context.Context, arg ListVehicleExpensesParams) ([]ListVehicleExpensesRow, error)
 	MarkExpenseSyncError(ctx context.Context, id int64) error
 	MarkExpenseSynced(ctx context.Context, id int64) error
+	MarkImportBatchRolledBack(ctx context.Context, id int64) (int64, error)
+	MarkReturnReminderSent(ctx context.Context, expenseID int64) error
+	MarkShoppingListConverted(ctx context.Context, arg MarkShoppingListConvertedParams) (int64, error)
 	// Marks a sync queue item as successfully completed.
 	MarkSyncComplete(ctx context.Context, id int64) error
-	// Marks a sync queue item as failed after max retries exceeded.
+	// Marks a sync queue item as failed after max retries exceeded, counting the
+	// last attempt.
 	MarkSyncFailed(ctx context.Context, arg MarkSyncFailedParams) error
 	// Marks an item as being processed.
 	MarkSyncProcessing(ctx context.Context, id int64) error
+	MoveCategoryKeywordsCategory(ctx context.Context, arg MoveCategoryKeywordsCategoryParams) error
+	MoveCategoryRulesCategory(ctx context.Context, arg MoveCategoryRulesCategoryParams) error
+	MoveRecurrentExpensesCategory(ctx context.Context, arg MoveRecurrentExpensesCategoryParams) error
+	MoveSavedViewsCategory(ctx context.Context, arg MoveSavedViewsCategoryParams) error
+	MuteInsight(ctx context.Context, arg MuteInsightParams) error
+	// Removes the changes made before the specified timestamp.
+	PruneAuditLog(ctx context.Context, changedAt time.Time) (int64, error)
+	// Deletes the runs of a job but the latest keep
+	PruneJobRuns(ctx context.Context, arg PruneJobRunsParams) error
+	QueueBlobDeletion(ctx context.Context, blobKey string) error
+	// Moves an expense to new categories unless it changed since version was
+	// read. An expense already in Google Sheets goes back to pending, to be
+	// written there again.
+	RecategorizeExpense(ctx context.Context, arg RecategorizeExpenseParams) (int64, error)
+	// Records a failed sync attempt with its error class.
+	RecordSyncError(ctx context.Context, arg RecordSyncErrorParams) error
 	RefreshCategories(ctx context.Context) error
 	RefreshPrimaryCategories(ctx context.Context) error
+	// Drops a reservation once its upload is stored or failed.
+	ReleaseAttachmentBytes(ctx context.Context, id int64) error
+	// Gives back expenses counted for a creation that then failed.
+	ReleaseDailyExpenses(ctx context.Context, arg ReleaseDailyExpensesParams) error
+	RenameCategoryKeywordsPrimary(ctx context.Context, arg RenameCategoryKeywordsPrimaryParams) error
+	// Points the mappings to a renamed primary category.
+	RenameCategoryMappingsPrimary(ctx context.Context, arg RenameCategoryMappingsPrimaryParams) error
+	RenameCategoryRulesPrimary(ctx context.Context, arg RenameCategoryRulesPrimaryParams) error
+	RenameCategoryTranslations(ctx context.Context, arg RenameCategoryTranslationsParams) error
+	RenamePrimaryCategory(ctx context.Context, arg RenamePrimaryCategoryParams) (int64, error)
+	RenameRecurrentExpensesPrimary(ctx context.Context, arg RenameRecurrentExpensesPrimaryParams) error
+	RenameSavedViewsPrimary(ctx context.Context, arg RenameSavedViewsPrimaryParams) error
+	ReopenMonth(ctx context.Context, period string) (int64, error)
+	// Holds size_bytes of a member's attachment quota for an upload unless,
+	// with their receipts (but the one of except_expense, which the upload
+	// replaces) and the reservations of the last hour, that goes over
+	// max_bytes; no row comes back when it would.
+	ReserveAttachmentBytes(ctx context.Context, arg ReserveAttachmentBytesParams) (int64, error)
 	// Resets items stuck in processing state (crash recovery).
 	ResetStaleProcessing(ctx context.Context) error
+	RestoreExpense(ctx context.Context, id int64) (int64, error)
+	RestoreIncome(ctx context.Context, id int64) (int64, error)
+	// Moves an expense to a new version with the same values, so a change
+	// stored beside it, such as its split, is written to Google Sheets again.
+	ResyncExpense(ctx context.Context, id int64) (int64, error)
+	// Makes pending items waiting for a retry ready for the next poll, e.g.
+	// once the Google Sheets credentials have been fixed.
+	RetryDeferredSyncs(ctx context.Context) (int64, error)
 	// Resets failed items back to pending for manual retry.
-	RetryFailedSyncs(ctx context.Context) error
-	UpdateRecurrentExpense(ctx context.Context, arg UpdateRecurrentExpenseParams) error
+	RetryFailedSyncs(ctx context.Context) (int64, error)
+	// Makes a failed or deferred item ready for the next poll, with its
+	// attempts reset.
+	RetrySyncItem(ctx context.Context, id int64) (int64, error)
+	SavePeerSyncState(ctx context.Context, arg SavePeerSyncStateParams) error
+	// The newest expenses between two dates matching a search; empty texts
+	// and zero amounts do not filter, a category matches either level.
+	SearchExpenses(ctx context.Context, arg SearchExpensesParams) ([]Expense, error)
+	SetAlertPreference(ctx context.Context, arg SetAlertPreferenceParams) error
+	// Enables or disables a rule.
+	SetCategoryRuleActive(ctx context.Context, arg SetCategoryRuleActiveParams) (int64, error)
+	SetImportBatchExpenses(ctx context.Context, arg SetImportBatchExpensesParams) error
+	SetPrimaryCategoryArchived(ctx context.Context, arg SetPrimaryCategoryArchivedParams) (int64, error)
+	// Resuming sets skip_until too, past the occurrences of the pause
+	SetRecurrentPaused(ctx context.Context, arg SetRecurrentPausedParams) (int64, error)
+	SetRecurrentSkipUntil(ctx context.Context, arg SetRecurrentSkipUntilParams) (int64, error)
+	SetSavedViewNotify(ctx context.Context, arg SetSavedViewNotifyParams) (int64, error)
+	SetSecondaryCategoryArchived(ctx context.Context, arg SetSecondaryCategoryArchivedParams) (int64, error)
+	// Clears a pending expense with the settled date and amount.
+	SettleExpense(ctx context.Context, arg SettleExpenseParams) (int64, error)
+	// Records the start of the import of a year, or its restart after a failure
+	StartSheetHistoryImport(ctx context.Context, arg StartSheetHistoryImportParams) error
+	// Totals per category of one kind of entry in the months between two
+	// periods (year * 100 + month), both included, largest first.
+	SumAggregatesByCategory(ctx context.Context, arg SumAggregatesByCategoryParams) ([]SumAggregatesByCategoryRow, error)
+	// Spending per tag and month between from_date (included) and to_date
+	// (excluded); an empty tag selects every tag.
+	SumExpenseTagsByMonth(ctx context.Context, arg SumExpenseTagsByMonthParams) ([]SumExpenseTagsByMonthRow, error)
+	// Income per tag and month, like SumExpenseTagsByMonth.
+	SumIncomeTagsByMonth(ctx context.Context, arg SumIncomeTagsByMonthParams) ([]SumIncomeTagsByMonthRow, error)
+	// Records the use of a key, at most once a minute.
+	TouchApiKey(ctx context.Context, id int64) error
+	// Moves an expense to the trash; it leaves every total and list until it
+	// is restored.
+	TrashExpense(ctx context.Context, id int64) (int64, error)
+	TrashIncome(ctx context.Context, id int64) (int64, error)
+	UnmuteInsight(ctx context.Context, arg UnmuteInsightParams) (int64, error)
+	// An expense already in Google Sheets goes back to pending, to be written
+	// there again.
+	UpdateExpense(ctx context.Context, arg UpdateExpenseParams) (int64, error)
+	UpdateExpenseAmount(ctx context.Context, arg UpdateExpenseAmountParams) error
+	UpdateExpenseCategory(ctx context.Context, arg UpdateExpenseCategoryParams) (int64, error)
+	UpdateExpenseFromPeer(ctx context.Context, arg UpdateExpenseFromPeerParams) error
+	// Stores the values of a row edited in Google Sheets. The sync status is
+	// kept: the sheet already holds them.
+	UpdateExpenseFromSheet(ctx context.Context, arg UpdateExpenseFromSheetParams) (int64, error)
+	UpdateIncomeFromPeer(ctx context.Context, arg UpdateIncomeFromPeerParams) error
+	UpdateRecurrentExpense(ctx context.Context, arg UpdateRecurrentExpenseParams) (int64, error)
+	UpdateRecurrentIncome(ctx context.Context, arg UpdateRecurrentIncomeParams) (int64, error)
+	UpdateRecurrentIncomeLastExecution(ctx context.Context, arg UpdateRecurrentIncomeLastExecutionParams) error
 	UpdateRecurrentLastExecution(ctx context.Context, arg UpdateRecurrentLastExecutionParams) error
+	UpdateSecondaryCategory(ctx context.Context, arg UpdateSecondaryCategoryParams) (int64, error)
+	// Items of converted lists are read-only.
+	UpdateShoppingListItem(ctx context.Context, arg UpdateShoppingListItemParams) (int64, error)
+	UpsertCategoryKeyword(ctx context.Context, arg UpsertCategoryKeywordParams) error
+	// Maps a Google Sheets secondary category to a primary category.
+	UpsertCategoryMapping(ctx context.Context, arg UpsertCategoryMappingParams) error
+	UpsertCategoryTranslation(ctx context.Context, arg UpsertCategoryTranslationParams) error
+	UpsertClassifierFeedback(ctx context.Context, arg UpsertClassifierFeedbackParams) error
+	// Reimbursements
+	// Links part of an expense to an income, adding to an existing link.
+	UpsertExpenseReimbursement(ctx context.Context, arg UpsertExpenseReimbursementParams) error
+	UpsertExpenseWorkflowState(ctx context.Context, arg UpsertExpenseWorkflowStateParams) error
+	UpsertFuelFill(ctx context.Context, arg UpsertFuelFillParams) error
+	// Returns the item with this normalized name, creating it when new.
+	UpsertItem(ctx context.Context, arg UpsertItemParams) (int64, error)
+	// Keeps the highest deleted version seen for the record.
+	UpsertPeerTombstone(ctx context.Context, arg UpsertPeerTombstoneParams) error
+	// A changed return deadline gets a new reminder.
+	UpsertPurchaseWarranty(ctx context.Context, arg UpsertPurchaseWarrantyParams) error
+	UpsertReceipt(ctx context.Context, arg UpsertReceiptParams) error
+	// Returns the ID of the tag, creating it on first use.
+	UpsertTag(ctx context.Context, name string) (int64, error)
+	UpsertUserQuota(ctx context.Context, arg UpsertUserQuotaParams) error
+	UpsertUtilityUsage(ctx context.Context, arg UpsertUtilityUsageParams) error
 }
 
 var _ Querier = (*Queries)(nil)
@@ -1,31 +1,29 @@
 -- name: CreateExpense :one
-INSERT INTO expenses (date, description, amount_cents, primary_category, secondary_category)
-VALUES (date(?), ?, ?, ?, ?)
+INSERT INTO expenses (date, description, amount_cents, primary_category, secondary_category, status, paid_by, account_id)
+VALUES (date(?), ?, ?, ?, ?, ?, ?, ?)
 RETURNING *;
 
 -- name: GetExpensesByMonth :many
 SELECT * FROM expenses
 WHERE strftime('%Y', date) = printf('%04d', ?)
   AND strftime('%m', date) = printf('%02d', ?)
+  AND deleted_at IS NULL
 ORDER BY date DESC, created_at DESC;
 
 -- name: GetMonthTotal :one
-SELECT CAST(COALESCE(SUM(amount_cents), 0) AS INTEGER) as total
-FROM expenses
-WHERE strftime('%Y', date) = printf('%04d', ?)
-  AND strftime('%m', date) = printf('%02d', ?);
+SELECT CAST(COALESCE(SUM(total_cents), 0) AS INTEGER) as total
+FROM monthly_aggregates
+WHERE kind = 'expense' AND year = ? AND month = ?;
 
 -- name: GetCategorySums :many
-SELECT primary_category, CAST(SUM(amount_cents) AS INTEGER) as total_amount
-FROM expenses
-WHERE strftime('%Y', date) = printf('%04d', ?)
-  AND strftime('%m', date) = printf('%02d', ?)
-GROUP BY primary_category
+SELECT category AS primary_category, total_cents AS total_amount
+FROM monthly_aggregates
+WHERE kind = 'expense' AND year = ? AND month = ?
 ORDER BY total_amount DESC;
 
 -- name: GetPendingSyncExpenses :many
 SELECT id, version, created_at FROM expenses 
-WHERE sync_status = 'pending'
+WHERE sync_status = 'pending' AND status = 'cleared' AND deleted_at IS NULL
 ORDER BY created_at ASC
 LIMIT ?;
 
@@ -40,21 +38,110 @@ SET sync_status = 'error'
 WHERE id = ?;
 
 -- name: GetExpense :one
-SELECT * FROM expenses WHERE id = ?;
+SELECT * FROM expenses WHERE id = ? AND deleted_at IS NULL;
 
 -- name: HardDeleteExpense :exec
 DELETE FROM expenses 
 WHERE id = ?;
 
+-- name: TrashExpense :execrows
+-- Moves an expense to the trash; it leaves every total and list until it
+-- is restored.
+UPDATE expenses SET deleted_at = CURRENT_TIMESTAMP
+WHERE id = ? AND deleted_at IS NULL;
+
+-- name: RestoreExpense :execrows
+UPDATE expenses SET deleted_at = NULL
+WHERE id = ? AND deleted_at IS NOT NULL;
+
+-- name: GetTrashedExpense :one
+SELECT * FROM expenses WHERE id = ? AND deleted_at IS NOT NULL;
+
+-- name: ListTrashedExpenses :many
+SELECT * FROM expenses
+WHERE deleted_at IS NOT NULL
+ORDER BY deleted_at DESC, id DESC;
+
+-- name: ListExpensesToPurge :many
+-- Expenses in the trash since before the cutoff.
+SELECT id FROM expenses
+WHERE deleted_at IS NOT NULL AND deleted_at < ?
+ORDER BY id;
+
+-- name: UpdateExpenseAmount :exec
+UPDATE expenses
+SET amount_cents = ?, version = version + 1
+WHERE id = ? AND deleted_at IS NULL;
+
+-- name: UpdateExpense :execrows
+-- An expense already in Google Sheets goes back to pending, to be written
+-- there again.
+UPDATE expenses
+SET date = date(?),
+    description = ?,
+    amount_cents = ?,
+    primary_category = ?,
+    secondary_category = ?,
+    paid_by = ?,
+    account_id = ?,
+    version = version + 1,
+    sync_status = CASE WHEN sync_status = 'synced' THEN 'pending' ELSE sync_status END
+WHERE id = ? AND version = ? AND deleted_at IS NULL;
+
+-- name: ResyncExpense :execrows
+-- Moves an expense to a new version with the same values, so a change
+-- stored beside it, such as its split, is written to Google Sheets again.
+UPDATE expenses
+SET version = version + 1,
+    sync_status = CASE WHEN sync_status = 'synced' THEN 'pending' ELSE sync_status END
+WHERE id = ? AND deleted_at IS NULL;
+
+-- name: RecategorizeExpense :execrows
+-- Moves an expense to new categories unless it changed since version was
+-- read. An expense already in Google Sheets goes back to pending, to be
+-- written there again.
+UPDATE expenses
+SET primary_category = ?,
+    secondary_category = ?,
+    version = version + 1,
+    sync_status = CASE WHEN sync_status = 'synced' THEN 'pending' ELSE sync_status END
+WHERE id = ? AND version = ? AND deleted_at IS NULL;
+
+-- name: GetPendingCategorySums :many
+-- Amounts of card holds not yet settled per primary category.
+SELECT primary_category, CAST(SUM(amount_cents) AS INTEGER) as total_amount
+FROM expenses
+WHERE status = 'pending' AND deleted_at IS NULL
+  AND strftime('%Y', date) = printf('%04d', ?)
+  AND strftime('%m', date) = printf('%02d', ?)
+GROUP BY primary_category;
+
+-- name: FindPendingExpenseMatch :one
+-- The card hold a settled transaction replaces: same description, dated up
+-- to 10 days before it, closest amount first.
+SELECT * FROM expenses
+WHERE status = 'pending' AND deleted_at IS NULL
+  AND lower(trim(description)) = lower(trim(sqlc.arg(description)))
+  AND date BETWEEN date(sqlc.arg(settled_date), '-10 days') AND date(sqlc.arg(settled_date))
+ORDER BY abs(amount_cents - sqlc.arg(amount_cents)), date, id
+LIMIT 1;
+
+-- name: SettleExpense :execrows
+-- Clears a pending expense with the settled date and amount.
+UPDATE expenses
+SET date = date(?), amount_cents = ?, status = 'cleared', version = version + 1
+WHERE id = ? AND status = 'pending' AND deleted_at IS NULL;
+
 -- Primary Categories queries
 -- name: GetPrimaryCategories :many
 SELECT name FROM primary_categories 
+WHERE archived = 0
 ORDER BY name ASC;
 
 -- name: CreatePrimaryCategory :one
 INSERT INTO primary_categories (name)
 VALUES (?)
-RETURNING id, name, created_at;
+RETURNING id, name, created_at, archived;
 
 -- name: DeletePrimaryCategory :exec
 DELETE FROM primary_categories WHERE name = ?;
@@ -62,18 +149,20 @@ DELETE FROM primary_categories WHERE name = ?;
 -- Secondary Categories queries
 -- name: GetSecondaryCategories :many
 SELECT name FROM secondary_categories 
+WHERE archived = 0
 ORDER BY name ASC;
 
 -- name: GetSecondariesByPrimary :many
 SELECT sc.name FROM secondary_categories sc
 JOIN primary_categories pc ON sc.primary_category_id = pc.id
-WHERE pc.name = ?
+WHERE pc.name = ? AND sc.archived = 0
 ORDER BY sc.name ASC;
 
 -- name: GetAllCategoriesWithSubs :many
 SELECT pc.name as primary_name, sc.name as secondary_name
 FROM primary_categories pc
-LEFT JOIN secondary_categories sc ON sc.primary_category_id = pc.id
+LEFT JOIN secondary_categories sc ON sc.primary_category_id = pc.id AND sc.archived = 0
+WHERE pc.archived = 0
 ORDER BY pc.name ASC, sc.name ASC;
 
 -- name: GetCategoriesOrderedByUsage :many
@@ -82,14 +171,16 @@ SELECT
   sc.name as secondary_name,
   COALESCE(exp_count.cnt, 0) as usage_count
 FROM primary_categories pc
-LEFT JOIN secondary_categories sc ON sc.primary_category_id = pc.id
+LEFT JOIN secondary_categories sc ON sc.primary_category_id = pc.id AND sc.archived = 0
 LEFT JOIN (
   SELECT primary_category, secondary_category, COUNT(*) as cnt
   FROM expenses
+  WHERE deleted_at IS NULL
   GROUP BY primary_category, secondary_category
 ) exp_count ON exp_count.primary_category = pc.name AND exp_count.secondary_category = sc.name
+WHERE pc.archived = 0
 ORDER BY
-  COALESCE((SELECT SUM(cnt) FROM (SELECT COUNT(*) as cnt FROM expenses WHERE primary_category = pc.name GROUP BY primary_category)), 0) DESC,
+  COALESCE((SELECT SUM(cnt) FROM (SELECT COUNT(*) as cnt FROM expenses WHERE primary_category = pc.name AND deleted_at IS NULL GROUP BY primary_category)), 0) DESC,
   pc.name ASC,
   COALESCE(exp_count.cnt, 0) DESC,
   sc.name ASC;
@@ -97,7 +188,7 @@ ORDER BY
 -- name: CreateSecondaryCategory :one
 INSERT INTO secondary_categories (name, primary_category_id)
 VALUES (?, ?)
-RETURNING id, name, primary_category_id, created_at;
+RETURNING id, name, primary_category_id, created_at, archived;
 
 -- name: DeleteSecondaryCategory :exec
 DELETE FROM secondary_categories WHERE name = ?;
@@ -112,9 +203,10 @@ DELETE FROM primary_categories;
 -- name: CreateRecurrentExpense :one
 INSERT INTO recurrent_expenses (
     start_date, end_date, repetition_type, description, 
-    amount_cents, primary_category, secondary_category
+    amount_cents, primary_category, secondary_category,
+    repeat_interval, day_of_month
 )
-VALUES (?, ?, ?, ?, ?, ?, ?)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
 RETURNING *;
 
 -- name: GetRecurrentExpenses :many
@@ -126,7 +218,7 @@ ORDER BY start_date DESC;
 SELECT * FROM recurrent_expenses
 WHERE id = ?;
 
--- name: UpdateRecurrentExpense :exec
+-- name: UpdateRecurrentExpense :execrows
 UPDATE recurrent_expenses
 SET start_date = ?, 
     end_date = ?, 
@@ -135,8 +227,11 @@ SET start_date = ?,
     amount_cents = ?, 
     primary_category = ?, 
     secondary_category = ?,
+    repeat_interval = ?,
+    day_of_month = ?,
+    version = version + 1,
     updated_at = CURRENT_TIMESTAMP
-WHERE id = ?;
+WHERE id = ? AND version = ?;
 
 -- name: DeactivateRecurrentExpense :exec
 UPDATE recurrent_expenses
@@ -164,58 +259,122 @@ WHERE id = ?;
 -- name: GetActiveRecurrentExpensesForProcessing :many
 SELECT * FROM recurrent_expenses
 WHERE is_active = 1
+  AND paused = 0
   AND start_date <= ?
   AND (end_date IS NULL OR end_date >= ?)
 ORDER BY start_date ASC;
 
+-- name: SetRecurrentPaused :execrows
+-- Resuming sets skip_until too, past the occurrences of the pause
+UPDATE recurrent_expenses
+SET paused = ?,
+    skip_until = ?,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = ? AND is_active = 1;
+
+-- name: SetRecurrentSkipUntil :execrows
+UPDATE recurrent_expenses
+SET skip_until = ?,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = ? AND is_active = 1;
+
 -- Income queries
 -- name: CreateIncome :one
-INSERT INTO incomes (date, description, amount_cents, category)
-VALUES (date(?), ?, ?, ?)
+INSERT INTO incomes (date, description, amount_cents, category, subcategory, tags, account_id)
+VALUES (date(?), ?, ?, ?, ?, ?, ?)
 RETURNING *;
 
 -- name: GetIncomesByMonth :many
 SELECT * FROM incomes
 WHERE strftime('%Y', date) = printf('%04d', ?)
   AND strftime('%m', date) = printf('%02d', ?)
+  AND deleted_at IS NULL
 ORDER BY date DESC, created_at DESC;
 
 -- name: GetIncomeMonthTotal :one
-SELECT CAST(COALESCE(SUM(amount_cents), 0) AS INTEGER) as total
-FROM incomes
-WHERE strftime('%Y', date) = printf('%04d', ?)
-  AND strftime('%m', date) = printf('%02d', ?);
+SELECT CAST(COALESCE(SUM(total_cents), 0) AS INTEGER) as total
+FROM monthly_aggregates
+WHERE kind = 'income' AND year = ? AND month = ?;
 
 -- name: GetIncomeCategorySums :many
-SELECT category, CAST(SUM(amount_cents) AS INTEGER) as total_amount
+SELECT category, total_cents AS total_amount
+FROM monthly_aggregates
+WHERE kind = 'income' AND year = ? AND month = ?
+ORDER BY total_amount DESC;
+
+-- name: GetIncomeSubcategorySums :many
+SELECT category, subcategory, CAST(SUM(amount_cents) AS INTEGER) as total_amount
 FROM incomes
 WHERE strftime('%Y', date) = printf('%04d', ?)
   AND strftime('%m', date) = printf('%02d', ?)
-GROUP BY category
+  AND deleted_at IS NULL
+  AND subcategory != ''
+GROUP BY category, subcategory
 ORDER BY total_amount DESC;
 
+-- name: GetIncomeSubcategories :many
+SELECT DISTINCT subcategory FROM incomes
+WHERE category = ? AND subcategory != '' AND deleted_at IS NULL
+ORDER BY subcategory ASC;
+
 -- name: GetIncome :one
-SELECT * FROM incomes WHERE id = ?;
+SELECT * FROM incomes WHERE id = ? AND deleted_at IS NULL;
 
 -- name: HardDeleteIncome :exec
 DELETE FROM incomes
 WHERE id = ?;
 
+-- name: TrashIncome :execrows
+UPDATE incomes SET deleted_at = CURRENT_TIMESTAMP
+WHERE id = ? AND deleted_at IS NULL;
+
+-- name: RestoreIncome :execrows
+UPDATE incomes SET deleted_at = NULL
+WHERE id = ? AND deleted_at IS NOT NULL;
+
+-- name: ListTrashedIncomes :many
+SELECT * FROM incomes
+WHERE deleted_at IS NOT NULL
+ORDER BY deleted_at DESC, id DESC;
+
+-- name: ListIncomesToPurge :many
+-- Incomes in the trash since before the cutoff.
+SELECT id FROM incomes
+WHERE deleted_at IS NOT NULL AND deleted_at < ?
+ORDER BY id;
+
 -- name: GetIncomeCategories :many
 SELECT name FROM income_categories
 ORDER BY name ASC;
 
 -- name: ListExpensesByDateRange :many
 SELECT * FROM expenses
-WHERE date >= ? AND date <= ?
+WHERE date >= ? AND date <= ? AND deleted_at IS NULL
 ORDER BY date DESC, created_at DESC;
 
+-- name: ListAllExpenses :many
+SELECT * FROM expenses
+WHERE deleted_at IS NULL
+ORDER BY date ASC, id ASC;
+
+-- name: ListExpensesBetweenDates :many
+-- The live expenses dated from_date through to_date, oldest first.
+SELECT * FROM expenses
+WHERE date BETWEEN date(sqlc.arg(from_date)) AND date(sqlc.arg(to_date))
+  AND deleted_at IS NULL
+ORDER BY date ASC, id ASC;
+
+-- name: ListAllIncomes :many
+SELECT * FROM incomes
+WHERE deleted_at IS NULL
+ORDER BY date ASC, id ASC;
+
 -- Sync Queue queries
 
 -- name: EnqueueSync :one
 -- Enqueues a sync operation for an expense.
-INSERT INTO sync_queue (operation, expense_id, status, created_at, updated_at)
-VALUES ('sync', ?, 'pending', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+INSERT INTO sync_queue (operation, expense_id, expense_version, trace_parent, status, created_at, updated_at)
+VALUES ('sync', ?, ?, ?, 'pending', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
 RETURNING *;
 
 -- name: EnqueueDelete :one
@@ -224,9 +383,9 @@ INSERT INTO sync_queue (
     operation, expense_id, status,
     expense_day, expense_month, expense_description,
     expense_amount_cents, expense_primary, expense_secondary,
-    created_at, updated_at
+    trace_parent, created_at, updated_at
 )
-VALUES ('delete', ?, 'pending', ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+VALUES ('delete', ?, 'pending', ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
 RETURNING *;
 
 -- name: DequeueSyncBatch :many
@@ -234,7 +393,7 @@ RETURNING *;
 SELECT * FROM sync_queue
 WHERE status = 'pending'
   AND (next_retry_at IS NULL OR next_retry_at <= CURRENT_TIMESTAMP)
-ORDER BY created_at ASC
+ORDER BY created_at ASC, id ASC
 LIMIT ?;
 
 -- name: MarkSyncProcessing :exec
@@ -252,24 +411,37 @@ SET status = 'completed',
 WHERE id = ?;
 
 -- name: MarkSyncFailed :exec
--- Marks a sync queue item as failed after max retries exceeded.
+-- Marks a sync queue item as failed after max retries exceeded, counting the
+-- last attempt.
 UPDATE sync_queue
 SET status = 'failed',
+    attempts = attempts + 1,
     last_error = ?,
     updated_at = CURRENT_TIMESTAMP
 WHERE id = ?;
 
 -- name: IncrementSyncAttempt :exec
--- Increments attempt count and schedules next retry with exponential backoff.
+-- Increments attempt count and schedules next retry with exponential backoff,
+-- at most an hour.
 UPDATE sync_queue
 SET attempts = attempts + 1,
     last_error = ?,
     status = 'pending',
-    next_retry_at = datetime(CURRENT_TIMESTAMP, '+' || (1 << attempts) || ' minutes'),
+    next_retry_at = datetime(CURRENT_TIMESTAMP, '+' || min(1 << attempts, 60) || ' minutes'),
     updated_at = CURRENT_TIMESTAMP
 WHERE id = ?;
 
--- name: RetryFailedSyncs :exec
+-- name: DeferSyncItem :exec
+-- Puts an item back to pending for a later poll without counting an attempt,
+-- e.g. when Google Sheets throttled the write.
+UPDATE sync_queue
+SET last_error = sqlc.arg(last_error),
+    status = 'pending',
+    next_retry_at = datetime(CURRENT_TIMESTAMP, '+' || sqlc.arg(delay_seconds) || ' seconds'),
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id);
+
+-- name: RetryFailedSyncs :execrows
 -- Resets failed items back to pending for manual retry.
 UPDATE sync_queue
 SET status = 'pending',
@@ -279,6 +451,15 @@ SET status = 'pending',
     updated_at = CURRENT_TIMESTAMP
 WHERE status = 'failed';
 
+-- name: RetryDeferredSyncs :execrows
+-- Makes pending items waiting for a retry ready for the next poll, e.g.
+-- once the Google Sheets credentials have been fixed.
+UPDATE sync_queue
+SET next_retry_at = NULL,
+    updated_at = CURRENT_TIMESTAMP
+WHERE status = 'pending'
+  AND next_retry_at IS NOT NULL;
+
 -- name: CleanupCompletedSyncs :exec
 -- Removes completed items older than the specified timestamp.
 DELETE FROM sync_queue
@@ -302,6 +483,1610 @@ SELECT
     CAST(SUM(CASE WHEN status = 'failed' THEN 1 ELSE 0 END) AS INTEGER) as failed_count
 FROM sync_queue;
 
+-- name: GetSyncQueueLag :one
+-- Returns the age in seconds of the oldest item not synced yet, 0 when none wait.
+SELECT CAST(COALESCE(MAX(strftime('%s', 'now') - strftime('%s', created_at)), 0) AS INTEGER) as lag_seconds
+FROM sync_queue
+WHERE status IN ('pending', 'processing');
+
 -- name: GetSyncQueueItem :one
 -- Gets a single sync queue item by ID.
-SELECT * FROM sync_queue WHERE id = ?;
\ No newline at end of file
+SELECT * FROM sync_queue WHERE id = ?;
+
+-- Expense Versions queries
+
+-- name: CreateExpenseVersion :exec
+-- Records a modification of an expense with its field diff.
+INSERT INTO expense_versions (expense_id, version, changed_by, changes)
+VALUES (?, ?, ?, ?);
+
+-- name: ListExpenseVersions :many
+-- Returns the history of an expense, newest first.
+SELECT * FROM expense_versions
+WHERE expense_id = ?
+ORDER BY version DESC, id DESC;
+
+-- Category Rules queries
+
+-- name: CreateCategoryRule :one
+-- Stores a categorization rule.
+INSERT INTO category_rules (name, expression, primary_category, secondary_category, priority)
+VALUES (?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: ListCategoryRules :many
+-- Returns all rules in evaluation order.
+SELECT * FROM category_rules
+ORDER BY priority, id;
+
+-- name: ListActiveCategoryRules :many
+-- Returns the active rules in evaluation order.
+SELECT * FROM category_rules
+WHERE is_active = 1
+ORDER BY priority, id;
+
+-- name: SetCategoryRuleActive :execrows
+-- Enables or disables a rule.
+UPDATE category_rules SET is_active = ? WHERE id = ?;
+
+-- name: DeleteCategoryRule :execrows
+-- Removes a rule.
+DELETE FROM category_rules WHERE id = ?;
+
+-- Demo mode reset
+-- name: DeleteAllExpenseVersions :exec
+DELETE FROM expense_versions;
+
+-- name: DeleteAllSyncQueue :exec
+DELETE FROM sync_queue;
+
+-- name: DeleteAllSyncErrors :exec
+DELETE FROM sync_errors;
+
+-- name: DeleteAllExpenses :exec
+DELETE FROM expenses;
+
+-- name: DeleteAllIncomes :exec
+DELETE FROM incomes;
+
+-- name: DeleteAllRecurrentExpenses :exec
+DELETE FROM recurrent_expenses;
+
+-- name: DeleteAllCategoryRules :exec
+DELETE FROM category_rules;
+
+-- name: DeleteAllPeerTombstones :exec
+DELETE FROM peer_tombstones;
+
+-- name: DeleteAllPeerChangelog :exec
+DELETE FROM peer_changelog;
+
+-- Peer sync
+-- name: ListPeerChanges :many
+-- Latest changes after the cursor, oldest first.
+SELECT * FROM peer_changelog
+WHERE seq > ?
+ORDER BY seq ASC
+LIMIT ?;
+
+-- name: GetExpenseByUID :one
+SELECT * FROM expenses WHERE uid = ?;
+
+-- name: GetIncomeByUID :one
+SELECT * FROM incomes WHERE uid = ?;
+
+-- name: CreateExpenseFromPeer :exec
+INSERT INTO expenses (uid, date, description, amount_cents, primary_category, secondary_category, status, version, modified_at)
+VALUES (?, date(?), ?, ?, ?, ?, ?, ?, ?);
+
+-- name: UpdateExpenseFromPeer :exec
+UPDATE expenses
+SET date = date(?), description = ?, amount_cents = ?, primary_category = ?, secondary_category = ?, status = ?, version = ?, modified_at = ?
+WHERE uid = ?;
+
+-- name: DeleteExpenseByUID :exec
+DELETE FROM expenses WHERE uid = ?;
+
+-- name: CreateIncomeFromPeer :exec
+INSERT INTO incomes (uid, date, description, amount_cents, category, subcategory, tags, version, modified_at)
+VALUES (?, date(?), ?, ?, ?, ?, ?, ?, ?);
+
+-- name: UpdateIncomeFromPeer :exec
+UPDATE incomes
+SET date = date(?), description = ?, amount_cents = ?, category = ?, subcategory = ?, tags = ?, version = ?, modified_at = ?
+WHERE uid = ?;
+
+-- name: DeleteIncomeByUID :exec
+DELETE FROM incomes WHERE uid = ?;
+
+-- name: GetPeerTombstone :one
+SELECT * FROM peer_tombstones WHERE kind = ? AND uid = ?;
+
+-- name: UpsertPeerTombstone :exec
+-- Keeps the highest deleted version seen for the record.
+INSERT INTO peer_tombstones (kind, uid, version, deleted_at)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (kind, uid) DO UPDATE SET version = max(version, excluded.version), deleted_at = excluded.deleted_at;
+
+-- name: DeletePeerTombstone :exec
+DELETE FROM peer_tombstones WHERE kind = ? AND uid = ?;
+
+-- name: GetPeerSyncState :one
+SELECT * FROM peer_sync_state WHERE peer = ?;
+
+-- name: SavePeerSyncState :exec
+INSERT INTO peer_sync_state (peer, pull_cursor, push_cursor, last_sync_at)
+VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+ON CONFLICT (peer) DO UPDATE SET pull_cursor = excluded.pull_cursor, push_cursor = excluded.push_cursor, last_sync_at = excluded.last_sync_at;
+
+-- Reimbursements
+-- name: UpsertExpenseReimbursement :exec
+-- Links part of an expense to an income, adding to an existing link.
+INSERT INTO expense_reimbursements (expense_id, income_id, amount_cents)
+VALUES (?, ?, ?)
+ON CONFLICT (expense_id, income_id) DO UPDATE SET amount_cents = amount_cents + excluded.amount_cents;
+
+-- name: DeleteExpenseReimbursement :execrows
+DELETE FROM expense_reimbursements WHERE expense_id = ? AND income_id = ?;
+
+-- name: GetExpenseReimbursedTotal :one
+SELECT CAST(COALESCE(SUM(amount_cents), 0) AS INTEGER) as total
+FROM expense_reimbursements
+WHERE expense_id = ?;
+
+-- name: GetIncomeReimbursedTotal :one
+SELECT CAST(COALESCE(SUM(amount_cents), 0) AS INTEGER) as total
+FROM expense_reimbursements
+WHERE income_id = ?;
+
+-- name: ListExpenseReimbursements :many
+-- Every link with its expense, grouped by income.
+SELECT r.income_id, r.expense_id, r.amount_cents,
+       e.date AS expense_date, e.description AS expense_description,
+       e.amount_cents AS expense_amount_cents, e.primary_category, e.secondary_category
+FROM expense_reimbursements r
+JOIN expenses e ON e.id = r.expense_id
+WHERE e.deleted_at IS NULL
+ORDER BY r.income_id, e.date, e.id;
+
+-- name: GetReimbursedCategorySums :many
+-- Reimbursed amounts per primary category for expenses in the month.
+SELECT e.primary_category, CAST(SUM(r.amount_cents) AS INTEGER) as total_amount
+FROM expense_reimbursements r
+JOIN expenses e ON e.id = r.expense_id
+WHERE strftime('%Y', e.date) = printf('%04d', ?)
+  AND strftime('%m', e.date) = printf('%02d', ?)
+  AND e.deleted_at IS NULL
+GROUP BY e.primary_category;
+
+-- name: ListIncomesByDateRange :many
+SELECT * FROM incomes
+WHERE date >= ? AND date <= ? AND deleted_at IS NULL
+ORDER BY date DESC, id DESC;
+
+-- name: DeleteAllExpenseReimbursements :exec
+DELETE FROM expense_reimbursements;
+
+-- name: GetExpenseWorkflowState :one
+SELECT state FROM expense_workflow
+WHERE expense_id = ?;
+
+-- name: UpsertExpenseWorkflowState :exec
+INSERT INTO expense_workflow (expense_id, state, updated_by)
+VALUES (?, ?, ?)
+ON CONFLICT (expense_id) DO UPDATE SET
+    state = excluded.state,
+    updated_by = excluded.updated_by,
+    updated_at = CURRENT_TIMESTAMP;
+
+-- name: ListExpensesByWorkflowState :many
+-- Expenses in a workflow state, newest first. Expenses without a workflow row are drafts.
+SELECT e.id, e.date, e.description, e.amount_cents, e.primary_category, e.secondary_category,
+       CAST(COALESCE(w.updated_by, '') AS TEXT) AS updated_by
+FROM expenses e
+LEFT JOIN expense_workflow w ON w.expense_id = e.id
+WHERE COALESCE(w.state, 'draft') = ? AND e.deleted_at IS NULL
+ORDER BY e.date DESC, e.id DESC
+LIMIT ?;
+
+-- name: CountExpensesByWorkflowState :many
+SELECT CAST(COALESCE(w.state, 'draft') AS TEXT) AS state, COUNT(*) AS count
+FROM expenses e
+LEFT JOIN expense_workflow w ON w.expense_id = e.id
+WHERE e.deleted_at IS NULL
+GROUP BY 1;
+
+-- name: DeleteAllExpenseWorkflow :exec
+DELETE FROM expense_workflow;
+
+-- name: CreateExpenseCalculation :exec
+INSERT INTO expense_calculations (expense_id, kind, quantity, rate_cents)
+VALUES (?, ?, ?, ?);
+
+-- name: GetExpenseCalculation :one
+SELECT expense_id, kind, quantity, rate_cents, created_at FROM expense_calculations
+WHERE expense_id = ?;
+
+-- name: DeleteAllExpenseCalculations :exec
+DELETE FROM expense_calculations;
+
+-- name: UpsertPurchaseWarranty :exec
+-- A changed return deadline gets a new reminder.
+INSERT INTO purchase_warranties (expense_id, warranty_months, return_deadline, proof)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (expense_id) DO UPDATE SET
+    warranty_months = excluded.warranty_months,
+    return_notified_at = CASE
+        WHEN return_deadline IS excluded.return_deadline THEN return_notified_at
+        ELSE NULL
+    END,
+    return_deadline = excluded.return_deadline,
+    proof = excluded.proof;
+
+-- name: DeletePurchaseWarranty :execrows
+DELETE FROM purchase_warranties
+WHERE expense_id = ?;
+
+-- name: ListPurchaseWarranties :many
+SELECT w.expense_id, w.warranty_months, w.return_deadline, w.proof,
+       e.date, e.description, e.amount_cents, e.primary_category, e.secondary_category
+FROM purchase_warranties w
+JOIN expenses e ON e.id = w.expense_id
+WHERE e.deleted_at IS NULL
+ORDER BY e.date DESC, e.id DESC;
+
+-- name: ListReturnDeadlinesToNotify :many
+-- Return deadlines within the range whose reminder was not sent yet.
+SELECT w.expense_id, w.warranty_months, w.return_deadline, w.proof,
+       e.date, e.description, e.amount_cents, e.primary_category, e.secondary_category
+FROM purchase_warranties w
+JOIN expenses e ON e.id = w.expense_id
+WHERE w.return_notified_at IS NULL AND e.deleted_at IS NULL
+  AND w.return_deadline >= ? AND w.return_deadline <= ?
+ORDER BY w.return_deadline, w.expense_id;
+
+-- name: MarkReturnReminderSent :exec
+UPDATE purchase_warranties
+SET return_notified_at = CURRENT_TIMESTAMP
+WHERE expense_id = ?;
+
+-- name: DeleteAllPurchaseWarranties :exec
+DELETE FROM purchase_warranties;
+
+-- name: CreateShoppingList :one
+INSERT INTO shopping_lists (name)
+VALUES (?)
+RETURNING id;
+
+-- name: GetShoppingList :one
+SELECT id, name, expense_id, converted_at, created_at FROM shopping_lists
+WHERE id = ?;
+
+-- name: GetShoppingListByExpense :one
+SELECT id, name, expense_id, converted_at, created_at FROM shopping_lists
+WHERE expense_id = ?;
+
+-- name: ListShoppingLists :many
+-- Open lists first, then the most recent conversions.
+SELECT l.id, l.name, l.expense_id, l.converted_at, l.created_at,
+       CAST(COUNT(i.id) AS INTEGER) as item_count,
+       CAST(COALESCE(SUM(CASE WHEN i.checked THEN i.price_cents ELSE 0 END), 0) AS INTEGER) as checked_cents
+FROM shopping_lists l
+LEFT JOIN shopping_list_items i ON i.list_id = l.id
+GROUP BY l.id
+ORDER BY l.converted_at IS NOT NULL, l.converted_at DESC, l.created_at DESC, l.id DESC
+LIMIT ?;
+
+-- name: DeleteShoppingList :execrows
+-- Converted lists are kept as the breakdown of their expense.
+DELETE FROM shopping_lists
+WHERE id = ? AND converted_at IS NULL;
+
+-- name: MarkShoppingListConverted :execrows
+UPDATE shopping_lists
+SET expense_id = ?, converted_at = CURRENT_TIMESTAMP
+WHERE id = ? AND converted_at IS NULL;
+
+-- name: CreateShoppingListItem :exec
+INSERT INTO shopping_list_items (list_id, name, quantity, price_cents)
+VALUES (?, ?, ?, ?);
+
+-- name: ListShoppingListItems :many
+SELECT id, list_id, name, quantity, price_cents, checked, created_at FROM shopping_list_items
+WHERE list_id = ?
+ORDER BY id;
+
+-- name: UpdateShoppingListItem :execrows
+-- Items of converted lists are read-only.
+UPDATE shopping_list_items
+SET checked = ?, price_cents = ?
+WHERE id = ? AND list_id IN (SELECT id FROM shopping_lists WHERE converted_at IS NULL);
+
+-- name: DeleteShoppingListItem :execrows
+DELETE FROM shopping_list_items
+WHERE id = ? AND list_id IN (SELECT id FROM shopping_lists WHERE converted_at IS NULL);
+
+-- name: DeleteAllShoppingLists :exec
+DELETE FROM shopping_lists;
+
+-- name: DeleteAllShoppingListItems :exec
+DELETE FROM shopping_list_items;
+
+-- name: UpsertItem :one
+-- Returns the item with this normalized name, creating it when new.
+INSERT INTO items (name, normalized_name)
+VALUES (?, ?)
+ON CONFLICT (normalized_name) DO UPDATE SET name = items.name
+RETURNING id;
+
+-- name: GetItemIDByNormalizedName :one
+SELECT id FROM items
+WHERE normalized_name = ?;
+
+-- name: ListItems :many
+SELECT id, name, normalized_name, created_at FROM items
+ORDER BY normalized_name;
+
+-- name: DeleteItem :execrows
+DELETE FROM items
+WHERE id = ?;
+
+-- name: CreateItemPrice :exec
+INSERT INTO item_prices (item_id, expense_id, date, quantity, unit_price_cents, source)
+VALUES (?, ?, ?, ?, ?, ?);
+
+-- name: DeleteItemPricesBySource :exec
+DELETE FROM item_prices
+WHERE item_id = ? AND source = ?;
+
+-- name: ListItemPrices :many
+SELECT item_id, date, quantity, unit_price_cents FROM item_prices
+ORDER BY item_id, date, id;
+
+-- name: DeleteAllItems :exec
+DELETE FROM items;
+
+-- name: DeleteAllItemPrices :exec
+DELETE FROM item_prices;
+
+-- name: CreateExpenseLineItem :exec
+INSERT INTO expense_line_items (expense_id, position, description, quantity, amount_cents, primary_category, secondary_category)
+VALUES (?, ?, ?, ?, ?, ?, ?);
+
+-- name: ListExpenseLineItems :many
+SELECT id, expense_id, position, description, quantity, amount_cents, primary_category, secondary_category, created_at
+FROM expense_line_items
+WHERE expense_id = ?
+ORDER BY position;
+
+-- name: DeleteExpenseLineItems :exec
+DELETE FROM expense_line_items
+WHERE expense_id = ?;
+
+-- name: ListMonthLineItems :many
+-- Line items of the month's expenses, grouped by expense.
+SELECT l.expense_id, e.amount_cents as expense_amount_cents, e.primary_category as expense_primary_category, e.status,
+       l.amount_cents, l.primary_category
+FROM expense_line_items l
+JOIN expenses e ON e.id = l.expense_id
+WHERE strftime('%Y', e.date) = printf('%04d', ?)
+  AND strftime('%m', e.date) = printf('%02d', ?)
+  AND e.deleted_at IS NULL
+ORDER BY l.expense_id, l.position;
+
+-- name: DeleteItemPricesByExpense :exec
+DELETE FROM item_prices
+WHERE expense_id = ? AND source = ?;
+
+-- name: DeleteAllExpenseLineItems :exec
+DELETE FROM expense_line_items;
+
+-- name: CreateExpenseSplit :exec
+INSERT INTO expense_splits (expense_id, position, note, amount_cents, primary_category, secondary_category)
+VALUES (?, ?, ?, ?, ?, ?);
+
+-- name: ListExpenseSplits :many
+SELECT id, expense_id, position, note, amount_cents, primary_category, secondary_category, created_at
+FROM expense_splits
+WHERE expense_id = ?
+ORDER BY position;
+
+-- name: ListSplitExpenseIDs :many
+-- The IDs of the split expenses.
+SELECT DISTINCT expense_id FROM expense_splits ORDER BY expense_id;
+
+-- name: DeleteExpenseSplits :exec
+DELETE FROM expense_splits
+WHERE expense_id = ?;
+
+-- name: ListMonthSplits :many
+-- Parts of the month's split expenses, grouped by expense.
+SELECT s.expense_id, e.amount_cents as expense_amount_cents, e.primary_category as expense_primary_category, e.status,
+       s.note, s.amount_cents, s.primary_category, s.secondary_category
+FROM expense_splits s
+JOIN expenses e ON e.id = s.expense_id
+WHERE strftime('%Y', e.date) = printf('%04d', ?)
+  AND strftime('%m', e.date) = printf('%02d', ?)
+  AND e.deleted_at IS NULL
+ORDER BY s.expense_id, s.position;
+
+-- name: DeleteAllExpenseSplits :exec
+DELETE FROM expense_splits;
+
+-- name: UpsertUtilityUsage :exec
+INSERT INTO utility_usage (expense_id, kind, quantity)
+VALUES (?, ?, ?)
+ON CONFLICT (expense_id) DO UPDATE SET
+    kind = excluded.kind,
+    quantity = excluded.quantity;
+
+-- name: GetUtilityUsage :one
+SELECT expense_id, kind, quantity, created_at FROM utility_usage
+WHERE expense_id = ?;
+
+-- name: DeleteUtilityUsage :execrows
+DELETE FROM utility_usage
+WHERE expense_id = ?;
+
+-- name: ListUtilityUsage :many
+SELECT u.expense_id, u.kind, u.quantity,
+       e.date, e.description, e.amount_cents, e.primary_category, e.secondary_category
+FROM utility_usage u
+JOIN expenses e ON e.id = u.expense_id
+WHERE e.deleted_at IS NULL
+ORDER BY u.kind, e.date, e.id;
+
+-- name: DeleteAllUtilityUsage :exec
+DELETE FROM utility_usage;
+
+-- name: UpsertFuelFill :exec
+INSERT INTO fuel_fills (expense_id, liters, odometer_km)
+VALUES (?, ?, ?)
+ON CONFLICT (expense_id) DO UPDATE SET
+    liters = excluded.liters,
+    odometer_km = excluded.odometer_km;
+
+-- name: GetFuelFill :one
+SELECT expense_id, liters, odometer_km, created_at FROM fuel_fills
+WHERE expense_id = ?;
+
+-- name: DeleteFuelFill :execrows
+DELETE FROM fuel_fills
+WHERE expense_id = ?;
+
+-- name: ListVehicleExpenses :many
+-- Expenses of the vehicle cost center, with their fuel fill if any.
+SELECT e.id, e.date, e.description, e.amount_cents,
+       f.liters, f.odometer_km
+FROM expenses e
+LEFT JOIN fuel_fills f ON f.expense_id = e.id
+WHERE (e.secondary_category = ? OR f.expense_id IS NOT NULL)
+  AND e.date >= ? AND e.date <= ?
+  AND e.deleted_at IS NULL
+ORDER BY e.date, e.id;
+
+-- name: DeleteAllFuelFills :exec
+DELETE FROM fuel_fills;
+
+-- name: GetPrimaryCategoryByName :one
+SELECT id, name, created_at, archived FROM primary_categories
+WHERE name = ?;
+
+-- name: UpsertCategoryTranslation :exec
+INSERT INTO category_translations (kind, name, locale, display_name)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (kind, name, locale) DO UPDATE SET
+    display_name = excluded.display_name;
+
+-- name: DeleteCategoryTranslation :execrows
+DELETE FROM category_translations
+WHERE kind = ? AND name = ? AND locale = ?;
+
+-- name: ListCategoryTranslations :many
+SELECT kind, name, locale, display_name FROM category_translations
+WHERE locale = ?
+ORDER BY kind, name;
+
+-- name: UpsertCategoryKeyword :exec
+INSERT INTO category_keywords (keyword, primary_category, secondary_category, disabled)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (keyword) DO UPDATE SET
+    primary_category = excluded.primary_category,
+    secondary_category = excluded.secondary_category,
+    disabled = excluded.disabled;
+
+-- name: DeleteCategoryKeyword :execrows
+DELETE FROM category_keywords WHERE keyword = ?;
+
+-- name: ListCategoryKeywords :many
+SELECT keyword, primary_category, secondary_category, disabled, created_at
+FROM category_keywords
+ORDER BY keyword;
+
+-- name: DeleteAllCategoryKeywords :exec
+DELETE FROM category_keywords;
+
+-- name: ListTrainingExpenses :many
+-- Most recent categorized expenses, the classifier's training set.
+SELECT description, primary_category, secondary_category
+FROM expenses
+WHERE secondary_category != sqlc.arg(uncategorized) AND deleted_at IS NULL
+ORDER BY date DESC, id DESC
+LIMIT sqlc.arg(max_rows);
+
+-- name: ListUncategorizedExpenses :many
+-- Expenses in the placeholder category whose proposal was not reviewed yet.
+SELECT e.* FROM expenses e
+LEFT JOIN classifier_feedback f ON f.expense_id = e.id
+WHERE e.secondary_category = ? AND f.expense_id IS NULL AND e.deleted_at IS NULL
+ORDER BY e.date DESC, e.id DESC
+LIMIT ?;
+
+-- name: UpdateExpenseCategory :execrows
+UPDATE expenses
+SET primary_category = ?, secondary_category = ?, version = version + 1
+WHERE id = ?;
+
+-- name: UpsertClassifierFeedback :exec
+INSERT INTO classifier_feedback (expense_id, verdict, primary_category, secondary_category, confidence)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT (expense_id) DO UPDATE SET
+    verdict = excluded.verdict,
+    primary_category = excluded.primary_category,
+    secondary_category = excluded.secondary_category,
+    confidence = excluded.confidence,
+    created_at = CURRENT_TIMESTAMP;
+
+-- name: CountClassifierFeedback :many
+SELECT verdict, COUNT(*) AS total FROM classifier_feedback
+GROUP BY verdict;
+
+-- name: DeleteAllClassifierFeedback :exec
+DELETE FROM classifier_feedback;
+
+-- name: CreateInsight :one
+-- Records an insight unless the same kind was already recorded for the
+-- category and month, in which case no row is returned.
+INSERT INTO insights (kind, primary_category, period, occurred_on, amount_cents, baseline_cents, days, since_period)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (kind, primary_category, period) DO NOTHING
+RETURNING id;
+
+-- name: ListInsights :many
+-- Insights not dismissed nor muted, newest first.
+SELECT i.* FROM insights i
+WHERE i.dismissed_at IS NULL
+  AND NOT EXISTS (SELECT 1 FROM insight_mutes m WHERE m.kind = i.kind AND m.primary_category = i.primary_category)
+ORDER BY i.occurred_on DESC, i.id DESC
+LIMIT ?;
+
+-- name: GetInsight :one
+SELECT * FROM insights WHERE id = ?;
+
+-- name: DismissInsight :execrows
+UPDATE insights SET dismissed_at = CURRENT_TIMESTAMP
+WHERE id = ? AND dismissed_at IS NULL;
+
+-- name: MuteInsight :exec
+INSERT INTO insight_mutes (kind, primary_category) VALUES (?, ?)
+ON CONFLICT (kind, primary_category) DO NOTHING;
+
+-- name: UnmuteInsight :execrows
+DELETE FROM insight_mutes WHERE kind = ? AND primary_category = ?;
+
+-- name: ListInsightMutes :many
+SELECT kind, primary_category, created_at FROM insight_mutes
+ORDER BY primary_category, kind;
+
+-- name: DeleteAllInsights :exec
+DELETE FROM insights;
+
+-- name: DeleteAllInsightMutes :exec
+DELETE FROM insight_mutes;
+
+-- name: SetAlertPreference :exec
+INSERT INTO alert_preferences (user_id, alert_type, scope, muted, snoozed_until)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT (user_id, alert_type, scope) DO UPDATE SET
+    muted = excluded.muted,
+    snoozed_until = excluded.snoozed_until,
+    updated_at = CURRENT_TIMESTAMP;
+
+-- name: ListAlertPreferences :many
+SELECT * FROM alert_preferences WHERE user_id = ?
+ORDER BY alert_type, scope;
+
+-- name: DeleteAlertPreference :execrows
+DELETE FROM alert_preferences WHERE user_id = ? AND alert_type = ? AND scope = ?;
+
+-- name: DeleteAllAlertPreferences :exec
+DELETE FROM alert_preferences;
+
+-- name: CloseMonth :exec
+INSERT INTO month_reviews (period) VALUES (?)
+ON CONFLICT (period) DO NOTHING;
+
+-- name: ReopenMonth :execrows
+DELETE FROM month_reviews WHERE period = ?;
+
+-- name: GetMonthReview :one
+SELECT * FROM month_reviews WHERE period = ?;
+
+-- name: ListMonthReviews :many
+SELECT * FROM month_reviews ORDER BY period DESC;
+
+-- name: DeleteAllMonthReviews :exec
+DELETE FROM month_reviews;
+
+-- name: CreateHistoryExpense :one
+INSERT INTO expenses (date, description, amount_cents, primary_category, secondary_category, sync_status, synced_at)
+VALUES (date(?), ?, ?, ?, ?, 'synced', CURRENT_TIMESTAMP)
+RETURNING *;
+
+-- name: CountExpensesInYear :one
+SELECT COUNT(*) FROM expenses WHERE strftime('%Y', date) = printf('%04d', ?);
+
+-- name: StartSheetHistoryImport :exec
+-- Records the start of the import of a year, or its restart after a failure
+INSERT INTO sheet_history_imports (year, expenses, total_rows) VALUES (?, 0, ?)
+ON CONFLICT (year) DO UPDATE SET total_rows = excluded.total_rows, last_error = '';
+
+-- name: AdvanceSheetHistoryImport :exec
+UPDATE sheet_history_imports SET next_row = ?, expenses = expenses + ? WHERE year = ?;
+
+-- name: CompleteSheetHistoryImport :exec
+UPDATE sheet_history_imports SET completed_at = CURRENT_TIMESTAMP, last_error = '' WHERE year = ?;
+
+-- name: FailSheetHistoryImport :exec
+UPDATE sheet_history_imports SET last_error = ? WHERE year = ?;
+
+-- name: GetSheetHistoryImport :one
+SELECT * FROM sheet_history_imports WHERE year = ?;
+
+-- name: ListSheetHistoryImports :many
+SELECT * FROM sheet_history_imports ORDER BY year;
+
+-- name: DeleteAllSheetHistoryImports :exec
+DELETE FROM sheet_history_imports;
+
+-- name: ListLedgerExpenses :many
+-- A page of the expenses between two dates, newest first, starting right
+-- after the expense (after_date, after_id) in that order.
+SELECT * FROM expenses
+WHERE date BETWEEN date(sqlc.arg(from_date)) AND date(sqlc.arg(to_date))
+  AND deleted_at IS NULL
+  AND (date < date(sqlc.arg(after_date)) OR (date = date(sqlc.arg(after_date)) AND id < sqlc.arg(after_id)))
+ORDER BY date DESC, id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: GetLedgerTotals :one
+SELECT COUNT(*) AS expenses, CAST(COALESCE(SUM(amount_cents), 0) AS INTEGER) AS total_cents
+FROM expenses
+WHERE date BETWEEN date(sqlc.arg(from_date)) AND date(sqlc.arg(to_date))
+  AND deleted_at IS NULL;
+
+-- name: ListExpenseFingerprints :many
+-- What identifies the expenses between two dates, to find duplicates of
+-- imported rows.
+SELECT date, description, amount_cents FROM expenses
+WHERE date BETWEEN date(sqlc.arg(from_date)) AND date(sqlc.arg(to_date))
+  AND deleted_at IS NULL;
+
+-- name: FindSimilarExpenses :many
+-- Live expenses of a day with an amount, to warn about a new expense that
+-- looks like one of them.
+SELECT id, date, description, amount_cents, primary_category, secondary_category, version, created_at, synced_at, sync_status, uid, modified_at, status, paid_by, deleted_at FROM expenses
+WHERE date = date(sqlc.arg(date)) AND amount_cents = sqlc.arg(amount_cents)
+  AND deleted_at IS NULL
+ORDER BY id;
+
+-- name: CreateSavedView :one
+INSERT INTO saved_views (name, primary_category, secondary_category, min_cents, max_cents, text, tag, notify)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id;
+
+-- name: ListSavedViews :many
+SELECT * FROM saved_views ORDER BY name;
+
+-- name: GetSavedView :one
+SELECT * FROM saved_views WHERE id = ?;
+
+-- name: SetSavedViewNotify :execrows
+UPDATE saved_views SET notify = ? WHERE id = ?;
+
+-- name: DeleteSavedView :execrows
+DELETE FROM saved_views WHERE id = ?;
+
+-- name: DeleteAllSavedViews :exec
+DELETE FROM saved_views;
+
+-- name: ListFilteredExpenses :many
+-- The latest expenses a saved view selects; zero amounts and empty texts
+-- and tags do not filter.
+SELECT * FROM expenses
+WHERE (sqlc.arg(primary_category) = '' OR primary_category = sqlc.arg(primary_category))
+  AND (sqlc.arg(secondary_category) = '' OR secondary_category = sqlc.arg(secondary_category))
+  AND (sqlc.arg(min_cents) = 0 OR amount_cents >= sqlc.arg(min_cents))
+  AND (sqlc.arg(max_cents) = 0 OR amount_cents <= sqlc.arg(max_cents))
+  AND (sqlc.arg(text) = '' OR instr(lower(description), lower(sqlc.arg(text))) > 0)
+  AND (sqlc.arg(tag) = '' OR EXISTS (
+    SELECT 1 FROM expense_tags et JOIN tags t ON t.id = et.tag_id
+    WHERE et.expense_id = expenses.id AND t.name = sqlc.arg(tag)
+  ))
+  AND deleted_at IS NULL
+ORDER BY date DESC, id DESC
+LIMIT sqlc.arg(max_rows);
+
+-- name: SearchExpenses :many
+-- The newest expenses between two dates matching a search; empty matches
+-- and zero amounts do not filter, a category matches either level. The
+-- match is an FTS5 query on the descriptions.
+SELECT * FROM expenses
+WHERE date BETWEEN date(sqlc.arg(from_date)) AND date(sqlc.arg(to_date))
+  AND deleted_at IS NULL
+  AND (sqlc.arg(category) = '' OR primary_category = sqlc.arg(category) OR secondary_category = sqlc.arg(category))
+  AND (sqlc.arg(min_cents) = 0 OR amount_cents >= sqlc.arg(min_cents))
+  AND (sqlc.arg(max_cents) = 0 OR amount_cents <= sqlc.arg(max_cents))
+  AND (sqlc.arg(match) = '' OR id IN (SELECT rowid FROM expenses_fts WHERE expenses_fts MATCH sqlc.arg(match)))
+ORDER BY date DESC, id DESC
+LIMIT sqlc.arg(max_rows);
+
+-- Household members
+-- name: CreateUser :one
+INSERT INTO users (name) VALUES (?)
+RETURNING *;
+
+-- name: ListUsers :many
+SELECT * FROM users ORDER BY name;
+
+-- name: DeleteUser :execrows
+DELETE FROM users WHERE id = ?;
+
+-- name: DeleteAllUsers :exec
+DELETE FROM users;
+
+-- API keys and quotas of the members
+-- name: CreateApiKey :one
+INSERT INTO api_keys (user_id, name, key_hash) VALUES (?, ?, ?)
+RETURNING *;
+
+-- name: GetApiKeyUser :one
+-- The key with the given hash and its member.
+SELECT k.id, u.id AS user_id, u.name
+FROM api_keys k
+JOIN users u ON u.id = k.user_id
+WHERE k.key_hash = ?;
+
+-- name: TouchApiKey :exec
+-- Records the use of a key, at most once a minute.
+UPDATE api_keys SET last_used_at = CURRENT_TIMESTAMP
+WHERE id = ?
+  AND (last_used_at IS NULL OR last_used_at < datetime('now', '-1 minute'));
+
+-- name: ListApiKeys :many
+SELECT * FROM api_keys ORDER BY user_id, id;
+
+-- name: DeleteApiKey :execrows
+DELETE FROM api_keys WHERE id = ?;
+
+-- name: GetUserQuota :one
+SELECT * FROM user_quotas WHERE user_id = ?;
+
+-- name: ListUserQuotas :many
+SELECT * FROM user_quotas;
+
+-- name: UpsertUserQuota :exec
+INSERT INTO user_quotas (user_id, daily_expenses, attachment_bytes) VALUES (?, ?, ?)
+ON CONFLICT (user_id) DO UPDATE SET
+    daily_expenses = excluded.daily_expenses,
+    attachment_bytes = excluded.attachment_bytes;
+
+-- name: ConsumeDailyExpenses :one
+-- Adds count expenses to the day of a member unless that goes over
+-- max_expenses (0 for no limit); no row comes back when it would.
+INSERT INTO user_daily_usage (user_id, day, expenses)
+VALUES (sqlc.arg(user_id), sqlc.arg(day), sqlc.arg(count))
+ON CONFLICT (user_id, day) DO UPDATE SET expenses = expenses + excluded.expenses
+WHERE sqlc.arg(max_expenses) = 0 OR expenses + excluded.expenses <= sqlc.arg(max_expenses)
+RETURNING expenses;
+
+-- name: ReleaseDailyExpenses :exec
+-- Gives back expenses counted for a creation that then failed.
+UPDATE user_daily_usage SET expenses = MAX(expenses - sqlc.arg(count), 0)
+WHERE user_id = sqlc.arg(user_id) AND day = sqlc.arg(day);
+
+-- name: GetDailyExpenses :one
+SELECT CAST(COALESCE(SUM(expenses), 0) AS INTEGER) AS expenses
+FROM user_daily_usage
+WHERE user_id = ? AND day = ?;
+
+-- name: ListDailyUsage :many
+SELECT * FROM user_daily_usage WHERE day = ?;
+
+-- name: GetAttachmentBytes :one
+-- Bytes of the receipts a member uploaded, leaving out the receipt of
+-- except_expense (the one an upload would replace).
+SELECT CAST(COALESCE(SUM(size_bytes), 0) AS INTEGER) AS total_bytes
+FROM receipts
+WHERE uploaded_by = sqlc.arg(user_id) AND expense_id != sqlc.arg(except_expense);
+
+-- name: ReserveAttachmentBytes :one
+-- Holds size_bytes of a member's attachment quota for an upload unless,
+-- with their receipts (but the one of except_expense, which the upload
+-- replaces) and the reservations of the last hour, that goes over
+-- max_bytes; no row comes back when it would.
+INSERT INTO attachment_reservations (user_id, size_bytes)
+SELECT sqlc.arg(user_id), sqlc.arg(size_bytes)
+WHERE (SELECT COALESCE(SUM(size_bytes), 0) FROM receipts
+       WHERE uploaded_by = sqlc.arg(user_id) AND expense_id != sqlc.arg(except_expense))
+    + (SELECT COALESCE(SUM(size_bytes), 0) FROM attachment_reservations
+       WHERE user_id = sqlc.arg(user_id) AND created_at > datetime('now', '-1 hour'))
+    + sqlc.arg(size_bytes) <= sqlc.arg(max_bytes)
+RETURNING id;
+
+-- name: GetReservedAttachmentBytes :one
+-- Bytes held by a member's uploads in progress, as counted by
+-- ReserveAttachmentBytes.
+SELECT CAST(COALESCE(SUM(size_bytes), 0) AS INTEGER) AS reserved_bytes
+FROM attachment_reservations
+WHERE user_id = ? AND created_at > datetime('now', '-1 hour');
+
+-- name: ReleaseAttachmentBytes :exec
+-- Drops a reservation once its upload is stored or failed.
+DELETE FROM attachment_reservations WHERE id = ?;
+
+-- name: ListAttachmentBytesByUser :many
+SELECT uploaded_by, CAST(SUM(size_bytes) AS INTEGER) AS total_bytes
+FROM receipts
+WHERE uploaded_by IS NOT NULL
+GROUP BY uploaded_by;
+
+-- name: GetMonthTotalsByPayer :many
+-- Settled expenses of a month per payer; a NULL payer collects the
+-- expenses nobody is set on.
+SELECT paid_by, CAST(SUM(amount_cents) AS INTEGER) as total_amount
+FROM expenses
+WHERE status = 'cleared' AND deleted_at IS NULL
+  AND strftime('%Y', date) = printf('%04d', ?)
+  AND strftime('%m', date) = printf('%02d', ?)
+GROUP BY paid_by;
+
+-- name: GetPayerCategoryTotals :many
+-- Expenses paid by a member per primary category between two dates.
+SELECT primary_category, CAST(SUM(amount_cents) AS INTEGER) as total_amount
+FROM expenses
+WHERE paid_by = sqlc.arg(paid_by) AND deleted_at IS NULL
+  AND date BETWEEN date(sqlc.arg(start_date)) AND date(sqlc.arg(end_date))
+GROUP BY primary_category
+ORDER BY total_amount DESC;
+
+-- Category management queries
+-- name: ListCategoryAdmin :many
+-- Lists every category, archived ones included, with the expenses filed under it.
+SELECT
+  pc.name AS primary_name,
+  pc.archived AS primary_archived,
+  sc.name AS secondary_name,
+  sc.archived AS secondary_archived,
+  (SELECT COUNT(*) FROM expenses e
+   WHERE e.primary_category = pc.name AND e.secondary_category = sc.name) AS expense_count
+FROM primary_categories pc
+LEFT JOIN secondary_categories sc ON sc.primary_category_id = pc.id
+ORDER BY pc.name ASC, sc.name ASC;
+
+-- name: GetSecondaryCategoryByName :one
+SELECT sc.id, sc.name, sc.primary_category_id, sc.created_at, sc.archived
+FROM secondary_categories sc
+JOIN primary_categories pc ON sc.primary_category_id = pc.id
+WHERE pc.name = sqlc.arg(primary_name) AND sc.name = sqlc.arg(name);
+
+-- name: RenamePrimaryCategory :execrows
+UPDATE primary_categories SET name = ? WHERE id = ?;
+
+-- name: UpdateSecondaryCategory :execrows
+UPDATE secondary_categories SET name = ?, primary_category_id = ? WHERE id = ?;
+
+-- name: SetPrimaryCategoryArchived :execrows
+UPDATE primary_categories SET archived = ? WHERE id = ?;
+
+-- name: SetSecondaryCategoryArchived :execrows
+UPDATE secondary_categories SET archived = ? WHERE id = ?;
+
+-- name: DeletePrimaryCategoryByID :exec
+DELETE FROM primary_categories WHERE id = ?;
+
+-- name: DeleteSecondaryCategoryByID :exec
+DELETE FROM secondary_categories WHERE id = ?;
+
+-- name: DeleteSecondaryCategoriesByPrimaryID :exec
+-- Foreign keys are not enforced, so the cascade of the schema never runs.
+DELETE FROM secondary_categories WHERE primary_category_id = ?;
+
+-- name: CountPrimaryCategoryReferences :one
+SELECT
+  (SELECT COUNT(*) FROM expenses WHERE expenses.primary_category = sqlc.arg(primary_category))
+  + (SELECT COUNT(*) FROM recurrent_expenses WHERE recurrent_expenses.primary_category = sqlc.arg(primary_category)) AS total;
+
+-- name: CountSecondaryCategoryReferences :one
+SELECT
+  (SELECT COUNT(*) FROM expenses
+   WHERE expenses.primary_category = sqlc.arg(primary_category) AND expenses.secondary_category = sqlc.arg(secondary_category))
+  + (SELECT COUNT(*) FROM recurrent_expenses
+   WHERE recurrent_expenses.primary_category = sqlc.arg(primary_category) AND recurrent_expenses.secondary_category = sqlc.arg(secondary_category)) AS total;
+
+-- name: ListExpensesByPrimaryCategory :many
+SELECT * FROM expenses WHERE primary_category = ? ORDER BY id;
+
+-- name: ListExpensesInCategory :many
+SELECT * FROM expenses WHERE primary_category = ? AND secondary_category = ? ORDER BY id;
+
+-- name: RenameRecurrentExpensesPrimary :exec
+UPDATE recurrent_expenses
+SET primary_category = sqlc.arg(new_primary), updated_at = CURRENT_TIMESTAMP
+WHERE primary_category = sqlc.arg(old_primary);
+
+-- name: MoveRecurrentExpensesCategory :exec
+UPDATE recurrent_expenses
+SET primary_category = sqlc.arg(new_primary), secondary_category = sqlc.arg(new_secondary), updated_at = CURRENT_TIMESTAMP
+WHERE primary_category = sqlc.arg(old_primary) AND secondary_category = sqlc.arg(old_secondary);
+
+-- name: RenameCategoryRulesPrimary :exec
+UPDATE category_rules
+SET primary_category = sqlc.arg(new_primary)
+WHERE primary_category = sqlc.arg(old_primary);
+
+-- name: MoveCategoryRulesCategory :exec
+UPDATE category_rules
+SET primary_category = sqlc.arg(new_primary), secondary_category = sqlc.arg(new_secondary)
+WHERE primary_category = sqlc.arg(old_primary) AND secondary_category = sqlc.arg(old_secondary);
+
+-- name: RenameCategoryKeywordsPrimary :exec
+UPDATE category_keywords
+SET primary_category = sqlc.arg(new_primary)
+WHERE primary_category = sqlc.arg(old_primary);
+
+-- name: MoveCategoryKeywordsCategory :exec
+UPDATE category_keywords
+SET primary_category = sqlc.arg(new_primary), secondary_category = sqlc.arg(new_secondary)
+WHERE primary_category = sqlc.arg(old_primary) AND secondary_category = sqlc.arg(old_secondary);
+
+-- name: RenameSavedViewsPrimary :exec
+UPDATE saved_views
+SET primary_category = sqlc.arg(new_primary)
+WHERE primary_category = sqlc.arg(old_primary);
+
+-- name: MoveSavedViewsCategory :exec
+UPDATE saved_views
+SET primary_category = sqlc.arg(new_primary), secondary_category = sqlc.arg(new_secondary)
+WHERE primary_category = sqlc.arg(old_primary) AND secondary_category = sqlc.arg(old_secondary);
+
+-- name: RenameCategoryTranslations :exec
+UPDATE OR REPLACE category_translations
+SET name = sqlc.arg(new_name)
+WHERE kind = sqlc.arg(kind) AND name = sqlc.arg(old_name);
+
+-- Sync error queries
+-- name: RecordSyncError :exec
+-- Records a failed sync attempt with its error class.
+INSERT INTO sync_errors (queue_id, expense_id, operation, attempt, class, message)
+VALUES (?, ?, ?, ?, ?, ?);
+
+-- name: ListSyncErrors :many
+-- Returns the latest failed sync attempts, newest first.
+SELECT * FROM sync_errors
+ORDER BY id DESC
+LIMIT ?;
+
+-- name: ListExpenseSyncErrors :many
+-- Returns the failed sync attempts of an expense, newest first.
+SELECT * FROM sync_errors
+WHERE expense_id = ?
+ORDER BY id DESC;
+
+-- name: CountSyncErrorsByClass :many
+SELECT class, COUNT(*) AS count
+FROM sync_errors
+GROUP BY class
+ORDER BY class;
+
+-- name: CleanupSyncErrors :exec
+-- Removes failed attempts older than the specified timestamp.
+DELETE FROM sync_errors
+WHERE created_at < ?;
+
+-- Category mappings
+-- name: ListCategoryMappings :many
+-- Lists the primary category of every secondary category of Google Sheets.
+SELECT sheet_name, primary_category, created_at, updated_at FROM category_mappings
+ORDER BY primary_category, sheet_name;
+
+-- name: GetCategoryMapping :one
+-- Returns the primary category a Google Sheets secondary category maps to.
+SELECT primary_category FROM category_mappings
+WHERE sheet_name = ?;
+
+-- name: UpsertCategoryMapping :exec
+-- Maps a Google Sheets secondary category to a primary category.
+INSERT INTO category_mappings (sheet_name, primary_category)
+VALUES (?, ?)
+ON CONFLICT(sheet_name) DO UPDATE SET
+    primary_category = excluded.primary_category,
+    updated_at = CURRENT_TIMESTAMP;
+
+-- name: DeleteCategoryMapping :execrows
+-- Removes the mapping of a Google Sheets secondary category.
+DELETE FROM category_mappings
+WHERE sheet_name = ?;
+
+-- name: RenameCategoryMappingsPrimary :exec
+-- Points the mappings to a renamed primary category.
+UPDATE category_mappings
+SET primary_category = sqlc.arg(new_primary),
+    updated_at = CURRENT_TIMESTAMP
+WHERE primary_category = sqlc.arg(old_primary);
+
+-- Tags
+-- name: UpsertTag :one
+-- Returns the ID of the tag, creating it on first use.
+INSERT INTO tags (name) VALUES (?)
+ON CONFLICT(name) DO UPDATE SET name = excluded.name
+RETURNING id;
+
+-- name: AddExpenseTag :exec
+INSERT OR IGNORE INTO expense_tags (expense_id, tag_id) VALUES (?, ?);
+
+-- name: DeleteExpenseTags :exec
+DELETE FROM expense_tags WHERE expense_id = ?;
+
+-- name: ListExpenseTags :many
+-- Tags of an expense in the order they were given.
+SELECT t.name FROM expense_tags et
+JOIN tags t ON t.id = et.tag_id
+WHERE et.expense_id = ?
+ORDER BY et.rowid;
+
+-- name: AddIncomeTag :exec
+INSERT OR IGNORE INTO income_tags (income_id, tag_id) VALUES (?, ?);
+
+-- name: DeleteIncomeTags :exec
+DELETE FROM income_tags WHERE income_id = ?;
+
+-- name: ListTags :many
+-- Tags in use by expenses or incomes, most used first, for autocompletion.
+SELECT name, uses FROM (
+    SELECT t.name,
+           CAST((SELECT COUNT(*) FROM expense_tags WHERE tag_id = t.id)
+              + (SELECT COUNT(*) FROM income_tags WHERE tag_id = t.id) AS INTEGER) AS uses
+    FROM tags t
+)
+WHERE uses > 0
+ORDER BY uses DESC, name;
+
+-- name: SumExpenseTagsByMonth :many
+-- Spending per tag and month between from_date (included) and to_date
+-- (excluded); an empty tag selects every tag.
+SELECT t.name AS tag,
+       CAST(strftime('%Y-%m', e.date) AS TEXT) AS month,
+       CAST(SUM(e.amount_cents) AS INTEGER) AS total_amount,
+       COUNT(*) AS entries
+FROM expense_tags et
+JOIN tags t ON t.id = et.tag_id
+JOIN expenses e ON e.id = et.expense_id
+WHERE date(e.date) >= date(sqlc.arg(from_date))
+  AND date(e.date) < date(sqlc.arg(to_date))
+  AND (sqlc.arg(tag) = '' OR t.name = sqlc.arg(tag))
+  AND e.deleted_at IS NULL
+GROUP BY t.name, month
+ORDER BY t.name, month;
+
+-- name: SumIncomeTagsByMonth :many
+-- Income per tag and month, like SumExpenseTagsByMonth.
+SELECT t.name AS tag,
+       CAST(strftime('%Y-%m', i.date) AS TEXT) AS month,
+       CAST(SUM(i.amount_cents) AS INTEGER) AS total_amount,
+       COUNT(*) AS entries
+FROM income_tags it
+JOIN tags t ON t.id = it.tag_id
+JOIN incomes i ON i.id = it.income_id
+WHERE date(i.date) >= date(sqlc.arg(from_date))
+  AND date(i.date) < date(sqlc.arg(to_date))
+  AND (sqlc.arg(tag) = '' OR t.name = sqlc.arg(tag))
+  AND i.deleted_at IS NULL
+GROUP BY t.name, month
+ORDER BY t.name, month;
+
+-- name: DeleteAllExpenseTags :exec
+DELETE FROM expense_tags;
+
+-- name: DeleteAllIncomeTags :exec
+DELETE FROM income_tags;
+
+-- name: DeleteAllTags :exec
+DELETE FROM tags;
+
+-- Receipts
+-- name: UpsertReceipt :exec
+INSERT INTO receipts (expense_id, blob_key, thumbnail_key, content_type, filename, size_bytes, uploaded_by)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (expense_id) DO UPDATE SET
+    blob_key = excluded.blob_key,
+    thumbnail_key = excluded.thumbnail_key,
+    content_type = excluded.content_type,
+    filename = excluded.filename,
+    size_bytes = excluded.size_bytes,
+    created_at = CURRENT_TIMESTAMP,
+    uploaded_by = excluded.uploaded_by;
+
+-- name: GetReceipt :one
+SELECT expense_id, blob_key, thumbnail_key, content_type, filename, size_bytes, created_at, uploaded_by FROM receipts
+WHERE expense_id = ?;
+
+-- name: DeleteReceipt :execrows
+DELETE FROM receipts
+WHERE expense_id = ?;
+
+-- name: ListReceiptsBetween :many
+-- Receipts of the expenses dated in the range, end excluded.
+SELECT r.expense_id, r.blob_key, r.thumbnail_key, r.content_type, r.filename, r.size_bytes, r.created_at, r.uploaded_by
+FROM receipts r
+JOIN expenses e ON e.id = r.expense_id
+WHERE date(e.date) >= date(sqlc.arg(from_date))
+  AND date(e.date) < date(sqlc.arg(to_date))
+  AND e.deleted_at IS NULL;
+
+-- name: QueueBlobDeletion :exec
+INSERT OR IGNORE INTO blob_deletions (blob_key)
+VALUES (?);
+
+-- name: ListBlobDeletions :many
+SELECT blob_key FROM blob_deletions
+ORDER BY created_at, blob_key
+LIMIT ?;
+
+-- name: DeleteBlobDeletion :exec
+DELETE FROM blob_deletions
+WHERE blob_key = ?;
+
+-- Recurrent income queries
+-- name: CreateRecurrentIncome :one
+INSERT INTO recurrent_incomes (
+    start_date, end_date, repetition_type, repeat_interval, day_of_month,
+    description, amount_cents, category, subcategory
+)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: GetRecurrentIncomes :many
+SELECT * FROM recurrent_incomes
+WHERE is_active = 1
+ORDER BY start_date DESC;
+
+-- name: GetRecurrentIncomeByID :one
+SELECT * FROM recurrent_incomes
+WHERE id = ?;
+
+-- name: UpdateRecurrentIncome :execrows
+UPDATE recurrent_incomes
+SET start_date = ?,
+    end_date = ?,
+    repetition_type = ?,
+    repeat_interval = ?,
+    day_of_month = ?,
+    description = ?,
+    amount_cents = ?,
+    category = ?,
+    subcategory = ?,
+    version = version + 1,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = ? AND version = ? AND is_active = 1;
+
+-- name: DeactivateRecurrentIncome :execrows
+UPDATE recurrent_incomes
+SET is_active = 0,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = ? AND is_active = 1;
+
+-- name: GetActiveRecurrentIncomesForProcessing :many
+SELECT * FROM recurrent_incomes
+WHERE is_active = 1
+  AND start_date <= ?
+  AND (end_date IS NULL OR end_date >= ?)
+ORDER BY start_date ASC;
+
+-- name: UpdateRecurrentIncomeLastExecution :exec
+UPDATE recurrent_incomes
+SET last_execution_date = ?,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: DeleteAllRecurrentIncomes :exec
+DELETE FROM recurrent_incomes;
+
+-- Job queries
+-- name: CreateJobRun :one
+INSERT INTO jobs (job, source, attempt, status, started_at)
+VALUES (?, ?, ?, 'running', ?)
+RETURNING *;
+
+-- name: FinishJobRun :exec
+UPDATE jobs
+SET status = ?,
+    finished_at = ?,
+    result = ?,
+    error = ?
+WHERE id = ?;
+
+-- name: GetLatestJobRuns :many
+-- Latest run of each job
+SELECT * FROM jobs
+WHERE id IN (SELECT MAX(id) FROM jobs GROUP BY job)
+ORDER BY job;
+
+-- name: GetJobRuns :many
+SELECT * FROM jobs
+WHERE job = ?
+ORDER BY id DESC
+LIMIT ?;
+
+-- name: InterruptRunningJobs :execrows
+-- Runs left running by a process that stopped
+UPDATE jobs
+SET status = 'interrupted',
+    finished_at = ?
+WHERE status = 'running';
+
+-- name: PruneJobRuns :exec
+-- Deletes the runs of a job but the latest keep
+DELETE FROM jobs
+WHERE job = sqlc.arg(job)
+  AND id <= (SELECT id FROM jobs WHERE job = sqlc.arg(job) ORDER BY id DESC LIMIT 1 OFFSET sqlc.arg(keep));
+
+-- Sync status queries
+-- name: ListOpenSyncItems :many
+-- Returns the queue items not written yet, failed first, with the expense
+-- they refer to; deleted expenses use the data stored in the item.
+SELECT q.id, q.operation, q.expense_id, q.status, q.attempts,
+    CAST(COALESCE(q.last_error, '') AS TEXT) AS last_error,
+    q.next_retry_at, q.updated_at,
+    e.date AS expense_date,
+    CAST(COALESCE(e.description, q.expense_description, '') AS TEXT) AS description,
+    CAST(COALESCE(e.amount_cents, q.expense_amount_cents, 0) AS INTEGER) AS amount_cents
+FROM sync_queue q
+LEFT JOIN expenses e ON e.id = q.expense_id
+WHERE q.status != 'completed'
+ORDER BY CASE q.status WHEN 'failed' THEN 0 ELSE 1 END, q.id DESC
+LIMIT ?;
+
+-- name: RetrySyncItem :execrows
+-- Makes a failed or deferred item ready for the next poll, with its
+-- attempts reset.
+UPDATE sync_queue
+SET status = 'pending',
+    attempts = 0,
+    next_retry_at = NULL,
+    last_error = NULL,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = ? AND status IN ('pending', 'failed');
+
+-- Sync idempotency queries
+-- name: ListUnfinishedSyncAppends :many
+-- Append items not completed yet, for the startup reconciliation with the sheet
+SELECT * FROM sync_queue
+WHERE operation = 'sync'
+  AND status IN ('pending', 'processing')
+ORDER BY id;
+
+-- Integrity check queries
+-- name: ListExpensesWithUnknownCategory :many
+-- Expenses whose category pair is not among the known categories; none
+-- while no categories were loaded yet.
+SELECT e.id, e.date, e.description, e.primary_category, e.secondary_category
+FROM expenses e
+WHERE EXISTS (SELECT 1 FROM primary_categories)
+  AND NOT EXISTS (
+    SELECT 1 FROM secondary_categories s
+    JOIN primary_categories p ON p.id = s.primary_category_id
+    WHERE p.name = e.primary_category AND s.name = e.secondary_category
+  )
+ORDER BY e.date DESC, e.id DESC
+LIMIT ?;
+
+-- name: ListRecurrentsEndingBeforeStart :many
+-- Recurrent expenses and incomes whose end date comes before the start.
+SELECT 'expense' AS kind, id, description,
+    CAST(date(start_date) AS TEXT) AS start_date, CAST(date(end_date) AS TEXT) AS end_date
+FROM recurrent_expenses
+WHERE end_date IS NOT NULL AND date(end_date) < date(start_date)
+UNION ALL
+SELECT 'income' AS kind, id, description,
+    CAST(date(start_date) AS TEXT) AS start_date, CAST(date(end_date) AS TEXT) AS end_date
+FROM recurrent_incomes
+WHERE end_date IS NOT NULL AND date(end_date) < date(start_date)
+ORDER BY kind, id;
+
+-- name: ListNonPositiveAmounts :many
+-- Amounts that must be positive but are not, left by databases older
+-- than the CHECK constraints or written with them disabled.
+SELECT 'expenses' AS table_name, id, amount_cents FROM expenses WHERE amount_cents <= 0
+UNION ALL
+SELECT 'incomes', id, amount_cents FROM incomes WHERE amount_cents <= 0
+UNION ALL
+SELECT 'recurrent_expenses', id, amount_cents FROM recurrent_expenses WHERE amount_cents <= 0
+UNION ALL
+SELECT 'recurrent_incomes', id, amount_cents FROM recurrent_incomes WHERE amount_cents <= 0
+ORDER BY table_name, id;
+
+-- name: ListOrphanReceipts :many
+-- Receipts of expenses that no longer exist.
+SELECT r.expense_id, r.blob_key, r.filename
+FROM receipts r
+WHERE NOT EXISTS (SELECT 1 FROM expenses e WHERE e.id = r.expense_id)
+ORDER BY r.expense_id;
+
+-- name: ListExpenseSyncFlags :many
+-- Sync flag of the cleared expenses in [from_date, to_date), and whether
+-- a queue item is still open for them.
+SELECT e.id, e.date, e.description, e.sync_status,
+    EXISTS (
+        SELECT 1 FROM sync_queue q
+        WHERE q.expense_id = e.id AND q.status != 'completed'
+    ) AS queued
+FROM expenses e
+WHERE date(e.date) >= date(sqlc.arg(from_date)) AND date(e.date) < date(sqlc.arg(to_date))
+  AND e.status = 'cleared'
+ORDER BY e.id;
+
+-- Sheet pull queries
+-- name: CountOpenSyncItemsForExpense :one
+-- Queue items of an expense not written to the sheet yet.
+SELECT COUNT(*) FROM sync_queue
+WHERE expense_id = ? AND status != 'completed';
+
+-- name: UpdateExpenseFromSheet :execrows
+-- Stores the values of a row edited in Google Sheets. The sync status is
+-- kept: the sheet already holds them.
+UPDATE expenses
+SET date = date(?),
+    description = ?,
+    amount_cents = ?,
+    primary_category = ?,
+    secondary_category = ?,
+    version = version + 1
+WHERE id = ? AND deleted_at IS NULL;
+
+-- Category cleanup queries
+-- name: ListUnusedCategories :many
+-- Categories no expense or recurrent expense uses; a primary category
+-- comes with an empty secondary_name.
+SELECT pc.name AS primary_name, sc.name AS secondary_name, sc.archived
+FROM secondary_categories sc
+JOIN primary_categories pc ON pc.id = sc.primary_category_id
+WHERE NOT EXISTS (
+    SELECT 1 FROM expenses e
+    WHERE e.primary_category = pc.name AND e.secondary_category = sc.name
+  )
+  AND NOT EXISTS (
+    SELECT 1 FROM recurrent_expenses r
+    WHERE r.primary_category = pc.name AND r.secondary_category = sc.name
+  )
+UNION ALL
+SELECT pc.name AS primary_name, '' AS secondary_name, pc.archived
+FROM primary_categories pc
+WHERE NOT EXISTS (SELECT 1 FROM expenses e WHERE e.primary_category = pc.name)
+  AND NOT EXISTS (SELECT 1 FROM recurrent_expenses r WHERE r.primary_category = pc.name)
+ORDER BY primary_name, secondary_name;
+
+-- name: ListOrphanCategoryPairs :many
+-- Category pairs used by expenses or recurrent expenses that are not among
+-- the categories, e.g. left by a removed or renamed primary category, with
+-- the first primary category holding a secondary of the same name.
+SELECT u.primary_category, u.secondary_category,
+    CAST(SUM(u.expense) AS INTEGER) AS expense_count,
+    CAST(SUM(u.recurrent) AS INTEGER) AS recurrent_count,
+    COALESCE((
+        SELECT p.name FROM secondary_categories s
+        JOIN primary_categories p ON p.id = s.primary_category_id
+        WHERE s.name = u.secondary_category
+        ORDER BY p.name
+        LIMIT 1
+    ), '') AS suggested_primary
+FROM (
+    SELECT primary_category, secondary_category, 1 AS expense, 0 AS recurrent FROM expenses
+    UNION ALL
+    SELECT primary_category, secondary_category, 0 AS expense, 1 AS recurrent FROM recurrent_expenses
+) u
+WHERE EXISTS (SELECT 1 FROM primary_categories)
+  AND NOT EXISTS (
+    SELECT 1 FROM secondary_categories s
+    JOIN primary_categories p ON p.id = s.primary_category_id
+    WHERE p.name = u.primary_category AND s.name = u.secondary_category
+  )
+GROUP BY u.primary_category, u.secondary_category
+ORDER BY expense_count DESC, u.primary_category, u.secondary_category;
+
+-- name: ListDetachedSecondaryCategories :many
+-- Secondary categories whose primary category no longer exists.
+SELECT sc.id, sc.name
+FROM secondary_categories sc
+WHERE NOT EXISTS (SELECT 1 FROM primary_categories pc WHERE pc.id = sc.primary_category_id)
+ORDER BY sc.name, sc.id;
+
+-- name: GetCategoryPairByID :one
+-- Names of a secondary category and of its primary category.
+SELECT pc.name AS primary_name, sc.name AS secondary_name
+FROM secondary_categories sc
+JOIN primary_categories pc ON pc.id = sc.primary_category_id
+WHERE sc.id = ?;
+
+-- name: DeleteDetachedSecondaryCategory :one
+-- Removes a secondary category whose primary category no longer exists.
+DELETE FROM secondary_categories
+WHERE id = ?
+  AND NOT EXISTS (SELECT 1 FROM primary_categories pc WHERE pc.id = secondary_categories.primary_category_id)
+RETURNING *;
+
+-- name: ListActiveCategoryPairs :many
+-- Secondary categories not archived, with their primary category.
+SELECT sc.id, pc.name AS primary_name, sc.name AS secondary_name
+FROM secondary_categories sc
+JOIN primary_categories pc ON pc.id = sc.primary_category_id
+WHERE sc.archived = 0 AND pc.archived = 0
+ORDER BY pc.name, sc.name;
+
+-- Import batch queries
+-- name: CreateImportBatch :one
+INSERT INTO import_batches (filename) VALUES (?)
+RETURNING *;
+
+-- name: AddImportBatchExpense :exec
+INSERT INTO import_batch_expenses (batch_id, expense_id) VALUES (?, ?);
+
+-- name: SetImportBatchExpenses :exec
+UPDATE import_batches SET expenses = ? WHERE id = ?;
+
+-- name: GetImportBatch :one
+SELECT * FROM import_batches WHERE id = ?;
+
+-- name: ListImportBatches :many
+-- Latest imports, newest first, with how many of their expenses still exist.
+SELECT b.id, b.filename, b.expenses, b.created_at, b.rolled_back_at,
+    (SELECT COUNT(*) FROM import_batch_expenses l
+     JOIN expenses e ON e.id = l.expense_id
+     WHERE l.batch_id = b.id) AS remaining
+FROM import_batches b
+ORDER BY b.id DESC
+LIMIT ?;
+
+-- name: ListImportBatchExpenses :many
+-- Expenses created by an import that still exist.
+SELECT e.* FROM expenses e
+JOIN import_batch_expenses l ON l.expense_id = e.id
+WHERE l.batch_id = ?
+ORDER BY e.id;
+
+-- name: MarkImportBatchRolledBack :execrows
+UPDATE import_batches SET rolled_back_at = CURRENT_TIMESTAMP
+WHERE id = ? AND rolled_back_at IS NULL;
+
+-- name: CancelPendingSyncItems :execrows
+-- Completes the sync items of an expense not picked up yet, for an
+-- expense removed before reaching the sheet.
+UPDATE sync_queue
+SET status = 'completed', processed_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP,
+    last_error = 'cancelled: expense deleted before sync'
+WHERE expense_id = ? AND operation = 'sync' AND status IN ('pending', 'failed');
+
+-- Audit log
+-- name: CreateAuditEntry :exec
+-- Records a change to an expense, income, recurrent or category.
+INSERT INTO audit_log (entity, entity_id, action, changed_by, before_json, after_json)
+VALUES (?, ?, ?, ?, ?, ?);
+
+-- name: ListAuditEntries :many
+-- Returns the changes made in [changed_from, changed_until), newest first,
+-- optionally of one entity type (empty for all) and one row (0 for all).
+SELECT * FROM audit_log
+WHERE changed_at >= sqlc.arg(changed_from)
+  AND changed_at < sqlc.arg(changed_until)
+  AND (sqlc.arg(entity) = '' OR entity = sqlc.arg(entity))
+  AND (sqlc.arg(entity_id) = 0 OR entity_id = sqlc.arg(entity_id))
+ORDER BY id DESC
+LIMIT sqlc.arg(max_entries);
+
+-- name: DeleteAllAuditLog :exec
+DELETE FROM audit_log;
+
+-- name: PruneAuditLog :execrows
+-- Removes the changes made before the specified timestamp.
+DELETE FROM audit_log
+WHERE changed_at < ?;
+
+-- Monthly aggregates
+-- name: SumAggregatesByCategory :many
+-- Totals per category of one kind of entry in the months between two
+-- periods (year * 100 + month), both included, largest first.
+SELECT category, CAST(SUM(total_cents) AS INTEGER) AS total_amount
+FROM monthly_aggregates
+WHERE kind = sqlc.arg(kind)
+  AND year * 100 + month BETWEEN sqlc.arg(from_period) AND sqlc.arg(to_period)
+GROUP BY category
+ORDER BY total_amount DESC;
+
+-- name: GetYearAggregates :many
+-- Totals per category and month of one kind of entry in a year.
+SELECT category, month, total_cents
+FROM monthly_aggregates
+WHERE kind = ? AND year = ?
+ORDER BY category, month;
+
+-- name: CountStaleAggregates :one
+-- Months and categories whose aggregate differs from their entries,
+-- missing and leftover rows included.
+SELECT COUNT(*) AS stale FROM (
+    SELECT kind, year, month, category FROM (
+        SELECT kind, year, month, category, total_cents, entries FROM monthly_aggregates
+        EXCEPT
+        SELECT kind, year, month, category, total_cents, entries FROM monthly_aggregates_fresh
+    )
+    UNION
+    SELECT kind, year, month, category FROM (
+        SELECT kind, year, month, category, total_cents, entries FROM monthly_aggregates_fresh
+        EXCEPT
+        SELECT kind, year, month, category, total_cents, entries FROM monthly_aggregates
+    )
+);
+
+-- name: ClearMonthlyAggregates :exec
+DELETE FROM monthly_aggregates;
+
+-- name: FillMonthlyAggregates :exec
+-- Recomputes the aggregates from the entries; run after
+-- ClearMonthlyAggregates.
+INSERT INTO monthly_aggregates (kind, year, month, category, total_cents, entries)
+SELECT kind, year, month, category, total_cents, entries FROM monthly_aggregates_fresh;
+
+-- Accounts and the transfers between them
+-- name: CreateAccount :one
+INSERT INTO accounts (name, kind, opening_balance_cents)
+VALUES (?, ?, ?)
+RETURNING *;
+
+-- name: GetAccount :one
+SELECT * FROM accounts WHERE id = ?;
+
+-- name: ListAccounts :many
+SELECT * FROM accounts ORDER BY name;
+
+-- name: DeleteAccount :execrows
+DELETE FROM accounts WHERE id = ?;
+
+-- name: DeleteAllAccounts :exec
+DELETE FROM accounts;
+
+-- name: ListAccountBalances :many
+-- What moved through each account: live incomes, live settled expenses
+-- (card holds wait until they clear) and transfers.
+SELECT a.id, a.name, a.kind, a.opening_balance_cents,
+       CAST(COALESCE((SELECT SUM(amount_cents) FROM incomes
+                      WHERE account_id = a.id AND deleted_at IS NULL), 0) AS INTEGER) AS incomes_cents,
+       CAST(COALESCE((SELECT SUM(amount_cents) FROM expenses
+                      WHERE account_id = a.id AND deleted_at IS NULL AND status = 'cleared'), 0) AS INTEGER) AS expenses_cents,
+       CAST(COALESCE((SELECT SUM(amount_cents) FROM account_transfers
+                      WHERE to_account_id = a.id), 0) AS INTEGER) AS transfers_in_cents,
+       CAST(COALESCE((SELECT SUM(amount_cents) FROM account_transfers
+                      WHERE from_account_id = a.id), 0) AS INTEGER) AS transfers_out_cents
+FROM accounts a
+ORDER BY a.name;
+
+-- name: CreateAccountTransfer :one
+INSERT INTO account_transfers (date, from_account_id, to_account_id, amount_cents, description)
+VALUES (date(?), ?, ?, ?, ?)
+RETURNING *;
+
+-- name: ListAccountTransfers :many
+SELECT * FROM account_transfers
+ORDER BY date DESC, id DESC
+LIMIT ?;
+
+-- name: DeleteAccountTransfer :execrows
+DELETE FROM account_transfers WHERE id = ?;
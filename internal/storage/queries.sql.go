@@ -11,252 +11,183 @@ import (
 	"time"
 )
 
-const cleanupCompletedSyncs = `-- name: CleanupCompletedSyncs :exec
-DELETE FROM sync_queue
-WHERE status = 'completed'
-  AND processed_at < ?
+const addExpenseTag = `-- name: AddExpenseTag :exec
+INSERT OR IGNORE INTO expense_tags (expense_id, tag_id) VALUES (?, ?)
 `
 
-// Removes completed items older than the specified timestamp.
-func (q *Queries) CleanupCompletedSyncs(ctx context.Context, processedAt interface{}) error {
-	_, err := q.db.ExecContext(ctx, cleanupCompletedSyncs, processedAt)
+type AddExpenseTagParams struct {
+	ExpenseID int64 `db:"expense_id" json:"expense_id"`
+	TagID     int64 `db:"tag_id" json:"tag_id"`
+}
+
+func (q *Queries) AddExpenseTag(ctx context.Context, arg AddExpenseTagParams) error {
+	_, err := q.db.ExecContext(ctx, addExpenseTag, arg.ExpenseID, arg.TagID)
 	return err
 }
 
-const createExpense = `-- name: CreateExpense :one
-INSERT INTO expenses (date, description, amount_cents, primary_category, secondary_category)
-VALUES (date(?), ?, ?, ?, ?)
-RETURNING id, date, description, amount_cents, primary_category, secondary_category, version, created_at, synced_at, sync_status
+const addImportBatchExpense = `-- name: AddImportBatchExpense :exec
+INSERT INTO import_batch_expenses (batch_id, expense_id) VALUES (?, ?)
 `
 
-type CreateExpenseParams struct {
-	Date              interface{} `db:"date" json:"date"`
-	Description       string      `db:"description" json:"description"`
-	AmountCents       int64       `db:"amount_cents" json:"amount_cents"`
-	PrimaryCategory   string      `db:"primary_category" json:"primary_category"`
-	SecondaryCategory string      `db:"secondary_category" json:"secondary_category"`
+type AddImportBatchExpenseParams struct {
+	BatchID   int64 `db:"batch_id" json:"batch_id"`
+	ExpenseID int64 `db:"expense_id" json:"expense_id"`
 }
 
-func (q *Queries) CreateExpense(ctx context.Context, arg CreateExpenseParams) (Expense, error) {
-	row := q.db.QueryRowContext(ctx, createExpense,
-		arg.Date,
-		arg.Description,
-		arg.AmountCents,
-		arg.PrimaryCategory,
-		arg.SecondaryCategory,
-	)
-	var i Expense
-	err := row.Scan(
-		&i.ID,
-		&i.Date,
-		&i.Description,
-		&i.AmountCents,
-		&i.PrimaryCategory,
-		&i.SecondaryCategory,
-		&i.Version,
-		&i.CreatedAt,
-		&i.SyncedAt,
-		&i.SyncStatus,
-	)
-	return i, err
+func (q *Queries) AddImportBatchExpense(ctx context.Context, arg AddImportBatchExpenseParams) error {
+	_, err := q.db.ExecContext(ctx, addImportBatchExpense, arg.BatchID, arg.ExpenseID)
+	return err
 }
 
-const createIncome = `-- name: CreateIncome :one
-INSERT INTO incomes (date, description, amount_cents, category)
-VALUES (date(?), ?, ?, ?)
-RETURNING id, date, description, amount_cents, category, version, created_at, synced_at, sync_status
+const addIncomeTag = `-- name: AddIncomeTag :exec
+INSERT OR IGNORE INTO income_tags (income_id, tag_id) VALUES (?, ?)
 `
 
-type CreateIncomeParams struct {
-	Date        interface{} `db:"date" json:"date"`
-	Description string      `db:"description" json:"description"`
-	AmountCents int64       `db:"amount_cents" json:"amount_cents"`
-	Category    string      `db:"category" json:"category"`
+type AddIncomeTagParams struct {
+	IncomeID int64 `db:"income_id" json:"income_id"`
+	TagID    int64 `db:"tag_id" json:"tag_id"`
 }
 
-// Income queries
-func (q *Queries) CreateIncome(ctx context.Context, arg CreateIncomeParams) (Income, error) {
-	row := q.db.QueryRowContext(ctx, createIncome,
-		arg.Date,
-		arg.Description,
-		arg.AmountCents,
-		arg.Category,
-	)
-	var i Income
-	err := row.Scan(
-		&i.ID,
-		&i.Date,
-		&i.Description,
-		&i.AmountCents,
-		&i.Category,
-		&i.Version,
-		&i.CreatedAt,
-		&i.SyncedAt,
-		&i.SyncStatus,
-	)
-	return i, err
+func (q *Queries) AddIncomeTag(ctx context.Context, arg AddIncomeTagParams) error {
+	_, err := q.db.ExecContext(ctx, addIncomeTag, arg.IncomeID, arg.TagID)
+	return err
 }
 
-const createPrimaryCategory = `-- name: CreatePrimaryCategory :one
-INSERT INTO primary_categories (name)
-VALUES (?)
-RETURNING id, name, created_at
+const advanceSheetHistoryImport = `-- name: AdvanceSheetHistoryImport :exec
+UPDATE sheet_history_imports SET next_row = ?, expenses = expenses + ? WHERE year = ?
 `
 
-func (q *Queries) CreatePrimaryCategory(ctx context.Context, name string) (PrimaryCategory, error) {
-	row := q.db.QueryRowContext(ctx, createPrimaryCategory, name)
-	var i PrimaryCategory
-	err := row.Scan(&i.ID, &i.Name, &i.CreatedAt)
-	return i, err
+type AdvanceSheetHistoryImportParams struct {
+	NextRow  int64 `db:"next_row" json:"next_row"`
+	Expenses int64 `db:"expenses" json:"expenses"`
+	Year     int64 `db:"year" json:"year"`
 }
 
-const createRecurrentExpense = `-- name: CreateRecurrentExpense :one
-INSERT INTO recurrent_expenses (
-    start_date, end_date, repetition_type, description, 
-    amount_cents, primary_category, secondary_category
-)
-VALUES (?, ?, ?, ?, ?, ?, ?)
-RETURNING id, start_date, end_date, repetition_type, description, amount_cents, primary_category, secondary_category, is_active, last_execution_date, created_at, updated_at
-`
-
-type CreateRecurrentExpenseParams struct {
-	StartDate         time.Time   `db:"start_date" json:"start_date"`
-	EndDate           interface{} `db:"end_date" json:"end_date"`
-	RepetitionType    string      `db:"repetition_type" json:"repetition_type"`
-	Description       string      `db:"description" json:"description"`
-	AmountCents       int64       `db:"amount_cents" json:"amount_cents"`
-	PrimaryCategory   string      `db:"primary_category" json:"primary_category"`
-	SecondaryCategory string      `db:"secondary_category" json:"secondary_category"`
+func (q *Queries) AdvanceSheetHistoryImport(ctx context.Context, arg AdvanceSheetHistoryImportParams) error {
+	_, err := q.db.ExecContext(ctx, advanceSheetHistoryImport, arg.NextRow, arg.Expenses, arg.Year)
+	return err
 }
 
-// Recurrent Expenses queries
-func (q *Queries) CreateRecurrentExpense(ctx context.Context, arg CreateRecurrentExpenseParams) (RecurrentExpense, error) {
-	row := q.db.QueryRowContext(ctx, createRecurrentExpense,
-		arg.StartDate,
-		arg.EndDate,
-		arg.RepetitionType,
-		arg.Description,
-		arg.AmountCents,
-		arg.PrimaryCategory,
-		arg.SecondaryCategory,
-	)
-	var i RecurrentExpense
-	err := row.Scan(
-		&i.ID,
-		&i.StartDate,
-		&i.EndDate,
-		&i.RepetitionType,
-		&i.Description,
-		&i.AmountCents,
-		&i.PrimaryCategory,
-		&i.SecondaryCategory,
-		&i.IsActive,
-		&i.LastExecutionDate,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-	)
-	return i, err
+const cancelPendingSyncItems = `-- name: CancelPendingSyncItems :execrows
+UPDATE sync_queue
+SET status = 'completed', processed_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP,
+    last_error = 'cancelled: expense deleted before sync'
+WHERE expense_id = ? AND operation = 'sync' AND status IN ('pending', 'failed')
+`
+
+// Completes the sync items of an expense not picked up yet, for an
+// expense removed before reaching the sheet.
+func (q *Queries) CancelPendingSyncItems(ctx context.Context, expenseID int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, cancelPendingSyncItems, expenseID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
-const createSecondaryCategory = `-- name: CreateSecondaryCategory :one
-INSERT INTO secondary_categories (name, primary_category_id)
-VALUES (?, ?)
-RETURNING id, name, primary_category_id, created_at
+const cleanupCompletedSyncs = `-- name: CleanupCompletedSyncs :exec
+DELETE FROM sync_queue
+WHERE status = 'completed'
+  AND processed_at < ?
 `
 
-type CreateSecondaryCategoryParams struct {
-	Name              string `db:"name" json:"name"`
-	PrimaryCategoryID int64  `db:"primary_category_id" json:"primary_category_id"`
+// Removes completed items older than the specified timestamp.
+func (q *Queries) CleanupCompletedSyncs(ctx context.Context, processedAt interface{}) error {
+	_, err := q.db.ExecContext(ctx, cleanupCompletedSyncs, processedAt)
+	return err
 }
 
-func (q *Queries) CreateSecondaryCategory(ctx context.Context, arg CreateSecondaryCategoryParams) (SecondaryCategory, error) {
-	row := q.db.QueryRowContext(ctx, createSecondaryCategory, arg.Name, arg.PrimaryCategoryID)
-	var i SecondaryCategory
-	err := row.Scan(
-		&i.ID,
-		&i.Name,
-		&i.PrimaryCategoryID,
-		&i.CreatedAt,
-	)
-	return i, err
+const cleanupSyncErrors = `-- name: CleanupSyncErrors :exec
+DELETE FROM sync_errors
+WHERE created_at < ?
+`
+
+// Removes failed attempts older than the specified timestamp.
+func (q *Queries) CleanupSyncErrors(ctx context.Context, createdAt time.Time) error {
+	_, err := q.db.ExecContext(ctx, cleanupSyncErrors, createdAt)
+	return err
 }
 
-const deactivateRecurrentExpense = `-- name: DeactivateRecurrentExpense :exec
-UPDATE recurrent_expenses
-SET is_active = 0,
-    updated_at = CURRENT_TIMESTAMP
-WHERE id = ?
+const clearMonthlyAggregates = `-- name: ClearMonthlyAggregates :exec
+DELETE FROM monthly_aggregates
 `
 
-func (q *Queries) DeactivateRecurrentExpense(ctx context.Context, id int64) error {
-	_, err := q.db.ExecContext(ctx, deactivateRecurrentExpense, id)
+func (q *Queries) ClearMonthlyAggregates(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, clearMonthlyAggregates)
 	return err
 }
 
-const deletePrimaryCategory = `-- name: DeletePrimaryCategory :exec
-DELETE FROM primary_categories WHERE name = ?
+const closeMonth = `-- name: CloseMonth :exec
+INSERT INTO month_reviews (period) VALUES (?)
+ON CONFLICT (period) DO NOTHING
 `
 
-func (q *Queries) DeletePrimaryCategory(ctx context.Context, name string) error {
-	_, err := q.db.ExecContext(ctx, deletePrimaryCategory, name)
+func (q *Queries) CloseMonth(ctx context.Context, period string) error {
+	_, err := q.db.ExecContext(ctx, closeMonth, period)
 	return err
 }
 
-const deleteRecurrentExpense = `-- name: DeleteRecurrentExpense :exec
-DELETE FROM recurrent_expenses
-WHERE id = ?
+const completeSheetHistoryImport = `-- name: CompleteSheetHistoryImport :exec
+UPDATE sheet_history_imports SET completed_at = CURRENT_TIMESTAMP, last_error = '' WHERE year = ?
 `
 
-func (q *Queries) DeleteRecurrentExpense(ctx context.Context, id int64) error {
-	_, err := q.db.ExecContext(ctx, deleteRecurrentExpense, id)
+func (q *Queries) CompleteSheetHistoryImport(ctx context.Context, year int64) error {
+	_, err := q.db.ExecContext(ctx, completeSheetHistoryImport, year)
 	return err
 }
 
-const deleteSecondaryCategory = `-- name: DeleteSecondaryCategory :exec
-DELETE FROM secondary_categories WHERE name = ?
+const consumeDailyExpenses = `-- name: ConsumeDailyExpenses :one
+
+INSERT INTO user_daily_usage (user_id, day, expenses)
+VALUES (?1, ?2, ?3)
+ON CONFLICT (user_id, day) DO UPDATE SET expenses = expenses + excluded.expenses
+WHERE ?4 = 0 OR expenses + excluded.expenses <= ?4
+RETURNING expenses
 `
 
-func (q *Queries) DeleteSecondaryCategory(ctx context.Context, name string) error {
-	_, err := q.db.ExecContext(ctx, deleteSecondaryCategory, name)
-	return err
+type ConsumeDailyExpensesParams struct {
+	UserID      int64       `db:"user_id" json:"user_id"`
+	Day         string      `db:"day" json:"day"`
+	Count       int64       `db:"count" json:"count"`
+	MaxExpenses interface{} `db:"max_expenses" json:"max_expenses"`
 }
 
-const dequeueSyncBatch = `-- name: DequeueSyncBatch :many
-SELECT id, operation, expense_id, expense_day, expense_month, expense_description, expense_amount_cents, expense_primary, expense_secondary, status, attempts, max_attempts, last_error, created_at, updated_at, processed_at, next_retry_at FROM sync_queue
-WHERE status = 'pending'
-  AND (next_retry_at IS NULL OR next_retry_at <= CURRENT_TIMESTAMP)
-ORDER BY created_at ASC
-LIMIT ?
+// Adds count expenses to the day of a member unless that goes over
+// max_expenses (0 for no limit); no row comes back when it would.
+func (q *Queries) ConsumeDailyExpenses(ctx context.Context, arg ConsumeDailyExpensesParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, consumeDailyExpenses,
+		arg.UserID,
+		arg.Day,
+		arg.Count,
+		arg.MaxExpenses,
+	)
+	var expenses int64
+	err := row.Scan(&expenses)
+	return expenses, err
+}
+
+const countClassifierFeedback = `-- name: CountClassifierFeedback :many
+SELECT verdict, COUNT(*) AS total FROM classifier_feedback
+GROUP BY verdict
 `
 
-// Fetches a batch of pending items ready for processing.
-func (q *Queries) DequeueSyncBatch(ctx context.Context, limit int64) ([]SyncQueue, error) {
-	rows, err := q.db.QueryContext(ctx, dequeueSyncBatch, limit)
+type CountClassifierFeedbackRow struct {
+	Verdict string `db:"verdict" json:"verdict"`
+	Total   int64  `db:"total" json:"total"`
+}
+
+func (q *Queries) CountClassifierFeedback(ctx context.Context) ([]CountClassifierFeedbackRow, error) {
+	rows, err := q.db.QueryContext(ctx, countClassifierFeedback)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []SyncQueue
+	var items []CountClassifierFeedbackRow
 	for rows.Next() {
-		var i SyncQueue
+		var i CountClassifierFeedbackRow
 		if err := rows.Scan(
-			&i.ID,
-			&i.Operation,
-			&i.ExpenseID,
-			&i.ExpenseDay,
-			&i.ExpenseMonth,
-			&i.ExpenseDescription,
-			&i.ExpenseAmountCents,
-			&i.ExpensePrimary,
-			&i.ExpenseSecondary,
-			&i.Status,
-			&i.Attempts,
-			&i.MaxAttempts,
-			&i.LastError,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-			&i.ProcessedAt,
-			&i.NextRetryAt,
+			&i.Verdict,
+			&i.Total,
 		); err != nil {
 			return nil, err
 		}
@@ -271,131 +202,29 @@ func (q *Queries) DequeueSyncBatch(ctx context.Context, limit int64) ([]SyncQueu
 	return items, nil
 }
 
-const enqueueDelete = `-- name: EnqueueDelete :one
-INSERT INTO sync_queue (
-    operation, expense_id, status,
-    expense_day, expense_month, expense_description,
-    expense_amount_cents, expense_primary, expense_secondary,
-    created_at, updated_at
-)
-VALUES ('delete', ?, 'pending', ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
-RETURNING id, operation, expense_id, expense_day, expense_month, expense_description, expense_amount_cents, expense_primary, expense_secondary, status, attempts, max_attempts, last_error, created_at, updated_at, processed_at, next_retry_at
+const countExpensesByWorkflowState = `-- name: CountExpensesByWorkflowState :many
+SELECT CAST(COALESCE(w.state, 'draft') AS TEXT) AS state, COUNT(*) AS count
+FROM expenses e
+LEFT JOIN expense_workflow w ON w.expense_id = e.id
+WHERE e.deleted_at IS NULL
+GROUP BY 1
 `
 
-type EnqueueDeleteParams struct {
-	ExpenseID          int64       `db:"expense_id" json:"expense_id"`
-	ExpenseDay         interface{} `db:"expense_day" json:"expense_day"`
-	ExpenseMonth       interface{} `db:"expense_month" json:"expense_month"`
-	ExpenseDescription interface{} `db:"expense_description" json:"expense_description"`
-	ExpenseAmountCents interface{} `db:"expense_amount_cents" json:"expense_amount_cents"`
-	ExpensePrimary     interface{} `db:"expense_primary" json:"expense_primary"`
-	ExpenseSecondary   interface{} `db:"expense_secondary" json:"expense_secondary"`
-}
-
-// Enqueues a delete operation with full expense data.
-func (q *Queries) EnqueueDelete(ctx context.Context, arg EnqueueDeleteParams) (SyncQueue, error) {
-	row := q.db.QueryRowContext(ctx, enqueueDelete,
-		arg.ExpenseID,
-		arg.ExpenseDay,
-		arg.ExpenseMonth,
-		arg.ExpenseDescription,
-		arg.ExpenseAmountCents,
-		arg.ExpensePrimary,
-		arg.ExpenseSecondary,
-	)
-	var i SyncQueue
-	err := row.Scan(
-		&i.ID,
-		&i.Operation,
-		&i.ExpenseID,
-		&i.ExpenseDay,
-		&i.ExpenseMonth,
-		&i.ExpenseDescription,
-		&i.ExpenseAmountCents,
-		&i.ExpensePrimary,
-		&i.ExpenseSecondary,
-		&i.Status,
-		&i.Attempts,
-		&i.MaxAttempts,
-		&i.LastError,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-		&i.ProcessedAt,
-		&i.NextRetryAt,
-	)
-	return i, err
+type CountExpensesByWorkflowStateRow struct {
+	State string `db:"state" json:"state"`
+	Count int64  `db:"count" json:"count"`
 }
 
-const enqueueSync = `-- name: EnqueueSync :one
-
-INSERT INTO sync_queue (operation, expense_id, status, created_at, updated_at)
-VALUES ('sync', ?, 'pending', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
-RETURNING id, operation, expense_id, expense_day, expense_month, expense_description, expense_amount_cents, expense_primary, expense_secondary, status, attempts, max_attempts, last_error, created_at, updated_at, processed_at, next_retry_at
-`
-
-// Sync Queue queries
-// Enqueues a sync operation for an expense.
-func (q *Queries) EnqueueSync(ctx context.Context, expenseID int64) (SyncQueue, error) {
-	row := q.db.QueryRowContext(ctx, enqueueSync, expenseID)
-	var i SyncQueue
-	err := row.Scan(
-		&i.ID,
-		&i.Operation,
-		&i.ExpenseID,
-		&i.ExpenseDay,
-		&i.ExpenseMonth,
-		&i.ExpenseDescription,
-		&i.ExpenseAmountCents,
-		&i.ExpensePrimary,
-		&i.ExpenseSecondary,
-		&i.Status,
-		&i.Attempts,
-		&i.MaxAttempts,
-		&i.LastError,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-		&i.ProcessedAt,
-		&i.NextRetryAt,
-	)
-	return i, err
-}
-
-const getActiveRecurrentExpensesByDate = `-- name: GetActiveRecurrentExpensesByDate :many
-SELECT id, start_date, end_date, repetition_type, description, amount_cents, primary_category, secondary_category, is_active, last_execution_date, created_at, updated_at FROM recurrent_expenses
-WHERE is_active = 1
-  AND start_date <= ?
-  AND (end_date IS NULL OR end_date >= ?)
-ORDER BY start_date DESC
-`
-
-type GetActiveRecurrentExpensesByDateParams struct {
-	StartDate time.Time   `db:"start_date" json:"start_date"`
-	EndDate   interface{} `db:"end_date" json:"end_date"`
-}
-
-func (q *Queries) GetActiveRecurrentExpensesByDate(ctx context.Context, arg GetActiveRecurrentExpensesByDateParams) ([]RecurrentExpense, error) {
-	rows, err := q.db.QueryContext(ctx, getActiveRecurrentExpensesByDate, arg.StartDate, arg.EndDate)
+func (q *Queries) CountExpensesByWorkflowState(ctx context.Context) ([]CountExpensesByWorkflowStateRow, error) {
+	rows, err := q.db.QueryContext(ctx, countExpensesByWorkflowState)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []RecurrentExpense
+	var items []CountExpensesByWorkflowStateRow
 	for rows.Next() {
-		var i RecurrentExpense
-		if err := rows.Scan(
-			&i.ID,
-			&i.StartDate,
-			&i.EndDate,
-			&i.RepetitionType,
-			&i.Description,
-			&i.AmountCents,
-			&i.PrimaryCategory,
-			&i.SecondaryCategory,
-			&i.IsActive,
-			&i.LastExecutionDate,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-		); err != nil {
+		var i CountExpensesByWorkflowStateRow
+		if err := rows.Scan(&i.State, &i.Count); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -409,41 +238,113 @@ func (q *Queries) GetActiveRecurrentExpensesByDate(ctx context.Context, arg GetA
 	return items, nil
 }
 
-const getActiveRecurrentExpensesForProcessing = `-- name: GetActiveRecurrentExpensesForProcessing :many
-SELECT id, start_date, end_date, repetition_type, description, amount_cents, primary_category, secondary_category, is_active, last_execution_date, created_at, updated_at FROM recurrent_expenses
-WHERE is_active = 1
-  AND start_date <= ?
-  AND (end_date IS NULL OR end_date >= ?)
-ORDER BY start_date ASC
+const countExpensesInYear = `-- name: CountExpensesInYear :one
+SELECT COUNT(*) FROM expenses WHERE strftime('%Y', date) = printf('%04d', ?)
 `
 
-type GetActiveRecurrentExpensesForProcessingParams struct {
-	StartDate time.Time   `db:"start_date" json:"start_date"`
-	EndDate   interface{} `db:"end_date" json:"end_date"`
+func (q *Queries) CountExpensesInYear(ctx context.Context, printf interface{}) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countExpensesInYear, printf)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
 }
 
-func (q *Queries) GetActiveRecurrentExpensesForProcessing(ctx context.Context, arg GetActiveRecurrentExpensesForProcessingParams) ([]RecurrentExpense, error) {
-	rows, err := q.db.QueryContext(ctx, getActiveRecurrentExpensesForProcessing, arg.StartDate, arg.EndDate)
+const countOpenSyncItemsForExpense = `-- name: CountOpenSyncItemsForExpense :one
+SELECT COUNT(*) FROM sync_queue
+WHERE expense_id = ? AND status != 'completed'
+`
+
+// Queue items of an expense not written to the sheet yet.
+func (q *Queries) CountOpenSyncItemsForExpense(ctx context.Context, expenseID int64) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countOpenSyncItemsForExpense, expenseID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countPrimaryCategoryReferences = `-- name: CountPrimaryCategoryReferences :one
+SELECT
+  (SELECT COUNT(*) FROM expenses WHERE expenses.primary_category = ?1)
+  + (SELECT COUNT(*) FROM recurrent_expenses WHERE recurrent_expenses.primary_category = ?1) AS total
+`
+
+func (q *Queries) CountPrimaryCategoryReferences(ctx context.Context, primaryCategory string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countPrimaryCategoryReferences, primaryCategory)
+	var total int64
+	err := row.Scan(&total)
+	return total, err
+}
+
+const countSecondaryCategoryReferences = `-- name: CountSecondaryCategoryReferences :one
+SELECT
+  (SELECT COUNT(*) FROM expenses
+   WHERE expenses.primary_category = ?1 AND expenses.secondary_category = ?2)
+  + (SELECT COUNT(*) FROM recurrent_expenses
+   WHERE recurrent_expenses.primary_category = ?1 AND recurrent_expenses.secondary_category = ?2) AS total
+`
+
+type CountSecondaryCategoryReferencesParams struct {
+	PrimaryCategory   string `db:"primary_category" json:"primary_category"`
+	SecondaryCategory string `db:"secondary_category" json:"secondary_category"`
+}
+
+func (q *Queries) CountSecondaryCategoryReferences(ctx context.Context, arg CountSecondaryCategoryReferencesParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countSecondaryCategoryReferences, arg.PrimaryCategory, arg.SecondaryCategory)
+	var total int64
+	err := row.Scan(&total)
+	return total, err
+}
+
+const countStaleAggregates = `-- name: CountStaleAggregates :one
+
+SELECT COUNT(*) AS stale FROM (
+    SELECT kind, year, month, category FROM (
+        SELECT kind, year, month, category, total_cents, entries FROM monthly_aggregates
+        EXCEPT
+        SELECT kind, year, month, category, total_cents, entries FROM monthly_aggregates_fresh
+    )
+    UNION
+    SELECT kind, year, month, category FROM (
+        SELECT kind, year, month, category, total_cents, entries FROM monthly_aggregates_fresh
+        EXCEPT
+        SELECT kind, year, month, category, total_cents, entries FROM monthly_aggregates
+    )
+)
+`
+
+// Months and categories whose aggregate differs from their entries,
+// missing and leftover rows included.
+func (q *Queries) CountStaleAggregates(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countStaleAggregates)
+	var stale int64
+	err := row.Scan(&stale)
+	return stale, err
+}
+
+const countSyncErrorsByClass = `-- name: CountSyncErrorsByClass :many
+SELECT class, COUNT(*) AS count
+FROM sync_errors
+GROUP BY class
+ORDER BY class
+`
+
+type CountSyncErrorsByClassRow struct {
+	Class string `db:"class" json:"class"`
+	Count int64  `db:"count" json:"count"`
+}
+
+func (q *Queries) CountSyncErrorsByClass(ctx context.Context) ([]CountSyncErrorsByClassRow, error) {
+	rows, err := q.db.QueryContext(ctx, countSyncErrorsByClass)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []RecurrentExpense
+	var items []CountSyncErrorsByClassRow
 	for rows.Next() {
-		var i RecurrentExpense
+		var i CountSyncErrorsByClassRow
 		if err := rows.Scan(
-			&i.ID,
-			&i.StartDate,
-			&i.EndDate,
-			&i.RepetitionType,
-			&i.Description,
-			&i.AmountCents,
-			&i.PrimaryCategory,
-			&i.SecondaryCategory,
-			&i.IsActive,
-			&i.LastExecutionDate,
-			&i.CreatedAt,
-			&i.UpdatedAt,
+			&i.Class,
+			&i.Count,
 		); err != nil {
 			return nil, err
 		}
@@ -458,167 +359,7774 @@ func (q *Queries) GetActiveRecurrentExpensesForProcessing(ctx context.Context, a
 	return items, nil
 }
 
-const getAllCategoriesWithSubs = `-- name: GetAllCategoriesWithSubs :many
-SELECT pc.name as primary_name, sc.name as secondary_name
-FROM primary_categories pc
-LEFT JOIN secondary_categories sc ON sc.primary_category_id = pc.id
-ORDER BY pc.name ASC, sc.name ASC
+const createAccount = `-- name: CreateAccount :one
+INSERT INTO accounts (name, kind, opening_balance_cents)
+VALUES (?, ?, ?)
+RETURNING id, name, kind, opening_balance_cents, created_at
 `
 
-type GetAllCategoriesWithSubsRow struct {
-	PrimaryName   string         `db:"primary_name" json:"primary_name"`
-	SecondaryName sql.NullString `db:"secondary_name" json:"secondary_name"`
+type CreateAccountParams struct {
+	Name                string `db:"name" json:"name"`
+	Kind                string `db:"kind" json:"kind"`
+	OpeningBalanceCents int64  `db:"opening_balance_cents" json:"opening_balance_cents"`
 }
 
-func (q *Queries) GetAllCategoriesWithSubs(ctx context.Context) ([]GetAllCategoriesWithSubsRow, error) {
-	rows, err := q.db.QueryContext(ctx, getAllCategoriesWithSubs)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []GetAllCategoriesWithSubsRow
-	for rows.Next() {
-		var i GetAllCategoriesWithSubsRow
-		if err := rows.Scan(&i.PrimaryName, &i.SecondaryName); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+// Accounts and the transfers between them
+func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, createAccount, arg.Name, arg.Kind, arg.OpeningBalanceCents)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Kind,
+		&i.OpeningBalanceCents,
+		&i.CreatedAt,
+	)
+	return i, err
 }
 
-const getCategoriesOrderedByUsage = `-- name: GetCategoriesOrderedByUsage :many
-SELECT
-  pc.name as primary_name,
-  sc.name as secondary_name,
-  COALESCE(exp_count.cnt, 0) as usage_count
-FROM primary_categories pc
-LEFT JOIN secondary_categories sc ON sc.primary_category_id = pc.id
-LEFT JOIN (
-  SELECT primary_category, secondary_category, COUNT(*) as cnt
-  FROM expenses
-  GROUP BY primary_category, secondary_category
-) exp_count ON exp_count.primary_category = pc.name AND exp_count.secondary_category = sc.name
-ORDER BY
-  COALESCE((SELECT SUM(cnt) FROM (SELECT COUNT(*) as cnt FROM expenses WHERE primary_category = pc.name GROUP BY primary_category)), 0) DESC,
-  pc.name ASC,
-  COALESCE(exp_count.cnt, 0) DESC,
-  sc.name ASC
+const createAccountTransfer = `-- name: CreateAccountTransfer :one
+INSERT INTO account_transfers (date, from_account_id, to_account_id, amount_cents, description)
+VALUES (date(?), ?, ?, ?, ?)
+RETURNING id, date, from_account_id, to_account_id, amount_cents, description, created_at
 `
 
-type GetCategoriesOrderedByUsageRow struct {
-	PrimaryName   string         `db:"primary_name" json:"primary_name"`
-	SecondaryName sql.NullString `db:"secondary_name" json:"secondary_name"`
-	UsageCount    int64          `db:"usage_count" json:"usage_count"`
+type CreateAccountTransferParams struct {
+	Date          interface{} `db:"date" json:"date"`
+	FromAccountID int64       `db:"from_account_id" json:"from_account_id"`
+	ToAccountID   int64       `db:"to_account_id" json:"to_account_id"`
+	AmountCents   int64       `db:"amount_cents" json:"amount_cents"`
+	Description   string      `db:"description" json:"description"`
 }
 
-func (q *Queries) GetCategoriesOrderedByUsage(ctx context.Context) ([]GetCategoriesOrderedByUsageRow, error) {
-	rows, err := q.db.QueryContext(ctx, getCategoriesOrderedByUsage)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []GetCategoriesOrderedByUsageRow
-	for rows.Next() {
-		var i GetCategoriesOrderedByUsageRow
-		if err := rows.Scan(&i.PrimaryName, &i.SecondaryName, &i.UsageCount); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+func (q *Queries) CreateAccountTransfer(ctx context.Context, arg CreateAccountTransferParams) (AccountTransfer, error) {
+	row := q.db.QueryRowContext(ctx, createAccountTransfer,
+		arg.Date,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.AmountCents,
+		arg.Description,
+	)
+	var i AccountTransfer
+	err := row.Scan(
+		&i.ID,
+		&i.Date,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.AmountCents,
+		&i.Description,
+		&i.CreatedAt,
+	)
+	return i, err
 }
 
-const getCategorySums = `-- name: GetCategorySums :many
-SELECT primary_category, CAST(SUM(amount_cents) AS INTEGER) as total_amount
-FROM expenses
-WHERE strftime('%Y', date) = printf('%04d', ?)
-  AND strftime('%m', date) = printf('%02d', ?)
-GROUP BY primary_category
-ORDER BY total_amount DESC
+const createApiKey = `-- name: CreateApiKey :one
+INSERT INTO api_keys (user_id, name, key_hash) VALUES (?, ?, ?)
+RETURNING id, user_id, name, key_hash, created_at, last_used_at
+`
+
+type CreateApiKeyParams struct {
+	UserID  int64  `db:"user_id" json:"user_id"`
+	Name    string `db:"name" json:"name"`
+	KeyHash string `db:"key_hash" json:"key_hash"`
+}
+
+func (q *Queries) CreateApiKey(ctx context.Context, arg CreateApiKeyParams) (ApiKey, error) {
+	row := q.db.QueryRowContext(ctx, createApiKey, arg.UserID, arg.Name, arg.KeyHash)
+	var i ApiKey
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.KeyHash,
+		&i.CreatedAt,
+		&i.LastUsedAt,
+	)
+	return i, err
+}
+
+const createAuditEntry = `-- name: CreateAuditEntry :exec
+
+INSERT INTO audit_log (entity, entity_id, action, changed_by, before_json, after_json)
+VALUES (?, ?, ?, ?, ?, ?)
+`
+
+type CreateAuditEntryParams struct {
+	Entity     string         `db:"entity" json:"entity"`
+	EntityID   int64          `db:"entity_id" json:"entity_id"`
+	Action     string         `db:"action" json:"action"`
+	ChangedBy  string         `db:"changed_by" json:"changed_by"`
+	BeforeJson sql.NullString `db:"before_json" json:"before_json"`
+	AfterJson  sql.NullString `db:"after_json" json:"after_json"`
+}
+
+// Audit log
+// Records a change to an expense, income, recurrent or category.
+func (q *Queries) CreateAuditEntry(ctx context.Context, arg CreateAuditEntryParams) error {
+	_, err := q.db.ExecContext(ctx, createAuditEntry,
+		arg.Entity,
+		arg.EntityID,
+		arg.Action,
+		arg.ChangedBy,
+		arg.BeforeJson,
+		arg.AfterJson,
+	)
+	return err
+}
+
+const createCategoryRule = `-- name: CreateCategoryRule :one
+
+INSERT INTO category_rules (name, expression, primary_category, secondary_category, priority)
+VALUES (?, ?, ?, ?, ?)
+RETURNING id, name, expression, primary_category, secondary_category, priority, is_active, created_at
+`
+
+type CreateCategoryRuleParams struct {
+	Name              string `db:"name" json:"name"`
+	Expression        string `db:"expression" json:"expression"`
+	PrimaryCategory   string `db:"primary_category" json:"primary_category"`
+	SecondaryCategory string `db:"secondary_category" json:"secondary_category"`
+	Priority          int64  `db:"priority" json:"priority"`
+}
+
+// Category Rules queries
+// Stores a categorization rule.
+func (q *Queries) CreateCategoryRule(ctx context.Context, arg CreateCategoryRuleParams) (CategoryRule, error) {
+	row := q.db.QueryRowContext(ctx, createCategoryRule,
+		arg.Name,
+		arg.Expression,
+		arg.PrimaryCategory,
+		arg.SecondaryCategory,
+		arg.Priority,
+	)
+	var i CategoryRule
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Expression,
+		&i.PrimaryCategory,
+		&i.SecondaryCategory,
+		&i.Priority,
+		&i.IsActive,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createExpense = `-- name: CreateExpense :one
+INSERT INTO expenses (date, description, amount_cents, primary_category, secondary_category, status, paid_by, account_id)
+VALUES (date(?), ?, ?, ?, ?, ?, ?, ?)
+RETURNING id, date, description, amount_cents, primary_category, secondary_category, version, created_at, synced_at, sync_status, uid, modified_at, status, paid_by, deleted_at, account_id
+`
+
+type CreateExpenseParams struct {
+	Date              interface{}   `db:"date" json:"date"`
+	Description       string        `db:"description" json:"description"`
+	AmountCents       int64         `db:"amount_cents" json:"amount_cents"`
+	PrimaryCategory   string        `db:"primary_category" json:"primary_category"`
+	SecondaryCategory string        `db:"secondary_category" json:"secondary_category"`
+	Status            string        `db:"status" json:"status"`
+	PaidBy            sql.NullInt64 `db:"paid_by" json:"paid_by"`
+	AccountID         sql.NullInt64 `db:"account_id" json:"account_id"`
+}
+
+func (q *Queries) CreateExpense(ctx context.Context, arg CreateExpenseParams) (Expense, error) {
+	row := q.db.QueryRowContext(ctx, createExpense,
+		arg.Date,
+		arg.Description,
+		arg.AmountCents,
+		arg.PrimaryCategory,
+		arg.SecondaryCategory,
+		arg.Status,
+		arg.PaidBy,
+		arg.AccountID,
+	)
+	var i Expense
+	err := row.Scan(
+		&i.ID,
+		&i.Date,
+		&i.Description,
+		&i.AmountCents,
+		&i.PrimaryCategory,
+		&i.SecondaryCategory,
+		&i.Version,
+		&i.CreatedAt,
+		&i.SyncedAt,
+		&i.SyncStatus,
+		&i.Uid,
+		&i.ModifiedAt,
+		&i.Status,
+		&i.PaidBy,
+		&i.DeletedAt,
+		&i.AccountID,
+	)
+	return i, err
+}
+
+const createExpenseCalculation = `-- name: CreateExpenseCalculation :exec
+INSERT INTO expense_calculations (expense_id, kind, quantity, rate_cents)
+VALUES (?, ?, ?, ?)
+`
+
+type CreateExpenseCalculationParams struct {
+	ExpenseID int64   `db:"expense_id" json:"expense_id"`
+	Kind      string  `db:"kind" json:"kind"`
+	Quantity  float64 `db:"quantity" json:"quantity"`
+	RateCents int64   `db:"rate_cents" json:"rate_cents"`
+}
+
+func (q *Queries) CreateExpenseCalculation(ctx context.Context, arg CreateExpenseCalculationParams) error {
+	_, err := q.db.ExecContext(ctx, createExpenseCalculation,
+		arg.ExpenseID,
+		arg.Kind,
+		arg.Quantity,
+		arg.RateCents,
+	)
+	return err
+}
+
+const createExpenseFromPeer = `-- name: CreateExpenseFromPeer :exec
+INSERT INTO expenses (uid, date, description, amount_cents, primary_category, secondary_category, status, version, modified_at)
+VALUES (?, date(?), ?, ?, ?, ?, ?, ?, ?)
+`
+
+type CreateExpenseFromPeerParams struct {
+	Uid               sql.NullString `db:"uid" json:"uid"`
+	Date              interface{}    `db:"date" json:"date"`
+	Description       string         `db:"description" json:"description"`
+	AmountCents       int64          `db:"amount_cents" json:"amount_cents"`
+	PrimaryCategory   string         `db:"primary_category" json:"primary_category"`
+	SecondaryCategory string         `db:"secondary_category" json:"secondary_category"`
+	Status            string         `db:"status" json:"status"`
+	Version           int64          `db:"version" json:"version"`
+	ModifiedAt        sql.NullString `db:"modified_at" json:"modified_at"`
+}
+
+func (q *Queries) CreateExpenseFromPeer(ctx context.Context, arg CreateExpenseFromPeerParams) error {
+	_, err := q.db.ExecContext(ctx, createExpenseFromPeer,
+		arg.Uid,
+		arg.Date,
+		arg.Description,
+		arg.AmountCents,
+		arg.PrimaryCategory,
+		arg.SecondaryCategory,
+		arg.Status,
+		arg.Version,
+		arg.ModifiedAt,
+	)
+	return err
+}
+
+const createExpenseLineItem = `-- name: CreateExpenseLineItem :exec
+INSERT INTO expense_line_items (expense_id, position, description, quantity, amount_cents, primary_category, secondary_category)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+`
+
+type CreateExpenseLineItemParams struct {
+	ExpenseID         int64   `db:"expense_id" json:"expense_id"`
+	Position          int64   `db:"position" json:"position"`
+	Description       string  `db:"description" json:"description"`
+	Quantity          float64 `db:"quantity" json:"quantity"`
+	AmountCents       int64   `db:"amount_cents" json:"amount_cents"`
+	PrimaryCategory   string  `db:"primary_category" json:"primary_category"`
+	SecondaryCategory string  `db:"secondary_category" json:"secondary_category"`
+}
+
+func (q *Queries) CreateExpenseLineItem(ctx context.Context, arg CreateExpenseLineItemParams) error {
+	_, err := q.db.ExecContext(ctx, createExpenseLineItem,
+		arg.ExpenseID,
+		arg.Position,
+		arg.Description,
+		arg.Quantity,
+		arg.AmountCents,
+		arg.PrimaryCategory,
+		arg.SecondaryCategory,
+	)
+	return err
+}
+
+const createExpenseSplit = `-- name: CreateExpenseSplit :exec
+INSERT INTO expense_splits (expense_id, position, note, amount_cents, primary_category, secondary_category)
+VALUES (?, ?, ?, ?, ?, ?)
+`
+
+type CreateExpenseSplitParams struct {
+	ExpenseID         int64  `db:"expense_id" json:"expense_id"`
+	Position          int64  `db:"position" json:"position"`
+	Note              string `db:"note" json:"note"`
+	AmountCents       int64  `db:"amount_cents" json:"amount_cents"`
+	PrimaryCategory   string `db:"primary_category" json:"primary_category"`
+	SecondaryCategory string `db:"secondary_category" json:"secondary_category"`
+}
+
+func (q *Queries) CreateExpenseSplit(ctx context.Context, arg CreateExpenseSplitParams) error {
+	_, err := q.db.ExecContext(ctx, createExpenseSplit,
+		arg.ExpenseID,
+		arg.Position,
+		arg.Note,
+		arg.AmountCents,
+		arg.PrimaryCategory,
+		arg.SecondaryCategory,
+	)
+	return err
+}
+
+const createExpenseVersion = `-- name: CreateExpenseVersion :exec
+
+INSERT INTO expense_versions (expense_id, version, changed_by, changes)
+VALUES (?, ?, ?, ?)
+`
+
+type CreateExpenseVersionParams struct {
+	ExpenseID int64  `db:"expense_id" json:"expense_id"`
+	Version   int64  `db:"version" json:"version"`
+	ChangedBy string `db:"changed_by" json:"changed_by"`
+	Changes   string `db:"changes" json:"changes"`
+}
+
+// Expense Versions queries
+// Records a modification of an expense with its field diff.
+func (q *Queries) CreateExpenseVersion(ctx context.Context, arg CreateExpenseVersionParams) error {
+	_, err := q.db.ExecContext(ctx, createExpenseVersion,
+		arg.ExpenseID,
+		arg.Version,
+		arg.ChangedBy,
+		arg.Changes,
+	)
+	return err
+}
+
+const createHistoryExpense = `-- name: CreateHistoryExpense :one
+INSERT INTO expenses (date, description, amount_cents, primary_category, secondary_category, sync_status, synced_at)
+VALUES (date(?), ?, ?, ?, ?, 'synced', CURRENT_TIMESTAMP)
+RETURNING id, date, description, amount_cents, primary_category, secondary_category, version, created_at, synced_at, sync_status, uid, modified_at, status, paid_by, deleted_at, account_id
+`
+
+type CreateHistoryExpenseParams struct {
+	Date              interface{} `db:"date" json:"date"`
+	Description       string      `db:"description" json:"description"`
+	AmountCents       int64       `db:"amount_cents" json:"amount_cents"`
+	PrimaryCategory   string      `db:"primary_category" json:"primary_category"`
+	SecondaryCategory string      `db:"secondary_category" json:"secondary_category"`
+}
+
+func (q *Queries) CreateHistoryExpense(ctx context.Context, arg CreateHistoryExpenseParams) (Expense, error) {
+	row := q.db.QueryRowContext(ctx, createHistoryExpense,
+		arg.Date,
+		arg.Description,
+		arg.AmountCents,
+		arg.PrimaryCategory,
+		arg.SecondaryCategory,
+	)
+	var i Expense
+	err := row.Scan(
+		&i.ID,
+		&i.Date,
+		&i.Description,
+		&i.AmountCents,
+		&i.PrimaryCategory,
+		&i.SecondaryCategory,
+		&i.Version,
+		&i.CreatedAt,
+		&i.SyncedAt,
+		&i.SyncStatus,
+		&i.Uid,
+		&i.ModifiedAt,
+		&i.Status,
+		&i.PaidBy,
+		&i.DeletedAt,
+		&i.AccountID,
+	)
+	return i, err
+}
+
+const createImportBatch = `-- name: CreateImportBatch :one
+INSERT INTO import_batches (filename) VALUES (?)
+RETURNING id, filename, expenses, created_at, rolled_back_at
+`
+
+func (q *Queries) CreateImportBatch(ctx context.Context, filename string) (ImportBatch, error) {
+	row := q.db.QueryRowContext(ctx, createImportBatch, filename)
+	var i ImportBatch
+	err := row.Scan(
+		&i.ID,
+		&i.Filename,
+		&i.Expenses,
+		&i.CreatedAt,
+		&i.RolledBackAt,
+	)
+	return i, err
+}
+
+const createIncome = `-- name: CreateIncome :one
+INSERT INTO incomes (date, description, amount_cents, category, subcategory, tags, account_id)
+VALUES (date(?), ?, ?, ?, ?, ?, ?)
+RETURNING id, date, description, amount_cents, category, version, created_at, synced_at, sync_status, uid, modified_at, subcategory, tags, deleted_at, account_id
+`
+
+type CreateIncomeParams struct {
+	Date        interface{}   `db:"date" json:"date"`
+	Description string        `db:"description" json:"description"`
+	AmountCents int64         `db:"amount_cents" json:"amount_cents"`
+	Category    string        `db:"category" json:"category"`
+	Subcategory string        `db:"subcategory" json:"subcategory"`
+	Tags        string        `db:"tags" json:"tags"`
+	AccountID   sql.NullInt64 `db:"account_id" json:"account_id"`
+}
+
+// Income queries
+func (q *Queries) CreateIncome(ctx context.Context, arg CreateIncomeParams) (Income, error) {
+	row := q.db.QueryRowContext(ctx, createIncome,
+		arg.Date,
+		arg.Description,
+		arg.AmountCents,
+		arg.Category,
+		arg.Subcategory,
+		arg.Tags,
+		arg.AccountID,
+	)
+	var i Income
+	err := row.Scan(
+		&i.ID,
+		&i.Date,
+		&i.Description,
+		&i.AmountCents,
+		&i.Category,
+		&i.Version,
+		&i.CreatedAt,
+		&i.SyncedAt,
+		&i.SyncStatus,
+		&i.Uid,
+		&i.ModifiedAt,
+		&i.Subcategory,
+		&i.Tags,
+		&i.DeletedAt,
+		&i.AccountID,
+	)
+	return i, err
+}
+
+const createIncomeFromPeer = `-- name: CreateIncomeFromPeer :exec
+INSERT INTO incomes (uid, date, description, amount_cents, category, subcategory, tags, version, modified_at)
+VALUES (?, date(?), ?, ?, ?, ?, ?, ?, ?)
+`
+
+type CreateIncomeFromPeerParams struct {
+	Uid         sql.NullString `db:"uid" json:"uid"`
+	Date        interface{}    `db:"date" json:"date"`
+	Description string         `db:"description" json:"description"`
+	AmountCents int64          `db:"amount_cents" json:"amount_cents"`
+	Category    string         `db:"category" json:"category"`
+	Subcategory string         `db:"subcategory" json:"subcategory"`
+	Tags        string         `db:"tags" json:"tags"`
+	Version     int64          `db:"version" json:"version"`
+	ModifiedAt  sql.NullString `db:"modified_at" json:"modified_at"`
+}
+
+func (q *Queries) CreateIncomeFromPeer(ctx context.Context, arg CreateIncomeFromPeerParams) error {
+	_, err := q.db.ExecContext(ctx, createIncomeFromPeer,
+		arg.Uid,
+		arg.Date,
+		arg.Description,
+		arg.AmountCents,
+		arg.Category,
+		arg.Subcategory,
+		arg.Tags,
+		arg.Version,
+		arg.ModifiedAt,
+	)
+	return err
+}
+
+const createInsight = `-- name: CreateInsight :one
+
+INSERT INTO insights (kind, primary_category, period, occurred_on, amount_cents, baseline_cents, days, since_period)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (kind, primary_category, period) DO NOTHING
+RETURNING id
+`
+
+type CreateInsightParams struct {
+	Kind            string    `db:"kind" json:"kind"`
+	PrimaryCategory string    `db:"primary_category" json:"primary_category"`
+	Period          string    `db:"period" json:"period"`
+	OccurredOn      time.Time `db:"occurred_on" json:"occurred_on"`
+	AmountCents     int64     `db:"amount_cents" json:"amount_cents"`
+	BaselineCents   int64     `db:"baseline_cents" json:"baseline_cents"`
+	Days            int64     `db:"days" json:"days"`
+	SincePeriod     string    `db:"since_period" json:"since_period"`
+}
+
+// Records an insight unless the same kind was already recorded for the
+// category and month, in which case no row is returned.
+func (q *Queries) CreateInsight(ctx context.Context, arg CreateInsightParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, createInsight,
+		arg.Kind,
+		arg.PrimaryCategory,
+		arg.Period,
+		arg.OccurredOn,
+		arg.AmountCents,
+		arg.BaselineCents,
+		arg.Days,
+		arg.SincePeriod,
+	)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createItemPrice = `-- name: CreateItemPrice :exec
+INSERT INTO item_prices (item_id, expense_id, date, quantity, unit_price_cents, source)
+VALUES (?, ?, ?, ?, ?, ?)
+`
+
+type CreateItemPriceParams struct {
+	ItemID         int64     `db:"item_id" json:"item_id"`
+	ExpenseID      int64     `db:"expense_id" json:"expense_id"`
+	Date           time.Time `db:"date" json:"date"`
+	Quantity       float64   `db:"quantity" json:"quantity"`
+	UnitPriceCents int64     `db:"unit_price_cents" json:"unit_price_cents"`
+	Source         string    `db:"source" json:"source"`
+}
+
+func (q *Queries) CreateItemPrice(ctx context.Context, arg CreateItemPriceParams) error {
+	_, err := q.db.ExecContext(ctx, createItemPrice,
+		arg.ItemID,
+		arg.ExpenseID,
+		arg.Date,
+		arg.Quantity,
+		arg.UnitPriceCents,
+		arg.Source,
+	)
+	return err
+}
+
+const createJobRun = `-- name: CreateJobRun :one
+INSERT INTO jobs (job, source, attempt, status, started_at)
+VALUES (?, ?, ?, 'running', ?)
+RETURNING id, job, source, attempt, status, started_at, finished_at, result, error
+`
+
+type CreateJobRunParams struct {
+	Job       string    `db:"job" json:"job"`
+	Source    string    `db:"source" json:"source"`
+	Attempt   int64     `db:"attempt" json:"attempt"`
+	StartedAt time.Time `db:"started_at" json:"started_at"`
+}
+
+func (q *Queries) CreateJobRun(ctx context.Context, arg CreateJobRunParams) (Job, error) {
+	row := q.db.QueryRowContext(ctx, createJobRun, arg.Job, arg.Source, arg.Attempt, arg.StartedAt)
+	var i Job
+	err := row.Scan(
+		&i.ID,
+		&i.Job,
+		&i.Source,
+		&i.Attempt,
+		&i.Status,
+		&i.StartedAt,
+		&i.FinishedAt,
+		&i.Result,
+		&i.Error,
+	)
+	return i, err
+}
+
+const createPrimaryCategory = `-- name: CreatePrimaryCategory :one
+INSERT INTO primary_categories (name)
+VALUES (?)
+RETURNING id, name, created_at, archived
+`
+
+func (q *Queries) CreatePrimaryCategory(ctx context.Context, name string) (PrimaryCategory, error) {
+	row := q.db.QueryRowContext(ctx, createPrimaryCategory, name)
+	var i PrimaryCategory
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.Archived,
+	)
+	return i, err
+}
+
+const createRecurrentExpense = `-- name: CreateRecurrentExpense :one
+INSERT INTO recurrent_expenses (
+    start_date, end_date, repetition_type, description, 
+    amount_cents, primary_category, secondary_category,
+    repeat_interval, day_of_month
+)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id, start_date, end_date, repetition_type, description, amount_cents, primary_category, secondary_category, is_active, last_execution_date, created_at, updated_at, version, repeat_interval, day_of_month, paused, skip_until
+`
+
+type CreateRecurrentExpenseParams struct {
+	StartDate         time.Time   `db:"start_date" json:"start_date"`
+	EndDate           interface{} `db:"end_date" json:"end_date"`
+	RepetitionType    string      `db:"repetition_type" json:"repetition_type"`
+	Description       string      `db:"description" json:"description"`
+	AmountCents       int64       `db:"amount_cents" json:"amount_cents"`
+	PrimaryCategory   string      `db:"primary_category" json:"primary_category"`
+	SecondaryCategory string      `db:"secondary_category" json:"secondary_category"`
+	RepeatInterval    int64       `db:"repeat_interval" json:"repeat_interval"`
+	DayOfMonth        int64       `db:"day_of_month" json:"day_of_month"`
+}
+
+// Recurrent Expenses queries
+func (q *Queries) CreateRecurrentExpense(ctx context.Context, arg CreateRecurrentExpenseParams) (RecurrentExpense, error) {
+	row := q.db.QueryRowContext(ctx, createRecurrentExpense,
+		arg.StartDate,
+		arg.EndDate,
+		arg.RepetitionType,
+		arg.Description,
+		arg.AmountCents,
+		arg.PrimaryCategory,
+		arg.SecondaryCategory,
+		arg.RepeatInterval,
+		arg.DayOfMonth,
+	)
+	var i RecurrentExpense
+	err := row.Scan(
+		&i.ID,
+		&i.StartDate,
+		&i.EndDate,
+		&i.RepetitionType,
+		&i.Description,
+		&i.AmountCents,
+		&i.PrimaryCategory,
+		&i.SecondaryCategory,
+		&i.IsActive,
+		&i.LastExecutionDate,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.RepeatInterval,
+		&i.DayOfMonth,
+		&i.Paused,
+		&i.SkipUntil,
+	)
+	return i, err
+}
+
+const createRecurrentIncome = `-- name: CreateRecurrentIncome :one
+INSERT INTO recurrent_incomes (
+    start_date, end_date, repetition_type, repeat_interval, day_of_month,
+    description, amount_cents, category, subcategory
+)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id, start_date, end_date, repetition_type, repeat_interval, day_of_month, description, amount_cents, category, subcategory, is_active, last_execution_date, version, created_at, updated_at
+`
+
+type CreateRecurrentIncomeParams struct {
+	StartDate      time.Time   `db:"start_date" json:"start_date"`
+	EndDate        interface{} `db:"end_date" json:"end_date"`
+	RepetitionType string      `db:"repetition_type" json:"repetition_type"`
+	RepeatInterval int64       `db:"repeat_interval" json:"repeat_interval"`
+	DayOfMonth     int64       `db:"day_of_month" json:"day_of_month"`
+	Description    string      `db:"description" json:"description"`
+	AmountCents    int64       `db:"amount_cents" json:"amount_cents"`
+	Category       string      `db:"category" json:"category"`
+	Subcategory    string      `db:"subcategory" json:"subcategory"`
+}
+
+func (q *Queries) CreateRecurrentIncome(ctx context.Context, arg CreateRecurrentIncomeParams) (RecurrentIncome, error) {
+	row := q.db.QueryRowContext(ctx, createRecurrentIncome, arg.StartDate, arg.EndDate, arg.RepetitionType, arg.RepeatInterval, arg.DayOfMonth, arg.Description, arg.AmountCents, arg.Category, arg.Subcategory)
+	var i RecurrentIncome
+	err := row.Scan(
+		&i.ID,
+		&i.StartDate,
+		&i.EndDate,
+		&i.RepetitionType,
+		&i.RepeatInterval,
+		&i.DayOfMonth,
+		&i.Description,
+		&i.AmountCents,
+		&i.Category,
+		&i.Subcategory,
+		&i.IsActive,
+		&i.LastExecutionDate,
+		&i.Version,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createSavedView = `-- name: CreateSavedView :one
+INSERT INTO saved_views (name, primary_category, secondary_category, min_cents, max_cents, text, tag, notify)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id
+`
+
+type CreateSavedViewParams struct {
+	Name              string `db:"name" json:"name"`
+	PrimaryCategory   string `db:"primary_category" json:"primary_category"`
+	SecondaryCategory string `db:"secondary_category" json:"secondary_category"`
+	MinCents          int64  `db:"min_cents" json:"min_cents"`
+	MaxCents          int64  `db:"max_cents" json:"max_cents"`
+	Text              string `db:"text" json:"text"`
+	Tag               string `db:"tag" json:"tag"`
+	Notify            bool   `db:"notify" json:"notify"`
+}
+
+func (q *Queries) CreateSavedView(ctx context.Context, arg CreateSavedViewParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, createSavedView,
+		arg.Name,
+		arg.PrimaryCategory,
+		arg.SecondaryCategory,
+		arg.MinCents,
+		arg.MaxCents,
+		arg.Text,
+		arg.Tag,
+		arg.Notify,
+	)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createSecondaryCategory = `-- name: CreateSecondaryCategory :one
+INSERT INTO secondary_categories (name, primary_category_id)
+VALUES (?, ?)
+RETURNING id, name, primary_category_id, created_at, archived
+`
+
+type CreateSecondaryCategoryParams struct {
+	Name              string `db:"name" json:"name"`
+	PrimaryCategoryID int64  `db:"primary_category_id" json:"primary_category_id"`
+}
+
+func (q *Queries) CreateSecondaryCategory(ctx context.Context, arg CreateSecondaryCategoryParams) (SecondaryCategory, error) {
+	row := q.db.QueryRowContext(ctx, createSecondaryCategory, arg.Name, arg.PrimaryCategoryID)
+	var i SecondaryCategory
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.PrimaryCategoryID,
+		&i.CreatedAt,
+		&i.Archived,
+	)
+	return i, err
+}
+
+const createShoppingList = `-- name: CreateShoppingList :one
+INSERT INTO shopping_lists (name)
+VALUES (?)
+RETURNING id
+`
+
+func (q *Queries) CreateShoppingList(ctx context.Context, name string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, createShoppingList, name)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createShoppingListItem = `-- name: CreateShoppingListItem :exec
+INSERT INTO shopping_list_items (list_id, name, quantity, price_cents)
+VALUES (?, ?, ?, ?)
+`
+
+type CreateShoppingListItemParams struct {
+	ListID     int64   `db:"list_id" json:"list_id"`
+	Name       string  `db:"name" json:"name"`
+	Quantity   float64 `db:"quantity" json:"quantity"`
+	PriceCents int64   `db:"price_cents" json:"price_cents"`
+}
+
+func (q *Queries) CreateShoppingListItem(ctx context.Context, arg CreateShoppingListItemParams) error {
+	_, err := q.db.ExecContext(ctx, createShoppingListItem,
+		arg.ListID,
+		arg.Name,
+		arg.Quantity,
+		arg.PriceCents,
+	)
+	return err
+}
+
+const createUser = `-- name: CreateUser :one
+INSERT INTO users (name) VALUES (?)
+RETURNING id, name, created_at
+`
+
+// Household members
+func (q *Queries) CreateUser(ctx context.Context, name string) (User, error) {
+	row := q.db.QueryRowContext(ctx, createUser, name)
+	var i User
+	err := row.Scan(&i.ID, &i.Name, &i.CreatedAt)
+	return i, err
+}
+
+const deactivateRecurrentExpense = `-- name: DeactivateRecurrentExpense :exec
+UPDATE recurrent_expenses
+SET is_active = 0,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+`
+
+func (q *Queries) DeactivateRecurrentExpense(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deactivateRecurrentExpense, id)
+	return err
+}
+
+const deactivateRecurrentIncome = `-- name: DeactivateRecurrentIncome :execrows
+UPDATE recurrent_incomes
+SET is_active = 0,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = ? AND is_active = 1
+`
+
+func (q *Queries) DeactivateRecurrentIncome(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deactivateRecurrentIncome, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deferSyncItem = `-- name: DeferSyncItem :exec
+UPDATE sync_queue
+SET last_error = ?1,
+    status = 'pending',
+    next_retry_at = datetime(CURRENT_TIMESTAMP, '+' || ?2 || ' seconds'),
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = ?3
+`
+
+type DeferSyncItemParams struct {
+	LastError    interface{} `db:"last_error" json:"last_error"`
+	DelaySeconds interface{} `db:"delay_seconds" json:"delay_seconds"`
+	ID           int64       `db:"id" json:"id"`
+}
+
+// Puts an item back to pending for a later poll without counting an attempt,
+// e.g. when Google Sheets throttled the write.
+func (q *Queries) DeferSyncItem(ctx context.Context, arg DeferSyncItemParams) error {
+	_, err := q.db.ExecContext(ctx, deferSyncItem, arg.LastError, arg.DelaySeconds, arg.ID)
+	return err
+}
+
+const deleteAccount = `-- name: DeleteAccount :execrows
+DELETE FROM accounts WHERE id = ?
+`
+
+func (q *Queries) DeleteAccount(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteAccount, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteAccountTransfer = `-- name: DeleteAccountTransfer :execrows
+DELETE FROM account_transfers WHERE id = ?
+`
+
+func (q *Queries) DeleteAccountTransfer(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteAccountTransfer, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteAlertPreference = `-- name: DeleteAlertPreference :execrows
+DELETE FROM alert_preferences WHERE user_id = ? AND alert_type = ? AND scope = ?
+`
+
+type DeleteAlertPreferenceParams struct {
+	UserID    string `db:"user_id" json:"user_id"`
+	AlertType string `db:"alert_type" json:"alert_type"`
+	Scope     string `db:"scope" json:"scope"`
+}
+
+func (q *Queries) DeleteAlertPreference(ctx context.Context, arg DeleteAlertPreferenceParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteAlertPreference, arg.UserID, arg.AlertType, arg.Scope)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteAllAccounts = `-- name: DeleteAllAccounts :exec
+DELETE FROM accounts
+`
+
+func (q *Queries) DeleteAllAccounts(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteAllAccounts)
+	return err
+}
+
+const deleteAllAuditLog = `-- name: DeleteAllAuditLog :exec
+DELETE FROM audit_log
+`
+
+func (q *Queries) DeleteAllAuditLog(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteAllAuditLog)
+	return err
+}
+
+const deleteAllAlertPreferences = `-- name: DeleteAllAlertPreferences :exec
+DELETE FROM alert_preferences
+`
+
+func (q *Queries) DeleteAllAlertPreferences(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteAllAlertPreferences)
+	return err
+}
+
+const deleteAllCategoryKeywords = `-- name: DeleteAllCategoryKeywords :exec
+DELETE FROM category_keywords
+`
+
+func (q *Queries) DeleteAllCategoryKeywords(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteAllCategoryKeywords)
+	return err
+}
+
+const deleteAllCategoryRules = `-- name: DeleteAllCategoryRules :exec
+DELETE FROM category_rules
+`
+
+func (q *Queries) DeleteAllCategoryRules(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteAllCategoryRules)
+	return err
+}
+
+const deleteAllClassifierFeedback = `-- name: DeleteAllClassifierFeedback :exec
+DELETE FROM classifier_feedback
+`
+
+func (q *Queries) DeleteAllClassifierFeedback(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteAllClassifierFeedback)
+	return err
+}
+
+const deleteAllExpenseCalculations = `-- name: DeleteAllExpenseCalculations :exec
+DELETE FROM expense_calculations
+`
+
+func (q *Queries) DeleteAllExpenseCalculations(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteAllExpenseCalculations)
+	return err
+}
+
+const deleteAllExpenseLineItems = `-- name: DeleteAllExpenseLineItems :exec
+DELETE FROM expense_line_items
+`
+
+func (q *Queries) DeleteAllExpenseLineItems(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteAllExpenseLineItems)
+	return err
+}
+
+const deleteAllExpenseReimbursements = `-- name: DeleteAllExpenseReimbursements :exec
+DELETE FROM expense_reimbursements
+`
+
+func (q *Queries) DeleteAllExpenseReimbursements(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteAllExpenseReimbursements)
+	return err
+}
+
+const deleteAllExpenseSplits = `-- name: DeleteAllExpenseSplits :exec
+DELETE FROM expense_splits
+`
+
+func (q *Queries) DeleteAllExpenseSplits(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteAllExpenseSplits)
+	return err
+}
+
+const deleteAllExpenseTags = `-- name: DeleteAllExpenseTags :exec
+DELETE FROM expense_tags
+`
+
+func (q *Queries) DeleteAllExpenseTags(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteAllExpenseTags)
+	return err
+}
+
+const deleteAllExpenseVersions = `-- name: DeleteAllExpenseVersions :exec
+DELETE FROM expense_versions
+`
+
+func (q *Queries) DeleteAllExpenseVersions(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteAllExpenseVersions)
+	return err
+}
+
+const deleteAllExpenseWorkflow = `-- name: DeleteAllExpenseWorkflow :exec
+DELETE FROM expense_workflow
+`
+
+func (q *Queries) DeleteAllExpenseWorkflow(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteAllExpenseWorkflow)
+	return err
+}
+
+const deleteAllExpenses = `-- name: DeleteAllExpenses :exec
+DELETE FROM expenses
+`
+
+func (q *Queries) DeleteAllExpenses(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteAllExpenses)
+	return err
+}
+
+const deleteAllFuelFills = `-- name: DeleteAllFuelFills :exec
+DELETE FROM fuel_fills
+`
+
+func (q *Queries) DeleteAllFuelFills(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteAllFuelFills)
+	return err
+}
+
+const deleteAllIncomeTags = `-- name: DeleteAllIncomeTags :exec
+DELETE FROM income_tags
+`
+
+func (q *Queries) DeleteAllIncomeTags(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteAllIncomeTags)
+	return err
+}
+
+const deleteAllIncomes = `-- name: DeleteAllIncomes :exec
+DELETE FROM incomes
+`
+
+func (q *Queries) DeleteAllIncomes(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteAllIncomes)
+	return err
+}
+
+const deleteAllInsightMutes = `-- name: DeleteAllInsightMutes :exec
+DELETE FROM insight_mutes
+`
+
+func (q *Queries) DeleteAllInsightMutes(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteAllInsightMutes)
+	return err
+}
+
+const deleteAllInsights = `-- name: DeleteAllInsights :exec
+DELETE FROM insights
+`
+
+func (q *Queries) DeleteAllInsights(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteAllInsights)
+	return err
+}
+
+const deleteAllItemPrices = `-- name: DeleteAllItemPrices :exec
+DELETE FROM item_prices
+`
+
+func (q *Queries) DeleteAllItemPrices(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteAllItemPrices)
+	return err
+}
+
+const deleteAllItems = `-- name: DeleteAllItems :exec
+DELETE FROM items
+`
+
+func (q *Queries) DeleteAllItems(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteAllItems)
+	return err
+}
+
+const deleteAllMonthReviews = `-- name: DeleteAllMonthReviews :exec
+DELETE FROM month_reviews
+`
+
+func (q *Queries) DeleteAllMonthReviews(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteAllMonthReviews)
+	return err
+}
+
+const deleteAllPeerChangelog = `-- name: DeleteAllPeerChangelog :exec
+DELETE FROM peer_changelog
+`
+
+func (q *Queries) DeleteAllPeerChangelog(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteAllPeerChangelog)
+	return err
+}
+
+const deleteAllPeerTombstones = `-- name: DeleteAllPeerTombstones :exec
+DELETE FROM peer_tombstones
+`
+
+func (q *Queries) DeleteAllPeerTombstones(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteAllPeerTombstones)
+	return err
+}
+
+const deleteAllPurchaseWarranties = `-- name: DeleteAllPurchaseWarranties :exec
+DELETE FROM purchase_warranties
+`
+
+func (q *Queries) DeleteAllPurchaseWarranties(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteAllPurchaseWarranties)
+	return err
+}
+
+const deleteAllRecurrentExpenses = `-- name: DeleteAllRecurrentExpenses :exec
+DELETE FROM recurrent_expenses
+`
+
+func (q *Queries) DeleteAllRecurrentExpenses(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteAllRecurrentExpenses)
+	return err
+}
+
+const deleteAllRecurrentIncomes = `-- name: DeleteAllRecurrentIncomes :exec
+DELETE FROM recurrent_incomes
+`
+
+func (q *Queries) DeleteAllRecurrentIncomes(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteAllRecurrentIncomes)
+	return err
+}
+
+const deleteAllSavedViews = `-- name: DeleteAllSavedViews :exec
+DELETE FROM saved_views
+`
+
+func (q *Queries) DeleteAllSavedViews(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteAllSavedViews)
+	return err
+}
+
+const deleteAllSheetHistoryImports = `-- name: DeleteAllSheetHistoryImports :exec
+DELETE FROM sheet_history_imports
+`
+
+func (q *Queries) DeleteAllSheetHistoryImports(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteAllSheetHistoryImports)
+	return err
+}
+
+const deleteAllShoppingListItems = `-- name: DeleteAllShoppingListItems :exec
+DELETE FROM shopping_list_items
+`
+
+func (q *Queries) DeleteAllShoppingListItems(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteAllShoppingListItems)
+	return err
+}
+
+const deleteAllShoppingLists = `-- name: DeleteAllShoppingLists :exec
+DELETE FROM shopping_lists
+`
+
+func (q *Queries) DeleteAllShoppingLists(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteAllShoppingLists)
+	return err
+}
+
+const deleteAllSyncErrors = `-- name: DeleteAllSyncErrors :exec
+DELETE FROM sync_errors
+`
+
+func (q *Queries) DeleteAllSyncErrors(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteAllSyncErrors)
+	return err
+}
+
+const deleteAllSyncQueue = `-- name: DeleteAllSyncQueue :exec
+DELETE FROM sync_queue
+`
+
+func (q *Queries) DeleteAllSyncQueue(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteAllSyncQueue)
+	return err
+}
+
+const deleteAllTags = `-- name: DeleteAllTags :exec
+DELETE FROM tags
+`
+
+func (q *Queries) DeleteAllTags(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteAllTags)
+	return err
+}
+
+const deleteAllUsers = `-- name: DeleteAllUsers :exec
+DELETE FROM users
+`
+
+func (q *Queries) DeleteAllUsers(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteAllUsers)
+	return err
+}
+
+const deleteAllUtilityUsage = `-- name: DeleteAllUtilityUsage :exec
+DELETE FROM utility_usage
+`
+
+func (q *Queries) DeleteAllUtilityUsage(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteAllUtilityUsage)
+	return err
+}
+
+const deleteApiKey = `-- name: DeleteApiKey :execrows
+DELETE FROM api_keys WHERE id = ?
+`
+
+func (q *Queries) DeleteApiKey(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteApiKey, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteBlobDeletion = `-- name: DeleteBlobDeletion :exec
+DELETE FROM blob_deletions
+WHERE blob_key = ?
+`
+
+func (q *Queries) DeleteBlobDeletion(ctx context.Context, blobKey string) error {
+	_, err := q.db.ExecContext(ctx, deleteBlobDeletion, blobKey)
+	return err
+}
+
+const deleteCategoryKeyword = `-- name: DeleteCategoryKeyword :execrows
+DELETE FROM category_keywords WHERE keyword = ?
+`
+
+func (q *Queries) DeleteCategoryKeyword(ctx context.Context, keyword string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteCategoryKeyword, keyword)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteCategoryMapping = `-- name: DeleteCategoryMapping :execrows
+DELETE FROM category_mappings
+WHERE sheet_name = ?
+`
+
+// Removes the mapping of a Google Sheets secondary category.
+func (q *Queries) DeleteCategoryMapping(ctx context.Context, sheetName string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteCategoryMapping, sheetName)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteCategoryRule = `-- name: DeleteCategoryRule :execrows
+DELETE FROM category_rules WHERE id = ?
+`
+
+// Removes a rule.
+func (q *Queries) DeleteCategoryRule(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteCategoryRule, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteCategoryTranslation = `-- name: DeleteCategoryTranslation :execrows
+DELETE FROM category_translations
+WHERE kind = ? AND name = ? AND locale = ?
+`
+
+type DeleteCategoryTranslationParams struct {
+	Kind   string `db:"kind" json:"kind"`
+	Name   string `db:"name" json:"name"`
+	Locale string `db:"locale" json:"locale"`
+}
+
+func (q *Queries) DeleteCategoryTranslation(ctx context.Context, arg DeleteCategoryTranslationParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteCategoryTranslation, arg.Kind, arg.Name, arg.Locale)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteDetachedSecondaryCategory = `-- name: DeleteDetachedSecondaryCategory :one
+DELETE FROM secondary_categories
+WHERE id = ?
+  AND NOT EXISTS (SELECT 1 FROM primary_categories pc WHERE pc.id = secondary_categories.primary_category_id)
+RETURNING id, name, primary_category_id, created_at, archived
+`
+
+// Removes a secondary category whose primary category no longer exists.
+func (q *Queries) DeleteDetachedSecondaryCategory(ctx context.Context, id int64) (SecondaryCategory, error) {
+	row := q.db.QueryRowContext(ctx, deleteDetachedSecondaryCategory, id)
+	var i SecondaryCategory
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.PrimaryCategoryID,
+		&i.CreatedAt,
+		&i.Archived,
+	)
+	return i, err
+}
+
+const deleteExpenseByUID = `-- name: DeleteExpenseByUID :exec
+DELETE FROM expenses WHERE uid = ?
+`
+
+func (q *Queries) DeleteExpenseByUID(ctx context.Context, uid sql.NullString) error {
+	_, err := q.db.ExecContext(ctx, deleteExpenseByUID, uid)
+	return err
+}
+
+const deleteExpenseLineItems = `-- name: DeleteExpenseLineItems :exec
+DELETE FROM expense_line_items
+WHERE expense_id = ?
+`
+
+func (q *Queries) DeleteExpenseLineItems(ctx context.Context, expenseID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteExpenseLineItems, expenseID)
+	return err
+}
+
+const deleteExpenseReimbursement = `-- name: DeleteExpenseReimbursement :execrows
+DELETE FROM expense_reimbursements WHERE expense_id = ? AND income_id = ?
+`
+
+type DeleteExpenseReimbursementParams struct {
+	ExpenseID int64 `db:"expense_id" json:"expense_id"`
+	IncomeID  int64 `db:"income_id" json:"income_id"`
+}
+
+func (q *Queries) DeleteExpenseReimbursement(ctx context.Context, arg DeleteExpenseReimbursementParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteExpenseReimbursement, arg.ExpenseID, arg.IncomeID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteExpenseSplits = `-- name: DeleteExpenseSplits :exec
+DELETE FROM expense_splits
+WHERE expense_id = ?
+`
+
+func (q *Queries) DeleteExpenseSplits(ctx context.Context, expenseID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteExpenseSplits, expenseID)
+	return err
+}
+
+const deleteExpenseTags = `-- name: DeleteExpenseTags :exec
+DELETE FROM expense_tags WHERE expense_id = ?
+`
+
+func (q *Queries) DeleteExpenseTags(ctx context.Context, expenseID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteExpenseTags, expenseID)
+	return err
+}
+
+const deleteFuelFill = `-- name: DeleteFuelFill :execrows
+DELETE FROM fuel_fills
+WHERE expense_id = ?
+`
+
+func (q *Queries) DeleteFuelFill(ctx context.Context, expenseID int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteFuelFill, expenseID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteIncomeByUID = `-- name: DeleteIncomeByUID :exec
+DELETE FROM incomes WHERE uid = ?
+`
+
+func (q *Queries) DeleteIncomeByUID(ctx context.Context, uid sql.NullString) error {
+	_, err := q.db.ExecContext(ctx, deleteIncomeByUID, uid)
+	return err
+}
+
+const deleteIncomeTags = `-- name: DeleteIncomeTags :exec
+DELETE FROM income_tags WHERE income_id = ?
+`
+
+func (q *Queries) DeleteIncomeTags(ctx context.Context, incomeID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteIncomeTags, incomeID)
+	return err
+}
+
+const deleteItem = `-- name: DeleteItem :execrows
+DELETE FROM items
+WHERE id = ?
+`
+
+func (q *Queries) DeleteItem(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteItem, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteItemPricesByExpense = `-- name: DeleteItemPricesByExpense :exec
+DELETE FROM item_prices
+WHERE expense_id = ? AND source = ?
+`
+
+type DeleteItemPricesByExpenseParams struct {
+	ExpenseID int64  `db:"expense_id" json:"expense_id"`
+	Source    string `db:"source" json:"source"`
+}
+
+func (q *Queries) DeleteItemPricesByExpense(ctx context.Context, arg DeleteItemPricesByExpenseParams) error {
+	_, err := q.db.ExecContext(ctx, deleteItemPricesByExpense, arg.ExpenseID, arg.Source)
+	return err
+}
+
+const deleteItemPricesBySource = `-- name: DeleteItemPricesBySource :exec
+DELETE FROM item_prices
+WHERE item_id = ? AND source = ?
+`
+
+type DeleteItemPricesBySourceParams struct {
+	ItemID int64  `db:"item_id" json:"item_id"`
+	Source string `db:"source" json:"source"`
+}
+
+func (q *Queries) DeleteItemPricesBySource(ctx context.Context, arg DeleteItemPricesBySourceParams) error {
+	_, err := q.db.ExecContext(ctx, deleteItemPricesBySource, arg.ItemID, arg.Source)
+	return err
+}
+
+const deletePeerTombstone = `-- name: DeletePeerTombstone :exec
+DELETE FROM peer_tombstones WHERE kind = ? AND uid = ?
+`
+
+type DeletePeerTombstoneParams struct {
+	Kind string `db:"kind" json:"kind"`
+	Uid  string `db:"uid" json:"uid"`
+}
+
+func (q *Queries) DeletePeerTombstone(ctx context.Context, arg DeletePeerTombstoneParams) error {
+	_, err := q.db.ExecContext(ctx, deletePeerTombstone, arg.Kind, arg.Uid)
+	return err
+}
+
+const deletePrimaryCategory = `-- name: DeletePrimaryCategory :exec
+DELETE FROM primary_categories WHERE name = ?
+`
+
+func (q *Queries) DeletePrimaryCategory(ctx context.Context, name string) error {
+	_, err := q.db.ExecContext(ctx, deletePrimaryCategory, name)
+	return err
+}
+
+const deletePrimaryCategoryByID = `-- name: DeletePrimaryCategoryByID :exec
+DELETE FROM primary_categories WHERE id = ?
+`
+
+func (q *Queries) DeletePrimaryCategoryByID(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deletePrimaryCategoryByID, id)
+	return err
+}
+
+const deletePurchaseWarranty = `-- name: DeletePurchaseWarranty :execrows
+DELETE FROM purchase_warranties
+WHERE expense_id = ?
+`
+
+func (q *Queries) DeletePurchaseWarranty(ctx context.Context, expenseID int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deletePurchaseWarranty, expenseID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteReceipt = `-- name: DeleteReceipt :execrows
+DELETE FROM receipts
+WHERE expense_id = ?
+`
+
+func (q *Queries) DeleteReceipt(ctx context.Context, expenseID int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteReceipt, expenseID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteRecurrentExpense = `-- name: DeleteRecurrentExpense :exec
+DELETE FROM recurrent_expenses
+WHERE id = ?
+`
+
+func (q *Queries) DeleteRecurrentExpense(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteRecurrentExpense, id)
+	return err
+}
+
+const deleteSavedView = `-- name: DeleteSavedView :execrows
+DELETE FROM saved_views WHERE id = ?
+`
+
+func (q *Queries) DeleteSavedView(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteSavedView, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteSecondaryCategoriesByPrimaryID = `-- name: DeleteSecondaryCategoriesByPrimaryID :exec
+DELETE FROM secondary_categories WHERE primary_category_id = ?
+`
+
+// Foreign keys are not enforced, so the cascade of the schema never runs.
+func (q *Queries) DeleteSecondaryCategoriesByPrimaryID(ctx context.Context, primaryCategoryID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteSecondaryCategoriesByPrimaryID, primaryCategoryID)
+	return err
+}
+
+const deleteSecondaryCategory = `-- name: DeleteSecondaryCategory :exec
+DELETE FROM secondary_categories WHERE name = ?
+`
+
+func (q *Queries) DeleteSecondaryCategory(ctx context.Context, name string) error {
+	_, err := q.db.ExecContext(ctx, deleteSecondaryCategory, name)
+	return err
+}
+
+const deleteSecondaryCategoryByID = `-- name: DeleteSecondaryCategoryByID :exec
+DELETE FROM secondary_categories WHERE id = ?
+`
+
+func (q *Queries) DeleteSecondaryCategoryByID(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteSecondaryCategoryByID, id)
+	return err
+}
+
+const deleteShoppingList = `-- name: DeleteShoppingList :execrows
+
+DELETE FROM shopping_lists
+WHERE id = ? AND converted_at IS NULL
+`
+
+// Converted lists are kept as the breakdown of their expense.
+func (q *Queries) DeleteShoppingList(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteShoppingList, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteShoppingListItem = `-- name: DeleteShoppingListItem :execrows
+DELETE FROM shopping_list_items
+WHERE id = ? AND list_id IN (SELECT id FROM shopping_lists WHERE converted_at IS NULL)
+`
+
+func (q *Queries) DeleteShoppingListItem(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteShoppingListItem, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteUser = `-- name: DeleteUser :execrows
+DELETE FROM users WHERE id = ?
+`
+
+func (q *Queries) DeleteUser(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUser, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteUtilityUsage = `-- name: DeleteUtilityUsage :execrows
+DELETE FROM utility_usage
+WHERE expense_id = ?
+`
+
+func (q *Queries) DeleteUtilityUsage(ctx context.Context, expenseID int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUtilityUsage, expenseID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const dequeueSyncBatch = `-- name: DequeueSyncBatch :many
+SELECT id, operation, expense_id, expense_day, expense_month, expense_description, expense_amount_cents, expense_primary, expense_secondary, status, attempts, max_attempts, last_error, created_at, updated_at, processed_at, next_retry_at, expense_version, trace_parent FROM sync_queue
+WHERE status = 'pending'
+  AND (next_retry_at IS NULL OR next_retry_at <= CURRENT_TIMESTAMP)
+ORDER BY created_at ASC, id ASC
+LIMIT ?
+`
+
+// Fetches a batch of pending items ready for processing.
+func (q *Queries) DequeueSyncBatch(ctx context.Context, limit int64) ([]SyncQueue, error) {
+	rows, err := q.db.QueryContext(ctx, dequeueSyncBatch, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SyncQueue
+	for rows.Next() {
+		var i SyncQueue
+		if err := rows.Scan(
+			&i.ID,
+			&i.Operation,
+			&i.ExpenseID,
+			&i.ExpenseDay,
+			&i.ExpenseMonth,
+			&i.ExpenseDescription,
+			&i.ExpenseAmountCents,
+			&i.ExpensePrimary,
+			&i.ExpenseSecondary,
+			&i.Status,
+			&i.Attempts,
+			&i.MaxAttempts,
+			&i.LastError,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ProcessedAt,
+			&i.NextRetryAt,
+			&i.ExpenseVersion,
+			&i.TraceParent,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const dismissInsight = `-- name: DismissInsight :execrows
+UPDATE insights SET dismissed_at = CURRENT_TIMESTAMP
+WHERE id = ? AND dismissed_at IS NULL
+`
+
+func (q *Queries) DismissInsight(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, dismissInsight, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const enqueueDelete = `-- name: EnqueueDelete :one
+INSERT INTO sync_queue (
+    operation, expense_id, status,
+    expense_day, expense_month, expense_description,
+    expense_amount_cents, expense_primary, expense_secondary,
+    trace_parent, created_at, updated_at
+)
+VALUES ('delete', ?, 'pending', ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+RETURNING id, operation, expense_id, expense_day, expense_month, expense_description, expense_amount_cents, expense_primary, expense_secondary, status, attempts, max_attempts, last_error, created_at, updated_at, processed_at, next_retry_at, expense_version, trace_parent
+`
+
+type EnqueueDeleteParams struct {
+	ExpenseID          int64       `db:"expense_id" json:"expense_id"`
+	ExpenseDay         interface{} `db:"expense_day" json:"expense_day"`
+	ExpenseMonth       interface{} `db:"expense_month" json:"expense_month"`
+	ExpenseDescription interface{} `db:"expense_description" json:"expense_description"`
+	ExpenseAmountCents interface{} `db:"expense_amount_cents" json:"expense_amount_cents"`
+	ExpensePrimary     interface{} `db:"expense_primary" json:"expense_primary"`
+	ExpenseSecondary   interface{} `db:"expense_secondary" json:"expense_secondary"`
+	TraceParent        interface{} `db:"trace_parent" json:"trace_parent"`
+}
+
+// Enqueues a delete operation with full expense data.
+func (q *Queries) EnqueueDelete(ctx context.Context, arg EnqueueDeleteParams) (SyncQueue, error) {
+	row := q.db.QueryRowContext(ctx, enqueueDelete,
+		arg.ExpenseID,
+		arg.ExpenseDay,
+		arg.ExpenseMonth,
+		arg.ExpenseDescription,
+		arg.ExpenseAmountCents,
+		arg.ExpensePrimary,
+		arg.ExpenseSecondary,
+		arg.TraceParent,
+	)
+	var i SyncQueue
+	err := row.Scan(
+		&i.ID,
+		&i.Operation,
+		&i.ExpenseID,
+		&i.ExpenseDay,
+		&i.ExpenseMonth,
+		&i.ExpenseDescription,
+		&i.ExpenseAmountCents,
+		&i.ExpensePrimary,
+		&i.ExpenseSecondary,
+		&i.Status,
+		&i.Attempts,
+		&i.MaxAttempts,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ProcessedAt,
+		&i.NextRetryAt,
+		&i.ExpenseVersion,
+		&i.TraceParent,
+	)
+	return i, err
+}
+
+const enqueueSync = `-- name: EnqueueSync :one
+
+INSERT INTO sync_queue (operation, expense_id, expense_version, trace_parent, status, created_at, updated_at)
+VALUES ('sync', ?, ?, ?, 'pending', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+RETURNING id, operation, expense_id, expense_day, expense_month, expense_description, expense_amount_cents, expense_primary, expense_secondary, status, attempts, max_attempts, last_error, created_at, updated_at, processed_at, next_retry_at, expense_version, trace_parent
+`
+
+type EnqueueSyncParams struct {
+	ExpenseID      int64       `db:"expense_id" json:"expense_id"`
+	ExpenseVersion interface{} `db:"expense_version" json:"expense_version"`
+	TraceParent    interface{} `db:"trace_parent" json:"trace_parent"`
+}
+
+// Sync Queue queries
+// Enqueues a sync operation for an expense.
+func (q *Queries) EnqueueSync(ctx context.Context, arg EnqueueSyncParams) (SyncQueue, error) {
+	row := q.db.QueryRowContext(ctx, enqueueSync, arg.ExpenseID, arg.ExpenseVersion, arg.TraceParent)
+	var i SyncQueue
+	err := row.Scan(
+		&i.ID,
+		&i.Operation,
+		&i.ExpenseID,
+		&i.ExpenseDay,
+		&i.ExpenseMonth,
+		&i.ExpenseDescription,
+		&i.ExpenseAmountCents,
+		&i.ExpensePrimary,
+		&i.ExpenseSecondary,
+		&i.Status,
+		&i.Attempts,
+		&i.MaxAttempts,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ProcessedAt,
+		&i.NextRetryAt,
+		&i.ExpenseVersion,
+		&i.TraceParent,
+	)
+	return i, err
+}
+
+const failSheetHistoryImport = `-- name: FailSheetHistoryImport :exec
+UPDATE sheet_history_imports SET last_error = ? WHERE year = ?
+`
+
+type FailSheetHistoryImportParams struct {
+	LastError string `db:"last_error" json:"last_error"`
+	Year      int64  `db:"year" json:"year"`
+}
+
+func (q *Queries) FailSheetHistoryImport(ctx context.Context, arg FailSheetHistoryImportParams) error {
+	_, err := q.db.ExecContext(ctx, failSheetHistoryImport, arg.LastError, arg.Year)
+	return err
+}
+
+const fillMonthlyAggregates = `-- name: FillMonthlyAggregates :exec
+
+INSERT INTO monthly_aggregates (kind, year, month, category, total_cents, entries)
+SELECT kind, year, month, category, total_cents, entries FROM monthly_aggregates_fresh
+`
+
+// Recomputes the aggregates from the entries; run after
+// ClearMonthlyAggregates.
+func (q *Queries) FillMonthlyAggregates(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, fillMonthlyAggregates)
+	return err
+}
+
+const findPendingExpenseMatch = `-- name: FindPendingExpenseMatch :one
+
+SELECT id, date, description, amount_cents, primary_category, secondary_category, version, created_at, synced_at, sync_status, uid, modified_at, status, paid_by, deleted_at, account_id FROM expenses
+WHERE status = 'pending' AND deleted_at IS NULL
+  AND lower(trim(description)) = lower(trim(?1))
+  AND date BETWEEN date(?2, '-10 days') AND date(?2)
+ORDER BY abs(amount_cents - ?3), date, id
+LIMIT 1
+`
+
+type FindPendingExpenseMatchParams struct {
+	Description string      `db:"description" json:"description"`
+	SettledDate interface{} `db:"settled_date" json:"settled_date"`
+	AmountCents int64       `db:"amount_cents" json:"amount_cents"`
+}
+
+// The card hold a settled transaction replaces: same description, dated up
+// to 10 days before it, closest amount first.
+func (q *Queries) FindPendingExpenseMatch(ctx context.Context, arg FindPendingExpenseMatchParams) (Expense, error) {
+	row := q.db.QueryRowContext(ctx, findPendingExpenseMatch, arg.Description, arg.SettledDate, arg.AmountCents)
+	var i Expense
+	err := row.Scan(
+		&i.ID,
+		&i.Date,
+		&i.Description,
+		&i.AmountCents,
+		&i.PrimaryCategory,
+		&i.SecondaryCategory,
+		&i.Version,
+		&i.CreatedAt,
+		&i.SyncedAt,
+		&i.SyncStatus,
+		&i.Uid,
+		&i.ModifiedAt,
+		&i.Status,
+		&i.PaidBy,
+		&i.DeletedAt,
+		&i.AccountID,
+	)
+	return i, err
+}
+
+const findSimilarExpenses = `-- name: FindSimilarExpenses :many
+
+SELECT id, date, description, amount_cents, primary_category, secondary_category, version, created_at, synced_at, sync_status, uid, modified_at, status, paid_by, deleted_at FROM expenses
+WHERE date = date(?1) AND amount_cents = ?2
+  AND deleted_at IS NULL
+ORDER BY id
+`
+
+type FindSimilarExpensesParams struct {
+	Date        interface{} `db:"date" json:"date"`
+	AmountCents int64       `db:"amount_cents" json:"amount_cents"`
+}
+
+// Live expenses of a day with an amount, to warn about a new expense that
+// looks like one of them.
+func (q *Queries) FindSimilarExpenses(ctx context.Context, arg FindSimilarExpensesParams) ([]Expense, error) {
+	rows, err := q.db.QueryContext(ctx, findSimilarExpenses, arg.Date, arg.AmountCents)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Expense
+	for rows.Next() {
+		var i Expense
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.Description,
+			&i.AmountCents,
+			&i.PrimaryCategory,
+			&i.SecondaryCategory,
+			&i.Version,
+			&i.CreatedAt,
+			&i.SyncedAt,
+			&i.SyncStatus,
+			&i.Uid,
+			&i.ModifiedAt,
+			&i.Status,
+			&i.PaidBy,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const finishJobRun = `-- name: FinishJobRun :exec
+UPDATE jobs
+SET status = ?,
+    finished_at = ?,
+    result = ?,
+    error = ?
+WHERE id = ?
+`
+
+type FinishJobRunParams struct {
+	Status     string       `db:"status" json:"status"`
+	FinishedAt sql.NullTime `db:"finished_at" json:"finished_at"`
+	Result     string       `db:"result" json:"result"`
+	Error      string       `db:"error" json:"error"`
+	ID         int64        `db:"id" json:"id"`
+}
+
+func (q *Queries) FinishJobRun(ctx context.Context, arg FinishJobRunParams) error {
+	_, err := q.db.ExecContext(ctx, finishJobRun, arg.Status, arg.FinishedAt, arg.Result, arg.Error, arg.ID)
+	return err
+}
+
+const getAccount = `-- name: GetAccount :one
+SELECT id, name, kind, opening_balance_cents, created_at FROM accounts WHERE id = ?
+`
+
+func (q *Queries) GetAccount(ctx context.Context, id int64) (Account, error) {
+	row := q.db.QueryRowContext(ctx, getAccount, id)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Kind,
+		&i.OpeningBalanceCents,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getActiveRecurrentExpensesByDate = `-- name: GetActiveRecurrentExpensesByDate :many
+SELECT id, start_date, end_date, repetition_type, description, amount_cents, primary_category, secondary_category, is_active, last_execution_date, created_at, updated_at, version, repeat_interval, day_of_month, paused, skip_until FROM recurrent_expenses
+WHERE is_active = 1
+  AND start_date <= ?
+  AND (end_date IS NULL OR end_date >= ?)
+ORDER BY start_date DESC
+`
+
+type GetActiveRecurrentExpensesByDateParams struct {
+	StartDate time.Time   `db:"start_date" json:"start_date"`
+	EndDate   interface{} `db:"end_date" json:"end_date"`
+}
+
+func (q *Queries) GetActiveRecurrentExpensesByDate(ctx context.Context, arg GetActiveRecurrentExpensesByDateParams) ([]RecurrentExpense, error) {
+	rows, err := q.db.QueryContext(ctx, getActiveRecurrentExpensesByDate, arg.StartDate, arg.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []RecurrentExpense
+	for rows.Next() {
+		var i RecurrentExpense
+		if err := rows.Scan(
+			&i.ID,
+			&i.StartDate,
+			&i.EndDate,
+			&i.RepetitionType,
+			&i.Description,
+			&i.AmountCents,
+			&i.PrimaryCategory,
+			&i.SecondaryCategory,
+			&i.IsActive,
+			&i.LastExecutionDate,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+			&i.RepeatInterval,
+			&i.DayOfMonth,
+			&i.Paused,
+			&i.SkipUntil,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getActiveRecurrentExpensesForProcessing = `-- name: GetActiveRecurrentExpensesForProcessing :many
+SELECT id, start_date, end_date, repetition_type, description, amount_cents, primary_category, secondary_category, is_active, last_execution_date, created_at, updated_at, version, repeat_interval, day_of_month, paused, skip_until FROM recurrent_expenses
+WHERE is_active = 1
+  AND paused = 0
+  AND start_date <= ?
+  AND (end_date IS NULL OR end_date >= ?)
+ORDER BY start_date ASC
+`
+
+type GetActiveRecurrentExpensesForProcessingParams struct {
+	StartDate time.Time   `db:"start_date" json:"start_date"`
+	EndDate   interface{} `db:"end_date" json:"end_date"`
+}
+
+func (q *Queries) GetActiveRecurrentExpensesForProcessing(ctx context.Context, arg GetActiveRecurrentExpensesForProcessingParams) ([]RecurrentExpense, error) {
+	rows, err := q.db.QueryContext(ctx, getActiveRecurrentExpensesForProcessing, arg.StartDate, arg.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []RecurrentExpense
+	for rows.Next() {
+		var i RecurrentExpense
+		if err := rows.Scan(
+			&i.ID,
+			&i.StartDate,
+			&i.EndDate,
+			&i.RepetitionType,
+			&i.Description,
+			&i.AmountCents,
+			&i.PrimaryCategory,
+			&i.SecondaryCategory,
+			&i.IsActive,
+			&i.LastExecutionDate,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+			&i.RepeatInterval,
+			&i.DayOfMonth,
+			&i.Paused,
+			&i.SkipUntil,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getActiveRecurrentIncomesForProcessing = `-- name: GetActiveRecurrentIncomesForProcessing :many
+SELECT id, start_date, end_date, repetition_type, repeat_interval, day_of_month, description, amount_cents, category, subcategory, is_active, last_execution_date, version, created_at, updated_at FROM recurrent_incomes
+WHERE is_active = 1
+  AND start_date <= ?
+  AND (end_date IS NULL OR end_date >= ?)
+ORDER BY start_date ASC
+`
+
+type GetActiveRecurrentIncomesForProcessingParams struct {
+	StartDate time.Time   `db:"start_date" json:"start_date"`
+	EndDate   interface{} `db:"end_date" json:"end_date"`
+}
+
+func (q *Queries) GetActiveRecurrentIncomesForProcessing(ctx context.Context, arg GetActiveRecurrentIncomesForProcessingParams) ([]RecurrentIncome, error) {
+	rows, err := q.db.QueryContext(ctx, getActiveRecurrentIncomesForProcessing, arg.StartDate, arg.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []RecurrentIncome
+	for rows.Next() {
+		var i RecurrentIncome
+		if err := rows.Scan(
+			&i.ID,
+			&i.StartDate,
+			&i.EndDate,
+			&i.RepetitionType,
+			&i.RepeatInterval,
+			&i.DayOfMonth,
+			&i.Description,
+			&i.AmountCents,
+			&i.Category,
+			&i.Subcategory,
+			&i.IsActive,
+			&i.LastExecutionDate,
+			&i.Version,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAllCategoriesWithSubs = `-- name: GetAllCategoriesWithSubs :many
+SELECT pc.name as primary_name, sc.name as secondary_name
+FROM primary_categories pc
+LEFT JOIN secondary_categories sc ON sc.primary_category_id = pc.id AND sc.archived = 0
+WHERE pc.archived = 0
+ORDER BY pc.name ASC, sc.name ASC
+`
+
+type GetAllCategoriesWithSubsRow struct {
+	PrimaryName   string         `db:"primary_name" json:"primary_name"`
+	SecondaryName sql.NullString `db:"secondary_name" json:"secondary_name"`
+}
+
+func (q *Queries) GetAllCategoriesWithSubs(ctx context.Context) ([]GetAllCategoriesWithSubsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getAllCategoriesWithSubs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetAllCategoriesWithSubsRow
+	for rows.Next() {
+		var i GetAllCategoriesWithSubsRow
+		if err := rows.Scan(&i.PrimaryName, &i.SecondaryName); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getApiKeyUser = `-- name: GetApiKeyUser :one
+
+SELECT k.id, u.id AS user_id, u.name
+FROM api_keys k
+JOIN users u ON u.id = k.user_id
+WHERE k.key_hash = ?
+`
+
+type GetApiKeyUserRow struct {
+	ID     int64  `db:"id" json:"id"`
+	UserID int64  `db:"user_id" json:"user_id"`
+	Name   string `db:"name" json:"name"`
+}
+
+// The key with the given hash and its member.
+func (q *Queries) GetApiKeyUser(ctx context.Context, keyHash string) (GetApiKeyUserRow, error) {
+	row := q.db.QueryRowContext(ctx, getApiKeyUser, keyHash)
+	var i GetApiKeyUserRow
+	err := row.Scan(&i.ID, &i.UserID, &i.Name)
+	return i, err
+}
+
+const getAttachmentBytes = `-- name: GetAttachmentBytes :one
+
+SELECT CAST(COALESCE(SUM(size_bytes), 0) AS INTEGER) AS total_bytes
+FROM receipts
+WHERE uploaded_by = ?1 AND expense_id != ?2
+`
+
+type GetAttachmentBytesParams struct {
+	UserID        sql.NullInt64 `db:"user_id" json:"user_id"`
+	ExceptExpense int64         `db:"except_expense" json:"except_expense"`
+}
+
+// Bytes of the receipts a member uploaded, leaving out the receipt of
+// except_expense (the one an upload would replace).
+func (q *Queries) GetAttachmentBytes(ctx context.Context, arg GetAttachmentBytesParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getAttachmentBytes, arg.UserID, arg.ExceptExpense)
+	var total_bytes int64
+	err := row.Scan(&total_bytes)
+	return total_bytes, err
+}
+
+const getCategoriesOrderedByUsage = `-- name: GetCategoriesOrderedByUsage :many
+SELECT
+  pc.name as primary_name,
+  sc.name as secondary_name,
+  COALESCE(exp_count.cnt, 0) as usage_count
+FROM primary_categories pc
+LEFT JOIN secondary_categories sc ON sc.primary_category_id = pc.id AND sc.archived = 0
+LEFT JOIN (
+  SELECT primary_category, secondary_category, COUNT(*) as cnt
+  FROM expenses
+  WHERE deleted_at IS NULL
+  GROUP BY primary_category, secondary_category
+) exp_count ON exp_count.primary_category = pc.name AND exp_count.secondary_category = sc.name
+WHERE pc.archived = 0
+ORDER BY
+  COALESCE((SELECT SUM(cnt) FROM (SELECT COUNT(*) as cnt FROM expenses WHERE primary_category = pc.name AND deleted_at IS NULL GROUP BY primary_category)), 0) DESC,
+  pc.name ASC,
+  COALESCE(exp_count.cnt, 0) DESC,
+  sc.name ASC
+`
+
+type GetCategoriesOrderedByUsageRow struct {
+	PrimaryName   string         `db:"primary_name" json:"primary_name"`
+	SecondaryName sql.NullString `db:"secondary_name" json:"secondary_name"`
+	UsageCount    int64          `db:"usage_count" json:"usage_count"`
+}
+
+func (q *Queries) GetCategoriesOrderedByUsage(ctx context.Context) ([]GetCategoriesOrderedByUsageRow, error) {
+	rows, err := q.db.QueryContext(ctx, getCategoriesOrderedByUsage)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetCategoriesOrderedByUsageRow
+	for rows.Next() {
+		var i GetCategoriesOrderedByUsageRow
+		if err := rows.Scan(&i.PrimaryName, &i.SecondaryName, &i.UsageCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getCategoryMapping = `-- name: GetCategoryMapping :one
+SELECT primary_category FROM category_mappings
+WHERE sheet_name = ?
+`
+
+// Returns the primary category a Google Sheets secondary category maps to.
+func (q *Queries) GetCategoryMapping(ctx context.Context, sheetName string) (string, error) {
+	row := q.db.QueryRowContext(ctx, getCategoryMapping, sheetName)
+	var primary_category string
+	err := row.Scan(&primary_category)
+	return primary_category, err
+}
+
+const getCategoryPairByID = `-- name: GetCategoryPairByID :one
+SELECT pc.name AS primary_name, sc.name AS secondary_name
+FROM secondary_categories sc
+JOIN primary_categories pc ON pc.id = sc.primary_category_id
+WHERE sc.id = ?
+`
+
+type GetCategoryPairByIDRow struct {
+	PrimaryName   string `db:"primary_name" json:"primary_name"`
+	SecondaryName string `db:"secondary_name" json:"secondary_name"`
+}
+
+// Names of a secondary category and of its primary category.
+func (q *Queries) GetCategoryPairByID(ctx context.Context, id int64) (GetCategoryPairByIDRow, error) {
+	row := q.db.QueryRowContext(ctx, getCategoryPairByID, id)
+	var i GetCategoryPairByIDRow
+	err := row.Scan(
+		&i.PrimaryName,
+		&i.SecondaryName,
+	)
+	return i, err
+}
+
+const getCategorySums = `-- name: GetCategorySums :many
+SELECT category AS primary_category, total_cents AS total_amount
+FROM monthly_aggregates
+WHERE kind = 'expense' AND year = ? AND month = ?
+ORDER BY total_amount DESC
+`
+
+type GetCategorySumsParams struct {
+	Year  int64 `db:"year" json:"year"`
+	Month int64 `db:"month" json:"month"`
+}
+
+type GetCategorySumsRow struct {
+	PrimaryCategory string `db:"primary_category" json:"primary_category"`
+	TotalAmount     int64  `db:"total_amount" json:"total_amount"`
+}
+
+func (q *Queries) GetCategorySums(ctx context.Context, arg GetCategorySumsParams) ([]GetCategorySumsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getCategorySums, arg.Year, arg.Month)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetCategorySumsRow
+	for rows.Next() {
+		var i GetCategorySumsRow
+		if err := rows.Scan(&i.PrimaryCategory, &i.TotalAmount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDailyExpenses = `-- name: GetDailyExpenses :one
+SELECT CAST(COALESCE(SUM(expenses), 0) AS INTEGER) AS expenses
+FROM user_daily_usage
+WHERE user_id = ? AND day = ?
+`
+
+type GetDailyExpensesParams struct {
+	UserID int64  `db:"user_id" json:"user_id"`
+	Day    string `db:"day" json:"day"`
+}
+
+func (q *Queries) GetDailyExpenses(ctx context.Context, arg GetDailyExpensesParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getDailyExpenses, arg.UserID, arg.Day)
+	var expenses int64
+	err := row.Scan(&expenses)
+	return expenses, err
+}
+
+const getExpense = `-- name: GetExpense :one
+SELECT id, date, description, amount_cents, primary_category, secondary_category, version, created_at, synced_at, sync_status, uid, modified_at, status, paid_by, deleted_at, account_id FROM expenses WHERE id = ? AND deleted_at IS NULL
+`
+
+func (q *Queries) GetExpense(ctx context.Context, id int64) (Expense, error) {
+	row := q.db.QueryRowContext(ctx, getExpense, id)
+	var i Expense
+	err := row.Scan(
+		&i.ID,
+		&i.Date,
+		&i.Description,
+		&i.AmountCents,
+		&i.PrimaryCategory,
+		&i.SecondaryCategory,
+		&i.Version,
+		&i.CreatedAt,
+		&i.SyncedAt,
+		&i.SyncStatus,
+		&i.Uid,
+		&i.ModifiedAt,
+		&i.Status,
+		&i.PaidBy,
+		&i.DeletedAt,
+		&i.AccountID,
+	)
+	return i, err
+}
+
+const getExpenseByUID = `-- name: GetExpenseByUID :one
+SELECT id, date, description, amount_cents, primary_category, secondary_category, version, created_at, synced_at, sync_status, uid, modified_at, status, paid_by, deleted_at, account_id FROM expenses WHERE uid = ?
+`
+
+func (q *Queries) GetExpenseByUID(ctx context.Context, uid sql.NullString) (Expense, error) {
+	row := q.db.QueryRowContext(ctx, getExpenseByUID, uid)
+	var i Expense
+	err := row.Scan(
+		&i.ID,
+		&i.Date,
+		&i.Description,
+		&i.AmountCents,
+		&i.PrimaryCategory,
+		&i.SecondaryCategory,
+		&i.Version,
+		&i.CreatedAt,
+		&i.SyncedAt,
+		&i.SyncStatus,
+		&i.Uid,
+		&i.ModifiedAt,
+		&i.Status,
+		&i.PaidBy,
+		&i.DeletedAt,
+		&i.AccountID,
+	)
+	return i, err
+}
+
+const getExpenseCalculation = `-- name: GetExpenseCalculation :one
+SELECT expense_id, kind, quantity, rate_cents, created_at FROM expense_calculations
+WHERE expense_id = ?
+`
+
+func (q *Queries) GetExpenseCalculation(ctx context.Context, expenseID int64) (ExpenseCalculation, error) {
+	row := q.db.QueryRowContext(ctx, getExpenseCalculation, expenseID)
+	var i ExpenseCalculation
+	err := row.Scan(
+		&i.ExpenseID,
+		&i.Kind,
+		&i.Quantity,
+		&i.RateCents,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getExpenseReimbursedTotal = `-- name: GetExpenseReimbursedTotal :one
+SELECT CAST(COALESCE(SUM(amount_cents), 0) AS INTEGER) as total
+FROM expense_reimbursements
+WHERE expense_id = ?
+`
+
+func (q *Queries) GetExpenseReimbursedTotal(ctx context.Context, expenseID int64) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getExpenseReimbursedTotal, expenseID)
+	var total int64
+	err := row.Scan(&total)
+	return total, err
+}
+
+const getExpenseWorkflowState = `-- name: GetExpenseWorkflowState :one
+SELECT state FROM expense_workflow
+WHERE expense_id = ?
+`
+
+func (q *Queries) GetExpenseWorkflowState(ctx context.Context, expenseID int64) (string, error) {
+	row := q.db.QueryRowContext(ctx, getExpenseWorkflowState, expenseID)
+	var state string
+	err := row.Scan(&state)
+	return state, err
+}
+
+const getExpensesByMonth = `-- name: GetExpensesByMonth :many
+SELECT id, date, description, amount_cents, primary_category, secondary_category, version, created_at, synced_at, sync_status, uid, modified_at, status, paid_by, deleted_at, account_id FROM expenses
+WHERE strftime('%Y', date) = printf('%04d', ?)
+  AND strftime('%m', date) = printf('%02d', ?)
+  AND deleted_at IS NULL
+ORDER BY date DESC, created_at DESC
+`
+
+type GetExpensesByMonthParams struct {
+	PRINTF   interface{} `db:"PRINTF" json:"PRINTF"`
+	PRINTF_2 interface{} `db:"PRINTF_2" json:"PRINTF_2"`
+}
+
+func (q *Queries) GetExpensesByMonth(ctx context.Context, arg GetExpensesByMonthParams) ([]Expense, error) {
+	rows, err := q.db.QueryContext(ctx, getExpensesByMonth, arg.PRINTF, arg.PRINTF_2)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Expense
+	for rows.Next() {
+		var i Expense
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.Description,
+			&i.AmountCents,
+			&i.PrimaryCategory,
+			&i.SecondaryCategory,
+			&i.Version,
+			&i.CreatedAt,
+			&i.SyncedAt,
+			&i.SyncStatus,
+			&i.Uid,
+			&i.ModifiedAt,
+			&i.Status,
+			&i.PaidBy,
+			&i.DeletedAt,
+			&i.AccountID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFuelFill = `-- name: GetFuelFill :one
+SELECT expense_id, liters, odometer_km, created_at FROM fuel_fills
+WHERE expense_id = ?
+`
+
+func (q *Queries) GetFuelFill(ctx context.Context, expenseID int64) (FuelFill, error) {
+	row := q.db.QueryRowContext(ctx, getFuelFill, expenseID)
+	var i FuelFill
+	err := row.Scan(
+		&i.ExpenseID,
+		&i.Liters,
+		&i.OdometerKm,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getImportBatch = `-- name: GetImportBatch :one
+SELECT id, filename, expenses, created_at, rolled_back_at FROM import_batches WHERE id = ?
+`
+
+func (q *Queries) GetImportBatch(ctx context.Context, id int64) (ImportBatch, error) {
+	row := q.db.QueryRowContext(ctx, getImportBatch, id)
+	var i ImportBatch
+	err := row.Scan(
+		&i.ID,
+		&i.Filename,
+		&i.Expenses,
+		&i.CreatedAt,
+		&i.RolledBackAt,
+	)
+	return i, err
+}
+
+const getIncome = `-- name: GetIncome :one
+SELECT id, date, description, amount_cents, category, version, created_at, synced_at, sync_status, uid, modified_at, subcategory, tags, deleted_at, account_id FROM incomes WHERE id = ? AND deleted_at IS NULL
+`
+
+func (q *Queries) GetIncome(ctx context.Context, id int64) (Income, error) {
+	row := q.db.QueryRowContext(ctx, getIncome, id)
+	var i Income
+	err := row.Scan(
+		&i.ID,
+		&i.Date,
+		&i.Description,
+		&i.AmountCents,
+		&i.Category,
+		&i.Version,
+		&i.CreatedAt,
+		&i.SyncedAt,
+		&i.SyncStatus,
+		&i.Uid,
+		&i.ModifiedAt,
+		&i.Subcategory,
+		&i.Tags,
+		&i.DeletedAt,
+		&i.AccountID,
+	)
+	return i, err
+}
+
+const getIncomeByUID = `-- name: GetIncomeByUID :one
+SELECT id, date, description, amount_cents, category, version, created_at, synced_at, sync_status, uid, modified_at, subcategory, tags, deleted_at, account_id FROM incomes WHERE uid = ?
+`
+
+func (q *Queries) GetIncomeByUID(ctx context.Context, uid sql.NullString) (Income, error) {
+	row := q.db.QueryRowContext(ctx, getIncomeByUID, uid)
+	var i Income
+	err := row.Scan(
+		&i.ID,
+		&i.Date,
+		&i.Description,
+		&i.AmountCents,
+		&i.Category,
+		&i.Version,
+		&i.CreatedAt,
+		&i.SyncedAt,
+		&i.SyncStatus,
+		&i.Uid,
+		&i.ModifiedAt,
+		&i.Subcategory,
+		&i.Tags,
+		&i.DeletedAt,
+		&i.AccountID,
+	)
+	return i, err
+}
+
+const getIncomeCategories = `-- name: GetIncomeCategories :many
+SELECT name FROM income_categories
+ORDER BY name ASC
+`
+
+func (q *Queries) GetIncomeCategories(ctx context.Context) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, getIncomeCategories)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		items = append(items, name)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getIncomeCategorySums = `-- name: GetIncomeCategorySums :many
+SELECT category, total_cents AS total_amount
+FROM monthly_aggregates
+WHERE kind = 'income' AND year = ? AND month = ?
+ORDER BY total_amount DESC
+`
+
+type GetIncomeCategorySumsParams struct {
+	Year  int64 `db:"year" json:"year"`
+	Month int64 `db:"month" json:"month"`
+}
+
+type GetIncomeCategorySumsRow struct {
+	Category    string `db:"category" json:"category"`
+	TotalAmount int64  `db:"total_amount" json:"total_amount"`
+}
+
+func (q *Queries) GetIncomeCategorySums(ctx context.Context, arg GetIncomeCategorySumsParams) ([]GetIncomeCategorySumsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getIncomeCategorySums, arg.Year, arg.Month)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetIncomeCategorySumsRow
+	for rows.Next() {
+		var i GetIncomeCategorySumsRow
+		if err := rows.Scan(&i.Category, &i.TotalAmount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getIncomeMonthTotal = `-- name: GetIncomeMonthTotal :one
+SELECT CAST(COALESCE(SUM(total_cents), 0) AS INTEGER) as total
+FROM monthly_aggregates
+WHERE kind = 'income' AND year = ? AND month = ?
+`
+
+type GetIncomeMonthTotalParams struct {
+	Year  int64 `db:"year" json:"year"`
+	Month int64 `db:"month" json:"month"`
+}
+
+func (q *Queries) GetIncomeMonthTotal(ctx context.Context, arg GetIncomeMonthTotalParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getIncomeMonthTotal, arg.Year, arg.Month)
+	var total int64
+	err := row.Scan(&total)
+	return total, err
+}
+
+const getIncomeReimbursedTotal = `-- name: GetIncomeReimbursedTotal :one
+SELECT CAST(COALESCE(SUM(amount_cents), 0) AS INTEGER) as total
+FROM expense_reimbursements
+WHERE income_id = ?
+`
+
+func (q *Queries) GetIncomeReimbursedTotal(ctx context.Context, incomeID int64) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getIncomeReimbursedTotal, incomeID)
+	var total int64
+	err := row.Scan(&total)
+	return total, err
+}
+
+const getIncomeSubcategories = `-- name: GetIncomeSubcategories :many
+SELECT DISTINCT subcategory FROM incomes
+WHERE category = ? AND subcategory != '' AND deleted_at IS NULL
+ORDER BY subcategory ASC
+`
+
+func (q *Queries) GetIncomeSubcategories(ctx context.Context, category string) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, getIncomeSubcategories, category)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var subcategory string
+		if err := rows.Scan(&subcategory); err != nil {
+			return nil, err
+		}
+		items = append(items, subcategory)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getIncomeSubcategorySums = `-- name: GetIncomeSubcategorySums :many
+SELECT category, subcategory, CAST(SUM(amount_cents) AS INTEGER) as total_amount
+FROM incomes
+WHERE strftime('%Y', date) = printf('%04d', ?)
+  AND strftime('%m', date) = printf('%02d', ?)
+  AND deleted_at IS NULL
+  AND subcategory != ''
+GROUP BY category, subcategory
+ORDER BY total_amount DESC
+`
+
+type GetIncomeSubcategorySumsParams struct {
+	PRINTF   interface{} `db:"PRINTF" json:"PRINTF"`
+	PRINTF_2 interface{} `db:"PRINTF_2" json:"PRINTF_2"`
+}
+
+type GetIncomeSubcategorySumsRow struct {
+	Category    string `db:"category" json:"category"`
+	Subcategory string `db:"subcategory" json:"subcategory"`
+	TotalAmount int64  `db:"total_amount" json:"total_amount"`
+}
+
+func (q *Queries) GetIncomeSubcategorySums(ctx context.Context, arg GetIncomeSubcategorySumsParams) ([]GetIncomeSubcategorySumsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getIncomeSubcategorySums, arg.PRINTF, arg.PRINTF_2)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetIncomeSubcategorySumsRow
+	for rows.Next() {
+		var i GetIncomeSubcategorySumsRow
+		if err := rows.Scan(&i.Category, &i.Subcategory, &i.TotalAmount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getIncomesByMonth = `-- name: GetIncomesByMonth :many
+SELECT id, date, description, amount_cents, category, version, created_at, synced_at, sync_status, uid, modified_at, subcategory, tags, deleted_at, account_id FROM incomes
+WHERE strftime('%Y', date) = printf('%04d', ?)
+  AND strftime('%m', date) = printf('%02d', ?)
+  AND deleted_at IS NULL
+ORDER BY date DESC, created_at DESC
+`
+
+type GetIncomesByMonthParams struct {
+	PRINTF   interface{} `db:"PRINTF" json:"PRINTF"`
+	PRINTF_2 interface{} `db:"PRINTF_2" json:"PRINTF_2"`
+}
+
+func (q *Queries) GetIncomesByMonth(ctx context.Context, arg GetIncomesByMonthParams) ([]Income, error) {
+	rows, err := q.db.QueryContext(ctx, getIncomesByMonth, arg.PRINTF, arg.PRINTF_2)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Income
+	for rows.Next() {
+		var i Income
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.Description,
+			&i.AmountCents,
+			&i.Category,
+			&i.Version,
+			&i.CreatedAt,
+			&i.SyncedAt,
+			&i.SyncStatus,
+			&i.Uid,
+			&i.ModifiedAt,
+			&i.Subcategory,
+			&i.Tags,
+			&i.DeletedAt,
+			&i.AccountID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getInsight = `-- name: GetInsight :one
+SELECT id, kind, primary_category, period, occurred_on, amount_cents, baseline_cents, days, since_period, dismissed_at, created_at FROM insights WHERE id = ?
+`
+
+func (q *Queries) GetInsight(ctx context.Context, id int64) (Insight, error) {
+	row := q.db.QueryRowContext(ctx, getInsight, id)
+	var i Insight
+	err := row.Scan(
+		&i.ID,
+		&i.Kind,
+		&i.PrimaryCategory,
+		&i.Period,
+		&i.OccurredOn,
+		&i.AmountCents,
+		&i.BaselineCents,
+		&i.Days,
+		&i.SincePeriod,
+		&i.DismissedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getItemIDByNormalizedName = `-- name: GetItemIDByNormalizedName :one
+SELECT id FROM items
+WHERE normalized_name = ?
+`
+
+func (q *Queries) GetItemIDByNormalizedName(ctx context.Context, normalizedName string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getItemIDByNormalizedName, normalizedName)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const getJobRuns = `-- name: GetJobRuns :many
+SELECT id, job, source, attempt, status, started_at, finished_at, result, error FROM jobs
+WHERE job = ?
+ORDER BY id DESC
+LIMIT ?
+`
+
+type GetJobRunsParams struct {
+	Job   string `db:"job" json:"job"`
+	Limit int64  `db:"limit" json:"limit"`
+}
+
+func (q *Queries) GetJobRuns(ctx context.Context, arg GetJobRunsParams) ([]Job, error) {
+	rows, err := q.db.QueryContext(ctx, getJobRuns, arg.Job, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Job
+	for rows.Next() {
+		var i Job
+		if err := rows.Scan(
+			&i.ID,
+			&i.Job,
+			&i.Source,
+			&i.Attempt,
+			&i.Status,
+			&i.StartedAt,
+			&i.FinishedAt,
+			&i.Result,
+			&i.Error,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLatestJobRuns = `-- name: GetLatestJobRuns :many
+SELECT id, job, source, attempt, status, started_at, finished_at, result, error FROM jobs
+WHERE id IN (SELECT MAX(id) FROM jobs GROUP BY job)
+ORDER BY job
+`
+
+// Latest run of each job
+func (q *Queries) GetLatestJobRuns(ctx context.Context) ([]Job, error) {
+	rows, err := q.db.QueryContext(ctx, getLatestJobRuns)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Job
+	for rows.Next() {
+		var i Job
+		if err := rows.Scan(
+			&i.ID,
+			&i.Job,
+			&i.Source,
+			&i.Attempt,
+			&i.Status,
+			&i.StartedAt,
+			&i.FinishedAt,
+			&i.Result,
+			&i.Error,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLedgerTotals = `-- name: GetLedgerTotals :one
+SELECT COUNT(*) AS expenses, CAST(COALESCE(SUM(amount_cents), 0) AS INTEGER) AS total_cents
+FROM expenses
+WHERE date BETWEEN date(?1) AND date(?2)
+  AND deleted_at IS NULL
+`
+
+type GetLedgerTotalsParams struct {
+	FromDate interface{} `db:"from_date" json:"from_date"`
+	ToDate   interface{} `db:"to_date" json:"to_date"`
+}
+
+type GetLedgerTotalsRow struct {
+	Expenses   int64 `db:"expenses" json:"expenses"`
+	TotalCents int64 `db:"total_cents" json:"total_cents"`
+}
+
+func (q *Queries) GetLedgerTotals(ctx context.Context, arg GetLedgerTotalsParams) (GetLedgerTotalsRow, error) {
+	row := q.db.QueryRowContext(ctx, getLedgerTotals, arg.FromDate, arg.ToDate)
+	var i GetLedgerTotalsRow
+	err := row.Scan(&i.Expenses, &i.TotalCents)
+	return i, err
+}
+
+const getMonthReview = `-- name: GetMonthReview :one
+SELECT period, closed_at FROM month_reviews WHERE period = ?
+`
+
+func (q *Queries) GetMonthReview(ctx context.Context, period string) (MonthReview, error) {
+	row := q.db.QueryRowContext(ctx, getMonthReview, period)
+	var i MonthReview
+	err := row.Scan(&i.Period, &i.ClosedAt)
+	return i, err
+}
+
+const getMonthTotal = `-- name: GetMonthTotal :one
+SELECT CAST(COALESCE(SUM(total_cents), 0) AS INTEGER) as total
+FROM monthly_aggregates
+WHERE kind = 'expense' AND year = ? AND month = ?
+`
+
+type GetMonthTotalParams struct {
+	Year  int64 `db:"year" json:"year"`
+	Month int64 `db:"month" json:"month"`
+}
+
+func (q *Queries) GetMonthTotal(ctx context.Context, arg GetMonthTotalParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getMonthTotal, arg.Year, arg.Month)
+	var total int64
+	err := row.Scan(&total)
+	return total, err
+}
+
+const getMonthTotalsByPayer = `-- name: GetMonthTotalsByPayer :many
+
+SELECT paid_by, CAST(SUM(amount_cents) AS INTEGER) as total_amount
+FROM expenses
+WHERE status = 'cleared' AND deleted_at IS NULL
+  AND strftime('%Y', date) = printf('%04d', ?)
+  AND strftime('%m', date) = printf('%02d', ?)
+GROUP BY paid_by
+`
+
+type GetMonthTotalsByPayerParams struct {
+	PRINTF   interface{} `db:"PRINTF" json:"PRINTF"`
+	PRINTF_2 interface{} `db:"PRINTF_2" json:"PRINTF_2"`
+}
+
+type GetMonthTotalsByPayerRow struct {
+	PaidBy      sql.NullInt64 `db:"paid_by" json:"paid_by"`
+	TotalAmount int64         `db:"total_amount" json:"total_amount"`
+}
+
+// Settled expenses of a month per payer; a NULL payer collects the
+// expenses nobody is set on.
+func (q *Queries) GetMonthTotalsByPayer(ctx context.Context, arg GetMonthTotalsByPayerParams) ([]GetMonthTotalsByPayerRow, error) {
+	rows, err := q.db.QueryContext(ctx, getMonthTotalsByPayer, arg.PRINTF, arg.PRINTF_2)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetMonthTotalsByPayerRow
+	for rows.Next() {
+		var i GetMonthTotalsByPayerRow
+		if err := rows.Scan(&i.PaidBy, &i.TotalAmount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPayerCategoryTotals = `-- name: GetPayerCategoryTotals :many
+
+SELECT primary_category, CAST(SUM(amount_cents) AS INTEGER) as total_amount
+FROM expenses
+WHERE paid_by = ?1 AND deleted_at IS NULL
+  AND date BETWEEN date(?2) AND date(?3)
+GROUP BY primary_category
+ORDER BY total_amount DESC
+`
+
+type GetPayerCategoryTotalsParams struct {
+	PaidBy    sql.NullInt64 `db:"paid_by" json:"paid_by"`
+	StartDate interface{}   `db:"start_date" json:"start_date"`
+	EndDate   interface{}   `db:"end_date" json:"end_date"`
+}
+
+type GetPayerCategoryTotalsRow struct {
+	PrimaryCategory string `db:"primary_category" json:"primary_category"`
+	TotalAmount     int64  `db:"total_amount" json:"total_amount"`
+}
+
+// Expenses paid by a member per primary category between two dates.
+func (q *Queries) GetPayerCategoryTotals(ctx context.Context, arg GetPayerCategoryTotalsParams) ([]GetPayerCategoryTotalsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPayerCategoryTotals, arg.PaidBy, arg.StartDate, arg.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPayerCategoryTotalsRow
+	for rows.Next() {
+		var i GetPayerCategoryTotalsRow
+		if err := rows.Scan(&i.PrimaryCategory, &i.TotalAmount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPeerSyncState = `-- name: GetPeerSyncState :one
+SELECT peer, pull_cursor, push_cursor, last_sync_at FROM peer_sync_state WHERE peer = ?
+`
+
+func (q *Queries) GetPeerSyncState(ctx context.Context, peer string) (PeerSyncState, error) {
+	row := q.db.QueryRowContext(ctx, getPeerSyncState, peer)
+	var i PeerSyncState
+	err := row.Scan(
+		&i.Peer,
+		&i.PullCursor,
+		&i.PushCursor,
+		&i.LastSyncAt,
+	)
+	return i, err
+}
+
+const getPeerTombstone = `-- name: GetPeerTombstone :one
+SELECT kind, uid, version, deleted_at FROM peer_tombstones WHERE kind = ? AND uid = ?
+`
+
+type GetPeerTombstoneParams struct {
+	Kind string `db:"kind" json:"kind"`
+	Uid  string `db:"uid" json:"uid"`
+}
+
+func (q *Queries) GetPeerTombstone(ctx context.Context, arg GetPeerTombstoneParams) (PeerTombstone, error) {
+	row := q.db.QueryRowContext(ctx, getPeerTombstone, arg.Kind, arg.Uid)
+	var i PeerTombstone
+	err := row.Scan(
+		&i.Kind,
+		&i.Uid,
+		&i.Version,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const getPendingCategorySums = `-- name: GetPendingCategorySums :many
+
+SELECT primary_category, CAST(SUM(amount_cents) AS INTEGER) as total_amount
+FROM expenses
+WHERE status = 'pending' AND deleted_at IS NULL
+  AND strftime('%Y', date) = printf('%04d', ?)
+  AND strftime('%m', date) = printf('%02d', ?)
+GROUP BY primary_category
+`
+
+type GetPendingCategorySumsParams struct {
+	PRINTF   interface{} `db:"PRINTF" json:"PRINTF"`
+	PRINTF_2 interface{} `db:"PRINTF_2" json:"PRINTF_2"`
+}
+
+type GetPendingCategorySumsRow struct {
+	PrimaryCategory string `db:"primary_category" json:"primary_category"`
+	TotalAmount     int64  `db:"total_amount" json:"total_amount"`
+}
+
+// Amounts of card holds not yet settled per primary category.
+func (q *Queries) GetPendingCategorySums(ctx context.Context, arg GetPendingCategorySumsParams) ([]GetPendingCategorySumsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPendingCategorySums, arg.PRINTF, arg.PRINTF_2)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPendingCategorySumsRow
+	for rows.Next() {
+		var i GetPendingCategorySumsRow
+		if err := rows.Scan(&i.PrimaryCategory, &i.TotalAmount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPendingSyncExpenses = `-- name: GetPendingSyncExpenses :many
+SELECT id, version, created_at FROM expenses 
+WHERE sync_status = 'pending' AND status = 'cleared' AND deleted_at IS NULL
+ORDER BY created_at ASC
+LIMIT ?
+`
+
+type GetPendingSyncExpensesRow struct {
+	ID        int64        `db:"id" json:"id"`
+	Version   int64        `db:"version" json:"version"`
+	CreatedAt sql.NullTime `db:"created_at" json:"created_at"`
+}
+
+func (q *Queries) GetPendingSyncExpenses(ctx context.Context, limit int64) ([]GetPendingSyncExpensesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPendingSyncExpenses, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPendingSyncExpensesRow
+	for rows.Next() {
+		var i GetPendingSyncExpensesRow
+		if err := rows.Scan(&i.ID, &i.Version, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPrimaryCategories = `-- name: GetPrimaryCategories :many
+SELECT name FROM primary_categories 
+WHERE archived = 0
+ORDER BY name ASC
+`
+
+// Primary Categories queries
+func (q *Queries) GetPrimaryCategories(ctx context.Context) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, getPrimaryCategories)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		items = append(items, name)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPrimaryCategoryByName = `-- name: GetPrimaryCategoryByName :one
+SELECT id, name, created_at, archived FROM primary_categories
+WHERE name = ?
+`
+
+func (q *Queries) GetPrimaryCategoryByName(ctx context.Context, name string) (PrimaryCategory, error) {
+	row := q.db.QueryRowContext(ctx, getPrimaryCategoryByName, name)
+	var i PrimaryCategory
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.Archived,
+	)
+	return i, err
+}
+
+const getReceipt = `-- name: GetReceipt :one
+SELECT expense_id, blob_key, thumbnail_key, content_type, filename, size_bytes, created_at, uploaded_by FROM receipts
+WHERE expense_id = ?
+`
+
+func (q *Queries) GetReceipt(ctx context.Context, expenseID int64) (Receipt, error) {
+	row := q.db.QueryRowContext(ctx, getReceipt, expenseID)
+	var i Receipt
+	err := row.Scan(
+		&i.ExpenseID,
+		&i.BlobKey,
+		&i.ThumbnailKey,
+		&i.ContentType,
+		&i.Filename,
+		&i.SizeBytes,
+		&i.CreatedAt,
+		&i.UploadedBy,
+	)
+	return i, err
+}
+
+const getRecurrentExpenseByID = `-- name: GetRecurrentExpenseByID :one
+SELECT id, start_date, end_date, repetition_type, description, amount_cents, primary_category, secondary_category, is_active, last_execution_date, created_at, updated_at, version, repeat_interval, day_of_month, paused, skip_until FROM recurrent_expenses
+WHERE id = ?
+`
+
+func (q *Queries) GetRecurrentExpenseByID(ctx context.Context, id int64) (RecurrentExpense, error) {
+	row := q.db.QueryRowContext(ctx, getRecurrentExpenseByID, id)
+	var i RecurrentExpense
+	err := row.Scan(
+		&i.ID,
+		&i.StartDate,
+		&i.EndDate,
+		&i.RepetitionType,
+		&i.Description,
+		&i.AmountCents,
+		&i.PrimaryCategory,
+		&i.SecondaryCategory,
+		&i.IsActive,
+		&i.LastExecutionDate,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.RepeatInterval,
+		&i.DayOfMonth,
+		&i.Paused,
+		&i.SkipUntil,
+	)
+	return i, err
+}
+
+const getRecurrentExpenses = `-- name: GetRecurrentExpenses :many
+SELECT id, start_date, end_date, repetition_type, description, amount_cents, primary_category, secondary_category, is_active, last_execution_date, created_at, updated_at, version, repeat_interval, day_of_month, paused, skip_until FROM recurrent_expenses
+WHERE is_active = 1
+ORDER BY start_date DESC
+`
+
+func (q *Queries) GetRecurrentExpenses(ctx context.Context) ([]RecurrentExpense, error) {
+	rows, err := q.db.QueryContext(ctx, getRecurrentExpenses)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []RecurrentExpense
+	for rows.Next() {
+		var i RecurrentExpense
+		if err := rows.Scan(
+			&i.ID,
+			&i.StartDate,
+			&i.EndDate,
+			&i.RepetitionType,
+			&i.Description,
+			&i.AmountCents,
+			&i.PrimaryCategory,
+			&i.SecondaryCategory,
+			&i.IsActive,
+			&i.LastExecutionDate,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+			&i.RepeatInterval,
+			&i.DayOfMonth,
+			&i.Paused,
+			&i.SkipUntil,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRecurrentIncomeByID = `-- name: GetRecurrentIncomeByID :one
+SELECT id, start_date, end_date, repetition_type, repeat_interval, day_of_month, description, amount_cents, category, subcategory, is_active, last_execution_date, version, created_at, updated_at FROM recurrent_incomes
+WHERE id = ?
+`
+
+func (q *Queries) GetRecurrentIncomeByID(ctx context.Context, id int64) (RecurrentIncome, error) {
+	row := q.db.QueryRowContext(ctx, getRecurrentIncomeByID, id)
+	var i RecurrentIncome
+	err := row.Scan(
+		&i.ID,
+		&i.StartDate,
+		&i.EndDate,
+		&i.RepetitionType,
+		&i.RepeatInterval,
+		&i.DayOfMonth,
+		&i.Description,
+		&i.AmountCents,
+		&i.Category,
+		&i.Subcategory,
+		&i.IsActive,
+		&i.LastExecutionDate,
+		&i.Version,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getRecurrentIncomes = `-- name: GetRecurrentIncomes :many
+SELECT id, start_date, end_date, repetition_type, repeat_interval, day_of_month, description, amount_cents, category, subcategory, is_active, last_execution_date, version, created_at, updated_at FROM recurrent_incomes
+WHERE is_active = 1
+ORDER BY start_date DESC
+`
+
+func (q *Queries) GetRecurrentIncomes(ctx context.Context) ([]RecurrentIncome, error) {
+	rows, err := q.db.QueryContext(ctx, getRecurrentIncomes)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []RecurrentIncome
+	for rows.Next() {
+		var i RecurrentIncome
+		if err := rows.Scan(
+			&i.ID,
+			&i.StartDate,
+			&i.EndDate,
+			&i.RepetitionType,
+			&i.RepeatInterval,
+			&i.DayOfMonth,
+			&i.Description,
+			&i.AmountCents,
+			&i.Category,
+			&i.Subcategory,
+			&i.IsActive,
+			&i.LastExecutionDate,
+			&i.Version,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getReimbursedCategorySums = `-- name: GetReimbursedCategorySums :many
+
+SELECT e.primary_category, CAST(SUM(r.amount_cents) AS INTEGER) as total_amount
+FROM expense_reimbursements r
+JOIN expenses e ON e.id = r.expense_id
+WHERE strftime('%Y', e.date) = printf('%04d', ?)
+  AND strftime('%m', e.date) = printf('%02d', ?)
+  AND e.deleted_at IS NULL
+GROUP BY e.primary_category
+`
+
+type GetReimbursedCategorySumsParams struct {
+	PRINTF   interface{} `db:"PRINTF" json:"PRINTF"`
+	PRINTF_2 interface{} `db:"PRINTF_2" json:"PRINTF_2"`
+}
+
+type GetReimbursedCategorySumsRow struct {
+	PrimaryCategory string `db:"primary_category" json:"primary_category"`
+	TotalAmount     int64  `db:"total_amount" json:"total_amount"`
+}
+
+// Reimbursed amounts per primary category for expenses in the month.
+func (q *Queries) GetReimbursedCategorySums(ctx context.Context, arg GetReimbursedCategorySumsParams) ([]GetReimbursedCategorySumsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getReimbursedCategorySums, arg.PRINTF, arg.PRINTF_2)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetReimbursedCategorySumsRow
+	for rows.Next() {
+		var i GetReimbursedCategorySumsRow
+		if err := rows.Scan(&i.PrimaryCategory, &i.TotalAmount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getReservedAttachmentBytes = `-- name: GetReservedAttachmentBytes :one
+
+SELECT CAST(COALESCE(SUM(size_bytes), 0) AS INTEGER) AS reserved_bytes
+FROM attachment_reservations
+WHERE user_id = ? AND created_at > datetime('now', '-1 hour')
+`
+
+// Bytes held by a member's uploads in progress, as counted by
+// ReserveAttachmentBytes.
+func (q *Queries) GetReservedAttachmentBytes(ctx context.Context, userID int64) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getReservedAttachmentBytes, userID)
+	var reserved_bytes int64
+	err := row.Scan(&reserved_bytes)
+	return reserved_bytes, err
+}
+
+const getSavedView = `-- name: GetSavedView :one
+SELECT id, name, primary_category, secondary_category, min_cents, max_cents, text, notify, created_at, tag FROM saved_views WHERE id = ?
+`
+
+func (q *Queries) GetSavedView(ctx context.Context, id int64) (SavedView, error) {
+	row := q.db.QueryRowContext(ctx, getSavedView, id)
+	var i SavedView
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.PrimaryCategory,
+		&i.SecondaryCategory,
+		&i.MinCents,
+		&i.MaxCents,
+		&i.Text,
+		&i.Notify,
+		&i.CreatedAt,
+		&i.Tag,
+	)
+	return i, err
+}
+
+const getSecondariesByPrimary = `-- name: GetSecondariesByPrimary :many
+SELECT sc.name FROM secondary_categories sc
+JOIN primary_categories pc ON sc.primary_category_id = pc.id
+WHERE pc.name = ? AND sc.archived = 0
+ORDER BY sc.name ASC
+`
+
+func (q *Queries) GetSecondariesByPrimary(ctx context.Context, name string) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, getSecondariesByPrimary, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		items = append(items, name)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSecondaryCategories = `-- name: GetSecondaryCategories :many
+SELECT name FROM secondary_categories 
+WHERE archived = 0
+ORDER BY name ASC
+`
+
+// Secondary Categories queries
+func (q *Queries) GetSecondaryCategories(ctx context.Context) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, getSecondaryCategories)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		items = append(items, name)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSecondaryCategoryByName = `-- name: GetSecondaryCategoryByName :one
+SELECT sc.id, sc.name, sc.primary_category_id, sc.created_at, sc.archived
+FROM secondary_categories sc
+JOIN primary_categories pc ON sc.primary_category_id = pc.id
+WHERE pc.name = ?1 AND sc.name = ?2
+`
+
+type GetSecondaryCategoryByNameParams struct {
+	PrimaryName string `db:"primary_name" json:"primary_name"`
+	Name        string `db:"name" json:"name"`
+}
+
+func (q *Queries) GetSecondaryCategoryByName(ctx context.Context, arg GetSecondaryCategoryByNameParams) (SecondaryCategory, error) {
+	row := q.db.QueryRowContext(ctx, getSecondaryCategoryByName, arg.PrimaryName, arg.Name)
+	var i SecondaryCategory
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.PrimaryCategoryID,
+		&i.CreatedAt,
+		&i.Archived,
+	)
+	return i, err
+}
+
+const getSheetHistoryImport = `-- name: GetSheetHistoryImport :one
+SELECT year, expenses, imported_at, total_rows, next_row, last_error, completed_at FROM sheet_history_imports WHERE year = ?
+`
+
+func (q *Queries) GetSheetHistoryImport(ctx context.Context, year int64) (SheetHistoryImport, error) {
+	row := q.db.QueryRowContext(ctx, getSheetHistoryImport, year)
+	var i SheetHistoryImport
+	err := row.Scan(
+		&i.Year,
+		&i.Expenses,
+		&i.ImportedAt,
+		&i.TotalRows,
+		&i.NextRow,
+		&i.LastError,
+		&i.CompletedAt,
+	)
+	return i, err
+}
+
+const getShoppingList = `-- name: GetShoppingList :one
+SELECT id, name, expense_id, converted_at, created_at FROM shopping_lists
+WHERE id = ?
+`
+
+func (q *Queries) GetShoppingList(ctx context.Context, id int64) (ShoppingList, error) {
+	row := q.db.QueryRowContext(ctx, getShoppingList, id)
+	var i ShoppingList
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.ExpenseID,
+		&i.ConvertedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getShoppingListByExpense = `-- name: GetShoppingListByExpense :one
+SELECT id, name, expense_id, converted_at, created_at FROM shopping_lists
+WHERE expense_id = ?
+`
+
+func (q *Queries) GetShoppingListByExpense(ctx context.Context, expenseID sql.NullInt64) (ShoppingList, error) {
+	row := q.db.QueryRowContext(ctx, getShoppingListByExpense, expenseID)
+	var i ShoppingList
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.ExpenseID,
+		&i.ConvertedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getSyncQueueItem = `-- name: GetSyncQueueItem :one
+SELECT id, operation, expense_id, expense_day, expense_month, expense_description, expense_amount_cents, expense_primary, expense_secondary, status, attempts, max_attempts, last_error, created_at, updated_at, processed_at, next_retry_at, expense_version, trace_parent FROM sync_queue WHERE id = ?
+`
+
+// Gets a single sync queue item by ID.
+func (q *Queries) GetSyncQueueItem(ctx context.Context, id int64) (SyncQueue, error) {
+	row := q.db.QueryRowContext(ctx, getSyncQueueItem, id)
+	var i SyncQueue
+	err := row.Scan(
+		&i.ID,
+		&i.Operation,
+		&i.ExpenseID,
+		&i.ExpenseDay,
+		&i.ExpenseMonth,
+		&i.ExpenseDescription,
+		&i.ExpenseAmountCents,
+		&i.ExpensePrimary,
+		&i.ExpenseSecondary,
+		&i.Status,
+		&i.Attempts,
+		&i.MaxAttempts,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ProcessedAt,
+		&i.NextRetryAt,
+		&i.ExpenseVersion,
+		&i.TraceParent,
+	)
+	return i, err
+}
+
+const getSyncQueueLag = `-- name: GetSyncQueueLag :one
+SELECT CAST(COALESCE(MAX(strftime('%s', 'now') - strftime('%s', created_at)), 0) AS INTEGER) as lag_seconds
+FROM sync_queue
+WHERE status IN ('pending', 'processing')
+`
+
+// Returns the age in seconds of the oldest item not synced yet, 0 when none wait.
+func (q *Queries) GetSyncQueueLag(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getSyncQueueLag)
+	var lag_seconds int64
+	err := row.Scan(&lag_seconds)
+	return lag_seconds, err
+}
+
+const getSyncQueueStats = `-- name: GetSyncQueueStats :one
+SELECT
+    CAST(SUM(CASE WHEN status = 'pending' THEN 1 ELSE 0 END) AS INTEGER) as pending_count,
+    CAST(SUM(CASE WHEN status = 'processing' THEN 1 ELSE 0 END) AS INTEGER) as processing_count,
+    CAST(SUM(CASE WHEN status = 'completed' THEN 1 ELSE 0 END) AS INTEGER) as completed_count,
+    CAST(SUM(CASE WHEN status = 'failed' THEN 1 ELSE 0 END) AS INTEGER) as failed_count
+FROM sync_queue
+`
+
+type GetSyncQueueStatsRow struct {
+	PendingCount    int64 `db:"pending_count" json:"pending_count"`
+	ProcessingCount int64 `db:"processing_count" json:"processing_count"`
+	CompletedCount  int64 `db:"completed_count" json:"completed_count"`
+	FailedCount     int64 `db:"failed_count" json:"failed_count"`
+}
+
+// Returns counts by status for monitoring.
+func (q *Queries) GetSyncQueueStats(ctx context.Context) (GetSyncQueueStatsRow, error) {
+	row := q.db.QueryRowContext(ctx, getSyncQueueStats)
+	var i GetSyncQueueStatsRow
+	err := row.Scan(
+		&i.PendingCount,
+		&i.ProcessingCount,
+		&i.CompletedCount,
+		&i.FailedCount,
+	)
+	return i, err
+}
+
+const getTrashedExpense = `-- name: GetTrashedExpense :one
+SELECT id, date, description, amount_cents, primary_category, secondary_category, version, created_at, synced_at, sync_status, uid, modified_at, status, paid_by, deleted_at, account_id FROM expenses WHERE id = ? AND deleted_at IS NOT NULL
+`
+
+func (q *Queries) GetTrashedExpense(ctx context.Context, id int64) (Expense, error) {
+	row := q.db.QueryRowContext(ctx, getTrashedExpense, id)
+	var i Expense
+	err := row.Scan(
+		&i.ID,
+		&i.Date,
+		&i.Description,
+		&i.AmountCents,
+		&i.PrimaryCategory,
+		&i.SecondaryCategory,
+		&i.Version,
+		&i.CreatedAt,
+		&i.SyncedAt,
+		&i.SyncStatus,
+		&i.Uid,
+		&i.ModifiedAt,
+		&i.Status,
+		&i.PaidBy,
+		&i.DeletedAt,
+		&i.AccountID,
+	)
+	return i, err
+}
+
+const getUserQuota = `-- name: GetUserQuota :one
+SELECT user_id, daily_expenses, attachment_bytes FROM user_quotas WHERE user_id = ?
+`
+
+func (q *Queries) GetUserQuota(ctx context.Context, userID int64) (UserQuota, error) {
+	row := q.db.QueryRowContext(ctx, getUserQuota, userID)
+	var i UserQuota
+	err := row.Scan(
+		&i.UserID,
+		&i.DailyExpenses,
+		&i.AttachmentBytes,
+	)
+	return i, err
+}
+
+const getUtilityUsage = `-- name: GetUtilityUsage :one
+SELECT expense_id, kind, quantity, created_at FROM utility_usage
+WHERE expense_id = ?
+`
+
+func (q *Queries) GetUtilityUsage(ctx context.Context, expenseID int64) (UtilityUsage, error) {
+	row := q.db.QueryRowContext(ctx, getUtilityUsage, expenseID)
+	var i UtilityUsage
+	err := row.Scan(
+		&i.ExpenseID,
+		&i.Kind,
+		&i.Quantity,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getYearAggregates = `-- name: GetYearAggregates :many
+
+SELECT category, month, total_cents
+FROM monthly_aggregates
+WHERE kind = ? AND year = ?
+ORDER BY category, month
+`
+
+type GetYearAggregatesParams struct {
+	Kind string `db:"kind" json:"kind"`
+	Year int64  `db:"year" json:"year"`
+}
+
+type GetYearAggregatesRow struct {
+	Category   string `db:"category" json:"category"`
+	Month      int64  `db:"month" json:"month"`
+	TotalCents int64  `db:"total_cents" json:"total_cents"`
+}
+
+// Totals per category and month of one kind of entry in a year.
+func (q *Queries) GetYearAggregates(ctx context.Context, arg GetYearAggregatesParams) ([]GetYearAggregatesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getYearAggregates, arg.Kind, arg.Year)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetYearAggregatesRow
+	for rows.Next() {
+		var i GetYearAggregatesRow
+		if err := rows.Scan(&i.Category, &i.Month, &i.TotalCents); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const hardDeleteExpense = `-- name: HardDeleteExpense :exec
+DELETE FROM expenses 
+WHERE id = ?
+`
+
+func (q *Queries) HardDeleteExpense(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, hardDeleteExpense, id)
+	return err
+}
+
+const hardDeleteIncome = `-- name: HardDeleteIncome :exec
+DELETE FROM incomes
+WHERE id = ?
+`
+
+func (q *Queries) HardDeleteIncome(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, hardDeleteIncome, id)
+	return err
+}
+
+const incrementSyncAttempt = `-- name: IncrementSyncAttempt :exec
+UPDATE sync_queue
+SET attempts = attempts + 1,
+    last_error = ?,
+    status = 'pending',
+    next_retry_at = datetime(CURRENT_TIMESTAMP, '+' || min(1 << attempts, 60) || ' minutes'),
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+`
+
+type IncrementSyncAttemptParams struct {
+	LastError interface{} `db:"last_error" json:"last_error"`
+	ID        int64       `db:"id" json:"id"`
+}
+
+// Increments attempt count and schedules next retry with exponential backoff,
+// at most an hour.
+func (q *Queries) IncrementSyncAttempt(ctx context.Context, arg IncrementSyncAttemptParams) error {
+	_, err := q.db.ExecContext(ctx, incrementSyncAttempt, arg.LastError, arg.ID)
+	return err
+}
+
+const interruptRunningJobs = `-- name: InterruptRunningJobs :execrows
+UPDATE jobs
+SET status = 'interrupted',
+    finished_at = ?
+WHERE status = 'running'
+`
+
+// Runs left running by a process that stopped
+func (q *Queries) InterruptRunningJobs(ctx context.Context, finishedAt sql.NullTime) (int64, error) {
+	result, err := q.db.ExecContext(ctx, interruptRunningJobs, finishedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const listAccountBalances = `-- name: ListAccountBalances :many
+
+SELECT a.id, a.name, a.kind, a.opening_balance_cents,
+       CAST(COALESCE((SELECT SUM(amount_cents) FROM incomes
+                      WHERE account_id = a.id AND deleted_at IS NULL), 0) AS INTEGER) AS incomes_cents,
+       CAST(COALESCE((SELECT SUM(amount_cents) FROM expenses
+                      WHERE account_id = a.id AND deleted_at IS NULL AND status = 'cleared'), 0) AS INTEGER) AS expenses_cents,
+       CAST(COALESCE((SELECT SUM(amount_cents) FROM account_transfers
+                      WHERE to_account_id = a.id), 0) AS INTEGER) AS transfers_in_cents,
+       CAST(COALESCE((SELECT SUM(amount_cents) FROM account_transfers
+                      WHERE from_account_id = a.id), 0) AS INTEGER) AS transfers_out_cents
+FROM accounts a
+ORDER BY a.name
+`
+
+type ListAccountBalancesRow struct {
+	ID                  int64  `db:"id" json:"id"`
+	Name                string `db:"name" json:"name"`
+	Kind                string `db:"kind" json:"kind"`
+	OpeningBalanceCents int64  `db:"opening_balance_cents" json:"opening_balance_cents"`
+	IncomesCents        int64  `db:"incomes_cents" json:"incomes_cents"`
+	ExpensesCents       int64  `db:"expenses_cents" json:"expenses_cents"`
+	TransfersInCents    int64  `db:"transfers_in_cents" json:"transfers_in_cents"`
+	TransfersOutCents   int64  `db:"transfers_out_cents" json:"transfers_out_cents"`
+}
+
+// What moved through each account: live incomes, live settled expenses
+// (card holds wait until they clear) and transfers.
+func (q *Queries) ListAccountBalances(ctx context.Context) ([]ListAccountBalancesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountBalances)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListAccountBalancesRow
+	for rows.Next() {
+		var i ListAccountBalancesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Kind,
+			&i.OpeningBalanceCents,
+			&i.IncomesCents,
+			&i.ExpensesCents,
+			&i.TransfersInCents,
+			&i.TransfersOutCents,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAccountTransfers = `-- name: ListAccountTransfers :many
+SELECT id, date, from_account_id, to_account_id, amount_cents, description, created_at FROM account_transfers
+ORDER BY date DESC, id DESC
+LIMIT ?
+`
+
+func (q *Queries) ListAccountTransfers(ctx context.Context, limit int64) ([]AccountTransfer, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountTransfers, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AccountTransfer
+	for rows.Next() {
+		var i AccountTransfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.AmountCents,
+			&i.Description,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAccounts = `-- name: ListAccounts :many
+SELECT id, name, kind, opening_balance_cents, created_at FROM accounts ORDER BY name
+`
+
+func (q *Queries) ListAccounts(ctx context.Context) ([]Account, error) {
+	rows, err := q.db.QueryContext(ctx, listAccounts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Account
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Kind,
+			&i.OpeningBalanceCents,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listActiveCategoryPairs = `-- name: ListActiveCategoryPairs :many
+SELECT sc.id, pc.name AS primary_name, sc.name AS secondary_name
+FROM secondary_categories sc
+JOIN primary_categories pc ON pc.id = sc.primary_category_id
+WHERE sc.archived = 0 AND pc.archived = 0
+ORDER BY pc.name, sc.name
+`
+
+type ListActiveCategoryPairsRow struct {
+	ID            int64  `db:"id" json:"id"`
+	PrimaryName   string `db:"primary_name" json:"primary_name"`
+	SecondaryName string `db:"secondary_name" json:"secondary_name"`
+}
+
+// Secondary categories not archived, with their primary category.
+func (q *Queries) ListActiveCategoryPairs(ctx context.Context) ([]ListActiveCategoryPairsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listActiveCategoryPairs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListActiveCategoryPairsRow
+	for rows.Next() {
+		var i ListActiveCategoryPairsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.PrimaryName,
+			&i.SecondaryName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listActiveCategoryRules = `-- name: ListActiveCategoryRules :many
+SELECT id, name, expression, primary_category, secondary_category, priority, is_active, created_at FROM category_rules
+WHERE is_active = 1
+ORDER BY priority, id
+`
+
+// Returns the active rules in evaluation order.
+func (q *Queries) ListActiveCategoryRules(ctx context.Context) ([]CategoryRule, error) {
+	rows, err := q.db.QueryContext(ctx, listActiveCategoryRules)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CategoryRule
+	for rows.Next() {
+		var i CategoryRule
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Expression,
+			&i.PrimaryCategory,
+			&i.SecondaryCategory,
+			&i.Priority,
+			&i.IsActive,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAlertPreferences = `-- name: ListAlertPreferences :many
+SELECT user_id, alert_type, scope, muted, snoozed_until, updated_at FROM alert_preferences WHERE user_id = ?
+ORDER BY alert_type, scope
+`
+
+func (q *Queries) ListAlertPreferences(ctx context.Context, userID string) ([]AlertPreference, error) {
+	rows, err := q.db.QueryContext(ctx, listAlertPreferences, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AlertPreference
+	for rows.Next() {
+		var i AlertPreference
+		if err := rows.Scan(
+			&i.UserID,
+			&i.AlertType,
+			&i.Scope,
+			&i.Muted,
+			&i.SnoozedUntil,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAllExpenses = `-- name: ListAllExpenses :many
+SELECT id, date, description, amount_cents, primary_category, secondary_category, version, created_at, synced_at, sync_status, uid, modified_at, status, paid_by, deleted_at, account_id FROM expenses
+WHERE deleted_at IS NULL
+ORDER BY date ASC, id ASC
+`
+
+func (q *Queries) ListAllExpenses(ctx context.Context) ([]Expense, error) {
+	rows, err := q.db.QueryContext(ctx, listAllExpenses)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Expense
+	for rows.Next() {
+		var i Expense
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.Description,
+			&i.AmountCents,
+			&i.PrimaryCategory,
+			&i.SecondaryCategory,
+			&i.Version,
+			&i.CreatedAt,
+			&i.SyncedAt,
+			&i.SyncStatus,
+			&i.Uid,
+			&i.ModifiedAt,
+			&i.Status,
+			&i.PaidBy,
+			&i.DeletedAt,
+			&i.AccountID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAllIncomes = `-- name: ListAllIncomes :many
+SELECT id, date, description, amount_cents, category, version, created_at, synced_at, sync_status, uid, modified_at, subcategory, tags, deleted_at, account_id FROM incomes
+WHERE deleted_at IS NULL
+ORDER BY date ASC, id ASC
+`
+
+func (q *Queries) ListAllIncomes(ctx context.Context) ([]Income, error) {
+	rows, err := q.db.QueryContext(ctx, listAllIncomes)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Income
+	for rows.Next() {
+		var i Income
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.Description,
+			&i.AmountCents,
+			&i.Category,
+			&i.Version,
+			&i.CreatedAt,
+			&i.SyncedAt,
+			&i.SyncStatus,
+			&i.Uid,
+			&i.ModifiedAt,
+			&i.Subcategory,
+			&i.Tags,
+			&i.DeletedAt,
+			&i.AccountID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listApiKeys = `-- name: ListApiKeys :many
+SELECT id, user_id, name, key_hash, created_at, last_used_at FROM api_keys ORDER BY user_id, id
+`
+
+func (q *Queries) ListApiKeys(ctx context.Context) ([]ApiKey, error) {
+	rows, err := q.db.QueryContext(ctx, listApiKeys)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ApiKey
+	for rows.Next() {
+		var i ApiKey
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.KeyHash,
+			&i.CreatedAt,
+			&i.LastUsedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAttachmentBytesByUser = `-- name: ListAttachmentBytesByUser :many
+SELECT uploaded_by, CAST(SUM(size_bytes) AS INTEGER) AS total_bytes
+FROM receipts
+WHERE uploaded_by IS NOT NULL
+GROUP BY uploaded_by
+`
+
+type ListAttachmentBytesByUserRow struct {
+	UploadedBy sql.NullInt64 `db:"uploaded_by" json:"uploaded_by"`
+	TotalBytes int64         `db:"total_bytes" json:"total_bytes"`
+}
+
+func (q *Queries) ListAttachmentBytesByUser(ctx context.Context) ([]ListAttachmentBytesByUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, listAttachmentBytesByUser)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListAttachmentBytesByUserRow
+	for rows.Next() {
+		var i ListAttachmentBytesByUserRow
+		if err := rows.Scan(&i.UploadedBy, &i.TotalBytes); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAuditEntries = `-- name: ListAuditEntries :many
+
+SELECT id, entity, entity_id, action, changed_by, changed_at, before_json, after_json FROM audit_log
+WHERE changed_at >= ?1
+  AND changed_at < ?2
+  AND (?3 = '' OR entity = ?3)
+  AND (?4 = 0 OR entity_id = ?4)
+ORDER BY id DESC
+LIMIT ?5
+`
+
+type ListAuditEntriesParams struct {
+	ChangedFrom  time.Time   `db:"changed_from" json:"changed_from"`
+	ChangedUntil time.Time   `db:"changed_until" json:"changed_until"`
+	Entity       interface{} `db:"entity" json:"entity"`
+	EntityID     interface{} `db:"entity_id" json:"entity_id"`
+	MaxEntries   int64       `db:"max_entries" json:"max_entries"`
+}
+
+// Returns the changes made in [changed_from, changed_until), newest first,
+// optionally of one entity type (empty for all) and one row (0 for all).
+func (q *Queries) ListAuditEntries(ctx context.Context, arg ListAuditEntriesParams) ([]AuditLog, error) {
+	rows, err := q.db.QueryContext(ctx, listAuditEntries,
+		arg.ChangedFrom,
+		arg.ChangedUntil,
+		arg.Entity,
+		arg.EntityID,
+		arg.MaxEntries,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AuditLog
+	for rows.Next() {
+		var i AuditLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.Entity,
+			&i.EntityID,
+			&i.Action,
+			&i.ChangedBy,
+			&i.ChangedAt,
+			&i.BeforeJson,
+			&i.AfterJson,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBlobDeletions = `-- name: ListBlobDeletions :many
+SELECT blob_key FROM blob_deletions
+ORDER BY created_at, blob_key
+LIMIT ?
+`
+
+func (q *Queries) ListBlobDeletions(ctx context.Context, limit int64) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listBlobDeletions, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var blob_key string
+		if err := rows.Scan(&blob_key); err != nil {
+			return nil, err
+		}
+		items = append(items, blob_key)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCategoryAdmin = `-- name: ListCategoryAdmin :many
+SELECT
+  pc.name AS primary_name,
+  pc.archived AS primary_archived,
+  sc.name AS secondary_name,
+  sc.archived AS secondary_archived,
+  (SELECT COUNT(*) FROM expenses e
+   WHERE e.primary_category = pc.name AND e.secondary_category = sc.name) AS expense_count
+FROM primary_categories pc
+LEFT JOIN secondary_categories sc ON sc.primary_category_id = pc.id
+ORDER BY pc.name ASC, sc.name ASC
+`
+
+type ListCategoryAdminRow struct {
+	PrimaryName       string         `db:"primary_name" json:"primary_name"`
+	PrimaryArchived   bool           `db:"primary_archived" json:"primary_archived"`
+	SecondaryName     sql.NullString `db:"secondary_name" json:"secondary_name"`
+	SecondaryArchived sql.NullBool   `db:"secondary_archived" json:"secondary_archived"`
+	ExpenseCount      int64          `db:"expense_count" json:"expense_count"`
+}
+
+// Lists every category, archived ones included, with the expenses filed under it.
+func (q *Queries) ListCategoryAdmin(ctx context.Context) ([]ListCategoryAdminRow, error) {
+	rows, err := q.db.QueryContext(ctx, listCategoryAdmin)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListCategoryAdminRow
+	for rows.Next() {
+		var i ListCategoryAdminRow
+		if err := rows.Scan(
+			&i.PrimaryName,
+			&i.PrimaryArchived,
+			&i.SecondaryName,
+			&i.SecondaryArchived,
+			&i.ExpenseCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCategoryKeywords = `-- name: ListCategoryKeywords :many
+SELECT keyword, primary_category, secondary_category, disabled, created_at
+FROM category_keywords
+ORDER BY keyword
+`
+
+func (q *Queries) ListCategoryKeywords(ctx context.Context) ([]CategoryKeyword, error) {
+	rows, err := q.db.QueryContext(ctx, listCategoryKeywords)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CategoryKeyword
+	for rows.Next() {
+		var i CategoryKeyword
+		if err := rows.Scan(
+			&i.Keyword,
+			&i.PrimaryCategory,
+			&i.SecondaryCategory,
+			&i.Disabled,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCategoryMappings = `-- name: ListCategoryMappings :many
+SELECT sheet_name, primary_category, created_at, updated_at FROM category_mappings
+ORDER BY primary_category, sheet_name
+`
+
+// Lists the primary category of every secondary category of Google Sheets.
+func (q *Queries) ListCategoryMappings(ctx context.Context) ([]CategoryMapping, error) {
+	rows, err := q.db.QueryContext(ctx, listCategoryMappings)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CategoryMapping
+	for rows.Next() {
+		var i CategoryMapping
+		if err := rows.Scan(
+			&i.SheetName,
+			&i.PrimaryCategory,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCategoryRules = `-- name: ListCategoryRules :many
+SELECT id, name, expression, primary_category, secondary_category, priority, is_active, created_at FROM category_rules
+ORDER BY priority, id
+`
+
+// Returns all rules in evaluation order.
+func (q *Queries) ListCategoryRules(ctx context.Context) ([]CategoryRule, error) {
+	rows, err := q.db.QueryContext(ctx, listCategoryRules)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CategoryRule
+	for rows.Next() {
+		var i CategoryRule
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Expression,
+			&i.PrimaryCategory,
+			&i.SecondaryCategory,
+			&i.Priority,
+			&i.IsActive,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCategoryTranslations = `-- name: ListCategoryTranslations :many
+SELECT kind, name, locale, display_name FROM category_translations
+WHERE locale = ?
+ORDER BY kind, name
+`
+
+func (q *Queries) ListCategoryTranslations(ctx context.Context, locale string) ([]CategoryTranslation, error) {
+	rows, err := q.db.QueryContext(ctx, listCategoryTranslations, locale)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CategoryTranslation
+	for rows.Next() {
+		var i CategoryTranslation
+		if err := rows.Scan(
+			&i.Kind,
+			&i.Name,
+			&i.Locale,
+			&i.DisplayName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDailyUsage = `-- name: ListDailyUsage :many
+SELECT user_id, day, expenses FROM user_daily_usage WHERE day = ?
+`
+
+func (q *Queries) ListDailyUsage(ctx context.Context, day string) ([]UserDailyUsage, error) {
+	rows, err := q.db.QueryContext(ctx, listDailyUsage, day)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UserDailyUsage
+	for rows.Next() {
+		var i UserDailyUsage
+		if err := rows.Scan(
+			&i.UserID,
+			&i.Day,
+			&i.Expenses,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDetachedSecondaryCategories = `-- name: ListDetachedSecondaryCategories :many
+SELECT sc.id, sc.name
+FROM secondary_categories sc
+WHERE NOT EXISTS (SELECT 1 FROM primary_categories pc WHERE pc.id = sc.primary_category_id)
+ORDER BY sc.name, sc.id
+`
+
+type ListDetachedSecondaryCategoriesRow struct {
+	ID   int64  `db:"id" json:"id"`
+	Name string `db:"name" json:"name"`
+}
+
+// Secondary categories whose primary category no longer exists.
+func (q *Queries) ListDetachedSecondaryCategories(ctx context.Context) ([]ListDetachedSecondaryCategoriesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listDetachedSecondaryCategories)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDetachedSecondaryCategoriesRow
+	for rows.Next() {
+		var i ListDetachedSecondaryCategoriesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listExpenseFingerprints = `-- name: ListExpenseFingerprints :many
+
+SELECT date, description, amount_cents FROM expenses
+WHERE date BETWEEN date(?1) AND date(?2)
+  AND deleted_at IS NULL
+`
+
+type ListExpenseFingerprintsParams struct {
+	FromDate interface{} `db:"from_date" json:"from_date"`
+	ToDate   interface{} `db:"to_date" json:"to_date"`
+}
+
+type ListExpenseFingerprintsRow struct {
+	Date        time.Time `db:"date" json:"date"`
+	Description string    `db:"description" json:"description"`
+	AmountCents int64     `db:"amount_cents" json:"amount_cents"`
+}
+
+// What identifies the expenses between two dates, to find duplicates of
+// imported rows.
+func (q *Queries) ListExpenseFingerprints(ctx context.Context, arg ListExpenseFingerprintsParams) ([]ListExpenseFingerprintsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listExpenseFingerprints, arg.FromDate, arg.ToDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListExpenseFingerprintsRow
+	for rows.Next() {
+		var i ListExpenseFingerprintsRow
+		if err := rows.Scan(&i.Date, &i.Description, &i.AmountCents); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listExpenseLineItems = `-- name: ListExpenseLineItems :many
+SELECT id, expense_id, position, description, quantity, amount_cents, primary_category, secondary_category, created_at
+FROM expense_line_items
+WHERE expense_id = ?
+ORDER BY position
+`
+
+func (q *Queries) ListExpenseLineItems(ctx context.Context, expenseID int64) ([]ExpenseLineItem, error) {
+	rows, err := q.db.QueryContext(ctx, listExpenseLineItems, expenseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ExpenseLineItem
+	for rows.Next() {
+		var i ExpenseLineItem
+		if err := rows.Scan(
+			&i.ID,
+			&i.ExpenseID,
+			&i.Position,
+			&i.Description,
+			&i.Quantity,
+			&i.AmountCents,
+			&i.PrimaryCategory,
+			&i.SecondaryCategory,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listExpenseReimbursements = `-- name: ListExpenseReimbursements :many
+
+SELECT r.income_id, r.expense_id, r.amount_cents,
+       e.date AS expense_date, e.description AS expense_description,
+       e.amount_cents AS expense_amount_cents, e.primary_category, e.secondary_category
+FROM expense_reimbursements r
+JOIN expenses e ON e.id = r.expense_id
+WHERE e.deleted_at IS NULL
+ORDER BY r.income_id, e.date, e.id
+`
+
+type ListExpenseReimbursementsRow struct {
+	IncomeID           int64     `db:"income_id" json:"income_id"`
+	ExpenseID          int64     `db:"expense_id" json:"expense_id"`
+	AmountCents        int64     `db:"amount_cents" json:"amount_cents"`
+	ExpenseDate        time.Time `db:"expense_date" json:"expense_date"`
+	ExpenseDescription string    `db:"expense_description" json:"expense_description"`
+	ExpenseAmountCents int64     `db:"expense_amount_cents" json:"expense_amount_cents"`
+	PrimaryCategory    string    `db:"primary_category" json:"primary_category"`
+	SecondaryCategory  string    `db:"secondary_category" json:"secondary_category"`
+}
+
+// Every link with its expense, grouped by income.
+func (q *Queries) ListExpenseReimbursements(ctx context.Context) ([]ListExpenseReimbursementsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listExpenseReimbursements)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListExpenseReimbursementsRow
+	for rows.Next() {
+		var i ListExpenseReimbursementsRow
+		if err := rows.Scan(
+			&i.IncomeID,
+			&i.ExpenseID,
+			&i.AmountCents,
+			&i.ExpenseDate,
+			&i.ExpenseDescription,
+			&i.ExpenseAmountCents,
+			&i.PrimaryCategory,
+			&i.SecondaryCategory,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listExpenseSplits = `-- name: ListExpenseSplits :many
+SELECT id, expense_id, position, note, amount_cents, primary_category, secondary_category, created_at
+FROM expense_splits
+WHERE expense_id = ?
+ORDER BY position
+`
+
+func (q *Queries) ListExpenseSplits(ctx context.Context, expenseID int64) ([]ExpenseSplit, error) {
+	rows, err := q.db.QueryContext(ctx, listExpenseSplits, expenseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ExpenseSplit
+	for rows.Next() {
+		var i ExpenseSplit
+		if err := rows.Scan(
+			&i.ID,
+			&i.ExpenseID,
+			&i.Position,
+			&i.Note,
+			&i.AmountCents,
+			&i.PrimaryCategory,
+			&i.SecondaryCategory,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listExpenseSyncErrors = `-- name: ListExpenseSyncErrors :many
+SELECT id, queue_id, expense_id, operation, attempt, class, message, created_at FROM sync_errors
+WHERE expense_id = ?
+ORDER BY id DESC
+`
+
+// Returns the failed sync attempts of an expense, newest first.
+func (q *Queries) ListExpenseSyncErrors(ctx context.Context, expenseID int64) ([]SyncError, error) {
+	rows, err := q.db.QueryContext(ctx, listExpenseSyncErrors, expenseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SyncError
+	for rows.Next() {
+		var i SyncError
+		if err := rows.Scan(
+			&i.ID,
+			&i.QueueID,
+			&i.ExpenseID,
+			&i.Operation,
+			&i.Attempt,
+			&i.Class,
+			&i.Message,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listExpenseSyncFlags = `-- name: ListExpenseSyncFlags :many
+SELECT e.id, e.date, e.description, e.sync_status,
+    EXISTS (
+        SELECT 1 FROM sync_queue q
+        WHERE q.expense_id = e.id AND q.status != 'completed'
+    ) AS queued
+FROM expenses e
+WHERE date(e.date) >= date(?1) AND date(e.date) < date(?2)
+  AND e.status = 'cleared'
+ORDER BY e.id
+`
+
+type ListExpenseSyncFlagsParams struct {
+	FromDate string `db:"from_date" json:"from_date"`
+	ToDate   string `db:"to_date" json:"to_date"`
+}
+
+type ListExpenseSyncFlagsRow struct {
+	ID          int64          `db:"id" json:"id"`
+	Date        time.Time      `db:"date" json:"date"`
+	Description string         `db:"description" json:"description"`
+	SyncStatus  sql.NullString `db:"sync_status" json:"sync_status"`
+	Queued      int64          `db:"queued" json:"queued"`
+}
+
+// Sync flag of the cleared expenses in [from_date, to_date), and whether
+// a queue item is still open for them.
+func (q *Queries) ListExpenseSyncFlags(ctx context.Context, arg ListExpenseSyncFlagsParams) ([]ListExpenseSyncFlagsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listExpenseSyncFlags, arg.FromDate, arg.ToDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListExpenseSyncFlagsRow
+	for rows.Next() {
+		var i ListExpenseSyncFlagsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.Description,
+			&i.SyncStatus,
+			&i.Queued,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listExpenseTags = `-- name: ListExpenseTags :many
+SELECT t.name FROM expense_tags et
+JOIN tags t ON t.id = et.tag_id
+WHERE et.expense_id = ?
+ORDER BY et.rowid
+`
+
+// Tags of an expense in the order they were given.
+func (q *Queries) ListExpenseTags(ctx context.Context, expenseID int64) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listExpenseTags, expenseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		items = append(items, name)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listExpenseVersions = `-- name: ListExpenseVersions :many
+SELECT id, expense_id, version, changed_by, changes, changed_at FROM expense_versions
+WHERE expense_id = ?
+ORDER BY version DESC, id DESC
+`
+
+// Returns the history of an expense, newest first.
+func (q *Queries) ListExpenseVersions(ctx context.Context, expenseID int64) ([]ExpenseVersion, error) {
+	rows, err := q.db.QueryContext(ctx, listExpenseVersions, expenseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ExpenseVersion
+	for rows.Next() {
+		var i ExpenseVersion
+		if err := rows.Scan(
+			&i.ID,
+			&i.ExpenseID,
+			&i.Version,
+			&i.ChangedBy,
+			&i.Changes,
+			&i.ChangedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listExpensesBetweenDates = `-- name: ListExpensesBetweenDates :many
+
+SELECT id, date, description, amount_cents, primary_category, secondary_category, version, created_at, synced_at, sync_status, uid, modified_at, status, paid_by, deleted_at, account_id FROM expenses
+WHERE date BETWEEN date(?1) AND date(?2)
+  AND deleted_at IS NULL
+ORDER BY date ASC, id ASC
+`
+
+type ListExpensesBetweenDatesParams struct {
+	FromDate interface{} `db:"from_date" json:"from_date"`
+	ToDate   interface{} `db:"to_date" json:"to_date"`
+}
+
+// The live expenses dated from_date through to_date, oldest first.
+func (q *Queries) ListExpensesBetweenDates(ctx context.Context, arg ListExpensesBetweenDatesParams) ([]Expense, error) {
+	rows, err := q.db.QueryContext(ctx, listExpensesBetweenDates, arg.FromDate, arg.ToDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Expense
+	for rows.Next() {
+		var i Expense
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.Description,
+			&i.AmountCents,
+			&i.PrimaryCategory,
+			&i.SecondaryCategory,
+			&i.Version,
+			&i.CreatedAt,
+			&i.SyncedAt,
+			&i.SyncStatus,
+			&i.Uid,
+			&i.ModifiedAt,
+			&i.Status,
+			&i.PaidBy,
+			&i.DeletedAt,
+			&i.AccountID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listExpensesByDateRange = `-- name: ListExpensesByDateRange :many
+SELECT id, date, description, amount_cents, primary_category, secondary_category, version, created_at, synced_at, sync_status, uid, modified_at, status, paid_by, deleted_at, account_id FROM expenses
+WHERE date >= ? AND date <= ? AND deleted_at IS NULL
+ORDER BY date DESC, created_at DESC
+`
+
+type ListExpensesByDateRangeParams struct {
+	Date   time.Time `db:"date" json:"date"`
+	Date_2 time.Time `db:"date_2" json:"date_2"`
+}
+
+func (q *Queries) ListExpensesByDateRange(ctx context.Context, arg ListExpensesByDateRangeParams) ([]Expense, error) {
+	rows, err := q.db.QueryContext(ctx, listExpensesByDateRange, arg.Date, arg.Date_2)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Expense
+	for rows.Next() {
+		var i Expense
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.Description,
+			&i.AmountCents,
+			&i.PrimaryCategory,
+			&i.SecondaryCategory,
+			&i.Version,
+			&i.CreatedAt,
+			&i.SyncedAt,
+			&i.SyncStatus,
+			&i.Uid,
+			&i.ModifiedAt,
+			&i.Status,
+			&i.PaidBy,
+			&i.DeletedAt,
+			&i.AccountID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listExpensesByPrimaryCategory = `-- name: ListExpensesByPrimaryCategory :many
+SELECT id, date, description, amount_cents, primary_category, secondary_category, version, created_at, synced_at, sync_status, uid, modified_at, status, paid_by, deleted_at, account_id FROM expenses WHERE primary_category = ? ORDER BY id
+`
+
+func (q *Queries) ListExpensesByPrimaryCategory(ctx context.Context, primaryCategory string) ([]Expense, error) {
+	rows, err := q.db.QueryContext(ctx, listExpensesByPrimaryCategory, primaryCategory)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Expense
+	for rows.Next() {
+		var i Expense
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.Description,
+			&i.AmountCents,
+			&i.PrimaryCategory,
+			&i.SecondaryCategory,
+			&i.Version,
+			&i.CreatedAt,
+			&i.SyncedAt,
+			&i.SyncStatus,
+			&i.Uid,
+			&i.ModifiedAt,
+			&i.Status,
+			&i.PaidBy,
+			&i.DeletedAt,
+			&i.AccountID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listExpensesByWorkflowState = `-- name: ListExpensesByWorkflowState :many
+
+SELECT e.id, e.date, e.description, e.amount_cents, e.primary_category, e.secondary_category,
+       CAST(COALESCE(w.updated_by, '') AS TEXT) AS updated_by
+FROM expenses e
+LEFT JOIN expense_workflow w ON w.expense_id = e.id
+WHERE COALESCE(w.state, 'draft') = ? AND e.deleted_at IS NULL
+ORDER BY e.date DESC, e.id DESC
+LIMIT ?
+`
+
+type ListExpensesByWorkflowStateParams struct {
+	State string `db:"state" json:"state"`
+	Limit int64  `db:"limit" json:"limit"`
+}
+
+type ListExpensesByWorkflowStateRow struct {
+	ID                int64     `db:"id" json:"id"`
+	Date              time.Time `db:"date" json:"date"`
+	Description       string    `db:"description" json:"description"`
+	AmountCents       int64     `db:"amount_cents" json:"amount_cents"`
+	PrimaryCategory   string    `db:"primary_category" json:"primary_category"`
+	SecondaryCategory string    `db:"secondary_category" json:"secondary_category"`
+	UpdatedBy         string    `db:"updated_by" json:"updated_by"`
+}
+
+// Expenses in a workflow state, newest first. Expenses without a workflow row are drafts.
+func (q *Queries) ListExpensesByWorkflowState(ctx context.Context, arg ListExpensesByWorkflowStateParams) ([]ListExpensesByWorkflowStateRow, error) {
+	rows, err := q.db.QueryContext(ctx, listExpensesByWorkflowState, arg.State, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListExpensesByWorkflowStateRow
+	for rows.Next() {
+		var i ListExpensesByWorkflowStateRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.Description,
+			&i.AmountCents,
+			&i.PrimaryCategory,
+			&i.SecondaryCategory,
+			&i.UpdatedBy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listExpensesInCategory = `-- name: ListExpensesInCategory :many
+SELECT id, date, description, amount_cents, primary_category, secondary_category, version, created_at, synced_at, sync_status, uid, modified_at, status, paid_by, deleted_at, account_id FROM expenses WHERE primary_category = ? AND secondary_category = ? ORDER BY id
+`
+
+type ListExpensesInCategoryParams struct {
+	PrimaryCategory   string `db:"primary_category" json:"primary_category"`
+	SecondaryCategory string `db:"secondary_category" json:"secondary_category"`
+}
+
+func (q *Queries) ListExpensesInCategory(ctx context.Context, arg ListExpensesInCategoryParams) ([]Expense, error) {
+	rows, err := q.db.QueryContext(ctx, listExpensesInCategory, arg.PrimaryCategory, arg.SecondaryCategory)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Expense
+	for rows.Next() {
+		var i Expense
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.Description,
+			&i.AmountCents,
+			&i.PrimaryCategory,
+			&i.SecondaryCategory,
+			&i.Version,
+			&i.CreatedAt,
+			&i.SyncedAt,
+			&i.SyncStatus,
+			&i.Uid,
+			&i.ModifiedAt,
+			&i.Status,
+			&i.PaidBy,
+			&i.DeletedAt,
+			&i.AccountID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listExpensesToPurge = `-- name: ListExpensesToPurge :many
+
+SELECT id FROM expenses
+WHERE deleted_at IS NOT NULL AND deleted_at < ?
+ORDER BY id
+`
+
+// Expenses in the trash since before the cutoff.
+func (q *Queries) ListExpensesToPurge(ctx context.Context, deletedAt sql.NullTime) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, listExpensesToPurge, deletedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listExpensesWithUnknownCategory = `-- name: ListExpensesWithUnknownCategory :many
+SELECT e.id, e.date, e.description, e.primary_category, e.secondary_category
+FROM expenses e
+WHERE EXISTS (SELECT 1 FROM primary_categories)
+  AND NOT EXISTS (
+    SELECT 1 FROM secondary_categories s
+    JOIN primary_categories p ON p.id = s.primary_category_id
+    WHERE p.name = e.primary_category AND s.name = e.secondary_category
+  )
+ORDER BY e.date DESC, e.id DESC
+LIMIT ?
+`
+
+type ListExpensesWithUnknownCategoryRow struct {
+	ID                int64     `db:"id" json:"id"`
+	Date              time.Time `db:"date" json:"date"`
+	Description       string    `db:"description" json:"description"`
+	PrimaryCategory   string    `db:"primary_category" json:"primary_category"`
+	SecondaryCategory string    `db:"secondary_category" json:"secondary_category"`
+}
+
+// Expenses whose category pair is not among the known categories; none
+// while no categories were loaded yet.
+func (q *Queries) ListExpensesWithUnknownCategory(ctx context.Context, limit int64) ([]ListExpensesWithUnknownCategoryRow, error) {
+	rows, err := q.db.QueryContext(ctx, listExpensesWithUnknownCategory, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListExpensesWithUnknownCategoryRow
+	for rows.Next() {
+		var i ListExpensesWithUnknownCategoryRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.Description,
+			&i.PrimaryCategory,
+			&i.SecondaryCategory,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFilteredExpenses = `-- name: ListFilteredExpenses :many
+
+SELECT id, date, description, amount_cents, primary_category, secondary_category, version, created_at, synced_at, sync_status, uid, modified_at, status, paid_by, deleted_at, account_id FROM expenses
+WHERE (?1 = '' OR primary_category = ?1)
+  AND (?2 = '' OR secondary_category = ?2)
+  AND (?3 = 0 OR amount_cents >= ?3)
+  AND (?4 = 0 OR amount_cents <= ?4)
+  AND (?5 = '' OR instr(lower(description), lower(?5)) > 0)
+  AND (?6 = '' OR EXISTS (
+    SELECT 1 FROM expense_tags et JOIN tags t ON t.id = et.tag_id
+    WHERE et.expense_id = expenses.id AND t.name = ?6
+  ))
+  AND deleted_at IS NULL
+ORDER BY date DESC, id DESC
+LIMIT ?7
+`
+
+type ListFilteredExpensesParams struct {
+	PrimaryCategory   interface{} `db:"primary_category" json:"primary_category"`
+	SecondaryCategory interface{} `db:"secondary_category" json:"secondary_category"`
+	MinCents          interface{} `db:"min_cents" json:"min_cents"`
+	MaxCents          interface{} `db:"max_cents" json:"max_cents"`
+	Text              interface{} `db:"text" json:"text"`
+	Tag               interface{} `db:"tag" json:"tag"`
+	MaxRows           int64       `db:"max_rows" json:"max_rows"`
+}
+
+// The latest expenses a saved view selects; zero amounts and empty texts
+// and tags do not filter.
+func (q *Queries) ListFilteredExpenses(ctx context.Context, arg ListFilteredExpensesParams) ([]Expense, error) {
+	rows, err := q.db.QueryContext(ctx, listFilteredExpenses,
+		arg.PrimaryCategory,
+		arg.SecondaryCategory,
+		arg.MinCents,
+		arg.MaxCents,
+		arg.Text,
+		arg.Tag,
+		arg.MaxRows,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Expense
+	for rows.Next() {
+		var i Expense
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.Description,
+			&i.AmountCents,
+			&i.PrimaryCategory,
+			&i.SecondaryCategory,
+			&i.Version,
+			&i.CreatedAt,
+			&i.SyncedAt,
+			&i.SyncStatus,
+			&i.Uid,
+			&i.ModifiedAt,
+			&i.Status,
+			&i.PaidBy,
+			&i.DeletedAt,
+			&i.AccountID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listImportBatchExpenses = `-- name: ListImportBatchExpenses :many
+SELECT e.id, e.date, e.description, e.amount_cents, e.primary_category, e.secondary_category, e.version, e.created_at, e.synced_at, e.sync_status, e.uid, e.modified_at, e.status, e.paid_by, e.deleted_at, e.account_id FROM expenses e
+JOIN import_batch_expenses l ON l.expense_id = e.id
+WHERE l.batch_id = ?
+ORDER BY e.id
+`
+
+// Expenses created by an import that still exist.
+func (q *Queries) ListImportBatchExpenses(ctx context.Context, batchID int64) ([]Expense, error) {
+	rows, err := q.db.QueryContext(ctx, listImportBatchExpenses, batchID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Expense
+	for rows.Next() {
+		var i Expense
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.Description,
+			&i.AmountCents,
+			&i.PrimaryCategory,
+			&i.SecondaryCategory,
+			&i.Version,
+			&i.CreatedAt,
+			&i.SyncedAt,
+			&i.SyncStatus,
+			&i.Uid,
+			&i.ModifiedAt,
+			&i.Status,
+			&i.PaidBy,
+			&i.DeletedAt,
+			&i.AccountID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listImportBatches = `-- name: ListImportBatches :many
+SELECT b.id, b.filename, b.expenses, b.created_at, b.rolled_back_at,
+    (SELECT COUNT(*) FROM import_batch_expenses l
+     JOIN expenses e ON e.id = l.expense_id
+     WHERE l.batch_id = b.id) AS remaining
+FROM import_batches b
+ORDER BY b.id DESC
+LIMIT ?
+`
+
+type ListImportBatchesRow struct {
+	ID           int64        `db:"id" json:"id"`
+	Filename     string       `db:"filename" json:"filename"`
+	Expenses     int64        `db:"expenses" json:"expenses"`
+	CreatedAt    time.Time    `db:"created_at" json:"created_at"`
+	RolledBackAt sql.NullTime `db:"rolled_back_at" json:"rolled_back_at"`
+	Remaining    int64        `db:"remaining" json:"remaining"`
+}
+
+// Latest imports, newest first, with how many of their expenses still exist.
+func (q *Queries) ListImportBatches(ctx context.Context, limit int64) ([]ListImportBatchesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listImportBatches, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListImportBatchesRow
+	for rows.Next() {
+		var i ListImportBatchesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Filename,
+			&i.Expenses,
+			&i.CreatedAt,
+			&i.RolledBackAt,
+			&i.Remaining,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listIncomesByDateRange = `-- name: ListIncomesByDateRange :many
+SELECT id, date, description, amount_cents, category, version, created_at, synced_at, sync_status, uid, modified_at, subcategory, tags, deleted_at, account_id FROM incomes
+WHERE date >= ? AND date <= ? AND deleted_at IS NULL
+ORDER BY date DESC, id DESC
+`
+
+type ListIncomesByDateRangeParams struct {
+	Date   time.Time `db:"date" json:"date"`
+	Date_2 time.Time `db:"date_2" json:"date_2"`
+}
+
+func (q *Queries) ListIncomesByDateRange(ctx context.Context, arg ListIncomesByDateRangeParams) ([]Income, error) {
+	rows, err := q.db.QueryContext(ctx, listIncomesByDateRange, arg.Date, arg.Date_2)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Income
+	for rows.Next() {
+		var i Income
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.Description,
+			&i.AmountCents,
+			&i.Category,
+			&i.Version,
+			&i.CreatedAt,
+			&i.SyncedAt,
+			&i.SyncStatus,
+			&i.Uid,
+			&i.ModifiedAt,
+			&i.Subcategory,
+			&i.Tags,
+			&i.DeletedAt,
+			&i.AccountID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listIncomesToPurge = `-- name: ListIncomesToPurge :many
+
+SELECT id FROM incomes
+WHERE deleted_at IS NOT NULL AND deleted_at < ?
+ORDER BY id
+`
+
+// Incomes in the trash since before the cutoff.
+func (q *Queries) ListIncomesToPurge(ctx context.Context, deletedAt sql.NullTime) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, listIncomesToPurge, deletedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listInsightMutes = `-- name: ListInsightMutes :many
+SELECT kind, primary_category, created_at FROM insight_mutes
+ORDER BY primary_category, kind
+`
+
+func (q *Queries) ListInsightMutes(ctx context.Context) ([]InsightMute, error) {
+	rows, err := q.db.QueryContext(ctx, listInsightMutes)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []InsightMute
+	for rows.Next() {
+		var i InsightMute
+		if err := rows.Scan(&i.Kind, &i.PrimaryCategory, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listInsights = `-- name: ListInsights :many
+
+SELECT i.id, i.kind, i.primary_category, i.period, i.occurred_on, i.amount_cents, i.baseline_cents, i.days, i.since_period, i.dismissed_at, i.created_at FROM insights i
+WHERE i.dismissed_at IS NULL
+  AND NOT EXISTS (SELECT 1 FROM insight_mutes m WHERE m.kind = i.kind AND m.primary_category = i.primary_category)
+ORDER BY i.occurred_on DESC, i.id DESC
+LIMIT ?
+`
+
+// Insights not dismissed nor muted, newest first.
+func (q *Queries) ListInsights(ctx context.Context, limit int64) ([]Insight, error) {
+	rows, err := q.db.QueryContext(ctx, listInsights, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Insight
+	for rows.Next() {
+		var i Insight
+		if err := rows.Scan(
+			&i.ID,
+			&i.Kind,
+			&i.PrimaryCategory,
+			&i.Period,
+			&i.OccurredOn,
+			&i.AmountCents,
+			&i.BaselineCents,
+			&i.Days,
+			&i.SincePeriod,
+			&i.DismissedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listItemPrices = `-- name: ListItemPrices :many
+SELECT item_id, date, quantity, unit_price_cents FROM item_prices
+ORDER BY item_id, date, id
+`
+
+type ListItemPricesRow struct {
+	ItemID         int64     `db:"item_id" json:"item_id"`
+	Date           time.Time `db:"date" json:"date"`
+	Quantity       float64   `db:"quantity" json:"quantity"`
+	UnitPriceCents int64     `db:"unit_price_cents" json:"unit_price_cents"`
+}
+
+func (q *Queries) ListItemPrices(ctx context.Context) ([]ListItemPricesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listItemPrices)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListItemPricesRow
+	for rows.Next() {
+		var i ListItemPricesRow
+		if err := rows.Scan(
+			&i.ItemID,
+			&i.Date,
+			&i.Quantity,
+			&i.UnitPriceCents,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listItems = `-- name: ListItems :many
+SELECT id, name, normalized_name, created_at FROM items
+ORDER BY normalized_name
+`
+
+func (q *Queries) ListItems(ctx context.Context) ([]Item, error) {
+	rows, err := q.db.QueryContext(ctx, listItems)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Item
+	for rows.Next() {
+		var i Item
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.NormalizedName,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLedgerExpenses = `-- name: ListLedgerExpenses :many
+
+SELECT id, date, description, amount_cents, primary_category, secondary_category, version, created_at, synced_at, sync_status, uid, modified_at, status, paid_by, deleted_at, account_id FROM expenses
+WHERE date BETWEEN date(?1) AND date(?2)
+  AND deleted_at IS NULL
+  AND (date < date(?3) OR (date = date(?3) AND id < ?4))
+ORDER BY date DESC, id DESC
+LIMIT ?5
+`
+
+type ListLedgerExpensesParams struct {
+	FromDate  interface{} `db:"from_date" json:"from_date"`
+	ToDate    interface{} `db:"to_date" json:"to_date"`
+	AfterDate interface{} `db:"after_date" json:"after_date"`
+	AfterID   int64       `db:"after_id" json:"after_id"`
+	PageSize  int64       `db:"page_size" json:"page_size"`
+}
+
+// A page of the expenses between two dates, newest first, starting right
+// after the expense (after_date, after_id) in that order.
+func (q *Queries) ListLedgerExpenses(ctx context.Context, arg ListLedgerExpensesParams) ([]Expense, error) {
+	rows, err := q.db.QueryContext(ctx, listLedgerExpenses,
+		arg.FromDate,
+		arg.ToDate,
+		arg.AfterDate,
+		arg.AfterID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Expense
+	for rows.Next() {
+		var i Expense
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.Description,
+			&i.AmountCents,
+			&i.PrimaryCategory,
+			&i.SecondaryCategory,
+			&i.Version,
+			&i.CreatedAt,
+			&i.SyncedAt,
+			&i.SyncStatus,
+			&i.Uid,
+			&i.ModifiedAt,
+			&i.Status,
+			&i.PaidBy,
+			&i.DeletedAt,
+			&i.AccountID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMonthLineItems = `-- name: ListMonthLineItems :many
+
+SELECT l.expense_id, e.amount_cents as expense_amount_cents, e.primary_category as expense_primary_category, e.status,
+       l.amount_cents, l.primary_category
+FROM expense_line_items l
+JOIN expenses e ON e.id = l.expense_id
+WHERE strftime('%Y', e.date) = printf('%04d', ?)
+  AND strftime('%m', e.date) = printf('%02d', ?)
+  AND e.deleted_at IS NULL
+ORDER BY l.expense_id, l.position
+`
+
+type ListMonthLineItemsParams struct {
+	PRINTF   interface{} `db:"PRINTF" json:"PRINTF"`
+	PRINTF_2 interface{} `db:"PRINTF_2" json:"PRINTF_2"`
+}
+
+type ListMonthLineItemsRow struct {
+	ExpenseID              int64  `db:"expense_id" json:"expense_id"`
+	ExpenseAmountCents     int64  `db:"expense_amount_cents" json:"expense_amount_cents"`
+	ExpensePrimaryCategory string `db:"expense_primary_category" json:"expense_primary_category"`
+	Status                 string `db:"status" json:"status"`
+	AmountCents            int64  `db:"amount_cents" json:"amount_cents"`
+	PrimaryCategory        string `db:"primary_category" json:"primary_category"`
+}
+
+// Line items of the month's expenses, grouped by expense.
+func (q *Queries) ListMonthLineItems(ctx context.Context, arg ListMonthLineItemsParams) ([]ListMonthLineItemsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listMonthLineItems, arg.PRINTF, arg.PRINTF_2)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListMonthLineItemsRow
+	for rows.Next() {
+		var i ListMonthLineItemsRow
+		if err := rows.Scan(
+			&i.ExpenseID,
+			&i.ExpenseAmountCents,
+			&i.ExpensePrimaryCategory,
+			&i.Status,
+			&i.AmountCents,
+			&i.PrimaryCategory,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMonthReviews = `-- name: ListMonthReviews :many
+SELECT period, closed_at FROM month_reviews ORDER BY period DESC
+`
+
+func (q *Queries) ListMonthReviews(ctx context.Context) ([]MonthReview, error) {
+	rows, err := q.db.QueryContext(ctx, listMonthReviews)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []MonthReview
+	for rows.Next() {
+		var i MonthReview
+		if err := rows.Scan(&i.Period, &i.ClosedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMonthSplits = `-- name: ListMonthSplits :many
+
+SELECT s.expense_id, e.amount_cents as expense_amount_cents, e.primary_category as expense_primary_category, e.status,
+       s.note, s.amount_cents, s.primary_category, s.secondary_category
+FROM expense_splits s
+JOIN expenses e ON e.id = s.expense_id
+WHERE strftime('%Y', e.date) = printf('%04d', ?)
+  AND strftime('%m', e.date) = printf('%02d', ?)
+  AND e.deleted_at IS NULL
+ORDER BY s.expense_id, s.position
+`
+
+type ListMonthSplitsParams struct {
+	PRINTF   interface{} `db:"PRINTF" json:"PRINTF"`
+	PRINTF_2 interface{} `db:"PRINTF_2" json:"PRINTF_2"`
+}
+
+type ListMonthSplitsRow struct {
+	ExpenseID              int64  `db:"expense_id" json:"expense_id"`
+	ExpenseAmountCents     int64  `db:"expense_amount_cents" json:"expense_amount_cents"`
+	ExpensePrimaryCategory string `db:"expense_primary_category" json:"expense_primary_category"`
+	Status                 string `db:"status" json:"status"`
+	Note                   string `db:"note" json:"note"`
+	AmountCents            int64  `db:"amount_cents" json:"amount_cents"`
+	PrimaryCategory        string `db:"primary_category" json:"primary_category"`
+	SecondaryCategory      string `db:"secondary_category" json:"secondary_category"`
+}
+
+// Parts of the month's split expenses, grouped by expense.
+func (q *Queries) ListMonthSplits(ctx context.Context, arg ListMonthSplitsParams) ([]ListMonthSplitsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listMonthSplits, arg.PRINTF, arg.PRINTF_2)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListMonthSplitsRow
+	for rows.Next() {
+		var i ListMonthSplitsRow
+		if err := rows.Scan(
+			&i.ExpenseID,
+			&i.ExpenseAmountCents,
+			&i.ExpensePrimaryCategory,
+			&i.Status,
+			&i.Note,
+			&i.AmountCents,
+			&i.PrimaryCategory,
+			&i.SecondaryCategory,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listNonPositiveAmounts = `-- name: ListNonPositiveAmounts :many
+SELECT 'expenses' AS table_name, id, amount_cents FROM expenses WHERE amount_cents <= 0
+UNION ALL
+SELECT 'incomes', id, amount_cents FROM incomes WHERE amount_cents <= 0
+UNION ALL
+SELECT 'recurrent_expenses', id, amount_cents FROM recurrent_expenses WHERE amount_cents <= 0
+UNION ALL
+SELECT 'recurrent_incomes', id, amount_cents FROM recurrent_incomes WHERE amount_cents <= 0
+ORDER BY table_name, id
+`
+
+type ListNonPositiveAmountsRow struct {
+	TableName   string `db:"table_name" json:"table_name"`
+	ID          int64  `db:"id" json:"id"`
+	AmountCents int64  `db:"amount_cents" json:"amount_cents"`
+}
+
+// Amounts that must be positive but are not, left by databases older
+// than the CHECK constraints or written with them disabled.
+func (q *Queries) ListNonPositiveAmounts(ctx context.Context) ([]ListNonPositiveAmountsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listNonPositiveAmounts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListNonPositiveAmountsRow
+	for rows.Next() {
+		var i ListNonPositiveAmountsRow
+		if err := rows.Scan(
+			&i.TableName,
+			&i.ID,
+			&i.AmountCents,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOpenSyncItems = `-- name: ListOpenSyncItems :many
+SELECT q.id, q.operation, q.expense_id, q.status, q.attempts,
+    CAST(COALESCE(q.last_error, '') AS TEXT) AS last_error,
+    q.next_retry_at, q.updated_at,
+    e.date AS expense_date,
+    CAST(COALESCE(e.description, q.expense_description, '') AS TEXT) AS description,
+    CAST(COALESCE(e.amount_cents, q.expense_amount_cents, 0) AS INTEGER) AS amount_cents
+FROM sync_queue q
+LEFT JOIN expenses e ON e.id = q.expense_id
+WHERE q.status != 'completed'
+ORDER BY CASE q.status WHEN 'failed' THEN 0 ELSE 1 END, q.id DESC
+LIMIT ?
+`
+
+type ListOpenSyncItemsRow struct {
+	ID          int64        `db:"id" json:"id"`
+	Operation   string       `db:"operation" json:"operation"`
+	ExpenseID   int64        `db:"expense_id" json:"expense_id"`
+	Status      string       `db:"status" json:"status"`
+	Attempts    int64        `db:"attempts" json:"attempts"`
+	LastError   string       `db:"last_error" json:"last_error"`
+	NextRetryAt interface{}  `db:"next_retry_at" json:"next_retry_at"`
+	UpdatedAt   time.Time    `db:"updated_at" json:"updated_at"`
+	ExpenseDate sql.NullTime `db:"expense_date" json:"expense_date"`
+	Description string       `db:"description" json:"description"`
+	AmountCents int64        `db:"amount_cents" json:"amount_cents"`
+}
+
+// Returns the queue items not written yet, failed first, with the expense
+// they refer to; deleted expenses use the data stored in the item.
+func (q *Queries) ListOpenSyncItems(ctx context.Context, limit int64) ([]ListOpenSyncItemsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listOpenSyncItems, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListOpenSyncItemsRow
+	for rows.Next() {
+		var i ListOpenSyncItemsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Operation,
+			&i.ExpenseID,
+			&i.Status,
+			&i.Attempts,
+			&i.LastError,
+			&i.NextRetryAt,
+			&i.UpdatedAt,
+			&i.ExpenseDate,
+			&i.Description,
+			&i.AmountCents,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOrphanCategoryPairs = `-- name: ListOrphanCategoryPairs :many
+SELECT u.primary_category, u.secondary_category,
+    CAST(SUM(u.expense) AS INTEGER) AS expense_count,
+    CAST(SUM(u.recurrent) AS INTEGER) AS recurrent_count,
+    COALESCE((
+        SELECT p.name FROM secondary_categories s
+        JOIN primary_categories p ON p.id = s.primary_category_id
+        WHERE s.name = u.secondary_category
+        ORDER BY p.name
+        LIMIT 1
+    ), '') AS suggested_primary
+FROM (
+    SELECT primary_category, secondary_category, 1 AS expense, 0 AS recurrent FROM expenses
+    UNION ALL
+    SELECT primary_category, secondary_category, 0 AS expense, 1 AS recurrent FROM recurrent_expenses
+) u
+WHERE EXISTS (SELECT 1 FROM primary_categories)
+  AND NOT EXISTS (
+    SELECT 1 FROM secondary_categories s
+    JOIN primary_categories p ON p.id = s.primary_category_id
+    WHERE p.name = u.primary_category AND s.name = u.secondary_category
+  )
+GROUP BY u.primary_category, u.secondary_category
+ORDER BY expense_count DESC, u.primary_category, u.secondary_category
+`
+
+type ListOrphanCategoryPairsRow struct {
+	PrimaryCategory   string `db:"primary_category" json:"primary_category"`
+	SecondaryCategory string `db:"secondary_category" json:"secondary_category"`
+	ExpenseCount      int64  `db:"expense_count" json:"expense_count"`
+	RecurrentCount    int64  `db:"recurrent_count" json:"recurrent_count"`
+	SuggestedPrimary  string `db:"suggested_primary" json:"suggested_primary"`
+}
+
+// Category pairs used by expenses or recurrent expenses that are not among
+// the categories, e.g. left by a removed or renamed primary category, with
+// the first primary category holding a secondary of the same name.
+func (q *Queries) ListOrphanCategoryPairs(ctx context.Context) ([]ListOrphanCategoryPairsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listOrphanCategoryPairs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListOrphanCategoryPairsRow
+	for rows.Next() {
+		var i ListOrphanCategoryPairsRow
+		if err := rows.Scan(
+			&i.PrimaryCategory,
+			&i.SecondaryCategory,
+			&i.ExpenseCount,
+			&i.RecurrentCount,
+			&i.SuggestedPrimary,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOrphanReceipts = `-- name: ListOrphanReceipts :many
+SELECT r.expense_id, r.blob_key, r.filename
+FROM receipts r
+WHERE NOT EXISTS (SELECT 1 FROM expenses e WHERE e.id = r.expense_id)
+ORDER BY r.expense_id
+`
+
+type ListOrphanReceiptsRow struct {
+	ExpenseID int64  `db:"expense_id" json:"expense_id"`
+	BlobKey   string `db:"blob_key" json:"blob_key"`
+	Filename  string `db:"filename" json:"filename"`
+}
+
+// Receipts of expenses that no longer exist.
+func (q *Queries) ListOrphanReceipts(ctx context.Context) ([]ListOrphanReceiptsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listOrphanReceipts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListOrphanReceiptsRow
+	for rows.Next() {
+		var i ListOrphanReceiptsRow
+		if err := rows.Scan(
+			&i.ExpenseID,
+			&i.BlobKey,
+			&i.Filename,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPeerChanges = `-- name: ListPeerChanges :many
+SELECT seq, kind, uid FROM peer_changelog
+WHERE seq > ?
+ORDER BY seq ASC
+LIMIT ?
+`
+
+type ListPeerChangesParams struct {
+	Seq   int64 `db:"seq" json:"seq"`
+	Limit int64 `db:"limit" json:"limit"`
+}
+
+// Latest changes after the cursor, oldest first.
+func (q *Queries) ListPeerChanges(ctx context.Context, arg ListPeerChangesParams) ([]PeerChangelog, error) {
+	rows, err := q.db.QueryContext(ctx, listPeerChanges, arg.Seq, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PeerChangelog
+	for rows.Next() {
+		var i PeerChangelog
+		if err := rows.Scan(&i.Seq, &i.Kind, &i.Uid); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPurchaseWarranties = `-- name: ListPurchaseWarranties :many
+SELECT w.expense_id, w.warranty_months, w.return_deadline, w.proof,
+       e.date, e.description, e.amount_cents, e.primary_category, e.secondary_category
+FROM purchase_warranties w
+JOIN expenses e ON e.id = w.expense_id
+WHERE e.deleted_at IS NULL
+ORDER BY e.date DESC, e.id DESC
+`
+
+type ListPurchaseWarrantiesRow struct {
+	ExpenseID         int64        `db:"expense_id" json:"expense_id"`
+	WarrantyMonths    int64        `db:"warranty_months" json:"warranty_months"`
+	ReturnDeadline    sql.NullTime `db:"return_deadline" json:"return_deadline"`
+	Proof             string       `db:"proof" json:"proof"`
+	Date              time.Time    `db:"date" json:"date"`
+	Description       string       `db:"description" json:"description"`
+	AmountCents       int64        `db:"amount_cents" json:"amount_cents"`
+	PrimaryCategory   string       `db:"primary_category" json:"primary_category"`
+	SecondaryCategory string       `db:"secondary_category" json:"secondary_category"`
+}
+
+func (q *Queries) ListPurchaseWarranties(ctx context.Context) ([]ListPurchaseWarrantiesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listPurchaseWarranties)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPurchaseWarrantiesRow
+	for rows.Next() {
+		var i ListPurchaseWarrantiesRow
+		if err := rows.Scan(
+			&i.ExpenseID,
+			&i.WarrantyMonths,
+			&i.ReturnDeadline,
+			&i.Proof,
+			&i.Date,
+			&i.Description,
+			&i.AmountCents,
+			&i.PrimaryCategory,
+			&i.SecondaryCategory,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listReceiptsBetween = `-- name: ListReceiptsBetween :many
+SELECT r.expense_id, r.blob_key, r.thumbnail_key, r.content_type, r.filename, r.size_bytes, r.created_at, r.uploaded_by
+FROM receipts r
+JOIN expenses e ON e.id = r.expense_id
+WHERE date(e.date) >= date(?1)
+  AND date(e.date) < date(?2)
+  AND e.deleted_at IS NULL
+`
+
+type ListReceiptsBetweenParams struct {
+	FromDate interface{} `db:"from_date" json:"from_date"`
+	ToDate   interface{} `db:"to_date" json:"to_date"`
+}
+
+// Receipts of the expenses dated in the range, end excluded.
+func (q *Queries) ListReceiptsBetween(ctx context.Context, arg ListReceiptsBetweenParams) ([]Receipt, error) {
+	rows, err := q.db.QueryContext(ctx, listReceiptsBetween, arg.FromDate, arg.ToDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Receipt
+	for rows.Next() {
+		var i Receipt
+		if err := rows.Scan(
+			&i.ExpenseID,
+			&i.BlobKey,
+			&i.ThumbnailKey,
+			&i.ContentType,
+			&i.Filename,
+			&i.SizeBytes,
+			&i.CreatedAt,
+			&i.UploadedBy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRecurrentsEndingBeforeStart = `-- name: ListRecurrentsEndingBeforeStart :many
+SELECT 'expense' AS kind, id, description,
+    CAST(date(start_date) AS TEXT) AS start_date, CAST(date(end_date) AS TEXT) AS end_date
+FROM recurrent_expenses
+WHERE end_date IS NOT NULL AND date(end_date) < date(start_date)
+UNION ALL
+SELECT 'income' AS kind, id, description,
+    CAST(date(start_date) AS TEXT) AS start_date, CAST(date(end_date) AS TEXT) AS end_date
+FROM recurrent_incomes
+WHERE end_date IS NOT NULL AND date(end_date) < date(start_date)
+ORDER BY kind, id
+`
+
+type ListRecurrentsEndingBeforeStartRow struct {
+	Kind        string `db:"kind" json:"kind"`
+	ID          int64  `db:"id" json:"id"`
+	Description string `db:"description" json:"description"`
+	StartDate   string `db:"start_date" json:"start_date"`
+	EndDate     string `db:"end_date" json:"end_date"`
+}
+
+// Recurrent expenses and incomes whose end date comes before the start.
+func (q *Queries) ListRecurrentsEndingBeforeStart(ctx context.Context) ([]ListRecurrentsEndingBeforeStartRow, error) {
+	rows, err := q.db.QueryContext(ctx, listRecurrentsEndingBeforeStart)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListRecurrentsEndingBeforeStartRow
+	for rows.Next() {
+		var i ListRecurrentsEndingBeforeStartRow
+		if err := rows.Scan(
+			&i.Kind,
+			&i.ID,
+			&i.Description,
+			&i.StartDate,
+			&i.EndDate,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listReturnDeadlinesToNotify = `-- name: ListReturnDeadlinesToNotify :many
+
+SELECT w.expense_id, w.warranty_months, w.return_deadline, w.proof,
+       e.date, e.description, e.amount_cents, e.primary_category, e.secondary_category
+FROM purchase_warranties w
+JOIN expenses e ON e.id = w.expense_id
+WHERE w.return_notified_at IS NULL AND e.deleted_at IS NULL
+  AND w.return_deadline >= ? AND w.return_deadline <= ?
+ORDER BY w.return_deadline, w.expense_id
+`
+
+type ListReturnDeadlinesToNotifyParams struct {
+	ReturnDeadline   sql.NullTime `db:"return_deadline" json:"return_deadline"`
+	ReturnDeadline_2 sql.NullTime `db:"return_deadline_2" json:"return_deadline_2"`
+}
+
+type ListReturnDeadlinesToNotifyRow struct {
+	ExpenseID         int64        `db:"expense_id" json:"expense_id"`
+	WarrantyMonths    int64        `db:"warranty_months" json:"warranty_months"`
+	ReturnDeadline    sql.NullTime `db:"return_deadline" json:"return_deadline"`
+	Proof             string       `db:"proof" json:"proof"`
+	Date              time.Time    `db:"date" json:"date"`
+	Description       string       `db:"description" json:"description"`
+	AmountCents       int64        `db:"amount_cents" json:"amount_cents"`
+	PrimaryCategory   string       `db:"primary_category" json:"primary_category"`
+	SecondaryCategory string       `db:"secondary_category" json:"secondary_category"`
+}
+
+// Return deadlines within the range whose reminder was not sent yet.
+func (q *Queries) ListReturnDeadlinesToNotify(ctx context.Context, arg ListReturnDeadlinesToNotifyParams) ([]ListReturnDeadlinesToNotifyRow, error) {
+	rows, err := q.db.QueryContext(ctx, listReturnDeadlinesToNotify, arg.ReturnDeadline, arg.ReturnDeadline_2)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListReturnDeadlinesToNotifyRow
+	for rows.Next() {
+		var i ListReturnDeadlinesToNotifyRow
+		if err := rows.Scan(
+			&i.ExpenseID,
+			&i.WarrantyMonths,
+			&i.ReturnDeadline,
+			&i.Proof,
+			&i.Date,
+			&i.Description,
+			&i.AmountCents,
+			&i.PrimaryCategory,
+			&i.SecondaryCategory,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSavedViews = `-- name: ListSavedViews :many
+SELECT id, name, primary_category, secondary_category, min_cents, max_cents, text, notify, created_at, tag FROM saved_views ORDER BY name
+`
+
+func (q *Queries) ListSavedViews(ctx context.Context) ([]SavedView, error) {
+	rows, err := q.db.QueryContext(ctx, listSavedViews)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SavedView
+	for rows.Next() {
+		var i SavedView
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.PrimaryCategory,
+			&i.SecondaryCategory,
+			&i.MinCents,
+			&i.MaxCents,
+			&i.Text,
+			&i.Notify,
+			&i.CreatedAt,
+			&i.Tag,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSheetHistoryImports = `-- name: ListSheetHistoryImports :many
+SELECT year, expenses, imported_at, total_rows, next_row, last_error, completed_at FROM sheet_history_imports ORDER BY year
+`
+
+func (q *Queries) ListSheetHistoryImports(ctx context.Context) ([]SheetHistoryImport, error) {
+	rows, err := q.db.QueryContext(ctx, listSheetHistoryImports)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SheetHistoryImport
+	for rows.Next() {
+		var i SheetHistoryImport
+		if err := rows.Scan(
+			&i.Year,
+			&i.Expenses,
+			&i.ImportedAt,
+			&i.TotalRows,
+			&i.NextRow,
+			&i.LastError,
+			&i.CompletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listShoppingListItems = `-- name: ListShoppingListItems :many
+SELECT id, list_id, name, quantity, price_cents, checked, created_at FROM shopping_list_items
+WHERE list_id = ?
+ORDER BY id
+`
+
+func (q *Queries) ListShoppingListItems(ctx context.Context, listID int64) ([]ShoppingListItem, error) {
+	rows, err := q.db.QueryContext(ctx, listShoppingListItems, listID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ShoppingListItem
+	for rows.Next() {
+		var i ShoppingListItem
+		if err := rows.Scan(
+			&i.ID,
+			&i.ListID,
+			&i.Name,
+			&i.Quantity,
+			&i.PriceCents,
+			&i.Checked,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listShoppingLists = `-- name: ListShoppingLists :many
+
+SELECT l.id, l.name, l.expense_id, l.converted_at, l.created_at,
+       CAST(COUNT(i.id) AS INTEGER) as item_count,
+       CAST(COALESCE(SUM(CASE WHEN i.checked THEN i.price_cents ELSE 0 END), 0) AS INTEGER) as checked_cents
+FROM shopping_lists l
+LEFT JOIN shopping_list_items i ON i.list_id = l.id
+GROUP BY l.id
+ORDER BY l.converted_at IS NOT NULL, l.converted_at DESC, l.created_at DESC, l.id DESC
+LIMIT ?
+`
+
+type ListShoppingListsRow struct {
+	ID           int64         `db:"id" json:"id"`
+	Name         string        `db:"name" json:"name"`
+	ExpenseID    sql.NullInt64 `db:"expense_id" json:"expense_id"`
+	ConvertedAt  sql.NullTime  `db:"converted_at" json:"converted_at"`
+	CreatedAt    time.Time     `db:"created_at" json:"created_at"`
+	ItemCount    int64         `db:"item_count" json:"item_count"`
+	CheckedCents int64         `db:"checked_cents" json:"checked_cents"`
+}
+
+// Open lists first, then the most recent conversions.
+func (q *Queries) ListShoppingLists(ctx context.Context, limit int64) ([]ListShoppingListsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listShoppingLists, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListShoppingListsRow
+	for rows.Next() {
+		var i ListShoppingListsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.ExpenseID,
+			&i.ConvertedAt,
+			&i.CreatedAt,
+			&i.ItemCount,
+			&i.CheckedCents,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSplitExpenseIDs = `-- name: ListSplitExpenseIDs :many
+
+SELECT DISTINCT expense_id FROM expense_splits ORDER BY expense_id
+`
+
+// The IDs of the split expenses.
+func (q *Queries) ListSplitExpenseIDs(ctx context.Context) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, listSplitExpenseIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var expense_id int64
+		if err := rows.Scan(&expense_id); err != nil {
+			return nil, err
+		}
+		items = append(items, expense_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSyncErrors = `-- name: ListSyncErrors :many
+SELECT id, queue_id, expense_id, operation, attempt, class, message, created_at FROM sync_errors
+ORDER BY id DESC
+LIMIT ?
+`
+
+// Returns the latest failed sync attempts, newest first.
+func (q *Queries) ListSyncErrors(ctx context.Context, limit int64) ([]SyncError, error) {
+	rows, err := q.db.QueryContext(ctx, listSyncErrors, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SyncError
+	for rows.Next() {
+		var i SyncError
+		if err := rows.Scan(
+			&i.ID,
+			&i.QueueID,
+			&i.ExpenseID,
+			&i.Operation,
+			&i.Attempt,
+			&i.Class,
+			&i.Message,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTags = `-- name: ListTags :many
+SELECT name, uses FROM (
+    SELECT t.name,
+           CAST((SELECT COUNT(*) FROM expense_tags WHERE tag_id = t.id)
+              + (SELECT COUNT(*) FROM income_tags WHERE tag_id = t.id) AS INTEGER) AS uses
+    FROM tags t
+)
+WHERE uses > 0
+ORDER BY uses DESC, name
+`
+
+type ListTagsRow struct {
+	Name string `db:"name" json:"name"`
+	Uses int64  `db:"uses" json:"uses"`
+}
+
+// Tags in use by expenses or incomes, most used first, for autocompletion.
+func (q *Queries) ListTags(ctx context.Context) ([]ListTagsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listTags)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTagsRow
+	for rows.Next() {
+		var i ListTagsRow
+		if err := rows.Scan(
+			&i.Name,
+			&i.Uses,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTrainingExpenses = `-- name: ListTrainingExpenses :many
+
+SELECT description, primary_category, secondary_category
+FROM expenses
+WHERE secondary_category != ?1 AND deleted_at IS NULL
+ORDER BY date DESC, id DESC
+LIMIT ?2
+`
+
+type ListTrainingExpensesParams struct {
+	Uncategorized string `db:"uncategorized" json:"uncategorized"`
+	MaxRows       int64  `db:"max_rows" json:"max_rows"`
+}
+
+type ListTrainingExpensesRow struct {
+	Description       string `db:"description" json:"description"`
+	PrimaryCategory   string `db:"primary_category" json:"primary_category"`
+	SecondaryCategory string `db:"secondary_category" json:"secondary_category"`
+}
+
+// Most recent categorized expenses, the classifier's training set.
+func (q *Queries) ListTrainingExpenses(ctx context.Context, arg ListTrainingExpensesParams) ([]ListTrainingExpensesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listTrainingExpenses, arg.Uncategorized, arg.MaxRows)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTrainingExpensesRow
+	for rows.Next() {
+		var i ListTrainingExpensesRow
+		if err := rows.Scan(
+			&i.Description,
+			&i.PrimaryCategory,
+			&i.SecondaryCategory,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTrashedExpenses = `-- name: ListTrashedExpenses :many
+SELECT id, date, description, amount_cents, primary_category, secondary_category, version, created_at, synced_at, sync_status, uid, modified_at, status, paid_by, deleted_at, account_id FROM expenses
+WHERE deleted_at IS NOT NULL
+ORDER BY deleted_at DESC, id DESC
+`
+
+func (q *Queries) ListTrashedExpenses(ctx context.Context) ([]Expense, error) {
+	rows, err := q.db.QueryContext(ctx, listTrashedExpenses)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Expense
+	for rows.Next() {
+		var i Expense
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.Description,
+			&i.AmountCents,
+			&i.PrimaryCategory,
+			&i.SecondaryCategory,
+			&i.Version,
+			&i.CreatedAt,
+			&i.SyncedAt,
+			&i.SyncStatus,
+			&i.Uid,
+			&i.ModifiedAt,
+			&i.Status,
+			&i.PaidBy,
+			&i.DeletedAt,
+			&i.AccountID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTrashedIncomes = `-- name: ListTrashedIncomes :many
+SELECT id, date, description, amount_cents, category, version, created_at, synced_at, sync_status, uid, modified_at, subcategory, tags, deleted_at, account_id FROM incomes
+WHERE deleted_at IS NOT NULL
+ORDER BY deleted_at DESC, id DESC
+`
+
+func (q *Queries) ListTrashedIncomes(ctx context.Context) ([]Income, error) {
+	rows, err := q.db.QueryContext(ctx, listTrashedIncomes)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Income
+	for rows.Next() {
+		var i Income
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.Description,
+			&i.AmountCents,
+			&i.Category,
+			&i.Version,
+			&i.CreatedAt,
+			&i.SyncedAt,
+			&i.SyncStatus,
+			&i.Uid,
+			&i.ModifiedAt,
+			&i.Subcategory,
+			&i.Tags,
+			&i.DeletedAt,
+			&i.AccountID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUncategorizedExpenses = `-- name: ListUncategorizedExpenses :many
+
+SELECT e.id, e.date, e.description, e.amount_cents, e.primary_category, e.secondary_category, e.version, e.created_at, e.synced_at, e.sync_status, e.uid, e.modified_at, e.status, e.paid_by, e.deleted_at, e.account_id FROM expenses e
+LEFT JOIN classifier_feedback f ON f.expense_id = e.id
+WHERE e.secondary_category = ? AND f.expense_id IS NULL AND e.deleted_at IS NULL
+ORDER BY e.date DESC, e.id DESC
+LIMIT ?
+`
+
+type ListUncategorizedExpensesParams struct {
+	SecondaryCategory string `db:"secondary_category" json:"secondary_category"`
+	Limit             int64  `db:"limit" json:"limit"`
+}
+
+// Expenses in the placeholder category whose proposal was not reviewed yet.
+func (q *Queries) ListUncategorizedExpenses(ctx context.Context, arg ListUncategorizedExpensesParams) ([]Expense, error) {
+	rows, err := q.db.QueryContext(ctx, listUncategorizedExpenses, arg.SecondaryCategory, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Expense
+	for rows.Next() {
+		var i Expense
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.Description,
+			&i.AmountCents,
+			&i.PrimaryCategory,
+			&i.SecondaryCategory,
+			&i.Version,
+			&i.CreatedAt,
+			&i.SyncedAt,
+			&i.SyncStatus,
+			&i.Uid,
+			&i.ModifiedAt,
+			&i.Status,
+			&i.PaidBy,
+			&i.DeletedAt,
+			&i.AccountID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUnfinishedSyncAppends = `-- name: ListUnfinishedSyncAppends :many
+SELECT id, operation, expense_id, expense_day, expense_month, expense_description, expense_amount_cents, expense_primary, expense_secondary, status, attempts, max_attempts, last_error, created_at, updated_at, processed_at, next_retry_at, expense_version, trace_parent FROM sync_queue
+WHERE operation = 'sync'
+  AND status IN ('pending', 'processing')
+ORDER BY id
+`
+
+// Append items not completed yet, for the startup reconciliation with the sheet
+func (q *Queries) ListUnfinishedSyncAppends(ctx context.Context) ([]SyncQueue, error) {
+	rows, err := q.db.QueryContext(ctx, listUnfinishedSyncAppends)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SyncQueue
+	for rows.Next() {
+		var i SyncQueue
+		if err := rows.Scan(
+			&i.ID,
+			&i.Operation,
+			&i.ExpenseID,
+			&i.ExpenseDay,
+			&i.ExpenseMonth,
+			&i.ExpenseDescription,
+			&i.ExpenseAmountCents,
+			&i.ExpensePrimary,
+			&i.ExpenseSecondary,
+			&i.Status,
+			&i.Attempts,
+			&i.MaxAttempts,
+			&i.LastError,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ProcessedAt,
+			&i.NextRetryAt,
+			&i.ExpenseVersion,
+			&i.TraceParent,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUnusedCategories = `-- name: ListUnusedCategories :many
+SELECT pc.name AS primary_name, sc.name AS secondary_name, sc.archived
+FROM secondary_categories sc
+JOIN primary_categories pc ON pc.id = sc.primary_category_id
+WHERE NOT EXISTS (
+    SELECT 1 FROM expenses e
+    WHERE e.primary_category = pc.name AND e.secondary_category = sc.name
+  )
+  AND NOT EXISTS (
+    SELECT 1 FROM recurrent_expenses r
+    WHERE r.primary_category = pc.name AND r.secondary_category = sc.name
+  )
+UNION ALL
+SELECT pc.name AS primary_name, '' AS secondary_name, pc.archived
+FROM primary_categories pc
+WHERE NOT EXISTS (SELECT 1 FROM expenses e WHERE e.primary_category = pc.name)
+  AND NOT EXISTS (SELECT 1 FROM recurrent_expenses r WHERE r.primary_category = pc.name)
+ORDER BY primary_name, secondary_name
+`
+
+type ListUnusedCategoriesRow struct {
+	PrimaryName   string `db:"primary_name" json:"primary_name"`
+	SecondaryName string `db:"secondary_name" json:"secondary_name"`
+	Archived      bool   `db:"archived" json:"archived"`
+}
+
+// Categories no expense or recurrent expense uses; a primary category
+// comes with an empty secondary_name.
+func (q *Queries) ListUnusedCategories(ctx context.Context) ([]ListUnusedCategoriesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listUnusedCategories)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUnusedCategoriesRow
+	for rows.Next() {
+		var i ListUnusedCategoriesRow
+		if err := rows.Scan(
+			&i.PrimaryName,
+			&i.SecondaryName,
+			&i.Archived,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserQuotas = `-- name: ListUserQuotas :many
+SELECT user_id, daily_expenses, attachment_bytes FROM user_quotas
+`
+
+func (q *Queries) ListUserQuotas(ctx context.Context) ([]UserQuota, error) {
+	rows, err := q.db.QueryContext(ctx, listUserQuotas)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UserQuota
+	for rows.Next() {
+		var i UserQuota
+		if err := rows.Scan(
+			&i.UserID,
+			&i.DailyExpenses,
+			&i.AttachmentBytes,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsers = `-- name: ListUsers :many
+SELECT id, name, created_at FROM users ORDER BY name
+`
+
+func (q *Queries) ListUsers(ctx context.Context) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, listUsers)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(&i.ID, &i.Name, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUtilityUsage = `-- name: ListUtilityUsage :many
+SELECT u.expense_id, u.kind, u.quantity,
+       e.date, e.description, e.amount_cents, e.primary_category, e.secondary_category
+FROM utility_usage u
+JOIN expenses e ON e.id = u.expense_id
+WHERE e.deleted_at IS NULL
+ORDER BY u.kind, e.date, e.id
+`
+
+type ListUtilityUsageRow struct {
+	ExpenseID         int64     `db:"expense_id" json:"expense_id"`
+	Kind              string    `db:"kind" json:"kind"`
+	Quantity          float64   `db:"quantity" json:"quantity"`
+	Date              time.Time `db:"date" json:"date"`
+	Description       string    `db:"description" json:"description"`
+	AmountCents       int64     `db:"amount_cents" json:"amount_cents"`
+	PrimaryCategory   string    `db:"primary_category" json:"primary_category"`
+	SecondaryCategory string    `db:"secondary_category" json:"secondary_category"`
+}
+
+func (q *Queries) ListUtilityUsage(ctx context.Context) ([]ListUtilityUsageRow, error) {
+	rows, err := q.db.QueryContext(ctx, listUtilityUsage)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUtilityUsageRow
+	for rows.Next() {
+		var i ListUtilityUsageRow
+		if err := rows.Scan(
+			&i.ExpenseID,
+			&i.Kind,
+			&i.Quantity,
+			&i.Date,
+			&i.Description,
+			&i.AmountCents,
+			&i.PrimaryCategory,
+			&i.SecondaryCategory,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listVehicleExpenses = `-- name: ListVehicleExpenses :many
+
+SELECT e.id, e.date, e.description, e.amount_cents,
+       f.liters, f.odometer_km
+FROM expenses e
+LEFT JOIN fuel_fills f ON f.expense_id = e.id
+WHERE (e.secondary_category = ? OR f.expense_id IS NOT NULL)
+  AND e.date >= ? AND e.date <= ?
+  AND e.deleted_at IS NULL
+ORDER BY e.date, e.id
+`
+
+type ListVehicleExpensesParams struct {
+	SecondaryCategory string    `db:"secondary_category" json:"secondary_category"`
+	Date              time.Time `db:"date" json:"date"`
+	Date_2            time.Time `db:"date_2" json:"date_2"`
+}
+
+type ListVehicleExpensesRow struct {
+	ID          int64           `db:"id" json:"id"`
+	Date        time.Time       `db:"date" json:"date"`
+	Description string          `db:"description" json:"description"`
+	AmountCents int64           `db:"amount_cents" json:"amount_cents"`
+	Liters      sql.NullFloat64 `db:"liters" json:"liters"`
+	OdometerKm  sql.NullInt64   `db:"odometer_km" json:"odometer_km"`
+}
+
+// Expenses of the vehicle cost center, with their fuel fill if any.
+func (q *Queries) ListVehicleExpenses(ctx context.Context, arg ListVehicleExpensesParams) ([]ListVehicleExpensesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listVehicleExpenses, arg.SecondaryCategory, arg.Date, arg.Date_2)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListVehicleExpensesRow
+	for rows.Next() {
+		var i ListVehicleExpensesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.Description,
+			&i.AmountCents,
+			&i.Liters,
+			&i.OdometerKm,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markExpenseSyncError = `-- name: MarkExpenseSyncError :exec
+UPDATE expenses 
+SET sync_status = 'error'
+WHERE id = ?
+`
+
+func (q *Queries) MarkExpenseSyncError(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, markExpenseSyncError, id)
+	return err
+}
+
+const markExpenseSynced = `-- name: MarkExpenseSynced :exec
+UPDATE expenses 
+SET sync_status = 'synced', synced_at = CURRENT_TIMESTAMP
+WHERE id = ?
+`
+
+func (q *Queries) MarkExpenseSynced(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, markExpenseSynced, id)
+	return err
+}
+
+const markImportBatchRolledBack = `-- name: MarkImportBatchRolledBack :execrows
+UPDATE import_batches SET rolled_back_at = CURRENT_TIMESTAMP
+WHERE id = ? AND rolled_back_at IS NULL
+`
+
+func (q *Queries) MarkImportBatchRolledBack(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, markImportBatchRolledBack, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const markReturnReminderSent = `-- name: MarkReturnReminderSent :exec
+UPDATE purchase_warranties
+SET return_notified_at = CURRENT_TIMESTAMP
+WHERE expense_id = ?
+`
+
+func (q *Queries) MarkReturnReminderSent(ctx context.Context, expenseID int64) error {
+	_, err := q.db.ExecContext(ctx, markReturnReminderSent, expenseID)
+	return err
+}
+
+const markShoppingListConverted = `-- name: MarkShoppingListConverted :execrows
+UPDATE shopping_lists
+SET expense_id = ?, converted_at = CURRENT_TIMESTAMP
+WHERE id = ? AND converted_at IS NULL
+`
+
+type MarkShoppingListConvertedParams struct {
+	ExpenseID sql.NullInt64 `db:"expense_id" json:"expense_id"`
+	ID        int64         `db:"id" json:"id"`
+}
+
+func (q *Queries) MarkShoppingListConverted(ctx context.Context, arg MarkShoppingListConvertedParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, markShoppingListConverted, arg.ExpenseID, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const markSyncComplete = `-- name: MarkSyncComplete :exec
+UPDATE sync_queue
+SET status = 'completed',
+    processed_at = CURRENT_TIMESTAMP,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+`
+
+// Marks a sync queue item as successfully completed.
+func (q *Queries) MarkSyncComplete(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, markSyncComplete, id)
+	return err
+}
+
+const markSyncFailed = `-- name: MarkSyncFailed :exec
+UPDATE sync_queue
+SET status = 'failed',
+    attempts = attempts + 1,
+    last_error = ?,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+`
+
+type MarkSyncFailedParams struct {
+	LastError interface{} `db:"last_error" json:"last_error"`
+	ID        int64       `db:"id" json:"id"`
+}
+
+// Marks a sync queue item as failed after max retries exceeded, counting the
+// last attempt.
+func (q *Queries) MarkSyncFailed(ctx context.Context, arg MarkSyncFailedParams) error {
+	_, err := q.db.ExecContext(ctx, markSyncFailed, arg.LastError, arg.ID)
+	return err
+}
+
+const markSyncProcessing = `-- name: MarkSyncProcessing :exec
+UPDATE sync_queue
+SET status = 'processing', updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+`
+
+// Marks an item as being processed.
+func (q *Queries) MarkSyncProcessing(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, markSyncProcessing, id)
+	return err
+}
+
+const moveCategoryKeywordsCategory = `-- name: MoveCategoryKeywordsCategory :exec
+UPDATE category_keywords
+SET primary_category = ?1, secondary_category = ?2
+WHERE primary_category = ?3 AND secondary_category = ?4
+`
+
+type MoveCategoryKeywordsCategoryParams struct {
+	NewPrimary   string `db:"new_primary" json:"new_primary"`
+	NewSecondary string `db:"new_secondary" json:"new_secondary"`
+	OldPrimary   string `db:"old_primary" json:"old_primary"`
+	OldSecondary string `db:"old_secondary" json:"old_secondary"`
+}
+
+func (q *Queries) MoveCategoryKeywordsCategory(ctx context.Context, arg MoveCategoryKeywordsCategoryParams) error {
+	_, err := q.db.ExecContext(ctx, moveCategoryKeywordsCategory, arg.NewPrimary, arg.NewSecondary, arg.OldPrimary, arg.OldSecondary)
+	return err
+}
+
+const moveCategoryRulesCategory = `-- name: MoveCategoryRulesCategory :exec
+UPDATE category_rules
+SET primary_category = ?1, secondary_category = ?2
+WHERE primary_category = ?3 AND secondary_category = ?4
+`
+
+type MoveCategoryRulesCategoryParams struct {
+	NewPrimary   string `db:"new_primary" json:"new_primary"`
+	NewSecondary string `db:"new_secondary" json:"new_secondary"`
+	OldPrimary   string `db:"old_primary" json:"old_primary"`
+	OldSecondary string `db:"old_secondary" json:"old_secondary"`
+}
+
+func (q *Queries) MoveCategoryRulesCategory(ctx context.Context, arg MoveCategoryRulesCategoryParams) error {
+	_, err := q.db.ExecContext(ctx, moveCategoryRulesCategory, arg.NewPrimary, arg.NewSecondary, arg.OldPrimary, arg.OldSecondary)
+	return err
+}
+
+const moveRecurrentExpensesCategory = `-- name: MoveRecurrentExpensesCategory :exec
+UPDATE recurrent_expenses
+SET primary_category = ?1, secondary_category = ?2, updated_at = CURRENT_TIMESTAMP
+WHERE primary_category = ?3 AND secondary_category = ?4
+`
+
+type MoveRecurrentExpensesCategoryParams struct {
+	NewPrimary   string `db:"new_primary" json:"new_primary"`
+	NewSecondary string `db:"new_secondary" json:"new_secondary"`
+	OldPrimary   string `db:"old_primary" json:"old_primary"`
+	OldSecondary string `db:"old_secondary" json:"old_secondary"`
+}
+
+func (q *Queries) MoveRecurrentExpensesCategory(ctx context.Context, arg MoveRecurrentExpensesCategoryParams) error {
+	_, err := q.db.ExecContext(ctx, moveRecurrentExpensesCategory, arg.NewPrimary, arg.NewSecondary, arg.OldPrimary, arg.OldSecondary)
+	return err
+}
+
+const moveSavedViewsCategory = `-- name: MoveSavedViewsCategory :exec
+UPDATE saved_views
+SET primary_category = ?1, secondary_category = ?2
+WHERE primary_category = ?3 AND secondary_category = ?4
+`
+
+type MoveSavedViewsCategoryParams struct {
+	NewPrimary   string `db:"new_primary" json:"new_primary"`
+	NewSecondary string `db:"new_secondary" json:"new_secondary"`
+	OldPrimary   string `db:"old_primary" json:"old_primary"`
+	OldSecondary string `db:"old_secondary" json:"old_secondary"`
+}
+
+func (q *Queries) MoveSavedViewsCategory(ctx context.Context, arg MoveSavedViewsCategoryParams) error {
+	_, err := q.db.ExecContext(ctx, moveSavedViewsCategory, arg.NewPrimary, arg.NewSecondary, arg.OldPrimary, arg.OldSecondary)
+	return err
+}
+
+const muteInsight = `-- name: MuteInsight :exec
+INSERT INTO insight_mutes (kind, primary_category) VALUES (?, ?)
+ON CONFLICT (kind, primary_category) DO NOTHING
+`
+
+type MuteInsightParams struct {
+	Kind            string `db:"kind" json:"kind"`
+	PrimaryCategory string `db:"primary_category" json:"primary_category"`
+}
+
+func (q *Queries) MuteInsight(ctx context.Context, arg MuteInsightParams) error {
+	_, err := q.db.ExecContext(ctx, muteInsight, arg.Kind, arg.PrimaryCategory)
+	return err
+}
+
+const pruneAuditLog = `-- name: PruneAuditLog :execrows
+
+DELETE FROM audit_log
+WHERE changed_at < ?
+`
+
+// Removes the changes made before the specified timestamp.
+func (q *Queries) PruneAuditLog(ctx context.Context, changedAt time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, pruneAuditLog, changedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const pruneJobRuns = `-- name: PruneJobRuns :exec
+DELETE FROM jobs
+WHERE job = ?1
+  AND id <= (SELECT id FROM jobs WHERE job = ?1 ORDER BY id DESC LIMIT 1 OFFSET ?2)
+`
+
+type PruneJobRunsParams struct {
+	Job  string `db:"job" json:"job"`
+	Keep int64  `db:"keep" json:"keep"`
+}
+
+// Deletes the runs of a job but the latest keep
+func (q *Queries) PruneJobRuns(ctx context.Context, arg PruneJobRunsParams) error {
+	_, err := q.db.ExecContext(ctx, pruneJobRuns, arg.Job, arg.Keep)
+	return err
+}
+
+const queueBlobDeletion = `-- name: QueueBlobDeletion :exec
+INSERT OR IGNORE INTO blob_deletions (blob_key)
+VALUES (?)
+`
+
+func (q *Queries) QueueBlobDeletion(ctx context.Context, blobKey string) error {
+	_, err := q.db.ExecContext(ctx, queueBlobDeletion, blobKey)
+	return err
+}
+
+const recategorizeExpense = `-- name: RecategorizeExpense :execrows
+
+UPDATE expenses
+SET primary_category = ?,
+    secondary_category = ?,
+    version = version + 1,
+    sync_status = CASE WHEN sync_status = 'synced' THEN 'pending' ELSE sync_status END
+WHERE id = ? AND version = ? AND deleted_at IS NULL
+`
+
+type RecategorizeExpenseParams struct {
+	PrimaryCategory   string `db:"primary_category" json:"primary_category"`
+	SecondaryCategory string `db:"secondary_category" json:"secondary_category"`
+	ID                int64  `db:"id" json:"id"`
+	Version           int64  `db:"version" json:"version"`
+}
+
+// Moves an expense to new categories unless it changed since version was
+// read. An expense already in Google Sheets goes back to pending, to be
+// written there again.
+func (q *Queries) RecategorizeExpense(ctx context.Context, arg RecategorizeExpenseParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, recategorizeExpense,
+		arg.PrimaryCategory,
+		arg.SecondaryCategory,
+		arg.ID,
+		arg.Version,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const recordSyncError = `-- name: RecordSyncError :exec
+INSERT INTO sync_errors (queue_id, expense_id, operation, attempt, class, message)
+VALUES (?, ?, ?, ?, ?, ?)
+`
+
+type RecordSyncErrorParams struct {
+	QueueID   int64  `db:"queue_id" json:"queue_id"`
+	ExpenseID int64  `db:"expense_id" json:"expense_id"`
+	Operation string `db:"operation" json:"operation"`
+	Attempt   int64  `db:"attempt" json:"attempt"`
+	Class     string `db:"class" json:"class"`
+	Message   string `db:"message" json:"message"`
+}
+
+// Records a failed sync attempt with its error class.
+func (q *Queries) RecordSyncError(ctx context.Context, arg RecordSyncErrorParams) error {
+	_, err := q.db.ExecContext(ctx, recordSyncError, arg.QueueID, arg.ExpenseID, arg.Operation, arg.Attempt, arg.Class, arg.Message)
+	return err
+}
+
+const refreshCategories = `-- name: RefreshCategories :exec
+DELETE FROM secondary_categories
+`
+
+func (q *Queries) RefreshCategories(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, refreshCategories)
+	return err
+}
+
+const refreshPrimaryCategories = `-- name: RefreshPrimaryCategories :exec
+DELETE FROM primary_categories
+`
+
+func (q *Queries) RefreshPrimaryCategories(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, refreshPrimaryCategories)
+	return err
+}
+
+const releaseAttachmentBytes = `-- name: ReleaseAttachmentBytes :exec
+
+DELETE FROM attachment_reservations WHERE id = ?
+`
+
+// Drops a reservation once its upload is stored or failed.
+func (q *Queries) ReleaseAttachmentBytes(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, releaseAttachmentBytes, id)
+	return err
+}
+
+const releaseDailyExpenses = `-- name: ReleaseDailyExpenses :exec
+
+UPDATE user_daily_usage SET expenses = MAX(expenses - ?1, 0)
+WHERE user_id = ?2 AND day = ?3
+`
+
+type ReleaseDailyExpensesParams struct {
+	Count  interface{} `db:"count" json:"count"`
+	UserID int64       `db:"user_id" json:"user_id"`
+	Day    string      `db:"day" json:"day"`
+}
+
+// Gives back expenses counted for a creation that then failed.
+func (q *Queries) ReleaseDailyExpenses(ctx context.Context, arg ReleaseDailyExpensesParams) error {
+	_, err := q.db.ExecContext(ctx, releaseDailyExpenses, arg.Count, arg.UserID, arg.Day)
+	return err
+}
+
+const renameCategoryKeywordsPrimary = `-- name: RenameCategoryKeywordsPrimary :exec
+UPDATE category_keywords
+SET primary_category = ?1
+WHERE primary_category = ?2
+`
+
+type RenameCategoryKeywordsPrimaryParams struct {
+	NewPrimary string `db:"new_primary" json:"new_primary"`
+	OldPrimary string `db:"old_primary" json:"old_primary"`
+}
+
+func (q *Queries) RenameCategoryKeywordsPrimary(ctx context.Context, arg RenameCategoryKeywordsPrimaryParams) error {
+	_, err := q.db.ExecContext(ctx, renameCategoryKeywordsPrimary, arg.NewPrimary, arg.OldPrimary)
+	return err
+}
+
+const renameCategoryMappingsPrimary = `-- name: RenameCategoryMappingsPrimary :exec
+UPDATE category_mappings
+SET primary_category = ?1,
+    updated_at = CURRENT_TIMESTAMP
+WHERE primary_category = ?2
+`
+
+type RenameCategoryMappingsPrimaryParams struct {
+	NewPrimary string `db:"new_primary" json:"new_primary"`
+	OldPrimary string `db:"old_primary" json:"old_primary"`
+}
+
+// Points the mappings to a renamed primary category.
+func (q *Queries) RenameCategoryMappingsPrimary(ctx context.Context, arg RenameCategoryMappingsPrimaryParams) error {
+	_, err := q.db.ExecContext(ctx, renameCategoryMappingsPrimary, arg.NewPrimary, arg.OldPrimary)
+	return err
+}
+
+const renameCategoryRulesPrimary = `-- name: RenameCategoryRulesPrimary :exec
+UPDATE category_rules
+SET primary_category = ?1
+WHERE primary_category = ?2
+`
+
+type RenameCategoryRulesPrimaryParams struct {
+	NewPrimary string `db:"new_primary" json:"new_primary"`
+	OldPrimary string `db:"old_primary" json:"old_primary"`
+}
+
+func (q *Queries) RenameCategoryRulesPrimary(ctx context.Context, arg RenameCategoryRulesPrimaryParams) error {
+	_, err := q.db.ExecContext(ctx, renameCategoryRulesPrimary, arg.NewPrimary, arg.OldPrimary)
+	return err
+}
+
+const renameCategoryTranslations = `-- name: RenameCategoryTranslations :exec
+UPDATE OR REPLACE category_translations
+SET name = ?1
+WHERE kind = ?2 AND name = ?3
+`
+
+type RenameCategoryTranslationsParams struct {
+	NewName string `db:"new_name" json:"new_name"`
+	Kind    string `db:"kind" json:"kind"`
+	OldName string `db:"old_name" json:"old_name"`
+}
+
+func (q *Queries) RenameCategoryTranslations(ctx context.Context, arg RenameCategoryTranslationsParams) error {
+	_, err := q.db.ExecContext(ctx, renameCategoryTranslations, arg.NewName, arg.Kind, arg.OldName)
+	return err
+}
+
+const renamePrimaryCategory = `-- name: RenamePrimaryCategory :execrows
+UPDATE primary_categories SET name = ? WHERE id = ?
+`
+
+type RenamePrimaryCategoryParams struct {
+	Name string `db:"name" json:"name"`
+	ID   int64  `db:"id" json:"id"`
+}
+
+func (q *Queries) RenamePrimaryCategory(ctx context.Context, arg RenamePrimaryCategoryParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, renamePrimaryCategory, arg.Name, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const renameRecurrentExpensesPrimary = `-- name: RenameRecurrentExpensesPrimary :exec
+UPDATE recurrent_expenses
+SET primary_category = ?1, updated_at = CURRENT_TIMESTAMP
+WHERE primary_category = ?2
+`
+
+type RenameRecurrentExpensesPrimaryParams struct {
+	NewPrimary string `db:"new_primary" json:"new_primary"`
+	OldPrimary string `db:"old_primary" json:"old_primary"`
+}
+
+func (q *Queries) RenameRecurrentExpensesPrimary(ctx context.Context, arg RenameRecurrentExpensesPrimaryParams) error {
+	_, err := q.db.ExecContext(ctx, renameRecurrentExpensesPrimary, arg.NewPrimary, arg.OldPrimary)
+	return err
+}
+
+const renameSavedViewsPrimary = `-- name: RenameSavedViewsPrimary :exec
+UPDATE saved_views
+SET primary_category = ?1
+WHERE primary_category = ?2
+`
+
+type RenameSavedViewsPrimaryParams struct {
+	NewPrimary string `db:"new_primary" json:"new_primary"`
+	OldPrimary string `db:"old_primary" json:"old_primary"`
+}
+
+func (q *Queries) RenameSavedViewsPrimary(ctx context.Context, arg RenameSavedViewsPrimaryParams) error {
+	_, err := q.db.ExecContext(ctx, renameSavedViewsPrimary, arg.NewPrimary, arg.OldPrimary)
+	return err
+}
+
+const reopenMonth = `-- name: ReopenMonth :execrows
+DELETE FROM month_reviews WHERE period = ?
+`
+
+func (q *Queries) ReopenMonth(ctx context.Context, period string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, reopenMonth, period)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const reserveAttachmentBytes = `-- name: ReserveAttachmentBytes :one
+
+INSERT INTO attachment_reservations (user_id, size_bytes)
+SELECT ?1, ?2
+WHERE (SELECT COALESCE(SUM(size_bytes), 0) FROM receipts
+       WHERE uploaded_by = ?1 AND expense_id != ?3)
+    + (SELECT COALESCE(SUM(size_bytes), 0) FROM attachment_reservations
+       WHERE user_id = ?1 AND created_at > datetime('now', '-1 hour'))
+    + ?2 <= ?4
+RETURNING id
+`
+
+type ReserveAttachmentBytesParams struct {
+	UserID        int64 `db:"user_id" json:"user_id"`
+	SizeBytes     int64 `db:"size_bytes" json:"size_bytes"`
+	ExceptExpense int64 `db:"except_expense" json:"except_expense"`
+	MaxBytes      int64 `db:"max_bytes" json:"max_bytes"`
+}
+
+// Holds size_bytes of a member's attachment quota for an upload unless,
+// with their receipts (but the one of except_expense, which the upload
+// replaces) and the reservations of the last hour, that goes over
+// max_bytes; no row comes back when it would.
+func (q *Queries) ReserveAttachmentBytes(ctx context.Context, arg ReserveAttachmentBytesParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, reserveAttachmentBytes,
+		arg.UserID,
+		arg.SizeBytes,
+		arg.ExceptExpense,
+		arg.MaxBytes,
+	)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const resetStaleProcessing = `-- name: ResetStaleProcessing :exec
+UPDATE sync_queue
+SET status = 'pending',
+    updated_at = CURRENT_TIMESTAMP
+WHERE status = 'processing'
+  AND updated_at < datetime(CURRENT_TIMESTAMP, '-5 minutes')
+`
+
+// Resets items stuck in processing state (crash recovery).
+func (q *Queries) ResetStaleProcessing(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, resetStaleProcessing)
+	return err
+}
+
+const restoreExpense = `-- name: RestoreExpense :execrows
+UPDATE expenses SET deleted_at = NULL
+WHERE id = ? AND deleted_at IS NOT NULL
+`
+
+func (q *Queries) RestoreExpense(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, restoreExpense, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const restoreIncome = `-- name: RestoreIncome :execrows
+UPDATE incomes SET deleted_at = NULL
+WHERE id = ? AND deleted_at IS NOT NULL
+`
+
+func (q *Queries) RestoreIncome(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, restoreIncome, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const resyncExpense = `-- name: ResyncExpense :execrows
+
+UPDATE expenses
+SET version = version + 1,
+    sync_status = CASE WHEN sync_status = 'synced' THEN 'pending' ELSE sync_status END
+WHERE id = ? AND deleted_at IS NULL
+`
+
+// Moves an expense to a new version with the same values, so a change
+// stored beside it, such as its split, is written to Google Sheets again.
+func (q *Queries) ResyncExpense(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, resyncExpense, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const retryDeferredSyncs = `-- name: RetryDeferredSyncs :execrows
+UPDATE sync_queue
+SET next_retry_at = NULL,
+    updated_at = CURRENT_TIMESTAMP
+WHERE status = 'pending'
+  AND next_retry_at IS NOT NULL
 `
 
-type GetCategorySumsParams struct {
-	PRINTF   interface{} `db:"PRINTF" json:"PRINTF"`
-	PRINTF_2 interface{} `db:"PRINTF_2" json:"PRINTF_2"`
+// Makes pending items waiting for a retry ready for the next poll, e.g.
+// once the Google Sheets credentials have been fixed.
+func (q *Queries) RetryDeferredSyncs(ctx context.Context) (int64, error) {
+	result, err := q.db.ExecContext(ctx, retryDeferredSyncs)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
-type GetCategorySumsRow struct {
-	PrimaryCategory string `db:"primary_category" json:"primary_category"`
-	TotalAmount     int64  `db:"total_amount" json:"total_amount"`
-}
+const retryFailedSyncs = `-- name: RetryFailedSyncs :execrows
+UPDATE sync_queue
+SET status = 'pending',
+    attempts = 0,
+    next_retry_at = NULL,
+    last_error = NULL,
+    updated_at = CURRENT_TIMESTAMP
+WHERE status = 'failed'
+`
 
-func (q *Queries) GetCategorySums(ctx context.Context, arg GetCategorySumsParams) ([]GetCategorySumsRow, error) {
-	rows, err := q.db.QueryContext(ctx, getCategorySums, arg.PRINTF, arg.PRINTF_2)
+// Resets failed items back to pending for manual retry.
+func (q *Queries) RetryFailedSyncs(ctx context.Context) (int64, error) {
+	result, err := q.db.ExecContext(ctx, retryFailedSyncs)
 	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []GetCategorySumsRow
-	for rows.Next() {
-		var i GetCategorySumsRow
-		if err := rows.Scan(&i.PrimaryCategory, &i.TotalAmount); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
+		return 0, err
 	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+	return result.RowsAffected()
+}
+
+const retrySyncItem = `-- name: RetrySyncItem :execrows
+UPDATE sync_queue
+SET status = 'pending',
+    attempts = 0,
+    next_retry_at = NULL,
+    last_error = NULL,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = ? AND status IN ('pending', 'failed')
+`
+
+// Makes a failed or deferred item ready for the next poll, with its
+// attempts reset.
+func (q *Queries) RetrySyncItem(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, retrySyncItem, id)
+	if err != nil {
+		return 0, err
 	}
-	return items, nil
+	return result.RowsAffected()
 }
 
-const getExpense = `-- name: GetExpense :one
-SELECT id, date, description, amount_cents, primary_category, secondary_category, version, created_at, synced_at, sync_status FROM expenses WHERE id = ?
+const savePeerSyncState = `-- name: SavePeerSyncState :exec
+INSERT INTO peer_sync_state (peer, pull_cursor, push_cursor, last_sync_at)
+VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+ON CONFLICT (peer) DO UPDATE SET pull_cursor = excluded.pull_cursor, push_cursor = excluded.push_cursor, last_sync_at = excluded.last_sync_at
 `
 
-func (q *Queries) GetExpense(ctx context.Context, id int64) (Expense, error) {
-	row := q.db.QueryRowContext(ctx, getExpense, id)
-	var i Expense
-	err := row.Scan(
-		&i.ID,
-		&i.Date,
-		&i.Description,
-		&i.AmountCents,
-		&i.PrimaryCategory,
-		&i.SecondaryCategory,
-		&i.Version,
-		&i.CreatedAt,
-		&i.SyncedAt,
-		&i.SyncStatus,
-	)
-	return i, err
+type SavePeerSyncStateParams struct {
+	Peer       string `db:"peer" json:"peer"`
+	PullCursor int64  `db:"pull_cursor" json:"pull_cursor"`
+	PushCursor int64  `db:"push_cursor" json:"push_cursor"`
 }
 
-const getExpensesByMonth = `-- name: GetExpensesByMonth :many
-SELECT id, date, description, amount_cents, primary_category, secondary_category, version, created_at, synced_at, sync_status FROM expenses
-WHERE strftime('%Y', date) = printf('%04d', ?)
-  AND strftime('%m', date) = printf('%02d', ?)
-ORDER BY date DESC, created_at DESC
+func (q *Queries) SavePeerSyncState(ctx context.Context, arg SavePeerSyncStateParams) error {
+	_, err := q.db.ExecContext(ctx, savePeerSyncState, arg.Peer, arg.PullCursor, arg.PushCursor)
+	return err
+}
+
+const searchExpenses = `-- name: SearchExpenses :many
+
+SELECT id, date, description, amount_cents, primary_category, secondary_category, version, created_at, synced_at, sync_status, uid, modified_at, status, paid_by, deleted_at, account_id FROM expenses
+WHERE date BETWEEN date(?1) AND date(?2)
+  AND deleted_at IS NULL
+  AND (?3 = '' OR primary_category = ?3 OR secondary_category = ?3)
+  AND (?4 = 0 OR amount_cents >= ?4)
+  AND (?5 = 0 OR amount_cents <= ?5)
+  AND (?6 = '' OR id IN (SELECT rowid FROM expenses_fts WHERE expenses_fts MATCH ?6))
+ORDER BY date DESC, id DESC
+LIMIT ?7
 `
 
-type GetExpensesByMonthParams struct {
-	PRINTF   interface{} `db:"PRINTF" json:"PRINTF"`
-	PRINTF_2 interface{} `db:"PRINTF_2" json:"PRINTF_2"`
+type SearchExpensesParams struct {
+	FromDate interface{} `db:"from_date" json:"from_date"`
+	ToDate   interface{} `db:"to_date" json:"to_date"`
+	Category interface{} `db:"category" json:"category"`
+	MinCents interface{} `db:"min_cents" json:"min_cents"`
+	MaxCents interface{} `db:"max_cents" json:"max_cents"`
+	Match    interface{} `db:"match" json:"match"`
+	MaxRows  int64       `db:"max_rows" json:"max_rows"`
 }
 
-func (q *Queries) GetExpensesByMonth(ctx context.Context, arg GetExpensesByMonthParams) ([]Expense, error) {
-	rows, err := q.db.QueryContext(ctx, getExpensesByMonth, arg.PRINTF, arg.PRINTF_2)
+// The newest expenses between two dates matching a search; empty matches
+// and zero amounts do not filter, a category matches either level. The
+// match is an FTS5 query on the descriptions.
+func (q *Queries) SearchExpenses(ctx context.Context, arg SearchExpensesParams) ([]Expense, error) {
+	rows, err := q.db.QueryContext(ctx, searchExpenses,
+		arg.FromDate,
+		arg.ToDate,
+		arg.Category,
+		arg.MinCents,
+		arg.MaxCents,
+		arg.Match,
+		arg.MaxRows,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -637,6 +8145,12 @@ func (q *Queries) GetExpensesByMonth(ctx context.Context, arg GetExpensesByMonth
 			&i.CreatedAt,
 			&i.SyncedAt,
 			&i.SyncStatus,
+			&i.Uid,
+			&i.ModifiedAt,
+			&i.Status,
+			&i.PaidBy,
+			&i.DeletedAt,
+			&i.AccountID,
 		); err != nil {
 			return nil, err
 		}
@@ -651,203 +8165,232 @@ func (q *Queries) GetExpensesByMonth(ctx context.Context, arg GetExpensesByMonth
 	return items, nil
 }
 
-const getIncome = `-- name: GetIncome :one
-SELECT id, date, description, amount_cents, category, version, created_at, synced_at, sync_status FROM incomes WHERE id = ?
+const setAlertPreference = `-- name: SetAlertPreference :exec
+INSERT INTO alert_preferences (user_id, alert_type, scope, muted, snoozed_until)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT (user_id, alert_type, scope) DO UPDATE SET
+    muted = excluded.muted,
+    snoozed_until = excluded.snoozed_until,
+    updated_at = CURRENT_TIMESTAMP
 `
 
-func (q *Queries) GetIncome(ctx context.Context, id int64) (Income, error) {
-	row := q.db.QueryRowContext(ctx, getIncome, id)
-	var i Income
-	err := row.Scan(
-		&i.ID,
-		&i.Date,
-		&i.Description,
-		&i.AmountCents,
-		&i.Category,
-		&i.Version,
-		&i.CreatedAt,
-		&i.SyncedAt,
-		&i.SyncStatus,
+type SetAlertPreferenceParams struct {
+	UserID       string       `db:"user_id" json:"user_id"`
+	AlertType    string       `db:"alert_type" json:"alert_type"`
+	Scope        string       `db:"scope" json:"scope"`
+	Muted        bool         `db:"muted" json:"muted"`
+	SnoozedUntil sql.NullTime `db:"snoozed_until" json:"snoozed_until"`
+}
+
+func (q *Queries) SetAlertPreference(ctx context.Context, arg SetAlertPreferenceParams) error {
+	_, err := q.db.ExecContext(ctx, setAlertPreference,
+		arg.UserID,
+		arg.AlertType,
+		arg.Scope,
+		arg.Muted,
+		arg.SnoozedUntil,
 	)
-	return i, err
+	return err
 }
 
-const getIncomeCategories = `-- name: GetIncomeCategories :many
-SELECT name FROM income_categories
-ORDER BY name ASC
+const setCategoryRuleActive = `-- name: SetCategoryRuleActive :execrows
+UPDATE category_rules SET is_active = ? WHERE id = ?
 `
 
-func (q *Queries) GetIncomeCategories(ctx context.Context) ([]string, error) {
-	rows, err := q.db.QueryContext(ctx, getIncomeCategories)
+type SetCategoryRuleActiveParams struct {
+	IsActive bool  `db:"is_active" json:"is_active"`
+	ID       int64 `db:"id" json:"id"`
+}
+
+// Enables or disables a rule.
+func (q *Queries) SetCategoryRuleActive(ctx context.Context, arg SetCategoryRuleActiveParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setCategoryRuleActive, arg.IsActive, arg.ID)
 	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []string
-	for rows.Next() {
-		var name string
-		if err := rows.Scan(&name); err != nil {
-			return nil, err
-		}
-		items = append(items, name)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+		return 0, err
 	}
-	return items, nil
+	return result.RowsAffected()
 }
 
-const getIncomeCategorySums = `-- name: GetIncomeCategorySums :many
-SELECT category, CAST(SUM(amount_cents) AS INTEGER) as total_amount
-FROM incomes
-WHERE strftime('%Y', date) = printf('%04d', ?)
-  AND strftime('%m', date) = printf('%02d', ?)
-GROUP BY category
-ORDER BY total_amount DESC
+const setImportBatchExpenses = `-- name: SetImportBatchExpenses :exec
+UPDATE import_batches SET expenses = ? WHERE id = ?
 `
 
-type GetIncomeCategorySumsParams struct {
-	PRINTF   interface{} `db:"PRINTF" json:"PRINTF"`
-	PRINTF_2 interface{} `db:"PRINTF_2" json:"PRINTF_2"`
+type SetImportBatchExpensesParams struct {
+	Expenses int64 `db:"expenses" json:"expenses"`
+	ID       int64 `db:"id" json:"id"`
 }
 
-type GetIncomeCategorySumsRow struct {
-	Category    string `db:"category" json:"category"`
-	TotalAmount int64  `db:"total_amount" json:"total_amount"`
+func (q *Queries) SetImportBatchExpenses(ctx context.Context, arg SetImportBatchExpensesParams) error {
+	_, err := q.db.ExecContext(ctx, setImportBatchExpenses, arg.Expenses, arg.ID)
+	return err
 }
 
-func (q *Queries) GetIncomeCategorySums(ctx context.Context, arg GetIncomeCategorySumsParams) ([]GetIncomeCategorySumsRow, error) {
-	rows, err := q.db.QueryContext(ctx, getIncomeCategorySums, arg.PRINTF, arg.PRINTF_2)
+const setPrimaryCategoryArchived = `-- name: SetPrimaryCategoryArchived :execrows
+UPDATE primary_categories SET archived = ? WHERE id = ?
+`
+
+type SetPrimaryCategoryArchivedParams struct {
+	Archived bool  `db:"archived" json:"archived"`
+	ID       int64 `db:"id" json:"id"`
+}
+
+func (q *Queries) SetPrimaryCategoryArchived(ctx context.Context, arg SetPrimaryCategoryArchivedParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setPrimaryCategoryArchived, arg.Archived, arg.ID)
 	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []GetIncomeCategorySumsRow
-	for rows.Next() {
-		var i GetIncomeCategorySumsRow
-		if err := rows.Scan(&i.Category, &i.TotalAmount); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+		return 0, err
 	}
-	return items, nil
+	return result.RowsAffected()
 }
 
-const getIncomeMonthTotal = `-- name: GetIncomeMonthTotal :one
-SELECT CAST(COALESCE(SUM(amount_cents), 0) AS INTEGER) as total
-FROM incomes
-WHERE strftime('%Y', date) = printf('%04d', ?)
-  AND strftime('%m', date) = printf('%02d', ?)
+const setRecurrentPaused = `-- name: SetRecurrentPaused :execrows
+UPDATE recurrent_expenses
+SET paused = ?,
+    skip_until = ?,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = ? AND is_active = 1
 `
 
-type GetIncomeMonthTotalParams struct {
-	PRINTF   interface{} `db:"PRINTF" json:"PRINTF"`
-	PRINTF_2 interface{} `db:"PRINTF_2" json:"PRINTF_2"`
+type SetRecurrentPausedParams struct {
+	Paused    bool        `db:"paused" json:"paused"`
+	SkipUntil interface{} `db:"skip_until" json:"skip_until"`
+	ID        int64       `db:"id" json:"id"`
 }
 
-func (q *Queries) GetIncomeMonthTotal(ctx context.Context, arg GetIncomeMonthTotalParams) (int64, error) {
-	row := q.db.QueryRowContext(ctx, getIncomeMonthTotal, arg.PRINTF, arg.PRINTF_2)
-	var total int64
-	err := row.Scan(&total)
-	return total, err
+// Resuming sets skip_until too, past the occurrences of the pause
+func (q *Queries) SetRecurrentPaused(ctx context.Context, arg SetRecurrentPausedParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setRecurrentPaused, arg.Paused, arg.SkipUntil, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
-const getIncomesByMonth = `-- name: GetIncomesByMonth :many
-SELECT id, date, description, amount_cents, category, version, created_at, synced_at, sync_status FROM incomes
-WHERE strftime('%Y', date) = printf('%04d', ?)
-  AND strftime('%m', date) = printf('%02d', ?)
-ORDER BY date DESC, created_at DESC
+const setRecurrentSkipUntil = `-- name: SetRecurrentSkipUntil :execrows
+UPDATE recurrent_expenses
+SET skip_until = ?,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = ? AND is_active = 1
 `
 
-type GetIncomesByMonthParams struct {
-	PRINTF   interface{} `db:"PRINTF" json:"PRINTF"`
-	PRINTF_2 interface{} `db:"PRINTF_2" json:"PRINTF_2"`
+type SetRecurrentSkipUntilParams struct {
+	SkipUntil interface{} `db:"skip_until" json:"skip_until"`
+	ID        int64       `db:"id" json:"id"`
 }
 
-func (q *Queries) GetIncomesByMonth(ctx context.Context, arg GetIncomesByMonthParams) ([]Income, error) {
-	rows, err := q.db.QueryContext(ctx, getIncomesByMonth, arg.PRINTF, arg.PRINTF_2)
+func (q *Queries) SetRecurrentSkipUntil(ctx context.Context, arg SetRecurrentSkipUntilParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setRecurrentSkipUntil, arg.SkipUntil, arg.ID)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
-	defer rows.Close()
-	var items []Income
-	for rows.Next() {
-		var i Income
-		if err := rows.Scan(
-			&i.ID,
-			&i.Date,
-			&i.Description,
-			&i.AmountCents,
-			&i.Category,
-			&i.Version,
-			&i.CreatedAt,
-			&i.SyncedAt,
-			&i.SyncStatus,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
+	return result.RowsAffected()
+}
+
+const setSavedViewNotify = `-- name: SetSavedViewNotify :execrows
+UPDATE saved_views SET notify = ? WHERE id = ?
+`
+
+type SetSavedViewNotifyParams struct {
+	Notify bool  `db:"notify" json:"notify"`
+	ID     int64 `db:"id" json:"id"`
+}
+
+func (q *Queries) SetSavedViewNotify(ctx context.Context, arg SetSavedViewNotifyParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setSavedViewNotify, arg.Notify, arg.ID)
+	if err != nil {
+		return 0, err
 	}
-	if err := rows.Close(); err != nil {
-		return nil, err
+	return result.RowsAffected()
+}
+
+const setSecondaryCategoryArchived = `-- name: SetSecondaryCategoryArchived :execrows
+UPDATE secondary_categories SET archived = ? WHERE id = ?
+`
+
+type SetSecondaryCategoryArchivedParams struct {
+	Archived bool  `db:"archived" json:"archived"`
+	ID       int64 `db:"id" json:"id"`
+}
+
+func (q *Queries) SetSecondaryCategoryArchived(ctx context.Context, arg SetSecondaryCategoryArchivedParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setSecondaryCategoryArchived, arg.Archived, arg.ID)
+	if err != nil {
+		return 0, err
 	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+	return result.RowsAffected()
+}
+
+const settleExpense = `-- name: SettleExpense :execrows
+
+UPDATE expenses
+SET date = date(?), amount_cents = ?, status = 'cleared', version = version + 1
+WHERE id = ? AND status = 'pending' AND deleted_at IS NULL
+`
+
+type SettleExpenseParams struct {
+	Date        interface{} `db:"date" json:"date"`
+	AmountCents int64       `db:"amount_cents" json:"amount_cents"`
+	ID          int64       `db:"id" json:"id"`
+}
+
+// Clears a pending expense with the settled date and amount.
+func (q *Queries) SettleExpense(ctx context.Context, arg SettleExpenseParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, settleExpense, arg.Date, arg.AmountCents, arg.ID)
+	if err != nil {
+		return 0, err
 	}
-	return items, nil
+	return result.RowsAffected()
 }
 
-const getMonthTotal = `-- name: GetMonthTotal :one
-SELECT CAST(COALESCE(SUM(amount_cents), 0) AS INTEGER) as total
-FROM expenses
-WHERE strftime('%Y', date) = printf('%04d', ?)
-  AND strftime('%m', date) = printf('%02d', ?)
+const startSheetHistoryImport = `-- name: StartSheetHistoryImport :exec
+
+INSERT INTO sheet_history_imports (year, expenses, total_rows) VALUES (?, 0, ?)
+ON CONFLICT (year) DO UPDATE SET total_rows = excluded.total_rows, last_error = ''
 `
 
-type GetMonthTotalParams struct {
-	PRINTF   interface{} `db:"PRINTF" json:"PRINTF"`
-	PRINTF_2 interface{} `db:"PRINTF_2" json:"PRINTF_2"`
+type StartSheetHistoryImportParams struct {
+	Year      int64 `db:"year" json:"year"`
+	TotalRows int64 `db:"total_rows" json:"total_rows"`
 }
 
-func (q *Queries) GetMonthTotal(ctx context.Context, arg GetMonthTotalParams) (int64, error) {
-	row := q.db.QueryRowContext(ctx, getMonthTotal, arg.PRINTF, arg.PRINTF_2)
-	var total int64
-	err := row.Scan(&total)
-	return total, err
+// Records the start of the import of a year, or its restart after a failure
+func (q *Queries) StartSheetHistoryImport(ctx context.Context, arg StartSheetHistoryImportParams) error {
+	_, err := q.db.ExecContext(ctx, startSheetHistoryImport, arg.Year, arg.TotalRows)
+	return err
 }
 
-const getPendingSyncExpenses = `-- name: GetPendingSyncExpenses :many
-SELECT id, version, created_at FROM expenses 
-WHERE sync_status = 'pending'
-ORDER BY created_at ASC
-LIMIT ?
+const sumAggregatesByCategory = `-- name: SumAggregatesByCategory :many
+
+SELECT category, CAST(SUM(total_cents) AS INTEGER) AS total_amount
+FROM monthly_aggregates
+WHERE kind = ?1
+  AND year * 100 + month BETWEEN ?2 AND ?3
+GROUP BY category
+ORDER BY total_amount DESC
 `
 
-type GetPendingSyncExpensesRow struct {
-	ID        int64        `db:"id" json:"id"`
-	Version   int64        `db:"version" json:"version"`
-	CreatedAt sql.NullTime `db:"created_at" json:"created_at"`
+type SumAggregatesByCategoryParams struct {
+	Kind       string      `db:"kind" json:"kind"`
+	FromPeriod interface{} `db:"from_period" json:"from_period"`
+	ToPeriod   interface{} `db:"to_period" json:"to_period"`
 }
 
-func (q *Queries) GetPendingSyncExpenses(ctx context.Context, limit int64) ([]GetPendingSyncExpensesRow, error) {
-	rows, err := q.db.QueryContext(ctx, getPendingSyncExpenses, limit)
+type SumAggregatesByCategoryRow struct {
+	Category    string `db:"category" json:"category"`
+	TotalAmount int64  `db:"total_amount" json:"total_amount"`
+}
+
+// Totals per category of one kind of entry in the months between two
+// periods (year * 100 + month), both included, largest first.
+func (q *Queries) SumAggregatesByCategory(ctx context.Context, arg SumAggregatesByCategoryParams) ([]SumAggregatesByCategoryRow, error) {
+	rows, err := q.db.QueryContext(ctx, sumAggregatesByCategory, arg.Kind, arg.FromPeriod, arg.ToPeriod)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetPendingSyncExpensesRow
+	var items []SumAggregatesByCategoryRow
 	for rows.Next() {
-		var i GetPendingSyncExpensesRow
-		if err := rows.Scan(&i.ID, &i.Version, &i.CreatedAt); err != nil {
+		var i SumAggregatesByCategoryRow
+		if err := rows.Scan(&i.Category, &i.TotalAmount); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -861,25 +8404,55 @@ func (q *Queries) GetPendingSyncExpenses(ctx context.Context, limit int64) ([]Ge
 	return items, nil
 }
 
-const getPrimaryCategories = `-- name: GetPrimaryCategories :many
-SELECT name FROM primary_categories 
-ORDER BY name ASC
+const sumExpenseTagsByMonth = `-- name: SumExpenseTagsByMonth :many
+SELECT t.name AS tag,
+       CAST(strftime('%Y-%m', e.date) AS TEXT) AS month,
+       CAST(SUM(e.amount_cents) AS INTEGER) AS total_amount,
+       COUNT(*) AS entries
+FROM expense_tags et
+JOIN tags t ON t.id = et.tag_id
+JOIN expenses e ON e.id = et.expense_id
+WHERE date(e.date) >= date(?1)
+  AND date(e.date) < date(?2)
+  AND (?3 = '' OR t.name = ?3)
+  AND e.deleted_at IS NULL
+GROUP BY t.name, month
+ORDER BY t.name, month
 `
 
-// Primary Categories queries
-func (q *Queries) GetPrimaryCategories(ctx context.Context) ([]string, error) {
-	rows, err := q.db.QueryContext(ctx, getPrimaryCategories)
+type SumExpenseTagsByMonthParams struct {
+	FromDate interface{} `db:"from_date" json:"from_date"`
+	ToDate   interface{} `db:"to_date" json:"to_date"`
+	Tag      interface{} `db:"tag" json:"tag"`
+}
+
+type SumExpenseTagsByMonthRow struct {
+	Tag         string `db:"tag" json:"tag"`
+	Month       string `db:"month" json:"month"`
+	TotalAmount int64  `db:"total_amount" json:"total_amount"`
+	Entries     int64  `db:"entries" json:"entries"`
+}
+
+// Spending per tag and month between from_date (included) and to_date
+// (excluded); an empty tag selects every tag.
+func (q *Queries) SumExpenseTagsByMonth(ctx context.Context, arg SumExpenseTagsByMonthParams) ([]SumExpenseTagsByMonthRow, error) {
+	rows, err := q.db.QueryContext(ctx, sumExpenseTagsByMonth, arg.FromDate, arg.ToDate, arg.Tag)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []string
+	var items []SumExpenseTagsByMonthRow
 	for rows.Next() {
-		var name string
-		if err := rows.Scan(&name); err != nil {
+		var i SumExpenseTagsByMonthRow
+		if err := rows.Scan(
+			&i.Tag,
+			&i.Month,
+			&i.TotalAmount,
+			&i.Entries,
+		); err != nil {
 			return nil, err
 		}
-		items = append(items, name)
+		items = append(items, i)
 	}
 	if err := rows.Close(); err != nil {
 		return nil, err
@@ -890,59 +8463,50 @@ func (q *Queries) GetPrimaryCategories(ctx context.Context) ([]string, error) {
 	return items, nil
 }
 
-const getRecurrentExpenseByID = `-- name: GetRecurrentExpenseByID :one
-SELECT id, start_date, end_date, repetition_type, description, amount_cents, primary_category, secondary_category, is_active, last_execution_date, created_at, updated_at FROM recurrent_expenses
-WHERE id = ?
+const sumIncomeTagsByMonth = `-- name: SumIncomeTagsByMonth :many
+SELECT t.name AS tag,
+       CAST(strftime('%Y-%m', i.date) AS TEXT) AS month,
+       CAST(SUM(i.amount_cents) AS INTEGER) AS total_amount,
+       COUNT(*) AS entries
+FROM income_tags it
+JOIN tags t ON t.id = it.tag_id
+JOIN incomes i ON i.id = it.income_id
+WHERE date(i.date) >= date(?1)
+  AND date(i.date) < date(?2)
+  AND (?3 = '' OR t.name = ?3)
+  AND i.deleted_at IS NULL
+GROUP BY t.name, month
+ORDER BY t.name, month
 `
 
-func (q *Queries) GetRecurrentExpenseByID(ctx context.Context, id int64) (RecurrentExpense, error) {
-	row := q.db.QueryRowContext(ctx, getRecurrentExpenseByID, id)
-	var i RecurrentExpense
-	err := row.Scan(
-		&i.ID,
-		&i.StartDate,
-		&i.EndDate,
-		&i.RepetitionType,
-		&i.Description,
-		&i.AmountCents,
-		&i.PrimaryCategory,
-		&i.SecondaryCategory,
-		&i.IsActive,
-		&i.LastExecutionDate,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-	)
-	return i, err
+type SumIncomeTagsByMonthParams struct {
+	FromDate interface{} `db:"from_date" json:"from_date"`
+	ToDate   interface{} `db:"to_date" json:"to_date"`
+	Tag      interface{} `db:"tag" json:"tag"`
 }
 
-const getRecurrentExpenses = `-- name: GetRecurrentExpenses :many
-SELECT id, start_date, end_date, repetition_type, description, amount_cents, primary_category, secondary_category, is_active, last_execution_date, created_at, updated_at FROM recurrent_expenses
-WHERE is_active = 1
-ORDER BY start_date DESC
-`
+type SumIncomeTagsByMonthRow struct {
+	Tag         string `db:"tag" json:"tag"`
+	Month       string `db:"month" json:"month"`
+	TotalAmount int64  `db:"total_amount" json:"total_amount"`
+	Entries     int64  `db:"entries" json:"entries"`
+}
 
-func (q *Queries) GetRecurrentExpenses(ctx context.Context) ([]RecurrentExpense, error) {
-	rows, err := q.db.QueryContext(ctx, getRecurrentExpenses)
+// Income per tag and month, like SumExpenseTagsByMonth.
+func (q *Queries) SumIncomeTagsByMonth(ctx context.Context, arg SumIncomeTagsByMonthParams) ([]SumIncomeTagsByMonthRow, error) {
+	rows, err := q.db.QueryContext(ctx, sumIncomeTagsByMonth, arg.FromDate, arg.ToDate, arg.Tag)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []RecurrentExpense
+	var items []SumIncomeTagsByMonthRow
 	for rows.Next() {
-		var i RecurrentExpense
+		var i SumIncomeTagsByMonthRow
 		if err := rows.Scan(
-			&i.ID,
-			&i.StartDate,
-			&i.EndDate,
-			&i.RepetitionType,
-			&i.Description,
-			&i.AmountCents,
-			&i.PrimaryCategory,
-			&i.SecondaryCategory,
-			&i.IsActive,
-			&i.LastExecutionDate,
-			&i.CreatedAt,
-			&i.UpdatedAt,
+			&i.Tag,
+			&i.Month,
+			&i.TotalAmount,
+			&i.Entries,
 		); err != nil {
 			return nil, err
 		}
@@ -957,376 +8521,716 @@ func (q *Queries) GetRecurrentExpenses(ctx context.Context) ([]RecurrentExpense,
 	return items, nil
 }
 
-const getSecondariesByPrimary = `-- name: GetSecondariesByPrimary :many
-SELECT sc.name FROM secondary_categories sc
-JOIN primary_categories pc ON sc.primary_category_id = pc.id
-WHERE pc.name = ?
-ORDER BY sc.name ASC
+const touchApiKey = `-- name: TouchApiKey :exec
+
+UPDATE api_keys SET last_used_at = CURRENT_TIMESTAMP
+WHERE id = ?
+  AND (last_used_at IS NULL OR last_used_at < datetime('now', '-1 minute'))
 `
 
-func (q *Queries) GetSecondariesByPrimary(ctx context.Context, name string) ([]string, error) {
-	rows, err := q.db.QueryContext(ctx, getSecondariesByPrimary, name)
+// Records the use of a key, at most once a minute.
+func (q *Queries) TouchApiKey(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, touchApiKey, id)
+	return err
+}
+
+const trashExpense = `-- name: TrashExpense :execrows
+
+UPDATE expenses SET deleted_at = CURRENT_TIMESTAMP
+WHERE id = ? AND deleted_at IS NULL
+`
+
+// Moves an expense to the trash; it leaves every total and list until it
+// is restored.
+func (q *Queries) TrashExpense(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, trashExpense, id)
 	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []string
-	for rows.Next() {
-		var name string
-		if err := rows.Scan(&name); err != nil {
-			return nil, err
-		}
-		items = append(items, name)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
+		return 0, err
 	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+	return result.RowsAffected()
+}
+
+const trashIncome = `-- name: TrashIncome :execrows
+UPDATE incomes SET deleted_at = CURRENT_TIMESTAMP
+WHERE id = ? AND deleted_at IS NULL
+`
+
+func (q *Queries) TrashIncome(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, trashIncome, id)
+	if err != nil {
+		return 0, err
 	}
-	return items, nil
+	return result.RowsAffected()
 }
 
-const getSecondaryCategories = `-- name: GetSecondaryCategories :many
-SELECT name FROM secondary_categories 
-ORDER BY name ASC
+const unmuteInsight = `-- name: UnmuteInsight :execrows
+DELETE FROM insight_mutes WHERE kind = ? AND primary_category = ?
 `
 
-// Secondary Categories queries
-func (q *Queries) GetSecondaryCategories(ctx context.Context) ([]string, error) {
-	rows, err := q.db.QueryContext(ctx, getSecondaryCategories)
+type UnmuteInsightParams struct {
+	Kind            string `db:"kind" json:"kind"`
+	PrimaryCategory string `db:"primary_category" json:"primary_category"`
+}
+
+func (q *Queries) UnmuteInsight(ctx context.Context, arg UnmuteInsightParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, unmuteInsight, arg.Kind, arg.PrimaryCategory)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
-	defer rows.Close()
-	var items []string
-	for rows.Next() {
-		var name string
-		if err := rows.Scan(&name); err != nil {
-			return nil, err
-		}
-		items = append(items, name)
+	return result.RowsAffected()
+}
+
+const updateExpense = `-- name: UpdateExpense :execrows
+
+UPDATE expenses
+SET date = date(?),
+    description = ?,
+    amount_cents = ?,
+    primary_category = ?,
+    secondary_category = ?,
+    paid_by = ?,
+    account_id = ?,
+    version = version + 1,
+    sync_status = CASE WHEN sync_status = 'synced' THEN 'pending' ELSE sync_status END
+WHERE id = ? AND version = ? AND deleted_at IS NULL
+`
+
+type UpdateExpenseParams struct {
+	Date              interface{}   `db:"date" json:"date"`
+	Description       string        `db:"description" json:"description"`
+	AmountCents       int64         `db:"amount_cents" json:"amount_cents"`
+	PrimaryCategory   string        `db:"primary_category" json:"primary_category"`
+	SecondaryCategory string        `db:"secondary_category" json:"secondary_category"`
+	PaidBy            sql.NullInt64 `db:"paid_by" json:"paid_by"`
+	AccountID         sql.NullInt64 `db:"account_id" json:"account_id"`
+	ID                int64         `db:"id" json:"id"`
+	Version           int64         `db:"version" json:"version"`
+}
+
+// An expense already in Google Sheets goes back to pending, to be written
+// there again.
+func (q *Queries) UpdateExpense(ctx context.Context, arg UpdateExpenseParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateExpense,
+		arg.Date,
+		arg.Description,
+		arg.AmountCents,
+		arg.PrimaryCategory,
+		arg.SecondaryCategory,
+		arg.PaidBy,
+		arg.AccountID,
+		arg.ID,
+		arg.Version,
+	)
+	if err != nil {
+		return 0, err
 	}
-	if err := rows.Close(); err != nil {
-		return nil, err
+	return result.RowsAffected()
+}
+
+const updateExpenseAmount = `-- name: UpdateExpenseAmount :exec
+UPDATE expenses
+SET amount_cents = ?, version = version + 1
+WHERE id = ? AND deleted_at IS NULL
+`
+
+type UpdateExpenseAmountParams struct {
+	AmountCents int64 `db:"amount_cents" json:"amount_cents"`
+	ID          int64 `db:"id" json:"id"`
+}
+
+func (q *Queries) UpdateExpenseAmount(ctx context.Context, arg UpdateExpenseAmountParams) error {
+	_, err := q.db.ExecContext(ctx, updateExpenseAmount, arg.AmountCents, arg.ID)
+	return err
+}
+
+const updateExpenseCategory = `-- name: UpdateExpenseCategory :execrows
+UPDATE expenses
+SET primary_category = ?, secondary_category = ?, version = version + 1
+WHERE id = ?
+`
+
+type UpdateExpenseCategoryParams struct {
+	PrimaryCategory   string `db:"primary_category" json:"primary_category"`
+	SecondaryCategory string `db:"secondary_category" json:"secondary_category"`
+	ID                int64  `db:"id" json:"id"`
+}
+
+func (q *Queries) UpdateExpenseCategory(ctx context.Context, arg UpdateExpenseCategoryParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateExpenseCategory, arg.PrimaryCategory, arg.SecondaryCategory, arg.ID)
+	if err != nil {
+		return 0, err
 	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+	return result.RowsAffected()
+}
+
+const updateExpenseFromPeer = `-- name: UpdateExpenseFromPeer :exec
+UPDATE expenses
+SET date = date(?), description = ?, amount_cents = ?, primary_category = ?, secondary_category = ?, status = ?, version = ?, modified_at = ?
+WHERE uid = ?
+`
+
+type UpdateExpenseFromPeerParams struct {
+	Date              interface{}    `db:"date" json:"date"`
+	Description       string         `db:"description" json:"description"`
+	AmountCents       int64          `db:"amount_cents" json:"amount_cents"`
+	PrimaryCategory   string         `db:"primary_category" json:"primary_category"`
+	SecondaryCategory string         `db:"secondary_category" json:"secondary_category"`
+	Status            string         `db:"status" json:"status"`
+	Version           int64          `db:"version" json:"version"`
+	ModifiedAt        sql.NullString `db:"modified_at" json:"modified_at"`
+	Uid               sql.NullString `db:"uid" json:"uid"`
+}
+
+func (q *Queries) UpdateExpenseFromPeer(ctx context.Context, arg UpdateExpenseFromPeerParams) error {
+	_, err := q.db.ExecContext(ctx, updateExpenseFromPeer,
+		arg.Date,
+		arg.Description,
+		arg.AmountCents,
+		arg.PrimaryCategory,
+		arg.SecondaryCategory,
+		arg.Status,
+		arg.Version,
+		arg.ModifiedAt,
+		arg.Uid,
+	)
+	return err
+}
+
+const updateExpenseFromSheet = `-- name: UpdateExpenseFromSheet :execrows
+UPDATE expenses
+SET date = date(?),
+    description = ?,
+    amount_cents = ?,
+    primary_category = ?,
+    secondary_category = ?,
+    version = version + 1
+WHERE id = ? AND deleted_at IS NULL
+`
+
+type UpdateExpenseFromSheetParams struct {
+	Date              string `db:"date" json:"date"`
+	Description       string `db:"description" json:"description"`
+	AmountCents       int64  `db:"amount_cents" json:"amount_cents"`
+	PrimaryCategory   string `db:"primary_category" json:"primary_category"`
+	SecondaryCategory string `db:"secondary_category" json:"secondary_category"`
+	ID                int64  `db:"id" json:"id"`
+}
+
+// Stores the values of a row edited in Google Sheets. The sync status is
+// kept: the sheet already holds them.
+func (q *Queries) UpdateExpenseFromSheet(ctx context.Context, arg UpdateExpenseFromSheetParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateExpenseFromSheet, arg.Date, arg.Description, arg.AmountCents, arg.PrimaryCategory, arg.SecondaryCategory, arg.ID)
+	if err != nil {
+		return 0, err
 	}
-	return items, nil
+	return result.RowsAffected()
 }
 
-const getSyncQueueItem = `-- name: GetSyncQueueItem :one
-SELECT id, operation, expense_id, expense_day, expense_month, expense_description, expense_amount_cents, expense_primary, expense_secondary, status, attempts, max_attempts, last_error, created_at, updated_at, processed_at, next_retry_at FROM sync_queue WHERE id = ?
+const updateIncomeFromPeer = `-- name: UpdateIncomeFromPeer :exec
+UPDATE incomes
+SET date = date(?), description = ?, amount_cents = ?, category = ?, subcategory = ?, tags = ?, version = ?, modified_at = ?
+WHERE uid = ?
 `
 
-// Gets a single sync queue item by ID.
-func (q *Queries) GetSyncQueueItem(ctx context.Context, id int64) (SyncQueue, error) {
-	row := q.db.QueryRowContext(ctx, getSyncQueueItem, id)
-	var i SyncQueue
-	err := row.Scan(
-		&i.ID,
-		&i.Operation,
-		&i.ExpenseID,
-		&i.ExpenseDay,
-		&i.ExpenseMonth,
-		&i.ExpenseDescription,
-		&i.ExpenseAmountCents,
-		&i.ExpensePrimary,
-		&i.ExpenseSecondary,
-		&i.Status,
-		&i.Attempts,
-		&i.MaxAttempts,
-		&i.LastError,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-		&i.ProcessedAt,
-		&i.NextRetryAt,
+type UpdateIncomeFromPeerParams struct {
+	Date        interface{}    `db:"date" json:"date"`
+	Description string         `db:"description" json:"description"`
+	AmountCents int64          `db:"amount_cents" json:"amount_cents"`
+	Category    string         `db:"category" json:"category"`
+	Subcategory string         `db:"subcategory" json:"subcategory"`
+	Tags        string         `db:"tags" json:"tags"`
+	Version     int64          `db:"version" json:"version"`
+	ModifiedAt  sql.NullString `db:"modified_at" json:"modified_at"`
+	Uid         sql.NullString `db:"uid" json:"uid"`
+}
+
+func (q *Queries) UpdateIncomeFromPeer(ctx context.Context, arg UpdateIncomeFromPeerParams) error {
+	_, err := q.db.ExecContext(ctx, updateIncomeFromPeer,
+		arg.Date,
+		arg.Description,
+		arg.AmountCents,
+		arg.Category,
+		arg.Subcategory,
+		arg.Tags,
+		arg.Version,
+		arg.ModifiedAt,
+		arg.Uid,
 	)
-	return i, err
+	return err
 }
 
-const getSyncQueueStats = `-- name: GetSyncQueueStats :one
-SELECT
-    CAST(SUM(CASE WHEN status = 'pending' THEN 1 ELSE 0 END) AS INTEGER) as pending_count,
-    CAST(SUM(CASE WHEN status = 'processing' THEN 1 ELSE 0 END) AS INTEGER) as processing_count,
-    CAST(SUM(CASE WHEN status = 'completed' THEN 1 ELSE 0 END) AS INTEGER) as completed_count,
-    CAST(SUM(CASE WHEN status = 'failed' THEN 1 ELSE 0 END) AS INTEGER) as failed_count
-FROM sync_queue
+const updateRecurrentExpense = `-- name: UpdateRecurrentExpense :execrows
+UPDATE recurrent_expenses
+SET start_date = ?, 
+    end_date = ?, 
+    repetition_type = ?, 
+    description = ?,
+    amount_cents = ?, 
+    primary_category = ?, 
+    secondary_category = ?,
+    repeat_interval = ?,
+    day_of_month = ?,
+    version = version + 1,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = ? AND version = ?
+`
+
+type UpdateRecurrentExpenseParams struct {
+	StartDate         time.Time   `db:"start_date" json:"start_date"`
+	EndDate           interface{} `db:"end_date" json:"end_date"`
+	RepetitionType    string      `db:"repetition_type" json:"repetition_type"`
+	Description       string      `db:"description" json:"description"`
+	AmountCents       int64       `db:"amount_cents" json:"amount_cents"`
+	PrimaryCategory   string      `db:"primary_category" json:"primary_category"`
+	SecondaryCategory string      `db:"secondary_category" json:"secondary_category"`
+	RepeatInterval    int64       `db:"repeat_interval" json:"repeat_interval"`
+	DayOfMonth        int64       `db:"day_of_month" json:"day_of_month"`
+	ID                int64       `db:"id" json:"id"`
+	Version           int64       `db:"version" json:"version"`
+}
+
+func (q *Queries) UpdateRecurrentExpense(ctx context.Context, arg UpdateRecurrentExpenseParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateRecurrentExpense,
+		arg.StartDate,
+		arg.EndDate,
+		arg.RepetitionType,
+		arg.Description,
+		arg.AmountCents,
+		arg.PrimaryCategory,
+		arg.SecondaryCategory,
+		arg.RepeatInterval,
+		arg.DayOfMonth,
+		arg.ID,
+		arg.Version,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateRecurrentIncome = `-- name: UpdateRecurrentIncome :execrows
+UPDATE recurrent_incomes
+SET start_date = ?,
+    end_date = ?,
+    repetition_type = ?,
+    repeat_interval = ?,
+    day_of_month = ?,
+    description = ?,
+    amount_cents = ?,
+    category = ?,
+    subcategory = ?,
+    version = version + 1,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = ? AND version = ? AND is_active = 1
 `
 
-type GetSyncQueueStatsRow struct {
-	PendingCount    int64 `db:"pending_count" json:"pending_count"`
-	ProcessingCount int64 `db:"processing_count" json:"processing_count"`
-	CompletedCount  int64 `db:"completed_count" json:"completed_count"`
-	FailedCount     int64 `db:"failed_count" json:"failed_count"`
+type UpdateRecurrentIncomeParams struct {
+	StartDate      time.Time   `db:"start_date" json:"start_date"`
+	EndDate        interface{} `db:"end_date" json:"end_date"`
+	RepetitionType string      `db:"repetition_type" json:"repetition_type"`
+	RepeatInterval int64       `db:"repeat_interval" json:"repeat_interval"`
+	DayOfMonth     int64       `db:"day_of_month" json:"day_of_month"`
+	Description    string      `db:"description" json:"description"`
+	AmountCents    int64       `db:"amount_cents" json:"amount_cents"`
+	Category       string      `db:"category" json:"category"`
+	Subcategory    string      `db:"subcategory" json:"subcategory"`
+	ID             int64       `db:"id" json:"id"`
+	Version        int64       `db:"version" json:"version"`
 }
 
-// Returns counts by status for monitoring.
-func (q *Queries) GetSyncQueueStats(ctx context.Context) (GetSyncQueueStatsRow, error) {
-	row := q.db.QueryRowContext(ctx, getSyncQueueStats)
-	var i GetSyncQueueStatsRow
-	err := row.Scan(
-		&i.PendingCount,
-		&i.ProcessingCount,
-		&i.CompletedCount,
-		&i.FailedCount,
-	)
-	return i, err
+func (q *Queries) UpdateRecurrentIncome(ctx context.Context, arg UpdateRecurrentIncomeParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateRecurrentIncome, arg.StartDate, arg.EndDate, arg.RepetitionType, arg.RepeatInterval, arg.DayOfMonth, arg.Description, arg.AmountCents, arg.Category, arg.Subcategory, arg.ID, arg.Version)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
-const hardDeleteExpense = `-- name: HardDeleteExpense :exec
-DELETE FROM expenses 
+const updateRecurrentIncomeLastExecution = `-- name: UpdateRecurrentIncomeLastExecution :exec
+UPDATE recurrent_incomes
+SET last_execution_date = ?,
+    updated_at = CURRENT_TIMESTAMP
 WHERE id = ?
 `
 
-func (q *Queries) HardDeleteExpense(ctx context.Context, id int64) error {
-	_, err := q.db.ExecContext(ctx, hardDeleteExpense, id)
-	return err
+type UpdateRecurrentIncomeLastExecutionParams struct {
+	LastExecutionDate interface{} `db:"last_execution_date" json:"last_execution_date"`
+	ID                int64       `db:"id" json:"id"`
 }
 
-const hardDeleteIncome = `-- name: HardDeleteIncome :exec
-DELETE FROM incomes
-WHERE id = ?
-`
-
-func (q *Queries) HardDeleteIncome(ctx context.Context, id int64) error {
-	_, err := q.db.ExecContext(ctx, hardDeleteIncome, id)
+func (q *Queries) UpdateRecurrentIncomeLastExecution(ctx context.Context, arg UpdateRecurrentIncomeLastExecutionParams) error {
+	_, err := q.db.ExecContext(ctx, updateRecurrentIncomeLastExecution, arg.LastExecutionDate, arg.ID)
 	return err
 }
 
-const incrementSyncAttempt = `-- name: IncrementSyncAttempt :exec
-UPDATE sync_queue
-SET attempts = attempts + 1,
-    last_error = ?,
-    status = 'pending',
-    next_retry_at = datetime(CURRENT_TIMESTAMP, '+' || (1 << attempts) || ' minutes'),
+const updateRecurrentLastExecution = `-- name: UpdateRecurrentLastExecution :exec
+UPDATE recurrent_expenses
+SET last_execution_date = ?,
     updated_at = CURRENT_TIMESTAMP
 WHERE id = ?
 `
 
-type IncrementSyncAttemptParams struct {
-	LastError interface{} `db:"last_error" json:"last_error"`
-	ID        int64       `db:"id" json:"id"`
+type UpdateRecurrentLastExecutionParams struct {
+	LastExecutionDate interface{} `db:"last_execution_date" json:"last_execution_date"`
+	ID                int64       `db:"id" json:"id"`
 }
 
-// Increments attempt count and schedules next retry with exponential backoff.
-func (q *Queries) IncrementSyncAttempt(ctx context.Context, arg IncrementSyncAttemptParams) error {
-	_, err := q.db.ExecContext(ctx, incrementSyncAttempt, arg.LastError, arg.ID)
+func (q *Queries) UpdateRecurrentLastExecution(ctx context.Context, arg UpdateRecurrentLastExecutionParams) error {
+	_, err := q.db.ExecContext(ctx, updateRecurrentLastExecution, arg.LastExecutionDate, arg.ID)
 	return err
 }
 
-const listExpensesByDateRange = `-- name: ListExpensesByDateRange :many
-SELECT id, date, description, amount_cents, primary_category, secondary_category, version, created_at, synced_at, sync_status FROM expenses
-WHERE date >= ? AND date <= ?
-ORDER BY date DESC, created_at DESC
+const updateSecondaryCategory = `-- name: UpdateSecondaryCategory :execrows
+UPDATE secondary_categories SET name = ?, primary_category_id = ? WHERE id = ?
 `
 
-type ListExpensesByDateRangeParams struct {
-	Date   time.Time `db:"date" json:"date"`
-	Date_2 time.Time `db:"date_2" json:"date_2"`
+type UpdateSecondaryCategoryParams struct {
+	Name              string `db:"name" json:"name"`
+	PrimaryCategoryID int64  `db:"primary_category_id" json:"primary_category_id"`
+	ID                int64  `db:"id" json:"id"`
 }
 
-func (q *Queries) ListExpensesByDateRange(ctx context.Context, arg ListExpensesByDateRangeParams) ([]Expense, error) {
-	rows, err := q.db.QueryContext(ctx, listExpensesByDateRange, arg.Date, arg.Date_2)
+func (q *Queries) UpdateSecondaryCategory(ctx context.Context, arg UpdateSecondaryCategoryParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateSecondaryCategory, arg.Name, arg.PrimaryCategoryID, arg.ID)
 	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []Expense
-	for rows.Next() {
-		var i Expense
-		if err := rows.Scan(
-			&i.ID,
-			&i.Date,
-			&i.Description,
-			&i.AmountCents,
-			&i.PrimaryCategory,
-			&i.SecondaryCategory,
-			&i.Version,
-			&i.CreatedAt,
-			&i.SyncedAt,
-			&i.SyncStatus,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+		return 0, err
 	}
-	return items, nil
+	return result.RowsAffected()
 }
 
-const markExpenseSyncError = `-- name: MarkExpenseSyncError :exec
-UPDATE expenses 
-SET sync_status = 'error'
-WHERE id = ?
+const updateShoppingListItem = `-- name: UpdateShoppingListItem :execrows
+
+UPDATE shopping_list_items
+SET checked = ?, price_cents = ?
+WHERE id = ? AND list_id IN (SELECT id FROM shopping_lists WHERE converted_at IS NULL)
 `
 
-func (q *Queries) MarkExpenseSyncError(ctx context.Context, id int64) error {
-	_, err := q.db.ExecContext(ctx, markExpenseSyncError, id)
-	return err
+type UpdateShoppingListItemParams struct {
+	Checked    bool  `db:"checked" json:"checked"`
+	PriceCents int64 `db:"price_cents" json:"price_cents"`
+	ID         int64 `db:"id" json:"id"`
 }
 
-const markExpenseSynced = `-- name: MarkExpenseSynced :exec
-UPDATE expenses 
-SET sync_status = 'synced', synced_at = CURRENT_TIMESTAMP
-WHERE id = ?
+// Items of converted lists are read-only.
+func (q *Queries) UpdateShoppingListItem(ctx context.Context, arg UpdateShoppingListItemParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateShoppingListItem, arg.Checked, arg.PriceCents, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const upsertCategoryKeyword = `-- name: UpsertCategoryKeyword :exec
+INSERT INTO category_keywords (keyword, primary_category, secondary_category, disabled)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (keyword) DO UPDATE SET
+    primary_category = excluded.primary_category,
+    secondary_category = excluded.secondary_category,
+    disabled = excluded.disabled
 `
 
-func (q *Queries) MarkExpenseSynced(ctx context.Context, id int64) error {
-	_, err := q.db.ExecContext(ctx, markExpenseSynced, id)
+type UpsertCategoryKeywordParams struct {
+	Keyword           string `db:"keyword" json:"keyword"`
+	PrimaryCategory   string `db:"primary_category" json:"primary_category"`
+	SecondaryCategory string `db:"secondary_category" json:"secondary_category"`
+	Disabled          bool   `db:"disabled" json:"disabled"`
+}
+
+func (q *Queries) UpsertCategoryKeyword(ctx context.Context, arg UpsertCategoryKeywordParams) error {
+	_, err := q.db.ExecContext(ctx, upsertCategoryKeyword,
+		arg.Keyword,
+		arg.PrimaryCategory,
+		arg.SecondaryCategory,
+		arg.Disabled,
+	)
 	return err
 }
 
-const markSyncComplete = `-- name: MarkSyncComplete :exec
-UPDATE sync_queue
-SET status = 'completed',
-    processed_at = CURRENT_TIMESTAMP,
+const upsertCategoryMapping = `-- name: UpsertCategoryMapping :exec
+INSERT INTO category_mappings (sheet_name, primary_category)
+VALUES (?, ?)
+ON CONFLICT(sheet_name) DO UPDATE SET
+    primary_category = excluded.primary_category,
     updated_at = CURRENT_TIMESTAMP
-WHERE id = ?
 `
 
-// Marks a sync queue item as successfully completed.
-func (q *Queries) MarkSyncComplete(ctx context.Context, id int64) error {
-	_, err := q.db.ExecContext(ctx, markSyncComplete, id)
+type UpsertCategoryMappingParams struct {
+	SheetName       string `db:"sheet_name" json:"sheet_name"`
+	PrimaryCategory string `db:"primary_category" json:"primary_category"`
+}
+
+// Maps a Google Sheets secondary category to a primary category.
+func (q *Queries) UpsertCategoryMapping(ctx context.Context, arg UpsertCategoryMappingParams) error {
+	_, err := q.db.ExecContext(ctx, upsertCategoryMapping, arg.SheetName, arg.PrimaryCategory)
 	return err
 }
 
-const markSyncFailed = `-- name: MarkSyncFailed :exec
-UPDATE sync_queue
-SET status = 'failed',
-    last_error = ?,
-    updated_at = CURRENT_TIMESTAMP
-WHERE id = ?
+const upsertCategoryTranslation = `-- name: UpsertCategoryTranslation :exec
+INSERT INTO category_translations (kind, name, locale, display_name)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (kind, name, locale) DO UPDATE SET
+    display_name = excluded.display_name
 `
 
-type MarkSyncFailedParams struct {
-	LastError interface{} `db:"last_error" json:"last_error"`
-	ID        int64       `db:"id" json:"id"`
+type UpsertCategoryTranslationParams struct {
+	Kind        string `db:"kind" json:"kind"`
+	Name        string `db:"name" json:"name"`
+	Locale      string `db:"locale" json:"locale"`
+	DisplayName string `db:"display_name" json:"display_name"`
 }
 
-// Marks a sync queue item as failed after max retries exceeded.
-func (q *Queries) MarkSyncFailed(ctx context.Context, arg MarkSyncFailedParams) error {
-	_, err := q.db.ExecContext(ctx, markSyncFailed, arg.LastError, arg.ID)
+func (q *Queries) UpsertCategoryTranslation(ctx context.Context, arg UpsertCategoryTranslationParams) error {
+	_, err := q.db.ExecContext(ctx, upsertCategoryTranslation,
+		arg.Kind,
+		arg.Name,
+		arg.Locale,
+		arg.DisplayName,
+	)
 	return err
 }
 
-const markSyncProcessing = `-- name: MarkSyncProcessing :exec
-UPDATE sync_queue
-SET status = 'processing', updated_at = CURRENT_TIMESTAMP
-WHERE id = ?
+const upsertClassifierFeedback = `-- name: UpsertClassifierFeedback :exec
+INSERT INTO classifier_feedback (expense_id, verdict, primary_category, secondary_category, confidence)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT (expense_id) DO UPDATE SET
+    verdict = excluded.verdict,
+    primary_category = excluded.primary_category,
+    secondary_category = excluded.secondary_category,
+    confidence = excluded.confidence,
+    created_at = CURRENT_TIMESTAMP
 `
 
-// Marks an item as being processed.
-func (q *Queries) MarkSyncProcessing(ctx context.Context, id int64) error {
-	_, err := q.db.ExecContext(ctx, markSyncProcessing, id)
+type UpsertClassifierFeedbackParams struct {
+	ExpenseID         int64   `db:"expense_id" json:"expense_id"`
+	Verdict           string  `db:"verdict" json:"verdict"`
+	PrimaryCategory   string  `db:"primary_category" json:"primary_category"`
+	SecondaryCategory string  `db:"secondary_category" json:"secondary_category"`
+	Confidence        float64 `db:"confidence" json:"confidence"`
+}
+
+func (q *Queries) UpsertClassifierFeedback(ctx context.Context, arg UpsertClassifierFeedbackParams) error {
+	_, err := q.db.ExecContext(ctx, upsertClassifierFeedback,
+		arg.ExpenseID,
+		arg.Verdict,
+		arg.PrimaryCategory,
+		arg.SecondaryCategory,
+		arg.Confidence,
+	)
 	return err
 }
 
-const refreshCategories = `-- name: RefreshCategories :exec
-DELETE FROM secondary_categories
+const upsertExpenseReimbursement = `-- name: UpsertExpenseReimbursement :exec
+
+INSERT INTO expense_reimbursements (expense_id, income_id, amount_cents)
+VALUES (?, ?, ?)
+ON CONFLICT (expense_id, income_id) DO UPDATE SET amount_cents = amount_cents + excluded.amount_cents
 `
 
-func (q *Queries) RefreshCategories(ctx context.Context) error {
-	_, err := q.db.ExecContext(ctx, refreshCategories)
+type UpsertExpenseReimbursementParams struct {
+	ExpenseID   int64 `db:"expense_id" json:"expense_id"`
+	IncomeID    int64 `db:"income_id" json:"income_id"`
+	AmountCents int64 `db:"amount_cents" json:"amount_cents"`
+}
+
+// Reimbursements
+// Links part of an expense to an income, adding to an existing link.
+func (q *Queries) UpsertExpenseReimbursement(ctx context.Context, arg UpsertExpenseReimbursementParams) error {
+	_, err := q.db.ExecContext(ctx, upsertExpenseReimbursement, arg.ExpenseID, arg.IncomeID, arg.AmountCents)
 	return err
 }
 
-const refreshPrimaryCategories = `-- name: RefreshPrimaryCategories :exec
-DELETE FROM primary_categories
+const upsertExpenseWorkflowState = `-- name: UpsertExpenseWorkflowState :exec
+INSERT INTO expense_workflow (expense_id, state, updated_by)
+VALUES (?, ?, ?)
+ON CONFLICT (expense_id) DO UPDATE SET
+    state = excluded.state,
+    updated_by = excluded.updated_by,
+    updated_at = CURRENT_TIMESTAMP
 `
 
-func (q *Queries) RefreshPrimaryCategories(ctx context.Context) error {
-	_, err := q.db.ExecContext(ctx, refreshPrimaryCategories)
+type UpsertExpenseWorkflowStateParams struct {
+	ExpenseID int64  `db:"expense_id" json:"expense_id"`
+	State     string `db:"state" json:"state"`
+	UpdatedBy string `db:"updated_by" json:"updated_by"`
+}
+
+func (q *Queries) UpsertExpenseWorkflowState(ctx context.Context, arg UpsertExpenseWorkflowStateParams) error {
+	_, err := q.db.ExecContext(ctx, upsertExpenseWorkflowState, arg.ExpenseID, arg.State, arg.UpdatedBy)
 	return err
 }
 
-const resetStaleProcessing = `-- name: ResetStaleProcessing :exec
-UPDATE sync_queue
-SET status = 'pending',
-    updated_at = CURRENT_TIMESTAMP
-WHERE status = 'processing'
-  AND updated_at < datetime(CURRENT_TIMESTAMP, '-5 minutes')
+const upsertFuelFill = `-- name: UpsertFuelFill :exec
+INSERT INTO fuel_fills (expense_id, liters, odometer_km)
+VALUES (?, ?, ?)
+ON CONFLICT (expense_id) DO UPDATE SET
+    liters = excluded.liters,
+    odometer_km = excluded.odometer_km
 `
 
-// Resets items stuck in processing state (crash recovery).
-func (q *Queries) ResetStaleProcessing(ctx context.Context) error {
-	_, err := q.db.ExecContext(ctx, resetStaleProcessing)
+type UpsertFuelFillParams struct {
+	ExpenseID  int64   `db:"expense_id" json:"expense_id"`
+	Liters     float64 `db:"liters" json:"liters"`
+	OdometerKm int64   `db:"odometer_km" json:"odometer_km"`
+}
+
+func (q *Queries) UpsertFuelFill(ctx context.Context, arg UpsertFuelFillParams) error {
+	_, err := q.db.ExecContext(ctx, upsertFuelFill, arg.ExpenseID, arg.Liters, arg.OdometerKm)
 	return err
 }
 
-const retryFailedSyncs = `-- name: RetryFailedSyncs :exec
-UPDATE sync_queue
-SET status = 'pending',
-    attempts = 0,
-    next_retry_at = NULL,
-    last_error = NULL,
-    updated_at = CURRENT_TIMESTAMP
-WHERE status = 'failed'
+const upsertItem = `-- name: UpsertItem :one
+
+INSERT INTO items (name, normalized_name)
+VALUES (?, ?)
+ON CONFLICT (normalized_name) DO UPDATE SET name = items.name
+RETURNING id
 `
 
-// Resets failed items back to pending for manual retry.
-func (q *Queries) RetryFailedSyncs(ctx context.Context) error {
-	_, err := q.db.ExecContext(ctx, retryFailedSyncs)
+type UpsertItemParams struct {
+	Name           string `db:"name" json:"name"`
+	NormalizedName string `db:"normalized_name" json:"normalized_name"`
+}
+
+// Returns the item with this normalized name, creating it when new.
+func (q *Queries) UpsertItem(ctx context.Context, arg UpsertItemParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, upsertItem, arg.Name, arg.NormalizedName)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const upsertPeerTombstone = `-- name: UpsertPeerTombstone :exec
+INSERT INTO peer_tombstones (kind, uid, version, deleted_at)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (kind, uid) DO UPDATE SET version = max(version, excluded.version), deleted_at = excluded.deleted_at
+`
+
+type UpsertPeerTombstoneParams struct {
+	Kind      string `db:"kind" json:"kind"`
+	Uid       string `db:"uid" json:"uid"`
+	Version   int64  `db:"version" json:"version"`
+	DeletedAt string `db:"deleted_at" json:"deleted_at"`
+}
+
+// Keeps the highest deleted version seen for the record.
+func (q *Queries) UpsertPeerTombstone(ctx context.Context, arg UpsertPeerTombstoneParams) error {
+	_, err := q.db.ExecContext(ctx, upsertPeerTombstone,
+		arg.Kind,
+		arg.Uid,
+		arg.Version,
+		arg.DeletedAt,
+	)
 	return err
 }
 
-const updateRecurrentExpense = `-- name: UpdateRecurrentExpense :exec
-UPDATE recurrent_expenses
-SET start_date = ?, 
-    end_date = ?, 
-    repetition_type = ?, 
-    description = ?,
-    amount_cents = ?, 
-    primary_category = ?, 
-    secondary_category = ?,
-    updated_at = CURRENT_TIMESTAMP
-WHERE id = ?
+const upsertPurchaseWarranty = `-- name: UpsertPurchaseWarranty :exec
+
+INSERT INTO purchase_warranties (expense_id, warranty_months, return_deadline, proof)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (expense_id) DO UPDATE SET
+    warranty_months = excluded.warranty_months,
+    return_notified_at = CASE
+        WHEN return_deadline IS excluded.return_deadline THEN return_notified_at
+        ELSE NULL
+    END,
+    return_deadline = excluded.return_deadline,
+    proof = excluded.proof
 `
 
-type UpdateRecurrentExpenseParams struct {
-	StartDate         time.Time   `db:"start_date" json:"start_date"`
-	EndDate           interface{} `db:"end_date" json:"end_date"`
-	RepetitionType    string      `db:"repetition_type" json:"repetition_type"`
-	Description       string      `db:"description" json:"description"`
-	AmountCents       int64       `db:"amount_cents" json:"amount_cents"`
-	PrimaryCategory   string      `db:"primary_category" json:"primary_category"`
-	SecondaryCategory string      `db:"secondary_category" json:"secondary_category"`
-	ID                int64       `db:"id" json:"id"`
+type UpsertPurchaseWarrantyParams struct {
+	ExpenseID      int64        `db:"expense_id" json:"expense_id"`
+	WarrantyMonths int64        `db:"warranty_months" json:"warranty_months"`
+	ReturnDeadline sql.NullTime `db:"return_deadline" json:"return_deadline"`
+	Proof          string       `db:"proof" json:"proof"`
 }
 
-func (q *Queries) UpdateRecurrentExpense(ctx context.Context, arg UpdateRecurrentExpenseParams) error {
-	_, err := q.db.ExecContext(ctx, updateRecurrentExpense,
-		arg.StartDate,
-		arg.EndDate,
-		arg.RepetitionType,
-		arg.Description,
-		arg.AmountCents,
-		arg.PrimaryCategory,
-		arg.SecondaryCategory,
-		arg.ID,
+// A changed return deadline gets a new reminder.
+func (q *Queries) UpsertPurchaseWarranty(ctx context.Context, arg UpsertPurchaseWarrantyParams) error {
+	_, err := q.db.ExecContext(ctx, upsertPurchaseWarranty,
+		arg.ExpenseID,
+		arg.WarrantyMonths,
+		arg.ReturnDeadline,
+		arg.Proof,
 	)
 	return err
 }
 
-const updateRecurrentLastExecution = `-- name: UpdateRecurrentLastExecution :exec
-UPDATE recurrent_expenses
-SET last_execution_date = ?,
-    updated_at = CURRENT_TIMESTAMP
-WHERE id = ?
+const upsertReceipt = `-- name: UpsertReceipt :exec
+INSERT INTO receipts (expense_id, blob_key, thumbnail_key, content_type, filename, size_bytes, uploaded_by)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (expense_id) DO UPDATE SET
+    blob_key = excluded.blob_key,
+    thumbnail_key = excluded.thumbnail_key,
+    content_type = excluded.content_type,
+    filename = excluded.filename,
+    size_bytes = excluded.size_bytes,
+    created_at = CURRENT_TIMESTAMP,
+    uploaded_by = excluded.uploaded_by
 `
 
-type UpdateRecurrentLastExecutionParams struct {
-	LastExecutionDate interface{} `db:"last_execution_date" json:"last_execution_date"`
-	ID                int64       `db:"id" json:"id"`
+type UpsertReceiptParams struct {
+	ExpenseID    int64         `db:"expense_id" json:"expense_id"`
+	BlobKey      string        `db:"blob_key" json:"blob_key"`
+	ThumbnailKey string        `db:"thumbnail_key" json:"thumbnail_key"`
+	ContentType  string        `db:"content_type" json:"content_type"`
+	Filename     string        `db:"filename" json:"filename"`
+	SizeBytes    int64         `db:"size_bytes" json:"size_bytes"`
+	UploadedBy   sql.NullInt64 `db:"uploaded_by" json:"uploaded_by"`
 }
 
-func (q *Queries) UpdateRecurrentLastExecution(ctx context.Context, arg UpdateRecurrentLastExecutionParams) error {
-	_, err := q.db.ExecContext(ctx, updateRecurrentLastExecution, arg.LastExecutionDate, arg.ID)
+func (q *Queries) UpsertReceipt(ctx context.Context, arg UpsertReceiptParams) error {
+	_, err := q.db.ExecContext(ctx, upsertReceipt, arg.ExpenseID, arg.BlobKey, arg.ThumbnailKey, arg.ContentType, arg.Filename, arg.SizeBytes, arg.UploadedBy)
+	return err
+}
+
+const upsertTag = `-- name: UpsertTag :one
+INSERT INTO tags (name) VALUES (?)
+ON CONFLICT(name) DO UPDATE SET name = excluded.name
+RETURNING id
+`
+
+// Returns the ID of the tag, creating it on first use.
+func (q *Queries) UpsertTag(ctx context.Context, name string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, upsertTag, name)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const upsertUserQuota = `-- name: UpsertUserQuota :exec
+INSERT INTO user_quotas (user_id, daily_expenses, attachment_bytes) VALUES (?, ?, ?)
+ON CONFLICT (user_id) DO UPDATE SET
+    daily_expenses = excluded.daily_expenses,
+    attachment_bytes = excluded.attachment_bytes
+`
+
+type UpsertUserQuotaParams struct {
+	UserID          int64         `db:"user_id" json:"user_id"`
+	DailyExpenses   sql.NullInt64 `db:"daily_expenses" json:"daily_expenses"`
+	AttachmentBytes sql.NullInt64 `db:"attachment_bytes" json:"attachment_bytes"`
+}
+
+func (q *Queries) UpsertUserQuota(ctx context.Context, arg UpsertUserQuotaParams) error {
+	_, err := q.db.ExecContext(ctx, upsertUserQuota, arg.UserID, arg.DailyExpenses, arg.AttachmentBytes)
+	return err
+}
+
+const upsertUtilityUsage = `-- name: UpsertUtilityUsage :exec
+INSERT INTO utility_usage (expense_id, kind, quantity)
+VALUES (?, ?, ?)
+ON CONFLICT (expense_id) DO UPDATE SET
+    kind = excluded.kind,
+    quantity = excluded.quantity
+`
+
+type UpsertUtilityUsageParams struct {
+	ExpenseID int64   `db:"expense_id" json:"expense_id"`
+	Kind      string  `db:"kind" json:"kind"`
+	Quantity  float64 `db:"quantity" json:"quantity"`
+}
+
+func (q *Queries) UpsertUtilityUsage(ctx context.Context, arg UpsertUtilityUsageParams) error {
+	_, err := q.db.ExecContext(ctx, upsertUtilityUsage, arg.ExpenseID, arg.Kind, arg.Quantity)
 	return err
 }
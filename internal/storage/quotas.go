@@ -0,0 +1,276 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"spese/internal/core"
+)
+
+// apiKeyPrefix starts every API key, telling them apart from the other
+// bearer tokens (WebSocket, peer, approver).
+const apiKeyPrefix = "spk_"
+
+// IsAPIKey reports whether a bearer token looks like an API key.
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, apiKeyPrefix)
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateAPIKey gives a member a new API key, returned only here: just its
+// hash is stored.
+func (r *SQLiteRepository) CreateAPIKey(ctx context.Context, userID int64, name string) (core.APIKey, string, error) {
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return core.APIKey{}, "", fmt.Errorf("generate api key: %w", err)
+	}
+	key := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(random)
+
+	row, err := r.queries.CreateApiKey(ctx, CreateApiKeyParams{
+		UserID:  userID,
+		Name:    strings.TrimSpace(name),
+		KeyHash: hashAPIKey(key),
+	})
+	if err != nil {
+		return core.APIKey{}, "", fmt.Errorf("create api key: %w", err)
+	}
+	return apiKeyFromRow(row), key, nil
+}
+
+// AuthenticateAPIKey returns the member owning key; the error wraps
+// sql.ErrNoRows for unknown or revoked keys.
+func (r *SQLiteRepository) AuthenticateAPIKey(ctx context.Context, key string) (core.User, error) {
+	row, err := r.reader(ctx).GetApiKeyUser(ctx, hashAPIKey(key))
+	if err != nil {
+		return core.User{}, fmt.Errorf("get api key: %w", err)
+	}
+	// Only informative, so a failure does not turn the request away
+	if err := r.queries.TouchApiKey(ctx, row.ID); err != nil {
+		slog.DebugContext(ctx, "Failed to record API key use", "error", err, "key_id", row.ID)
+	}
+	return core.User{ID: row.UserID, Name: row.Name}, nil
+}
+
+// ListAPIKeys returns every key by member, oldest first.
+func (r *SQLiteRepository) ListAPIKeys(ctx context.Context) ([]core.APIKey, error) {
+	rows, err := r.reader(ctx).ListApiKeys(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list api keys: %w", err)
+	}
+	keys := make([]core.APIKey, len(rows))
+	for i, row := range rows {
+		keys[i] = apiKeyFromRow(row)
+	}
+	return keys, nil
+}
+
+// DeleteAPIKey revokes a key.
+func (r *SQLiteRepository) DeleteAPIKey(ctx context.Context, id int64) error {
+	n, err := r.queries.DeleteApiKey(ctx, id)
+	if err != nil {
+		return fmt.Errorf("delete api key: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("api key %d: %w", id, sql.ErrNoRows)
+	}
+	return nil
+}
+
+func apiKeyFromRow(row ApiKey) core.APIKey {
+	return core.APIKey{
+		ID:         row.ID,
+		UserID:     row.UserID,
+		Name:       row.Name,
+		CreatedAt:  row.CreatedAt,
+		LastUsedAt: row.LastUsedAt.Time,
+	}
+}
+
+// QuotaOverride returns the quotas set for a member, empty when they use
+// the instance defaults.
+func (r *SQLiteRepository) QuotaOverride(ctx context.Context, userID int64) (core.QuotaOverride, error) {
+	row, err := r.reader(ctx).GetUserQuota(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return core.QuotaOverride{}, nil
+	}
+	if err != nil {
+		return core.QuotaOverride{}, fmt.Errorf("get user quota: %w", err)
+	}
+	return quotaOverrideFromRow(row), nil
+}
+
+// QuotaOverrides returns the quotas set per member.
+func (r *SQLiteRepository) QuotaOverrides(ctx context.Context) (map[int64]core.QuotaOverride, error) {
+	rows, err := r.reader(ctx).ListUserQuotas(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list user quotas: %w", err)
+	}
+	overrides := make(map[int64]core.QuotaOverride, len(rows))
+	for _, row := range rows {
+		overrides[row.UserID] = quotaOverrideFromRow(row)
+	}
+	return overrides, nil
+}
+
+// SetQuotaOverride sets the quotas of a member; nil fields go back to the
+// instance defaults.
+func (r *SQLiteRepository) SetQuotaOverride(ctx context.Context, userID int64, o core.QuotaOverride) error {
+	nullable := func(v *int64) sql.NullInt64 {
+		if v == nil {
+			return sql.NullInt64{}
+		}
+		return sql.NullInt64{Int64: *v, Valid: true}
+	}
+	if err := r.queries.UpsertUserQuota(ctx, UpsertUserQuotaParams{
+		UserID:          userID,
+		DailyExpenses:   nullable(o.DailyExpenses),
+		AttachmentBytes: nullable(o.AttachmentBytes),
+	}); err != nil {
+		return fmt.Errorf("set user quota: %w", err)
+	}
+	return nil
+}
+
+func quotaOverrideFromRow(row UserQuota) core.QuotaOverride {
+	var o core.QuotaOverride
+	if row.DailyExpenses.Valid {
+		o.DailyExpenses = &row.DailyExpenses.Int64
+	}
+	if row.AttachmentBytes.Valid {
+		o.AttachmentBytes = &row.AttachmentBytes.Int64
+	}
+	return o
+}
+
+// ConsumeDailyExpenses counts n new expenses on a member's day (a
+// YYYY-MM-DD date), returning the day's total. When that would go over
+// limit (0 for none) nothing is counted and the error is a
+// *core.QuotaError. The check and the count are a single statement, so
+// concurrent requests cannot overshoot together.
+func (r *SQLiteRepository) ConsumeDailyExpenses(ctx context.Context, userID int64, day string, n, limit int64) (int64, error) {
+	quotaErr := func() error {
+		used, err := r.queries.GetDailyExpenses(ctx, GetDailyExpensesParams{UserID: userID, Day: day})
+		if err != nil {
+			return fmt.Errorf("get daily expenses: %w", err)
+		}
+		return &core.QuotaError{Quota: core.QuotaDailyExpenses, Limit: limit, Used: used, Requested: n}
+	}
+	// A first insert of the day skips the WHERE of the upsert
+	if limit > 0 && n > limit {
+		return 0, quotaErr()
+	}
+	total, err := r.queries.ConsumeDailyExpenses(ctx, ConsumeDailyExpensesParams{
+		UserID:      userID,
+		Day:         day,
+		Count:       n,
+		MaxExpenses: limit,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, quotaErr()
+	}
+	if err != nil {
+		return 0, fmt.Errorf("consume daily expenses: %w", err)
+	}
+	return total, nil
+}
+
+// ReleaseDailyExpenses gives back n expenses counted by
+// ConsumeDailyExpenses for a creation that failed.
+func (r *SQLiteRepository) ReleaseDailyExpenses(ctx context.Context, userID int64, day string, n int64) error {
+	if err := r.queries.ReleaseDailyExpenses(ctx, ReleaseDailyExpensesParams{Count: n, UserID: userID, Day: day}); err != nil {
+		return fmt.Errorf("release daily expenses: %w", err)
+	}
+	return nil
+}
+
+// DailyExpenses returns the expenses each member created with their keys
+// on day.
+func (r *SQLiteRepository) DailyExpenses(ctx context.Context, day string) (map[int64]int64, error) {
+	rows, err := r.reader(ctx).ListDailyUsage(ctx, day)
+	if err != nil {
+		return nil, fmt.Errorf("list daily usage: %w", err)
+	}
+	used := make(map[int64]int64, len(rows))
+	for _, row := range rows {
+		used[row.UserID] = row.Expenses
+	}
+	return used, nil
+}
+
+// AttachmentBytes returns the bytes of the receipts a member uploaded,
+// leaving out the receipt of exceptExpense, which an upload replaces.
+func (r *SQLiteRepository) AttachmentBytes(ctx context.Context, userID, exceptExpense int64) (int64, error) {
+	total, err := r.reader(ctx).GetAttachmentBytes(ctx, GetAttachmentBytesParams{
+		UserID:        sql.NullInt64{Int64: userID, Valid: true},
+		ExceptExpense: exceptExpense,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("get attachment bytes: %w", err)
+	}
+	return total, nil
+}
+
+// ReserveAttachmentBytes holds size bytes of a member's attachment quota
+// for the upload of the receipt of expense, whose current receipt is not
+// counted, and returns the reservation. When that would go over limit
+// nothing is held and the error is a *core.QuotaError. The check and the
+// reservation are a single statement, so concurrent uploads cannot
+// overshoot together; the reservation is given back with
+// ReleaseAttachmentBytes once the receipt is stored or the upload failed.
+func (r *SQLiteRepository) ReserveAttachmentBytes(ctx context.Context, userID, expense, size, limit int64) (int64, error) {
+	id, err := r.queries.ReserveAttachmentBytes(ctx, ReserveAttachmentBytesParams{
+		UserID:        userID,
+		SizeBytes:     size,
+		ExceptExpense: expense,
+		MaxBytes:      limit,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		used, err := r.AttachmentBytes(ctx, userID, expense)
+		if err != nil {
+			return 0, err
+		}
+		reserved, err := r.queries.GetReservedAttachmentBytes(ctx, userID)
+		if err != nil {
+			return 0, fmt.Errorf("get reserved attachment bytes: %w", err)
+		}
+		return 0, &core.QuotaError{Quota: core.QuotaAttachmentBytes, Limit: limit, Used: used + reserved, Requested: size}
+	}
+	if err != nil {
+		return 0, fmt.Errorf("reserve attachment bytes: %w", err)
+	}
+	return id, nil
+}
+
+// ReleaseAttachmentBytes drops a reservation of ReserveAttachmentBytes.
+func (r *SQLiteRepository) ReleaseAttachmentBytes(ctx context.Context, id int64) error {
+	if err := r.queries.ReleaseAttachmentBytes(ctx, id); err != nil {
+		return fmt.Errorf("release attachment bytes: %w", err)
+	}
+	return nil
+}
+
+// AttachmentBytesByUser returns the bytes of the receipts each member
+// uploaded.
+func (r *SQLiteRepository) AttachmentBytesByUser(ctx context.Context) (map[int64]int64, error) {
+	rows, err := r.reader(ctx).ListAttachmentBytesByUser(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list attachment bytes: %w", err)
+	}
+	used := make(map[int64]int64, len(rows))
+	for _, row := range rows {
+		used[row.UploadedBy.Int64] = row.TotalBytes
+	}
+	return used, nil
+}
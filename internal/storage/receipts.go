@@ -0,0 +1,146 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"spese/internal/core"
+)
+
+// SetExpenseReceipt records the receipt of an expense, whose files are
+// already in blob storage. The files of a receipt it replaces are queued
+// for deletion.
+func (r *SQLiteRepository) SetExpenseReceipt(ctx context.Context, rec core.Receipt) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	txQueries := r.withTx(tx)
+
+	if _, err := txQueries.GetExpense(ctx, rec.ExpenseID); err != nil {
+		return fmt.Errorf("get expense: %w", err)
+	}
+	if err := queueReceiptBlobs(ctx, txQueries, rec.ExpenseID); err != nil {
+		return err
+	}
+	if err := txQueries.UpsertReceipt(ctx, UpsertReceiptParams{
+		ExpenseID:    rec.ExpenseID,
+		BlobKey:      rec.Key,
+		ThumbnailKey: rec.ThumbnailKey,
+		ContentType:  rec.ContentType,
+		Filename:     rec.Filename,
+		SizeBytes:    rec.Size,
+		UploadedBy:   sql.NullInt64{Int64: rec.UploadedBy, Valid: rec.UploadedBy != 0},
+	}); err != nil {
+		return fmt.Errorf("set receipt: %w", err)
+	}
+	return tx.Commit()
+}
+
+// ExpenseReceipt returns the receipt of an expense; the error wraps
+// sql.ErrNoRows when it has none.
+func (r *SQLiteRepository) ExpenseReceipt(ctx context.Context, expenseID int64) (core.Receipt, error) {
+	row, err := r.reader(ctx).GetReceipt(ctx, expenseID)
+	if err != nil {
+		return core.Receipt{}, fmt.Errorf("get receipt: %w", err)
+	}
+	return receiptFromRow(row), nil
+}
+
+// DeleteExpenseReceipt removes the receipt of an expense and queues its
+// files for deletion.
+func (r *SQLiteRepository) DeleteExpenseReceipt(ctx context.Context, expenseID int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	txQueries := r.withTx(tx)
+
+	if err := queueReceiptBlobs(ctx, txQueries, expenseID); err != nil {
+		return err
+	}
+	n, err := txQueries.DeleteReceipt(ctx, expenseID)
+	if err != nil {
+		return fmt.Errorf("delete receipt: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("receipt not found: %d: %w", expenseID, sql.ErrNoRows)
+	}
+	return tx.Commit()
+}
+
+// ReceiptsBetween returns the receipts of the expenses dated from from
+// (included) to to (excluded), by expense ID.
+func (r *SQLiteRepository) ReceiptsBetween(ctx context.Context, from, to time.Time) (map[int64]core.Receipt, error) {
+	rows, err := r.reader(ctx).ListReceiptsBetween(ctx, ListReceiptsBetweenParams{
+		FromDate: from.Format("2006-01-02"),
+		ToDate:   to.Format("2006-01-02"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list receipts: %w", err)
+	}
+	receipts := make(map[int64]core.Receipt, len(rows))
+	for _, row := range rows {
+		receipts[row.ExpenseID] = receiptFromRow(row)
+	}
+	return receipts, nil
+}
+
+// PendingBlobDeletions returns up to limit blob keys waiting to be deleted
+// from storage, oldest first.
+func (r *SQLiteRepository) PendingBlobDeletions(ctx context.Context, limit int) ([]string, error) {
+	keys, err := r.queries.ListBlobDeletions(ctx, int64(limit))
+	if err != nil {
+		return nil, fmt.Errorf("list blob deletions: %w", err)
+	}
+	return keys, nil
+}
+
+// BlobDeleted forgets a blob once it is gone from storage.
+func (r *SQLiteRepository) BlobDeleted(ctx context.Context, key string) error {
+	if err := r.queries.DeleteBlobDeletion(ctx, key); err != nil {
+		return fmt.Errorf("forget blob deletion: %w", err)
+	}
+	return nil
+}
+
+// queueReceiptBlobs queues the files of the current receipt of an expense,
+// if any, for deletion.
+func queueReceiptBlobs(ctx context.Context, q *Queries, expenseID int64) error {
+	old, err := q.GetReceipt(ctx, expenseID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("get receipt: %w", err)
+	}
+	for _, key := range []string{old.BlobKey, old.ThumbnailKey} {
+		if key == "" {
+			continue
+		}
+		if err := q.QueueBlobDeletion(ctx, key); err != nil {
+			return fmt.Errorf("queue blob deletion: %w", err)
+		}
+	}
+	return nil
+}
+
+func receiptFromRow(row Receipt) core.Receipt {
+	return core.Receipt{
+		ExpenseID:    row.ExpenseID,
+		Key:          row.BlobKey,
+		ThumbnailKey: row.ThumbnailKey,
+		ContentType:  row.ContentType,
+		Filename:     row.Filename,
+		Size:         row.SizeBytes,
+		CreatedAt:    row.CreatedAt,
+		UploadedBy:   row.UploadedBy.Int64,
+	}
+}
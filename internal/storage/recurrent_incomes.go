@@ -0,0 +1,214 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"spese/internal/core"
+)
+
+// CreateRecurrentIncome stores a new recurrent income configuration and
+// returns its ID.
+func (r *SQLiteRepository) CreateRecurrentIncome(ctx context.Context, ri core.RecurrentIncome) (int64, error) {
+	row, err := r.queries.CreateRecurrentIncome(ctx, CreateRecurrentIncomeParams{
+		StartDate:      ri.StartDate.Time,
+		EndDate:        nullableDate(ri.EndDate),
+		RepetitionType: string(ri.Every),
+		RepeatInterval: int64(ri.Step()),
+		DayOfMonth:     int64(ri.DayOfMonth),
+		Description:    ri.Description,
+		AmountCents:    ri.Amount.Cents,
+		Category:       ri.Category,
+		Subcategory:    strings.TrimSpace(ri.Subcategory),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("create recurrent income: %w", err)
+	}
+	if err := recordAudit(ctx, r.queries, AuditRecurrentIncome, row.ID, nil, recurrentIncomeSnapshot(row)); err != nil {
+		return 0, err
+	}
+
+	slog.InfoContext(ctx, "Recurrent income created",
+		"id", row.ID,
+		"description", row.Description,
+		"repetition", row.RepetitionType,
+		"amount_cents", row.AmountCents)
+	return row.ID, nil
+}
+
+// GetRecurrentIncomes returns all active recurrent incomes
+func (r *SQLiteRepository) GetRecurrentIncomes(ctx context.Context) ([]core.RecurrentIncome, error) {
+	rows, err := r.reader(ctx).GetRecurrentIncomes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get recurrent incomes: %w", err)
+	}
+	incomes := make([]core.RecurrentIncome, len(rows))
+	for i, row := range rows {
+		incomes[i] = recurrentIncomeFromRow(row)
+	}
+	return incomes, nil
+}
+
+// GetRecurrentIncomeByID returns an active recurrent income
+func (r *SQLiteRepository) GetRecurrentIncomeByID(ctx context.Context, id int64) (core.RecurrentIncome, error) {
+	row, err := r.reader(ctx).GetRecurrentIncomeByID(ctx, id)
+	if err == nil && !row.IsActive {
+		err = sql.ErrNoRows
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return core.RecurrentIncome{}, fmt.Errorf("recurrent income not found: %d: %w", id, err)
+	}
+	if err != nil {
+		return core.RecurrentIncome{}, fmt.Errorf("get recurrent income: %w", err)
+	}
+	return recurrentIncomeFromRow(row), nil
+}
+
+// UpdateRecurrentIncome updates a recurrent income. ri.Version must be the
+// version the change is based on; if the stored row has moved on,
+// core.ErrVersionConflict is returned and nothing is written.
+func (r *SQLiteRepository) UpdateRecurrentIncome(ctx context.Context, id int64, ri core.RecurrentIncome) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	txQueries := r.withTx(tx)
+	old, err := txQueries.GetRecurrentIncomeByID(ctx, id)
+	if err == nil && !old.IsActive {
+		err = sql.ErrNoRows
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("recurrent income not found: %d: %w", id, err)
+	}
+	if err != nil {
+		return fmt.Errorf("get recurrent income: %w", err)
+	}
+
+	rows, err := txQueries.UpdateRecurrentIncome(ctx, UpdateRecurrentIncomeParams{
+		StartDate:      ri.StartDate.Time,
+		EndDate:        nullableDate(ri.EndDate),
+		RepetitionType: string(ri.Every),
+		RepeatInterval: int64(ri.Step()),
+		DayOfMonth:     int64(ri.DayOfMonth),
+		Description:    ri.Description,
+		AmountCents:    ri.Amount.Cents,
+		Category:       ri.Category,
+		Subcategory:    strings.TrimSpace(ri.Subcategory),
+		ID:             id,
+		Version:        ri.Version,
+	})
+	if err != nil {
+		return fmt.Errorf("update recurrent income: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("update recurrent income %d: %w", id, core.ErrVersionConflict)
+	}
+	if err := auditRecurrentIncome(ctx, txQueries, id, &old, false); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+
+	slog.InfoContext(ctx, "Recurrent income updated", "id", id)
+	return nil
+}
+
+// DeleteRecurrentIncome soft-deletes a recurrent income by marking it as
+// inactive; the incomes it already created are kept.
+func (r *SQLiteRepository) DeleteRecurrentIncome(ctx context.Context, id int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	txQueries := r.withTx(tx)
+	old, err := txQueries.GetRecurrentIncomeByID(ctx, id)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("get recurrent income: %w", err)
+	}
+	rows, err := txQueries.DeactivateRecurrentIncome(ctx, id)
+	if err != nil {
+		return fmt.Errorf("deactivate recurrent income: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("recurrent income not found: %d: %w", id, sql.ErrNoRows)
+	}
+	if err := auditRecurrentIncome(ctx, txQueries, id, &old, true); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+
+	slog.InfoContext(ctx, "Recurrent income deactivated", "id", id)
+	return nil
+}
+
+// GetActiveRecurrentIncomesForProcessing returns the recurrent incomes
+// that may need processing on now
+func (r *SQLiteRepository) GetActiveRecurrentIncomesForProcessing(ctx context.Context, now time.Time) ([]core.RecurrentIncome, error) {
+	rows, err := r.reader(ctx).GetActiveRecurrentIncomesForProcessing(ctx, GetActiveRecurrentIncomesForProcessingParams{
+		StartDate: now,
+		EndDate:   now,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get active recurrent incomes for processing: %w", err)
+	}
+	incomes := make([]core.RecurrentIncome, len(rows))
+	for i, row := range rows {
+		incomes[i] = recurrentIncomeFromRow(row)
+	}
+	return incomes, nil
+}
+
+// UpdateRecurrentIncomeLastExecution records the date of the last income
+// created for a recurrent income
+func (r *SQLiteRepository) UpdateRecurrentIncomeLastExecution(ctx context.Context, id int64, executionDate time.Time) error {
+	if err := r.queries.UpdateRecurrentIncomeLastExecution(ctx, UpdateRecurrentIncomeLastExecutionParams{
+		LastExecutionDate: executionDate,
+		ID:                id,
+	}); err != nil {
+		return fmt.Errorf("update recurrent income last execution: %w", err)
+	}
+	return nil
+}
+
+// recurrentIncomeFromRow maps a recurrent income row to the domain type
+func recurrentIncomeFromRow(row RecurrentIncome) core.RecurrentIncome {
+	ri := core.RecurrentIncome{
+		ID:          row.ID,
+		StartDate:   core.Date{Time: row.StartDate},
+		Every:       core.RepetitionTypes(row.RepetitionType),
+		Interval:    int(row.RepeatInterval),
+		DayOfMonth:  int(row.DayOfMonth),
+		Description: row.Description,
+		Amount:      core.Money{Cents: row.AmountCents},
+		Category:    row.Category,
+		Subcategory: row.Subcategory,
+		Version:     row.Version,
+	}
+	if end, ok := row.EndDate.(time.Time); ok && !end.IsZero() {
+		ri.EndDate = core.Date{Time: end}
+	}
+	if last, ok := row.LastExecutionDate.(time.Time); ok {
+		ri.LastRun = core.Date{Time: last}
+	}
+	return ri
+}
+
+// nullableDate returns d for a nullable DATE column, nil when it is zero
+func nullableDate(d core.Date) interface{} {
+	if d.IsZero() {
+		return nil
+	}
+	return d.Time
+}
@@ -0,0 +1,219 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"spese/internal/core"
+)
+
+// SetExcludeReimbursed makes ReadMonthOverview subtract reimbursed amounts
+// from the month total and from each category.
+func (r *SQLiteRepository) SetExcludeReimbursed(exclude bool) {
+	r.excludeReimbursed = exclude
+}
+
+// LinkReimbursement links an income to the given expenses. Each expense is
+// covered for its unreimbursed part, until the income's unlinked amount
+// runs out. Returns core.ErrNothingToReimburse if no amount was linked.
+func (r *SQLiteRepository) LinkReimbursement(ctx context.Context, incomeID int64, expenseIDs []int64) ([]core.ReimbursementLink, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	txQueries := r.withTx(tx)
+
+	income, err := txQueries.GetIncome(ctx, incomeID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("income not found: %d", incomeID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get income: %w", err)
+	}
+	linked, err := txQueries.GetIncomeReimbursedTotal(ctx, incomeID)
+	if err != nil {
+		return nil, fmt.Errorf("get income reimbursed total: %w", err)
+	}
+	available := income.AmountCents - linked
+
+	var links []core.ReimbursementLink
+	for _, expenseID := range expenseIDs {
+		if available <= 0 {
+			break
+		}
+		expense, err := txQueries.GetExpense(ctx, expenseID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("expense not found: %d", expenseID)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("get expense: %w", err)
+		}
+		if expense.Status == string(core.StatusPending) {
+			// Card holds can change amount: link them once settled
+			continue
+		}
+		covered, err := txQueries.GetExpenseReimbursedTotal(ctx, expenseID)
+		if err != nil {
+			return nil, fmt.Errorf("get expense reimbursed total: %w", err)
+		}
+		amount := min(expense.AmountCents-covered, available)
+		if amount <= 0 {
+			continue
+		}
+		if err := txQueries.UpsertExpenseReimbursement(ctx, UpsertExpenseReimbursementParams{
+			ExpenseID:   expenseID,
+			IncomeID:    incomeID,
+			AmountCents: amount,
+		}); err != nil {
+			return nil, fmt.Errorf("link reimbursement: %w", err)
+		}
+		available -= amount
+		links = append(links, core.ReimbursementLink{
+			ExpenseID: expenseID,
+			Expense:   expenseFromRow(expense),
+			Amount:    core.Money{Cents: amount},
+		})
+	}
+	if len(links) == 0 {
+		return nil, core.ErrNothingToReimburse
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+	return links, nil
+}
+
+// UnlinkReimbursement removes the link between an income and an expense.
+func (r *SQLiteRepository) UnlinkReimbursement(ctx context.Context, incomeID, expenseID int64) error {
+	n, err := r.queries.DeleteExpenseReimbursement(ctx, DeleteExpenseReimbursementParams{
+		ExpenseID: expenseID,
+		IncomeID:  incomeID,
+	})
+	if err != nil {
+		return fmt.Errorf("unlink reimbursement: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("reimbursement not found: income %d, expense %d", incomeID, expenseID)
+	}
+	return nil
+}
+
+// ListReimbursements returns every income with linked expenses, newest
+// income first.
+func (r *SQLiteRepository) ListReimbursements(ctx context.Context) ([]core.Reimbursement, error) {
+	rows, err := r.reader(ctx).ListExpenseReimbursements(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list reimbursements: %w", err)
+	}
+
+	var reimbursements []core.Reimbursement
+	for _, row := range rows {
+		n := len(reimbursements)
+		if n == 0 || reimbursements[n-1].IncomeID != row.IncomeID {
+			income, err := r.reader(ctx).GetIncome(ctx, row.IncomeID)
+			if err != nil {
+				return nil, fmt.Errorf("get income %d: %w", row.IncomeID, err)
+			}
+			reimbursements = append(reimbursements, core.Reimbursement{
+				IncomeID: row.IncomeID,
+				Income:   incomeFromRow(income),
+			})
+			n++
+		}
+		current := &reimbursements[n-1]
+		current.Links = append(current.Links, core.ReimbursementLink{
+			ExpenseID: row.ExpenseID,
+			Expense: core.Expense{
+				Date:        core.Date{Time: row.ExpenseDate},
+				Description: row.ExpenseDescription,
+				Amount:      core.Money{Cents: row.ExpenseAmountCents},
+				Primary:     row.PrimaryCategory,
+				Secondary:   row.SecondaryCategory,
+			},
+			Amount: core.Money{Cents: row.AmountCents},
+		})
+		current.Linked.Cents += row.AmountCents
+	}
+
+	sort.SliceStable(reimbursements, func(i, j int) bool {
+		a, b := reimbursements[i], reimbursements[j]
+		if !a.Income.Date.Equal(b.Income.Date.Time) {
+			return a.Income.Date.After(b.Income.Date.Time)
+		}
+		return a.IncomeID > b.IncomeID
+	})
+	return reimbursements, nil
+}
+
+// ListReimbursementCandidates returns the expenses and incomes dated within
+// the range, newest first, for choosing what to link.
+func (r *SQLiteRepository) ListReimbursementCandidates(ctx context.Context, startDate, endDate time.Time) ([]ExpenseWithID, []IncomeWithID, error) {
+	expenses, err := r.ListExpensesWithIDByDateRange(ctx, startDate, endDate)
+	if err != nil {
+		return nil, nil, err
+	}
+	dbIncomes, err := r.reader(ctx).ListIncomesByDateRange(ctx, ListIncomesByDateRangeParams{
+		Date:   startDate,
+		Date_2: endDate,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("list incomes by date range: %w", err)
+	}
+
+	incomes := make([]IncomeWithID, len(dbIncomes))
+	for i, inc := range dbIncomes {
+		incomes[i] = IncomeWithID{
+			ID:     strconv.FormatInt(inc.ID, 10),
+			Income: incomeFromRow(inc),
+		}
+	}
+	return expenses, incomes, nil
+}
+
+// excludeReimbursedAmounts subtracts the month's reimbursed amounts from the
+// overview.
+func (r *SQLiteRepository) excludeReimbursedAmounts(ctx context.Context, overview *core.MonthOverview) error {
+	sums, err := r.reader(ctx).GetReimbursedCategorySums(ctx, GetReimbursedCategorySumsParams{
+		PRINTF:   int64(overview.Year),
+		PRINTF_2: int64(overview.Month),
+	})
+	if err != nil {
+		return fmt.Errorf("get reimbursed category sums: %w", err)
+	}
+	amounts := make(map[string]int64, len(sums))
+	for _, s := range sums {
+		amounts[s.PrimaryCategory] = s.TotalAmount
+	}
+	subtractCategoryAmounts(overview, amounts)
+	return nil
+}
+
+// subtractCategoryAmounts removes amounts, by primary category, from the
+// overview total and categories, dropping categories left at zero.
+func subtractCategoryAmounts(overview *core.MonthOverview, amounts map[string]int64) {
+	if len(amounts) == 0 {
+		return
+	}
+	for _, cents := range amounts {
+		overview.Total.Cents -= cents
+	}
+	categories := overview.ByCategory[:0]
+	for _, c := range overview.ByCategory {
+		c.Amount.Cents -= amounts[c.Name]
+		if c.Amount.Cents > 0 {
+			categories = append(categories, c)
+		}
+	}
+	sort.SliceStable(categories, func(i, j int) bool {
+		return categories[i].Amount.Cents > categories[j].Amount.Cents
+	})
+	overview.ByCategory = categories
+}
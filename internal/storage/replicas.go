@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+type replicaReadsKey struct{}
+
+// AllowReplicaReads marks ctx as tolerating slightly stale data, letting
+// reads go to a read replica. Contexts without the mark (background jobs,
+// requests from a session that just wrote) always read the primary.
+func AllowReplicaReads(ctx context.Context) context.Context {
+	return context.WithValue(ctx, replicaReadsKey{}, true)
+}
+
+func replicaReadsAllowed(ctx context.Context) bool {
+	allowed, _ := ctx.Value(replicaReadsKey{}).(bool)
+	return allowed
+}
+
+// readReplica is a read-only copy of the database, such as a LiteFS
+// replica mount or a file restored by Litestream.
+type readReplica struct {
+	path    string
+	db      *sql.DB
+	queries *Queries
+}
+
+// AddReadReplica opens the SQLite file at path read-only and adds it to the
+// replicas serving reads marked with AllowReplicaReads. It must be called
+// before the repository is used.
+func (r *SQLiteRepository) AddReadReplica(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("read replica: %w", err)
+	}
+
+	db, err := sql.Open("sqlite", "file:"+path+"?mode=ro")
+	if err != nil {
+		return fmt.Errorf("open read replica %s: %w", path, err)
+	}
+	db.SetMaxOpenConns(20)
+	db.SetMaxIdleConns(10)
+	db.SetConnMaxLifetime(time.Hour)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return fmt.Errorf("ping read replica %s: %w", path, err)
+	}
+
+	r.replicas = append(r.replicas, readReplica{path: path, db: db, queries: New(r.observed(db))})
+	return nil
+}
+
+// reader returns the queries to use for a read: the next replica in turn
+// when ctx allows it, the primary's read-only connection otherwise.
+func (r *SQLiteRepository) reader(ctx context.Context) *Queries {
+	if len(r.replicas) == 0 || !replicaReadsAllowed(ctx) {
+		return r.readQueries
+	}
+	n := atomic.AddUint64(&r.nextReplica, 1)
+	return r.replicas[n%uint64(len(r.replicas))].queries
+}
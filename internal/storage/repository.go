@@ -7,7 +7,10 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"spese/internal/core"
@@ -20,6 +23,23 @@ type SQLiteRepository struct {
 	readDB      *sql.DB  // Read-only connection for queries
 	queries     *Queries // Queries using main connection
 	readQueries *Queries // Queries using read-only connection
+
+	// Optional read replicas, used round-robin for AllowReplicaReads contexts
+	replicas    []readReplica
+	nextReplica uint64
+
+	// Subtract reimbursed amounts and card holds from month overviews
+	excludeReimbursed bool
+	excludePending    bool
+
+	// Count line items under their own categories in month overviews
+	lineItemCategories bool
+
+	// Receives the duration of every query; see ObserveQueries
+	observer atomic.Pointer[QueryObserver]
+
+	// The last run of Maintain; see DatabaseStats
+	lastMaintenance atomic.Pointer[MaintenanceResult]
 }
 
 func NewSQLiteRepository(dbPath string) (*SQLiteRepository, error) {
@@ -27,8 +47,10 @@ func NewSQLiteRepository(dbPath string) (*SQLiteRepository, error) {
 		return nil, fmt.Errorf("create db directory: %w", err)
 	}
 
-	// Configure SQLite connection with optimizations for reduced locking
-	dsn := fmt.Sprintf("%s?_journal_mode=WAL&_synchronous=NORMAL&_cache_size=1000&_timeout=5000&_busy_timeout=5000", dbPath)
+	// Configure SQLite connection with optimizations for reduced locking.
+	// The driver applies only the _pragma parameters: busy_timeout makes
+	// concurrent writers, such as the job workers, wait for the lock.
+	dsn := fmt.Sprintf("%s?_journal_mode=WAL&_synchronous=NORMAL&_cache_size=1000&_timeout=5000&_busy_timeout=5000&_pragma=busy_timeout(5000)", dbPath)
 	db, err := sql.Open("sqlite", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("open sqlite database: %w", err)
@@ -45,7 +67,7 @@ func NewSQLiteRepository(dbPath string) (*SQLiteRepository, error) {
 	}
 
 	// Create read-only connection with similar optimizations
-	readDSN := fmt.Sprintf("%s?_journal_mode=WAL&_synchronous=NORMAL&_cache_size=1000&_timeout=5000&_busy_timeout=5000&mode=ro", dbPath)
+	readDSN := fmt.Sprintf("%s?_journal_mode=WAL&_synchronous=NORMAL&_cache_size=1000&_timeout=5000&_busy_timeout=5000&_pragma=busy_timeout(5000)&mode=ro", dbPath)
 	readDB, err := sql.Open("sqlite", readDSN)
 	if err != nil {
 		db.Close()
@@ -71,11 +93,11 @@ func NewSQLiteRepository(dbPath string) (*SQLiteRepository, error) {
 	}
 
 	repo := &SQLiteRepository{
-		db:          db,
-		readDB:      readDB,
-		queries:     New(db),
-		readQueries: New(readDB),
+		db:     db,
+		readDB: readDB,
 	}
+	repo.queries = New(repo.observed(db))
+	repo.readQueries = New(repo.observed(readDB))
 
 	return repo, nil
 }
@@ -95,6 +117,12 @@ func (r *SQLiteRepository) Close() error {
 		}
 	}
 
+	for _, replica := range r.replicas {
+		if err := replica.db.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close read replica %s: %w", replica.path, err))
+		}
+	}
+
 	if len(errs) > 0 {
 		return fmt.Errorf("close repository: %v", errs)
 	}
@@ -102,22 +130,53 @@ func (r *SQLiteRepository) Close() error {
 	return nil
 }
 
+// EnableWAL switches the database to write-ahead logging, which Litestream
+// needs to replicate it. The mode is stored in the database file, so it
+// applies to every connection.
+func (r *SQLiteRepository) EnableWAL(ctx context.Context) error {
+	var mode string
+	if err := r.db.QueryRowContext(ctx, "PRAGMA journal_mode=WAL").Scan(&mode); err != nil {
+		return fmt.Errorf("enable WAL: %w", err)
+	}
+	if mode != "wal" {
+		return fmt.Errorf("enable WAL: journal mode is %q", mode)
+	}
+	return nil
+}
+
 // Append implements sheets.ExpenseWriter
 func (r *SQLiteRepository) Append(ctx context.Context, e core.Expense) (string, error) {
 	// Format date as string for SQLite
 	dateStr := fmt.Sprintf("%04d-%02d-%02d", e.Date.Year(), e.Date.Month(), e.Date.Day())
 
+	if err := checkMonthOpen(ctx, r.queries, e.Date.Time); err != nil {
+		return "", err
+	}
+
 	expense, err := r.queries.CreateExpense(ctx, CreateExpenseParams{
 		Date:              dateStr,
 		Description:       e.Description,
 		AmountCents:       e.Amount.Cents,
 		PrimaryCategory:   e.Primary,
 		SecondaryCategory: e.Secondary,
+		Status:            expenseStatus(e),
+		PaidBy:            paidBy(e),
+		AccountID:         accountID(e.AccountID),
 	})
 	if err != nil {
 		return "", fmt.Errorf("create expense: %w", err)
 	}
 
+	if err := recordExpenseVersion(ctx, r.queries, expense.ID, expense.Version, diffExpenses(nil, expense)); err != nil {
+		return "", err
+	}
+	if err := auditExpense(ctx, r.queries, expense.ID, nil, &expense); err != nil {
+		return "", err
+	}
+	if err := setExpenseTags(ctx, r.queries, expense.ID, e.Tags); err != nil {
+		return "", err
+	}
+
 	slog.InfoContext(ctx, "Expense saved to SQLite",
 		"id", expense.ID,
 		"description", expense.Description,
@@ -130,13 +189,13 @@ func (r *SQLiteRepository) Append(ctx context.Context, e core.Expense) (string,
 // List implements sheets.TaxonomyReader
 func (r *SQLiteRepository) List(ctx context.Context) ([]string, []string, error) {
 	// Get primary categories from database using read-only connection
-	primaryCategories, err := r.readQueries.GetPrimaryCategories(ctx)
+	primaryCategories, err := r.reader(ctx).GetPrimaryCategories(ctx)
 	if err != nil {
 		return nil, nil, fmt.Errorf("get primary categories: %w", err)
 	}
 
 	// Get all secondary categories from database using read-only connection
-	secondaryCategories, err := r.readQueries.GetSecondaryCategories(ctx)
+	secondaryCategories, err := r.reader(ctx).GetSecondaryCategories(ctx)
 	if err != nil {
 		return nil, nil, fmt.Errorf("get secondary categories: %w", err)
 	}
@@ -146,7 +205,7 @@ func (r *SQLiteRepository) List(ctx context.Context) ([]string, []string, error)
 
 // GetSecondariesByPrimary returns secondary categories for a given primary category
 func (r *SQLiteRepository) GetSecondariesByPrimary(ctx context.Context, primaryCategory string) ([]string, error) {
-	secondaryCategories, err := r.readQueries.GetSecondariesByPrimary(ctx, primaryCategory)
+	secondaryCategories, err := r.reader(ctx).GetSecondariesByPrimary(ctx, primaryCategory)
 	if err != nil {
 		return nil, fmt.Errorf("get secondary categories for primary %s: %w", primaryCategory, err)
 	}
@@ -162,7 +221,7 @@ type CategoryWithSubs struct {
 
 // GetAllCategoriesWithSubs returns all primary categories with their subcategories ordered by usage
 func (r *SQLiteRepository) GetAllCategoriesWithSubs(ctx context.Context) ([]CategoryWithSubs, error) {
-	rows, err := r.readQueries.GetCategoriesOrderedByUsage(ctx)
+	rows, err := r.reader(ctx).GetCategoriesOrderedByUsage(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("get categories ordered by usage: %w", err)
 	}
@@ -199,9 +258,9 @@ func (r *SQLiteRepository) ReadMonthOverview(ctx context.Context, year int, mont
 	}
 
 	// Get total for the month using read-only connection
-	total, err := r.readQueries.GetMonthTotal(ctx, GetMonthTotalParams{
-		PRINTF:   int64(year),
-		PRINTF_2: int64(month),
+	total, err := r.reader(ctx).GetMonthTotal(ctx, GetMonthTotalParams{
+		Year:  int64(year),
+		Month: int64(month),
 	})
 	if err != nil {
 		return overview, fmt.Errorf("get month total: %w", err)
@@ -210,9 +269,9 @@ func (r *SQLiteRepository) ReadMonthOverview(ctx context.Context, year int, mont
 	overview.Total = core.Money{Cents: total}
 
 	// Get category sums using read-only connection
-	categorySums, err := r.readQueries.GetCategorySums(ctx, GetCategorySumsParams{
-		PRINTF:   int64(year),
-		PRINTF_2: int64(month),
+	categorySums, err := r.reader(ctx).GetCategorySums(ctx, GetCategorySumsParams{
+		Year:  int64(year),
+		Month: int64(month),
 	})
 	if err != nil {
 		return overview, fmt.Errorf("get category sums: %w", err)
@@ -225,12 +284,32 @@ func (r *SQLiteRepository) ReadMonthOverview(ctx context.Context, year int, mont
 		})
 	}
 
+	if r.excludeReimbursed {
+		if err := r.excludeReimbursedAmounts(ctx, &overview); err != nil {
+			return overview, err
+		}
+	}
+	if r.excludePending {
+		if err := r.excludePendingAmounts(ctx, &overview); err != nil {
+			return overview, err
+		}
+	}
+	split, err := r.applySplitCategories(ctx, &overview)
+	if err != nil {
+		return overview, err
+	}
+	if r.lineItemCategories {
+		if err := r.applyLineItemCategories(ctx, &overview, split); err != nil {
+			return overview, err
+		}
+	}
+
 	return overview, nil
 }
 
 // ListExpenses implements sheets.ExpenseLister
 func (r *SQLiteRepository) ListExpenses(ctx context.Context, year int, month int) ([]core.Expense, error) {
-	dbExpenses, err := r.readQueries.GetExpensesByMonth(ctx, GetExpensesByMonthParams{
+	dbExpenses, err := r.reader(ctx).GetExpensesByMonth(ctx, GetExpensesByMonthParams{
 		PRINTF:   int64(year),
 		PRINTF_2: int64(month),
 	})
@@ -240,13 +319,7 @@ func (r *SQLiteRepository) ListExpenses(ctx context.Context, year int, month int
 
 	expenses := make([]core.Expense, len(dbExpenses))
 	for i, e := range dbExpenses {
-		expenses[i] = core.Expense{
-			Date:        core.Date{Time: e.Date},
-			Description: e.Description,
-			Amount:      core.Money{Cents: e.AmountCents},
-			Primary:     e.PrimaryCategory,
-			Secondary:   e.SecondaryCategory,
-		}
+		expenses[i] = expenseFromRow(e)
 	}
 
 	return expenses, nil
@@ -254,7 +327,7 @@ func (r *SQLiteRepository) ListExpenses(ctx context.Context, year int, month int
 
 // ListExpensesWithID returns expenses with their IDs for the specified year and month
 func (r *SQLiteRepository) ListExpensesWithID(ctx context.Context, year int, month int) ([]ExpenseWithID, error) {
-	dbExpenses, err := r.readQueries.GetExpensesByMonth(ctx, GetExpensesByMonthParams{
+	dbExpenses, err := r.reader(ctx).GetExpensesByMonth(ctx, GetExpensesByMonthParams{
 		PRINTF:   int64(year),
 		PRINTF_2: int64(month),
 	})
@@ -265,14 +338,8 @@ func (r *SQLiteRepository) ListExpensesWithID(ctx context.Context, year int, mon
 	expensesWithID := make([]ExpenseWithID, len(dbExpenses))
 	for i, e := range dbExpenses {
 		expensesWithID[i] = ExpenseWithID{
-			ID: strconv.FormatInt(e.ID, 10),
-			Expense: core.Expense{
-				Date:        core.Date{Time: e.Date},
-				Description: e.Description,
-				Amount:      core.Money{Cents: e.AmountCents},
-				Primary:     e.PrimaryCategory,
-				Secondary:   e.SecondaryCategory,
-			},
+			ID:      strconv.FormatInt(e.ID, 10),
+			Expense: expenseFromRow(e),
 		}
 	}
 
@@ -291,14 +358,45 @@ func (r *SQLiteRepository) ListExpensesByDateRange(ctx context.Context, startDat
 
 	expenses := make([]core.Expense, len(dbExpenses))
 	for i, e := range dbExpenses {
-		expenses[i] = core.Expense{
-			Date:        core.Date{Time: e.Date},
-			Description: e.Description,
-			Amount:      core.Money{Cents: e.AmountCents},
-			Primary:     e.PrimaryCategory,
-			Secondary:   e.SecondaryCategory,
+		expenses[i] = expenseFromRow(e)
+	}
+
+	return expenses, nil
+}
+
+// ListExpensesWithIDByDateRange returns the expenses within a date range
+// with their IDs, newest first
+func (r *SQLiteRepository) ListExpensesWithIDByDateRange(ctx context.Context, startDate, endDate time.Time) ([]ExpenseWithID, error) {
+	dbExpenses, err := r.reader(ctx).ListExpensesByDateRange(ctx, ListExpensesByDateRangeParams{
+		Date:   startDate,
+		Date_2: endDate,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list expenses by date range: %w", err)
+	}
+
+	expenses := make([]ExpenseWithID, len(dbExpenses))
+	for i, e := range dbExpenses {
+		expenses[i] = ExpenseWithID{
+			ID:        strconv.FormatInt(e.ID, 10),
+			Expense:   expenseFromRow(e),
+			CreatedAt: e.CreatedAt.Time,
 		}
 	}
+	return expenses, nil
+}
+
+// ListAllExpenses returns every expense, oldest first
+func (r *SQLiteRepository) ListAllExpenses(ctx context.Context) ([]core.Expense, error) {
+	dbExpenses, err := r.reader(ctx).ListAllExpenses(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list all expenses: %w", err)
+	}
+
+	expenses := make([]core.Expense, len(dbExpenses))
+	for i, e := range dbExpenses {
+		expenses[i] = expenseFromRow(e)
+	}
 
 	return expenses, nil
 }
@@ -346,7 +444,7 @@ func (r *SQLiteRepository) MarkSyncError(ctx context.Context, id int64) error {
 
 // GetExpense retrieves a single expense by ID
 func (r *SQLiteRepository) GetExpense(ctx context.Context, id int64) (*Expense, error) {
-	expense, err := r.readQueries.GetExpense(ctx, id)
+	expense, err := r.reader(ctx).GetExpense(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("get expense by id: %w", err)
 	}
@@ -355,15 +453,160 @@ func (r *SQLiteRepository) GetExpense(ctx context.Context, id int64) (*Expense,
 
 // HardDeleteExpense permanently deletes an expense (hard delete)
 func (r *SQLiteRepository) HardDeleteExpense(ctx context.Context, id int64) error {
+	expense, getErr := r.queries.GetExpense(ctx, id)
+	if getErr == nil {
+		if err := checkMonthOpen(ctx, r.queries, expense.Date); err != nil {
+			return err
+		}
+	}
+
 	err := r.queries.HardDeleteExpense(ctx, id)
 	if err != nil {
 		return fmt.Errorf("hard delete expense: %w", err)
 	}
+	if getErr == nil {
+		if err := auditExpense(ctx, r.queries, id, &expense, nil); err != nil {
+			return err
+		}
+	}
 
 	slog.InfoContext(ctx, "Expense hard deleted", "id", id)
 	return nil
 }
 
+// UpdateExpenseAmount overwrites the amount of an expense, bumps its version
+// and records the change in the expense history
+func (r *SQLiteRepository) UpdateExpenseAmount(ctx context.Context, id int64, cents int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	txQueries := r.withTx(tx)
+
+	old, err := txQueries.GetExpense(ctx, id)
+	if err != nil {
+		return fmt.Errorf("get expense: %w", err)
+	}
+	if err := checkMonthOpen(ctx, txQueries, old.Date); err != nil {
+		return err
+	}
+	if err := checkSplitAmount(ctx, txQueries, old, cents); err != nil {
+		return err
+	}
+
+	if err := txQueries.UpdateExpenseAmount(ctx, UpdateExpenseAmountParams{
+		AmountCents: cents,
+		ID:          id,
+	}); err != nil {
+		return fmt.Errorf("update expense amount: %w", err)
+	}
+
+	updated := old
+	updated.AmountCents = cents
+	if err := recordExpenseVersion(ctx, txQueries, id, old.Version+1, diffExpenses(&old, updated)); err != nil {
+		return err
+	}
+	if err := auditExpense(ctx, txQueries, id, &old, &updated); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+
+	slog.InfoContext(ctx, "Expense amount updated", "id", id, "amount_cents", cents)
+	return nil
+}
+
+// UpdateExpense replaces the date, description, amount and categories of
+// an expense and records the change in the expense history. version must be
+// the version the change is based on; if the stored row has moved on,
+// core.ErrVersionConflict is returned and nothing is written. An expense
+// already in Google Sheets is queued to be deleted there and written again;
+// one still waiting for its first sync goes out with the new values.
+func (r *SQLiteRepository) UpdateExpense(ctx context.Context, id int64, e core.Expense, version int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	txQueries := r.withTx(tx)
+
+	old, err := txQueries.GetExpense(ctx, id)
+	if err != nil {
+		return fmt.Errorf("get expense: %w", err)
+	}
+	// Moving an expense needs both its old and its new month open
+	if err := checkMonthOpen(ctx, txQueries, old.Date); err != nil {
+		return err
+	}
+	if err := checkMonthOpen(ctx, txQueries, e.Date.Time); err != nil {
+		return err
+	}
+	if err := checkSplitAmount(ctx, txQueries, old, e.Amount.Cents); err != nil {
+		return err
+	}
+	oldRows, err := sheetRows(ctx, txQueries, old)
+	if err != nil {
+		return err
+	}
+
+	rows, err := txQueries.UpdateExpense(ctx, UpdateExpenseParams{
+		Date:              fmt.Sprintf("%04d-%02d-%02d", e.Date.Year(), e.Date.Month(), e.Date.Day()),
+		Description:       e.Description,
+		AmountCents:       e.Amount.Cents,
+		PrimaryCategory:   e.Primary,
+		SecondaryCategory: e.Secondary,
+		PaidBy:            paidBy(e),
+		AccountID:         accountID(e.AccountID),
+		ID:                id,
+		Version:           version,
+	})
+	if err != nil {
+		return fmt.Errorf("update expense: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("update expense %d: %w", id, core.ErrVersionConflict)
+	}
+
+	updated, err := txQueries.GetExpense(ctx, id)
+	if err != nil {
+		return fmt.Errorf("get expense: %w", err)
+	}
+	if err := recordExpenseVersion(ctx, txQueries, id, updated.Version, diffExpenses(&old, updated)); err != nil {
+		return err
+	}
+	if err := auditExpense(ctx, txQueries, id, &old, &updated); err != nil {
+		return err
+	}
+	if err := setExpenseTags(ctx, txQueries, id, e.Tags); err != nil {
+		return err
+	}
+
+	if old.SyncStatus.String == "synced" {
+		if err := enqueueSheetDelete(ctx, txQueries, id, oldRows); err != nil {
+			return err
+		}
+		if _, err := txQueries.EnqueueSync(ctx, EnqueueSyncParams{
+			ExpenseID:      id,
+			ExpenseVersion: updated.Version,
+			TraceParent:    traceParent(ctx),
+		}); err != nil {
+			return fmt.Errorf("enqueue sync: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+
+	slog.InfoContext(ctx, "Expense updated", "id", id, "version", updated.Version)
+	return nil
+}
+
 // ExpenseWithID represents an expense with its database ID for sync operations
 type ExpenseWithID struct {
 	ID        string
@@ -399,105 +642,6 @@ func (r *SQLiteRepository) syncPrimaryCategories(ctx context.Context, categories
 
 // syncSecondaryCategories syncs secondary categories with mapping to primaries
 func (r *SQLiteRepository) syncSecondaryCategories(ctx context.Context, categories []string) error {
-	// Mapping of secondary categories to their primary categories
-	// This maps categories from Google Sheets to our hierarchical structure
-	categoryMapping := map[string]string{
-		// Casa
-		"Mutuo":              "Casa",
-		"Spese condominiali": "Casa",
-		"Internet":           "Casa",
-		"Mobili":             "Casa",
-		"Assicurazioni":      "Casa",
-		"Pulizia":            "Casa",
-		"Elettricità":        "Casa",
-		"Telefono":           "Casa",
-		"Bollette":           "Casa", // Legacy mapping
-		"Affitto":            "Casa", // Legacy mapping
-
-		// Salute
-		"Assicurazione sanitaria": "Salute",
-		"Dottori":                 "Salute",
-		"Medicine":                "Salute",
-		"Personale":               "Salute",
-		"Sport":                   "Salute",
-		"Medico":                  "Salute", // Legacy mapping
-		"Farmacia":                "Salute", // Legacy mapping
-
-		// Spesa
-		"Everli":                   "Spesa",
-		"Altre spese (non Everli)": "Spesa",
-		"Supermercato":             "Spesa", // Legacy mapping
-
-		// Trasporti
-		"Trasporto locale":   "Trasporti",
-		"Car sharing":        "Trasporti",
-		"Spese automobile":   "Trasporti",
-		"Servizi taxi":       "Trasporti",
-		"Benzina":            "Trasporti", // Legacy mapping
-		"Trasporto Pubblico": "Trasporti", // Legacy mapping
-
-		// Fuori (come fuori a cena...)
-		"Ristoranti":  "Fuori (come fuori a cena...)",
-		"Bar":         "Fuori (come fuori a cena...)",
-		"Cibo a casa": "Fuori (come fuori a cena...)",
-		"Ristorante":  "Fuori (come fuori a cena...)", // Legacy mapping
-
-		// Viaggi
-		"Vacanza":        "Viaggi",
-		"Vacanza estiva": "Viaggi",
-
-		// Bimbi
-		"Cura bimbi":  "Bimbi",
-		"Roba bimbi":  "Bimbi",
-		"Corsi bimbi": "Bimbi",
-		"Baby sitter": "Bimbi",
-
-		// Vestiti
-		"Vestiti e":     "Vestiti",
-		"Vestiti g":     "Vestiti",
-		"Vestiti bimbi": "Vestiti",
-		"Abbigliamento": "Vestiti", // Legacy mapping
-		"Scarpe":        "Vestiti", // Legacy mapping
-
-		// Divertimento
-		"Tech":                   "Divertimento",
-		"Libri e":                "Divertimento",
-		"Divertimento e":         "Divertimento",
-		"Learning e":             "Divertimento",
-		"Giochi e":               "Divertimento",
-		"Giochi g":               "Divertimento",
-		"Learning g":             "Divertimento",
-		"Divertimento familiare": "Divertimento",
-		"Altri divertimenti":     "Divertimento",
-		"Cinema":                 "Divertimento", // Legacy mapping
-		"Hobby":                  "Divertimento", // Legacy mapping
-
-		// Regali
-		"Altri regali": "Regali",
-		"Compleanno":   "Regali", // Legacy mapping
-		"Natale":       "Regali", // Legacy mapping
-
-		// Tasse e Percentuali
-		"Brokers":                   "Tasse e Percentuali",
-		"Banche":                    "Tasse e Percentuali",
-		"Consulting":                "Tasse e Percentuali",
-		"Altre tasse e percentuali": "Tasse e Percentuali",
-		"IRPEF":                     "Tasse e Percentuali", // Legacy mapping
-		"IMU":                       "Tasse e Percentuali", // Legacy mapping
-
-		// Altre spese
-		"Tasse statali": "Altre spese",
-		"2DM":           "Altre spese",
-		"Unknown":       "Altre spese",
-		"Varie":         "Altre spese", // Legacy mapping
-		"Azioni":        "Altre spese", // Legacy mapping
-		"Crypto":        "Altre spese", // Legacy mapping
-
-		// Lavoro
-		"Lavoro g": "Lavoro",
-		"Lavoro e": "Lavoro",
-	}
-
 	slog.InfoContext(ctx, "Syncing secondary categories from Google Sheets", "count", len(categories))
 
 	// For each category from Google Sheets, map it to the appropriate primary
@@ -507,11 +651,14 @@ func (r *SQLiteRepository) syncSecondaryCategories(ctx context.Context, categori
 			continue
 		}
 
-		primaryCategory, exists := categoryMapping[category]
+		primaryCategory, exists, err := r.MappedPrimary(ctx, category)
+		if err != nil {
+			return err
+		}
 		if !exists {
 			slog.WarnContext(ctx, "Unknown secondary category from Google Sheets",
 				"category", category,
-				"action", "skipping")
+				"action", "skipping, map it through /api/v1/category-mappings")
 			continue
 		}
 
@@ -553,13 +700,13 @@ func (r *SQLiteRepository) syncSecondaryCategories(ctx context.Context, categori
 // GetCategoryCount returns the total number of categories in the database
 func (r *SQLiteRepository) GetCategoryCount(ctx context.Context) (int64, error) {
 	// Count primary categories using read-only connection
-	primaries, err := r.readQueries.GetPrimaryCategories(ctx)
+	primaries, err := r.reader(ctx).GetPrimaryCategories(ctx)
 	if err != nil {
 		return 0, fmt.Errorf("get primary categories: %w", err)
 	}
 
 	// Count secondary categories using read-only connection
-	secondaries, err := r.readQueries.GetSecondaryCategories(ctx)
+	secondaries, err := r.reader(ctx).GetSecondaryCategories(ctx)
 	if err != nil {
 		return 0, fmt.Errorf("get secondary categories: %w", err)
 	}
@@ -610,10 +757,15 @@ func (r *SQLiteRepository) CreateRecurrentExpense(ctx context.Context, re core.R
 		AmountCents:       re.Amount.Cents,
 		PrimaryCategory:   re.Primary,
 		SecondaryCategory: re.Secondary,
+		RepeatInterval:    int64(re.Step()),
+		DayOfMonth:        int64(re.DayOfMonth),
 	})
 	if err != nil {
 		return 0, fmt.Errorf("create recurrent expense: %w", err)
 	}
+	if err := recordAudit(ctx, r.queries, AuditRecurrentExpense, expense.ID, nil, recurrentExpenseSnapshot(expense)); err != nil {
+		return 0, err
+	}
 
 	slog.InfoContext(ctx, "Recurrent expense created",
 		"id", expense.ID,
@@ -626,27 +778,14 @@ func (r *SQLiteRepository) CreateRecurrentExpense(ctx context.Context, re core.R
 
 // GetRecurrentExpenses returns all active recurrent expenses
 func (r *SQLiteRepository) GetRecurrentExpenses(ctx context.Context) ([]core.RecurrentExpenses, error) {
-	dbExpenses, err := r.readQueries.GetRecurrentExpenses(ctx)
+	dbExpenses, err := r.reader(ctx).GetRecurrentExpenses(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("get recurrent expenses: %w", err)
 	}
 
 	expenses := make([]core.RecurrentExpenses, len(dbExpenses))
 	for i, e := range dbExpenses {
-		expenses[i] = core.RecurrentExpenses{
-			ID:          e.ID,
-			StartDate:   core.Date{Time: e.StartDate},
-			Every:       core.RepetitionTypes(e.RepetitionType),
-			Description: e.Description,
-			Amount:      core.Money{Cents: e.AmountCents},
-			Primary:     e.PrimaryCategory,
-			Secondary:   e.SecondaryCategory,
-		}
-
-		// Handle nullable EndDate
-		if endTime, ok := e.EndDate.(time.Time); ok {
-			expenses[i].EndDate = core.Date{Time: endTime}
-		}
+		expenses[i] = recurrentFromRow(e)
 	}
 
 	return expenses, nil
@@ -654,40 +793,43 @@ func (r *SQLiteRepository) GetRecurrentExpenses(ctx context.Context) ([]core.Rec
 
 // GetRecurrentExpenseByID returns a single recurrent expense by ID
 func (r *SQLiteRepository) GetRecurrentExpenseByID(ctx context.Context, id int64) (*core.RecurrentExpenses, error) {
-	dbExpense, err := r.readQueries.GetRecurrentExpenseByID(ctx, id)
+	dbExpense, err := r.reader(ctx).GetRecurrentExpenseByID(ctx, id)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("recurrent expense not found: %d", id)
+			return nil, fmt.Errorf("recurrent expense not found: %d: %w", id, err)
 		}
 		return nil, fmt.Errorf("get recurrent expense: %w", err)
 	}
 
-	expense := &core.RecurrentExpenses{
-		ID:          dbExpense.ID,
-		StartDate:   core.Date{Time: dbExpense.StartDate},
-		Every:       core.RepetitionTypes(dbExpense.RepetitionType),
-		Description: dbExpense.Description,
-		Amount:      core.Money{Cents: dbExpense.AmountCents},
-		Primary:     dbExpense.PrimaryCategory,
-		Secondary:   dbExpense.SecondaryCategory,
-	}
-
-	// Handle nullable EndDate
-	if endTime, ok := dbExpense.EndDate.(time.Time); ok {
-		expense.EndDate = core.Date{Time: endTime}
-	}
-
-	return expense, nil
+	expense := recurrentFromRow(dbExpense)
+	return &expense, nil
 }
 
-// UpdateRecurrentExpense updates an existing recurrent expense
+// UpdateRecurrentExpense updates an existing recurrent expense.
+// re.Version must be the version the change is based on; if the stored row
+// has moved on, core.ErrVersionConflict is returned and nothing is written.
 func (r *SQLiteRepository) UpdateRecurrentExpense(ctx context.Context, id int64, re core.RecurrentExpenses) error {
 	var endDate interface{}
 	if !re.EndDate.IsZero() {
 		endDate = re.EndDate.Time
 	}
 
-	err := r.queries.UpdateRecurrentExpense(ctx, UpdateRecurrentExpenseParams{
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	txQueries := r.withTx(tx)
+	old, err := txQueries.GetRecurrentExpenseByID(ctx, id)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("recurrent expense not found: %d", id)
+	}
+	if err != nil {
+		return fmt.Errorf("get recurrent expense: %w", err)
+	}
+
+	rows, err := txQueries.UpdateRecurrentExpense(ctx, UpdateRecurrentExpenseParams{
 		ID:                id,
 		StartDate:         re.StartDate.Time,
 		EndDate:           endDate,
@@ -696,10 +838,22 @@ func (r *SQLiteRepository) UpdateRecurrentExpense(ctx context.Context, id int64,
 		AmountCents:       re.Amount.Cents,
 		PrimaryCategory:   re.Primary,
 		SecondaryCategory: re.Secondary,
+		RepeatInterval:    int64(re.Step()),
+		DayOfMonth:        int64(re.DayOfMonth),
+		Version:           re.Version,
 	})
 	if err != nil {
 		return fmt.Errorf("update recurrent expense: %w", err)
 	}
+	if rows == 0 {
+		return fmt.Errorf("update recurrent expense %d: %w", id, core.ErrVersionConflict)
+	}
+	if err := auditRecurrentExpense(ctx, txQueries, id, &old, false); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
 
 	slog.InfoContext(ctx, "Recurrent expense updated", "id", id)
 	return nil
@@ -707,18 +861,146 @@ func (r *SQLiteRepository) UpdateRecurrentExpense(ctx context.Context, id int64,
 
 // DeleteRecurrentExpense soft-deletes a recurrent expense by marking it as inactive
 func (r *SQLiteRepository) DeleteRecurrentExpense(ctx context.Context, id int64) error {
-	err := r.queries.DeactivateRecurrentExpense(ctx, id)
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	txQueries := r.withTx(tx)
+	old, getErr := txQueries.GetRecurrentExpenseByID(ctx, id)
+	if err := txQueries.DeactivateRecurrentExpense(ctx, id); err != nil {
 		return fmt.Errorf("deactivate recurrent expense: %w", err)
 	}
+	// Deleting twice, or an unknown ID, changes nothing
+	if getErr == nil && old.IsActive {
+		if err := auditRecurrentExpense(ctx, txQueries, id, &old, true); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
 
 	slog.InfoContext(ctx, "Recurrent expense deactivated", "id", id)
 	return nil
 }
 
+// PauseRecurrentExpense stops a recurrent expense from creating expenses
+// until it is resumed, keeping its configuration.
+func (r *SQLiteRepository) PauseRecurrentExpense(ctx context.Context, id int64) error {
+	return r.setRecurrentPaused(ctx, id, func(re core.RecurrentExpenses) (bool, core.Date) {
+		return true, re.SkipUntil
+	})
+}
+
+// ResumeRecurrentExpense lets a paused recurrent expense create expenses
+// again. The occurrences that fell before now are skipped rather than
+// created all at once.
+func (r *SQLiteRepository) ResumeRecurrentExpense(ctx context.Context, id int64, now time.Time) error {
+	yesterday := core.NewDate(now.Year(), int(now.Month()), now.Day()-1)
+	return r.setRecurrentPaused(ctx, id, func(re core.RecurrentExpenses) (bool, core.Date) {
+		if !re.Paused || re.SkipUntil.After(yesterday.Time) {
+			return false, re.SkipUntil
+		}
+		return false, yesterday
+	})
+}
+
+func (r *SQLiteRepository) setRecurrentPaused(ctx context.Context, id int64, next func(core.RecurrentExpenses) (bool, core.Date)) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	txQueries := r.withTx(tx)
+	row, err := activeRecurrent(ctx, txQueries, id)
+	if err != nil {
+		return err
+	}
+
+	paused, skipUntil := next(recurrentFromRow(row))
+	var skip interface{}
+	if !skipUntil.IsZero() {
+		skip = skipUntil.Time
+	}
+	if _, err := txQueries.SetRecurrentPaused(ctx, SetRecurrentPausedParams{
+		Paused:    paused,
+		SkipUntil: skip,
+		ID:        id,
+	}); err != nil {
+		return fmt.Errorf("set recurrent paused: %w", err)
+	}
+	if err := auditRecurrentExpense(ctx, txQueries, id, &row, false); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+
+	slog.InfoContext(ctx, "Recurrent expense paused state changed", "id", id, "paused", paused)
+	return nil
+}
+
+// activeRecurrent loads a recurrent expense that has not been deleted.
+func activeRecurrent(ctx context.Context, q *Queries, id int64) (RecurrentExpense, error) {
+	row, err := q.GetRecurrentExpenseByID(ctx, id)
+	if err == nil && !row.IsActive {
+		err = sql.ErrNoRows
+	}
+	if err == sql.ErrNoRows {
+		return row, fmt.Errorf("recurrent expense not found: %d: %w", id, err)
+	}
+	if err != nil {
+		return row, fmt.Errorf("get recurrent expense: %w", err)
+	}
+	return row, nil
+}
+
+// SkipNextRecurrentOccurrence skips the next occurrence of a recurrent
+// expense, so no expense is created for it, and returns its date.
+// core.ErrNoOccurrence is returned when no occurrence is left.
+func (r *SQLiteRepository) SkipNextRecurrentOccurrence(ctx context.Context, id int64) (core.Date, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return core.Date{}, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	txQueries := r.withTx(tx)
+	row, err := activeRecurrent(ctx, txQueries, id)
+	if err != nil {
+		return core.Date{}, err
+	}
+
+	re := recurrentFromRow(row)
+	// Skipping ahead of a resume is allowed
+	re.Paused = false
+	next, ok := re.NextDue()
+	if !ok {
+		return core.Date{}, fmt.Errorf("skip recurrent expense %d: %w", id, core.ErrNoOccurrence)
+	}
+	if _, err := txQueries.SetRecurrentSkipUntil(ctx, SetRecurrentSkipUntilParams{
+		SkipUntil: next.Time,
+		ID:        id,
+	}); err != nil {
+		return core.Date{}, fmt.Errorf("set recurrent skip: %w", err)
+	}
+	if err := auditRecurrentExpense(ctx, txQueries, id, &row, false); err != nil {
+		return core.Date{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return core.Date{}, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	slog.InfoContext(ctx, "Recurrent occurrence skipped", "id", id, "date", next.Format("2006-01-02"))
+	return next, nil
+}
+
 // GetActiveRecurrentExpensesForProcessing returns all active recurring expenses that may need processing
 func (r *SQLiteRepository) GetActiveRecurrentExpensesForProcessing(ctx context.Context, now time.Time) ([]core.RecurrentExpenses, error) {
-	dbExpenses, err := r.readQueries.GetActiveRecurrentExpensesForProcessing(ctx, GetActiveRecurrentExpensesForProcessingParams{
+	dbExpenses, err := r.reader(ctx).GetActiveRecurrentExpensesForProcessing(ctx, GetActiveRecurrentExpensesForProcessingParams{
 		StartDate: now,
 		EndDate:   now,
 	})
@@ -728,20 +1010,7 @@ func (r *SQLiteRepository) GetActiveRecurrentExpensesForProcessing(ctx context.C
 
 	expenses := make([]core.RecurrentExpenses, len(dbExpenses))
 	for i, e := range dbExpenses {
-		expenses[i] = core.RecurrentExpenses{
-			ID:          e.ID,
-			StartDate:   core.Date{Time: e.StartDate},
-			Every:       core.RepetitionTypes(e.RepetitionType),
-			Description: e.Description,
-			Amount:      core.Money{Cents: e.AmountCents},
-			Primary:     e.PrimaryCategory,
-			Secondary:   e.SecondaryCategory,
-		}
-
-		// Parse EndDate if present
-		if endDate, ok := e.EndDate.(time.Time); ok && !endDate.IsZero() {
-			expenses[i].EndDate = core.Date{Time: endDate}
-		}
+		expenses[i] = recurrentFromRow(e)
 	}
 
 	return expenses, nil
@@ -767,7 +1036,7 @@ func (r *SQLiteRepository) UpdateRecurrentLastExecution(ctx context.Context, id
 // GetRecurrentExpenseRaw returns the raw database record for a recurring expense
 // This includes the last_execution_date field which is used for processing logic
 func (r *SQLiteRepository) GetRecurrentExpenseRaw(ctx context.Context, id int64) (*RecurrentExpense, error) {
-	dbExpense, err := r.readQueries.GetRecurrentExpenseByID(ctx, id)
+	dbExpense, err := r.reader(ctx).GetRecurrentExpenseByID(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("get recurrent expense raw: %w", err)
 	}
@@ -775,22 +1044,88 @@ func (r *SQLiteRepository) GetRecurrentExpenseRaw(ctx context.Context, id int64)
 	return &dbExpense, nil
 }
 
+// recurrentFromRow maps a recurrent expense row, whose nullable dates come
+// back as interface{}, to the domain type.
+func recurrentFromRow(e RecurrentExpense) core.RecurrentExpenses {
+	re := core.RecurrentExpenses{
+		ID:          e.ID,
+		StartDate:   core.Date{Time: e.StartDate},
+		Every:       core.RepetitionTypes(e.RepetitionType),
+		Interval:    int(e.RepeatInterval),
+		DayOfMonth:  int(e.DayOfMonth),
+		Description: e.Description,
+		Amount:      core.Money{Cents: e.AmountCents},
+		Primary:     e.PrimaryCategory,
+		Secondary:   e.SecondaryCategory,
+		Version:     e.Version,
+		Paused:      e.Paused,
+	}
+	if endTime, ok := e.EndDate.(time.Time); ok && !endTime.IsZero() {
+		re.EndDate = core.Date{Time: endTime}
+	}
+	if skip, ok := e.SkipUntil.(time.Time); ok {
+		re.SkipUntil = core.Date{Time: skip}
+	}
+	if last, ok := e.LastExecutionDate.(time.Time); ok {
+		re.LastRun = core.Date{Time: last}
+	}
+	return re
+}
+
 // Income methods
 
+func expenseFromRow(e Expense) core.Expense {
+	return core.Expense{
+		Date:        core.Date{Time: e.Date},
+		Description: e.Description,
+		Amount:      core.Money{Cents: e.AmountCents},
+		Primary:     e.PrimaryCategory,
+		Secondary:   e.SecondaryCategory,
+		Status:      core.ExpenseStatus(e.Status),
+		PaidBy:      e.PaidBy.Int64,
+		AccountID:   e.AccountID.Int64,
+	}
+}
+
+func incomeFromRow(inc Income) core.Income {
+	return core.Income{
+		Date:        core.Date{Time: inc.Date},
+		Description: inc.Description,
+		Amount:      core.Money{Cents: inc.AmountCents},
+		Category:    inc.Category,
+		Subcategory: inc.Subcategory,
+		Tags:        core.ParseTags(inc.Tags),
+		AccountID:   inc.AccountID.Int64,
+	}
+}
+
 // AppendIncome implements income writer
 func (r *SQLiteRepository) AppendIncome(ctx context.Context, i core.Income) (string, error) {
 	// Format date as string for SQLite
 	dateStr := fmt.Sprintf("%04d-%02d-%02d", i.Date.Year(), i.Date.Month(), i.Date.Day())
 
+	if err := checkMonthOpen(ctx, r.queries, i.Date.Time); err != nil {
+		return "", err
+	}
+
 	income, err := r.queries.CreateIncome(ctx, CreateIncomeParams{
 		Date:        dateStr,
 		Description: i.Description,
 		AmountCents: i.Amount.Cents,
 		Category:    i.Category,
+		Subcategory: strings.TrimSpace(i.Subcategory),
+		Tags:        strings.Join(core.NormalizeTags(i.Tags), ","),
+		AccountID:   accountID(i.AccountID),
 	})
 	if err != nil {
 		return "", fmt.Errorf("create income: %w", err)
 	}
+	if err := setIncomeTags(ctx, r.queries, income.ID, income.Tags); err != nil {
+		return "", err
+	}
+	if err := auditIncome(ctx, r.queries, income.ID, nil, &income); err != nil {
+		return "", err
+	}
 
 	slog.InfoContext(ctx, "Income saved to SQLite",
 		"id", income.ID,
@@ -803,7 +1138,7 @@ func (r *SQLiteRepository) AppendIncome(ctx context.Context, i core.Income) (str
 
 // GetIncomeCategories returns all income categories
 func (r *SQLiteRepository) GetIncomeCategories(ctx context.Context) ([]string, error) {
-	categories, err := r.readQueries.GetIncomeCategories(ctx)
+	categories, err := r.reader(ctx).GetIncomeCategories(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("get income categories: %w", err)
 	}
@@ -818,9 +1153,9 @@ func (r *SQLiteRepository) ReadIncomeMonthOverview(ctx context.Context, year int
 	}
 
 	// Get total for the month using read-only connection
-	total, err := r.readQueries.GetIncomeMonthTotal(ctx, GetIncomeMonthTotalParams{
-		PRINTF:   int64(year),
-		PRINTF_2: int64(month),
+	total, err := r.reader(ctx).GetIncomeMonthTotal(ctx, GetIncomeMonthTotalParams{
+		Year:  int64(year),
+		Month: int64(month),
 	})
 	if err != nil {
 		return overview, fmt.Errorf("get income month total: %w", err)
@@ -829,9 +1164,9 @@ func (r *SQLiteRepository) ReadIncomeMonthOverview(ctx context.Context, year int
 	overview.Total = core.Money{Cents: total}
 
 	// Get category sums using read-only connection
-	categorySums, err := r.readQueries.GetIncomeCategorySums(ctx, GetIncomeCategorySumsParams{
-		PRINTF:   int64(year),
-		PRINTF_2: int64(month),
+	categorySums, err := r.reader(ctx).GetIncomeCategorySums(ctx, GetIncomeCategorySumsParams{
+		Year:  int64(year),
+		Month: int64(month),
 	})
 	if err != nil {
 		return overview, fmt.Errorf("get income category sums: %w", err)
@@ -844,12 +1179,70 @@ func (r *SQLiteRepository) ReadIncomeMonthOverview(ctx context.Context, year int
 		})
 	}
 
+	subcategorySums, err := r.reader(ctx).GetIncomeSubcategorySums(ctx, GetIncomeSubcategorySumsParams{
+		PRINTF:   int64(year),
+		PRINTF_2: int64(month),
+	})
+	if err != nil {
+		return overview, fmt.Errorf("get income subcategory sums: %w", err)
+	}
+
+	for _, ss := range subcategorySums {
+		overview.BySubcategory = append(overview.BySubcategory, core.SubcategoryAmount{
+			Category:    ss.Category,
+			Subcategory: ss.Subcategory,
+			Amount:      core.Money{Cents: ss.TotalAmount},
+		})
+	}
+
+	// Tags are a comma-separated column, so they are summed here
+	incomes, err := r.reader(ctx).GetIncomesByMonth(ctx, GetIncomesByMonthParams{
+		PRINTF:   int64(year),
+		PRINTF_2: int64(month),
+	})
+	if err != nil {
+		return overview, fmt.Errorf("get incomes by month: %w", err)
+	}
+	overview.ByTag = sumIncomesByTag(incomes)
+
 	return overview, nil
 }
 
+// sumIncomesByTag totals incomes per tag, largest first.
+func sumIncomesByTag(incomes []Income) []core.CategoryAmount {
+	totals := make(map[string]int64)
+	for _, inc := range incomes {
+		for _, tag := range core.ParseTags(inc.Tags) {
+			totals[tag] += inc.AmountCents
+		}
+	}
+
+	byTag := make([]core.CategoryAmount, 0, len(totals))
+	for tag, cents := range totals {
+		byTag = append(byTag, core.CategoryAmount{Name: tag, Amount: core.Money{Cents: cents}})
+	}
+	sort.Slice(byTag, func(i, j int) bool {
+		if byTag[i].Amount.Cents != byTag[j].Amount.Cents {
+			return byTag[i].Amount.Cents > byTag[j].Amount.Cents
+		}
+		return byTag[i].Name < byTag[j].Name
+	})
+	return byTag
+}
+
+// GetIncomeSubcategories returns the subcategories already used for an
+// income category, as suggestions for new incomes
+func (r *SQLiteRepository) GetIncomeSubcategories(ctx context.Context, category string) ([]string, error) {
+	subcategories, err := r.reader(ctx).GetIncomeSubcategories(ctx, category)
+	if err != nil {
+		return nil, fmt.Errorf("get income subcategories: %w", err)
+	}
+	return subcategories, nil
+}
+
 // ListIncomes returns all incomes for a given month
 func (r *SQLiteRepository) ListIncomes(ctx context.Context, year int, month int) ([]core.Income, error) {
-	dbIncomes, err := r.readQueries.GetIncomesByMonth(ctx, GetIncomesByMonthParams{
+	dbIncomes, err := r.reader(ctx).GetIncomesByMonth(ctx, GetIncomesByMonthParams{
 		PRINTF:   int64(year),
 		PRINTF_2: int64(month),
 	})
@@ -859,12 +1252,7 @@ func (r *SQLiteRepository) ListIncomes(ctx context.Context, year int, month int)
 
 	incomes := make([]core.Income, len(dbIncomes))
 	for i, inc := range dbIncomes {
-		incomes[i] = core.Income{
-			Date:        core.Date{Time: inc.Date},
-			Description: inc.Description,
-			Amount:      core.Money{Cents: inc.AmountCents},
-			Category:    inc.Category,
-		}
+		incomes[i] = incomeFromRow(inc)
 	}
 
 	return incomes, nil
@@ -878,7 +1266,7 @@ type IncomeWithID struct {
 
 // ListIncomesWithID returns incomes with their IDs for the specified year and month
 func (r *SQLiteRepository) ListIncomesWithID(ctx context.Context, year int, month int) ([]IncomeWithID, error) {
-	dbIncomes, err := r.readQueries.GetIncomesByMonth(ctx, GetIncomesByMonthParams{
+	dbIncomes, err := r.reader(ctx).GetIncomesByMonth(ctx, GetIncomesByMonthParams{
 		PRINTF:   int64(year),
 		PRINTF_2: int64(month),
 	})
@@ -889,25 +1277,56 @@ func (r *SQLiteRepository) ListIncomesWithID(ctx context.Context, year int, mont
 	incomesWithID := make([]IncomeWithID, len(dbIncomes))
 	for i, inc := range dbIncomes {
 		incomesWithID[i] = IncomeWithID{
-			ID: strconv.FormatInt(inc.ID, 10),
-			Income: core.Income{
-				Date:        core.Date{Time: inc.Date},
-				Description: inc.Description,
-				Amount:      core.Money{Cents: inc.AmountCents},
-				Category:    inc.Category,
-			},
+			ID:     strconv.FormatInt(inc.ID, 10),
+			Income: incomeFromRow(inc),
 		}
 	}
 
 	return incomesWithID, nil
 }
 
+// GetIncome retrieves a single income by ID
+func (r *SQLiteRepository) GetIncome(ctx context.Context, id int64) (core.Income, error) {
+	income, err := r.reader(ctx).GetIncome(ctx, id)
+	if err != nil {
+		return core.Income{}, fmt.Errorf("get income by id: %w", err)
+	}
+	return incomeFromRow(income), nil
+}
+
+// ListAllIncomes returns every income, oldest first
+func (r *SQLiteRepository) ListAllIncomes(ctx context.Context) ([]core.Income, error) {
+	dbIncomes, err := r.reader(ctx).ListAllIncomes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list all incomes: %w", err)
+	}
+
+	incomes := make([]core.Income, len(dbIncomes))
+	for i, inc := range dbIncomes {
+		incomes[i] = incomeFromRow(inc)
+	}
+
+	return incomes, nil
+}
+
 // HardDeleteIncome permanently deletes an income (hard delete)
 func (r *SQLiteRepository) HardDeleteIncome(ctx context.Context, id int64) error {
+	income, getErr := r.queries.GetIncome(ctx, id)
+	if getErr == nil {
+		if err := checkMonthOpen(ctx, r.queries, income.Date); err != nil {
+			return err
+		}
+	}
+
 	err := r.queries.HardDeleteIncome(ctx, id)
 	if err != nil {
 		return fmt.Errorf("hard delete income: %w", err)
 	}
+	if getErr == nil {
+		if err := auditIncome(ctx, r.queries, id, &income, nil); err != nil {
+			return err
+		}
+	}
 
 	slog.InfoContext(ctx, "Income hard deleted", "id", id)
 	return nil
@@ -915,9 +1334,13 @@ func (r *SQLiteRepository) HardDeleteIncome(ctx context.Context, id int64) error
 
 // Sync Queue methods
 
-// EnqueueSync adds a sync operation to the queue
-func (r *SQLiteRepository) EnqueueSync(ctx context.Context, expenseID int64) (SyncQueue, error) {
-	item, err := r.queries.EnqueueSync(ctx, expenseID)
+// EnqueueSync adds a sync operation to the queue for the given expense version
+func (r *SQLiteRepository) EnqueueSync(ctx context.Context, expenseID, version int64) (SyncQueue, error) {
+	item, err := r.queries.EnqueueSync(ctx, EnqueueSyncParams{
+		ExpenseID:      expenseID,
+		ExpenseVersion: version,
+		TraceParent:    traceParent(ctx),
+	})
 	if err != nil {
 		return SyncQueue{}, fmt.Errorf("enqueue sync: %w", err)
 	}
@@ -935,6 +1358,7 @@ func (r *SQLiteRepository) EnqueueDelete(ctx context.Context, expenseID int64, d
 		ExpenseAmountCents: amountCents,
 		ExpensePrimary:     primary,
 		ExpenseSecondary:   secondary,
+		TraceParent:        traceParent(ctx),
 	})
 	if err != nil {
 		return SyncQueue{}, fmt.Errorf("enqueue delete: %w", err)
@@ -996,16 +1420,75 @@ func (r *SQLiteRepository) IncrementSyncAttempt(ctx context.Context, id int64, e
 	return nil
 }
 
-// RetryFailedSyncs resets failed items back to pending for manual retry
-func (r *SQLiteRepository) RetryFailedSyncs(ctx context.Context) error {
-	err := r.queries.RetryFailedSyncs(ctx)
+// DeferSyncItem puts an item back to pending, to be picked up again after
+// delay, without counting an attempt
+func (r *SQLiteRepository) DeferSyncItem(ctx context.Context, id int64, errorMsg string, delay time.Duration) error {
+	err := r.queries.DeferSyncItem(ctx, DeferSyncItemParams{
+		LastError:    errorMsg,
+		DelaySeconds: int64(delay.Seconds()),
+		ID:           id,
+	})
 	if err != nil {
-		return fmt.Errorf("retry failed syncs: %w", err)
+		return fmt.Errorf("defer sync item: %w", err)
 	}
-	slog.InfoContext(ctx, "Reset failed sync items for retry")
 	return nil
 }
 
+// RetryFailedSyncs resets failed items back to pending for manual retry,
+// returning how many there were
+func (r *SQLiteRepository) RetryFailedSyncs(ctx context.Context) (int64, error) {
+	n, err := r.queries.RetryFailedSyncs(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("retry failed syncs: %w", err)
+	}
+	slog.InfoContext(ctx, "Reset failed sync items for retry", "count", n)
+	return n, nil
+}
+
+// RetryDeferredSyncs makes the pending items waiting for a retry ready for
+// the next poll, returning how many there were
+func (r *SQLiteRepository) RetryDeferredSyncs(ctx context.Context) (int64, error) {
+	n, err := r.queries.RetryDeferredSyncs(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("retry deferred syncs: %w", err)
+	}
+	return n, nil
+}
+
+// RetrySyncItem makes a failed or deferred queue item ready for the next
+// poll; it returns sql.ErrNoRows when no such item is waiting
+func (r *SQLiteRepository) RetrySyncItem(ctx context.Context, id int64) error {
+	n, err := r.queries.RetrySyncItem(ctx, id)
+	if err != nil {
+		return fmt.Errorf("retry sync item: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("retry sync item %d: %w", id, sql.ErrNoRows)
+	}
+	slog.InfoContext(ctx, "Reset sync item for retry", "id", id)
+	return nil
+}
+
+// OpenSyncItems returns up to limit queue items not written yet, failed
+// first
+func (r *SQLiteRepository) OpenSyncItems(ctx context.Context, limit int) ([]ListOpenSyncItemsRow, error) {
+	items, err := r.reader(ctx).ListOpenSyncItems(ctx, int64(limit))
+	if err != nil {
+		return nil, fmt.Errorf("list open sync items: %w", err)
+	}
+	return items, nil
+}
+
+// UnfinishedSyncAppends returns the append items still pending or in
+// processing, oldest first
+func (r *SQLiteRepository) UnfinishedSyncAppends(ctx context.Context) ([]SyncQueue, error) {
+	items, err := r.queries.ListUnfinishedSyncAppends(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list unfinished sync appends: %w", err)
+	}
+	return items, nil
+}
+
 // CleanupCompletedSyncs removes completed items older than the specified time
 func (r *SQLiteRepository) CleanupCompletedSyncs(ctx context.Context, olderThan time.Time) error {
 	err := r.queries.CleanupCompletedSyncs(ctx, olderThan)
@@ -1024,6 +1507,16 @@ func (r *SQLiteRepository) ResetStaleProcessing(ctx context.Context) error {
 	return nil
 }
 
+// SyncQueueLag returns how long the oldest item waiting for the sync has
+// been queued.
+func (r *SQLiteRepository) SyncQueueLag(ctx context.Context) (time.Duration, error) {
+	secs, err := r.reader(ctx).GetSyncQueueLag(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("get sync queue lag: %w", err)
+	}
+	return time.Duration(secs) * time.Second, nil
+}
+
 // GetSyncQueueStats returns counts by status for monitoring
 func (r *SQLiteRepository) GetSyncQueueStats(ctx context.Context) (*GetSyncQueueStatsRow, error) {
 	stats, err := r.queries.GetSyncQueueStats(ctx)
@@ -1035,46 +1528,119 @@ func (r *SQLiteRepository) GetSyncQueueStats(ctx context.Context) (*GetSyncQueue
 
 // AppendAndEnqueueSync creates an expense and enqueues it for sync in a single atomic transaction
 func (r *SQLiteRepository) AppendAndEnqueueSync(ctx context.Context, e core.Expense) (string, error) {
-	tx, err := r.db.BeginTx(ctx, nil)
+	refs, err := r.AppendBatchAndEnqueueSync(ctx, []core.Expense{e})
 	if err != nil {
-		return "", fmt.Errorf("begin transaction: %w", err)
+		return "", err
 	}
-	defer tx.Rollback()
-
-	txQueries := r.queries.WithTx(tx)
-
-	// Format date as string for SQLite
-	dateStr := fmt.Sprintf("%04d-%02d-%02d", e.Date.Year(), e.Date.Month(), e.Date.Day())
+	return refs[0], nil
+}
 
-	// Create expense
-	expense, err := txQueries.CreateExpense(ctx, CreateExpenseParams{
-		Date:              dateStr,
-		Description:       e.Description,
-		AmountCents:       e.Amount.Cents,
-		PrimaryCategory:   e.Primary,
-		SecondaryCategory: e.Secondary,
-	})
+// AppendBatchAndEnqueueSync saves expenses and enqueues them for sync in a
+// single transaction: either all of them are stored or none is. It returns
+// the IDs in input order.
+func (r *SQLiteRepository) AppendBatchAndEnqueueSync(ctx context.Context, expenses []core.Expense) ([]string, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		return "", fmt.Errorf("create expense: %w", err)
+		return nil, fmt.Errorf("begin transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	// Enqueue for sync
-	_, err = txQueries.EnqueueSync(ctx, expense.ID)
+	saved, _, err := appendBatch(ctx, r.withTx(tx), expenses)
 	if err != nil {
-		return "", fmt.Errorf("enqueue sync: %w", err)
+		return nil, err
 	}
 
 	if err := tx.Commit(); err != nil {
-		return "", fmt.Errorf("commit transaction: %w", err)
+		return nil, fmt.Errorf("commit transaction: %w", err)
 	}
+	return savedRefs(ctx, saved), nil
+}
 
-	slog.InfoContext(ctx, "Expense saved and enqueued for sync",
-		"id", expense.ID,
-		"description", expense.Description,
-		"amount_cents", expense.AmountCents,
-		"date", dateStr)
+// appendBatch saves expenses and enqueues them for sync within q's
+// transaction. It returns the stored expenses in input order and, for
+// each, whether it was created rather than settling a card hold.
+func appendBatch(ctx context.Context, txQueries *Queries, expenses []core.Expense) ([]Expense, []bool, error) {
+	saved := make([]Expense, 0, len(expenses))
+	created := make([]bool, 0, len(expenses))
+	for _, e := range expenses {
+		// Format date as string for SQLite
+		dateStr := fmt.Sprintf("%04d-%02d-%02d", e.Date.Year(), e.Date.Month(), e.Date.Day())
+
+		if err := checkMonthOpen(ctx, txQueries, e.Date.Time); err != nil {
+			return nil, nil, err
+		}
 
-	return strconv.FormatInt(expense.ID, 10), nil
+		// A settled transaction replaces the card hold imported before it
+		if !e.IsPending() {
+			settled, ok, err := settlePendingMatch(ctx, txQueries, e, dateStr)
+			if err != nil {
+				return nil, nil, err
+			}
+			if ok {
+				saved = append(saved, settled)
+				created = append(created, false)
+				continue
+			}
+		}
+
+		// Create expense
+		expense, err := txQueries.CreateExpense(ctx, CreateExpenseParams{
+			Date:              dateStr,
+			Description:       e.Description,
+			AmountCents:       e.Amount.Cents,
+			PrimaryCategory:   e.Primary,
+			SecondaryCategory: e.Secondary,
+			Status:            expenseStatus(e),
+			PaidBy:            paidBy(e),
+			AccountID:         accountID(e.AccountID),
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("create expense: %w", err)
+		}
+
+		if err := recordExpenseVersion(ctx, txQueries, expense.ID, expense.Version, diffExpenses(nil, expense)); err != nil {
+			return nil, nil, err
+		}
+		if err := auditExpense(ctx, txQueries, expense.ID, nil, &expense); err != nil {
+			return nil, nil, err
+		}
+		if err := setExpenseTags(ctx, txQueries, expense.ID, e.Tags); err != nil {
+			return nil, nil, err
+		}
+
+		// Enqueue for sync; card holds are synced once cleared and give a
+		// price only then
+		if !e.IsPending() {
+			if err := recordDescriptionPrice(ctx, txQueries, expense); err != nil {
+				return nil, nil, err
+			}
+			_, err = txQueries.EnqueueSync(ctx, EnqueueSyncParams{
+				ExpenseID:      expense.ID,
+				ExpenseVersion: expense.Version,
+				TraceParent:    traceParent(ctx),
+			})
+			if err != nil {
+				return nil, nil, fmt.Errorf("enqueue sync: %w", err)
+			}
+		}
+		saved = append(saved, expense)
+		created = append(created, true)
+	}
+	return saved, created, nil
+}
+
+// savedRefs logs the expenses a batch stored and returns their IDs
+func savedRefs(ctx context.Context, saved []Expense) []string {
+	refs := make([]string, len(saved))
+	for i, expense := range saved {
+		slog.InfoContext(ctx, "Expense saved and enqueued for sync",
+			"id", expense.ID,
+			"description", expense.Description,
+			"amount_cents", expense.AmountCents,
+			"date", expense.Date.Format("2006-01-02"))
+		refs[i] = strconv.FormatInt(expense.ID, 10)
+	}
+	return refs
 }
 
 // HardDeleteAndEnqueueSync deletes an expense and enqueues delete operation atomically
@@ -1085,31 +1651,37 @@ func (r *SQLiteRepository) HardDeleteAndEnqueueSync(ctx context.Context, id int6
 	}
 	defer tx.Rollback()
 
-	txQueries := r.queries.WithTx(tx)
+	txQueries := r.withTx(tx)
 
 	// Get expense data inside transaction to avoid TOCTOU race
 	expense, err := txQueries.GetExpense(ctx, id)
 	if err != nil {
 		return fmt.Errorf("get expense: %w", err)
 	}
+	if err := checkMonthOpen(ctx, txQueries, expense.Date); err != nil {
+		return err
+	}
+
+	// The parts of a split expense go with it
+	rows, err := sheetRows(ctx, txQueries, expense)
+	if err != nil {
+		return err
+	}
 
 	// Delete expense
 	if err := txQueries.HardDeleteExpense(ctx, id); err != nil {
 		return fmt.Errorf("delete expense: %w", err)
 	}
+	if err := auditExpense(ctx, txQueries, id, &expense, nil); err != nil {
+		return err
+	}
 
-	// Enqueue delete operation with expense data for Google Sheets sync
-	_, err = txQueries.EnqueueDelete(ctx, EnqueueDeleteParams{
-		ExpenseID:          id,
-		ExpenseDay:         int64(expense.Date.Day()),
-		ExpenseMonth:       int64(expense.Date.Month()),
-		ExpenseDescription: expense.Description,
-		ExpenseAmountCents: expense.AmountCents,
-		ExpensePrimary:     expense.PrimaryCategory,
-		ExpenseSecondary:   expense.SecondaryCategory,
-	})
-	if err != nil {
-		return fmt.Errorf("enqueue delete: %w", err)
+	// Enqueue delete operation with expense data for Google Sheets sync;
+	// card holds never reached the sheet
+	if expense.Status != string(core.StatusPending) {
+		if err := enqueueSheetDelete(ctx, txQueries, id, rows); err != nil {
+			return err
+		}
 	}
 
 	if err := tx.Commit(); err != nil {
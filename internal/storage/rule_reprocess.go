@@ -0,0 +1,159 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"spese/internal/core"
+)
+
+// RuleCandidate is a live expense whose categories a rule may change, with
+// the version it was read at.
+type RuleCandidate struct {
+	ID      int64
+	Version int64
+	Expense core.Expense
+}
+
+// CategoryChange moves an expense to new categories, provided it is still
+// at the version the change was proposed for.
+type CategoryChange struct {
+	ID        int64
+	Version   int64
+	Primary   string
+	Secondary string
+}
+
+// ListRuleCandidates returns the live expenses whose categories can be
+// changed in bulk, oldest first; only the uncategorized ones when
+// uncategorized is set. Split expenses, whose parts carry the categories,
+// and the expenses of closed months are left out.
+func (r *SQLiteRepository) ListRuleCandidates(ctx context.Context, uncategorized bool) ([]RuleCandidate, error) {
+	q := r.reader(ctx)
+	rows, err := q.ListAllExpenses(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list all expenses: %w", err)
+	}
+	splitIDs, err := q.ListSplitExpenseIDs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list split expenses: %w", err)
+	}
+	split := make(map[int64]bool, len(splitIDs))
+	for _, id := range splitIDs {
+		split[id] = true
+	}
+	reviews, err := q.ListMonthReviews(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list month reviews: %w", err)
+	}
+	closed := make(map[string]bool, len(reviews))
+	for _, review := range reviews {
+		closed[review.Period] = true
+	}
+
+	var candidates []RuleCandidate
+	for _, row := range rows {
+		e := expenseFromRow(row)
+		if split[row.ID] || closed[core.Period(row.Date)] || (uncategorized && !e.IsUncategorized()) {
+			continue
+		}
+		candidates = append(candidates, RuleCandidate{ID: row.ID, Version: row.Version, Expense: e})
+	}
+	return candidates, nil
+}
+
+// RecategorizeExpenses applies changes in one transaction and returns how
+// many were applied. An expense changed, split or deleted since the change
+// was proposed, or now in a closed month, is skipped. Each move is recorded
+// in the expense history, and expenses already in Google Sheets are queued
+// to be deleted there and written again, as for an edit.
+func (r *SQLiteRepository) RecategorizeExpenses(ctx context.Context, changes []CategoryChange) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	txQueries := r.withTx(tx)
+
+	applied := 0
+	for _, c := range changes {
+		ok, err := recategorizeChange(ctx, txQueries, c)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			applied++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit transaction: %w", err)
+	}
+	return applied, nil
+}
+
+func recategorizeChange(ctx context.Context, q *Queries, c CategoryChange) (bool, error) {
+	old, err := q.GetExpense(ctx, c.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("get expense: %w", err)
+	}
+	if old.Version != c.Version || (old.PrimaryCategory == c.Primary && old.SecondaryCategory == c.Secondary) {
+		return false, nil
+	}
+	if err := checkMonthOpen(ctx, q, old.Date); errors.Is(err, core.ErrMonthClosed) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	parts, err := splitParts(ctx, q, c.ID)
+	if err != nil {
+		return false, err
+	}
+	if len(parts) > 0 {
+		return false, nil
+	}
+
+	n, err := q.RecategorizeExpense(ctx, RecategorizeExpenseParams{
+		PrimaryCategory:   c.Primary,
+		SecondaryCategory: c.Secondary,
+		ID:                c.ID,
+		Version:           c.Version,
+	})
+	if err != nil {
+		return false, fmt.Errorf("recategorize expense: %w", err)
+	}
+	if n == 0 {
+		return false, nil
+	}
+
+	updated := old
+	updated.PrimaryCategory = c.Primary
+	updated.SecondaryCategory = c.Secondary
+	updated.Version = old.Version + 1
+	if err := recordExpenseVersion(ctx, q, c.ID, updated.Version, diffExpenses(&old, updated)); err != nil {
+		return false, err
+	}
+	if err := auditExpense(ctx, q, c.ID, &old, &updated); err != nil {
+		return false, err
+	}
+
+	if old.SyncStatus.String == "synced" {
+		if err := enqueueSheetDelete(ctx, q, c.ID, []core.Expense{expenseFromRow(old)}); err != nil {
+			return false, err
+		}
+		if _, err := q.EnqueueSync(ctx, EnqueueSyncParams{
+			ExpenseID:      c.ID,
+			ExpenseVersion: updated.Version,
+			TraceParent:    traceParent(ctx),
+		}); err != nil {
+			return false, fmt.Errorf("enqueue sync: %w", err)
+		}
+	}
+	return true, nil
+}
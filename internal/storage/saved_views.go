@@ -0,0 +1,120 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"spese/internal/core"
+)
+
+func savedViewFromRow(row SavedView) core.SavedView {
+	return core.SavedView{
+		ID:   row.ID,
+		Name: row.Name,
+		Filter: core.ExpenseFilter{
+			Primary:   row.PrimaryCategory,
+			Secondary: row.SecondaryCategory,
+			Min:       core.Money{Cents: row.MinCents},
+			Max:       core.Money{Cents: row.MaxCents},
+			Text:      row.Text,
+			Tag:       row.Tag,
+		},
+		Notify: row.Notify,
+	}
+}
+
+// CreateSavedView stores a new view and returns its ID. View names are
+// unique.
+func (r *SQLiteRepository) CreateSavedView(ctx context.Context, v core.SavedView) (int64, error) {
+	if err := v.Validate(); err != nil {
+		return 0, err
+	}
+	id, err := r.queries.CreateSavedView(ctx, CreateSavedViewParams{
+		Name:              strings.TrimSpace(v.Name),
+		PrimaryCategory:   v.Filter.Primary,
+		SecondaryCategory: v.Filter.Secondary,
+		MinCents:          v.Filter.Min.Cents,
+		MaxCents:          v.Filter.Max.Cents,
+		Text:              v.Filter.Text,
+		Tag:               v.Filter.Tag,
+		Notify:            v.Notify,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("create saved view: %w", err)
+	}
+	return id, nil
+}
+
+// ListSavedViews returns every view by name.
+func (r *SQLiteRepository) ListSavedViews(ctx context.Context) ([]core.SavedView, error) {
+	rows, err := r.reader(ctx).ListSavedViews(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list saved views: %w", err)
+	}
+	views := make([]core.SavedView, len(rows))
+	for i, row := range rows {
+		views[i] = savedViewFromRow(row)
+	}
+	return views, nil
+}
+
+// GetSavedView returns a view by ID.
+func (r *SQLiteRepository) GetSavedView(ctx context.Context, id int64) (core.SavedView, error) {
+	row, err := r.reader(ctx).GetSavedView(ctx, id)
+	if err != nil {
+		return core.SavedView{}, fmt.Errorf("get saved view %d: %w", id, err)
+	}
+	return savedViewFromRow(row), nil
+}
+
+// SetSavedViewNotify turns the alerts of a view on or off.
+func (r *SQLiteRepository) SetSavedViewNotify(ctx context.Context, id int64, notify bool) error {
+	n, err := r.queries.SetSavedViewNotify(ctx, SetSavedViewNotifyParams{Notify: notify, ID: id})
+	if err != nil {
+		return fmt.Errorf("set saved view notify: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("saved view not found: %d", id)
+	}
+	return nil
+}
+
+// DeleteSavedView removes a view.
+func (r *SQLiteRepository) DeleteSavedView(ctx context.Context, id int64) error {
+	n, err := r.queries.DeleteSavedView(ctx, id)
+	if err != nil {
+		return fmt.Errorf("delete saved view: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("saved view not found: %d", id)
+	}
+	return nil
+}
+
+// ListViewExpenses returns up to limit of the expenses a filter selects,
+// newest first.
+func (r *SQLiteRepository) ListViewExpenses(ctx context.Context, f core.ExpenseFilter, limit int) ([]ExpenseWithID, error) {
+	rows, err := r.reader(ctx).ListFilteredExpenses(ctx, ListFilteredExpensesParams{
+		PrimaryCategory:   f.Primary,
+		SecondaryCategory: f.Secondary,
+		MinCents:          f.Min.Cents,
+		MaxCents:          f.Max.Cents,
+		Text:              f.Text,
+		Tag:               f.Tag,
+		MaxRows:           int64(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list view expenses: %w", err)
+	}
+	expenses := make([]ExpenseWithID, len(rows))
+	for i, row := range rows {
+		expenses[i] = ExpenseWithID{
+			ID:        strconv.FormatInt(row.ID, 10),
+			Expense:   expenseFromRow(row),
+			CreatedAt: row.CreatedAt.Time,
+		}
+	}
+	return expenses, nil
+}
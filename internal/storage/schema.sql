@@ -8,18 +8,31 @@ CREATE TABLE expenses (
     version INTEGER NOT NULL DEFAULT 1,
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     synced_at DATETIME NULL,
-    sync_status TEXT DEFAULT 'pending' CHECK (sync_status IN ('pending', 'synced', 'error'))
+    sync_status TEXT DEFAULT 'pending' CHECK (sync_status IN ('pending', 'synced', 'error')),
+    uid TEXT NULL,
+    modified_at TEXT NULL,
+    status TEXT NOT NULL DEFAULT 'cleared' CHECK (status IN ('pending', 'cleared')),
+    paid_by INTEGER NULL,
+    deleted_at DATETIME NULL, -- set while the expense is in the trash
+    account_id INTEGER NULL
 );
 
 CREATE INDEX idx_expenses_date ON expenses(date);
+CREATE INDEX idx_expenses_pending ON expenses(date) WHERE status = 'pending';
+CREATE UNIQUE INDEX idx_expenses_uid ON expenses(uid);
 CREATE INDEX idx_expenses_sync_status ON expenses(sync_status);
 CREATE INDEX idx_expenses_created_at ON expenses(created_at);
+CREATE INDEX idx_expenses_paid_by ON expenses(paid_by, date) WHERE paid_by IS NOT NULL;
+CREATE INDEX idx_expenses_deleted_at ON expenses(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_expenses_live_date ON expenses(date, id) WHERE deleted_at IS NULL;
+CREATE INDEX idx_expenses_account ON expenses(account_id) WHERE account_id IS NOT NULL;
 
 -- Primary categories table
 CREATE TABLE primary_categories (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     name TEXT NOT NULL UNIQUE,
-    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    archived BOOLEAN NOT NULL DEFAULT 0
 );
 
 -- Secondary categories table with foreign key to primary
@@ -28,6 +41,7 @@ CREATE TABLE secondary_categories (
     name TEXT NOT NULL,
     primary_category_id INTEGER NOT NULL,
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    archived BOOLEAN NOT NULL DEFAULT 0,
     FOREIGN KEY (primary_category_id) REFERENCES primary_categories(id) ON DELETE CASCADE
 );
 
@@ -49,7 +63,12 @@ CREATE TABLE recurrent_expenses (
     is_active BOOLEAN NOT NULL DEFAULT 1,
     last_execution_date DATE NULL,
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    version INTEGER NOT NULL DEFAULT 1,
+    repeat_interval INTEGER NOT NULL DEFAULT 1 CHECK (repeat_interval BETWEEN 1 AND 99),
+    day_of_month INTEGER NOT NULL DEFAULT 0 CHECK (day_of_month BETWEEN 0 AND 31),
+    paused BOOLEAN NOT NULL DEFAULT 0,
+    skip_until DATE NULL
 );
 
 -- Create indexes for recurrent expenses
@@ -58,6 +77,28 @@ CREATE INDEX idx_recurrent_expenses_start_date ON recurrent_expenses(start_date)
 CREATE INDEX idx_recurrent_expenses_repetition ON recurrent_expenses(repetition_type);
 CREATE INDEX idx_recurrent_expenses_last_execution ON recurrent_expenses(last_execution_date);
 
+-- Recurrent incomes table
+CREATE TABLE recurrent_incomes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    start_date DATE NOT NULL,
+    end_date DATE NULL,
+    repetition_type TEXT NOT NULL CHECK (repetition_type IN ('daily', 'weekly', 'monthly', 'yearly')),
+    repeat_interval INTEGER NOT NULL DEFAULT 1 CHECK (repeat_interval BETWEEN 1 AND 99),
+    day_of_month INTEGER NOT NULL DEFAULT 0 CHECK (day_of_month BETWEEN 0 AND 31),
+    description TEXT NOT NULL,
+    amount_cents INTEGER NOT NULL CHECK (amount_cents > 0),
+    category TEXT NOT NULL,
+    subcategory TEXT NOT NULL DEFAULT '',
+    is_active BOOLEAN NOT NULL DEFAULT 1,
+    last_execution_date DATE NULL,
+    version INTEGER NOT NULL DEFAULT 1,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create indexes for recurrent incomes
+CREATE INDEX idx_recurrent_incomes_active ON recurrent_incomes(is_active);
+
 -- Income categories table
 CREATE TABLE income_categories (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -75,13 +116,24 @@ CREATE TABLE incomes (
     version INTEGER NOT NULL DEFAULT 1,
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     synced_at DATETIME NULL,
-    sync_status TEXT DEFAULT 'pending' CHECK (sync_status IN ('pending', 'synced', 'error'))
+    sync_status TEXT DEFAULT 'pending' CHECK (sync_status IN ('pending', 'synced', 'error')),
+    uid TEXT NULL,
+    modified_at TEXT NULL,
+    subcategory TEXT NOT NULL DEFAULT '',
+    tags TEXT NOT NULL DEFAULT '', -- comma-separated, normalized
+    deleted_at DATETIME NULL, -- set while the income is in the trash
+    account_id INTEGER NULL
 );
 
+CREATE UNIQUE INDEX idx_incomes_uid ON incomes(uid);
+
 -- Create indexes for incomes
 CREATE INDEX idx_incomes_date ON incomes(date);
 CREATE INDEX idx_incomes_category ON incomes(category);
+CREATE INDEX idx_incomes_category_subcategory ON incomes(category, subcategory);
 CREATE INDEX idx_incomes_sync_status ON incomes(sync_status);
+CREATE INDEX idx_incomes_deleted_at ON incomes(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_incomes_account ON incomes(account_id) WHERE account_id IS NOT NULL;
 CREATE INDEX idx_income_categories_name ON income_categories(name);
 
 -- Sync queue table for SQLite-based sync operations
@@ -104,9 +156,525 @@ CREATE TABLE sync_queue (
     created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
     updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
     processed_at DATETIME NULL,
-    next_retry_at DATETIME NULL
+    next_retry_at DATETIME NULL,
+    expense_version INTEGER NULL,
+    trace_parent TEXT NULL
 );
 
 -- Index for efficient queue polling
 CREATE INDEX idx_sync_queue_status_next_retry ON sync_queue(status, next_retry_at);
-CREATE INDEX idx_sync_queue_created_at ON sync_queue(created_at);
\ No newline at end of file
+CREATE INDEX idx_sync_queue_created_at ON sync_queue(created_at);
+
+-- One row per failed sync attempt, with the class driving its retry policy
+CREATE TABLE sync_errors (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    queue_id INTEGER NOT NULL,
+    expense_id INTEGER NOT NULL,
+    operation TEXT NOT NULL,
+    attempt INTEGER NOT NULL,
+    class TEXT NOT NULL CHECK (class IN ('quota', 'auth', 'not_found', 'validation', 'transient')),
+    message TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_sync_errors_expense ON sync_errors(expense_id);
+CREATE INDEX idx_sync_errors_created_at ON sync_errors(created_at);
+
+-- Expense versions: one row per modification with the field diff
+CREATE TABLE expense_versions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    expense_id INTEGER NOT NULL,
+    version INTEGER NOT NULL,
+    changed_by TEXT NOT NULL DEFAULT 'system',
+    changes TEXT NOT NULL,
+    changed_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_expense_versions_expense_id ON expense_versions(expense_id, version);
+
+-- Categorization rules evaluated on new expenses
+CREATE TABLE category_rules (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    expression TEXT NOT NULL,
+    primary_category TEXT NOT NULL,
+    secondary_category TEXT NOT NULL,
+    priority INTEGER NOT NULL DEFAULT 0,
+    is_active BOOLEAN NOT NULL DEFAULT 1,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_category_rules_active ON category_rules(is_active, priority);
+
+-- Peer sync (triggers maintaining these tables live in migration 000017)
+CREATE TABLE peer_tombstones (
+    kind TEXT NOT NULL CHECK (kind IN ('expense', 'income')),
+    uid TEXT NOT NULL,
+    version INTEGER NOT NULL,
+    deleted_at TEXT NOT NULL,
+    PRIMARY KEY (kind, uid)
+);
+
+CREATE TABLE peer_changelog (
+    seq INTEGER PRIMARY KEY AUTOINCREMENT,
+    kind TEXT NOT NULL CHECK (kind IN ('expense', 'income')),
+    uid TEXT NOT NULL,
+    UNIQUE (kind, uid)
+);
+
+CREATE TABLE peer_sync_state (
+    peer TEXT PRIMARY KEY,
+    pull_cursor INTEGER NOT NULL DEFAULT 0,
+    push_cursor INTEGER NOT NULL DEFAULT 0,
+    last_sync_at DATETIME NULL
+);
+
+-- Reimbursement links (cascade triggers live in migration 000019)
+CREATE TABLE expense_reimbursements (
+    expense_id INTEGER NOT NULL,
+    income_id INTEGER NOT NULL,
+    amount_cents INTEGER NOT NULL CHECK (amount_cents > 0),
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (expense_id, income_id)
+);
+
+CREATE INDEX idx_expense_reimbursements_income ON expense_reimbursements(income_id);
+
+-- Business workflow state (cascade trigger lives in migration 000021)
+CREATE TABLE expense_workflow (
+    expense_id INTEGER PRIMARY KEY,
+    state TEXT NOT NULL CHECK (state IN ('draft', 'submitted', 'approved', 'reimbursed')),
+    updated_by TEXT NOT NULL DEFAULT '',
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_expense_workflow_state ON expense_workflow(state);
+
+-- Calculated expense inputs (cascade trigger lives in migration 000022)
+CREATE TABLE expense_calculations (
+    expense_id INTEGER PRIMARY KEY,
+    kind TEXT NOT NULL CHECK (kind IN ('mileage', 'per_diem')),
+    quantity REAL NOT NULL CHECK (quantity > 0),
+    rate_cents INTEGER NOT NULL CHECK (rate_cents > 0),
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Purchase warranties (cascade trigger lives in migration 000023)
+CREATE TABLE purchase_warranties (
+    expense_id INTEGER PRIMARY KEY,
+    warranty_months INTEGER NOT NULL DEFAULT 0 CHECK (warranty_months >= 0),
+    return_deadline DATE NULL,
+    proof TEXT NOT NULL DEFAULT '',
+    return_notified_at DATETIME NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_purchase_warranties_return ON purchase_warranties(return_deadline)
+    WHERE return_notified_at IS NULL;
+
+-- Shopping lists (cascade triggers live in migration 000024)
+CREATE TABLE shopping_lists (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    expense_id INTEGER NULL,
+    converted_at DATETIME NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_shopping_lists_expense ON shopping_lists(expense_id);
+
+CREATE TABLE shopping_list_items (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    list_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    quantity REAL NOT NULL DEFAULT 1 CHECK (quantity > 0),
+    price_cents INTEGER NOT NULL DEFAULT 0 CHECK (price_cents >= 0),
+    checked BOOLEAN NOT NULL DEFAULT 0,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_shopping_list_items_list ON shopping_list_items(list_id);
+
+-- Price history of items (cascade triggers live in migration 000025)
+CREATE TABLE items (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    normalized_name TEXT NOT NULL UNIQUE,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE item_prices (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    item_id INTEGER NOT NULL,
+    expense_id INTEGER NOT NULL,
+    date DATE NOT NULL,
+    quantity REAL NOT NULL DEFAULT 1 CHECK (quantity > 0),
+    unit_price_cents INTEGER NOT NULL CHECK (unit_price_cents > 0),
+    source TEXT NOT NULL CHECK (source IN ('description', 'line_item')),
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_item_prices_item_date ON item_prices(item_id, date);
+CREATE INDEX idx_item_prices_expense ON item_prices(expense_id);
+
+-- Receipt line items (cascade trigger lives in migration 000026)
+CREATE TABLE expense_line_items (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    expense_id INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    description TEXT NOT NULL,
+    quantity REAL NOT NULL DEFAULT 1 CHECK (quantity > 0),
+    amount_cents INTEGER NOT NULL CHECK (amount_cents > 0),
+    primary_category TEXT NOT NULL DEFAULT '',
+    secondary_category TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (expense_id, position)
+);
+
+-- Utility consumption (cascade trigger lives in migration 000027)
+CREATE TABLE utility_usage (
+    expense_id INTEGER PRIMARY KEY,
+    kind TEXT NOT NULL CHECK (kind IN ('electricity', 'gas', 'water')),
+    quantity REAL NOT NULL CHECK (quantity > 0),
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Fuel fills of vehicle expenses (cascade trigger lives in migration 000028)
+CREATE TABLE fuel_fills (
+    expense_id INTEGER PRIMARY KEY,
+    liters REAL NOT NULL CHECK (liters > 0),
+    odometer_km INTEGER NOT NULL CHECK (odometer_km > 0),
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_fuel_fills_odometer ON fuel_fills(odometer_km);
+
+-- Category display names per locale (English names seeded in migration 000029)
+CREATE TABLE category_translations (
+    kind TEXT NOT NULL CHECK (kind IN ('primary', 'secondary')),
+    name TEXT NOT NULL,
+    locale TEXT NOT NULL,
+    display_name TEXT NOT NULL,
+    PRIMARY KEY (kind, name, locale)
+);
+
+-- Primary category of each secondary category found in Google Sheets
+CREATE TABLE category_mappings (
+    sheet_name TEXT PRIMARY KEY,
+    primary_category TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- User overrides of the builtin category keyword dictionaries
+CREATE TABLE category_keywords (
+    keyword TEXT PRIMARY KEY,
+    primary_category TEXT NOT NULL DEFAULT '',
+    secondary_category TEXT NOT NULL DEFAULT '',
+    disabled BOOLEAN NOT NULL DEFAULT 0,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Reviewed classifier proposals (cascade trigger lives in migration 000031)
+CREATE TABLE classifier_feedback (
+    expense_id INTEGER PRIMARY KEY,
+    verdict TEXT NOT NULL CHECK (verdict IN ('accepted', 'rejected', 'taught')),
+    primary_category TEXT NOT NULL DEFAULT '',
+    secondary_category TEXT NOT NULL DEFAULT '',
+    confidence REAL NOT NULL DEFAULT 0,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Spending insights shown in the dashboard feed
+CREATE TABLE insights (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    kind TEXT NOT NULL CHECK (kind IN ('category_up', 'category_back', 'budget_reached')),
+    primary_category TEXT NOT NULL,
+    period TEXT NOT NULL,
+    occurred_on DATE NOT NULL,
+    amount_cents INTEGER NOT NULL DEFAULT 0,
+    baseline_cents INTEGER NOT NULL DEFAULT 0,
+    days INTEGER NOT NULL DEFAULT 0,
+    since_period TEXT NOT NULL DEFAULT '',
+    dismissed_at DATETIME NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (kind, primary_category, period)
+);
+
+CREATE INDEX idx_insights_feed ON insights(occurred_on) WHERE dismissed_at IS NULL;
+
+-- Insight kinds muted per primary category
+CREATE TABLE insight_mutes (
+    kind TEXT NOT NULL,
+    primary_category TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (kind, primary_category)
+);
+
+-- Alert types muted or snoozed per user
+CREATE TABLE alert_preferences (
+    user_id TEXT NOT NULL,
+    alert_type TEXT NOT NULL CHECK (alert_type IN ('budget', 'spending', 'return_due', 'sync', 'view')),
+    scope TEXT NOT NULL DEFAULT '',
+    muted BOOLEAN NOT NULL DEFAULT 0,
+    snoozed_until DATETIME NULL,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, alert_type, scope)
+);
+
+-- Months closed by their end-of-month review
+CREATE TABLE month_reviews (
+    period TEXT PRIMARY KEY,
+    closed_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Years of the Google Sheets history imported into the database, with the
+-- checkpoint an interrupted import resumes from
+CREATE TABLE sheet_history_imports (
+    year INTEGER PRIMARY KEY,
+    expenses INTEGER NOT NULL,
+    imported_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    total_rows INTEGER NOT NULL DEFAULT 0,
+    next_row INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    completed_at DATETIME
+);
+
+-- Named expense filters, optionally alerting on new matching expenses
+CREATE TABLE saved_views (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    primary_category TEXT NOT NULL DEFAULT '',
+    secondary_category TEXT NOT NULL DEFAULT '',
+    min_cents INTEGER NOT NULL DEFAULT 0,
+    max_cents INTEGER NOT NULL DEFAULT 0,
+    text TEXT NOT NULL DEFAULT '',
+    notify BOOLEAN NOT NULL DEFAULT 0,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    tag TEXT NOT NULL DEFAULT ''
+);
+
+-- Free labels of expenses and incomes (delete triggers live in migration
+-- 000042); incomes keep a comma-separated copy in incomes.tags
+CREATE TABLE tags (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE expense_tags (
+    expense_id INTEGER NOT NULL,
+    tag_id INTEGER NOT NULL,
+    PRIMARY KEY (expense_id, tag_id)
+);
+
+CREATE INDEX idx_expense_tags_tag ON expense_tags(tag_id);
+
+CREATE TABLE income_tags (
+    income_id INTEGER NOT NULL,
+    tag_id INTEGER NOT NULL,
+    PRIMARY KEY (income_id, tag_id)
+);
+
+CREATE INDEX idx_income_tags_tag ON income_tags(tag_id);
+
+-- Receipt files of expenses in blob storage (delete trigger lives in
+-- migration 000043)
+CREATE TABLE receipts (
+    expense_id INTEGER PRIMARY KEY,
+    blob_key TEXT NOT NULL,
+    thumbnail_key TEXT NOT NULL DEFAULT '',
+    content_type TEXT NOT NULL,
+    filename TEXT NOT NULL DEFAULT '',
+    size_bytes INTEGER NOT NULL CHECK (size_bytes >= 0),
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    uploaded_by INTEGER NULL -- member whose API key uploaded it
+);
+
+CREATE INDEX idx_receipts_uploaded_by ON receipts(uploaded_by) WHERE uploaded_by IS NOT NULL;
+
+-- Blobs no longer referenced, waiting to be deleted from storage
+CREATE TABLE blob_deletions (
+    blob_key TEXT PRIMARY KEY,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Household members (unassign trigger lives in migration 000038)
+CREATE TABLE users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE COLLATE NOCASE,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- API keys of the members, stored as SHA-256 (cascade trigger lives in
+-- migration 000055)
+CREATE TABLE api_keys (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    key_hash TEXT NOT NULL UNIQUE,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_used_at DATETIME NULL
+);
+
+CREATE INDEX idx_api_keys_user ON api_keys(user_id);
+
+-- Per-member overrides of the instance quotas (NULL keeps the default)
+CREATE TABLE user_quotas (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    daily_expenses INTEGER NULL CHECK (daily_expenses >= 0),
+    attachment_bytes INTEGER NULL CHECK (attachment_bytes >= 0)
+);
+
+-- Expenses created with a member's keys per day
+CREATE TABLE user_daily_usage (
+    user_id INTEGER NOT NULL,
+    day TEXT NOT NULL,
+    expenses INTEGER NOT NULL DEFAULT 0 CHECK (expenses >= 0),
+    PRIMARY KEY (user_id, day)
+);
+
+-- Attachment quota held by receipt uploads in progress
+CREATE TABLE attachment_reservations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    size_bytes INTEGER NOT NULL CHECK (size_bytes >= 0),
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_attachment_reservations_user ON attachment_reservations(user_id, created_at);
+
+-- Accounts and the transfers between them (unassign trigger lives in
+-- migration 000056)
+CREATE TABLE accounts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE COLLATE NOCASE,
+    kind TEXT NOT NULL CHECK (kind IN ('checking', 'cash', 'credit_card')),
+    opening_balance_cents INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE account_transfers (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    date DATE NOT NULL,
+    from_account_id INTEGER NOT NULL REFERENCES accounts(id),
+    to_account_id INTEGER NOT NULL REFERENCES accounts(id),
+    amount_cents INTEGER NOT NULL CHECK (amount_cents > 0),
+    description TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (from_account_id <> to_account_id)
+);
+
+CREATE INDEX idx_account_transfers_date ON account_transfers(date DESC, id DESC);
+
+-- Category lines of split expenses (cascade trigger lives in migration
+-- 000057)
+CREATE TABLE expense_splits (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    expense_id INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    note TEXT NOT NULL DEFAULT '',
+    amount_cents INTEGER NOT NULL CHECK (amount_cents > 0),
+    primary_category TEXT NOT NULL,
+    secondary_category TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (expense_id, position)
+);
+
+-- Runs of the background jobs, one row per attempt
+CREATE TABLE jobs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    job TEXT NOT NULL,
+    source TEXT NOT NULL CHECK (source IN ('schedule', 'manual', 'retry')),
+    attempt INTEGER NOT NULL DEFAULT 1 CHECK (attempt >= 1),
+    status TEXT NOT NULL CHECK (status IN ('running', 'succeeded', 'failed', 'interrupted')),
+    started_at DATETIME NOT NULL,
+    finished_at DATETIME NULL,
+    result TEXT NOT NULL DEFAULT '',
+    error TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX idx_jobs_job ON jobs(job, id);
+
+-- CSV imports with the expenses each created
+CREATE TABLE import_batches (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    filename TEXT NOT NULL,
+    expenses INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    rolled_back_at DATETIME NULL
+);
+
+CREATE TABLE import_batch_expenses (
+    batch_id INTEGER NOT NULL REFERENCES import_batches(id) ON DELETE CASCADE,
+    expense_id INTEGER NOT NULL,
+    PRIMARY KEY (batch_id, expense_id)
+);
+
+CREATE INDEX idx_import_batch_expenses_expense ON import_batch_expenses(expense_id);
+
+-- Audit log of the changes to expenses, incomes, recurrents and categories
+CREATE TABLE audit_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    entity TEXT NOT NULL CHECK (entity IN ('expense', 'income', 'recurrent_expense', 'recurrent_income', 'category', 'subcategory')),
+    entity_id INTEGER NOT NULL,
+    action TEXT NOT NULL CHECK (action IN ('create', 'update', 'delete')),
+    changed_by TEXT NOT NULL DEFAULT 'system',
+    changed_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    before_json TEXT NULL,
+    after_json TEXT NULL
+);
+
+CREATE INDEX idx_audit_log_changed_at ON audit_log(changed_at);
+CREATE INDEX idx_audit_log_entity ON audit_log(entity, entity_id);
+
+-- Full-text indexes of the descriptions (triggers keeping them in step
+-- live in migration 000053)
+CREATE VIRTUAL TABLE expenses_fts USING fts5(
+    description,
+    content = 'expenses',
+    content_rowid = 'id',
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
+CREATE VIRTUAL TABLE incomes_fts USING fts5(
+    description,
+    content = 'incomes',
+    content_rowid = 'id',
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
+-- Totals per month and category of the live expenses and incomes
+-- (triggers keeping them in step live in migration 000054)
+CREATE TABLE monthly_aggregates (
+    kind TEXT NOT NULL CHECK (kind IN ('expense', 'income')),
+    year INTEGER NOT NULL,
+    month INTEGER NOT NULL,
+    category TEXT NOT NULL,
+    total_cents INTEGER NOT NULL,
+    entries INTEGER NOT NULL,
+    PRIMARY KEY (kind, year, month, category)
+);
+
+-- What the table should hold, recomputed from the entries; the rebuild
+-- job compares the two and copies this over
+CREATE VIEW monthly_aggregates_fresh AS
+SELECT 'expense' AS kind,
+       CAST(strftime('%Y', date) AS INTEGER) AS year,
+       CAST(strftime('%m', date) AS INTEGER) AS month,
+       primary_category AS category,
+       SUM(amount_cents) AS total_cents,
+       COUNT(*) AS entries
+FROM expenses
+WHERE deleted_at IS NULL
+GROUP BY 1, 2, 3, 4
+UNION ALL
+SELECT 'income',
+       CAST(strftime('%Y', date) AS INTEGER),
+       CAST(strftime('%m', date) AS INTEGER),
+       category,
+       SUM(amount_cents),
+       COUNT(*)
+FROM incomes
+WHERE deleted_at IS NULL
+GROUP BY 1, 2, 3, 4;
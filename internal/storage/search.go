@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"spese/internal/core"
+)
+
+// ExpenseSearch selects the expenses of a search; empty fields do not
+// filter
+type ExpenseSearch struct {
+	Text     string    // Each word starts a word of the description, ignoring case and accents
+	From, To core.Date // Inclusive; zero for no bound
+	Category string    // Primary or secondary category
+	Min, Max core.Money
+}
+
+// SearchExpenses returns up to limit of the expenses a search selects,
+// newest first. The text goes through the full-text index of the
+// descriptions: "farm" finds "Farmacia comunale", in any year, without
+// reading every description.
+func (r *SQLiteRepository) SearchExpenses(ctx context.Context, s ExpenseSearch, limit int) ([]ExpenseWithID, error) {
+	match := ftsMatch(s.Text)
+	if s.Text != "" && match == "" {
+		return nil, nil // Only punctuation, which the index does not hold
+	}
+	from, to := "0001-01-01", "9999-12-31"
+	if !s.From.IsZero() {
+		from = s.From.Format("2006-01-02")
+	}
+	if !s.To.IsZero() {
+		to = s.To.Format("2006-01-02")
+	}
+	rows, err := r.reader(ctx).SearchExpenses(ctx, SearchExpensesParams{
+		FromDate: from,
+		ToDate:   to,
+		Category: s.Category,
+		MinCents: s.Min.Cents,
+		MaxCents: s.Max.Cents,
+		Match:    match,
+		MaxRows:  int64(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("search expenses: %w", err)
+	}
+	expenses := make([]ExpenseWithID, len(rows))
+	for i, row := range rows {
+		expenses[i] = ExpenseWithID{
+			ID:        strconv.FormatInt(row.ID, 10),
+			Expense:   expenseFromRow(row),
+			CreatedAt: row.CreatedAt.Time,
+		}
+	}
+	return expenses, nil
+}
+
+// ftsMatch turns a typed text into an FTS5 query matching the descriptions
+// with a word starting with each of its words. The words are quoted, so
+// FTS5 operators and column filters in the text are taken literally.
+func ftsMatch(text string) string {
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	for i, w := range words {
+		words[i] = `"` + w + `"*`
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,165 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"spese/internal/core"
+)
+
+// ImportedHistoryYear is a year of the Google Sheets history being copied
+// into the database, with the checkpoint its import resumes from
+type ImportedHistoryYear struct {
+	Year        int
+	Expenses    int // Stored so far
+	TotalRows   int // Rows read from the sheet
+	NextRow     int // First row not stored yet
+	LastError   string
+	ImportedAt  time.Time // When the import started
+	CompletedAt time.Time // Zero until every row is stored
+}
+
+// Completed reports whether every row of the year was stored.
+func (y ImportedHistoryYear) Completed() bool {
+	return !y.CompletedAt.IsZero()
+}
+
+func importedHistoryYearFromRow(row SheetHistoryImport) ImportedHistoryYear {
+	return ImportedHistoryYear{
+		Year:        int(row.Year),
+		Expenses:    int(row.Expenses),
+		TotalRows:   int(row.TotalRows),
+		NextRow:     int(row.NextRow),
+		LastError:   row.LastError,
+		ImportedAt:  row.ImportedAt,
+		CompletedAt: row.CompletedAt.Time,
+	}
+}
+
+// ListImportedHistoryYears returns the years whose import from Google
+// Sheets started, oldest first.
+func (r *SQLiteRepository) ListImportedHistoryYears(ctx context.Context) ([]ImportedHistoryYear, error) {
+	rows, err := r.reader(ctx).ListSheetHistoryImports(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list sheet history imports: %w", err)
+	}
+	years := make([]ImportedHistoryYear, len(rows))
+	for i, row := range rows {
+		years[i] = importedHistoryYearFromRow(row)
+	}
+	return years, nil
+}
+
+// GetHistoryImport returns the import of a year, and false when it never
+// started.
+func (r *SQLiteRepository) GetHistoryImport(ctx context.Context, year int) (ImportedHistoryYear, bool, error) {
+	row, err := r.reader(ctx).GetSheetHistoryImport(ctx, int64(year))
+	if errors.Is(err, sql.ErrNoRows) {
+		return ImportedHistoryYear{}, false, nil
+	}
+	if err != nil {
+		return ImportedHistoryYear{}, false, fmt.Errorf("get sheet history import of %d: %w", year, err)
+	}
+	return importedHistoryYearFromRow(row), true, nil
+}
+
+// CountYearExpenses returns how many expenses the database holds for a year
+func (r *SQLiteRepository) CountYearExpenses(ctx context.Context, year int) (int, error) {
+	n, err := r.reader(ctx).CountExpensesInYear(ctx, year)
+	if err != nil {
+		return 0, fmt.Errorf("count expenses of %d: %w", year, err)
+	}
+	return int(n), nil
+}
+
+// StartHistoryImport records the start of the import of a year read
+// from a sheet of totalRows rows. Restarting an import keeps its
+// checkpoint and clears the last error.
+func (r *SQLiteRepository) StartHistoryImport(ctx context.Context, year, totalRows int) error {
+	if err := r.queries.StartSheetHistoryImport(ctx, StartSheetHistoryImportParams{Year: int64(year), TotalRows: int64(totalRows)}); err != nil {
+		return fmt.Errorf("start import of %d: %w", year, err)
+	}
+	return nil
+}
+
+// ImportHistoryRows stores the expenses read from the rows from to next-1
+// of the sheet of a year and moves the checkpoint to next, in the same
+// transaction: a chunk is either stored and recorded or not at all, so
+// resuming never copies a row twice. It fails when the checkpoint is not
+// at from, as when another run stored the chunk first.
+//
+// The expenses are already in the spreadsheet, so they are stored as
+// synced and not queued for sync. Closed months are not checked: the
+// import brings in history the app never had, it does not edit reviewed
+// months.
+func (r *SQLiteRepository) ImportHistoryRows(ctx context.Context, year, from, next int, expenses []core.Expense) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	q := r.withTx(tx)
+
+	progress, err := q.GetSheetHistoryImport(ctx, int64(year))
+	if err != nil {
+		return fmt.Errorf("get sheet history import of %d: %w", year, err)
+	}
+	if progress.CompletedAt.Valid || int(progress.NextRow) != from {
+		return fmt.Errorf("import of %d is at row %d, not %d", year, progress.NextRow, from)
+	}
+
+	for _, e := range expenses {
+		if e.Date.Year() != year {
+			return fmt.Errorf("expense %q dated %s is not in %d", e.Description, e.Date.Format("2006-01-02"), year)
+		}
+		expense, err := q.CreateHistoryExpense(ctx, CreateHistoryExpenseParams{
+			Date:              fmt.Sprintf("%04d-%02d-%02d", e.Date.Year(), e.Date.Month(), e.Date.Day()),
+			Description:       e.Description,
+			AmountCents:       e.Amount.Cents,
+			PrimaryCategory:   e.Primary,
+			SecondaryCategory: e.Secondary,
+		})
+		if err != nil {
+			return fmt.Errorf("create expense: %w", err)
+		}
+		if err := recordExpenseVersion(ctx, q, expense.ID, expense.Version, diffExpenses(nil, expense)); err != nil {
+			return err
+		}
+		if err := auditExpense(ctx, q, expense.ID, nil, &expense); err != nil {
+			return err
+		}
+	}
+
+	err = q.AdvanceSheetHistoryImport(ctx, AdvanceSheetHistoryImportParams{NextRow: int64(next), Expenses: int64(len(expenses)), Year: int64(year)})
+	if err != nil {
+		return fmt.Errorf("advance import of %d: %w", year, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// CompleteHistoryImport records that every row of a year was stored.
+func (r *SQLiteRepository) CompleteHistoryImport(ctx context.Context, year int) error {
+	if err := r.queries.CompleteSheetHistoryImport(ctx, int64(year)); err != nil {
+		return fmt.Errorf("complete import of %d: %w", year, err)
+	}
+	slog.InfoContext(ctx, "Google Sheets history imported", "year", year)
+	return nil
+}
+
+// FailHistoryImport records why the import of a year stopped; the next
+// run resumes from its checkpoint.
+func (r *SQLiteRepository) FailHistoryImport(ctx context.Context, year int, reason string) error {
+	if err := r.queries.FailSheetHistoryImport(ctx, FailSheetHistoryImportParams{LastError: reason, Year: int64(year)}); err != nil {
+		return fmt.Errorf("record failed import of %d: %w", year, err)
+	}
+	return nil
+}
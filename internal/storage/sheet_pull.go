@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"spese/internal/core"
+)
+
+// ApplySheetEdit stores the values of an expense edited directly in Google
+// Sheets, keeping its sync status since the sheet already holds them, and
+// returns the updated expense. The edit is skipped, returning nil, while
+// the expense has queue items not written yet: the app's own change
+// replaces the row then.
+func (r *SQLiteRepository) ApplySheetEdit(ctx context.Context, id int64, e core.Expense) (*Expense, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	q := r.withTx(tx)
+
+	open, err := q.CountOpenSyncItemsForExpense(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("count open sync items: %w", err)
+	}
+	if open > 0 {
+		return nil, nil
+	}
+
+	old, err := q.GetExpense(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("get expense: %w", err)
+	}
+	if err := checkMonthOpen(ctx, q, old.Date); err != nil {
+		return nil, err
+	}
+	if err := checkMonthOpen(ctx, q, e.Date.Time); err != nil {
+		return nil, err
+	}
+
+	if _, err := q.UpdateExpenseFromSheet(ctx, UpdateExpenseFromSheetParams{
+		Date:              fmt.Sprintf("%04d-%02d-%02d", e.Date.Year(), e.Date.Month(), e.Date.Day()),
+		Description:       e.Description,
+		AmountCents:       e.Amount.Cents,
+		PrimaryCategory:   e.Primary,
+		SecondaryCategory: e.Secondary,
+		ID:                id,
+	}); err != nil {
+		return nil, fmt.Errorf("update expense from sheet: %w", err)
+	}
+	updated, err := q.GetExpense(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("get expense: %w", err)
+	}
+	if err := recordExpenseVersion(ctx, q, id, updated.Version, diffExpenses(&old, updated)); err != nil {
+		return nil, err
+	}
+	if err := auditExpense(ctx, q, id, &old, &updated); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+	return &updated, nil
+}
+
+// CreateSheetExpense stores an expense added directly in Google Sheets as
+// already synced, and returns it.
+func (r *SQLiteRepository) CreateSheetExpense(ctx context.Context, e core.Expense) (*Expense, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	q := r.withTx(tx)
+
+	if err := checkMonthOpen(ctx, q, e.Date.Time); err != nil {
+		return nil, err
+	}
+	expense, err := q.CreateHistoryExpense(ctx, CreateHistoryExpenseParams{
+		Date:              fmt.Sprintf("%04d-%02d-%02d", e.Date.Year(), e.Date.Month(), e.Date.Day()),
+		Description:       e.Description,
+		AmountCents:       e.Amount.Cents,
+		PrimaryCategory:   e.Primary,
+		SecondaryCategory: e.Secondary,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create expense: %w", err)
+	}
+	if err := recordExpenseVersion(ctx, q, expense.ID, expense.Version, diffExpenses(nil, expense)); err != nil {
+		return nil, err
+	}
+	if err := auditExpense(ctx, q, expense.ID, nil, &expense); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+	return &expense, nil
+}
@@ -0,0 +1,227 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"spese/internal/core"
+)
+
+// shoppingListsLimit caps the lists shown, open ones first
+const shoppingListsLimit = 50
+
+// ShoppingListSummary is a shopping list without its items.
+type ShoppingListSummary struct {
+	ID        int64
+	Name      string
+	ExpenseID int64 // Zero while open
+	Items     int
+	Checked   core.Money // Sum of the checked items' prices
+}
+
+// CreateShoppingList creates an empty shopping list and returns its ID.
+func (r *SQLiteRepository) CreateShoppingList(ctx context.Context, name string) (int64, error) {
+	if err := core.ValidateShoppingName(name); err != nil {
+		return 0, err
+	}
+	id, err := r.queries.CreateShoppingList(ctx, name)
+	if err != nil {
+		return 0, fmt.Errorf("create shopping list: %w", err)
+	}
+	return id, nil
+}
+
+// ListShoppingLists returns the open lists followed by the most recently
+// converted ones.
+func (r *SQLiteRepository) ListShoppingLists(ctx context.Context) ([]ShoppingListSummary, error) {
+	rows, err := r.reader(ctx).ListShoppingLists(ctx, shoppingListsLimit)
+	if err != nil {
+		return nil, fmt.Errorf("list shopping lists: %w", err)
+	}
+
+	lists := make([]ShoppingListSummary, len(rows))
+	for i, row := range rows {
+		lists[i] = ShoppingListSummary{
+			ID:        row.ID,
+			Name:      row.Name,
+			ExpenseID: row.ExpenseID.Int64,
+			Items:     int(row.ItemCount),
+			Checked:   core.Money{Cents: row.CheckedCents},
+		}
+	}
+	return lists, nil
+}
+
+// GetShoppingList returns a shopping list with its items.
+func (r *SQLiteRepository) GetShoppingList(ctx context.Context, id int64) (core.ShoppingList, error) {
+	q := r.reader(ctx)
+	row, err := q.GetShoppingList(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return core.ShoppingList{}, fmt.Errorf("shopping list not found: %d", id)
+	}
+	if err != nil {
+		return core.ShoppingList{}, fmt.Errorf("get shopping list: %w", err)
+	}
+	return r.shoppingListWithItems(ctx, q, row)
+}
+
+// GetShoppingListByExpense returns the list an expense was converted from,
+// or nil when it was entered otherwise.
+func (r *SQLiteRepository) GetShoppingListByExpense(ctx context.Context, expenseID int64) (*core.ShoppingList, error) {
+	q := r.reader(ctx)
+	row, err := q.GetShoppingListByExpense(ctx, sql.NullInt64{Int64: expenseID, Valid: true})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get shopping list by expense: %w", err)
+	}
+	list, err := r.shoppingListWithItems(ctx, q, row)
+	if err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+func (r *SQLiteRepository) shoppingListWithItems(ctx context.Context, q *Queries, row ShoppingList) (core.ShoppingList, error) {
+	items, err := q.ListShoppingListItems(ctx, row.ID)
+	if err != nil {
+		return core.ShoppingList{}, fmt.Errorf("list shopping list items: %w", err)
+	}
+
+	list := core.ShoppingList{
+		ID:        row.ID,
+		Name:      row.Name,
+		ExpenseID: row.ExpenseID.Int64,
+		Items:     make([]core.ShoppingItem, len(items)),
+	}
+	for i, item := range items {
+		list.Items[i] = core.ShoppingItem{
+			ID:       item.ID,
+			Name:     item.Name,
+			Quantity: item.Quantity,
+			Price:    core.Money{Cents: item.PriceCents},
+			Checked:  item.Checked,
+		}
+	}
+	return list, nil
+}
+
+// DeleteShoppingList removes an open list with its items. Converted lists
+// are kept as the breakdown of their expense.
+func (r *SQLiteRepository) DeleteShoppingList(ctx context.Context, id int64) error {
+	n, err := r.queries.DeleteShoppingList(ctx, id)
+	if err != nil {
+		return fmt.Errorf("delete shopping list: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("open shopping list not found: %d", id)
+	}
+	return nil
+}
+
+// AddShoppingItem appends an item to an open list.
+func (r *SQLiteRepository) AddShoppingItem(ctx context.Context, listID int64, item core.ShoppingItem) error {
+	if err := item.Validate(); err != nil {
+		return err
+	}
+	list, err := r.GetShoppingList(ctx, listID)
+	if err != nil {
+		return err
+	}
+	if list.Converted() {
+		return core.ErrListConverted
+	}
+	if len(list.Items) >= core.MaxShoppingItems {
+		return fmt.Errorf("too many items (max %d)", core.MaxShoppingItems)
+	}
+
+	if err := r.queries.CreateShoppingListItem(ctx, CreateShoppingListItemParams{
+		ListID:     listID,
+		Name:       item.Name,
+		Quantity:   item.Quantity,
+		PriceCents: item.Price.Cents,
+	}); err != nil {
+		return fmt.Errorf("add shopping item: %w", err)
+	}
+	return nil
+}
+
+// UpdateShoppingItem checks an item off (or back on) and sets its price.
+// Items of converted lists are read-only.
+func (r *SQLiteRepository) UpdateShoppingItem(ctx context.Context, itemID int64, checked bool, price core.Money) error {
+	if price.Cents < 0 {
+		return core.ErrInvalidAmount
+	}
+	n, err := r.queries.UpdateShoppingListItem(ctx, UpdateShoppingListItemParams{
+		Checked:    checked,
+		PriceCents: price.Cents,
+		ID:         itemID,
+	})
+	if err != nil {
+		return fmt.Errorf("update shopping item: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("item not found or list converted: %d", itemID)
+	}
+	return nil
+}
+
+// DeleteShoppingItem removes an item from an open list.
+func (r *SQLiteRepository) DeleteShoppingItem(ctx context.Context, itemID int64) error {
+	n, err := r.queries.DeleteShoppingListItem(ctx, itemID)
+	if err != nil {
+		return fmt.Errorf("delete shopping item: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("item not found or list converted: %d", itemID)
+	}
+	return nil
+}
+
+// MarkShoppingListConverted links an open list to the expense created from
+// it, closing the list, and records the unit prices of its checked items.
+func (r *SQLiteRepository) MarkShoppingListConverted(ctx context.Context, listID, expenseID int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	txQueries := r.withTx(tx)
+
+	n, err := txQueries.MarkShoppingListConverted(ctx, MarkShoppingListConvertedParams{
+		ExpenseID: sql.NullInt64{Int64: expenseID, Valid: true},
+		ID:        listID,
+	})
+	if err != nil {
+		return fmt.Errorf("mark shopping list converted: %w", err)
+	}
+	if n == 0 {
+		return core.ErrListConverted
+	}
+
+	expense, err := txQueries.GetExpense(ctx, expenseID)
+	if err != nil {
+		return fmt.Errorf("get expense: %w", err)
+	}
+	items, err := txQueries.ListShoppingListItems(ctx, listID)
+	if err != nil {
+		return fmt.Errorf("list shopping list items: %w", err)
+	}
+	for _, item := range items {
+		if !item.Checked {
+			continue
+		}
+		if err := recordLineItemPrice(ctx, txQueries, expense, item.Name, item.Quantity, core.Money{Cents: item.PriceCents}); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
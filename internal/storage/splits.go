@@ -0,0 +1,232 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"spese/internal/core"
+)
+
+// SetExpenseSplit replaces the parts of a split expense; no parts makes it
+// an expense of its own categories again. The parts must add up to the
+// amount of the expense (see core.ValidateSplit), and card holds are split
+// once settled. An expense already in Google Sheets is queued to be
+// deleted there and written again as the rows of its new parts.
+func (r *SQLiteRepository) SetExpenseSplit(ctx context.Context, expenseID int64, parts []core.SplitPart) error {
+	for i := range parts {
+		parts[i].Note = strings.TrimSpace(parts[i].Note)
+		parts[i].Primary = strings.TrimSpace(parts[i].Primary)
+		parts[i].Secondary = strings.TrimSpace(parts[i].Secondary)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	txQueries := r.withTx(tx)
+
+	expense, err := txQueries.GetExpense(ctx, expenseID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("expense not found: %d: %w", expenseID, err)
+	}
+	if err != nil {
+		return fmt.Errorf("get expense: %w", err)
+	}
+	if err := checkMonthOpen(ctx, txQueries, expense.Date); err != nil {
+		return err
+	}
+	if len(parts) > 0 && expense.Status == string(core.StatusPending) {
+		return fmt.Errorf("%w: a card hold is split once settled", core.ErrInvalidSplit)
+	}
+	if err := core.ValidateSplit(core.Money{Cents: expense.AmountCents}, parts); err != nil {
+		return err
+	}
+
+	oldRows, err := sheetRows(ctx, txQueries, expense)
+	if err != nil {
+		return err
+	}
+	if err := txQueries.DeleteExpenseSplits(ctx, expenseID); err != nil {
+		return fmt.Errorf("delete expense split: %w", err)
+	}
+	for i, p := range parts {
+		if err := txQueries.CreateExpenseSplit(ctx, CreateExpenseSplitParams{
+			ExpenseID:         expenseID,
+			Position:          int64(i),
+			Note:              p.Note,
+			AmountCents:       p.Amount.Cents,
+			PrimaryCategory:   p.Primary,
+			SecondaryCategory: p.Secondary,
+		}); err != nil {
+			return fmt.Errorf("create expense split: %w", err)
+		}
+	}
+
+	// The sheet holds the rows of the old parts: replace them. An expense
+	// still waiting for its first sync goes out with the new ones.
+	if expense.SyncStatus.String == "synced" {
+		if err := enqueueSheetDelete(ctx, txQueries, expenseID, oldRows); err != nil {
+			return err
+		}
+		if _, err := txQueries.ResyncExpense(ctx, expenseID); err != nil {
+			return fmt.Errorf("resync expense: %w", err)
+		}
+		if _, err := txQueries.EnqueueSync(ctx, EnqueueSyncParams{
+			ExpenseID:      expenseID,
+			ExpenseVersion: expense.Version + 1,
+			TraceParent:    traceParent(ctx),
+		}); err != nil {
+			return fmt.Errorf("enqueue sync: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// ListExpenseSplit returns the parts of a split expense in order, none for
+// an expense that is not split.
+func (r *SQLiteRepository) ListExpenseSplit(ctx context.Context, expenseID int64) ([]core.SplitPart, error) {
+	return splitParts(ctx, r.reader(ctx), expenseID)
+}
+
+// MonthSplits returns the parts of the month's split expenses by expense ID.
+func (r *SQLiteRepository) MonthSplits(ctx context.Context, year, month int) (map[int64][]core.SplitPart, error) {
+	rows, err := r.reader(ctx).ListMonthSplits(ctx, ListMonthSplitsParams{
+		PRINTF:   int64(year),
+		PRINTF_2: int64(month),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list month splits: %w", err)
+	}
+	splits := make(map[int64][]core.SplitPart)
+	for _, row := range rows {
+		splits[row.ExpenseID] = append(splits[row.ExpenseID], core.SplitPart{
+			Note:      row.Note,
+			Amount:    core.Money{Cents: row.AmountCents},
+			Primary:   row.PrimaryCategory,
+			Secondary: row.SecondaryCategory,
+		})
+	}
+	return splits, nil
+}
+
+func splitParts(ctx context.Context, q *Queries, expenseID int64) ([]core.SplitPart, error) {
+	rows, err := q.ListExpenseSplits(ctx, expenseID)
+	if err != nil {
+		return nil, fmt.Errorf("list expense split: %w", err)
+	}
+	parts := make([]core.SplitPart, len(rows))
+	for i, row := range rows {
+		parts[i] = core.SplitPart{
+			Note:      row.Note,
+			Amount:    core.Money{Cents: row.AmountCents},
+			Primary:   row.PrimaryCategory,
+			Secondary: row.SecondaryCategory,
+		}
+	}
+	return parts, nil
+}
+
+// sheetRows returns the rows an expense is written to Google Sheets as:
+// one per part when it is split (see core.FlattenSplit). Deleting the
+// expense drops its parts, so they are read before.
+func sheetRows(ctx context.Context, q *Queries, e Expense) ([]core.Expense, error) {
+	parts, err := splitParts(ctx, q, e.ID)
+	if err != nil {
+		return nil, err
+	}
+	return core.FlattenSplit(expenseFromRow(e), parts), nil
+}
+
+// enqueueSheetDelete queues the removal of the sheet rows of an expense,
+// one delete per row since the sheet finds rows by their values.
+func enqueueSheetDelete(ctx context.Context, q *Queries, expenseID int64, rows []core.Expense) error {
+	for _, row := range rows {
+		if _, err := q.EnqueueDelete(ctx, EnqueueDeleteParams{
+			ExpenseID:          expenseID,
+			ExpenseDay:         int64(row.Date.Day()),
+			ExpenseMonth:       int64(row.Date.Month()),
+			ExpenseDescription: row.Description,
+			ExpenseAmountCents: row.Amount.Cents,
+			ExpensePrimary:     row.Primary,
+			ExpenseSecondary:   row.Secondary,
+			TraceParent:        traceParent(ctx),
+		}); err != nil {
+			return fmt.Errorf("enqueue delete: %w", err)
+		}
+	}
+	return nil
+}
+
+// checkSplitAmount refuses a new amount for a split expense: the parts
+// would no longer add up to it.
+func checkSplitAmount(ctx context.Context, q *Queries, e Expense, cents int64) error {
+	if cents == e.AmountCents {
+		return nil
+	}
+	parts, err := splitParts(ctx, q, e.ID)
+	if err != nil {
+		return err
+	}
+	if len(parts) > 0 {
+		return fmt.Errorf("%w: change the parts of a split expense to change its amount", core.ErrInvalidSplit)
+	}
+	return nil
+}
+
+// applySplitCategories counts the parts of split expenses under their own
+// primary categories instead of the expense's, and returns the IDs of the
+// split expenses. The month total is unchanged. Expenses whose parts no
+// longer add up to their amount, as after an edit received from a peer,
+// stay in their own category.
+func (r *SQLiteRepository) applySplitCategories(ctx context.Context, overview *core.MonthOverview) (map[int64]bool, error) {
+	rows, err := r.reader(ctx).ListMonthSplits(ctx, ListMonthSplitsParams{
+		PRINTF:   int64(overview.Year),
+		PRINTF_2: int64(overview.Month),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list month splits: %w", err)
+	}
+
+	split := make(map[int64]bool)
+	deltas := make(map[string]int64)
+	for start := 0; start < len(rows); {
+		end := start
+		var sum int64
+		for end < len(rows) && rows[end].ExpenseID == rows[start].ExpenseID {
+			sum += rows[end].AmountCents
+			end++
+		}
+		expense, parts := rows[start], rows[start:end]
+		start = end
+		split[expense.ExpenseID] = true
+
+		if sum != expense.ExpenseAmountCents {
+			slog.WarnContext(ctx, "Split parts do not add up to the expense, not applied",
+				"expense_id", expense.ExpenseID, "parts_cents", sum, "amount_cents", expense.ExpenseAmountCents)
+			continue
+		}
+		// Excluded card holds are not in the categories to move from
+		if r.excludePending && expense.Status == string(core.StatusPending) {
+			continue
+		}
+		for _, p := range parts {
+			if p.PrimaryCategory == expense.ExpensePrimaryCategory {
+				continue
+			}
+			deltas[expense.ExpensePrimaryCategory] -= p.AmountCents
+			deltas[p.PrimaryCategory] += p.AmountCents
+		}
+	}
+	moveCategoryAmounts(overview, deltas)
+	return split, nil
+}
@@ -0,0 +1,156 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrConsoleQuery is returned for a console query that is not a single
+// read-only statement
+var ErrConsoleQuery = errors.New("only a single SELECT, WITH, VALUES or EXPLAIN statement can be run")
+
+// consoleKeywords are the statements a console query may start with
+var consoleKeywords = []string{"select", "with", "values", "explain"}
+
+// ConsoleResult holds the rows of a console query, every value as text
+type ConsoleResult struct {
+	Columns   []string
+	Rows      [][]sql.NullString // Invalid for NULL
+	Truncated bool               // The query returned more than the limit
+}
+
+// ConsoleQuery runs an ad-hoc query typed by a user on the read-only
+// connection and returns up to maxRows rows. Only one statement starting
+// with SELECT, WITH, VALUES or EXPLAIN is accepted, and the connection
+// refuses writes besides, so a CTE ending in DELETE fails too. ctx bounds
+// how long the query may run.
+func (r *SQLiteRepository) ConsoleQuery(ctx context.Context, query string, maxRows int) (ConsoleResult, error) {
+	query, err := checkConsoleQuery(query)
+	if err != nil {
+		return ConsoleResult{}, err
+	}
+
+	conn, err := r.readDB.Conn(ctx)
+	if err != nil {
+		return ConsoleResult{}, fmt.Errorf("get connection: %w", err)
+	}
+	defer conn.Close()
+	// The read pool never writes, so the connection may keep the setting
+	if _, err := conn.ExecContext(ctx, "PRAGMA query_only = ON"); err != nil {
+		return ConsoleResult{}, fmt.Errorf("set query_only: %w", err)
+	}
+
+	rows, err := conn.QueryContext(ctx, query)
+	if err != nil {
+		return ConsoleResult{}, err
+	}
+	defer rows.Close()
+
+	var res ConsoleResult
+	if res.Columns, err = rows.Columns(); err != nil {
+		return res, err
+	}
+	values := make([]any, len(res.Columns))
+	dest := make([]any, len(values))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	for rows.Next() {
+		if len(res.Rows) == maxRows {
+			res.Truncated = true
+			break
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return res, err
+		}
+		row := make([]sql.NullString, len(values))
+		for i, v := range values {
+			row[i] = consoleValue(v)
+		}
+		res.Rows = append(res.Rows, row)
+	}
+	if err := rows.Err(); err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+// consoleValue renders a value of a console row: blobs that are not text
+// as a hex literal, times as RFC 3339
+func consoleValue(v any) sql.NullString {
+	switch val := v.(type) {
+	case nil:
+		return sql.NullString{}
+	case []byte:
+		if utf8.Valid(val) {
+			return sql.NullString{String: string(val), Valid: true}
+		}
+		return sql.NullString{String: "x'" + hex.EncodeToString(val) + "'", Valid: true}
+	case time.Time:
+		return sql.NullString{String: val.Format(time.RFC3339), Valid: true}
+	}
+	return sql.NullString{String: fmt.Sprint(v), Valid: true}
+}
+
+// checkConsoleQuery returns the query without trailing semicolons, or
+// ErrConsoleQuery unless it is one statement starting with an allowed
+// keyword. Quoted strings and identifiers and comments are skipped.
+func checkConsoleQuery(query string) (string, error) {
+	query = strings.TrimRightFunc(query, func(r rune) bool { return r == ';' || unicode.IsSpace(r) })
+
+	first := ""
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`' || c == '[':
+			end := map[byte]byte{'\'': '\'', '"': '"', '`': '`', '[': ']'}[c]
+			j := strings.IndexByte(query[i+1:], end)
+			if j < 0 {
+				return "", ErrConsoleQuery
+			}
+			i += j + 2
+		case strings.HasPrefix(query[i:], "--"):
+			j := strings.IndexByte(query[i:], '\n')
+			if j < 0 {
+				i = len(query)
+			} else {
+				i += j + 1
+			}
+		case strings.HasPrefix(query[i:], "/*"):
+			j := strings.Index(query[i+2:], "*/")
+			if j < 0 {
+				i = len(query)
+			} else {
+				i += j + 4
+			}
+		case c == ';':
+			return "", ErrConsoleQuery
+		case first == "" && isWordByte(c):
+			j := i
+			for j < len(query) && isWordByte(query[j]) {
+				j++
+			}
+			first = strings.ToLower(query[i:j])
+			i = j
+		default:
+			i++
+		}
+	}
+	for _, kw := range consoleKeywords {
+		if first == kw {
+			return query, nil
+		}
+	}
+	return "", ErrConsoleQuery
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"spese/internal/core"
+)
+
+// The Each* methods walk a result set one row at a time, calling fn while
+// the cursor is still open, so exports never hold a whole table in memory.
+// They stop at the first error returned by fn or when ctx is cancelled.
+
+// EachExpense calls fn for every live expense, oldest first.
+func (r *SQLiteRepository) EachExpense(ctx context.Context, fn func(core.Expense) error) error {
+	rows, err := r.reader(ctx).db.QueryContext(ctx, listAllExpenses)
+	if err != nil {
+		return fmt.Errorf("stream expenses: %w", err)
+	}
+	if err := eachExpenseRow(ctx, rows, fn); err != nil {
+		return fmt.Errorf("stream expenses: %w", err)
+	}
+	return nil
+}
+
+// EachExpenseBetween calls fn for every live expense dated from through to,
+// both included, oldest first.
+func (r *SQLiteRepository) EachExpenseBetween(ctx context.Context, from, to time.Time, fn func(core.Expense) error) error {
+	rows, err := r.reader(ctx).db.QueryContext(ctx, listExpensesBetweenDates,
+		from.Format("2006-01-02"), to.Format("2006-01-02"))
+	if err != nil {
+		return fmt.Errorf("stream expenses between dates: %w", err)
+	}
+	if err := eachExpenseRow(ctx, rows, fn); err != nil {
+		return fmt.Errorf("stream expenses between dates: %w", err)
+	}
+	return nil
+}
+
+// EachIncome calls fn for every live income, oldest first.
+func (r *SQLiteRepository) EachIncome(ctx context.Context, fn func(core.Income) error) error {
+	rows, err := r.reader(ctx).db.QueryContext(ctx, listAllIncomes)
+	if err != nil {
+		return fmt.Errorf("stream incomes: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var i Income
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.Description,
+			&i.AmountCents,
+			&i.Category,
+			&i.Version,
+			&i.CreatedAt,
+			&i.SyncedAt,
+			&i.SyncStatus,
+			&i.Uid,
+			&i.ModifiedAt,
+			&i.Subcategory,
+			&i.Tags,
+			&i.DeletedAt,
+			&i.AccountID,
+		); err != nil {
+			return fmt.Errorf("stream incomes: %w", err)
+		}
+		if err := fn(incomeFromRow(i)); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("stream incomes: %w", err)
+	}
+	return nil
+}
+
+func eachExpenseRow(ctx context.Context, rows *sql.Rows, fn func(core.Expense) error) error {
+	defer rows.Close()
+	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var e Expense
+		if err := rows.Scan(
+			&e.ID,
+			&e.Date,
+			&e.Description,
+			&e.AmountCents,
+			&e.PrimaryCategory,
+			&e.SecondaryCategory,
+			&e.Version,
+			&e.CreatedAt,
+			&e.SyncedAt,
+			&e.SyncStatus,
+			&e.Uid,
+			&e.ModifiedAt,
+			&e.Status,
+			&e.PaidBy,
+			&e.DeletedAt,
+			&e.AccountID,
+		); err != nil {
+			return err
+		}
+		if err := fn(expenseFromRow(e)); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
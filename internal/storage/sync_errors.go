@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// RecordSyncError stores a failed attempt of a sync queue item with the
+// class of its error (quota, auth, not_found, validation, transient).
+func (r *SQLiteRepository) RecordSyncError(ctx context.Context, item SyncQueue, class, message string) error {
+	err := r.queries.RecordSyncError(ctx, RecordSyncErrorParams{
+		QueueID:   item.ID,
+		ExpenseID: item.ExpenseID,
+		Operation: item.Operation,
+		Attempt:   item.Attempts + 1,
+		Class:     class,
+		Message:   message,
+	})
+	if err != nil {
+		return fmt.Errorf("record sync error: %w", err)
+	}
+	return nil
+}
+
+// ListSyncErrors returns the latest failed sync attempts, newest first.
+func (r *SQLiteRepository) ListSyncErrors(ctx context.Context, limit int) ([]SyncError, error) {
+	errs, err := r.reader(ctx).ListSyncErrors(ctx, int64(limit))
+	if err != nil {
+		return nil, fmt.Errorf("list sync errors: %w", err)
+	}
+	return errs, nil
+}
+
+// ListExpenseSyncErrors returns the failed sync attempts of an expense,
+// newest first.
+func (r *SQLiteRepository) ListExpenseSyncErrors(ctx context.Context, expenseID int64) ([]SyncError, error) {
+	errs, err := r.reader(ctx).ListExpenseSyncErrors(ctx, expenseID)
+	if err != nil {
+		return nil, fmt.Errorf("list sync errors of expense %d: %w", expenseID, err)
+	}
+	return errs, nil
+}
+
+// SyncErrorCounts returns the number of failed sync attempts by error class.
+func (r *SQLiteRepository) SyncErrorCounts(ctx context.Context) (map[string]int64, error) {
+	rows, err := r.reader(ctx).CountSyncErrorsByClass(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("count sync errors: %w", err)
+	}
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Class] = row.Count
+	}
+	return counts, nil
+}
+
+// CleanupSyncErrors removes failed attempts older than the specified time
+func (r *SQLiteRepository) CleanupSyncErrors(ctx context.Context, olderThan time.Time) error {
+	if err := r.queries.CleanupSyncErrors(ctx, olderThan); err != nil {
+		return fmt.Errorf("cleanup sync errors: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"spese/internal/core"
+)
+
+// setExpenseTags replaces the tags of an expense, creating the tags not
+// used before.
+func setExpenseTags(ctx context.Context, q *Queries, expenseID int64, tags []string) error {
+	if err := q.DeleteExpenseTags(ctx, expenseID); err != nil {
+		return fmt.Errorf("clear expense tags: %w", err)
+	}
+	for _, tag := range core.NormalizeTags(tags) {
+		tagID, err := q.UpsertTag(ctx, tag)
+		if err != nil {
+			return fmt.Errorf("upsert tag %q: %w", tag, err)
+		}
+		if err := q.AddExpenseTag(ctx, AddExpenseTagParams{ExpenseID: expenseID, TagID: tagID}); err != nil {
+			return fmt.Errorf("add expense tag: %w", err)
+		}
+	}
+	return nil
+}
+
+// setIncomeTags links an income to the tags of its comma-separated tags
+// column, replacing the previous links.
+func setIncomeTags(ctx context.Context, q *Queries, incomeID int64, tags string) error {
+	if err := q.DeleteIncomeTags(ctx, incomeID); err != nil {
+		return fmt.Errorf("clear income tags: %w", err)
+	}
+	for _, tag := range core.ParseTags(tags) {
+		tagID, err := q.UpsertTag(ctx, tag)
+		if err != nil {
+			return fmt.Errorf("upsert tag %q: %w", tag, err)
+		}
+		if err := q.AddIncomeTag(ctx, AddIncomeTagParams{IncomeID: incomeID, TagID: tagID}); err != nil {
+			return fmt.Errorf("add income tag: %w", err)
+		}
+	}
+	return nil
+}
+
+// ExpenseTags returns the tags of an expense in the order they were given.
+func (r *SQLiteRepository) ExpenseTags(ctx context.Context, expenseID int64) ([]string, error) {
+	tags, err := r.reader(ctx).ListExpenseTags(ctx, expenseID)
+	if err != nil {
+		return nil, fmt.Errorf("list expense tags: %w", err)
+	}
+	return tags, nil
+}
+
+// ListTags returns the tags carried by some expense or income, most used
+// first.
+func (r *SQLiteRepository) ListTags(ctx context.Context) ([]core.TagUsage, error) {
+	rows, err := r.reader(ctx).ListTags(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list tags: %w", err)
+	}
+	tags := make([]core.TagUsage, len(rows))
+	for i, row := range rows {
+		tags[i] = core.TagUsage{Name: row.Name, Uses: row.Uses}
+	}
+	return tags, nil
+}
+
+// TagReport adds up the expenses and incomes of every tag, or of tag alone
+// when not empty, from from (included) to to (excluded), month by month.
+// Tags are ordered by spending, largest first; an expense counts once for
+// each of its tags.
+func (r *SQLiteRepository) TagReport(ctx context.Context, from, to time.Time, tag string) ([]core.TagSummary, error) {
+	tag = core.NormalizeTag(tag)
+	fromDate, toDate := from.Format("2006-01-02"), to.Format("2006-01-02")
+
+	spent, err := r.reader(ctx).SumExpenseTagsByMonth(ctx, SumExpenseTagsByMonthParams{FromDate: fromDate, ToDate: toDate, Tag: tag})
+	if err != nil {
+		return nil, fmt.Errorf("sum expense tags: %w", err)
+	}
+	received, err := r.reader(ctx).SumIncomeTagsByMonth(ctx, SumIncomeTagsByMonthParams{FromDate: fromDate, ToDate: toDate, Tag: tag})
+	if err != nil {
+		return nil, fmt.Errorf("sum income tags: %w", err)
+	}
+
+	byTag := make(map[string]*core.TagSummary)
+	add := func(name, month string, cents, entries int64, income bool) error {
+		year, m, ok := strings.Cut(month, "-")
+		y, errY := strconv.Atoi(year)
+		mm, errM := strconv.Atoi(m)
+		if !ok || errY != nil || errM != nil {
+			return fmt.Errorf("tag %q: invalid month %q", name, month)
+		}
+		summary := byTag[name]
+		if summary == nil {
+			summary = &core.TagSummary{Tag: name}
+			byTag[name] = summary
+		}
+		i := sort.Search(len(summary.Months), func(i int) bool {
+			got := summary.Months[i]
+			return got.Year > y || got.Year == y && got.Month >= mm
+		})
+		if i == len(summary.Months) || summary.Months[i].Year != y || summary.Months[i].Month != mm {
+			summary.Months = append(summary.Months, core.TagMonth{})
+			copy(summary.Months[i+1:], summary.Months[i:])
+			summary.Months[i] = core.TagMonth{Year: y, Month: mm}
+		}
+		if income {
+			summary.Months[i].Received.Cents += cents
+			summary.Received.Cents += cents
+		} else {
+			summary.Months[i].Spent.Cents += cents
+			summary.Spent.Cents += cents
+		}
+		summary.Months[i].Entries += entries
+		summary.Entries += entries
+		return nil
+	}
+	for _, row := range spent {
+		if err := add(row.Tag, row.Month, row.TotalAmount, row.Entries, false); err != nil {
+			return nil, err
+		}
+	}
+	for _, row := range received {
+		if err := add(row.Tag, row.Month, row.TotalAmount, row.Entries, true); err != nil {
+			return nil, err
+		}
+	}
+
+	report := make([]core.TagSummary, 0, len(byTag))
+	for _, summary := range byTag {
+		report = append(report, *summary)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Spent.Cents != report[j].Spent.Cents {
+			return report[i].Spent.Cents > report[j].Spent.Cents
+		}
+		return report[i].Tag < report[j].Tag
+	})
+	return report, nil
+}
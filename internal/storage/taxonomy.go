@@ -0,0 +1,508 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"spese/internal/core"
+)
+
+// ImportTaxonomy adds the categories missing from the taxonomy in a single
+// transaction, leaving the existing ones untouched, and returns how many
+// primary and secondary categories it created. Importing the same groups
+// twice creates nothing the second time.
+func (r *SQLiteRepository) ImportTaxonomy(ctx context.Context, groups []core.CategoryGroup) (int, error) {
+	for _, g := range groups {
+		if err := g.Validate(); err != nil {
+			return 0, err
+		}
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	txQueries := r.withTx(tx)
+
+	created := 0
+	for _, g := range groups {
+		primary, err := txQueries.GetPrimaryCategoryByName(ctx, g.Primary)
+		if errors.Is(err, sql.ErrNoRows) {
+			primary, err = txQueries.CreatePrimaryCategory(ctx, g.Primary)
+			if err != nil {
+				return 0, fmt.Errorf("create primary category %q: %w", g.Primary, err)
+			}
+			if err := recordAudit(ctx, txQueries, AuditCategory, primary.ID, nil, categorySnapshot(primary)); err != nil {
+				return 0, err
+			}
+			created++
+		} else if err != nil {
+			return 0, fmt.Errorf("get primary category %q: %w", g.Primary, err)
+		}
+
+		for _, name := range g.Secondaries {
+			// Archived secondaries count as present, so re-importing does
+			// not bring them back as duplicates
+			_, err := txQueries.GetSecondaryCategoryByName(ctx, GetSecondaryCategoryByNameParams{
+				PrimaryName: g.Primary,
+				Name:        name,
+			})
+			if err == nil {
+				continue
+			}
+			if !errors.Is(err, sql.ErrNoRows) {
+				return 0, fmt.Errorf("get secondary category %q: %w", name, err)
+			}
+			sc, err := txQueries.CreateSecondaryCategory(ctx, CreateSecondaryCategoryParams{
+				Name:              name,
+				PrimaryCategoryID: primary.ID,
+			})
+			if err != nil {
+				return 0, fmt.Errorf("create secondary category %q: %w", name, err)
+			}
+			if err := recordAudit(ctx, txQueries, AuditSubcategory, sc.ID, nil, subcategorySnapshot(sc, g.Primary)); err != nil {
+				return 0, err
+			}
+			created++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit transaction: %w", err)
+	}
+	return created, nil
+}
+
+// CategoryAdmin is a primary category as the management page lists it,
+// archived secondaries included.
+type CategoryAdmin struct {
+	Primary     string
+	Archived    bool
+	Expenses    int64
+	Secondaries []SecondaryCategoryAdmin
+}
+
+// SecondaryCategoryAdmin is a secondary category with the number of
+// expenses filed under it.
+type SecondaryCategoryAdmin struct {
+	Name     string
+	Archived bool
+	Expenses int64
+}
+
+// ListCategoryAdmin returns every category, archived ones included, with
+// how many expenses use it.
+func (r *SQLiteRepository) ListCategoryAdmin(ctx context.Context) ([]CategoryAdmin, error) {
+	rows, err := r.reader(ctx).ListCategoryAdmin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list categories: %w", err)
+	}
+	var result []CategoryAdmin
+	for _, row := range rows {
+		if len(result) == 0 || result[len(result)-1].Primary != row.PrimaryName {
+			result = append(result, CategoryAdmin{Primary: row.PrimaryName, Archived: row.PrimaryArchived})
+		}
+		if !row.SecondaryName.Valid {
+			continue
+		}
+		group := &result[len(result)-1]
+		group.Expenses += row.ExpenseCount
+		group.Secondaries = append(group.Secondaries, SecondaryCategoryAdmin{
+			Name:     row.SecondaryName.String,
+			Archived: row.SecondaryArchived.Bool,
+			Expenses: row.ExpenseCount,
+		})
+	}
+	return result, nil
+}
+
+// CreateCategory adds a primary category, or a secondary one under primary
+// when secondary is set. The primary category is created when missing.
+func (r *SQLiteRepository) CreateCategory(ctx context.Context, primary, secondary string) error {
+	if err := core.ValidateCategoryName(primary); err != nil {
+		return err
+	}
+	if secondary != "" {
+		if err := core.ValidateCategoryName(secondary); err != nil {
+			return err
+		}
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	txQueries := r.withTx(tx)
+
+	parent, err := txQueries.GetPrimaryCategoryByName(ctx, primary)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		if parent, err = txQueries.CreatePrimaryCategory(ctx, primary); err != nil {
+			return fmt.Errorf("create primary category %q: %w", primary, err)
+		}
+		if err := recordAudit(ctx, txQueries, AuditCategory, parent.ID, nil, categorySnapshot(parent)); err != nil {
+			return err
+		}
+	case err != nil:
+		return fmt.Errorf("get primary category %q: %w", primary, err)
+	case secondary == "":
+		return fmt.Errorf("%w: %q", core.ErrCategoryExists, primary)
+	}
+
+	if secondary != "" {
+		if err := secondaryAbsent(ctx, txQueries, primary, secondary); err != nil {
+			return err
+		}
+		sc, err := txQueries.CreateSecondaryCategory(ctx, CreateSecondaryCategoryParams{
+			Name:              secondary,
+			PrimaryCategoryID: parent.ID,
+		})
+		if err != nil {
+			return fmt.Errorf("create secondary category %q: %w", secondary, err)
+		}
+		if err := recordAudit(ctx, txQueries, AuditSubcategory, sc.ID, nil, subcategorySnapshot(sc, primary)); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	slog.InfoContext(ctx, "Category created", "primary", primary, "secondary", secondary)
+	return nil
+}
+
+// RenameCategory renames a primary category, or its secondary category when
+// secondary is set. Expenses (with a history entry each), recurrent
+// expenses, rules, keywords, saved views and translations follow the new
+// name; rows already written to Google Sheets keep the old one.
+func (r *SQLiteRepository) RenameCategory(ctx context.Context, primary, secondary, name string) error {
+	if err := core.ValidateCategoryName(name); err != nil {
+		return err
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	txQueries := r.withTx(tx)
+
+	if secondary == "" {
+		err = renamePrimary(ctx, txQueries, primary, name)
+	} else {
+		err = moveSecondaryCategory(ctx, txQueries, primary, secondary, primary, name)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	slog.InfoContext(ctx, "Category renamed", "primary", primary, "secondary", secondary, "name", name)
+	return nil
+}
+
+// MoveSecondaryCategory re-parents a secondary category under another,
+// existing primary category, moving what files under it along.
+func (r *SQLiteRepository) MoveSecondaryCategory(ctx context.Context, primary, secondary, newPrimary string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	txQueries := r.withTx(tx)
+
+	if err := moveSecondaryCategory(ctx, txQueries, primary, secondary, newPrimary, secondary); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	slog.InfoContext(ctx, "Category moved", "primary", primary, "secondary", secondary, "new_primary", newPrimary)
+	return nil
+}
+
+// SetCategoryArchived archives or restores a primary category, or its
+// secondary category when secondary is set. Archived categories are no
+// longer offered for new expenses; existing expenses keep them.
+func (r *SQLiteRepository) SetCategoryArchived(ctx context.Context, primary, secondary string, archived bool) error {
+	if secondary == "" {
+		parent, err := r.queries.GetPrimaryCategoryByName(ctx, primary)
+		if err != nil {
+			return categoryLookupError(err, primary)
+		}
+		if _, err := r.queries.SetPrimaryCategoryArchived(ctx, SetPrimaryCategoryArchivedParams{Archived: archived, ID: parent.ID}); err != nil {
+			return fmt.Errorf("archive primary category: %w", err)
+		}
+		updated := parent
+		updated.Archived = archived
+		if err := recordAudit(ctx, r.queries, AuditCategory, parent.ID, categorySnapshot(parent), categorySnapshot(updated)); err != nil {
+			return err
+		}
+	} else {
+		sc, err := r.queries.GetSecondaryCategoryByName(ctx, GetSecondaryCategoryByNameParams{PrimaryName: primary, Name: secondary})
+		if err != nil {
+			return categoryLookupError(err, primary+" / "+secondary)
+		}
+		if _, err := r.queries.SetSecondaryCategoryArchived(ctx, SetSecondaryCategoryArchivedParams{Archived: archived, ID: sc.ID}); err != nil {
+			return fmt.Errorf("archive secondary category: %w", err)
+		}
+		updated := sc
+		updated.Archived = archived
+		if err := recordAudit(ctx, r.queries, AuditSubcategory, sc.ID, subcategorySnapshot(sc, primary), subcategorySnapshot(updated, primary)); err != nil {
+			return err
+		}
+	}
+	slog.InfoContext(ctx, "Category archive state changed", "primary", primary, "secondary", secondary, "archived", archived)
+	return nil
+}
+
+// DeleteCategory removes a primary category with its secondaries, or only
+// its secondary category when secondary is set. Categories still used by
+// expenses or recurrent expenses are refused with core.ErrCategoryInUse;
+// archive them instead.
+func (r *SQLiteRepository) DeleteCategory(ctx context.Context, primary, secondary string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	txQueries := r.withTx(tx)
+
+	if secondary == "" {
+		parent, err := txQueries.GetPrimaryCategoryByName(ctx, primary)
+		if err != nil {
+			return categoryLookupError(err, primary)
+		}
+		refs, err := txQueries.CountPrimaryCategoryReferences(ctx, primary)
+		if err != nil {
+			return fmt.Errorf("count category references: %w", err)
+		}
+		if refs > 0 {
+			return fmt.Errorf("%w: %q is used %d times", core.ErrCategoryInUse, primary, refs)
+		}
+		if err := txQueries.DeleteSecondaryCategoriesByPrimaryID(ctx, parent.ID); err != nil {
+			return fmt.Errorf("delete secondary categories: %w", err)
+		}
+		if err := txQueries.DeletePrimaryCategoryByID(ctx, parent.ID); err != nil {
+			return fmt.Errorf("delete primary category: %w", err)
+		}
+		if err := recordAudit(ctx, txQueries, AuditCategory, parent.ID, categorySnapshot(parent), nil); err != nil {
+			return err
+		}
+	} else {
+		sc, err := txQueries.GetSecondaryCategoryByName(ctx, GetSecondaryCategoryByNameParams{PrimaryName: primary, Name: secondary})
+		if err != nil {
+			return categoryLookupError(err, primary+" / "+secondary)
+		}
+		refs, err := txQueries.CountSecondaryCategoryReferences(ctx, CountSecondaryCategoryReferencesParams{
+			PrimaryCategory:   primary,
+			SecondaryCategory: secondary,
+		})
+		if err != nil {
+			return fmt.Errorf("count category references: %w", err)
+		}
+		if refs > 0 {
+			return fmt.Errorf("%w: %q is used %d times", core.ErrCategoryInUse, secondary, refs)
+		}
+		if err := txQueries.DeleteSecondaryCategoryByID(ctx, sc.ID); err != nil {
+			return fmt.Errorf("delete secondary category: %w", err)
+		}
+		if err := recordAudit(ctx, txQueries, AuditSubcategory, sc.ID, subcategorySnapshot(sc, primary), nil); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	slog.InfoContext(ctx, "Category deleted", "primary", primary, "secondary", secondary)
+	return nil
+}
+
+// renamePrimary renames a primary category and everything filed under it.
+func renamePrimary(ctx context.Context, q *Queries, primary, name string) error {
+	parent, err := q.GetPrimaryCategoryByName(ctx, primary)
+	if err != nil {
+		return categoryLookupError(err, primary)
+	}
+	if name == primary {
+		return nil
+	}
+	if _, err := q.GetPrimaryCategoryByName(ctx, name); err == nil {
+		return fmt.Errorf("%w: %q", core.ErrCategoryExists, name)
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("get primary category %q: %w", name, err)
+	}
+	if _, err := q.RenamePrimaryCategory(ctx, RenamePrimaryCategoryParams{Name: name, ID: parent.ID}); err != nil {
+		return fmt.Errorf("rename primary category: %w", err)
+	}
+	renamed := parent
+	renamed.Name = name
+	if err := recordAudit(ctx, q, AuditCategory, parent.ID, categorySnapshot(parent), categorySnapshot(renamed)); err != nil {
+		return err
+	}
+
+	expenses, err := q.ListExpensesByPrimaryCategory(ctx, primary)
+	if err != nil {
+		return fmt.Errorf("list expenses of %q: %w", primary, err)
+	}
+	for _, e := range expenses {
+		if err := recategorize(ctx, q, e, name, e.SecondaryCategory); err != nil {
+			return err
+		}
+	}
+
+	recurrents, err := recurrentsInCategory(ctx, q, primary, "")
+	if err != nil {
+		return err
+	}
+	if err := q.RenameRecurrentExpensesPrimary(ctx, RenameRecurrentExpensesPrimaryParams{NewPrimary: name, OldPrimary: primary}); err != nil {
+		return fmt.Errorf("rename category of recurrent expenses: %w", err)
+	}
+	if err := auditRecurrentsMoved(ctx, q, recurrents); err != nil {
+		return err
+	}
+	if err := q.RenameCategoryRulesPrimary(ctx, RenameCategoryRulesPrimaryParams{NewPrimary: name, OldPrimary: primary}); err != nil {
+		return fmt.Errorf("rename category of rules: %w", err)
+	}
+	if err := q.RenameCategoryKeywordsPrimary(ctx, RenameCategoryKeywordsPrimaryParams{NewPrimary: name, OldPrimary: primary}); err != nil {
+		return fmt.Errorf("rename category of keywords: %w", err)
+	}
+	if err := q.RenameSavedViewsPrimary(ctx, RenameSavedViewsPrimaryParams{NewPrimary: name, OldPrimary: primary}); err != nil {
+		return fmt.Errorf("rename category of saved views: %w", err)
+	}
+	if err := q.RenameCategoryMappingsPrimary(ctx, RenameCategoryMappingsPrimaryParams{NewPrimary: name, OldPrimary: primary}); err != nil {
+		return fmt.Errorf("rename category of sheet mappings: %w", err)
+	}
+	if err := q.RenameCategoryTranslations(ctx, RenameCategoryTranslationsParams{NewName: name, Kind: "primary", OldName: primary}); err != nil {
+		return fmt.Errorf("rename category translations: %w", err)
+	}
+	return nil
+}
+
+// moveSecondaryCategory renames and/or re-parents a secondary category and
+// everything filed under it.
+func moveSecondaryCategory(ctx context.Context, q *Queries, primary, secondary, newPrimary, newSecondary string) error {
+	sc, err := q.GetSecondaryCategoryByName(ctx, GetSecondaryCategoryByNameParams{PrimaryName: primary, Name: secondary})
+	if err != nil {
+		return categoryLookupError(err, primary+" / "+secondary)
+	}
+	if newPrimary == primary && newSecondary == secondary {
+		return nil
+	}
+	parent, err := q.GetPrimaryCategoryByName(ctx, newPrimary)
+	if err != nil {
+		return categoryLookupError(err, newPrimary)
+	}
+	if err := secondaryAbsent(ctx, q, newPrimary, newSecondary); err != nil {
+		return err
+	}
+	if _, err := q.UpdateSecondaryCategory(ctx, UpdateSecondaryCategoryParams{
+		Name:              newSecondary,
+		PrimaryCategoryID: parent.ID,
+		ID:                sc.ID,
+	}); err != nil {
+		return fmt.Errorf("update secondary category: %w", err)
+	}
+	moved := sc
+	moved.Name = newSecondary
+	moved.PrimaryCategoryID = parent.ID
+	if err := recordAudit(ctx, q, AuditSubcategory, sc.ID, subcategorySnapshot(sc, primary), subcategorySnapshot(moved, newPrimary)); err != nil {
+		return err
+	}
+
+	expenses, err := q.ListExpensesInCategory(ctx, ListExpensesInCategoryParams{
+		PrimaryCategory:   primary,
+		SecondaryCategory: secondary,
+	})
+	if err != nil {
+		return fmt.Errorf("list expenses of %q: %w", secondary, err)
+	}
+	for _, e := range expenses {
+		if err := recategorize(ctx, q, e, newPrimary, newSecondary); err != nil {
+			return err
+		}
+	}
+
+	recurrents, err := recurrentsInCategory(ctx, q, primary, secondary)
+	if err != nil {
+		return err
+	}
+	if err := q.MoveRecurrentExpensesCategory(ctx, MoveRecurrentExpensesCategoryParams{
+		NewPrimary: newPrimary, NewSecondary: newSecondary, OldPrimary: primary, OldSecondary: secondary,
+	}); err != nil {
+		return fmt.Errorf("move category of recurrent expenses: %w", err)
+	}
+	if err := auditRecurrentsMoved(ctx, q, recurrents); err != nil {
+		return err
+	}
+	if err := q.MoveCategoryRulesCategory(ctx, MoveCategoryRulesCategoryParams{
+		NewPrimary: newPrimary, NewSecondary: newSecondary, OldPrimary: primary, OldSecondary: secondary,
+	}); err != nil {
+		return fmt.Errorf("move category of rules: %w", err)
+	}
+	if err := q.MoveCategoryKeywordsCategory(ctx, MoveCategoryKeywordsCategoryParams{
+		NewPrimary: newPrimary, NewSecondary: newSecondary, OldPrimary: primary, OldSecondary: secondary,
+	}); err != nil {
+		return fmt.Errorf("move category of keywords: %w", err)
+	}
+	if err := q.MoveSavedViewsCategory(ctx, MoveSavedViewsCategoryParams{
+		NewPrimary: newPrimary, NewSecondary: newSecondary, OldPrimary: primary, OldSecondary: secondary,
+	}); err != nil {
+		return fmt.Errorf("move category of saved views: %w", err)
+	}
+	if newSecondary != secondary {
+		if err := q.RenameCategoryTranslations(ctx, RenameCategoryTranslationsParams{
+			NewName: newSecondary, Kind: "secondary", OldName: secondary,
+		}); err != nil {
+			return fmt.Errorf("rename category translations: %w", err)
+		}
+	}
+	return nil
+}
+
+// recategorize moves one expense to new categories, recording the change in
+// its history.
+func recategorize(ctx context.Context, q *Queries, old Expense, primary, secondary string) error {
+	if _, err := q.UpdateExpenseCategory(ctx, UpdateExpenseCategoryParams{
+		PrimaryCategory:   primary,
+		SecondaryCategory: secondary,
+		ID:                old.ID,
+	}); err != nil {
+		return fmt.Errorf("update expense category: %w", err)
+	}
+	updated := old
+	updated.PrimaryCategory = primary
+	updated.SecondaryCategory = secondary
+	if err := recordExpenseVersion(ctx, q, old.ID, old.Version+1, diffExpenses(&old, updated)); err != nil {
+		return err
+	}
+	return auditExpense(ctx, q, old.ID, &old, &updated)
+}
+
+func secondaryAbsent(ctx context.Context, q *Queries, primary, secondary string) error {
+	_, err := q.GetSecondaryCategoryByName(ctx, GetSecondaryCategoryByNameParams{PrimaryName: primary, Name: secondary})
+	if err == nil {
+		return fmt.Errorf("%w: %q in %q", core.ErrCategoryExists, secondary, primary)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("get secondary category %q: %w", secondary, err)
+	}
+	return nil
+}
+
+func categoryLookupError(err error, name string) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %q", core.ErrCategoryNotFound, name)
+	}
+	return fmt.Errorf("get category %q: %w", name, err)
+}
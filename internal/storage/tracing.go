@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("spese/internal/storage")
+
+// startQuerySpan starts the span of a query, named after it
+func startQuerySpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "sqlite "+name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemNameSQLite, semconv.DBOperationName(name)))
+}
+
+// endQuerySpan ends a query span, marking it failed on err
+func endQuerySpan(span trace.Span, err error) {
+	if err != nil && err != context.Canceled {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// traceParent returns the W3C traceparent of the span in ctx, stored with
+// the sync queue items so the sync continues the trace of the request
+// that enqueued them; nil outside a sampled trace.
+func traceParent(ctx context.Context) interface{} {
+	if !trace.SpanContextFromContext(ctx).IsSampled() {
+		return nil
+	}
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+	if tp := carrier.Get("traceparent"); tp != "" {
+		return tp
+	}
+	return nil
+}
+
+// SyncTraceContext returns ctx continuing the trace an item was enqueued
+// in, or ctx itself when the item has none.
+func SyncTraceContext(ctx context.Context, item SyncQueue) context.Context {
+	tp, ok := item.TraceParent.(string)
+	if !ok || tp == "" {
+		return ctx
+	}
+	return propagation.TraceContext{}.Extract(ctx, propagation.MapCarrier{"traceparent": tp})
+}
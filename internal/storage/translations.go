@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"spese/internal/core"
+)
+
+// Category kinds of a translation.
+const (
+	CategoryKindPrimary   = "primary"
+	CategoryKindSecondary = "secondary"
+)
+
+// CategoryLabels returns the display names of the categories in a locale.
+func (r *SQLiteRepository) CategoryLabels(ctx context.Context, locale core.Locale) (core.CategoryLabels, error) {
+	rows, err := r.reader(ctx).ListCategoryTranslations(ctx, string(locale))
+	if err != nil {
+		return core.CategoryLabels{}, fmt.Errorf("list category translations: %w", err)
+	}
+
+	labels := core.CategoryLabels{Primary: make(map[string]string), Secondary: make(map[string]string)}
+	for _, row := range rows {
+		if row.Kind == CategoryKindPrimary {
+			labels.Primary[row.Name] = row.DisplayName
+		} else {
+			labels.Secondary[row.Name] = row.DisplayName
+		}
+	}
+	return labels, nil
+}
+
+// SetCategoryTranslation sets the display name of a category in a locale;
+// an empty name removes the translation, so the key is shown again.
+func (r *SQLiteRepository) SetCategoryTranslation(ctx context.Context, kind, name string, locale core.Locale, displayName string) error {
+	if kind != CategoryKindPrimary && kind != CategoryKindSecondary {
+		return fmt.Errorf("invalid category kind: %q", kind)
+	}
+	if _, ok := core.ParseLocale(string(locale)); !ok {
+		return fmt.Errorf("unsupported locale: %q", locale)
+	}
+	displayName = strings.TrimSpace(displayName)
+	if len(displayName) > core.MaxCategoryNameLength {
+		return fmt.Errorf("display name too long: %q", displayName)
+	}
+
+	if displayName == "" {
+		if _, err := r.queries.DeleteCategoryTranslation(ctx, DeleteCategoryTranslationParams{
+			Kind:   kind,
+			Name:   name,
+			Locale: string(locale),
+		}); err != nil {
+			return fmt.Errorf("delete category translation: %w", err)
+		}
+		return nil
+	}
+
+	if err := r.queries.UpsertCategoryTranslation(ctx, UpsertCategoryTranslationParams{
+		Kind:        kind,
+		Name:        name,
+		Locale:      string(locale),
+		DisplayName: displayName,
+	}); err != nil {
+		return fmt.Errorf("set category translation: %w", err)
+	}
+	return nil
+}
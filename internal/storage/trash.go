@@ -0,0 +1,299 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"spese/internal/core"
+)
+
+// Kinds of the items in the trash
+const (
+	TrashKindExpense = "expense"
+	TrashKindIncome  = "income"
+)
+
+// TrashItem is a deleted expense or income waiting for the purge
+type TrashItem struct {
+	Kind        string // TrashKindExpense or TrashKindIncome
+	ID          int64
+	Date        time.Time
+	Description string
+	AmountCents int64
+	Category    string // Primary category of an expense, category of an income
+	Subcategory string
+	DeletedAt   time.Time
+}
+
+// ErrNotInTrash is returned when restoring an item that is not in the trash
+var ErrNotInTrash = errors.New("item not in trash")
+
+// TrashExpense moves an expense to the trash. It leaves totals and lists
+// at once, while its row stays in Google Sheets until the trash is purged.
+// Sync items not picked up yet are cancelled; a restore queues them again.
+func (r *SQLiteRepository) TrashExpense(ctx context.Context, id int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	txQueries := r.withTx(tx)
+
+	expense, err := txQueries.GetExpense(ctx, id)
+	if err != nil {
+		return fmt.Errorf("get expense: %w", err)
+	}
+	if err := checkMonthOpen(ctx, txQueries, expense.Date); err != nil {
+		return err
+	}
+	if _, err := txQueries.TrashExpense(ctx, id); err != nil {
+		return fmt.Errorf("trash expense: %w", err)
+	}
+	if _, err := txQueries.CancelPendingSyncItems(ctx, id); err != nil {
+		return fmt.Errorf("cancel pending sync items: %w", err)
+	}
+	if err := auditExpense(ctx, txQueries, id, &expense, nil); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+
+	slog.InfoContext(ctx, "Expense moved to trash", "id", id, "description", expense.Description)
+	return nil
+}
+
+// RestoreExpense takes an expense out of the trash. A settled expense not
+// yet in Google Sheets is queued for the sync again.
+func (r *SQLiteRepository) RestoreExpense(ctx context.Context, id int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	txQueries := r.withTx(tx)
+
+	expense, err := txQueries.GetTrashedExpense(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotInTrash
+	}
+	if err != nil {
+		return fmt.Errorf("get trashed expense: %w", err)
+	}
+	if err := checkMonthOpen(ctx, txQueries, expense.Date); err != nil {
+		return err
+	}
+	if _, err := txQueries.RestoreExpense(ctx, id); err != nil {
+		return fmt.Errorf("restore expense: %w", err)
+	}
+	expense.DeletedAt = sql.NullTime{}
+	if err := auditExpense(ctx, txQueries, id, nil, &expense); err != nil {
+		return err
+	}
+	if expense.Status == string(core.StatusCleared) && expense.SyncStatus.String != "synced" {
+		if _, err := txQueries.EnqueueSync(ctx, EnqueueSyncParams{
+			ExpenseID:      id,
+			ExpenseVersion: expense.Version,
+			TraceParent:    traceParent(ctx),
+		}); err != nil {
+			return fmt.Errorf("enqueue sync: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+
+	slog.InfoContext(ctx, "Expense restored from trash", "id", id)
+	return nil
+}
+
+// TrashIncome moves an income to the trash
+func (r *SQLiteRepository) TrashIncome(ctx context.Context, id int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	txQueries := r.withTx(tx)
+
+	income, err := txQueries.GetIncome(ctx, id)
+	if err != nil {
+		return fmt.Errorf("get income: %w", err)
+	}
+	if err := checkMonthOpen(ctx, txQueries, income.Date); err != nil {
+		return err
+	}
+	if _, err := txQueries.TrashIncome(ctx, id); err != nil {
+		return fmt.Errorf("trash income: %w", err)
+	}
+	if err := auditIncome(ctx, txQueries, id, &income, nil); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+
+	slog.InfoContext(ctx, "Income moved to trash", "id", id, "description", income.Description)
+	return nil
+}
+
+// RestoreIncome takes an income out of the trash
+func (r *SQLiteRepository) RestoreIncome(ctx context.Context, id int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	txQueries := r.withTx(tx)
+
+	n, err := txQueries.RestoreIncome(ctx, id)
+	if err != nil {
+		return fmt.Errorf("restore income: %w", err)
+	}
+	if n == 0 {
+		return ErrNotInTrash
+	}
+	income, err := txQueries.GetIncome(ctx, id)
+	if err != nil {
+		return fmt.Errorf("get income: %w", err)
+	}
+	if err := checkMonthOpen(ctx, txQueries, income.Date); err != nil {
+		return err
+	}
+	if err := auditIncome(ctx, txQueries, id, nil, &income); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+
+	slog.InfoContext(ctx, "Income restored from trash", "id", id)
+	return nil
+}
+
+// ListTrash returns the expenses and incomes in the trash, most recently
+// deleted first
+func (r *SQLiteRepository) ListTrash(ctx context.Context) ([]TrashItem, error) {
+	expenses, err := r.reader(ctx).ListTrashedExpenses(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list trashed expenses: %w", err)
+	}
+	incomes, err := r.reader(ctx).ListTrashedIncomes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list trashed incomes: %w", err)
+	}
+
+	items := make([]TrashItem, 0, len(expenses)+len(incomes))
+	for _, e := range expenses {
+		items = append(items, TrashItem{
+			Kind:        TrashKindExpense,
+			ID:          e.ID,
+			Date:        e.Date,
+			Description: e.Description,
+			AmountCents: e.AmountCents,
+			Category:    e.PrimaryCategory,
+			Subcategory: e.SecondaryCategory,
+			DeletedAt:   e.DeletedAt.Time,
+		})
+	}
+	for _, i := range incomes {
+		items = append(items, TrashItem{
+			Kind:        TrashKindIncome,
+			ID:          i.ID,
+			Date:        i.Date,
+			Description: i.Description,
+			AmountCents: i.AmountCents,
+			Category:    i.Category,
+			Subcategory: i.Subcategory,
+			DeletedAt:   i.DeletedAt.Time,
+		})
+	}
+	sort.SliceStable(items, func(a, b int) bool { return items[a].DeletedAt.After(items[b].DeletedAt) })
+	return items, nil
+}
+
+// PurgeTrash deletes for good the expenses and incomes moved to the trash
+// before olderThan and returns how many it deleted. Purged expenses are
+// queued for deletion from Google Sheets, except card holds that never
+// reached it.
+func (r *SQLiteRepository) PurgeTrash(ctx context.Context, olderThan time.Time) (int, error) {
+	cutoff := sql.NullTime{Time: olderThan.UTC(), Valid: true}
+
+	expenseIDs, err := r.queries.ListExpensesToPurge(ctx, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("list expenses to purge: %w", err)
+	}
+	purged := 0
+	for _, id := range expenseIDs {
+		if err := r.purgeExpense(ctx, id); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+
+	incomeIDs, err := r.queries.ListIncomesToPurge(ctx, cutoff)
+	if err != nil {
+		return purged, fmt.Errorf("list incomes to purge: %w", err)
+	}
+	for _, id := range incomeIDs {
+		if err := r.queries.HardDeleteIncome(ctx, id); err != nil {
+			return purged, fmt.Errorf("delete income %d: %w", id, err)
+		}
+		purged++
+	}
+
+	if purged > 0 {
+		slog.InfoContext(ctx, "Trash purged", "expenses", len(expenseIDs), "incomes", len(incomeIDs))
+	}
+	return purged, nil
+}
+
+// purgeExpense deletes a trashed expense and queues its removal from the
+// sheet atomically
+func (r *SQLiteRepository) purgeExpense(ctx context.Context, id int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	txQueries := r.withTx(tx)
+
+	expense, err := txQueries.GetTrashedExpense(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil // Restored meanwhile
+	}
+	if err != nil {
+		return fmt.Errorf("get trashed expense: %w", err)
+	}
+	rows, err := sheetRows(ctx, txQueries, expense)
+	if err != nil {
+		return err
+	}
+	if err := txQueries.HardDeleteExpense(ctx, id); err != nil {
+		return fmt.Errorf("delete expense %d: %w", id, err)
+	}
+	if expense.Status != string(core.StatusPending) {
+		if err := enqueueSheetDelete(ctx, txQueries, id, rows); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
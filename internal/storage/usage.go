@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"spese/internal/core"
+)
+
+// UtilityBill is a utility expense with the consumption it billed.
+type UtilityBill struct {
+	ExpenseID int64
+	Expense   core.Expense
+	Usage     core.UtilityUsage
+}
+
+// SetExpenseUsage records or replaces the consumption billed by an expense.
+func (r *SQLiteRepository) SetExpenseUsage(ctx context.Context, expenseID int64, u core.UtilityUsage) error {
+	if err := u.Validate(); err != nil {
+		return err
+	}
+	if _, err := r.queries.GetExpense(ctx, expenseID); errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("expense not found: %d", expenseID)
+	} else if err != nil {
+		return fmt.Errorf("get expense: %w", err)
+	}
+
+	if err := r.queries.UpsertUtilityUsage(ctx, UpsertUtilityUsageParams{
+		ExpenseID: expenseID,
+		Kind:      string(u.Kind),
+		Quantity:  u.Quantity,
+	}); err != nil {
+		return fmt.Errorf("set utility usage: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpenseUsage removes the consumption of an expense.
+func (r *SQLiteRepository) DeleteExpenseUsage(ctx context.Context, expenseID int64) error {
+	n, err := r.queries.DeleteUtilityUsage(ctx, expenseID)
+	if err != nil {
+		return fmt.Errorf("delete utility usage: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("utility usage not found: %d", expenseID)
+	}
+	return nil
+}
+
+// GetExpenseUsage returns the consumption billed by an expense, or nil when
+// none was recorded.
+func (r *SQLiteRepository) GetExpenseUsage(ctx context.Context, expenseID int64) (*core.UtilityUsage, error) {
+	row, err := r.reader(ctx).GetUtilityUsage(ctx, expenseID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get utility usage: %w", err)
+	}
+	return &core.UtilityUsage{Kind: core.UtilityKind(row.Kind), Quantity: row.Quantity}, nil
+}
+
+// ListUtilityBills returns the bills with a recorded consumption, by
+// utility and oldest first.
+func (r *SQLiteRepository) ListUtilityBills(ctx context.Context) ([]UtilityBill, error) {
+	rows, err := r.reader(ctx).ListUtilityUsage(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list utility usage: %w", err)
+	}
+
+	bills := make([]UtilityBill, len(rows))
+	for i, row := range rows {
+		bills[i] = UtilityBill{
+			ExpenseID: row.ExpenseID,
+			Expense: core.Expense{
+				Date:        core.Date{Time: row.Date},
+				Description: row.Description,
+				Amount:      core.Money{Cents: row.AmountCents},
+				Primary:     row.PrimaryCategory,
+				Secondary:   row.SecondaryCategory,
+			},
+			Usage: core.UtilityUsage{Kind: core.UtilityKind(row.Kind), Quantity: row.Quantity},
+		}
+	}
+	return bills, nil
+}
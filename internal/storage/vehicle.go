@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"spese/internal/core"
+)
+
+// VehicleExpense is an expense of the vehicle cost center, with its fuel
+// fill when it is a refuelling.
+type VehicleExpense struct {
+	ExpenseID int64
+	Expense   core.Expense
+	Fill      *core.FuelFill
+}
+
+// SetFuelFill records or replaces the liters and odometer reading of a fuel
+// expense.
+func (r *SQLiteRepository) SetFuelFill(ctx context.Context, expenseID int64, f core.FuelFill) error {
+	if err := f.Validate(); err != nil {
+		return err
+	}
+	if _, err := r.queries.GetExpense(ctx, expenseID); errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("expense not found: %d", expenseID)
+	} else if err != nil {
+		return fmt.Errorf("get expense: %w", err)
+	}
+
+	if err := r.queries.UpsertFuelFill(ctx, UpsertFuelFillParams{
+		ExpenseID:  expenseID,
+		Liters:     f.Liters,
+		OdometerKm: f.Odometer,
+	}); err != nil {
+		return fmt.Errorf("set fuel fill: %w", err)
+	}
+	return nil
+}
+
+// DeleteFuelFill removes the fuel fill of an expense.
+func (r *SQLiteRepository) DeleteFuelFill(ctx context.Context, expenseID int64) error {
+	n, err := r.queries.DeleteFuelFill(ctx, expenseID)
+	if err != nil {
+		return fmt.Errorf("delete fuel fill: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("fuel fill not found: %d", expenseID)
+	}
+	return nil
+}
+
+// GetFuelFill returns the fuel fill of an expense, or nil when none was
+// recorded.
+func (r *SQLiteRepository) GetFuelFill(ctx context.Context, expenseID int64) (*core.FuelFill, error) {
+	row, err := r.reader(ctx).GetFuelFill(ctx, expenseID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get fuel fill: %w", err)
+	}
+	return &core.FuelFill{Liters: row.Liters, Odometer: row.OdometerKm}, nil
+}
+
+// ListVehicleExpenses returns, oldest first, the expenses within a date
+// range that are in the vehicle's secondary category or carry a fuel fill.
+func (r *SQLiteRepository) ListVehicleExpenses(ctx context.Context, category string, startDate, endDate time.Time) ([]VehicleExpense, error) {
+	rows, err := r.reader(ctx).ListVehicleExpenses(ctx, ListVehicleExpensesParams{
+		SecondaryCategory: category,
+		Date:              startDate,
+		Date_2:            endDate,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list vehicle expenses: %w", err)
+	}
+
+	expenses := make([]VehicleExpense, len(rows))
+	for i, row := range rows {
+		expenses[i] = VehicleExpense{
+			ExpenseID: row.ID,
+			Expense: core.Expense{
+				Date:        core.Date{Time: row.Date},
+				Description: row.Description,
+				Amount:      core.Money{Cents: row.AmountCents},
+			},
+		}
+		if row.Liters.Valid && row.OdometerKm.Valid {
+			expenses[i].Fill = &core.FuelFill{Liters: row.Liters.Float64, Odometer: row.OdometerKm.Int64}
+		}
+	}
+	return expenses, nil
+}
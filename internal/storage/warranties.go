@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"spese/internal/core"
+)
+
+// WarrantyItem is a purchase with its warranty terms.
+type WarrantyItem struct {
+	ExpenseID int64
+	Expense   core.Expense
+	Warranty  core.Warranty
+}
+
+// SetExpenseWarranty records or replaces the warranty of a purchase.
+// Changing the return deadline re-arms its reminder.
+func (r *SQLiteRepository) SetExpenseWarranty(ctx context.Context, expenseID int64, w core.Warranty) error {
+	expense, err := r.queries.GetExpense(ctx, expenseID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("expense not found: %d", expenseID)
+	}
+	if err != nil {
+		return fmt.Errorf("get expense: %w", err)
+	}
+	if err := w.Validate(core.Date{Time: expense.Date}); err != nil {
+		return err
+	}
+
+	var deadline sql.NullTime
+	if !w.ReturnDeadline.IsZero() {
+		deadline = sql.NullTime{Time: w.ReturnDeadline.Time, Valid: true}
+	}
+	if err := r.queries.UpsertPurchaseWarranty(ctx, UpsertPurchaseWarrantyParams{
+		ExpenseID:      expenseID,
+		WarrantyMonths: int64(w.Months),
+		ReturnDeadline: deadline,
+		Proof:          w.Proof,
+	}); err != nil {
+		return fmt.Errorf("set warranty: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpenseWarranty removes the warranty of a purchase.
+func (r *SQLiteRepository) DeleteExpenseWarranty(ctx context.Context, expenseID int64) error {
+	n, err := r.queries.DeletePurchaseWarranty(ctx, expenseID)
+	if err != nil {
+		return fmt.Errorf("delete warranty: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("warranty not found: %d", expenseID)
+	}
+	return nil
+}
+
+// ListActiveWarranties returns the purchases still under warranty or still
+// returnable on day now, newest purchase first.
+func (r *SQLiteRepository) ListActiveWarranties(ctx context.Context, now time.Time) ([]WarrantyItem, error) {
+	rows, err := r.reader(ctx).ListPurchaseWarranties(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list warranties: %w", err)
+	}
+
+	var items []WarrantyItem
+	for _, row := range rows {
+		item := warrantyItemFromRow(ListReturnDeadlinesToNotifyRow(row))
+		if item.Warranty.Active(item.Expense.Date, now) {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+// ListReturnsToRemind returns the purchases whose return deadline falls
+// between from and to and whose reminder was not sent yet.
+func (r *SQLiteRepository) ListReturnsToRemind(ctx context.Context, from, to time.Time) ([]WarrantyItem, error) {
+	rows, err := r.queries.ListReturnDeadlinesToNotify(ctx, ListReturnDeadlinesToNotifyParams{
+		ReturnDeadline:   sql.NullTime{Time: from, Valid: true},
+		ReturnDeadline_2: sql.NullTime{Time: to, Valid: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list return deadlines: %w", err)
+	}
+
+	items := make([]WarrantyItem, len(rows))
+	for i, row := range rows {
+		items[i] = warrantyItemFromRow(row)
+	}
+	return items, nil
+}
+
+// MarkReturnReminded records that the return reminder of a purchase went out.
+func (r *SQLiteRepository) MarkReturnReminded(ctx context.Context, expenseID int64) error {
+	if err := r.queries.MarkReturnReminderSent(ctx, expenseID); err != nil {
+		return fmt.Errorf("mark return reminder sent: %w", err)
+	}
+	return nil
+}
+
+func warrantyItemFromRow(row ListReturnDeadlinesToNotifyRow) WarrantyItem {
+	item := WarrantyItem{
+		ExpenseID: row.ExpenseID,
+		Expense: core.Expense{
+			Date:        core.Date{Time: row.Date},
+			Description: row.Description,
+			Amount:      core.Money{Cents: row.AmountCents},
+			Primary:     row.PrimaryCategory,
+			Secondary:   row.SecondaryCategory,
+		},
+		Warranty: core.Warranty{
+			Months: int(row.WarrantyMonths),
+			Proof:  row.Proof,
+		},
+	}
+	if row.ReturnDeadline.Valid {
+		item.Warranty.ReturnDeadline = core.Date{Time: row.ReturnDeadline.Time}
+	}
+	return item
+}
@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"spese/internal/core"
+)
+
+// WorkflowItem is an expense listed in a workflow state.
+type WorkflowItem struct {
+	ID        string
+	Expense   core.Expense
+	UpdatedBy string // Who moved it to the state, empty for drafts never submitted
+}
+
+// getWorkflowState returns the workflow state of an expense, draft when it
+// was never moved through the workflow.
+func getWorkflowState(ctx context.Context, q *Queries, expenseID int64) (core.WorkflowState, error) {
+	state, err := q.GetExpenseWorkflowState(ctx, expenseID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return core.WorkflowDraft, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("get workflow state: %w", err)
+	}
+	return core.WorkflowState(state), nil
+}
+
+// GetExpenseWorkflowState returns the workflow state of an expense.
+func (r *SQLiteRepository) GetExpenseWorkflowState(ctx context.Context, expenseID int64) (core.WorkflowState, error) {
+	return getWorkflowState(ctx, r.reader(ctx), expenseID)
+}
+
+// TransitionExpenseWorkflow moves an expense to the given state on behalf of
+// role, returning the state it left. Returns core.ErrInvalidTransition or
+// core.ErrTransitionForbidden when the move is not allowed.
+func (r *SQLiteRepository) TransitionExpenseWorkflow(ctx context.Context, expenseID int64, to core.WorkflowState, role core.WorkflowRole) (core.WorkflowState, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	txQueries := r.withTx(tx)
+
+	if _, err := txQueries.GetExpense(ctx, expenseID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", fmt.Errorf("expense not found: %d", expenseID)
+		}
+		return "", fmt.Errorf("get expense: %w", err)
+	}
+	from, err := getWorkflowState(ctx, txQueries, expenseID)
+	if err != nil {
+		return "", err
+	}
+	if err := core.CheckWorkflowTransition(from, to, role); err != nil {
+		return from, err
+	}
+	if err := txQueries.UpsertExpenseWorkflowState(ctx, UpsertExpenseWorkflowStateParams{
+		ExpenseID: expenseID,
+		State:     string(to),
+		UpdatedBy: core.ActorFromContext(ctx),
+	}); err != nil {
+		return "", fmt.Errorf("update workflow state: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("commit transaction: %w", err)
+	}
+
+	slog.InfoContext(ctx, "Expense workflow transition", "id", expenseID, "from", from, "to", to, "role", role)
+	return from, nil
+}
+
+// ListExpensesByWorkflowState returns up to limit expenses in the given
+// state, newest first.
+func (r *SQLiteRepository) ListExpensesByWorkflowState(ctx context.Context, state core.WorkflowState, limit int) ([]WorkflowItem, error) {
+	rows, err := r.reader(ctx).ListExpensesByWorkflowState(ctx, ListExpensesByWorkflowStateParams{
+		State: string(state),
+		Limit: int64(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list expenses by workflow state: %w", err)
+	}
+
+	items := make([]WorkflowItem, len(rows))
+	for i, row := range rows {
+		items[i] = WorkflowItem{
+			ID: strconv.FormatInt(row.ID, 10),
+			Expense: core.Expense{
+				Date:        core.Date{Time: row.Date},
+				Description: row.Description,
+				Amount:      core.Money{Cents: row.AmountCents},
+				Primary:     row.PrimaryCategory,
+				Secondary:   row.SecondaryCategory,
+			},
+			UpdatedBy: row.UpdatedBy,
+		}
+	}
+	return items, nil
+}
+
+// CountExpensesByWorkflowState returns how many expenses are in each state.
+func (r *SQLiteRepository) CountExpensesByWorkflowState(ctx context.Context) (map[core.WorkflowState]int64, error) {
+	rows, err := r.reader(ctx).CountExpensesByWorkflowState(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("count expenses by workflow state: %w", err)
+	}
+	counts := make(map[core.WorkflowState]int64, len(core.WorkflowStates))
+	for _, row := range rows {
+		counts[core.WorkflowState(row.State)] = row.Count
+	}
+	return counts, nil
+}
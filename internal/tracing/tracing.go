@@ -0,0 +1,71 @@
+// Package tracing sets up OpenTelemetry tracing. Spans are started across
+// the app (HTTP handlers, SQLite queries, the sync queue, outbound calls
+// such as the Sheets API) and exported by the provider installed here; with
+// no exporter they cost next to nothing and only the trace context travels.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+)
+
+// Exporters accepted by Setup
+const (
+	ExporterNone    = "none"
+	ExporterOTLP    = "otlp"    // OTLP over HTTP, to OTEL_EXPORTER_OTLP_ENDPOINT
+	ExporterConsole = "console" // Spans printed to stdout, for debugging
+)
+
+// Exporters lists the accepted exporter names.
+var Exporters = []string{ExporterNone, ExporterOTLP, ExporterConsole}
+
+// Setup installs the W3C trace context and baggage propagators and, unless
+// exporter is "none", a tracer provider sending spans to exporter. The
+// standard OTEL_* variables configure the rest: OTEL_SERVICE_NAME,
+// OTEL_EXPORTER_OTLP_ENDPOINT, OTEL_TRACES_SAMPLER and so on. The
+// returned function flushes and stops the provider.
+func Setup(ctx context.Context, exporter string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{}))
+
+	var (
+		spanExporter sdktrace.SpanExporter
+		err          error
+	)
+	switch exporter {
+	case ExporterNone, "":
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		spanExporter, err = otlptracehttp.New(ctx)
+	case ExporterConsole:
+		spanExporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("create %s trace exporter: %w", exporter, err)
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName("spese")),
+		resource.WithFromEnv(), // OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES win
+		resource.WithTelemetrySDK())
+	if err != nil {
+		return nil, fmt.Errorf("trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithResource(res))
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
@@ -0,0 +1,39 @@
+// Amount hints shared by the expense, income and recurrent forms. The
+// server reads the typed amount exactly as it will on submit, so the hint
+// shows what gets stored: "1.234,56", "12,5" and "12.50" all work.
+const amountHints = {
+  timer: null,
+
+  // Keep digits and separators; both the dot and the comma stay, since
+  // which one is the decimal separator depends on the whole amount
+  clean(value) {
+    return value.replace(/[^\d,\.]/g, '');
+  },
+
+  // Validate value after a pause in typing and pass the answer, or null
+  // for an empty field, to done
+  check(value, done) {
+    clearTimeout(this.timer);
+    if (!value) {
+      done(null);
+      return;
+    }
+    this.timer = setTimeout(async () => {
+      try {
+        const resp = await fetch('/api/validate/amount', {
+          method: 'POST',
+          body: new URLSearchParams({ amount: value })
+        });
+        if (resp.ok) done(await resp.json());
+      } catch (e) {
+        console.error('Failed to validate the amount:', e);
+      }
+    }, 250);
+  },
+
+  // Text of the hint under the amount field
+  text(hint) {
+    if (!hint) return '';
+    return hint.valid ? 'Verrà registrato ' + hint.formatted : hint.error;
+  }
+};
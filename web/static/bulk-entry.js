@@ -0,0 +1,196 @@
+// Keyboard-only entry of a stack of receipts. Rows are checked one at a
+// time by a dry run of the batch endpoint and saved together, in one
+// transaction, by the batch endpoint itself.
+const bulkEntry = {
+  form: null,
+  rows: null,
+  flash: null,
+  fields: ['date', 'description', 'amount', 'primary', 'secondary'],
+
+  init() {
+    this.form = document.getElementById('bulk-entry');
+    if (!this.form) return;
+    this.rows = document.getElementById('bulk-rows');
+    this.flash = document.getElementById('bulk-flash');
+
+    this.form.addEventListener('keydown', (e) => this.onKey(e));
+    this.form.addEventListener('focusout', (e) => this.onLeave(e));
+    this.form.addEventListener('submit', (e) => {
+      e.preventDefault();
+      this.save();
+    });
+    this.form.addEventListener('click', (e) => {
+      const action = e.target.closest('[data-bulk]');
+      if (!action) return;
+      if (action.dataset.bulk === 'add') this.addRow().querySelector('[name="description"]').focus();
+      if (action.dataset.bulk === 'remove') this.removeRow(action.closest('tr'));
+    });
+
+    this.addRow().querySelector('[name="date"]').focus();
+  },
+
+  // All the entry rows, in order
+  all() {
+    return Array.from(this.rows.querySelectorAll('tr.bulk-row'));
+  },
+
+  // Append a row dated like the last one, since receipts come in piles
+  // from the same days
+  addRow() {
+    const last = this.all().pop();
+    const limit = Number(this.form.dataset.maxRows);
+    if (this.all().length >= limit) {
+      this.show('error', 'Massimo ' + limit + ' righe per volta');
+      return last;
+    }
+    this.rows.appendChild(document.getElementById('bulk-row').content.cloneNode(true));
+    const row = this.all().pop();
+    row.querySelector('[name="date"]').value = last
+      ? last.querySelector('[name="date"]').value
+      : this.form.dataset.today;
+    this.count();
+    return row;
+  },
+
+  // Remove a row and its error line, keeping at least one row
+  removeRow(row) {
+    if (this.all().length === 1) {
+      this.fields.forEach((f) => { if (f !== 'date') row.querySelector('[name="' + f + '"]').value = ''; });
+      this.setError(row, '');
+      return;
+    }
+    row.nextElementSibling.remove();
+    row.remove();
+    this.count();
+  },
+
+  // Only the date is filled in: the row is left out when saving
+  blank(row) {
+    return this.fields.every((f) => f === 'date' || !row.querySelector('[name="' + f + '"]').value.trim());
+  },
+
+  data(row) {
+    const item = {};
+    this.fields.forEach((f) => { item[f] = row.querySelector('[name="' + f + '"]').value.trim(); });
+    return item;
+  },
+
+  setError(row, message) {
+    const line = row.nextElementSibling;
+    line.hidden = !message;
+    line.querySelector('td').textContent = message;
+    row.classList.toggle('bulk-row--invalid', Boolean(message));
+  },
+
+  count() {
+    const n = this.all().filter((row) => !this.blank(row)).length;
+    document.getElementById('bulk-count').textContent = n === 1 ? '1 riga' : n + ' righe';
+  },
+
+  show(kind, message) {
+    this.flash.innerHTML = '';
+    const div = document.createElement('div');
+    div.className = kind;
+    div.textContent = message;
+    this.flash.appendChild(div);
+  },
+
+  onKey(e) {
+    const input = e.target.closest('input');
+    const row = e.target.closest('tr.bulk-row');
+    if (!input || !row) return;
+
+    if (e.key === 'Enter' && (e.ctrlKey || e.metaKey)) {
+      e.preventDefault();
+      this.save();
+    } else if (e.key === 'Enter') {
+      // Next row, adding one after the last; the row is checked on leave
+      e.preventDefault();
+      const rows = this.all();
+      const next = rows[rows.indexOf(row) + 1] || this.addRow();
+      next.querySelector(next === row ? '[name="' + input.name + '"]' : '[name="description"]').focus();
+    } else if (e.key === 'Escape' && this.blank(row) && this.all().length > 1) {
+      e.preventDefault();
+      const rows = this.all();
+      const focus = rows[rows.indexOf(row) - 1] || rows[1];
+      this.removeRow(row);
+      focus.querySelector('[name="' + input.name + '"]').focus();
+    } else if (e.key.toLowerCase() === 'd' && (e.ctrlKey || e.metaKey)) {
+      // Fill down, like a spreadsheet
+      e.preventDefault();
+      const above = this.all()[this.all().indexOf(row) - 1];
+      if (above) input.value = above.querySelector('[name="' + input.name + '"]').value;
+    }
+  },
+
+  // Check a row on the server when the focus leaves it
+  onLeave(e) {
+    const row = e.target.closest('tr.bulk-row');
+    if (!row || (e.relatedTarget && row.contains(e.relatedTarget))) return;
+    this.count();
+    if (this.blank(row)) {
+      this.setError(row, '');
+      return;
+    }
+    this.check(row);
+  },
+
+  async check(row) {
+    const item = this.data(row);
+    try {
+      const resp = await fetch('/api/v1/expenses:batch?dry_run=true', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ expenses: [item] })
+      });
+      const body = await resp.json();
+      // The row changed while the answer was on its way
+      if (JSON.stringify(item) !== JSON.stringify(this.data(row))) return;
+      this.setError(row, resp.ok ? '' : (body.results ? body.results[0].error : body.error) || 'Riga non valida');
+    } catch (err) {
+      console.error('Failed to check the row:', err);
+    }
+  },
+
+  // Save every filled row in one batch; nothing is saved if a row fails
+  async save() {
+    const rows = this.all().filter((row) => !this.blank(row));
+    if (rows.length === 0) {
+      this.show('error', 'Nessuna riga da registrare');
+      return;
+    }
+    let resp, body;
+    try {
+      resp = await fetch('/api/v1/expenses:batch', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ expenses: rows.map((row) => this.data(row)) })
+      });
+      body = await resp.json();
+    } catch (err) {
+      this.show('error', 'Errore di rete: nessuna spesa registrata');
+      return;
+    }
+
+    if (resp.status === 201) {
+      const date = rows[rows.length - 1].querySelector('[name="date"]').value;
+      this.rows.innerHTML = '';
+      this.addRow().querySelector('[name="date"]').value = date;
+      this.count();
+      this.show('success', body.created === 1 ? '1 spesa registrata' : body.created + ' spese registrate');
+      this.all()[0].querySelector('[name="description"]').focus();
+      return;
+    }
+
+    let first = null;
+    (body.results || []).forEach((result) => {
+      const row = rows[result.index];
+      this.setError(row, result.error || '');
+      if (result.error && !first) first = row;
+    });
+    this.show('error', body.error || 'Nessuna spesa registrata: correggi le righe segnate');
+    if (first) first.querySelector('[name="description"]').focus();
+  }
+};
+
+document.addEventListener('DOMContentLoaded', () => bulkEntry.init());
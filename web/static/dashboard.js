@@ -93,7 +93,8 @@ function initBottomSheet() {
   // Listen for successful form submissions
   document.body.addEventListener('htmx:afterRequest', (event) => {
     const target = event.detail.target;
-    if (target && target.closest('.bottom-sheet__content')) {
+    // Forms swapped into the sheet by the server may be detached by now
+    if (target && target.closest('.bottom-sheet__content, [data-bottom-sheet-form]')) {
       const xhr = event.detail.xhr;
       if (xhr && xhr.status >= 200 && xhr.status < 300) {
         const trigger = xhr.getResponseHeader('HX-Trigger');
@@ -0,0 +1,11 @@
+// Demo mode: mutations answer 403, which htmx does not swap. Flash the
+// banner instead so the user knows why nothing happened.
+document.addEventListener('htmx:responseError', function (evt) {
+  var xhr = evt.detail.xhr;
+  if (!xhr || xhr.status !== 403 || !xhr.getResponseHeader('X-Demo-Mode')) return;
+  var banner = document.getElementById('demo-banner');
+  if (!banner) return;
+  banner.classList.remove('demo-banner--alert');
+  void banner.offsetWidth; // restart the animation
+  banner.classList.add('demo-banner--alert');
+});
@@ -1,16 +1,26 @@
 function expenseForm() {
   return {
+    amountHint: null,
     categories: [],
     selectedPrimary: '',
     selectedSecondary: '',
     selectedDate: '',
+    closedMonths: [],
     loading: true,
+    categoryTouched: false,
 
     get currentSecondaries() {
       const cat = this.categories.find(c => c.primary === this.selectedPrimary);
       return cat ? cat.secondaries : [];
     },
 
+    // Display name of a secondary category in the UI language; the key is
+    // what the form submits
+    secondaryLabel(sub) {
+      const cat = this.categories.find(c => c.primary === this.selectedPrimary);
+      return (cat && cat.secondary_labels && cat.secondary_labels[sub]) || sub;
+    },
+
     get selectedDay() {
       if (!this.selectedDate) return '';
       return new Date(this.selectedDate).getDate();
@@ -21,14 +31,32 @@ function expenseForm() {
       return new Date(this.selectedDate).getMonth() + 1;
     },
 
+    // Months closed by their review refuse new expenses
+    get monthClosed() {
+      return this.closedMonths.includes(this.selectedDate.slice(0, 7));
+    },
+
+    get selectedYear() {
+      return this.selectedDate.slice(0, 4);
+    },
+
+    // Move the date to the same day of another year, for entries made
+    // across New Year
+    setYear(year) {
+      if (this.selectedDate) {
+        this.selectedDate = year + this.selectedDate.slice(4);
+      }
+    },
+
     get isValid() {
       return this.selectedPrimary && this.selectedSecondary;
     },
 
     async init() {
-      // Set today's date
-      const today = new Date();
-      this.selectedDate = today.toISOString().split('T')[0];
+      // Start from the date the server picks: today, or yesterday in the
+      // small hours when DAY_START_HOUR is set
+      this.selectedDate = this.$el.dataset.defaultDate || new Date().toISOString().split('T')[0];
+      this.closedMonths = (this.$el.dataset.closedMonths || '').split(',').filter(Boolean);
 
       // Load categories
       try {
@@ -54,6 +82,7 @@ function expenseForm() {
     },
 
     selectPrimary(primary) {
+      this.categoryTouched = true;
       this.selectedPrimary = primary;
       this.selectedSecondary = '';
       // Auto-select if only one secondary
@@ -63,16 +92,40 @@ function expenseForm() {
     },
 
     selectSecondary(secondary) {
+      this.categoryTouched = true;
       this.selectedSecondary = secondary;
     },
 
+    // Preselect the category the rules or the keyword dictionary pick for
+    // the description, unless the user already chose one
+    async suggestCategory(description) {
+      if (this.categoryTouched || !description.trim()) return;
+      try {
+        const resp = await fetch('/api/categories/suggest?description=' + encodeURIComponent(description));
+        if (resp.status !== 200) return;
+        const suggestion = await resp.json();
+        const cat = this.categories.find(c => c.primary === suggestion.primary);
+        if (this.categoryTouched || !cat || !cat.secondaries.includes(suggestion.secondary)) return;
+        this.selectedPrimary = suggestion.primary;
+        this.selectedSecondary = suggestion.secondary;
+      } catch (e) {
+        console.error('Failed to suggest a category:', e);
+      }
+    },
+
+    // Keep the amount as typed and hint what will be stored
     formatAmount(event) {
-      let value = event.target.value;
-      // Allow only numbers and comma/dot
-      value = value.replace(/[^\d,\.]/g, '');
-      // Replace comma with dot for backend
-      value = value.replace(',', '.');
-      event.target.value = value;
+      event.target.value = amountHints.clean(event.target.value);
+      amountHints.check(event.target.value, (hint) => { this.amountHint = hint; });
     }
   }
 }
+
+// A possible duplicate answers 409 with a "save anyway" button: show it in
+// the flash area instead of discarding it as an error response
+document.addEventListener('htmx:beforeSwap', (event) => {
+  if (event.detail.xhr.status === 409 && event.detail.elt.closest('#expense-form')) {
+    event.detail.shouldSwap = true;
+    event.detail.isError = false;
+  }
+});
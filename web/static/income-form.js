@@ -1,6 +1,8 @@
 function incomeForm() {
   return {
+    amountHint: null,
     categories: [],
+    subcategories: [],
     selectedCategory: '',
     selectedDate: '',
     loading: true,
@@ -10,6 +12,10 @@ function incomeForm() {
       return new Date(this.selectedDate).getDate();
     },
 
+    get selectedYear() {
+      return this.selectedDate.slice(0, 4);
+    },
+
     get selectedMonth() {
       if (!this.selectedDate) return '';
       return new Date(this.selectedDate).getMonth() + 1;
@@ -40,19 +46,29 @@ function incomeForm() {
 
       // Pre-select first category
       if (this.categories.length > 0) {
-        this.selectedCategory = this.categories[0];
+        this.selectCategory(this.categories[0]);
       }
     },
 
     selectCategory(category) {
       this.selectedCategory = category;
+      this.loadSubcategories(category);
+    },
+
+    async loadSubcategories(category) {
+      try {
+        const resp = await fetch('/api/income-subcategories?category=' + encodeURIComponent(category));
+        this.subcategories = await resp.json();
+      } catch (e) {
+        console.error('Failed to load income subcategories:', e);
+        this.subcategories = [];
+      }
     },
 
+    // Keep the amount as typed and hint what will be stored
     formatAmount(event) {
-      let value = event.target.value;
-      value = value.replace(/[^\d,\.]/g, '');
-      value = value.replace(',', '.');
-      event.target.value = value;
+      event.target.value = amountHints.clean(event.target.value);
+      amountHints.check(event.target.value, (hint) => { this.amountHint = hint; });
     }
   }
 }
@@ -1,5 +1,6 @@
 function recurrentForm() {
   return {
+    amountHint: null,
     categories: [],
     selectedPrimary: '',
     selectedSecondary: '',
@@ -7,17 +8,28 @@ function recurrentForm() {
     loading: true,
 
     frequencies: [
-      { value: 'daily', label: 'Giornaliera' },
-      { value: 'weekly', label: 'Settimanale' },
-      { value: 'monthly', label: 'Mensile' },
-      { value: 'yearly', label: 'Annuale' }
+      { value: 'daily', label: 'Giornaliera', unit: 'giorni' },
+      { value: 'weekly', label: 'Settimanale', unit: 'settimane' },
+      { value: 'monthly', label: 'Mensile', unit: 'mesi' },
+      { value: 'yearly', label: 'Annuale', unit: 'anni' }
     ],
 
+    // Unit the "every N" field counts in
+    frequencyUnit() {
+      const freq = this.frequencies.find(f => f.value === this.selectedFrequency);
+      return freq ? freq.unit : '';
+    },
+
     get currentSecondaries() {
       const cat = this.categories.find(c => c.primary === this.selectedPrimary);
       return cat ? cat.secondaries : [];
     },
 
+    secondaryLabel(sub) {
+      const cat = this.categories.find(c => c.primary === this.selectedPrimary);
+      return (cat && cat.secondary_labels && cat.secondary_labels[sub]) || sub;
+    },
+
     get isValid() {
       return this.selectedPrimary && this.selectedSecondary && this.selectedFrequency;
     },
@@ -62,17 +74,17 @@ function recurrentForm() {
       this.selectedFrequency = freq;
     },
 
+    // Keep the amount as typed and hint what will be stored
     formatAmount(event) {
-      let value = event.target.value;
-      value = value.replace(/[^\d,\.]/g, '');
-      value = value.replace(',', '.');
-      event.target.value = value;
+      event.target.value = amountHints.clean(event.target.value);
+      amountHints.check(event.target.value, (hint) => { this.amountHint = hint; });
     }
   }
 }
 
 function recurrentEditForm(initialData) {
   return {
+    amountHint: null,
     categories: [],
     selectedPrimary: initialData?.primary || '',
     selectedSecondary: initialData?.secondary || '',
@@ -80,17 +92,28 @@ function recurrentEditForm(initialData) {
     loading: true,
 
     frequencies: [
-      { value: 'daily', label: 'Giornaliera' },
-      { value: 'weekly', label: 'Settimanale' },
-      { value: 'monthly', label: 'Mensile' },
-      { value: 'yearly', label: 'Annuale' }
+      { value: 'daily', label: 'Giornaliera', unit: 'giorni' },
+      { value: 'weekly', label: 'Settimanale', unit: 'settimane' },
+      { value: 'monthly', label: 'Mensile', unit: 'mesi' },
+      { value: 'yearly', label: 'Annuale', unit: 'anni' }
     ],
 
+    // Unit the "every N" field counts in
+    frequencyUnit() {
+      const freq = this.frequencies.find(f => f.value === this.selectedFrequency);
+      return freq ? freq.unit : '';
+    },
+
     get currentSecondaries() {
       const cat = this.categories.find(c => c.primary === this.selectedPrimary);
       return cat ? cat.secondaries : [];
     },
 
+    secondaryLabel(sub) {
+      const cat = this.categories.find(c => c.primary === this.selectedPrimary);
+      return (cat && cat.secondary_labels && cat.secondary_labels[sub]) || sub;
+    },
+
     get isValid() {
       return this.selectedPrimary && this.selectedSecondary && this.selectedFrequency;
     },
@@ -123,11 +146,19 @@ function recurrentEditForm(initialData) {
       this.selectedFrequency = freq;
     },
 
+    // Keep the amount as typed and hint what will be stored
     formatAmount(event) {
-      let value = event.target.value;
-      value = value.replace(/[^\d,\.]/g, '');
-      value = value.replace(',', '.');
-      event.target.value = value;
+      event.target.value = amountHints.clean(event.target.value);
+      amountHints.check(event.target.value, (hint) => { this.amountHint = hint; });
     }
   }
 }
+
+// Version conflicts answer 409 with a merge form: let HTMX swap it in
+// instead of discarding it as an error response
+document.addEventListener('htmx:beforeSwap', (event) => {
+  if (event.detail.xhr.status === 409) {
+    event.detail.shouldSwap = true;
+    event.detail.isError = false;
+  }
+});
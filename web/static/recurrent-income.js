@@ -0,0 +1,40 @@
+// Recurrent incomes on the income page
+
+// Validation errors (422), version conflicts (409) and a missing version
+// (428) carry the reason: show them next to the form instead of discarding
+// the response
+document.addEventListener('htmx:beforeSwap', (event) => {
+  const status = event.detail.xhr.status;
+  if ((status === 422 || status === 409 || status === 428) &&
+      event.detail.elt.closest('#recurrent-income-form, .recurrent-income-edit')) {
+    event.detail.shouldSwap = true;
+    event.detail.isError = false;
+  }
+});
+
+// Clear the form once a recurrent income has been saved
+document.addEventListener('recurrent-income:created', () => {
+  const form = document.getElementById('recurrent-income-form');
+  if (form) {
+    form.reset();
+    syncDayOfMonth(form);
+  }
+});
+
+// The day of the month only applies to monthly and yearly schedules
+function syncDayOfMonth(form) {
+  const every = form.querySelector('[name="repetition_type"]');
+  const day = form.querySelector('[name="day_of_month"]');
+  if (!every || !day) return;
+  day.disabled = every.value !== 'monthly' && every.value !== 'yearly';
+}
+
+document.addEventListener('change', (event) => {
+  if (event.target.name === 'repetition_type' && event.target.form) {
+    syncDayOfMonth(event.target.form);
+  }
+});
+
+document.addEventListener('htmx:load', (event) => {
+  event.detail.elt.querySelectorAll('#recurrent-income-form, .recurrent-income-edit').forEach(syncDayOfMonth);
+});
@@ -0,0 +1,14 @@
+// Validation errors (422) carry the reason, e.g. an invalid condition:
+// show them in the flash area instead of discarding the response
+document.addEventListener('htmx:beforeSwap', (event) => {
+  if (event.detail.xhr.status === 422 && event.detail.elt.closest('#rule-form')) {
+    event.detail.shouldSwap = true;
+    event.detail.isError = false;
+  }
+});
+
+// Clear the form once a rule has been saved
+document.addEventListener('rules:created', () => {
+  const form = document.getElementById('rule-form');
+  if (form) form.reset();
+});
@@ -0,0 +1,8 @@
+// Invalid queries (400) carry SQLite's message: show it in the results
+// area instead of discarding the response
+document.addEventListener('htmx:beforeSwap', (event) => {
+  if (event.detail.xhr.status === 400 && event.detail.elt.closest('#sql-form')) {
+    event.detail.shouldSwap = true;
+    event.detail.isError = false;
+  }
+});
@@ -0,0 +1,46 @@
+// Tag suggestions shared by the expense and income forms. Inputs with a
+// data-tags attribute hold comma-separated tags; the datalist it names is
+// filled with the known tags, most used first, each appended to what is
+// already typed so picking one completes the last tag only.
+const tagHints = {
+  tags: null,
+
+  async load() {
+    if (this.tags) return this.tags;
+    try {
+      const resp = await fetch('/api/tags');
+      this.tags = resp.ok ? await resp.json() : [];
+    } catch (e) {
+      console.error('Failed to load tags:', e);
+      this.tags = [];
+    }
+    return this.tags;
+  },
+
+  async suggest(input) {
+    const list = document.getElementById(input.dataset.tags);
+    if (!list) return;
+    const tags = await this.load();
+    const parts = input.value.split(',');
+    const last = parts.pop().trim().toLowerCase();
+    const typed = parts.map((t) => t.trim().toLowerCase()).filter(Boolean);
+    const prefix = typed.length ? typed.join(', ') + ', ' : '';
+    list.replaceChildren(...tags
+      .filter((t) => !typed.includes(t) && t.startsWith(last))
+      .slice(0, 10)
+      .map((t) => {
+        const option = document.createElement('option');
+        option.value = prefix + t;
+        return option;
+      }));
+  }
+};
+
+// Delegated, so inputs swapped in by htmx (the inline edit form) work too
+['focusin', 'input'].forEach((type) => {
+  document.addEventListener(type, (event) => {
+    if (event.target.matches && event.target.matches('input[data-tags]')) {
+      tagHints.suggest(event.target);
+    }
+  });
+});
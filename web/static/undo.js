@@ -0,0 +1,42 @@
+// Undo toast: a deletion answered with an "undo:show" trigger shows a
+// toast whose "Annulla" posts the id to the restore URL. The toast goes
+// away by itself after the given seconds.
+document.addEventListener('undo:show', function (evt) {
+  var detail = evt.detail || {};
+  if (!detail.url || !detail.id) return;
+
+  var container = document.querySelector('.toast-container');
+  if (!container) {
+    container = document.createElement('div');
+    container.className = 'toast-container';
+    container.setAttribute('aria-live', 'polite');
+    document.body.appendChild(container);
+  }
+
+  var toast = document.createElement('div');
+  toast.className = 'toast';
+  toast.setAttribute('role', 'status');
+  var message = document.createElement('span');
+  message.className = 'toast__message';
+  message.textContent = detail.message || 'Eliminato';
+  var undo = document.createElement('button');
+  undo.type = 'button';
+  undo.className = 'toast__action';
+  undo.textContent = 'Annulla';
+  toast.appendChild(message);
+  toast.appendChild(undo);
+  container.appendChild(toast);
+
+  var dismiss = function () {
+    clearTimeout(timer);
+    toast.classList.add('toast--out');
+    setTimeout(function () { toast.remove(); }, 300);
+  };
+  var timer = setTimeout(dismiss, (detail.seconds || 10) * 1000);
+
+  undo.addEventListener('click', function () {
+    undo.disabled = true;
+    // The response's HX-Trigger refreshes the lists and totals
+    htmx.ajax('POST', detail.url, { values: { id: detail.id }, swap: 'none' }).then(dismiss, dismiss);
+  });
+});